@@ -0,0 +1,442 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+
+	"github.com/songzhibin97/vman/internal/config"
+	"github.com/songzhibin97/vman/internal/download"
+	"github.com/songzhibin97/vman/internal/proxy"
+	"github.com/songzhibin97/vman/internal/recipe"
+	"github.com/songzhibin97/vman/pkg/types"
+	"github.com/songzhibin97/vman/pkg/utils"
+)
+
+// toolSourceTypes 支持的下载源类型
+var toolSourceTypes = []string{"github", "direct", "archive"}
+
+// addCmd 交互式生成工具定义
+var addCmd = &cobra.Command{
+	Use:         "add <tool>",
+	Annotations: mutating(),
+	Short:       "交互式创建工具定义",
+	Long: `通过问答生成工具定义文件（~/.vman/tools/<tool>.toml）。
+
+没有通过 --type、--repo 或 --url 给出下载源时，先查找该工具的recipe（见 vman recipes），
+找到时直接使用recipe中的定义并安装其中的钩子，不再询问；使用 --no-recipe 跳过recipe。
+
+依次询问下载源类型（github、direct、archive）、仓库或URL模板、资产文件名模式和二进制文件名，
+保存前会试下载一个版本以验证定义是否可用，试下载的文件不会被安装。
+
+通过参数给出的项不再询问；标准输入不是终端时不会提问，缺少必要参数时直接报错。
+URL模板和资产文件名模式中可以使用 {version}、{os}、{arch} 占位符。
+
+对不完全信任的第三方工具可以使用 --sandbox，通过代理执行时只允许写入项目目录和临时目录。`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		uiOptions := getUIOptions(cmd)
+
+		opts := &addToolOptions{}
+		opts.sourceType, _ = cmd.Flags().GetString("type")
+		opts.repo, _ = cmd.Flags().GetString("repo")
+		opts.url, _ = cmd.Flags().GetString("url")
+		opts.pattern, _ = cmd.Flags().GetString("pattern")
+		opts.binary, _ = cmd.Flags().GetString("binary")
+		opts.description, _ = cmd.Flags().GetString("description")
+		opts.homepage, _ = cmd.Flags().GetString("homepage")
+		opts.shim, _ = cmd.Flags().GetString("shim")
+		opts.version, _ = cmd.Flags().GetString("version")
+		noVerify, _ := cmd.Flags().GetBool("no-verify")
+		noRecipe, _ := cmd.Flags().GetBool("no-recipe")
+		force, _ := cmd.Flags().GetBool("force")
+		sandbox, _ := cmd.Flags().GetBool("sandbox")
+		timeout, _ := cmd.Flags().GetDuration("timeout")
+
+		cmd.SilenceUsage = true
+
+		validator := config.NewValidator()
+		if err := validator.ValidateToolName(name); err != nil {
+			return fmt.Errorf("无效的工具名称: %w", err)
+		}
+
+		homeDir, err := utils.GetHomeDir()
+		if err != nil {
+			return fmt.Errorf("获取用户主目录失败: %w", err)
+		}
+		toolFile := filepath.Join(types.DefaultConfigPaths(homeDir).ToolsDir, name+".toml")
+
+		if _, err := os.Stat(toolFile); err == nil && !force {
+			return fmt.Errorf("工具定义 %s 已存在，使用 --force 覆盖", toolFile)
+		}
+
+		managers, err := createManagers()
+		if err != nil {
+			return fmt.Errorf("创建管理器失败: %w", err)
+		}
+
+		// 没有给出下载源时优先使用recipe
+		var found *recipe.Recipe
+		if !noRecipe && opts.sourceType == "" && opts.repo == "" && opts.url == "" {
+			found, err = recipeRegistry(managers.config).Lookup(name)
+			if err != nil && !errors.Is(err, recipe.ErrNotFound) {
+				return err
+			}
+		}
+
+		prompter := newToolPrompter(os.Stdin, os.Stdout, stdinIsTerminal())
+		var metadata *types.ToolMetadata
+		if found != nil {
+			fmt.Printf("使用 %s 的recipe (%s)\n", name, found.Source)
+			metadata = metadataFromRecipe(found, opts)
+			if !recipeSupportsPlatform(metadata) {
+				PrintWarning(fmt.Sprintf("%s 的recipe只支持 %s", name, strings.Join(metadata.Platforms, ", ")), uiOptions)
+			}
+		} else if metadata, err = scaffoldToolMetadata(name, opts, prompter); err != nil {
+			return err
+		}
+
+		if sandbox {
+			metadata.Sandbox = true
+		}
+
+		if err := validator.ValidateToolMetadata(metadata); err != nil {
+			return fmt.Errorf("工具定义无效: %w", err)
+		}
+
+		// 工具的垫片不能覆盖其他工具、别名或vman子命令
+		if err := checkShimName(proxy.ShimEntry{Name: metadata.ShimName(), Kind: proxy.ShimKindTool, Target: name}); err != nil {
+			return err
+		}
+
+		if noVerify {
+			PrintWarning("已跳过试下载，工具定义未经验证", uiOptions)
+		} else if metadata.DownloadConfig.Type != "github" && opts.version == "" {
+			PrintWarning(fmt.Sprintf("%s 源无法查询最新版本，未指定 --version，已跳过试下载", metadata.DownloadConfig.Type), uiOptions)
+		} else {
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			fmt.Println("正在试下载以验证工具定义...")
+			result, err := download.TrialDownload(ctx, afero.NewOsFs(), metadata, opts.version, managers.storage.GetTempDir())
+			if err != nil {
+				PrintError(fmt.Sprintf("试下载失败: %v", err), uiOptions)
+				save, confirmErr := prompter.confirm("仍然保存工具定义?", false)
+				if confirmErr != nil || !save {
+					return fmt.Errorf("工具定义未保存: %w", err)
+				}
+			} else {
+				fmt.Printf("  版本:     %s\n", result.Version)
+				fmt.Printf("  下载地址: %s\n", result.URL)
+				fmt.Printf("  文件大小: %s\n", formatBytes(result.Size))
+				fmt.Printf("  可执行文件: %s\n", result.Binary)
+			}
+		}
+
+		data, err := encodeToolMetadata(metadata)
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(toolFile), 0755); err != nil {
+			return fmt.Errorf("创建工具定义目录失败: %w", err)
+		}
+		if err := utils.WritePrivateFile(afero.NewOsFs(), toolFile, data); err != nil {
+			return fmt.Errorf("写入工具定义失败: %w", err)
+		}
+
+		if found != nil {
+			hooksDir := types.HookSettings{}.GetDir(managers.config.GetConfigDir())
+			if globalConfig, err := managers.config.LoadGlobal(); err == nil && globalConfig != nil {
+				hooksDir = globalConfig.Settings.Hooks.GetDir(managers.config.GetConfigDir())
+			}
+			written, err := found.InstallHooks(hooksDir)
+			if err != nil {
+				return fmt.Errorf("安装recipe钩子失败: %w", err)
+			}
+			for _, path := range written {
+				fmt.Printf("已安装钩子: %s\n", path)
+			}
+		}
+
+		PrintSuccess(fmt.Sprintf("已创建工具定义: %s", toolFile), uiOptions)
+		fmt.Printf("运行 'vman install %s <version>' 安装\n", name)
+		return nil
+	},
+}
+
+// addToolOptions 工具定义的各项取值，为空的项需要询问
+type addToolOptions struct {
+	sourceType  string
+	repo        string
+	url         string
+	pattern     string
+	binary      string
+	description string
+	homepage    string
+	shim        string
+	version     string
+}
+
+// toolPrompter 逐行读取回答的提问器
+type toolPrompter struct {
+	in          *bufio.Reader
+	out         io.Writer
+	interactive bool
+}
+
+// newToolPrompter 创建提问器，interactive 为 false 时不提问，直接使用默认值
+func newToolPrompter(in io.Reader, out io.Writer, interactive bool) *toolPrompter {
+	return &toolPrompter{in: bufio.NewReader(in), out: out, interactive: interactive}
+}
+
+// ask 提问并返回回答，直接回车时返回默认值
+func (p *toolPrompter) ask(label, defaultValue string) (string, error) {
+	if !p.interactive {
+		return defaultValue, nil
+	}
+
+	if defaultValue != "" {
+		fmt.Fprintf(p.out, "%s [%s]: ", label, defaultValue)
+	} else {
+		fmt.Fprintf(p.out, "%s: ", label)
+	}
+
+	line, err := p.in.ReadString('\n')
+	if err == io.EOF && line == "" {
+		// 输入已结束（如重定向自 /dev/null），之后的问题都使用默认值
+		fmt.Fprintln(p.out)
+		p.interactive = false
+		return defaultValue, nil
+	}
+	if err != nil && err != io.EOF {
+		return "", fmt.Errorf("读取输入失败: %w", err)
+	}
+
+	if answer := strings.TrimSpace(line); answer != "" {
+		return answer, nil
+	}
+	return defaultValue, nil
+}
+
+// require 提问直到得到非空回答，非交互模式下返回缺少参数的错误
+func (p *toolPrompter) require(label, flag string) (string, error) {
+	if !p.interactive {
+		return "", fmt.Errorf("缺少 --%s（标准输入不是终端，无法交互询问）", flag)
+	}
+
+	for {
+		answer, err := p.ask(label, "")
+		if err != nil {
+			return "", err
+		}
+		if answer != "" {
+			return answer, nil
+		}
+		if !p.interactive {
+			return "", fmt.Errorf("缺少 --%s（输入已结束，无法继续询问）", flag)
+		}
+		fmt.Fprintln(p.out, "此项不能为空")
+	}
+}
+
+// choose 从选项中选择一项，可以输入序号或选项名称
+func (p *toolPrompter) choose(label string, options []string, defaultValue string) (string, error) {
+	if p.interactive {
+		for i, option := range options {
+			fmt.Fprintf(p.out, "  %d) %s\n", i+1, option)
+		}
+	}
+
+	for {
+		answer, err := p.ask(label, defaultValue)
+		if err != nil {
+			return "", err
+		}
+		if index, err := strconv.Atoi(answer); err == nil && index >= 1 && index <= len(options) {
+			return options[index-1], nil
+		}
+		if containsString(options, answer) {
+			return answer, nil
+		}
+		if !p.interactive {
+			return "", fmt.Errorf("无效的选项: %s（可选: %s）", answer, strings.Join(options, ", "))
+		}
+		fmt.Fprintf(p.out, "无效的选项: %s\n", answer)
+	}
+}
+
+// confirm 询问是否继续，非交互模式下返回默认值
+func (p *toolPrompter) confirm(label string, defaultYes bool) (bool, error) {
+	defaultValue := "y/N"
+	if defaultYes {
+		defaultValue = "Y/n"
+	}
+
+	answer, err := p.ask(label, defaultValue)
+	if err != nil {
+		return false, err
+	}
+	if answer == defaultValue {
+		return defaultYes, nil
+	}
+
+	answer = strings.ToLower(answer)
+	return answer == "y" || answer == "yes", nil
+}
+
+// scaffoldToolMetadata 根据已给出的选项和问答结果生成工具元数据
+func scaffoldToolMetadata(name string, opts *addToolOptions, p *toolPrompter) (*types.ToolMetadata, error) {
+	var err error
+
+	if opts.sourceType == "" {
+		if opts.sourceType, err = p.choose("下载源类型", toolSourceTypes, "github"); err != nil {
+			return nil, err
+		}
+	} else if !containsString(toolSourceTypes, opts.sourceType) {
+		return nil, fmt.Errorf("不支持的源类型: %s（可选: %s）", opts.sourceType, strings.Join(toolSourceTypes, ", "))
+	}
+
+	metadata := &types.ToolMetadata{
+		Name: name,
+		Shim: opts.shim,
+		DownloadConfig: types.DownloadConfig{
+			Type: opts.sourceType,
+		},
+	}
+
+	switch opts.sourceType {
+	case "github":
+		if opts.repo == "" {
+			if opts.repo, err = p.require("GitHub仓库 (owner/repo)", "repo"); err != nil {
+				return nil, err
+			}
+		}
+		if strings.Count(opts.repo, "/") != 1 {
+			return nil, fmt.Errorf("GitHub仓库格式应为 owner/repo: %s", opts.repo)
+		}
+		if opts.pattern == "" {
+			if opts.pattern, err = p.ask("资产文件名模式（留空自动匹配当前平台）", ""); err != nil {
+				return nil, err
+			}
+		}
+		metadata.DownloadConfig.Repository = opts.repo
+		metadata.DownloadConfig.AssetPattern = opts.pattern
+		metadata.Repository = "https://github.com/" + opts.repo
+	case "direct", "archive":
+		if opts.url == "" {
+			if opts.url, err = p.require("下载URL模板（可用 {version}、{os}、{arch}）", "url"); err != nil {
+				return nil, err
+			}
+		}
+		metadata.DownloadConfig.URLTemplate = opts.url
+	}
+
+	// 校验要求主页，未指定时默认为仓库地址或下载站点
+	homepage := opts.homepage
+	if homepage == "" && metadata.Repository != "" {
+		homepage = metadata.Repository
+	} else if u, err := url.Parse(opts.url); homepage == "" && err == nil && u.Host != "" {
+		homepage = u.Scheme + "://" + u.Host
+	}
+
+	if opts.binary == "" {
+		if opts.binary, err = p.ask("二进制文件名", name); err != nil {
+			return nil, err
+		}
+	}
+	metadata.DownloadConfig.ExtractBinary = opts.binary
+
+	if opts.description == "" {
+		if opts.description, err = p.ask("工具描述", name); err != nil {
+			return nil, err
+		}
+	}
+	metadata.Description = opts.description
+
+	if opts.homepage == "" {
+		if opts.homepage, err = p.ask("主页", homepage); err != nil {
+			return nil, err
+		}
+	}
+	metadata.Homepage = opts.homepage
+
+	if metadata.Repository == "" {
+		if metadata.Repository, err = p.ask("源码仓库地址", metadata.Homepage); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.version == "" {
+		label := "试下载的版本（留空使用最新版本）"
+		if opts.sourceType != "github" {
+			label = "试下载的版本（留空跳过验证）"
+		}
+		if opts.version, err = p.ask(label, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	return metadata, nil
+}
+
+// encodeToolMetadata 将工具元数据编码为工具定义文件内容
+func encodeToolMetadata(metadata *types.ToolMetadata) ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# %s 的工具定义，由 vman add 生成\n\n", metadata.Name)
+
+	if err := toml.NewEncoder(&buf).Encode(metadata); err != nil {
+		return nil, fmt.Errorf("编码工具定义失败: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// stdinIsTerminal 标准输入是否为终端
+// /dev/null 也是字符设备，重定向自 /dev/null 时不算终端
+func stdinIsTerminal() bool {
+	stat, err := os.Stdin.Stat()
+	if err != nil {
+		return false
+	}
+	if stat.Mode()&os.ModeCharDevice == 0 {
+		return false
+	}
+	if null, err := os.Stat(os.DevNull); err == nil && os.SameFile(stat, null) {
+		return false
+	}
+	return true
+}
+
+func init() {
+	rootCmd.AddCommand(addCmd)
+
+	addCmd.Flags().String("type", "", "下载源类型 (github, direct, archive)")
+	addCmd.Flags().String("repo", "", "GitHub仓库 (格式: owner/repo)")
+	addCmd.Flags().String("url", "", "下载URL模板")
+	addCmd.Flags().String("pattern", "", "资产文件名匹配模式")
+	addCmd.Flags().String("binary", "", "压缩包中的二进制文件名，默认与工具名相同")
+	addCmd.Flags().String("description", "", "工具描述")
+	addCmd.Flags().String("homepage", "", "工具主页")
+	addCmd.Flags().String("shim", "", "垫片（命令）名称，默认与工具名相同")
+	addCmd.Flags().String("version", "", "用于试下载的版本，默认使用最新版本")
+	addCmd.Flags().Bool("no-verify", false, "不试下载，直接保存工具定义")
+	addCmd.Flags().Bool("no-recipe", false, "不使用recipe，逐项询问工具定义")
+	addCmd.Flags().BoolP("force", "f", false, "覆盖已存在的工具定义")
+	addCmd.Flags().Bool("sandbox", false, "在沙箱中执行该工具，只允许写入项目目录和临时目录")
+	addCmd.Flags().Duration("timeout", 5*time.Minute, "试下载的超时时间")
+
+	regroupCommand(toolCmd, "add", addCmd)
+}
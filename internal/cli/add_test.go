@@ -0,0 +1,149 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/BurntSushi/toml"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/songzhibin97/vman/internal/download"
+	"github.com/songzhibin97/vman/internal/recipe"
+	"github.com/songzhibin97/vman/pkg/types"
+)
+
+func TestScaffoldToolMetadata_Interactive(t *testing.T) {
+	// 类型输入序号，仓库先直接回车一次，描述和主页使用默认值
+	input := strings.Join([]string{"1", "", "cli/cli", "gh_{version}_{os}_{arch}.tar.gz", "gh", "", "", "2.40.0"}, "\n") + "\n"
+	var out bytes.Buffer
+	opts := &addToolOptions{}
+
+	metadata, err := scaffoldToolMetadata("gh", opts, newToolPrompter(strings.NewReader(input), &out, true))
+	require.NoError(t, err)
+
+	assert.Equal(t, "github", metadata.DownloadConfig.Type)
+	assert.Equal(t, "cli/cli", metadata.DownloadConfig.Repository)
+	assert.Equal(t, "gh_{version}_{os}_{arch}.tar.gz", metadata.DownloadConfig.AssetPattern)
+	assert.Equal(t, "gh", metadata.DownloadConfig.ExtractBinary)
+	assert.Equal(t, "gh", metadata.Description)
+	assert.Equal(t, "https://github.com/cli/cli", metadata.Homepage)
+	assert.Equal(t, "2.40.0", opts.version)
+	assert.Contains(t, out.String(), "此项不能为空")
+}
+
+func TestScaffoldToolMetadata_NonInteractive(t *testing.T) {
+	prompter := newToolPrompter(strings.NewReader(""), &bytes.Buffer{}, false)
+
+	_, err := scaffoldToolMetadata("tf", &addToolOptions{sourceType: "archive"}, prompter)
+	assert.ErrorContains(t, err, "--url")
+
+	_, err = scaffoldToolMetadata("tf", &addToolOptions{sourceType: "rpm"}, prompter)
+	assert.ErrorContains(t, err, "不支持的源类型")
+
+	metadata, err := scaffoldToolMetadata("tf", &addToolOptions{sourceType: "archive", url: "https://example.com/{version}.zip", binary: "terraform"}, prompter)
+	require.NoError(t, err)
+	assert.Equal(t, "terraform", metadata.DownloadConfig.ExtractBinary)
+	assert.Equal(t, "tf", metadata.Description)
+
+	save, err := prompter.confirm("仍然保存?", false)
+	require.NoError(t, err)
+	assert.False(t, save)
+}
+
+func TestEncodeToolMetadata(t *testing.T) {
+	metadata := &types.ToolMetadata{
+		Name:        "gh",
+		Description: "GitHub CLI",
+		Shim:        "gh-cli",
+		DownloadConfig: types.DownloadConfig{
+			Type:          "github",
+			Repository:    "cli/cli",
+			ExtractBinary: "gh",
+		},
+	}
+
+	data, err := encodeToolMetadata(metadata)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(data), "# gh "))
+
+	var decoded types.ToolMetadata
+	_, err = toml.Decode(string(data), &decoded)
+	require.NoError(t, err)
+	assert.Equal(t, *metadata, decoded)
+}
+
+func TestTrialDownload_Direct(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/demo/1.2.3/demo" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("#!/bin/sh\necho demo\n"))
+	}))
+	defer server.Close()
+
+	metadata := &types.ToolMetadata{
+		Name: "demo",
+		DownloadConfig: types.DownloadConfig{
+			Type:          "direct",
+			URLTemplate:   server.URL + "/demo/{version}/demo",
+			ExtractBinary: "demo",
+		},
+	}
+
+	fs := afero.NewMemMapFs()
+	result, err := download.TrialDownload(context.Background(), fs, metadata, "1.2.3", "/tmp")
+	require.NoError(t, err)
+	assert.Equal(t, "demo", result.Binary)
+	assert.Equal(t, int64(20), result.Size)
+
+	// 临时文件已清理
+	entries, _ := afero.ReadDir(fs, "/tmp")
+	assert.Empty(t, entries)
+
+	_, err = download.TrialDownload(context.Background(), fs, metadata, "", "/tmp")
+	assert.Error(t, err)
+}
+
+func TestMetadataFromRecipe(t *testing.T) {
+	r, err := recipe.NewRegistry().Lookup("awscli")
+	require.NoError(t, err)
+
+	metadata := metadataFromRecipe(r, &addToolOptions{description: "aws"})
+	assert.Equal(t, "aws", metadata.Description)
+	assert.Equal(t, "aws", metadata.ShimName())
+	assert.Equal(t, "AWS 命令行工具 v2", r.Metadata.Description)
+
+	metadata = metadataFromRecipe(r, &addToolOptions{shim: "aws2"})
+	assert.Equal(t, "aws2", metadata.ShimName())
+	assert.Equal(t, runtime.GOOS == "linux" && (runtime.GOARCH == "amd64" || runtime.GOARCH == "arm64"), recipeSupportsPlatform(metadata))
+}
+
+func TestBundledRecipeShimNames(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	previous := commandProxy
+	commandProxy = nil
+	t.Cleanup(func() { commandProxy = previous })
+
+	recipes, err := recipe.NewRegistry().List()
+	require.NoError(t, err)
+	toolsDir := types.DefaultConfigPaths(home).ToolsDir
+	for _, r := range recipes {
+		t.Run(r.Name, func(t *testing.T) {
+			// 安装时写入工具定义后检查垫片名称，内置 recipe（包括 protoc）都能通过检查
+			path, err := r.Save(toolsDir)
+			require.NoError(t, err)
+			defer os.Remove(path)
+			assert.NoError(t, checkToolShimName(r.Name))
+		})
+	}
+}
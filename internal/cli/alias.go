@@ -0,0 +1,277 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/songzhibin97/vman/internal/proxy"
+	"github.com/songzhibin97/vman/pkg/types"
+)
+
+// aliasNamePattern 别名只能包含可以作为文件名的字符
+var aliasNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// aliasCmd 命令别名管理
+var aliasCmd = &cobra.Command{
+	Use:   "alias",
+	Short: "管理命令别名",
+	Long: `管理命令别名。别名会生成同名的垫片，执行时展开为指定的工具、版本和预设参数。
+
+别名保存在全局配置文件的 aliases 中。`,
+}
+
+// aliasAddCmd 添加命令别名
+var aliasAddCmd = &cobra.Command{
+	Use:         "add <name> <tool[@version] [args...]>",
+	Annotations: mutating(),
+	Short:       "添加命令别名",
+	Long: `添加命令别名并生成对应的垫片。
+
+版本可以是精确版本或版本前缀，如 1.27 会使用已安装的 1.27.x 中最高的版本。
+不指定版本时使用当前目录配置的版本。执行别名时传入的参数追加在预设参数之后。
+
+示例:
+  vman alias add k8s-prod "kubectl@1.27 --context prod"
+  vman alias add tfplan "terraform plan -out=tfplan"
+  vman alias add k kubectl`,
+	Args: cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		force, _ := cmd.Flags().GetBool("force")
+
+		alias, err := parseAliasSpec(args[1:])
+		if err != nil {
+			return err
+		}
+
+		if !aliasNamePattern.MatchString(name) {
+			return fmt.Errorf("无效的别名: %s", name)
+		}
+
+		managers, err := createManagers()
+		if err != nil {
+			return fmt.Errorf("创建管理器失败: %w", err)
+		}
+
+		// 别名的垫片不能覆盖工具、其他别名或vman子命令
+		if err := checkShimName(proxy.ShimEntry{Name: name, Kind: proxy.ShimKindAlias, Target: alias.Target()}); err != nil {
+			cmd.SilenceUsage = true
+			return err
+		}
+
+		globalConfig, err := managers.config.LoadGlobal()
+		if err != nil {
+			return fmt.Errorf("加载全局配置失败: %w", err)
+		}
+		if globalConfig.Aliases == nil {
+			globalConfig.Aliases = make(map[string]types.CommandAlias)
+		}
+		if _, exists := globalConfig.Aliases[name]; exists && !force {
+			return fmt.Errorf("别名 %s 已存在，使用 --force 覆盖", name)
+		}
+
+		globalConfig.Aliases[name] = *alias
+		if err := managers.config.SaveGlobal(globalConfig); err != nil {
+			return fmt.Errorf("保存全局配置失败: %w", err)
+		}
+
+		fmt.Printf("✅ 已添加别名 %s -> %s\n", name, formatAlias(*alias))
+
+		if err := regenerateShims(); err != nil {
+			fmt.Printf("警告: 重新生成垫片失败: %v\n", err)
+		}
+		return nil
+	},
+}
+
+// aliasListCmd 列出命令别名
+var aliasListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "列出命令别名",
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		jsonFormat, _ := cmd.Flags().GetBool("json")
+
+		managers, err := createManagers()
+		if err != nil {
+			return fmt.Errorf("创建管理器失败: %w", err)
+		}
+
+		globalConfig, err := managers.config.LoadGlobal()
+		if err != nil {
+			return fmt.Errorf("加载全局配置失败: %w", err)
+		}
+
+		if jsonFormat {
+			aliases := globalConfig.Aliases
+			if aliases == nil {
+				aliases = map[string]types.CommandAlias{}
+			}
+			jsonData, err := json.MarshalIndent(aliases, "", "  ")
+			if err != nil {
+				return fmt.Errorf("JSON编码失败: %w", err)
+			}
+			fmt.Println(string(jsonData))
+			return nil
+		}
+
+		if len(globalConfig.Aliases) == 0 {
+			fmt.Println("没有配置命令别名")
+			return nil
+		}
+
+		names := make([]string, 0, len(globalConfig.Aliases))
+		for name := range globalConfig.Aliases {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		table := NewTablePrinter([]string{"别名", "工具", "版本", "参数"}, getUIOptions(cmd))
+		for _, name := range names {
+			alias := globalConfig.Aliases[name]
+			version := alias.Version
+			if version == "" {
+				version = "(当前版本)"
+			}
+			table.AddRow([]string{name, alias.Tool, version, strings.Join(alias.Args, " ")})
+		}
+		table.Print()
+		return nil
+	},
+}
+
+// aliasRemoveCmd 删除命令别名
+var aliasRemoveCmd = &cobra.Command{
+	Use:         "remove <name>",
+	Annotations: mutating(),
+	Aliases:     []string{"rm"},
+	Short:       "删除命令别名",
+	Args:        cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+
+		managers, err := createManagers()
+		if err != nil {
+			return fmt.Errorf("创建管理器失败: %w", err)
+		}
+
+		globalConfig, err := managers.config.LoadGlobal()
+		if err != nil {
+			return fmt.Errorf("加载全局配置失败: %w", err)
+		}
+		if _, exists := globalConfig.Aliases[name]; !exists {
+			return fmt.Errorf("别名 %s 不存在", name)
+		}
+
+		delete(globalConfig.Aliases, name)
+		if err := managers.config.SaveGlobal(globalConfig); err != nil {
+			return fmt.Errorf("保存全局配置失败: %w", err)
+		}
+
+		fmt.Printf("✅ 已删除别名 %s\n", name)
+
+		if err := regenerateShims(); err != nil {
+			fmt.Printf("警告: 重新生成垫片失败: %v\n", err)
+		}
+		return nil
+	},
+}
+
+// parseAliasSpec 解析别名定义，如 "kubectl@1.27 --context prod"
+func parseAliasSpec(args []string) (*types.CommandAlias, error) {
+	// 整个定义作为一个参数传入时按shell规则拆分
+	if len(args) == 1 {
+		split, err := splitCommandLine(args[0])
+		if err != nil {
+			return nil, err
+		}
+		args = split
+	}
+	if len(args) == 0 || args[0] == "" {
+		return nil, fmt.Errorf("别名定义不能为空")
+	}
+
+	alias := &types.CommandAlias{Tool: args[0]}
+	if at := strings.Index(args[0], "@"); at >= 0 {
+		alias.Tool = args[0][:at]
+		alias.Version = args[0][at+1:]
+		if alias.Tool == "" || alias.Version == "" {
+			return nil, fmt.Errorf("无效的工具定义: %s", args[0])
+		}
+	}
+	if len(args) > 1 {
+		alias.Args = args[1:]
+	}
+
+	return alias, nil
+}
+
+// splitCommandLine 按shell规则拆分命令行，支持单引号、双引号和反斜杠转义
+func splitCommandLine(line string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	inArg := false
+	var quote rune
+	escaped := false
+
+	for _, r := range line {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+			inArg = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+
+	if quote != 0 || escaped {
+		return nil, fmt.Errorf("命令行引号不匹配: %s", line)
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
+
+// formatAlias 格式化别名定义
+func formatAlias(alias types.CommandAlias) string {
+	parts := append([]string{alias.Target()}, alias.Args...)
+	return strings.Join(parts, " ")
+}
+
+func init() {
+	rootCmd.AddCommand(aliasCmd)
+	aliasCmd.AddCommand(aliasAddCmd)
+	aliasCmd.AddCommand(aliasListCmd)
+	aliasCmd.AddCommand(aliasRemoveCmd)
+
+	// 名称之后的参数都属于别名定义，如 vman alias add k8s-prod kubectl@1.27 --context prod
+	aliasAddCmd.Flags().SetInterspersed(false)
+	aliasAddCmd.Flags().BoolP("force", "f", false, "覆盖已存在的别名")
+	aliasListCmd.Flags().Bool("json", false, "使用JSON格式输出")
+}
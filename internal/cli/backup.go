@@ -0,0 +1,334 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/songzhibin97/vman/internal/config"
+	"github.com/songzhibin97/vman/pkg/types"
+	"github.com/songzhibin97/vman/pkg/utils"
+)
+
+// backupCmd 备份和恢复配置
+var backupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: "备份和恢复配置",
+	Long: `备份全局配置和工具定义，可以同时记录已安装版本的清单（文件路径和SHA-256，不复制二进制文件）、
+垫片和项目配置。恢复时检查备份引用的版本是否已安装，并可以重新安装缺失的版本。
+
+备份默认保存在数据目录的 backups 目录中，以ID区分。
+
+启用 settings.backup.enabled 后，vman 在修改配置的命令（如 install、use、remove）执行前
+自动备份配置，vman serve 运行期间按 settings.backup.interval 定时备份，
+只保留最近的 settings.backup.keep_last 个自动备份。配置没有变化时不重复备份。`,
+}
+
+var backupListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "列出备份",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		options := getUIOptions(cmd)
+
+		api, _, err := newConfigAPI()
+		if err != nil {
+			return err
+		}
+		backups, err := api.ListBackups()
+		if err != nil {
+			return err
+		}
+		if len(backups) == 0 {
+			fmt.Println("没有备份")
+			return nil
+		}
+
+		table := NewTablePrinter([]string{"ID", "CREATED", "REASON", "PROJECTS", "VERSIONS"}, options)
+		for _, backup := range backups {
+			reason := backup.Manifest.Reason
+			if reason == "" {
+				reason = "-"
+			}
+			table.AddRow([]string{
+				backup.ID,
+				backup.Manifest.CreatedAt.Local().Format("2006-01-02 15:04:05"),
+				reason,
+				fmt.Sprintf("%d", len(backup.Manifest.Projects)),
+				fmt.Sprintf("%d", len(backup.Manifest.Versions)),
+			})
+		}
+		table.Print()
+		return nil
+	},
+}
+
+var backupCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "创建备份",
+	Long: `创建备份。
+
+示例:
+  vman backup create
+  vman backup create --versions --shims --projects ~/src
+  vman backup create -o /mnt/usb/vman-backup`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		output, _ := cmd.Flags().GetString("output")
+		includeVersions, _ := cmd.Flags().GetBool("versions")
+		includeShims, _ := cmd.Flags().GetBool("shims")
+		projectRoots, _ := cmd.Flags().GetStringSlice("projects")
+		options := getUIOptions(cmd)
+
+		api, paths, err := newConfigAPI()
+		if err != nil {
+			return err
+		}
+		if output == "" {
+			output = filepath.Join(config.BackupsDir(paths), "manual-"+time.Now().Format("20060102-150405"))
+		}
+		for i, root := range projectRoots {
+			if projectRoots[i], err = utils.ExpandPath(root); err != nil {
+				return fmt.Errorf("解析路径失败: %w", err)
+			}
+		}
+
+		manifest, err := api.Backup(context.Background(), output, &config.BackupOptions{
+			IncludeVersions: includeVersions,
+			IncludeShims:    includeShims,
+			ProjectRoots:    projectRoots,
+			Reason:          "manual",
+		})
+		if err != nil {
+			return fmt.Errorf("备份失败: %w", err)
+		}
+
+		PrintSuccess(fmt.Sprintf("已备份到 %s", output), options)
+		Infof(options, "  配置: %s\n", strings.Join(manifest.Files, ", "))
+		if len(projectRoots) > 0 {
+			Infof(options, "  项目配置: %d 个\n", len(manifest.Projects))
+		}
+		if includeVersions {
+			Infof(options, "  版本清单: %d 个版本\n", len(manifest.Versions))
+		}
+		if includeShims {
+			Infof(options, "  垫片: %d 个\n", len(manifest.Shims))
+		}
+		return nil
+	},
+}
+
+var backupRestoreCmd = &cobra.Command{
+	Use:         "restore <id|path>",
+	Annotations: mutating(),
+	Short:       "从备份恢复配置",
+	Long: `从备份恢复全局配置、工具定义和项目配置，当前的配置会被覆盖。
+
+项目配置恢复到备份时的路径，在另一台机器或新的主目录中恢复时可以用 --map 替换路径前缀，
+目标目录不存在的项目配置会被跳过。恢复后检查备份引用的版本是否已安装以及文件是否被修改。
+
+示例:
+  vman backup restore manual-20240601-120000
+  vman backup restore /mnt/usb/vman-backup --map /home/alice/src=/Users/alice/code`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		mappings, _ := cmd.Flags().GetStringArray("map")
+		skipProjects, _ := cmd.Flags().GetBool("skip-projects")
+		options := getUIOptions(cmd)
+
+		api, paths, err := newConfigAPI()
+		if err != nil {
+			return err
+		}
+		backupPath := resolveBackupPath(paths, args[0])
+
+		restoreOptions := &config.RestoreOptions{
+			PathMapping:  make(map[string]string),
+			SkipProjects: skipProjects,
+		}
+		for _, mapping := range mappings {
+			from, to, ok := strings.Cut(mapping, "=")
+			if !ok || from == "" || to == "" {
+				return fmt.Errorf("无效的路径映射 %q，格式为 <旧路径>=<新路径>", mapping)
+			}
+			restoreOptions.PathMapping[from] = to
+		}
+
+		manifest, err := api.LoadBackupManifest(backupPath)
+		if err != nil {
+			return err
+		}
+		if len(manifest.Files) == 0 && len(manifest.Projects) == 0 {
+			return fmt.Errorf("%s 不是有效的备份", backupPath)
+		}
+		confirmed, err := confirmAction(cmd, fmt.Sprintf("将用 %s 创建的备份覆盖当前配置，确定吗？", manifest.CreatedAt.Local().Format("2006-01-02 15:04:05")))
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			fmt.Println("操作已取消")
+			return nil
+		}
+
+		report, err := api.Restore(context.Background(), backupPath, restoreOptions)
+		if err != nil {
+			return fmt.Errorf("恢复失败: %w", err)
+		}
+		PrintSuccess(fmt.Sprintf("已从 %s 恢复配置", backupPath), options)
+		for _, project := range report.Projects {
+			Infof(options, "  恢复项目配置 %s\n", project)
+		}
+		for _, project := range report.SkippedProjects {
+			PrintWarning(fmt.Sprintf("目录不存在，跳过项目配置 %s（可以用 --map 映射到新路径）", project), options)
+		}
+		for _, version := range report.ModifiedVersions {
+			PrintWarning(fmt.Sprintf("%s 的文件与备份时不一致", version), options)
+		}
+
+		if err := regenerateShims(); err != nil {
+			PrintWarning(fmt.Sprintf("重新生成垫片失败: %v", err), options)
+		}
+		return reinstallMissingVersions(cmd, report.MissingVersions, options)
+	},
+}
+
+// autoBackupBeforeCommand 在修改配置的命令执行前创建自动备份，备份失败只给出警告
+func autoBackupBeforeCommand(cmd *cobra.Command) {
+	if !isMutatingCommand(cmd) {
+		return
+	}
+	api, _, err := newConfigAPI()
+	if err != nil {
+		return
+	}
+	if _, err := api.AutoBackup(context.Background(), resolveCommand(cmd).Name()); err != nil {
+		PrintWarning(fmt.Sprintf("自动备份配置失败: %v", err), getUIOptions(cmd))
+	}
+}
+
+// runScheduledBackups 在 vman serve 运行期间按 settings.backup.interval 定时备份配置
+// 每次检查时重新读取配置，运行期间修改的设置无需重启即可生效
+func runScheduledBackups(ctx context.Context, logger *logrus.Logger) {
+	check := func() {
+		api, _, err := newConfigAPI()
+		if err != nil {
+			logger.Warnf("scheduled backup: %v", err)
+			return
+		}
+		due, err := api.AutoBackupDue(time.Now())
+		if err != nil || !due {
+			if err != nil {
+				logger.Warnf("scheduled backup: %v", err)
+			}
+			return
+		}
+		if path, err := api.AutoBackup(ctx, "scheduled"); err != nil {
+			logger.Warnf("scheduled backup: %v", err)
+		} else if path != "" {
+			logger.Infof("configuration backed up to %s", path)
+		}
+	}
+
+	check()
+	ticker := time.NewTicker(backupCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			check()
+		}
+	}
+}
+
+// backupCheckInterval vman serve 检查是否需要定时备份的间隔
+const backupCheckInterval = 10 * time.Minute
+
+// newConfigAPI 创建配置管理API
+func newConfigAPI() (config.API, *types.ConfigPaths, error) {
+	homeDir, err := utils.GetHomeDir()
+	if err != nil {
+		return nil, nil, err
+	}
+	api, err := config.NewAPI(homeDir)
+	if err != nil {
+		return nil, nil, fmt.Errorf("创建配置管理器失败: %w", err)
+	}
+	return api, types.DefaultConfigPaths(homeDir), nil
+}
+
+// resolveBackupPath 将备份ID解析为备份目录，参数本身是已存在的目录或包含路径分隔符时按路径处理
+func resolveBackupPath(paths *types.ConfigPaths, idOrPath string) string {
+	if strings.ContainsRune(idOrPath, filepath.Separator) || strings.Contains(idOrPath, "/") {
+		return idOrPath
+	}
+	if info, err := os.Stat(idOrPath); err == nil && info.IsDir() {
+		return idOrPath
+	}
+	return filepath.Join(config.BackupsDir(paths), idOrPath)
+}
+
+// reinstallMissingVersions 提示备份引用但未安装的版本，确认后重新安装
+// 非交互环境中指定 --yes 时直接安装，否则只给出提示
+func reinstallMissingVersions(cmd *cobra.Command, missing []config.VersionRef, options *UIOptions) error {
+	if len(missing) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(missing))
+	for _, version := range missing {
+		names = append(names, version.String())
+	}
+	PrintWarning(fmt.Sprintf("以下版本未安装: %s", strings.Join(names, ", ")), options)
+
+	yes, _ := cmd.Flags().GetBool("yes")
+	if !yes && !stdinInteractive() {
+		fmt.Println("运行 vman install <tool> <version> 重新安装")
+		return nil
+	}
+	confirmed, err := confirmAction(cmd, fmt.Sprintf("重新安装这 %d 个版本吗？", len(missing)))
+	if err != nil || !confirmed {
+		return err
+	}
+
+	versionManager, err := createIntegratedManagerForStore(false)
+	if err != nil {
+		return fmt.Errorf("创建管理器失败: %w", err)
+	}
+	var failed []string
+	for _, version := range missing {
+		if err := installRequiredVersion(versionManager, version.Tool, version.Version, options); err != nil {
+			PrintError(fmt.Sprintf("安装 %s 失败: %v", version, err), options)
+			failed = append(failed, version.String())
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%d 个版本安装失败: %s", len(failed), strings.Join(failed, ", "))
+	}
+	return regenerateShims()
+}
+
+func init() {
+	rootCmd.AddCommand(backupCmd)
+	backupCmd.AddCommand(backupCreateCmd)
+	backupCmd.AddCommand(backupListCmd)
+	backupCmd.AddCommand(backupRestoreCmd)
+
+	backupCreateCmd.Flags().StringP("output", "o", "", "备份目录（默认为数据目录的 backups/manual-<时间>）")
+	backupCreateCmd.Flags().Bool("versions", false, "记录已安装版本的文件路径和SHA-256")
+	backupCreateCmd.Flags().Bool("shims", false, "记录垫片")
+	backupCreateCmd.Flags().StringSlice("projects", nil, "在这些目录下查找并备份项目配置，可以重复指定")
+
+	backupRestoreCmd.Flags().StringArray("map", nil, "将备份时的项目路径前缀映射到新的路径，格式为 <旧路径>=<新路径>")
+	backupRestoreCmd.Flags().Bool("skip-projects", false, "不恢复项目配置")
+}
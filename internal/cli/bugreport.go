@@ -0,0 +1,142 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/songzhibin97/vman/internal/diagnostics"
+	"github.com/songzhibin97/vman/pkg/types"
+	"github.com/songzhibin97/vman/pkg/utils"
+)
+
+// issuesURL 提交问题的地址
+const issuesURL = "https://github.com/songzhibin97/vman/issues"
+
+// bugreportCmd 生成诊断包
+var bugreportCmd = &cobra.Command{
+	Use:   "bugreport",
+	Short: "生成用于提交问题的诊断包",
+	Long: `收集系统信息、vman 相关的环境变量、全局和项目配置、已安装的版本、
+最近的日志和 vman doctor 的检查结果，打包为 zip 文件。
+
+诊断包中的令牌、密码等凭据和URL中的认证信息会被替换为 <redacted>，
+主目录替换为 ~，提交前仍建议检查一遍内容。
+
+默认保存到数据目录的 crash 目录中，vman 崩溃时自动生成的诊断包也保存在这里。
+
+示例:
+  vman bugreport
+  vman bugreport -o vman-report.zip`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		output, _ := cmd.Flags().GetString("output")
+
+		homeDir, err := utils.GetHomeDir()
+		if err != nil {
+			return err
+		}
+		paths := types.DefaultConfigPaths(homeDir)
+
+		bundle := collectDiagnostics("bugreport", paths, homeDir)
+		bundle.Add("doctor.txt", []byte(doctorReport()))
+
+		path := output
+		if path == "" {
+			path, err = bundle.Save(diagnostics.CrashDir(paths))
+		} else {
+			err = bundle.SaveAs(path)
+		}
+		if err != nil {
+			return fmt.Errorf("保存诊断包失败: %w", err)
+		}
+
+		PrintSuccess(fmt.Sprintf("诊断包已保存到 %s", path), getUIOptions(cmd))
+		fmt.Printf("包含: %s\n", strings.Join(bundle.Names(), ", "))
+		fmt.Printf("凭据已替换为 <redacted>，检查内容后附在问题中提交: %s\n", issuesURL)
+		return nil
+	},
+}
+
+// collectDiagnostics 收集当前进程的诊断信息
+func collectDiagnostics(reason string, paths *types.ConfigPaths, homeDir string) *diagnostics.Bundle {
+	workDir, _ := os.Getwd()
+	return diagnostics.Collect(paths, diagnostics.Options{
+		Reason:  reason,
+		Version: rootCmd.Version,
+		Args:    os.Args,
+		WorkDir: workDir,
+		HomeDir: homeDir,
+		Environ: os.Environ(),
+	})
+}
+
+// doctorReport 以文本形式返回 vman doctor 的检查结果
+func doctorReport() string {
+	managers, err := createManagers()
+	if err != nil {
+		return fmt.Sprintf("创建管理器失败: %v\n", err)
+	}
+
+	var b strings.Builder
+	for _, check := range doctorChecks {
+		for _, result := range check(managers) {
+			status := "ok"
+			switch result.Status {
+			case doctorWarning:
+				status = "warning"
+			case doctorError:
+				status = "error"
+			}
+			fmt.Fprintf(&b, "[%s] %s", status, result.Name)
+			if result.Message != "" {
+				fmt.Fprintf(&b, ": %s", result.Message)
+			}
+			b.WriteString("\n")
+			for _, detail := range result.Details {
+				fmt.Fprintf(&b, "    %s\n", detail)
+			}
+		}
+	}
+	return b.String()
+}
+
+// handlePanic 保存崩溃时的诊断包并提示用户提交问题，返回代替 panic 的错误
+func handlePanic(value interface{}, stack []byte) error {
+	fmt.Fprintf(os.Stderr, "vman 发生了内部错误: %v\n", value)
+	report := fmt.Sprintf("panic: %v\n\n%s", value, stack)
+
+	path, err := savePanicBundle(report)
+	if err != nil {
+		// 无法保存诊断包时直接输出堆栈
+		fmt.Fprintf(os.Stderr, "保存诊断包失败: %v\n\n%s\n", err, stack)
+	} else {
+		fmt.Fprintf(os.Stderr, "诊断包已保存到 %s\n", path)
+		fmt.Fprintf(os.Stderr, "请在提交问题时附上该文件: %s\n", issuesURL)
+	}
+	return fmt.Errorf("panic: %v", value)
+}
+
+// savePanicBundle 收集诊断信息并保存到崩溃目录，收集过程中再次 panic 时返回错误
+func savePanicBundle(report string) (path string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("collecting diagnostics panicked: %v", r)
+		}
+	}()
+
+	homeDir, _ := utils.GetHomeDir()
+	paths := types.DefaultConfigPaths(homeDir)
+	bundle := collectDiagnostics("panic", paths, homeDir)
+	bundle.Add("panic.txt", []byte(report))
+	return bundle.Save(diagnostics.CrashDir(paths))
+}
+
+func init() {
+	rootCmd.AddCommand(bugreportCmd)
+
+	bugreportCmd.Flags().StringP("output", "o", "", "诊断包保存路径，默认保存到数据目录的 crash 目录")
+}
@@ -0,0 +1,26 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/songzhibin97/vman/internal/diagnostics"
+	"github.com/songzhibin97/vman/pkg/types"
+)
+
+func TestHandlePanic_SavesBundle(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv(types.EnvVmanHome, root)
+
+	err := handlePanic("boom", []byte("goroutine 1 [running]:\nmain.main()\n"))
+	assert.EqualError(t, err, "panic: boom")
+
+	entries, err := os.ReadDir(diagnostics.CrashDir(types.ConfigPathsFromRoot(root)))
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, ".zip", filepath.Ext(entries[0].Name()))
+}
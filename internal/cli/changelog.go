@@ -0,0 +1,141 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/songzhibin97/vman/internal/download"
+	"github.com/songzhibin97/vman/internal/proxy"
+	"github.com/songzhibin97/vman/pkg/types"
+)
+
+// defaultChangelogTimeout 未设置下载超时时获取发布说明的超时时间
+const defaultChangelogTimeout = time.Minute
+
+// changelogCmd 显示两个版本之间的发布说明
+var changelogCmd = &cobra.Command{
+	Use:   "changelog <tool>",
+	Short: "显示两个版本之间的发布说明",
+	Long: `获取并显示工具在 --from 之后（不含）到 --to（含）之间各版本的发布说明，用于评估升级。
+
+发布说明来源:
+  - 工具定义中的 changelog 地址。地址中有 {version} 等版本变量时按版本分别获取，
+    否则获取整个变更日志（如 CHANGELOG.md）并按版本标题拆分
+  - 没有配置 changelog 时使用发布源中各版本的说明，如GitHub release的正文
+
+--from 默认为当前目录下生效的版本，--to 默认为最新版本。默认忽略预发布版本，
+使用 --prerelease 包含预发布版本。
+
+示例:
+  vman changelog kubectl
+  vman changelog terraform --from 1.6.0 --to 1.7.5
+  vman changelog helm --json`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		tool := args[0]
+		from, _ := cmd.Flags().GetString("from")
+		to, _ := cmd.Flags().GetString("to")
+		prerelease, _ := cmd.Flags().GetBool("prerelease")
+		jsonFormat, _ := cmd.Flags().GetBool("json")
+		cmd.SilenceUsage = true
+
+		managers, err := createManagers()
+		if err != nil {
+			return fmt.Errorf("创建管理器失败: %w", err)
+		}
+		metadata, err := managers.config.LoadToolConfig(tool)
+		if err != nil {
+			return fmt.Errorf("未找到工具 %s 的定义: %w", tool, err)
+		}
+
+		if from == "" {
+			cwd, _ := os.Getwd()
+			resolver := proxy.NewVersionResolver(managers.config, managers.version)
+			if resolution, err := resolver.ResolveVersion(context.Background(), tool, cwd); err == nil && resolution.Version != types.SystemVersion {
+				from = resolution.Version
+			} else if installed, err := managers.version.GetInstalledVersions(tool); err == nil && len(installed) > 0 {
+				from = highestVersion(installed)
+			} else {
+				return fmt.Errorf("%s 没有生效的版本，请使用 --from 指定起始版本", tool)
+			}
+		}
+
+		integratedManager, err := createIntegratedManager()
+		if err != nil {
+			return fmt.Errorf("创建管理器失败: %w", err)
+		}
+		// 配置了整个变更日志时不需要发布源的版本列表
+		available, searchErr := integratedManager.SearchAvailableVersions(tool)
+		if searchErr != nil && (metadata.Changelog == "" || download.IsPerVersionChangelog(metadata.Changelog)) {
+			return fmt.Errorf("查询 %s 的远程版本失败: %w", tool, searchErr)
+		}
+
+		timeout := defaultChangelogTimeout
+		if globalConfig, err := managers.config.LoadGlobal(); err == nil && globalConfig.Settings.Download.Timeout > 0 {
+			timeout = globalConfig.Settings.Download.Timeout
+		}
+		client := download.NewHTTPClient(timeout)
+
+		Infof(getUIOptions(cmd), "正在获取 %s 的发布说明...\n", tool)
+		notes, err := download.FetchReleaseNotes(context.Background(), client, metadata, available, from, to, prerelease)
+		if err != nil {
+			return err
+		}
+
+		if jsonFormat {
+			jsonData, err := json.MarshalIndent(notes, "", "  ")
+			if err != nil {
+				return fmt.Errorf("JSON编码失败: %w", err)
+			}
+			fmt.Println(string(jsonData))
+			return nil
+		}
+		printReleaseNotes(tool, from, to, notes, getUIOptions(cmd))
+		return nil
+	},
+}
+
+// printReleaseNotes 按版本从新到旧输出发布说明
+func printReleaseNotes(tool, from, to string, notes []download.ReleaseNote, options *UIOptions) {
+	if to == "" {
+		to = "最新版本"
+	}
+	if len(notes) == 0 {
+		fmt.Printf("%s 在 %s 之后到 %s 之间没有发布说明\n", tool, from, to)
+		return
+	}
+
+	for i, note := range notes {
+		if i > 0 {
+			fmt.Println()
+		}
+		header := ColorizeBold(fmt.Sprintf("%s %s", tool, note.Version), options)
+		if note.ReleaseDate != "" {
+			date := note.ReleaseDate
+			if released, err := time.Parse(time.RFC3339, date); err == nil {
+				date = released.Format("2006-01-02")
+			}
+			header += " " + ColorizeDim("("+date+")", options)
+		}
+		fmt.Println(header)
+		if note.Notes == "" {
+			fmt.Println(ColorizeDim("（没有发布说明）", options))
+			continue
+		}
+		fmt.Println(note.Notes)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(changelogCmd)
+
+	changelogCmd.Flags().String("from", "", "起始版本（不含），默认为当前生效的版本")
+	changelogCmd.Flags().String("to", "", "结束版本（含），默认为最新版本")
+	changelogCmd.Flags().Bool("prerelease", false, "包含预发布版本")
+	changelogCmd.Flags().Bool("json", false, "使用JSON格式输出")
+}
@@ -1,10 +1,16 @@
 package cli
 
 import (
+	"os"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/songzhibin97/vman/pkg/types"
 )
 
 func TestRootCommand(t *testing.T) {
@@ -40,6 +46,96 @@ func TestRootCommandHasRequiredSubcommands(t *testing.T) {
 	}
 }
 
+// readOnlyCommands 不修改配置、已安装版本、垫片或用户文件的命令
+// 新增的命令需要在定义中设置 Annotations: mutating()，或确认不写入任何文件后加入这里
+var readOnlyCommands = map[string]bool{
+	"vman alias list":        true,
+	"vman backup create":     true,
+	"vman backup list":       true,
+	"vman bugreport":         true,
+	"vman changelog":         true,
+	"vman completion":        true,
+	"vman completion bridge": true,
+	"vman completion script": true,
+	"vman config get":        true,
+	"vman config show":       true,
+	"vman current":           true,
+	"vman doctor":            true,
+	"vman du":                true,
+	"vman exec":              true,
+	"vman history":           true,
+	"vman info":              true,
+	"vman inspect-archive":   true,
+	"vman list":              true,
+	"vman list-sources":      true,
+	"vman lsp":               true,
+	"vman news":              true,
+	"vman outdated":          true,
+	"vman path check":        true,
+	"vman projects list":     true,
+	"vman projects status":   true,
+	"vman prompt":            true,
+	"vman prompt starship":   true,
+	"vman protoc status":     true,
+	"vman proxy status":      true,
+	"vman recipes":           true,
+	"vman registry list":     true,
+	"vman report usage":      true,
+	"vman sandbox-run":       true,
+	"vman search":            true,
+	"vman serve":             true,
+	"vman shell-init":        true,
+	"vman shellenv":          true,
+	"vman stats":             true,
+	"vman test-source":       true,
+	"vman which":             true,
+}
+
+func TestMutatingCommandsAnnotated(t *testing.T) {
+	var walk func(cmd *cobra.Command)
+	walk = func(cmd *cobra.Command) {
+		for _, sub := range cmd.Commands() {
+			walk(sub)
+			// 分组子命令按原有命令判断
+			if !sub.Runnable() || resolveCommand(sub) != sub || sub.Name() == "help" {
+				continue
+			}
+			_, annotated := sub.Annotations[annotationMutating]
+			assert.NotEqual(t, annotated, readOnlyCommands[sub.CommandPath()],
+				"%s 需要在定义中标记为修改操作（mutating()），或加入 readOnlyCommands", sub.CommandPath())
+		}
+	}
+	walk(rootCmd)
+
+	for _, path := range []string{"vman path fix", "vman registry keygen", "vman registry sign", "vman protoc setup", "vman mirror", "vman completion install", "vman uninstall"} {
+		cmd, _, err := rootCmd.Find(strings.Fields(path)[1:])
+		require.NoError(t, err, path)
+		assert.True(t, isMutatingCommand(cmd), path)
+	}
+	// 分组子命令按原有命令判断
+	cmd, _, err := rootCmd.Find([]string{"version", "install"})
+	require.NoError(t, err)
+	assert.True(t, isMutatingCommand(cmd))
+	assert.False(t, isMutatingCommand(exportCmd), "vman export 只在写入文件时检查")
+}
+
+func TestCheckReadOnly(t *testing.T) {
+	t.Setenv(types.EnvVmanHome, t.TempDir())
+
+	t.Setenv(types.EnvVmanReadOnly, "true")
+	err := checkReadOnly(installCmd, loadGlobalSettings())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "只读模式")
+	assert.NoError(t, checkReadOnly(listCmd, loadGlobalSettings()))
+	assert.NoError(t, checkReadOnly(execCmd, loadGlobalSettings()))
+	completionInstall, _, err := rootCmd.Find([]string{"completion", "install"})
+	require.NoError(t, err)
+	assert.Error(t, checkReadOnly(completionInstall, loadGlobalSettings()), "completion install 修改shell配置文件")
+
+	t.Setenv(types.EnvVmanReadOnly, "0")
+	assert.NoError(t, checkReadOnly(installCmd, loadGlobalSettings()))
+}
+
 func TestInitCommandFlags(t *testing.T) {
 	// 测试init命令的标志
 	// 首先找到init命令
@@ -195,6 +291,98 @@ func TestVersionHelperFunctions(t *testing.T) {
 	assert.Equal(t, "1.0.0", result)
 }
 
+func TestDisplayWidth(t *testing.T) {
+	assert.Equal(t, 5, displayWidth("1.0.0"))
+	assert.Equal(t, 4, displayWidth("版本"))
+	assert.Equal(t, 6, displayWidth("a版本b"))
+
+	assert.Equal(t, "   ", padding("版本", 7))
+	assert.Equal(t, "", padding("1.0.0", 3))
+}
+
+func TestParseAge(t *testing.T) {
+	d, err := parseAge("180d")
+	assert.NoError(t, err)
+	assert.Equal(t, 180*24*time.Hour, d)
+
+	d, err = parseAge("2w")
+	assert.NoError(t, err)
+	assert.Equal(t, 14*24*time.Hour, d)
+
+	d, err = parseAge("36h")
+	assert.NoError(t, err)
+	assert.Equal(t, 36*time.Hour, d)
+
+	_, err = parseAge("abc")
+	assert.Error(t, err)
+
+	_, err = parseAge("-1d")
+	assert.Error(t, err)
+}
+
+func TestInfoCommand(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	cmd, _, err := rootCmd.Find([]string{"info"})
+	assert.NoError(t, err)
+	assert.Equal(t, "info", cmd.Name())
+	assert.NotNil(t, cmd.Flags().Lookup("json"))
+
+	// 支持 info <tool> 和 info <tool> <version>
+	assert.NoError(t, cmd.Args(cmd, []string{"kubectl"}))
+	assert.NoError(t, cmd.Args(cmd, []string{"kubectl", "1.29.0"}))
+	assert.Error(t, cmd.Args(cmd, []string{}))
+}
+
+func TestPathAndDoctorCommands(t *testing.T) {
+	cmd, _, err := rootCmd.Find([]string{"path", "check"})
+	assert.NoError(t, err)
+	assert.Equal(t, "check", cmd.Name())
+	assert.NotNil(t, cmd.Flags().Lookup("json"))
+
+	cmd, _, err = rootCmd.Find([]string{"path", "fix"})
+	assert.NoError(t, err)
+	assert.Equal(t, "fix", cmd.Name())
+	assert.NotNil(t, cmd.Flags().Lookup("shell"))
+	assert.NotNil(t, cmd.Flags().Lookup("print"))
+
+	cmd, _, err = rootCmd.Find([]string{"doctor"})
+	assert.NoError(t, err)
+	assert.Equal(t, "doctor", cmd.Name())
+}
+
+func TestParseAliasSpec(t *testing.T) {
+	alias, err := parseAliasSpec([]string{"kubectl@1.27 --context prod"})
+	assert.NoError(t, err)
+	assert.Equal(t, "kubectl", alias.Tool)
+	assert.Equal(t, "1.27", alias.Version)
+	assert.Equal(t, []string{"--context", "prod"}, alias.Args)
+
+	// 未加引号时定义由多个参数组成
+	alias, err = parseAliasSpec([]string{"terraform", "plan", "-out=tfplan"})
+	assert.NoError(t, err)
+	assert.Equal(t, "terraform", alias.Tool)
+	assert.Empty(t, alias.Version)
+	assert.Equal(t, []string{"plan", "-out=tfplan"}, alias.Args)
+
+	_, err = parseAliasSpec([]string{"kubectl@"})
+	assert.Error(t, err)
+	_, err = parseAliasSpec([]string{""})
+	assert.Error(t, err)
+}
+
+func TestSplitCommandLine(t *testing.T) {
+	args, err := splitCommandLine(`kubectl --context "prod cluster" -l 'app=a b' a\ b`)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"kubectl", "--context", "prod cluster", "-l", "app=a b", "a b"}, args)
+
+	args, err = splitCommandLine(`echo ""`)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"echo", ""}, args)
+
+	_, err = splitCommandLine(`echo "unterminated`)
+	assert.Error(t, err)
+}
+
 func TestFormatBytesFunction(t *testing.T) {
 	// 测试字节格式化函数
 	result := formatBytesEnhanced(0)
@@ -289,6 +477,40 @@ func TestGlobalFlags(t *testing.T) {
 	verboseFlag := persistentFlags.Lookup("verbose")
 	assert.NotNil(t, verboseFlag)
 	assert.Equal(t, "v", verboseFlag.Shorthand)
+
+	// 检查quiet和yes标志
+	assert.Equal(t, "q", persistentFlags.Lookup("quiet").Shorthand)
+	assert.Equal(t, "y", persistentFlags.Lookup("yes").Shorthand)
+}
+
+// TestConfirmAction 测试确认提示在非交互环境中的行为
+func TestConfirmAction(t *testing.T) {
+	previous := stdinInteractive
+	defer func() { stdinInteractive = previous }()
+	stdinInteractive = func() bool { return false }
+
+	newCommand := func(args ...string) *cobra.Command {
+		cmd := &cobra.Command{Use: "test"}
+		cmd.Flags().BoolP("yes", "y", false, "")
+		cmd.Flags().BoolP("quiet", "q", false, "")
+		require.NoError(t, cmd.ParseFlags(args))
+		return cmd
+	}
+
+	// 非交互环境中不能询问，需要 --yes
+	confirmed, err := confirmAction(newCommand(), "确定吗？")
+	assert.ErrorIs(t, err, errConfirmationRequired)
+	assert.False(t, confirmed)
+	assert.False(t, getUIOptions(newCommand()).Interactive)
+
+	confirmed, err = confirmAction(newCommand("-y"), "确定吗？")
+	assert.NoError(t, err)
+	assert.True(t, confirmed)
+
+	stdinInteractive = func() bool { return true }
+	assert.True(t, getUIOptions(newCommand()).Interactive)
+	assert.False(t, getUIOptions(newCommand("--yes")).Interactive)
+	assert.True(t, getUIOptions(newCommand("-q")).Quiet)
 }
 
 // BenchmarkRootCommandExecution 性能测试
@@ -339,6 +561,35 @@ func TestCommandValidation(t *testing.T) {
 	}
 }
 
+// TestExecPassesArgsThrough 测试工具名之后的参数原样传给工具
+func TestExecPassesArgsThrough(t *testing.T) {
+	tests := []struct {
+		args     []string
+		expected []string
+	}{
+		{[]string{"kubectl", "--help"}, []string{"kubectl", "--help"}},
+		{[]string{"kubectl", "-v", "6"}, []string{"kubectl", "-v", "6"}},
+		{[]string{"kubectl@1.29.0", "--version"}, []string{"kubectl@1.29.0", "--version"}},
+		{[]string{"seq", "-5", "-1"}, []string{"seq", "-5", "-1"}},
+		{[]string{"rg", "--", "-pattern"}, []string{"rg", "--", "-pattern"}},
+		{[]string{"go", "run", ".", "--", "-x"}, []string{"go", "run", ".", "--", "-x"}},
+		{[]string{"ls", "*.go", "--color=never"}, []string{"ls", "*.go", "--color=never"}},
+		{[]string{"--", "kubectl", "--help"}, []string{"kubectl", "--help"}},
+		{[]string{"--", "-tool", "-q"}, []string{"-tool", "-q"}},
+	}
+
+	for _, test := range tests {
+		require.NoError(t, execCmd.ParseFlags(test.args), "%v", test.args)
+		assert.Equal(t, test.expected, execCmd.Flags().Args(), "%v", test.args)
+	}
+	quiet, _ := execCmd.Flags().GetBool("quiet")
+	assert.False(t, quiet, "flags after the tool name must not be parsed by vman")
+
+	protocExec := newProtocExecCmd()
+	require.NoError(t, protocExec.ParseFlags([]string{"make", "-j4", "--dry-run"}))
+	assert.Equal(t, []string{"make", "-j4", "--dry-run"}, protocExec.Flags().Args())
+}
+
 // TestHelpOutput 测试帮助输出
 func TestHelpOutput(t *testing.T) {
 	// 测试根命令的帮助输出
@@ -369,3 +620,27 @@ func TestWithMockEnvironment(t *testing.T) {
 	// 执行需要环境设置的测试
 	assert.True(t, true, "Mock environment test")
 }
+
+func TestCheckOwnership(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("需要root权限修改目录所有者")
+	}
+	home := t.TempDir()
+	t.Setenv(types.EnvVmanHome, home)
+	t.Setenv("SUDO_USER", "alice")
+
+	assert.NoError(t, checkOwnership(installCmd, types.Settings{}))
+
+	require.NoError(t, os.Chown(home, 12345, 12345))
+	assert.NoError(t, checkOwnership(installCmd, types.Settings{}), "默认只给出警告")
+	assert.NoError(t, checkOwnership(listCmd, types.Settings{RootPolicy: types.RootPolicyRefuse}), "只检查修改操作")
+	assert.NoError(t, checkOwnership(installCmd, types.Settings{RootPolicy: types.RootPolicyAllow}))
+
+	err := checkOwnership(installCmd, types.Settings{RootPolicy: types.RootPolicyRefuse})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "--system")
+
+	require.NoError(t, installCmd.Flags().Set("system", "true"))
+	defer installCmd.Flags().Set("system", "false")
+	assert.NoError(t, checkOwnership(installCmd, types.Settings{RootPolicy: types.RootPolicyRefuse}))
+}
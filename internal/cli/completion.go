@@ -1,10 +1,17 @@
 package cli
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"slices"
+	"strings"
 
+	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
+
+	"github.com/songzhibin97/vman/internal/proxy"
+	"github.com/songzhibin97/vman/pkg/types"
 )
 
 // completionCmd Tab补全命令
@@ -51,13 +58,158 @@ PowerShell:
   # 临时启用
   vman completion powershell | Out-String | Invoke-Expression
   
-  # 永久启用，将输出添加到您的PowerShell配置文件中`,
+  # 永久启用，将输出添加到您的PowerShell配置文件中
+
+使用 vman completion install 在 bash、zsh、fish 的配置文件中启用补全，同时启用托管工具
+（如 kubectl）当前版本自己的补全。`,
 	DisableFlagsInUseLine: true,
 	ValidArgs:             []string{"bash", "zsh", "fish", "powershell"},
 	Args:                  cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
 	Run:                   runCompletionCommand,
 }
 
+// completionInstallCmd 在shell配置文件中加载补全，包括托管工具自身的补全
+var completionInstallCmd = &cobra.Command{
+	Use:         "install [bash|zsh|fish]",
+	Annotations: mutating(),
+	Short:       "在shell配置文件中启用vman和托管工具的补全",
+	Long: `在shell配置文件中写入加载补全的片段，除了vman自身的补全，还为托管的工具注册补全桥接：
+按 TAB 补全 kubectl 等工具时，加载当前目录解析出的版本自己的补全脚本，切换版本后自动换用新版本的补全。
+
+工具定义中的 completion 设置输出补全脚本的参数（如 ["completion", "{shell}"]），
+各版本的补全脚本在第一次补全时生成并缓存在缓存目录的 completions 中。
+
+写入的片段由 "# vman completion" 标记包围，重复运行只会更新这一段，使用 --remove 移除。
+不指定shell时自动检测当前shell。`,
+	Args:      cobra.MaximumNArgs(1),
+	ValidArgs: proxy.CompletionShells,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		options := getUIOptions(cmd)
+		remove, _ := cmd.Flags().GetBool("remove")
+
+		shell := proxy.NewShellIntegrator().DetectShell()
+		if len(args) == 1 {
+			shell = args[0]
+		}
+		if !slices.Contains(proxy.CompletionShells, shell) {
+			return fmt.Errorf("不支持的shell类型: %s，支持 %s", shell, strings.Join(proxy.CompletionShells, ", "))
+		}
+
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return fmt.Errorf("获取用户主目录失败: %w", err)
+		}
+		configFile := getShellConfigFile(shell, homeDir)
+
+		if remove {
+			removed, err := proxy.RemoveCompletionSnippet(afero.NewOsFs(), configFile)
+			if err != nil {
+				return fmt.Errorf("移除补全配置失败: %w", err)
+			}
+			if !removed {
+				fmt.Printf("%s 中没有 vman completion install 写入的配置\n", configFile)
+				return nil
+			}
+			PrintSuccess(fmt.Sprintf("已从 %s 中移除补全配置，重新打开终端后生效", configFile), options)
+			return nil
+		}
+
+		changed, err := proxy.InstallCompletionSnippet(afero.NewOsFs(), configFile, shell)
+		if err != nil {
+			return fmt.Errorf("写入补全配置失败: %w", err)
+		}
+		if !changed {
+			fmt.Printf("%s 中已启用补全\n", configFile)
+			return nil
+		}
+		PrintSuccess(fmt.Sprintf("已在 %s 中启用补全", configFile), options)
+		fmt.Printf("重新打开终端或运行以下命令以生效:\n  %s\n", reloadCommand(shell, configFile))
+		return nil
+	},
+}
+
+// completionBridgeCmd 输出托管工具的补全桥接脚本，由 vman completion install 写入的片段加载
+var completionBridgeCmd = &cobra.Command{
+	Use:       "bridge <bash|zsh|fish>",
+	Short:     "输出托管工具的补全桥接脚本",
+	Hidden:    true,
+	Args:      cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+	ValidArgs: proxy.CompletionShells,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		managers, err := createManagers()
+		if err != nil {
+			return err
+		}
+		tools, err := managers.config.ListTools()
+		if err != nil {
+			return err
+		}
+
+		var commands []proxy.BridgedCommand
+		for _, tool := range tools {
+			metadata, err := managers.config.LoadToolConfig(tool)
+			if err != nil || len(metadata.Completion) == 0 {
+				continue
+			}
+			commands = append(commands, proxy.BridgedCommand{Command: metadata.ShimName(), Tool: tool})
+		}
+		script, err := proxy.CompletionBridge(args[0], commands)
+		if err != nil {
+			return err
+		}
+		fmt.Print(script)
+		return nil
+	},
+}
+
+// completionScriptCmd 输出工具在当前目录解析出的版本的补全脚本路径，补全桥接在每次补全时调用
+var completionScriptCmd = &cobra.Command{
+	Use:    "script <bash|zsh|fish> <tool>",
+	Short:  "输出工具当前版本的补全脚本路径",
+	Hidden: true,
+	Args:   cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		shell, tool := args[0], args[1]
+		if !slices.Contains(proxy.CompletionShells, shell) {
+			return fmt.Errorf("不支持的shell类型: %s", shell)
+		}
+
+		managers, err := createManagers()
+		if err != nil {
+			return err
+		}
+		metadata, err := managers.config.LoadToolConfig(tool)
+		if err != nil {
+			return err
+		}
+		if len(metadata.Completion) == 0 {
+			return fmt.Errorf("工具 %s 没有配置 completion", tool)
+		}
+
+		cwd, err := os.Getwd()
+		if err != nil {
+			return err
+		}
+		resolution, err := proxy.NewVersionResolver(managers.config, managers.version).ResolveVersion(context.Background(), tool, cwd)
+		if err != nil {
+			return err
+		}
+		if resolution.Version == types.SystemVersion || !resolution.IsInstalled {
+			return fmt.Errorf("%s %s 不是已安装的托管版本", tool, resolution.Version)
+		}
+
+		binaryPath := managers.storage.GetBinaryPath(tool, resolution.Version)
+		path, err := proxy.GenerateCompletionScript(afero.NewOsFs(), managers.storage.GetCacheDir(), tool, resolution.Version, binaryPath, shell, metadata.Completion)
+		if err != nil {
+			return err
+		}
+		fmt.Println(path)
+		return nil
+	},
+}
+
 func runCompletionCommand(cmd *cobra.Command, args []string) {
 	switch args[0] {
 	case "bash":
@@ -421,6 +573,11 @@ func installCompletionScript(shell string) error {
 // 注册completion命令
 func init() {
 	rootCmd.AddCommand(completionCmd)
+	completionCmd.AddCommand(completionInstallCmd)
+	completionCmd.AddCommand(completionBridgeCmd)
+	completionCmd.AddCommand(completionScriptCmd)
+
+	completionInstallCmd.Flags().Bool("remove", false, "从shell配置文件中移除 vman completion install 写入的片段")
 
 	// 在根命令初始化完成后设置补全
 	cobra.OnInitialize(setupCompletions)
@@ -0,0 +1,211 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/songzhibin97/vman/internal/config"
+	"github.com/songzhibin97/vman/pkg/types"
+	"github.com/songzhibin97/vman/pkg/utils"
+)
+
+var configGetCmd = &cobra.Command{
+	Use:   "get <key>",
+	Short: "显示全局设置项的值",
+	Long:  `显示全局配置中设置项的当前值，未设置时显示默认值。`,
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		api, _, err := newConfigAPI()
+		if err != nil {
+			return err
+		}
+		value, err := api.GetGlobalSetting(context.Background(), args[0])
+		if err != nil {
+			return fmt.Errorf("读取全局配置失败: %w", err)
+		}
+		if value == nil {
+			return fmt.Errorf("未知的设置项: %s", args[0])
+		}
+		fmt.Println(formatSettingValue(value))
+		return nil
+	},
+}
+
+var configSetCmd = &cobra.Command{
+	Use:         "set <key> <value>",
+	Annotations: mutating(),
+	Short:       "修改全局设置项",
+	Long: `修改全局配置中的设置项，值按设置项的类型解析：时长如 30s、10m，大小如 512MB，
+列表使用逗号分隔。修改后的配置通过校验才会保存。`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		key, raw := args[0], args[1]
+
+		api, _, err := newConfigAPI()
+		if err != nil {
+			return err
+		}
+		ctx := context.Background()
+		current, err := api.GetGlobalSetting(ctx, key)
+		if err != nil {
+			return fmt.Errorf("读取全局配置失败: %w", err)
+		}
+		if current == nil {
+			return fmt.Errorf("未知的设置项: %s", key)
+		}
+		value, err := parseSettingValue(current, raw)
+		if err != nil {
+			return fmt.Errorf("%s 的值无效: %w", key, err)
+		}
+		if err := api.SetGlobalSetting(ctx, key, value); err != nil {
+			return fmt.Errorf("修改设置失败: %w", err)
+		}
+
+		PrintSuccess(fmt.Sprintf("%s = %s", key, formatSettingValue(value)), getUIOptions(cmd))
+		return nil
+	},
+}
+
+var configShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "显示当前项目或全局的配置",
+	Long: `显示当前目录所在项目的 .vman.yaml，使用 --global 显示全局配置文件。
+
+使用 --resolved 显示读取后最终生效的配置：项目配置通过 extends 继承基础配置、全局配置设置了
+settings.remote 远程配置时为合并后的配置，开头的注释列出合并的配置来源，后面的被前面的覆盖。配置中 sops 或 age 加密的值解密后
+显示为 <encrypted>，使用 --reveal 显示解密后的值。`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		global, _ := cmd.Flags().GetBool("global")
+		resolved, _ := cmd.Flags().GetBool("resolved")
+		reveal, _ := cmd.Flags().GetBool("reveal")
+
+		homeDir, err := utils.GetHomeDir()
+		if err != nil {
+			return err
+		}
+		configManager, err := config.NewManager(homeDir)
+		if err != nil {
+			return fmt.Errorf("创建配置管理器失败: %w", err)
+		}
+
+		var configPath string
+		// 合并的配置来源，前面的覆盖后面的
+		var sources []string
+		var document interface{}
+		var secrets types.SecretValues
+		if global {
+			configPath = types.DefaultConfigPaths(homeDir).GlobalConfigFile
+			sources = []string{configPath}
+			if resolved || reveal {
+				globalConfig, err := configManager.LoadGlobal()
+				if err != nil {
+					return err
+				}
+				document, secrets = globalConfig, globalConfig.Secrets
+				if remote := globalConfig.Remote; remote != nil {
+					source := remote.Source
+					if remote.Stale {
+						source += " (远程配置不可用，使用缓存)"
+					}
+					sources = append([]string{source}, sources...)
+				}
+			}
+		} else {
+			workDir, err := os.Getwd()
+			if err != nil {
+				return err
+			}
+			projectDir, projectConfig, err := findNearestProjectConfig(configManager, workDir)
+			if err != nil {
+				return err
+			}
+			configPath = configManager.GetProjectConfigPath(projectDir)
+			sources = append([]string{configPath}, projectConfig.BaseSources...)
+			resolvedConfig := *projectConfig
+			resolvedConfig.Extends = ""
+			document, secrets = resolvedConfig, projectConfig.Secrets
+		}
+
+		// 文件原样输出，加密的值保持加密
+		if !resolved && !reveal {
+			data, err := os.ReadFile(configPath)
+			if err != nil {
+				return fmt.Errorf("读取配置失败: %w", err)
+			}
+			_, err = os.Stdout.Write(data)
+			return err
+		}
+
+		data, err := yaml.Marshal(document)
+		if err != nil {
+			return err
+		}
+		if !reveal {
+			if data, err = config.MaskSecrets(data, secrets); err != nil {
+				return err
+			}
+		}
+
+		fmt.Println("# 合并自:")
+		for _, source := range sources {
+			fmt.Printf("#   %s\n", source)
+		}
+		if len(secrets) > 0 && !reveal {
+			fmt.Printf("# %d 个加密的值已隐藏，使用 --reveal 显示\n", len(secrets))
+		}
+		_, err = os.Stdout.Write(data)
+		return err
+	},
+}
+
+// parseSettingValue 按设置项当前值的类型解析命令行给出的值
+func parseSettingValue(current interface{}, raw string) (interface{}, error) {
+	switch current.(type) {
+	case bool:
+		return strconv.ParseBool(raw)
+	case int:
+		return strconv.Atoi(raw)
+	case float64:
+		return strconv.ParseFloat(raw, 64)
+	case time.Duration:
+		return time.ParseDuration(raw)
+	case types.ByteSize:
+		return types.ParseByteSize(raw)
+	case []string:
+		// 列表由配置API按逗号拆分
+		return raw, nil
+	default:
+		return raw, nil
+	}
+}
+
+// formatSettingValue 设置项的值的显示形式
+func formatSettingValue(value interface{}) string {
+	switch v := value.(type) {
+	case []string:
+		return strings.Join(v, ",")
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func init() {
+	configCmd.AddCommand(configGetCmd)
+	configCmd.AddCommand(configSetCmd)
+	configCmd.AddCommand(configShowCmd)
+
+	configShowCmd.Flags().Bool("global", false, "显示全局配置")
+	configShowCmd.Flags().Bool("resolved", false, "显示合并 extends 继承的基础配置后的配置")
+	configShowCmd.Flags().Bool("reveal", false, "显示加密的值解密后的内容，包含 --resolved")
+}
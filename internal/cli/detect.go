@@ -0,0 +1,203 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/spf13/cobra"
+
+	"github.com/songzhibin97/vman/internal/proxy"
+	"github.com/songzhibin97/vman/pkg/types"
+)
+
+// detectCmd 根据项目类型生成项目配置
+var detectCmd = &cobra.Command{
+	Use:         "detect",
+	Annotations: mutating(),
+	Short:       "根据检测到的项目类型生成 .vman.yaml",
+	Long: `检测当前项目的类型和构建系统，推荐需要固定版本的工具并写入项目根目录的 .vman.yaml。
+
+推荐规则:
+  Go 项目        go, golangci-lint
+  Node.js 项目   node，使用 pnpm/yarn 锁文件时加上对应的包管理器
+  Python 项目    python，使用 poetry 时加上 poetry
+  Rust 项目      rust
+  Java 项目      java, gradle 或 maven
+  Kubernetes     存在清单文件时加上 kubectl，存在 Helm chart 时加上 helm
+
+package.json 的 engines 和 go.mod 的 go/toolchain 指令中声明的版本要求会被固定到
+.vman.yaml 中：有满足要求的已安装版本时写入该版本，否则写入版本约束本身。
+其他工具的版本依次取全局版本、已安装的最新版本，都没有时为 latest。
+项目配置中已经存在的工具保持不变。
+
+示例:
+  vman detect             # 显示推荐并确认后写入
+  vman detect --yes       # 不确认直接写入，适用于自动化脚本
+  vman detect --dry-run   # 只显示推荐，不写入`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		options := getUIOptions(cmd)
+
+		managers, err := createManagers()
+		if err != nil {
+			return fmt.Errorf("创建管理器失败: %w", err)
+		}
+
+		cwd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("获取当前目录失败: %w", err)
+		}
+
+		projectContext, err := proxy.NewContextManager(managers.config).DetectProjectContext(cwd)
+		if err != nil {
+			return fmt.Errorf("检测项目失败: %w", err)
+		}
+
+		projectConfig, err := managers.config.LoadProject(projectContext.RootPath)
+		if err != nil {
+			return fmt.Errorf("加载项目配置失败: %w", err)
+		}
+
+		proposals := proposeProjectTools(managers, projectContext, projectConfig)
+
+		fmt.Printf("项目目录: %s\n", projectContext.RootPath)
+		if projectContext.ProjectType != "" {
+			fmt.Printf("项目类型: %s", projectContext.ProjectType)
+			if projectContext.BuildSystem != "" {
+				fmt.Printf(" (%s)", projectContext.BuildSystem)
+			}
+			fmt.Println()
+		}
+
+		if len(proposals) == 0 {
+			fmt.Println("没有检测到可推荐的工具")
+			return nil
+		}
+
+		fmt.Println()
+		table := NewTablePrinter([]string{"TOOL", "VERSION", "REASON", "STATUS"}, options)
+		pending := 0
+		for _, p := range proposals {
+			status := ColorizeSuccess("新增", options)
+			if p.Existing {
+				status = ColorizeDim("已配置", options)
+			} else {
+				pending++
+			}
+			table.AddRow([]string{p.Tool, p.Version, p.Reason, status})
+		}
+		table.Print()
+		fmt.Println()
+
+		configPath := managers.config.GetProjectConfigPath(projectContext.RootPath)
+		if pending == 0 {
+			fmt.Printf("%s 已包含所有推荐的工具\n", configPath)
+			return nil
+		}
+		if dryRun {
+			return nil
+		}
+		confirmed, err := confirmAction(cmd, fmt.Sprintf("写入 %d 个工具到 %s?", pending, configPath))
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			fmt.Println("操作已取消")
+			return nil
+		}
+
+		if projectConfig.Tools == nil {
+			projectConfig.Tools = make(map[string]string)
+		}
+		for _, p := range proposals {
+			if !p.Existing {
+				projectConfig.Tools[p.Tool] = p.Version
+			}
+		}
+
+		if err := managers.config.SaveProject(projectContext.RootPath, projectConfig); err != nil {
+			return fmt.Errorf("保存项目配置失败: %w", err)
+		}
+
+		PrintSuccess(fmt.Sprintf("已更新 %s", configPath), options)
+		return nil
+	},
+}
+
+// toolProposal 推荐写入项目配置的工具
+type toolProposal struct {
+	proxy.ToolSuggestion
+	Version  string
+	Existing bool
+}
+
+// proposeProjectTools 为推荐的工具选择版本
+func proposeProjectTools(managers *managers, pc *proxy.ProjectContext, projectConfig *types.ProjectConfig) []toolProposal {
+	var globalVersions map[string]string
+	if globalConfig, err := managers.config.LoadGlobal(); err == nil {
+		globalVersions = globalConfig.GlobalVersions
+	}
+
+	suggestions := proxy.SuggestTools(pc)
+	for _, tool := range sortedEngineTools(pc.Engines) {
+		found := false
+		for _, s := range suggestions {
+			found = found || s.Tool == tool
+		}
+		if !found {
+			suggestions = append(suggestions, proxy.ToolSuggestion{Tool: tool})
+		}
+	}
+
+	resolver := proxy.NewVersionResolver(managers.config, managers.version)
+
+	var proposals []toolProposal
+	for _, suggestion := range suggestions {
+		proposal := toolProposal{ToolSuggestion: suggestion}
+		engine, hasEngine := pc.Engines[suggestion.Tool]
+		if hasEngine {
+			proposal.Reason = fmt.Sprintf("%s (%s)", filepath.Base(engine.Source), engine.Constraint)
+		}
+
+		if version, ok := projectConfig.Tools[suggestion.Tool]; ok {
+			proposal.Version = version
+			proposal.Existing = true
+		} else if hasEngine {
+			// 固定为满足清单文件要求的已安装版本，没有时保留约束，安装后由解析器选择
+			if version, err := resolver.ResolveConstraint(suggestion.Tool, engine.Constraint); err == nil {
+				proposal.Version = version
+			} else {
+				proposal.Version = engine.Constraint
+			}
+		} else if version := globalVersions[suggestion.Tool]; version != "" {
+			proposal.Version = version
+		} else if version, err := managers.version.GetLatestVersion(suggestion.Tool); err == nil && version != "" {
+			proposal.Version = version
+		} else {
+			proposal.Version = "latest"
+		}
+
+		proposals = append(proposals, proposal)
+	}
+
+	return proposals
+}
+
+// sortedEngineTools 按工具名排序的版本要求
+func sortedEngineTools(engines map[string]proxy.EngineConstraint) []string {
+	tools := make([]string, 0, len(engines))
+	for tool := range engines {
+		tools = append(tools, tool)
+	}
+	sort.Strings(tools)
+	return tools
+}
+
+func init() {
+	rootCmd.AddCommand(detectCmd)
+
+	detectCmd.Flags().Bool("dry-run", false, "只显示推荐，不写入配置")
+}
@@ -0,0 +1,546 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+
+	"github.com/songzhibin97/vman/internal/proxy"
+	"github.com/songzhibin97/vman/internal/server"
+	"github.com/songzhibin97/vman/internal/storage"
+	"github.com/songzhibin97/vman/pkg/types"
+	"github.com/songzhibin97/vman/pkg/utils"
+)
+
+// doctorStatus 检查结果状态
+type doctorStatus int
+
+const (
+	doctorOK doctorStatus = iota
+	doctorWarning
+	doctorError
+)
+
+// doctorResult 单项检查结果
+type doctorResult struct {
+	Name    string
+	Status  doctorStatus
+	Message string
+	Details []string
+	Hint    string
+}
+
+// doctorCheck 检查项
+type doctorCheck func(managers *managers) []doctorResult
+
+// doctorChecks 所有检查项，按顺序执行
+var doctorChecks = []doctorCheck{
+	checkDirectories,
+	checkShimsPath,
+	checkGlobalVersions,
+	checkProjectTools,
+	checkShimCollisions,
+	checkShimIntegrity,
+	checkPermissions,
+	checkFileOwners,
+}
+
+// doctorCmd 环境诊断命令
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "诊断vman运行环境",
+	Long: `检查vman运行环境中的常见问题，包括：
+- 存储目录是否存在且可写
+- shims目录是否在PATH中，以及受管工具是否被PATH中排在前面的同名文件遮蔽
+- 全局配置中的版本是否已安装
+- 当前目录的项目配置中的工具是否已安装，缺少必需的工具为错误，缺少 optional 的工具为警告
+- 工具和命令别名的垫片是否同名，或与 vman 本身同名
+- shims目录是否可写，垫片是否被删除、失去执行权限或被其他内容覆盖，是否由旧版本的 vman 生成或被手动修改过
+- 已安装的二进制和垫片是否可执行，全局配置、工具定义等可能包含凭据的文件是否只有所有者可以访问
+- vman目录中是否有不属于当前用户的文件（通常是用 sudo 运行 vman 时留下的）
+
+使用 --fix-perms 在检查前修正文件权限，--fix-shims 在检查前重新生成有问题的垫片。发现错误时命令以非零状态退出。`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		options := getUIOptions(cmd)
+
+		managers, err := createManagers()
+		if err != nil {
+			return fmt.Errorf("创建管理器失败: %w", err)
+		}
+
+		if fixPerms, _ := cmd.Flags().GetBool("fix-perms"); fixPerms {
+			if err := fixPermissions(options); err != nil {
+				cmd.SilenceUsage = true
+				return err
+			}
+		}
+
+		if fixShims, _ := cmd.Flags().GetBool("fix-shims"); fixShims {
+			if err := fixBrokenShims(options); err != nil {
+				cmd.SilenceUsage = true
+				return err
+			}
+		}
+
+		warnings, errors := runDoctorChecks(managers, options)
+
+		fmt.Println()
+		if errors == 0 && warnings == 0 {
+			PrintSuccess("没有发现问题", options)
+			return nil
+		}
+
+		fmt.Printf("发现 %d 个错误，%d 个警告\n", errors, warnings)
+		if errors > 0 {
+			cmd.SilenceUsage = true
+			return fmt.Errorf("环境检查未通过")
+		}
+		return nil
+	},
+}
+
+// runDoctorChecks 执行所有检查项并打印结果，返回警告和错误的数量
+func runDoctorChecks(managers *managers, options *UIOptions) (warnings, errors int) {
+	for _, check := range doctorChecks {
+		for _, result := range check(managers) {
+			printDoctorResult(result, options)
+			switch result.Status {
+			case doctorWarning:
+				warnings++
+			case doctorError:
+				errors++
+			}
+		}
+	}
+	return warnings, errors
+}
+
+// printDoctorResult 打印检查结果
+func printDoctorResult(result doctorResult, options *UIOptions) {
+	message := result.Name
+	if result.Message != "" {
+		message = fmt.Sprintf("%s: %s", result.Name, result.Message)
+	}
+
+	switch result.Status {
+	case doctorOK:
+		PrintSuccess(message, options)
+	case doctorWarning:
+		PrintWarning(message, options)
+	default:
+		PrintError(message, options)
+	}
+
+	for _, detail := range result.Details {
+		fmt.Printf("    %s\n", detail)
+	}
+	if result.Hint != "" {
+		fmt.Printf("    %s\n", ColorizeDim(result.Hint, options))
+	}
+}
+
+// checkDirectories 检查存储目录
+func checkDirectories(managers *managers) []doctorResult {
+	dirs := []struct{ name, path string }{
+		{"配置目录", managers.storage.GetConfigDir()},
+		{"版本目录", managers.storage.GetVersionsDir()},
+		{"临时目录", managers.storage.GetTempDir()},
+	}
+
+	var problems []string
+	for _, d := range dirs {
+		name, dir := d.name, d.path
+		info, err := os.Stat(dir)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s %s 不存在", name, dir))
+			continue
+		}
+		if !info.IsDir() {
+			problems = append(problems, fmt.Sprintf("%s %s 不是目录", name, dir))
+			continue
+		}
+		probe, err := os.CreateTemp(dir, ".vman-doctor-*")
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s %s 不可写", name, dir))
+			continue
+		}
+		probe.Close()
+		os.Remove(probe.Name())
+	}
+
+	if len(problems) > 0 {
+		return []doctorResult{{
+			Name:    "存储目录",
+			Status:  doctorError,
+			Message: "存在问题",
+			Details: problems,
+		}}
+	}
+	return []doctorResult{{Name: "存储目录", Status: doctorOK}}
+}
+
+// checkShimsPath 检查shims目录在PATH中的位置和工具优先级
+func checkShimsPath(managers *managers) []doctorResult {
+	if err := initProxy(); err != nil {
+		return []doctorResult{{Name: "PATH", Status: doctorError, Message: err.Error()}}
+	}
+
+	report, err := commandProxy.CheckPath()
+	if err != nil {
+		return []doctorResult{{Name: "PATH", Status: doctorError, Message: err.Error()}}
+	}
+
+	shellType := proxy.NewShellIntegrator().DetectShell()
+	hint := fmt.Sprintf("运行 vman path fix --shell %s 修复，或运行 vman path check 查看详情", shellType)
+
+	if !report.ShimsInPath {
+		return []doctorResult{{
+			Name:    "PATH",
+			Status:  doctorError,
+			Message: fmt.Sprintf("shims目录 %s 不在PATH中", report.ShimsDir),
+			Hint:    hint,
+		}}
+	}
+
+	var shadowed []string
+	for _, tool := range report.Tools {
+		if tool.Shadowed {
+			shadowed = append(shadowed, fmt.Sprintf("%s: 实际执行 %s", tool.Tool, tool.Effective))
+		}
+	}
+
+	if len(shadowed) > 0 {
+		return []doctorResult{{
+			Name:    "PATH",
+			Status:  doctorError,
+			Message: fmt.Sprintf("%d 个工具被PATH中排在shims目录前面的文件遮蔽", len(shadowed)),
+			Details: shadowed,
+			Hint:    hint,
+		}}
+	}
+
+	return []doctorResult{{
+		Name:    "PATH",
+		Status:  doctorOK,
+		Message: fmt.Sprintf("shims目录位于PATH第 %d 项", report.ShimsIndex+1),
+	}}
+}
+
+// checkGlobalVersions 检查全局版本是否已安装
+func checkGlobalVersions(managers *managers) []doctorResult {
+	globalConfig, err := managers.config.LoadGlobal()
+	if err != nil {
+		return []doctorResult{{Name: "全局配置", Status: doctorError, Message: err.Error()}}
+	}
+
+	var missing, details []string
+	for tool, version := range globalConfig.GlobalVersions {
+		if version != "" && !managers.version.IsVersionInstalled(tool, version) {
+			missing = append(missing, fmt.Sprintf("%s@%s", tool, version))
+
+			// 共享版本目录中其他平台安装的版本对当前平台不可用
+			if foreign, err := managers.storage.GetForeignVersions(tool); err == nil && len(foreign[version]) > 0 {
+				details = append(details, fmt.Sprintf("%s@%s 只为 %s 安装，当前平台为 %s",
+					tool, version, strings.Join(foreign[version], ", "), types.PlatformDirName()))
+			}
+		}
+	}
+
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		sort.Strings(details)
+		return []doctorResult{{
+			Name:    "全局版本",
+			Status:  doctorWarning,
+			Message: "以下版本已配置但未安装: " + strings.Join(missing, ", "),
+			Details: details,
+			Hint:    "运行 vman install <tool> <version> 安装",
+		}}
+	}
+	return []doctorResult{{Name: "全局版本", Status: doctorOK}}
+}
+
+// checkProjectTools 检查当前目录的项目配置中的工具是否已安装，没有项目配置时不检查
+func checkProjectTools(managers *managers) []doctorResult {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil
+	}
+	requirements, err := projectToolRequirements(managers.config, cwd)
+	if err != nil {
+		return []doctorResult{{Name: "项目工具", Status: doctorError, Message: err.Error()}}
+	}
+	if len(requirements.Versions) == 0 {
+		return nil
+	}
+
+	var required, optional []string
+	for tool, requested := range requirements.Versions {
+		installed, _ := managers.version.ListVersions(tool)
+		if requested == "" || requested == "latest" {
+			if len(installed) > 0 {
+				continue
+			}
+		} else if matchInstalledVersion(installed, requested) != "" {
+			continue
+		}
+		if requirements.Optional[tool] {
+			optional = append(optional, tool+"@"+requested)
+		} else {
+			required = append(required, tool+"@"+requested)
+		}
+	}
+	sort.Strings(required)
+	sort.Strings(optional)
+
+	var results []doctorResult
+	if len(required) > 0 {
+		results = append(results, doctorResult{
+			Name:    "项目工具",
+			Status:  doctorError,
+			Message: "以下必需的工具未安装: " + strings.Join(required, ", "),
+			Hint:    "运行 vman install 安装项目配置中的工具",
+		})
+	}
+	if len(optional) > 0 {
+		results = append(results, doctorResult{
+			Name:    "项目工具",
+			Status:  doctorWarning,
+			Message: "以下可选的工具未安装: " + strings.Join(optional, ", "),
+			Hint:    "运行 vman install 安装，或在需要时使用 vman install --group <分组>",
+		})
+	}
+	if len(results) == 0 {
+		return []doctorResult{{Name: "项目工具", Status: doctorOK, Message: fmt.Sprintf("%d 个工具均已安装", len(requirements.Versions))}}
+	}
+	return results
+}
+
+// checkShimCollisions 检查垫片名称冲突
+func checkShimCollisions(managers *managers) []doctorResult {
+	if err := initProxy(); err != nil {
+		return []doctorResult{{Name: "垫片名称", Status: doctorError, Message: err.Error()}}
+	}
+
+	collisions, err := commandProxy.CheckShimCollisions()
+	if err != nil {
+		return []doctorResult{{Name: "垫片名称", Status: doctorError, Message: err.Error()}}
+	}
+	if len(collisions) == 0 {
+		return []doctorResult{{Name: "垫片名称", Status: doctorOK}}
+	}
+
+	var details []string
+	for _, collision := range collisions {
+		details = append(details, collision.String())
+	}
+	return []doctorResult{{
+		Name:    "垫片名称",
+		Status:  doctorError,
+		Message: fmt.Sprintf("%d 个垫片名称冲突，冲突的垫片不会生成", len(collisions)),
+		Details: details,
+		Hint:    "在工具定义中设置 shim = \"<名称>\" 改名，或使用 vman alias remove 删除冲突的别名",
+	}}
+}
+
+// shimIssueNames 垫片问题的描述
+var shimIssueNames = map[string]string{
+	proxy.ShimMissing:       "缺失",
+	proxy.ShimNotExecutable: "不可执行",
+	proxy.ShimBroken:        "已损坏",
+	proxy.ShimOutdated:      "由旧版本的 vman 生成",
+	proxy.ShimModified:      "被手动修改过",
+}
+
+// checkShimIntegrity 检查shims目录是否可写，以及垫片是否缺失或损坏
+func checkShimIntegrity(managers *managers) []doctorResult {
+	if err := initProxy(); err != nil {
+		return []doctorResult{{Name: "垫片完整性", Status: doctorError, Message: err.Error()}}
+	}
+
+	report, err := commandProxy.CheckShims()
+	if err != nil {
+		return []doctorResult{{Name: "垫片完整性", Status: doctorError, Message: err.Error()}}
+	}
+
+	var results []doctorResult
+	if report.DirIssue != "" {
+		results = append(results, doctorResult{
+			Name:    "垫片完整性",
+			Status:  doctorError,
+			Message: fmt.Sprintf("shims目录 %s %s", report.ShimsDir, report.DirIssue),
+			Hint:    "检查目录的所有者和权限，不可写时无法生成或修复垫片",
+		})
+	}
+	// 过时和被修改的垫片仍然可以使用，作为警告报告
+	var broken, stale []string
+	for _, problem := range report.Problems {
+		detail := fmt.Sprintf("%s: %s (%s)", problem.Entry.Name, shimIssueNames[problem.Issue], problem.Entry)
+		if problem.Issue == proxy.ShimOutdated || problem.Issue == proxy.ShimModified {
+			stale = append(stale, detail)
+		} else {
+			broken = append(broken, detail)
+		}
+	}
+	if len(broken) > 0 {
+		results = append(results, doctorResult{
+			Name:    "垫片完整性",
+			Status:  doctorError,
+			Message: fmt.Sprintf("%d 个垫片缺失或损坏，对应的命令无法使用", len(broken)),
+			Details: broken,
+			Hint:    "运行 vman doctor --fix-shims 或 vman proxy rehash 重新生成",
+		})
+	}
+	if len(stale) > 0 {
+		results = append(results, doctorResult{
+			Name:    "垫片完整性",
+			Status:  doctorWarning,
+			Message: fmt.Sprintf("%d 个垫片由旧版本的 vman 生成或被手动修改过，行为可能与当前版本不同", len(stale)),
+			Details: stale,
+			Hint:    "运行 vman doctor --fix-shims 或 vman proxy rehash 重新生成（会覆盖手动修改）",
+		})
+	}
+	if len(results) == 0 {
+		return []doctorResult{{Name: "垫片完整性", Status: doctorOK}}
+	}
+	return results
+}
+
+// fixBrokenShims 重新生成缺失、损坏、过时或被修改的垫片
+func fixBrokenShims(options *UIOptions) error {
+	if err := initProxy(); err != nil {
+		return err
+	}
+	report, err := commandProxy.CheckShims()
+	if err != nil {
+		return err
+	}
+	if len(report.Problems) == 0 {
+		return nil
+	}
+	if err := commandProxy.RepairShims(report.Problems); err != nil {
+		return fmt.Errorf("重新生成垫片失败: %w", err)
+	}
+	PrintSuccess(fmt.Sprintf("已重新生成 %d 个垫片", len(report.Problems)), options)
+	return nil
+}
+
+// permissionIssues 检查vman管理的文件权限
+func permissionIssues() ([]storage.PermissionIssue, error) {
+	homeDir, err := utils.GetHomeDir()
+	if err != nil {
+		return nil, err
+	}
+	paths := types.DefaultConfigPaths(homeDir)
+	return storage.CheckPermissions(afero.NewOsFs(), paths, filepath.Join(paths.ConfigDir, server.TokenFileName))
+}
+
+// checkPermissions 检查文件权限
+func checkPermissions(managers *managers) []doctorResult {
+	issues, err := permissionIssues()
+	if err != nil {
+		return []doctorResult{{Name: "文件权限", Status: doctorError, Message: err.Error()}}
+	}
+	if len(issues) == 0 {
+		return []doctorResult{{Name: "文件权限", Status: doctorOK}}
+	}
+
+	var executable, private int
+	var details []string
+	for _, issue := range issues {
+		if issue.Kind == storage.PermissionExecutable {
+			executable++
+		} else {
+			private++
+		}
+		details = append(details, issue.String())
+	}
+	var problems []string
+	if executable > 0 {
+		problems = append(problems, fmt.Sprintf("%d 个二进制或垫片不可执行", executable))
+	}
+	if private > 0 {
+		problems = append(problems, fmt.Sprintf("%d 个可能包含凭据的文件可被其他用户读取", private))
+	}
+	return []doctorResult{{
+		Name:    "文件权限",
+		Status:  doctorWarning,
+		Message: strings.Join(problems, "，"),
+		Details: details,
+		Hint:    "运行 vman doctor --fix-perms 修复",
+	}}
+}
+
+// checkFileOwners 检查vman目录中不属于当前用户的文件
+func checkFileOwners(managers *managers) []doctorResult {
+	euid := os.Geteuid()
+	if euid < 0 {
+		return nil
+	}
+	homeDir, err := utils.GetHomeDir()
+	if err != nil {
+		return []doctorResult{{Name: "文件所有者", Status: doctorError, Message: err.Error()}}
+	}
+	paths := types.DefaultConfigPaths(homeDir)
+	dirs := []string{paths.ConfigDir}
+	if paths.DataDir != paths.ConfigDir {
+		dirs = append(dirs, paths.DataDir)
+	}
+
+	const maxDetails = 10
+	foreign, err := storage.FindForeignFiles(afero.NewOsFs(), euid, maxDetails+1, dirs...)
+	if err != nil {
+		return []doctorResult{{Name: "文件所有者", Status: doctorError, Message: err.Error()}}
+	}
+	if len(foreign) == 0 {
+		return []doctorResult{{Name: "文件所有者", Status: doctorOK}}
+	}
+
+	message := fmt.Sprintf("%d 个文件不属于当前用户，vman 可能无法修改它们", len(foreign))
+	owner := strconv.Itoa(euid)
+	if current, err := user.Current(); err == nil {
+		owner = current.Username
+	}
+	if len(foreign) > maxDetails {
+		message = fmt.Sprintf("超过 %d 个文件不属于当前用户，vman 可能无法修改它们", maxDetails)
+		foreign = foreign[:maxDetails]
+	}
+	return []doctorResult{{
+		Name:    "文件所有者",
+		Status:  doctorWarning,
+		Message: message,
+		Details: foreign,
+		Hint:    fmt.Sprintf("通常是用 sudo 运行 vman 造成的，运行 sudo chown -R %s %s 修复", owner, strings.Join(dirs, " ")),
+	}}
+}
+
+// fixPermissions 修正不符合权限策略的文件
+func fixPermissions(options *UIOptions) error {
+	issues, err := permissionIssues()
+	if err != nil {
+		return err
+	}
+	if len(issues) == 0 {
+		return nil
+	}
+	if err := storage.FixPermissions(afero.NewOsFs(), issues); err != nil {
+		return fmt.Errorf("修正文件权限失败: %w", err)
+	}
+	PrintSuccess(fmt.Sprintf("已修正 %d 个文件的权限", len(issues)), options)
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+
+	doctorCmd.Flags().Bool("fix-perms", false, "修正二进制、垫片和包含凭据的文件的权限")
+	doctorCmd.Flags().Bool("fix-shims", false, "重新生成缺失、损坏、过时或被手动修改的垫片")
+}
@@ -3,11 +3,21 @@ package cli
 import (
 	"context"
 	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/Masterminds/semver/v3"
+	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
 
+	"github.com/songzhibin97/vman/internal/config"
 	"github.com/songzhibin97/vman/internal/download"
+	"github.com/songzhibin97/vman/internal/proxy"
+	"github.com/songzhibin97/vman/internal/storage"
 	"github.com/songzhibin97/vman/internal/version"
 	"github.com/songzhibin97/vman/pkg/types"
 )
@@ -24,48 +34,91 @@ func init() {
 }
 
 var installCmd = &cobra.Command{
-	Use:   "install <tool> [version]",
-	Short: "安装工具版本",
+	Use:         "install <tool>[@version] [version]",
+	Annotations: mutating(),
+	Short:       "安装工具版本",
 	Long: `自动下载并安装指定工具的版本。如果不指定版本，则安装最新版本。
+版本也可以用 <tool>@<version> 的形式给出。没有工具定义时使用该工具的recipe（见 vman recipes）。
 
-示例:
-  vman install kubectl 1.29.0    # 安装指定版本
-  vman install kubectl           # 安装最新版本
-  vman install terraform         # 安装最新版本`,
-	Args: cobra.RangeArgs(1, 2),
+配置了系统级共享存储（settings.system.root 或 VMAN_SYSTEM_ROOT）后，
+管理员可使用 --system 将版本安装到共享存储，所有用户均可使用。
+
+不指定工具时安装当前目录的项目配置（.vman.yaml、.vman-version）中的所有工具，
+版本要求为范围时安装满足要求的最高版本。每个工具安装完成后记录进度，
+中途失败时运行 vman resume 从失败的工具继续。
+--group 只安装项目配置 groups 中指定分组的工具；标记为 optional 的工具安装失败时只警告，
+不影响退出状态，--required-only 跳过这些工具。
+
+--plan 只输出安装计划，不下载和安装：每个工具解析得到的版本、选择的安装包和下载地址、
+安装包大小（下载信息中没有时发送 HEAD 请求查询）、是否使用下载缓存，以及估算需要的磁盘空间。
+加上 --json 输出JSON，便于在 CI 中审查变更；有工具无法安装时退出状态非零。`,
+	Args: cobra.RangeArgs(0, 2),
 	RunE: func(cmd *cobra.Command, args []string) error {
+		groups, _ := cmd.Flags().GetStringSlice("group")
+		requiredOnly, _ := cmd.Flags().GetBool("required-only")
+		if len(args) > 0 && (len(groups) > 0 || requiredOnly) {
+			return fmt.Errorf("--group 和 --required-only 只能在安装项目配置中的工具时使用")
+		}
+		if plan, _ := cmd.Flags().GetBool("plan"); plan {
+			return runInstallPlan(cmd, args, groups, requiredOnly)
+		}
+		if len(args) == 0 {
+			system, _ := cmd.Flags().GetBool("system")
+			return runProjectInstall(cmd, system, groups, requiredOnly)
+		}
 		tool := args[0]
 		var versionStr string
 
+		// 支持 tool@version 形式
+		if name, version, ok := strings.Cut(tool, "@"); ok && len(args) == 1 {
+			tool = name
+			args = []string{name}
+			if version != "" {
+				args = append(args, version)
+			}
+		}
+
 		// 获取选项
 		force, _ := cmd.Flags().GetBool("force")
 		global, _ := cmd.Flags().GetBool("global")
+		system, _ := cmd.Flags().GetBool("system")
+		options := getUIOptions(cmd)
 
 		// 创建集成管理器
-		integratedManager, err := createIntegratedManager()
+		integratedManager, err := createIntegratedManagerForStore(system)
 		if err != nil {
 			return fmt.Errorf("创建管理器失败: %w", err)
 		}
+		managers, err := createManagersForStore(system)
+		if err != nil {
+			return fmt.Errorf("创建管理器失败: %w", err)
+		}
+
+		// 工具的垫片不能覆盖其他工具、别名或vman本身
+		if err := checkToolShimName(tool); err != nil {
+			cmd.SilenceUsage = true
+			return err
+		}
 
 		// 确定版本
 		if len(args) == 2 {
 			versionStr = args[1]
 		} else {
 			// 安装最新版本
-			fmt.Printf("正在获取 %s 的最新版本...\n", tool)
+			Infof(options, "正在获取 %s 的最新版本...\n", tool)
 			latestVersion, err := integratedManager.InstallLatestVersion(tool)
 			if err != nil {
 				return fmt.Errorf("安装最新版本失败: %w", err)
 			}
 			versionStr = latestVersion
-			fmt.Printf("成功安装最新版本: %s@%s\n", tool, versionStr)
+			Infof(options, "成功安装最新版本: %s@%s\n", tool, versionStr)
 
 			// 设置为全局版本（如果指定）
 			if global {
-				if err := integratedManager.SetGlobalVersion(tool, versionStr); err != nil {
+				if err := setGlobalVersionWithHistory(integratedManager, managers.config, tool, versionStr); err != nil {
 					fmt.Printf("警告: 设置全局版本失败: %v\n", err)
 				} else {
-					fmt.Printf("设置 %s@%s 为全局版本\n", tool, versionStr)
+					Infof(options, "设置 %s@%s 为全局版本\n", tool, versionStr)
 				}
 			}
 			return nil
@@ -73,47 +126,38 @@ var installCmd = &cobra.Command{
 
 		// 检查版本是否已安装
 		if !force && integratedManager.IsVersionInstalled(tool, versionStr) {
-			fmt.Printf("版本 %s@%s 已安装\n", tool, versionStr)
+			Infof(options, "版本 %s@%s 已安装\n", tool, versionStr)
 
 			// 设置为全局版本（如果指定）
 			if global {
-				if err := integratedManager.SetGlobalVersion(tool, versionStr); err != nil {
+				if err := setGlobalVersionWithHistory(integratedManager, managers.config, tool, versionStr); err != nil {
 					fmt.Printf("警告: 设置全局版本失败: %v\n", err)
 				} else {
-					fmt.Printf("设置 %s@%s 为全局版本\n", tool, versionStr)
+					Infof(options, "设置 %s@%s 为全局版本\n", tool, versionStr)
 				}
 			}
 			return nil
 		}
 
 		// 安装版本（带进度）
-		fmt.Printf("正在安装 %s@%s...\n", tool, versionStr)
-
-		// 进度回调
-		progressCallback := func(info *types.ProgressInfo) {
-			if info.Total > 0 {
-				fmt.Printf("\r下载进度: %.1f%% (%s) - %s",
-					info.Percentage,
-					formatBytes(info.Downloaded),
-					info.Status)
-			} else {
-				fmt.Printf("\r%s", info.Status)
-			}
-		}
+		Infof(options, "正在安装 %s@%s...\n", tool, versionStr)
 
-		if err := integratedManager.InstallVersionWithProgress(tool, versionStr, progressCallback); err != nil {
-			fmt.Println() // 换行
+		// 静默模式下不显示进度，输出不是终端时定期输出心跳行
+		progress := startInstallProgress(tool+"@"+versionStr, options)
+		err = integratedManager.InstallVersionWithProgress(tool, versionStr, progress.Callback())
+		progress.Finish(err)
+		if err != nil {
 			return fmt.Errorf("安装失败: %w", err)
 		}
 
-		fmt.Printf("\n成功安装 %s@%s\n", tool, versionStr)
+		Infof(options, "成功安装 %s@%s\n", tool, versionStr)
 
 		// 设置为全局版本（如果指定）
 		if global {
-			if err := integratedManager.SetGlobalVersion(tool, versionStr); err != nil {
+			if err := setGlobalVersionWithHistory(integratedManager, managers.config, tool, versionStr); err != nil {
 				fmt.Printf("警告: 设置全局版本失败: %v\n", err)
 			} else {
-				fmt.Printf("设置 %s@%s 为全局版本\n", tool, versionStr)
+				Infof(options, "设置 %s@%s 为全局版本\n", tool, versionStr)
 			}
 		}
 
@@ -121,17 +165,351 @@ var installCmd = &cobra.Command{
 	},
 }
 
+// runProjectInstall 安装当前目录的项目配置中的工具，每个工具为一个可继续的步骤
+// groups 不为空时只安装这些分组的工具，requiredOnly 时跳过可选的工具
+func runProjectInstall(cmd *cobra.Command, system bool, groups []string, requiredOnly bool) error {
+	cmd.SilenceUsage = true
+	managers, err := createManagersForStore(system)
+	if err != nil {
+		return fmt.Errorf("创建管理器失败: %w", err)
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("获取当前目录失败: %w", err)
+	}
+
+	requirements, err := projectToolRequirements(managers.config, cwd)
+	if err != nil {
+		return err
+	}
+	if len(requirements.Versions) == 0 {
+		fmt.Println("当前目录的项目配置中没有配置工具")
+		return nil
+	}
+
+	tools, err := requirements.Select(groups, requiredOnly)
+	if err != nil {
+		return err
+	}
+	if len(tools) == 0 {
+		fmt.Println("没有需要安装的工具")
+		return nil
+	}
+
+	journal, err := newJournal("install")
+	if err != nil {
+		return err
+	}
+	journal.Options["system"] = strconv.FormatBool(system)
+	for _, tool := range tools {
+		params := map[string]string{"tool": tool, "version": requirements.Versions[tool]}
+		if requirements.Optional[tool] {
+			params["optional"] = "true"
+		}
+		journal.AddStep(tool+"@"+requirements.Versions[tool], params)
+	}
+	return runJournal(cmd, journal)
+}
+
+// installStepExecutor 执行 vman install 操作日志中的步骤
+func installStepExecutor(cmd *cobra.Command, journal *storage.Journal) (func(step *storage.JournalStep) error, error) {
+	integratedManager, err := createIntegratedManagerForStore(journal.Options["system"] == "true")
+	if err != nil {
+		return nil, fmt.Errorf("创建管理器失败: %w", err)
+	}
+	return func(step *storage.JournalStep) error {
+		err := installRequiredVersion(integratedManager, step.Params["tool"], step.Params["version"], getUIOptions(cmd))
+		if err != nil && step.Params["optional"] == "true" {
+			// 可选的工具安装失败不中断其他工具的安装
+			PrintWarning(fmt.Sprintf("安装可选工具 %s 失败: %v", step.Name, err), getUIOptions(cmd))
+			return nil
+		}
+		return err
+	}, nil
+}
+
+// installRequiredVersion 安装满足版本要求的版本，已安装满足要求的版本时跳过
+func installRequiredVersion(versionManager version.Manager, tool, requested string, options *UIOptions) error {
+	if requested == "" || requested == "latest" {
+		Infof(options, "正在安装 %s 的最新版本...\n", tool)
+		installed, err := versionManager.InstallLatestVersion(tool)
+		if err != nil {
+			return err
+		}
+		Infof(options, "成功安装 %s@%s\n", tool, installed)
+		return nil
+	}
+
+	target, installed, err := resolveRequiredVersion(versionManager, tool, requested)
+	if err != nil {
+		return err
+	}
+	if installed {
+		if isVersionConstraint(requested) {
+			Infof(options, "%s@%s 已安装，满足 %s\n", tool, target, requested)
+		} else {
+			Infof(options, "%s@%s 已安装\n", tool, target)
+		}
+		return nil
+	}
+
+	Infof(options, "正在安装 %s@%s...\n", tool, target)
+	progress := startInstallProgress(tool+"@"+target, options)
+	err = versionManager.InstallVersionWithProgress(tool, target, progress.Callback())
+	progress.Finish(err)
+	if err != nil {
+		return err
+	}
+	Infof(options, "成功安装 %s@%s\n", tool, target)
+	return nil
+}
+
+// resolveRequiredVersion 将版本要求解析为要安装的版本，installed 表示已安装满足要求的版本
+// 版本要求为空或 latest 时为最新的稳定版本，范围要求优先使用已安装的满足要求的最高版本
+func resolveRequiredVersion(versionManager version.Manager, tool, requested string) (string, bool, error) {
+	if requested == "" || requested == "latest" {
+		available, err := versionManager.SearchAvailableVersions(tool)
+		if err != nil {
+			return "", false, err
+		}
+		if len(available) == 0 {
+			return "", false, fmt.Errorf("未找到 %s 的可用版本", tool)
+		}
+		latest := available[0].Version
+		for _, info := range available {
+			if !info.IsPrerelease {
+				latest = info.Version
+				break
+			}
+		}
+		return latest, versionManager.IsVersionInstalled(tool, latest), nil
+	}
+
+	if !isVersionConstraint(requested) {
+		return requested, versionManager.IsVersionInstalled(tool, requested), nil
+	}
+
+	constraint, err := semver.NewConstraint(requested)
+	if err != nil {
+		return "", false, fmt.Errorf("无效的版本要求 %s: %w", requested, err)
+	}
+	installed, _ := versionManager.GetInstalledVersions(tool)
+	if version := highestMatchingVersion(installed, constraint); version != "" {
+		return version, true, nil
+	}
+
+	available, err := versionManager.SearchAvailableVersions(tool)
+	if err != nil {
+		return "", false, err
+	}
+	var candidates []string
+	for _, info := range available {
+		if !info.IsPrerelease {
+			candidates = append(candidates, info.Version)
+		}
+	}
+	target := highestMatchingVersion(candidates, constraint)
+	if target == "" {
+		return "", false, fmt.Errorf("没有满足 %s 的 %s 版本", requested, tool)
+	}
+	return target, false, nil
+}
+
+// isVersionConstraint 判断版本要求是否为范围而不是精确版本
+func isVersionConstraint(requested string) bool {
+	return strings.ContainsAny(requested, "~^<>=*, |") || strings.HasSuffix(requested, ".x")
+}
+
+// highestMatchingVersion 返回满足约束的最高版本，没有时返回空
+func highestMatchingVersion(versions []string, constraint *semver.Constraints) string {
+	var best *semver.Version
+	result := ""
+	for _, v := range versions {
+		parsed, err := semver.NewVersion(v)
+		if err != nil || !constraint.Check(parsed) {
+			continue
+		}
+		if best == nil || parsed.GreaterThan(best) {
+			best = parsed
+			result = v
+		}
+	}
+	return result
+}
+
+// projectRequirements 在某个目录生效的项目工具版本要求
+type projectRequirements struct {
+	Versions map[string]string
+	// Optional 标记为可选的工具，缺少或安装失败时只警告
+	Optional map[string]bool
+	// Groups 工具分组，同名分组较近的配置优先
+	Groups map[string][]string
+}
+
+// Select 返回要安装的工具，groups 不为空时只包括这些分组的工具，requiredOnly 时跳过可选的工具
+func (r *projectRequirements) Select(groups []string, requiredOnly bool) ([]string, error) {
+	selected := make(map[string]bool)
+	for _, group := range groups {
+		tools, err := r.Group(group)
+		if err != nil {
+			return nil, err
+		}
+		for _, tool := range tools {
+			selected[tool] = true
+		}
+	}
+
+	tools := make([]string, 0, len(r.Versions))
+	for tool := range r.Versions {
+		if len(groups) > 0 && !selected[tool] {
+			continue
+		}
+		if requiredOnly && r.Optional[tool] {
+			continue
+		}
+		tools = append(tools, tool)
+	}
+	sort.Strings(tools)
+	return tools, nil
+}
+
+// Group 返回分组中配置了版本要求的工具
+func (r *projectRequirements) Group(name string) ([]string, error) {
+	members, ok := r.Groups[name]
+	if !ok {
+		groups := make([]string, 0, len(r.Groups))
+		for group := range r.Groups {
+			groups = append(groups, group)
+		}
+		sort.Strings(groups)
+		if len(groups) == 0 {
+			return nil, fmt.Errorf("项目配置中没有定义分组 %s", name)
+		}
+		return nil, fmt.Errorf("项目配置中没有定义分组 %s，可用的分组: %s", name, strings.Join(groups, ", "))
+	}
+	var tools []string
+	for _, tool := range members {
+		if _, ok := r.Versions[tool]; ok {
+			tools = append(tools, tool)
+		}
+	}
+	return tools, nil
+}
+
+// projectToolRequirements 从 dir 向上查找 .vman-version 和 .vman.yaml 中在 dir 生效的工具版本要求
+// 与版本解析的顺序一致，较近的配置优先，同一目录中 .vman-version 优先
+func projectToolRequirements(configManager config.Manager, dir string) (*projectRequirements, error) {
+	dir = canonicalDir(dir)
+	requirements := &projectRequirements{
+		Versions: make(map[string]string),
+		Optional: make(map[string]bool),
+		Groups:   make(map[string][]string),
+	}
+	fs := afero.NewOsFs()
+	versionFiles := ecosystemVersionFiles(configManager)
+	for current := dir; ; {
+		versions, err := readVersionFile(filepath.Join(current, ".vman-version"))
+		if err != nil {
+			return nil, err
+		}
+		for tool, v := range versions {
+			if _, ok := requirements.Versions[tool]; !ok {
+				requirements.Versions[tool] = v
+			}
+		}
+
+		if _, err := os.Stat(configManager.GetProjectConfigPath(current)); err == nil {
+			projectConfig, err := configManager.LoadProject(current)
+			if err != nil {
+				return nil, err
+			}
+			relPath, _ := filepath.Rel(current, dir)
+			workspace, _ := config.ActiveWorkspace(fs, dir, current)
+			tools := make(map[string]bool)
+			for tool := range projectConfig.Tools {
+				tools[tool] = true
+			}
+			for _, pathTools := range projectConfig.Paths {
+				for tool := range pathTools {
+					tools[tool] = true
+				}
+			}
+			for tool := range tools {
+				if _, ok := requirements.Versions[tool]; ok {
+					continue
+				}
+				if v, ok := projectConfig.ToolVersionForWorkspace(tool, workspace); ok {
+					requirements.Versions[tool] = v
+				} else if v, pattern, ok := projectConfig.ToolVersionForPath(tool, filepath.ToSlash(relPath)); ok {
+					requirements.Versions[tool] = v
+					if pattern == "" && projectConfig.IsOptional(tool) {
+						requirements.Optional[tool] = true
+					}
+				}
+			}
+			for group, members := range projectConfig.Groups {
+				if _, ok := requirements.Groups[group]; !ok {
+					requirements.Groups[group] = members
+				}
+			}
+		}
+
+		// .nvmrc 等生态版本文件，只包含已定义的工具，其他工具的版本文件可能由 pyenv 等工具管理
+		for tool, files := range versionFiles {
+			if _, ok := requirements.Versions[tool]; ok {
+				continue
+			}
+			if v, _ := proxy.ReadEcosystemVersion(fs, current, files); v != "" {
+				if _, err := configManager.LoadToolConfig(tool); err == nil {
+					requirements.Versions[tool] = v
+				}
+			}
+		}
+
+		parent := filepath.Dir(current)
+		if parent == current {
+			return requirements, nil
+		}
+		current = parent
+	}
+}
+
+// ecosystemVersionFiles 各工具兼容读取的生态版本文件，合并默认映射和 settings.resolution.version_files
+func ecosystemVersionFiles(configManager config.Manager) map[string][]string {
+	resolution := types.ResolutionSettings{}
+	if globalConfig, err := configManager.LoadGlobal(); err == nil {
+		resolution = globalConfig.Settings.Resolution
+	}
+	files := make(map[string][]string)
+	for tool := range types.DefaultVersionFiles {
+		files[tool] = resolution.VersionFilesFor(tool)
+	}
+	for tool := range resolution.VersionFiles {
+		files[tool] = resolution.VersionFilesFor(tool)
+	}
+	return files
+}
+
 var updateCmd = &cobra.Command{
-	Use:   "update <tool>",
-	Short: "更新工具到最新版本",
+	Use:         "update <tool>",
+	Annotations: mutating(),
+	Aliases:     []string{"upgrade"},
+	Short:       "更新工具到最新版本",
 	Long: `更新指定工具到最新版本。
 
+使用 --delta 时优先下载从当前版本安装包升级的 bsdiff 补丁，而不是完整安装包，
+补丁来自镜像（vman mirror --deltas）或工具定义中的 delta_url_template。
+应用补丁需要下载缓存中保留的当前版本安装包，并按下载源的校验和验证生成的安装包；
+没有补丁、旧安装包或校验和时下载完整安装包。使用 --delta 更新后新版本的安装包会保留在缓存中。
+
 示例:
   vman update kubectl
-  vman update terraform`,
+  vman update terraform
+  vman upgrade kubectl --delta`,
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		tool := args[0]
+		delta, _ := cmd.Flags().GetBool("delta")
 
 		// 创建集成管理器
 		integratedManager, err := createIntegratedManager()
@@ -139,14 +517,19 @@ var updateCmd = &cobra.Command{
 			return fmt.Errorf("创建管理器失败: %w", err)
 		}
 
-		fmt.Printf("正在更新 %s...\n", tool)
+		Infof(getUIOptions(cmd), "正在更新 %s...\n", tool)
 
-		newVersion, err := integratedManager.UpdateTool(tool)
+		var newVersion string
+		if integManager, ok := integratedManager.(*version.IntegratedManager); ok && delta {
+			newVersion, err = integManager.UpdateToolWithDelta(tool)
+		} else {
+			newVersion, err = integratedManager.UpdateTool(tool)
+		}
 		if err != nil {
 			return fmt.Errorf("更新失败: %w", err)
 		}
 
-		fmt.Printf("成功更新到版本: %s\n", newVersion)
+		PrintSuccess(fmt.Sprintf("成功更新到版本: %s", newVersion), getUIOptions(cmd))
 		return nil
 	},
 }
@@ -173,7 +556,7 @@ var searchCmd = &cobra.Command{
 			return fmt.Errorf("创建管理器失败: %w", err)
 		}
 
-		fmt.Printf("正在搜索 %s 的可用版本...\n", tool)
+		Infof(getUIOptions(cmd), "正在搜索 %s 的可用版本...\n", tool)
 
 		versions, err := integratedManager.SearchAvailableVersions(tool)
 		if err != nil {
@@ -242,8 +625,9 @@ var searchCmd = &cobra.Command{
 }
 
 var addSourceCmd = &cobra.Command{
-	Use:   "add-source <tool>",
-	Short: "添加工具的下载源配置",
+	Use:         "add-source <tool>",
+	Annotations: mutating(),
+	Short:       "添加工具的下载源配置",
 	Long: `为工具添加下载源配置。支持GitHub、直接URL等多种类型。
 
 示例:
@@ -251,7 +635,10 @@ var addSourceCmd = &cobra.Command{
   vman add-source kubectl --type github --repo kubernetes/kubernetes --pattern "kubernetes-client-{os}-{arch}.tar.gz"
   
   # 直接URL源  
-  vman add-source terraform --type direct --url "https://releases.hashicorp.com/terraform/{version}/terraform_{version}_{os}_{arch}.zip"`,
+  vman add-source terraform --type direct --url "https://releases.hashicorp.com/terraform/{version}/terraform_{version}_{os}_{arch}.zip"
+
+  # 工具名与其他工具冲突时，为垫片指定其他名称
+  vman add-source list --type github --repo example/list --shim list-cli`,
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		tool := args[0]
@@ -262,6 +649,7 @@ var addSourceCmd = &cobra.Command{
 		pattern, _ := cmd.Flags().GetString("pattern")
 		urlTemplate, _ := cmd.Flags().GetString("url")
 		description, _ := cmd.Flags().GetString("description")
+		shim, _ := cmd.Flags().GetString("shim")
 
 		if sourceType == "" {
 			return fmt.Errorf("必须指定 --type")
@@ -271,11 +659,19 @@ var addSourceCmd = &cobra.Command{
 		metadata := &types.ToolMetadata{
 			Name:        tool,
 			Description: description,
+			Shim:        shim,
 			DownloadConfig: types.DownloadConfig{
 				Type: sourceType,
 			},
 		}
 
+		// 工具的垫片不能覆盖其他工具、别名或vman本身
+		entry := proxy.ShimEntry{Name: metadata.ShimName(), Kind: proxy.ShimKindTool, Target: tool}
+		if err := checkShimName(entry); err != nil {
+			cmd.SilenceUsage = true
+			return err
+		}
+
 		// 根据类型设置配置
 		switch sourceType {
 		case "github":
@@ -349,8 +745,9 @@ var listSourcesCmd = &cobra.Command{
 }
 
 var removeSourceCmd = &cobra.Command{
-	Use:   "remove-source <tool>",
-	Short: "移除工具的下载源配置",
+	Use:         "remove-source <tool>",
+	Annotations: mutating(),
+	Short:       "移除工具的下载源配置",
 	Long: `移除指定工具的下载源配置。
 
 示例:
@@ -377,17 +774,19 @@ var removeSourceCmd = &cobra.Command{
 
 // createIntegratedManager 创建集成管理器
 func createIntegratedManager() (version.Manager, error) {
+	return createIntegratedManagerForStore(false)
+}
+
+// createIntegratedManagerForStore 创建集成管理器，system 为 true 时安装到系统级共享存储
+func createIntegratedManagerForStore(system bool) (version.Manager, error) {
 	// 创建基础管理器
-	managers, err := createManagers()
+	managers, err := createManagersForStore(system)
 	if err != nil {
 		return nil, err
 	}
 
-	// 创建下载管理器
-	downloadManager, err := createDownloadManager()
-	if err != nil {
-		return nil, err
-	}
+	// 创建下载管理器，与版本管理器使用同一存储
+	downloadManager := download.NewManager(managers.storage, managers.config)
 
 	// 创建适配器
 	adapter := &DownloadManagerAdapter{
@@ -421,6 +820,7 @@ func (a *DownloadManagerAdapter) Download(ctx context.Context, tool, version str
 		downloadOpts.TempDir = options.TempDir
 		downloadOpts.KeepDownload = options.KeepDownload
 		downloadOpts.Headers = options.Headers
+		downloadOpts.DeltaFrom = options.DeltaFrom
 	}
 	return a.Manager.Download(ctx, tool, version, downloadOpts)
 }
@@ -437,6 +837,7 @@ func (a *DownloadManagerAdapter) DownloadWithProgress(ctx context.Context, tool,
 		downloadOpts.TempDir = options.TempDir
 		downloadOpts.KeepDownload = options.KeepDownload
 		downloadOpts.Headers = options.Headers
+		downloadOpts.DeltaFrom = options.DeltaFrom
 	}
 
 	// 转换进度回调
@@ -504,6 +905,14 @@ func init() {
 	// install命令的标志
 	installCmd.Flags().BoolP("force", "f", false, "强制重新安装")
 	installCmd.Flags().BoolP("global", "g", false, "安装后设置为全局版本")
+	installCmd.Flags().Bool("system", false, "安装到系统级共享存储（需要管理员权限）")
+	installCmd.Flags().StringSlice("group", nil, "只安装项目配置中这些分组的工具，逗号分隔")
+	installCmd.Flags().Bool("required-only", false, "跳过项目配置中标记为 optional 的工具")
+	installCmd.Flags().Bool("plan", false, "只输出安装计划，不下载和安装")
+	installCmd.Flags().Bool("json", false, "以JSON格式输出安装计划（与 --plan 一起使用）")
+
+	// update命令的标志
+	updateCmd.Flags().Bool("delta", false, "优先使用从当前版本升级的二进制差分补丁")
 
 	// search命令的标志
 	searchCmd.Flags().IntP("limit", "l", 20, "限制显示的版本数量")
@@ -515,5 +924,13 @@ func init() {
 	addSourceCmd.Flags().String("pattern", "", "资产文件名匹配模式")
 	addSourceCmd.Flags().String("url", "", "URL模板")
 	addSourceCmd.Flags().String("description", "", "工具描述")
+	addSourceCmd.Flags().String("shim", "", "垫片（命令）名称，默认与工具名相同，用于避免与其他工具同名")
 	addSourceCmd.MarkFlagRequired("type")
+
+	regroupCommand(versionCmd, "install", installCmd)
+	regroupCommand(versionCmd, "update", updateCmd)
+	regroupCommand(versionCmd, "search", searchCmd)
+	regroupCommand(toolCmd, "add-source", addSourceCmd)
+	regroupCommand(toolCmd, "list", listSourcesCmd)
+	regroupCommand(toolCmd, "remove", removeSourceCmd)
 }
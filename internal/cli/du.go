@@ -0,0 +1,132 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/songzhibin97/vman/internal/storage"
+)
+
+// duCmd 显示已安装版本占用的磁盘空间
+var duCmd = &cobra.Command{
+	Use:   "du [tool]",
+	Short: "显示已安装版本占用的磁盘空间",
+	Long: `显示各工具已安装版本的大小和总大小。
+
+大小按版本目录中的文件计算，与其他版本共享数据（settings.storage.dedup）的文件在每个版本中都计入。
+使用 --dedup-savings 显示共享数据节省的空间和实际占用的空间，按安装时的共享记录统计。`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		dedupSavings, _ := cmd.Flags().GetBool("dedup-savings")
+		jsonFormat, _ := cmd.Flags().GetBool("json")
+
+		managers, err := createManagers()
+		if err != nil {
+			return fmt.Errorf("创建管理器失败: %w", err)
+		}
+
+		var tool string
+		if len(args) > 0 {
+			tool = args[0]
+		}
+		report, err := collectDiskUsage(managers, tool)
+		if err != nil {
+			return err
+		}
+		if dedupSavings {
+			if report.Savings, err = managers.storage.GetDedupSavings(); err != nil {
+				return fmt.Errorf("统计共享数据节省的空间失败: %w", err)
+			}
+		}
+
+		if jsonFormat {
+			data, err := json.MarshalIndent(report, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Println(string(data))
+			return nil
+		}
+		printDiskUsage(report, getUIOptions(cmd))
+		return nil
+	},
+}
+
+// diskUsageReport vman du 的输出
+type diskUsageReport struct {
+	Versions []versionDiskUsage    `json:"versions"`
+	Total    int64                 `json:"total"`
+	Savings  *storage.DedupSavings `json:"dedup_savings,omitempty"`
+}
+
+// versionDiskUsage 一个版本占用的空间
+type versionDiskUsage struct {
+	Tool    string `json:"tool"`
+	Version string `json:"version"`
+	Size    int64  `json:"size"`
+}
+
+// collectDiskUsage 统计已安装版本的大小，tool 为空时统计所有工具
+func collectDiskUsage(managers *managers, tool string) (*diskUsageReport, error) {
+	tools := []string{tool}
+	if tool == "" {
+		allTools, err := managers.version.ListAllTools()
+		if err != nil {
+			return nil, fmt.Errorf("获取工具列表失败: %w", err)
+		}
+		tools = allTools
+	}
+
+	report := &diskUsageReport{Versions: []versionDiskUsage{}}
+	for _, t := range tools {
+		versions, err := managers.version.ListVersions(t)
+		if err != nil {
+			return nil, fmt.Errorf("获取 %s 的版本列表失败: %w", t, err)
+		}
+		for _, v := range versions {
+			size, _ := calculateDirSize(managers.storage.GetToolVersionPath(t, v))
+			report.Versions = append(report.Versions, versionDiskUsage{Tool: t, Version: v, Size: size})
+			report.Total += size
+		}
+	}
+	return report, nil
+}
+
+// printDiskUsage 以表格输出磁盘空间占用
+func printDiskUsage(report *diskUsageReport, options *UIOptions) {
+	if len(report.Versions) == 0 {
+		fmt.Println("没有已安装的版本")
+		return
+	}
+
+	table := NewTablePrinter([]string{"TOOL", "VERSION", "SIZE"}, options)
+	for _, usage := range report.Versions {
+		table.AddRow([]string{usage.Tool, usage.Version, formatBytes(usage.Size)})
+	}
+	table.Print()
+	fmt.Printf("\n共 %d 个版本，%s\n", len(report.Versions), formatBytes(report.Total))
+
+	if report.Savings == nil {
+		return
+	}
+	savings := report.Savings
+	if savings.Reflinked+savings.Hardlinked == 0 {
+		fmt.Println("没有与其他版本共享数据的文件，可以通过 settings.storage.dedup 开启")
+		return
+	}
+	fmt.Printf("共享数据节省: %s（写时复制克隆 %d 个文件，硬链接 %d 个文件）\n",
+		formatBytes(savings.SavedBytes), savings.Reflinked, savings.Hardlinked)
+	if savings.SavedBytes <= report.Total {
+		fmt.Printf("实际占用: 约 %s\n", formatBytes(report.Total-savings.SavedBytes))
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(duCmd)
+
+	duCmd.Flags().Bool("dedup-savings", false, "显示版本之间共享数据节省的空间")
+	duCmd.Flags().Bool("json", false, "使用JSON格式输出")
+}
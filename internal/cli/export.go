@@ -0,0 +1,576 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+
+	"github.com/songzhibin97/vman/internal/config"
+	"github.com/songzhibin97/vman/internal/importer"
+	"github.com/songzhibin97/vman/internal/nix"
+	"github.com/songzhibin97/vman/internal/proxy"
+	"github.com/songzhibin97/vman/pkg/types"
+)
+
+// 导出格式
+const (
+	exportFormatDirenv       = "direnv"
+	exportFormatModulefile   = "modulefile"
+	exportFormatNix          = "nix"
+	exportFormatToolVersions = "tool-versions"
+)
+
+// direnvExportMarker vman export 写入 .envrc 的配置段标记
+const direnvExportMarker = "# vman export"
+
+// exportCmd 导出项目工具的环境设置
+var exportCmd = &cobra.Command{
+	Use:         "export",
+	Annotations: mutatingWhenWriting(),
+	Short:       "导出项目工具的PATH和环境变量或版本，用于direnv、environment-modules、Nix或asdf",
+	Long: `按当前目录解析项目配置（.vman.yaml）中的工具版本，导出将这些版本的bin目录加入PATH、
+并设置项目配置 defaults 中环境变量的设置，不需要shell集成和垫片即可使用项目的工具。
+
+格式:
+  direnv      写入项目目录下 .envrc 中由 "# vman export" 标记包围的配置段（PATH_add 和 export），
+              .envrc 中的其他内容保持不变，写入后需要运行 direnv allow
+  modulefile  输出 environment-modules / Lmod 的 Tcl modulefile（prepend-path 和 setenv），
+              默认输出到标准输出，使用 --output 写入 MODULEPATH 中的文件
+  nix         写入项目目录下的 flake.nix，devShell 中包含 nixpkgs 里对应的包；nixpkgs 只为
+              go、nodejs、python 等少数工具提供按版本区分的包，其他工具使用 nixpkgs 中的版本，
+              没有对应包的工具会列出，需要手动添加
+  tool-versions
+              写入项目目录下 asdf 的 .tool-versions，使不使用 vman 的成员通过 asdf 使用相同的版本；
+              只更新项目配置中工具的行，其他工具的行和注释保持不变。版本来自项目配置（而不是
+              .tool-versions 本身），版本约束和别名解析为已安装的最高版本
+
+写入文件的导出记录在配置目录的 exports.json 中，vman lock（vman freeze）固定版本后自动重新生成。
+系统版本（system）的工具不加入PATH，未安装的版本会给出警告。输出到标准输出时警告写入标准错误。
+
+--check 不写入文件，只检查文件是否与项目配置一致，不一致时列出差异并返回非零退出码，
+用于在CI中发现 .vman.yaml 和 .tool-versions 等导出文件之间的不同步。`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		format, _ := cmd.Flags().GetString("format")
+		output, _ := cmd.Flags().GetString("output")
+		check, _ := cmd.Flags().GetBool("check")
+		options := getUIOptions(cmd)
+
+		switch format {
+		case exportFormatDirenv, exportFormatModulefile, exportFormatNix, exportFormatToolVersions:
+		default:
+			return fmt.Errorf("不支持的导出格式: %s，支持 %s, %s, %s, %s", format, exportFormatDirenv, exportFormatModulefile, exportFormatNix, exportFormatToolVersions)
+		}
+
+		managers, err := createManagers()
+		if err != nil {
+			return fmt.Errorf("创建管理器失败: %w", err)
+		}
+		cwd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("获取当前目录失败: %w", err)
+		}
+
+		env, err := collectExportEnvironment(managers, format, cwd)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case output != "":
+		case format == exportFormatDirenv:
+			output = filepath.Join(env.ProjectDir, ".envrc")
+		case format == exportFormatNix:
+			output = filepath.Join(env.ProjectDir, "flake.nix")
+		case format == exportFormatToolVersions:
+			output = filepath.Join(env.ProjectDir, ".tool-versions")
+		}
+		toStdout := output == "" || output == "-"
+		if check && toStdout {
+			return fmt.Errorf("--check 需要检查的文件，使用 --output 指定")
+		}
+		for _, warning := range exportWarnings(format, env) {
+			if toStdout {
+				fmt.Fprintln(os.Stderr, warning)
+			} else {
+				PrintWarning(warning, options)
+			}
+		}
+		if toStdout {
+			fmt.Print(renderExport(format, env))
+			return nil
+		}
+
+		output, err = filepath.Abs(output)
+		if err != nil {
+			return err
+		}
+		fs := afero.NewOsFs()
+		if check {
+			return checkExport(fs, format, output, env, options)
+		}
+		if err := checkWrite(cmd); err != nil {
+			return err
+		}
+		if err := writeExport(fs, format, output, env); err != nil {
+			return err
+		}
+
+		registryPath := filepath.Join(managers.config.GetConfigDir(), config.ExportsFile)
+		registry, err := config.LoadExportRegistry(fs, registryPath)
+		if err != nil {
+			return err
+		}
+		registry.Record(env.ConfigPath, config.ExportRecord{Format: format, Output: output})
+		if err := registry.Save(fs, registryPath); err != nil {
+			return err
+		}
+
+		PrintSuccess(fmt.Sprintf("已导出 %d 个工具到 %s", len(env.Tools), output), options)
+		switch format {
+		case exportFormatDirenv:
+			fmt.Println("运行 direnv allow 使修改生效")
+		case exportFormatNix:
+			fmt.Println("运行 nix develop 进入开发环境，git 仓库中需要先 git add flake.nix")
+		case exportFormatToolVersions:
+			fmt.Println("asdf 用户运行 asdf install 安装这些版本")
+		}
+		return nil
+	},
+}
+
+// exportedTool 导出的工具版本
+type exportedTool struct {
+	Name    string
+	Version string
+	BinDir  string // 系统版本或未安装时为空
+	Missing bool   // 版本未安装
+}
+
+// projectEnvironment 项目在某个目录下解析出的工具和环境变量
+type projectEnvironment struct {
+	ProjectDir string
+	ConfigPath string
+	Tools      []exportedTool
+	Env        map[string]string
+	Warnings   []string
+}
+
+// collectProjectEnvironment 解析目录所在项目配置中的工具版本和默认环境变量
+func collectProjectEnvironment(managers *managers, dir string) (*projectEnvironment, error) {
+	dir = canonicalDir(dir)
+	configDir, projectConfig, err := findNearestProjectConfig(managers.config, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	env := &projectEnvironment{
+		ProjectDir: configDir,
+		ConfigPath: managers.config.GetProjectConfigPath(configDir),
+		Env:        make(map[string]string),
+	}
+	resolver := proxy.NewVersionResolver(managers.config, managers.version)
+	relPath, _ := filepath.Rel(configDir, dir)
+	envSource := make(map[string]string)
+	for _, tool := range projectToolsForDir(configDir, projectConfig, dir) {
+		exported := exportedTool{Name: tool}
+		resolution, err := resolver.ResolveVersion(context.Background(), tool, dir)
+		switch {
+		case err != nil:
+			// 没有安装满足要求的版本时使用项目配置中的版本要求
+			exported.Version, _, _ = projectConfig.ToolVersionForPath(tool, filepath.ToSlash(relPath))
+			exported.Missing = true
+		case resolution.Version == types.SystemVersion:
+			exported.Version = resolution.Version
+		case !managers.version.IsVersionInstalled(tool, resolution.Version):
+			exported.Version = resolution.Version
+			exported.Missing = true
+		default:
+			exported.Version = resolution.Version
+			exported.BinDir = filepath.Dir(managers.storage.GetBinaryPath(tool, resolution.Version))
+		}
+		env.Tools = append(env.Tools, exported)
+
+		defaults, configPath := proxy.ProjectDefaults(afero.NewOsFs(), managers.config, tool, dir)
+		for _, key := range defaults.Denied {
+			env.Warnings = append(env.Warnings, fmt.Sprintf("忽略 %s 中 %s 的默认环境变量 %s：项目配置不能设置在执行时加载代码的变量", configPath, tool, key))
+		}
+		for key, value := range defaults.Env {
+			if previous, ok := envSource[key]; ok && env.Env[key] != value {
+				env.Warnings = append(env.Warnings, fmt.Sprintf("%s 和 %s 的默认环境变量 %s 不同，使用 %s 的值", previous, tool, key, previous))
+				continue
+			}
+			env.Env[key] = value
+			envSource[key] = tool
+		}
+	}
+	return env, nil
+}
+
+// collectExportEnvironment 按导出格式收集项目的工具版本
+func collectExportEnvironment(managers *managers, format, dir string) (*projectEnvironment, error) {
+	if format == exportFormatToolVersions {
+		return collectToolVersions(managers, dir)
+	}
+	return collectProjectEnvironment(managers, dir)
+}
+
+// collectToolVersions 项目配置中在目录下生效的工具版本，用于生成 .tool-versions。
+// 不通过 ResolveVersion 解析：它优先读取目录中的 .tool-versions，会把文件中过期的版本当作项目配置的版本
+func collectToolVersions(managers *managers, dir string) (*projectEnvironment, error) {
+	dir = canonicalDir(dir)
+	configDir, projectConfig, err := findNearestProjectConfig(managers.config, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	env := &projectEnvironment{
+		ProjectDir: configDir,
+		ConfigPath: managers.config.GetProjectConfigPath(configDir),
+		Env:        make(map[string]string),
+	}
+	resolver := proxy.NewVersionResolver(managers.config, managers.version)
+	relPath, _ := filepath.Rel(configDir, dir)
+	workspace, _ := config.ActiveWorkspace(afero.NewOsFs(), dir, configDir)
+	for _, tool := range projectToolsForDir(configDir, projectConfig, dir) {
+		requirement, ok := projectConfig.ToolVersionForWorkspace(tool, workspace)
+		if !ok {
+			requirement, _, _ = projectConfig.ToolVersionForPath(tool, filepath.ToSlash(relPath))
+		}
+		version, err := exactToolVersion(resolver, tool, requirement)
+		if err != nil {
+			env.Warnings = append(env.Warnings, fmt.Sprintf("%s 的版本要求 %s 没有满足的已安装版本，未导出，运行 vman install %s 后重新导出", tool, requirement, tool))
+			continue
+		}
+		env.Tools = append(env.Tools, exportedTool{Name: tool, Version: version})
+	}
+	return env, nil
+}
+
+// exactToolVersion 版本要求对应的具体版本：完整版本号和 system 保持不变，版本约束和别名解析为已安装的版本
+func exactToolVersion(resolver proxy.VersionResolver, tool, requirement string) (string, error) {
+	if requirement == types.SystemVersion {
+		return requirement, nil
+	}
+	if _, err := semver.StrictNewVersion(strings.TrimPrefix(requirement, "v")); err == nil {
+		return requirement, nil
+	}
+	if version, err := resolver.ResolveAlias(tool, requirement); err == nil {
+		return version, nil
+	}
+	return resolver.ResolveConstraint(tool, requirement)
+}
+
+// exportWarnings 导出时需要提示的问题：未安装的版本，或 nixpkgs 中没有对应包和版本的工具
+func exportWarnings(format string, env *projectEnvironment) []string {
+	warnings := append([]string{}, env.Warnings...)
+	if format != exportFormatNix {
+		for _, tool := range env.Tools {
+			if tool.Missing {
+				warnings = append(warnings, fmt.Sprintf("%s@%s 未安装，运行 vman install %s %s 后重新导出", tool.Name, tool.Version, tool.Name, tool.Version))
+			}
+		}
+		return warnings
+	}
+
+	result := nix.Map(nixTools(env))
+	for _, tool := range result.Unmapped {
+		warnings = append(warnings, fmt.Sprintf("%s %s 在 nixpkgs 中没有对应的包，需要手动添加", tool.Name, tool.Version))
+	}
+	for _, pkg := range result.Packages {
+		if pkg.Pinned == "" {
+			warnings = append(warnings, fmt.Sprintf("%s 使用 nixpkgs 中的版本（pkgs.%s），可能不是 %s", pkg.Name, pkg.Attribute, pkg.Version))
+		}
+	}
+	return warnings
+}
+
+// nixTools 映射到 nixpkgs 的工具，系统版本的工具不映射
+func nixTools(env *projectEnvironment) []nix.Tool {
+	var tools []nix.Tool
+	for _, tool := range env.Tools {
+		if tool.Version != types.SystemVersion {
+			tools = append(tools, nix.Tool{Name: tool.Name, Version: tool.Version})
+		}
+	}
+	return tools
+}
+
+// projectToolsForDir 项目配置中在目录下生效的工具，按名称排序
+func projectToolsForDir(configDir string, projectConfig *types.ProjectConfig, dir string) []string {
+	seen := make(map[string]bool)
+	for tool := range projectConfig.Tools {
+		seen[tool] = true
+	}
+	for _, tools := range projectConfig.Paths {
+		for tool := range tools {
+			seen[tool] = true
+		}
+	}
+
+	relPath, _ := filepath.Rel(configDir, canonicalDir(dir))
+	tools := make([]string, 0, len(seen))
+	for tool := range seen {
+		if _, _, ok := projectConfig.ToolVersionForPath(tool, filepath.ToSlash(relPath)); ok {
+			tools = append(tools, tool)
+		}
+	}
+	sort.Strings(tools)
+	return tools
+}
+
+// renderExport 按格式生成导出内容
+func renderExport(format string, env *projectEnvironment) string {
+	switch format {
+	case exportFormatModulefile:
+		return renderModulefile(env)
+	case exportFormatNix:
+		return nix.GenerateFlake(filepath.Base(env.ProjectDir)+" development shell generated by vman", nix.Map(nixTools(env)), env.Env)
+	case exportFormatToolVersions:
+		return mergeToolVersions(fmt.Sprintf("# Generated by vman export from %s, regenerate with vman export --format tool-versions\n", filepath.Base(env.ConfigPath)), env.Tools)
+	default:
+		return renderDirenv(env)
+	}
+}
+
+// renderDirenv 生成 .envrc 片段
+func renderDirenv(env *projectEnvironment) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Generated by vman export from %s, regenerate with vman export or vman lock\n", env.ConfigPath)
+	// PATH_add 添加到PATH开头，倒序添加使PATH中的顺序与工具顺序一致
+	for i := len(env.Tools) - 1; i >= 0; i-- {
+		if tool := env.Tools[i]; tool.BinDir != "" {
+			fmt.Fprintf(&b, "PATH_add %s # %s %s\n", shellQuote(tool.BinDir), tool.Name, tool.Version)
+		}
+	}
+	for _, key := range sortedKeys(env.Env) {
+		fmt.Fprintf(&b, "export %s=%s\n", key, shellQuote(env.Env[key]))
+	}
+	return b.String()
+}
+
+// renderModulefile 生成 environment-modules 的 Tcl modulefile
+func renderModulefile(env *projectEnvironment) string {
+	var b strings.Builder
+	b.WriteString("#%Module1.0\n")
+	fmt.Fprintf(&b, "## Generated by vman export from %s, regenerate with vman export or vman lock\n", env.ConfigPath)
+
+	var versions []string
+	for _, tool := range env.Tools {
+		versions = append(versions, tool.Name+" "+tool.Version)
+	}
+	fmt.Fprintf(&b, "module-whatis %s\n", tclQuote("vman tools for "+filepath.Base(env.ProjectDir)+": "+strings.Join(versions, ", ")))
+
+	// prepend-path 同样添加到PATH开头
+	for i := len(env.Tools) - 1; i >= 0; i-- {
+		if env.Tools[i].BinDir != "" {
+			fmt.Fprintf(&b, "prepend-path PATH %s\n", tclQuote(env.Tools[i].BinDir))
+		}
+	}
+	for _, key := range sortedKeys(env.Env) {
+		fmt.Fprintf(&b, "setenv %s %s\n", key, tclQuote(env.Env[key]))
+	}
+	return b.String()
+}
+
+// toolVersionsLine 解析 .tool-versions 的一行：vman 工具名、版本和行尾注释，空行和注释行的工具名为空
+func toolVersionsLine(line string) (tool string, versions []string, comment string) {
+	if i := strings.Index(line, "#"); i >= 0 {
+		line, comment = line[:i], line[i:]
+	}
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return "", nil, comment
+	}
+	return importer.AsdfTool(fields[0]), fields[1:], comment
+}
+
+// mergeToolVersions 更新 .tool-versions 中导出工具的版本，其他工具的行和注释保持不变，
+// 重复的行只保留第一行，文件中没有的工具按 asdf 插件名追加到末尾
+func mergeToolVersions(existing string, tools []exportedTool) string {
+	versions := make(map[string]string, len(tools))
+	for _, tool := range tools {
+		versions[tool.Name] = tool.Version
+	}
+
+	var b strings.Builder
+	written := make(map[string]bool)
+	if existing != "" {
+		for _, line := range strings.Split(strings.TrimSuffix(existing, "\n"), "\n") {
+			tool, _, comment := toolVersionsLine(line)
+			version, ok := versions[tool]
+			switch {
+			case !ok:
+				b.WriteString(line + "\n")
+			case written[tool]:
+			default:
+				plugin := strings.Fields(line)[0]
+				if comment != "" {
+					fmt.Fprintf(&b, "%s %s %s\n", plugin, version, comment)
+				} else {
+					fmt.Fprintf(&b, "%s %s\n", plugin, version)
+				}
+				written[tool] = true
+			}
+		}
+	}
+	for _, tool := range tools {
+		if !written[tool.Name] {
+			fmt.Fprintf(&b, "%s %s\n", importer.AsdfPlugin(tool.Name), tool.Version)
+		}
+	}
+	return b.String()
+}
+
+// toolVersionsDrift .tool-versions 与导出工具版本的差异
+func toolVersionsDrift(existing string, tools []exportedTool) []string {
+	current := make(map[string]string)
+	for _, line := range strings.Split(existing, "\n") {
+		if tool, versions, _ := toolVersionsLine(line); tool != "" && len(versions) > 0 {
+			if _, ok := current[tool]; !ok {
+				current[tool] = versions[0]
+			}
+		}
+	}
+
+	var drift []string
+	for _, tool := range tools {
+		version, ok := current[tool.Name]
+		switch {
+		case !ok:
+			drift = append(drift, fmt.Sprintf("%s: 缺少，项目配置为 %s", tool.Name, tool.Version))
+		case version != tool.Version:
+			drift = append(drift, fmt.Sprintf("%s: %s，项目配置为 %s", tool.Name, version, tool.Version))
+		}
+	}
+	return drift
+}
+
+// exportContent 写入文件的导出内容，direnv 格式只替换 .envrc 中vman的配置段，tool-versions 格式只更新导出工具的行
+func exportContent(fs afero.Fs, format, output string, env *projectEnvironment) (string, error) {
+	if format != exportFormatDirenv && format != exportFormatToolVersions {
+		return renderExport(format, env), nil
+	}
+
+	var existing string
+	if data, err := afero.ReadFile(fs, output); err == nil {
+		existing = string(data)
+	} else if !os.IsNotExist(err) {
+		return "", fmt.Errorf("读取 %s 失败: %w", output, err)
+	}
+	if format == exportFormatToolVersions {
+		if existing == "" {
+			return renderExport(format, env), nil
+		}
+		return mergeToolVersions(existing, env.Tools), nil
+	}
+	return proxy.ReplaceMarkedSection(existing, direnvExportMarker, renderExport(format, env)), nil
+}
+
+// checkExport 检查导出的文件是否与项目配置一致，不一致时列出差异并返回错误
+func checkExport(fs afero.Fs, format, output string, env *projectEnvironment, options *UIOptions) error {
+	content, err := exportContent(fs, format, output, env)
+	if err != nil {
+		return err
+	}
+	existing, err := afero.ReadFile(fs, output)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("读取 %s 失败: %w", output, err)
+	}
+	if err == nil && string(existing) == content {
+		PrintSuccess(fmt.Sprintf("%s 与项目配置一致", output), options)
+		return nil
+	}
+
+	if err != nil {
+		fmt.Printf("%s 不存在\n", output)
+	} else if format == exportFormatToolVersions {
+		for _, line := range toolVersionsDrift(string(existing), env.Tools) {
+			fmt.Printf("  %s\n", line)
+		}
+	}
+	return fmt.Errorf("%s 与项目配置 %s 不一致，运行 vman export --format %s 重新生成", output, env.ConfigPath, format)
+}
+
+// writeExport 将导出内容写入文件
+func writeExport(fs afero.Fs, format, output string, env *projectEnvironment) error {
+	content, err := exportContent(fs, format, output, env)
+	if err != nil {
+		return err
+	}
+
+	if err := fs.MkdirAll(filepath.Dir(output), 0755); err != nil {
+		return fmt.Errorf("创建目录失败: %w", err)
+	}
+	if err := afero.WriteFile(fs, output, []byte(content), 0644); err != nil {
+		return fmt.Errorf("写入 %s 失败: %w", output, err)
+	}
+	return nil
+}
+
+// regenerateExports 重新生成项目通过 vman export 写入的文件
+func regenerateExports(cmd *cobra.Command, managers *managers, dir string) error {
+	fs := afero.NewOsFs()
+	registry, err := config.LoadExportRegistry(fs, filepath.Join(managers.config.GetConfigDir(), config.ExportsFile))
+	if err != nil {
+		return err
+	}
+	env, err := collectProjectEnvironment(managers, dir)
+	if err != nil {
+		return err
+	}
+
+	options := getUIOptions(cmd)
+	for _, record := range registry[env.ConfigPath] {
+		recordEnv := env
+		if record.Format == exportFormatToolVersions {
+			if recordEnv, err = collectToolVersions(managers, dir); err != nil {
+				PrintWarning(fmt.Sprintf("重新生成 %s 失败: %v", record.Output, err), options)
+				continue
+			}
+		}
+		if err := writeExport(fs, record.Format, record.Output, recordEnv); err != nil {
+			PrintWarning(fmt.Sprintf("重新生成 %s 失败: %v", record.Output, err), options)
+			continue
+		}
+		PrintSuccess(fmt.Sprintf("已重新生成 %s", record.Output), options)
+	}
+	return nil
+}
+
+// shellQuote 用单引号引用shell参数
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+// tclQuote 引用Tcl参数，转义双引号、反斜杠和替换字符
+func tclQuote(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `\$`, `[`, `\[`, `]`, `\]`)
+	return `"` + replacer.Replace(value) + `"`
+}
+
+// sortedKeys 按名称排序的键
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+
+	exportCmd.Flags().String("format", exportFormatDirenv, "导出格式: direnv, modulefile, nix, tool-versions")
+	exportCmd.Flags().StringP("output", "o", "", "写入的文件，- 表示标准输出（direnv 默认为项目目录下的 .envrc，nix 为 flake.nix，tool-versions 为 .tool-versions，modulefile 为标准输出）")
+	exportCmd.Flags().Bool("check", false, "只检查文件是否与项目配置一致，不一致时返回非零退出码")
+}
@@ -0,0 +1,101 @@
+package cli
+
+import (
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testProjectEnvironment() *projectEnvironment {
+	return &projectEnvironment{
+		ProjectDir: "/work/app",
+		ConfigPath: "/work/app/.vman.yaml",
+		Tools: []exportedTool{
+			{Name: "kubectl", Version: "1.29.0", BinDir: "/home/u/.vman/versions/kubectl/1.29.0/bin"},
+			{Name: "python", Version: "system"},
+			{Name: "terraform", Version: "1.6.0", BinDir: "/home/u/.vman/versions/terraform/1.6.0/bin"},
+		},
+		Env: map[string]string{"KUBECONFIG": "/work/app/kube's config", "TF_DATA_DIR": "$HOME/.tf"},
+	}
+}
+
+func TestRenderDirenv(t *testing.T) {
+	content := renderDirenv(testProjectEnvironment())
+	assert.Contains(t, content, "PATH_add '/home/u/.vman/versions/kubectl/1.29.0/bin' # kubectl 1.29.0\n")
+	assert.NotContains(t, content, "python")
+	assert.Contains(t, content, `export KUBECONFIG='/work/app/kube'\''s config'`)
+	assert.Contains(t, content, `export TF_DATA_DIR='$HOME/.tf'`)
+
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash is not installed")
+	}
+	script := "PATH_add() { PATH=\"$1:$PATH\"; }\n" + content + `printf '%s|%s' "$KUBECONFIG" "${PATH%%:*}"`
+	output, err := exec.Command("bash", "-c", script).CombinedOutput()
+	require.NoError(t, err, string(output))
+	assert.Equal(t, "/work/app/kube's config|/home/u/.vman/versions/kubectl/1.29.0/bin", string(output))
+}
+
+func TestRenderModulefile(t *testing.T) {
+	content := renderModulefile(testProjectEnvironment())
+	assert.True(t, strings.HasPrefix(content, "#%Module1.0\n"))
+	assert.Contains(t, content, `module-whatis "vman tools for app: kubectl 1.29.0, python system, terraform 1.6.0"`)
+	// kubectl 在 PATH 中排在 terraform 前面
+	assert.Less(t, strings.Index(content, "terraform/1.6.0/bin"), strings.Index(content, "kubectl/1.29.0/bin"))
+	assert.Contains(t, content, `setenv TF_DATA_DIR "\$HOME/.tf"`)
+}
+
+func TestWriteExportDirenv(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	envrc := filepath.Join("/work/app", ".envrc")
+	require.NoError(t, afero.WriteFile(fs, envrc, []byte("dotenv\n"), 0644))
+
+	env := testProjectEnvironment()
+	require.NoError(t, writeExport(fs, exportFormatDirenv, envrc, env))
+	env.Tools = env.Tools[:1]
+	require.NoError(t, writeExport(fs, exportFormatDirenv, envrc, env))
+
+	data, err := afero.ReadFile(fs, envrc)
+	require.NoError(t, err)
+	content := string(data)
+	assert.True(t, strings.HasPrefix(content, "dotenv\n"))
+	assert.Equal(t, 2, strings.Count(content, direnvExportMarker+"\n"))
+	assert.NotContains(t, content, "terraform", "重新生成时替换原来的配置段")
+}
+
+func TestMergeToolVersions(t *testing.T) {
+	tools := []exportedTool{
+		{Name: "node", Version: "20.11.0"},
+		{Name: "terraform", Version: "1.6.0"},
+		{Name: "go", Version: "1.22.1"},
+	}
+	existing := "# team tools\nnodejs 18.19.0 # LTS\nruby 3.3.0\nterraform 1.5.7\nterraform 1.4.0\n"
+
+	content := mergeToolVersions(existing, tools)
+	assert.Equal(t, "# team tools\nnodejs 20.11.0 # LTS\nruby 3.3.0\nterraform 1.6.0\ngolang 1.22.1\n", content)
+	assert.Equal(t, content, mergeToolVersions(content, tools), "再次导出时内容不变")
+
+	assert.Equal(t, []string{
+		"node: 18.19.0，项目配置为 20.11.0",
+		"terraform: 1.5.7，项目配置为 1.6.0",
+		"go: 缺少，项目配置为 1.22.1",
+	}, toolVersionsDrift(existing, tools))
+	assert.Empty(t, toolVersionsDrift(content, tools))
+}
+
+func TestCheckExport(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	output := filepath.Join("/work/app", ".tool-versions")
+	env := testProjectEnvironment()
+
+	assert.Error(t, checkExport(fs, exportFormatToolVersions, output, env, &UIOptions{}), "文件不存在")
+	require.NoError(t, writeExport(fs, exportFormatToolVersions, output, env))
+	assert.NoError(t, checkExport(fs, exportFormatToolVersions, output, env, &UIOptions{}))
+
+	env.Tools[0].Version = "1.30.0"
+	assert.ErrorContains(t, checkExport(fs, exportFormatToolVersions, output, env, &UIOptions{}), "不一致")
+}
@@ -0,0 +1,185 @@
+package cli
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// helpDocs 帮助文档：topics 中是 vman help <topic> 的主题，examples 中是各命令的示例
+// 示例按命令路径存放，如 vman version install 的示例在 examples/version/install.txt，主题中通过
+// <!-- example: version install --> 引用，命令的 Example 和帮助主题使用同一份示例
+//
+//go:embed helpdocs
+var helpDocs embed.FS
+
+const (
+	helpTopicsDir   = "helpdocs/topics"
+	helpExamplesDir = "helpdocs/examples"
+)
+
+// exampleDirective 帮助主题中引用命令示例的注释
+var exampleDirective = regexp.MustCompile(`^<!--\s*example:\s*(.+?)\s*-->$`)
+
+// helpTopic 一个帮助主题
+type helpTopic struct {
+	// Name 主题名，即文件名去掉 .md
+	Name string
+
+	// Title 标题，来自文件开头的 title 字段
+	Title string
+
+	// Body 主题内容（Markdown）
+	Body string
+}
+
+// loadHelpTopics 读取所有帮助主题，按名称排列
+func loadHelpTopics() ([]*helpTopic, error) {
+	entries, err := fs.ReadDir(helpDocs, helpTopicsDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var topics []*helpTopic
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ".md")
+		if !ok || entry.IsDir() {
+			continue
+		}
+		data, err := fs.ReadFile(helpDocs, path.Join(helpTopicsDir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		topic := &helpTopic{Name: name, Body: string(data)}
+		if rest, ok := strings.CutPrefix(topic.Body, "---\n"); ok {
+			if header, body, ok := strings.Cut(rest, "\n---\n"); ok {
+				for _, line := range strings.Split(header, "\n") {
+					if title, ok := strings.CutPrefix(line, "title:"); ok {
+						topic.Title = strings.TrimSpace(title)
+					}
+				}
+				topic.Body = body
+			}
+		}
+		topics = append(topics, topic)
+	}
+
+	sort.Slice(topics, func(i, j int) bool {
+		return topics[i].Name < topics[j].Name
+	})
+	return topics, nil
+}
+
+// commandExample 读取命令的示例，path 为不含 vman 的命令路径，如 "version install"
+func commandExample(path string) (string, bool) {
+	words := strings.Fields(path)
+	if len(words) == 0 {
+		return "", false
+	}
+	words[len(words)-1] += ".txt"
+	data, err := fs.ReadFile(helpDocs, helpExamplesDir+"/"+strings.Join(words, "/"))
+	if err != nil {
+		return "", false
+	}
+	return strings.TrimRight(string(data), "\n"), true
+}
+
+// renderHelpTopic 将帮助主题渲染为终端中显示的文本，格式与命令的详细说明一致：
+// 一级标题显示为标题行，二级标题显示为 "标题:"，代码块和引用的示例缩进两格，去掉行内代码的反引号
+func renderHelpTopic(body string) (string, error) {
+	var lines []string
+	inCode := false
+	for _, line := range strings.Split(strings.TrimSpace(body), "\n") {
+		if strings.HasPrefix(line, "```") {
+			inCode = !inCode
+			continue
+		}
+		if inCode {
+			lines = append(lines, indentLines(line))
+			continue
+		}
+
+		if match := exampleDirective.FindStringSubmatch(line); match != nil {
+			example, ok := commandExample(match[1])
+			if !ok {
+				return "", fmt.Errorf("没有命令 %s 的示例", match[1])
+			}
+			lines = append(lines, indentLines(example))
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(line, "# "):
+			line = strings.TrimPrefix(line, "# ")
+		case strings.HasPrefix(line, "## "):
+			line = strings.TrimPrefix(line, "## ") + ":"
+		case strings.HasPrefix(line, "- "):
+			line = "  " + line
+		}
+		lines = append(lines, strings.ReplaceAll(line, "`", ""))
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n")), nil
+}
+
+// indentLines 每个非空行缩进两格
+func indentLines(text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = "  " + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// applyCommandExamples 为有示例的命令设置 Example
+// 隐藏的平铺命令没有自己的示例时使用分组后的命令的示例
+func applyCommandExamples(cmd *cobra.Command) {
+	for _, child := range cmd.Commands() {
+		applyCommandExamples(child)
+	}
+	if !cmd.HasParent() {
+		return
+	}
+
+	example, ok := commandExample(commandPathWithoutRoot(cmd))
+	if !ok && cmd.Hidden {
+		if route, found := routeOf(cmd); found {
+			example, ok = commandExample(commandPathWithoutRoot(route))
+		}
+	}
+	if ok {
+		cmd.Example = indentLines(example)
+	}
+}
+
+// commandPathWithoutRoot 不含 vman 的命令路径
+func commandPathWithoutRoot(cmd *cobra.Command) string {
+	_, path, _ := strings.Cut(cmd.CommandPath(), " ")
+	return path
+}
+
+func init() {
+	// 帮助主题注册为没有操作的命令，显示在帮助的 Additional help topics 中，通过 vman help <topic> 查看
+	topics, err := loadHelpTopics()
+	if err != nil {
+		return
+	}
+	for _, topic := range topics {
+		long, err := renderHelpTopic(topic.Body)
+		if err != nil {
+			long = topic.Body
+		}
+		rootCmd.AddCommand(&cobra.Command{
+			Use:   topic.Name,
+			Short: topic.Title,
+			Long:  long,
+		})
+	}
+}
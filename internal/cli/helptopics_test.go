@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"io/fs"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHelpTopics(t *testing.T) {
+	topics, err := loadHelpTopics()
+	require.NoError(t, err)
+
+	names := make([]string, 0, len(topics))
+	for _, topic := range topics {
+		names = append(names, topic.Name)
+		assert.NotEmpty(t, topic.Title, topic.Name)
+
+		rendered, err := renderHelpTopic(topic.Body)
+		require.NoError(t, err, topic.Name)
+		assert.NotContains(t, rendered, "```", topic.Name)
+		assert.NotContains(t, rendered, "<!--", topic.Name)
+
+		cmd, _, err := rootCmd.Find([]string{topic.Name})
+		require.NoError(t, err, topic.Name)
+		assert.True(t, cmd.IsAdditionalHelpTopicCommand(), topic.Name)
+	}
+	assert.Subset(t, names, []string{"getting-started", "ci", "air-gapped", "writing-tool-definitions"})
+}
+
+func TestRenderHelpTopic(t *testing.T) {
+	rendered, err := renderHelpTopic("# 标题\n\n## 安装\n\n运行 `vman setup`：\n\n```bash\nvman setup\n```\n\n<!-- example: cache clean -->\n\n- 列表")
+	require.NoError(t, err)
+	assert.Equal(t, "标题\n\n安装:\n\n运行 vman setup：\n\n  vman setup\n\n  vman cache clean\n  vman cache clean --yes  # 不询问直接清空，适用于脚本\n\n  - 列表", rendered)
+
+	_, err = renderHelpTopic("<!-- example: no such command -->")
+	assert.Error(t, err)
+}
+
+func TestCommandExamples(t *testing.T) {
+	// 每个示例文件都对应一个命令
+	err := fs.WalkDir(helpDocs, helpExamplesDir, func(path string, entry fs.DirEntry, err error) error {
+		require.NoError(t, err)
+		if entry.IsDir() {
+			return nil
+		}
+		commandPath := strings.TrimSuffix(strings.TrimPrefix(path, helpExamplesDir+"/"), ".txt")
+		cmd, _, err := rootCmd.Find(strings.Split(commandPath, "/"))
+		require.NoError(t, err, path)
+		assert.Equal(t, "vman "+strings.ReplaceAll(commandPath, "/", " "), cmd.CommandPath(), path)
+		return nil
+	})
+	require.NoError(t, err)
+
+	applyCommandExamples(rootCmd)
+	assert.Contains(t, installCmd.Example, "vman version install kubectl 1.29.0", "隐藏的平铺命令使用分组后的命令的示例")
+	cmd, _, err := rootCmd.Find([]string{"version", "install"})
+	require.NoError(t, err)
+	assert.Equal(t, installCmd.Example, cmd.Example)
+	assert.True(t, strings.HasPrefix(mirrorCmd.Example, "  # "))
+}
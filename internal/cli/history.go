@@ -0,0 +1,297 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+
+	"github.com/songzhibin97/vman/internal/config"
+	"github.com/songzhibin97/vman/internal/version"
+)
+
+// historyCmd 显示版本切换历史
+var historyCmd = &cobra.Command{
+	Use:   "history [tool]",
+	Short: "显示版本切换历史",
+	Long: `显示通过 vman 进行的版本切换记录，包括工具、原版本、新版本、时间和执行的命令。
+
+默认显示全局版本的切换和当前目录所在项目的切换，使用 --all 显示所有项目的记录。
+记录保存在配置目录下的 history.jsonl 中。
+
+示例:
+  vman history
+  vman history kubectl
+  vman history --all --limit 50
+  vman history --json`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		all, _ := cmd.Flags().GetBool("all")
+		limit, _ := cmd.Flags().GetInt("limit")
+		jsonFormat, _ := cmd.Flags().GetBool("json")
+
+		managers, err := createManagers()
+		if err != nil {
+			return fmt.Errorf("创建管理器失败: %w", err)
+		}
+
+		entries, err := openHistory(managers.config).Entries()
+		if err != nil {
+			return err
+		}
+
+		cwd, _ := os.Getwd()
+		var filtered []*version.HistoryEntry
+		for _, entry := range entries {
+			if len(args) == 1 && entry.Tool != args[0] {
+				continue
+			}
+			if !all && !historyAppliesTo(entry, cwd) {
+				continue
+			}
+			filtered = append(filtered, entry)
+		}
+		if limit > 0 && len(filtered) > limit {
+			filtered = filtered[len(filtered)-limit:]
+		}
+
+		if jsonFormat {
+			if filtered == nil {
+				filtered = []*version.HistoryEntry{}
+			}
+			jsonData, err := json.MarshalIndent(filtered, "", "  ")
+			if err != nil {
+				return fmt.Errorf("JSON编码失败: %w", err)
+			}
+			fmt.Println(string(jsonData))
+			return nil
+		}
+
+		if len(filtered) == 0 {
+			fmt.Println("没有版本切换记录")
+			return nil
+		}
+
+		table := NewTablePrinter([]string{"TIME", "TOOL", "SCOPE", "OLD", "NEW", "COMMAND"}, getUIOptions(cmd))
+		for _, entry := range filtered {
+			scope := entry.Scope
+			if entry.Path != "" {
+				scope = entry.Path
+			}
+			table.AddRow([]string{
+				entry.Time.Local().Format("2006-01-02 15:04:05"),
+				entry.Tool,
+				scope,
+				historyVersion(entry.Old),
+				historyVersion(entry.New),
+				entry.Command,
+			})
+		}
+		table.Print()
+		return nil
+	},
+}
+
+// undoCmd 撤销最近一次版本切换
+var undoCmd = &cobra.Command{
+	Use:         "undo [tool]",
+	Annotations: mutating(),
+	Short:       "撤销最近一次版本切换",
+	Long: `将最近一次版本切换恢复为切换前的版本，切换前没有设置版本时取消设置。
+
+只撤销全局版本和当前目录所在项目的切换，可以指定工具只撤销该工具的切换。
+多次执行依次撤销更早的切换，撤销本身同样记录在历史中。
+
+示例:
+  vman undo
+  vman undo kubectl
+  vman undo --global`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		globalOnly, _ := cmd.Flags().GetBool("global")
+
+		managers, err := createManagers()
+		if err != nil {
+			return fmt.Errorf("创建管理器失败: %w", err)
+		}
+
+		cwd, _ := os.Getwd()
+		history := openHistory(managers.config)
+		entry, err := history.LastUndoable(func(entry *version.HistoryEntry) bool {
+			if len(args) == 1 && entry.Tool != args[0] {
+				return false
+			}
+			if globalOnly && entry.Scope != version.HistoryScopeGlobal {
+				return false
+			}
+			return historyAppliesTo(entry, cwd)
+		})
+		if err != nil {
+			return err
+		}
+		if entry == nil {
+			return fmt.Errorf("没有可以撤销的版本切换")
+		}
+
+		if err := revertVersionSwitch(managers, entry); err != nil {
+			return fmt.Errorf("撤销失败: %w", err)
+		}
+		recordVersionSwitch(managers.config, &version.HistoryEntry{
+			Tool:   entry.Tool,
+			Scope:  entry.Scope,
+			Path:   entry.Path,
+			Old:    entry.New,
+			New:    entry.Old,
+			Undoes: entry.ID,
+		})
+
+		target := "全局版本"
+		if entry.Path != "" {
+			target = entry.Path
+		}
+		PrintSuccess(fmt.Sprintf("已撤销 %s 的切换: %s -> %s (%s)", entry.Tool, historyVersion(entry.New), historyVersion(entry.Old), target), getUIOptions(cmd))
+
+		if err := regenerateShims(); err != nil {
+			fmt.Printf("警告: 重新生成垫片失败: %v\n", err)
+		}
+		return nil
+	},
+}
+
+// openHistory 打开配置目录下的版本切换历史
+func openHistory(configManager config.Manager) *version.History {
+	return version.NewHistory(afero.NewOsFs(), filepath.Join(configManager.GetConfigDir(), version.HistoryFile))
+}
+
+// recordVersionSwitch 记录一次版本切换，版本没有变化时不记录，记录失败不影响切换本身
+func recordVersionSwitch(configManager config.Manager, entry *version.HistoryEntry) {
+	if entry.Old == entry.New {
+		return
+	}
+	if entry.Command == "" {
+		entry.Command = "vman " + strings.Join(os.Args[1:], " ")
+	}
+	if err := openHistory(configManager).Append(entry); err != nil {
+		fmt.Printf("警告: 记录版本切换历史失败: %v\n", err)
+	}
+}
+
+// setGlobalVersionWithHistory 设置全局版本并记录切换历史
+func setGlobalVersionWithHistory(versionManager version.Manager, configManager config.Manager, tool, versionStr string) error {
+	old := globalVersionOf(configManager, tool)
+	if err := versionManager.SetGlobalVersion(tool, versionStr); err != nil {
+		return err
+	}
+	recordVersionSwitch(configManager, &version.HistoryEntry{
+		Tool:  tool,
+		Scope: version.HistoryScopeGlobal,
+		Old:   old,
+		New:   versionStr,
+	})
+	return nil
+}
+
+// setProjectVersionWithHistory 在 projectPath 的 .vman.yaml 中设置版本并记录切换历史
+func setProjectVersionWithHistory(managers *managers, tool, versionStr, projectPath string) error {
+	var old string
+	if projectConfig, err := managers.config.LoadProject(projectPath); err == nil {
+		old = projectConfig.Tools[tool]
+	}
+	if err := managers.version.SetProjectVersion(tool, versionStr, projectPath); err != nil {
+		return err
+	}
+	recordVersionSwitch(managers.config, &version.HistoryEntry{
+		Tool:  tool,
+		Scope: version.HistoryScopeProject,
+		Path:  managers.config.GetProjectConfigPath(projectPath),
+		Old:   old,
+		New:   versionStr,
+	})
+	return nil
+}
+
+// globalVersionOf 返回工具当前的全局版本
+func globalVersionOf(configManager config.Manager, tool string) string {
+	globalConfig, err := configManager.LoadGlobal()
+	if err != nil {
+		return ""
+	}
+	return globalConfig.GlobalVersions[tool]
+}
+
+// revertVersionSwitch 将切换记录涉及的配置恢复为切换前的版本
+func revertVersionSwitch(managers *managers, entry *version.HistoryEntry) error {
+	if entry.Scope == version.HistoryScopeGlobal {
+		if entry.Old != "" {
+			return managers.version.SetGlobalVersion(entry.Tool, entry.Old)
+		}
+		globalConfig, err := managers.config.LoadGlobal()
+		if err != nil {
+			return err
+		}
+		delete(globalConfig.GlobalVersions, entry.Tool)
+		return managers.config.SaveGlobal(globalConfig)
+	}
+
+	if filepath.Base(entry.Path) == ".vman-version" {
+		versions, err := readVersionFile(entry.Path)
+		if err != nil {
+			return err
+		}
+		if entry.Old != "" {
+			versions[entry.Tool] = entry.Old
+		} else {
+			delete(versions, entry.Tool)
+		}
+		return writeVersionFile(entry.Path, versions)
+	}
+
+	// 切换前的值可能是版本约束，直接写回配置而不校验是否已安装
+	projectPath := filepath.Dir(entry.Path)
+	projectConfig, err := managers.config.LoadProject(projectPath)
+	if err != nil {
+		return err
+	}
+	if projectConfig.Tools == nil {
+		projectConfig.Tools = make(map[string]string)
+	}
+	if entry.Old != "" {
+		projectConfig.Tools[entry.Tool] = entry.Old
+	} else {
+		delete(projectConfig.Tools, entry.Tool)
+	}
+	return managers.config.SaveProject(projectPath, projectConfig)
+}
+
+// historyAppliesTo 记录是否影响 dir：全局切换，或 dir 位于记录的项目中
+func historyAppliesTo(entry *version.HistoryEntry, dir string) bool {
+	if entry.Scope == version.HistoryScopeGlobal {
+		return true
+	}
+	projectDir := canonicalDir(filepath.Dir(entry.Path))
+	rel, err := filepath.Rel(projectDir, canonicalDir(dir))
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// historyVersion 显示记录中的版本，未设置时显示为 -
+func historyVersion(v string) string {
+	if v == "" {
+		return "-"
+	}
+	return v
+}
+
+func init() {
+	rootCmd.AddCommand(historyCmd)
+	rootCmd.AddCommand(undoCmd)
+
+	historyCmd.Flags().Bool("all", false, "显示所有项目的记录")
+	historyCmd.Flags().Int("limit", 20, "最多显示的记录数，0 表示不限制")
+	historyCmd.Flags().Bool("json", false, "使用JSON格式输出")
+	undoCmd.Flags().Bool("global", false, "只撤销全局版本的切换")
+}
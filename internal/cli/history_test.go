@@ -0,0 +1,54 @@
+package cli
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/songzhibin97/vman/internal/version"
+)
+
+func TestHistory_LastUndoable(t *testing.T) {
+	history := version.NewHistory(afero.NewMemMapFs(), "/config/history.jsonl")
+
+	entry, err := history.LastUndoable(nil)
+	require.NoError(t, err)
+	assert.Nil(t, entry)
+
+	require.NoError(t, history.Append(&version.HistoryEntry{ID: 1, Tool: "kubectl", Scope: version.HistoryScopeGlobal, Old: "1.28.0", New: "1.29.0"}))
+	require.NoError(t, history.Append(&version.HistoryEntry{ID: 2, Tool: "helm", Scope: version.HistoryScopeGlobal, New: "3.14.0"}))
+	require.NoError(t, history.Append(&version.HistoryEntry{ID: 3, Tool: "kubectl", Scope: version.HistoryScopeGlobal, Old: "1.29.0", New: "1.30.0"}))
+
+	entries, err := history.Entries()
+	require.NoError(t, err)
+	assert.Len(t, entries, 3)
+
+	entry, err = history.LastUndoable(nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), entry.ID)
+
+	// 撤销后依次撤销更早的切换
+	require.NoError(t, history.Append(&version.HistoryEntry{ID: 4, Tool: "kubectl", Scope: version.HistoryScopeGlobal, Old: "1.30.0", New: "1.29.0", Undoes: 3}))
+	entry, err = history.LastUndoable(nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), entry.ID)
+
+	entry, err = history.LastUndoable(func(e *version.HistoryEntry) bool { return e.Tool == "kubectl" })
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), entry.ID)
+}
+
+func TestHistoryAppliesTo(t *testing.T) {
+	project := t.TempDir()
+	configPath := filepath.Join(project, ".vman.yaml")
+	entry := &version.HistoryEntry{Scope: version.HistoryScopeProject, Path: configPath}
+
+	assert.True(t, historyAppliesTo(entry, project))
+	assert.True(t, historyAppliesTo(entry, filepath.Join(project, "sub", "dir")))
+	assert.False(t, historyAppliesTo(entry, filepath.Dir(project)))
+	assert.False(t, historyAppliesTo(entry, project+"-other"))
+	assert.True(t, historyAppliesTo(&version.HistoryEntry{Scope: version.HistoryScopeGlobal}, project))
+}
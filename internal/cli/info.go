@@ -0,0 +1,345 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/songzhibin97/vman/internal/proxy"
+	"github.com/songzhibin97/vman/pkg/types"
+	"github.com/songzhibin97/vman/pkg/utils"
+)
+
+// infoCmd 显示工具或已安装版本的详细信息
+var infoCmd = &cobra.Command{
+	Use:   "info <tool> [version]",
+	Short: "显示工具或已安装版本的详细信息",
+	Long: `显示工具的详细信息。
+
+只指定工具名时，显示工具元数据（描述、主页、仓库、支持的平台）、
+已安装版本及其大小、安装时间和最后使用时间、全局和当前项目选择的版本以及垫片路径。
+
+同时指定版本时，显示该版本的安装路径、校验和以及下载来源记录。
+下载来源记录包含原始下载地址、重定向后的实际地址、下载策略、
+文件校验和以及ETag等HTTP响应头，可用于审计二进制文件的来源。
+
+示例:
+  vman info kubectl
+  vman info kubectl 1.29.0
+  vman info terraform --json`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		tool := args[0]
+		jsonFormat, _ := cmd.Flags().GetBool("json")
+
+		managers, err := createManagers()
+		if err != nil {
+			return fmt.Errorf("创建管理器失败: %w", err)
+		}
+
+		if len(args) == 2 {
+			return showVersionInfo(managers, tool, args[1], jsonFormat)
+		}
+		return showToolInfo(managers, tool, jsonFormat, getUIOptions(cmd))
+	},
+}
+
+// toolInfo 工具信息输出
+type toolInfo struct {
+	Tool          string            `json:"tool"`
+	Description   string            `json:"description,omitempty"`
+	Homepage      string            `json:"homepage,omitempty"`
+	Repository    string            `json:"repository,omitempty"`
+	SourceType    string            `json:"source_type,omitempty"`
+	Platforms     []string          `json:"platforms,omitempty"`
+	GlobalVersion string            `json:"global_version,omitempty"`
+	ActiveVersion string            `json:"active_version,omitempty"`
+	ActiveSource  string            `json:"active_source,omitempty"`
+	ActiveConfig  string            `json:"active_config,omitempty"`
+	ShimPath      string            `json:"shim_path"`
+	ShimInstalled bool              `json:"shim_installed"`
+	Versions      []toolVersionInfo `json:"versions"`
+}
+
+// toolVersionInfo 已安装版本信息输出
+type toolVersionInfo struct {
+	Version     string    `json:"version"`
+	Path        string    `json:"path"`
+	Size        int64     `json:"size"`
+	InstalledAt time.Time `json:"installed_at,omitempty"`
+	InstallType string    `json:"install_type,omitempty"`
+	LastUsed    time.Time `json:"last_used,omitempty"`
+	Global      bool      `json:"global"`
+	Active      bool      `json:"active"`
+}
+
+// showToolInfo 显示工具信息
+func showToolInfo(managers *managers, tool string, jsonFormat bool, options *UIOptions) error {
+	versions, err := managers.version.ListVersions(tool)
+	if err != nil {
+		return fmt.Errorf("获取 %s 的已安装版本失败: %w", tool, err)
+	}
+
+	metadata, metadataErr := managers.config.LoadToolConfig(tool)
+	if metadataErr != nil && len(versions) == 0 {
+		return fmt.Errorf("未找到工具 %s", tool)
+	}
+
+	info := &toolInfo{
+		Tool:     tool,
+		Versions: []toolVersionInfo{},
+	}
+
+	if metadata != nil {
+		info.Description = metadata.Description
+		info.Homepage = metadata.Homepage
+		info.Repository = metadata.Repository
+		info.SourceType = metadata.DownloadConfig.Type
+		info.Platforms = metadata.Platforms
+		if info.Repository == "" {
+			info.Repository = metadata.DownloadConfig.Repository
+		}
+	}
+
+	if globalConfig, err := managers.config.LoadGlobal(); err == nil {
+		info.GlobalVersion = globalConfig.GlobalVersions[tool]
+	}
+
+	if cwd, err := os.Getwd(); err == nil {
+		resolver := proxy.NewVersionResolver(managers.config, managers.version)
+		if resolution, err := resolver.ResolveVersion(context.Background(), tool, cwd); err == nil {
+			info.ActiveVersion = resolution.Version
+			info.ActiveSource = resolution.Source
+			info.ActiveConfig = resolution.ConfigPath
+		}
+	}
+
+	if err := initProxy(); err == nil {
+		info.ShimPath = commandProxy.GetShimPath(tool)
+		info.ShimInstalled = utils.FileExists(info.ShimPath)
+	}
+
+	for _, v := range versions {
+		versionInfo := toolVersionInfo{
+			Version: v,
+			Path:    managers.storage.GetToolVersionPath(tool, v),
+			Global:  v == info.GlobalVersion,
+			Active:  v == info.ActiveVersion,
+		}
+
+		if size, err := calculateDirSize(versionInfo.Path); err == nil {
+			versionInfo.Size = size
+		}
+
+		if lastUsed, err := managers.storage.GetLastUsed(tool, v); err == nil {
+			versionInfo.LastUsed = lastUsed
+		}
+
+		if versionMetadata, err := managers.version.GetVersionMetadata(tool, v); err == nil {
+			versionInfo.InstalledAt = versionMetadata.InstalledAt
+			versionInfo.InstallType = versionMetadata.InstallType
+		} else if stat, err := os.Stat(versionInfo.Path); err == nil {
+			versionInfo.InstalledAt = stat.ModTime()
+		}
+
+		info.Versions = append(info.Versions, versionInfo)
+	}
+
+	if jsonFormat {
+		jsonData, err := json.MarshalIndent(info, "", "  ")
+		if err != nil {
+			return fmt.Errorf("JSON编码失败: %w", err)
+		}
+		fmt.Println(string(jsonData))
+		return nil
+	}
+
+	printToolInfo(info, options)
+	return nil
+}
+
+// printToolInfo 打印工具信息
+func printToolInfo(info *toolInfo, options *UIOptions) {
+	fmt.Println(info.Tool)
+	if info.Description != "" {
+		fmt.Printf("  描述:       %s\n", info.Description)
+	}
+	if info.Homepage != "" {
+		fmt.Printf("  主页:       %s\n", info.Homepage)
+	}
+	if info.Repository != "" {
+		fmt.Printf("  仓库:       %s\n", info.Repository)
+	}
+	if info.SourceType != "" {
+		fmt.Printf("  下载方式:   %s\n", info.SourceType)
+	}
+	if len(info.Platforms) > 0 {
+		fmt.Printf("  支持平台:   %s\n", strings.Join(info.Platforms, ", "))
+	}
+
+	fmt.Println()
+	if info.GlobalVersion != "" {
+		fmt.Printf("  全局版本:   %s\n", info.GlobalVersion)
+	} else {
+		fmt.Println("  全局版本:   未设置")
+	}
+	if info.ActiveVersion != "" {
+		source := info.ActiveSource
+		if info.ActiveConfig != "" {
+			source = fmt.Sprintf("%s: %s", source, info.ActiveConfig)
+		}
+		fmt.Printf("  当前版本:   %s (%s)\n", info.ActiveVersion, source)
+	} else {
+		fmt.Println("  当前版本:   未设置")
+	}
+	if info.ShimPath != "" {
+		status := "未生成"
+		if info.ShimInstalled {
+			status = "已生成"
+		}
+		fmt.Printf("  垫片路径:   %s (%s)\n", info.ShimPath, status)
+	}
+
+	fmt.Println()
+	if len(info.Versions) == 0 {
+		fmt.Println("没有已安装的版本")
+		return
+	}
+
+	fmt.Println("已安装版本:")
+	table := NewTablePrinter([]string{"", "版本", "大小", "安装时间", "最后使用", "安装方式"}, options)
+	for _, v := range info.Versions {
+		marker := ""
+		if v.Active {
+			marker = "*"
+		} else if v.Global {
+			marker = "g"
+		}
+
+		installedAt := "-"
+		if !v.InstalledAt.IsZero() {
+			installedAt = v.InstalledAt.Format("2006-01-02 15:04")
+		}
+
+		lastUsed := "从未使用"
+		if !v.LastUsed.IsZero() {
+			lastUsed = v.LastUsed.Format("2006-01-02")
+		}
+
+		installType := v.InstallType
+		if installType == "" {
+			installType = "-"
+		}
+
+		table.AddRow([]string{marker, v.Version, formatBytes(v.Size), installedAt, lastUsed, installType})
+	}
+	table.Print()
+}
+
+// showVersionInfo 显示已安装版本的详细信息
+func showVersionInfo(managers *managers, tool, version string, jsonFormat bool) error {
+	if !managers.version.IsVersionInstalled(tool, version) {
+		return fmt.Errorf("版本 %s@%s 未安装", tool, version)
+	}
+
+	metadata, err := managers.version.GetVersionMetadata(tool, version)
+	if err != nil {
+		return fmt.Errorf("获取版本元数据失败: %w", err)
+	}
+
+	if jsonFormat {
+		jsonData, err := json.MarshalIndent(metadata, "", "  ")
+		if err != nil {
+			return fmt.Errorf("JSON编码失败: %w", err)
+		}
+		fmt.Println(string(jsonData))
+		return nil
+	}
+
+	printVersionMetadata(metadata)
+	return nil
+}
+
+// printVersionMetadata 打印版本元数据
+func printVersionMetadata(metadata *types.VersionMetadata) {
+	fmt.Printf("%s@%s\n", metadata.ToolName, metadata.Version)
+	fmt.Printf("  安装路径:   %s\n", metadata.InstallPath)
+	fmt.Printf("  二进制文件: %s\n", metadata.BinaryPath)
+	fmt.Printf("  安装方式:   %s\n", metadata.InstallType)
+	if !metadata.InstalledAt.IsZero() {
+		fmt.Printf("  安装时间:   %s\n", metadata.InstalledAt.Format(time.RFC3339))
+	}
+	if metadata.Size > 0 {
+		fmt.Printf("  文件大小:   %s\n", formatBytes(metadata.Size))
+	}
+	if metadata.Checksum != "" {
+		fmt.Printf("  SHA256:     %s\n", metadata.Checksum)
+	}
+	if metadata.Source != "" {
+		fmt.Printf("  来源:       %s\n", metadata.Source)
+	}
+
+	p := metadata.Provenance
+	if p == nil {
+		fmt.Println("\n没有下载来源记录")
+		return
+	}
+
+	fmt.Println("\n下载来源:")
+	fmt.Printf("  下载地址:   %s\n", p.URL)
+	if p.ResolvedURL != "" && p.ResolvedURL != p.URL {
+		fmt.Printf("  实际地址:   %s\n", p.ResolvedURL)
+	}
+	if p.Redirects > 0 {
+		fmt.Printf("  重定向:     %d 次\n", p.Redirects)
+	}
+	fmt.Printf("  下载策略:   %s\n", p.Strategy)
+	if p.Filename != "" {
+		fmt.Printf("  文件名:     %s\n", p.Filename)
+	}
+	if p.Size > 0 {
+		fmt.Printf("  文件大小:   %s\n", formatBytes(p.Size))
+	}
+	fmt.Printf("  SHA256:     %s\n", p.Checksum)
+	if p.ExpectedChecksum != "" {
+		fmt.Printf("  声明校验和: %s (已验证: %t)\n", p.ExpectedChecksum, p.ChecksumVerified)
+	} else {
+		fmt.Println("  声明校验和: 无")
+	}
+	if p.ETag != "" {
+		fmt.Printf("  ETag:       %s\n", p.ETag)
+	}
+	if p.LastModified != "" {
+		fmt.Printf("  修改时间:   %s\n", p.LastModified)
+	}
+	if p.ContentType != "" {
+		fmt.Printf("  内容类型:   %s\n", p.ContentType)
+	}
+	fmt.Printf("  下载时间:   %s\n", p.DownloadedAt.Format(time.RFC3339))
+}
+
+// calculateDirSize 计算目录占用的磁盘大小
+func calculateDirSize(dir string) (int64, error) {
+	var size int64
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
+}
+
+func init() {
+	rootCmd.AddCommand(infoCmd)
+	infoCmd.Flags().Bool("json", false, "使用JSON格式输出")
+}
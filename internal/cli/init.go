@@ -4,30 +4,34 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"runtime"
 	"strings"
 
+	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
 
+	"github.com/songzhibin97/vman/internal/proxy"
+	"github.com/songzhibin97/vman/pkg/types"
 	"github.com/songzhibin97/vman/pkg/utils"
 )
 
 // initCmd 初始化vman环境
 var initCmd = &cobra.Command{
-	Use:   "init [shell]",
-	Short: "初始化vman环境",
+	Use:         "init [shell]",
+	Annotations: mutating(),
+	Short:       "初始化vman环境",
 	Long: `初始化vman环境，包括：
 - 创建必要的目录结构
 - 生成默认配置文件
 - 设置shell集成
 - 配置代理环境
 
-支持的shell: bash, zsh, fish, powershell
+支持的shell: bash, zsh, fish, nu, xonsh, powershell, pwsh
 
 示例:
   vman init          # 自动检测当前shell
   vman init bash     # 为bash生成配置
-  vman init zsh      # 为zsh生成配置`,
+  vman init zsh      # 为zsh生成配置
+  vman init nu       # 为Nushell生成配置`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// 获取选项
@@ -39,11 +43,11 @@ var initCmd = &cobra.Command{
 		if len(args) == 1 {
 			shell = args[0]
 		} else {
-			shell = detectShell()
+			shell = proxy.NewShellIntegrator().DetectShell()
 		}
 
 		// 验证shell类型
-		if !isValidShell(shell) {
+		if !proxy.NewShellIntegrator().ValidateShellSupport(shell) {
 			return fmt.Errorf("不支持的shell类型: %s", shell)
 		}
 
@@ -79,39 +83,6 @@ var initCmd = &cobra.Command{
 	},
 }
 
-// detectShell 自动检测当前shell
-func detectShell() string {
-	// 首先检查SHELL环境变量
-	if shell := os.Getenv("SHELL"); shell != "" {
-		return filepath.Base(shell)
-	}
-
-	// 检查特定的环境变量
-	switch {
-	case os.Getenv("ZSH_VERSION") != "":
-		return "zsh"
-	case os.Getenv("BASH_VERSION") != "":
-		return "bash"
-	case os.Getenv("FISH_VERSION") != "":
-		return "fish"
-	case runtime.GOOS == "windows":
-		return "powershell"
-	default:
-		return "bash" // 默认值
-	}
-}
-
-// isValidShell 检查shell是否有效
-func isValidShell(shell string) bool {
-	validShells := []string{"bash", "zsh", "fish", "powershell", "cmd"}
-	for _, valid := range validShells {
-		if shell == valid {
-			return true
-		}
-	}
-	return false
-}
-
 // initDirectories 初始化必要的目录结构
 func initDirectories(force bool) error {
 	fmt.Println("📁 创建目录结构...")
@@ -122,13 +93,15 @@ func initDirectories(force bool) error {
 	}
 
 	// 创建必要的目录
+	paths := types.DefaultConfigPaths(homeDir)
 	directories := []string{
-		filepath.Join(homeDir, ".vman"),
-		filepath.Join(homeDir, ".vman", "versions"),
-		filepath.Join(homeDir, ".vman", "shims"),
-		filepath.Join(homeDir, ".vman", "cache"),
-		filepath.Join(homeDir, ".vman", "logs"),
-		filepath.Join(homeDir, ".vman", "tmp"),
+		paths.ConfigDir,
+		paths.DataDir,
+		paths.VersionsDir,
+		paths.ShimsDir,
+		paths.CacheDir,
+		paths.LogsDir,
+		paths.TempDir,
 	}
 
 	for _, dir := range directories {
@@ -155,7 +128,7 @@ func initConfig(force bool) error {
 		return fmt.Errorf("获取用户主目录失败: %w", err)
 	}
 
-	configPath := filepath.Join(homeDir, ".vman", "config.yaml")
+	configPath := types.DefaultConfigPaths(homeDir).GlobalConfigFile
 
 	// 检查配置文件是否已存在
 	if utils.FileExists(configPath) && !force {
@@ -210,7 +183,7 @@ logging:
   max_backups: 5
 `
 
-	if err := os.WriteFile(configPath, []byte(defaultConfig), 0644); err != nil {
+	if err := os.WriteFile(configPath, []byte(defaultConfig), utils.PrivateFileMode); err != nil {
 		return fmt.Errorf("创建配置文件失败: %w", err)
 	}
 
@@ -239,6 +212,18 @@ func setupShellIntegration(shell string, force bool) error {
 		return fmt.Errorf("无法确定%s的配置文件路径", shell)
 	}
 
+	// Nushell 的钩子脚本写入单独的文件，由 config.nu source
+	if shell == "nu" {
+		hookScript, err := proxy.NewShellIntegrator().GenerateShellHook(shell)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(nushellHookFile(), []byte(hookScript), 0644); err != nil {
+			return fmt.Errorf("写入Nushell钩子脚本失败: %w", err)
+		}
+		fmt.Printf("  ✅ %s\n", nushellHookFile())
+	}
+
 	// 检查是否已经集成
 	if utils.FileExists(configFile) {
 		content, err := os.ReadFile(configFile)
@@ -268,8 +253,9 @@ func setupShellIntegration(shell string, force bool) error {
 // generateShellInitScript 生成shell初始化脚本
 func generateShellInitScript(shell string) string {
 	homeDir, _ := os.UserHomeDir()
-	vmanDir := filepath.Join(homeDir, ".vman")
-	shimsDir := filepath.Join(vmanDir, "shims")
+	paths := types.DefaultConfigPaths(homeDir)
+	vmanDir := paths.ConfigDir
+	shimsDir := paths.ShimsDir
 
 	switch shell {
 	case "bash", "zsh":
@@ -296,45 +282,60 @@ if command -v vman >/dev/null 2>&1
 end
 `, vmanDir, shimsDir)
 
-	case "powershell":
+	case "nu":
+		// Nushell 只能 source 解析时已存在的文件，钩子脚本由 setupShellIntegration 写入 vman.nu
+		return fmt.Sprintf(`
+# vman initialization
+$env.VMAN_ROOT = '%s'
+source '%s'
+`, vmanDir, nushellHookFile())
+
+	case "xonsh":
+		return fmt.Sprintf(`
+# vman initialization
+$VMAN_ROOT = r'%s'
+if !(which vman):
+    execx($(vman shell-init xonsh))
+`, vmanDir)
+
+	case "powershell", "pwsh":
 		return fmt.Sprintf(`
 # vman initialization
 $env:VMAN_ROOT = "%s"
-$env:PATH = "%s;" + $env:PATH
+$env:PATH = "%s" + [System.IO.Path]::PathSeparator + $env:PATH
 
 # vman shell integration
 if (Get-Command vman -ErrorAction SilentlyContinue) {
-  vman shell-init powershell | Invoke-Expression
+  vman shell-init %s | Out-String | Invoke-Expression
 }
-`, vmanDir, shimsDir)
+`, vmanDir, shimsDir, shell)
 
 	default:
 		return ""
 	}
 }
 
+// nushellHookFile Nushell 钩子脚本的路径，与 config.nu 位于同一目录
+func nushellHookFile() string {
+	homeDir, _ := os.UserHomeDir()
+	return proxy.NushellHookFile(proxy.ShellConfigPath(afero.NewOsFs(), homeDir, "nu"))
+}
+
 // getShellConfigFile 获取shell配置文件路径
 func getShellConfigFile(shell, homeDir string) string {
-	switch shell {
-	case "bash":
+	if shell == "bash" {
 		// 优先使用.bashrc，如果不存在则使用.bash_profile
 		bashrc := filepath.Join(homeDir, ".bashrc")
 		if utils.FileExists(bashrc) {
 			return bashrc
 		}
 		return filepath.Join(homeDir, ".bash_profile")
-	case "zsh":
-		return filepath.Join(homeDir, ".zshrc")
-	case "fish":
-		configDir := filepath.Join(homeDir, ".config", "fish")
-		os.MkdirAll(configDir, 0755)
-		return filepath.Join(configDir, "config.fish")
-	case "powershell":
-		// PowerShell配置文件路径比较复杂，这里使用默认路径
-		return filepath.Join(homeDir, "Documents", "PowerShell", "Microsoft.PowerShell_profile.ps1")
-	default:
-		return ""
 	}
+	configFile := proxy.ShellConfigPath(afero.NewOsFs(), homeDir, shell)
+	if configFile != "" {
+		os.MkdirAll(filepath.Dir(configFile), 0755)
+	}
+	return configFile
 }
 
 // setupProxyEnvironment 设置代理环境
@@ -367,7 +368,11 @@ func printPostInitInstructions(shell string) {
 		fmt.Printf("   source ~/.zshrc\n")
 	case "fish":
 		fmt.Printf("   source ~/.config/fish/config.fish\n")
-	case "powershell":
+	case "nu":
+		fmt.Printf("   exec nu\n")
+	case "xonsh":
+		fmt.Printf("   source ~/.xonshrc\n")
+	case "powershell", "pwsh":
 		fmt.Printf("   . $PROFILE\n")
 	}
 
@@ -0,0 +1,172 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+
+	"github.com/songzhibin97/vman/internal/download"
+	"github.com/songzhibin97/vman/pkg/utils"
+)
+
+// inspectArchiveCmd 查看安装包内容
+var inspectArchiveCmd = &cobra.Command{
+	Use:   "inspect-archive <file> | <tool> [version]",
+	Short: "查看安装包中的文件",
+	Long: `列出安装包中的文件，用于在安装前确认 extract_binary 等设置。
+
+参数为本地文件时直接读取；否则视为工具名，按工具定义下载指定版本（默认最新版本）的安装包到临时目录后查看，
+不会安装。支持 zip、tar、tar.gz、tar.xz、tar.bz2、tar.zst 和 tar.br 格式（tar.xz、tar.zst、tar.br 分别需要系统中安装 xz、zstd、brotli 命令）。`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		extractName, _ := cmd.Flags().GetString("extract")
+		output, _ := cmd.Flags().GetString("output")
+		keep, _ := cmd.Flags().GetBool("keep")
+		jsonFormat, _ := cmd.Flags().GetBool("json")
+		timeout, _ := cmd.Flags().GetDuration("timeout")
+		encodings, _ := cmd.Flags().GetStringSlice("encoding")
+		uiOptions := getUIOptions(cmd)
+
+		cmd.SilenceUsage = true
+
+		for _, name := range encodings {
+			if _, ok := utils.LookupEncoding(name); !ok {
+				return fmt.Errorf("不支持的文件名编码 %s，可选: %s", name, strings.Join(utils.EncodingNames(), ", "))
+			}
+		}
+
+		fs := afero.NewOsFs()
+		archivePath := args[0]
+
+		if !utils.FileExists(archivePath) {
+			tool := args[0]
+			version := ""
+			if len(args) > 1 {
+				version = args[1]
+			}
+
+			managers, err := createManagers()
+			if err != nil {
+				return fmt.Errorf("创建管理器失败: %w", err)
+			}
+			metadata, err := managers.config.LoadToolConfig(tool)
+			if err != nil {
+				return fmt.Errorf("%s 既不是文件，也没有找到对应的工具定义: %w", tool, err)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			dir := filepath.Join(managers.storage.GetTempDir(), fmt.Sprintf("inspect-%s-%d", tool, time.Now().UnixNano()))
+			if !keep {
+				defer os.RemoveAll(dir)
+			}
+
+			if !jsonFormat {
+				fmt.Printf("正在下载 %s 的安装包...\n", tool)
+			}
+			artifact, err := download.FetchArtifact(ctx, fs, metadata, version, dir)
+			if err != nil {
+				return err
+			}
+			archivePath = artifact.Path
+			if !jsonFormat {
+				fmt.Printf("已下载 %s@%s: %s\n", tool, artifact.Version, artifact.URL)
+				if keep {
+					fmt.Printf("安装包保存在: %s\n", archivePath)
+				}
+				fmt.Println()
+			}
+		} else if len(args) > 1 {
+			return fmt.Errorf("查看本地文件时不能指定版本")
+		}
+
+		if extractName != "" {
+			if output == "" {
+				output = filepath.Base(extractName)
+			}
+			extractor := download.NewArchiveExtractor(fs, logrus.New())
+			if err := extractor.ExtractFile(archivePath, extractName, output); err != nil {
+				return err
+			}
+			PrintSuccess(fmt.Sprintf("已提取 %s 到 %s", extractName, output), uiOptions)
+			return nil
+		}
+
+		entries, err := download.ListArchive(fs, archivePath, encodings...)
+		if err != nil {
+			return err
+		}
+
+		if jsonFormat {
+			jsonData, err := json.MarshalIndent(entries, "", "  ")
+			if err != nil {
+				return fmt.Errorf("JSON编码失败: %w", err)
+			}
+			fmt.Println(string(jsonData))
+			return nil
+		}
+
+		printArchiveEntries(archivePath, entries, uiOptions)
+		return nil
+	},
+}
+
+// printArchiveEntries 以类似 tar -tv 的格式打印压缩包条目
+func printArchiveEntries(archivePath string, entries []*download.ArchiveEntry, options *UIOptions) {
+	table := NewTablePrinter([]string{"MODE", "SIZE", "MODIFIED", "NAME"}, options)
+
+	var files int
+	var total int64
+	var executables []string
+	for _, entry := range entries {
+		name := entry.Name
+		size := "-"
+		switch {
+		case entry.IsLink():
+			name += " -> " + entry.Linkname
+		case !entry.IsDir:
+			files++
+			total += entry.Size
+			size = formatBytes(entry.Size)
+			if entry.Mode.Perm()&0111 != 0 || strings.HasSuffix(strings.ToLower(entry.Name), ".exe") {
+				executables = append(executables, entry.Name)
+			}
+		}
+
+		modified := "-"
+		if !entry.ModTime.IsZero() {
+			modified = entry.ModTime.Format("2006-01-02 15:04")
+		}
+		table.AddRow([]string{entry.Mode.String(), size, modified, name})
+	}
+
+	fmt.Printf("%s (%s)\n\n", ColorizeBold(filepath.Base(archivePath), options), download.DetectArchiveFormat(archivePath))
+	table.Print()
+	fmt.Printf("\n共 %d 个文件，%s\n", files, formatBytes(total))
+	if len(executables) > 0 {
+		fmt.Printf("可执行文件: %s\n", strings.Join(executables, ", "))
+	}
+	if root := download.ArchiveRoot(entries); root != "" {
+		fmt.Printf("顶层目录: %s/（安装时会自动去掉，可通过 strip_components 调整）\n", root)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(inspectArchiveCmd)
+
+	inspectArchiveCmd.Flags().String("extract", "", "只提取压缩包中的指定文件")
+	inspectArchiveCmd.Flags().StringP("output", "o", "", "--extract 的输出路径，默认为当前目录下的同名文件")
+	inspectArchiveCmd.Flags().Bool("keep", false, "保留下载的安装包")
+	inspectArchiveCmd.Flags().StringSlice("encoding", nil, "zip中非UTF-8文件名依次尝试的编码，如 gbk、shift_jis，默认根据系统语言环境选择")
+	inspectArchiveCmd.Flags().Bool("json", false, "使用JSON格式输出")
+	inspectArchiveCmd.Flags().Duration("timeout", 10*time.Minute, "下载的超时时间")
+}
@@ -0,0 +1,199 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/songzhibin97/vman/internal/download"
+	"github.com/songzhibin97/vman/pkg/types"
+	"github.com/songzhibin97/vman/pkg/utils"
+)
+
+// installPlan vman install --plan 的输出
+type installPlan struct {
+	Platform string               `json:"platform"`
+	Items    []*download.PlanItem `json:"items"`
+
+	// DownloadSize 需要下载的安装包总大小（字节），不包括大小未知的安装包
+	DownloadSize int64 `json:"download_size"`
+
+	// UnknownSizes 大小未知的安装包数
+	UnknownSizes int `json:"unknown_sizes"`
+
+	// RequiredDisk 估算需要的磁盘空间（字节），包括下载的临时文件和安装后占用的空间
+	RequiredDisk int64 `json:"required_disk"`
+
+	// AvailableDisk 版本目录所在分区的可用空间（字节），无法查询时为 -1
+	AvailableDisk int64 `json:"available_disk"`
+
+	// Errors 无法安装的工具数
+	Errors int `json:"errors"`
+}
+
+// add 将工具版本加入计划并累计大小
+func (p *installPlan) add(item *download.PlanItem) {
+	p.Items = append(p.Items, item)
+	switch item.Action {
+	case download.PlanActionError:
+		p.Errors++
+		return
+	case download.PlanActionInstalled:
+		return
+	}
+	if item.Size < 0 {
+		p.UnknownSizes++
+		return
+	}
+	if item.Action == download.PlanActionDownload {
+		p.DownloadSize += item.Size
+	}
+	p.RequiredDisk += item.Size + item.InstallSize
+}
+
+// runInstallPlan 输出安装计划：解析版本、选择安装包并查询大小，不下载和安装
+// args 为 install 命令的参数，为空时为项目配置中的工具
+func runInstallPlan(cmd *cobra.Command, args []string, groups []string, requiredOnly bool) error {
+	cmd.SilenceUsage = true
+	system, _ := cmd.Flags().GetBool("system")
+	force, _ := cmd.Flags().GetBool("force")
+	jsonFormat, _ := cmd.Flags().GetBool("json")
+
+	managers, err := createManagersForStore(system)
+	if err != nil {
+		return fmt.Errorf("创建管理器失败: %w", err)
+	}
+	versionManager, err := createIntegratedManagerForStore(system)
+	if err != nil {
+		return fmt.Errorf("创建管理器失败: %w", err)
+	}
+	downloadManager := download.NewManager(managers.storage, managers.config)
+
+	// 要安装的工具和版本要求
+	var tools []string
+	requested := make(map[string]string)
+	if len(args) == 0 {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("获取当前目录失败: %w", err)
+		}
+		requirements, err := projectToolRequirements(managers.config, cwd)
+		if err != nil {
+			return err
+		}
+		if tools, err = requirements.Select(groups, requiredOnly); err != nil {
+			return err
+		}
+		requested = requirements.Versions
+	} else {
+		tool := args[0]
+		if len(args) == 2 {
+			requested[tool] = args[1]
+		} else if name, version, ok := strings.Cut(tool, "@"); ok {
+			tool = name
+			requested[tool] = version
+		}
+		tools = []string{tool}
+	}
+
+	plan := &installPlan{Platform: types.PlatformDirName(), Items: []*download.PlanItem{}}
+	for _, tool := range tools {
+		target, installed, err := resolveRequiredVersion(versionManager, tool, requested[tool])
+		if err != nil {
+			plan.add(&download.PlanItem{Tool: tool, Requested: requested[tool], Action: download.PlanActionError, Size: -1, InstallSize: -1, Error: err.Error()})
+			continue
+		}
+		if installed && !force {
+			plan.add(&download.PlanItem{Tool: tool, Requested: requested[tool], Version: target, Action: download.PlanActionInstalled, Size: -1, InstallSize: -1})
+			continue
+		}
+
+		item, err := downloadManager.PlanInstall(context.Background(), tool, target)
+		if err != nil {
+			item = &download.PlanItem{Tool: tool, Version: target, Action: download.PlanActionError, Size: -1, InstallSize: -1, Error: err.Error()}
+		}
+		item.Requested = requested[tool]
+		plan.add(item)
+	}
+
+	plan.AvailableDisk = -1
+	if available, err := utils.AvailableDiskSpace(managers.storage.GetVersionsDir()); err == nil {
+		plan.AvailableDisk = int64(available)
+	}
+
+	if jsonFormat {
+		data, err := json.MarshalIndent(plan, "", "  ")
+		if err != nil {
+			return fmt.Errorf("序列化安装计划失败: %w", err)
+		}
+		fmt.Println(string(data))
+	} else {
+		printInstallPlan(plan, getUIOptions(cmd))
+	}
+
+	if plan.Errors > 0 {
+		return fmt.Errorf("%d 个工具无法安装", plan.Errors)
+	}
+	return nil
+}
+
+// printInstallPlan 以表格输出安装计划
+func printInstallPlan(plan *installPlan, options *UIOptions) {
+	if len(plan.Items) == 0 {
+		fmt.Println("没有需要安装的工具")
+		return
+	}
+
+	table := NewTablePrinter([]string{"TOOL", "REQUESTED", "VERSION", "ACTION", "SOURCE", "ASSET", "SIZE", "URL"}, options)
+	for _, item := range plan.Items {
+		size := "-"
+		if item.Size >= 0 {
+			size = formatBytes(item.Size)
+		} else if item.Action == download.PlanActionDownload {
+			size = "未知"
+		}
+		table.AddRow([]string{
+			item.Tool,
+			valueOrDash(item.Requested),
+			valueOrDash(item.Version),
+			item.Action,
+			valueOrDash(item.Source),
+			valueOrDash(item.Asset),
+			size,
+			valueOrDash(item.URL),
+		})
+	}
+	table.Print()
+
+	for _, item := range plan.Items {
+		if item.Error != "" {
+			PrintWarning(fmt.Sprintf("%s: %s", item.Tool, item.Error), options)
+		}
+	}
+
+	fmt.Printf("\n平台: %s\n", plan.Platform)
+	summary := fmt.Sprintf("需要下载: %s", formatBytes(plan.DownloadSize))
+	if plan.UnknownSizes > 0 {
+		summary += fmt.Sprintf("（另有 %d 个安装包大小未知）", plan.UnknownSizes)
+	}
+	fmt.Println(summary)
+	fmt.Printf("需要磁盘空间: 约 %s\n", formatBytes(plan.RequiredDisk))
+	if plan.AvailableDisk >= 0 {
+		fmt.Printf("可用磁盘空间: %s\n", formatBytes(plan.AvailableDisk))
+		if plan.RequiredDisk > plan.AvailableDisk {
+			PrintWarning("磁盘空间可能不足，可以运行 vman prune 清理长期未使用的版本", options)
+		}
+	}
+}
+
+// valueOrDash 空值显示为 -
+func valueOrDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
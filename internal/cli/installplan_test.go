@@ -0,0 +1,24 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/songzhibin97/vman/internal/download"
+)
+
+func TestInstallPlan_Add(t *testing.T) {
+	plan := &installPlan{}
+	plan.add(&download.PlanItem{Tool: "kubectl", Action: download.PlanActionDownload, Size: 1000, InstallSize: 3000})
+	plan.add(&download.PlanItem{Tool: "helm", Action: download.PlanActionCached, Size: 500, InstallSize: 1500})
+	plan.add(&download.PlanItem{Tool: "jq", Action: download.PlanActionDownload, Size: -1, InstallSize: -1})
+	plan.add(&download.PlanItem{Tool: "terraform", Action: download.PlanActionInstalled, Size: -1, InstallSize: -1})
+	plan.add(&download.PlanItem{Tool: "protoc", Action: download.PlanActionError, Size: -1, InstallSize: -1, Error: "下载地址返回 404"})
+
+	assert.Len(t, plan.Items, 5)
+	assert.Equal(t, int64(1000), plan.DownloadSize, "使用缓存的安装包不需要下载")
+	assert.Equal(t, int64(6000), plan.RequiredDisk)
+	assert.Equal(t, 1, plan.UnknownSizes)
+	assert.Equal(t, 1, plan.Errors)
+}
@@ -0,0 +1,544 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/spf13/cobra"
+
+	"github.com/songzhibin97/vman/internal/proxy"
+	"github.com/songzhibin97/vman/pkg/types"
+)
+
+var listCmd = &cobra.Command{
+	Use:   "list [tool]",
+	Short: "列出工具版本",
+	Long: `以表格列出所有工具及其版本，包括全局版本、当前目录使用的版本、占用空间、
+安装时间和最后使用时间。已在配置中选择但尚未安装的版本也会列出。
+
+过滤:
+  --tool <name>        只显示指定工具（也可以作为位置参数传入）
+  --outdated           只显示已安装了更新版本的旧版本
+  --unused[=<时长>]    只显示从未使用过的版本，或超过指定时长未使用的版本
+
+排序:
+  --sort tool|version|size|installed|used   默认按工具和版本排序
+  --reverse                                  倒序
+
+输出:
+  --tree        按工具分组显示为树形
+  --porcelain   稳定的制表符分隔输出，供脚本解析
+
+校验:
+  --verify      比较配置中记录的已安装版本与 versions 目录，列出不一致的条目
+  --fix         与 --verify 一起使用，以 versions 目录为准更新配置
+
+--porcelain 每行的字段依次为: 工具、版本、状态、字节数、安装时间、最后使用时间。
+状态为逗号分隔的 installed/missing/foreign/system、shared、global、project、env 等标记，
+shared 表示版本来自系统级共享存储；
+foreign 表示版本只为其他平台安装（共享的版本目录位于网络文件系统上时），
+并附带 foreign:<os>-<arch> 标记列出这些平台；
+时间为 RFC3339 格式，未知时为 "-"。
+
+示例:
+  vman list                        # 列出所有工具
+  vman list kubectl                # 列出kubectl的所有版本
+  vman list --unused=90d           # 90天未使用的版本
+  vman list --sort size --reverse  # 按占用空间从大到小
+  vman list --porcelain            # 供脚本使用
+  vman list --verify               # 检查配置与磁盘是否一致`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts := listOptions{}
+		opts.tool, _ = cmd.Flags().GetString("tool")
+		opts.outdated, _ = cmd.Flags().GetBool("outdated")
+		opts.sortBy, _ = cmd.Flags().GetString("sort")
+		opts.reverse, _ = cmd.Flags().GetBool("reverse")
+		opts.tree, _ = cmd.Flags().GetBool("tree")
+		opts.porcelain, _ = cmd.Flags().GetBool("porcelain")
+
+		if len(args) == 1 {
+			opts.tool = args[0]
+		}
+
+		verify, _ := cmd.Flags().GetBool("verify")
+		fix, _ := cmd.Flags().GetBool("fix")
+		if fix && !verify {
+			return fmt.Errorf("--fix can only be used with --verify")
+		}
+
+		if cmd.Flags().Changed("unused") {
+			unused, _ := cmd.Flags().GetString("unused")
+			opts.unusedOnly = true
+			if unused != "" && unused != "never" {
+				threshold, err := parseAge(unused)
+				if err != nil {
+					return err
+				}
+				opts.unusedFor = threshold
+			}
+		}
+
+		switch opts.sortBy {
+		case "", "tool", "version", "size", "installed", "used":
+		default:
+			return fmt.Errorf("unsupported sort key: %s", opts.sortBy)
+		}
+
+		managers, err := createManagers()
+		if err != nil {
+			return fmt.Errorf("failed to create managers: %w", err)
+		}
+
+		if verify {
+			return verifyInstalledVersions(cmd, managers, opts.tool, fix)
+		}
+
+		entries, err := collectListEntries(managers, opts.tool)
+		if err != nil {
+			return err
+		}
+
+		entries = filterListEntries(entries, opts, time.Now())
+		sortListEntries(entries, opts.sortBy, opts.reverse)
+
+		switch {
+		case opts.porcelain:
+			printListPorcelain(entries)
+		case len(entries) == 0:
+			if opts.tool != "" {
+				fmt.Printf("No versions installed for %s\n", opts.tool)
+			} else {
+				fmt.Println("No tools installed")
+			}
+		case opts.tree:
+			printListTree(entries)
+		default:
+			printListTable(entries, getUIOptions(cmd))
+		}
+
+		return nil
+	},
+}
+
+// listOptions list命令选项
+type listOptions struct {
+	tool       string
+	outdated   bool
+	unusedOnly bool
+	unusedFor  time.Duration
+	sortBy     string
+	reverse    bool
+	tree       bool
+	porcelain  bool
+}
+
+// listEntry list命令的一行
+type listEntry struct {
+	Tool        string
+	Version     string
+	Installed   bool
+	Shared      bool     // 来自系统级共享存储
+	Foreign     []string // 只为其他平台安装时的平台列表
+	Global      bool
+	Active      bool
+	Source      string // 当前目录生效版本的来源: project, env, global, latest
+	Outdated    bool
+	Size        int64
+	InstalledAt time.Time
+	LastUsed    time.Time
+}
+
+// collectListEntries 收集所有工具版本信息
+func collectListEntries(managers *managers, tool string) ([]*listEntry, error) {
+	tools := []string{tool}
+	if tool == "" {
+		allTools, err := managers.version.ListAllTools()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list tools: %w", err)
+		}
+		tools = allTools
+	}
+
+	globalVersions := map[string]string{}
+	if globalConfig, err := managers.config.LoadGlobal(); err == nil && globalConfig.GlobalVersions != nil {
+		globalVersions = globalConfig.GlobalVersions
+		if tool == "" {
+			// 已配置全局版本但尚未安装任何版本的工具也需要列出
+			for t := range globalVersions {
+				if !containsString(tools, t) {
+					tools = append(tools, t)
+				}
+			}
+		}
+	}
+
+	cwd, _ := os.Getwd()
+	resolver := proxy.NewVersionResolver(managers.config, managers.version)
+
+	var entries []*listEntry
+	for _, t := range tools {
+		versions, err := managers.version.ListVersions(t)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list versions for %s: %w", t, err)
+		}
+
+		latest, _ := managers.version.GetLatestVersion(t)
+
+		var activeVersion, activeSource string
+		if cwd != "" {
+			if resolution, err := resolver.ResolveVersion(context.Background(), t, cwd); err == nil {
+				activeVersion = resolution.Version
+				activeSource = resolution.Source
+			}
+		}
+
+		seen := make(map[string]bool)
+		for _, v := range versions {
+			seen[v] = true
+			entry := &listEntry{
+				Tool:      t,
+				Version:   v,
+				Installed: true,
+				Shared:    managers.storage.IsSystemVersion(t, v),
+				Global:    v == globalVersions[t],
+				Active:    v == activeVersion,
+				Outdated:  latest != "" && v != latest,
+			}
+			if entry.Active {
+				entry.Source = activeSource
+			}
+
+			versionPath := managers.storage.GetToolVersionPath(t, v)
+			entry.Size, _ = calculateDirSize(versionPath)
+			entry.LastUsed, _ = managers.storage.GetLastUsed(t, v)
+			if metadata, err := managers.version.GetVersionMetadata(t, v); err == nil {
+				entry.InstalledAt = metadata.InstalledAt
+			} else if stat, err := os.Stat(versionPath); err == nil {
+				entry.InstalledAt = stat.ModTime()
+			}
+
+			entries = append(entries, entry)
+		}
+
+		// 共享版本目录中只为其他平台安装的版本
+		foreign, err := managers.storage.GetForeignVersions(t)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list foreign versions for %s: %w", t, err)
+		}
+		for v, platforms := range foreign {
+			seen[v] = true
+			entry := &listEntry{
+				Tool:    t,
+				Version: v,
+				Foreign: platforms,
+				Global:  v == globalVersions[t],
+				Active:  v == activeVersion,
+			}
+			if entry.Active {
+				entry.Source = activeSource
+			}
+			entries = append(entries, entry)
+		}
+
+		// 已选择但未安装的版本
+		for _, v := range []string{globalVersions[t], activeVersion} {
+			if v == "" || seen[v] {
+				continue
+			}
+			seen[v] = true
+			entry := &listEntry{
+				Tool:    t,
+				Version: v,
+				Global:  v == globalVersions[t],
+				Active:  v == activeVersion,
+			}
+			if entry.Active {
+				entry.Source = activeSource
+			}
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries, nil
+}
+
+// filterListEntries 按选项过滤
+func filterListEntries(entries []*listEntry, opts listOptions, now time.Time) []*listEntry {
+	var result []*listEntry
+	for _, e := range entries {
+		if opts.outdated && !e.Outdated {
+			continue
+		}
+		if opts.unusedOnly {
+			if !e.Installed {
+				continue
+			}
+			if opts.unusedFor == 0 {
+				if !e.LastUsed.IsZero() {
+					continue
+				}
+			} else {
+				reference := e.LastUsed
+				if reference.IsZero() {
+					reference = e.InstalledAt
+				}
+				if reference.IsZero() || now.Sub(reference) < opts.unusedFor {
+					continue
+				}
+			}
+		}
+		result = append(result, e)
+	}
+	return result
+}
+
+// sortListEntries 排序
+func sortListEntries(entries []*listEntry, sortBy string, reverse bool) {
+	less := func(a, b *listEntry) bool {
+		switch sortBy {
+		case "size":
+			if a.Size != b.Size {
+				return a.Size < b.Size
+			}
+		case "installed":
+			if !a.InstalledAt.Equal(b.InstalledAt) {
+				return a.InstalledAt.Before(b.InstalledAt)
+			}
+		case "used":
+			if !a.LastUsed.Equal(b.LastUsed) {
+				return a.LastUsed.Before(b.LastUsed)
+			}
+		case "version":
+			if c := compareVersionStrings(a.Version, b.Version); c != 0 {
+				return c < 0
+			}
+		}
+		if a.Tool != b.Tool {
+			return a.Tool < b.Tool
+		}
+		return compareVersionStrings(a.Version, b.Version) < 0
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		if reverse {
+			return less(entries[j], entries[i])
+		}
+		return less(entries[i], entries[j])
+	})
+}
+
+// compareVersionStrings 比较版本号，无法按语义化版本解析时按字符串比较
+func compareVersionStrings(a, b string) int {
+	va, errA := semver.NewVersion(a)
+	vb, errB := semver.NewVersion(b)
+	if errA == nil && errB == nil {
+		return va.Compare(vb)
+	}
+	return strings.Compare(a, b)
+}
+
+// statusFlags 返回条目的状态标记
+func (e *listEntry) statusFlags() []string {
+	var flags []string
+	switch {
+	case e.Version == types.SystemVersion:
+		flags = append(flags, "system")
+	case e.Installed:
+		flags = append(flags, "installed")
+	case len(e.Foreign) > 0:
+		flags = append(flags, "foreign")
+	default:
+		flags = append(flags, "missing")
+	}
+	for _, platform := range e.Foreign {
+		flags = append(flags, "foreign:"+platform)
+	}
+	if e.Shared {
+		flags = append(flags, "shared")
+	}
+	if e.Global {
+		flags = append(flags, "global")
+	}
+	if e.Active && e.Source != "" && e.Source != "global" {
+		flags = append(flags, e.Source)
+	}
+	if e.Outdated {
+		flags = append(flags, "outdated")
+	}
+	return flags
+}
+
+// printListTable 以表格输出
+func printListTable(entries []*listEntry, options *UIOptions) {
+	table := NewTablePrinter([]string{"", "TOOL", "VERSION", "STATUS", "SIZE", "INSTALLED", "LAST USED"}, options)
+	for _, e := range entries {
+		table.AddRow(listRow(e))
+	}
+	table.Print()
+}
+
+// printListTree 按工具分组输出
+func printListTree(entries []*listEntry) {
+	var current string
+	for i, e := range entries {
+		if e.Tool != current {
+			current = e.Tool
+			fmt.Println(e.Tool)
+		}
+
+		branch := "├──"
+		if i == len(entries)-1 || entries[i+1].Tool != e.Tool {
+			branch = "└──"
+		}
+
+		row := listRow(e)
+		fmt.Printf("%s %s %s [%s] %s，安装于 %s，最后使用 %s\n", branch, row[0], e.Version, row[3], row[4], row[5], row[6])
+	}
+}
+
+// listRow 生成表格行
+func listRow(e *listEntry) []string {
+	marker := " "
+	if e.Active {
+		marker = "*"
+	}
+
+	size, installed, lastUsed := "-", "-", "从未使用"
+	if e.Installed {
+		size = formatBytes(e.Size)
+	}
+	if !e.InstalledAt.IsZero() {
+		installed = e.InstalledAt.Format("2006-01-02")
+	}
+	if !e.LastUsed.IsZero() {
+		lastUsed = e.LastUsed.Format("2006-01-02")
+	} else if !e.Installed {
+		lastUsed = "-"
+	}
+
+	return []string{marker, e.Tool, e.Version, strings.Join(e.statusFlags(), ","), size, installed, lastUsed}
+}
+
+// printListPorcelain 以稳定格式输出
+func printListPorcelain(entries []*listEntry) {
+	formatTime := func(t time.Time) string {
+		if t.IsZero() {
+			return "-"
+		}
+		return t.UTC().Format(time.RFC3339)
+	}
+
+	for _, e := range entries {
+		fmt.Printf("%s\t%s\t%s\t%d\t%s\t%s\n",
+			e.Tool, e.Version, strings.Join(e.statusFlags(), ","), e.Size,
+			formatTime(e.InstalledAt), formatTime(e.LastUsed))
+	}
+}
+
+// verifyInstalledVersions 比较配置中记录的已安装版本与versions目录
+func verifyInstalledVersions(cmd *cobra.Command, managers *managers, tool string, fix bool) error {
+	tools := []string{tool}
+	if tool == "" {
+		var err error
+		tools, err = managers.version.ListAllTools()
+		if err != nil {
+			return fmt.Errorf("failed to list tools: %w", err)
+		}
+		// 只存在于配置中的工具也需要检查
+		if globalConfig, err := managers.config.LoadGlobal(); err == nil {
+			for t := range globalConfig.Tools {
+				if !containsString(tools, t) {
+					tools = append(tools, t)
+				}
+			}
+			for t := range globalConfig.GlobalVersions {
+				if !containsString(tools, t) {
+					tools = append(tools, t)
+				}
+			}
+		}
+		sort.Strings(tools)
+	}
+
+	options := getUIOptions(cmd)
+	table := NewTablePrinter([]string{"TOOL", "VERSION", "PROBLEM"}, options)
+	drifted, staleGlobal := 0, 0
+	for _, t := range tools {
+		verifyFunc := managers.version.VerifyInstalledVersions
+		if fix {
+			verifyFunc = managers.version.ReconcileInstalledVersions
+		}
+		drift, err := verifyFunc(t)
+		if err != nil {
+			return fmt.Errorf("failed to verify %s: %w", t, err)
+		}
+		if !drift.HasDrift() {
+			continue
+		}
+		drifted++
+
+		for _, v := range drift.Untracked {
+			table.AddRow([]string{t, v, "installed but not recorded in config"})
+		}
+		for _, v := range drift.Missing {
+			table.AddRow([]string{t, v, "recorded in config but not installed"})
+		}
+		if drift.StaleCurrent != "" {
+			table.AddRow([]string{t, drift.StaleCurrent, "current version is not installed"})
+		}
+		if drift.StaleGlobal != "" {
+			staleGlobal++
+			table.AddRow([]string{t, drift.StaleGlobal, "global version is not installed"})
+		}
+	}
+
+	if drifted == 0 {
+		PrintSuccess("Config matches installed versions", options)
+		return nil
+	}
+
+	table.Print()
+	fmt.Println()
+	if fix {
+		PrintSuccess(fmt.Sprintf("Updated installed versions of %d tool(s) from disk", drifted), options)
+		if staleGlobal > 0 {
+			fmt.Println("Global versions are not changed; run 'vman global <tool> <version>' to select an installed version")
+		}
+		return nil
+	}
+
+	fmt.Println("Run 'vman list --verify --fix' to update the config from the versions directory")
+	cmd.SilenceUsage = true
+	return fmt.Errorf("found discrepancies in %d tool(s)", drifted)
+}
+
+// containsString 检查切片中是否包含字符串
+func containsString(items []string, item string) bool {
+	for _, i := range items {
+		if i == item {
+			return true
+		}
+	}
+	return false
+}
+
+func init() {
+	listCmd.Flags().String("tool", "", "只显示指定工具")
+	listCmd.Flags().Bool("outdated", false, "只显示已安装了更新版本的旧版本")
+	listCmd.Flags().String("unused", "", "只显示从未使用或超过指定时长未使用的版本，如 --unused=90d")
+	listCmd.Flags().Lookup("unused").NoOptDefVal = "never"
+	listCmd.Flags().String("sort", "tool", "排序字段: tool, version, size, installed, used")
+	listCmd.Flags().Bool("reverse", false, "倒序排列")
+	listCmd.Flags().Bool("tree", false, "按工具分组显示为树形")
+	listCmd.Flags().Bool("porcelain", false, "稳定的制表符分隔输出，供脚本解析")
+	listCmd.Flags().Bool("verify", false, "比较配置中记录的已安装版本与versions目录")
+	listCmd.Flags().Bool("fix", false, "与 --verify 一起使用，以versions目录为准更新配置")
+
+	regroupCommand(versionCmd, "list", listCmd)
+}
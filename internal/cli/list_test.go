@@ -0,0 +1,71 @@
+package cli
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestListEntries(now time.Time) []*listEntry {
+	return []*listEntry{
+		{Tool: "kubectl", Version: "1.9.0", Installed: true, Outdated: true, Size: 300, InstalledAt: now.Add(-400 * 24 * time.Hour)},
+		{Tool: "kubectl", Version: "1.10.0", Installed: true, Global: true, Size: 100, InstalledAt: now.Add(-10 * 24 * time.Hour), LastUsed: now.Add(-24 * time.Hour)},
+		{Tool: "helm", Version: "3.0.0", Installed: true, Size: 200, InstalledAt: now.Add(-200 * 24 * time.Hour), LastUsed: now.Add(-100 * 24 * time.Hour)},
+		{Tool: "helm", Version: "3.1.0", Global: true},
+	}
+}
+
+func TestFilterListEntries(t *testing.T) {
+	now := time.Now()
+	entries := newTestListEntries(now)
+
+	outdated := filterListEntries(entries, listOptions{outdated: true}, now)
+	assert.Len(t, outdated, 1)
+	assert.Equal(t, "1.9.0", outdated[0].Version)
+
+	neverUsed := filterListEntries(entries, listOptions{unusedOnly: true}, now)
+	assert.Len(t, neverUsed, 1)
+	assert.Equal(t, "1.9.0", neverUsed[0].Version)
+
+	unused := filterListEntries(entries, listOptions{unusedOnly: true, unusedFor: 90 * 24 * time.Hour}, now)
+	assert.Len(t, unused, 2)
+}
+
+func TestListRow(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.Local)
+	entries := newTestListEntries(now)
+
+	assert.Equal(t, "从未使用", listRow(entries[0])[6])
+	assert.Equal(t, "2024-05-31", listRow(entries[1])[6])
+	// 尚未安装的版本没有使用时间
+	assert.Equal(t, "-", listRow(entries[3])[6])
+}
+
+func TestSortListEntries(t *testing.T) {
+	entries := newTestListEntries(time.Now())
+
+	sortListEntries(entries, "tool", false)
+	var order []string
+	for _, e := range entries {
+		order = append(order, e.Tool+"@"+e.Version)
+	}
+	assert.Equal(t, []string{"helm@3.0.0", "helm@3.1.0", "kubectl@1.9.0", "kubectl@1.10.0"}, order)
+
+	sortListEntries(entries, "size", true)
+	assert.Equal(t, int64(300), entries[0].Size)
+}
+
+func TestListEntryStatusFlags(t *testing.T) {
+	e := &listEntry{Installed: true, Global: true, Active: true, Source: "project"}
+	assert.Equal(t, []string{"installed", "global", "project"}, e.statusFlags())
+
+	e = &listEntry{Global: true}
+	assert.Equal(t, []string{"missing", "global"}, e.statusFlags())
+}
+
+func TestCompareVersionStrings(t *testing.T) {
+	assert.Equal(t, -1, compareVersionStrings("1.9.0", "1.10.0"))
+	assert.Equal(t, 0, compareVersionStrings("v1.2.3", "1.2.3"))
+	assert.Equal(t, 1, compareVersionStrings("nightly", "beta"))
+}
@@ -0,0 +1,55 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/songzhibin97/vman/internal/server"
+	"github.com/songzhibin97/vman/pkg/vman"
+)
+
+// lspCmd 通过标准输入输出提供版本状态查询
+var lspCmd = &cobra.Command{
+	Use:   "lsp",
+	Short: "通过标准输入输出提供版本状态查询（供编辑器扩展使用）",
+	Long: fmt.Sprintf(`以长期运行的模式在标准输入输出上提供 JSON-RPC 2.0 服务，回答"哪些版本作用于该文件夹"的查询，
+供编辑器扩展在状态栏显示如 "terraform 1.6.0 (project)" 的信息。
+
+消息使用与 LSP 相同的 Content-Length 头分帧，可以直接使用 vscode-languageclient 等 LSP 客户端库。
+
+方法:
+  initialize                            开始监视 workspaceFolders（或 rootUri）中的文件夹
+  vman/status {"folder": "<路径或URI>"}   返回文件夹中各工具生效的版本，并开始监视该文件夹
+  workspace/didChangeWorkspaceFolders   增加或移除监视的文件夹
+  shutdown / exit                       结束服务
+
+监视的文件夹中工具版本变化时（如修改了项目配置、切换了全局版本）发送 %s 通知。`, server.StatusChangedMethod),
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		interval, _ := cmd.Flags().GetDuration("watch-interval")
+
+		cmd.SilenceUsage = true
+
+		client, err := vman.New(nil)
+		if err != nil {
+			return fmt.Errorf("创建管理器失败: %w", err)
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		return server.NewStatusServer(client, interval).Serve(ctx, os.Stdin, os.Stdout)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(lspCmd)
+
+	lspCmd.Flags().Duration("watch-interval", 2*time.Second, "检查监视的文件夹中版本变化的间隔，0 表示不监视")
+}
@@ -0,0 +1,22 @@
+package cli
+
+import (
+	"os"
+	"testing"
+)
+
+// TestMain 将 HOME 指向临时目录，测试中运行的命令不会写入真实的或相对于仓库的配置目录
+func TestMain(m *testing.M) {
+	home, err := os.MkdirTemp("", "vman-cli-test-home")
+	if err != nil {
+		panic(err)
+	}
+	os.Setenv("HOME", home)
+	for _, name := range []string{"VMAN_HOME", "XDG_CONFIG_HOME", "XDG_DATA_HOME", "XDG_CACHE_HOME"} {
+		os.Unsetenv(name)
+	}
+
+	code := m.Run()
+	os.RemoveAll(home)
+	os.Exit(code)
+}
@@ -0,0 +1,387 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+
+	"github.com/songzhibin97/vman/internal/importer"
+	"github.com/songzhibin97/vman/internal/storage"
+	"github.com/songzhibin97/vman/pkg/types"
+	"github.com/songzhibin97/vman/pkg/utils"
+)
+
+// migrateCmd 升级存储目录结构，或从其他版本管理器导入
+var migrateCmd = &cobra.Command{
+	Use:         "migrate",
+	Annotations: mutating(),
+	Short:       "升级存储目录结构或从其他版本管理器迁移",
+	Long: `将 vman 存储目录升级到当前版本使用的目录结构。
+
+目录结构版本记录在配置目录下的 .layout-version 文件中。
+正常情况下首次运行新版本 vman 时会自动完成迁移，此命令用于预览或手动执行迁移。
+迁移前会备份受影响的文件，任一步骤失败时自动从备份恢复。
+
+使用 --from 从 asdf、nvm、pyenv 或 tfenv 迁移：扫描其已安装的版本，通过符号链接复用已下载的文件
+注册为 vman 的版本，为有recipe的工具创建工具定义，并把其全局版本写入 vman 的全局配置
+（vman 中已设置的全局版本不覆盖）。--project 同时把当前目录的版本文件（如 .tool-versions）写入 .vman.yaml。
+链接的版本依赖原安装目录，卸载原版本管理器前需要用 vman install 重新安装这些版本。
+
+示例:
+  vman migrate --dry-run   # 只显示将要执行的迁移步骤
+  vman migrate             # 执行迁移
+  vman migrate --from asdf --dry-run
+  vman migrate --from nvm --project`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		if from, _ := cmd.Flags().GetString("from"); from != "" {
+			project, _ := cmd.Flags().GetBool("project")
+			return runToolImport(cmd, from, dryRun, project)
+		}
+		if project, _ := cmd.Flags().GetBool("project"); project {
+			return fmt.Errorf("--project 需要与 --from 一起使用")
+		}
+
+		homeDir, err := utils.GetHomeDir()
+		if err != nil {
+			return fmt.Errorf("获取用户主目录失败: %w", err)
+		}
+
+		// 不经过 createManagers，避免在 --dry-run 时被自动迁移
+		migrator := storage.NewLayoutMigrator(types.DefaultConfigPaths(homeDir))
+
+		plan, err := migrator.Migrate(dryRun)
+		if err != nil {
+			cmd.SilenceUsage = true
+			return err
+		}
+
+		printLayoutMigrationPlan(plan, dryRun, getUIOptions(cmd))
+		return nil
+	},
+}
+
+// printLayoutMigrationPlan 输出迁移计划或迁移结果
+func printLayoutMigrationPlan(plan *storage.LayoutMigrationPlan, dryRun bool, options *UIOptions) {
+	if plan.Fresh {
+		if dryRun {
+			fmt.Printf("尚未安装任何内容，将直接记录目录结构版本 %d\n", plan.To)
+		} else {
+			PrintSuccess(fmt.Sprintf("已记录目录结构版本 %d", plan.To), options)
+		}
+		return
+	}
+
+	if !plan.NeedsMigration() {
+		fmt.Printf("存储目录结构已是最新版本 (%d)\n", plan.To)
+		return
+	}
+
+	fmt.Printf("存储目录结构版本: %d -> %d\n", plan.From, plan.To)
+	for _, step := range plan.Steps {
+		fmt.Printf("  - v%d -> v%d: %s\n", step.From, step.From+1, step.Description)
+	}
+
+	if dryRun {
+		fmt.Println("\n(dry-run) 未做任何修改")
+		return
+	}
+
+	if plan.BackupPath != "" {
+		fmt.Printf("备份位置: %s\n", plan.BackupPath)
+	}
+	PrintSuccess("存储目录结构迁移完成", options)
+}
+
+// runToolImport 从其他版本管理器导入已安装的版本、工具定义和版本设置
+func runToolImport(cmd *cobra.Command, source string, dryRun, project bool) error {
+	uiOptions := getUIOptions(cmd)
+	cmd.SilenceUsage = true
+
+	homeDir, err := utils.GetHomeDir()
+	if err != nil {
+		return fmt.Errorf("获取用户主目录失败: %w", err)
+	}
+	projectDir := ""
+	if project {
+		if projectDir, err = os.Getwd(); err != nil {
+			return fmt.Errorf("获取当前目录失败: %w", err)
+		}
+	}
+
+	result, err := importer.Scan(afero.NewOsFs(), source, homeDir, projectDir)
+	if err != nil {
+		return err
+	}
+	managers, err := createManagers()
+	if err != nil {
+		return fmt.Errorf("创建管理器失败: %w", err)
+	}
+
+	// 按工具整理：已在 vman 中安装的版本跳过，垫片名冲突的工具跳过
+	var pending []importer.Installation
+	blocked := make(map[string]error)
+	fmt.Printf("%s (%s) 中已安装的版本:\n", source, result.Root)
+	for _, installation := range result.Installations {
+		status := "导入"
+		if _, checked := blocked[installation.Tool]; !checked {
+			blocked[installation.Tool] = checkToolShimName(installation.Tool)
+		}
+		switch {
+		case blocked[installation.Tool] != nil:
+			status = fmt.Sprintf("跳过: %v", blocked[installation.Tool])
+		case managers.version.IsVersionInstalled(installation.Tool, installation.Version):
+			status = "已安装，跳过"
+		default:
+			pending = append(pending, installation)
+		}
+		fmt.Printf("  %s %s (%s) - %s\n", installation.Tool, installation.Version, installation.Dir, status)
+	}
+	for _, dir := range sortedKeys(result.Skipped) {
+		fmt.Printf("  %s - 跳过: %s\n", dir, result.Skipped[dir])
+	}
+	if len(result.Installations) == 0 {
+		fmt.Println("  (无)")
+	}
+
+	globalConfig, err := managers.config.LoadGlobal()
+	if err != nil {
+		return fmt.Errorf("加载全局配置失败: %w", err)
+	}
+	globals := make(map[string]string)
+	for _, tool := range sortedKeys(result.GlobalVersions) {
+		if current := globalConfig.GlobalVersions[tool]; current != "" {
+			fmt.Printf("全局版本 %s %s: vman 中已设置为 %s，不覆盖\n", tool, result.GlobalVersions[tool], current)
+			continue
+		}
+		if blocked[tool] == nil {
+			globals[tool] = result.GlobalVersions[tool]
+			fmt.Printf("全局版本: %s %s\n", tool, result.GlobalVersions[tool])
+		}
+	}
+	if project {
+		if result.ProjectFile == "" {
+			fmt.Printf("当前目录没有 %s 的版本文件\n", source)
+		}
+		for _, tool := range sortedKeys(result.ProjectVersions) {
+			fmt.Printf("项目版本 (%s): %s %s\n", result.ProjectFile, tool, result.ProjectVersions[tool])
+		}
+	}
+
+	if len(pending) == 0 && len(globals) == 0 && len(result.ProjectVersions) == 0 {
+		fmt.Println("没有需要迁移的内容")
+		return nil
+	}
+	if dryRun {
+		fmt.Println("\n(dry-run) 未做任何修改")
+		return nil
+	}
+	confirmed, err := confirmAction(cmd, fmt.Sprintf("从 %s 迁移以上内容吗？", source))
+	if err != nil {
+		return err
+	}
+	if !confirmed {
+		fmt.Println("操作已取消")
+		return nil
+	}
+
+	// 为没有定义的工具使用recipe创建工具定义，没有recipe的工具只注册版本
+	paths := types.DefaultConfigPaths(homeDir)
+	defined := make(map[string]bool)
+	for _, installation := range pending {
+		if defined[installation.Tool] {
+			continue
+		}
+		defined[installation.Tool] = true
+		if _, err := managers.config.LoadToolConfig(installation.Tool); err == nil {
+			continue
+		}
+		found, err := recipeRegistry(managers.config).Lookup(installation.Tool)
+		if err != nil {
+			PrintWarning(fmt.Sprintf("%s 没有recipe，只注册已安装的版本，可以使用 'vman add %s' 添加工具定义", installation.Tool, installation.Tool), uiOptions)
+			continue
+		}
+		data, err := encodeToolMetadata(metadataFromRecipe(found, &addToolOptions{}))
+		if err != nil {
+			return err
+		}
+		toolFile := filepath.Join(paths.ToolsDir, installation.Tool+".toml")
+		if err := os.MkdirAll(paths.ToolsDir, 0755); err != nil {
+			return fmt.Errorf("创建工具定义目录失败: %w", err)
+		}
+		if err := utils.WritePrivateFile(afero.NewOsFs(), toolFile, data); err != nil {
+			return fmt.Errorf("写入工具定义失败: %w", err)
+		}
+		fmt.Printf("已创建工具定义: %s\n", toolFile)
+	}
+
+	imported := 0
+	for _, installation := range pending {
+		if err := importInstallation(managers, installation); err != nil {
+			PrintError(fmt.Sprintf("导入 %s@%s 失败: %v", installation.Tool, installation.Version, err), uiOptions)
+			continue
+		}
+		imported++
+	}
+	for _, tool := range sortedKeys(globals) {
+		if err := managers.version.SetGlobalVersion(tool, globals[tool]); err != nil {
+			PrintWarning(fmt.Sprintf("设置全局版本 %s@%s 失败: %v", tool, globals[tool], err), uiOptions)
+		}
+	}
+	if len(result.ProjectVersions) > 0 {
+		projectConfig, err := managers.config.LoadProject(projectDir)
+		if err != nil {
+			return fmt.Errorf("加载项目配置失败: %w", err)
+		}
+		if projectConfig.Tools == nil {
+			projectConfig.Tools = make(map[string]string)
+		}
+		for tool, v := range result.ProjectVersions {
+			if _, ok := projectConfig.Tools[tool]; !ok {
+				projectConfig.Tools[tool] = v
+			}
+		}
+		if err := managers.config.SaveProject(projectDir, projectConfig); err != nil {
+			return fmt.Errorf("保存项目配置失败: %w", err)
+		}
+		fmt.Printf("已更新 %s\n", managers.config.GetProjectConfigPath(projectDir))
+	}
+
+	if err := regenerateShims(); err != nil {
+		fmt.Printf("警告: 重新生成垫片失败: %v\n", err)
+	}
+	PrintSuccess(fmt.Sprintf("已从 %s 导入 %d 个版本", source, imported), uiOptions)
+	return nil
+}
+
+// importInstallation 链接其他版本管理器的安装目录，注册为 vman 的版本
+func importInstallation(managers *managers, installation importer.Installation) error {
+	versionDir := managers.storage.GetToolVersionPath(installation.Tool, installation.Version)
+	if err := importer.Link(installation, versionDir); err != nil {
+		os.RemoveAll(versionDir)
+		return err
+	}
+
+	metadata := &types.VersionMetadata{
+		Version:     installation.Version,
+		ToolName:    installation.Tool,
+		InstallPath: versionDir,
+		BinaryPath:  managers.storage.GetBinaryPath(installation.Tool, installation.Version),
+		InstalledAt: time.Now(),
+		InstallType: "migrated",
+		Source:      installation.Dir,
+	}
+	if err := managers.storage.SaveVersionMetadata(installation.Tool, installation.Version, metadata); err != nil {
+		os.RemoveAll(versionDir)
+		return err
+	}
+	_, err := managers.version.ReconcileInstalledVersions(installation.Tool)
+	return err
+}
+
+// migrateHomeCmd 将已有数据迁移到 VMAN_HOME 或 XDG 目录
+var migrateHomeCmd = &cobra.Command{
+	Use:         "migrate-home",
+	Annotations: mutating(),
+	Short:       "将已有数据迁移到新的vman主目录",
+	Long: `将已安装的版本、垫片、配置和缓存从旧目录移动到当前生效的目录。
+
+当前生效的目录由以下环境变量决定（优先级从高到低）:
+  VMAN_HOME   所有数据存放在该目录下
+  VMAN_XDG=1  按XDG规范拆分: 配置在 $XDG_CONFIG_HOME/vman，
+              数据在 $XDG_DATA_HOME/vman，缓存在 $XDG_CACHE_HOME/vman
+默认从平台默认目录迁移，可使用 --from 指定旧的根目录。
+
+示例:
+  VMAN_HOME=/data/vman vman migrate-home --dry-run
+  VMAN_XDG=1 vman migrate-home
+  vman migrate-home --from ~/.vman`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		from, _ := cmd.Flags().GetString("from")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		homeDir, err := utils.GetHomeDir()
+		if err != nil {
+			return fmt.Errorf("获取用户主目录失败: %w", err)
+		}
+
+		source := types.PlatformConfigPaths(homeDir)
+		if from != "" {
+			fromDir, err := utils.ExpandPath(from)
+			if err != nil {
+				return fmt.Errorf("解析路径失败: %w", err)
+			}
+			source = types.ConfigPathsFromRoot(fromDir)
+		}
+		target := types.DefaultConfigPaths(homeDir)
+
+		fs := afero.NewOsFs()
+		moves, err := storage.PlanHomeMoves(fs, source, target)
+		if err != nil {
+			cmd.SilenceUsage = true
+			return err
+		}
+
+		if len(moves) == 0 {
+			fmt.Printf("没有需要迁移的内容（源目录: %s）\n", source.ConfigDir)
+			if from == "" && os.Getenv(types.EnvVmanHome) == "" && os.Getenv(types.EnvVmanXDG) == "" {
+				fmt.Println("提示: 请先设置 VMAN_HOME 或 VMAN_XDG=1 指定新的目录")
+			}
+			return nil
+		}
+
+		fmt.Println("将执行以下移动:")
+		for _, move := range moves {
+			fmt.Printf("  %s: %s -> %s\n", move.Name, move.From, move.To)
+		}
+
+		if dryRun {
+			fmt.Println("\n(dry-run) 未做任何修改")
+			return nil
+		}
+
+		confirmed, err := confirmAction(cmd, "确定要移动这些文件吗？")
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			fmt.Println("操作已取消")
+			return nil
+		}
+
+		if err := storage.MoveHome(fs, moves); err != nil {
+			cmd.SilenceUsage = true
+			return err
+		}
+
+		// 垫片可能记录了旧目录中的路径
+		if err := regenerateShims(); err != nil {
+			fmt.Printf("警告: 重新生成垫片失败: %v\n", err)
+		}
+
+		PrintSuccess("迁移完成", getUIOptions(cmd))
+		if source.ShimsDir != target.ShimsDir {
+			fmt.Printf("请将 shell 配置中的 PATH 更新为: %s\n", target.ShimsDir)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(migrateCmd)
+	rootCmd.AddCommand(migrateHomeCmd)
+
+	migrateCmd.Flags().Bool("dry-run", false, "只显示将要执行的迁移步骤")
+	migrateCmd.Flags().String("from", "", "从其他版本管理器迁移: "+strings.Join(importer.Sources, ", "))
+	migrateCmd.Flags().Bool("project", false, "同时将当前目录的版本文件写入 .vman.yaml（需要 --from）")
+
+	migrateHomeCmd.Flags().String("from", "", "旧的vman根目录（默认为平台默认目录）")
+	migrateHomeCmd.Flags().Bool("dry-run", false, "只显示将要移动的内容")
+}
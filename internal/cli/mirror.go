@@ -0,0 +1,250 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+
+	"github.com/songzhibin97/vman/internal/download"
+)
+
+// mirrorCmd 将工具各平台的安装包下载到内部镜像
+var mirrorCmd = &cobra.Command{
+	Use:         "mirror [tool@version...] --dest <dir|s3://bucket/prefix>",
+	Annotations: mutating(),
+	Short:       "下载工具各平台的安装包到内部镜像",
+	Long: `按工具定义下载指定版本在所有平台的安装包，写入镜像目录，用于向离线环境同步工具。
+
+镜像布局为 <dest>/<工具>/<版本>/<os>-<arch>/，目录中包含安装包和记录校验和的 mirror.json。
+将镜像目录发布为静态HTTP服务或对象存储后，在其他机器上设置 settings.download.mirror 为其地址，
+安装时优先从镜像下载并校验，镜像中没有的版本仍使用原下载源。
+
+版本可以用 tool@version 参数指定，也可以用 --versions-from 从文件读取：
+  - .vman.yaml：项目配置中 tools 和 paths 的所有版本（先用 vman lock 固定为精确版本）
+  - .vman-version、.tool-versions：每行一个 "工具 版本"
+--tools 只镜像文件中的部分工具。
+
+平台默认使用工具定义中的 platforms，没有时为 linux、darwin 的 amd64/arm64 和 windows-amd64。
+--dest 为 s3:// 地址时先下载到临时目录，再通过 aws s3 sync 上传，需要安装并配置 aws 命令。
+
+--deltas N 为每个安装包生成从镜像目录中同一平台最接近的 N 个旧版本升级的补丁，写入平台目录下的 deltas/，
+客户端使用 vman update --delta 时下载补丁而不是完整安装包。生成补丁需要 bsdiff 命令，
+旧版本需要已在本地镜像目录中（s3:// 目标只能使用同一次镜像的版本）。`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		tools, _ := cmd.Flags().GetStringSlice("tools")
+		versionsFrom, _ := cmd.Flags().GetString("versions-from")
+		dest, _ := cmd.Flags().GetString("dest")
+		platforms, _ := cmd.Flags().GetStringSlice("platforms")
+		timeout, _ := cmd.Flags().GetDuration("timeout")
+		deltas, _ := cmd.Flags().GetInt("deltas")
+		uiOptions := getUIOptions(cmd)
+
+		if dest == "" {
+			return fmt.Errorf("需要使用 --dest 指定镜像目录")
+		}
+		for _, platform := range platforms {
+			if _, err := download.ParseMirrorPlatform(platform); err != nil {
+				return err
+			}
+		}
+		requested, err := mirrorRequests(args, tools, versionsFrom)
+		if err != nil {
+			return err
+		}
+		cmd.SilenceUsage = true
+		if deltas > 0 {
+			if _, err := exec.LookPath("bsdiff"); err != nil {
+				return fmt.Errorf("生成补丁需要 bsdiff 命令: %w", err)
+			}
+		}
+
+		// s3:// 目标先写入临时目录，全部下载后上传
+		root := dest
+		s3Dest := strings.HasPrefix(dest, "s3://")
+		if s3Dest {
+			if _, err := exec.LookPath("aws"); err != nil {
+				return fmt.Errorf("上传到 %s 需要 aws 命令: %w", dest, err)
+			}
+			if root, err = os.MkdirTemp("", "vman-mirror-"); err != nil {
+				return fmt.Errorf("创建临时目录失败: %w", err)
+			}
+			defer os.RemoveAll(root)
+		} else if strings.Contains(dest, "://") {
+			return fmt.Errorf("不支持的镜像目标 %s，请使用本地目录或 s3:// 地址", dest)
+		}
+
+		managers, err := createManagers()
+		if err != nil {
+			return fmt.Errorf("创建管理器失败: %w", err)
+		}
+
+		fs := afero.NewOsFs()
+		logger := logrus.New()
+		logger.SetLevel(logrus.ErrorLevel)
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		var mirrored, failed int
+		var size int64
+		var entries []*download.MirrorEntry
+		for _, tool := range sortedMirrorTools(requested) {
+			metadata, err := managers.config.LoadToolConfig(tool)
+			if err != nil {
+				PrintWarning(fmt.Sprintf("没有找到 %s 的工具定义，跳过: %v", tool, err), uiOptions)
+				failed++
+				continue
+			}
+			strategy, err := download.NewStrategy(metadata, fs, logger)
+			if err != nil {
+				PrintWarning(fmt.Sprintf("%s: %v", tool, err), uiOptions)
+				failed++
+				continue
+			}
+			toolPlatforms := platforms
+			if len(toolPlatforms) == 0 {
+				toolPlatforms = download.MirrorPlatforms(metadata)
+			}
+
+			for _, version := range requested[tool] {
+				for _, platform := range toolPlatforms {
+					entry, err := download.MirrorArtifact(ctx, fs, strategy, version, platform, root)
+					if err != nil {
+						PrintWarning(fmt.Sprintf("镜像 %s@%s (%s) 失败: %v", tool, version, platform, err), uiOptions)
+						failed++
+						continue
+					}
+					mirrored++
+					size += entry.Size
+					entries = append(entries, entry)
+					Infof(uiOptions, "已镜像 %s@%s (%s) %s %s\n", tool, version, platform, entry.Filename, formatBytes(entry.Size))
+				}
+			}
+		}
+
+		// 所有版本都镜像后再生成补丁，同一次镜像的旧版本也可以作为补丁的基础
+		if deltas > 0 {
+			for _, entry := range entries {
+				generated, err := download.MirrorDeltas(fs, root, entry, deltas, func(oldPath, newPath, patchPath string) error {
+					output, err := exec.CommandContext(ctx, "bsdiff", oldPath, newPath, patchPath).CombinedOutput()
+					if err != nil {
+						return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(output)))
+					}
+					return nil
+				})
+				if err != nil {
+					PrintWarning(fmt.Sprintf("%s@%s (%s): %v", entry.Tool, entry.Version, entry.Platform, err), uiOptions)
+					failed++
+					continue
+				}
+				if len(generated) > 0 {
+					Infof(uiOptions, "已生成 %s@%s (%s) 从 %s 升级的补丁\n", entry.Tool, entry.Version, entry.Platform, strings.Join(generated, "、"))
+				}
+			}
+		}
+
+		if s3Dest && mirrored > 0 {
+			Infof(uiOptions, "正在上传到 %s...\n", dest)
+			upload := exec.CommandContext(ctx, "aws", "s3", "sync", root, dest)
+			upload.Stdout = os.Stderr
+			upload.Stderr = os.Stderr
+			if err := upload.Run(); err != nil {
+				return fmt.Errorf("上传到 %s 失败: %w", dest, err)
+			}
+		}
+
+		if failed > 0 {
+			return fmt.Errorf("镜像了 %d 个安装包 (%s)，%d 个失败", mirrored, formatBytes(size), failed)
+		}
+		PrintSuccess(fmt.Sprintf("镜像了 %d 个安装包 (%s) 到 %s", mirrored, formatBytes(size), dest), uiOptions)
+		return nil
+	},
+}
+
+// mirrorRequests 汇总要镜像的工具版本，tools 不为空时只保留其中的工具
+func mirrorRequests(args, tools []string, versionsFrom string) (map[string][]string, error) {
+	requested := make(map[string][]string)
+	add := func(tool, version string) error {
+		if isVersionConstraint(version) || version == "latest" {
+			return fmt.Errorf("%s 的版本 %s 不是精确版本，请先使用 vman lock 固定版本", tool, version)
+		}
+		for _, existing := range requested[tool] {
+			if existing == version {
+				return nil
+			}
+		}
+		requested[tool] = append(requested[tool], version)
+		return nil
+	}
+
+	for _, arg := range args {
+		tool, version, ok := strings.Cut(arg, "@")
+		if !ok || tool == "" || version == "" {
+			return nil, fmt.Errorf("无效的参数 %s，应为 tool@version", arg)
+		}
+		if err := add(tool, version); err != nil {
+			return nil, err
+		}
+	}
+
+	if versionsFrom != "" {
+		versions, err := readToolVersionsFile(versionsFrom)
+		if err != nil {
+			return nil, err
+		}
+		if len(versions) == 0 {
+			return nil, fmt.Errorf("%s 中没有工具版本", versionsFrom)
+		}
+		for tool, toolVersions := range versions {
+			if len(tools) > 0 && !containsString(tools, tool) {
+				continue
+			}
+			for _, version := range toolVersions {
+				if err := add(tool, version); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	for _, tool := range tools {
+		if _, ok := requested[tool]; !ok {
+			if versionsFrom != "" {
+				return nil, fmt.Errorf("%s 中没有 %s 的版本", versionsFrom, tool)
+			}
+			return nil, fmt.Errorf("没有指定 %s 的版本，请使用 %s@<version> 或 --versions-from", tool, tool)
+		}
+	}
+	if len(requested) == 0 {
+		return nil, fmt.Errorf("没有要镜像的工具版本，请使用 tool@version 参数或 --versions-from")
+	}
+	return requested, nil
+}
+
+// sortedMirrorTools 按名称排序的工具列表
+func sortedMirrorTools(requested map[string][]string) []string {
+	tools := make([]string, 0, len(requested))
+	for tool := range requested {
+		tools = append(tools, tool)
+	}
+	sort.Strings(tools)
+	return tools
+}
+
+func init() {
+	rootCmd.AddCommand(mirrorCmd)
+
+	mirrorCmd.Flags().StringSlice("tools", nil, "只镜像这些工具，逗号分隔")
+	mirrorCmd.Flags().String("versions-from", "", "从项目配置、.vman-version 或 .tool-versions 读取工具版本")
+	mirrorCmd.Flags().String("dest", "", "镜像目录，本地路径或 s3://bucket/prefix")
+	mirrorCmd.Flags().StringSlice("platforms", nil, "镜像的平台，如 linux-amd64,darwin-arm64，默认使用工具定义中的平台")
+	mirrorCmd.Flags().Duration("timeout", 30*time.Minute, "整个镜像过程的超时时间")
+	mirrorCmd.Flags().Int("deltas", 0, "为每个安装包生成从最接近的 N 个旧版本升级的 bsdiff 补丁")
+}
@@ -0,0 +1,45 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMirrorRequests(t *testing.T) {
+	dir := t.TempDir()
+	project := filepath.Join(dir, ".vman.yaml")
+	require.NoError(t, os.WriteFile(project, []byte(`version: "1.0"
+tools:
+  kubectl: 1.29.0
+  helm: 3.14.0
+  terraform: 1.7.0
+paths:
+  legacy/*:
+    kubectl: 1.27.4
+`), 0644))
+	toolVersions := filepath.Join(dir, ".tool-versions")
+	require.NoError(t, os.WriteFile(toolVersions, []byte("# pinned\nkubectl 1.30.0\n"), 0644))
+
+	requested, err := mirrorRequests(nil, []string{"kubectl", "helm"}, project)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"1.29.0", "1.27.4"}, requested["kubectl"])
+	assert.Equal(t, []string{"3.14.0"}, requested["helm"])
+	assert.NotContains(t, requested, "terraform")
+
+	requested, err = mirrorRequests([]string{"jq@1.7.1"}, nil, toolVersions)
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]string{"kubectl": {"1.30.0"}, "jq": {"1.7.1"}}, requested)
+
+	_, err = mirrorRequests(nil, []string{"go"}, project)
+	assert.ErrorContains(t, err, "go")
+	_, err = mirrorRequests([]string{"kubectl@^1.29"}, nil, "")
+	assert.ErrorContains(t, err, "vman lock")
+	_, err = mirrorRequests([]string{"kubectl"}, nil, "")
+	assert.Error(t, err)
+	_, err = mirrorRequests(nil, nil, "")
+	assert.Error(t, err)
+}
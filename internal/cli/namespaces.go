@@ -0,0 +1,141 @@
+package cli
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// 命令按名词分组：vman tool ...、vman version ...、vman config ...、vman cache ...
+// 分组中的子命令是原有命令的路由，执行时调用原有命令的实现；
+// 原有的平铺命令（vman install 等）继续可用，但不在帮助中显示
+
+// toolCmd 管理工具定义
+var toolCmd = &cobra.Command{
+	Use:   "tool",
+	Short: "管理工具定义和下载源",
+	Long: `管理工具定义和下载源。
+
+示例:
+  vman tool add kubectl                        # 交互式创建工具定义
+  vman tool add-source kubectl --type github --repo kubernetes/kubernetes
+  vman tool list                               # 列出已定义的工具
+  vman tool test kubectl                       # 测试下载源
+  vman tool remove kubectl                     # 移除工具定义`,
+	Args: cobra.NoArgs,
+}
+
+// versionCmd 管理工具版本
+var versionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "安装、切换和删除工具版本",
+	Long: `安装、切换和删除工具版本。
+
+示例:
+  vman version install kubectl 1.29.0
+  vman version use kubectl 1.29.0
+  vman version list kubectl
+  vman version current
+  vman version remove kubectl 1.28.0`,
+	Args: cobra.NoArgs,
+}
+
+// configCmd 查看和修改全局设置
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "查看和修改全局设置",
+	Long: `查看和修改全局配置中的设置项，设置项使用 settings 下的路径，如 download.timeout。
+
+示例:
+  vman config get download.timeout
+  vman config set download.timeout 10m
+  vman config set proxy.enabled false
+  vman config reset                            # 恢复默认配置`,
+	Args: cobra.NoArgs,
+}
+
+// cacheCmd 管理下载缓存
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "管理缓存和临时文件",
+	Long: `管理缓存和临时文件。
+
+示例:
+  vman cache clean`,
+	Args: cobra.NoArgs,
+}
+
+// routeTargets 分组子命令对应的原有命令
+var routeTargets = make(map[*cobra.Command]*cobra.Command)
+
+// routeCommand 在 group 中添加名为 name 的子命令，执行 target 的实现
+// 子命令与 target 共用标志，需要在 target 的标志定义之后调用
+func routeCommand(group *cobra.Command, name string, target *cobra.Command) *cobra.Command {
+	use := name
+	if _, args, ok := strings.Cut(target.Use, " "); ok {
+		use += " " + args
+	}
+	route := &cobra.Command{
+		Use:       use,
+		Aliases:   target.Aliases,
+		Short:     target.Short,
+		Long:      renameExamples(target.Long, target.Name(), group.Name()+" "+name),
+		Args:      target.Args,
+		ValidArgs: target.ValidArgs,
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if target.ValidArgsFunction == nil {
+				return nil, cobra.ShellCompDirectiveDefault
+			}
+			return target.ValidArgsFunction(cmd, args, toComplete)
+		},
+		PreRun:   target.PreRun,
+		PreRunE:  target.PreRunE,
+		Run:      target.Run,
+		RunE:     target.RunE,
+		PostRun:  target.PostRun,
+		PostRunE: target.PostRunE,
+	}
+	route.Flags().AddFlagSet(target.Flags())
+	group.AddCommand(route)
+	routeTargets[route] = target
+	return route
+}
+
+// renameExamples 将帮助中的 vman <old> 示例改写为 vman <new>
+func renameExamples(text, old, new string) string {
+	pattern := regexp.MustCompile(`vman ` + regexp.QuoteMeta(old) + `(\s|$)`)
+	return pattern.ReplaceAllString(text, "vman "+new+"$1")
+}
+
+// routeOf 返回 target 在分组中对应的子命令
+func routeOf(target *cobra.Command) (*cobra.Command, bool) {
+	for route, t := range routeTargets {
+		if t == target {
+			return route, true
+		}
+	}
+	return nil, false
+}
+
+// regroupCommand 将平铺的 target 移入分组，原命令保留为隐藏的兼容命令
+func regroupCommand(group *cobra.Command, name string, target *cobra.Command) {
+	routeCommand(group, name, target)
+	target.Hidden = true
+}
+
+// resolveCommand 返回实际执行的命令，分组子命令解析为原有命令
+// 只读模式、自动备份等按命令路径匹配的检查使用解析后的命令
+func resolveCommand(cmd *cobra.Command) *cobra.Command {
+	if target, ok := routeTargets[cmd]; ok {
+		return target
+	}
+	return cmd
+}
+
+func init() {
+	rootCmd.AddCommand(toolCmd)
+	rootCmd.AddCommand(versionCmd)
+	rootCmd.AddCommand(configCmd)
+	rootCmd.AddCommand(cacheCmd)
+}
@@ -0,0 +1,93 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/songzhibin97/vman/pkg/types"
+)
+
+func TestCommandGroups(t *testing.T) {
+	routes := map[string]*cobra.Command{
+		"tool add":          addCmd,
+		"tool add-source":   addSourceCmd,
+		"tool list":         listSourcesCmd,
+		"tool remove":       removeSourceCmd,
+		"tool test":         testSourceCmd,
+		"version install":   installCmd,
+		"version use":       useCmd,
+		"version remove":    removeCmd,
+		"version uninstall": removeCmd,
+		"version list":      listCmd,
+		"version current":   currentCmd,
+		"version which":     whichCmd,
+		"config reset":      resetConfigCmd,
+		"cache clean":       resetCacheCmd,
+	}
+	for path, target := range routes {
+		cmd, _, err := rootCmd.Find(strings.Fields(path))
+		require.NoError(t, err, path)
+		assert.Same(t, target, resolveCommand(cmd), path)
+
+		// 分组子命令与原有命令共用标志
+		target.Flags().VisitAll(func(flag *pflag.Flag) {
+			assert.Same(t, flag, cmd.Flags().Lookup(flag.Name), "%s --%s", path, flag.Name)
+		})
+	}
+
+	// 原有的平铺命令仍然可用，但不在帮助中显示
+	for _, name := range []string{"install", "use", "remove", "list", "add", "list-sources", "uninstall"} {
+		cmd, _, err := rootCmd.Find([]string{name})
+		require.NoError(t, err, name)
+		assert.True(t, cmd.Hidden, name)
+	}
+	cmd, _, err := rootCmd.Find([]string{"reset", "cache"})
+	require.NoError(t, err)
+	assert.False(t, cmd.Hidden)
+}
+
+func TestGroupedCommandsAreMutating(t *testing.T) {
+	t.Setenv(types.EnvVmanHome, t.TempDir())
+	t.Setenv(types.EnvVmanReadOnly, "true")
+
+	for _, path := range []string{"version install", "tool add", "config set", "cache clean"} {
+		cmd, _, err := rootCmd.Find(strings.Fields(path))
+		require.NoError(t, err, path)
+		assert.Error(t, checkReadOnly(cmd, loadGlobalSettings()), path)
+	}
+	cmd, _, err := rootCmd.Find([]string{"version", "list"})
+	require.NoError(t, err)
+	assert.NoError(t, checkReadOnly(cmd, loadGlobalSettings()))
+}
+
+func TestRenameExamples(t *testing.T) {
+	text := "示例:\n  vman list kubectl\n  vman list-sources\n  vman list"
+	assert.Equal(t, "示例:\n  vman version list kubectl\n  vman list-sources\n  vman version list", renameExamples(text, "list", "version list"))
+}
+
+func TestParseSettingValue(t *testing.T) {
+	value, err := parseSettingValue(time.Duration(0), "10m")
+	require.NoError(t, err)
+	assert.Equal(t, 10*time.Minute, value)
+
+	value, err = parseSettingValue(false, "true")
+	require.NoError(t, err)
+	assert.Equal(t, true, value)
+
+	value, err = parseSettingValue(types.ByteSize(0), "512MB")
+	require.NoError(t, err)
+	assert.Equal(t, types.ByteSize(512<<20), value)
+
+	value, err = parseSettingValue([]string{}, "gbk,shift_jis")
+	require.NoError(t, err)
+	assert.Equal(t, "gbk,shift_jis", value)
+
+	_, err = parseSettingValue(0, "many")
+	assert.Error(t, err)
+}
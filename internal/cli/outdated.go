@@ -0,0 +1,323 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/spf13/cobra"
+
+	"github.com/songzhibin97/vman/internal/proxy"
+	"github.com/songzhibin97/vman/internal/version"
+	"github.com/songzhibin97/vman/pkg/types"
+)
+
+// outdatedCmd 比较已安装版本与远程最新版本
+var outdatedCmd = &cobra.Command{
+	Use:   "outdated [tool...]",
+	Short: "列出有新版本的已安装工具",
+	Long: `查询每个已安装工具的远程版本，列出当前版本、期望版本和最新版本。
+
+当前版本为当前目录下生效的版本，没有配置版本时为已安装的最高版本。
+期望版本为满足配置中的版本约束（如 ~1.29、>=1.28 <1.30）和工具定义中
+versions.constraints 的最高远程版本；配置为精确版本时期望版本即该版本。
+最新版本为远程最高版本。默认忽略预发布版本，使用 --prerelease 包含预发布版本。
+
+使用 --exit-code 时，存在可更新的工具则以非零状态退出，可用于CI检查。
+使用 --all-projects 时检查所有注册项目（vman projects add）中引用的工具，当前版本为在项目目录中生效的版本。
+订阅的工具（vman subscribe）在订阅渠道中有新版本时，在标准错误输出提示。
+
+示例:
+  vman outdated
+  vman outdated kubectl helm
+  vman outdated --json
+  vman outdated --exit-code
+  vman outdated --all-projects`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		jsonFormat, _ := cmd.Flags().GetBool("json")
+		exitCode, _ := cmd.Flags().GetBool("exit-code")
+		prerelease, _ := cmd.Flags().GetBool("prerelease")
+		allProjects, _ := cmd.Flags().GetBool("all-projects")
+
+		managers, err := createManagers()
+		if err != nil {
+			return fmt.Errorf("创建管理器失败: %w", err)
+		}
+		integratedManager, err := createIntegratedManager()
+		if err != nil {
+			return fmt.Errorf("创建管理器失败: %w", err)
+		}
+
+		tools := args
+		if len(tools) == 0 && !allProjects {
+			tools, err = managers.version.ListAllTools()
+			if err != nil {
+				return fmt.Errorf("获取已安装工具失败: %w", err)
+			}
+			sort.Strings(tools)
+		}
+
+		cwd, _ := os.Getwd()
+		resolver := proxy.NewVersionResolver(managers.config, managers.version)
+		checker := &outdatedChecker{managers: managers, integrated: integratedManager, resolver: resolver, prerelease: prerelease}
+
+		var entries []*outdatedEntry
+		if allProjects {
+			registry, err := loadProjectRegistry(managers.config)
+			if err != nil {
+				return err
+			}
+			for _, dir := range registry.Paths() {
+				if !dirExists(dir) {
+					PrintWarning(fmt.Sprintf("注册的项目 %s 不存在", dir), getUIOptions(cmd))
+					continue
+				}
+				versions, err := projectVersions(dir)
+				if err != nil {
+					PrintWarning(fmt.Sprintf("读取项目 %s 的版本失败: %v", dir, err), getUIOptions(cmd))
+					continue
+				}
+				for _, tool := range sortedProjectTools(versions) {
+					if len(args) > 0 && !containsString(args, tool) {
+						continue
+					}
+					if entry := checker.check(tool, dir); entry != nil {
+						entry.Project = dir
+						entries = append(entries, entry)
+					}
+				}
+			}
+		} else {
+			for _, tool := range tools {
+				if entry := checker.check(tool, cwd); entry != nil {
+					entries = append(entries, entry)
+				}
+			}
+		}
+
+		if jsonFormat {
+			jsonData, err := json.MarshalIndent(entries, "", "  ")
+			if err != nil {
+				return fmt.Errorf("JSON编码失败: %w", err)
+			}
+			fmt.Println(string(jsonData))
+		} else {
+			printOutdatedTable(entries, getUIOptions(cmd))
+		}
+
+		notifySubscriptions(managers.config, func(tool string) ([]*types.VersionInfo, error) {
+			remote := checker.remote(tool)
+			return remote.versions, remote.err
+		}, getUIOptions(cmd))
+
+		if exitCode {
+			for _, entry := range entries {
+				if entry.Outdated {
+					cmd.SilenceUsage = true
+					return fmt.Errorf("存在可更新的工具")
+				}
+			}
+		}
+		return nil
+	},
+}
+
+// outdatedChecker 比较工具版本，同一工具的远程版本只查询一次
+type outdatedChecker struct {
+	managers   *managers
+	integrated version.Manager
+	resolver   proxy.VersionResolver
+	prerelease bool
+	available  map[string]outdatedRemote
+}
+
+// outdatedRemote 工具的远程版本查询结果
+type outdatedRemote struct {
+	versions []*types.VersionInfo
+	err      error
+}
+
+// check 比较工具在 dir 中生效的版本与远程版本，工具没有已安装版本时返回 nil
+func (c *outdatedChecker) check(tool, dir string) *outdatedEntry {
+	installed, err := c.managers.version.GetInstalledVersions(tool)
+	if err != nil || len(installed) == 0 {
+		return nil
+	}
+
+	entry := &outdatedEntry{Tool: tool, Current: highestVersion(installed)}
+	if resolution, err := c.resolver.ResolveVersion(context.Background(), tool, dir); err == nil && resolution.Version != types.SystemVersion {
+		entry.Current = resolution.Version
+		entry.Requested = resolution.RequestedVersion
+		entry.Source = resolution.Source
+	}
+
+	var constraints types.VersionConstraints
+	if metadata, err := c.managers.config.LoadToolConfig(tool); err == nil {
+		constraints = metadata.VersionConfig.Constraints
+	}
+
+	if remote := c.remote(tool); remote.err != nil {
+		entry.Error = remote.err.Error()
+	} else {
+		entry.resolve(remote.versions, constraints, c.prerelease)
+	}
+	return entry
+}
+
+// remote 查询工具的远程版本，结果会被缓存
+func (c *outdatedChecker) remote(tool string) outdatedRemote {
+	if c.available == nil {
+		c.available = map[string]outdatedRemote{}
+	}
+	remote, ok := c.available[tool]
+	if !ok {
+		remote.versions, remote.err = c.integrated.SearchAvailableVersions(tool)
+		c.available[tool] = remote
+	}
+	return remote
+}
+
+// outdatedEntry 一个工具的版本比较结果
+type outdatedEntry struct {
+	Project   string `json:"project,omitempty"`
+	Tool      string `json:"tool"`
+	Current   string `json:"current"`
+	Wanted    string `json:"wanted,omitempty"`
+	Latest    string `json:"latest,omitempty"`
+	Requested string `json:"requested,omitempty"`
+	Source    string `json:"source,omitempty"`
+	Outdated  bool   `json:"outdated"`
+	Error     string `json:"error,omitempty"`
+}
+
+// resolve 根据远程版本计算期望版本和最新版本
+func (e *outdatedEntry) resolve(available []*types.VersionInfo, constraints types.VersionConstraints, prerelease bool) {
+	var requested *semver.Constraints
+	if e.Requested != "" && e.Requested != e.Current {
+		requested, _ = semver.NewConstraint(e.Requested)
+	}
+	var minVersion, maxVersion *semver.Version
+	if constraints.MinVersion != "" {
+		minVersion, _ = semver.NewVersion(constraints.MinVersion)
+	}
+	if constraints.MaxVersion != "" {
+		maxVersion, _ = semver.NewVersion(constraints.MaxVersion)
+	}
+
+	var latest, wanted *semver.Version
+	for _, info := range available {
+		if info.IsPrerelease && !prerelease {
+			continue
+		}
+		v, err := semver.NewVersion(info.Version)
+		if err != nil {
+			continue
+		}
+		if v.Prerelease() != "" && !prerelease {
+			continue
+		}
+
+		if latest == nil || v.GreaterThan(latest) {
+			latest = v
+			e.Latest = info.Version
+		}
+
+		if minVersion != nil && v.LessThan(minVersion) {
+			continue
+		}
+		if maxVersion != nil && v.GreaterThan(maxVersion) {
+			continue
+		}
+		if requested != nil && !requested.Check(v) {
+			continue
+		}
+		if wanted == nil || v.GreaterThan(wanted) {
+			wanted = v
+			e.Wanted = info.Version
+		}
+	}
+
+	// 配置为精确版本或别名时不期望更新，latest 除外
+	if e.Requested != "" && e.Requested != "latest" && requested == nil {
+		e.Wanted = e.Current
+	}
+
+	current, err := semver.NewVersion(e.Current)
+	e.Outdated = err == nil && latest != nil && latest.GreaterThan(current)
+}
+
+// highestVersion 返回版本列表中的最高版本，无法解析时返回最后一个
+func highestVersion(versions []string) string {
+	var best *semver.Version
+	result := versions[len(versions)-1]
+	for _, version := range versions {
+		v, err := semver.NewVersion(version)
+		if err != nil {
+			continue
+		}
+		if best == nil || v.GreaterThan(best) {
+			best = v
+			result = version
+		}
+	}
+	return result
+}
+
+// printOutdatedTable 以表格输出版本比较结果
+func printOutdatedTable(entries []*outdatedEntry, options *UIOptions) {
+	if len(entries) == 0 {
+		fmt.Println("没有已安装的工具")
+		return
+	}
+
+	// 检查注册项目时增加项目列
+	headers := []string{"TOOL", "CURRENT", "WANTED", "LATEST", "SOURCE"}
+	withProject := entries[0].Project != ""
+	if withProject {
+		headers = append([]string{"PROJECT"}, headers...)
+	}
+
+	table := NewTablePrinter(headers, options)
+	for _, e := range entries {
+		wanted, latest := e.Wanted, e.Latest
+		if e.Error != "" {
+			wanted, latest = "-", "?"
+		}
+		if wanted == "" {
+			wanted = "-"
+		}
+		if latest == "" {
+			latest = "-"
+		}
+		source := e.Source
+		if source == "" {
+			source = "-"
+		}
+		row := []string{e.Tool, e.Current, wanted, latest, source}
+		if withProject {
+			row = append([]string{e.Project}, row...)
+		}
+		table.AddRow(row)
+	}
+	table.Print()
+
+	warned := map[string]bool{}
+	for _, e := range entries {
+		if e.Error != "" && !warned[e.Tool] {
+			warned[e.Tool] = true
+			PrintWarning(fmt.Sprintf("查询 %s 的远程版本失败: %s", e.Tool, e.Error), options)
+		}
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(outdatedCmd)
+
+	outdatedCmd.Flags().Bool("json", false, "使用JSON格式输出")
+	outdatedCmd.Flags().Bool("exit-code", false, "存在可更新的工具时以非零状态退出")
+	outdatedCmd.Flags().Bool("prerelease", false, "包含预发布版本")
+	outdatedCmd.Flags().Bool("all-projects", false, "检查所有通过 vman projects add 注册的项目")
+}
@@ -0,0 +1,90 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/songzhibin97/vman/pkg/types"
+)
+
+func newTestRemoteVersions() []*types.VersionInfo {
+	return []*types.VersionInfo{
+		{Version: "1.31.0-rc.1", IsPrerelease: true},
+		{Version: "1.30.2"},
+		{Version: "1.29.5"},
+		{Version: "1.29.0"},
+		{Version: "1.28.9"},
+	}
+}
+
+func TestOutdatedEntry_Resolve(t *testing.T) {
+	tests := []struct {
+		name        string
+		entry       outdatedEntry
+		constraints types.VersionConstraints
+		prerelease  bool
+		wanted      string
+		latest      string
+		outdated    bool
+	}{
+		{
+			name:     "没有配置版本",
+			entry:    outdatedEntry{Current: "1.29.0"},
+			wanted:   "1.30.2",
+			latest:   "1.30.2",
+			outdated: true,
+		},
+		{
+			name:     "精确版本",
+			entry:    outdatedEntry{Current: "1.29.0", Requested: "1.29.0"},
+			wanted:   "1.29.0",
+			latest:   "1.30.2",
+			outdated: true,
+		},
+		{
+			name:     "版本约束",
+			entry:    outdatedEntry{Current: "1.29.0", Requested: "~1.29"},
+			wanted:   "1.29.5",
+			latest:   "1.30.2",
+			outdated: true,
+		},
+		{
+			name:        "工具定义的最高版本",
+			entry:       outdatedEntry{Current: "1.28.9"},
+			constraints: types.VersionConstraints{MaxVersion: "1.29.9"},
+			wanted:      "1.29.5",
+			latest:      "1.30.2",
+			outdated:    true,
+		},
+		{
+			name:       "包含预发布版本",
+			entry:      outdatedEntry{Current: "1.30.2"},
+			prerelease: true,
+			wanted:     "1.31.0-rc.1",
+			latest:     "1.31.0-rc.1",
+			outdated:   true,
+		},
+		{
+			name:   "已是最新",
+			entry:  outdatedEntry{Current: "1.30.2"},
+			wanted: "1.30.2",
+			latest: "1.30.2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := tt.entry
+			entry.resolve(newTestRemoteVersions(), tt.constraints, tt.prerelease)
+			assert.Equal(t, tt.wanted, entry.Wanted)
+			assert.Equal(t, tt.latest, entry.Latest)
+			assert.Equal(t, tt.outdated, entry.Outdated)
+		})
+	}
+}
+
+func TestHighestVersion(t *testing.T) {
+	assert.Equal(t, "1.10.0", highestVersion([]string{"1.9.0", "1.10.0", "1.2.0"}))
+	assert.Equal(t, "nightly", highestVersion([]string{"nightly"}))
+}
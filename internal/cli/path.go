@@ -0,0 +1,175 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/songzhibin97/vman/internal/proxy"
+)
+
+// pathCmd PATH相关命令
+var pathCmd = &cobra.Command{
+	Use:   "path",
+	Short: "检查和修复PATH中垫片的优先级",
+	Long: `检查和修复shims目录在PATH中的优先级。
+
+如果shims目录在PATH中位于 /usr/local/bin 等目录之后，
+系统中安装的同名工具会被优先执行，vman管理的版本不会生效。`,
+}
+
+// pathCheckCmd 检查PATH优先级
+var pathCheckCmd = &cobra.Command{
+	Use:   "check",
+	Short: "显示每个受管工具在PATH中的所有匹配项",
+	Long: `按PATH顺序列出每个受管工具的所有同名可执行文件，并标出实际会被执行的文件。
+
+存在冲突时命令以非零状态退出，可用于脚本和CI检查。
+
+示例:
+  vman path check
+  vman path check --json`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		jsonFormat, _ := cmd.Flags().GetBool("json")
+
+		if err := initProxy(); err != nil {
+			return err
+		}
+
+		report, err := commandProxy.CheckPath()
+		if err != nil {
+			return fmt.Errorf("检查PATH失败: %w", err)
+		}
+
+		if jsonFormat {
+			jsonData, err := json.MarshalIndent(report, "", "  ")
+			if err != nil {
+				return fmt.Errorf("JSON编码失败: %w", err)
+			}
+			fmt.Println(string(jsonData))
+		} else {
+			printPathReport(report, getUIOptions(cmd))
+		}
+
+		if report.HasConflicts() {
+			cmd.SilenceUsage = true
+			return fmt.Errorf("发现PATH冲突")
+		}
+		return nil
+	},
+}
+
+// pathFixCmd 修复PATH优先级
+var pathFixCmd = &cobra.Command{
+	Use:         "fix",
+	Annotations: mutating(),
+	Short:       "将shims目录移到PATH最前面",
+	Long: `在shell配置文件末尾写入一段配置，将shims目录移到PATH最前面。
+
+配置段写在文件末尾，确保在其他修改PATH的配置之后执行。重复执行会替换之前写入的配置段。
+
+示例:
+  vman path fix --shell zsh           # 修改 ~/.zshrc
+  vman path fix --shell bash --print  # 只打印配置，不修改文件
+  eval "$(vman path fix --print)"     # 仅在当前会话中修复`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		shellType, _ := cmd.Flags().GetString("shell")
+		printOnly, _ := cmd.Flags().GetBool("print")
+
+		if err := initProxy(); err != nil {
+			return err
+		}
+
+		if shellType == "" {
+			shellType = proxy.NewShellIntegrator().DetectShell()
+		}
+
+		if printOnly {
+			shimsDir := commandProxy.GetProxyStatus().ShimsDir
+			snippet, err := proxy.NewPathManager().GenerateShimPathFix(shellType, shimsDir)
+			if err != nil {
+				return err
+			}
+			fmt.Println(snippet)
+			return nil
+		}
+
+		profile, err := commandProxy.FixPath(shellType)
+		if err != nil {
+			return fmt.Errorf("修复PATH失败: %w", err)
+		}
+
+		PrintSuccess(fmt.Sprintf("已更新 %s", profile), getUIOptions(cmd))
+		fmt.Println("请重新启动shell或运行以下命令使修改生效:")
+		fmt.Printf("  source %s\n", profile)
+		return nil
+	},
+}
+
+// printPathReport 打印PATH检查报告
+func printPathReport(report *proxy.PathReport, options *UIOptions) {
+	if report.ShimsInPath {
+		fmt.Printf("Shims目录: %s (PATH中第 %d 项)\n", report.ShimsDir, report.ShimsIndex+1)
+	} else {
+		fmt.Printf("Shims目录: %s (%s)\n", report.ShimsDir, ColorizeError("不在PATH中", options))
+	}
+
+	if len(report.Tools) == 0 {
+		fmt.Println("\n没有受管的工具")
+		return
+	}
+
+	for _, tool := range report.Tools {
+		fmt.Println()
+		status := ColorizeSuccess("正常", options)
+		switch {
+		case tool.Shadowed:
+			status = ColorizeError("被遮蔽", options)
+		case tool.NoShim:
+			status = ColorizeWarning("未找到垫片", options)
+		}
+		fmt.Printf("%s: %s\n", ColorizeBold(tool.Tool, options), status)
+
+		if len(tool.Candidates) == 0 {
+			fmt.Println("  PATH中没有找到该工具")
+			continue
+		}
+
+		for i, candidate := range tool.Candidates {
+			marker := "  "
+			if i == 0 {
+				marker = "→ "
+			}
+			label := ""
+			if candidate.IsShim {
+				label = " (vman)"
+			}
+			fmt.Printf("  %s%d. %s%s\n", marker, i+1, candidate.Path, label)
+		}
+	}
+
+	if report.HasConflicts() {
+		printPathFixSuggestion(options)
+	}
+}
+
+// printPathFixSuggestion 打印修复建议
+func printPathFixSuggestion(options *UIOptions) {
+	shellType := proxy.NewShellIntegrator().DetectShell()
+	fmt.Println()
+	fmt.Printf("%s运行以下命令将shims目录移到PATH最前面:\n", Emoji(EmojiInfo, options))
+	fmt.Printf("  vman path fix --shell %s\n", shellType)
+}
+
+func init() {
+	rootCmd.AddCommand(pathCmd)
+	pathCmd.AddCommand(pathCheckCmd)
+	pathCmd.AddCommand(pathFixCmd)
+
+	pathCheckCmd.Flags().Bool("json", false, "使用JSON格式输出")
+	pathFixCmd.Flags().String("shell", "", "shell类型: bash, zsh, fish, powershell（默认自动检测）")
+	pathFixCmd.Flags().Bool("print", false, "只打印配置片段，不修改配置文件")
+}
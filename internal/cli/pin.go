@@ -0,0 +1,311 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+
+	"github.com/songzhibin97/vman/internal/config"
+	"github.com/songzhibin97/vman/internal/proxy"
+	"github.com/songzhibin97/vman/pkg/types"
+)
+
+// pinCmd 将项目配置中的版本要求固定为当前解析出的精确版本
+var pinCmd = &cobra.Command{
+	Use:         "pin <tool...>",
+	Annotations: mutating(),
+	Short:       "将项目配置中的版本要求固定为当前版本",
+	Long: `将项目配置（.vman.yaml）中工具的版本范围或别名替换为当前解析出的精确版本，
+并在该行添加注释记录固定的日期和原来的版本要求，例如:
+
+  kubectl: 1.29.5 # pinned 2024-06-01 from ^1.29 by vman
+
+原来的版本要求同时记录在配置目录下的 pins.json 中，使用 vman unpin 恢复。
+
+示例:
+  vman pin kubectl
+  vman pin kubectl helm`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		return runPin(cmd, args)
+	},
+}
+
+// freezeCmd 固定项目配置中的所有工具
+var freezeCmd = &cobra.Command{
+	Use:         "freeze",
+	Annotations: mutating(),
+	Aliases:     []string{"lock"},
+	Short:       "将项目配置中所有工具固定为当前版本",
+	Long: `对最近的项目配置（.vman.yaml）中的所有工具执行 vman pin，
+已经是精确版本的工具保持不变。固定后重新生成该项目通过 vman export 写入的文件。
+
+示例:
+  vman freeze
+  vman lock`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+
+		managers, err := createManagers()
+		if err != nil {
+			return fmt.Errorf("创建管理器失败: %w", err)
+		}
+		cwd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("获取当前目录失败: %w", err)
+		}
+
+		configDir, projectConfig, err := findNearestProjectConfig(managers.config, cwd)
+		if err != nil {
+			return err
+		}
+
+		// 只固定在当前目录生效的版本项
+		tools := projectToolsForDir(configDir, projectConfig, cwd)
+
+		if len(tools) == 0 {
+			fmt.Printf("%s 中没有配置工具\n", managers.config.GetProjectConfigPath(configDir))
+			return nil
+		}
+		if err := runPin(cmd, tools); err != nil {
+			return err
+		}
+		return regenerateExports(cmd, managers, cwd)
+	},
+}
+
+// unpinCmd 恢复固定前的版本要求
+var unpinCmd = &cobra.Command{
+	Use:         "unpin <tool...>",
+	Annotations: mutating(),
+	Short:       "恢复 vman pin 固定前的版本要求",
+	Long: `将项目配置中由 vman pin 固定的版本恢复为原来的版本要求，并删除固定注释，保留该行原有的注释。
+
+原来的版本要求优先从配置目录下的 pins.json 中读取，没有记录时（如在另一台机器上）
+从 vman pin 写入的注释中读取。
+
+示例:
+  vman unpin kubectl`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+
+		managers, err := createManagers()
+		if err != nil {
+			return fmt.Errorf("创建管理器失败: %w", err)
+		}
+		cwd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("获取当前目录失败: %w", err)
+		}
+
+		fs := afero.NewOsFs()
+		historyPath := filepath.Join(managers.config.GetConfigDir(), config.PinHistoryFile)
+		history, err := config.LoadPinHistory(fs, historyPath)
+		if err != nil {
+			return err
+		}
+
+		uiOptions := getUIOptions(cmd)
+		var failed int
+		for _, tool := range args {
+			configPath, pattern, err := findProjectToolConfig(managers.config, tool, cwd)
+			if err != nil {
+				PrintWarning(err.Error(), uiOptions)
+				failed++
+				continue
+			}
+			entry, err := config.ReadProjectToolEntry(fs, configPath, pattern, tool)
+			if err != nil {
+				PrintWarning(err.Error(), uiOptions)
+				failed++
+				continue
+			}
+
+			previous, ok := "", false
+			if record, found := history.Take(configPath, config.PinKey(pattern, tool)); found && record.Version == entry.Version {
+				previous, ok = record.Previous, true
+			}
+			if !ok {
+				previous, ok = config.ParsePinComment(entry.Comment)
+			}
+			if !ok {
+				PrintWarning(fmt.Sprintf("%s 没有固定记录", tool), uiOptions)
+				failed++
+				continue
+			}
+
+			if err := config.WriteProjectToolEntry(fs, configPath, pattern, tool, &config.ProjectToolEntry{
+				Version: previous,
+				Comment: config.StripPinComment(entry.Comment),
+			}); err != nil {
+				PrintWarning(err.Error(), uiOptions)
+				failed++
+				continue
+			}
+			PrintSuccess(fmt.Sprintf("%s: %s -> %s", tool, entry.Version, previous), uiOptions)
+		}
+
+		if err := history.Save(fs, historyPath); err != nil {
+			return err
+		}
+		if failed > 0 {
+			return fmt.Errorf("%d 个工具恢复失败", failed)
+		}
+		return nil
+	},
+}
+
+// runPin 固定工具的版本
+func runPin(cmd *cobra.Command, tools []string) error {
+	managers, err := createManagers()
+	if err != nil {
+		return fmt.Errorf("创建管理器失败: %w", err)
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("获取当前目录失败: %w", err)
+	}
+
+	fs := afero.NewOsFs()
+	historyPath := filepath.Join(managers.config.GetConfigDir(), config.PinHistoryFile)
+	history, err := config.LoadPinHistory(fs, historyPath)
+	if err != nil {
+		return err
+	}
+
+	resolver := proxy.NewVersionResolver(managers.config, managers.version)
+	uiOptions := getUIOptions(cmd)
+	now := time.Now()
+	var failed int
+	for _, tool := range tools {
+		configPath, pattern, err := findProjectToolConfig(managers.config, tool, cwd)
+		if err != nil {
+			PrintWarning(err.Error(), uiOptions)
+			failed++
+			continue
+		}
+		entry, err := config.ReadProjectToolEntry(fs, configPath, pattern, tool)
+		if err != nil {
+			PrintWarning(err.Error(), uiOptions)
+			failed++
+			continue
+		}
+
+		resolution, err := resolver.ResolveVersion(context.Background(), tool, cwd)
+		if err != nil {
+			PrintWarning(fmt.Sprintf("解析 %s 的版本失败: %v", tool, err), uiOptions)
+			failed++
+			continue
+		}
+		if resolution.Source != "project" || !samePath(resolution.ConfigPath, configPath) {
+			PrintWarning(fmt.Sprintf("%s 当前生效的版本 %s 来自 %s，不是 %s", tool, resolution.Version, resolutionOrigin(resolution), configPath), uiOptions)
+			failed++
+			continue
+		}
+
+		if entry.Version == resolution.Version {
+			fmt.Printf("%s 已经是精确版本 %s\n", tool, entry.Version)
+			continue
+		}
+
+		if err := config.WriteProjectToolEntry(fs, configPath, pattern, tool, &config.ProjectToolEntry{
+			Version: resolution.Version,
+			Comment: config.PinComment(entry.Comment, now, entry.Version),
+		}); err != nil {
+			PrintWarning(err.Error(), uiOptions)
+			failed++
+			continue
+		}
+		history.Record(configPath, config.PinKey(pattern, tool), &config.PinRecord{
+			Previous: entry.Version,
+			Version:  resolution.Version,
+			PinnedAt: now,
+		})
+		PrintSuccess(fmt.Sprintf("%s: %s -> %s", tool, entry.Version, resolution.Version), uiOptions)
+	}
+
+	if err := history.Save(fs, historyPath); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d 个工具固定失败", failed)
+	}
+	return nil
+}
+
+// findNearestProjectConfig 从 dir 向上查找最近的项目配置文件，返回其所在目录
+func findNearestProjectConfig(configManager config.Manager, dir string) (string, *types.ProjectConfig, error) {
+	current := canonicalDir(dir)
+	for {
+		if _, err := os.Stat(configManager.GetProjectConfigPath(current)); err == nil {
+			projectConfig, err := configManager.LoadProject(current)
+			if err != nil {
+				return "", nil, err
+			}
+			return current, projectConfig, nil
+		}
+		parent := filepath.Dir(current)
+		if parent == current {
+			return "", nil, fmt.Errorf("没有找到项目配置文件 .vman.yaml")
+		}
+		current = parent
+	}
+}
+
+// findProjectToolConfig 从 dir 向上查找配置了工具的项目配置文件，返回文件路径和 paths 中匹配的模式
+func findProjectToolConfig(configManager config.Manager, tool, dir string) (string, string, error) {
+	dir = canonicalDir(dir)
+	current := dir
+	for {
+		if _, err := os.Stat(configManager.GetProjectConfigPath(current)); err == nil {
+			projectConfig, err := configManager.LoadProject(current)
+			if err != nil {
+				return "", "", err
+			}
+			relPath, _ := filepath.Rel(current, dir)
+			if _, pattern, ok := projectConfig.ToolVersionForPath(tool, filepath.ToSlash(relPath)); ok {
+				return configManager.GetProjectConfigPath(current), pattern, nil
+			}
+		}
+		parent := filepath.Dir(current)
+		if parent == current {
+			return "", "", fmt.Errorf("项目配置中没有配置 %s", tool)
+		}
+		current = parent
+	}
+}
+
+// canonicalDir 解析目录中的符号链接，失败时返回原路径
+func canonicalDir(dir string) string {
+	if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+		return resolved
+	}
+	return dir
+}
+
+// samePath 判断两个路径解析符号链接后是否相同
+func samePath(a, b string) bool {
+	return canonicalDir(a) == canonicalDir(b)
+}
+
+// resolutionOrigin 版本解析结果的来源说明
+func resolutionOrigin(resolution *proxy.VersionResolution) string {
+	if resolution.ConfigPath != "" {
+		return resolution.ConfigPath
+	}
+	return resolution.Source
+}
+
+func init() {
+	rootCmd.AddCommand(pinCmd)
+	rootCmd.AddCommand(freezeCmd)
+	rootCmd.AddCommand(unpinCmd)
+}
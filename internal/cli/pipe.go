@@ -0,0 +1,25 @@
+package cli
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/songzhibin97/vman/internal/proxy"
+)
+
+// setupBrokenPipeHandling 命令的输出通过管道传给 head 等程序、读取端提前关闭时，不输出写入失败的错误和用法
+// 由 Execute 以与被 SIGPIPE 终止相同的状态退出
+func setupBrokenPipeHandling(cmd *cobra.Command) {
+	for _, child := range cmd.Commands() {
+		setupBrokenPipeHandling(child)
+	}
+	if run := cmd.RunE; run != nil {
+		cmd.RunE = func(cmd *cobra.Command, args []string) error {
+			err := run(cmd, args)
+			if proxy.IsBrokenPipe(err) {
+				cmd.SilenceErrors = true
+				cmd.SilenceUsage = true
+			}
+			return err
+		}
+	}
+}
@@ -0,0 +1,293 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/songzhibin97/vman/internal/storage"
+	"github.com/songzhibin97/vman/pkg/types"
+	"github.com/songzhibin97/vman/pkg/utils"
+)
+
+const (
+	// progressRegistryDir 临时目录下登记正在终端中输出进度的 vman 进程的目录名
+	progressRegistryDir = "progress"
+
+	// siblingCheckInterval 刷新进度时检查其他 vman 进程的最短间隔
+	siblingCheckInterval = 500 * time.Millisecond
+
+	// sharedProgressInterval 与其他进程共用终端时逐行输出进度的最长间隔
+	sharedProgressInterval = 5 * time.Second
+)
+
+// installProgress 安装时的下载进度输出
+//
+// 标准输出是终端时原地刷新一行进度；不是终端时（CI日志、重定向到文件）不能使用回车刷新，
+// 改为每隔 interval 输出一行心跳，下载暂时没有进展时也会输出，避免 CI 因长时间没有输出
+// 而终止任务，下载结束后输出一行汇总
+//
+// 多个 vman 进程同时向同一终端输出进度时（make -j、并行脚本），原地刷新的进度行会互相覆盖，
+// 因此终端中的进度登记到临时目录，发现其他进程后改为带 [tool@version] 前缀的逐行输出
+type installProgress struct {
+	out      io.Writer
+	label    string
+	terminal bool
+	interval time.Duration
+	start    time.Time
+
+	mu   sync.Mutex
+	last types.ProgressInfo
+	stop chan struct{}
+	done chan struct{}
+
+	// registry 登记目录，tty 当前终端，为空时不检查其他进程
+	registry   string
+	tty        string
+	unregister func()
+	lastCheck  time.Time
+	drawn      bool
+}
+
+// newInstallProgress 创建安装进度输出，label 为 tool@version
+func newInstallProgress(out io.Writer, label string, terminal bool, interval time.Duration) *installProgress {
+	p := &installProgress{
+		out:      out,
+		label:    label,
+		terminal: terminal,
+		interval: interval,
+		start:    time.Now(),
+	}
+	if !terminal && interval > 0 {
+		p.stop = make(chan struct{})
+		p.done = make(chan struct{})
+		go p.heartbeat()
+	}
+	return p
+}
+
+// startInstallProgress 按当前输出和全局设置创建安装进度输出，静默模式下返回 nil
+func startInstallProgress(label string, options *UIOptions) *installProgress {
+	if options.Quiet {
+		return nil
+	}
+	interval := loadGlobalSettings().Download.GetProgressInterval()
+	terminal := stdoutIsTerminal()
+	p := newInstallProgress(os.Stdout, label, terminal, interval)
+	if homeDir, err := utils.GetHomeDir(); err == nil && terminal {
+		p.shareTerminal(filepath.Join(types.DefaultConfigPaths(homeDir).TempDir, progressRegistryDir), terminalName())
+	}
+	return p
+}
+
+// shareTerminal 将进度登记到 dir，已有其他进程在同一终端输出进度时直接改为逐行输出
+func (p *installProgress) shareTerminal(dir, tty string) {
+	p.registry = dir
+	p.tty = tty
+	p.unregister = registerProgress(dir, tty)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.lastCheck = time.Now()
+	if progressSiblings(dir, tty) > 0 {
+		p.switchToLines()
+	}
+}
+
+// switchToLines 改为逐行输出进度，调用时需持有 p.mu
+func (p *installProgress) switchToLines() {
+	if p.drawn {
+		fmt.Fprintln(p.out)
+	}
+	p.terminal = false
+	fmt.Fprintf(p.out, "[%s] 其他 vman 进程正在同时输出进度，改为逐行输出\n", p.label)
+
+	if p.interval <= 0 || p.interval > sharedProgressInterval {
+		p.interval = sharedProgressInterval
+	}
+	p.stop = make(chan struct{})
+	p.done = make(chan struct{})
+	go p.heartbeat()
+}
+
+// Callback 返回传给下载的进度回调，p 为 nil 时返回 nil
+func (p *installProgress) Callback() func(*types.ProgressInfo) {
+	if p == nil {
+		return nil
+	}
+	return p.update
+}
+
+// update 记录最新进度，终端中同时刷新进度行
+func (p *installProgress) update(info *types.ProgressInfo) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.last = *info
+
+	if !p.terminal {
+		return
+	}
+	if p.registry != "" && time.Since(p.lastCheck) >= siblingCheckInterval {
+		p.lastCheck = time.Now()
+		if progressSiblings(p.registry, p.tty) > 0 {
+			p.switchToLines()
+			return
+		}
+	}
+	p.drawn = true
+	if info.Total > 0 {
+		fmt.Fprintf(p.out, "\r下载进度: %.1f%% (%s) - %s", info.Percentage, formatBytes(info.Downloaded), info.Status)
+	} else {
+		fmt.Fprintf(p.out, "\r%s", info.Status)
+	}
+}
+
+// heartbeat 定期输出心跳行，直到 Finish
+func (p *installProgress) heartbeat() {
+	defer close(p.done)
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+			p.mu.Lock()
+			fmt.Fprintln(p.out, p.heartbeatLine(time.Since(p.start)))
+			p.mu.Unlock()
+		}
+	}
+}
+
+// heartbeatLine 一行心跳：已下载字节数、进度、速度和预计剩余时间
+func (p *installProgress) heartbeatLine(elapsed time.Duration) string {
+	info := p.last
+	if info.Downloaded == 0 && info.Total == 0 {
+		if info.Status != "" {
+			return fmt.Sprintf("[%s] %s，已用时 %s", p.label, info.Status, formatDuration(elapsed))
+		}
+		return fmt.Sprintf("[%s] 等待下载开始，已用时 %s", p.label, formatDuration(elapsed))
+	}
+
+	line := fmt.Sprintf("[%s] 已下载 %s", p.label, formatBytes(info.Downloaded))
+	if info.Total > 0 {
+		line += fmt.Sprintf(" / %s (%.1f%%)", formatBytes(info.Total), float64(info.Downloaded)/float64(info.Total)*100)
+	}
+	speed := info.Speed
+	if speed <= 0 && elapsed >= time.Second {
+		speed = int64(float64(info.Downloaded) / elapsed.Seconds())
+	}
+	if speed > 0 {
+		line += fmt.Sprintf("，%s/s", formatBytes(speed))
+		if info.Total > info.Downloaded {
+			line += fmt.Sprintf("，预计剩余 %s", formatDuration(time.Duration((info.Total-info.Downloaded)/speed)*time.Second))
+		}
+	}
+	return line + fmt.Sprintf("，已用时 %s", formatDuration(elapsed))
+}
+
+// Finish 停止心跳并注销登记，非终端输出时打印一行汇总
+func (p *installProgress) Finish(err error) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	stop, done := p.stop, p.done
+	p.mu.Unlock()
+	if stop != nil {
+		close(stop)
+		<-done
+	}
+	if p.unregister != nil {
+		p.unregister()
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.terminal {
+		fmt.Fprintln(p.out)
+		return
+	}
+	fmt.Fprintln(p.out, p.summaryLine(time.Since(p.start), err))
+}
+
+// summaryLine 一行汇总：下载大小、用时和平均速度
+func (p *installProgress) summaryLine(elapsed time.Duration, err error) string {
+	downloaded := p.last.Downloaded
+	if err == nil && p.last.Total > downloaded {
+		downloaded = p.last.Total
+	}
+	if err != nil {
+		return fmt.Sprintf("[%s] 安装失败，已下载 %s，用时 %s", p.label, formatBytes(downloaded), formatDuration(elapsed))
+	}
+
+	line := fmt.Sprintf("[%s] 下载完成 %s，用时 %s", p.label, formatBytes(downloaded), formatDuration(elapsed))
+	if elapsed >= time.Second && downloaded > 0 {
+		line += fmt.Sprintf("，平均 %s/s", formatBytes(int64(float64(downloaded)/elapsed.Seconds())))
+	}
+	return line
+}
+
+// stdoutIsTerminal 标准输出是否为终端
+func stdoutIsTerminal() bool {
+	stat, err := os.Stdout.Stat()
+	if err != nil {
+		return false
+	}
+	return stat.Mode()&os.ModeCharDevice != 0
+}
+
+// registerProgress 在 dir 中以进程号登记当前进程输出进度的终端，返回注销函数
+func registerProgress(dir, tty string) func() {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return func() {}
+	}
+	path := filepath.Join(dir, strconv.Itoa(os.Getpid()))
+	if err := os.WriteFile(path, []byte(tty), 0644); err != nil {
+		return func() {}
+	}
+	return func() { os.Remove(path) }
+}
+
+// progressSiblings 统计 dir 中登记的、向同一终端输出进度的其他进程数，顺带清理已退出进程的登记
+// 任一方无法确定终端时按共用终端处理
+func progressSiblings(dir, tty string) int {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0
+	}
+	count := 0
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil || pid == os.Getpid() {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		if !storage.ProcessAlive(pid) {
+			os.Remove(path)
+			continue
+		}
+		other, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		if tty != "" && len(other) > 0 && string(other) != tty {
+			continue
+		}
+		count++
+	}
+	return count
+}
+
+// terminalName 标准输出所在终端的设备路径，无法确定时返回空字符串
+func terminalName() string {
+	name, err := os.Readlink("/proc/self/fd/1")
+	if err != nil {
+		return ""
+	}
+	return name
+}
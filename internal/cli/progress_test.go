@@ -0,0 +1,115 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/songzhibin97/vman/pkg/types"
+)
+
+func TestInstallProgressHeartbeat(t *testing.T) {
+	var out bytes.Buffer
+	progress := newInstallProgress(&out, "kubectl@1.29.0", false, 0)
+	progress.Callback()(&types.ProgressInfo{Total: 4 << 20, Downloaded: 1 << 20, Speed: 512 << 10})
+
+	line := progress.heartbeatLine(10 * time.Second)
+	assert.Equal(t, "[kubectl@1.29.0] 已下载 1.0 MB / 4.0 MB (25.0%)，512.0 KB/s，预计剩余 6s，已用时 10s", line)
+	assert.Empty(t, out.String(), "非终端输出时进度回调不直接输出")
+
+	// 还没有收到进度时也输出心跳
+	idle := newInstallProgress(&out, "go@1.22.0", false, 0)
+	assert.Equal(t, "[go@1.22.0] 等待下载开始，已用时 45s", idle.heartbeatLine(45*time.Second))
+
+	assert.Equal(t, "[kubectl@1.29.0] 下载完成 4.0 MB，用时 8s，平均 512.0 KB/s", progress.summaryLine(8*time.Second, nil))
+	assert.Equal(t, "[kubectl@1.29.0] 安装失败，已下载 1.0 MB，用时 8s", progress.summaryLine(8*time.Second, errors.New("boom")))
+}
+
+func TestInstallProgressTicker(t *testing.T) {
+	var out syncBuffer
+	progress := newInstallProgress(&out, "kubectl@1.29.0", false, 10*time.Millisecond)
+	progress.Callback()(&types.ProgressInfo{Total: 100, Downloaded: 50})
+	assert.Eventually(t, func() bool {
+		return strings.Contains(out.String(), "已下载 50 B / 100 B (50.0%)")
+	}, time.Second, 5*time.Millisecond)
+
+	progress.Finish(nil)
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.Contains(t, lines[len(lines)-1], "[kubectl@1.29.0] 下载完成 100 B")
+	assert.NotContains(t, out.String(), "\r")
+
+	// 静默模式不创建进度输出
+	var quiet *installProgress
+	assert.Nil(t, quiet.Callback())
+	quiet.Finish(nil)
+}
+
+func TestInstallProgressSiblings(t *testing.T) {
+	dir := t.TempDir()
+	tty := "/dev/pts/1"
+	sibling := filepath.Join(dir, strconv.Itoa(os.Getppid()))
+
+	var out syncBuffer
+	progress := newInstallProgress(&out, "kubectl@1.29.0", true, time.Minute)
+	progress.shareTerminal(dir, tty)
+	assert.True(t, progress.terminal, "没有其他进程时原地刷新进度")
+	assert.FileExists(t, filepath.Join(dir, strconv.Itoa(os.Getpid())))
+
+	// 其他终端中的进程不影响，已退出进程的登记被清理
+	require.NoError(t, os.WriteFile(sibling, []byte("/dev/pts/2"), 0644))
+	dead := filepath.Join(dir, "999999999")
+	require.NoError(t, os.WriteFile(dead, []byte(tty), 0644))
+	assert.Equal(t, 0, progressSiblings(dir, tty))
+	assert.NoFileExists(t, dead)
+
+	progress.Callback()(&types.ProgressInfo{Total: 100, Downloaded: 10, Percentage: 10, Status: "downloading"})
+	assert.Contains(t, out.String(), "\r下载进度: 10.0%")
+
+	// 同一终端出现其他进程后换行并改为逐行输出
+	require.NoError(t, os.WriteFile(sibling, []byte(tty), 0644))
+	progress.lastCheck = time.Time{}
+	progress.Callback()(&types.ProgressInfo{Total: 100, Downloaded: 50})
+	assert.False(t, progress.terminal)
+	assert.Equal(t, sharedProgressInterval, progress.interval)
+	assert.Contains(t, out.String(), "10.0% (10 B) - downloading\n[kubectl@1.29.0] 其他 vman 进程正在同时输出进度，改为逐行输出\n")
+
+	progress.Finish(nil)
+	assert.Contains(t, out.String(), "[kubectl@1.29.0] 下载完成 100 B")
+	assert.NoFileExists(t, filepath.Join(dir, strconv.Itoa(os.Getpid())))
+
+	// 启动时已有其他进程则直接逐行输出
+	var lines syncBuffer
+	later := newInstallProgress(&lines, "go@1.22.0", true, time.Minute)
+	later.shareTerminal(dir, tty)
+	later.Callback()(&types.ProgressInfo{Total: 100, Downloaded: 50})
+	later.Finish(nil)
+	assert.NotContains(t, lines.String(), "\r")
+	assert.True(t, strings.HasPrefix(lines.String(), "[go@1.22.0] 其他 vman 进程正在同时输出进度"))
+}
+
+// syncBuffer 可以在心跳协程写入时并发读取的缓冲区
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
@@ -0,0 +1,395 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/songzhibin97/vman/internal/config"
+	"github.com/songzhibin97/vman/pkg/types"
+	"github.com/songzhibin97/vman/pkg/utils"
+)
+
+// projectsCmd 项目注册表管理
+var projectsCmd = &cobra.Command{
+	Use:   "projects",
+	Short: "管理注册的项目",
+	Long: `管理注册的项目。注册的项目记录在配置目录下的 projects.json 中。
+
+注册项目中 .vman.yaml、.vman-version 和 .tool-versions 引用的版本在 vman prune 时不会被删除，
+vman outdated --all-projects 检查所有注册项目中的工具版本。`,
+}
+
+// projectsListCmd 列出注册的项目
+var projectsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "列出注册的项目",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		jsonFormat, _ := cmd.Flags().GetBool("json")
+
+		managers, err := createManagers()
+		if err != nil {
+			return fmt.Errorf("创建管理器失败: %w", err)
+		}
+		registry, err := loadProjectRegistry(managers.config)
+		if err != nil {
+			return err
+		}
+
+		if jsonFormat {
+			jsonData, err := json.MarshalIndent(registry.Projects, "", "  ")
+			if err != nil {
+				return fmt.Errorf("JSON编码失败: %w", err)
+			}
+			fmt.Println(string(jsonData))
+			return nil
+		}
+
+		if len(registry.Projects) == 0 {
+			fmt.Println("没有注册的项目，使用 vman projects add <path> 注册")
+			return nil
+		}
+		table := NewTablePrinter([]string{"PATH", "TOOLS", "ADDED"}, getUIOptions(cmd))
+		for _, project := range registry.Projects {
+			tools := "(不存在)"
+			if dirExists(project.Path) {
+				versions, err := projectVersions(project.Path)
+				if err != nil {
+					tools = "(读取失败)"
+				} else {
+					tools = fmt.Sprint(len(versions))
+				}
+			}
+			table.AddRow([]string{project.Path, tools, project.AddedAt.Format("2006-01-02")})
+		}
+		table.Print()
+		return nil
+	},
+}
+
+// projectsAddCmd 注册项目
+var projectsAddCmd = &cobra.Command{
+	Use:         "add <path...>",
+	Annotations: mutating(),
+	Short:       "注册项目",
+	Long: `注册项目目录。目录中需要有 .vman.yaml、.vman-version 或 .tool-versions。
+
+示例:
+  vman projects add .
+  vman projects add ~/src/api ~/src/web`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		return updateProjectRegistry(cmd, func(registry *config.ProjectRegistry) (int, error) {
+			added := 0
+			for _, arg := range args {
+				dir, err := projectDir(arg)
+				if err != nil {
+					return added, err
+				}
+				if !config.HasProjectVersionFile(afero.NewOsFs(), dir) {
+					return added, fmt.Errorf("%s 中没有 %s", dir, strings.Join(config.ProjectVersionFiles, "、"))
+				}
+				if registry.Add(dir, time.Now()) {
+					Infof(getUIOptions(cmd), "已注册项目 %s\n", dir)
+					added++
+				} else {
+					fmt.Printf("项目 %s 已注册\n", dir)
+				}
+			}
+			return added, nil
+		})
+	},
+}
+
+// projectsRemoveCmd 取消注册项目
+var projectsRemoveCmd = &cobra.Command{
+	Use:         "remove <path...>",
+	Annotations: mutating(),
+	Short:       "取消注册项目",
+	Long: `取消注册项目，不会修改项目目录。使用 --missing 取消注册所有已不存在的项目。
+
+示例:
+  vman projects remove ~/src/old-api
+  vman projects remove --missing`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		missing, _ := cmd.Flags().GetBool("missing")
+		if len(args) == 0 && !missing {
+			return fmt.Errorf("请指定项目路径或使用 --missing")
+		}
+		cmd.SilenceUsage = true
+
+		return updateProjectRegistry(cmd, func(registry *config.ProjectRegistry) (int, error) {
+			removed := 0
+			for _, arg := range args {
+				// 目录可能已被删除，不要求路径存在
+				dir, err := filepath.Abs(arg)
+				if err != nil {
+					return removed, fmt.Errorf("解析路径失败: %w", err)
+				}
+				if dir = canonicalDir(dir); !registry.Remove(dir) {
+					return removed, fmt.Errorf("项目 %s 未注册", dir)
+				}
+				Infof(getUIOptions(cmd), "已取消注册项目 %s\n", dir)
+				removed++
+			}
+			if missing {
+				for _, path := range registry.Paths() {
+					if !dirExists(path) {
+						registry.Remove(path)
+						Infof(getUIOptions(cmd), "已取消注册不存在的项目 %s\n", path)
+						removed++
+					}
+				}
+			}
+			return removed, nil
+		})
+	},
+}
+
+// projectsScanCmd 扫描并注册目录下的项目
+var projectsScanCmd = &cobra.Command{
+	Use:         "scan <dir>",
+	Annotations: mutating(),
+	Short:       "扫描目录并注册其中的项目",
+	Long: `在目录下查找有 .vman.yaml、.vman-version 或 .tool-versions 的子目录并注册。
+跳过 .git、node_modules、vendor 和 .terraform 目录。
+
+示例:
+  vman projects scan ~/src
+  vman projects scan ~/src --depth 3 --dry-run`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		depth, _ := cmd.Flags().GetInt("depth")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		cmd.SilenceUsage = true
+
+		root, err := projectDir(args[0])
+		if err != nil {
+			return err
+		}
+		found, err := config.ScanProjects(afero.NewOsFs(), root, depth)
+		if err != nil {
+			return err
+		}
+		if len(found) == 0 {
+			fmt.Printf("%s 下没有找到项目\n", root)
+			return nil
+		}
+
+		if dryRun {
+			for _, dir := range found {
+				fmt.Println(dir)
+			}
+			return nil
+		}
+		return updateProjectRegistry(cmd, func(registry *config.ProjectRegistry) (int, error) {
+			added := 0
+			for _, dir := range found {
+				if registry.Add(dir, time.Now()) {
+					Infof(getUIOptions(cmd), "已注册项目 %s\n", dir)
+					added++
+				}
+			}
+			Infof(getUIOptions(cmd), "找到 %d 个项目，新注册 %d 个\n", len(found), added)
+			return added, nil
+		})
+	},
+}
+
+// projectRegistryPath 项目注册表的路径
+func projectRegistryPath(configManager config.Manager) string {
+	return filepath.Join(configManager.GetConfigDir(), config.ProjectsFile)
+}
+
+// loadProjectRegistry 读取项目注册表
+func loadProjectRegistry(configManager config.Manager) (*config.ProjectRegistry, error) {
+	return config.LoadProjectRegistry(afero.NewOsFs(), projectRegistryPath(configManager))
+}
+
+// updateProjectRegistry 修改项目注册表，update 返回修改的项目数，有修改时保存
+func updateProjectRegistry(cmd *cobra.Command, update func(*config.ProjectRegistry) (int, error)) error {
+	managers, err := createManagers()
+	if err != nil {
+		return fmt.Errorf("创建管理器失败: %w", err)
+	}
+	registry, err := loadProjectRegistry(managers.config)
+	if err != nil {
+		return err
+	}
+
+	changed, updateErr := update(registry)
+	if changed > 0 {
+		if err := registry.Save(afero.NewOsFs(), projectRegistryPath(managers.config)); err != nil {
+			return err
+		}
+	}
+	return updateErr
+}
+
+// projectDir 将参数转换为项目目录的绝对路径
+func projectDir(arg string) (string, error) {
+	dir, err := filepath.Abs(arg)
+	if err != nil {
+		return "", fmt.Errorf("解析路径失败: %w", err)
+	}
+	if !dirExists(dir) {
+		return dir, fmt.Errorf("目录不存在: %s", dir)
+	}
+	return canonicalDir(dir), nil
+}
+
+// dirExists 路径是否为已存在的目录
+func dirExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// projectVersions 项目目录中各版本文件引用的工具版本
+func projectVersions(dir string) (map[string][]string, error) {
+	versions := make(map[string][]string)
+	for _, name := range config.ProjectVersionFiles {
+		path := filepath.Join(dir, name)
+		if !utils.FileExists(path) {
+			continue
+		}
+		fileVersions, err := readToolVersionsFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		for tool, list := range fileVersions {
+			for _, version := range list {
+				if !containsString(versions[tool], version) {
+					versions[tool] = append(versions[tool], version)
+				}
+			}
+		}
+	}
+	return versions, nil
+}
+
+// readToolVersionsFile 读取版本文件中的所有工具版本，支持项目配置（tools 和 paths）和 "工具 版本" 格式的文件
+func readToolVersionsFile(path string) (map[string][]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取版本文件失败: %w", err)
+	}
+
+	versions := make(map[string][]string)
+	var project types.ProjectConfig
+	if err := yaml.Unmarshal(data, &project); err == nil && (len(project.Tools) > 0 || len(project.Paths) > 0) {
+		for tool, version := range project.Tools {
+			versions[tool] = append(versions[tool], version)
+		}
+		for _, pathTools := range project.Paths {
+			for tool, version := range pathTools {
+				if !containsString(versions[tool], version) {
+					versions[tool] = append(versions[tool], version)
+				}
+			}
+		}
+		return versions, nil
+	}
+
+	lines, err := readVersionFile(path)
+	if err != nil {
+		return nil, err
+	}
+	for tool, version := range lines {
+		versions[tool] = []string{version}
+	}
+	return versions, nil
+}
+
+// projectRetention 注册项目引用的已安装版本，工具 -> 版本 -> 引用该版本的项目
+type projectRetention map[string]map[string]string
+
+// retainedByProjects 查找注册项目引用的已安装版本，版本要求为范围时保留满足范围的最高已安装版本
+func retainedByProjects(managers *managers, options *UIOptions) projectRetention {
+	retained := projectRetention{}
+	registry, err := loadProjectRegistry(managers.config)
+	if err != nil {
+		PrintWarning(fmt.Sprintf("读取项目注册表失败，不保留项目引用的版本: %v", err), options)
+		return retained
+	}
+
+	for _, dir := range registry.Paths() {
+		if !dirExists(dir) {
+			PrintWarning(fmt.Sprintf("注册的项目 %s 不存在，使用 vman projects remove --missing 清理", dir), options)
+			continue
+		}
+		versions, err := projectVersions(dir)
+		if err != nil {
+			PrintWarning(fmt.Sprintf("读取项目 %s 的版本失败: %v", dir, err), options)
+			continue
+		}
+		for tool, requested := range versions {
+			installed, err := managers.version.ListVersions(tool)
+			if err != nil || len(installed) == 0 {
+				continue
+			}
+			for _, version := range requested {
+				if version = matchInstalledVersion(installed, version); version == "" {
+					continue
+				}
+				if retained[tool] == nil {
+					retained[tool] = map[string]string{}
+				}
+				if _, ok := retained[tool][version]; !ok {
+					retained[tool][version] = dir
+				}
+			}
+		}
+	}
+	return retained
+}
+
+// matchInstalledVersion 返回与版本要求对应的已安装版本，没有时返回空
+func matchInstalledVersion(installed []string, requested string) string {
+	if isVersionConstraint(requested) {
+		constraint, err := semver.NewConstraint(requested)
+		if err != nil {
+			return ""
+		}
+		return highestMatchingVersion(installed, constraint)
+	}
+	for _, version := range installed {
+		if strings.TrimPrefix(version, "v") == strings.TrimPrefix(requested, "v") {
+			return version
+		}
+	}
+	return ""
+}
+
+// sortedProjectTools 按名称排序的工具列表
+func sortedProjectTools(versions map[string][]string) []string {
+	tools := make([]string, 0, len(versions))
+	for tool := range versions {
+		tools = append(tools, tool)
+	}
+	sort.Strings(tools)
+	return tools
+}
+
+func init() {
+	rootCmd.AddCommand(projectsCmd)
+	projectsCmd.AddCommand(projectsListCmd)
+	projectsCmd.AddCommand(projectsAddCmd)
+	projectsCmd.AddCommand(projectsRemoveCmd)
+	projectsCmd.AddCommand(projectsScanCmd)
+
+	projectsListCmd.Flags().Bool("json", false, "使用JSON格式输出")
+	projectsRemoveCmd.Flags().Bool("missing", false, "取消注册所有已不存在的项目")
+	projectsScanCmd.Flags().Int("depth", 2, "扫描的最大目录深度")
+	projectsScanCmd.Flags().Bool("dry-run", false, "只列出找到的项目，不注册")
+}
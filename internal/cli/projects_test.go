@@ -0,0 +1,42 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProjectVersions(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".vman.yaml"), []byte(`version: "1.0"
+tools:
+  kubectl: 1.29.0
+  terraform: ~1.7
+`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".tool-versions"), []byte("kubectl 1.30.0\nhelm 3.14.0\n"), 0644))
+
+	versions, err := projectVersions(dir)
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]string{
+		"kubectl":   {"1.29.0", "1.30.0"},
+		"terraform": {"~1.7"},
+		"helm":      {"3.14.0"},
+	}, versions)
+
+	versions, err = projectVersions(t.TempDir())
+	require.NoError(t, err)
+	assert.Empty(t, versions)
+}
+
+func TestMatchInstalledVersion(t *testing.T) {
+	installed := []string{"1.6.6", "1.7.0", "1.7.5", "v1.8.0"}
+
+	assert.Equal(t, "1.7.0", matchInstalledVersion(installed, "1.7.0"))
+	assert.Equal(t, "v1.8.0", matchInstalledVersion(installed, "1.8.0"))
+	assert.Equal(t, "1.7.5", matchInstalledVersion(installed, "~1.7"))
+	assert.Equal(t, "", matchInstalledVersion(installed, "1.9.0"))
+	assert.Equal(t, "", matchInstalledVersion(installed, "^2"))
+}
@@ -0,0 +1,220 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/songzhibin97/vman/internal/config"
+	"github.com/songzhibin97/vman/pkg/utils"
+)
+
+// projectsStatusCmd 检查所有注册项目的状态
+var projectsStatusCmd = &cobra.Command{
+	Use:   "status [path...]",
+	Short: "检查所有注册项目的工具状态",
+	Long: `逐个检查注册的项目（默认全部），报告：
+  - 缺少的工具：版本文件引用了但没有安装的版本，或没有工具定义的工具
+  - 过期的版本：版本文件中的版本低于远程最新版本（--offline 时不检查）
+  - 配置错误：版本文件无法解析或 .vman.yaml 没有通过验证
+
+使用 --exit-code 时，任一项目有问题则以非零状态退出。
+
+示例:
+  vman projects status
+  vman projects status --offline
+  vman projects status ~/src/api --json`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		jsonFormat, _ := cmd.Flags().GetBool("json")
+		offline, _ := cmd.Flags().GetBool("offline")
+		exitCode, _ := cmd.Flags().GetBool("exit-code")
+		prerelease, _ := cmd.Flags().GetBool("prerelease")
+		uiOptions := getUIOptions(cmd)
+
+		managers, err := createManagers()
+		if err != nil {
+			return fmt.Errorf("创建管理器失败: %w", err)
+		}
+		registry, err := loadProjectRegistry(managers.config)
+		if err != nil {
+			return err
+		}
+
+		dirs := registry.Paths()
+		if len(args) > 0 {
+			dirs = dirs[:0]
+			for _, arg := range args {
+				dir, err := filepath.Abs(arg)
+				if err != nil {
+					return fmt.Errorf("解析路径失败: %w", err)
+				}
+				dir = canonicalDir(dir)
+				if !registry.Contains(dir) {
+					return fmt.Errorf("项目 %s 未注册，使用 vman projects add 注册", dir)
+				}
+				dirs = append(dirs, dir)
+			}
+		}
+		if len(dirs) == 0 {
+			fmt.Println("没有注册的项目，使用 vman projects add <path> 注册")
+			return nil
+		}
+
+		var checker *outdatedChecker
+		if !offline {
+			integratedManager, err := createIntegratedManager()
+			if err != nil {
+				return fmt.Errorf("创建管理器失败: %w", err)
+			}
+			checker = &outdatedChecker{managers: managers, integrated: integratedManager, prerelease: prerelease}
+		}
+
+		statuses := make([]*projectStatus, 0, len(dirs))
+		for _, dir := range dirs {
+			statuses = append(statuses, checkProjectStatus(managers, checker, dir))
+		}
+
+		if jsonFormat {
+			jsonData, err := json.MarshalIndent(statuses, "", "  ")
+			if err != nil {
+				return fmt.Errorf("JSON编码失败: %w", err)
+			}
+			fmt.Println(string(jsonData))
+		} else {
+			printProjectStatuses(statuses, uiOptions)
+		}
+
+		if exitCode {
+			for _, status := range statuses {
+				if status.HasIssues() {
+					cmd.SilenceUsage = true
+					return fmt.Errorf("存在有问题的项目")
+				}
+			}
+		}
+		return nil
+	},
+}
+
+// projectStatus 一个注册项目的检查结果
+type projectStatus struct {
+	Path     string             `json:"path"`
+	Tools    int                `json:"tools"`
+	Missing  []string           `json:"missing,omitempty"`
+	Outdated []*projectOutdated `json:"outdated,omitempty"`
+	Errors   []string           `json:"errors,omitempty"`
+	// Warnings 不影响项目状态的提示，如查询远程版本失败
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// projectOutdated 版本文件中低于远程最新版本的工具
+type projectOutdated struct {
+	Tool      string `json:"tool"`
+	Requested string `json:"requested"`
+	Current   string `json:"current"`
+	Latest    string `json:"latest"`
+}
+
+// HasIssues 项目是否有缺少的工具、过期的版本或配置错误
+func (s *projectStatus) HasIssues() bool {
+	return len(s.Missing) > 0 || len(s.Outdated) > 0 || len(s.Errors) > 0
+}
+
+// checkProjectStatus 检查一个项目，checker 为 nil 时不查询远程版本
+func checkProjectStatus(managers *managers, checker *outdatedChecker, dir string) *projectStatus {
+	status := &projectStatus{Path: dir}
+	if !dirExists(dir) {
+		status.Errors = append(status.Errors, "项目目录不存在")
+		return status
+	}
+
+	if configPath := managers.config.GetProjectConfigPath(dir); utils.FileExists(configPath) {
+		projectConfig, err := managers.config.LoadProject(dir)
+		if err == nil {
+			err = config.NewValidator().ValidateProjectConfig(projectConfig)
+		}
+		if err != nil {
+			status.Errors = append(status.Errors, fmt.Sprintf("%s: %v", filepath.Base(configPath), err))
+		}
+	}
+
+	versions, err := projectVersions(dir)
+	if err != nil {
+		status.Errors = append(status.Errors, err.Error())
+		return status
+	}
+	status.Tools = len(versions)
+
+	for _, tool := range sortedProjectTools(versions) {
+		metadata, err := managers.config.LoadToolConfig(tool)
+		if err != nil {
+			status.Missing = append(status.Missing, fmt.Sprintf("%s (没有工具定义)", tool))
+			continue
+		}
+		installed, _ := managers.version.ListVersions(tool)
+
+		for _, requested := range versions[tool] {
+			current := matchInstalledVersion(installed, requested)
+			if current == "" {
+				status.Missing = append(status.Missing, tool+"@"+requested)
+				continue
+			}
+			if checker == nil {
+				continue
+			}
+
+			remote := checker.remote(tool)
+			if remote.err != nil {
+				status.Warnings = append(status.Warnings, fmt.Sprintf("查询 %s 的远程版本失败: %v", tool, remote.err))
+				break
+			}
+			entry := &outdatedEntry{Tool: tool, Current: current, Requested: requested}
+			entry.resolve(remote.versions, metadata.VersionConfig.Constraints, checker.prerelease)
+			if entry.Outdated {
+				status.Outdated = append(status.Outdated, &projectOutdated{Tool: tool, Requested: requested, Current: current, Latest: entry.Latest})
+			}
+		}
+	}
+	return status
+}
+
+// printProjectStatuses 输出项目检查结果，每个项目一行状态，有问题时列出详情
+func printProjectStatuses(statuses []*projectStatus, options *UIOptions) {
+	withIssues := 0
+	for _, status := range statuses {
+		if !status.HasIssues() {
+			fmt.Printf("%s %s %s\n", Emoji(EmojiCheckMark, options), ColorizeBold(status.Path, options),
+				ColorizeDim(fmt.Sprintf("(%d 个工具)", status.Tools), options))
+		} else {
+			withIssues++
+			issues := len(status.Missing) + len(status.Outdated) + len(status.Errors)
+			fmt.Printf("%s %s %s\n", Emoji(EmojiWarning, options), ColorizeBold(status.Path, options),
+				ColorizeWarning(fmt.Sprintf("%d 个问题", issues), options))
+			for _, message := range status.Errors {
+				fmt.Printf("    %s %s\n", ColorizeError("配置错误", options), message)
+			}
+			for _, missing := range status.Missing {
+				fmt.Printf("    %s %s\n", ColorizeError("缺少", options), missing)
+			}
+			for _, outdated := range status.Outdated {
+				fmt.Printf("    %s %s %s，最新 %s\n", ColorizeWarning("过期", options), outdated.Tool, outdated.Requested, outdated.Latest)
+			}
+		}
+		for _, warning := range status.Warnings {
+			fmt.Printf("    %s\n", ColorizeDim(warning, options))
+		}
+	}
+
+	fmt.Printf("\n%d 个项目，%d 个有问题\n", len(statuses), withIssues)
+}
+
+func init() {
+	projectsCmd.AddCommand(projectsStatusCmd)
+
+	projectsStatusCmd.Flags().Bool("json", false, "使用JSON格式输出")
+	projectsStatusCmd.Flags().Bool("offline", false, "不查询远程版本，只检查缺少的工具和配置错误")
+	projectsStatusCmd.Flags().Bool("exit-code", false, "存在有问题的项目时以非零状态退出")
+	projectsStatusCmd.Flags().Bool("prerelease", false, "将预发布版本作为最新版本")
+}
@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/songzhibin97/vman/pkg/types"
+)
+
+func TestCheckProjectStatus(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	paths := types.DefaultConfigPaths(home)
+
+	require.NoError(t, os.MkdirAll(paths.ToolsDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(paths.ToolsDir, "kubectl.toml"), []byte(`name = "kubectl"
+
+[download]
+type = "direct"
+url_template = "https://dl.k8s.io/release/v{version}/bin/{os}/{arch}/kubectl"
+`), 0644))
+	binDir := filepath.Join(paths.ToolVersionDir("kubectl", "1.29.0"), "bin")
+	require.NoError(t, os.MkdirAll(binDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(binDir, "kubectl"), []byte("#!/bin/sh\n"), 0755))
+
+	managers, err := createManagers()
+	require.NoError(t, err)
+
+	project := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(project, ".tool-versions"), []byte("kubectl 1.29.0\nhelm 3.14.0\n"), 0644))
+	status := checkProjectStatus(managers, nil, project)
+	assert.Equal(t, 2, status.Tools)
+	assert.Equal(t, []string{"helm (没有工具定义)"}, status.Missing)
+	assert.Empty(t, status.Errors)
+	assert.True(t, status.HasIssues())
+
+	require.NoError(t, os.WriteFile(filepath.Join(project, ".tool-versions"), []byte("kubectl 1.30.0\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(project, ".vman.yaml"), []byte("version: \"9\"\ntools:\n  kubectl: 1.29.0\n"), 0644))
+	status = checkProjectStatus(managers, nil, project)
+	assert.Equal(t, []string{"kubectl@1.30.0"}, status.Missing)
+	require.Len(t, status.Errors, 1)
+	assert.Contains(t, status.Errors[0], ".vman.yaml")
+
+	status = checkProjectStatus(managers, nil, filepath.Join(project, "missing"))
+	assert.Equal(t, []string{"项目目录不存在"}, status.Errors)
+}
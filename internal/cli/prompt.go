@@ -0,0 +1,348 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/songzhibin97/vman/internal/proxy"
+	"github.com/songzhibin97/vman/pkg/types"
+	"github.com/songzhibin97/vman/pkg/utils"
+)
+
+// promptCacheFile 缓存目录中提示符版本缓存的文件名
+const promptCacheFile = "prompt.json"
+
+// promptCacheMaxEntries 提示符缓存最多保留的目录数
+const promptCacheMaxEntries = 256
+
+// promptInputFiles 每一级目录中影响版本解析的文件
+var promptInputFiles = []string{".vman-version", ".tool-versions", ".vman.yaml", "package.json", "go.mod"}
+
+// promptPlaceholder 格式中的 {tool} 占位符
+var promptPlaceholder = regexp.MustCompile(`\{([A-Za-z0-9._-]+)\}`)
+
+// promptCmd 输出当前目录生效的工具版本，用于嵌入shell提示符
+var promptCmd = &cobra.Command{
+	Use:   "prompt",
+	Short: "输出当前生效的工具版本，用于shell提示符",
+	Long: `按格式输出当前目录生效的工具版本，适合嵌入 PS1 或 starship 等提示符。
+
+格式中的 {tool} 替换为该工具当前生效的版本，未安装或无法解析时替换为空。
+不指定格式时输出由项目配置或环境变量指定版本的工具，如 kubectl@1.29.0。
+
+解析结果持久缓存在缓存目录的 prompt.json 中，项目配置、全局配置、
+已安装版本和版本环境变量没有变化时直接使用缓存，不需要加载配置。
+
+示例:
+  vman prompt
+  vman prompt --format '{kubectl} {terraform}'
+  PS1='$(vman prompt --format "k8s:{kubectl}") \$ '
+  vman prompt starship kubectl terraform >> ~/.config/starship.toml`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		format, _ := cmd.Flags().GetString("format")
+
+		homeDir, err := utils.GetHomeDir()
+		if err != nil {
+			return err
+		}
+		cwd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("获取当前目录失败: %w", err)
+		}
+
+		paths := types.DefaultConfigPaths(homeDir)
+		versions, err := promptVersions(paths, canonicalDir(cwd), promptTools(paths, format))
+		if err != nil {
+			return err
+		}
+		fmt.Println(renderPrompt(format, versions))
+		return nil
+	},
+}
+
+// promptStarshipCmd 生成 starship 自定义模块配置
+var promptStarshipCmd = &cobra.Command{
+	Use:   "starship [tool...]",
+	Short: "生成 starship 自定义模块配置",
+	Long: `为每个工具生成一个 starship 自定义模块，通过 vman prompt 显示当前生效的版本。
+工具没有生效的版本时 starship 不显示该模块。不指定工具时为所有已安装的工具生成。
+
+示例:
+  vman prompt starship kubectl terraform >> ~/.config/starship.toml`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		tools := args
+		if len(tools) == 0 {
+			homeDir, err := utils.GetHomeDir()
+			if err != nil {
+				return err
+			}
+			tools = installedToolNames(types.DefaultConfigPaths(homeDir))
+		}
+		if len(tools) == 0 {
+			return fmt.Errorf("没有已安装的工具，请指定工具名称")
+		}
+		fmt.Print(starshipModules(tools))
+		return nil
+	},
+}
+
+// promptVersion 提示符中一个工具的解析结果
+type promptVersion struct {
+	Version string `json:"version,omitempty"`
+	Source  string `json:"source,omitempty"`
+}
+
+// promptCacheEntry 一个目录的解析结果，Stamp 为解析时所有输入文件和环境变量的摘要
+type promptCacheEntry struct {
+	Stamp    string                    `json:"stamp"`
+	Tools    map[string]*promptVersion `json:"tools"`
+	CachedAt time.Time                 `json:"cached_at"`
+}
+
+// promptCache 提示符版本缓存，按目录保存
+type promptCache struct {
+	Entries map[string]*promptCacheEntry `json:"entries"`
+}
+
+// promptTools 返回需要解析的工具：格式中的占位符，没有格式时为所有已安装的工具
+func promptTools(paths *types.ConfigPaths, format string) []string {
+	if format == "" {
+		return installedToolNames(paths)
+	}
+	seen := make(map[string]bool)
+	var tools []string
+	for _, match := range promptPlaceholder.FindAllStringSubmatch(format, -1) {
+		if !seen[match[1]] {
+			seen[match[1]] = true
+			tools = append(tools, match[1])
+		}
+	}
+	sort.Strings(tools)
+	return tools
+}
+
+// installedToolNames 读取版本目录中已安装的工具
+func installedToolNames(paths *types.ConfigPaths) []string {
+	entries, err := os.ReadDir(paths.VersionsDir)
+	if err != nil {
+		return nil
+	}
+	var tools []string
+	for _, entry := range entries {
+		if entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") {
+			tools = append(tools, entry.Name())
+		}
+	}
+	return tools
+}
+
+// promptVersions 返回 dir 中各工具生效的版本，缓存有效时不加载配置
+func promptVersions(paths *types.ConfigPaths, dir string, tools []string) (map[string]*promptVersion, error) {
+	cachePath := filepath.Join(paths.CacheDir, promptCacheFile)
+	cache := loadPromptCache(cachePath)
+	stamp := promptStamp(paths, dir, tools)
+
+	if entry, ok := cache.Entries[dir]; ok && entry.Stamp == stamp {
+		return entry.Tools, nil
+	}
+
+	versions, err := resolvePromptVersions(dir, tools)
+	if err != nil {
+		return nil, err
+	}
+	cache.put(dir, &promptCacheEntry{Stamp: stamp, Tools: versions, CachedAt: time.Now()})
+	// 缓存写入失败只影响下次的速度
+	_ = cache.save(cachePath)
+	return versions, nil
+}
+
+// resolvePromptVersions 加载配置解析各工具的版本，未安装的工具不解析以免触发自动安装
+func resolvePromptVersions(dir string, tools []string) (map[string]*promptVersion, error) {
+	// 提示符中不能出现日志，管理器的日志默认写到标准错误
+	if devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0); err == nil {
+		stderr := os.Stderr
+		os.Stderr = devNull
+		defer func() {
+			os.Stderr = stderr
+			devNull.Close()
+		}()
+	}
+
+	managers, err := createManagers()
+	if err != nil {
+		return nil, fmt.Errorf("创建管理器失败: %w", err)
+	}
+	resolver := proxy.NewVersionResolver(managers.config, managers.version)
+
+	versions := make(map[string]*promptVersion, len(tools))
+	for _, tool := range tools {
+		versions[tool] = &promptVersion{}
+		if installed, err := managers.version.GetInstalledVersions(tool); err != nil || len(installed) == 0 {
+			continue
+		}
+		resolution, err := resolver.ResolveVersion(context.Background(), tool, dir)
+		if err != nil {
+			continue
+		}
+		versions[tool] = &promptVersion{Version: resolution.Version, Source: resolution.Source}
+	}
+	return versions, nil
+}
+
+// promptStamp 计算影响 dir 中版本解析的输入摘要：
+// 各级目录的项目配置和清单文件、全局配置、工具定义、已安装版本和版本环境变量
+func promptStamp(paths *types.ConfigPaths, dir string, tools []string) string {
+	var b strings.Builder
+	stampFile := func(path string) {
+		info, err := os.Stat(path)
+		if err != nil {
+			fmt.Fprintf(&b, "%s:-\n", path)
+			return
+		}
+		fmt.Fprintf(&b, "%s:%d:%d\n", path, info.ModTime().UnixNano(), info.Size())
+	}
+
+	for current := dir; ; {
+		for _, name := range promptInputFiles {
+			stampFile(filepath.Join(current, name))
+		}
+		parent := filepath.Dir(current)
+		if parent == current {
+			break
+		}
+		current = parent
+	}
+
+	stampFile(paths.GlobalConfigFile)
+	stampFile(paths.VersionsDir)
+	for _, tool := range tools {
+		stampFile(filepath.Join(paths.VersionsDir, tool))
+		stampFile(filepath.Join(paths.ToolsDir, tool+".toml"))
+		upper := strings.ToUpper(tool)
+		fmt.Fprintf(&b, "%s=%s\nVMAN_%s=%s\n", upper, os.Getenv(upper+"_VERSION"), upper, os.Getenv("VMAN_"+upper+"_VERSION"))
+	}
+
+	hash := fnv.New64a()
+	hash.Write([]byte(b.String()))
+	return fmt.Sprintf("%016x", hash.Sum64())
+}
+
+// renderPrompt 按格式输出版本，没有格式时输出由项目或环境变量指定版本的工具
+func renderPrompt(format string, versions map[string]*promptVersion) string {
+	if format != "" {
+		return promptPlaceholder.ReplaceAllStringFunc(format, func(placeholder string) string {
+			if v, ok := versions[placeholder[1:len(placeholder)-1]]; ok {
+				return v.Version
+			}
+			return ""
+		})
+	}
+
+	tools := make([]string, 0, len(versions))
+	for tool, v := range versions {
+		switch v.Source {
+		case "project", "engines", "env":
+			tools = append(tools, tool)
+		}
+	}
+	sort.Strings(tools)
+
+	parts := make([]string, 0, len(tools))
+	for _, tool := range tools {
+		parts = append(parts, tool+"@"+versions[tool].Version)
+	}
+	return strings.Join(parts, " ")
+}
+
+// starshipModules 生成 starship 自定义模块配置
+func starshipModules(tools []string) string {
+	var b strings.Builder
+	b.WriteString("# 由 vman prompt starship 生成\n")
+	for _, tool := range tools {
+		fmt.Fprintf(&b, `
+[custom.vman_%s]
+description = "%s version managed by vman"
+command = "vman prompt --format '{%s}'"
+when = true
+symbol = "%s "
+format = "[$symbol$output]($style) "
+style = "bold blue"
+`, strings.NewReplacer("-", "_", ".", "_").Replace(tool), tool, tool, tool)
+	}
+	return b.String()
+}
+
+// loadPromptCache 读取提示符缓存，文件不存在或损坏时返回空缓存
+func loadPromptCache(path string) *promptCache {
+	cache := &promptCache{}
+	if data, err := os.ReadFile(path); err == nil {
+		_ = json.Unmarshal(data, cache)
+	}
+	if cache.Entries == nil {
+		cache.Entries = make(map[string]*promptCacheEntry)
+	}
+	return cache
+}
+
+// put 保存目录的解析结果，超过上限时丢弃最早缓存的目录
+func (c *promptCache) put(dir string, entry *promptCacheEntry) {
+	c.Entries[dir] = entry
+	for len(c.Entries) > promptCacheMaxEntries {
+		oldest := ""
+		for key, e := range c.Entries {
+			if oldest == "" || e.CachedAt.Before(c.Entries[oldest].CachedAt) {
+				oldest = key
+			}
+		}
+		delete(c.Entries, oldest)
+	}
+}
+
+// save 写入提示符缓存，先写临时文件再重命名，避免并发的提示符读到不完整的文件
+func (c *promptCache) save(path string) error {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), promptCacheFile+".*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(promptCmd)
+	promptCmd.AddCommand(promptStarshipCmd)
+
+	promptCmd.Flags().StringP("format", "f", "", "输出格式，{tool} 替换为工具的版本")
+}
@@ -0,0 +1,94 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/songzhibin97/vman/pkg/types"
+)
+
+func TestRenderPrompt(t *testing.T) {
+	versions := map[string]*promptVersion{
+		"kubectl":   {Version: "1.29.0", Source: "project"},
+		"terraform": {Version: "1.7.0", Source: "global"},
+		"go":        {Version: "1.22.1", Source: "engines"},
+		"helm":      {},
+	}
+
+	assert.Equal(t, "k8s:1.29.0 tf:1.7.0 helm: x:", renderPrompt("k8s:{kubectl} tf:{terraform} helm:{helm} x:{unknown}", versions))
+	assert.Equal(t, "go@1.22.1 kubectl@1.29.0", renderPrompt("", versions))
+}
+
+func TestPromptTools(t *testing.T) {
+	paths := types.ConfigPathsFromRoot(t.TempDir())
+	require.NoError(t, os.MkdirAll(filepath.Join(paths.VersionsDir, "kubectl"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(paths.VersionsDir, "helm"), 0755))
+
+	assert.Equal(t, []string{"kubectl", "terraform"}, promptTools(paths, "{terraform} {kubectl} {terraform}"))
+	assert.Equal(t, []string{"helm", "kubectl"}, promptTools(paths, ""))
+}
+
+func TestPromptStamp(t *testing.T) {
+	paths := types.ConfigPathsFromRoot(t.TempDir())
+	project := t.TempDir()
+	sub := filepath.Join(project, "sub")
+	require.NoError(t, os.MkdirAll(sub, 0755))
+
+	stamp := promptStamp(paths, sub, []string{"kubectl"})
+	assert.Equal(t, stamp, promptStamp(paths, sub, []string{"kubectl"}))
+
+	// 上级目录中新增项目配置
+	require.NoError(t, os.WriteFile(filepath.Join(project, ".vman.yaml"), []byte("tools:\n  kubectl: 1.29.0\n"), 0644))
+	changed := promptStamp(paths, sub, []string{"kubectl"})
+	assert.NotEqual(t, stamp, changed)
+
+	// 安装新版本
+	require.NoError(t, os.MkdirAll(filepath.Join(paths.VersionsDir, "kubectl", "1.30.0"), 0755))
+	installed := promptStamp(paths, sub, []string{"kubectl"})
+	assert.NotEqual(t, changed, installed)
+
+	// 版本环境变量
+	t.Setenv("VMAN_KUBECTL_VERSION", "1.30.0")
+	assert.NotEqual(t, installed, promptStamp(paths, sub, []string{"kubectl"}))
+}
+
+func TestPromptCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache", promptCacheFile)
+
+	cache := loadPromptCache(path)
+	assert.Empty(t, cache.Entries)
+
+	now := time.Now()
+	for i := 0; i < promptCacheMaxEntries+1; i++ {
+		cache.put(fmt.Sprintf("/project/%d", i), &promptCacheEntry{
+			Stamp:    "stamp",
+			Tools:    map[string]*promptVersion{"kubectl": {Version: "1.29.0", Source: "project"}},
+			CachedAt: now.Add(time.Duration(i) * time.Second),
+		})
+	}
+	assert.Len(t, cache.Entries, promptCacheMaxEntries)
+	assert.NotContains(t, cache.Entries, "/project/0")
+	require.NoError(t, cache.save(path))
+
+	loaded := loadPromptCache(path)
+	require.Contains(t, loaded.Entries, "/project/1")
+	assert.Equal(t, "1.29.0", loaded.Entries["/project/1"].Tools["kubectl"].Version)
+
+	// 损坏的缓存文件视为空缓存
+	require.NoError(t, os.WriteFile(path, []byte("{"), 0644))
+	assert.Empty(t, loadPromptCache(path).Entries)
+}
+
+func TestStarshipModules(t *testing.T) {
+	modules := starshipModules([]string{"kubectl", "aws-cli"})
+	assert.Contains(t, modules, "[custom.vman_kubectl]")
+	assert.Contains(t, modules, `command = "vman prompt --format '{kubectl}'"`)
+	assert.Contains(t, modules, "[custom.vman_aws_cli]")
+	assert.Contains(t, modules, `command = "vman prompt --format '{aws-cli}'"`)
+}
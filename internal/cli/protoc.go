@@ -10,6 +10,8 @@ import (
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/spf13/afero"
+
+	"github.com/songzhibin97/vman/pkg/types"
 )
 
 // ProtocManager protoc专用管理器
@@ -28,7 +30,7 @@ func NewProtocManager() *ProtocManager {
 	return &ProtocManager{
 		fs:             afero.NewOsFs(),
 		logger:         logrus.New(),
-		shimsDir:       filepath.Join(homeDir, ".vman", "shims"),
+		shimsDir:       types.DefaultConfigPaths(homeDir).ShimsDir,
 		backupSuffix:   ".protoc-backup",
 		protocBackedUp: false,
 		originalPATH:   os.Getenv("PATH"),
@@ -54,8 +56,9 @@ func newProtocCmd() *cobra.Command {
 // newProtocSetupCmd 一键设置
 func newProtocSetupCmd() *cobra.Command {
 	return &cobra.Command{
-		Use:   "setup",
-		Short: "一键设置protoc环境",
+		Use:         "setup",
+		Annotations: mutating(),
+		Short:       "一键设置protoc环境",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			manager := NewProtocManager()
 			return manager.Setup()
@@ -65,10 +68,11 @@ func newProtocSetupCmd() *cobra.Command {
 
 // newProtocExecCmd 执行命令
 func newProtocExecCmd() *cobra.Command {
-	return &cobra.Command{
-		Use:   "exec [command...]",
-		Short: "在protoc模式下执行命令",
-		Example: "vman protoc exec make api",
+	cmd := &cobra.Command{
+		Use:         "exec [command...]",
+		Annotations: mutating(),
+		Short:       "在protoc模式下执行命令",
+		Example:     "vman protoc exec make api",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if len(args) == 0 {
 				return fmt.Errorf("请指定要执行的命令")
@@ -77,14 +81,17 @@ func newProtocExecCmd() *cobra.Command {
 			return manager.ExecCommand(args)
 		},
 	}
+	passThroughArgs(cmd)
+	return cmd
 }
 
 // newProtocMakeAPICmd 一键make api命令
 func newProtocMakeAPICmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "make-api",
-		Short: "一键执行make api命令",
-		Long:  `一键执行make api命令，自动处理所有protoc环境设置`,
+		Use:         "make-api",
+		Annotations: mutating(),
+		Short:       "一键执行make api命令",
+		Long:        `一键执行make api命令，自动处理所有protoc环境设置`,
 		Example: `  # 在当前目录执行make api
   vman protoc make-api
   
@@ -9,6 +9,8 @@ import (
 	"github.com/spf13/afero"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/songzhibin97/vman/pkg/types"
 )
 
 // TestProtocManager 测试ProtocManager的创建和基本属性
@@ -18,7 +20,8 @@ func TestProtocManager(t *testing.T) {
 	assert.NotNil(t, manager)
 	assert.NotNil(t, manager.fs)
 	assert.NotNil(t, manager.logger)
-	assert.Contains(t, manager.shimsDir, ".vman/shims")
+	homeDir, _ := os.UserHomeDir()
+	assert.Equal(t, types.DefaultConfigPaths(homeDir).ShimsDir, manager.shimsDir)
 	assert.Equal(t, ".protoc-backup", manager.backupSuffix)
 	assert.False(t, manager.protocBackedUp)
 	assert.NotEmpty(t, manager.originalPATH)
@@ -1,18 +1,21 @@
 package cli
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"os/exec"
 	"strings"
 	"time"
 
+	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 
 	"github.com/songzhibin97/vman/internal/config"
 	"github.com/songzhibin97/vman/internal/proxy"
-	"github.com/songzhibin97/vman/internal/storage"
-	"github.com/songzhibin97/vman/internal/version"
+	"github.com/songzhibin97/vman/pkg/types"
+	"github.com/songzhibin97/vman/pkg/utils"
 )
 
 var (
@@ -25,25 +28,49 @@ func initProxy() error {
 		return nil
 	}
 
-	// 创建配置管理器
-	configManager, err := config.NewManager("")
+	// 使用与其他命令相同的配置目录，避免按当前目录解析相对路径
+	managers, err := createManagers()
 	if err != nil {
-		return fmt.Errorf("failed to create config manager: %w", err)
-	}
-	if err := configManager.Initialize(); err != nil {
-		return fmt.Errorf("failed to initialize config manager: %w", err)
+		return fmt.Errorf("failed to create managers: %w", err)
 	}
 
-	// 创建版本管理器
-	storageManager := storage.NewManager()
-	versionManager := version.NewManager(storageManager, configManager)
+	// 使用集成管理器，使 auto-install 回退策略可以下载安装工具
+	versionManager, err := createIntegratedManager()
+	if err != nil {
+		return fmt.Errorf("failed to create version manager: %w", err)
+	}
 
 	// 创建代理
-	commandProxy = proxy.NewCommandProxy(configManager, versionManager)
+	commandProxy = proxy.NewCommandProxy(managers.config, versionManager)
+	commandProxy.SetReservedNames(reservedCommandNames())
 
 	return nil
 }
 
+// reservedCommandNames 返回不能用作垫片名称的命令
+// 垫片是执行 `vman exec <tool>` 的脚本，不按 argv[0] 分发，与vman子命令同名并不冲突；
+// 只有名为 vman 的垫片会遮蔽vman本身
+func reservedCommandNames() []string {
+	return []string{rootCmd.Name()}
+}
+
+// checkShimName 检查工具或别名的垫片名称是否与现有垫片或vman本身冲突
+func checkShimName(entry proxy.ShimEntry) error {
+	if err := initProxy(); err != nil {
+		return err
+	}
+	return commandProxy.CheckShimName(entry)
+}
+
+// checkToolShimName 检查工具的垫片名称，工具定义中可通过 shim 字段改名
+func checkToolShimName(tool string) error {
+	managers, err := createManagers()
+	if err != nil {
+		return fmt.Errorf("failed to create managers: %w", err)
+	}
+	return checkShimName(proxy.ShimEntry{Name: proxy.ShimNameFor(managers.config, tool), Kind: proxy.ShimKindTool, Target: tool})
+}
+
 // proxyCmd 代理相关命令的根命令
 var proxyCmd = &cobra.Command{
 	Use:   "proxy",
@@ -55,8 +82,9 @@ var proxyCmd = &cobra.Command{
 
 // setupCmd 设置代理环境命令
 var setupCmd = &cobra.Command{
-	Use:   "setup",
-	Short: "设置代理环境",
+	Use:         "setup",
+	Annotations: mutating(),
+	Short:       "设置代理环境",
 	Long: `设置vman代理环境，包括：
 - 将shims目录添加到PATH
 - 安装shell钩子
@@ -81,8 +109,9 @@ var setupCmd = &cobra.Command{
 
 // cleanupCmd 清理代理环境命令
 var cleanupCmd = &cobra.Command{
-	Use:   "cleanup",
-	Short: "清理代理环境",
+	Use:         "cleanup",
+	Annotations: mutating(),
+	Short:       "清理代理环境",
 	Long: `清理vman代理环境，包括：
 - 从PATH中移除shims目录
 - 卸载shell钩子
@@ -133,19 +162,42 @@ var statusCmd = &cobra.Command{
 
 // rehashCmd 重新生成垫片命令
 var rehashCmd = &cobra.Command{
-	Use:   "rehash",
-	Short: "重新生成所有垫片",
+	Use:         "rehash",
+	Annotations: mutating(),
+	Short:       "重新生成所有垫片",
 	Long: `重新生成所有已安装工具的垫片文件。
 
 这个命令在以下情况下很有用：
 - 安装了新工具
 - 更改了工具版本
-- 垫片文件损坏`,
+- 垫片文件损坏
+- 升级 vman 后，旧版本生成的垫片需要更新
+
+垫片中记录了生成它的 vman 版本、格式版本和内容哈希，重新生成前会列出过时和被手动修改的垫片。`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		if err := initProxy(); err != nil {
 			return err
 		}
 
+		// 报告将被替换的过时和被修改的垫片
+		if report, err := commandProxy.CheckShims(); err == nil {
+			var outdated, modified []string
+			for _, problem := range report.Problems {
+				switch problem.Issue {
+				case proxy.ShimOutdated:
+					outdated = append(outdated, problem.Entry.Name)
+				case proxy.ShimModified:
+					modified = append(modified, problem.Entry.Name)
+				}
+			}
+			if len(outdated) > 0 {
+				fmt.Printf("%d 个垫片由旧版本的 vman 生成，将重新生成: %s\n", len(outdated), strings.Join(outdated, ", "))
+			}
+			if len(modified) > 0 {
+				fmt.Printf("以下垫片被手动修改过，修改将被覆盖: %s\n", strings.Join(modified, ", "))
+			}
+		}
+
 		fmt.Println("正在重新生成垫片...")
 		if err := commandProxy.RehashShims(); err != nil {
 			return fmt.Errorf("重新生成垫片失败: %w", err)
@@ -165,7 +217,10 @@ var execCmd = &cobra.Command{
 这个命令会：
 1. 解析当前上下文中工具的版本
 2. 查找对应的可执行文件
-3. 透明地转发所有参数`,
+3. 透明地转发所有参数
+
+工具名之后的参数原样传给工具，包括 --help、-v、负数和 --；vman 自身的标志需要放在
+工具名之前，如 vman exec -v kubectl --help。工具名以 - 开头时在前面加 --。`,
 	Args: cobra.MinimumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		if err := initProxy(); err != nil {
@@ -177,6 +232,13 @@ var execCmd = &cobra.Command{
 
 		// 执行命令
 		if err := commandProxy.InterceptCommand(toolName, toolArgs); err != nil {
+			cmd.SilenceUsage = true
+			if strings.Contains(err.Error(), "no version configured") {
+				fmt.Fprintf(os.Stderr, "工具 '%s' 没有配置版本\n", toolName)
+				fmt.Fprintf(os.Stderr, "运行 vman use %s <version> 选择版本，或在全局配置中设置 settings.resolution.fallback\n", toolName)
+				os.Exit(127)
+			}
+
 			// 检查是否是找不到工具的错误
 			if strings.Contains(err.Error(), "not found") || strings.Contains(err.Error(), "not installed") {
 				fmt.Fprintf(os.Stderr, "工具 '%s' 未找到或未安装\n", toolName)
@@ -184,6 +246,19 @@ var execCmd = &cobra.Command{
 				fmt.Fprintf(os.Stderr, "  vman install %s <version>\n", toolName)
 				os.Exit(127)
 			}
+
+			// 命令本身以非零状态退出或被信号终止时，以相同的状态退出，不输出错误，
+			// 如通过管道传给 head 时工具被 SIGPIPE 终止；超出资源限制等附加说明仍然输出
+			var exitErr *exec.ExitError
+			if errors.As(err, &exitErr) {
+				if err.Error() != exitErr.Error() {
+					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				}
+				proxy.ExitWithStatus(exitErr)
+			}
+
+			// 其他失败提示生成诊断包
+			fmt.Fprintf(os.Stderr, "运行 vman bugreport 生成诊断包，提交问题时附上\n")
 			return err
 		}
 
@@ -191,6 +266,46 @@ var execCmd = &cobra.Command{
 	},
 }
 
+// passThroughArgs 第一个参数之后不再解析标志，之后的参数原样传给要执行的命令，
+// 包括 --help、-v、负数和 --；命令之前的 -- 仍然表示 vman 标志的结束
+func passThroughArgs(cmd *cobra.Command) {
+	cmd.Flags().SetInterspersed(false)
+}
+
+// runFastPath 尝试以快速路径执行工具，工具启动后不返回；不满足条件或启动失败时返回，由 exec 命令完整处理
+func runFastPath(toolName string, args []string) {
+	if !proxy.FastPathEnabled() {
+		return
+	}
+	homeDir, err := utils.GetHomeDir()
+	if err != nil {
+		return
+	}
+	workDir, err := os.Getwd()
+	if err != nil {
+		return
+	}
+
+	// 还没有初始化时由完整流程创建目录和全局配置
+	configPaths := types.DefaultConfigPaths(homeDir)
+	if _, err := os.Stat(configPaths.GlobalConfigFile); err != nil {
+		return
+	}
+	configManager, err := config.NewManager(homeDir)
+	if err != nil {
+		return
+	}
+	storageManager, err := createStorageManager(configManager, configPaths, false)
+	if err != nil {
+		return
+	}
+
+	fs := afero.NewOsFs()
+	if fastPath, ok := proxy.ResolveFastPath(fs, configManager, storageManager, toolName, workDir); ok {
+		_ = fastPath.Exec(fs, args)
+	}
+}
+
 // shimCmd 垫片管理命令
 var shimCmd = &cobra.Command{
 	Use:   "shim",
@@ -200,10 +315,11 @@ var shimCmd = &cobra.Command{
 
 // generateShimCmd 生成垫片命令
 var generateShimCmd = &cobra.Command{
-	Use:   "generate <tool> <version>",
-	Short: "生成工具垫片",
-	Long:  `为指定的工具和版本生成垫片文件。`,
-	Args:  cobra.ExactArgs(2),
+	Use:         "generate <tool> <version>",
+	Annotations: mutating(),
+	Short:       "生成工具垫片",
+	Long:        `为指定的工具和版本生成垫片文件。`,
+	Args:        cobra.ExactArgs(2),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		if err := initProxy(); err != nil {
 			return err
@@ -224,10 +340,11 @@ var generateShimCmd = &cobra.Command{
 
 // removeShimCmd 移除垫片命令
 var removeShimCmd = &cobra.Command{
-	Use:   "remove <tool>",
-	Short: "移除工具垫片",
-	Long:  `移除指定工具的垫片文件。`,
-	Args:  cobra.ExactArgs(1),
+	Use:         "remove <tool>",
+	Annotations: mutating(),
+	Short:       "移除工具垫片",
+	Long:        `移除指定工具的垫片文件。`,
+	Args:        cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		if err := initProxy(); err != nil {
 			return err
@@ -251,19 +368,31 @@ var proxyInitCmd = &cobra.Command{
 	Short: "生成shell初始化脚本",
 	Long: `生成shell初始化脚本，用于激活vman代理功能。
 
+脚本会把shims目录加入PATH，并设置命令未找到时的钩子、切换目录时刷新
+vman_prompt_info 的钩子和命令补全。
+
 支持的shell类型：
 - bash
-- zsh  
+- zsh
 - fish
+- nu (Nushell)
+- xonsh
 - cmd (Windows)
-- powershell (Windows)
+- powershell (Windows PowerShell)
+- pwsh (PowerShell Core)
 
-如果不指定shell类型，会自动检测当前shell。
+如果不指定shell类型，会根据父进程、NU_VERSION、XONSH_VERSION、SHELL
+和 PSModulePath 自动检测当前shell。
 
 使用方法：
-  eval "$(vman shell-init)"           # 自动检测shell
-  eval "$(vman shell-init zsh)"       # 指定zsh
-  source <(vman shell-init bash)      # bash语法`,
+  eval "$(vman shell-init)"                             # 自动检测shell
+  eval "$(vman shell-init zsh)"                         # 指定zsh
+  source <(vman shell-init bash)                        # bash语法
+  vman shell-init fish | source                         # fish
+  execx($(vman shell-init xonsh))                       # xonsh
+  vman shell-init pwsh | Out-String | Invoke-Expression  # PowerShell
+  vman shell-init nu | save -f ($nu.default-config-dir | path join vman.nu)
+                                                        # Nushell，然后在 config.nu 中 source vman.nu`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		var shellType string
@@ -336,6 +465,7 @@ func init() {
 	rootCmd.AddCommand(proxyInitCmd)
 
 	// 设置标志
+	passThroughArgs(execCmd)
 	setupCmd.Flags().Bool("force", false, "强制重新设置")
 	cleanupCmd.Flags().Bool("all", false, "清理所有相关文件")
 	statusCmd.Flags().BoolP("verbose", "v", false, "显示详细信息")
@@ -0,0 +1,21 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckToolShimName_Subcommands(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	previous := commandProxy
+	commandProxy = nil
+	t.Cleanup(func() { commandProxy = previous })
+
+	// 垫片通过 vman exec 运行工具，与子命令同名不冲突，vman install protoc 可以通过检查
+	assert.NoError(t, checkToolShimName("protoc"))
+	assert.NoError(t, checkToolShimName("list"))
+
+	// 名为 vman 的垫片会遮蔽 vman 本身
+	assert.ErrorContains(t, checkToolShimName("vman"), "reserved name vman")
+}
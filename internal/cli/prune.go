@@ -0,0 +1,231 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// pruneCmd 清理长期未使用的版本
+var pruneCmd = &cobra.Command{
+	Use:         "prune",
+	Annotations: mutating(),
+	Short:       "清理长期未使用的工具版本",
+	Long: `删除超过指定时间未被使用的工具版本。
+
+版本的最后使用时间由代理执行命令时记录（每天最多更新一次）。
+从未被使用过的版本按安装时间计算。全局版本、当前目录正在使用的版本和
+通过 vman projects add 注册的项目中 .vman.yaml、.vman-version、.tool-versions 引用的版本不会被删除。
+
+时间支持 d（天）、w（周）以及 Go 的时间格式（如 720h）。
+
+示例:
+  vman prune --unused-for 180d             # 删除180天未使用的版本
+  vman prune --unused-for 12w --dry-run    # 只显示将要删除的版本
+  vman prune --unused-for 90d --tool kubectl`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		unusedFor, _ := cmd.Flags().GetString("unused-for")
+		tool, _ := cmd.Flags().GetString("tool")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		force, _ := cmd.Flags().GetBool("force")
+		options := getUIOptions(cmd)
+
+		if unusedFor == "" {
+			return fmt.Errorf("请使用 --unused-for 指定未使用时长，例如 --unused-for 180d")
+		}
+
+		threshold, err := parseAge(unusedFor)
+		if err != nil {
+			return err
+		}
+
+		managers, err := createManagers()
+		if err != nil {
+			return fmt.Errorf("创建管理器失败: %w", err)
+		}
+
+		retained := retainedByProjects(managers, options)
+		candidates, kept, err := findPruneCandidates(managers, tool, threshold, time.Now(), retained)
+		if err != nil {
+			return err
+		}
+
+		if len(kept) > 0 {
+			fmt.Println("以下版本被注册的项目引用，保留:")
+			for _, c := range kept {
+				fmt.Printf("  - %s@%s (%s)\n", c.tool, c.version, c.project)
+			}
+		}
+
+		if len(candidates) == 0 {
+			fmt.Printf("没有超过 %s 未使用的版本\n", unusedFor)
+			return nil
+		}
+
+		fmt.Printf("以下版本超过 %s 未使用:\n", unusedFor)
+		var totalSize int64
+		for _, c := range candidates {
+			totalSize += c.size
+			fmt.Printf("  - %s@%s (%s, %s)\n", c.tool, c.version, describeLastUsed(c), formatBytes(c.size))
+		}
+		fmt.Printf("共 %d 个版本，%s\n", len(candidates), formatBytes(totalSize))
+
+		if dryRun {
+			return nil
+		}
+
+		if !force {
+			confirmed, err := confirmAction(cmd, "确定要删除这些版本吗？")
+			if err != nil {
+				return err
+			}
+			if !confirmed {
+				fmt.Println("操作已取消")
+				return nil
+			}
+		}
+
+		removed := 0
+		for _, c := range candidates {
+			if err := managers.version.RemoveVersion(c.tool, c.version); err != nil {
+				fmt.Printf("❌ 删除 %s@%s 失败: %v\n", c.tool, c.version, err)
+				continue
+			}
+			Infof(options, "✅ 已删除 %s@%s\n", c.tool, c.version)
+			removed++
+		}
+
+		Infof(options, "\n清理完成: %d/%d 个版本成功删除\n", removed, len(candidates))
+
+		if removed > 0 {
+			if err := regenerateShims(); err != nil {
+				fmt.Printf("警告: 重新生成垫片失败: %v\n", err)
+			}
+		}
+
+		return nil
+	},
+}
+
+// pruneCandidate 待清理的版本
+type pruneCandidate struct {
+	tool        string
+	version     string
+	lastUsed    time.Time
+	installedAt time.Time
+	size        int64
+	project     string // 引用该版本的注册项目
+}
+
+// findPruneCandidates 查找超过指定时长未使用的版本，注册项目引用的版本不删除，作为 kept 返回
+func findPruneCandidates(managers *managers, tool string, threshold time.Duration, now time.Time, retained projectRetention) (candidates, kept []pruneCandidate, err error) {
+	tools := []string{tool}
+	if tool == "" {
+		allTools, err := managers.version.ListAllTools()
+		if err != nil {
+			return nil, nil, fmt.Errorf("获取工具列表失败: %w", err)
+		}
+		tools = allTools
+	}
+
+	globalVersions := map[string]string{}
+	if globalConfig, err := managers.config.LoadGlobal(); err == nil && globalConfig.GlobalVersions != nil {
+		globalVersions = globalConfig.GlobalVersions
+	}
+
+	for _, t := range tools {
+		versions, err := managers.version.ListVersions(t)
+		if err != nil {
+			return nil, nil, fmt.Errorf("获取 %s 的版本列表失败: %w", t, err)
+		}
+
+		currentVersion, _ := managers.version.GetCurrentVersion(t)
+
+		for _, v := range versions {
+			// 保留全局版本和当前正在使用的版本
+			if v == globalVersions[t] || v == currentVersion {
+				continue
+			}
+
+			c := pruneCandidate{tool: t, version: v}
+			c.lastUsed, _ = managers.storage.GetLastUsed(t, v)
+
+			versionPath := managers.storage.GetToolVersionPath(t, v)
+			if metadata, err := managers.version.GetVersionMetadata(t, v); err == nil {
+				c.installedAt = metadata.InstalledAt
+			} else if stat, err := os.Stat(versionPath); err == nil {
+				c.installedAt = stat.ModTime()
+			}
+
+			reference := c.lastUsed
+			if reference.IsZero() {
+				reference = c.installedAt
+			}
+			if reference.IsZero() || now.Sub(reference) < threshold {
+				continue
+			}
+
+			if project, ok := retained[t][v]; ok {
+				c.project = project
+				kept = append(kept, c)
+				continue
+			}
+
+			c.size, _ = calculateDirSize(versionPath)
+			candidates = append(candidates, c)
+		}
+	}
+
+	return candidates, kept, nil
+}
+
+// describeLastUsed 描述版本的最后使用情况
+func describeLastUsed(c pruneCandidate) string {
+	if c.lastUsed.IsZero() {
+		return fmt.Sprintf("从未使用，安装于 %s", c.installedAt.Format("2006-01-02"))
+	}
+	return fmt.Sprintf("最后使用于 %s", c.lastUsed.Format("2006-01-02"))
+}
+
+// parseAge 解析时长，支持 d（天）和 w（周）后缀
+func parseAge(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, fmt.Errorf("时长不能为空")
+	}
+
+	units := map[string]time.Duration{
+		"d": 24 * time.Hour,
+		"w": 7 * 24 * time.Hour,
+	}
+
+	for suffix, unit := range units {
+		if strings.HasSuffix(value, suffix) {
+			n, err := strconv.Atoi(strings.TrimSuffix(value, suffix))
+			if err != nil || n < 0 {
+				return 0, fmt.Errorf("无效的时长: %s", value)
+			}
+			return time.Duration(n) * unit, nil
+		}
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("无效的时长: %s", value)
+	}
+	return d, nil
+}
+
+func init() {
+	rootCmd.AddCommand(pruneCmd)
+
+	pruneCmd.Flags().String("unused-for", "", "删除超过该时长未使用的版本，如 180d、12w")
+	pruneCmd.Flags().String("tool", "", "只清理指定工具")
+	pruneCmd.Flags().Bool("dry-run", false, "只显示将要删除的版本")
+	pruneCmd.Flags().BoolP("force", "f", false, "跳过确认提示（同 --yes）")
+}
@@ -0,0 +1,71 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/songzhibin97/vman/internal/config"
+	"github.com/songzhibin97/vman/pkg/types"
+	"github.com/songzhibin97/vman/pkg/utils"
+)
+
+// annotationMutating 命令注解，标记修改配置、已安装版本、垫片或用户文件的命令
+// 只读模式下拒绝执行，以 root 运行时检查配置目录的所有者，启用自动备份时执行前备份配置
+const annotationMutating = "vman.mutating"
+
+// annotationMutating 的值
+const (
+	mutatingAlways  = "always"   // 执行前检查
+	mutatingOnWrite = "on-write" // 按参数决定是否写入文件，命令在写入前调用 checkWrite
+)
+
+// mutating 修改操作命令的注解，在命令定义中设置
+func mutating() map[string]string {
+	return map[string]string{annotationMutating: mutatingAlways}
+}
+
+// mutatingWhenWriting 只在写入文件时修改的命令（如输出到标准输出时不写入的 vman export）的注解
+func mutatingWhenWriting() map[string]string {
+	return map[string]string{annotationMutating: mutatingOnWrite}
+}
+
+// isMutatingCommand 命令是否修改配置、已安装版本或垫片，分组子命令按对应的原有命令判断
+func isMutatingCommand(cmd *cobra.Command) bool {
+	return resolveCommand(cmd).Annotations[annotationMutating] == mutatingAlways
+}
+
+// checkWrite 注解为 mutatingOnWrite 的命令确定要写入文件后调用，执行与其他修改操作相同的只读模式和所有者检查
+func checkWrite(cmd *cobra.Command) error {
+	settings := loadGlobalSettings()
+	if settings.IsReadOnly() {
+		return readOnlyError(cmd)
+	}
+	return checkConfigOwner(cmd, settings)
+}
+
+// loadGlobalSettings 读取全局设置，无法读取时返回默认值，环境变量仍然生效
+func loadGlobalSettings() types.Settings {
+	if homeDir, err := utils.GetHomeDir(); err == nil {
+		if configManager, err := config.NewManager(homeDir); err == nil {
+			if globalConfig, err := configManager.LoadGlobal(); err == nil {
+				return globalConfig.Settings
+			}
+		}
+	}
+	return types.Settings{}
+}
+
+// checkReadOnly 只读模式下拒绝执行修改操作的命令
+func checkReadOnly(cmd *cobra.Command, settings types.Settings) error {
+	if !isMutatingCommand(cmd) || !settings.IsReadOnly() {
+		return nil
+	}
+	return readOnlyError(cmd)
+}
+
+// readOnlyError 只读模式下拒绝执行命令的错误
+func readOnlyError(cmd *cobra.Command) error {
+	cmd.SilenceUsage = true
+	return fmt.Errorf("vman 处于只读模式（settings.readonly 或环境变量 %s），不允许执行 %s\n版本解析和 vman exec 不受影响", types.EnvVmanReadOnly, cmd.CommandPath())
+}
@@ -0,0 +1,141 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/songzhibin97/vman/internal/config"
+	"github.com/songzhibin97/vman/internal/recipe"
+	"github.com/songzhibin97/vman/pkg/types"
+)
+
+// recipesCmd 列出可用的工具recipe
+var recipesCmd = &cobra.Command{
+	Use:   "recipes [tool]",
+	Short: "列出可用的工具recipe",
+	Long: `列出可用的工具recipe，或显示指定工具的recipe。
+
+recipe 是常用工具的预置定义，vman add <tool> 会优先使用 recipe 生成工具定义。
+查找顺序为配置目录下的 recipes 目录、设置 recipes.dirs 中的目录（如克隆的社区recipe仓库），
+最后是 vman 内置的 recipe。每个 recipe 是以工具名命名的目录，包含 recipe.toml 和可选的 hooks 目录。`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+
+		managers, err := createManagers()
+		if err != nil {
+			return fmt.Errorf("创建管理器失败: %w", err)
+		}
+		registry := recipeRegistry(managers.config)
+
+		if len(args) == 1 {
+			r, err := registry.Lookup(args[0])
+			if err != nil {
+				if errors.Is(err, recipe.ErrNotFound) {
+					return fmt.Errorf("没有 %s 的recipe，使用 'vman add %s --type ...' 手动创建工具定义", args[0], args[0])
+				}
+				return err
+			}
+			printRecipe(r)
+			return nil
+		}
+
+		recipes, err := registry.List()
+		if err != nil {
+			return err
+		}
+		for _, r := range recipes {
+			source := ""
+			if r.Source != recipe.BundledSource {
+				source = "  (" + r.Source + ")"
+			}
+			fmt.Printf("  %-12s %s%s\n", r.Name, r.Metadata.Description, source)
+		}
+		return nil
+	},
+}
+
+// recipeRegistry 按设置创建recipe注册表
+func recipeRegistry(configManager config.Manager) *recipe.Registry {
+	var settings types.RecipeSettings
+	if globalConfig, err := configManager.LoadGlobal(); err == nil && globalConfig != nil {
+		settings = globalConfig.Settings.Recipes
+	}
+	return recipe.NewRegistryForSettings(configManager.GetConfigDir(), settings)
+}
+
+// metadataFromRecipe 根据recipe生成工具元数据，通过参数给出的项覆盖recipe中的值
+func metadataFromRecipe(r *recipe.Recipe, opts *addToolOptions) *types.ToolMetadata {
+	metadata := *r.Metadata
+	if opts.shim != "" {
+		metadata.Shim = opts.shim
+	}
+	if opts.binary != "" {
+		metadata.DownloadConfig.ExtractBinary = opts.binary
+	}
+	if opts.description != "" {
+		metadata.Description = opts.description
+	}
+	if opts.homepage != "" {
+		metadata.Homepage = opts.homepage
+	}
+	if opts.pattern != "" {
+		metadata.DownloadConfig.AssetPattern = opts.pattern
+	}
+	return &metadata
+}
+
+// recipeSupportsPlatform recipe是否支持当前平台，未声明平台时视为支持
+func recipeSupportsPlatform(metadata *types.ToolMetadata) bool {
+	if len(metadata.Platforms) == 0 {
+		return true
+	}
+	current := runtime.GOOS + "_" + runtime.GOARCH
+	for _, platform := range metadata.Platforms {
+		if platform == current {
+			return true
+		}
+	}
+	return false
+}
+
+// printRecipe 显示recipe的内容
+func printRecipe(r *recipe.Recipe) {
+	metadata := r.Metadata
+	fmt.Printf("%s: %s\n", r.Name, metadata.Description)
+	fmt.Printf("  来源:       %s\n", r.Source)
+	fmt.Printf("  主页:       %s\n", metadata.Homepage)
+	fmt.Printf("  下载类型:   %s\n", metadata.DownloadConfig.Type)
+	if metadata.DownloadConfig.Repository != "" {
+		fmt.Printf("  仓库:       %s\n", metadata.DownloadConfig.Repository)
+	}
+	if metadata.DownloadConfig.URLTemplate != "" {
+		fmt.Printf("  下载地址:   %s\n", metadata.DownloadConfig.URLTemplate)
+	}
+	if metadata.DownloadConfig.AssetPattern != "" {
+		fmt.Printf("  资产模式:   %s\n", metadata.DownloadConfig.AssetPattern)
+	}
+	if len(metadata.Platforms) > 0 {
+		fmt.Printf("  支持平台:   %s\n", strings.Join(metadata.Platforms, ", "))
+	}
+	if metadata.Shim != "" {
+		fmt.Printf("  垫片名称:   %s\n", metadata.Shim)
+	}
+	if len(r.Hooks) > 0 {
+		hooks := make([]string, 0, len(r.Hooks))
+		for name := range r.Hooks {
+			hooks = append(hooks, name)
+		}
+		sort.Strings(hooks)
+		fmt.Printf("  钩子:       %s\n", strings.Join(hooks, ", "))
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(recipesCmd)
+}
@@ -0,0 +1,250 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/songzhibin97/vman/internal/download"
+	"github.com/songzhibin97/vman/internal/recipe"
+	"github.com/songzhibin97/vman/pkg/types"
+)
+
+// defaultRegistryTimeout 未设置下载超时时更新注册表的超时时间
+const defaultRegistryTimeout = 5 * time.Minute
+
+// registryCmd 管理远程recipe注册表
+var registryCmd = &cobra.Command{
+	Use:   "registry",
+	Short: "管理远程recipe注册表",
+	Long: `管理远程recipe注册表。
+
+注册表在设置 recipes.registries 中配置，vman registry update 下载注册表的索引（index.json）
+和其中的 recipe。索引必须由可信的发布者签名（index.json.sig）：签名用
+注册表配置的 public_keys 验证，每个文件的 SHA-256 必须与索引一致。没有签名的注册表默认拒绝，
+确认来源可信时可以使用 --allow-unsigned；签名无效或索引比本地副本旧时总是拒绝。
+
+发布者用 vman registry keygen 生成密钥对，用 vman registry sign 为注册表目录生成签名的索引。`,
+}
+
+// registryUpdateCmd 更新远程recipe注册表
+var registryUpdateCmd = &cobra.Command{
+	Use:         "update [name...]",
+	Annotations: mutating(),
+	Short:       "下载并验证远程recipe注册表",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		allowUnsigned, _ := cmd.Flags().GetBool("allow-unsigned")
+
+		managers, err := createManagers()
+		if err != nil {
+			return fmt.Errorf("创建管理器失败: %w", err)
+		}
+		globalConfig, err := managers.config.LoadGlobal()
+		if err != nil {
+			return fmt.Errorf("加载全局配置失败: %w", err)
+		}
+		registries, err := selectRegistries(globalConfig.Settings.Recipes.Registries, args)
+		if err != nil {
+			return err
+		}
+
+		timeout := globalConfig.Settings.Download.Timeout
+		if timeout <= 0 {
+			timeout = defaultRegistryTimeout
+		}
+		client := download.NewHTTPClient(timeout)
+		options := getUIOptions(cmd)
+
+		failed := 0
+		for _, registry := range registries {
+			result, err := recipe.UpdateRegistry(context.Background(), client, registry, managers.config.GetConfigDir(), allowUnsigned)
+			switch {
+			case errors.Is(err, recipe.ErrUnsigned):
+				failed++
+				PrintError(fmt.Sprintf("注册表 %s 的索引没有签名，已拒绝；确认来源可信时使用 --allow-unsigned", registry.Name), options)
+				continue
+			case errors.Is(err, recipe.ErrBadSignature):
+				failed++
+				PrintError(fmt.Sprintf("注册表 %s 的签名验证失败，已拒绝: 索引被篡改或不是由可信的发布者签名", registry.Name), options)
+				continue
+			case errors.Is(err, recipe.ErrRollback):
+				failed++
+				PrintError(fmt.Sprintf("注册表 %s 的索引比本地副本旧，已拒绝: 可能是重放的旧索引或镜像尚未同步", registry.Name), options)
+				continue
+			case err != nil:
+				failed++
+				PrintError(fmt.Sprintf("更新注册表 %s 失败: %v", registry.Name, err), options)
+				continue
+			}
+
+			if !result.Signed {
+				PrintWarning(fmt.Sprintf("注册表 %s 没有签名，内容未经验证", registry.Name), options)
+			}
+			PrintSuccess(fmt.Sprintf("已更新注册表 %s: %d 个recipe，%d 个文件", registry.Name, result.Recipes, result.Files), options)
+		}
+
+		if failed > 0 {
+			return fmt.Errorf("%d 个注册表更新失败", failed)
+		}
+		return nil
+	},
+}
+
+// registryListCmd 列出配置的远程recipe注册表
+var registryListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "列出配置的远程recipe注册表",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+
+		managers, err := createManagers()
+		if err != nil {
+			return fmt.Errorf("创建管理器失败: %w", err)
+		}
+		globalConfig, err := managers.config.LoadGlobal()
+		if err != nil {
+			return fmt.Errorf("加载全局配置失败: %w", err)
+		}
+		registries := globalConfig.Settings.Recipes.Registries
+		if len(registries) == 0 {
+			fmt.Println("没有配置远程recipe注册表，在设置 recipes.registries 中添加")
+			return nil
+		}
+
+		table := NewTablePrinter([]string{"NAME", "URL", "RECIPES", "SIGNED", "UPDATED"}, getUIOptions(cmd))
+		for _, registry := range registries {
+			recipes, signed, updated := "-", "-", "未下载"
+			if index, ok, err := recipe.LocalIndex(managers.config.GetConfigDir(), registry.Name); err == nil {
+				recipes = strconv.Itoa(len(index.Recipes()))
+				signed = "no"
+				if ok {
+					signed = "yes"
+				}
+				updated = index.UpdatedAt.Local().Format("2006-01-02 15:04:05")
+			}
+			table.AddRow([]string{registry.Name, registry.URL, recipes, signed, updated})
+		}
+		table.Print()
+		return nil
+	},
+}
+
+// registryKeygenCmd 生成注册表发布者的密钥对
+var registryKeygenCmd = &cobra.Command{
+	Use:         "keygen <private-key-file>",
+	Annotations: mutating(),
+	Short:       "生成注册表发布者的密钥对",
+	Long: `生成 Ed25519 密钥对，私钥写入指定文件（权限 0600），公钥输出到标准输出。
+
+将公钥添加到注册表配置的 public_keys 中，用户即可验证该发布者签名的注册表。`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		path := args[0]
+		if _, err := os.Stat(path); err == nil {
+			return fmt.Errorf("%s 已存在，不会覆盖已有的私钥", path)
+		}
+
+		public, private, err := recipe.GenerateKey()
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, []byte(private+"\n"), 0600); err != nil {
+			return fmt.Errorf("写入私钥失败: %w", err)
+		}
+		PrintSuccess(fmt.Sprintf("私钥已写入 %s，请妥善保管", path), getUIOptions(cmd))
+		fmt.Printf("公钥: %s\n", public)
+		return nil
+	},
+}
+
+// registrySignCmd 为注册表目录生成签名的索引
+var registrySignCmd = &cobra.Command{
+	Use:         "sign <dir>",
+	Annotations: mutating(),
+	Short:       "为注册表目录生成签名的索引",
+	Long: `为注册表目录生成索引 index.json（每个文件的 SHA-256），并用私钥签名写入 index.json.sig。
+
+注册表目录的结构与 recipes 目录相同：每个 recipe 是以工具名命名的目录，包含 recipe.toml
+和可选的 hooks 目录。修改任何文件后需要重新签名。`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		dir := args[0]
+		keyFile, _ := cmd.Flags().GetString("key")
+		if keyFile == "" {
+			return fmt.Errorf("需要使用 --key 指定私钥文件")
+		}
+
+		privateKey, err := os.ReadFile(keyFile)
+		if err != nil {
+			return fmt.Errorf("读取私钥失败: %w", err)
+		}
+		index, err := recipe.BuildIndex(dir)
+		if err != nil {
+			return err
+		}
+		signature, err := recipe.SignIndex(string(privateKey), index)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(dir, recipe.IndexFile), index, 0644); err != nil {
+			return fmt.Errorf("写入索引失败: %w", err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, recipe.SignatureFile), []byte(signature+"\n"), 0644); err != nil {
+			return fmt.Errorf("写入签名失败: %w", err)
+		}
+		PrintSuccess(fmt.Sprintf("已签名 %s", filepath.Join(dir, recipe.IndexFile)), getUIOptions(cmd))
+		return nil
+	},
+}
+
+// selectRegistries 按名称选择要更新的注册表，没有指定名称时选择全部
+func selectRegistries(registries []types.RecipeRegistry, names []string) ([]types.RecipeRegistry, error) {
+	if len(registries) == 0 {
+		return nil, fmt.Errorf("没有配置远程recipe注册表，在设置 recipes.registries 中添加")
+	}
+	if len(names) == 0 {
+		return registries, nil
+	}
+
+	var selected []types.RecipeRegistry
+	for _, name := range names {
+		found := false
+		for _, registry := range registries {
+			if registry.Name == name {
+				selected = append(selected, registry)
+				found = true
+				break
+			}
+		}
+		if !found {
+			var known []string
+			for _, registry := range registries {
+				known = append(known, registry.Name)
+			}
+			return nil, fmt.Errorf("没有名为 %s 的注册表，已配置: %s", name, strings.Join(known, ", "))
+		}
+	}
+	return selected, nil
+}
+
+func init() {
+	rootCmd.AddCommand(registryCmd)
+	registryCmd.AddCommand(registryUpdateCmd)
+	registryCmd.AddCommand(registryListCmd)
+	registryCmd.AddCommand(registryKeygenCmd)
+	registryCmd.AddCommand(registrySignCmd)
+
+	registryUpdateCmd.Flags().Bool("allow-unsigned", false, "接受没有签名的注册表索引（签名无效时仍然拒绝）")
+	registrySignCmd.Flags().String("key", "", "私钥文件")
+}
@@ -2,6 +2,7 @@ package cli
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"os"
 	"strings"
@@ -11,16 +12,13 @@ import (
 
 // removeCmd 删除工具版本命令
 var removeCmd = &cobra.Command{
-	Use:     "remove <tool> <version>",
-	Aliases: []string{"uninstall", "rm"},
-	Short:   "删除工具版本",
+	Use:         "remove <tool> <version>",
+	Annotations: mutating(),
+	Aliases:     []string{"uninstall", "rm"},
+	Short:       "删除工具版本",
 	Long: `删除已安装的工具版本。
 
-示例:
-  vman remove kubectl 1.28.0     # 删除指定版本
-  vman remove terraform 1.5.0   # 删除指定版本
-  vman rm kubectl 1.28.0        # 使用别名
-  vman remove kubectl --all     # 删除所有版本`,
+删除前会询问确认，标准输入不是终端时需要使用 --yes（或 --force）跳过确认。`,
 	Args: cobra.RangeArgs(1, 2),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		tool := args[0]
@@ -28,16 +26,17 @@ var removeCmd = &cobra.Command{
 		// 获取选项
 		force, _ := cmd.Flags().GetBool("force")
 		all, _ := cmd.Flags().GetBool("all")
+		system, _ := cmd.Flags().GetBool("system")
 
 		// 创建管理器
-		managers, err := createManagers()
+		managers, err := createManagersForStore(system)
 		if err != nil {
 			return fmt.Errorf("创建管理器失败: %w", err)
 		}
 
 		if all {
 			// 删除所有版本
-			return removeAllVersions(tool, force, managers)
+			return removeAllVersions(cmd, tool, force, managers)
 		}
 
 		// 删除指定版本
@@ -46,12 +45,14 @@ var removeCmd = &cobra.Command{
 		}
 
 		version := args[1]
-		return removeVersion(tool, version, force, managers)
+		return removeVersion(cmd, tool, version, force, managers)
 	},
 }
 
 // removeVersion 删除指定版本
-func removeVersion(tool, version string, force bool, managers *managers) error {
+func removeVersion(cmd *cobra.Command, tool, version string, force bool, managers *managers) error {
+	options := getUIOptions(cmd)
+
 	// 检查版本是否存在
 	if !managers.version.IsVersionInstalled(tool, version) {
 		return fmt.Errorf("版本 %s@%s 未安装", tool, version)
@@ -59,23 +60,29 @@ func removeVersion(tool, version string, force bool, managers *managers) error {
 
 	// 检查是否为当前使用的版本
 	currentVersion, _ := managers.version.GetCurrentVersion(tool)
-	if currentVersion == version && !force {
-		fmt.Printf("⚠️  版本 %s@%s 当前正在使用\n", tool, version)
-		if !confirmAction("确定要删除吗？") {
+	if !force {
+		if currentVersion == version {
+			PrintWarning(fmt.Sprintf("版本 %s@%s 当前正在使用", tool, version), options)
+		}
+		confirmed, err := confirmAction(cmd, fmt.Sprintf("确定要删除 %s@%s 吗？", tool, version))
+		if err != nil {
+			return err
+		}
+		if !confirmed {
 			fmt.Println("操作已取消")
 			return nil
 		}
 	}
 
 	// 显示删除信息
-	fmt.Printf("正在删除 %s@%s...\n", tool, version)
+	Infof(options, "正在删除 %s@%s...\n", tool, version)
 
 	// 执行删除
 	if err := managers.version.RemoveVersion(tool, version); err != nil {
 		return fmt.Errorf("删除版本失败: %w", err)
 	}
 
-	fmt.Printf("✅ 成功删除 %s@%s\n", tool, version)
+	PrintSuccess(fmt.Sprintf("成功删除 %s@%s", tool, version), options)
 
 	// 如果删除的是当前版本，清除引用
 	if currentVersion == version {
@@ -83,11 +90,11 @@ func removeVersion(tool, version string, force bool, managers *managers) error {
 		versions, err := managers.version.ListVersions(tool)
 		if err == nil && len(versions) > 0 {
 			newVersion := versions[0]
-			if err := managers.version.SetGlobalVersion(tool, newVersion); err == nil {
-				fmt.Printf("已自动切换到 %s@%s\n", tool, newVersion)
+			if err := setGlobalVersionWithHistory(managers.version, managers.config, tool, newVersion); err == nil {
+				Infof(options, "已自动切换到 %s@%s\n", tool, newVersion)
 			}
 		} else {
-			fmt.Printf("⚠️  %s 没有其他可用版本\n", tool)
+			PrintWarning(fmt.Sprintf("%s 没有其他可用版本", tool), options)
 		}
 	}
 
@@ -100,7 +107,9 @@ func removeVersion(tool, version string, force bool, managers *managers) error {
 }
 
 // removeAllVersions 删除所有版本
-func removeAllVersions(tool string, force bool, managers *managers) error {
+func removeAllVersions(cmd *cobra.Command, tool string, force bool, managers *managers) error {
+	options := getUIOptions(cmd)
+
 	// 获取所有版本
 	versions, err := managers.version.ListVersions(tool)
 	if err != nil {
@@ -113,33 +122,37 @@ func removeAllVersions(tool string, force bool, managers *managers) error {
 	}
 
 	// 显示将要删除的版本
-	fmt.Printf("将要删除 %s 的以下版本:\n", tool)
+	Infof(options, "将要删除 %s 的以下版本:\n", tool)
 	for _, version := range versions {
-		fmt.Printf("  - %s\n", version)
+		Infof(options, "  - %s\n", version)
 	}
 
 	// 确认操作
 	if !force {
-		if !confirmAction(fmt.Sprintf("确定要删除 %s 的所有 %d 个版本吗？", tool, len(versions))) {
+		confirmed, err := confirmAction(cmd, fmt.Sprintf("确定要删除 %s 的所有 %d 个版本吗？", tool, len(versions)))
+		if err != nil {
+			return err
+		}
+		if !confirmed {
 			fmt.Println("操作已取消")
 			return nil
 		}
 	}
 
 	// 执行删除
-	fmt.Printf("正在删除 %s 的所有版本...\n", tool)
+	Infof(options, "正在删除 %s 的所有版本...\n", tool)
 
 	successCount := 0
 	for _, version := range versions {
 		if err := managers.version.RemoveVersion(tool, version); err != nil {
 			fmt.Printf("❌ 删除 %s@%s 失败: %v\n", tool, version, err)
 		} else {
-			fmt.Printf("✅ 已删除 %s@%s\n", tool, version)
+			Infof(options, "✅ 已删除 %s@%s\n", tool, version)
 			successCount++
 		}
 	}
 
-	fmt.Printf("\n删除完成: %d/%d 个版本成功删除\n", successCount, len(versions))
+	Infof(options, "\n删除完成: %d/%d 个版本成功删除\n", successCount, len(versions))
 
 	// 重新生成垫片
 	if err := regenerateShims(); err != nil {
@@ -149,18 +162,30 @@ func removeAllVersions(tool string, force bool, managers *managers) error {
 	return nil
 }
 
+// errConfirmationRequired 非交互环境中执行需要确认的操作
+var errConfirmationRequired = errors.New("该操作需要确认，非交互环境中请使用 --yes 跳过确认")
+
 // confirmAction 确认用户操作
-func confirmAction(message string) bool {
+// 指定 --yes 时直接确认；标准输入不是终端时无法询问，返回 errConfirmationRequired
+func confirmAction(cmd *cobra.Command, message string) (bool, error) {
+	if yes, _ := cmd.Flags().GetBool("yes"); yes {
+		return true, nil
+	}
+	if !stdinInteractive() {
+		cmd.SilenceUsage = true
+		return false, errConfirmationRequired
+	}
+
 	fmt.Printf("%s [y/N]: ", message)
 
 	reader := bufio.NewReader(os.Stdin)
 	response, err := reader.ReadString('\n')
 	if err != nil {
-		return false
+		return false, nil
 	}
 
 	response = strings.TrimSpace(strings.ToLower(response))
-	return response == "y" || response == "yes"
+	return response == "y" || response == "yes", nil
 }
 
 func init() {
@@ -168,6 +193,9 @@ func init() {
 	rootCmd.AddCommand(removeCmd)
 
 	// 添加选项
-	removeCmd.Flags().BoolP("force", "f", false, "强制删除，跳过确认提示")
+	removeCmd.Flags().BoolP("force", "f", false, "强制删除，跳过确认提示（同 --yes）")
 	removeCmd.Flags().Bool("all", false, "删除指定工具的所有版本")
+	removeCmd.Flags().Bool("system", false, "删除系统级共享存储中的版本（需要管理员权限）")
+
+	regroupCommand(versionCmd, "remove", removeCmd)
 }
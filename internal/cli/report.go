@@ -0,0 +1,325 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+
+	"github.com/songzhibin97/vman/internal/proxy"
+	"github.com/songzhibin97/vman/pkg/types"
+	"github.com/songzhibin97/vman/pkg/utils"
+)
+
+// reportCmd 生成本地报告
+var reportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "生成本地报告",
+	Long:  `根据本地记录的数据生成报告，报告只在本机生成，不会上传。`,
+}
+
+// reportUsageCmd 汇总工具版本的使用情况
+var reportUsageCmd = &cobra.Command{
+	Use:   "usage",
+	Short: "汇总已安装版本的使用情况",
+	Long: `汇总执行统计（vman stats）和版本的最后使用时间，生成使用报告：
+  - 使用中的版本：统计周期内通过垫片或 vman exec 执行过的版本
+  - 未使用的版本：已安装但统计周期内没有执行过的版本，可以用 vman prune 清理
+  - 最慢的工具：统计周期内执行过的工具按 P90 耗时排序
+
+版本的最后使用时间每天最多更新一次；执行次数和耗时从 stats.json 开始记录时累计，不按统计周期拆分。
+
+示例:
+  vman report usage
+  vman report usage --since 90d --json
+  vman report usage --format html --output usage.html`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		since, _ := cmd.Flags().GetString("since")
+		format, _ := cmd.Flags().GetString("format")
+		outputPath, _ := cmd.Flags().GetString("output")
+		top, _ := cmd.Flags().GetInt("top")
+		if jsonFormat, _ := cmd.Flags().GetBool("json"); jsonFormat {
+			format = "json"
+		}
+
+		period, err := parseAge(since)
+		if err != nil {
+			return err
+		}
+		if format != "table" && format != "json" && format != "html" {
+			return fmt.Errorf("不支持的格式 %s，可选 table、json、html", format)
+		}
+		cmd.SilenceUsage = true
+
+		homeDir, err := utils.GetHomeDir()
+		if err != nil {
+			return err
+		}
+		stats, err := proxy.LoadExecutionStatistics(afero.NewOsFs(), filepath.Join(types.DefaultConfigPaths(homeDir).CacheDir, proxy.StatsFile))
+		if err != nil {
+			return err
+		}
+		managers, err := createManagers()
+		if err != nil {
+			return fmt.Errorf("创建管理器失败: %w", err)
+		}
+
+		now := time.Now()
+		report, err := buildUsageReport(managers, stats, now.Add(-period), now, top)
+		if err != nil {
+			return err
+		}
+		report.Period = since
+
+		var out io.Writer = os.Stdout
+		if outputPath != "" {
+			file, err := os.Create(outputPath)
+			if err != nil {
+				return fmt.Errorf("创建报告文件失败: %w", err)
+			}
+			defer file.Close()
+			out = file
+		}
+
+		switch format {
+		case "json":
+			jsonData, err := json.MarshalIndent(report, "", "  ")
+			if err != nil {
+				return fmt.Errorf("JSON编码失败: %w", err)
+			}
+			fmt.Fprintln(out, string(jsonData))
+		case "html":
+			if err := usageReportTemplate.Execute(out, report); err != nil {
+				return fmt.Errorf("生成HTML报告失败: %w", err)
+			}
+		default:
+			tableOptions := *getUIOptions(cmd)
+			if outputPath != "" {
+				tableOptions.NoColor = true
+			}
+			printUsageReport(out, report, &tableOptions)
+		}
+
+		if outputPath != "" {
+			PrintSuccess(fmt.Sprintf("已保存使用报告到 %s", outputPath), getUIOptions(cmd))
+		}
+		return nil
+	},
+}
+
+// usageReport 工具版本的使用报告
+type usageReport struct {
+	GeneratedAt time.Time `json:"generated_at"`
+	Period      string    `json:"period,omitempty"`
+	Since       time.Time `json:"since"`
+	// StatsSince 执行统计开始记录的时间，执行次数和耗时从此时累计
+	StatsSince time.Time       `json:"stats_since"`
+	Used       []*usageVersion `json:"used"`
+	Unused     []*usageVersion `json:"unused"`
+	Slowest    []statsRow      `json:"slowest"`
+	// UnusedSize 未使用版本占用的磁盘空间
+	UnusedSize int64 `json:"unused_size"`
+}
+
+// usageVersion 一个已安装版本的使用情况
+type usageVersion struct {
+	Tool        string    `json:"tool"`
+	Version     string    `json:"version"`
+	Runs        int       `json:"runs"`
+	LastUsed    time.Time `json:"last_used,omitempty"`
+	InstalledAt time.Time `json:"installed_at,omitempty"`
+	Size        int64     `json:"size,omitempty"`
+}
+
+// NeverUsed 版本是否从未被使用
+func (v *usageVersion) NeverUsed() bool {
+	return v.LastUsed.IsZero()
+}
+
+// buildUsageReport 汇总已安装版本的最后使用时间和执行统计，since 之后使用过的版本计为使用中
+func buildUsageReport(managers *managers, stats *proxy.ExecutionStatistics, since, now time.Time, top int) (*usageReport, error) {
+	report := &usageReport{
+		GeneratedAt: now,
+		Since:       since,
+		StatsSince:  stats.Since,
+		Used:        []*usageVersion{},
+		Unused:      []*usageVersion{},
+		Slowest:     []statsRow{},
+	}
+
+	tools, err := managers.version.ListAllTools()
+	if err != nil {
+		return nil, fmt.Errorf("获取工具列表失败: %w", err)
+	}
+	sort.Strings(tools)
+
+	for _, tool := range tools {
+		versions, err := managers.version.ListVersions(tool)
+		if err != nil {
+			return nil, fmt.Errorf("获取 %s 的版本列表失败: %w", tool, err)
+		}
+		var runs map[string]int
+		if toolStats, ok := stats.ToolStats[tool]; ok {
+			runs = toolStats.Versions
+		}
+
+		for _, v := range versions {
+			usage := &usageVersion{Tool: tool, Version: v, Runs: runs[v]}
+			usage.LastUsed, _ = managers.storage.GetLastUsed(tool, v)
+			if metadata, err := managers.version.GetVersionMetadata(tool, v); err == nil {
+				usage.InstalledAt = metadata.InstalledAt
+			}
+
+			if !usage.LastUsed.IsZero() && !usage.LastUsed.Before(since) {
+				report.Used = append(report.Used, usage)
+				continue
+			}
+			usage.Size, _ = calculateDirSize(managers.storage.GetToolVersionPath(tool, v))
+			report.UnusedSize += usage.Size
+			report.Unused = append(report.Unused, usage)
+		}
+	}
+
+	// 使用中的版本按执行次数排序
+	sort.SliceStable(report.Used, func(i, j int) bool { return report.Used[i].Runs > report.Used[j].Runs })
+
+	var recent []*proxy.ToolStatistics
+	for _, toolStats := range stats.Tools() {
+		if toolStats.ExecutionCount > 0 && !toolStats.LastExecuted.Before(since) {
+			recent = append(recent, toolStats)
+		}
+	}
+	sort.SliceStable(recent, func(i, j int) bool { return recent[i].Percentile(90) > recent[j].Percentile(90) })
+	if top > 0 && len(recent) > top {
+		recent = recent[:top]
+	}
+	report.Slowest = statsRows(recent)
+
+	return report, nil
+}
+
+// printUsageReport 以表格输出使用报告
+func printUsageReport(out io.Writer, report *usageReport, options *UIOptions) {
+	fmt.Fprintf(out, "%s\n", ColorizeBold(fmt.Sprintf("使用中的版本 (%s 以来)", report.Since.Local().Format("2006-01-02")), options))
+	if len(report.Used) == 0 {
+		fmt.Fprintln(out, "没有使用过的版本")
+	} else {
+		table := NewTablePrinter([]string{"TOOL", "VERSION", "RUNS", "LAST USED"}, options)
+		for _, usage := range report.Used {
+			table.AddRow([]string{usage.Tool, usage.Version, strconv.Itoa(usage.Runs), usage.LastUsed.Local().Format("2006-01-02")})
+		}
+		table.PrintTo(out)
+	}
+
+	fmt.Fprintf(out, "\n%s\n", ColorizeBold("未使用的版本", options))
+	if len(report.Unused) == 0 {
+		fmt.Fprintln(out, "没有未使用的版本")
+	} else {
+		table := NewTablePrinter([]string{"TOOL", "VERSION", "LAST USED", "INSTALLED", "SIZE"}, options)
+		for _, usage := range report.Unused {
+			lastUsed := "从未使用"
+			if !usage.NeverUsed() {
+				lastUsed = usage.LastUsed.Local().Format("2006-01-02")
+			}
+			installed := "-"
+			if !usage.InstalledAt.IsZero() {
+				installed = usage.InstalledAt.Local().Format("2006-01-02")
+			}
+			table.AddRow([]string{usage.Tool, usage.Version, lastUsed, installed, formatBytes(usage.Size)})
+		}
+		table.PrintTo(out)
+		fmt.Fprintf(out, "共 %d 个版本，%s，可以使用 vman prune 清理\n", len(report.Unused), formatBytes(report.UnusedSize))
+	}
+
+	fmt.Fprintf(out, "\n%s\n", ColorizeBold("最慢的工具", options))
+	if len(report.Slowest) == 0 {
+		fmt.Fprintln(out, "没有执行统计")
+	} else {
+		table := NewTablePrinter([]string{"TOOL", "RUNS", "AVG", "P90", "MAX"}, options)
+		for _, row := range report.Slowest {
+			table.AddRow([]string{
+				row.Tool,
+				strconv.Itoa(row.Runs),
+				formatLatency(fromMilliseconds(row.AvgMs)),
+				formatLatency(fromMilliseconds(row.P90Ms)),
+				formatLatency(fromMilliseconds(row.MaxMs)),
+			})
+		}
+		table.PrintTo(out)
+	}
+
+	fmt.Fprintf(out, "\n%s\n", ColorizeDim(fmt.Sprintf("执行次数和耗时从 %s 开始累计", report.StatsSince.Local().Format("2006-01-02 15:04:05")), options))
+}
+
+// fromMilliseconds 将毫秒转换为耗时
+func fromMilliseconds(ms float64) time.Duration {
+	return time.Duration(ms * float64(time.Millisecond))
+}
+
+// usageReportTemplate HTML格式的使用报告
+var usageReportTemplate = template.Must(template.New("usage").Funcs(template.FuncMap{
+	"date":    func(t time.Time) string { return t.Local().Format("2006-01-02") },
+	"bytes":   formatBytes,
+	"latency": func(ms float64) string { return formatLatency(fromMilliseconds(ms)) },
+}).Parse(`<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+<meta charset="utf-8">
+<title>vman 使用报告</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: 4px 12px; text-align: left; }
+th { background: #f4f4f4; }
+.dim { color: #888; }
+</style>
+</head>
+<body>
+<h1>vman 使用报告</h1>
+<p class="dim">生成于 {{date .GeneratedAt}}，统计 {{date .Since}} 以来的使用情况；执行次数和耗时从 {{date .StatsSince}} 开始累计</p>
+
+<h2>使用中的版本</h2>
+{{if .Used}}<table>
+<tr><th>工具</th><th>版本</th><th>执行次数</th><th>最后使用</th></tr>
+{{range .Used}}<tr><td>{{.Tool}}</td><td>{{.Version}}</td><td>{{.Runs}}</td><td>{{date .LastUsed}}</td></tr>
+{{end}}</table>
+{{else}}<p>没有使用过的版本</p>
+{{end}}
+<h2>未使用的版本</h2>
+{{if .Unused}}<table>
+<tr><th>工具</th><th>版本</th><th>最后使用</th><th>安装时间</th><th>大小</th></tr>
+{{range .Unused}}<tr><td>{{.Tool}}</td><td>{{.Version}}</td><td>{{if .NeverUsed}}从未使用{{else}}{{date .LastUsed}}{{end}}</td><td>{{if .InstalledAt.IsZero}}-{{else}}{{date .InstalledAt}}{{end}}</td><td>{{bytes .Size}}</td></tr>
+{{end}}</table>
+<p>共 {{len .Unused}} 个版本，{{bytes .UnusedSize}}</p>
+{{else}}<p>没有未使用的版本</p>
+{{end}}
+<h2>最慢的工具</h2>
+{{if .Slowest}}<table>
+<tr><th>工具</th><th>执行次数</th><th>平均</th><th>P90</th><th>最长</th></tr>
+{{range .Slowest}}<tr><td>{{.Tool}}</td><td>{{.Runs}}</td><td>{{latency .AvgMs}}</td><td>{{latency .P90Ms}}</td><td>{{latency .MaxMs}}</td></tr>
+{{end}}</table>
+{{else}}<p>没有执行统计</p>
+{{end}}
+</body>
+</html>
+`))
+
+func init() {
+	rootCmd.AddCommand(reportCmd)
+	reportCmd.AddCommand(reportUsageCmd)
+
+	reportUsageCmd.Flags().String("since", "30d", "统计周期，如 30d、12w")
+	reportUsageCmd.Flags().String("format", "table", "输出格式: table、json、html")
+	reportUsageCmd.Flags().Bool("json", false, "使用JSON格式输出（同 --format json）")
+	reportUsageCmd.Flags().StringP("output", "o", "", "将报告写入文件")
+	reportUsageCmd.Flags().Int("top", 10, "最慢的工具显示的数量，0 表示全部")
+}
@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/songzhibin97/vman/internal/proxy"
+	"github.com/songzhibin97/vman/internal/storage"
+	"github.com/songzhibin97/vman/pkg/types"
+)
+
+func TestBuildUsageReport(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	paths := types.DefaultConfigPaths(home)
+
+	now := time.Now()
+	for _, version := range []string{"1.28.0", "1.29.0", "1.30.0"} {
+		binDir := filepath.Join(paths.ToolVersionDir("kubectl", version), "bin")
+		require.NoError(t, os.MkdirAll(binDir, 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(binDir, "kubectl"), []byte("#!/bin/sh\n"), 0755))
+	}
+	require.NoError(t, storage.MarkUsed(afero.NewOsFs(), paths.ToolVersionDir("kubectl", "1.29.0"), now.Add(-2*24*time.Hour)))
+	require.NoError(t, storage.MarkUsed(afero.NewOsFs(), paths.ToolVersionDir("kubectl", "1.30.0"), now.Add(-60*24*time.Hour)))
+
+	stats := proxy.NewExecutionStatistics()
+	for _, session := range []*proxy.TrackingSession{
+		{Tool: "kubectl", Version: "1.29.0", StartTime: now.Add(-time.Hour), Duration: 50 * time.Millisecond},
+		{Tool: "kubectl", Version: "1.29.0", StartTime: now.Add(-time.Hour), Duration: 70 * time.Millisecond},
+		{Tool: "terraform", Version: "1.7.0", StartTime: now.Add(-time.Hour), Duration: 3 * time.Second},
+		{Tool: "helm", Version: "3.14.0", StartTime: now.Add(-90 * 24 * time.Hour), Duration: time.Minute},
+	} {
+		stats.Record(session)
+	}
+
+	managers, err := createManagers()
+	require.NoError(t, err)
+
+	report, err := buildUsageReport(managers, stats, now.Add(-30*24*time.Hour), now, 10)
+	require.NoError(t, err)
+
+	require.Len(t, report.Used, 1)
+	assert.Equal(t, "1.29.0", report.Used[0].Version)
+	assert.Equal(t, 2, report.Used[0].Runs)
+
+	require.Len(t, report.Unused, 2)
+	assert.Equal(t, "1.28.0", report.Unused[0].Version)
+	assert.True(t, report.Unused[0].NeverUsed())
+	assert.Equal(t, "1.30.0", report.Unused[1].Version)
+	assert.False(t, report.Unused[1].NeverUsed())
+	assert.Positive(t, report.UnusedSize)
+
+	// helm 在统计周期之前执行，不计入最慢的工具
+	require.Len(t, report.Slowest, 2)
+	assert.Equal(t, "terraform", report.Slowest[0].Tool)
+	assert.Equal(t, "kubectl", report.Slowest[1].Tool)
+
+	report, err = buildUsageReport(managers, stats, now.Add(-30*24*time.Hour), now, 1)
+	require.NoError(t, err)
+	assert.Len(t, report.Slowest, 1)
+
+	var html bytes.Buffer
+	require.NoError(t, usageReportTemplate.Execute(&html, report))
+	assert.Contains(t, html.String(), "<td>1.28.0</td>")
+	assert.Contains(t, html.String(), "从未使用")
+}
@@ -0,0 +1,147 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/songzhibin97/vman/internal/config"
+	"github.com/songzhibin97/vman/pkg/utils"
+)
+
+// resetCmd 按范围重置vman
+var resetCmd = &cobra.Command{
+	Use:         "reset",
+	Annotations: mutating(),
+	Short:       "重置配置、缓存或工具",
+	Long: `按范围重置vman，每个范围只删除对应的文件和目录：
+
+  vman reset config               全局配置恢复为默认值，工具定义和已安装的版本保持不变
+  vman reset cache                清空缓存目录和临时目录
+  vman reset tools [--tool x]     删除工具定义、已安装的版本和垫片，可以只重置指定的工具
+  vman reset --all                执行以上所有重置
+
+执行前会列出将要删除的内容并询问确认，并将全局配置和工具定义备份到数据目录的 backups 目录中。`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if all, _ := cmd.Flags().GetBool("all"); !all {
+			return fmt.Errorf("请指定重置范围: vman reset config|cache|tools，或使用 --all 重置全部内容")
+		}
+		return runReset(cmd, config.ResetAll, nil)
+	},
+}
+
+var resetConfigCmd = &cobra.Command{
+	Use:         "config",
+	Annotations: mutating(),
+	Short:       "将全局配置恢复为默认值",
+	Args:        cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runReset(cmd, config.ResetConfig, nil)
+	},
+}
+
+var resetCacheCmd = &cobra.Command{
+	Use:         "cache",
+	Annotations: mutating(),
+	Short:       "清空缓存目录和临时目录",
+	Args:        cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runReset(cmd, config.ResetCache, nil)
+	},
+}
+
+var resetToolsCmd = &cobra.Command{
+	Use:         "tools",
+	Annotations: mutating(),
+	Short:       "删除工具定义和已安装的版本",
+	Long: `删除工具定义、已安装的版本和垫片，并从全局配置中移除这些工具的全局版本。
+
+示例:
+  vman reset tools                         # 重置所有工具
+  vman reset tools --tool kubectl --tool helm`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		tools, _ := cmd.Flags().GetStringSlice("tool")
+		return runReset(cmd, config.ResetTools, tools)
+	},
+}
+
+// runReset 列出将要删除的内容，确认后按范围重置
+func runReset(cmd *cobra.Command, scope config.ResetScope, tools []string) error {
+	cmd.SilenceUsage = true
+	options := getUIOptions(cmd)
+
+	homeDir, err := utils.GetHomeDir()
+	if err != nil {
+		return err
+	}
+	api, err := config.NewAPI(homeDir)
+	if err != nil {
+		return fmt.Errorf("创建配置管理器失败: %w", err)
+	}
+
+	targets, err := api.ResetTargets(scope, tools...)
+	if err != nil {
+		return err
+	}
+	Infof(options, "将删除以下内容:\n")
+	for _, target := range targets {
+		if _, err := os.Stat(target); err == nil {
+			Infof(options, "  - %s\n", target)
+		}
+	}
+
+	confirmed, err := confirmAction(cmd, fmt.Sprintf("确定要重置%s吗？", resetScopeName(scope)))
+	if err != nil {
+		return err
+	}
+	if !confirmed {
+		fmt.Println("操作已取消")
+		return nil
+	}
+
+	backupPath, err := api.Reset(context.Background(), scope, tools...)
+	if err != nil {
+		return fmt.Errorf("重置失败: %w", err)
+	}
+
+	if scope == config.ResetTools || scope == config.ResetAll {
+		if err := regenerateShims(); err != nil {
+			PrintWarning(fmt.Sprintf("重新生成垫片失败: %v", err), options)
+		}
+	}
+
+	PrintSuccess(fmt.Sprintf("已重置%s", resetScopeName(scope)), options)
+	Infof(options, "重置前的配置已备份到 %s\n", backupPath)
+	return nil
+}
+
+// resetScopeName 重置范围的描述
+func resetScopeName(scope config.ResetScope) string {
+	switch scope {
+	case config.ResetConfig:
+		return "全局配置"
+	case config.ResetCache:
+		return "缓存"
+	case config.ResetTools:
+		return "工具"
+	default:
+		return "全部内容"
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(resetCmd)
+	resetCmd.AddCommand(resetConfigCmd)
+	resetCmd.AddCommand(resetCacheCmd)
+	resetCmd.AddCommand(resetToolsCmd)
+
+	resetCmd.Flags().Bool("all", false, "重置配置、缓存和所有工具")
+	resetToolsCmd.Flags().StringSlice("tool", nil, "只重置指定的工具，可以重复指定")
+
+	routeCommand(configCmd, "reset", resetConfigCmd)
+	routeCommand(cacheCmd, "clean", resetCacheCmd)
+}
@@ -0,0 +1,176 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+
+	"github.com/songzhibin97/vman/internal/storage"
+	"github.com/songzhibin97/vman/pkg/types"
+	"github.com/songzhibin97/vman/pkg/utils"
+)
+
+// journalExecutor 根据操作日志创建执行单个步骤的函数
+type journalExecutor func(cmd *cobra.Command, journal *storage.Journal) (func(step *storage.JournalStep) error, error)
+
+// journalExecutors 可以通过 vman resume 继续的操作
+var journalExecutors = map[string]journalExecutor{
+	"install": installStepExecutor,
+}
+
+// resumeCmd 继续中途失败的多步骤操作
+var resumeCmd = &cobra.Command{
+	Use:         "resume [id]",
+	Annotations: mutating(),
+	Short:       "继续中途失败的多步骤操作",
+	Long: `vman install 等多步骤操作在每个步骤完成后记录进度，中途失败时（如网络中断）
+可以用 vman resume 从失败的步骤继续，已完成的步骤不再重复执行。
+
+不指定ID时继续最近一次未完成的操作。
+
+示例:
+  vman resume
+  vman resume --list
+  vman resume install-20240601-120000-4242
+  vman resume --discard`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		list, _ := cmd.Flags().GetBool("list")
+		discard, _ := cmd.Flags().GetBool("discard")
+
+		dir, err := journalDir()
+		if err != nil {
+			return err
+		}
+		journals, err := storage.ListJournals(afero.NewOsFs(), dir)
+		if err != nil {
+			return err
+		}
+
+		if list {
+			printJournals(journals, getUIOptions(cmd))
+			return nil
+		}
+		if len(journals) == 0 {
+			fmt.Println("没有未完成的操作")
+			return nil
+		}
+
+		journal := journals[len(journals)-1]
+		if len(args) == 1 {
+			journal = nil
+			for _, j := range journals {
+				if j.ID == args[0] {
+					journal = j
+				}
+			}
+			if journal == nil {
+				return fmt.Errorf("没有找到操作 %s，运行 vman resume --list 查看未完成的操作", args[0])
+			}
+		}
+
+		if discard {
+			if err := journal.Remove(); err != nil {
+				return err
+			}
+			PrintSuccess(fmt.Sprintf("已放弃操作 %s", journal.ID), getUIOptions(cmd))
+			return nil
+		}
+
+		if journal.WorkDir != "" {
+			if err := os.Chdir(journal.WorkDir); err != nil {
+				return fmt.Errorf("切换到操作的工作目录 %s 失败: %w", journal.WorkDir, err)
+			}
+		}
+		remaining := journal.Remaining()
+		fmt.Printf("继续 %s: 剩余 %d/%d 个步骤\n", journalTitle(journal), len(remaining), len(journal.Steps))
+		return runJournal(cmd, journal)
+	},
+}
+
+// journalDir 保存操作日志的目录
+func journalDir() (string, error) {
+	homeDir, err := utils.GetHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(types.DefaultConfigPaths(homeDir).DataDir, storage.JournalDirName), nil
+}
+
+// newJournal 为当前命令创建操作日志
+func newJournal(operation string) (*storage.Journal, error) {
+	dir, err := journalDir()
+	if err != nil {
+		return nil, err
+	}
+	journal := storage.NewJournal(afero.NewOsFs(), dir, operation)
+	journal.Command = "vman " + strings.Join(os.Args[1:], " ")
+	journal.WorkDir, _ = os.Getwd()
+	return journal, nil
+}
+
+// runJournal 执行操作日志中尚未完成的步骤，失败时提示使用 vman resume 继续
+func runJournal(cmd *cobra.Command, journal *storage.Journal) error {
+	executor, ok := journalExecutors[journal.Operation]
+	if !ok {
+		return fmt.Errorf("不支持继续 %s 操作", journal.Operation)
+	}
+	execute, err := executor(cmd, journal)
+	if err != nil {
+		return err
+	}
+
+	if err := journal.Run(execute); err != nil {
+		cmd.SilenceUsage = true
+		return fmt.Errorf("%w\n还有 %d 个步骤未完成，运行 vman resume 从失败的步骤继续", err, len(journal.Remaining()))
+	}
+	PrintSuccess(fmt.Sprintf("全部 %d 个步骤已完成", len(journal.Steps)), getUIOptions(cmd))
+	return nil
+}
+
+// printJournals 列出未完成的操作
+func printJournals(journals []*storage.Journal, options *UIOptions) {
+	if len(journals) == 0 {
+		fmt.Println("没有未完成的操作")
+		return
+	}
+
+	table := NewTablePrinter([]string{"ID", "COMMAND", "REMAINING", "FAILED STEP", "UPDATED"}, options)
+	for _, journal := range journals {
+		failed := "-"
+		for _, step := range journal.Steps {
+			if step.Status == storage.StepFailed {
+				failed = step.Name
+				break
+			}
+		}
+		table.AddRow([]string{
+			journal.ID,
+			journalTitle(journal),
+			fmt.Sprintf("%d/%d", len(journal.Remaining()), len(journal.Steps)),
+			failed,
+			journal.UpdatedAt.Local().Format("2006-01-02 15:04:05"),
+		})
+	}
+	table.Print()
+}
+
+// journalTitle 操作的描述
+func journalTitle(journal *storage.Journal) string {
+	if journal.Command != "" {
+		return journal.Command
+	}
+	return journal.Operation
+}
+
+func init() {
+	rootCmd.AddCommand(resumeCmd)
+
+	resumeCmd.Flags().Bool("list", false, "列出未完成的操作")
+	resumeCmd.Flags().Bool("discard", false, "放弃操作并删除其记录")
+}
@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/songzhibin97/vman/internal/config"
+	"github.com/songzhibin97/vman/pkg/types"
+)
+
+func TestProjectToolRequirements(t *testing.T) {
+	t.Setenv(types.EnvVmanHome, t.TempDir())
+	configManager, err := config.NewManager(t.TempDir())
+	require.NoError(t, err)
+
+	project := t.TempDir()
+	service := filepath.Join(project, "services", "api")
+	require.NoError(t, os.MkdirAll(service, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(project, ".vman.yaml"), []byte(`version: "1.0"
+tools:
+  kubectl: ~1.29
+  helm: 3.14.0
+paths:
+  services/*:
+    kubectl: 1.28.9
+`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(service, ".vman-version"), []byte("terraform 1.7.0\n"), 0644))
+
+	requirements, err := projectToolRequirements(configManager, project)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"kubectl": "~1.29", "helm": "3.14.0"}, requirements.Versions)
+
+	requirements, err = projectToolRequirements(configManager, service)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"kubectl": "1.28.9", "helm": "3.14.0", "terraform": "1.7.0"}, requirements.Versions)
+}
+
+func TestProjectToolRequirements_GroupsAndOptional(t *testing.T) {
+	t.Setenv(types.EnvVmanHome, t.TempDir())
+	configManager, err := config.NewManager(t.TempDir())
+	require.NoError(t, err)
+
+	project := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(project, ".vman.yaml"), []byte(`version: "1.0"
+tools:
+  terraform: 1.7.0
+  terragrunt: 0.55.0
+  hugo:
+    version: 0.121.0
+    optional: true
+groups:
+  infra: [terraform, terragrunt, tflint]
+  docs: [hugo]
+`), 0644))
+
+	requirements, err := projectToolRequirements(configManager, project)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{"hugo": true}, requirements.Optional)
+
+	// 分组中没有配置版本的工具被忽略
+	tools, err := requirements.Group("infra")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"terraform", "terragrunt"}, tools)
+
+	_, err = requirements.Group("ci")
+	assert.ErrorContains(t, err, "docs, infra")
+}
+
+func TestHighestMatchingVersion(t *testing.T) {
+	constraint, err := semver.NewConstraint("~1.29")
+	require.NoError(t, err)
+
+	assert.Equal(t, "1.29.3", highestMatchingVersion([]string{"1.28.9", "1.29.0", "1.29.3", "1.30.1", "latest"}, constraint))
+	assert.Equal(t, "", highestMatchingVersion([]string{"1.30.1"}, constraint))
+
+	assert.True(t, isVersionConstraint("~1.29"))
+	assert.True(t, isVersionConstraint(">=1.28 <1.30"))
+	assert.True(t, isVersionConstraint("1.29.x"))
+	assert.False(t, isVersionConstraint("1.29.0"))
+	assert.False(t, isVersionConstraint("v1.29.0"))
+}
@@ -1,7 +1,17 @@
 package cli
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+
 	"github.com/spf13/cobra"
+
+	"github.com/songzhibin97/vman/internal/download"
+	"github.com/songzhibin97/vman/internal/proxy"
+	"github.com/songzhibin97/vman/pkg/types"
 )
 
 var rootCmd = &cobra.Command{
@@ -16,17 +26,82 @@ var rootCmd = &cobra.Command{
 - 自动下载和安装工具
 - 透明的命令代理`,
 	Version: "0.1.0",
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		settings := loadGlobalSettings()
+		if err := checkReadOnly(cmd, settings); err != nil {
+			return err
+		}
+		if err := checkOwnership(cmd, settings); err != nil {
+			return err
+		}
+		if err := download.ConfigureNetwork(settings.Network); err != nil {
+			cmd.SilenceUsage = true
+			return fmt.Errorf("网络设置无效: %w", err)
+		}
+		if err := applyTempDirFlag(cmd); err != nil {
+			return err
+		}
+		autoBackupBeforeCommand(cmd)
+		return nil
+	},
+	PersistentPostRun: func(cmd *cobra.Command, args []string) {
+		printNetworkStats(cmd)
+	},
+}
+
+// applyTempDirFlag 通过环境变量 VMAN_TEMP_DIR 将 --temp-dir 传给下载管理器，优先于 settings.storage.temp_dir
+func applyTempDirFlag(cmd *cobra.Command) error {
+	tempDir, _ := cmd.Flags().GetString("temp-dir")
+	if tempDir == "" {
+		return nil
+	}
+	absDir, err := filepath.Abs(tempDir)
+	if err != nil {
+		return fmt.Errorf("解析临时目录失败: %w", err)
+	}
+	return os.Setenv(types.EnvVmanTempDir, absDir)
 }
 
-// Execute 执行根命令
-func Execute() error {
-	return rootCmd.Execute()
+// printNetworkStats 详细输出模式下，命令发送过HTTP请求时在标准错误输出连接复用统计
+func printNetworkStats(cmd *cobra.Command) {
+	options := getUIOptions(cmd)
+	stats := download.NetworkStats()
+	if !options.Verbose || options.Quiet || stats.Requests == 0 {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "网络连接: %s\n", stats)
+}
+
+// Execute 执行根命令，命令中发生 panic 时保存诊断包并返回错误
+func Execute() (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = handlePanic(r, debug.Stack())
+		}
+	}()
+	// 垫片调用 vman exec <tool> 时先尝试快速路径，满足条件时不再解析命令行和加载全局设置
+	if len(os.Args) > 2 && os.Args[1] == execCmd.Name() && !strings.HasPrefix(os.Args[2], "-") {
+		runFastPath(os.Args[2], os.Args[3:])
+	}
+	applyCommandExamples(rootCmd)
+	setupCommandSuggestions(rootCmd)
+	setupBrokenPipeHandling(rootCmd)
+	if err = rootCmd.Execute(); proxy.IsBrokenPipe(err) {
+		proxy.ExitBrokenPipe()
+	}
+	return err
 }
 
 func init() {
+	// 生成的垫片中记录vman版本
+	proxy.VmanVersion = rootCmd.Version
+
 	// 这里将添加全局标志和配置
 	rootCmd.PersistentFlags().StringP("config", "c", "", "配置文件路径")
 	rootCmd.PersistentFlags().BoolP("verbose", "v", false, "详细输出")
 	rootCmd.PersistentFlags().Bool("no-color", false, "禁用彩色输出")
 	rootCmd.PersistentFlags().Bool("no-emoji", false, "禁用emoji图标")
+	rootCmd.PersistentFlags().BoolP("quiet", "q", false, "不输出进度和提示信息")
+	rootCmd.PersistentFlags().BoolP("yes", "y", false, "跳过确认提示，适用于脚本")
+	rootCmd.PersistentFlags().String("temp-dir", "", "下载和解压安装包的临时目录（覆盖 settings.storage.temp_dir）")
 }
@@ -0,0 +1,38 @@
+package cli
+
+import (
+	"os"
+	"runtime"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	"github.com/songzhibin97/vman/internal/proxy"
+)
+
+// sandboxRunCmd 在 Landlock 限制下执行工具，由代理在没有 bubblewrap 的 Linux 上调用
+var sandboxRunCmd = &cobra.Command{
+	Use:    proxy.SandboxRunCommand + " [--writable dir]... [--offline] -- <command> [args...]",
+	Short:  "在沙箱中执行命令（内部使用）",
+	Hidden: true,
+	Args:   cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		writable, _ := cmd.Flags().GetStringArray("writable")
+		offline, _ := cmd.Flags().GetBool("offline")
+
+		// Landlock 只限制调用线程，在同一线程中 exec 后整个进程受到限制
+		runtime.LockOSThread()
+		if err := proxy.RestrictWrites(writable, offline); err != nil {
+			return err
+		}
+		return syscall.Exec(args[0], args, os.Environ())
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(sandboxRunCmd)
+
+	sandboxRunCmd.Flags().StringArray("writable", nil, "允许写入的目录")
+	sandboxRunCmd.Flags().Bool("offline", false, "禁止TCP连接和监听")
+}
@@ -0,0 +1,135 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/songzhibin97/vman/internal/server"
+	"github.com/songzhibin97/vman/pkg/types"
+	"github.com/songzhibin97/vman/pkg/vman"
+)
+
+// serveCmd 启动本地 API 服务
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "启动本地 REST 和 gRPC API 服务",
+	Long: fmt.Sprintf(`启动本地 REST 和 gRPC API 服务，供编辑器扩展和内部面板查询、安装工具版本。
+
+除 /api/v1/health 和 gRPC 健康检查外，所有请求都需要在 Authorization 头（gRPC 为 authorization
+元数据）中携带 Bearer 令牌。
+令牌依次取自环境变量 %s、--token-file 指定的文件，都未设置时自动生成并以 0600 权限
+保存在配置目录下的 %s 中。令牌不通过命令行参数传递，避免在进程列表中泄露。
+
+接口:
+  GET  /api/v1/health                       健康检查（无需认证）
+  GET  /api/v1/config                       全局版本设置
+  GET  /api/v1/tools                        已安装的工具
+  GET  /api/v1/tools/{tool}/versions        已安装的版本
+  GET  /api/v1/tools/{tool}/resolve?dir=    目录下生效的版本
+  POST /api/v1/tools/{tool}/install         安装版本，请求体 {"version": "1.6.0"}
+  PUT  /api/v1/tools/{tool}/global          设置全局版本，请求体 {"version": "1.6.0"}
+
+gRPC 服务 %s 默认监听 --grpc-listen 指定的地址，提供 GetConfig、ListTools、ListVersions、
+Resolve、Install、SetGlobalVersion 方法，请求和响应都是 google.protobuf.Struct，字段与 REST 接口相同。
+--grpc-listen 为空时不启动 gRPC 服务。`, types.EnvVmanAPIToken, server.TokenFileName, server.GRPCServiceName),
+	Example: `  # 在默认地址 localhost:7070 上启动
+  vman serve
+
+  # 查询项目中生效的 terraform 版本
+  curl -H "Authorization: Bearer $VMAN_API_TOKEN" \
+    "http://localhost:7070/api/v1/tools/terraform/resolve?dir=$PWD"`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		listen, _ := cmd.Flags().GetString("listen")
+		grpcListen, _ := cmd.Flags().GetString("grpc-listen")
+		tokenFile, _ := cmd.Flags().GetString("token-file")
+
+		cmd.SilenceUsage = true
+
+		client, err := vman.New(nil)
+		if err != nil {
+			return fmt.Errorf("创建管理器失败: %w", err)
+		}
+
+		token := os.Getenv(types.EnvVmanAPIToken)
+		if token == "" && tokenFile != "" {
+			token, err = server.ReadTokenFile(tokenFile)
+			if err != nil {
+				return fmt.Errorf("读取令牌文件失败: %w", err)
+			}
+			if token == "" {
+				return fmt.Errorf("令牌文件 %s 为空", tokenFile)
+			}
+		}
+		if token == "" {
+			configDir := client.Paths().ConfigDir
+			token, err = server.LoadOrCreateToken(configDir)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("访问令牌保存在 %s\n", filepath.Join(configDir, server.TokenFileName))
+		}
+
+		for _, addr := range []string{listen, grpcListen} {
+			if host, _, err := net.SplitHostPort(addr); err == nil && !isLoopbackHost(host) {
+				fmt.Fprintf(os.Stderr, "警告: %s 不是本机回环地址，API 将对网络中的其他机器开放\n", addr)
+			}
+		}
+
+		logger := logrus.New()
+		if verbose, _ := cmd.Flags().GetBool("verbose"); verbose {
+			logger.SetLevel(logrus.DebugLevel)
+		}
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		go runScheduledBackups(ctx, logger)
+
+		apiServer := server.New(client, token, logger)
+		errCh := make(chan error, 2)
+		running := 1
+		go func() { errCh <- apiServer.ListenAndServe(ctx, listen) }()
+		fmt.Printf("vman API 服务已启动: http://%s%s\n", listen, server.APIPrefix)
+		if grpcListen != "" {
+			running++
+			go func() { errCh <- apiServer.ServeGRPC(ctx, grpcListen) }()
+			fmt.Printf("vman gRPC 服务已启动: %s (%s)\n", grpcListen, server.GRPCServiceName)
+		}
+
+		// 任一服务退出时停止另一个服务
+		var serveErr error
+		for i := 0; i < running; i++ {
+			if err := <-errCh; err != nil && serveErr == nil {
+				serveErr = err
+				stop()
+			}
+		}
+		return serveErr
+	},
+}
+
+// isLoopbackHost 判断监听地址是否只对本机开放
+func isLoopbackHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func init() {
+	rootCmd.AddCommand(serveCmd)
+
+	serveCmd.Flags().String("listen", "localhost:7070", "监听地址")
+	serveCmd.Flags().String("grpc-listen", "localhost:7071", "gRPC 服务的监听地址，为空时不启动 gRPC 服务")
+	serveCmd.Flags().String("token-file", "", "从文件读取访问令牌，默认读取环境变量或自动生成")
+}
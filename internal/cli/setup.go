@@ -0,0 +1,136 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+
+	"github.com/songzhibin97/vman/internal/proxy"
+)
+
+// onboardCmd 一次完成新用户需要的设置
+var onboardCmd = &cobra.Command{
+	Use:         "setup [shell]",
+	Annotations: mutating(),
+	Short:       "一键完成vman的初始设置",
+	Long: `一键完成vman的初始设置：
+- 检测当前shell（也可以通过参数指定）
+- 创建存储目录和默认配置文件
+- 在shell配置文件末尾写入加载vman的片段，将shims目录加入PATH并启用shell集成
+- 为已安装的工具生成垫片
+- 最后运行 vman doctor 的全部检查
+
+写入的片段由 "# vman setup" 标记包围，重复运行只会更新这一段，
+使用 vman setup --remove 可以干净地移除。
+
+支持的shell: bash, zsh, fish, nu, xonsh, powershell, pwsh`,
+	Example: `  # 自动检测shell
+  vman setup
+
+  # 为zsh设置
+  vman setup zsh
+
+  # 移除写入shell配置文件的片段
+  vman setup --remove`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		options := getUIOptions(cmd)
+		remove, _ := cmd.Flags().GetBool("remove")
+
+		shellIntegrator := proxy.NewShellIntegrator()
+		shell := shellIntegrator.DetectShell()
+		if len(args) == 1 {
+			shell = args[0]
+		}
+		if !shellIntegrator.ValidateShellSupport(shell) || shell == "cmd" {
+			return fmt.Errorf("不支持的shell类型: %s，支持 bash, zsh, fish, nu, xonsh, powershell, pwsh", shell)
+		}
+
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return fmt.Errorf("获取用户主目录失败: %w", err)
+		}
+		configFile := getShellConfigFile(shell, homeDir)
+
+		if remove {
+			removed, err := proxy.RemoveSetupSnippet(afero.NewOsFs(), configFile, shell)
+			if err != nil {
+				return fmt.Errorf("移除shell配置失败: %w", err)
+			}
+			if !removed {
+				fmt.Printf("%s 中没有 vman setup 写入的配置\n", configFile)
+				return nil
+			}
+			PrintSuccess(fmt.Sprintf("已从 %s 中移除vman的配置，重新打开终端后生效", configFile), options)
+			return nil
+		}
+
+		if err := initDirectories(false); err != nil {
+			return fmt.Errorf("初始化目录结构失败: %w", err)
+		}
+		if err := initConfig(false); err != nil {
+			return fmt.Errorf("初始化配置文件失败: %w", err)
+		}
+
+		fmt.Printf("🐚 设置%s集成...\n", shell)
+		changed, err := proxy.InstallSetupSnippet(afero.NewOsFs(), configFile, shell)
+		if err != nil {
+			return fmt.Errorf("设置shell集成失败: %w", err)
+		}
+		if changed {
+			fmt.Printf("  ✅ %s\n", configFile)
+		} else {
+			fmt.Printf("  ⏭  %s (已集成)\n", configFile)
+		}
+
+		fmt.Println("🔧 生成垫片...")
+		if err := initProxy(); err != nil {
+			return err
+		}
+		if err := commandProxy.RehashShims(); err != nil {
+			PrintWarning(fmt.Sprintf("生成垫片失败: %v", err), options)
+		}
+
+		// 当前进程的PATH还没有加载新的配置，按shell集成的方式加入shims目录后再检查
+		if report, err := commandProxy.CheckPath(); err == nil && !report.ShimsInPath {
+			os.Setenv("PATH", report.ShimsDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+		}
+
+		fmt.Println("\n🩺 检查环境...")
+		managers, err := createManagers()
+		if err != nil {
+			return fmt.Errorf("创建管理器失败: %w", err)
+		}
+		warnings, errors := runDoctorChecks(managers, options)
+
+		fmt.Println()
+		if errors > 0 {
+			fmt.Printf("发现 %d 个错误，%d 个警告，运行 vman doctor 查看详情\n", errors, warnings)
+			return fmt.Errorf("环境检查未通过")
+		}
+		PrintSuccess("vman设置完成", options)
+		fmt.Printf("重新打开终端或运行以下命令以激活vman:\n  %s\n", reloadCommand(shell, configFile))
+		return nil
+	},
+}
+
+// reloadCommand 在当前shell中重新加载配置文件的命令
+func reloadCommand(shell, configFile string) string {
+	switch shell {
+	case "nu":
+		return "exec nu"
+	case "powershell", "pwsh":
+		return ". $PROFILE"
+	default:
+		return "source " + configFile
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(onboardCmd)
+
+	onboardCmd.Flags().Bool("remove", false, "从shell配置文件中移除 vman setup 写入的片段")
+}
@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/songzhibin97/vman/internal/proxy"
+)
+
+// shellenvCmd 输出登录shell使用的环境变量设置
+var shellenvCmd = &cobra.Command{
+	Use:   "shellenv [shell]",
+	Short: "输出登录shell使用的PATH和环境变量设置",
+	Long: `输出设置 VMAN_DIR、VMAN_SHIMS_DIR 并将shims目录加入PATH的命令。
+
+与 vman shell-init 不同，这里不注册目录切换钩子、命令补全和提示符函数，也不运行任何子进程，
+只需要一行就可以放在登录shell的配置文件中，启动开销可以忽略。图形界面启动的程序、
+ssh 远程命令和 IDE 等非交互环境也能找到vman管理的工具。交互式shell中仍然可以使用
+vman shell-init 获得完整的集成，两者可以同时使用，PATH不会重复添加。
+
+不指定shell时自动检测当前shell。支持的shell: ` + strings.Join(proxy.ShellEnvShells(), ", ") + `
+
+登录shell的配置文件:
+  zsh          ~/.zprofile           eval "$(vman shellenv zsh)"
+  bash         ~/.bash_profile       eval "$(vman shellenv bash)"
+  sh/dash      ~/.profile            eval "$(vman shellenv sh)"
+  fish         ~/.config/fish/config.fish
+                                     vman shellenv fish | source
+  nu           env.nu                vman shellenv nu | save -f ($nu.default-config-dir | path join vman-env.nu)
+                                     然后在 env.nu 中 source vman-env.nu
+  xonsh        ~/.xonshrc            execx($(vman shellenv xonsh))
+  PowerShell   $PROFILE              vman shellenv pwsh | Out-String | Invoke-Expression
+               （Windows PowerShell 5.1 使用 vman shellenv powershell）
+  cmd          AutoRun 或批处理文件   vman shellenv cmd > "%USERPROFILE%\vman_env.cmd"`,
+	Example: `  # 添加到 ~/.zprofile
+  echo 'eval "$(/usr/local/bin/vman shellenv zsh)"' >> ~/.zprofile
+
+  # 添加到 PowerShell 配置文件
+  Add-Content $PROFILE 'vman shellenv pwsh | Out-String | Invoke-Expression'`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+
+		shell := proxy.NewShellIntegrator().DetectShell()
+		if len(args) == 1 {
+			shell = args[0]
+		}
+
+		script, err := proxy.GenerateShellEnv(shell)
+		if err != nil {
+			return fmt.Errorf("不支持的shell类型: %s，支持 %s", shell, strings.Join(proxy.ShellEnvShells(), ", "))
+		}
+		fmt.Print(script)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(shellenvCmd)
+}
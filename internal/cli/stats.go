@@ -0,0 +1,226 @@
+package cli
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+
+	"github.com/songzhibin97/vman/internal/proxy"
+	"github.com/songzhibin97/vman/pkg/types"
+	"github.com/songzhibin97/vman/pkg/utils"
+)
+
+// statsCmd 显示通过垫片执行工具的统计
+var statsCmd = &cobra.Command{
+	Use:   "stats [tool...]",
+	Short: "显示工具的执行次数和耗时统计",
+	Long: `显示通过 vman 代理执行的各工具的执行次数、失败次数和耗时百分位数。
+
+统计在每次执行结束时汇总写入缓存目录的 stats.json，耗时百分位数按直方图估算。
+
+示例:
+  vman stats
+  vman stats kubectl --json
+  vman stats --export stats.csv
+  vman stats --reset
+  vman stats kubectl --reset`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		jsonFormat, _ := cmd.Flags().GetBool("json")
+		reset, _ := cmd.Flags().GetBool("reset")
+		exportPath, _ := cmd.Flags().GetString("export")
+
+		homeDir, err := utils.GetHomeDir()
+		if err != nil {
+			return err
+		}
+		fs := afero.NewOsFs()
+		statsPath := filepath.Join(types.DefaultConfigPaths(homeDir).CacheDir, proxy.StatsFile)
+
+		if reset {
+			target := "所有工具"
+			if len(args) > 0 {
+				target = strings.Join(args, ", ")
+			}
+			confirmed, err := confirmAction(cmd, fmt.Sprintf("确定要清除%s的执行统计吗？", target))
+			if err != nil {
+				return err
+			}
+			if !confirmed {
+				fmt.Println("操作已取消")
+				return nil
+			}
+			if err := proxy.NewPersistentPerformanceMonitor(fs, statsPath).Reset(args...); err != nil {
+				return fmt.Errorf("重置统计失败: %w", err)
+			}
+			if len(args) == 0 {
+				PrintSuccess("已清除所有工具的执行统计", getUIOptions(cmd))
+			} else {
+				PrintSuccess(fmt.Sprintf("已清除 %s 的执行统计", strings.Join(args, ", ")), getUIOptions(cmd))
+			}
+			return nil
+		}
+
+		stats, err := proxy.LoadExecutionStatistics(fs, statsPath)
+		if err != nil {
+			return err
+		}
+		tools := filterToolStatistics(stats, args)
+
+		if exportPath != "" {
+			if err := exportStatistics(exportPath, tools); err != nil {
+				return fmt.Errorf("导出统计失败: %w", err)
+			}
+			PrintSuccess(fmt.Sprintf("已导出 %d 个工具的统计到 %s", len(tools), exportPath), getUIOptions(cmd))
+			return nil
+		}
+
+		if jsonFormat {
+			jsonData, err := json.MarshalIndent(statsRows(tools), "", "  ")
+			if err != nil {
+				return fmt.Errorf("JSON编码失败: %w", err)
+			}
+			fmt.Println(string(jsonData))
+			return nil
+		}
+
+		if len(tools) == 0 {
+			fmt.Println("没有执行统计")
+			return nil
+		}
+
+		table := NewTablePrinter([]string{"TOOL", "RUNS", "FAILED", "AVG", "P50", "P90", "P99", "MAX", "LAST RUN"}, getUIOptions(cmd))
+		for _, tool := range tools {
+			table.AddRow([]string{
+				tool.Tool,
+				strconv.Itoa(tool.ExecutionCount),
+				strconv.Itoa(tool.FailureCount),
+				formatLatency(tool.AverageDuration()),
+				formatLatency(tool.Percentile(50)),
+				formatLatency(tool.Percentile(90)),
+				formatLatency(tool.Percentile(99)),
+				formatLatency(tool.MaxDuration),
+				tool.LastExecuted.Local().Format("2006-01-02 15:04:05"),
+			})
+		}
+		table.Print()
+		fmt.Printf("\n统计开始于 %s\n", stats.Since.Local().Format("2006-01-02 15:04:05"))
+		return nil
+	},
+}
+
+// statsRow 导出的单个工具统计，耗时以毫秒表示
+type statsRow struct {
+	Tool         string         `json:"tool"`
+	Runs         int            `json:"runs"`
+	Failed       int            `json:"failed"`
+	AvgMs        float64        `json:"avg_ms"`
+	P50Ms        float64        `json:"p50_ms"`
+	P90Ms        float64        `json:"p90_ms"`
+	P99Ms        float64        `json:"p99_ms"`
+	MinMs        float64        `json:"min_ms"`
+	MaxMs        float64        `json:"max_ms"`
+	LastExecuted time.Time      `json:"last_executed"`
+	Versions     map[string]int `json:"versions,omitempty"`
+}
+
+// filterToolStatistics 返回指定工具的统计，没有指定时返回全部
+func filterToolStatistics(stats *proxy.ExecutionStatistics, tools []string) []*proxy.ToolStatistics {
+	if len(tools) == 0 {
+		return stats.Tools()
+	}
+	var filtered []*proxy.ToolStatistics
+	for _, tool := range tools {
+		if toolStats, ok := stats.ToolStats[tool]; ok {
+			filtered = append(filtered, toolStats)
+		}
+	}
+	return filtered
+}
+
+// statsRows 将统计转换为导出格式
+func statsRows(tools []*proxy.ToolStatistics) []statsRow {
+	rows := make([]statsRow, 0, len(tools))
+	for _, tool := range tools {
+		rows = append(rows, statsRow{
+			Tool:         tool.Tool,
+			Runs:         tool.ExecutionCount,
+			Failed:       tool.FailureCount,
+			AvgMs:        milliseconds(tool.AverageDuration()),
+			P50Ms:        milliseconds(tool.Percentile(50)),
+			P90Ms:        milliseconds(tool.Percentile(90)),
+			P99Ms:        milliseconds(tool.Percentile(99)),
+			MinMs:        milliseconds(tool.MinDuration),
+			MaxMs:        milliseconds(tool.MaxDuration),
+			LastExecuted: tool.LastExecuted,
+			Versions:     tool.Versions,
+		})
+	}
+	return rows
+}
+
+// exportStatistics 导出统计，.csv 文件导出为CSV，其他为JSON
+func exportStatistics(path string, tools []*proxy.ToolStatistics) error {
+	rows := statsRows(tools)
+	if !strings.EqualFold(filepath.Ext(path), ".csv") {
+		data, err := json.MarshalIndent(rows, "", "  ")
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(path, append(data, '\n'), 0644)
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	writer.Write([]string{"tool", "runs", "failed", "avg_ms", "p50_ms", "p90_ms", "p99_ms", "min_ms", "max_ms", "last_executed"})
+	for _, row := range rows {
+		writer.Write([]string{
+			row.Tool,
+			strconv.Itoa(row.Runs),
+			strconv.Itoa(row.Failed),
+			strconv.FormatFloat(row.AvgMs, 'f', -1, 64),
+			strconv.FormatFloat(row.P50Ms, 'f', -1, 64),
+			strconv.FormatFloat(row.P90Ms, 'f', -1, 64),
+			strconv.FormatFloat(row.P99Ms, 'f', -1, 64),
+			strconv.FormatFloat(row.MinMs, 'f', -1, 64),
+			strconv.FormatFloat(row.MaxMs, 'f', -1, 64),
+			row.LastExecuted.Format(time.RFC3339),
+		})
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// milliseconds 将耗时转换为毫秒，保留三位小数
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// formatLatency 格式化命令耗时，一秒以内显示为毫秒
+func formatLatency(d time.Duration) string {
+	if d < time.Second {
+		return fmt.Sprintf("%dms", d.Milliseconds())
+	}
+	return d.Round(10 * time.Millisecond).String()
+}
+
+func init() {
+	rootCmd.AddCommand(statsCmd)
+
+	statsCmd.Flags().Bool("json", false, "使用JSON格式输出")
+	statsCmd.Flags().Bool("reset", false, "清除统计，指定工具时只清除这些工具")
+	statsCmd.Flags().String("export", "", "导出统计到文件，.csv 文件导出为CSV，其他为JSON")
+}
@@ -0,0 +1,96 @@
+package cli
+
+import (
+	"context"
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/songzhibin97/vman/internal/proxy"
+)
+
+func TestPerformanceMonitor_Persistence(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	path := "/cache/" + proxy.StatsFile
+
+	monitor := proxy.NewPersistentPerformanceMonitor(fs, path)
+	for i := 0; i < 3; i++ {
+		session := monitor.StartTracking(context.Background(), "kubectl", "1.29.0")
+		monitor.EndTracking(session)
+	}
+	session := monitor.StartTracking(context.Background(), "terraform", "1.7.0")
+	session.Failed = true
+	monitor.EndTracking(session)
+
+	// 另一个进程读取到之前的统计
+	stats := proxy.NewPersistentPerformanceMonitor(fs, path).GetStatistics()
+	require.Contains(t, stats.ToolStats, "kubectl")
+	assert.Equal(t, 3, stats.ToolStats["kubectl"].ExecutionCount)
+	assert.Equal(t, 3, stats.ToolStats["kubectl"].Versions["1.29.0"])
+	assert.Equal(t, 1, stats.ToolStats["terraform"].FailureCount)
+
+	require.NoError(t, monitor.Reset("kubectl"))
+	stats = monitor.GetStatistics()
+	assert.NotContains(t, stats.ToolStats, "kubectl")
+	assert.Contains(t, stats.ToolStats, "terraform")
+
+	monitor.ClearStatistics()
+	assert.Empty(t, monitor.GetStatistics().ToolStats)
+}
+
+func TestToolStatistics_Percentile(t *testing.T) {
+	stats := proxy.NewExecutionStatistics()
+	record := func(d time.Duration) {
+		stats.Record(&proxy.TrackingSession{Tool: "kubectl", StartTime: time.Now(), Duration: d})
+	}
+	for i := 0; i < 90; i++ {
+		record(3 * time.Millisecond)
+	}
+	for i := 0; i < 9; i++ {
+		record(150 * time.Millisecond)
+	}
+	record(1500 * time.Millisecond)
+
+	tool := stats.ToolStats["kubectl"]
+	assert.Equal(t, 100, tool.ExecutionCount)
+	assert.Equal(t, 5*time.Millisecond, tool.Percentile(50))
+	assert.Equal(t, 5*time.Millisecond, tool.Percentile(90))
+	assert.Equal(t, 200*time.Millisecond, tool.Percentile(99))
+	assert.Equal(t, 1500*time.Millisecond, tool.Percentile(100))
+	assert.Equal(t, 3*time.Millisecond, tool.MinDuration)
+
+	// 区间上界超过最大耗时时以最大耗时为准
+	single := proxy.NewExecutionStatistics()
+	single.Record(&proxy.TrackingSession{Tool: "helm", StartTime: time.Now(), Duration: 12 * time.Millisecond})
+	assert.Equal(t, 12*time.Millisecond, single.ToolStats["helm"].Percentile(50))
+}
+
+func TestExportStatistics(t *testing.T) {
+	stats := proxy.NewExecutionStatistics()
+	stats.Record(&proxy.TrackingSession{Tool: "kubectl", Version: "1.29.0", StartTime: time.Now(), Duration: 1500 * time.Microsecond})
+
+	dir := t.TempDir()
+	csvPath := filepath.Join(dir, "stats.csv")
+	require.NoError(t, exportStatistics(csvPath, stats.Tools()))
+
+	file, err := os.Open(csvPath)
+	require.NoError(t, err)
+	defer file.Close()
+	records, err := csv.NewReader(file).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, "tool", records[0][0])
+	assert.Equal(t, []string{"kubectl", "1", "0", "1.5"}, records[1][:4])
+
+	jsonPath := filepath.Join(dir, "stats.json")
+	require.NoError(t, exportStatistics(jsonPath, stats.Tools()))
+	data, err := os.ReadFile(jsonPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"avg_ms": 1.5`)
+}
@@ -0,0 +1,340 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+
+	"github.com/songzhibin97/vman/internal/config"
+	"github.com/songzhibin97/vman/pkg/types"
+)
+
+// subscribeCmd 订阅工具的发布渠道
+var subscribeCmd = &cobra.Command{
+	Use:         "subscribe [tool...]",
+	Annotations: mutating(),
+	Short:       "订阅工具的新版本",
+	Long: `订阅工具的发布渠道。订阅记录在配置目录下的 subscriptions.json 中。
+
+vman outdated 检查更新时，订阅渠道中出现新版本会在标准错误输出提示，
+使用 --desktop 时同时发送桌面通知（Linux 需要 notify-send，macOS 使用 osascript）。
+vman news 列出订阅工具最近发布的版本。不指定工具时列出所有订阅。
+
+渠道:
+  stable       稳定版本（默认）
+  prerelease   包括预发布版本在内的所有版本
+  版本约束     如 ~1.29、>=1.30，只关注满足约束的版本
+
+示例:
+  vman subscribe kubectl --channel stable
+  vman subscribe terraform --channel "~1.7" --desktop
+  vman subscribe`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		channel, _ := cmd.Flags().GetString("channel")
+		desktop, _ := cmd.Flags().GetBool("desktop")
+		cmd.SilenceUsage = true
+
+		managers, err := createManagers()
+		if err != nil {
+			return fmt.Errorf("创建管理器失败: %w", err)
+		}
+		subscriptions, err := loadSubscriptions(managers.config)
+		if err != nil {
+			return err
+		}
+		if len(args) == 0 {
+			printSubscriptions(subscriptions, getUIOptions(cmd))
+			return nil
+		}
+
+		if err := config.ValidateChannel(channel); err != nil {
+			return err
+		}
+		integratedManager, err := createIntegratedManager()
+		if err != nil {
+			return fmt.Errorf("创建管理器失败: %w", err)
+		}
+		for _, tool := range args {
+			if _, err := managers.config.LoadToolConfig(tool); err != nil {
+				return fmt.Errorf("未找到工具 %s 的定义，请先使用 vman add-source 添加", tool)
+			}
+			subscription := config.Subscription{Tool: tool, Channel: channel, Desktop: desktop, SubscribedAt: time.Now()}
+			// 记录当前的最高版本，之后发布的版本才会通知
+			if available, err := integratedManager.SearchAvailableVersions(tool); err != nil {
+				PrintWarning(fmt.Sprintf("查询 %s 的远程版本失败，将在下次检查更新时记录当前版本: %v", tool, err), getUIOptions(cmd))
+			} else {
+				subscription.MarkSeen(available)
+			}
+			subscriptions.Set(subscription)
+			Infof(getUIOptions(cmd), "已订阅 %s 的 %s 渠道\n", tool, channel)
+		}
+		return subscriptions.Save(afero.NewOsFs(), subscriptionsPath(managers.config))
+	},
+}
+
+// unsubscribeCmd 取消订阅
+var unsubscribeCmd = &cobra.Command{
+	Use:         "unsubscribe <tool...>",
+	Annotations: mutating(),
+	Short:       "取消订阅工具的新版本",
+	Args:        cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+
+		managers, err := createManagers()
+		if err != nil {
+			return fmt.Errorf("创建管理器失败: %w", err)
+		}
+		subscriptions, err := loadSubscriptions(managers.config)
+		if err != nil {
+			return err
+		}
+		for _, tool := range args {
+			if !subscriptions.Remove(tool) {
+				return fmt.Errorf("未订阅 %s", tool)
+			}
+			Infof(getUIOptions(cmd), "已取消订阅 %s\n", tool)
+		}
+		return subscriptions.Save(afero.NewOsFs(), subscriptionsPath(managers.config))
+	},
+}
+
+// newsCmd 列出订阅工具最近发布的版本
+var newsCmd = &cobra.Command{
+	Use:   "news [tool...]",
+	Short: "列出订阅工具最近发布的版本",
+	Long: `从发布源查询订阅工具（vman subscribe）最近发布的版本，列出订阅渠道中的版本。
+上次通知之后发布的版本标记为 NEW，列出后不再通知。
+没有发布日期的版本只按 --limit 限制数量。
+
+示例:
+  vman news
+  vman news kubectl --since 7d
+  vman news --json`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		sinceFlag, _ := cmd.Flags().GetString("since")
+		limit, _ := cmd.Flags().GetInt("limit")
+		jsonFormat, _ := cmd.Flags().GetBool("json")
+
+		since, err := parseAge(sinceFlag)
+		if err != nil {
+			return err
+		}
+		cmd.SilenceUsage = true
+
+		managers, err := createManagers()
+		if err != nil {
+			return fmt.Errorf("创建管理器失败: %w", err)
+		}
+		integratedManager, err := createIntegratedManager()
+		if err != nil {
+			return fmt.Errorf("创建管理器失败: %w", err)
+		}
+		subscriptions, err := loadSubscriptions(managers.config)
+		if err != nil {
+			return err
+		}
+		for _, tool := range args {
+			if subscriptions.Get(tool) == nil {
+				return fmt.Errorf("未订阅 %s，使用 vman subscribe %s 订阅", tool, tool)
+			}
+		}
+		if len(subscriptions.Subscriptions) == 0 {
+			fmt.Println("没有订阅的工具，使用 vman subscribe <tool> 订阅")
+			return nil
+		}
+
+		cutoff := time.Now().Add(-since)
+		var entries []*newsEntry
+		changed := false
+		for i := range subscriptions.Subscriptions {
+			subscription := &subscriptions.Subscriptions[i]
+			if len(args) > 0 && !containsString(args, subscription.Tool) {
+				continue
+			}
+			available, err := integratedManager.SearchAvailableVersions(subscription.Tool)
+			if err != nil {
+				PrintWarning(fmt.Sprintf("查询 %s 的远程版本失败: %v", subscription.Tool, err), getUIOptions(cmd))
+				continue
+			}
+			entries = append(entries, recentReleases(subscription, available, cutoff, limit)...)
+			if subscription.MarkSeen(available) {
+				changed = true
+			}
+		}
+		if changed {
+			if err := subscriptions.Save(afero.NewOsFs(), subscriptionsPath(managers.config)); err != nil {
+				return err
+			}
+		}
+
+		if jsonFormat {
+			jsonData, err := json.MarshalIndent(entries, "", "  ")
+			if err != nil {
+				return fmt.Errorf("JSON编码失败: %w", err)
+			}
+			fmt.Println(string(jsonData))
+			return nil
+		}
+		if len(entries) == 0 {
+			fmt.Printf("最近 %s 内订阅的工具没有发布新版本\n", sinceFlag)
+			return nil
+		}
+		options := getUIOptions(cmd)
+		table := NewTablePrinter([]string{"TOOL", "VERSION", "CHANNEL", "RELEASED", ""}, options)
+		for _, entry := range entries {
+			released := "-"
+			if entry.ReleaseDate != nil {
+				released = entry.ReleaseDate.Format("2006-01-02")
+			}
+			marker := ""
+			if entry.New {
+				marker = ColorizeBold("NEW", options)
+			}
+			table.AddRow([]string{entry.Tool, entry.Version, entry.Channel, released, marker})
+		}
+		table.Print()
+		return nil
+	},
+}
+
+// newsEntry 订阅工具发布的一个版本
+type newsEntry struct {
+	Tool        string     `json:"tool"`
+	Version     string     `json:"version"`
+	Channel     string     `json:"channel"`
+	ReleaseDate *time.Time `json:"release_date,omitempty"`
+	New         bool       `json:"new"`
+	Changelog   string     `json:"changelog,omitempty"`
+}
+
+// recentReleases 返回订阅渠道中在 cutoff 之后发布的版本，每个工具最多 limit 个
+func recentReleases(subscription *config.Subscription, available []*types.VersionInfo, cutoff time.Time, limit int) []*newsEntry {
+	var entries []*newsEntry
+	for _, info := range subscription.Releases(available) {
+		if limit > 0 && len(entries) >= limit {
+			break
+		}
+		entry := &newsEntry{
+			Tool:      subscription.Tool,
+			Version:   info.Version,
+			Channel:   subscription.Channel,
+			New:       subscription.IsNew(info.Version),
+			Changelog: info.ChangeLog,
+		}
+		if released, err := time.Parse(time.RFC3339, info.ReleaseDate); err == nil {
+			if released.Before(cutoff) {
+				continue
+			}
+			entry.ReleaseDate = &released
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// notifySubscriptions 检查更新时通知订阅渠道中的新版本，remote 返回工具的远程版本
+func notifySubscriptions(configManager config.Manager, remote func(tool string) ([]*types.VersionInfo, error), options *UIOptions) {
+	subscriptions, err := loadSubscriptions(configManager)
+	if err != nil || len(subscriptions.Subscriptions) == 0 {
+		return
+	}
+
+	changed := false
+	for i := range subscriptions.Subscriptions {
+		subscription := &subscriptions.Subscriptions[i]
+		available, err := remote(subscription.Tool)
+		if err != nil {
+			continue
+		}
+		if releases := subscription.NewReleases(available); len(releases) > 0 {
+			versions := make([]string, 0, len(releases))
+			for _, release := range releases {
+				versions = append(versions, release.Version)
+			}
+			message := fmt.Sprintf("%s 的 %s 渠道发布了新版本: %s", subscription.Tool, subscription.Channel, strings.Join(versions, ", "))
+			fmt.Fprintf(os.Stderr, "%s%s\n", Emoji(EmojiInfo, options), ColorizeWarning(message, options))
+			if subscription.Desktop {
+				sendDesktopNotification("vman", message)
+			}
+		}
+		if subscription.MarkSeen(available) {
+			changed = true
+		}
+	}
+	if changed {
+		if err := subscriptions.Save(afero.NewOsFs(), subscriptionsPath(configManager)); err != nil {
+			fmt.Fprintf(os.Stderr, "保存订阅失败: %v\n", err)
+		}
+	}
+}
+
+// sendDesktopNotification 发送桌面通知，当前平台不支持时返回 false
+func sendDesktopNotification(title, message string) bool {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("osascript", "-e", fmt.Sprintf("display notification %s with title %s", strconv.Quote(message), strconv.Quote(title)))
+	case "linux", "freebsd", "openbsd", "netbsd":
+		if _, err := exec.LookPath("notify-send"); err != nil {
+			return false
+		}
+		cmd = exec.Command("notify-send", title, message)
+	default:
+		return false
+	}
+	return cmd.Run() == nil
+}
+
+// printSubscriptions 以表格列出订阅
+func printSubscriptions(subscriptions *config.Subscriptions, options *UIOptions) {
+	if len(subscriptions.Subscriptions) == 0 {
+		fmt.Println("没有订阅的工具，使用 vman subscribe <tool> 订阅")
+		return
+	}
+	table := NewTablePrinter([]string{"TOOL", "CHANNEL", "LAST SEEN", "DESKTOP", "SUBSCRIBED"}, options)
+	for _, subscription := range subscriptions.Subscriptions {
+		lastSeen := subscription.LastSeen
+		if lastSeen == "" {
+			lastSeen = "-"
+		}
+		desktop := "-"
+		if subscription.Desktop {
+			desktop = "yes"
+		}
+		table.AddRow([]string{subscription.Tool, subscription.Channel, lastSeen, desktop, subscription.SubscribedAt.Format("2006-01-02")})
+	}
+	table.Print()
+}
+
+// subscriptionsPath 订阅文件的路径
+func subscriptionsPath(configManager config.Manager) string {
+	return filepath.Join(configManager.GetConfigDir(), config.SubscriptionsFile)
+}
+
+// loadSubscriptions 读取订阅
+func loadSubscriptions(configManager config.Manager) (*config.Subscriptions, error) {
+	return config.LoadSubscriptions(afero.NewOsFs(), subscriptionsPath(configManager))
+}
+
+func init() {
+	rootCmd.AddCommand(subscribeCmd)
+	rootCmd.AddCommand(unsubscribeCmd)
+	rootCmd.AddCommand(newsCmd)
+
+	subscribeCmd.Flags().String("channel", config.ChannelStable, "发布渠道: stable、prerelease 或版本约束")
+	subscribeCmd.Flags().Bool("desktop", false, "有新版本时同时发送桌面通知")
+
+	newsCmd.Flags().String("since", "30d", "列出该时长内发布的版本，如 7d、2w")
+	newsCmd.Flags().Int("limit", 5, "每个工具最多列出的版本数")
+	newsCmd.Flags().Bool("json", false, "使用JSON格式输出")
+}
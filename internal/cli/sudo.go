@@ -0,0 +1,71 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/songzhibin97/vman/pkg/types"
+	"github.com/songzhibin97/vman/pkg/utils"
+)
+
+// checkOwnership 当前用户不是vman目录的所有者时（通常是用 sudo 运行）按 settings.root_policy 警告或拒绝执行修改操作
+// 否则新建的文件属于当前用户，之后以原用户运行时无法修改
+// 使用 --system 操作系统级共享存储时不检查
+func checkOwnership(cmd *cobra.Command, settings types.Settings) error {
+	if !isMutatingCommand(cmd) {
+		return nil
+	}
+	return checkConfigOwner(cmd, settings)
+}
+
+// checkConfigOwner 检查当前用户是否为vman目录的所有者，不判断命令是否修改
+func checkConfigOwner(cmd *cobra.Command, settings types.Settings) error {
+	if settings.RootPolicy == types.RootPolicyAllow {
+		return nil
+	}
+	if system, err := cmd.Flags().GetBool("system"); err == nil && system {
+		return nil
+	}
+
+	euid := os.Geteuid()
+	if euid < 0 {
+		return nil
+	}
+	homeDir, err := utils.GetHomeDir()
+	if err != nil {
+		return nil
+	}
+	owner, dir, ok := pathOwner(types.DefaultConfigPaths(homeDir).ConfigDir)
+	if !ok || owner == euid {
+		return nil
+	}
+
+	message := fmt.Sprintf("当前用户（UID %d）不是 %s 的所有者（UID %d），继续执行会在其中留下原用户无法修改的文件", euid, dir, owner)
+	if sudoUser := os.Getenv("SUDO_USER"); sudoUser != "" {
+		message += fmt.Sprintf("\n看起来是通过 sudo 运行的：请以 %s 身份直接运行，为所有用户安装时使用 --system", sudoUser)
+	}
+	if settings.RootPolicy == types.RootPolicyRefuse {
+		cmd.SilenceUsage = true
+		return fmt.Errorf("%s\n（settings.root_policy 为 refuse）", message)
+	}
+	PrintWarning(message, getUIOptions(cmd))
+	return nil
+}
+
+// pathOwner 返回 path 或其最近的已存在的上级目录的所有者
+func pathOwner(path string) (int, string, bool) {
+	for {
+		if info, err := os.Stat(path); err == nil {
+			owner, ok := utils.FileOwner(info)
+			return owner, path, ok
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return 0, "", false
+		}
+		path = parent
+	}
+}
@@ -0,0 +1,169 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/songzhibin97/vman/internal/config"
+	"github.com/songzhibin97/vman/pkg/utils"
+)
+
+// setupCommandSuggestions 为有子命令的命令设置未知子命令的处理：给出名称相近的命令，而不是显示帮助或只报错
+// 分组命令（vman tool 等）本身没有操作，没有参数时显示帮助
+func setupCommandSuggestions(cmd *cobra.Command) {
+	for _, child := range cmd.Commands() {
+		setupCommandSuggestions(child)
+	}
+	if !cmd.HasSubCommands() {
+		return
+	}
+
+	if !cmd.Runnable() {
+		cmd.Args = cobra.ArbitraryArgs
+		cmd.RunE = runCommandGroup
+		return
+	}
+	if validate := cmd.Args; validate != nil {
+		cmd.Args = func(cmd *cobra.Command, args []string) error {
+			if err := validate(cmd, args); err != nil {
+				if len(args) > 0 && len(suggestCommands(cmd, args[0])) > 0 {
+					cmd.SilenceUsage = true
+					return unknownCommandError(cmd, args)
+				}
+				return err
+			}
+			return nil
+		}
+	}
+}
+
+// runCommandGroup 没有参数时显示帮助，参数不是子命令时报错并给出名称相近的命令
+func runCommandGroup(cmd *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		return cmd.Help()
+	}
+	cmd.SilenceUsage = true
+	return unknownCommandError(cmd, args)
+}
+
+// unknownCommandError 未知子命令 args[0] 的错误，附带名称相近的命令
+// 相近的命令以工具名为第一个参数且 args[1] 不是已知的工具时，同时给出名称相近的工具，如 vman isntall kubctl
+func unknownCommandError(cmd *cobra.Command, args []string) error {
+	name := args[0]
+	message := fmt.Sprintf("未知命令 %q，运行 '%s --help' 查看可用命令", name, cmd.CommandPath())
+
+	suggestions := suggestCommands(cmd, name)
+	if len(suggestions) == 0 {
+		return errors.New(message)
+	}
+	tool := ""
+	if len(args) > 1 {
+		tool = suggestToolName(args[1])
+	}
+
+	message += "\n\n你是不是要执行:"
+	for _, suggestion := range suggestions {
+		line := suggestion.CommandPath()
+		if tool != "" && takesTool(suggestion) {
+			line += " " + tool
+		}
+		message += "\n  " + line
+	}
+	return errors.New(message)
+}
+
+// suggestCommands 返回 parent 下与 name 相近的命令
+// 隐藏的平铺命令按分组后的命令给出；根命令下没有相近的命令时查找分组中的子命令，如 vman clean 给出 vman cache clean
+func suggestCommands(parent *cobra.Command, name string) []*cobra.Command {
+	candidates := make(map[string][]*cobra.Command)
+	var names []string
+	add := func(cmd *cobra.Command) {
+		shown := cmd
+		if cmd.Hidden {
+			route, ok := routeOf(cmd)
+			if !ok {
+				return
+			}
+			shown = route
+		}
+		for _, candidate := range append([]string{cmd.Name()}, cmd.Aliases...) {
+			if _, ok := candidates[candidate]; !ok {
+				names = append(names, candidate)
+			}
+			candidates[candidate] = append(candidates[candidate], shown)
+		}
+	}
+
+	var groups []*cobra.Command
+	for _, child := range parent.Commands() {
+		add(child)
+		if !parent.HasParent() && child.IsAvailableCommand() && child.HasSubCommands() {
+			groups = append(groups, child)
+		}
+	}
+	if suggestions := similarCommands(name, names, candidates); len(suggestions) > 0 {
+		return suggestions
+	}
+
+	// 根命令下没有相近的命令时查找分组中的子命令
+	candidates = make(map[string][]*cobra.Command)
+	names = nil
+	for _, group := range groups {
+		for _, child := range group.Commands() {
+			add(child)
+		}
+	}
+	return similarCommands(name, names, candidates)
+}
+
+// similarCommands 按名称的相近程度返回候选命令，去掉重复的命令
+func similarCommands(name string, names []string, candidates map[string][]*cobra.Command) []*cobra.Command {
+	var suggestions []*cobra.Command
+	seen := make(map[*cobra.Command]bool)
+	for _, candidate := range utils.SuggestSimilar(name, names) {
+		for _, cmd := range candidates[candidate] {
+			if !seen[cmd] {
+				seen[cmd] = true
+				suggestions = append(suggestions, cmd)
+			}
+		}
+	}
+	return suggestions
+}
+
+// takesTool 命令的第一个参数是否为工具名
+func takesTool(cmd *cobra.Command) bool {
+	_, args, _ := strings.Cut(cmd.Use, " ")
+	return strings.HasPrefix(args, "<tool") || strings.HasPrefix(args, "[tool")
+}
+
+// suggestToolName name 不是已定义的工具或recipe时，返回名称最相近的一个，否则返回空字符串
+func suggestToolName(name string) string {
+	homeDir, err := utils.GetHomeDir()
+	if err != nil {
+		return ""
+	}
+	configManager, err := config.NewManager(homeDir)
+	if err != nil {
+		return ""
+	}
+
+	tools, _ := configManager.ListTools()
+	if recipes, err := recipeRegistry(configManager).List(); err == nil {
+		for _, r := range recipes {
+			tools = append(tools, r.Name)
+		}
+	}
+	for _, tool := range tools {
+		if tool == name {
+			return ""
+		}
+	}
+	if suggestions := utils.SuggestSimilar(name, tools); len(suggestions) > 0 {
+		return suggestions[0]
+	}
+	return ""
+}
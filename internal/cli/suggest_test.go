@@ -0,0 +1,47 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/songzhibin97/vman/pkg/types"
+)
+
+func TestUnknownCommandSuggestions(t *testing.T) {
+	t.Setenv(types.EnvVmanHome, t.TempDir())
+	setupCommandSuggestions(rootCmd)
+
+	// 隐藏的平铺命令按分组后的命令给出，同时纠正工具名
+	err := runCommandGroup(rootCmd, []string{"isntall", "kubctl"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `未知命令 "isntall"`)
+	assert.Contains(t, err.Error(), "vman version install kubectl")
+
+	// 根命令下没有相近的命令时查找分组中的子命令
+	err = runCommandGroup(rootCmd, []string{"clean"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "vman cache clean")
+
+	err = runCommandGroup(versionCmd, []string{"lsit"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "vman version list")
+	assert.NotContains(t, err.Error(), "vman tool list")
+
+	// 可执行的命令的子命令拼错时也给出提示
+	err = resetCmd.Args(resetCmd, []string{"cahce"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "vman reset cache")
+
+	err = runCommandGroup(rootCmd, []string{"xyzzy"})
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "你是不是要执行")
+}
+
+func TestTakesTool(t *testing.T) {
+	assert.True(t, takesTool(installCmd))
+	assert.True(t, takesTool(duCmd))
+	assert.False(t, takesTool(resetCacheCmd))
+	assert.False(t, takesTool(pruneCmd))
+}
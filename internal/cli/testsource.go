@@ -0,0 +1,188 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/songzhibin97/vman/internal/download"
+	"github.com/songzhibin97/vman/pkg/types"
+	"github.com/songzhibin97/vman/pkg/utils"
+)
+
+// testSourceCmd 试运行检查工具定义
+var testSourceCmd = &cobra.Command{
+	Use:   "test-source <tool|file.toml>",
+	Short: "检查工具定义能否正常使用",
+	Long: `按工具定义走一遍安装前的各个步骤，但不下载或安装任何文件：
+
+  1. 检查定义中的字段和未识别的键
+  2. 列出可用版本，确定用于检查的版本
+  3. 为每个声明的平台展开URL模板或匹配GitHub资产
+  4. 对解析出的下载地址发送 HEAD 请求
+
+参数可以是工具名（读取工具目录下的 <tool>.toml），也可以是 .toml 文件路径。
+发现错误时以非零状态退出，便于在编写工具定义时或 CI 中使用。`,
+	Example: `  # 检查已添加的工具定义
+  vman test-source terraform
+
+  # 检查尚未放入工具目录的定义文件
+  vman test-source ./mytool.toml --version 1.2.0
+
+  # 只检查部分平台，不访问下载地址
+  vman test-source kubectl --platform linux_amd64,darwin_arm64 --no-head`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		versionFlag, _ := cmd.Flags().GetString("version")
+		platforms, _ := cmd.Flags().GetStringSlice("platform")
+		noHead, _ := cmd.Flags().GetBool("no-head")
+		jsonFormat, _ := cmd.Flags().GetBool("json")
+		timeout, _ := cmd.Flags().GetDuration("timeout")
+
+		cmd.SilenceUsage = true
+
+		path, err := resolveToolDefinitionPath(args[0])
+		if err != nil {
+			return err
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("读取工具定义失败: %w", err)
+		}
+
+		metadata, findings, err := download.DecodeToolDefinition(data)
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		report := download.CheckSource(ctx, metadata, download.SourceCheckOptions{
+			Version:      versionFlag,
+			Platforms:    platforms,
+			SkipHead:     noHead,
+			VersionLimit: 10,
+		})
+		report.Findings = append(findings, report.Findings...)
+
+		if jsonFormat {
+			jsonData, err := json.MarshalIndent(report, "", "  ")
+			if err != nil {
+				return fmt.Errorf("JSON编码失败: %w", err)
+			}
+			fmt.Println(string(jsonData))
+		} else {
+			printSourceReport(path, report, getUIOptions(cmd))
+		}
+
+		if report.HasErrors() {
+			return fmt.Errorf("工具定义 %s 有 %d 个错误", path, report.Count(download.LintError))
+		}
+		return nil
+	},
+}
+
+// resolveToolDefinitionPath 将工具名或文件路径解析为工具定义文件路径
+func resolveToolDefinitionPath(arg string) (string, error) {
+	if strings.HasSuffix(arg, ".toml") || strings.ContainsRune(arg, filepath.Separator) {
+		if !utils.FileExists(arg) {
+			return "", fmt.Errorf("工具定义文件不存在: %s", arg)
+		}
+		return arg, nil
+	}
+
+	homeDir, err := utils.GetHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("获取用户主目录失败: %w", err)
+	}
+
+	path := filepath.Join(types.DefaultConfigPaths(homeDir).ToolsDir, arg+".toml")
+	if !utils.FileExists(path) {
+		return "", fmt.Errorf("没有找到 %s 的工具定义: %s", arg, path)
+	}
+	return path, nil
+}
+
+// printSourceReport 打印工具定义检查报告
+func printSourceReport(path string, report *download.SourceReport, options *UIOptions) {
+	name := report.Tool
+	if name == "" {
+		name = strings.TrimSuffix(filepath.Base(path), ".toml")
+	}
+	fmt.Printf("%s (%s)\n", ColorizeBold(name, options), path)
+	fmt.Printf("  下载类型: %s\n", report.Type)
+	if report.Template != "" {
+		fmt.Printf("  模板:     %s\n", report.Template)
+	}
+	if len(report.Versions) > 0 {
+		fmt.Printf("  可用版本: %s\n", strings.Join(report.Versions, ", "))
+	}
+	if report.Version != "" {
+		fmt.Printf("  检查版本: %s\n", report.Version)
+	}
+
+	if len(report.Platforms) > 0 {
+		fmt.Println()
+		table := NewTablePrinter([]string{"PLATFORM", "STATUS", "SIZE", "URL"}, options)
+		for _, p := range report.Platforms {
+			status := "-"
+			switch {
+			case p.Error != "" && p.Status == 0:
+				status = ColorizeError("error", options)
+			case p.Status >= 400:
+				status = ColorizeError(strconv.Itoa(p.Status), options)
+			case p.Status > 0:
+				status = ColorizeSuccess(strconv.Itoa(p.Status), options)
+			}
+			size := "-"
+			if p.Size > 0 {
+				size = formatBytes(p.Size)
+			}
+			url := p.URL
+			if url == "" {
+				url = p.Error
+			}
+			table.AddRow([]string{p.Platform, status, size, url})
+		}
+		table.Print()
+	}
+
+	fmt.Println()
+	if len(report.Findings) == 0 {
+		PrintSuccess("没有发现问题", options)
+		return
+	}
+	for _, finding := range report.Findings {
+		message := finding.String()
+		switch finding.Severity {
+		case download.LintError:
+			PrintError(message, options)
+		case download.LintWarning:
+			PrintWarning(message, options)
+		default:
+			PrintInfo(message, options)
+		}
+	}
+	fmt.Printf("共 %d 个错误，%d 个警告\n", report.Count(download.LintError), report.Count(download.LintWarning))
+}
+
+func init() {
+	rootCmd.AddCommand(testSourceCmd)
+
+	testSourceCmd.Flags().String("version", "", "用于解析下载地址的版本，默认使用最新版本")
+	testSourceCmd.Flags().StringSlice("platform", nil, "要检查的平台 (如 linux_amd64)，默认使用定义中声明的平台")
+	testSourceCmd.Flags().Bool("no-head", false, "不访问下载地址，只展开模板")
+	testSourceCmd.Flags().Bool("json", false, "使用JSON格式输出")
+	testSourceCmd.Flags().Duration("timeout", 2*time.Minute, "检查的超时时间")
+
+	regroupCommand(toolCmd, "test", testSourceCmd)
+}
@@ -0,0 +1,90 @@
+package cli
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/songzhibin97/vman/internal/download"
+)
+
+func TestDecodeToolDefinition_UnknownKeys(t *testing.T) {
+	data := `
+[tool]
+name = "demo"
+
+[download]
+type = "direct"
+
+[download.os_mapping]
+darwin = "macos"
+`
+	_, findings, err := download.DecodeToolDefinition([]byte(data))
+	require.NoError(t, err)
+
+	var keys []string
+	for _, finding := range findings {
+		assert.Equal(t, download.LintWarning, finding.Severity)
+		keys = append(keys, finding.Message)
+	}
+	assert.Len(t, keys, 2)
+	assert.Contains(t, strings.Join(keys, "\n"), "download.os_mapping")
+}
+
+func TestCheckSource_Direct(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Path, "windows") {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Length", "42")
+	}))
+	defer server.Close()
+
+	data := `
+name = "demo"
+description = "demo tool"
+homepage = "https://example.com"
+repository = "https://example.com/demo"
+platforms = ["linux_amd64", "windows_amd64", "bogus"]
+
+[download]
+type = "direct"
+url_template = "` + server.URL + `/{version}/demo_{os}_{arch}_{flavor}"
+`
+	metadata, findings, err := download.DecodeToolDefinition([]byte(data))
+	require.NoError(t, err)
+	assert.Empty(t, findings)
+
+	report := download.CheckSource(context.Background(), metadata, download.SourceCheckOptions{Version: "1.0.0"})
+	require.Len(t, report.Platforms, 2)
+
+	linux := report.Platforms[0]
+	assert.Equal(t, "linux_amd64", linux.Platform)
+	assert.Equal(t, server.URL+"/1.0.0/demo_linux_amd64_{flavor}", linux.URL)
+	assert.Equal(t, http.StatusOK, linux.Status)
+	assert.Equal(t, int64(42), linux.Size)
+	assert.Equal(t, http.StatusNotFound, report.Platforms[1].Status)
+
+	var messages []string
+	for _, finding := range report.Findings {
+		if finding.Severity == download.LintError {
+			messages = append(messages, finding.String())
+		}
+	}
+	all := strings.Join(messages, "\n")
+	assert.Contains(t, all, "{flavor}")
+	assert.Contains(t, all, `"bogus"`)
+	assert.Contains(t, all, "windows_amd64")
+	assert.Equal(t, 3, report.Count(download.LintError))
+
+	// 不指定版本且无法列出版本时报告错误
+	report = download.CheckSource(context.Background(), metadata, download.SourceCheckOptions{SkipHead: true})
+	assert.True(t, report.HasErrors())
+	assert.Empty(t, report.Platforms)
+}
@@ -2,11 +2,14 @@ package cli
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/spf13/cobra"
+
 	"github.com/songzhibin97/vman/pkg/types"
 )
 
@@ -46,8 +49,31 @@ type UIOptions struct {
 	NoEmoji     bool
 	Verbose     bool
 	Interactive bool
+	// Quiet 不输出进度和提示信息，只保留警告、错误和命令的结果
+	Quiet bool
+}
+
+// getUIOptions 从命令行标志获取UI选项
+// 指定 --yes 或标准输入不是终端时为非交互模式，不会询问用户
+func getUIOptions(cmd *cobra.Command) *UIOptions {
+	noColor, _ := cmd.Flags().GetBool("no-color")
+	noEmoji, _ := cmd.Flags().GetBool("no-emoji")
+	verbose, _ := cmd.Flags().GetBool("verbose")
+	quiet, _ := cmd.Flags().GetBool("quiet")
+	yes, _ := cmd.Flags().GetBool("yes")
+
+	return &UIOptions{
+		NoColor:     noColor,
+		NoEmoji:     noEmoji,
+		Verbose:     verbose,
+		Interactive: !yes && stdinInteractive(),
+		Quiet:       quiet,
+	}
 }
 
+// stdinInteractive 标准输入是否可以询问用户，测试中可以替换
+var stdinInteractive = stdinIsTerminal
+
 // ColorSupport 检查终端是否支持颜色
 func ColorSupport() bool {
 	term := os.Getenv("TERM")
@@ -115,8 +141,19 @@ func Emoji(emoji string, options *UIOptions) string {
 	return emoji + " "
 }
 
-// PrintSuccess 打印成功消息
+// Infof 打印进度和提示信息，静默模式下不输出
+func Infof(options *UIOptions, format string, args ...interface{}) {
+	if options != nil && options.Quiet {
+		return
+	}
+	fmt.Printf(format, args...)
+}
+
+// PrintSuccess 打印成功消息，静默模式下不输出
 func PrintSuccess(message string, options *UIOptions) {
+	if options != nil && options.Quiet {
+		return
+	}
 	emoji := Emoji(EmojiCheckMark, options)
 	colored := ColorizeSuccess(message, options)
 	fmt.Printf("%s%s\n", emoji, colored)
@@ -136,8 +173,11 @@ func PrintWarning(message string, options *UIOptions) {
 	fmt.Printf("%s%s\n", emoji, colored)
 }
 
-// PrintInfo 打印信息消息
+// PrintInfo 打印信息消息，静默模式下不输出
 func PrintInfo(message string, options *UIOptions) {
+	if options != nil && options.Quiet {
+		return
+	}
 	emoji := Emoji(EmojiInfo, options)
 	colored := ColorizeInfo(message, options)
 	fmt.Printf("%s%s\n", emoji, colored)
@@ -414,6 +454,11 @@ func (tp *TablePrinter) AddRow(row []string) {
 
 // Print 打印表格
 func (tp *TablePrinter) Print() {
+	tp.PrintTo(os.Stdout)
+}
+
+// PrintTo 将表格输出到 w
+func (tp *TablePrinter) PrintTo(w io.Writer) {
 	if len(tp.headers) == 0 {
 		return
 	}
@@ -421,39 +466,62 @@ func (tp *TablePrinter) Print() {
 	// 计算列宽
 	colWidths := make([]int, len(tp.headers))
 	for i, header := range tp.headers {
-		colWidths[i] = len(header)
+		colWidths[i] = displayWidth(header)
 	}
 
 	for _, row := range tp.rows {
 		for i, cell := range row {
-			if i < len(colWidths) && len(cell) > colWidths[i] {
-				colWidths[i] = len(cell)
+			if i < len(colWidths) && displayWidth(cell) > colWidths[i] {
+				colWidths[i] = displayWidth(cell)
 			}
 		}
 	}
 
 	// 打印表头
 	for i, header := range tp.headers {
-		colored := ColorizeBold(header, tp.options)
-		fmt.Printf("%-*s", colWidths[i]+2, colored)
+		fmt.Fprint(w, ColorizeBold(header, tp.options)+padding(header, colWidths[i]+2))
 	}
-	fmt.Println()
+	fmt.Fprintln(w)
 
 	// 打印分割线
 	for i := range tp.headers {
-		fmt.Printf("%-*s", colWidths[i]+2, strings.Repeat("-", colWidths[i]))
+		fmt.Fprintf(w, "%-*s", colWidths[i]+2, strings.Repeat("-", colWidths[i]))
 	}
-	fmt.Println()
+	fmt.Fprintln(w)
 
 	// 打印行
 	for _, row := range tp.rows {
 		for i, cell := range row {
 			if i < len(colWidths) {
-				fmt.Printf("%-*s", colWidths[i]+2, cell)
+				fmt.Fprint(w, cell+padding(cell, colWidths[i]+2))
 			}
 		}
-		fmt.Println()
+		fmt.Fprintln(w)
+	}
+}
+
+// displayWidth 计算文本在终端中的显示宽度（中日韩字符占两列）
+func displayWidth(text string) int {
+	width := 0
+	for _, r := range text {
+		if r >= 0x1100 && (r <= 0x115f || (r >= 0x2e80 && r <= 0xa4cf) ||
+			(r >= 0xac00 && r <= 0xd7a3) || (r >= 0xf900 && r <= 0xfaff) ||
+			(r >= 0xfe30 && r <= 0xfe4f) || (r >= 0xff00 && r <= 0xff60) ||
+			(r >= 0xffe0 && r <= 0xffe6)) {
+			width += 2
+		} else {
+			width++
+		}
+	}
+	return width
+}
+
+// padding 返回将文本补齐到指定宽度所需的空格
+func padding(text string, width int) string {
+	if n := width - displayWidth(text); n > 0 {
+		return strings.Repeat(" ", n)
 	}
+	return ""
 }
 
 // ShowBanner 显示横幅
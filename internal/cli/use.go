@@ -4,25 +4,26 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 
+	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
 
+	"github.com/songzhibin97/vman/internal/proxy"
+	"github.com/songzhibin97/vman/internal/version"
+	"github.com/songzhibin97/vman/pkg/types"
 	"github.com/songzhibin97/vman/pkg/utils"
 )
 
 // useCmd 快速切换工具版本命令
 var useCmd = &cobra.Command{
-	Use:   "use <tool> <version>",
-	Short: "切换工具版本",
-	Long: `快速切换工具版本。支持全局切换和本地项目切换。
-
-示例:
-  vman use kubectl 1.29.0        # 在当前项目中使用kubectl 1.29.0
-  vman use kubectl 1.29.0 -g     # 全局切换到kubectl 1.29.0
-  vman use terraform latest      # 使用最新版本
-  vman use terraform system      # 使用系统版本`,
-	Args: cobra.ExactArgs(2),
+	Use:         "use <tool> <version>",
+	Annotations: mutating(),
+	Short:       "切换工具版本",
+	Long:        `快速切换工具版本。支持全局切换和本地项目切换。`,
+	Args:        cobra.ExactArgs(2),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		tool := args[0]
 		version := args[1]
@@ -43,19 +44,31 @@ var useCmd = &cobra.Command{
 		}
 
 		// 检查版本是否已安装
-		if resolvedVersion != "system" && !managers.version.IsVersionInstalled(tool, resolvedVersion) {
+		if resolvedVersion == types.SystemVersion {
+			systemPath, err := proxy.NewVersionResolver(managers.config, managers.version).FindSystemExecutable(tool)
+			if err != nil {
+				fmt.Printf("警告: PATH中没有找到系统自带的 %s，执行时将会失败\n", tool)
+			} else {
+				fmt.Printf("将使用系统中的 %s\n", systemPath)
+			}
+		} else if !managers.version.IsVersionInstalled(tool, resolvedVersion) {
 			return fmt.Errorf("版本 %s@%s 未安装。请先运行: vman install %s %s", tool, resolvedVersion, tool, resolvedVersion)
 		}
 
+		if err := checkDeprecatedVersion(managers, tool, resolvedVersion, getUIOptions(cmd)); err != nil {
+			cmd.SilenceUsage = true
+			return err
+		}
+
 		if global {
 			// 全局切换
-			if err := managers.version.SetGlobalVersion(tool, resolvedVersion); err != nil {
+			if err := setGlobalVersionWithHistory(managers.version, managers.config, tool, resolvedVersion); err != nil {
 				return fmt.Errorf("设置全局版本失败: %w", err)
 			}
 			fmt.Printf("✅ 成功设置 %s@%s 为全局版本\n", tool, resolvedVersion)
 		} else {
 			// 本地项目切换
-			if err := setLocalVersion(tool, resolvedVersion); err != nil {
+			if err := setLocalVersion(managers, tool, resolvedVersion); err != nil {
 				return fmt.Errorf("设置本地版本失败: %w", err)
 			}
 			fmt.Printf("✅ 成功设置 %s@%s 为当前项目版本\n", tool, resolvedVersion)
@@ -85,9 +98,9 @@ func resolveVersion(tool, version string, managers *managers) (string, error) {
 		// 返回第一个版本（通常是最新的）
 		return versions[0], nil
 
-	case "system":
-		// 使用系统版本
-		return "system", nil
+	case types.SystemVersion:
+		// 使用系统版本，执行时跳过shims目录查找PATH中的同名工具
+		return types.SystemVersion, nil
 
 	default:
 		// 直接返回指定版本
@@ -95,8 +108,28 @@ func resolveVersion(tool, version string, managers *managers) (string, error) {
 	}
 }
 
-// setLocalVersion 设置本地项目版本
-func setLocalVersion(tool, version string) error {
+// checkDeprecatedVersion 切换到工具定义中弃用的版本时提示，settings.resolution.deprecated 为 error 时拒绝
+func checkDeprecatedVersion(managers *managers, tool, versionStr string, options *UIOptions) error {
+	metadata, err := managers.config.LoadToolConfig(tool)
+	if err != nil {
+		return nil
+	}
+	policy := types.DeprecationWarn
+	if globalConfig, err := managers.config.LoadGlobal(); err == nil {
+		policy = globalConfig.Settings.Resolution.DeprecationPolicy()
+	}
+	warning, err := proxy.CheckDeprecation(afero.NewOsFs(), "", metadata, versionStr, policy, time.Now())
+	if err != nil {
+		return err
+	}
+	if warning != "" {
+		PrintWarning(warning, options)
+	}
+	return nil
+}
+
+// setLocalVersion 在项目根目录的 .vman-version 中设置版本并记录切换历史
+func setLocalVersion(managers *managers, tool, versionStr string) error {
 	// 查找项目根目录
 	projectRoot, err := findProjectRoot()
 	if err != nil {
@@ -106,38 +139,70 @@ func setLocalVersion(tool, version string) error {
 
 	// 读取现有的 .vman-version 文件
 	versionFile := filepath.Join(projectRoot, ".vman-version")
+	versions, err := readVersionFile(versionFile)
+	if err != nil {
+		return err
+	}
+
+	// 更新版本
+	old := versions[tool]
+	versions[tool] = versionStr
+
+	if err := writeVersionFile(versionFile, versions); err != nil {
+		return err
+	}
+
+	recordVersionSwitch(managers.config, &version.HistoryEntry{
+		Tool:  tool,
+		Scope: version.HistoryScopeProject,
+		Path:  versionFile,
+		Old:   old,
+		New:   versionStr,
+	})
+	return nil
+}
+
+// readVersionFile 读取 .vman-version 文件中的工具版本，文件不存在时返回空映射
+func readVersionFile(versionFile string) (map[string]string, error) {
 	versions := make(map[string]string)
+	if !utils.FileExists(versionFile) {
+		return versions, nil
+	}
 
-	if utils.FileExists(versionFile) {
-		content, err := os.ReadFile(versionFile)
-		if err != nil {
-			return fmt.Errorf("读取版本文件失败: %w", err)
-		}
+	content, err := os.ReadFile(versionFile)
+	if err != nil {
+		return nil, fmt.Errorf("读取版本文件失败: %w", err)
+	}
 
-		// 解析现有版本
-		lines := strings.Split(string(content), "\n")
-		for _, line := range lines {
-			line = strings.TrimSpace(line)
-			if line == "" || strings.HasPrefix(line, "#") {
-				continue
-			}
-			parts := strings.Fields(line)
-			if len(parts) == 2 {
-				versions[parts[0]] = parts[1]
-			}
+	// 解析现有版本
+	lines := strings.Split(string(content), "\n")
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := strings.Fields(line)
+		if len(parts) == 2 {
+			versions[parts[0]] = parts[1]
 		}
 	}
+	return versions, nil
+}
 
-	// 更新版本
-	versions[tool] = version
+// writeVersionFile 将工具版本写入 .vman-version 文件
+func writeVersionFile(versionFile string, versions map[string]string) error {
+	tools := make([]string, 0, len(versions))
+	for t := range versions {
+		tools = append(tools, t)
+	}
+	sort.Strings(tools)
 
-	// 写入文件
 	var content strings.Builder
 	content.WriteString("# vman版本配置文件\n")
 	content.WriteString("# 格式: <工具名> <版本>\n\n")
 
-	for t, v := range versions {
-		content.WriteString(fmt.Sprintf("%s %s\n", t, v))
+	for _, t := range tools {
+		content.WriteString(fmt.Sprintf("%s %s\n", t, versions[t]))
 	}
 
 	if err := os.WriteFile(versionFile, []byte(content.String()), 0644); err != nil {
@@ -148,43 +213,38 @@ func setLocalVersion(tool, version string) error {
 }
 
 // findProjectRoot 查找项目根目录
+// 优先使用最近的包含 .vman.yaml 的目录，避免 monorepo 子项目的版本写到仓库根目录；
+// 没有 .vman.yaml 时使用与代理相同的检测逻辑，正确处理符号链接进入的目录和git worktree
 func findProjectRoot() (string, error) {
 	currentDir, err := os.Getwd()
 	if err != nil {
 		return "", err
 	}
+	return findProjectRootFrom(currentDir)
+}
 
-	// 向上查找项目标识文件
-	projectFiles := []string{
-		".git",
-		".vman-version",
-		".tool-versions",
-		"package.json",
-		"go.mod",
-		"Cargo.toml",
-		"pom.xml",
-		"build.gradle",
-		"requirements.txt",
-		"Pipfile",
+// findProjectRootFrom 从 startDir 向上查找项目根目录
+func findProjectRootFrom(startDir string) (string, error) {
+	dir := startDir
+	if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+		dir = resolved
 	}
-
-	dir := currentDir
 	for {
-		for _, file := range projectFiles {
-			if utils.FileExists(filepath.Join(dir, file)) {
-				return dir, nil
-			}
+		if utils.FileExists(filepath.Join(dir, ".vman.yaml")) {
+			return dir, nil
 		}
-
 		parent := filepath.Dir(dir)
 		if parent == dir {
-			// 到达根目录
 			break
 		}
 		dir = parent
 	}
 
-	return "", fmt.Errorf("未找到项目根目录")
+	root, err := proxy.NewContextManager(nil).FindProjectRoot(startDir)
+	if err != nil {
+		return "", fmt.Errorf("未找到项目根目录")
+	}
+	return root, nil
 }
 
 // regenerateShims 重新生成垫片
@@ -204,4 +264,6 @@ func init() {
 
 	// 添加选项
 	useCmd.Flags().BoolP("global", "g", false, "设置为全局版本（而非项目本地版本）")
+
+	regroupCommand(versionCmd, "use", useCmd)
 }
@@ -0,0 +1,35 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindProjectRootFrom(t *testing.T) {
+	repo, err := filepath.EvalSymlinks(t.TempDir())
+	require.NoError(t, err)
+	service := filepath.Join(repo, "services", "api")
+	module := filepath.Join(service, "internal", "worker")
+	require.NoError(t, os.MkdirAll(filepath.Join(repo, ".git"), 0755))
+	require.NoError(t, os.MkdirAll(module, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(module, "go.mod"), []byte("module worker\n"), 0644))
+
+	// 没有 .vman.yaml 时使用最近的项目标识
+	root, err := findProjectRootFrom(module)
+	require.NoError(t, err)
+	assert.Equal(t, module, root)
+
+	// 最近的 .vman.yaml 优先于更近的其他标识
+	require.NoError(t, os.WriteFile(filepath.Join(service, ".vman.yaml"), []byte("tools: {}\n"), 0644))
+	root, err = findProjectRootFrom(module)
+	require.NoError(t, err)
+	assert.Equal(t, service, root)
+
+	root, err = findProjectRootFrom(filepath.Join(repo, "services"))
+	require.NoError(t, err)
+	assert.Equal(t, repo, root)
+}
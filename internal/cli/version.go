@@ -1,13 +1,17 @@
 package cli
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"sort"
 	"strings"
 
+	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
 
 	"github.com/songzhibin97/vman/internal/config"
+	"github.com/songzhibin97/vman/internal/proxy"
 	"github.com/songzhibin97/vman/internal/storage"
 	"github.com/songzhibin97/vman/internal/version"
 	"github.com/songzhibin97/vman/pkg/types"
@@ -24,11 +28,22 @@ func init() {
 	rootCmd.AddCommand(localCmd)
 	rootCmd.AddCommand(uninstallCmd)
 	rootCmd.AddCommand(whichCmd)
+
+	whichCmd.Flags().Bool("explain", false, "显示版本来源以及项目配置中的默认参数和环境变量")
+
+	regroupCommand(versionCmd, "register", registerCmd)
+	regroupCommand(versionCmd, "current", currentCmd)
+	regroupCommand(versionCmd, "global", globalCmd)
+	regroupCommand(versionCmd, "local", localCmd)
+	regroupCommand(versionCmd, "which", whichCmd)
+	// uninstall 是 vman version remove 的别名
+	uninstallCmd.Hidden = true
 }
 
 var registerCmd = &cobra.Command{
-	Use:   "register <tool> <version> <binary_path>",
-	Short: "手动注册工具版本",
+	Use:         "register <tool> <version> <binary_path>",
+	Annotations: mutating(),
+	Short:       "手动注册工具版本",
 	Long: `手动注册一个工具版本，将指定的二进制文件注册到vman中。
 
 示例:
@@ -56,6 +71,12 @@ var registerCmd = &cobra.Command{
 			return fmt.Errorf("failed to create managers: %w", err)
 		}
 
+		// 工具的垫片不能覆盖其他工具、别名或vman子命令
+		if err := checkToolShimName(tool); err != nil {
+			cmd.SilenceUsage = true
+			return err
+		}
+
 		// 注册版本
 		if err := managers.version.RegisterVersion(tool, versionStr, binaryPath); err != nil {
 			return fmt.Errorf("failed to register version: %w", err)
@@ -66,88 +87,11 @@ var registerCmd = &cobra.Command{
 	},
 }
 
-var listCmd = &cobra.Command{
-	Use:   "list [tool]",
-	Short: "列出工具版本",
-	Long: `列出已安装的工具版本。如果指定了工具名，则列出该工具的所有版本；否则列出所有工具。
-
-示例:
-  vman list              # 列出所有工具
-  vman list kubectl      # 列出kubectl的所有版本`,
-	Args: cobra.MaximumNArgs(1),
-	RunE: func(cmd *cobra.Command, args []string) error {
-		managers, err := createManagers()
-		if err != nil {
-			return fmt.Errorf("failed to create managers: %w", err)
-		}
-
-		if len(args) == 1 {
-			// 列出指定工具的版本
-			tool := args[0]
-			versions, err := managers.version.ListVersions(tool)
-			if err != nil {
-				return fmt.Errorf("failed to list versions for %s: %w", tool, err)
-			}
-
-			if len(versions) == 0 {
-				fmt.Printf("No versions installed for %s\n", tool)
-				return nil
-			}
-
-			// 获取当前版本
-			currentVersion, _ := managers.version.GetCurrentVersion(tool)
-
-			fmt.Printf("Installed versions for %s:\n", tool)
-			for _, v := range versions {
-				marker := "  "
-				if v == currentVersion {
-					marker = "* "
-				}
-				fmt.Printf("%s%s\n", marker, v)
-			}
-		} else {
-			// 列出所有工具
-			tools, err := managers.version.ListAllTools()
-			if err != nil {
-				return fmt.Errorf("failed to list tools: %w", err)
-			}
-
-			if len(tools) == 0 {
-				fmt.Println("No tools installed")
-				return nil
-			}
-
-			fmt.Println("Installed tools:")
-			for _, tool := range tools {
-				versions, err := managers.version.ListVersions(tool)
-				if err != nil {
-					fmt.Printf("  %s: <error getting versions>\n", tool)
-					continue
-				}
-
-				currentVersion, _ := managers.version.GetCurrentVersion(tool)
-				versionStr := strings.Join(versions, ", ")
-				if currentVersion != "" {
-					fmt.Printf("  %s: %s (current: %s)\n", tool, versionStr, currentVersion)
-				} else {
-					fmt.Printf("  %s: %s\n", tool, versionStr)
-				}
-			}
-		}
-
-		return nil
-	},
-}
-
 var currentCmd = &cobra.Command{
 	Use:   "current [tool]",
 	Short: "显示当前使用的版本",
-	Long: `显示当前使用的工具版本。如果指定了工具名，则显示该工具的当前版本；否则显示所有工具的当前版本。
-
-示例:
-  vman current           # 显示所有工具的当前版本
-  vman current kubectl   # 显示kubectl的当前版本`,
-	Args: cobra.MaximumNArgs(1),
+	Long:  `显示当前使用的工具版本。如果指定了工具名，则显示该工具的当前版本；否则显示所有工具的当前版本。`,
+	Args:  cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		managers, err := createManagers()
 		if err != nil {
@@ -191,8 +135,9 @@ var currentCmd = &cobra.Command{
 }
 
 var globalCmd = &cobra.Command{
-	Use:   "global <tool> <version>",
-	Short: "设置工具的全局版本",
+	Use:         "global <tool> <version>",
+	Annotations: mutating(),
+	Short:       "设置工具的全局版本",
 	Long: `设置工具的全局默认版本。
 
 示例:
@@ -208,7 +153,7 @@ var globalCmd = &cobra.Command{
 			return fmt.Errorf("failed to create managers: %w", err)
 		}
 
-		if err := managers.version.SetGlobalVersion(tool, versionStr); err != nil {
+		if err := setGlobalVersionWithHistory(managers.version, managers.config, tool, versionStr); err != nil {
 			return fmt.Errorf("failed to set global version: %w", err)
 		}
 
@@ -218,8 +163,9 @@ var globalCmd = &cobra.Command{
 }
 
 var localCmd = &cobra.Command{
-	Use:   "local <tool> <version>",
-	Short: "设置工具的项目级版本",
+	Use:         "local <tool> <version>",
+	Annotations: mutating(),
+	Short:       "设置工具的项目级版本",
 	Long: `在当前目录设置工具的项目级版本。项目级版本优先于全局版本。
 
 示例:
@@ -235,19 +181,23 @@ var localCmd = &cobra.Command{
 			return fmt.Errorf("failed to create managers: %w", err)
 		}
 
-		if err := managers.version.SetLocalVersion(tool, versionStr); err != nil {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get current directory: %w", err)
+		}
+		if err := setProjectVersionWithHistory(managers, tool, versionStr, cwd); err != nil {
 			return fmt.Errorf("failed to set local version: %w", err)
 		}
 
-		cwd, _ := os.Getwd()
 		fmt.Printf("Set local version for %s to %s in %s\n", tool, versionStr, cwd)
 		return nil
 	},
 }
 
 var uninstallCmd = &cobra.Command{
-	Use:   "uninstall <tool> <version>",
-	Short: "卸载工具版本",
+	Use:         "uninstall <tool> <version>",
+	Annotations: mutating(),
+	Short:       "卸载工具版本",
 	Long: `卸载指定的工具版本。
 
 示例:
@@ -277,9 +227,13 @@ var whichCmd = &cobra.Command{
 	Short: "显示工具的当前二进制文件路径",
 	Long: `显示工具当前版本的二进制文件路径。
 
+使用 --explain 时按当前目录解析版本，并显示版本来源以及通过代理执行时
+项目配置注入的默认参数和环境变量。
+
 示例:
   vman which kubectl
-  vman which terraform`,
+  vman which terraform
+  vman which terraform --explain`,
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		tool := args[0]
@@ -289,6 +243,11 @@ var whichCmd = &cobra.Command{
 			return fmt.Errorf("failed to create managers: %w", err)
 		}
 
+		if explain, _ := cmd.Flags().GetBool("explain"); explain {
+			cmd.SilenceUsage = true
+			return explainWhich(managers, tool)
+		}
+
 		// 获取当前版本
 		version, err := managers.version.GetCurrentVersion(tool)
 		if err != nil {
@@ -306,6 +265,53 @@ var whichCmd = &cobra.Command{
 	},
 }
 
+// explainWhich 显示工具在当前目录解析到的可执行文件、版本来源和项目配置中的默认值
+func explainWhich(managers *managers, tool string) error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	resolver := proxy.NewVersionResolver(managers.config, managers.version)
+	resolution, err := resolver.ResolveVersion(context.Background(), tool, cwd)
+	if err != nil {
+		return fmt.Errorf("failed to resolve version for %s: %w", tool, err)
+	}
+
+	binaryPath := resolution.SystemPath
+	if resolution.Version != types.SystemVersion {
+		binaryPath = managers.storage.GetBinaryPath(tool, resolution.Version)
+	}
+	fmt.Println(binaryPath)
+	fmt.Printf("版本: %s (来源: %s)\n", resolution.Version, resolutionOrigin(resolution))
+
+	if types.DefaultArgsDisabled() {
+		fmt.Printf("默认参数: 已通过 %s 禁用\n", types.EnvVmanNoDefaultArgs)
+		return nil
+	}
+	defaults, configPath := proxy.ProjectDefaults(afero.NewOsFs(), managers.config, tool, cwd)
+	if defaults.IsEmpty() && len(defaults.Denied) == 0 {
+		fmt.Println("默认参数: 无")
+		return nil
+	}
+	fmt.Printf("默认值来自: %s\n", configPath)
+	if len(defaults.Args) > 0 {
+		fmt.Printf("默认参数: %s\n", strings.Join(defaults.Args, " "))
+	}
+	keys := make([]string, 0, len(defaults.Env))
+	for key := range defaults.Env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Printf("环境变量: %s=%s\n", key, defaults.Env[key])
+	}
+	for _, key := range defaults.Denied {
+		fmt.Printf("环境变量: %s 已忽略（项目配置不能设置在执行时加载代码的变量）\n", key)
+	}
+	return nil
+}
+
 // managers 结构体用于管理各种管理器
 type managers struct {
 	version version.Manager
@@ -315,6 +321,11 @@ type managers struct {
 
 // createManagers 创建管理器实例
 func createManagers() (*managers, error) {
+	return createManagersForStore(false)
+}
+
+// createManagersForStore 创建管理器，system 为 true 时直接操作系统级共享存储
+func createManagersForStore(system bool) (*managers, error) {
 	// 获取配置目录
 	homeDir, err := utils.GetHomeDir()
 	if err != nil {
@@ -329,14 +340,13 @@ func createManagers() (*managers, error) {
 		return nil, fmt.Errorf("failed to create config manager: %w", err)
 	}
 
-	// 创建存储管理器
-	storageManager := storage.NewFilesystemManager(configPaths)
-
-	// 创建版本管理器
-	versionManager := version.NewManager(storageManager, configManager)
+	// 升级旧版本的存储目录结构
+	if _, err := storage.NewLayoutMigrator(configPaths).Migrate(false); err != nil {
+		return nil, fmt.Errorf("failed to migrate storage layout: %w", err)
+	}
 
 	// 确保目录存在
-	if err := storageManager.EnsureDirectories(); err != nil {
+	if err := storage.NewFilesystemManager(configPaths).EnsureDirectories(); err != nil {
 		return nil, fmt.Errorf("failed to ensure directories: %w", err)
 	}
 
@@ -345,9 +355,55 @@ func createManagers() (*managers, error) {
 		return nil, fmt.Errorf("failed to initialize config: %w", err)
 	}
 
+	// 创建存储管理器，配置了系统级存储时叠加在用户存储之后
+	storageManager, err := createStorageManager(configManager, configPaths, system)
+	if err != nil {
+		return nil, err
+	}
+
+	// 创建版本管理器
+	versionManager := version.NewManager(storageManager, configManager)
+
 	return &managers{
 		version: versionManager,
 		config:  configManager,
 		storage: storageManager,
 	}, nil
 }
+
+// createStorageManager 根据系统级存储设置创建存储管理器
+func createStorageManager(configManager config.Manager, configPaths *types.ConfigPaths, system bool) (storage.Manager, error) {
+	var systemRoot string
+	if globalConfig, err := configManager.LoadGlobal(); err == nil {
+		systemRoot = globalConfig.Settings.System.GetRoot()
+	} else {
+		systemRoot = types.SystemSettings{}.GetRoot()
+	}
+
+	if !system {
+		if systemRoot == "" {
+			return storage.NewFilesystemManager(configPaths), nil
+		}
+		return storage.NewFilesystemManagerWithSystemStore(afero.NewOsFs(), configPaths, types.ConfigPathsFromRoot(systemRoot)), nil
+	}
+
+	if systemRoot == "" {
+		return nil, fmt.Errorf("system store is not configured, set settings.system.root or %s", types.EnvVmanSystemRoot)
+	}
+
+	// 只有版本目录位于系统级存储，下载源、缓存和临时文件仍使用用户目录
+	systemPaths := *configPaths
+	systemPaths.VersionsDir = types.ConfigPathsFromRoot(systemRoot).VersionsDir
+	if err := storage.CheckWritable(afero.NewOsFs(), systemPaths.VersionsDir); err != nil {
+		return nil, fmt.Errorf("cannot install into system store %s (run as an administrator, e.g. with sudo): %w", systemRoot, err)
+	}
+
+	// 系统级存储有独立的目录结构版本标记，在具有写权限时一并迁移
+	if _, err := storage.NewLayoutMigrator(types.ConfigPathsFromRoot(systemRoot)).Migrate(false); err != nil {
+		return nil, fmt.Errorf("failed to migrate system store layout: %w", err)
+	}
+
+	// 用户存储只在列出版本时叠加，使配置中的已安装版本记录仍包含用户安装的版本；
+	// 是否已安装只检查系统存储，用户已安装同一版本时仍安装到系统存储
+	return storage.NewFilesystemManagerForSystemInstall(afero.NewOsFs(), &systemPaths, configPaths), nil
+}
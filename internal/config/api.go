@@ -5,10 +5,13 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/songzhibin97/vman/pkg/types"
+	"github.com/songzhibin97/vman/pkg/utils"
 	"github.com/spf13/afero"
 )
 
@@ -16,9 +19,14 @@ import (
 type API interface {
 	// 初始化相关
 	Init(ctx context.Context) error
-	Reset(ctx context.Context) error
-	Backup(ctx context.Context, backupPath string) error
-	Restore(ctx context.Context, backupPath string) error
+	ResetTargets(scope ResetScope, tools ...string) ([]string, error)
+	Reset(ctx context.Context, scope ResetScope, tools ...string) (string, error)
+	Backup(ctx context.Context, backupPath string, options *BackupOptions) (*BackupManifest, error)
+	Restore(ctx context.Context, backupPath string, options *RestoreOptions) (*RestoreReport, error)
+	LoadBackupManifest(backupPath string) (*BackupManifest, error)
+	ListBackups() ([]*BackupEntry, error)
+	AutoBackup(ctx context.Context, reason string) (string, error)
+	AutoBackupDue(now time.Time) (bool, error)
 
 	// 全局配置管理
 	GetGlobalConfig(ctx context.Context) (*types.GlobalConfig, error)
@@ -99,99 +107,6 @@ func (api *DefaultAPI) Init(ctx context.Context) error {
 	return nil
 }
 
-// Reset 重置配置
-func (api *DefaultAPI) Reset(ctx context.Context) error {
-	api.logger.Warn("Resetting vman configuration")
-
-	// 备份现有配置
-	backupPath := filepath.Join(api.paths.ConfigDir, fmt.Sprintf("backup_%d", time.Now().Unix()))
-	if err := api.Backup(ctx, backupPath); err != nil {
-		api.logger.Warnf("Failed to backup configuration before reset: %v", err)
-	}
-
-	// 删除配置目录
-	if err := api.fs.RemoveAll(api.paths.ConfigDir); err != nil {
-		return fmt.Errorf("failed to remove config directory: %w", err)
-	}
-
-	// 重新初始化
-	if err := api.Init(ctx); err != nil {
-		return fmt.Errorf("failed to reinitialize after reset: %w", err)
-	}
-
-	api.logger.Info("Configuration reset completed")
-	return nil
-}
-
-// Backup 备份配置
-func (api *DefaultAPI) Backup(ctx context.Context, backupPath string) error {
-	api.logger.Infof("Backing up configuration to: %s", backupPath)
-
-	// 创建备份目录
-	if err := api.fs.MkdirAll(backupPath, 0755); err != nil {
-		return fmt.Errorf("failed to create backup directory: %w", err)
-	}
-
-	// 复制配置文件
-	files := []string{
-		api.paths.GlobalConfigFile,
-	}
-
-	for _, file := range files {
-		if _, err := api.fs.Stat(file); err == nil {
-			destPath := filepath.Join(backupPath, filepath.Base(file))
-			if err := api.copyFile(file, destPath); err != nil {
-				return fmt.Errorf("failed to backup file %s: %w", file, err)
-			}
-		}
-	}
-
-	// 复制工具配置目录
-	if _, err := api.fs.Stat(api.paths.ToolsDir); err == nil {
-		destToolsDir := filepath.Join(backupPath, "tools")
-		if err := api.copyDir(api.paths.ToolsDir, destToolsDir); err != nil {
-			return fmt.Errorf("failed to backup tools directory: %w", err)
-		}
-	}
-
-	api.logger.Info("Configuration backup completed")
-	return nil
-}
-
-// Restore 恢复配置
-func (api *DefaultAPI) Restore(ctx context.Context, backupPath string) error {
-	api.logger.Infof("Restoring configuration from: %s", backupPath)
-
-	// 检查备份目录是否存在
-	if _, err := api.fs.Stat(backupPath); os.IsNotExist(err) {
-		return fmt.Errorf("backup directory does not exist: %s", backupPath)
-	}
-
-	// 恢复全局配置文件
-	globalConfigBackup := filepath.Join(backupPath, "config.yaml")
-	if _, err := api.fs.Stat(globalConfigBackup); err == nil {
-		if err := api.copyFile(globalConfigBackup, api.paths.GlobalConfigFile); err != nil {
-			return fmt.Errorf("failed to restore global config: %w", err)
-		}
-	}
-
-	// 恢复工具配置目录
-	toolsBackupDir := filepath.Join(backupPath, "tools")
-	if _, err := api.fs.Stat(toolsBackupDir); err == nil {
-		// 先删除现有的工具配置目录
-		if err := api.fs.RemoveAll(api.paths.ToolsDir); err != nil {
-			return fmt.Errorf("failed to remove existing tools directory: %w", err)
-		}
-
-		if err := api.copyDir(toolsBackupDir, api.paths.ToolsDir); err != nil {
-			return fmt.Errorf("failed to restore tools directory: %w", err)
-		}
-	}
-
-	api.logger.Info("Configuration restore completed")
-	return nil
-}
-
 // GetGlobalConfig 获取全局配置
 func (api *DefaultAPI) GetGlobalConfig(ctx context.Context) (*types.GlobalConfig, error) {
 	return api.manager.LoadGlobal()
@@ -385,17 +300,26 @@ func (api *DefaultAPI) RemoveToolVersion(ctx context.Context, toolName, version
 }
 
 // ListInstalledVersions 列出已安装的版本
+// 以versions目录为准，配置中的 tools.<tool>.installed_versions 可能与磁盘不一致
 func (api *DefaultAPI) ListInstalledVersions(ctx context.Context, toolName string) ([]string, error) {
-	config, err := api.GetGlobalConfig(ctx)
+	toolDir := filepath.Join(api.paths.VersionsDir, toolName)
+	entries, err := afero.ReadDir(api.fs, toolDir)
 	if err != nil {
-		return nil, err
+		if os.IsNotExist(err) {
+			return []string{}, nil
+		}
+		return nil, fmt.Errorf("failed to read tool directory %s: %w", toolDir, err)
 	}
 
-	if toolInfo, exists := config.Tools[toolName]; exists {
-		return toolInfo.InstalledVersions, nil
+	versions := []string{}
+	for _, entry := range entries {
+		if entry.IsDir() && api.manager.IsToolInstalled(toolName, entry.Name()) {
+			versions = append(versions, entry.Name())
+		}
 	}
+	sort.Strings(versions)
 
-	return []string{}, nil
+	return versions, nil
 }
 
 // GetEffectiveConfig 获取有效配置
@@ -431,12 +355,13 @@ func (api *DefaultAPI) StopWatch(ctx context.Context) error {
 // 私有辅助方法
 
 // copyFile 复制文件
+// 备份和恢复的全局配置、工具定义都可能包含凭据，副本只允许所有者读写
 func (api *DefaultAPI) copyFile(src, dst string) error {
 	data, err := afero.ReadFile(api.fs, src)
 	if err != nil {
 		return err
 	}
-	return afero.WriteFile(api.fs, dst, data, 0644)
+	return utils.WritePrivateFile(api.fs, dst, data)
 }
 
 // copyDir 复制目录
@@ -468,6 +393,18 @@ func (api *DefaultAPI) notifyConfigChange(event *types.ConfigChangeEvent) {
 	}
 }
 
+// byteSizeValue 将设置值转换为字节数，支持 types.ByteSize 和 8GB 这样的字符串
+func byteSizeValue(key string, value interface{}) (types.ByteSize, error) {
+	switch size := value.(type) {
+	case types.ByteSize:
+		return size, nil
+	case string:
+		return types.ParseByteSize(size)
+	default:
+		return 0, fmt.Errorf("invalid type for %s, expected size string", key)
+	}
+}
+
 // getSettingValue 获取设置值
 func (api *DefaultAPI) getSettingValue(config *types.GlobalConfig, key string) interface{} {
 	// 根据key返回对应的设置值
@@ -478,14 +415,58 @@ func (api *DefaultAPI) getSettingValue(config *types.GlobalConfig, key string) i
 		return config.Settings.Download.Retries
 	case "download.concurrent_downloads":
 		return config.Settings.Download.ConcurrentDownloads
+	case "download.max_extracted_size":
+		return config.Settings.Download.MaxExtractedSize
+	case "download.max_extracted_file_size":
+		return config.Settings.Download.MaxExtractedFileSize
+	case "download.max_archive_entries":
+		return config.Settings.Download.MaxArchiveEntries
+	case "download.max_compression_ratio":
+		return config.Settings.Download.MaxCompressionRatio
+	case "download.preserve_mtime":
+		return config.Settings.Download.PreserveMtime
+	case "download.preserve_xattrs":
+		return config.Settings.Download.PreserveXattrs
+	case "download.zip_filename_encodings":
+		return config.Settings.Download.ZipFilenameEncodings
+	case "download.progress_interval":
+		return config.Settings.Download.GetProgressInterval()
+	case "download.mirror":
+		return config.Settings.Download.Mirror
 	case "proxy.enabled":
 		return config.Settings.Proxy.Enabled
 	case "proxy.shims_in_path":
 		return config.Settings.Proxy.ShimsInPath
+	case "proxy.self_heal":
+		return config.Settings.Proxy.GetSelfHeal()
 	case "logging.level":
 		return config.Settings.Logging.Level
 	case "logging.file":
 		return config.Settings.Logging.File
+	case "resolution.fallback":
+		return config.Settings.Resolution.Fallback
+	case "resolution.merge_strategy":
+		return config.Settings.Resolution.Strategy().String()
+	case "resolution.deprecated":
+		return config.Settings.Resolution.DeprecationPolicy()
+	case "system.root":
+		return config.Settings.System.Root
+	case "backup.enabled":
+		return config.Settings.Backup.Enabled
+	case "backup.interval":
+		return config.Settings.Backup.GetInterval()
+	case "backup.keep_last":
+		return config.Settings.Backup.GetKeepLast()
+	case "limits.timeout":
+		return config.Settings.Limits.Timeout
+	case "limits.max_memory":
+		return config.Settings.Limits.MaxMemory
+	case "limits.nice":
+		return config.Settings.Limits.Nice
+	case "readonly":
+		return config.Settings.ReadOnly
+	case "root_policy":
+		return config.Settings.RootPolicy
 	default:
 		return nil
 	}
@@ -513,6 +494,66 @@ func (api *DefaultAPI) setSettingValue(config *types.GlobalConfig, key string, v
 		} else {
 			return fmt.Errorf("invalid type for download.concurrent_downloads, expected int")
 		}
+	case "download.max_extracted_size":
+		size, err := byteSizeValue(key, value)
+		if err != nil {
+			return err
+		}
+		config.Settings.Download.MaxExtractedSize = size
+	case "download.max_extracted_file_size":
+		size, err := byteSizeValue(key, value)
+		if err != nil {
+			return err
+		}
+		config.Settings.Download.MaxExtractedFileSize = size
+	case "download.max_archive_entries":
+		if entries, ok := value.(int); ok {
+			config.Settings.Download.MaxArchiveEntries = entries
+		} else {
+			return fmt.Errorf("invalid type for download.max_archive_entries, expected int")
+		}
+	case "download.max_compression_ratio":
+		switch ratio := value.(type) {
+		case float64:
+			config.Settings.Download.MaxCompressionRatio = ratio
+		case int:
+			config.Settings.Download.MaxCompressionRatio = float64(ratio)
+		default:
+			return fmt.Errorf("invalid type for download.max_compression_ratio, expected number")
+		}
+	case "download.preserve_mtime":
+		if preserve, ok := value.(bool); ok {
+			config.Settings.Download.PreserveMtime = preserve
+		} else {
+			return fmt.Errorf("invalid type for download.preserve_mtime, expected bool")
+		}
+	case "download.preserve_xattrs":
+		if preserve, ok := value.(bool); ok {
+			config.Settings.Download.PreserveXattrs = preserve
+		} else {
+			return fmt.Errorf("invalid type for download.preserve_xattrs, expected bool")
+		}
+	case "download.zip_filename_encodings":
+		switch encodings := value.(type) {
+		case []string:
+			config.Settings.Download.ZipFilenameEncodings = encodings
+		case string:
+			config.Settings.Download.ZipFilenameEncodings = strings.Split(encodings, ",")
+		default:
+			return fmt.Errorf("invalid type for download.zip_filename_encodings, expected string list")
+		}
+	case "download.progress_interval":
+		if interval, ok := value.(time.Duration); ok {
+			config.Settings.Download.ProgressInterval = interval
+		} else {
+			return fmt.Errorf("invalid type for download.progress_interval, expected time.Duration")
+		}
+	case "download.mirror":
+		if mirror, ok := value.(string); ok {
+			config.Settings.Download.Mirror = mirror
+		} else {
+			return fmt.Errorf("invalid type for download.mirror, expected string")
+		}
 	case "proxy.enabled":
 		if enabled, ok := value.(bool); ok {
 			config.Settings.Proxy.Enabled = enabled
@@ -525,6 +566,15 @@ func (api *DefaultAPI) setSettingValue(config *types.GlobalConfig, key string, v
 		} else {
 			return fmt.Errorf("invalid type for proxy.shims_in_path, expected bool")
 		}
+	case "proxy.self_heal":
+		mode, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("invalid type for proxy.self_heal, expected string")
+		}
+		if !types.IsValidShimHeal(mode) {
+			return fmt.Errorf("invalid proxy.self_heal: %s", mode)
+		}
+		config.Settings.Proxy.SelfHeal = mode
 	case "logging.level":
 		if level, ok := value.(string); ok {
 			config.Settings.Logging.Level = level
@@ -537,6 +587,93 @@ func (api *DefaultAPI) setSettingValue(config *types.GlobalConfig, key string, v
 		} else {
 			return fmt.Errorf("invalid type for logging.file, expected string")
 		}
+	case "resolution.fallback":
+		fallback, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("invalid type for resolution.fallback, expected string")
+		}
+		if !types.IsValidFallback(fallback) {
+			return fmt.Errorf("invalid resolution.fallback: %s", fallback)
+		}
+		config.Settings.Resolution.Fallback = fallback
+	case "resolution.merge_strategy":
+		name, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("invalid type for resolution.merge_strategy, expected string")
+		}
+		if _, err := types.ParseMergeStrategy(name); err != nil {
+			return fmt.Errorf("invalid resolution.merge_strategy: %w", err)
+		}
+		config.Settings.Resolution.MergeStrategy = name
+	case "resolution.deprecated":
+		policy, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("invalid type for resolution.deprecated, expected string")
+		}
+		if !types.IsValidDeprecationPolicy(policy) {
+			return fmt.Errorf("invalid resolution.deprecated: %s", policy)
+		}
+		config.Settings.Resolution.Deprecated = policy
+	case "system.root":
+		root, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("invalid type for system.root, expected string")
+		}
+		if root != "" && !filepath.IsAbs(root) {
+			return fmt.Errorf("system.root must be an absolute path: %s", root)
+		}
+		config.Settings.System.Root = root
+	case "backup.enabled":
+		if enabled, ok := value.(bool); ok {
+			config.Settings.Backup.Enabled = enabled
+		} else {
+			return fmt.Errorf("invalid type for backup.enabled, expected bool")
+		}
+	case "backup.interval":
+		if interval, ok := value.(time.Duration); ok {
+			config.Settings.Backup.Interval = interval
+		} else {
+			return fmt.Errorf("invalid type for backup.interval, expected time.Duration")
+		}
+	case "backup.keep_last":
+		if keep, ok := value.(int); ok {
+			config.Settings.Backup.KeepLast = keep
+		} else {
+			return fmt.Errorf("invalid type for backup.keep_last, expected int")
+		}
+	case "limits.timeout":
+		if timeout, ok := value.(time.Duration); ok {
+			config.Settings.Limits.Timeout = timeout
+		} else {
+			return fmt.Errorf("invalid type for limits.timeout, expected time.Duration")
+		}
+	case "limits.max_memory":
+		size, err := byteSizeValue(key, value)
+		if err != nil {
+			return err
+		}
+		config.Settings.Limits.MaxMemory = size
+	case "limits.nice":
+		if nice, ok := value.(int); ok {
+			config.Settings.Limits.Nice = nice
+		} else {
+			return fmt.Errorf("invalid type for limits.nice, expected int")
+		}
+	case "readonly":
+		if readOnly, ok := value.(bool); ok {
+			config.Settings.ReadOnly = readOnly
+		} else {
+			return fmt.Errorf("invalid type for readonly, expected bool")
+		}
+	case "root_policy":
+		policy, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("invalid type for root_policy, expected string")
+		}
+		if !types.IsValidRootPolicy(policy) {
+			return fmt.Errorf("invalid root_policy: %s", policy)
+		}
+		config.Settings.RootPolicy = policy
 	default:
 		return fmt.Errorf("unknown setting key: %s", key)
 	}
@@ -0,0 +1,194 @@
+package config
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+// AutoBackupPrefix 自动备份的ID前缀，只有自动备份会按 keep_last 轮换
+const AutoBackupPrefix = "auto-"
+
+// BackupEntry 备份目录中的一个备份
+type BackupEntry struct {
+	ID       string
+	Path     string
+	Manifest *BackupManifest
+}
+
+// IsAuto 是否为自动备份
+func (e *BackupEntry) IsAuto() bool {
+	return strings.HasPrefix(e.ID, AutoBackupPrefix)
+}
+
+// ListBackups 按创建时间从早到晚列出备份目录中的备份，无法读取清单的目录会被跳过
+func (api *DefaultAPI) ListBackups() ([]*BackupEntry, error) {
+	dir := BackupsDir(api.paths)
+	entries, err := afero.ReadDir(api.fs, dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read backups directory: %w", err)
+	}
+
+	var backups []*BackupEntry
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		manifest, err := api.LoadBackupManifest(path)
+		if err != nil || len(manifest.Files) == 0 {
+			continue
+		}
+		backups = append(backups, &BackupEntry{ID: entry.Name(), Path: path, Manifest: manifest})
+	}
+	sort.SliceStable(backups, func(i, j int) bool {
+		return backups[i].Manifest.CreatedAt.Before(backups[j].Manifest.CreatedAt)
+	})
+	return backups, nil
+}
+
+// AutoBackup 按 settings.backup 创建自动备份并删除超出 keep_last 的旧自动备份
+// 未启用自动备份，或配置与最近一次自动备份相同时不创建备份，返回空路径
+func (api *DefaultAPI) AutoBackup(ctx context.Context, reason string) (string, error) {
+	config, err := api.manager.LoadGlobal()
+	if err != nil {
+		return "", fmt.Errorf("failed to load global config: %w", err)
+	}
+	settings := config.Settings.Backup
+	if !settings.Enabled {
+		return "", nil
+	}
+
+	backups, err := api.ListBackups()
+	if err != nil {
+		return "", err
+	}
+	if latest := latestAutoBackup(backups); latest != nil && latest.Manifest.Digest != "" {
+		digest, err := api.configDigest()
+		if err != nil {
+			return "", err
+		}
+		if digest == latest.Manifest.Digest {
+			return "", nil
+		}
+	}
+
+	backupPath := api.newBackupPath(AutoBackupPrefix)
+	if _, err := api.Backup(ctx, backupPath, &BackupOptions{Reason: reason}); err != nil {
+		return "", err
+	}
+	if err := api.pruneAutoBackups(settings.GetKeepLast()); err != nil {
+		return backupPath, err
+	}
+	return backupPath, nil
+}
+
+// AutoBackupDue 是否到了定时备份的时间，即距最近一次自动备份已超过 settings.backup.interval
+func (api *DefaultAPI) AutoBackupDue(now time.Time) (bool, error) {
+	config, err := api.manager.LoadGlobal()
+	if err != nil {
+		return false, fmt.Errorf("failed to load global config: %w", err)
+	}
+	if !config.Settings.Backup.Enabled {
+		return false, nil
+	}
+	backups, err := api.ListBackups()
+	if err != nil {
+		return false, err
+	}
+	latest := latestAutoBackup(backups)
+	return latest == nil || now.Sub(latest.Manifest.CreatedAt) >= config.Settings.Backup.GetInterval(), nil
+}
+
+// pruneAutoBackups 只保留最近的 keep 个自动备份
+func (api *DefaultAPI) pruneAutoBackups(keep int) error {
+	backups, err := api.ListBackups()
+	if err != nil {
+		return err
+	}
+	var auto []*BackupEntry
+	for _, backup := range backups {
+		if backup.IsAuto() {
+			auto = append(auto, backup)
+		}
+	}
+	for i := 0; i < len(auto)-keep; i++ {
+		if err := api.fs.RemoveAll(auto[i].Path); err != nil {
+			return fmt.Errorf("failed to remove old backup %s: %w", auto[i].ID, err)
+		}
+	}
+	return nil
+}
+
+// newBackupPath 生成不与已有备份重复的备份目录，如 backups/auto-20240601-120000
+func (api *DefaultAPI) newBackupPath(prefix string) string {
+	base := filepath.Join(BackupsDir(api.paths), prefix+time.Now().Format("20060102-150405"))
+	path := base
+	for i := 2; ; i++ {
+		if _, err := api.fs.Stat(path); os.IsNotExist(err) {
+			return path
+		}
+		path = fmt.Sprintf("%s-%d", base, i)
+	}
+}
+
+// configDigest 计算全局配置和工具定义的摘要，用于判断配置自上次备份以来是否变化
+func (api *DefaultAPI) configDigest() (string, error) {
+	hash := sha256.New()
+	add := func(path string) error {
+		file, err := api.fs.Open(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		defer file.Close()
+		rel, _ := filepath.Rel(api.paths.ConfigDir, path)
+		fmt.Fprintf(hash, "%s\x00", filepath.ToSlash(rel))
+		_, err = io.Copy(hash, file)
+		return err
+	}
+
+	if err := add(api.paths.GlobalConfigFile); err != nil {
+		return "", fmt.Errorf("failed to digest global config: %w", err)
+	}
+	err := afero.Walk(api.fs, api.paths.ToolsDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		return add(path)
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to digest tools directory: %w", err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// latestAutoBackup 返回最近的自动备份
+func latestAutoBackup(backups []*BackupEntry) *BackupEntry {
+	for i := len(backups) - 1; i >= 0; i-- {
+		if backups[i].IsAuto() {
+			return backups[i]
+		}
+	}
+	return nil
+}
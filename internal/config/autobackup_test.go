@@ -0,0 +1,66 @@
+package config
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAutoBackup_SkipsUnchangedAndRotates(t *testing.T) {
+	api, _ := newTestAPI(t)
+	ctx := context.Background()
+
+	// 未启用时不备份
+	path, err := api.AutoBackup(ctx, "install")
+	require.NoError(t, err)
+	assert.Empty(t, path)
+
+	globalConfig, err := api.manager.LoadGlobal()
+	require.NoError(t, err)
+	globalConfig.Settings.Backup.Enabled = true
+	globalConfig.Settings.Backup.KeepLast = 2
+	require.NoError(t, api.manager.SaveGlobal(globalConfig))
+
+	_, err = api.Backup(ctx, filepath.Join(BackupsDir(api.paths), "manual-1"), nil)
+	require.NoError(t, err)
+
+	due, err := api.AutoBackupDue(time.Now())
+	require.NoError(t, err)
+	assert.True(t, due)
+
+	first, err := api.AutoBackup(ctx, "install")
+	require.NoError(t, err)
+	require.NotEmpty(t, first)
+
+	// 配置没有变化时跳过
+	path, err = api.AutoBackup(ctx, "install")
+	require.NoError(t, err)
+	assert.Empty(t, path)
+
+	due, err = api.AutoBackupDue(time.Now())
+	require.NoError(t, err)
+	assert.False(t, due)
+
+	var created []string
+	for _, tool := range []string{"kubectl", "helm"} {
+		globalConfig.GlobalVersions = map[string]string{tool: "1.0.0"}
+		require.NoError(t, api.manager.SaveGlobal(globalConfig))
+		path, err := api.AutoBackup(ctx, "use")
+		require.NoError(t, err)
+		require.NotEmpty(t, path)
+		created = append(created, filepath.Base(path))
+	}
+
+	backups, err := api.ListBackups()
+	require.NoError(t, err)
+	var ids []string
+	for _, backup := range backups {
+		ids = append(ids, backup.ID)
+	}
+	assert.Equal(t, append([]string{"manual-1"}, created...), ids, "只轮换自动备份")
+	assert.Equal(t, "use", backups[2].Manifest.Reason)
+}
@@ -0,0 +1,501 @@
+package config
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/afero"
+
+	"github.com/songzhibin97/vman/pkg/types"
+)
+
+const (
+	// BackupManifestFile 备份目录中的清单文件名
+	BackupManifestFile = "manifest.json"
+
+	// BackupManifestVersion 清单格式版本
+	BackupManifestVersion = 1
+
+	// backupProjectsDir 备份目录中保存项目配置的子目录
+	backupProjectsDir = "projects"
+
+	// projectScanDepth 查找项目配置时最多进入的目录层数
+	projectScanDepth = 6
+)
+
+// projectConfigFiles 备份的项目配置文件
+var projectConfigFiles = []string{".vman.yaml", ".vman-version"}
+
+// projectScanSkipDirs 查找项目配置时跳过的目录
+var projectScanSkipDirs = map[string]bool{"node_modules": true, "vendor": true}
+
+// BackupOptions 备份选项，为 nil 时只备份全局配置和工具定义
+type BackupOptions struct {
+	// IncludeVersions 记录已安装版本的文件路径和SHA-256，不复制二进制文件
+	IncludeVersions bool
+	// IncludeShims 记录垫片的名称和链接目标
+	IncludeShims bool
+	// ProjectRoots 在这些目录下查找 .vman.yaml 和 .vman-version 并一起备份
+	ProjectRoots []string
+	// Reason 创建备份的原因，记录在清单中
+	Reason string
+}
+
+// BackupManifest 备份清单，记录备份的内容和备份时的环境
+type BackupManifest struct {
+	FormatVersion int             `json:"format_version"`
+	CreatedAt     time.Time       `json:"created_at"`
+	Reason        string          `json:"reason,omitempty"`
+	Digest        string          `json:"digest,omitempty"`
+	ConfigDir     string          `json:"config_dir"`
+	VersionsDir   string          `json:"versions_dir"`
+	Files         []string        `json:"files"`
+	Projects      []BackupProject `json:"projects,omitempty"`
+	Versions      []BackupVersion `json:"versions,omitempty"`
+	Shims         []BackupShim    `json:"shims,omitempty"`
+}
+
+// BackupProject 备份的项目配置文件
+type BackupProject struct {
+	// Path 备份时项目配置文件的绝对路径
+	Path string `json:"path"`
+	// File 文件在备份目录中的相对路径
+	File string `json:"file"`
+}
+
+// BackupVersion 已安装的版本，只记录文件路径和摘要
+type BackupVersion struct {
+	Tool    string `json:"tool"`
+	Version string `json:"version"`
+	// Files 相对于版本目录的路径到SHA-256的映射
+	Files map[string]string `json:"files"`
+}
+
+// BackupShim 垫片
+type BackupShim struct {
+	Name   string `json:"name"`
+	Target string `json:"target,omitempty"`
+}
+
+// RestoreOptions 恢复选项
+type RestoreOptions struct {
+	// PathMapping 将备份时的项目路径前缀映射到新的前缀，用于在另一台机器或新的主目录中恢复
+	PathMapping map[string]string
+	// SkipProjects 不恢复项目配置
+	SkipProjects bool
+}
+
+// RestoreReport 恢复结果
+type RestoreReport struct {
+	Manifest *BackupManifest
+	// Projects 恢复的项目配置文件路径
+	Projects []string
+	// SkippedProjects 目标目录不存在而没有恢复的项目配置文件
+	SkippedProjects []string
+	// MissingVersions 备份中记录或全局配置引用、但当前没有安装的版本
+	MissingVersions []VersionRef
+	// ModifiedVersions 已安装但文件与备份时不一致的版本
+	ModifiedVersions []VersionRef
+	// MissingShims 备份时存在、当前不存在的垫片
+	MissingShims []string
+}
+
+// VersionRef 工具版本
+type VersionRef struct {
+	Tool    string `json:"tool"`
+	Version string `json:"version"`
+}
+
+// String 返回 tool@version
+func (r VersionRef) String() string {
+	return r.Tool + "@" + r.Version
+}
+
+// Backup 将全局配置和工具定义复制到 backupPath，并按选项记录版本清单、垫片和项目配置
+func (api *DefaultAPI) Backup(ctx context.Context, backupPath string, options *BackupOptions) (*BackupManifest, error) {
+	api.logger.Infof("Backing up configuration to: %s", backupPath)
+	if options == nil {
+		options = &BackupOptions{}
+	}
+
+	if err := api.fs.MkdirAll(backupPath, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	manifest := &BackupManifest{
+		FormatVersion: BackupManifestVersion,
+		CreatedAt:     time.Now(),
+		Reason:        options.Reason,
+		ConfigDir:     api.paths.ConfigDir,
+		VersionsDir:   api.paths.VersionsDir,
+	}
+	digest, err := api.configDigest()
+	if err != nil {
+		return nil, err
+	}
+	manifest.Digest = digest
+
+	if _, err := api.fs.Stat(api.paths.GlobalConfigFile); err == nil {
+		name := filepath.Base(api.paths.GlobalConfigFile)
+		if err := api.copyFile(api.paths.GlobalConfigFile, filepath.Join(backupPath, name)); err != nil {
+			return nil, fmt.Errorf("failed to backup file %s: %w", api.paths.GlobalConfigFile, err)
+		}
+		manifest.Files = append(manifest.Files, name)
+	}
+
+	if _, err := api.fs.Stat(api.paths.ToolsDir); err == nil {
+		if err := api.copyDir(api.paths.ToolsDir, filepath.Join(backupPath, "tools")); err != nil {
+			return nil, fmt.Errorf("failed to backup tools directory: %w", err)
+		}
+		manifest.Files = append(manifest.Files, "tools")
+	}
+
+	for _, root := range options.ProjectRoots {
+		projects, err := api.findProjectConfigs(root)
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range projects {
+			file := filepath.ToSlash(filepath.Join(backupProjectsDir, fmt.Sprintf("%d", len(manifest.Projects)+1), filepath.Base(path)))
+			if err := api.copyProjectFile(path, filepath.Join(backupPath, filepath.FromSlash(file))); err != nil {
+				return nil, fmt.Errorf("failed to backup project config %s: %w", path, err)
+			}
+			manifest.Projects = append(manifest.Projects, BackupProject{Path: path, File: file})
+		}
+	}
+
+	if options.IncludeVersions {
+		versions, err := api.versionInventory()
+		if err != nil {
+			return nil, err
+		}
+		manifest.Versions = versions
+	}
+
+	if options.IncludeShims {
+		shims, err := api.shimInventory()
+		if err != nil {
+			return nil, err
+		}
+		manifest.Shims = shims
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal backup manifest: %w", err)
+	}
+	if err := afero.WriteFile(api.fs, filepath.Join(backupPath, BackupManifestFile), data, 0600); err != nil {
+		return nil, fmt.Errorf("failed to write backup manifest: %w", err)
+	}
+
+	api.logger.Info("Configuration backup completed")
+	return manifest, nil
+}
+
+// LoadBackupManifest 读取备份清单，没有清单的旧备份返回只包含文件列表的清单
+func (api *DefaultAPI) LoadBackupManifest(backupPath string) (*BackupManifest, error) {
+	data, err := afero.ReadFile(api.fs, filepath.Join(backupPath, BackupManifestFile))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read backup manifest: %w", err)
+	}
+
+	manifest := &BackupManifest{}
+	if os.IsNotExist(err) {
+		for _, name := range []string{filepath.Base(api.paths.GlobalConfigFile), "tools"} {
+			if _, err := api.fs.Stat(filepath.Join(backupPath, name)); err == nil {
+				manifest.Files = append(manifest.Files, name)
+			}
+		}
+		if info, err := api.fs.Stat(backupPath); err == nil {
+			manifest.CreatedAt = info.ModTime()
+		}
+		return manifest, nil
+	}
+	if err := json.Unmarshal(data, manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse backup manifest: %w", err)
+	}
+	if manifest.FormatVersion > BackupManifestVersion {
+		return nil, fmt.Errorf("backup format version %d is newer than supported version %d", manifest.FormatVersion, BackupManifestVersion)
+	}
+	return manifest, nil
+}
+
+// Restore 从备份中恢复全局配置、工具定义和项目配置，并检查备份引用的版本是否已安装
+func (api *DefaultAPI) Restore(ctx context.Context, backupPath string, options *RestoreOptions) (*RestoreReport, error) {
+	api.logger.Infof("Restoring configuration from: %s", backupPath)
+	if options == nil {
+		options = &RestoreOptions{}
+	}
+
+	if _, err := api.fs.Stat(backupPath); os.IsNotExist(err) {
+		return nil, fmt.Errorf("backup directory does not exist: %s", backupPath)
+	}
+	manifest, err := api.LoadBackupManifest(backupPath)
+	if err != nil {
+		return nil, err
+	}
+	report := &RestoreReport{Manifest: manifest}
+
+	globalConfigBackup := filepath.Join(backupPath, filepath.Base(api.paths.GlobalConfigFile))
+	if _, err := api.fs.Stat(globalConfigBackup); err == nil {
+		if err := api.copyFile(globalConfigBackup, api.paths.GlobalConfigFile); err != nil {
+			return nil, fmt.Errorf("failed to restore global config: %w", err)
+		}
+		// 丢弃缓存，之后读取恢复后的配置
+		if manager, ok := api.manager.(*DefaultManager); ok {
+			manager.invalidateGlobal()
+		}
+	}
+
+	toolsBackupDir := filepath.Join(backupPath, "tools")
+	if _, err := api.fs.Stat(toolsBackupDir); err == nil {
+		if err := api.fs.RemoveAll(api.paths.ToolsDir); err != nil {
+			return nil, fmt.Errorf("failed to remove existing tools directory: %w", err)
+		}
+		if err := api.copyDir(toolsBackupDir, api.paths.ToolsDir); err != nil {
+			return nil, fmt.Errorf("failed to restore tools directory: %w", err)
+		}
+	}
+
+	if !options.SkipProjects {
+		for _, project := range manifest.Projects {
+			target := MapPath(project.Path, options.PathMapping)
+			if info, err := api.fs.Stat(filepath.Dir(target)); err != nil || !info.IsDir() {
+				report.SkippedProjects = append(report.SkippedProjects, target)
+				continue
+			}
+			if err := api.copyProjectFile(filepath.Join(backupPath, filepath.FromSlash(project.File)), target); err != nil {
+				return nil, fmt.Errorf("failed to restore project config %s: %w", target, err)
+			}
+			report.Projects = append(report.Projects, target)
+		}
+	}
+
+	if err := api.checkRestoredVersions(manifest, report); err != nil {
+		return nil, err
+	}
+	for _, shim := range manifest.Shims {
+		if _, err := api.fs.Stat(filepath.Join(api.paths.ShimsDir, shim.Name)); err != nil {
+			report.MissingShims = append(report.MissingShims, shim.Name)
+		}
+	}
+
+	api.logger.Info("Configuration restore completed")
+	return report, nil
+}
+
+// MapPath 按最长的匹配前缀替换路径，没有匹配的前缀时原样返回
+func MapPath(path string, mapping map[string]string) string {
+	best := ""
+	for from := range mapping {
+		from = filepath.Clean(from)
+		if (path == from || strings.HasPrefix(path, from+string(filepath.Separator))) && len(from) > len(best) {
+			best = from
+		}
+	}
+	if best == "" {
+		return path
+	}
+	return filepath.Join(filepath.Clean(mapping[best]), strings.TrimPrefix(path, best))
+}
+
+// findProjectConfigs 在 root 下查找项目配置文件，跳过隐藏目录和依赖目录
+func (api *DefaultAPI) findProjectConfigs(root string) ([]string, error) {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+
+	var found []string
+	err = api.walkProjectDirs(root, 0, func(dir string) {
+		for _, name := range projectConfigFiles {
+			path := filepath.Join(dir, name)
+			if info, err := api.fs.Stat(path); err == nil && info.Mode().IsRegular() {
+				found = append(found, path)
+			}
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan projects in %s: %w", root, err)
+	}
+	return found, nil
+}
+
+// walkProjectDirs 依次访问 dir 及其子目录，最多进入 projectScanDepth 层
+func (api *DefaultAPI) walkProjectDirs(dir string, depth int, visit func(dir string)) error {
+	visit(dir)
+	if depth >= projectScanDepth {
+		return nil
+	}
+
+	entries, err := afero.ReadDir(api.fs, dir)
+	if err != nil {
+		if os.IsPermission(err) || os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") || projectScanSkipDirs[entry.Name()] {
+			continue
+		}
+		if err := api.walkProjectDirs(filepath.Join(dir, entry.Name()), depth+1, visit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// versionInventory 记录 versions 目录中每个版本的文件和SHA-256
+func (api *DefaultAPI) versionInventory() ([]BackupVersion, error) {
+	var versions []BackupVersion
+	tools, err := afero.ReadDir(api.fs, api.paths.VersionsDir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read versions directory: %w", err)
+	}
+	for _, tool := range tools {
+		if !tool.IsDir() {
+			continue
+		}
+		toolDir := filepath.Join(api.paths.VersionsDir, tool.Name())
+		entries, err := afero.ReadDir(api.fs, toolDir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read versions directory: %w", err)
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				continue
+			}
+			files, err := api.hashTree(filepath.Join(toolDir, entry.Name()))
+			if err != nil {
+				return nil, err
+			}
+			versions = append(versions, BackupVersion{Tool: tool.Name(), Version: entry.Name(), Files: files})
+		}
+	}
+	return versions, nil
+}
+
+// hashTree 计算目录下 bin 目录中所有普通文件的SHA-256，键为使用 / 分隔的相对路径
+// 安装元数据和最后使用标记等文件会随使用变化，不记录
+func (api *DefaultAPI) hashTree(dir string) (map[string]string, error) {
+	files := make(map[string]string)
+	err := afero.Walk(api.fs, dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() || filepath.Base(filepath.Dir(path)) != "bin" {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		sum, err := api.fileSHA256(path)
+		if err != nil {
+			return err
+		}
+		files[filepath.ToSlash(rel)] = sum
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash %s: %w", dir, err)
+	}
+	return files, nil
+}
+
+// shimInventory 记录垫片的名称和链接目标
+func (api *DefaultAPI) shimInventory() ([]BackupShim, error) {
+	entries, err := afero.ReadDir(api.fs, api.paths.ShimsDir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read shims directory: %w", err)
+	}
+	var shims []BackupShim
+	for _, entry := range entries {
+		shim := BackupShim{Name: entry.Name()}
+		if entry.Mode()&os.ModeSymlink != 0 {
+			shim.Target, _ = os.Readlink(filepath.Join(api.paths.ShimsDir, entry.Name()))
+		}
+		shims = append(shims, shim)
+	}
+	return shims, nil
+}
+
+// checkRestoredVersions 找出备份清单中记录和恢复的全局配置引用、但当前不存在或已被修改的版本
+func (api *DefaultAPI) checkRestoredVersions(manifest *BackupManifest, report *RestoreReport) error {
+	seen := make(map[VersionRef]bool)
+	for _, version := range manifest.Versions {
+		ref := VersionRef{Tool: version.Tool, Version: version.Version}
+		seen[ref] = true
+
+		versionDir := filepath.Join(api.paths.VersionsDir, version.Tool, version.Version)
+		if _, err := api.fs.Stat(versionDir); err != nil {
+			report.MissingVersions = append(report.MissingVersions, ref)
+			continue
+		}
+		for rel, expected := range version.Files {
+			sum, err := api.fileSHA256(filepath.Join(versionDir, filepath.FromSlash(rel)))
+			if err != nil || sum != expected {
+				report.ModifiedVersions = append(report.ModifiedVersions, ref)
+				break
+			}
+		}
+	}
+
+	globalConfig, err := api.manager.LoadGlobal()
+	if err != nil {
+		return fmt.Errorf("failed to load restored global config: %w", err)
+	}
+	for tool, version := range globalConfig.GlobalVersions {
+		ref := VersionRef{Tool: tool, Version: version}
+		if version == "" || version == types.SystemVersion || seen[ref] {
+			continue
+		}
+		if _, err := api.fs.Stat(filepath.Join(api.paths.VersionsDir, tool, version)); err != nil {
+			report.MissingVersions = append(report.MissingVersions, ref)
+		}
+	}
+
+	sortRefs := func(refs []VersionRef) {
+		sort.Slice(refs, func(i, j int) bool { return refs[i].String() < refs[j].String() })
+	}
+	sortRefs(report.MissingVersions)
+	sortRefs(report.ModifiedVersions)
+	return nil
+}
+
+// copyProjectFile 备份或恢复项目配置文件，项目配置通常提交到仓库中，保持普通文件权限
+func (api *DefaultAPI) copyProjectFile(src, dst string) error {
+	data, err := afero.ReadFile(api.fs, src)
+	if err != nil {
+		return err
+	}
+	if err := api.fs.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	return afero.WriteFile(api.fs, dst, data, 0644)
+}
+
+// fileSHA256 计算文件的SHA-256
+func (api *DefaultAPI) fileSHA256(path string) (string, error) {
+	file, err := api.fs.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
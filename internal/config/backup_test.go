@@ -0,0 +1,78 @@
+package config
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackupAndRestore_ManifestAndProjects(t *testing.T) {
+	api, fs := newTestAPI(t)
+	paths := api.paths
+
+	kubectl := filepath.Join(paths.VersionsDir, "kubectl", "1.29.0", "linux-amd64", "bin", "kubectl")
+	helm := filepath.Join(paths.VersionsDir, "helm", "3.14.0", "linux-amd64", "bin", "helm")
+	require.NoError(t, afero.WriteFile(fs, kubectl, []byte("kubectl"), 0755))
+	require.NoError(t, afero.WriteFile(fs, helm, []byte("helm"), 0755))
+	require.NoError(t, afero.WriteFile(fs, filepath.Join(paths.VersionsDir, "helm", "3.14.0", ".last-used"), nil, 0644))
+	require.NoError(t, afero.WriteFile(fs, filepath.Join(paths.ShimsDir, "kubectl"), []byte("#!/bin/sh"), 0755))
+	require.NoError(t, afero.WriteFile(fs, "/src/api/.vman.yaml", []byte("tools:\n  kubectl: 1.29.0\n"), 0644))
+	require.NoError(t, afero.WriteFile(fs, "/src/web/.vman-version", []byte("helm 3.14.0\n"), 0644))
+	require.NoError(t, afero.WriteFile(fs, "/src/web/node_modules/x/.vman.yaml", []byte("tools: {}\n"), 0644))
+
+	globalConfig, err := api.manager.LoadGlobal()
+	require.NoError(t, err)
+	globalConfig.GlobalVersions = map[string]string{"kubectl": "1.29.0", "terraform": "1.7.0"}
+	require.NoError(t, api.manager.SaveGlobal(globalConfig))
+
+	backupPath := filepath.Join(BackupsDir(paths), "manual")
+	manifest, err := api.Backup(context.Background(), backupPath, &BackupOptions{
+		IncludeVersions: true,
+		IncludeShims:    true,
+		ProjectRoots:    []string{"/src"},
+	})
+	require.NoError(t, err)
+	require.Len(t, manifest.Projects, 2)
+	assert.Equal(t, "/src/api/.vman.yaml", manifest.Projects[0].Path)
+	require.Len(t, manifest.Versions, 2)
+	for _, version := range manifest.Versions {
+		assert.Len(t, version.Files, 1, "只记录 bin 目录中的文件")
+	}
+	assert.Equal(t, []BackupShim{{Name: "kubectl"}}, manifest.Shims)
+
+	loaded, err := api.LoadBackupManifest(backupPath)
+	require.NoError(t, err)
+	assert.Equal(t, manifest.Projects, loaded.Projects)
+
+	// 模拟在新的主目录中恢复：kubectl 被修改，helm 和垫片丢失
+	require.NoError(t, afero.WriteFile(fs, kubectl, []byte("tampered"), 0755))
+	require.NoError(t, fs.RemoveAll(filepath.Join(paths.VersionsDir, "helm")))
+	require.NoError(t, fs.Remove(filepath.Join(paths.ShimsDir, "kubectl")))
+	require.NoError(t, fs.MkdirAll("/work/api", 0755))
+
+	report, err := api.Restore(context.Background(), backupPath, &RestoreOptions{
+		PathMapping: map[string]string{"/src": "/work"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/work/api/.vman.yaml"}, report.Projects)
+	assert.Equal(t, []string{"/work/web/.vman-version"}, report.SkippedProjects)
+	assert.Equal(t, []VersionRef{{"helm", "3.14.0"}, {"terraform", "1.7.0"}}, report.MissingVersions)
+	assert.Equal(t, []VersionRef{{"kubectl", "1.29.0"}}, report.ModifiedVersions)
+	assert.Equal(t, []string{"kubectl"}, report.MissingShims)
+
+	data, err := afero.ReadFile(fs, "/work/api/.vman.yaml")
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "kubectl: 1.29.0")
+}
+
+func TestMapPath(t *testing.T) {
+	mapping := map[string]string{"/home/old": "/home/new", "/home/old/src": "/data/src"}
+	assert.Equal(t, "/home/new/x/.vman.yaml", MapPath("/home/old/x/.vman.yaml", mapping))
+	assert.Equal(t, "/data/src/api/.vman.yaml", MapPath("/home/old/src/api/.vman.yaml", mapping))
+	assert.Equal(t, "/home/older/.vman.yaml", MapPath("/home/older/.vman.yaml", mapping))
+	assert.Equal(t, "/tmp/.vman.yaml", MapPath("/tmp/.vman.yaml", nil))
+}
@@ -0,0 +1,64 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/afero"
+)
+
+// ExportsFile 配置目录中记录 vman export 写入的文件的文件名，vman lock 据此重新生成
+const ExportsFile = "exports.json"
+
+// ExportRecord 一次导出的格式和写入的文件
+type ExportRecord struct {
+	Format string `json:"format"`
+	Output string `json:"output"`
+}
+
+// ExportRegistry 导出记录，按项目配置文件路径分组
+type ExportRegistry map[string][]ExportRecord
+
+// LoadExportRegistry 读取导出记录，文件不存在时返回空记录
+func LoadExportRegistry(fs afero.Fs, path string) (ExportRegistry, error) {
+	registry := ExportRegistry{}
+	data, err := afero.ReadFile(fs, path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return registry, nil
+		}
+		return nil, fmt.Errorf("failed to read export registry: %w", err)
+	}
+	if err := json.Unmarshal(data, &registry); err != nil {
+		return nil, fmt.Errorf("failed to parse export registry: %w", err)
+	}
+	return registry, nil
+}
+
+// Save 保存导出记录
+func (r ExportRegistry) Save(fs afero.Fs, path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal export registry: %w", err)
+	}
+	if err := fs.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create export registry directory: %w", err)
+	}
+	if err := afero.WriteFile(fs, path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write export registry: %w", err)
+	}
+	return nil
+}
+
+// Record 记录导出，同一文件只保留最后一次导出的格式
+func (r ExportRegistry) Record(configPath string, record ExportRecord) {
+	for i, existing := range r[configPath] {
+		if existing.Output == record.Output {
+			r[configPath][i] = record
+			return
+		}
+	}
+	r[configPath] = append(r[configPath], record)
+}
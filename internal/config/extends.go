@@ -0,0 +1,417 @@
+package config
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/spf13/afero"
+	"gopkg.in/yaml.v3"
+
+	"github.com/songzhibin97/vman/pkg/types"
+	"github.com/songzhibin97/vman/pkg/utils"
+)
+
+// ExtendsCacheDir 缓存目录下保存通过 URL 继承的基础配置的目录名
+const ExtendsCacheDir = "extends"
+
+// remoteBaseMaxAge 通过 URL 继承的基础配置的缓存有效期，过期后重新下载，下载失败时继续使用缓存
+const remoteBaseMaxAge = time.Hour
+
+// maxRemoteBaseSize 通过 URL 继承的基础配置的大小上限
+const maxRemoteBaseSize = 1 << 20
+
+// HTTPClient 下载通过 URL 继承的基础配置使用的HTTP客户端，download 包将其替换为使用网络设置（代理、证书）的客户端
+var HTTPClient = &http.Client{Timeout: 30 * time.Second}
+
+// fetchConfigURL 下载基础配置
+func fetchConfigURL(ctx context.Context, rawURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	return doConfigRequest(req)
+}
+
+// doConfigRequest 发送请求并读取配置内容，大小不能超过 maxRemoteBaseSize
+func doConfigRequest(req *http.Request) ([]byte, error) {
+	resp, err := HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxRemoteBaseSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxRemoteBaseSize {
+		return nil, fmt.Errorf("larger than %d bytes", maxRemoteBaseSize)
+	}
+	return data, nil
+}
+
+// isConfigURL extends 是否为 http(s) URL
+func isConfigURL(ref string) bool {
+	return strings.HasPrefix(ref, "http://") || strings.HasPrefix(ref, "https://")
+}
+
+// extendsSource 解析 source 中 extends 引用的基础配置的来源：URL 或绝对路径
+// 相对路径相对于 source 所在的目录，source 为 URL 时相对于该 URL
+func extendsSource(source, ref string) (string, error) {
+	if isConfigURL(ref) {
+		return ref, nil
+	}
+	if isConfigURL(source) {
+		base, err := url.Parse(source)
+		if err != nil {
+			return "", err
+		}
+		target, err := url.Parse(ref)
+		if err != nil {
+			return "", fmt.Errorf("invalid extends %q: %w", ref, err)
+		}
+		return base.ResolveReference(target).String(), nil
+	}
+
+	path, err := utils.ExpandPath(ref)
+	if err != nil {
+		return "", err
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(filepath.Dir(source), path)
+	}
+	return filepath.Clean(path), nil
+}
+
+// resolveExtends 依次加载 config 继承的基础配置并合并，source 为 config 的来源，
+// chain 为正在合并的配置来源，用于检测循环继承
+func (m *DefaultManager) resolveExtends(source string, config *types.ProjectConfig, chain []string) (*types.ProjectConfig, error) {
+	if config.Extends == "" {
+		return config, nil
+	}
+	baseSource, err := extendsSource(source, config.Extends)
+	if err != nil {
+		return nil, fmt.Errorf("invalid extends in %s: %w", source, err)
+	}
+	chain = append(chain, source)
+	for _, seen := range chain {
+		if seen == baseSource {
+			return nil, fmt.Errorf("extends cycle: %s -> %s", strings.Join(chain, " -> "), baseSource)
+		}
+	}
+
+	data, err := m.readConfigSource(baseSource)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s extended by %s: %w", baseSource, source, err)
+	}
+	data, secrets, _, err := decryptYAML(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s extended by %s: %w", baseSource, source, err)
+	}
+	var base types.ProjectConfig
+	if err := yaml.Unmarshal(data, &base); err != nil {
+		return nil, fmt.Errorf("failed to parse %s extended by %s: %w", baseSource, source, err)
+	}
+	base.Secrets = secrets
+	resolved, err := m.resolveExtends(baseSource, &base, chain)
+	if err != nil {
+		return nil, err
+	}
+
+	merged := MergeProjectConfigs(resolved, config)
+	merged.BaseSources = append([]string{baseSource}, resolved.BaseSources...)
+	return merged, nil
+}
+
+// readConfigSource 读取基础配置的内容，URL 通过缓存读取
+func (m *DefaultManager) readConfigSource(source string) ([]byte, error) {
+	if !isConfigURL(source) {
+		return afero.ReadFile(m.fs, source)
+	}
+	data, _, err := m.readCachedSource(ExtendsCacheDir, source, remoteBaseMaxAge, fetchConfigURL)
+	return data, err
+}
+
+// readCachedSource 通过缓存目录下的 dir 读取远程配置，缓存未过期时不重新下载；
+// 下载失败时使用过期的缓存，离线时配置仍然可用，stale 表示使用了过期的缓存
+func (m *DefaultManager) readCachedSource(dir, source string, maxAge time.Duration, fetch func(ctx context.Context, source string) ([]byte, error)) (data []byte, stale bool, err error) {
+	sum := sha256.Sum256([]byte(source))
+	cachePath := filepath.Join(m.paths.CacheDir, dir, hex.EncodeToString(sum[:])+".yaml")
+	info, statErr := m.fs.Stat(cachePath)
+	if statErr == nil && time.Since(info.ModTime()) < maxAge {
+		data, err = afero.ReadFile(m.fs, cachePath)
+		return data, false, err
+	}
+
+	data, err = fetch(context.Background(), source)
+	if err != nil {
+		if statErr == nil {
+			m.logger.Warnf("Failed to refresh %s, using cached copy: %v", redactURL(source), err)
+			data, err = afero.ReadFile(m.fs, cachePath)
+			return data, true, err
+		}
+		return nil, false, err
+	}
+
+	// 远程配置中可能包含凭据，缓存只允许所有者读写
+	if err := m.fs.MkdirAll(filepath.Dir(cachePath), 0755); err == nil {
+		if err := utils.WritePrivateFile(m.fs, cachePath, data); err != nil {
+			m.logger.Debugf("Failed to cache %s: %v", redactURL(source), err)
+		}
+	}
+	return data, false, nil
+}
+
+// redactURL 隐藏 URL 中的密码，用于日志和显示
+func redactURL(source string) string {
+	u, err := url.Parse(source)
+	if err != nil || u.User == nil {
+		return source
+	}
+	return u.Redacted()
+}
+
+// MergeProjectConfigs 合并基础配置和继承它的配置，override 中的设置优先：
+// 工具按名称覆盖（包括启用条件和可选标记），paths 按模式和工具覆盖，defaults、groups 和 workspaces 按键覆盖。
+// 结果的 Inherited 为 base 中没有被覆盖的设置
+func MergeProjectConfigs(base, override *types.ProjectConfig) *types.ProjectConfig {
+	merged := &types.ProjectConfig{
+		Version: override.Version,
+		Extends: override.Extends,
+	}
+	if merged.Version == "" {
+		merged.Version = base.Version
+	}
+	inherited := &types.ProjectConfig{}
+
+	// 工具
+	overridden := func(name string) bool {
+		_, active := override.Tools[name]
+		_, inactive := override.Inactive[name]
+		return active || inactive
+	}
+	for name, version := range base.Tools {
+		if !overridden(name) {
+			setMap(&merged.Tools, name, version)
+			setMap(&inherited.Tools, name, version)
+		}
+	}
+	for name, version := range base.Inactive {
+		if !overridden(name) {
+			setMap(&merged.Inactive, name, version)
+			setMap(&inherited.Inactive, name, version)
+		}
+	}
+	for name, condition := range base.Conditions {
+		if !overridden(name) {
+			setMap(&merged.Conditions, name, condition)
+			setMap(&inherited.Conditions, name, condition)
+		}
+	}
+	for name, optional := range base.Optional {
+		if !overridden(name) {
+			setMap(&merged.Optional, name, optional)
+			setMap(&inherited.Optional, name, optional)
+		}
+	}
+	for name, version := range override.Tools {
+		setMap(&merged.Tools, name, version)
+	}
+	for name, version := range override.Inactive {
+		setMap(&merged.Inactive, name, version)
+	}
+	for name, condition := range override.Conditions {
+		setMap(&merged.Conditions, name, condition)
+	}
+	for name, optional := range override.Optional {
+		setMap(&merged.Optional, name, optional)
+	}
+	if merged.Tools == nil {
+		merged.Tools = make(map[string]string)
+	}
+
+	// 子目录的工具版本
+	for pattern, tools := range base.Paths {
+		for name, version := range tools {
+			if _, ok := override.Paths[pattern][name]; ok {
+				continue
+			}
+			setPathTool(&merged.Paths, pattern, name, version)
+			setPathTool(&inherited.Paths, pattern, name, version)
+		}
+	}
+	for pattern, tools := range override.Paths {
+		for name, version := range tools {
+			setPathTool(&merged.Paths, pattern, name, version)
+		}
+	}
+
+	// 默认参数和工具分组
+	for name, defaults := range base.Defaults {
+		if _, ok := override.Defaults[name]; !ok {
+			setMap(&merged.Defaults, name, defaults)
+			setMap(&inherited.Defaults, name, defaults)
+		}
+	}
+	for name, defaults := range override.Defaults {
+		setMap(&merged.Defaults, name, defaults)
+	}
+	for name, tools := range base.Groups {
+		if _, ok := override.Groups[name]; !ok {
+			setMap(&merged.Groups, name, tools)
+			setMap(&inherited.Groups, name, tools)
+		}
+	}
+	for name, tools := range override.Groups {
+		setMap(&merged.Groups, name, tools)
+	}
+
+	// terraform 工作区的版本
+	for workspace, version := range base.Workspaces {
+		if _, ok := override.Workspaces[workspace]; !ok {
+			setMap(&merged.Workspaces, workspace, version)
+			setMap(&inherited.Workspaces, workspace, version)
+		}
+	}
+	for workspace, version := range override.Workspaces {
+		setMap(&merged.Workspaces, workspace, version)
+	}
+
+	// 加密的值按路径记录，本文件中的优先
+	if len(base.Secrets) > 0 || len(override.Secrets) > 0 {
+		merged.Secrets = make(types.SecretValues)
+		for path, secret := range base.Secrets {
+			merged.Secrets[path] = secret
+		}
+		for path, secret := range override.Secrets {
+			merged.Secrets[path] = secret
+		}
+	}
+
+	merged.Inherited = inherited
+	return merged
+}
+
+// withoutInherited 去掉 config 中没有修改的继承设置，得到保存到文件中的配置
+func withoutInherited(config *types.ProjectConfig) *types.ProjectConfig {
+	inherited := config.Inherited
+	if inherited == nil {
+		return config
+	}
+
+	own := *config
+	own.Inherited = nil
+	own.BaseSources = nil
+	own.Tools, own.Inactive, own.Conditions, own.Optional = nil, nil, nil, nil
+	for _, name := range toolNames(config) {
+		if sameToolEntry(config, inherited, name) {
+			continue
+		}
+		if version, ok := config.Tools[name]; ok {
+			setMap(&own.Tools, name, version)
+		}
+		if version, ok := config.Inactive[name]; ok {
+			setMap(&own.Inactive, name, version)
+		}
+		if condition, ok := config.Conditions[name]; ok {
+			setMap(&own.Conditions, name, condition)
+		}
+		if config.Optional[name] {
+			setMap(&own.Optional, name, true)
+		}
+	}
+	if own.Tools == nil {
+		own.Tools = make(map[string]string)
+	}
+
+	own.Paths = nil
+	for pattern, tools := range config.Paths {
+		for name, version := range tools {
+			if inheritedVersion, ok := inherited.Paths[pattern][name]; !ok || inheritedVersion != version {
+				setPathTool(&own.Paths, pattern, name, version)
+			}
+		}
+	}
+	own.Defaults = nil
+	for name, defaults := range config.Defaults {
+		if inheritedDefaults, ok := inherited.Defaults[name]; !ok || !reflect.DeepEqual(inheritedDefaults, defaults) {
+			setMap(&own.Defaults, name, defaults)
+		}
+	}
+	own.Groups = nil
+	for name, tools := range config.Groups {
+		if inheritedTools, ok := inherited.Groups[name]; !ok || !reflect.DeepEqual(inheritedTools, tools) {
+			setMap(&own.Groups, name, tools)
+		}
+	}
+	own.Workspaces = nil
+	for workspace, version := range config.Workspaces {
+		if inheritedVersion, ok := inherited.Workspaces[workspace]; !ok || inheritedVersion != version {
+			setMap(&own.Workspaces, workspace, version)
+		}
+	}
+	return &own
+}
+
+// toolNames 配置中的工具名，包括本机未启用的工具
+func toolNames(config *types.ProjectConfig) []string {
+	names := make([]string, 0, len(config.Tools)+len(config.Inactive))
+	for name := range config.Tools {
+		names = append(names, name)
+	}
+	for name := range config.Inactive {
+		if _, ok := config.Tools[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// sameToolEntry 工具在两个配置中的版本、启用条件和可选标记是否相同
+func sameToolEntry(a, b *types.ProjectConfig, name string) bool {
+	versionOf := func(c *types.ProjectConfig) (string, bool) {
+		if version, ok := c.Tools[name]; ok {
+			return version, true
+		}
+		version, ok := c.Inactive[name]
+		return version, ok
+	}
+	versionA, okA := versionOf(a)
+	versionB, okB := versionOf(b)
+	if !okA || !okB || versionA != versionB || a.Optional[name] != b.Optional[name] {
+		return false
+	}
+	conditionA, conditionalA := a.Conditions[name]
+	conditionB, conditionalB := b.Conditions[name]
+	return conditionalA == conditionalB && reflect.DeepEqual(conditionA, conditionB)
+}
+
+// setMap 设置 map 中的值，map 为 nil 时先创建
+func setMap[V any](m *map[string]V, key string, value V) {
+	if *m == nil {
+		*m = make(map[string]V)
+	}
+	(*m)[key] = value
+}
+
+// setPathTool 设置 paths 中模式下工具的版本
+func setPathTool(paths *map[string]map[string]string, pattern, name, version string) {
+	if *paths == nil {
+		*paths = make(map[string]map[string]string)
+	}
+	if (*paths)[pattern] == nil {
+		(*paths)[pattern] = make(map[string]string)
+	}
+	(*paths)[pattern][name] = version
+}
@@ -0,0 +1,184 @@
+package config
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/songzhibin97/vman/pkg/types"
+)
+
+func newExtendsTestManager(t *testing.T, files map[string]string) *DefaultManager {
+	fs := afero.NewMemMapFs()
+	for path, content := range files {
+		require.NoError(t, afero.WriteFile(fs, path, []byte(content), 0644))
+	}
+	return &DefaultManager{
+		fs:     fs,
+		paths:  types.DefaultConfigPaths("/home/test"),
+		logger: testLogger(),
+	}
+}
+
+func TestLoadProjectExtends(t *testing.T) {
+	manager := newExtendsTestManager(t, map[string]string{
+		"/org/base.yaml": `
+tools:
+  terraform: 1.5.0
+  kubectl: 1.28.0
+  jq:
+    version: "1.7"
+    optional: true
+paths:
+  "services/*":
+    go: 1.21.0
+    node: 20.0.0
+defaults:
+  terraform:
+    args: [-no-color]
+groups:
+  infra: [terraform, kubectl]
+`,
+		"/org/shared/.vman-base.yaml": `
+extends: ../base.yaml
+tools:
+  kubectl: 1.29.0
+  helm: 3.14.0
+`,
+		"/org/project/.vman.yaml": `
+version: "1.0"
+extends: ../shared/.vman-base.yaml
+tools:
+  helm: 3.15.0
+paths:
+  "services/*":
+    go: 1.22.0
+groups:
+  infra: [terraform]
+`,
+	})
+
+	config, err := manager.LoadProject("/org/project")
+	require.NoError(t, err)
+
+	assert.Equal(t, "../shared/.vman-base.yaml", config.Extends)
+	assert.Equal(t, map[string]string{
+		"terraform": "1.5.0",
+		"kubectl":   "1.29.0",
+		"jq":        "1.7",
+		"helm":      "3.15.0",
+	}, config.Tools)
+	assert.True(t, config.IsOptional("jq"))
+	assert.Equal(t, map[string]string{"go": "1.22.0", "node": "20.0.0"}, config.Paths["services/*"])
+	assert.Equal(t, []string{"-no-color"}, config.Defaults["terraform"].Args)
+	assert.Equal(t, []string{"terraform"}, config.Groups["infra"])
+	assert.Equal(t, []string{"/org/shared/.vman-base.yaml", "/org/base.yaml"}, config.BaseSources)
+}
+
+func TestLoadProjectExtendsErrors(t *testing.T) {
+	manager := newExtendsTestManager(t, map[string]string{
+		"/a/.vman.yaml": "extends: ../b/.vman.yaml\n",
+		"/b/.vman.yaml": "extends: ../c/base.yaml\n",
+		"/c/base.yaml":  "extends: ../a/.vman.yaml\n",
+		"/d/.vman.yaml": "extends: missing.yaml\n",
+	})
+
+	_, err := manager.LoadProject("/a")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "extends cycle: /a/.vman.yaml -> /b/.vman.yaml -> /c/base.yaml -> /a/.vman.yaml")
+
+	_, err = manager.LoadProject("/d")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "/d/missing.yaml")
+}
+
+func TestSaveProjectKeepsInheritedSettings(t *testing.T) {
+	manager := newExtendsTestManager(t, map[string]string{
+		"/org/base.yaml": `
+tools:
+  terraform: 1.5.0
+  kubectl: 1.28.0
+  jq: "1.7"
+groups:
+  infra: [terraform, kubectl]
+`,
+		"/org/project/.vman.yaml": `
+version: "1.0"
+extends: ../base.yaml
+tools:
+  jq: "1.7"
+`,
+	})
+
+	config, err := manager.LoadProject("/org/project")
+	require.NoError(t, err)
+	config.Tools["kubectl"] = "1.29.0"
+	config.Tools["helm"] = "3.15.0"
+	require.NoError(t, manager.SaveProject("/org/project", config))
+
+	data, err := afero.ReadFile(manager.fs, "/org/project/.vman.yaml")
+	require.NoError(t, err)
+	var saved types.ProjectConfig
+	require.NoError(t, yaml.Unmarshal(data, &saved))
+
+	// 没有修改的继承设置不写入，本文件中与基础配置相同的版本保留
+	assert.Equal(t, "../base.yaml", saved.Extends)
+	assert.Equal(t, map[string]string{"kubectl": "1.29.0", "helm": "3.15.0", "jq": "1.7"}, saved.Tools)
+	assert.Empty(t, saved.Groups)
+
+	reloaded, err := manager.LoadProject("/org/project")
+	require.NoError(t, err)
+	assert.Equal(t, "1.5.0", reloaded.Tools["terraform"])
+	assert.Equal(t, "1.29.0", reloaded.Tools["kubectl"])
+	assert.Equal(t, []string{"terraform", "kubectl"}, reloaded.Groups["infra"])
+}
+
+func TestLoadProjectExtendsURL(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch r.URL.Path {
+		case "/vman/base.yaml":
+			w.Write([]byte("extends: common.yaml\ntools:\n  terraform: 1.5.0\n"))
+		case "/vman/common.yaml":
+			w.Write([]byte("tools:\n  jq: \"1.7\"\n"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+
+	manager := newExtendsTestManager(t, map[string]string{
+		"/project/.vman.yaml": "extends: " + server.URL + "/vman/base.yaml\ntools:\n  kubectl: 1.29.0\n",
+	})
+
+	config, err := manager.LoadProject("/project")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"terraform": "1.5.0", "jq": "1.7", "kubectl": "1.29.0"}, config.Tools)
+	assert.Equal(t, []string{server.URL + "/vman/base.yaml", server.URL + "/vman/common.yaml"}, config.BaseSources)
+	assert.Equal(t, 2, requests)
+
+	// 缓存有效期内不再下载
+	_, err = manager.LoadProject("/project")
+	require.NoError(t, err)
+	assert.Equal(t, 2, requests)
+
+	// 缓存过期后下载失败时使用过期的缓存
+	server.Close()
+	old := time.Now().Add(-2 * remoteBaseMaxAge)
+	cached, err := afero.Glob(manager.fs, filepath.Join(manager.paths.CacheDir, ExtendsCacheDir, "*.yaml"))
+	require.NoError(t, err)
+	require.Len(t, cached, 2)
+	for _, path := range cached {
+		require.NoError(t, manager.fs.Chtimes(path, old, old))
+	}
+	config, err = manager.LoadProject("/project")
+	require.NoError(t, err)
+	assert.Equal(t, "1.5.0", config.Tools["terraform"])
+}
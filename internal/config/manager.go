@@ -14,6 +14,7 @@ import (
 	"gopkg.in/yaml.v3"
 
 	"github.com/songzhibin97/vman/pkg/types"
+	"github.com/songzhibin97/vman/pkg/utils"
 )
 
 // Manager 配置管理器接口
@@ -147,12 +148,43 @@ func (m *DefaultManager) LoadGlobal() (*types.GlobalConfig, error) {
 		return nil, fmt.Errorf("failed to read global config file: %w", err)
 	}
 
+	// 解密加密的值
+	data, secrets, sopsEncrypted, err := decryptYAML(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load global config file: %w", err)
+	}
+
 	// 解析YAML
 	var config types.GlobalConfig
 	if err := yaml.Unmarshal(data, &config); err != nil {
 		return nil, fmt.Errorf("failed to parse global config file: %w", err)
 	}
 
+	// 合并远程配置，远程配置中的设置优先
+	if remote := config.Settings.Remote; remote.URL != "" {
+		var doc yaml.Node
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("failed to parse global config file: %w", err)
+		}
+		layer, merged, err := m.applyRemoteConfig(&doc, remote, secrets)
+		if err != nil {
+			return nil, err
+		}
+		if layer != nil {
+			config = types.GlobalConfig{}
+			if err := doc.Decode(&config); err != nil {
+				return nil, fmt.Errorf("failed to parse remote config %s: %w", layer.Source, err)
+			}
+			config.Remote = layer
+			if err := normalizeRemoteValues(&config); err != nil {
+				return nil, err
+			}
+		}
+		secrets = merged
+	}
+	config.Secrets = secrets
+	config.SopsEncrypted = sopsEncrypted
+
 	// 应用默认值
 	m.applyGlobalDefaults(&config)
 
@@ -161,6 +193,11 @@ func (m *DefaultManager) LoadGlobal() (*types.GlobalConfig, error) {
 	return m.globalCfg, nil
 }
 
+// invalidateGlobal 丢弃缓存的全局配置，下次读取时重新加载文件
+func (m *DefaultManager) invalidateGlobal() {
+	m.globalCfg = nil
+}
+
 // LoadProject 加载项目配置
 func (m *DefaultManager) LoadProject(projectPath string) (*types.ProjectConfig, error) {
 	m.logger.Debugf("Loading project configuration from: %s", projectPath)
@@ -179,17 +216,41 @@ func (m *DefaultManager) LoadProject(projectPath string) (*types.ProjectConfig,
 		return nil, fmt.Errorf("failed to read project config file: %w", err)
 	}
 
+	// 解密加密的值
+	data, secrets, sopsEncrypted, err := decryptYAML(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load project config file: %w", err)
+	}
+
 	// 解析YAML
-	var config types.ProjectConfig
-	if err := yaml.Unmarshal(data, &config); err != nil {
+	var own types.ProjectConfig
+	if err := yaml.Unmarshal(data, &own); err != nil {
 		return nil, fmt.Errorf("failed to parse project config file: %w", err)
 	}
+	own.Secrets = secrets
+
+	// 合并 extends 继承的基础配置
+	source, err := filepath.Abs(configPath)
+	if err != nil {
+		source = configPath
+	}
+	config, err := m.resolveExtends(source, &own, nil)
+	if err != nil {
+		return nil, err
+	}
+	config.SopsEncrypted = sopsEncrypted
 
 	// 应用默认值
-	m.applyProjectDefaults(&config)
+	m.applyProjectDefaults(config)
+
+	for pattern := range config.Paths {
+		if err := utils.ValidatePathGlob(pattern); err != nil {
+			return nil, fmt.Errorf("invalid path pattern %q in %s: %w", pattern, configPath, err)
+		}
+	}
 
 	m.logger.Debug("Project configuration loaded successfully")
-	return &config, nil
+	return config, nil
 }
 
 // LoadToolConfig 加载工具配置
@@ -209,6 +270,11 @@ func (m *DefaultManager) LoadToolConfig(toolName string) (*types.ToolMetadata, e
 		return nil, fmt.Errorf("failed to read tool config file: %w", err)
 	}
 
+	// 解密 age 加密的值
+	if data, err = decryptTOML(data); err != nil {
+		return nil, fmt.Errorf("failed to load tool config file: %w", err)
+	}
+
 	// 解析TOML
 	var metadata types.ToolMetadata
 	if err := toml.Unmarshal(data, &metadata); err != nil {
@@ -223,14 +289,21 @@ func (m *DefaultManager) LoadToolConfig(toolName string) (*types.ToolMetadata, e
 func (m *DefaultManager) SaveGlobal(config *types.GlobalConfig) error {
 	m.logger.Debug("Saving global configuration")
 
-	// 序列化为YAML
-	data, err := yaml.Marshal(config)
+	if config.SopsEncrypted {
+		return fmt.Errorf("failed to write global config file: %w", ErrSopsEncrypted)
+	}
+
+	// 序列化为YAML，没有修改的远程配置值不写入，没有修改的解密值重新写入加密内容
+	data, err := withoutRemote(config)
 	if err != nil {
 		return fmt.Errorf("failed to marshal global config: %w", err)
 	}
+	if data, err = restoreSecrets(data, config.Secrets); err != nil {
+		return fmt.Errorf("failed to marshal global config: %w", err)
+	}
 
-	// 写入文件
-	if err := afero.WriteFile(m.fs, m.paths.GlobalConfigFile, data, 0644); err != nil {
+	// 全局配置中可能包含代理密码等凭据，只允许所有者读写
+	if err := utils.WritePrivateFile(m.fs, m.paths.GlobalConfigFile, data); err != nil {
 		return fmt.Errorf("failed to write global config file: %w", err)
 	}
 
@@ -252,11 +325,18 @@ func (m *DefaultManager) SaveProject(projectPath string, config *types.ProjectCo
 		return fmt.Errorf("failed to create project config directory: %w", err)
 	}
 
-	// 序列化为YAML
-	data, err := yaml.Marshal(config)
+	if config.SopsEncrypted {
+		return fmt.Errorf("failed to write project config file: %w", ErrSopsEncrypted)
+	}
+
+	// 序列化为YAML，没有修改的继承设置仍然由基础配置提供，没有修改的解密值重新写入加密内容
+	data, err := yaml.Marshal(withoutInherited(config))
 	if err != nil {
 		return fmt.Errorf("failed to marshal project config: %w", err)
 	}
+	if data, err = restoreSecrets(data, config.Secrets); err != nil {
+		return fmt.Errorf("failed to marshal project config: %w", err)
+	}
 
 	// 写入文件
 	if err := afero.WriteFile(m.fs, configPath, data, 0644); err != nil {
@@ -277,7 +357,14 @@ func (m *DefaultManager) GetEffectiveVersion(toolName, projectPath string) (stri
 		return "", fmt.Errorf("failed to load project config: %w", err)
 	}
 
-	// 在项目配置中查找
+	// 在项目配置中查找，当前 terraform 工作区配置的版本优先
+	if len(projectConfig.Workspaces) > 0 {
+		workspace, _ := ActiveWorkspace(m.fs, projectPath)
+		if version, ok := projectConfig.ToolVersionForWorkspace(toolName, workspace); ok && m.IsToolInstalled(toolName, version) {
+			m.logger.Debugf("Found version %s for %s in project config workspaces[%s]", version, toolName, workspace)
+			return version, nil
+		}
+	}
 	if version, exists := projectConfig.Tools[toolName]; exists && version != "" {
 		// 验证版本是否真实存在
 		if m.IsToolInstalled(toolName, version) {
@@ -371,7 +458,7 @@ func (m *DefaultManager) IsToolInstalled(toolName, version string) bool {
 	m.logger.Debugf("Checking if tool %s version %s is installed", toolName, version)
 
 	// 检查版本目录是否存在
-	versionDir := filepath.Join(m.paths.VersionsDir, toolName, version)
+	versionDir := m.paths.ToolVersionDir(toolName, version)
 	if _, err := m.fs.Stat(versionDir); os.IsNotExist(err) {
 		return false
 	}
@@ -390,7 +477,7 @@ func (m *DefaultManager) SetToolVersion(toolName, version string, global bool, p
 	m.logger.Debugf("Setting tool %s version to %s (global: %v, project: %s)", toolName, version, global, projectPath)
 
 	// 验证版本是否真实安装（除非是system版本）
-	if version != "system" && !m.IsToolInstalled(toolName, version) {
+	if version != types.SystemVersion && !m.IsToolInstalled(toolName, version) {
 		return fmt.Errorf("cannot set version %s@%s: version not installed", toolName, version)
 	}
 
@@ -414,7 +501,7 @@ func (m *DefaultManager) SetToolVersion(toolName, version string, global bool, p
 		toolInfo.CurrentVersion = version
 
 		// 只为真实安装的版本添加到已安装版本列表
-		if version != "system" {
+		if version != types.SystemVersion {
 			found := false
 			for _, v := range toolInfo.InstalledVersions {
 				if v == version {
@@ -561,28 +648,8 @@ func (m *DefaultManager) GetEffectiveConfig(projectPath string) (*types.Effectiv
 		return nil, fmt.Errorf("failed to load project config: %w", err)
 	}
 
-	// 合并版本配置
-	resolvedVersions := make(map[string]string)
-	configSource := make(map[string]string)
-
-	// 先使用全局版本
-	for toolName, version := range globalConfig.GlobalVersions {
-		resolvedVersions[toolName] = version
-		configSource[toolName] = "global"
-	}
-
-	// 项目配置覆盖全局配置
-	for toolName, version := range projectConfig.Tools {
-		resolvedVersions[toolName] = version
-		configSource[toolName] = projectPath
-	}
-
-	return &types.EffectiveConfig{
-		Global:           globalConfig,
-		Project:          projectConfig,
-		ResolvedVersions: resolvedVersions,
-		ConfigSource:     configSource,
-	}, nil
+	// 与版本解析器使用相同的合并逻辑
+	return NewMerger().MergeConfigs(globalConfig, projectConfig, globalConfig.Settings.Resolution.Strategy())
 }
 
 // 私有方法
@@ -612,6 +679,11 @@ func (m *DefaultManager) applyGlobalDefaults(config *types.GlobalConfig) {
 		config.Settings.Logging.File = "~/.vman/logs/vman.log"
 	}
 
+	// 应用版本解析设置默认值
+	if config.Settings.Resolution.Fallback == "" {
+		config.Settings.Resolution.Fallback = types.FallbackLatestInstalled
+	}
+
 	// 初始化映射（如果为nil）
 	if config.GlobalVersions == nil {
 		config.GlobalVersions = make(map[string]string)
@@ -675,5 +747,30 @@ func (m *DefaultManager) validateGlobalConfig(config *types.GlobalConfig) error
 		}
 	}
 
+	// 验证回退策略
+	if !types.IsValidFallback(config.Settings.Resolution.Fallback) {
+		return &types.ConfigValidationError{
+			Field:   "settings.resolution.fallback",
+			Message: "invalid fallback, must be one of: error, system, latest-installed, auto-install",
+			Value:   config.Settings.Resolution.Fallback,
+		}
+	}
+	for tool, fallback := range config.Settings.Resolution.Tools {
+		if !types.IsValidFallback(fallback) {
+			return &types.ConfigValidationError{
+				Field:   "settings.resolution.tools." + tool,
+				Message: "invalid fallback, must be one of: error, system, latest-installed, auto-install",
+				Value:   fallback,
+			}
+		}
+	}
+	if _, err := types.ParseMergeStrategy(config.Settings.Resolution.MergeStrategy); err != nil {
+		return &types.ConfigValidationError{
+			Field:   "settings.resolution.merge_strategy",
+			Message: "invalid merge strategy, must be one of: override, append, error",
+			Value:   config.Settings.Resolution.MergeStrategy,
+		}
+	}
+
 	return nil
 }
@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/sirupsen/logrus"
@@ -21,6 +22,116 @@ type Merger interface {
 
 	// GetVersionSource 获取版本来源
 	GetVersionSource(toolName string, global *types.GlobalConfig, project *types.ProjectConfig) (string, string)
+
+	// MergeVersions 按策略合并多层版本配置，layers 按优先级从低到高排列
+	MergeVersions(layers []VersionLayer, strategy types.ConfigMergeStrategy) (map[string]MergedVersion, error)
+}
+
+// 版本来源
+const (
+	SourceGlobal     = "global"
+	SourceGlobalTool = "global_tool"
+	SourceProject    = "project"
+	SourceEnv        = "env"
+)
+
+// VersionLayer 参与合并的一层版本配置
+type VersionLayer struct {
+	Source      string            // 层的来源，如 global、project、env
+	Versions    map[string]string // 工具名 -> 版本
+	ToolSources map[string]string // 单个工具的具体来源，未设置时使用 Source
+}
+
+// sourceOf 获取工具在该层中的来源
+func (l VersionLayer) sourceOf(toolName string) string {
+	if source, ok := l.ToolSources[toolName]; ok {
+		return source
+	}
+	return l.Source
+}
+
+// Filter 只保留指定工具
+func (l VersionLayer) Filter(toolNames ...string) VersionLayer {
+	filtered := VersionLayer{
+		Source:      l.Source,
+		Versions:    make(map[string]string),
+		ToolSources: make(map[string]string),
+	}
+	for _, name := range toolNames {
+		if version, ok := l.Versions[name]; ok {
+			filtered.Versions[name] = version
+			filtered.ToolSources[name] = l.sourceOf(name)
+		}
+	}
+	return filtered
+}
+
+// MergedVersion 合并后的工具版本
+type MergedVersion struct {
+	Version string
+	Source  string
+}
+
+// MergeConflictError 冲突报错策略下同一工具在不同层中配置了不同版本
+type MergeConflictError struct {
+	Tool           string
+	Version        string
+	Source         string
+	ConflictWith   string
+	ConflictSource string
+}
+
+// Error 实现error接口
+func (e *MergeConflictError) Error() string {
+	return fmt.Sprintf("conflicting versions for %s: %s from %s and %s from %s",
+		e.Tool, e.ConflictWith, e.ConflictSource, e.Version, e.Source)
+}
+
+// GlobalVersionLayer 由全局配置生成版本层
+// global_versions 优先，tools 中记录的当前版本只在 global_versions 未配置该工具时使用
+func GlobalVersionLayer(global *types.GlobalConfig) VersionLayer {
+	layer := VersionLayer{
+		Source:      SourceGlobal,
+		Versions:    make(map[string]string),
+		ToolSources: make(map[string]string),
+	}
+	if global == nil {
+		return layer
+	}
+
+	for toolName, toolInfo := range global.Tools {
+		if toolInfo.CurrentVersion != "" {
+			layer.Versions[toolName] = toolInfo.CurrentVersion
+			layer.ToolSources[toolName] = SourceGlobalTool
+		}
+	}
+	for toolName, version := range global.GlobalVersions {
+		if version != "" {
+			layer.Versions[toolName] = version
+			layer.ToolSources[toolName] = SourceGlobal
+		}
+	}
+
+	return layer
+}
+
+// ProjectVersionLayer 由项目配置生成版本层
+func ProjectVersionLayer(project *types.ProjectConfig) VersionLayer {
+	layer := VersionLayer{
+		Source:   SourceProject,
+		Versions: make(map[string]string),
+	}
+	if project == nil {
+		return layer
+	}
+
+	for toolName, version := range project.Tools {
+		if version != "" {
+			layer.Versions[toolName] = version
+		}
+	}
+
+	return layer
 }
 
 // DefaultMerger 默认配置合并器实现
@@ -48,20 +159,16 @@ func (m *DefaultMerger) MergeConfigs(global *types.GlobalConfig, project *types.
 		project = types.GetDefaultProjectConfig()
 	}
 
-	// 初始化解析结果
-	resolvedVersions := make(map[string]string)
-	configSource := make(map[string]string)
+	merged, err := m.MergeVersions([]VersionLayer{GlobalVersionLayer(global), ProjectVersionLayer(project)}, strategy)
+	if err != nil {
+		return nil, err
+	}
 
-	// 根据策略合并版本
-	switch strategy {
-	case types.OverrideStrategy:
-		m.mergeWithOverride(global, project, resolvedVersions, configSource)
-	case types.MergeStrategy:
-		m.mergeWithMerge(global, project, resolvedVersions, configSource)
-	case types.IgnoreStrategy:
-		m.mergeWithIgnore(global, project, resolvedVersions, configSource)
-	default:
-		m.mergeWithOverride(global, project, resolvedVersions, configSource) // 默认使用覆盖策略
+	resolvedVersions := make(map[string]string, len(merged))
+	configSource := make(map[string]string, len(merged))
+	for toolName, mv := range merged {
+		resolvedVersions[toolName] = mv.Version
+		configSource[toolName] = mv.Source
 	}
 
 	effective := &types.EffectiveConfig{
@@ -109,19 +216,13 @@ func (m *DefaultMerger) ResolveVersion(toolName, requestedVersion string, metada
 // MergeSettings 合并设置（项目级设置优先）
 func (m *DefaultMerger) MergeSettings(global *types.Settings, project *types.Settings) *types.Settings {
 	// 如果项目设置为nil，返回全局设置的副本
+	// 以全局设置为基础，保留回退策略、钩子等未在下面单独处理的设置
+	merged := *global
 	if project == nil {
-		return &types.Settings{
-			Download: global.Download,
-			Proxy:    global.Proxy,
-			Logging:  global.Logging,
-		}
+		return &merged
 	}
 
-	// 合并设置（项目设置优先）
-	merged := &types.Settings{}
-
-	// 合并下载设置
-	merged.Download = global.Download
+	// 合并下载设置（项目设置优先）
 	if project.Download.Timeout > 0 {
 		merged.Download.Timeout = project.Download.Timeout
 	}
@@ -132,12 +233,7 @@ func (m *DefaultMerger) MergeSettings(global *types.Settings, project *types.Set
 		merged.Download.ConcurrentDownloads = project.Download.ConcurrentDownloads
 	}
 
-	// 合并代理设置
-	merged.Proxy = global.Proxy
-	// 项目级代理设置会覆盖全局设置
-
 	// 合并日志设置
-	merged.Logging = global.Logging
 	if project.Logging.Level != "" {
 		merged.Logging.Level = project.Logging.Level
 	}
@@ -145,7 +241,7 @@ func (m *DefaultMerger) MergeSettings(global *types.Settings, project *types.Set
 		merged.Logging.File = project.Logging.File
 	}
 
-	return merged
+	return &merged
 }
 
 // GetVersionSource 获取版本来源
@@ -176,95 +272,59 @@ func (m *DefaultMerger) GetVersionSource(toolName string, global *types.GlobalCo
 
 // 私有方法
 
-// mergeWithOverride 使用覆盖策略合并（项目配置覆盖全局配置）
-func (m *DefaultMerger) mergeWithOverride(global *types.GlobalConfig, project *types.ProjectConfig, resolved map[string]string, source map[string]string) {
-	m.logger.Debug("Applying override merge strategy")
-
-	// 首先添加全局版本
-	if global.GlobalVersions != nil {
-		for toolName, version := range global.GlobalVersions {
-			resolved[toolName] = version
-			source[toolName] = "global"
+// MergeVersions 按策略合并多层版本配置
+//
+// layers 按优先级从低到高排列，例如全局配置、项目配置、环境变量。各策略的行为：
+//   - override、merge：高优先级层的版本覆盖低优先级层
+//   - ignore：只使用最低优先级的一层
+//   - append：高优先级层只添加低优先级层中没有的工具，已有的版本保持不变
+//   - error：同一工具在不同层中的版本不一致时返回 *MergeConflictError
+func (m *DefaultMerger) MergeVersions(layers []VersionLayer, strategy types.ConfigMergeStrategy) (map[string]MergedVersion, error) {
+	m.logger.Debugf("Merging %d version layers with strategy: %s", len(layers), strategy)
+
+	if strategy == types.IgnoreStrategy && len(layers) > 1 {
+		layers = layers[:1]
+	}
+
+	merged := make(map[string]MergedVersion)
+	for _, layer := range layers {
+		// 按工具名排序，冲突时报告的工具是确定的
+		toolNames := make([]string, 0, len(layer.Versions))
+		for toolName := range layer.Versions {
+			toolNames = append(toolNames, toolName)
 		}
-	}
+		sort.Strings(toolNames)
 
-	// 添加工具信息中的当前版本（如果全局版本中没有）
-	if global.Tools != nil {
-		for toolName, toolInfo := range global.Tools {
-			if toolInfo.CurrentVersion != "" && resolved[toolName] == "" {
-				resolved[toolName] = toolInfo.CurrentVersion
-				source[toolName] = "global_tool"
+		for _, toolName := range toolNames {
+			version := layer.Versions[toolName]
+			if version == "" {
+				continue
 			}
-		}
-	}
-
-	// 项目配置覆盖全局配置
-	if project.Tools != nil {
-		for toolName, version := range project.Tools {
-			if version != "" {
-				resolved[toolName] = version
-				source[toolName] = "project"
-			}
-		}
-	}
-}
-
-// mergeWithMerge 使用合并策略合并（合并所有版本，项目优先）
-func (m *DefaultMerger) mergeWithMerge(global *types.GlobalConfig, project *types.ProjectConfig, resolved map[string]string, source map[string]string) {
-	m.logger.Debug("Applying merge strategy")
-
-	// 合并全局版本
-	if global.GlobalVersions != nil {
-		for toolName, version := range global.GlobalVersions {
-			resolved[toolName] = version
-			source[toolName] = "global"
-		}
-	}
-
-	// 合并工具信息中的当前版本
-	if global.Tools != nil {
-		for toolName, toolInfo := range global.Tools {
-			if toolInfo.CurrentVersion != "" {
-				if existing, exists := resolved[toolName]; !exists || existing == "" {
-					resolved[toolName] = toolInfo.CurrentVersion
-					source[toolName] = "global_tool"
+			current := MergedVersion{Version: version, Source: layer.sourceOf(toolName)}
+
+			existing, exists := merged[toolName]
+			if exists {
+				switch strategy {
+				case types.AppendStrategy:
+					continue
+				case types.ErrorOnConflictStrategy:
+					if existing.Version != version {
+						return nil, &MergeConflictError{
+							Tool:           toolName,
+							Version:        version,
+							Source:         current.Source,
+							ConflictWith:   existing.Version,
+							ConflictSource: existing.Source,
+						}
+					}
 				}
 			}
-		}
-	}
-
-	// 合并项目配置（项目优先）
-	if project.Tools != nil {
-		for toolName, version := range project.Tools {
-			if version != "" {
-				resolved[toolName] = version
-				source[toolName] = "project"
-			}
-		}
-	}
-}
 
-// mergeWithIgnore 使用忽略策略合并（只使用全局配置）
-func (m *DefaultMerger) mergeWithIgnore(global *types.GlobalConfig, project *types.ProjectConfig, resolved map[string]string, source map[string]string) {
-	m.logger.Debug("Applying ignore strategy")
-
-	// 只使用全局版本
-	if global.GlobalVersions != nil {
-		for toolName, version := range global.GlobalVersions {
-			resolved[toolName] = version
-			source[toolName] = "global"
+			merged[toolName] = current
 		}
 	}
 
-	// 添加工具信息中的当前版本
-	if global.Tools != nil {
-		for toolName, toolInfo := range global.Tools {
-			if toolInfo.CurrentVersion != "" && resolved[toolName] == "" {
-				resolved[toolName] = toolInfo.CurrentVersion
-				source[toolName] = "global_tool"
-			}
-		}
-	}
+	return merged, nil
 }
 
 // applyVersionConstraints 应用版本约束
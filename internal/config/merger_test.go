@@ -413,3 +413,155 @@ func TestAdvancedMerger_MergeConfigs(t *testing.T) {
 	assert.Error(t, err)
 	assert.Nil(t, effective)
 }
+
+func TestDefaultMerger_MergeVersions(t *testing.T) {
+	merger := NewMerger()
+
+	global := VersionLayer{
+		Source:      SourceGlobal,
+		Versions:    map[string]string{"kubectl": "1.28.0", "terraform": "1.5.0", "sqlc": "1.19.0"},
+		ToolSources: map[string]string{"sqlc": SourceGlobalTool},
+	}
+	project := VersionLayer{
+		Source:   SourceProject,
+		Versions: map[string]string{"kubectl": "1.29.0", "helm": "3.12.0", "terraform": "1.5.0"},
+	}
+	env := VersionLayer{
+		Source:   SourceEnv,
+		Versions: map[string]string{"helm": "3.13.0"},
+	}
+
+	tests := []struct {
+		name     string
+		layers   []VersionLayer
+		strategy types.ConfigMergeStrategy
+		expected map[string]MergedVersion
+		conflict *MergeConflictError
+	}{
+		{
+			name:     "override",
+			layers:   []VersionLayer{global, project, env},
+			strategy: types.OverrideStrategy,
+			expected: map[string]MergedVersion{
+				"kubectl":   {Version: "1.29.0", Source: SourceProject},
+				"terraform": {Version: "1.5.0", Source: SourceProject},
+				"sqlc":      {Version: "1.19.0", Source: SourceGlobalTool},
+				"helm":      {Version: "3.13.0", Source: SourceEnv},
+			},
+		},
+		{
+			name:     "merge behaves like override",
+			layers:   []VersionLayer{global, project},
+			strategy: types.MergeStrategy,
+			expected: map[string]MergedVersion{
+				"kubectl":   {Version: "1.29.0", Source: SourceProject},
+				"terraform": {Version: "1.5.0", Source: SourceProject},
+				"sqlc":      {Version: "1.19.0", Source: SourceGlobalTool},
+				"helm":      {Version: "3.12.0", Source: SourceProject},
+			},
+		},
+		{
+			name:     "ignore uses lowest layer only",
+			layers:   []VersionLayer{global, project, env},
+			strategy: types.IgnoreStrategy,
+			expected: map[string]MergedVersion{
+				"kubectl":   {Version: "1.28.0", Source: SourceGlobal},
+				"terraform": {Version: "1.5.0", Source: SourceGlobal},
+				"sqlc":      {Version: "1.19.0", Source: SourceGlobalTool},
+			},
+		},
+		{
+			name:     "append keeps existing versions",
+			layers:   []VersionLayer{global, project, env},
+			strategy: types.AppendStrategy,
+			expected: map[string]MergedVersion{
+				"kubectl":   {Version: "1.28.0", Source: SourceGlobal},
+				"terraform": {Version: "1.5.0", Source: SourceGlobal},
+				"sqlc":      {Version: "1.19.0", Source: SourceGlobalTool},
+				"helm":      {Version: "3.12.0", Source: SourceProject},
+			},
+		},
+		{
+			name:     "error on conflicting versions",
+			layers:   []VersionLayer{global, project},
+			strategy: types.ErrorOnConflictStrategy,
+			conflict: &MergeConflictError{
+				Tool:           "kubectl",
+				Version:        "1.29.0",
+				Source:         SourceProject,
+				ConflictWith:   "1.28.0",
+				ConflictSource: SourceGlobal,
+			},
+		},
+		{
+			name:     "error allows identical versions",
+			layers:   []VersionLayer{global, project.Filter("terraform", "helm"), env.Filter("kubectl")},
+			strategy: types.ErrorOnConflictStrategy,
+			expected: map[string]MergedVersion{
+				"kubectl":   {Version: "1.28.0", Source: SourceGlobal},
+				"terraform": {Version: "1.5.0", Source: SourceProject},
+				"sqlc":      {Version: "1.19.0", Source: SourceGlobalTool},
+				"helm":      {Version: "3.12.0", Source: SourceProject},
+			},
+		},
+		{
+			name:     "no layers",
+			strategy: types.OverrideStrategy,
+			expected: map[string]MergedVersion{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged, err := merger.MergeVersions(tt.layers, tt.strategy)
+			if tt.conflict != nil {
+				var conflictErr *MergeConflictError
+				require.ErrorAs(t, err, &conflictErr)
+				assert.Equal(t, tt.conflict, conflictErr)
+				assert.Nil(t, merged)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, merged)
+		})
+	}
+}
+
+func TestDefaultMerger_MergeConfigs_Strategies(t *testing.T) {
+	merger := NewMerger()
+
+	globalConfig := &types.GlobalConfig{
+		Version:        "1.0",
+		GlobalVersions: map[string]string{"kubectl": "1.28.0"},
+		Tools: map[string]types.ToolInfo{
+			// global_versions 中已配置时忽略 tools 中的当前版本
+			"kubectl": {CurrentVersion: "1.27.0"},
+		},
+	}
+	projectConfig := &types.ProjectConfig{
+		Version: "1.0",
+		Tools:   map[string]string{"kubectl": "1.29.0", "helm": "3.12.0"},
+	}
+
+	effective, err := merger.MergeConfigs(globalConfig, projectConfig, types.AppendStrategy)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"kubectl": "1.28.0", "helm": "3.12.0"}, effective.ResolvedVersions)
+	assert.Equal(t, map[string]string{"kubectl": SourceGlobal, "helm": SourceProject}, effective.ConfigSource)
+
+	_, err = merger.MergeConfigs(globalConfig, projectConfig, types.ErrorOnConflictStrategy)
+	assert.EqualError(t, err, "conflicting versions for kubectl: 1.28.0 from global and 1.29.0 from project")
+}
+
+func TestDefaultMerger_MergeSettings_KeepsGlobalSettings(t *testing.T) {
+	merger := NewMerger()
+
+	global := &types.Settings{
+		Resolution: types.ResolutionSettings{Fallback: types.FallbackSystem},
+		Hooks:      types.HookSettings{PreExec: []string{"echo pre"}},
+	}
+
+	merged := merger.MergeSettings(global, &types.Settings{})
+	assert.Equal(t, types.FallbackSystem, merged.Resolution.Fallback)
+	assert.Equal(t, []string{"echo pre"}, merged.Hooks.PreExec)
+}
@@ -0,0 +1,246 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"github.com/spf13/afero"
+	"gopkg.in/yaml.v3"
+)
+
+// PinHistoryFile 配置目录中记录固定版本前原始版本要求的文件名
+const PinHistoryFile = "pins.json"
+
+// pinCommentPattern 匹配 PinComment 生成的注释
+var pinCommentPattern = regexp.MustCompile(`\s*#\s*pinned (\S+) from (.+) by vman$`)
+
+// PinComment 生成固定版本时写入项目配置的注释，追加在版本项原有的注释之后
+func PinComment(existing string, pinnedAt time.Time, previous string) string {
+	comment := fmt.Sprintf("# pinned %s from %s by vman", pinnedAt.Format("2006-01-02"), previous)
+	if existing = StripPinComment(existing); existing != "" {
+		comment = existing + " " + comment
+	}
+	return comment
+}
+
+// StripPinComment 删除注释中 PinComment 追加的部分
+func StripPinComment(comment string) string {
+	return pinCommentPattern.ReplaceAllString(comment, "")
+}
+
+// ParsePinComment 从 PinComment 生成的注释中取出固定前的版本要求
+func ParsePinComment(comment string) (string, bool) {
+	match := pinCommentPattern.FindStringSubmatch(comment)
+	if match == nil {
+		return "", false
+	}
+	return match[2], true
+}
+
+// ProjectToolEntry 项目配置文件中一个工具的版本项
+type ProjectToolEntry struct {
+	// Version 配置的版本要求
+	Version string
+
+	// Comment 版本项的行尾注释
+	Comment string
+}
+
+// ReadProjectToolEntry 读取项目配置中工具的版本项，pattern 为空时读取 tools，否则读取 paths.<pattern>
+func ReadProjectToolEntry(fs afero.Fs, configPath, pattern, tool string) (*ProjectToolEntry, error) {
+	doc, err := readProjectDocument(fs, configPath)
+	if err != nil {
+		return nil, err
+	}
+	value := findToolNode(doc, pattern, tool)
+	if value == nil {
+		return nil, fmt.Errorf("tool %s is not configured in %s", tool, configPath)
+	}
+	return &ProjectToolEntry{Version: value.Value, Comment: value.LineComment}, nil
+}
+
+// WriteProjectToolEntry 修改项目配置中工具的版本项，保留文件中的其他内容和注释
+func WriteProjectToolEntry(fs afero.Fs, configPath, pattern, tool string, entry *ProjectToolEntry) error {
+	doc, err := readProjectDocument(fs, configPath)
+	if err != nil {
+		return err
+	}
+	if len(doc.Content) > 0 && mappingValue(doc.Content[0], "sops") != nil {
+		return fmt.Errorf("failed to write %s: %w", configPath, ErrSopsEncrypted)
+	}
+	value := findToolNode(doc, pattern, tool)
+	if value == nil {
+		return fmt.Errorf("tool %s is not configured in %s", tool, configPath)
+	}
+
+	value.Value = entry.Version
+	value.Tag = "!!str"
+	value.Style = 0
+	value.LineComment = entry.Comment
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(documentIndent(doc))
+	if err := encoder.Encode(doc); err != nil {
+		return fmt.Errorf("failed to marshal project config: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return fmt.Errorf("failed to marshal project config: %w", err)
+	}
+
+	if err := afero.WriteFile(fs, configPath, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write project config file: %w", err)
+	}
+	return nil
+}
+
+// readProjectDocument 读取项目配置文件的YAML节点树
+func readProjectDocument(fs afero.Fs, configPath string) (*yaml.Node, error) {
+	data, err := afero.ReadFile(fs, configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read project config file: %w", err)
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse project config file: %w", err)
+	}
+	return &doc, nil
+}
+
+// documentIndent 返回文件原有的缩进宽度（第一个嵌套映射相对于其键的缩进），无法判断时为 2
+func documentIndent(doc *yaml.Node) int {
+	var find func(node *yaml.Node) int
+	find = func(node *yaml.Node) int {
+		if node.Kind != yaml.MappingNode {
+			return 0
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if value.Kind == yaml.MappingNode && value.Style&yaml.FlowStyle == 0 && len(value.Content) > 0 {
+				if indent := value.Content[0].Column - key.Column; indent > 0 {
+					return indent
+				}
+			}
+			if indent := find(value); indent > 0 {
+				return indent
+			}
+		}
+		return 0
+	}
+	if len(doc.Content) > 0 {
+		if indent := find(doc.Content[0]); indent > 0 {
+			return indent
+		}
+	}
+	return 2
+}
+
+// findToolNode 在项目配置节点树中查找工具版本的值节点
+func findToolNode(doc *yaml.Node, pattern, tool string) *yaml.Node {
+	if len(doc.Content) == 0 {
+		return nil
+	}
+	var tools *yaml.Node
+	if pattern == "" {
+		tools = mappingValue(doc.Content[0], "tools")
+	} else {
+		tools = mappingValue(mappingValue(doc.Content[0], "paths"), pattern)
+	}
+	value := mappingValue(tools, tool)
+	if value != nil && value.Kind == yaml.MappingNode {
+		// 带启用条件的工具写作 {version: ..., only: [...]}
+		value = mappingValue(value, "version")
+	}
+	if value == nil || value.Kind != yaml.ScalarNode {
+		return nil
+	}
+	return value
+}
+
+// mappingValue 返回映射节点中键对应的值节点
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// PinRecord 一次固定版本的记录
+type PinRecord struct {
+	Previous string    `json:"previous"`
+	Version  string    `json:"version"`
+	PinnedAt time.Time `json:"pinned_at"`
+}
+
+// PinHistory 固定版本记录，按项目配置文件路径和版本项分组
+type PinHistory map[string]map[string]*PinRecord
+
+// PinKey 版本项在固定版本记录中的键
+func PinKey(pattern, tool string) string {
+	if pattern == "" {
+		return tool
+	}
+	return pattern + ":" + tool
+}
+
+// LoadPinHistory 读取固定版本记录，文件不存在时返回空记录
+func LoadPinHistory(fs afero.Fs, path string) (PinHistory, error) {
+	history := PinHistory{}
+	data, err := afero.ReadFile(fs, path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return history, nil
+		}
+		return nil, fmt.Errorf("failed to read pin history: %w", err)
+	}
+	if err := json.Unmarshal(data, &history); err != nil {
+		return nil, fmt.Errorf("failed to parse pin history: %w", err)
+	}
+	return history, nil
+}
+
+// Save 保存固定版本记录
+func (h PinHistory) Save(fs afero.Fs, path string) error {
+	data, err := json.MarshalIndent(h, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal pin history: %w", err)
+	}
+	if err := fs.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create pin history directory: %w", err)
+	}
+	if err := afero.WriteFile(fs, path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write pin history: %w", err)
+	}
+	return nil
+}
+
+// Record 记录固定版本
+func (h PinHistory) Record(configPath, key string, record *PinRecord) {
+	if h[configPath] == nil {
+		h[configPath] = make(map[string]*PinRecord)
+	}
+	h[configPath][key] = record
+}
+
+// Take 取出并删除固定版本记录
+func (h PinHistory) Take(configPath, key string) (*PinRecord, bool) {
+	record, ok := h[configPath][key]
+	if !ok {
+		return nil, false
+	}
+	delete(h[configPath], key)
+	if len(h[configPath]) == 0 {
+		delete(h, configPath)
+	}
+	return record, true
+}
@@ -0,0 +1,135 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPinComment(t *testing.T) {
+	pinnedAt := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	comment := PinComment("", pinnedAt, "^1.29")
+	assert.Equal(t, "# pinned 2024-06-01 from ^1.29 by vman", comment)
+	assert.Equal(t, "", StripPinComment(comment))
+
+	previous, ok := ParsePinComment(comment)
+	assert.True(t, ok)
+	assert.Equal(t, "^1.29", previous)
+
+	previous, ok = ParsePinComment("# pinned 2024-06-01 from >=1.28 <1.30 by vman")
+	assert.True(t, ok)
+	assert.Equal(t, ">=1.28 <1.30", previous)
+
+	_, ok = ParsePinComment("# keep in sync with CI")
+	assert.False(t, ok)
+
+	// 原有的注释保留在固定注释之前
+	comment = PinComment("# cluster is 1.29", pinnedAt, "^1.29")
+	assert.Equal(t, "# cluster is 1.29 # pinned 2024-06-01 from ^1.29 by vman", comment)
+	previous, ok = ParsePinComment(comment)
+	assert.True(t, ok)
+	assert.Equal(t, "^1.29", previous)
+	assert.Equal(t, "# cluster is 1.29", StripPinComment(comment))
+}
+
+func TestWriteProjectToolEntry_PreservesComments(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/project/.vman.yaml", []byte(`# project tools
+version: "1.0"
+tools:
+    kubectl: ^1.29 # cluster is 1.29
+    helm: 3.14.0
+paths:
+    services/*:
+        kubectl: ~1.28
+`), 0644))
+
+	entry, err := ReadProjectToolEntry(fs, "/project/.vman.yaml", "", "kubectl")
+	require.NoError(t, err)
+	assert.Equal(t, "^1.29", entry.Version)
+	assert.Equal(t, "# cluster is 1.29", entry.Comment)
+
+	require.NoError(t, WriteProjectToolEntry(fs, "/project/.vman.yaml", "", "kubectl", &ProjectToolEntry{
+		Version: "1.29.5",
+		Comment: "# pinned 2024-06-01 from ^1.29 by vman",
+	}))
+	require.NoError(t, WriteProjectToolEntry(fs, "/project/.vman.yaml", "services/*", "kubectl", &ProjectToolEntry{
+		Version: "1.28.9",
+	}))
+
+	data, err := afero.ReadFile(fs, "/project/.vman.yaml")
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "# project tools")
+	assert.Contains(t, string(data), "kubectl: 1.29.5 # pinned 2024-06-01 from ^1.29 by vman")
+	assert.Contains(t, string(data), "helm: 3.14.0")
+	assert.Contains(t, string(data), "kubectl: 1.28.9")
+
+	_, err = ReadProjectToolEntry(fs, "/project/.vman.yaml", "", "terraform")
+	assert.Error(t, err)
+}
+
+func TestWriteProjectToolEntry_PreservesIndent(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	original := `version: "1.0"
+tools:
+  kubectl: ^1.29
+  helm: 3.14.0
+defaults:
+  kubectl:
+    args: ["--context=dev"]
+`
+	require.NoError(t, afero.WriteFile(fs, "/project/.vman.yaml", []byte(original), 0644))
+
+	require.NoError(t, WriteProjectToolEntry(fs, "/project/.vman.yaml", "", "kubectl", &ProjectToolEntry{Version: "1.29.5"}))
+	data, err := afero.ReadFile(fs, "/project/.vman.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, strings.Replace(original, "^1.29", "1.29.5", 1), string(data))
+}
+
+func TestWriteProjectToolEntry_ConditionalTool(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/project/.vman.yaml", []byte(`version: "1.0"
+tools:
+    awscli:
+        version: ^2.15
+        only: [darwin, linux/amd64]
+`), 0644))
+
+	entry, err := ReadProjectToolEntry(fs, "/project/.vman.yaml", "", "awscli")
+	require.NoError(t, err)
+	assert.Equal(t, "^2.15", entry.Version)
+
+	require.NoError(t, WriteProjectToolEntry(fs, "/project/.vman.yaml", "", "awscli", &ProjectToolEntry{Version: "2.15.3"}))
+	data, err := afero.ReadFile(fs, "/project/.vman.yaml")
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "version: 2.15.3")
+	assert.Contains(t, string(data), "only: [darwin, linux/amd64]")
+}
+
+func TestPinHistory(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	history, err := LoadPinHistory(fs, "/config/pins.json")
+	require.NoError(t, err)
+	assert.Empty(t, history)
+
+	history.Record("/project/.vman.yaml", PinKey("", "kubectl"), &PinRecord{Previous: "^1.29", Version: "1.29.5"})
+	history.Record("/project/.vman.yaml", PinKey("services/*", "kubectl"), &PinRecord{Previous: "~1.28", Version: "1.28.9"})
+	require.NoError(t, history.Save(fs, "/config/pins.json"))
+
+	loaded, err := LoadPinHistory(fs, "/config/pins.json")
+	require.NoError(t, err)
+	record, ok := loaded.Take("/project/.vman.yaml", "kubectl")
+	require.True(t, ok)
+	assert.Equal(t, "^1.29", record.Previous)
+
+	_, ok = loaded.Take("/project/.vman.yaml", "kubectl")
+	assert.False(t, ok)
+	_, ok = loaded.Take("/project/.vman.yaml", "services/*:kubectl")
+	assert.True(t, ok)
+	assert.Empty(t, loaded)
+}
@@ -0,0 +1,147 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+// ProjectsFile 配置目录中记录通过 vman projects add 注册的项目的文件名
+const ProjectsFile = "projects.json"
+
+// ProjectVersionFiles 项目目录中记录工具版本的文件，清理和跨项目检查据此确定项目使用的版本
+var ProjectVersionFiles = []string{".vman.yaml", ".vman-version", ".tool-versions"}
+
+// RegisteredProject 注册的项目
+type RegisteredProject struct {
+	Path    string    `json:"path"`
+	AddedAt time.Time `json:"added_at"`
+}
+
+// ProjectRegistry 注册的项目，按路径排序
+type ProjectRegistry struct {
+	Projects []RegisteredProject `json:"projects"`
+}
+
+// LoadProjectRegistry 读取项目注册表，文件不存在时返回空注册表
+func LoadProjectRegistry(fs afero.Fs, path string) (*ProjectRegistry, error) {
+	registry := &ProjectRegistry{}
+	data, err := afero.ReadFile(fs, path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return registry, nil
+		}
+		return nil, fmt.Errorf("failed to read project registry: %w", err)
+	}
+	if err := json.Unmarshal(data, registry); err != nil {
+		return nil, fmt.Errorf("failed to parse project registry: %w", err)
+	}
+	return registry, nil
+}
+
+// Save 保存项目注册表
+func (r *ProjectRegistry) Save(fs afero.Fs, path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal project registry: %w", err)
+	}
+	if err := fs.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create project registry directory: %w", err)
+	}
+	if err := afero.WriteFile(fs, path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write project registry: %w", err)
+	}
+	return nil
+}
+
+// Add 注册项目，已注册时返回 false
+func (r *ProjectRegistry) Add(path string, now time.Time) bool {
+	if r.Contains(path) {
+		return false
+	}
+	r.Projects = append(r.Projects, RegisteredProject{Path: path, AddedAt: now})
+	sort.Slice(r.Projects, func(i, j int) bool { return r.Projects[i].Path < r.Projects[j].Path })
+	return true
+}
+
+// Remove 取消注册项目，未注册时返回 false
+func (r *ProjectRegistry) Remove(path string) bool {
+	for i, project := range r.Projects {
+		if project.Path == path {
+			r.Projects = append(r.Projects[:i], r.Projects[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Contains 项目是否已注册
+func (r *ProjectRegistry) Contains(path string) bool {
+	for _, project := range r.Projects {
+		if project.Path == path {
+			return true
+		}
+	}
+	return false
+}
+
+// Paths 所有注册项目的路径
+func (r *ProjectRegistry) Paths() []string {
+	paths := make([]string, 0, len(r.Projects))
+	for _, project := range r.Projects {
+		paths = append(paths, project.Path)
+	}
+	return paths
+}
+
+// HasProjectVersionFile 目录中是否有记录工具版本的文件
+func HasProjectVersionFile(fs afero.Fs, dir string) bool {
+	for _, name := range ProjectVersionFiles {
+		if info, err := fs.Stat(filepath.Join(dir, name)); err == nil && !info.IsDir() {
+			return true
+		}
+	}
+	return false
+}
+
+// scanSkipDirs 扫描项目时跳过的目录
+var scanSkipDirs = map[string]bool{".git": true, "node_modules": true, "vendor": true, ".terraform": true}
+
+// ScanProjects 在 root 下查找有版本文件的目录，maxDepth 为相对 root 的最大深度，0 表示只检查 root
+func ScanProjects(fs afero.Fs, root string, maxDepth int) ([]string, error) {
+	var projects []string
+	var walk func(dir string, depth int) error
+	walk = func(dir string, depth int) error {
+		if HasProjectVersionFile(fs, dir) {
+			projects = append(projects, dir)
+		}
+		if depth >= maxDepth {
+			return nil
+		}
+		entries, err := afero.ReadDir(fs, dir)
+		if err != nil {
+			if dir == root {
+				return fmt.Errorf("failed to read directory %s: %w", dir, err)
+			}
+			return nil
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() || scanSkipDirs[entry.Name()] {
+				continue
+			}
+			if err := walk(filepath.Join(dir, entry.Name()), depth+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := walk(root, 0); err != nil {
+		return nil, err
+	}
+	return projects, nil
+}
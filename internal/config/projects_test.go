@@ -0,0 +1,58 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProjectRegistry(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	path := "/home/user/.vman/projects.json"
+
+	registry, err := LoadProjectRegistry(fs, path)
+	require.NoError(t, err)
+	assert.Empty(t, registry.Projects)
+
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	assert.True(t, registry.Add("/src/web", now))
+	assert.True(t, registry.Add("/src/api", now))
+	assert.False(t, registry.Add("/src/api", now))
+	require.NoError(t, registry.Save(fs, path))
+
+	loaded, err := LoadProjectRegistry(fs, path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/src/api", "/src/web"}, loaded.Paths())
+	assert.True(t, loaded.Projects[0].AddedAt.Equal(now))
+
+	assert.True(t, loaded.Remove("/src/api"))
+	assert.False(t, loaded.Remove("/src/api"))
+	assert.Equal(t, []string{"/src/web"}, loaded.Paths())
+}
+
+func TestScanProjects(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	for _, file := range []string{
+		"/src/.vman.yaml",
+		"/src/api/.tool-versions",
+		"/src/web/app/.vman-version",
+		"/src/web/node_modules/dep/.vman.yaml",
+		"/src/deep/a/b/.vman.yaml",
+	} {
+		require.NoError(t, afero.WriteFile(fs, file, []byte("kubectl 1.29.0\n"), 0644))
+	}
+
+	projects, err := ScanProjects(fs, "/src", 2)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/src", "/src/api", "/src/web/app"}, projects)
+
+	projects, err = ScanProjects(fs, "/src", 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/src"}, projects)
+
+	_, err = ScanProjects(fs, "/missing", 2)
+	assert.Error(t, err)
+}
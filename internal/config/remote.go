@@ -0,0 +1,384 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/songzhibin97/vman/pkg/types"
+)
+
+// RemoteCacheDir 缓存目录下保存远程配置的目录名
+const RemoteCacheDir = "remote-config"
+
+// remoteConfigKeys 远程配置可以覆盖的全局配置项，tools 记录本机已安装的版本，不由远程配置下发
+var remoteConfigKeys = []string{"settings", "global_versions", "aliases"}
+
+// RemoteProtectedSettings 可以执行命令、替换下载来源或信任的密钥、转发认证头、改变主机名解析
+// 或权限检查的设置项，只有列在本地配置的 settings.remote.trust 中时才由远程配置覆盖
+var RemoteProtectedSettings = []string{
+	"hooks", "env", "recipes", "download.mirror", "download.redirects", "network",
+	"storage", "system", "readonly", "root_policy",
+}
+
+// IsRemoteConfigURL 检查 settings.remote.url 是否为支持的远程配置地址，远程配置只能通过HTTPS读取
+func IsRemoteConfigURL(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	switch u.Scheme {
+	case "https":
+		return true
+	case "consul+https", "etcd+https":
+		return strings.Trim(u.Path, "/") != ""
+	}
+	return false
+}
+
+// fetchRemoteConfig 从远程配置地址读取配置：
+// https 直接下载，consul 读取 KV 中的键（令牌来自 CONSUL_HTTP_TOKEN），etcd 通过 v3 HTTP 接口读取键
+func fetchRemoteConfig(ctx context.Context, rawURL string) ([]byte, error) {
+	if !IsRemoteConfigURL(rawURL) {
+		return nil, fmt.Errorf("unsupported remote config URL, must be an https, consul+https, or etcd+https URL")
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	scheme := strings.TrimSuffix(u.Scheme, "+https")
+	endpoint := &url.URL{Scheme: "https", Host: u.Host}
+	key := strings.TrimPrefix(u.Path, "/")
+
+	switch scheme {
+	case "consul":
+		endpoint.Path = "/v1/kv/" + key
+		query := u.Query()
+		query.Set("raw", "")
+		endpoint.RawQuery = query.Encode()
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint.String(), nil)
+		if err != nil {
+			return nil, err
+		}
+		if token := os.Getenv("CONSUL_HTTP_TOKEN"); token != "" {
+			req.Header.Set("X-Consul-Token", token)
+		}
+		return doConfigRequest(req)
+	case "etcd":
+		return fetchEtcdKey(ctx, endpoint, u.User, key)
+	default:
+		return fetchConfigURL(ctx, rawURL)
+	}
+}
+
+// fetchEtcdKey 通过 etcd v3 的 HTTP 接口读取键，URL 中有用户名和密码时先认证
+func fetchEtcdKey(ctx context.Context, endpoint *url.URL, user *url.Userinfo, key string) ([]byte, error) {
+	post := func(path string, body interface{}, token string) ([]byte, error) {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		target := *endpoint
+		target.Path = path
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, target.String(), bytes.NewReader(payload))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", token)
+		}
+		return doConfigRequest(req)
+	}
+
+	var token string
+	if user != nil {
+		password, _ := user.Password()
+		data, err := post("/v3/auth/authenticate", map[string]string{"name": user.Username(), "password": password}, "")
+		if err != nil {
+			return nil, fmt.Errorf("etcd authentication failed: %w", err)
+		}
+		var auth struct {
+			Token string `json:"token"`
+		}
+		if err := json.Unmarshal(data, &auth); err != nil {
+			return nil, fmt.Errorf("etcd authentication failed: %w", err)
+		}
+		token = auth.Token
+	}
+
+	data, err := post("/v3/kv/range", map[string]string{"key": base64.StdEncoding.EncodeToString([]byte(key))}, token)
+	if err != nil {
+		return nil, err
+	}
+	var result struct {
+		Kvs []struct {
+			Value string `json:"value"`
+		} `json:"kvs"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("invalid etcd response: %w", err)
+	}
+	if len(result.Kvs) == 0 {
+		return nil, fmt.Errorf("key %s not found", key)
+	}
+	return base64.StdEncoding.DecodeString(result.Kvs[0].Value)
+}
+
+// applyRemoteConfig 读取 settings.remote 指定的远程配置，覆盖 doc 中的全局配置项，返回覆盖的值和合并后的加密值
+// 远程配置不可用且没有缓存时只给出警告，使用本地配置
+func (m *DefaultManager) applyRemoteConfig(doc *yaml.Node, remote types.RemoteSettings, secrets types.SecretValues) (*types.RemoteLayer, types.SecretValues, error) {
+	data, stale, err := m.readCachedSource(RemoteCacheDir, remote.URL, remote.GetTTL(), fetchRemoteConfig)
+	if err != nil {
+		m.logger.Warnf("Failed to load remote config %s, using local settings: %v", redactURL(remote.URL), err)
+		return nil, secrets, nil
+	}
+	data, remoteSecrets, _, err := decryptYAML(data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load remote config %s: %w", redactURL(remote.URL), err)
+	}
+	var remoteDoc yaml.Node
+	if err := yaml.Unmarshal(data, &remoteDoc); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse remote config %s: %w", redactURL(remote.URL), err)
+	}
+
+	layer := &types.RemoteLayer{Source: redactURL(remote.URL), Stale: stale}
+	if len(remoteDoc.Content) == 0 {
+		return layer, secrets, nil
+	}
+	root := documentMapping(doc)
+	for _, key := range remoteConfigKeys {
+		value := mappingValue(remoteDoc.Content[0], key)
+		if value == nil {
+			continue
+		}
+		if key == "settings" {
+			// 远程配置不能再指定远程配置，受保护的设置项需要本地配置信任
+			value = withoutKey(value, "remote")
+			var ignored []string
+			value, ignored = restrictRemoteSettings(value, remote.Trust)
+			for _, setting := range ignored {
+				m.logger.Warnf("Ignoring settings.%s from remote config %s, add it to settings.remote.trust to allow it", setting, redactURL(remote.URL))
+			}
+		}
+		overrideNode(root, []string{key}, value, layer)
+	}
+
+	// 显示配置时同样隐藏远程配置中加密的值，与本地配置重叠的路径保留本地的加密内容用于保存
+	for path, secret := range remoteSecrets {
+		if _, ok := secrets[path]; !ok {
+			if secrets == nil {
+				secrets = make(types.SecretValues)
+			}
+			secrets[path] = types.SecretValue{Plaintext: secret.Plaintext}
+		}
+	}
+	return layer, secrets, nil
+}
+
+// normalizeRemoteValues 将远程配置的值换成解析后重新序列化的形式（如 5m 为 5m0s），保存时与配置中的值比较
+func normalizeRemoteValues(config *types.GlobalConfig) error {
+	var doc yaml.Node
+	if err := doc.Encode(config); err != nil {
+		return err
+	}
+	values := config.Remote.Values[:0]
+	for _, remote := range config.Remote.Values {
+		// 全局配置中没有的设置项不会生效，不需要记录
+		node := findNodePath(&doc, remote.Path)
+		if node == nil {
+			continue
+		}
+		remote.Value = nil
+		if err := node.Decode(&remote.Value); err != nil {
+			return err
+		}
+		values = append(values, remote)
+	}
+	config.Remote.Values = values
+	return nil
+}
+
+// withoutRemote 序列化全局配置，没有修改的远程配置值换回本地配置中的值，修改过的值写入本地配置
+func withoutRemote(config *types.GlobalConfig) ([]byte, error) {
+	if config.Remote == nil {
+		return yaml.Marshal(config)
+	}
+	var doc yaml.Node
+	if err := doc.Encode(config); err != nil {
+		return nil, err
+	}
+	for _, remote := range config.Remote.Values {
+		node := findNodePath(&doc, remote.Path)
+		if node == nil {
+			continue
+		}
+		var value interface{}
+		if err := node.Decode(&value); err != nil || !reflect.DeepEqual(value, remote.Value) {
+			continue
+		}
+		if remote.Local != nil {
+			if err := setNodePath(&doc, remote.Path, remote.Local); err != nil {
+				return nil, err
+			}
+		} else {
+			removeNodePath(&doc, remote.Path)
+		}
+	}
+	return yaml.Marshal(&doc)
+}
+
+// documentMapping 返回 YAML 文档的根映射节点，空文档时创建
+func documentMapping(doc *yaml.Node) *yaml.Node {
+	if doc.Kind != yaml.DocumentNode {
+		*doc = yaml.Node{Kind: yaml.DocumentNode}
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		doc.Content = []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}
+	}
+	return doc.Content[0]
+}
+
+// overrideNode 用 value 覆盖 mapping 中 path 的值：映射逐键合并，其他值整体替换，替换的值记录到 layer
+func overrideNode(mapping *yaml.Node, path []string, value *yaml.Node, layer *types.RemoteLayer) {
+	key := path[len(path)-1]
+	current := mappingValue(mapping, key)
+	if value.Kind == yaml.MappingNode {
+		if current == nil || current.Kind != yaml.MappingNode {
+			current = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			setMappingValue(mapping, key, current)
+		}
+		for i := 0; i+1 < len(value.Content); i += 2 {
+			childPath := append(append([]string{}, path...), value.Content[i].Value)
+			overrideNode(current, childPath, value.Content[i+1], layer)
+		}
+		return
+	}
+
+	remoteValue := types.RemoteValue{Path: path}
+	if current != nil {
+		_ = current.Decode(&remoteValue.Local)
+	}
+	layer.Values = append(layer.Values, remoteValue)
+	setMappingValue(mapping, key, value)
+}
+
+// withoutKey 返回去掉 key 的映射节点副本
+func withoutKey(mapping *yaml.Node, key string) *yaml.Node {
+	if mapping.Kind != yaml.MappingNode {
+		return mapping
+	}
+	copied := *mapping
+	copied.Content = nil
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value != key {
+			copied.Content = append(copied.Content, mapping.Content[i], mapping.Content[i+1])
+		}
+	}
+	return &copied
+}
+
+// restrictRemoteSettings 去掉远程配置的 settings 中没有被信任的受保护设置项，返回去掉的设置项
+func restrictRemoteSettings(settings *yaml.Node, trust []string) (*yaml.Node, []string) {
+	var ignored []string
+	for _, setting := range RemoteProtectedSettings {
+		if slices.Contains(trust, setting) {
+			continue
+		}
+		if restricted, ok := withoutPath(settings, strings.Split(setting, ".")); ok {
+			settings = restricted
+			ignored = append(ignored, setting)
+		}
+	}
+	return settings, ignored
+}
+
+// withoutPath 返回去掉 path 的映射节点副本，path 不存在时返回 false
+func withoutPath(mapping *yaml.Node, path []string) (*yaml.Node, bool) {
+	value := mappingValue(mapping, path[0])
+	if value == nil {
+		return mapping, false
+	}
+	if len(path) == 1 {
+		return withoutKey(mapping, path[0]), true
+	}
+	child, ok := withoutPath(value, path[1:])
+	if !ok {
+		return mapping, false
+	}
+	copied := *mapping
+	copied.Content = append([]*yaml.Node(nil), mapping.Content...)
+	setMappingValue(&copied, path[0], child)
+	return &copied, true
+}
+
+// setMappingValue 设置映射节点中 key 的值，没有该键时添加
+func setMappingValue(mapping *yaml.Node, key string, value *yaml.Node) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			mapping.Content[i+1] = value
+			return
+		}
+	}
+	mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, value)
+}
+
+// findNodePath 按键路径查找 YAML 文档中的节点
+func findNodePath(doc *yaml.Node, path []string) *yaml.Node {
+	node := doc
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+	for _, key := range path {
+		if node = mappingValue(node, key); node == nil {
+			return nil
+		}
+	}
+	return node
+}
+
+// setNodePath 按键路径设置 YAML 文档中的值，创建缺少的映射
+func setNodePath(doc *yaml.Node, path []string, value interface{}) error {
+	var node yaml.Node
+	if err := node.Encode(value); err != nil {
+		return err
+	}
+	mapping := doc
+	if mapping.Kind != yaml.MappingNode {
+		mapping = documentMapping(doc)
+	}
+	for _, key := range path[:len(path)-1] {
+		child := mappingValue(mapping, key)
+		if child == nil || child.Kind != yaml.MappingNode {
+			child = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			setMappingValue(mapping, key, child)
+		}
+		mapping = child
+	}
+	setMappingValue(mapping, path[len(path)-1], &node)
+	return nil
+}
+
+// removeNodePath 按键路径删除 YAML 文档中的值
+func removeNodePath(doc *yaml.Node, path []string) {
+	parent := findNodePath(doc, path[:len(path)-1])
+	if parent == nil || parent.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(parent.Content); i += 2 {
+		if parent.Content[i].Value == path[len(path)-1] {
+			parent.Content = append(parent.Content[:i], parent.Content[i+2:]...)
+			return
+		}
+	}
+}
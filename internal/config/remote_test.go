@@ -0,0 +1,239 @@
+package config
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/songzhibin97/vman/pkg/types"
+)
+
+const remoteSettings = `
+settings:
+  download:
+    timeout: 10m
+    mirror: https://mirror.example.com/vman
+  hooks:
+    pre_exec: [/tmp/evil.sh]
+  readonly: true
+  remote:
+    url: https://elsewhere.example.com/config.yaml
+global_versions:
+  terraform: 1.7.0
+tools:
+  kubectl:
+    current_version: 9.9.9
+`
+
+func TestLoadGlobalRemoteConfig(t *testing.T) {
+	requests := 0
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(remoteSettings))
+	}))
+	defer server.Close()
+	useTestHTTPClient(t, server)
+
+	manager := newExtendsTestManager(t, nil)
+	local := `version: "1.0"
+settings:
+  download:
+    timeout: 5m
+    retries: 5
+  remote:
+    url: ` + server.URL + `/config.yaml
+    trust: [download.mirror]
+global_versions:
+  terraform: 1.5.0
+  kubectl: 1.29.0
+`
+	require.NoError(t, afero.WriteFile(manager.fs, manager.paths.GlobalConfigFile, []byte(local), 0644))
+
+	config, err := manager.LoadGlobal()
+	require.NoError(t, err)
+	assert.Equal(t, 10*time.Minute, config.Settings.Download.Timeout)
+	assert.Equal(t, "https://mirror.example.com/vman", config.Settings.Download.Mirror)
+	assert.Equal(t, 5, config.Settings.Download.Retries)
+	assert.Equal(t, server.URL+"/config.yaml", config.Settings.Remote.URL)
+	// 没有被信任的受保护设置项不由远程配置覆盖
+	assert.Empty(t, config.Settings.Hooks.PreExec)
+	assert.False(t, config.Settings.ReadOnly)
+	assert.Equal(t, map[string]string{"terraform": "1.7.0", "kubectl": "1.29.0"}, config.GlobalVersions)
+	assert.Empty(t, config.Tools)
+	require.NotNil(t, config.Remote)
+	assert.False(t, config.Remote.Stale)
+
+	// 保存时没有修改的远程配置值不写入本地配置
+	config.GlobalVersions["kubectl"] = "1.30.0"
+	config.Settings.Download.Retries = 3
+	require.NoError(t, manager.SaveGlobal(config))
+	saved, err := afero.ReadFile(manager.fs, manager.paths.GlobalConfigFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(saved), "timeout: 5m")
+	assert.Contains(t, string(saved), "terraform: 1.5.0")
+	assert.Contains(t, string(saved), "kubectl: 1.30.0")
+	assert.NotContains(t, string(saved), "mirror.example.com")
+
+	// 缓存有效期内不再下载，过期后下载失败时使用过期的缓存
+	manager.invalidateGlobal()
+	_, err = manager.LoadGlobal()
+	require.NoError(t, err)
+	assert.Equal(t, 1, requests)
+
+	server.Close()
+	old := time.Now().Add(-2 * types.DefaultRemoteTTL)
+	cached, err := afero.Glob(manager.fs, filepath.Join(manager.paths.CacheDir, RemoteCacheDir, "*.yaml"))
+	require.NoError(t, err)
+	require.Len(t, cached, 1)
+	require.NoError(t, manager.fs.Chtimes(cached[0], old, old))
+	manager.invalidateGlobal()
+	config, err = manager.LoadGlobal()
+	require.NoError(t, err)
+	assert.True(t, config.Remote.Stale)
+	assert.Equal(t, "https://mirror.example.com/vman", config.Settings.Download.Mirror)
+
+	// 没有缓存时使用本地配置
+	require.NoError(t, manager.fs.Remove(cached[0]))
+	manager.invalidateGlobal()
+	config, err = manager.LoadGlobal()
+	require.NoError(t, err)
+	assert.Nil(t, config.Remote)
+	assert.Empty(t, config.Settings.Download.Mirror)
+}
+
+func TestFetchRemoteConfigProviders(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/kv/vman/config":
+			if _, raw := r.URL.Query()["raw"]; !raw || r.Header.Get("X-Consul-Token") != "consul-token" {
+				http.Error(w, "forbidden", http.StatusForbidden)
+				return
+			}
+			w.Write([]byte("from: consul\n"))
+		case "/v3/auth/authenticate":
+			var auth map[string]string
+			json.NewDecoder(r.Body).Decode(&auth)
+			if auth["name"] != "vman" || auth["password"] != "secret" {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			w.Write([]byte(`{"token":"etcd-token"}`))
+		case "/v3/kv/range":
+			var query map[string]string
+			json.NewDecoder(r.Body).Decode(&query)
+			key, _ := base64.StdEncoding.DecodeString(query["key"])
+			if string(key) != "vman/config" || r.Header.Get("Authorization") != "etcd-token" {
+				w.Write([]byte(`{}`))
+				return
+			}
+			w.Write([]byte(`{"kvs":[{"value":"` + base64.StdEncoding.EncodeToString([]byte("from: etcd\n")) + `"}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	useTestHTTPClient(t, server)
+	host := strings.TrimPrefix(server.URL, "https://")
+	t.Setenv("CONSUL_HTTP_TOKEN", "consul-token")
+
+	data, err := fetchRemoteConfig(context.Background(), "consul+https://"+host+"/vman/config")
+	require.NoError(t, err)
+	assert.Equal(t, "from: consul\n", string(data))
+
+	data, err = fetchRemoteConfig(context.Background(), "etcd+https://vman:secret@"+host+"/vman/config")
+	require.NoError(t, err)
+	assert.Equal(t, "from: etcd\n", string(data))
+
+	_, err = fetchRemoteConfig(context.Background(), "etcd+https://vman:secret@"+host+"/missing")
+	assert.ErrorContains(t, err, "not found")
+
+	assert.True(t, IsRemoteConfigURL("consul+https://consul.internal:8501/vman/config"))
+	assert.False(t, IsRemoteConfigURL("consul://consul.internal:8500"))
+	assert.False(t, IsRemoteConfigURL("ftp://example.com/config.yaml"))
+
+	// 远程配置只能通过HTTPS读取
+	assert.False(t, IsRemoteConfigURL("http://config.example.com/vman.yaml"))
+	assert.False(t, IsRemoteConfigURL("consul://consul.internal:8500/vman/config"))
+	_, err = fetchRemoteConfig(context.Background(), "etcd://vman:secret@"+host+"/vman/config")
+	assert.ErrorContains(t, err, "unsupported remote config URL")
+}
+
+func TestRestrictRemoteSettings(t *testing.T) {
+	var doc yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(`
+download:
+  timeout: 10m
+  mirror: https://mirror.example.com/vman
+env:
+  vars:
+    LD_PRELOAD: /tmp/evil.so
+recipes:
+  registries:
+    - name: evil
+      url: https://evil.example.com
+logging:
+  level: debug
+`), &doc))
+	settings := doc.Content[0]
+
+	restricted, ignored := restrictRemoteSettings(settings, nil)
+	assert.Equal(t, []string{"env", "recipes", "download.mirror"}, ignored)
+	var got map[string]interface{}
+	require.NoError(t, restricted.Decode(&got))
+	assert.Equal(t, map[string]interface{}{
+		"download": map[string]interface{}{"timeout": "10m"},
+		"logging":  map[string]interface{}{"level": "debug"},
+	}, got)
+	// 原节点不被修改
+	assert.NotNil(t, mappingValue(mappingValue(settings, "download"), "mirror"))
+
+	_, ignored = restrictRemoteSettings(settings, []string{"env", "recipes", "download.mirror"})
+	assert.Empty(t, ignored)
+}
+
+func TestRestrictRemoteSettings_CredentialsAndNetwork(t *testing.T) {
+	var doc yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(`
+download:
+  redirects:
+    keep_auth_hosts: ["*"]
+network:
+  hosts:
+    github.com: 203.0.113.7
+  dns_servers: [203.0.113.53]
+`), &doc))
+	settings := doc.Content[0]
+
+	// 未信任时远程配置不能转发认证头，也不能改变主机名解析
+	restricted, ignored := restrictRemoteSettings(settings, nil)
+	assert.Equal(t, []string{"download.redirects", "network"}, ignored)
+	var got types.Settings
+	require.NoError(t, restricted.Decode(&got))
+	assert.Empty(t, got.Download.Redirects.KeepAuthHosts)
+	assert.Empty(t, got.Network.Hosts)
+	assert.Empty(t, got.Network.DNSServers)
+
+	restricted, ignored = restrictRemoteSettings(settings, []string{"download.redirects", "network"})
+	assert.Empty(t, ignored)
+	require.NoError(t, restricted.Decode(&got))
+	assert.Equal(t, []string{"*"}, got.Download.Redirects.KeepAuthHosts)
+	assert.Equal(t, []string{"203.0.113.53"}, got.Network.DNSServers)
+}
+
+// useTestHTTPClient 测试期间使用信任测试服务器证书的HTTP客户端
+func useTestHTTPClient(t *testing.T, server *httptest.Server) {
+	original := HTTPClient
+	HTTPClient = server.Client()
+	t.Cleanup(func() { HTTPClient = original })
+}
@@ -0,0 +1,135 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/songzhibin97/vman/pkg/types"
+)
+
+// BackupsDirName 数据目录中保存配置备份的目录名
+const BackupsDirName = "backups"
+
+// BackupsDir 返回保存配置备份的目录
+// 备份位于数据目录而不是配置目录中，重置配置时不会被一并删除
+func BackupsDir(paths *types.ConfigPaths) string {
+	return filepath.Join(paths.DataDir, BackupsDirName)
+}
+
+// ResetScope 重置的范围
+type ResetScope string
+
+const (
+	// ResetConfig 将全局配置恢复为默认值，工具定义和已安装的版本保持不变
+	ResetConfig ResetScope = "config"
+	// ResetCache 清空缓存目录和临时目录
+	ResetCache ResetScope = "cache"
+	// ResetTools 删除工具定义、已安装的版本和垫片，并从全局配置中移除这些工具
+	ResetTools ResetScope = "tools"
+	// ResetAll 执行以上所有重置，备份目录保留
+	ResetAll ResetScope = "all"
+)
+
+// ResetTargets 返回重置时将删除的路径，用于在执行前展示给用户确认
+// 只有 ResetTools 可以指定工具，未指定时重置所有工具
+func (api *DefaultAPI) ResetTargets(scope ResetScope, tools ...string) ([]string, error) {
+	if len(tools) > 0 && scope != ResetTools {
+		return nil, fmt.Errorf("tools can only be specified when resetting tools")
+	}
+	for _, tool := range tools {
+		if err := api.validator.ValidateToolName(tool); err != nil {
+			return nil, err
+		}
+	}
+
+	switch scope {
+	case ResetConfig:
+		return []string{api.paths.GlobalConfigFile}, nil
+	case ResetCache:
+		return []string{api.paths.CacheDir, api.paths.TempDir}, nil
+	case ResetTools:
+		if len(tools) == 0 {
+			return []string{api.paths.ToolsDir, api.paths.VersionsDir, api.paths.ShimsDir, api.paths.BinDir}, nil
+		}
+		var targets []string
+		for _, tool := range tools {
+			targets = append(targets,
+				filepath.Join(api.paths.ToolsDir, tool+".toml"),
+				filepath.Join(api.paths.VersionsDir, tool),
+			)
+		}
+		return targets, nil
+	case ResetAll:
+		var targets []string
+		for _, s := range []ResetScope{ResetConfig, ResetCache, ResetTools} {
+			scoped, _ := api.ResetTargets(s)
+			targets = append(targets, scoped...)
+		}
+		return targets, nil
+	default:
+		return nil, fmt.Errorf("unknown reset scope: %s", scope)
+	}
+}
+
+// Reset 按范围重置，只删除 ResetTargets 返回的路径并重新创建所需的目录和默认配置
+// 重置前将全局配置和工具定义备份到 BackupsDir 中，返回备份的路径
+func (api *DefaultAPI) Reset(ctx context.Context, scope ResetScope, tools ...string) (string, error) {
+	targets, err := api.ResetTargets(scope, tools...)
+	if err != nil {
+		return "", err
+	}
+	api.logger.Warnf("Resetting vman %s", scope)
+
+	backupPath := api.newBackupPath("reset-")
+	if _, err := api.Backup(ctx, backupPath, &BackupOptions{Reason: "reset"}); err != nil {
+		return "", fmt.Errorf("failed to backup configuration before reset: %w", err)
+	}
+
+	for _, target := range targets {
+		if err := api.fs.RemoveAll(target); err != nil && !os.IsNotExist(err) {
+			return backupPath, fmt.Errorf("failed to remove %s: %w", target, err)
+		}
+	}
+
+	switch scope {
+	case ResetConfig, ResetAll:
+		if err := api.manager.SaveGlobal(types.GetDefaultGlobalConfig()); err != nil {
+			return backupPath, fmt.Errorf("failed to restore default global config: %w", err)
+		}
+	case ResetTools:
+		if err := api.forgetTools(tools); err != nil {
+			return backupPath, err
+		}
+	}
+
+	if err := api.Init(ctx); err != nil {
+		return backupPath, fmt.Errorf("failed to reinitialize after reset: %w", err)
+	}
+
+	api.logger.Infof("Reset of %s completed", scope)
+	return backupPath, nil
+}
+
+// forgetTools 从全局配置中移除工具的全局版本和安装记录，tools 为空时移除所有工具
+func (api *DefaultAPI) forgetTools(tools []string) error {
+	globalConfig, err := api.manager.LoadGlobal()
+	if err != nil {
+		return fmt.Errorf("failed to load global config: %w", err)
+	}
+
+	if len(tools) == 0 {
+		globalConfig.GlobalVersions = make(map[string]string)
+		globalConfig.Tools = make(map[string]types.ToolInfo)
+	}
+	for _, tool := range tools {
+		delete(globalConfig.GlobalVersions, tool)
+		delete(globalConfig.Tools, tool)
+	}
+
+	if err := api.manager.SaveGlobal(globalConfig); err != nil {
+		return fmt.Errorf("failed to save global config: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package config
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/songzhibin97/vman/pkg/types"
+)
+
+func newTestAPI(t *testing.T) (*DefaultAPI, afero.Fs) {
+	fs := afero.NewMemMapFs()
+	paths := types.ConfigPathsFromRoot("/home/test/.vman")
+	manager := &DefaultManager{fs: fs, paths: paths, logger: testLogger()}
+	require.NoError(t, manager.Initialize())
+
+	api := &DefaultAPI{
+		manager:   manager,
+		validator: NewValidator(),
+		logger:    testLogger(),
+		fs:        fs,
+		paths:     paths,
+	}
+	return api, fs
+}
+
+func TestReset_Tools(t *testing.T) {
+	api, fs := newTestAPI(t)
+	paths := api.paths
+
+	for _, tool := range []string{"kubectl", "helm"} {
+		require.NoError(t, afero.WriteFile(fs, filepath.Join(paths.ToolsDir, tool+".toml"), []byte("name = \""+tool+"\""), 0600))
+		require.NoError(t, afero.WriteFile(fs, filepath.Join(paths.VersionsDir, tool, "1.0.0", "bin", tool), []byte("bin"), 0755))
+	}
+	globalConfig, err := api.manager.LoadGlobal()
+	require.NoError(t, err)
+	globalConfig.GlobalVersions = map[string]string{"kubectl": "1.0.0", "helm": "1.0.0"}
+	require.NoError(t, api.manager.SaveGlobal(globalConfig))
+
+	_, err = api.ResetTargets(ResetCache, "kubectl")
+	assert.Error(t, err)
+	_, err = api.ResetTargets(ResetTools, "../etc")
+	assert.Error(t, err)
+
+	backup, err := api.Reset(context.Background(), ResetTools, "kubectl")
+	require.NoError(t, err)
+	assert.Equal(t, BackupsDir(paths), filepath.Dir(backup))
+
+	// 备份中保留了重置前的工具定义
+	exists, _ := afero.Exists(fs, filepath.Join(backup, "tools", "kubectl.toml"))
+	assert.True(t, exists)
+
+	// 只删除指定工具
+	exists, _ = afero.Exists(fs, filepath.Join(paths.VersionsDir, "kubectl"))
+	assert.False(t, exists)
+	exists, _ = afero.Exists(fs, filepath.Join(paths.ToolsDir, "kubectl.toml"))
+	assert.False(t, exists)
+	exists, _ = afero.Exists(fs, filepath.Join(paths.VersionsDir, "helm", "1.0.0", "bin", "helm"))
+	assert.True(t, exists)
+
+	globalConfig, err = api.manager.LoadGlobal()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"helm": "1.0.0"}, globalConfig.GlobalVersions)
+}
+
+func TestReset_ConfigKeepsVersions(t *testing.T) {
+	api, fs := newTestAPI(t)
+	paths := api.paths
+
+	binary := filepath.Join(paths.VersionsDir, "kubectl", "1.0.0", "bin", "kubectl")
+	require.NoError(t, afero.WriteFile(fs, binary, []byte("bin"), 0755))
+	require.NoError(t, afero.WriteFile(fs, filepath.Join(paths.CacheDir, "prompt.json"), []byte("{}"), 0644))
+	globalConfig, err := api.manager.LoadGlobal()
+	require.NoError(t, err)
+	globalConfig.GlobalVersions = map[string]string{"kubectl": "1.0.0"}
+	require.NoError(t, api.manager.SaveGlobal(globalConfig))
+
+	_, err = api.Reset(context.Background(), ResetConfig)
+	require.NoError(t, err)
+	globalConfig, err = api.manager.LoadGlobal()
+	require.NoError(t, err)
+	assert.Empty(t, globalConfig.GlobalVersions)
+	exists, _ := afero.Exists(fs, binary)
+	assert.True(t, exists)
+
+	_, err = api.Reset(context.Background(), ResetCache)
+	require.NoError(t, err)
+	exists, _ = afero.Exists(fs, filepath.Join(paths.CacheDir, "prompt.json"))
+	assert.False(t, exists)
+	exists, _ = afero.DirExists(fs, paths.CacheDir)
+	assert.True(t, exists)
+	exists, _ = afero.Exists(fs, binary)
+	assert.True(t, exists)
+}
@@ -0,0 +1,367 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+
+	"github.com/songzhibin97/vman/pkg/types"
+	"github.com/songzhibin97/vman/pkg/utils"
+)
+
+// AgeArmorHeader age 加密内容（ASCII armor 格式，age -a）的开头，以此开头的字符串值在读取配置时解密
+const AgeArmorHeader = "-----BEGIN AGE ENCRYPTED FILE-----"
+
+// 系统钥匙串中保存 age 私钥的条目
+const (
+	KeychainService       = "vman"
+	KeychainAgeKeyAccount = "age-key"
+)
+
+// MaskedSecret 显示配置时代替加密的值
+const MaskedSecret = "<encrypted>"
+
+// sopsEncryptedPrefix sops 加密后的值的开头
+const sopsEncryptedPrefix = "ENC["
+
+// ErrSopsEncrypted 配置文件由 sops 加密，vman 不能修改
+var ErrSopsEncrypted = errors.New("the file is encrypted with sops, edit it with sops instead")
+
+// SecretDecryptor 解密配置文件中的加密内容
+type SecretDecryptor interface {
+	// DecryptValue 解密 age 加密的值
+	DecryptValue(ciphertext string) (string, error)
+
+	// DecryptDocument 解密 sops 加密的 YAML 文档
+	DecryptDocument(data []byte) ([]byte, error)
+}
+
+// Decryptor 读取配置时使用的解密器，默认调用 age 和 sops 命令
+var Decryptor SecretDecryptor = commandDecryptor{}
+
+// decryptYAML 解密 YAML 配置：由 sops 加密的文件先整体解密，再解密其中 age 加密的值
+// 返回解密后的文档和其中加密的值；没有加密内容时原样返回
+func decryptYAML(data []byte) ([]byte, types.SecretValues, bool, error) {
+	if !bytes.Contains(data, []byte(AgeArmorHeader)) && !bytes.Contains(data, []byte("sops:")) {
+		return data, nil, false, nil
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil || len(doc.Content) == 0 {
+		// 由调用方报告解析错误
+		return data, nil, false, nil
+	}
+
+	secrets := make(types.SecretValues)
+	sopsEncrypted := mappingValue(doc.Content[0], "sops") != nil
+	if sopsEncrypted {
+		// 记录 sops 加密的值的路径，显示配置时隐藏
+		walkScalars(doc.Content[0], "", func(path string, node *yaml.Node) {
+			if strings.HasPrefix(node.Value, sopsEncryptedPrefix) {
+				secrets[path] = types.SecretValue{Ciphertext: node.Value}
+			}
+		})
+		decrypted, err := Decryptor.DecryptDocument(data)
+		if err != nil {
+			return nil, nil, false, fmt.Errorf("failed to decrypt sops encrypted file: %w", err)
+		}
+		doc = yaml.Node{}
+		if err := yaml.Unmarshal(decrypted, &doc); err != nil || len(doc.Content) == 0 {
+			return decrypted, nil, true, nil
+		}
+	}
+
+	var decryptErr error
+	walkScalars(doc.Content[0], "", func(path string, node *yaml.Node) {
+		if secret, ok := secrets[path]; ok {
+			secret.Plaintext = node.Value
+			secrets[path] = secret
+			return
+		}
+		if decryptErr != nil || !strings.HasPrefix(strings.TrimSpace(node.Value), AgeArmorHeader) {
+			return
+		}
+		plaintext, err := Decryptor.DecryptValue(node.Value)
+		if err != nil {
+			decryptErr = fmt.Errorf("failed to decrypt %s: %w", path, err)
+			return
+		}
+		secrets[path] = types.SecretValue{Ciphertext: node.Value, Plaintext: plaintext}
+		setScalar(node, plaintext)
+	})
+	if decryptErr != nil {
+		return nil, nil, false, decryptErr
+	}
+
+	out, err := yaml.Marshal(&doc)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	return out, secrets, sopsEncrypted, nil
+}
+
+// restoreSecrets 将保存的 YAML 中没有修改的解密值替换回加密内容
+func restoreSecrets(data []byte, secrets types.SecretValues) ([]byte, error) {
+	return replaceSecrets(data, secrets, func(secret types.SecretValue, value string) (string, bool) {
+		return secret.Ciphertext, value == secret.Plaintext && secret.Ciphertext != ""
+	})
+}
+
+// MaskSecrets 将 YAML 中的解密值替换为 MaskedSecret，用于显示配置
+func MaskSecrets(data []byte, secrets types.SecretValues) ([]byte, error) {
+	return replaceSecrets(data, secrets, func(secret types.SecretValue, value string) (string, bool) {
+		return MaskedSecret, true
+	})
+}
+
+// replaceSecrets 按路径替换 YAML 中加密的值，replace 返回 false 时保留原值
+func replaceSecrets(data []byte, secrets types.SecretValues, replace func(secret types.SecretValue, value string) (string, bool)) ([]byte, error) {
+	if len(secrets) == 0 {
+		return data, nil
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 {
+		return data, nil
+	}
+	walkScalars(doc.Content[0], "", func(path string, node *yaml.Node) {
+		if secret, ok := secrets[path]; ok {
+			if value, ok := replace(secret, node.Value); ok {
+				setScalar(node, value)
+			}
+		}
+	})
+	return yaml.Marshal(&doc)
+}
+
+// walkScalars 遍历 YAML 节点中的标量值，path 为以 . 连接的键和序号
+func walkScalars(node *yaml.Node, path string, visit func(path string, node *yaml.Node)) {
+	join := func(key string) string {
+		if path == "" {
+			return key
+		}
+		return path + "." + key
+	}
+	switch node.Kind {
+	case yaml.ScalarNode:
+		visit(path, node)
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			walkScalars(node.Content[i+1], join(node.Content[i].Value), visit)
+		}
+	case yaml.SequenceNode:
+		for i, child := range node.Content {
+			walkScalars(child, join(strconv.Itoa(i)), visit)
+		}
+	}
+}
+
+// setScalar 设置字符串标量的值，多行内容使用块格式
+func setScalar(node *yaml.Node, value string) {
+	node.Value = value
+	node.Tag = "!!str"
+	node.Style = 0
+	if strings.Contains(value, "\n") {
+		node.Style = yaml.LiteralStyle
+	}
+}
+
+// decryptTOML 解密 TOML 配置中 age 加密的值，工具定义中的请求头等凭据可以加密后提交
+func decryptTOML(data []byte) ([]byte, error) {
+	if !bytes.Contains(data, []byte(AgeArmorHeader)) {
+		return data, nil
+	}
+	var document map[string]interface{}
+	if err := toml.Unmarshal(data, &document); err != nil {
+		// 由调用方报告解析错误
+		return data, nil
+	}
+	if err := decryptValues(document, ""); err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(document); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// decryptValues 解密 TOML 文档中 age 加密的字符串
+func decryptValues(value interface{}, path string) error {
+	join := func(key string) string {
+		if path == "" {
+			return key
+		}
+		return path + "." + key
+	}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if s, ok := child.(string); ok && strings.HasPrefix(strings.TrimSpace(s), AgeArmorHeader) {
+				plaintext, err := Decryptor.DecryptValue(s)
+				if err != nil {
+					return fmt.Errorf("failed to decrypt %s: %w", join(key), err)
+				}
+				v[key] = plaintext
+				continue
+			}
+			if err := decryptValues(child, join(key)); err != nil {
+				return err
+			}
+		}
+	case []map[string]interface{}:
+		for i, child := range v {
+			if err := decryptValues(child, join(strconv.Itoa(i))); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for i, child := range v {
+			if s, ok := child.(string); ok && strings.HasPrefix(strings.TrimSpace(s), AgeArmorHeader) {
+				plaintext, err := Decryptor.DecryptValue(s)
+				if err != nil {
+					return fmt.Errorf("failed to decrypt %s: %w", join(strconv.Itoa(i)), err)
+				}
+				v[i] = plaintext
+				continue
+			}
+			if err := decryptValues(child, join(strconv.Itoa(i))); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// commandDecryptor 调用 age 和 sops 命令解密
+type commandDecryptor struct{}
+
+// DecryptValue 使用 age 解密，去掉明文末尾的换行
+func (commandDecryptor) DecryptValue(ciphertext string) (string, error) {
+	identity, err := AgeIdentity()
+	if err != nil {
+		return "", err
+	}
+
+	// age 只从文件读取私钥，写入只有当前用户可以读取的临时文件，解密后删除
+	dir, err := os.MkdirTemp("", "vman-age-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(dir)
+	identityFile := filepath.Join(dir, "identity")
+	if err := os.WriteFile(identityFile, []byte(identity), 0600); err != nil {
+		return "", err
+	}
+
+	cmd := exec.Command("age", "--decrypt", "-i", identityFile)
+	cmd.Stdin = strings.NewReader(ciphertext)
+	output, err := runDecryptCommand(cmd)
+	if err != nil {
+		return "", err
+	}
+	plaintext := strings.TrimSuffix(string(output), "\n")
+	return strings.TrimSuffix(plaintext, "\r"), nil
+}
+
+// DecryptDocument 使用 sops 解密，找到 age 私钥时通过 SOPS_AGE_KEY 传给 sops
+func (commandDecryptor) DecryptDocument(data []byte) ([]byte, error) {
+	file, err := os.CreateTemp("", "vman-sops-*.yaml")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(file.Name())
+	_, err = file.Write(data)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.Command("sops", "--decrypt", "--input-type", "yaml", "--output-type", "yaml", file.Name())
+	cmd.Env = os.Environ()
+	if identity, err := AgeIdentity(); err == nil {
+		cmd.Env = append(cmd.Env, "SOPS_AGE_KEY="+identity)
+	}
+	return runDecryptCommand(cmd)
+}
+
+// runDecryptCommand 执行解密命令，失败时返回命令的错误输出
+func runDecryptCommand(cmd *exec.Cmd) ([]byte, error) {
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if errors.Is(err, exec.ErrNotFound) {
+		return nil, fmt.Errorf("%s is not installed", filepath.Base(cmd.Path))
+	}
+	if err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return nil, fmt.Errorf("%s: %s", filepath.Base(cmd.Path), message)
+		}
+		return nil, err
+	}
+	return output, nil
+}
+
+// keychainLookup 从系统钥匙串读取条目，macOS 使用 security，Linux 使用 secret-tool（libsecret）
+var keychainLookup = func(service, account string) (string, error) {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("security", "find-generic-password", "-s", service, "-a", account, "-w")
+	case "linux", "freebsd":
+		cmd = exec.Command("secret-tool", "lookup", "service", service, "account", account)
+	default:
+		return "", fmt.Errorf("reading the keychain is not supported on %s", runtime.GOOS)
+	}
+	output, err := runDecryptCommand(cmd)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// AgeIdentity 查找解密使用的 age 私钥，依次为：环境变量 VMAN_AGE_KEY、SOPS_AGE_KEY，
+// 私钥文件 VMAN_AGE_KEY_FILE、SOPS_AGE_KEY_FILE，系统钥匙串中服务 vman、账户 age-key 的条目，
+// 以及 sops 默认的私钥文件（用户配置目录下的 sops/age/keys.txt）
+func AgeIdentity() (string, error) {
+	for _, name := range []string{types.EnvVmanAgeKey, "SOPS_AGE_KEY"} {
+		if key := strings.TrimSpace(os.Getenv(name)); key != "" {
+			return key, nil
+		}
+	}
+	for _, name := range []string{types.EnvVmanAgeKeyFile, "SOPS_AGE_KEY_FILE"} {
+		if path := os.Getenv(name); path != "" {
+			path, err := utils.ExpandPath(path)
+			if err != nil {
+				return "", err
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return "", fmt.Errorf("failed to read age key file from %s: %w", name, err)
+			}
+			return string(data), nil
+		}
+	}
+	if key, err := keychainLookup(KeychainService, KeychainAgeKeyAccount); err == nil && key != "" {
+		return key, nil
+	}
+	if configDir, err := os.UserConfigDir(); err == nil {
+		if data, err := os.ReadFile(filepath.Join(configDir, "sops", "age", "keys.txt")); err == nil {
+			return string(data), nil
+		}
+	}
+	return "", fmt.Errorf("no age key found, set %s or %s, or store the key in the keychain (service %q, account %q)",
+		types.EnvVmanAgeKey, types.EnvVmanAgeKeyFile, KeychainService, KeychainAgeKeyAccount)
+}
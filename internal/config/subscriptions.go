@@ -0,0 +1,178 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/spf13/afero"
+
+	"github.com/songzhibin97/vman/pkg/types"
+)
+
+// SubscriptionsFile 配置目录中记录通过 vman subscribe 订阅的工具的文件名
+const SubscriptionsFile = "subscriptions.json"
+
+// 发布渠道，也可以使用版本约束（如 ~1.29）作为渠道
+const (
+	ChannelStable     = "stable"     // 稳定版本
+	ChannelPrerelease = "prerelease" // 包括预发布版本在内的所有版本
+)
+
+// Subscription 工具发布渠道的订阅
+type Subscription struct {
+	Tool         string    `json:"tool"`
+	Channel      string    `json:"channel"`
+	Desktop      bool      `json:"desktop,omitempty"`   // 有新版本时同时发送桌面通知
+	LastSeen     string    `json:"last_seen,omitempty"` // 已通知过的最高版本
+	SubscribedAt time.Time `json:"subscribed_at"`
+}
+
+// Subscriptions 订阅的工具，按工具名排序
+type Subscriptions struct {
+	Subscriptions []Subscription `json:"subscriptions"`
+}
+
+// ValidateChannel 检查渠道是否为 stable、prerelease 或有效的版本约束
+func ValidateChannel(channel string) error {
+	if channel == ChannelStable || channel == ChannelPrerelease {
+		return nil
+	}
+	if _, err := semver.NewConstraint(channel); err != nil {
+		return fmt.Errorf("invalid channel %q, expected %s, %s or a version constraint", channel, ChannelStable, ChannelPrerelease)
+	}
+	return nil
+}
+
+// LoadSubscriptions 读取订阅，文件不存在时返回空订阅
+func LoadSubscriptions(fs afero.Fs, path string) (*Subscriptions, error) {
+	subscriptions := &Subscriptions{}
+	data, err := afero.ReadFile(fs, path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return subscriptions, nil
+		}
+		return nil, fmt.Errorf("failed to read subscriptions: %w", err)
+	}
+	if err := json.Unmarshal(data, subscriptions); err != nil {
+		return nil, fmt.Errorf("failed to parse subscriptions: %w", err)
+	}
+	return subscriptions, nil
+}
+
+// Save 保存订阅
+func (s *Subscriptions) Save(fs afero.Fs, path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal subscriptions: %w", err)
+	}
+	if err := fs.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create subscriptions directory: %w", err)
+	}
+	if err := afero.WriteFile(fs, path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write subscriptions: %w", err)
+	}
+	return nil
+}
+
+// Set 添加订阅，工具已订阅时替换原订阅
+func (s *Subscriptions) Set(subscription Subscription) {
+	s.Remove(subscription.Tool)
+	s.Subscriptions = append(s.Subscriptions, subscription)
+	sort.Slice(s.Subscriptions, func(i, j int) bool { return s.Subscriptions[i].Tool < s.Subscriptions[j].Tool })
+}
+
+// Remove 取消订阅，未订阅时返回 false
+func (s *Subscriptions) Remove(tool string) bool {
+	for i, subscription := range s.Subscriptions {
+		if subscription.Tool == tool {
+			s.Subscriptions = append(s.Subscriptions[:i], s.Subscriptions[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Get 返回工具的订阅，未订阅时返回 nil
+func (s *Subscriptions) Get(tool string) *Subscription {
+	for i := range s.Subscriptions {
+		if s.Subscriptions[i].Tool == tool {
+			return &s.Subscriptions[i]
+		}
+	}
+	return nil
+}
+
+// Matches 版本是否属于订阅的渠道，无法解析的版本不属于任何渠道
+func (s *Subscription) Matches(info *types.VersionInfo) bool {
+	v, err := semver.NewVersion(info.Version)
+	if err != nil {
+		return false
+	}
+	switch s.Channel {
+	case ChannelPrerelease:
+		return true
+	case ChannelStable, "":
+		return !info.IsPrerelease && v.Prerelease() == ""
+	}
+	constraint, err := semver.NewConstraint(s.Channel)
+	return err == nil && constraint.Check(v)
+}
+
+// Releases 返回属于订阅渠道的版本，从高到低排序
+func (s *Subscription) Releases(available []*types.VersionInfo) []*types.VersionInfo {
+	var releases []*types.VersionInfo
+	for _, info := range available {
+		if s.Matches(info) {
+			releases = append(releases, info)
+		}
+	}
+	sort.SliceStable(releases, func(i, j int) bool {
+		vi, _ := semver.NewVersion(releases[i].Version)
+		vj, _ := semver.NewVersion(releases[j].Version)
+		return vi.GreaterThan(vj)
+	})
+	return releases
+}
+
+// IsNew 版本是否高于已通知过的最高版本
+func (s *Subscription) IsNew(version string) bool {
+	if s.LastSeen == "" {
+		return false
+	}
+	v, err := semver.NewVersion(version)
+	if err != nil {
+		return false
+	}
+	seen, err := semver.NewVersion(s.LastSeen)
+	return err != nil || v.GreaterThan(seen)
+}
+
+// NewReleases 返回渠道中高于已通知过的最高版本的版本，从高到低排序
+// 订阅后第一次检查时只记录当前的最高版本，不返回任何版本
+func (s *Subscription) NewReleases(available []*types.VersionInfo) []*types.VersionInfo {
+	var releases []*types.VersionInfo
+	for _, info := range s.Releases(available) {
+		if s.IsNew(info.Version) {
+			releases = append(releases, info)
+		}
+	}
+	return releases
+}
+
+// MarkSeen 将渠道中的最高版本记录为已通知，有变化时返回 true
+func (s *Subscription) MarkSeen(available []*types.VersionInfo) bool {
+	releases := s.Releases(available)
+	if len(releases) == 0 || releases[0].Version == s.LastSeen {
+		return false
+	}
+	if s.LastSeen != "" && !s.IsNew(releases[0].Version) {
+		return false
+	}
+	s.LastSeen = releases[0].Version
+	return true
+}
@@ -0,0 +1,72 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/songzhibin97/vman/pkg/types"
+)
+
+func TestSubscriptions(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	path := "/home/user/.vman/subscriptions.json"
+
+	subscriptions, err := LoadSubscriptions(fs, path)
+	require.NoError(t, err)
+	assert.Empty(t, subscriptions.Subscriptions)
+
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	subscriptions.Set(Subscription{Tool: "terraform", Channel: "~1.7", SubscribedAt: now})
+	subscriptions.Set(Subscription{Tool: "kubectl", Channel: ChannelStable, SubscribedAt: now})
+	subscriptions.Set(Subscription{Tool: "kubectl", Channel: ChannelPrerelease, SubscribedAt: now})
+	require.NoError(t, subscriptions.Save(fs, path))
+
+	loaded, err := LoadSubscriptions(fs, path)
+	require.NoError(t, err)
+	require.Len(t, loaded.Subscriptions, 2)
+	assert.Equal(t, "kubectl", loaded.Subscriptions[0].Tool)
+	assert.Equal(t, ChannelPrerelease, loaded.Get("kubectl").Channel)
+	assert.True(t, loaded.Remove("kubectl"))
+	assert.False(t, loaded.Remove("kubectl"))
+	assert.Nil(t, loaded.Get("kubectl"))
+
+	assert.NoError(t, ValidateChannel(ChannelStable))
+	assert.NoError(t, ValidateChannel(">=1.30"))
+	assert.Error(t, ValidateChannel("nightly"))
+}
+
+func TestSubscription_NewReleases(t *testing.T) {
+	available := []*types.VersionInfo{
+		{Version: "1.30.0"},
+		{Version: "1.31.0-rc.1", IsPrerelease: true},
+		{Version: "1.29.5"},
+		{Version: "1.29.4"},
+	}
+
+	// 第一次检查只记录当前的最高版本
+	stable := &Subscription{Tool: "kubectl", Channel: ChannelStable}
+	assert.Empty(t, stable.NewReleases(available))
+	assert.True(t, stable.MarkSeen(available))
+	assert.Equal(t, "1.30.0", stable.LastSeen)
+	assert.False(t, stable.MarkSeen(available))
+
+	available = append(available, &types.VersionInfo{Version: "1.30.1"}, &types.VersionInfo{Version: "1.31.0"})
+	releases := stable.NewReleases(available)
+	require.Len(t, releases, 2)
+	assert.Equal(t, "1.31.0", releases[0].Version)
+	assert.Equal(t, "1.30.1", releases[1].Version)
+	assert.True(t, stable.MarkSeen(available))
+	assert.Empty(t, stable.NewReleases(available))
+
+	prerelease := &Subscription{Tool: "kubectl", Channel: ChannelPrerelease, LastSeen: "1.30.0"}
+	assert.Len(t, prerelease.NewReleases(available), 3)
+
+	patch := &Subscription{Tool: "kubectl", Channel: "~1.29", LastSeen: "1.29.4"}
+	releases = patch.NewReleases(available)
+	require.Len(t, releases, 1)
+	assert.Equal(t, "1.29.5", releases[0].Version)
+}
@@ -1,15 +1,20 @@
 package config
 
 import (
+	"crypto/ed25519"
+	"encoding/base64"
 	"fmt"
+	"net"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/Masterminds/semver/v3"
 	"github.com/sirupsen/logrus"
 	"github.com/songzhibin97/vman/pkg/types"
+	"github.com/songzhibin97/vman/pkg/utils"
 )
 
 // Validator 配置验证器接口
@@ -102,6 +107,36 @@ func (v *DefaultValidator) ValidateProjectConfig(config *types.ProjectConfig) er
 	if err := v.validateToolVersions(config.Tools); err != nil {
 		return err
 	}
+	if err := v.validateToolVersions(config.Inactive); err != nil {
+		return err
+	}
+
+	// 验证启用条件
+	for toolName, condition := range config.Conditions {
+		if err := condition.Validate(); err != nil {
+			return fmt.Errorf("invalid condition for tool %s in project tools: %w", toolName, err)
+		}
+	}
+
+	// 验证默认参数和环境变量
+	if err := v.validateToolDefaults(config.Defaults); err != nil {
+		return err
+	}
+
+	// 验证工具分组
+	if err := v.validateToolGroups(config.Groups); err != nil {
+		return err
+	}
+
+	// 验证 terraform 工作区的版本
+	for workspace, version := range config.Workspaces {
+		if strings.TrimSpace(workspace) == "" {
+			return fmt.Errorf("workspace name cannot be empty in project workspaces")
+		}
+		if err := v.ValidateVersion(version); err != nil {
+			return fmt.Errorf("invalid version for workspace %s in project workspaces: %w", workspace, err)
+		}
+	}
 
 	v.logger.Debug("Project configuration validation passed")
 	return nil
@@ -124,6 +159,17 @@ func (v *DefaultValidator) ValidateToolMetadata(metadata *types.ToolMetadata) er
 		return err
 	}
 
+	// 验证垫片名称
+	if metadata.Shim != "" {
+		if err := v.ValidateToolName(metadata.Shim); err != nil {
+			return &types.ConfigValidationError{
+				Field:   "shim",
+				Message: "shim name can only contain letters, numbers, hyphens, and underscores",
+				Value:   metadata.Shim,
+			}
+		}
+	}
+
 	// 验证描述
 	if strings.TrimSpace(metadata.Description) == "" {
 		return &types.ConfigValidationError{
@@ -143,6 +189,13 @@ func (v *DefaultValidator) ValidateToolMetadata(metadata *types.ToolMetadata) er
 		return err
 	}
 
+	// 验证发布说明地址
+	if metadata.Changelog != "" {
+		if err := v.validateURL(metadata.Changelog, "changelog"); err != nil {
+			return err
+		}
+	}
+
 	// 验证下载配置
 	if err := v.validateDownloadConfig(&metadata.DownloadConfig); err != nil {
 		return err
@@ -153,6 +206,16 @@ func (v *DefaultValidator) ValidateToolMetadata(metadata *types.ToolMetadata) er
 		return err
 	}
 
+	// 验证环境变量策略
+	if err := v.validateEnvPolicy(&metadata.Env, "env"); err != nil {
+		return err
+	}
+
+	// 验证资源限制
+	if err := v.validateExecLimits(&metadata.Limits, "limits"); err != nil {
+		return err
+	}
+
 	v.logger.Debug("Tool metadata validation passed")
 	return nil
 }
@@ -275,6 +338,16 @@ func (v *DefaultValidator) validateSettings(settings *types.Settings) error {
 		return err
 	}
 
+	// 验证网络设置
+	if err := validateNetworkSettings(&settings.Network); err != nil {
+		return err
+	}
+
+	// 验证存储设置
+	if err := validateStorageSettings(&settings.Storage); err != nil {
+		return err
+	}
+
 	// 验证代理设置
 	if err := v.validateProxySettings(&settings.Proxy); err != nil {
 		return err
@@ -285,6 +358,225 @@ func (v *DefaultValidator) validateSettings(settings *types.Settings) error {
 		return err
 	}
 
+	// 验证备份设置
+	if err := v.validateBackupSettings(&settings.Backup); err != nil {
+		return err
+	}
+
+	// 验证环境变量策略
+	if err := v.validateEnvPolicy(&settings.Env.EnvPolicy, "settings.env"); err != nil {
+		return err
+	}
+	for tool, policy := range settings.Env.Tools {
+		if err := v.validateEnvPolicy(&policy, "settings.env.tools."+tool); err != nil {
+			return err
+		}
+	}
+
+	// 验证远程recipe注册表
+	if err := v.validateRecipeRegistries(settings.Recipes.Registries); err != nil {
+		return err
+	}
+
+	// 验证资源限制
+	if err := v.validateExecLimits(&settings.Limits.ExecLimits, "settings.limits"); err != nil {
+		return err
+	}
+	for tool, limits := range settings.Limits.Tools {
+		if err := v.validateExecLimits(&limits, "settings.limits.tools."+tool); err != nil {
+			return err
+		}
+	}
+
+	if !types.IsValidDeprecationPolicy(settings.Resolution.Deprecated) {
+		return &types.ConfigValidationError{
+			Field:   "settings.resolution.deprecated",
+			Message: fmt.Sprintf("invalid deprecated policy %q, must be one of: warn, error, ignore", settings.Resolution.Deprecated),
+			Value:   settings.Resolution.Deprecated,
+		}
+	}
+
+	for tool, files := range settings.Resolution.VersionFiles {
+		for _, name := range files {
+			if name == "" || strings.ContainsAny(name, `/\`) {
+				return &types.ConfigValidationError{
+					Field:   "settings.resolution.version_files." + tool,
+					Message: fmt.Sprintf("invalid version file %q, must be a file name without directories", name),
+					Value:   name,
+				}
+			}
+		}
+	}
+
+	if !types.IsValidRootPolicy(settings.RootPolicy) {
+		return &types.ConfigValidationError{
+			Field:   "settings.root_policy",
+			Message: fmt.Sprintf("invalid root_policy %q, must be one of: warn, refuse, allow", settings.RootPolicy),
+			Value:   settings.RootPolicy,
+		}
+	}
+
+	if settings.Remote.URL != "" && !IsRemoteConfigURL(settings.Remote.URL) {
+		return &types.ConfigValidationError{
+			Field:   "settings.remote.url",
+			Message: "must be an https, consul+https, or etcd+https URL with a key",
+			Value:   redactURL(settings.Remote.URL),
+		}
+	}
+	for _, setting := range settings.Remote.Trust {
+		if !slices.Contains(RemoteProtectedSettings, setting) {
+			return &types.ConfigValidationError{
+				Field:   "settings.remote.trust",
+				Message: fmt.Sprintf("must be one of: %s", strings.Join(RemoteProtectedSettings, ", ")),
+				Value:   setting,
+			}
+		}
+	}
+	if settings.Remote.TTL < 0 {
+		return &types.ConfigValidationError{
+			Field:   "settings.remote.ttl",
+			Message: "ttl must be >= 0",
+			Value:   settings.Remote.TTL,
+		}
+	}
+
+	return nil
+}
+
+// validateEnvPolicy 验证环境变量策略中的模式
+func (v *DefaultValidator) validateEnvPolicy(policy *types.EnvPolicy, field string) error {
+	for name, patterns := range map[string][]string{"strip": policy.Strip, "allow": policy.Allow} {
+		if err := types.ValidateEnvPatterns(patterns); err != nil {
+			return &types.ConfigValidationError{
+				Field:   field + "." + name,
+				Message: err.Error(),
+				Value:   patterns,
+			}
+		}
+	}
+	return nil
+}
+
+// validateRecipeRegistries 验证远程recipe注册表的名称、地址和公钥
+func (v *DefaultValidator) validateRecipeRegistries(registries []types.RecipeRegistry) error {
+	seen := make(map[string]bool)
+	for i, registry := range registries {
+		field := fmt.Sprintf("settings.recipes.registries[%d]", i)
+		if err := v.ValidateToolName(registry.Name); err != nil || seen[registry.Name] {
+			return &types.ConfigValidationError{
+				Field:   field + ".name",
+				Message: "registry name must be unique and can only contain letters, numbers, hyphens, and underscores",
+				Value:   registry.Name,
+			}
+		}
+		seen[registry.Name] = true
+
+		if !strings.HasPrefix(registry.URL, "https://") && !strings.HasPrefix(registry.URL, "http://") {
+			return &types.ConfigValidationError{
+				Field:   field + ".url",
+				Message: "url must start with http:// or https://",
+				Value:   registry.URL,
+			}
+		}
+
+		for _, key := range registry.PublicKeys {
+			data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(key))
+			if err != nil || len(data) != ed25519.PublicKeySize {
+				return &types.ConfigValidationError{
+					Field:   field + ".public_keys",
+					Message: "public key must be a base64 encoded Ed25519 public key",
+					Value:   key,
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// validateExecLimits 验证执行工具时的资源限制
+func (v *DefaultValidator) validateExecLimits(limits *types.ExecLimits, field string) error {
+	if limits.Timeout < 0 {
+		return &types.ConfigValidationError{
+			Field:   field + ".timeout",
+			Message: "timeout must be >= 0",
+			Value:   limits.Timeout,
+		}
+	}
+
+	if limits.Nice < -20 || limits.Nice > 19 {
+		return &types.ConfigValidationError{
+			Field:   field + ".nice",
+			Message: "nice must be between -20 and 19",
+			Value:   limits.Nice,
+		}
+	}
+
+	return nil
+}
+
+// validateToolDefaults 验证项目配置中的默认参数和环境变量
+func (v *DefaultValidator) validateToolDefaults(defaults map[string]types.ToolDefaults) error {
+	for tool, toolDefaults := range defaults {
+		if err := v.ValidateToolName(tool); err != nil {
+			return err
+		}
+		for name := range toolDefaults.Env {
+			if name == "" || strings.ContainsAny(name, "= ") {
+				return &types.ConfigValidationError{
+					Field:   fmt.Sprintf("defaults.%s.env", tool),
+					Message: "invalid environment variable name",
+					Value:   name,
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// validateToolGroups 验证工具分组
+func (v *DefaultValidator) validateToolGroups(groups map[string][]string) error {
+	for group, tools := range groups {
+		if group == "" || strings.ContainsAny(group, ", ") {
+			return &types.ConfigValidationError{
+				Field:   "groups",
+				Message: "invalid group name",
+				Value:   group,
+			}
+		}
+		if len(tools) == 0 {
+			return &types.ConfigValidationError{
+				Field:   fmt.Sprintf("groups.%s", group),
+				Message: "group must contain at least one tool",
+				Value:   tools,
+			}
+		}
+		for _, tool := range tools {
+			if err := v.ValidateToolName(tool); err != nil {
+				return fmt.Errorf("invalid tool name in group %s: %w", group, err)
+			}
+		}
+	}
+	return nil
+}
+
+// validateBackupSettings 验证自动备份设置
+func (v *DefaultValidator) validateBackupSettings(settings *types.BackupSettings) error {
+	if settings.Interval != 0 && settings.Interval < time.Minute {
+		return &types.ConfigValidationError{
+			Field:   "settings.backup.interval",
+			Message: "interval must be at least 1 minute",
+			Value:   settings.Interval,
+		}
+	}
+
+	if settings.KeepLast < 0 {
+		return &types.ConfigValidationError{
+			Field:   "settings.backup.keep_last",
+			Message: "keep_last must be >= 0",
+			Value:   settings.KeepLast,
+		}
+	}
+
 	return nil
 }
 
@@ -341,12 +633,67 @@ func (v *DefaultValidator) validateDownloadSettings(settings *types.DownloadSett
 		}
 	}
 
+	// 验证解压安全限制
+	if settings.MaxArchiveEntries < 0 {
+		return &types.ConfigValidationError{
+			Field:   "settings.download.max_archive_entries",
+			Message: "max_archive_entries must be >= 0",
+			Value:   settings.MaxArchiveEntries,
+		}
+	}
+
+	// 验证非终端输出时的进度间隔
+	if settings.ProgressInterval < 0 {
+		return &types.ConfigValidationError{
+			Field:   "settings.download.progress_interval",
+			Message: "progress_interval must be >= 0",
+			Value:   settings.ProgressInterval,
+		}
+	}
+
+	// 镜像只能通过HTTP或本地路径读取，S3桶需要使用其HTTPS地址
+	if strings.Contains(settings.Mirror, "://") && !strings.HasPrefix(settings.Mirror, "http://") && !strings.HasPrefix(settings.Mirror, "https://") {
+		return &types.ConfigValidationError{
+			Field:   "settings.download.mirror",
+			Message: "mirror must be an http(s) URL or a local path, use the bucket's https endpoint for S3",
+			Value:   settings.Mirror,
+		}
+	}
+
+	if err := validateRedirectPolicy(&settings.Redirects); err != nil {
+		return err
+	}
+
+	for _, name := range settings.ZipFilenameEncodings {
+		if _, ok := utils.LookupEncoding(name); !ok {
+			return &types.ConfigValidationError{
+				Field:   "settings.download.zip_filename_encodings",
+				Message: fmt.Sprintf("unsupported encoding %q, must be one of: %s", name, strings.Join(utils.EncodingNames(), ", ")),
+				Value:   name,
+			}
+		}
+	}
+
+	if settings.MaxCompressionRatio != 0 && settings.MaxCompressionRatio < 1 {
+		return &types.ConfigValidationError{
+			Field:   "settings.download.max_compression_ratio",
+			Message: "max_compression_ratio must be 0 (unlimited) or >= 1",
+			Value:   settings.MaxCompressionRatio,
+		}
+	}
+
 	return nil
 }
 
 // validateProxySettings 验证代理设置
 func (v *DefaultValidator) validateProxySettings(settings *types.ProxySettings) error {
-	// 代理设置目前只是布尔值，不需要特殊验证
+	if !types.IsValidShimHeal(settings.SelfHeal) {
+		return &types.ConfigValidationError{
+			Field:   "settings.proxy.self_heal",
+			Message: fmt.Sprintf("invalid self_heal %q, must be one of: auto, warn, off", settings.SelfHeal),
+			Value:   settings.SelfHeal,
+		}
+	}
 	return nil
 }
 
@@ -435,6 +782,119 @@ func (v *DefaultValidator) validateToolVersions(tools map[string]string) error {
 	return nil
 }
 
+// validateRedirectPolicy 验证重定向策略
+func validateRedirectPolicy(policy *types.RedirectPolicy) error {
+	if policy.MaxHops < -1 {
+		return &types.ConfigValidationError{
+			Field:   "settings.download.redirects.max_hops",
+			Message: "max_hops must be >= 0, or -1 to disable redirects",
+			Value:   policy.MaxHops,
+		}
+	}
+
+	hosts := map[string][]string{
+		"keep_auth_hosts": policy.KeepAuthHosts,
+		"drop_auth_hosts": policy.DropAuthHosts,
+	}
+	for _, field := range []string{"keep_auth_hosts", "drop_auth_hosts"} {
+		for _, host := range hosts[field] {
+			pattern := strings.TrimPrefix(strings.TrimSpace(host), "*.")
+			if pattern == "" || strings.ContainsAny(pattern, "/:*@ ") {
+				return &types.ConfigValidationError{
+					Field:   "settings.download.redirects." + field,
+					Message: fmt.Sprintf("%s entries must be host names like example.com or *.example.com, without scheme, port or path", field),
+					Value:   host,
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// validateNetworkSettings 验证网络设置
+func validateNetworkSettings(settings *types.NetworkSettings) error {
+	if !types.IsValidIPVersion(settings.IPVersion) {
+		return &types.ConfigValidationError{
+			Field:   "settings.network.ip_version",
+			Message: fmt.Sprintf("invalid ip_version %q, must be one of: ipv4, ipv6, prefer-ipv4, prefer-ipv6", settings.IPVersion),
+			Value:   settings.IPVersion,
+		}
+	}
+
+	pool := map[string]int{
+		"max_idle_conns":          settings.MaxIdleConns,
+		"max_idle_conns_per_host": settings.MaxIdleConnsPerHost,
+		"max_conns_per_host":      settings.MaxConnsPerHost,
+	}
+	for _, field := range []string{"max_idle_conns", "max_idle_conns_per_host", "max_conns_per_host"} {
+		if pool[field] < 0 {
+			return &types.ConfigValidationError{
+				Field:   "settings.network." + field,
+				Message: field + " must not be negative",
+				Value:   pool[field],
+			}
+		}
+	}
+	if settings.IdleConnTimeout < 0 {
+		return &types.ConfigValidationError{
+			Field:   "settings.network.idle_conn_timeout",
+			Message: "idle_conn_timeout must not be negative",
+			Value:   settings.IdleConnTimeout,
+		}
+	}
+
+	for host, ip := range settings.Hosts {
+		if net.ParseIP(ip) == nil {
+			return &types.ConfigValidationError{
+				Field:   "settings.network.hosts." + host,
+				Message: fmt.Sprintf("settings.network.hosts.%s must be an IP address, got %q", host, ip),
+				Value:   ip,
+			}
+		}
+	}
+
+	for _, server := range settings.DNSServers {
+		host := server
+		if h, _, err := net.SplitHostPort(server); err == nil {
+			host = h
+		}
+		if net.ParseIP(host) == nil {
+			return &types.ConfigValidationError{
+				Field:   "settings.network.dns_servers",
+				Message: fmt.Sprintf("dns_servers entries must be an IP address or IP:port, got %q", server),
+				Value:   server,
+			}
+		}
+	}
+	return nil
+}
+
+// validateStorageSettings 验证存储设置
+func validateStorageSettings(settings *types.StorageSettings) error {
+	if settings.TempDir != "" && !filepath.IsAbs(settings.TempDir) {
+		return &types.ConfigValidationError{
+			Field:   "settings.storage.temp_dir",
+			Message: fmt.Sprintf("settings.storage.temp_dir must be an absolute path, got %q", settings.TempDir),
+			Value:   settings.TempDir,
+		}
+	}
+	if !types.IsValidDedupMode(settings.Dedup) {
+		return &types.ConfigValidationError{
+			Field:   "settings.storage.dedup",
+			Message: fmt.Sprintf("invalid dedup %q, must be one of: off, reflink, hardlink, auto", settings.Dedup),
+			Value:   settings.Dedup,
+		}
+	}
+	if settings.TmpfsMaxArtifact < 0 {
+		return &types.ConfigValidationError{
+			Field:   "settings.storage.tmpfs_max_artifact",
+			Message: "tmpfs_max_artifact must not be negative",
+			Value:   settings.TmpfsMaxArtifact,
+		}
+	}
+	return nil
+}
+
 // validateDownloadConfig 验证下载配置
 func (v *DefaultValidator) validateDownloadConfig(config *types.DownloadConfig) error {
 	// 验证下载类型
@@ -452,6 +912,27 @@ func (v *DefaultValidator) validateDownloadConfig(config *types.DownloadConfig)
 		}
 	}
 
+	if config.StripComponents < -1 {
+		return &types.ConfigValidationError{
+			Field:   "download.strip_components",
+			Message: "strip_components must be -1 (keep layout), 0 (auto) or a positive number",
+			Value:   config.StripComponents,
+		}
+	}
+
+	if config.DeltaURLTemplate != "" {
+		if err := v.validateURL(config.DeltaURLTemplate, "download.delta_url_template"); err != nil {
+			return err
+		}
+		if !strings.Contains(config.DeltaURLTemplate, "{from}") {
+			return &types.ConfigValidationError{
+				Field:   "download.delta_url_template",
+				Message: "delta_url_template must contain {from}",
+				Value:   config.DeltaURLTemplate,
+			}
+		}
+	}
+
 	// 根据类型验证相应字段
 	switch config.Type {
 	case "direct":
@@ -526,6 +1007,24 @@ func (v *DefaultValidator) validateVersionConfig(config *types.VersionConfig) er
 		}
 	}
 
+	// 验证弃用的版本范围
+	for i, deprecated := range config.Deprecated {
+		if strings.TrimSpace(deprecated.Versions) == "" {
+			return &types.ConfigValidationError{
+				Field:   fmt.Sprintf("versions.deprecated[%d].versions", i),
+				Message: "versions is required",
+				Value:   deprecated.Versions,
+			}
+		}
+		if _, err := semver.NewConstraint(deprecated.Versions); err != nil {
+			return &types.ConfigValidationError{
+				Field:   fmt.Sprintf("versions.deprecated[%d].versions", i),
+				Message: fmt.Sprintf("invalid version range: %v", err),
+				Value:   deprecated.Versions,
+			}
+		}
+	}
+
 	return nil
 }
 
@@ -138,6 +138,21 @@ func TestDefaultValidator_ValidateToolMetadata(t *testing.T) {
 		assert.Contains(t, err.Error(), "tool name can only contain")
 	})
 
+	t.Run("InvalidShim", func(t *testing.T) {
+		metadata := &types.ToolMetadata{
+			Name:        "testtool",
+			Shim:        "bin/testtool",
+			Description: "Test tool",
+			DownloadConfig: types.DownloadConfig{
+				Type:        "direct",
+				URLTemplate: "https://example.com/{version}",
+			},
+		}
+		err := validator.ValidateToolMetadata(metadata)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "shim name can only contain")
+	})
+
 	t.Run("EmptyDescription", func(t *testing.T) {
 		metadata := &types.ToolMetadata{
 			Name:       "testtool",
@@ -215,6 +230,24 @@ func TestDefaultValidator_ValidateToolMetadata(t *testing.T) {
 		assert.Contains(t, err.Error(), "extract_binary is required for archive download type")
 	})
 
+	t.Run("InvalidStripComponents", func(t *testing.T) {
+		metadata := &types.ToolMetadata{
+			Name:        "testtool",
+			Description: "Test tool",
+			Homepage:    "https://example.com",
+			Repository:  "https://github.com/example/tool",
+			DownloadConfig: types.DownloadConfig{
+				Type:            "archive",
+				URLTemplate:     "https://example.com/{version}.zip",
+				ExtractBinary:   "testtool",
+				StripComponents: -2,
+			},
+		}
+		err := validator.ValidateToolMetadata(metadata)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "strip_components must be")
+	})
+
 	t.Run("InvalidVersionConstraints", func(t *testing.T) {
 		metadata := &types.ToolMetadata{
 			Name:        "testtool",
@@ -404,6 +437,26 @@ func TestDefaultValidator_ValidateDownloadSettings(t *testing.T) {
 		assert.Contains(t, err.Error(), "retries cannot exceed 10")
 	})
 
+	t.Run("RedirectPolicy", func(t *testing.T) {
+		settings := &types.DownloadSettings{
+			Timeout:             300 * time.Second,
+			Retries:             3,
+			ConcurrentDownloads: 2,
+			Redirects:           types.RedirectPolicy{MaxHops: -1, KeepAuthHosts: []string{"*.example.com"}},
+		}
+		assert.NoError(t, validator.validateDownloadSettings(settings))
+
+		settings.Redirects.KeepAuthHosts = []string{"https://example.com"}
+		err := validator.validateDownloadSettings(settings)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "keep_auth_hosts")
+
+		settings.Redirects = types.RedirectPolicy{MaxHops: -2}
+		err = validator.validateDownloadSettings(settings)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "max_hops")
+	})
+
 	t.Run("ZeroConcurrentDownloads", func(t *testing.T) {
 		settings := &types.DownloadSettings{
 			Timeout:             300 * time.Second,
@@ -425,4 +478,66 @@ func TestDefaultValidator_ValidateDownloadSettings(t *testing.T) {
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "concurrent_downloads cannot exceed 10")
 	})
+
+	t.Run("InvalidCompressionRatio", func(t *testing.T) {
+		settings := &types.DownloadSettings{
+			Timeout:             300 * time.Second,
+			Retries:             3,
+			ConcurrentDownloads: 2,
+			MaxCompressionRatio: 0.5,
+		}
+		err := validator.validateDownloadSettings(settings)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "max_compression_ratio must be")
+	})
+}
+
+func TestValidateNetworkSettings(t *testing.T) {
+	settings := &types.NetworkSettings{
+		IPVersion:          types.IPVersionPreferIPv4,
+		HappyEyeballsDelay: 100 * time.Millisecond,
+		Hosts:              map[string]string{"github.com": "140.82.112.3", "mirror.internal": "::1"},
+		DNSServers:         []string{"10.0.0.53", "10.0.0.54:5353", "[2001:db8::53]:53"},
+	}
+	assert.NoError(t, validateNetworkSettings(settings))
+
+	settings.IPVersion = "ipv5"
+	err := validateNetworkSettings(settings)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "ip_version")
+
+	settings.IPVersion = ""
+	settings.Hosts = map[string]string{"github.com": "github-mirror.internal"}
+	err = validateNetworkSettings(settings)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "settings.network.hosts.github.com")
+
+	settings.Hosts = nil
+	settings.DNSServers = []string{"dns.example.com"}
+	err = validateNetworkSettings(settings)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "dns_servers")
+
+	settings.DNSServers = nil
+	settings.MaxIdleConnsPerHost = -1
+	err = validateNetworkSettings(settings)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "max_idle_conns_per_host")
+}
+
+func TestValidateStorageSettings(t *testing.T) {
+	assert.NoError(t, validateStorageSettings(&types.StorageSettings{}))
+	assert.NoError(t, validateStorageSettings(&types.StorageSettings{TempDir: "/var/tmp/vman", TmpfsMaxArtifact: 1 << 30, Dedup: types.DedupAuto}))
+
+	err := validateStorageSettings(&types.StorageSettings{TempDir: "tmp"})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "settings.storage.temp_dir")
+
+	err = validateStorageSettings(&types.StorageSettings{Dedup: "copy"})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "dedup")
+
+	err = validateStorageSettings(&types.StorageSettings{TmpfsMaxArtifact: -1})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "tmpfs_max_artifact")
 }
@@ -0,0 +1,33 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/afero"
+
+	"github.com/songzhibin97/vman/pkg/types"
+)
+
+// WorkspaceFile terraform workspace select 记录当前工作区的文件，相对于 terraform 的工作目录
+var WorkspaceFile = filepath.Join(".terraform", "environment")
+
+// ActiveWorkspace 返回当前的 terraform 工作区：TF_WORKSPACE 优先，其次依次查找 dirs 中的 .terraform/environment，
+// 都没有时为 default。同时返回工作区的来源
+func ActiveWorkspace(fs afero.Fs, dirs ...string) (workspace, source string) {
+	if workspace := strings.TrimSpace(os.Getenv(types.EnvTFWorkspace)); workspace != "" {
+		return workspace, types.EnvTFWorkspace
+	}
+	for _, dir := range dirs {
+		path := filepath.Join(dir, WorkspaceFile)
+		data, err := afero.ReadFile(fs, path)
+		if err != nil {
+			continue
+		}
+		if workspace := strings.TrimSpace(string(data)); workspace != "" {
+			return workspace, path
+		}
+	}
+	return types.DefaultWorkspace, ""
+}
@@ -0,0 +1,61 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/songzhibin97/vman/pkg/types"
+)
+
+func TestProjectWorkspaces(t *testing.T) {
+	manager := newExtendsTestManager(t, map[string]string{
+		"/org/base.yaml": `
+workspaces:
+  prod: 1.5.7
+  staging: 1.6.0
+`,
+		"/org/infra/.vman.yaml": `
+extends: ../base.yaml
+tools:
+  terraform: 1.7.0
+  kubectl: 1.29.0
+workspaces:
+  prod: 1.5.7
+  dev: 1.7.0
+`,
+		"/org/infra/network/.terraform/environment": "staging\n",
+	})
+	t.Setenv(types.EnvTFWorkspace, "")
+
+	config, err := manager.LoadProject("/org/infra")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"prod": "1.5.7", "staging": "1.6.0", "dev": "1.7.0"}, config.Workspaces)
+	assert.Equal(t, map[string]string{"prod": "1.5.7", "dev": "1.7.0"}, withoutInherited(config).Workspaces,
+		"继承的工作区版本不写入本文件")
+
+	// terraform workspace select 记录的工作区，工作目录中的优先
+	workspace, source := ActiveWorkspace(manager.fs, "/org/infra/network", "/org/infra")
+	assert.Equal(t, "staging", workspace)
+	assert.Equal(t, "/org/infra/network/.terraform/environment", source)
+	version, ok := config.ToolVersionForWorkspace("terraform", workspace)
+	assert.True(t, ok)
+	assert.Equal(t, "1.6.0", version)
+	_, ok = config.ToolVersionForWorkspace("kubectl", workspace)
+	assert.False(t, ok, "workspaces 只适用于 terraform 和 tofu")
+
+	// TF_WORKSPACE 优先，没有选择工作区时为 default
+	t.Setenv(types.EnvTFWorkspace, "prod")
+	workspace, source = ActiveWorkspace(manager.fs, "/org/infra/network")
+	assert.Equal(t, "prod", workspace)
+	assert.Equal(t, types.EnvTFWorkspace, source)
+	t.Setenv(types.EnvTFWorkspace, "")
+	workspace, _ = ActiveWorkspace(manager.fs, "/org/infra")
+	assert.Equal(t, types.DefaultWorkspace, workspace)
+	_, ok = config.ToolVersionForWorkspace("terraform", workspace)
+	assert.False(t, ok, "没有配置的工作区使用 tools 中的版本")
+
+	config.Workspaces["dev"] = "not a version!"
+	assert.Error(t, NewValidator().ValidateProjectConfig(config))
+}
@@ -0,0 +1,322 @@
+// Package diagnostics 收集提交问题时需要的诊断信息，写入前去除其中的凭据
+package diagnostics
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/songzhibin97/vman/pkg/types"
+)
+
+// CrashDirName 数据目录中保存崩溃诊断包的目录名
+const CrashDirName = "crash"
+
+const (
+	// maxLogBytes 每个日志文件最多收集的字节数，从文件末尾读取
+	maxLogBytes = 64 * 1024
+
+	// maxLogFiles 最多收集的日志文件数，按修改时间从新到旧
+	maxLogFiles = 5
+
+	// maxConfigBytes 配置文件最多收集的字节数
+	maxConfigBytes = 256 * 1024
+)
+
+// envAllowlist 收集的环境变量，其他变量可能包含无关的个人信息，不收集
+var envAllowlist = []string{
+	"PATH", "SHELL", "TERM", "HOME", "LANG", "CI", "GITHUB_ACTIONS",
+	"HTTP_PROXY", "HTTPS_PROXY", "NO_PROXY", "http_proxy", "https_proxy", "no_proxy",
+}
+
+// envPrefixes 按前缀收集的环境变量
+var envPrefixes = []string{"VMAN_", "XDG_", "LC_"}
+
+// envExcluded 匹配前缀但不收集的环境变量：私钥及其路径
+var envExcluded = []string{types.EnvVmanAgeKey, types.EnvVmanAgeKeyFile}
+
+// CrashDir 保存崩溃诊断包的目录
+func CrashDir(paths *types.ConfigPaths) string {
+	return filepath.Join(paths.DataDir, CrashDirName)
+}
+
+// Options 收集诊断信息的上下文
+type Options struct {
+	// Reason 生成诊断包的原因，如 panic、bugreport
+	Reason string
+	// Version vman 版本
+	Version string
+	// Args 命令行参数
+	Args []string
+	// WorkDir 工作目录，用于查找项目配置
+	WorkDir string
+	// HomeDir 用户主目录，诊断信息中替换为 ~
+	HomeDir string
+	// Environ 环境变量，为空时不收集
+	Environ []string
+}
+
+// Bundle 诊断包，由多个文本文件组成，添加时即去除凭据
+type Bundle struct {
+	CreatedAt time.Time
+	reason    string
+	homeDir   string
+	names     []string
+	files     map[string][]byte
+}
+
+// NewBundle 创建空的诊断包
+func NewBundle(reason, homeDir string) *Bundle {
+	return &Bundle{
+		CreatedAt: time.Now(),
+		reason:    reason,
+		homeDir:   homeDir,
+		files:     make(map[string][]byte),
+	}
+}
+
+// Collect 收集系统信息、环境变量、全局和项目配置、已安装版本和最近的日志
+func Collect(paths *types.ConfigPaths, opts Options) *Bundle {
+	bundle := NewBundle(opts.Reason, opts.HomeDir)
+
+	var summary strings.Builder
+	fmt.Fprintf(&summary, "reason: %s\n", opts.Reason)
+	fmt.Fprintf(&summary, "time: %s\n", bundle.CreatedAt.Format(time.RFC3339))
+	fmt.Fprintf(&summary, "vman: %s\n", opts.Version)
+	fmt.Fprintf(&summary, "go: %s\n", runtime.Version())
+	fmt.Fprintf(&summary, "platform: %s/%s\n", runtime.GOOS, runtime.GOARCH)
+	fmt.Fprintf(&summary, "cpus: %d\n", runtime.NumCPU())
+	fmt.Fprintf(&summary, "args: %s\n", strings.Join(opts.Args, " "))
+	fmt.Fprintf(&summary, "workdir: %s\n", opts.WorkDir)
+	fmt.Fprintf(&summary, "config dir: %s\n", paths.ConfigDir)
+	fmt.Fprintf(&summary, "data dir: %s\n", paths.DataDir)
+	bundle.Add("system.txt", []byte(summary.String()))
+
+	if len(opts.Environ) > 0 {
+		bundle.Add("env.txt", []byte(EnvSnapshot(opts.Environ)))
+	}
+
+	bundle.AddFile("config/config.yaml", paths.GlobalConfigFile, maxConfigBytes)
+	if opts.WorkDir != "" {
+		for i, path := range projectConfigs(opts.WorkDir) {
+			bundle.AddFile(fmt.Sprintf("config/project-%d/%s", i, filepath.Base(path)), path, maxConfigBytes)
+		}
+	}
+
+	bundle.Add("installed.txt", []byte(installedVersions(paths.VersionsDir)))
+
+	for _, path := range recentLogs(paths.LogsDir) {
+		bundle.AddFile("logs/"+filepath.Base(path), path, maxLogBytes)
+	}
+	return bundle
+}
+
+// Add 添加文本文件，内容中的凭据和主目录会被替换
+func (b *Bundle) Add(name string, data []byte) {
+	if _, exists := b.files[name]; !exists {
+		b.names = append(b.names, name)
+	}
+	b.files[name] = []byte(Redact(string(data), b.homeDir))
+}
+
+// AddFile 添加磁盘上的文件，超过 maxBytes 时只保留末尾部分，文件不存在时跳过
+func (b *Bundle) AddFile(name, path string, maxBytes int64) {
+	data, err := readTail(path, maxBytes)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			b.Add(name+".error", []byte(err.Error()))
+		}
+		return
+	}
+	b.Add(name, []byte(fmt.Sprintf("# %s\n%s", path, data)))
+}
+
+// Names 按添加顺序返回诊断包中的文件名
+func (b *Bundle) Names() []string {
+	return append([]string(nil), b.names...)
+}
+
+// File 返回诊断包中的文件内容
+func (b *Bundle) File(name string) ([]byte, bool) {
+	data, ok := b.files[name]
+	return data, ok
+}
+
+// WriteZip 以zip格式写出诊断包
+func (b *Bundle) WriteZip(w io.Writer) error {
+	archive := zip.NewWriter(w)
+	for _, name := range b.names {
+		header := &zip.FileHeader{Name: name, Method: zip.Deflate, Modified: b.CreatedAt}
+		writer, err := archive.CreateHeader(header)
+		if err != nil {
+			return fmt.Errorf("failed to add %s to bundle: %w", name, err)
+		}
+		if _, err := writer.Write(b.files[name]); err != nil {
+			return fmt.Errorf("failed to add %s to bundle: %w", name, err)
+		}
+	}
+	return archive.Close()
+}
+
+// Save 将诊断包保存到 dir 中，文件名包含原因和时间，返回文件路径
+// 诊断包只对当前用户可读
+func (b *Bundle) Save(dir string) (string, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create directory %s: %w", dir, err)
+	}
+	path := filepath.Join(dir, fmt.Sprintf("vman-%s-%s.zip", b.reason, b.CreatedAt.Format("20060102-150405")))
+	return path, b.SaveAs(path)
+}
+
+// SaveAs 将诊断包保存到指定路径
+func (b *Bundle) SaveAs(path string) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create bundle: %w", err)
+	}
+	if err := b.WriteZip(file); err != nil {
+		file.Close()
+		os.Remove(path)
+		return err
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(path)
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+	return nil
+}
+
+// EnvSnapshot 返回白名单中的环境变量，按名称排序
+func EnvSnapshot(environ []string) string {
+	var lines []string
+	for _, entry := range environ {
+		name, _, ok := strings.Cut(entry, "=")
+		if ok && envIncluded(name) {
+			lines = append(lines, entry)
+		}
+	}
+	sort.Strings(lines)
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// envIncluded 判断环境变量是否收集
+func envIncluded(name string) bool {
+	for _, excluded := range envExcluded {
+		if name == excluded {
+			return false
+		}
+	}
+	for _, allowed := range envAllowlist {
+		if name == allowed {
+			return true
+		}
+	}
+	for _, prefix := range envPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return strings.HasSuffix(name, "_VERSION")
+}
+
+// projectConfigs 从 dir 向上查找项目配置文件
+func projectConfigs(dir string) []string {
+	var found []string
+	for current := dir; ; {
+		for _, name := range []string{".vman.yaml", ".vman-version", ".tool-versions"} {
+			path := filepath.Join(current, name)
+			if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+				found = append(found, path)
+			}
+		}
+		parent := filepath.Dir(current)
+		if parent == current {
+			return found
+		}
+		current = parent
+	}
+}
+
+// installedVersions 列出版本目录中的工具、版本和平台
+func installedVersions(versionsDir string) string {
+	var b strings.Builder
+	tools, err := os.ReadDir(versionsDir)
+	if err != nil {
+		return fmt.Sprintf("%s: %v\n", versionsDir, err)
+	}
+	for _, tool := range tools {
+		if !tool.IsDir() {
+			continue
+		}
+		versions, _ := os.ReadDir(filepath.Join(versionsDir, tool.Name()))
+		for _, version := range versions {
+			if !version.IsDir() {
+				continue
+			}
+			var platforms []string
+			entries, _ := os.ReadDir(filepath.Join(versionsDir, tool.Name(), version.Name()))
+			for _, entry := range entries {
+				if entry.IsDir() && types.IsPlatformDirName(entry.Name()) {
+					platforms = append(platforms, entry.Name())
+				}
+			}
+			fmt.Fprintf(&b, "%s %s %s\n", tool.Name(), version.Name(), strings.Join(platforms, ","))
+		}
+	}
+	return b.String()
+}
+
+// recentLogs 返回日志目录中最近修改的日志文件
+func recentLogs(logsDir string) []string {
+	entries, err := os.ReadDir(logsDir)
+	if err != nil {
+		return nil
+	}
+
+	type logFile struct {
+		path    string
+		modTime time.Time
+	}
+	var logs []logFile
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if info, err := entry.Info(); err == nil {
+			logs = append(logs, logFile{filepath.Join(logsDir, entry.Name()), info.ModTime()})
+		}
+	}
+	sort.Slice(logs, func(i, j int) bool { return logs[i].modTime.After(logs[j].modTime) })
+
+	var paths []string
+	for i := 0; i < len(logs) && i < maxLogFiles; i++ {
+		paths = append(paths, logs[i].path)
+	}
+	return paths
+}
+
+// readTail 读取文件末尾最多 maxBytes 字节
+func readTail(path string, maxBytes int64) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() > maxBytes {
+		if _, err := file.Seek(info.Size()-maxBytes, io.SeekStart); err != nil {
+			return nil, err
+		}
+	}
+	return io.ReadAll(io.LimitReader(file, maxBytes))
+}
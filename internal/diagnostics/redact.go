@@ -0,0 +1,37 @@
+package diagnostics
+
+import (
+	"regexp"
+	"strings"
+)
+
+// redacted 替换凭据的占位符
+const redacted = "<redacted>"
+
+var (
+	// urlCredentials URL中的用户名和密码
+	urlCredentials = regexp.MustCompile(`([a-zA-Z][a-zA-Z0-9+.-]*://)[^/\s:@]+:[^/\s@]+@`)
+
+	// bearerToken Authorization 头中的令牌
+	bearerToken = regexp.MustCompile(`(?i)\b(bearer|basic)(\s+)[A-Za-z0-9._~+/=-]{8,}`)
+
+	// knownTokens GitHub、GitLab 等服务的令牌格式和 age 私钥
+	knownTokens = regexp.MustCompile(`\b(gh[pousr]_[A-Za-z0-9]{20,}|github_pat_[A-Za-z0-9_]{20,}|glpat-[A-Za-z0-9_-]{20,}|AKIA[0-9A-Z]{16}|AGE-SECRET-KEY-[0-9A-Z]+)\b`)
+
+	// secretAssignment 名称中包含 token、secret、password 等的键值对
+	secretAssignment = regexp.MustCompile(`(?i)([\w.-]*(?:token|secret|passw(?:or)?d|api[_-]?key|access[_-]?key|private[_-]?key|credentials?)[\w.-]*["']?\s*[:=]\s*)(["']?)[^\s"',}]+`)
+)
+
+// Redact 去除文本中的凭据，并将主目录替换为 ~
+func Redact(text, homeDir string) string {
+	text = urlCredentials.ReplaceAllString(text, "${1}"+redacted+"@")
+	text = secretAssignment.ReplaceAllString(text, "${1}${2}"+redacted)
+	text = bearerToken.ReplaceAllString(text, "${1}${2}"+redacted)
+	text = knownTokens.ReplaceAllString(text, redacted)
+
+	homeDir = strings.TrimRight(homeDir, `/\`)
+	if homeDir != "" {
+		text = strings.ReplaceAll(text, homeDir, "~")
+	}
+	return text
+}
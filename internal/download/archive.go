@@ -0,0 +1,476 @@
+package download
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/bzip2"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/spf13/afero"
+
+	"github.com/songzhibin97/vman/pkg/utils"
+)
+
+// 支持的压缩包格式
+const (
+	FormatZip    = "zip"
+	FormatTar    = "tar"
+	FormatTarGz  = "tar.gz"
+	FormatTarXz  = "tar.xz"
+	FormatTarBz  = "tar.bz2"
+	FormatTarZst = "tar.zst"
+	FormatTarBr  = "tar.br"
+)
+
+// archiveSuffixes 文件扩展名与压缩包格式的对应关系，较长的扩展名在前
+var archiveSuffixes = []struct {
+	suffix string
+	format string
+}{
+	{".tar.gz", FormatTarGz},
+	{".tgz", FormatTarGz},
+	{".tar.xz", FormatTarXz},
+	{".txz", FormatTarXz},
+	{".tar.bz2", FormatTarBz},
+	{".tbz2", FormatTarBz},
+	{".tbz", FormatTarBz},
+	{".tar.zst", FormatTarZst},
+	{".tar.zstd", FormatTarZst},
+	{".tzst", FormatTarZst},
+	{".tar.br", FormatTarBr},
+	{".tar", FormatTar},
+	{".zip", FormatZip},
+}
+
+// 单个文件的压缩格式，tar类压缩包的外层压缩也使用这些格式
+const (
+	CompressionGzip   = "gzip"
+	CompressionXz     = "xz"
+	CompressionBzip2  = "bzip2"
+	CompressionZstd   = "zstd"
+	CompressionBrotli = "brotli"
+)
+
+// compressedSuffixes 单个压缩文件的扩展名与压缩格式的对应关系，如 protoc-gen-foo-linux-amd64.gz
+var compressedSuffixes = []struct {
+	suffix      string
+	compression string
+}{
+	{".gz", CompressionGzip},
+	{".xz", CompressionXz},
+	{".bz2", CompressionBzip2},
+	{".zst", CompressionZstd},
+	{".br", CompressionBrotli},
+}
+
+// archiveCompressions tar类压缩包的外层压缩格式
+var archiveCompressions = map[string]string{
+	FormatTarGz:  CompressionGzip,
+	FormatTarXz:  CompressionXz,
+	FormatTarBz:  CompressionBzip2,
+	FormatTarZst: CompressionZstd,
+	FormatTarBr:  CompressionBrotli,
+}
+
+// paxXattrPrefix tar的PAX记录中扩展属性的前缀
+const paxXattrPrefix = "SCHILY.xattr."
+
+// userXattrPrefix 只恢复 user 命名空间的扩展属性，security.capability、trusted.* 等会赋予文件权限，不从压缩包恢复
+const userXattrPrefix = "user."
+
+// errStopWalk 提前结束遍历
+var errStopWalk = errors.New("stop walk")
+
+// errSizeLimit 解压的数据超过大小上限
+var errSizeLimit = errors.New("size limit exceeded")
+
+// ArchiveEntry 压缩包中的条目
+type ArchiveEntry struct {
+	Name     string      `json:"name"`
+	Size     int64       `json:"size"`
+	Mode     os.FileMode `json:"mode"`
+	ModTime  time.Time   `json:"mod_time"`
+	IsDir    bool        `json:"is_dir"`
+	Linkname string      `json:"linkname,omitempty"` // 符号链接或硬链接的目标
+
+	// Xattrs 扩展属性，目前只有tar压缩包中以PAX记录保存的 user 命名空间的扩展属性
+	Xattrs map[string]string `json:"xattrs,omitempty"`
+}
+
+// IsLink 是否为链接
+func (e *ArchiveEntry) IsLink() bool {
+	return e.Linkname != ""
+}
+
+// ArchiveWalkFunc 遍历压缩包条目的回调，open 用于读取文件条目的内容
+// 返回 errStopWalk 时结束遍历且不作为错误返回
+type ArchiveWalkFunc func(entry *ArchiveEntry, open func() (io.ReadCloser, error)) error
+
+// ArchiveReader 压缩包读取器，统一各格式的列出和解压
+type ArchiveReader interface {
+	// Format 压缩包格式
+	Format() string
+
+	// Walk 按顺序遍历压缩包中的条目
+	Walk(fn ArchiveWalkFunc) error
+
+	// Close 关闭压缩包
+	Close() error
+}
+
+// DetectArchiveFormat 根据文件名判断压缩包格式，不是压缩包时返回空字符串
+func DetectArchiveFormat(filename string) string {
+	lower := strings.ToLower(filename)
+	for _, s := range archiveSuffixes {
+		if strings.HasSuffix(lower, s.suffix) {
+			return s.format
+		}
+	}
+	return ""
+}
+
+// DetectCompression 判断不是压缩包的单个文件是否经过压缩，返回压缩格式和去掉压缩扩展名后的文件名
+// 不是压缩文件或者是压缩包（如 .tar.gz）时返回空的压缩格式
+func DetectCompression(filename string) (compression, name string) {
+	if DetectArchiveFormat(filename) != "" {
+		return "", filename
+	}
+	lower := strings.ToLower(filename)
+	for _, s := range compressedSuffixes {
+		if strings.HasSuffix(lower, s.suffix) && len(filename) > len(s.suffix) {
+			return s.compression, filename[:len(filename)-len(s.suffix)]
+		}
+	}
+	return "", filename
+}
+
+// OpenArchive 打开压缩包
+// encodings 为zip中未标记UTF-8的文件名依次尝试的编码，如 gbk、shift_jis，未指定时根据系统语言环境选择
+func OpenArchive(fs afero.Fs, archivePath string, encodings ...string) (ArchiveReader, error) {
+	format := DetectArchiveFormat(archivePath)
+	if format == "" {
+		return nil, fmt.Errorf("不支持的压缩格式: %s", archivePath)
+	}
+
+	file, err := fs.Open(archivePath)
+	if err != nil {
+		return nil, fmt.Errorf("打开压缩文件失败: %w", err)
+	}
+
+	if format == FormatZip {
+		stat, err := file.Stat()
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("读取zip文件信息失败: %w", err)
+		}
+		reader, err := zip.NewReader(file, stat.Size())
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("打开zip文件失败: %w", err)
+		}
+		if len(encodings) == 0 {
+			encodings = utils.LocaleEncodings()
+		}
+		return &zipArchive{file: file, reader: reader, encodings: encodings}, nil
+	}
+
+	stream, err := decompressStream(format, file)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &tarArchive{format: format, file: file, stream: stream}, nil
+}
+
+// ListArchive 列出压缩包中的所有条目，encodings 的含义同 OpenArchive
+func ListArchive(fs afero.Fs, archivePath string, encodings ...string) ([]*ArchiveEntry, error) {
+	archive, err := OpenArchive(fs, archivePath, encodings...)
+	if err != nil {
+		return nil, err
+	}
+	defer archive.Close()
+
+	var entries []*ArchiveEntry
+	err = archive.Walk(func(entry *ArchiveEntry, _ func() (io.ReadCloser, error)) error {
+		entries = append(entries, entry)
+		return nil
+	})
+	return entries, err
+}
+
+// ArchiveRoot 返回所有条目共同的顶层目录，条目不都位于同一个目录下时返回空字符串
+func ArchiveRoot(entries []*ArchiveEntry) string {
+	root := ""
+	for _, entry := range entries {
+		parts := splitEntryPath(entry.Name)
+		if len(parts) == 0 {
+			continue
+		}
+		if parts[0] == ".." || (len(parts) == 1 && !entry.IsDir) {
+			return ""
+		}
+		if root == "" {
+			root = parts[0]
+		} else if parts[0] != root {
+			return ""
+		}
+	}
+	return root
+}
+
+// splitEntryPath 将条目路径拆分为各层名称，忽略 "." 和空的层
+func splitEntryPath(name string) []string {
+	var parts []string
+	for _, part := range strings.Split(name, "/") {
+		if part != "" && part != "." {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
+
+// decompressStream 为tar类格式创建解压流
+func decompressStream(format string, r io.Reader) (io.ReadCloser, error) {
+	if format == FormatTar {
+		return io.NopCloser(r), nil
+	}
+	compression, ok := archiveCompressions[format]
+	if !ok {
+		return nil, fmt.Errorf("不支持的压缩格式: %s", format)
+	}
+	return decompressReader(compression, r)
+}
+
+// decompressReader 按压缩格式创建解压流
+func decompressReader(compression string, r io.Reader) (io.ReadCloser, error) {
+	switch compression {
+	case CompressionGzip:
+		gzReader, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("创建gzip读取器失败: %w", err)
+		}
+		return gzReader, nil
+	case CompressionBzip2:
+		return io.NopCloser(bzip2.NewReader(r)), nil
+	case CompressionXz, CompressionZstd, CompressionBrotli:
+		return newCommandReader(r, compression, "--decompress", "--stdout")
+	default:
+		return nil, fmt.Errorf("不支持的压缩格式: %s", compression)
+	}
+}
+
+// commandReader 通过系统命令解压的读取器
+type commandReader struct {
+	io.ReadCloser
+	cmd *exec.Cmd
+}
+
+// newCommandReader 标准库不支持xz、zstd、brotli，借助系统中的同名命令流式解压
+func newCommandReader(r io.Reader, command string, args ...string) (io.ReadCloser, error) {
+	path, err := exec.LookPath(command)
+	if err != nil {
+		return nil, fmt.Errorf("解压该格式需要系统中安装 %s 命令: %w", command, err)
+	}
+
+	cmd := exec.Command(path, args...)
+	cmd.Stdin = r
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("创建%s读取器失败: %w", command, err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("启动%s失败: %w", command, err)
+	}
+	return &commandReader{ReadCloser: stdout, cmd: cmd}, nil
+}
+
+// Close 关闭读取器并等待解压命令退出
+// 提前结束读取时命令会因管道关闭而退出，数据损坏由tar读取时报告，这里不再检查退出状态
+func (c *commandReader) Close() error {
+	err := c.ReadCloser.Close()
+	c.cmd.Wait()
+	return err
+}
+
+// tarArchive tar、tar.gz、tar.xz、tar.bz2、tar.zst、tar.br 压缩包
+type tarArchive struct {
+	format string
+	file   afero.File
+	stream io.ReadCloser
+	walked bool
+}
+
+// Format 压缩包格式
+func (a *tarArchive) Format() string {
+	return a.format
+}
+
+// Walk 按顺序遍历条目，tar 为流式格式，只能遍历一次
+func (a *tarArchive) Walk(fn ArchiveWalkFunc) error {
+	if a.walked {
+		return fmt.Errorf("tar压缩包只能遍历一次")
+	}
+	a.walked = true
+
+	tarReader := tar.NewReader(a.stream)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("读取tar条目失败: %w", err)
+		}
+
+		entry := &ArchiveEntry{
+			Name:    header.Name,
+			Size:    header.Size,
+			Mode:    header.FileInfo().Mode(),
+			ModTime: header.ModTime,
+			IsDir:   header.Typeflag == tar.TypeDir,
+		}
+		if header.Typeflag == tar.TypeSymlink || header.Typeflag == tar.TypeLink {
+			entry.Linkname = header.Linkname
+		}
+		for key, value := range header.PAXRecords {
+			if name := strings.TrimPrefix(key, paxXattrPrefix); name != key && strings.HasPrefix(name, userXattrPrefix) {
+				if entry.Xattrs == nil {
+					entry.Xattrs = make(map[string]string)
+				}
+				entry.Xattrs[name] = value
+			}
+		}
+
+		open := func() (io.ReadCloser, error) {
+			return io.NopCloser(tarReader), nil
+		}
+		if err := fn(entry, open); err != nil {
+			if err == errStopWalk {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// Close 关闭压缩包
+func (a *tarArchive) Close() error {
+	streamErr := a.stream.Close()
+	if err := a.file.Close(); err != nil {
+		return err
+	}
+	return streamErr
+}
+
+// zipArchive zip 压缩包
+type zipArchive struct {
+	file      afero.File
+	reader    *zip.Reader
+	encodings []string
+}
+
+// Format 压缩包格式
+func (a *zipArchive) Format() string {
+	return FormatZip
+}
+
+// Walk 按顺序遍历条目
+func (a *zipArchive) Walk(fn ArchiveWalkFunc) error {
+	for _, file := range a.reader.File {
+		info := file.FileInfo()
+		entry := &ArchiveEntry{
+			Name:    a.entryName(file),
+			Size:    int64(file.UncompressedSize64),
+			Mode:    info.Mode(),
+			ModTime: file.Modified,
+			IsDir:   info.IsDir(),
+		}
+
+		f := file
+		if info.Mode()&os.ModeSymlink != 0 {
+			if target, err := readZipLink(f); err == nil {
+				entry.Linkname = target
+			}
+		}
+
+		if err := fn(entry, f.Open); err != nil {
+			if err == errStopWalk {
+				return nil
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// Close 关闭压缩包
+func (a *zipArchive) Close() error {
+	return a.file.Close()
+}
+
+// zipFlagUTF8 zip通用标志位中表示文件名为UTF-8的位
+const zipFlagUTF8 = 0x800
+
+// zipUnicodePathExtra Info-ZIP Unicode Path 扩展字段的标识
+const zipUnicodePathExtra = 0x7075
+
+// entryName 解码条目的文件名
+// 优先使用 Unicode Path 扩展字段，其次对未标记UTF-8且不是合法UTF-8的文件名依次尝试配置的编码
+func (a *zipArchive) entryName(file *zip.File) string {
+	if name, ok := zipUnicodePath(file); ok {
+		return name
+	}
+	if file.Flags&zipFlagUTF8 != 0 {
+		return file.Name
+	}
+	name, _ := utils.DecodeFilename(file.Name, a.encodings)
+	return name
+}
+
+// zipUnicodePath 读取 Info-ZIP Unicode Path 扩展字段中的UTF-8文件名
+// 字段中记录了原文件名的CRC32，文件名被其他工具改过时不再使用
+func zipUnicodePath(file *zip.File) (string, bool) {
+	extra := file.Extra
+	for len(extra) >= 4 {
+		tag := binary.LittleEndian.Uint16(extra[0:2])
+		size := int(binary.LittleEndian.Uint16(extra[2:4]))
+		if len(extra) < 4+size {
+			break
+		}
+		data := extra[4 : 4+size]
+		extra = extra[4+size:]
+
+		if tag != zipUnicodePathExtra || len(data) < 5 || data[0] != 1 {
+			continue
+		}
+		if binary.LittleEndian.Uint32(data[1:5]) != crc32.ChecksumIEEE([]byte(file.Name)) {
+			continue
+		}
+		return string(data[5:]), true
+	}
+	return "", false
+}
+
+// readZipLink 读取zip中符号链接条目的目标
+func readZipLink(file *zip.File) (string, error) {
+	rc, err := file.Open()
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(io.LimitReader(rc, 4096))
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
@@ -0,0 +1,459 @@
+package download
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/encoding/simplifiedchinese"
+
+	"github.com/songzhibin97/vman/pkg/types"
+)
+
+// testArchiveFiles 测试压缩包中的文件
+var testArchiveFiles = []struct {
+	name    string
+	content string
+	mode    int64
+}{
+	{"tool-1.0/", "", 0755},
+	{"tool-1.0/bin/tool", "#!/bin/sh\necho tool\n", 0755},
+	{"tool-1.0/README.md", "readme", 0644},
+}
+
+func buildTar(t *testing.T) []byte {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, f := range testArchiveFiles {
+		header := &tar.Header{Name: f.name, Mode: f.mode, Size: int64(len(f.content)), ModTime: time.Unix(1700000000, 0), Typeflag: tar.TypeReg}
+		if f.content == "" {
+			header.Typeflag = tar.TypeDir
+		}
+		require.NoError(t, tw.WriteHeader(header))
+		_, err := tw.Write([]byte(f.content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "tool-1.0/link", Typeflag: tar.TypeSymlink, Linkname: "bin/tool"}))
+	require.NoError(t, tw.Close())
+	return buf.Bytes()
+}
+
+func buildZip(t *testing.T) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, f := range testArchiveFiles {
+		header := &zip.FileHeader{Name: f.name, Method: zip.Deflate}
+		if f.content == "" {
+			header.SetMode(os.ModeDir | 0755)
+		} else {
+			header.SetMode(0644)
+			if f.mode == 0755 {
+				header.SetMode(0755)
+			}
+		}
+		w, err := zw.CreateHeader(header)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(f.content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+// compressWith 使用系统命令压缩，命令不存在时跳过测试
+func compressWith(t *testing.T, command string, data []byte) []byte {
+	path, err := exec.LookPath(command)
+	if err != nil {
+		t.Skipf("%s not available", command)
+	}
+	cmd := exec.Command(path, "-c")
+	cmd.Stdin = bytes.NewReader(data)
+	out, err := cmd.Output()
+	require.NoError(t, err)
+	return out
+}
+
+func TestArchiveFormats(t *testing.T) {
+	tarData := buildTar(t)
+
+	var gz bytes.Buffer
+	gw := gzip.NewWriter(&gz)
+	_, err := gw.Write(tarData)
+	require.NoError(t, err)
+	require.NoError(t, gw.Close())
+
+	archives := map[string]func(t *testing.T) []byte{
+		"tool.zip":     buildZip,
+		"tool.tar":     func(t *testing.T) []byte { return tarData },
+		"tool.tgz":     func(t *testing.T) []byte { return gz.Bytes() },
+		"tool.tar.xz":  func(t *testing.T) []byte { return compressWith(t, "xz", tarData) },
+		"tool.tar.bz2": func(t *testing.T) []byte { return compressWith(t, "bzip2", tarData) },
+		"tool.tar.zst": func(t *testing.T) []byte { return compressWith(t, "zstd", tarData) },
+		"tool.tar.br":  func(t *testing.T) []byte { return compressWith(t, "brotli", tarData) },
+	}
+
+	for name, build := range archives {
+		t.Run(name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			archivePath := filepath.Join("/dl", name)
+			require.NoError(t, afero.WriteFile(fs, archivePath, build(t), 0644))
+
+			entries, err := ListArchive(fs, archivePath)
+			require.NoError(t, err)
+			require.GreaterOrEqual(t, len(entries), 3)
+			assert.True(t, entries[0].IsDir)
+			assert.Equal(t, "tool-1.0/bin/tool", entries[1].Name)
+			assert.Equal(t, int64(20), entries[1].Size)
+
+			extractor := NewArchiveExtractor(fs, logrus.New())
+			assert.True(t, extractor.SupportsFormat(name))
+
+			// 提取单个文件
+			require.NoError(t, extractor.ExtractFile(archivePath, "bin/tool", "/out/tool"))
+			data, err := afero.ReadFile(fs, "/out/tool")
+			require.NoError(t, err)
+			assert.Equal(t, "#!/bin/sh\necho tool\n", string(data))
+			assert.Error(t, extractor.ExtractFile(archivePath, "missing", "/out/missing"))
+
+			// 全部解压
+			require.NoError(t, extractor.Extract(archivePath, "/extract"))
+			info, err := fs.Stat("/extract/tool-1.0/bin/tool")
+			require.NoError(t, err)
+			assert.Equal(t, "-rwxr-xr-x", info.Mode().Perm().String())
+			data, err = afero.ReadFile(fs, "/extract/tool-1.0/README.md")
+			require.NoError(t, err)
+			assert.Equal(t, "readme", string(data))
+		})
+	}
+}
+
+func TestDetectArchiveFormat(t *testing.T) {
+	assert.Equal(t, FormatTarGz, DetectArchiveFormat("a.TAR.GZ"))
+	assert.Equal(t, FormatTarXz, DetectArchiveFormat("a.txz"))
+	assert.Equal(t, FormatTarBz, DetectArchiveFormat("a.tar.bz2"))
+	assert.Equal(t, FormatTarZst, DetectArchiveFormat("a.tzst"))
+	assert.Equal(t, FormatTarZst, DetectArchiveFormat("a.tar.zst"))
+	assert.Equal(t, FormatTarBr, DetectArchiveFormat("a.tar.br"))
+	assert.Equal(t, FormatTar, DetectArchiveFormat("a.tar"))
+	assert.Equal(t, FormatZip, DetectArchiveFormat("a.zip"))
+	assert.Equal(t, "", DetectArchiveFormat("kubectl"))
+
+	_, err := OpenArchive(afero.NewMemMapFs(), "kubectl")
+	assert.Error(t, err)
+}
+
+func TestDetectCompression(t *testing.T) {
+	compression, name := DetectCompression("protoc-gen-foo-linux-amd64.GZ")
+	assert.Equal(t, CompressionGzip, compression)
+	assert.Equal(t, "protoc-gen-foo-linux-amd64", name)
+	compression, name = DetectCompression("tool.zst")
+	assert.Equal(t, CompressionZstd, compression)
+	assert.Equal(t, "tool", name)
+
+	compression, _ = DetectCompression("tool.tar.gz")
+	assert.Equal(t, "", compression)
+	compression, _ = DetectCompression("kubectl")
+	assert.Equal(t, "", compression)
+	compression, _ = DetectCompression(".gz")
+	assert.Equal(t, "", compression)
+}
+
+func TestExtractCompressedBinary(t *testing.T) {
+	content := []byte("#!/bin/sh\necho tool\n")
+	var gz bytes.Buffer
+	gw := gzip.NewWriter(&gz)
+	_, err := gw.Write(content)
+	require.NoError(t, err)
+	require.NoError(t, gw.Close())
+
+	files := map[string]func(t *testing.T) []byte{
+		"tool-linux-amd64.gz":  func(t *testing.T) []byte { return gz.Bytes() },
+		"tool-linux-amd64.xz":  func(t *testing.T) []byte { return compressWith(t, "xz", content) },
+		"tool-linux-amd64.bz2": func(t *testing.T) []byte { return compressWith(t, "bzip2", content) },
+		"tool-linux-amd64.zst": func(t *testing.T) []byte { return compressWith(t, "zstd", content) },
+	}
+	for name, build := range files {
+		t.Run(name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			require.NoError(t, afero.WriteFile(fs, "/dl/"+name, build(t), 0644))
+			extractor := NewArchiveExtractor(fs, logrus.New())
+
+			require.NoError(t, extractor.ExtractWithOptions("/dl/"+name, "/out", &ExtractOptions{}))
+			data, err := afero.ReadFile(fs, "/out/tool-linux-amd64")
+			require.NoError(t, err)
+			assert.Equal(t, content, data)
+			info, err := fs.Stat("/out/tool-linux-amd64")
+			require.NoError(t, err)
+			assert.NotZero(t, info.Mode().Perm()&0100)
+
+			err = extractor.ExtractWithOptions("/dl/"+name, "/limited", &ExtractOptions{Limits: ExtractLimits{MaxFileSize: 5}})
+			var limitErr *ExtractLimitError
+			require.True(t, errors.As(err, &limitErr), "%v", err)
+			assert.Equal(t, LimitFileSize, limitErr.Limit)
+		})
+	}
+
+	// 损坏的压缩文件
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/dl/tool.gz", []byte("not gzip"), 0644))
+	assert.Error(t, NewArchiveExtractor(fs, logrus.New()).Extract("/dl/tool.gz", "/out"))
+}
+
+func TestArchiveRoot(t *testing.T) {
+	entries := func(names ...string) []*ArchiveEntry {
+		var result []*ArchiveEntry
+		for _, name := range names {
+			result = append(result, &ArchiveEntry{Name: name, IsDir: strings.HasSuffix(name, "/")})
+		}
+		return result
+	}
+
+	assert.Equal(t, "tool-1.0", ArchiveRoot(entries("tool-1.0/", "tool-1.0/bin/tool")))
+	assert.Equal(t, "tool-1.0", ArchiveRoot(entries("./", "./tool-1.0/bin/tool", "./tool-1.0/README.md")))
+	assert.Equal(t, "", ArchiveRoot(entries("bin/protoc", "include/", "readme.txt")))
+	assert.Equal(t, "", ArchiveRoot(entries("kubectl")))
+	assert.Equal(t, "", ArchiveRoot(entries("../evil/x")))
+}
+
+func TestExtractStripped(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/dl/tool.tar", buildTar(t), 0644))
+	extractor := NewArchiveExtractor(fs, logrus.New())
+
+	tests := []struct {
+		strip    int
+		expected string
+	}{
+		{0, "/out0/bin/tool"},
+		{-1, "/out-1/tool-1.0/bin/tool"},
+		{2, "/out2/tool"},
+	}
+
+	for _, tt := range tests {
+		dir := fmt.Sprintf("/out%d", tt.strip)
+		require.NoError(t, extractor.ExtractWithOptions("/dl/tool.tar", dir, &ExtractOptions{StripComponents: tt.strip}))
+		exists, err := afero.Exists(fs, tt.expected)
+		require.NoError(t, err)
+		assert.True(t, exists, tt.expected)
+	}
+}
+
+func TestExtractWithOptions_MaxExtractedSize(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/dl/tool.zip", buildZip(t), 0644))
+	extractor := NewArchiveExtractor(fs, logrus.New())
+
+	var files []string
+	err := extractor.ExtractWithOptions("/dl/tool.zip", "/ok", &ExtractOptions{
+		Limits: ExtractLimits{MaxTotalSize: 26},
+		Progress: func(p *ExtractProgress) {
+			files = append(files, p.Entry)
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"tool-1.0/bin/tool", "tool-1.0/README.md"}, files)
+
+	err = extractor.ExtractWithOptions("/dl/tool.zip", "/limited", &ExtractOptions{Limits: ExtractLimits{MaxTotalSize: 25}})
+	var limitErr *ExtractLimitError
+	require.True(t, errors.As(err, &limitErr))
+	assert.Equal(t, LimitTotalSize, limitErr.Limit)
+	assert.Equal(t, "tool-1.0/README.md", limitErr.Entry)
+	exists, _ := afero.Exists(fs, "/limited/README.md")
+	assert.False(t, exists)
+}
+
+func TestExtractWithOptions_Limits(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/dl/tool.tar", buildTar(t), 0644))
+	extractor := NewArchiveExtractor(fs, logrus.New())
+
+	tests := []struct {
+		limits   ExtractLimits
+		expected ExtractLimit
+	}{
+		{ExtractLimits{MaxFileSize: 10}, LimitFileSize},
+		{ExtractLimits{MaxEntries: 2}, LimitEntries},
+		{ExtractLimits{MaxCompressionRatio: 0.001}, LimitCompressionRatio},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.expected), func(t *testing.T) {
+			err := extractor.ExtractWithOptions("/dl/tool.tar", "/out", &ExtractOptions{Limits: tt.limits})
+			var limitErr *ExtractLimitError
+			require.True(t, errors.As(err, &limitErr), "%v", err)
+			assert.Equal(t, tt.expected, limitErr.Limit)
+		})
+	}
+
+	// 未超过限制时正常解压
+	limits := ExtractLimits{MaxFileSize: 20, MaxEntries: 4, MaxCompressionRatio: 1}
+	require.NoError(t, extractor.ExtractWithOptions("/dl/tool.tar", "/ok", &ExtractOptions{Limits: limits}))
+}
+
+func TestExtractLimits_Merge(t *testing.T) {
+	settings := types.DownloadSettings{MaxExtractedSize: 8 << 30, MaxArchiveEntries: 1000, MaxCompressionRatio: 50}
+	limits := ExtractLimits{MaxEntries: 10}.Merge(ExtractLimitsFromSettings(settings))
+
+	assert.Equal(t, ExtractLimits{MaxTotalSize: 8 << 30, MaxEntries: 10, MaxCompressionRatio: 50}, limits)
+
+	// 全局设置中未配置的项使用默认限制，默认限制的每一项都不为 0
+	limits = limits.Merge(DefaultExtractLimits)
+	assert.Equal(t, ExtractLimits{MaxTotalSize: 8 << 30, MaxFileSize: 4 << 30, MaxEntries: 10, MaxCompressionRatio: 50}, limits)
+	defaults := ExtractLimits{}.Merge(ExtractLimitsFromSettings(types.DownloadSettings{})).Merge(DefaultExtractLimits)
+	assert.Equal(t, DefaultExtractLimits, defaults)
+	assert.NotZero(t, defaults.MaxTotalSize)
+	assert.NotZero(t, defaults.MaxFileSize)
+	assert.NotZero(t, defaults.MaxEntries)
+	assert.NotZero(t, defaults.MaxCompressionRatio)
+}
+
+// TestListArchive_Zip64 条目数超过65535时zip使用zip64格式的目录结尾记录
+func TestListArchive_Zip64(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping zip64 test in short mode")
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	const count = 70000
+	for i := 0; i < count; i++ {
+		_, err := zw.CreateHeader(&zip.FileHeader{Name: fmt.Sprintf("pkg/f%d", i), Method: zip.Store})
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/dl/many.zip", buf.Bytes(), 0644))
+
+	entries, err := ListArchive(fs, "/dl/many.zip")
+	require.NoError(t, err)
+	assert.Len(t, entries, count)
+	assert.Equal(t, "pkg", ArchiveRoot(entries))
+}
+
+func TestExtractWithOptions_PreserveMtime(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/dl/tool.tar", buildTar(t), 0644))
+	extractor := NewArchiveExtractor(fs, logrus.New())
+	archived := time.Unix(1700000000, 0)
+
+	require.NoError(t, extractor.ExtractWithOptions("/dl/tool.tar", "/keep", &ExtractOptions{PreserveMtime: true}))
+	for _, path := range []string{"/keep/bin/tool", "/keep/README.md"} {
+		info, err := fs.Stat(path)
+		require.NoError(t, err)
+		assert.True(t, info.ModTime().Equal(archived), path)
+	}
+
+	require.NoError(t, extractor.ExtractWithOptions("/dl/tool.tar", "/now", nil))
+	info, err := fs.Stat("/now/bin/tool")
+	require.NoError(t, err)
+	assert.False(t, info.ModTime().Equal(archived))
+}
+
+func TestExtractWithOptions_PreserveXattrs(t *testing.T) {
+	if !xattrSupported {
+		t.Skip("xattrs not supported on this platform")
+	}
+
+	dir := t.TempDir()
+	probe := filepath.Join(dir, "probe")
+	require.NoError(t, os.WriteFile(probe, nil, 0644))
+	if err := setXattr(probe, "user.vman.test", "1"); err != nil {
+		t.Skipf("filesystem does not support user xattrs: %v", err)
+	}
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	content := "#!/bin/sh\n"
+	require.NoError(t, tw.WriteHeader(&tar.Header{
+		Name:     "tool",
+		Mode:     0755,
+		Size:     int64(len(content)),
+		Typeflag: tar.TypeReg,
+		Format:   tar.FormatPAX,
+		PAXRecords: map[string]string{
+			"SCHILY.xattr.user.vman.origin":       "archive",
+			"SCHILY.xattr.security.capability":    "\x01\x00\x00\x02",
+			"SCHILY.xattr.trusted.overlay.opaque": "y",
+		},
+	}))
+	_, err := tw.Write([]byte(content))
+	require.NoError(t, err)
+	require.NoError(t, tw.Close())
+
+	archivePath := filepath.Join(dir, "tool.tar")
+	require.NoError(t, os.WriteFile(archivePath, buf.Bytes(), 0644))
+
+	fs := afero.NewOsFs()
+	entries, err := ListArchive(fs, archivePath)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"user.vman.origin": "archive"}, entries[0].Xattrs)
+
+	extractor := NewArchiveExtractor(fs, logrus.New())
+	out := filepath.Join(dir, "out")
+	require.NoError(t, extractor.ExtractWithOptions(archivePath, out, &ExtractOptions{PreserveXattrs: true}))
+	xattrs, err := listXattrs(filepath.Join(out, "tool"))
+	require.NoError(t, err)
+	assert.Equal(t, "archive", xattrs["user.vman.origin"])
+	assert.NotContains(t, xattrs, "security.capability")
+	assert.Error(t, setXattr(filepath.Join(out, "tool"), "security.capability", "x"))
+
+	// 复制到版本目录时保留扩展属性
+	copied := filepath.Join(dir, "copied")
+	require.NoError(t, os.WriteFile(copied, []byte(content), 0755))
+	require.NoError(t, copyFileAttributes(fs, filepath.Join(out, "tool"), copied))
+	xattrs, err = listXattrs(copied)
+	require.NoError(t, err)
+	assert.Equal(t, "archive", xattrs["user.vman.origin"])
+}
+
+func TestListArchive_ZipFilenameEncoding(t *testing.T) {
+	gbkName, err := simplifiedchinese.GBK.NewEncoder().String("工具/说明.txt")
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	// 未标记UTF-8的GBK文件名
+	_, err = zw.CreateHeader(&zip.FileHeader{Name: gbkName, Method: zip.Store, NonUTF8: true})
+	require.NoError(t, err)
+
+	// 带有 Unicode Path 扩展字段的文件名
+	original := "legacy.txt"
+	unicodeName := "ツール.txt"
+	extra := make([]byte, 9)
+	binary.LittleEndian.PutUint16(extra[0:2], zipUnicodePathExtra)
+	binary.LittleEndian.PutUint16(extra[2:4], uint16(5+len(unicodeName)))
+	extra[4] = 1
+	binary.LittleEndian.PutUint32(extra[5:9], crc32.ChecksumIEEE([]byte(original)))
+	extra = append(extra, unicodeName...)
+	_, err = zw.CreateHeader(&zip.FileHeader{Name: original, Method: zip.Store, Extra: extra})
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/dl/names.zip", buf.Bytes(), 0644))
+
+	entries, err := ListArchive(fs, "/dl/names.zip", "gbk")
+	require.NoError(t, err)
+	assert.Equal(t, "工具/说明.txt", entries[0].Name)
+	assert.Equal(t, unicodeName, entries[1].Name)
+
+	entries, err = ListArchive(fs, "/dl/names.zip", "cp437")
+	require.NoError(t, err)
+	assert.NotEqual(t, "工具/说明.txt", entries[0].Name)
+}
@@ -0,0 +1,206 @@
+package download
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+
+	"github.com/songzhibin97/vman/pkg/types"
+)
+
+// maxChangelogSize 变更日志文件的最大大小
+const maxChangelogSize = 16 << 20
+
+// errChangelogNotFound 发布说明不存在
+var errChangelogNotFound = errors.New("changelog not found")
+
+// ReleaseNote 一个版本的发布说明
+type ReleaseNote struct {
+	Version     string `json:"version"`
+	ReleaseDate string `json:"release_date,omitempty"`
+	Notes       string `json:"notes"`
+}
+
+// changelogHeading 变更日志中的版本标题，如 "## [1.30.0] - 2024-04-17"、"# v1.30.0"、"## 1.30.0 (April 17, 2024)"
+var changelogHeading = regexp.MustCompile(`^#{1,4}\s+(?:\[?(?:Release|Version)\s+)?\[?v?(\d+\.\d+(?:\.\d+)?(?:-[0-9A-Za-z.-]+)?)\]?(.*)$`)
+
+// changelogDate 版本标题中的日期
+var changelogDate = regexp.MustCompile(`\d{4}-\d{2}-\d{2}`)
+
+// ParseChangelog 将 CHANGELOG.md 等变更日志按版本标题拆分为各版本的发布说明，保持文件中的顺序
+func ParseChangelog(text string) []ReleaseNote {
+	var notes []ReleaseNote
+	var current *ReleaseNote
+	var level int
+	var body []string
+	flush := func() {
+		if current != nil {
+			current.Notes = strings.TrimSpace(strings.Join(body, "\n"))
+			notes = append(notes, *current)
+		}
+	}
+
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		if match := changelogHeading.FindStringSubmatch(line); match != nil {
+			flush()
+			current = &ReleaseNote{Version: match[1], ReleaseDate: changelogDate.FindString(match[2])}
+			level = headingLevel(line)
+			body = nil
+			continue
+		}
+		// 同级或更高级的非版本标题（如 "## Unreleased"）结束当前版本
+		if current != nil && strings.HasPrefix(line, "#") && headingLevel(line) <= level {
+			flush()
+			current = nil
+			continue
+		}
+		body = append(body, line)
+	}
+	flush()
+	return notes
+}
+
+// headingLevel Markdown 标题的级别
+func headingLevel(line string) int {
+	return len(line) - len(strings.TrimLeft(line, "#"))
+}
+
+// FilterReleaseNotes 返回 from 之后（不含）到 to（含）的发布说明，从新到旧排序
+// from、to 为空时不限制；不包含预发布版本，除非 prerelease 为 true 或 to 本身是预发布版本
+func FilterReleaseNotes(notes []ReleaseNote, from, to string, prerelease bool) []ReleaseNote {
+	var fromVersion, toVersion *semver.Version
+	if from != "" {
+		fromVersion, _ = semver.NewVersion(from)
+	}
+	if to != "" {
+		toVersion, _ = semver.NewVersion(to)
+		if toVersion != nil && toVersion.Prerelease() != "" {
+			prerelease = true
+		}
+	}
+
+	var filtered []ReleaseNote
+	var versions []*semver.Version
+	for _, note := range notes {
+		v, err := semver.NewVersion(note.Version)
+		if err != nil || (v.Prerelease() != "" && !prerelease) {
+			continue
+		}
+		if fromVersion != nil && !v.GreaterThan(fromVersion) {
+			continue
+		}
+		if toVersion != nil && v.GreaterThan(toVersion) {
+			continue
+		}
+		filtered = append(filtered, note)
+		versions = append(versions, v)
+	}
+	sort.Sort(releaseNotesByVersion{filtered, versions})
+	return filtered
+}
+
+// releaseNotesByVersion 按版本从新到旧排序发布说明
+type releaseNotesByVersion struct {
+	notes    []ReleaseNote
+	versions []*semver.Version
+}
+
+func (r releaseNotesByVersion) Len() int           { return len(r.notes) }
+func (r releaseNotesByVersion) Less(i, j int) bool { return r.versions[i].GreaterThan(r.versions[j]) }
+func (r releaseNotesByVersion) Swap(i, j int) {
+	r.notes[i], r.notes[j] = r.notes[j], r.notes[i]
+	r.versions[i], r.versions[j] = r.versions[j], r.versions[i]
+}
+
+// FetchReleaseNotes 获取工具在 from 之后到 to 的发布说明，从新到旧排序
+// 工具定义配置了 changelog 时从该地址获取：地址中有版本变量时按版本分别获取，否则获取整个变更日志并按版本标题拆分；
+// 否则使用发布源中各版本的说明（如GitHub release的正文），available 为发布源的版本列表
+func FetchReleaseNotes(ctx context.Context, client *http.Client, metadata *types.ToolMetadata, available []*types.VersionInfo, from, to string, prerelease bool) ([]ReleaseNote, error) {
+	if metadata.Changelog == "" {
+		var notes []ReleaseNote
+		for _, info := range available {
+			notes = append(notes, ReleaseNote{Version: info.Version, ReleaseDate: info.ReleaseDate, Notes: strings.TrimSpace(info.ChangeLog)})
+		}
+		notes = FilterReleaseNotes(notes, from, to, prerelease)
+		for _, note := range notes {
+			if note.Notes != "" {
+				return notes, nil
+			}
+		}
+		if len(notes) > 0 && metadata.DownloadConfig.Type != "github" {
+			return nil, fmt.Errorf("工具 %s 的发布源没有发布说明，请在工具定义中配置 changelog", metadata.Name)
+		}
+		return notes, nil
+	}
+
+	if !IsPerVersionChangelog(metadata.Changelog) {
+		text, err := fetchChangelog(ctx, client, metadata.Changelog)
+		if err != nil {
+			return nil, fmt.Errorf("获取变更日志 %s 失败: %w", metadata.Changelog, err)
+		}
+		return FilterReleaseNotes(ParseChangelog(text), from, to, prerelease), nil
+	}
+
+	var versions []ReleaseNote
+	for _, info := range available {
+		versions = append(versions, ReleaseNote{Version: info.Version, ReleaseDate: info.ReleaseDate})
+	}
+	versions = FilterReleaseNotes(versions, from, to, prerelease)
+	notes := make([]ReleaseNote, 0, len(versions))
+	for _, note := range versions {
+		url, err := ExpandTemplate(metadata.Changelog, TemplateData{Name: metadata.Name, Version: note.Version})
+		if err != nil {
+			return nil, err
+		}
+		text, err := fetchChangelog(ctx, client, url)
+		if errors.Is(err, errChangelogNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("获取 %s 的发布说明失败: %w", note.Version, err)
+		}
+		note.Notes = strings.TrimSpace(text)
+		notes = append(notes, note)
+	}
+	return notes, nil
+}
+
+// IsPerVersionChangelog 发布说明地址中是否有版本变量，有时按版本分别获取
+func IsPerVersionChangelog(url string) bool {
+	return strings.Contains(url, "{version}") || strings.Contains(url, ".Version")
+}
+
+// fetchChangelog 下载发布说明，404 时返回 errChangelogNotFound
+func fetchChangelog(ctx context.Context, client *http.Client, url string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return "", errChangelogNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxChangelogSize+1))
+	if err != nil {
+		return "", err
+	}
+	if len(data) > maxChangelogSize {
+		return "", fmt.Errorf("%s is larger than %d bytes", url, maxChangelogSize)
+	}
+	return string(data), nil
+}
@@ -0,0 +1,96 @@
+package download
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/songzhibin97/vman/pkg/types"
+)
+
+const testChangelog = `# Changelog
+
+## [Unreleased]
+- 未发布的修改
+
+## [1.30.0] - 2024-04-17
+### Added
+- 新功能
+
+## 1.30.0-rc.1
+- 候选版本
+
+## v1.29.1
+- 修复问题
+
+## [1.29.0] - 2024-01-10
+- 第一个版本
+`
+
+func TestParseChangelog(t *testing.T) {
+	notes := ParseChangelog(testChangelog)
+	require.Len(t, notes, 4)
+	assert.Equal(t, ReleaseNote{Version: "1.30.0", ReleaseDate: "2024-04-17", Notes: "### Added\n- 新功能"}, notes[0])
+	assert.Equal(t, "1.30.0-rc.1", notes[1].Version)
+	assert.Equal(t, "1.29.1", notes[2].Version)
+	assert.Equal(t, "- 修复问题", notes[2].Notes)
+
+	filtered := FilterReleaseNotes(notes, "1.29.0", "", false)
+	require.Len(t, filtered, 2)
+	assert.Equal(t, "1.30.0", filtered[0].Version)
+	assert.Equal(t, "1.29.1", filtered[1].Version)
+
+	filtered = FilterReleaseNotes(notes, "1.29.0", "1.30.0-rc.1", false)
+	require.Len(t, filtered, 2)
+	assert.Equal(t, "1.30.0-rc.1", filtered[0].Version)
+}
+
+func TestFetchReleaseNotes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/CHANGELOG.md":
+			w.Write([]byte(testChangelog))
+		case "/notes/1.30.0.md":
+			w.Write([]byte("1.30.0 的发布说明\n"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	available := []*types.VersionInfo{
+		{Version: "1.30.0", ChangeLog: "GitHub release 正文"},
+		{Version: "1.29.1"},
+		{Version: "1.29.0"},
+	}
+
+	// 使用发布源中的说明
+	metadata := &types.ToolMetadata{Name: "kubectl", DownloadConfig: types.DownloadConfig{Type: "github"}}
+	notes, err := FetchReleaseNotes(context.Background(), server.Client(), metadata, available, "1.29.0", "", false)
+	require.NoError(t, err)
+	require.Len(t, notes, 2)
+	assert.Equal(t, "GitHub release 正文", notes[0].Notes)
+
+	// 整个变更日志
+	metadata.Changelog = server.URL + "/CHANGELOG.md"
+	notes, err = FetchReleaseNotes(context.Background(), server.Client(), metadata, nil, "1.29.1", "", false)
+	require.NoError(t, err)
+	require.Len(t, notes, 1)
+	assert.Equal(t, "1.30.0", notes[0].Version)
+
+	// 按版本获取，不存在的版本跳过
+	metadata.Changelog = server.URL + "/notes/{version}.md"
+	notes, err = FetchReleaseNotes(context.Background(), server.Client(), metadata, available, "1.29.0", "", false)
+	require.NoError(t, err)
+	require.Len(t, notes, 1)
+	assert.Equal(t, "1.30.0 的发布说明", notes[0].Notes)
+
+	// 直接下载的发布源没有说明
+	metadata = &types.ToolMetadata{Name: "terraform", DownloadConfig: types.DownloadConfig{Type: "direct"}}
+	_, err = FetchReleaseNotes(context.Background(), server.Client(), metadata, available[1:], "1.29.0", "", false)
+	assert.Error(t, err)
+}
@@ -0,0 +1,134 @@
+package download
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/songzhibin97/vman/pkg/utils"
+)
+
+func checksumTestPayload() []byte {
+	return bytes.Repeat([]byte("vman-checksum-"), 10000)
+}
+
+func TestHTTPDownloader_StreamsChecksum(t *testing.T) {
+	payload := checksumTestPayload()
+	sum := sha256.Sum256(payload)
+	want := hex.EncodeToString(sum[:])
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "tool.tar.gz", time.Time{}, bytes.NewReader(payload))
+	}))
+	defer server.Close()
+
+	fs := afero.NewMemMapFs()
+	downloader := NewHTTPDownloader(fs, logrus.New())
+
+	checksums, err := utils.NewMultiHasher(utils.ChecksumSHA256, utils.ChecksumBLAKE3)
+	require.NoError(t, err)
+	err = downloader.Download(context.Background(), server.URL, "/tmp/tool.tar.gz", &DownloadOptions{Checksums: checksums})
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(payload)), checksums.Written())
+	assert.Equal(t, want, checksums.Sum(utils.ChecksumSHA256))
+
+	// 断点续传时已下载的部分也计入校验和
+	require.NoError(t, afero.WriteFile(fs, "/tmp/resume.tar.gz", payload[:1000], 0644))
+	checksums, err = utils.NewMultiHasher(utils.ChecksumSHA256)
+	require.NoError(t, err)
+	err = downloader.Download(context.Background(), server.URL, "/tmp/resume.tar.gz", &DownloadOptions{Resume: true, Checksums: checksums})
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(payload)), checksums.Written())
+	assert.Equal(t, want, checksums.Sum(utils.ChecksumSHA256))
+
+	data, err := afero.ReadFile(fs, "/tmp/resume.tar.gz")
+	require.NoError(t, err)
+	assert.Equal(t, payload, data)
+}
+
+func TestHTTPDownloader_ResumeNotSupported(t *testing.T) {
+	payload := checksumTestPayload()
+
+	// 忽略 Range 头，总是返回完整内容
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(payload)
+	}))
+	defer server.Close()
+
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/tmp/tool.tar.gz", payload[:1000], 0644))
+
+	checksums, err := utils.NewMultiHasher(utils.ChecksumSHA256)
+	require.NoError(t, err)
+	downloader := NewHTTPDownloader(fs, logrus.New())
+	err = downloader.Download(context.Background(), server.URL, "/tmp/tool.tar.gz", &DownloadOptions{Resume: true, Checksums: checksums})
+	require.NoError(t, err)
+
+	data, err := afero.ReadFile(fs, "/tmp/tool.tar.gz")
+	require.NoError(t, err)
+	assert.Equal(t, payload, data)
+	assert.Equal(t, int64(len(payload)), checksums.Written())
+}
+
+func TestCacheManager_Integrity(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	cache := NewCacheManager(fs, "/cache", logrus.New())
+	payload := checksumTestPayload()
+
+	require.NoError(t, afero.WriteFile(fs, "/tmp/tool.zip", payload, 0644))
+	require.NoError(t, cache.SaveToCache("tool", "1.0.0", "tool.zip", "/tmp/tool.zip"))
+
+	cached := cache.GetCachedFile("tool", "1.0.0", "tool.zip")
+	exists, err := afero.Exists(fs, cached+cacheDigestSuffix)
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	require.NoError(t, cache.LoadFromCache("tool", "1.0.0", "tool.zip", "/work/tool.zip"))
+	data, err := afero.ReadFile(fs, "/work/tool.zip")
+	require.NoError(t, err)
+	assert.Equal(t, payload, data)
+
+	// 缓存文件损坏时报错并删除
+	corrupted := append([]byte(nil), payload...)
+	corrupted[10] ^= 0xff
+	require.NoError(t, afero.WriteFile(fs, cached, corrupted, 0644))
+	err = cache.LoadFromCache("tool", "1.0.0", "tool.zip", "/work/tool2.zip")
+	require.Error(t, err)
+	assert.False(t, cache.IsCached("tool", "1.0.0", "tool.zip"))
+}
+
+func TestDefaultManager_CompleteChecksums(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	m := &DefaultManager{fs: fs, logger: logrus.New()}
+	payload := checksumTestPayload()
+	require.NoError(t, afero.WriteFile(fs, "/tmp/tool.zip", payload, 0644))
+
+	options := &DownloadOptions{}
+	sum := sha256.Sum256(payload)
+	checksums, err := m.prepareChecksums(options, "sha512:"+hex.EncodeToString(make([]byte, 64)))
+	require.NoError(t, err)
+	assert.Same(t, checksums, options.Checksums)
+	assert.Equal(t, []string{utils.ChecksumSHA256, utils.ChecksumSHA512}, checksums.Algorithms())
+
+	// 策略没有写入校验和时重新读取文件
+	completed, err := m.completeChecksums("/tmp/tool.zip", checksums)
+	require.NoError(t, err)
+	assert.NotSame(t, checksums, completed)
+	assert.Equal(t, hex.EncodeToString(sum[:]), completed.Sum(utils.ChecksumSHA256))
+
+	assert.NoError(t, m.validateChecksum(completed, hex.EncodeToString(sum[:])))
+	assert.Error(t, m.validateChecksum(completed, "sha512:"+hex.EncodeToString(make([]byte, 64))))
+
+	_, err = m.prepareChecksums(&DownloadOptions{}, "md5:d41d8cd98f00b204e9800998ecf8427e")
+	assert.Error(t, err)
+}
@@ -0,0 +1,109 @@
+package download
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"net/http/httptrace"
+	"sync/atomic"
+	"time"
+)
+
+// ConnectionStats 本进程HTTP请求的连接复用统计
+type ConnectionStats struct {
+	// Requests 发送的请求数，包括重定向后的请求
+	Requests int64
+
+	// NewConnections 新建的连接数
+	NewConnections int64
+
+	// ReusedConnections 复用已有连接的请求数，HTTP/2 在同一连接上的并发请求也计为复用
+	ReusedConnections int64
+
+	// TLSHandshakes 完成的TLS握手次数
+	TLSHandshakes int64
+
+	// HTTP2Requests 使用HTTP/2的请求数
+	HTTP2Requests int64
+
+	// ConnectTime 等待新建连接（DNS解析、TCP连接和TLS握手）的总时间
+	ConnectTime time.Duration
+}
+
+// ReuseRatio 复用连接的请求占比
+func (s ConnectionStats) ReuseRatio() float64 {
+	total := s.NewConnections + s.ReusedConnections
+	if total == 0 {
+		return 0
+	}
+	return float64(s.ReusedConnections) / float64(total)
+}
+
+// String 统计的单行摘要
+func (s ConnectionStats) String() string {
+	return fmt.Sprintf("%d 个请求，新建 %d 个连接，复用 %d 次 (%.0f%%)，TLS握手 %d 次，HTTP/2 请求 %d 个，建立连接耗时 %s",
+		s.Requests, s.NewConnections, s.ReusedConnections, s.ReuseRatio()*100,
+		s.TLSHandshakes, s.HTTP2Requests, s.ConnectTime.Round(time.Millisecond))
+}
+
+// connectionCounters 连接统计的计数器
+type connectionCounters struct {
+	requests      atomic.Int64
+	newConns      atomic.Int64
+	reusedConns   atomic.Int64
+	tlsHandshakes atomic.Int64
+	http2Requests atomic.Int64
+	connectNanos  atomic.Int64
+}
+
+// connectionStats 通过 NewHTTPClient 创建的所有客户端共用的统计
+var connectionStats connectionCounters
+
+// NetworkStats 返回本进程通过 NewHTTPClient 发送的请求的连接复用统计
+func NetworkStats() ConnectionStats {
+	return ConnectionStats{
+		Requests:          connectionStats.requests.Load(),
+		NewConnections:    connectionStats.newConns.Load(),
+		ReusedConnections: connectionStats.reusedConns.Load(),
+		TLSHandshakes:     connectionStats.tlsHandshakes.Load(),
+		HTTP2Requests:     connectionStats.http2Requests.Load(),
+		ConnectTime:       time.Duration(connectionStats.connectNanos.Load()),
+	}
+}
+
+// ResetNetworkStats 清空连接复用统计
+func ResetNetworkStats() {
+	connectionStats.requests.Store(0)
+	connectionStats.newConns.Store(0)
+	connectionStats.reusedConns.Store(0)
+	connectionStats.tlsHandshakes.Store(0)
+	connectionStats.http2Requests.Store(0)
+	connectionStats.connectNanos.Store(0)
+}
+
+// traceConnections 在请求上下文中加入记录连接复用的跟踪，保留请求已有的跟踪
+func traceConnections(req *http.Request) *http.Request {
+	connectionStats.requests.Add(1)
+	var start time.Time
+	trace := &httptrace.ClientTrace{
+		GetConn: func(string) {
+			start = time.Now()
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			if info.Reused {
+				connectionStats.reusedConns.Add(1)
+				return
+			}
+			connectionStats.newConns.Add(1)
+			if !start.IsZero() {
+				connectionStats.connectNanos.Add(int64(time.Since(start)))
+			}
+		},
+		TLSHandshakeDone: func(_ tls.ConnectionState, err error) {
+			if err == nil {
+				connectionStats.tlsHandshakes.Add(1)
+			}
+		},
+	}
+	return req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+}
@@ -0,0 +1,301 @@
+package download
+
+import (
+	"bytes"
+	"compress/bzip2"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/afero"
+
+	"github.com/songzhibin97/vman/pkg/types"
+	"github.com/songzhibin97/vman/pkg/utils"
+)
+
+// 增量升级：安装包的二进制差分补丁使用 bsdiff 格式（BSDIFF40），由 bsdiff 命令生成。
+// 补丁的来源为镜像中的 deltas 目录（vman mirror --deltas 生成）或工具定义中的 delta_url_template，
+// 应用补丁需要旧版本的安装包，使用增量升级或配置了补丁地址的工具在安装后将安装包保留在下载缓存中。
+
+// DeltaExtension 补丁文件的扩展名
+const DeltaExtension = ".bsdiff"
+
+// MirrorDeltasDir 镜像中平台目录下存放补丁的子目录，补丁文件名为 <旧版本>.bsdiff
+const MirrorDeltasDir = "deltas"
+
+// bsdiffMagic bsdiff 补丁的文件头
+const bsdiffMagic = "BSDIFF40"
+
+// maxDeltaOutputSize 补丁生成的安装包的最大大小
+const maxDeltaOutputSize = 4 << 30
+
+// DeltaStrategy 可以提供二进制差分补丁的下载策略
+type DeltaStrategy interface {
+	// GetDeltaURL 返回从 from 版本的安装包生成 version 版本安装包的补丁地址，没有补丁时返回空
+	GetDeltaURL(ctx context.Context, from, version string) string
+}
+
+// ApplyBsdiff 将 bsdiff 补丁应用到旧文件，返回新文件的内容
+// 补丁来自镜像或下载地址，应用前没有校验，所有长度和偏移在使用前检查范围；输出随读取到的数据增长，
+// 不按补丁头中的大小预先分配
+func ApplyBsdiff(old, patch []byte) ([]byte, error) {
+	if len(patch) < 32 || string(patch[:8]) != bsdiffMagic {
+		return nil, fmt.Errorf("不是有效的 bsdiff 补丁")
+	}
+	ctrlLen := offtin(patch[8:16])
+	diffLen := offtin(patch[16:24])
+	newSize := offtin(patch[24:32])
+	bodyLen := int64(len(patch) - 32)
+	if ctrlLen < 0 || diffLen < 0 || newSize < 0 || newSize > maxDeltaOutputSize ||
+		ctrlLen > bodyLen || diffLen > bodyLen-ctrlLen {
+		return nil, fmt.Errorf("bsdiff 补丁头损坏")
+	}
+
+	body := patch[32:]
+	ctrl := bzip2.NewReader(bytes.NewReader(body[:ctrlLen]))
+	diff := bzip2.NewReader(bytes.NewReader(body[ctrlLen : ctrlLen+diffLen]))
+	extra := bzip2.NewReader(bytes.NewReader(body[ctrlLen+diffLen:]))
+
+	var result bytes.Buffer
+	oldSize := int64(len(old))
+	var oldPos, newPos int64
+	buf := make([]byte, 8)
+	for newPos < newSize {
+		var control [3]int64
+		for i := range control {
+			if _, err := io.ReadFull(ctrl, buf); err != nil {
+				return nil, fmt.Errorf("读取 bsdiff 控制块失败: %w", err)
+			}
+			control[i] = offtin(buf)
+		}
+		// 分别与剩余长度比较，相加可能溢出
+		if control[0] < 0 || control[0] > newSize-newPos || control[0] > oldSize-oldPos ||
+			control[1] < 0 || control[1] > newSize-newPos-control[0] {
+			return nil, fmt.Errorf("bsdiff 补丁损坏")
+		}
+
+		// 差异块的字节与旧文件对应位置的字节相加
+		if _, err := io.CopyN(&result, diff, control[0]); err != nil {
+			return nil, fmt.Errorf("读取 bsdiff 差异块失败: %w", err)
+		}
+		block := result.Bytes()[newPos:]
+		for i := range block {
+			block[i] += old[oldPos+int64(i)]
+		}
+		newPos += control[0]
+		oldPos += control[0]
+
+		// 额外块的字节直接写入
+		if _, err := io.CopyN(&result, extra, control[1]); err != nil {
+			return nil, fmt.Errorf("读取 bsdiff 额外块失败: %w", err)
+		}
+		newPos += control[1]
+
+		// 最后一组控制数据的移动不再使用
+		if newPos < newSize && (control[2] < -oldPos || control[2] > oldSize-oldPos) {
+			return nil, fmt.Errorf("bsdiff 补丁损坏")
+		}
+		oldPos += control[2]
+	}
+	return result.Bytes(), nil
+}
+
+// offtin 读取 bsdiff 中以符号位和小端绝对值表示的整数
+func offtin(buf []byte) int64 {
+	value := int64(binary.LittleEndian.Uint64(buf) &^ (1 << 63))
+	if buf[7]&0x80 != 0 {
+		return -value
+	}
+	return value
+}
+
+// CachedArtifact 返回下载缓存中工具版本的安装包路径，没有缓存时返回空
+func CachedArtifact(fs afero.Fs, cacheDir, tool, version string) string {
+	entries, err := afero.ReadDir(fs, filepath.Join(cacheDir, tool, version))
+	if err != nil {
+		return ""
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() && !strings.HasSuffix(entry.Name(), cacheDigestSuffix) {
+			return filepath.Join(cacheDir, tool, version, entry.Name())
+		}
+	}
+	return ""
+}
+
+// deltaURL 补丁地址：镜像中有补丁时优先使用镜像，否则按工具定义中的 delta_url_template 生成
+func deltaURL(ctx context.Context, strategy Strategy, from, version string) (string, error) {
+	if deltaStrategy, ok := strategy.(DeltaStrategy); ok {
+		if url := deltaStrategy.GetDeltaURL(ctx, from, version); url != "" {
+			return url, nil
+		}
+	}
+	metadata := strategy.GetToolMetadata()
+	if metadata.DownloadConfig.DeltaURLTemplate == "" {
+		return "", nil
+	}
+	platform := types.GetCurrentPlatform()
+	url, err := ExpandTemplate(strings.ReplaceAll(metadata.DownloadConfig.DeltaURLTemplate, "{from}", from), TemplateData{
+		Name:    metadata.Name,
+		Version: version,
+		OS:      platform.OS,
+		Arch:    platform.Arch,
+	})
+	if err != nil {
+		return "", fmt.Errorf("展开补丁地址失败: %w", err)
+	}
+	return url, nil
+}
+
+// downloadDelta 从缓存中 options.DeltaFrom 版本的安装包和补丁生成新版本的安装包，写入 downloadPath
+// 没有旧安装包、补丁或校验和，或者生成的安装包校验失败时返回 false，由调用方下载完整安装包
+func (m *DefaultManager) downloadDelta(ctx context.Context, strategy Strategy, tool, version string, downloadInfo *types.DownloadInfo, downloadPath string, options *DownloadOptions) bool {
+	if options.DeltaFrom == "" || options.DeltaFrom == version {
+		return false
+	}
+	if options.SkipChecksum || downloadInfo.Checksum == "" {
+		m.logger.Infof("%s@%s 没有校验和，无法验证补丁生成的安装包，下载完整安装包", tool, version)
+		return false
+	}
+	base := CachedArtifact(m.fs, m.storageManager.GetCacheDir(), tool, options.DeltaFrom)
+	if base == "" {
+		m.logger.Infof("下载缓存中没有 %s@%s 的安装包，下载完整安装包", tool, options.DeltaFrom)
+		return false
+	}
+	url, err := deltaURL(ctx, strategy, options.DeltaFrom, version)
+	if err != nil || url == "" {
+		m.logger.Infof("没有 %s %s -> %s 的补丁，下载完整安装包", tool, options.DeltaFrom, version)
+		return false
+	}
+
+	// 补丁不计入安装包的校验和
+	patchOptions := *options
+	patchOptions.Checksums = nil
+	patchOptions.OnResponse = nil
+	patchPath := downloadPath + DeltaExtension
+	defer m.fs.Remove(patchPath)
+	if err := strategy.Download(ctx, url, patchPath, &patchOptions); err != nil {
+		m.logger.Infof("下载补丁 %s 失败，下载完整安装包: %v", url, err)
+		return false
+	}
+
+	if err := m.applyDelta(base, patchPath, downloadPath, downloadInfo.Checksum); err != nil {
+		m.logger.Warnf("应用补丁 %s 失败，下载完整安装包: %v", url, err)
+		m.fs.Remove(downloadPath)
+		return false
+	}
+	if info, err := m.fs.Stat(patchPath); err == nil {
+		m.logger.Infof("使用补丁升级 %s %s -> %s，下载 %d 字节", tool, options.DeltaFrom, version, info.Size())
+	}
+	return true
+}
+
+// applyDelta 将补丁应用到旧安装包生成新安装包，并验证新安装包的校验和
+func (m *DefaultManager) applyDelta(basePath, patchPath, targetPath, checksum string) error {
+	old, err := afero.ReadFile(m.fs, basePath)
+	if err != nil {
+		return fmt.Errorf("读取旧安装包失败: %w", err)
+	}
+	patch, err := afero.ReadFile(m.fs, patchPath)
+	if err != nil {
+		return fmt.Errorf("读取补丁失败: %w", err)
+	}
+	result, err := ApplyBsdiff(old, patch)
+	if err != nil {
+		return err
+	}
+
+	algorithm, _, err := utils.ParseChecksum(checksum)
+	if err != nil {
+		return fmt.Errorf("无效的校验和: %w", err)
+	}
+	hasher, err := utils.NewMultiHasher(algorithm)
+	if err != nil {
+		return err
+	}
+	hasher.Write(result)
+	if err := hasher.Verify(checksum); err != nil {
+		return fmt.Errorf("补丁生成的安装包校验失败: %w", err)
+	}
+	return afero.WriteFile(m.fs, targetPath, result, 0644)
+}
+
+// cacheArtifact 将安装包保留在下载缓存中供以后增量升级，只保留已安装版本的安装包
+func (m *DefaultManager) cacheArtifact(tool, version, downloadPath string) {
+	cacheDir := m.storageManager.GetCacheDir()
+	cache := NewCacheManager(m.fs, cacheDir, m.logger)
+	if existing := CachedArtifact(m.fs, cacheDir, tool, version); existing != "" {
+		m.fs.RemoveAll(filepath.Dir(existing))
+	}
+	if err := cache.SaveToCache(tool, version, filepath.Base(downloadPath), downloadPath); err != nil {
+		m.logger.Warnf("保留 %s@%s 的安装包失败: %v", tool, version, err)
+		return
+	}
+
+	entries, err := afero.ReadDir(m.fs, filepath.Join(cacheDir, tool))
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() || entry.Name() == version {
+			continue
+		}
+		if _, err := m.fs.Stat(m.storageManager.GetToolVersionPath(tool, entry.Name())); errors.Is(err, os.ErrNotExist) {
+			m.fs.RemoveAll(filepath.Join(cacheDir, tool, entry.Name()))
+		}
+	}
+}
+
+// cachedArtifactFor 下载缓存中可以直接使用的该版本安装包，没有时返回空
+// 文件名与下载信息一致，且下载源声明了校验和时与校验和一致，才视为同一个安装包
+func (m *DefaultManager) cachedArtifactFor(tool, version string, downloadInfo *types.DownloadInfo, options *DownloadOptions) string {
+	if options.Force || downloadInfo.Filename == "" {
+		return ""
+	}
+	cache := NewCacheManager(m.fs, m.storageManager.GetCacheDir(), m.logger)
+	if !cache.IsCached(tool, version, downloadInfo.Filename) {
+		return ""
+	}
+	cached := cache.GetCachedFile(tool, version, downloadInfo.Filename)
+	if options.SkipChecksum || downloadInfo.Checksum == "" {
+		return cached
+	}
+
+	algorithm, _, err := utils.ParseChecksum(downloadInfo.Checksum)
+	if err != nil {
+		return ""
+	}
+	checksums, err := utils.NewMultiHasher(algorithm)
+	if err != nil {
+		return ""
+	}
+	file, err := m.fs.Open(cached)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+	if _, err := copyBuffer(checksums, file); err != nil || checksums.Verify(downloadInfo.Checksum) != nil {
+		m.logger.Debugf("下载缓存中 %s@%s 的安装包与校验和不一致，重新下载", tool, version)
+		return ""
+	}
+	return cached
+}
+
+// useCachedArtifact 下载缓存中有该版本的安装包时复制到 downloadPath，不再下载
+func (m *DefaultManager) useCachedArtifact(tool, version string, downloadInfo *types.DownloadInfo, downloadPath string, options *DownloadOptions) bool {
+	if m.cachedArtifactFor(tool, version, downloadInfo, options) == "" {
+		return false
+	}
+	cache := NewCacheManager(m.fs, m.storageManager.GetCacheDir(), m.logger)
+	if err := cache.LoadFromCache(tool, version, downloadInfo.Filename, downloadPath); err != nil {
+		m.logger.Warnf("使用下载缓存中 %s@%s 的安装包失败，重新下载: %v", tool, version, err)
+		return false
+	}
+	m.logger.Infof("使用下载缓存中 %s@%s 的安装包", tool, version)
+	return true
+}
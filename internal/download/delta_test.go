@@ -0,0 +1,161 @@
+package download
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/songzhibin97/vman/internal/storage"
+	"github.com/songzhibin97/vman/pkg/types"
+)
+
+var (
+	testDeltaOld = []byte("vman kubectl 1.29.0 binary \x00\x01\x02 payload with some shared content")
+	testDeltaNew = []byte("vman kubectl 1.30.0 binary \x00\x01\x03 payload with more shared content!")
+)
+
+// testDeltaPatch 由 testDeltaOld 生成 testDeltaNew 的 bsdiff 补丁，包含两组控制数据和向后移动的旧文件位置
+const testDeltaPatch = "QlNESUZGNDAzAAAAAAAAAHEAAAAAAAAAQAAAAAAAAABCWmg5MUFZJlNZr/dSjwAADchAWhggAEAAIAAiNpGgQwIvZJEkwEa+zxdyRThQkK/3Uo9CWmg5MUFZJlNZRuHWGAAAAH8vweyIAAAAgGB4DBAAUACAELSsAQggAEAByYygAFRgADIA0wIyMI9Qxo0BoBiAyBpjQgxRmguA4qAIhJCnyLXz5nKZKUdMN2pq3tOIAcHwMU9rgAPxdyRThQkEbh1hgEJaaDkxQVkmU1kz5BLRAAACEYBgAAAEBAAgACGMgzTQYPF3JFOFCQM+QS0Q"
+
+func TestApplyBsdiff(t *testing.T) {
+	patch, err := base64.StdEncoding.DecodeString(testDeltaPatch)
+	require.NoError(t, err)
+
+	result, err := ApplyBsdiff(testDeltaOld, patch)
+	require.NoError(t, err)
+	assert.Equal(t, testDeltaNew, result)
+
+	_, err = ApplyBsdiff(testDeltaOld, []byte("not a patch"))
+	assert.Error(t, err)
+	_, err = ApplyBsdiff(testDeltaOld, patch[:40])
+	assert.Error(t, err)
+
+	// 构造的补丁：相加会溢出的控制数据、移出旧文件范围的位置、补丁头声明的大小远超实际数据
+	for _, malformed := range []string{
+		"QlNESUZGNDArAAAAAAAAAA4AAAAAAAAAZAAAAAAAAABCWmg5MUFZJlNZhk7QmgAAAEQATABAACAAISGgzTTJgicXckU4UJCGTtCaQlpoORdyRThQkAAAAABCWmg5F3JFOFCQAAAAAA==",
+		"QlNESUZGNDAxAAAAAAAAAA4AAAAAAAAAAgAAAAAAAABCWmg5MUFZJlNZgEbeiAAAAUBAfghAACAAMQwIGTQeps4mNsVJFHi7kinChIQCNvRAQlpoORdyRThQkAAAAABCWmg5MUFZJlNZ6ZP9zQAAAAEAMAAgACEAgrF3JFOFCQ6ZP9zQ",
+		"QlNESUZGNDAsAAAAAAAAAA4AAAAAAAAA/////wAAAABCWmg5MUFZJlNZUX6hDQAAAMAAxEAAAKAAMM00EhougaPF3JFOFCQUX6hDQEJaaDkXckU4UJAAAAAAQlpoORdyRThQkAAAAAA=",
+	} {
+		data, err := base64.StdEncoding.DecodeString(malformed)
+		require.NoError(t, err)
+		_, err = ApplyBsdiff(testDeltaOld, data)
+		assert.Error(t, err)
+	}
+}
+
+func TestDownloadDelta(t *testing.T) {
+	patch, err := base64.StdEncoding.DecodeString(testDeltaPatch)
+	require.NoError(t, err)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/deltas/1.29.0-1.30.0.bsdiff" {
+			w.Write(patch)
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	home := t.TempDir()
+	fs := afero.NewOsFs()
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	m := &DefaultManager{
+		storageManager: storage.NewFilesystemManagerWithFs(fs, types.DefaultConfigPaths(home)),
+		fs:             fs,
+		logger:         logger,
+	}
+	metadata := &types.ToolMetadata{
+		Name: "kubectl",
+		DownloadConfig: types.DownloadConfig{
+			Type:             "direct",
+			URLTemplate:      server.URL + "/{version}/kubectl",
+			DeltaURLTemplate: server.URL + "/deltas/{from}-{version}.bsdiff",
+		},
+	}
+	strategy := NewDirectStrategy(metadata, fs, logger)
+	sum := sha256.Sum256(testDeltaNew)
+	info := &types.DownloadInfo{Filename: "kubectl", Checksum: "sha256:" + hex.EncodeToString(sum[:])}
+	target := filepath.Join(t.TempDir(), "kubectl")
+	ctx := context.Background()
+
+	// 缓存中没有旧安装包
+	assert.False(t, m.downloadDelta(ctx, strategy, "kubectl", "1.30.0", info, target, &DownloadOptions{DeltaFrom: "1.29.0"}))
+
+	oldPath := filepath.Join(t.TempDir(), "kubectl")
+	require.NoError(t, os.WriteFile(oldPath, testDeltaOld, 0644))
+	require.NoError(t, os.MkdirAll(m.storageManager.GetToolVersionPath("kubectl", "1.29.0"), 0755))
+	m.cacheArtifact("kubectl", "1.29.0", oldPath)
+	assert.NotEmpty(t, CachedArtifact(fs, m.storageManager.GetCacheDir(), "kubectl", "1.29.0"))
+
+	assert.True(t, m.downloadDelta(ctx, strategy, "kubectl", "1.30.0", info, target, &DownloadOptions{DeltaFrom: "1.29.0"}))
+	data, err := os.ReadFile(target)
+	require.NoError(t, err)
+	assert.Equal(t, testDeltaNew, data)
+	assert.NoFileExists(t, target+DeltaExtension)
+
+	// 生成的安装包与校验和不一致时下载完整安装包
+	require.NoError(t, os.Remove(target))
+	wrong := &types.DownloadInfo{Filename: "kubectl", Checksum: "sha256:" + hex.EncodeToString(make([]byte, 32))}
+	assert.False(t, m.downloadDelta(ctx, strategy, "kubectl", "1.30.0", wrong, target, &DownloadOptions{DeltaFrom: "1.29.0"}))
+	assert.NoFileExists(t, target)
+
+	// 没有补丁或校验和
+	assert.False(t, m.downloadDelta(ctx, strategy, "kubectl", "1.31.0", info, target, &DownloadOptions{DeltaFrom: "1.29.0"}))
+	assert.False(t, m.downloadDelta(ctx, strategy, "kubectl", "1.30.0", &types.DownloadInfo{Filename: "kubectl"}, target, &DownloadOptions{DeltaFrom: "1.29.0"}))
+
+	// 保留新版本的安装包时清理未安装版本的安装包
+	require.NoError(t, os.RemoveAll(m.storageManager.GetToolVersionPath("kubectl", "1.29.0")))
+	require.NoError(t, os.WriteFile(target, testDeltaNew, 0644))
+	m.cacheArtifact("kubectl", "1.30.0", target)
+	assert.Empty(t, CachedArtifact(fs, m.storageManager.GetCacheDir(), "kubectl", "1.29.0"))
+	assert.NotEmpty(t, CachedArtifact(fs, m.storageManager.GetCacheDir(), "kubectl", "1.30.0"))
+}
+
+func TestMirrorDeltas(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	root := "/mirror"
+	platform := "linux-amd64"
+	for _, version := range []string{"1.28.0", "1.29.0", "1.30.0", "1.31.0"} {
+		dir := filepath.Join(root, "kubectl", version, platform)
+		entry := &MirrorEntry{Tool: "kubectl", Version: version, Platform: platform, Filename: "kubectl-" + version + ".tar.gz"}
+		require.NoError(t, afero.WriteFile(fs, filepath.Join(dir, entry.Filename), []byte(version), 0644))
+		require.NoError(t, writeMirrorIndex(fs, dir, entry))
+	}
+
+	entry := &MirrorEntry{Tool: "kubectl", Version: "1.30.0", Platform: platform, Filename: "kubectl-1.30.0.tar.gz"}
+	var patches []string
+	generated, err := MirrorDeltas(fs, root, entry, 2, func(oldPath, newPath, patchPath string) error {
+		patches = append(patches, patchPath)
+		return afero.WriteFile(fs, patchPath, []byte("patch"), 0644)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1.29.0", "1.28.0"}, generated)
+	assert.Equal(t, filepath.Join(root, "kubectl", "1.30.0", platform, MirrorDeltasDir, "1.29.0"+DeltaExtension), patches[0])
+
+	data, err := afero.ReadFile(fs, filepath.Join(root, "kubectl", "1.30.0", platform, MirrorIndexFile))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"1.29.0"`)
+
+	// 镜像索引中记录了补丁时使用镜像中的补丁
+	strategy := NewMirrorStrategy(NewDirectStrategy(&types.ToolMetadata{Name: "kubectl"}, fs, logrus.New()), root, fs, logrus.New()).(*MirrorStrategy)
+	if types.PlatformDirName() == platform {
+		assert.Equal(t, filepath.Join(root, "kubectl", "1.30.0", platform, MirrorDeltasDir, "1.29.0"+DeltaExtension), strategy.GetDeltaURL(context.Background(), "1.29.0", "1.30.0"))
+	}
+	assert.Empty(t, strategy.GetDeltaURL(context.Background(), "1.27.0", "1.30.0"))
+
+	// 没有更低的版本时不生成补丁
+	generated, err = MirrorDeltas(fs, root, &MirrorEntry{Tool: "kubectl", Version: "1.28.0", Platform: platform, Filename: "kubectl-1.28.0.tar.gz"}, 2, nil)
+	require.NoError(t, err)
+	assert.Empty(t, generated)
+}
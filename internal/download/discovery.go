@@ -175,7 +175,7 @@ func (m *DefaultPlatformMatcher) GetPattern() string {
 
 // IsArchiveSupported 是否支持压缩包格式
 func (m *DefaultPlatformMatcher) IsArchiveSupported(filename string) bool {
-	supportedExt := []string{".tar.gz", ".tgz", ".zip", ".tar.bz2", ".tar.xz"}
+	supportedExt := []string{".tar.gz", ".tgz", ".zip", ".tar.bz2", ".tar.xz", ".tar.zst", ".tzst", ".tar.br"}
 
 	for _, ext := range supportedExt {
 		if strings.HasSuffix(strings.ToLower(filename), ext) {
@@ -286,6 +286,11 @@ func TestDefaultPlatformMatcher_IsArchiveSupported(t *testing.T) {
 			filename: "kubectl.tar.xz",
 			expected: true,
 		},
+		{
+			name:     "tar.zst file",
+			filename: "kubectl.tar.zst",
+			expected: true,
+		},
 		{
 			name:     "exe file",
 			filename: "kubectl.exe",
@@ -446,7 +451,7 @@ func (m *MockStrategy) DownloadWithProgress(ctx context.Context, url, targetPath
 	return nil
 }
 
-func (m *MockStrategy) ExtractArchive(archivePath, targetPath string) error {
+func (m *MockStrategy) ExtractArchive(archivePath, targetPath string, options *ExtractOptions) error {
 	return nil
 }
 
@@ -0,0 +1,125 @@
+package download
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/spf13/afero"
+
+	"github.com/songzhibin97/vman/pkg/utils"
+)
+
+// availableDiskSpace 查询路径所在文件系统的可用空间，测试中可以替换
+var availableDiskSpace = utils.AvailableDiskSpace
+
+// isTmpfs 查询路径是否位于内存文件系统，测试中可以替换
+var isTmpfs = utils.IsTmpfs
+
+// DiskSpaceShortage 下载或解压前检查发现的磁盘空间不足
+type DiskSpaceShortage struct {
+	// Path 空间不足的目录
+	Path string
+
+	// Required 估算需要的字节数
+	Required int64
+
+	// Available 可用的字节数
+	Available int64
+}
+
+func (e *DiskSpaceShortage) Error() string {
+	return fmt.Sprintf("磁盘空间不足: %s 所在的分区需要约 %s，只有 %s 可用\n"+
+		"可以运行 vman prune 清理长期未使用的版本，或 vman reset cache 清空下载缓存和临时目录",
+		e.Path, formatSize(e.Required), formatSize(e.Available))
+}
+
+// checkDiskSpace 检查临时目录和版本目录所在分区的空间是否足够下载和安装大小为 size 的安装包，downloaded 表示安装包已经下载
+// 临时目录需要容纳安装包和解压后的文件，解压后的文件移动到版本目录；两个目录在同一分区时移动不占用额外空间
+// size 未知或无法查询可用空间时不检查
+func (m *DefaultManager) checkDiskSpace(filename string, size int64, downloaded bool, tempDir, versionDir string) error {
+	if size <= 0 {
+		return nil
+	}
+	if _, ok := m.fs.(*afero.OsFs); !ok {
+		return nil
+	}
+
+	extracted := estimateInstallSize(filename, size)
+	tempRequired := extracted
+	if !downloaded {
+		tempRequired += size
+	}
+	checks := []struct {
+		path     string
+		required int64
+	}{
+		{tempDir, tempRequired},
+		{versionDir, extracted},
+	}
+	for _, check := range checks {
+		available, err := availableDiskSpace(check.path)
+		if err != nil {
+			m.logger.Debugf("查询 %s 的可用空间失败: %v", check.path, err)
+			continue
+		}
+		if uint64(check.required) > available {
+			return &DiskSpaceShortage{Path: filepath.Clean(check.path), Required: check.required, Available: int64(available)}
+		}
+	}
+	return nil
+}
+
+// tempDirFor 选择下载和解压安装包的临时目录
+// 临时目录位于tmpfs时，内存中放不下的安装包改用磁盘上的目录：大小未知、超过 options.TmpfsMaxArtifact 或tmpfs可用空间不足
+// 依次尝试vman的临时目录和数据目录下的 tmp，与版本目录在同一分区时安装只需移动文件
+func (m *DefaultManager) tempDirFor(filename string, size int64, options *DownloadOptions) string {
+	tempDir := options.TempDir
+	if _, ok := m.fs.(*afero.OsFs); !ok {
+		return tempDir
+	}
+	if tmpfs, err := isTmpfs(tempDir); err != nil || !tmpfs || m.fitsTmpfs(tempDir, filename, size, options.TmpfsMaxArtifact) {
+		return tempDir
+	}
+
+	candidates := []string{
+		m.storageManager.GetTempDir(),
+		filepath.Join(filepath.Dir(m.storageManager.GetVersionsDir()), "tmp"),
+	}
+	for _, dir := range candidates {
+		if dir == tempDir {
+			continue
+		}
+		if tmpfs, err := isTmpfs(dir); err == nil && !tmpfs {
+			m.logger.Infof("临时目录 %s 位于tmpfs，%s 改用 %s 下载和解压", tempDir, filename, dir)
+			return dir
+		}
+	}
+	m.logger.Debugf("没有磁盘上的临时目录可用，继续使用 %s", tempDir)
+	return tempDir
+}
+
+// fitsTmpfs 大小已知且不超过限制的安装包，下载和解压需要的空间不超过tmpfs的可用空间时可以放在tmpfs中
+func (m *DefaultManager) fitsTmpfs(dir, filename string, size, limit int64) bool {
+	if size <= 0 || (limit > 0 && size > limit) {
+		return false
+	}
+	available, err := availableDiskSpace(dir)
+	if err != nil {
+		return false
+	}
+	return uint64(size+estimateInstallSize(filename, size)) <= available
+}
+
+// formatSize 格式化字节数
+func formatSize(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,84 @@
+package download
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/songzhibin97/vman/internal/storage"
+	"github.com/songzhibin97/vman/pkg/types"
+)
+
+func TestCheckDiskSpace(t *testing.T) {
+	tempDir := filepath.Join(t.TempDir(), "tmp")
+	versionDir := filepath.Join(t.TempDir(), "versions", "kubectl", "1.30.0")
+	available := map[string]uint64{tempDir: 10000, versionDir: 10000}
+	original := availableDiskSpace
+	availableDiskSpace = func(path string) (uint64, error) {
+		space, ok := available[path]
+		if !ok {
+			return 0, errors.New("unknown path")
+		}
+		return space, nil
+	}
+	defer func() { availableDiskSpace = original }()
+
+	m := &DefaultManager{fs: afero.NewOsFs(), logger: logrus.New()}
+
+	// 压缩包下载和解压共需要约 4 倍于安装包的空间
+	assert.NoError(t, m.checkDiskSpace("kubectl.tar.gz", 2500, false, tempDir, versionDir))
+	err := m.checkDiskSpace("kubectl.tar.gz", 2600, false, tempDir, versionDir)
+	var shortage *DiskSpaceShortage
+	require.True(t, errors.As(err, &shortage))
+	assert.Equal(t, tempDir, shortage.Path)
+	assert.Equal(t, int64(10400), shortage.Required)
+	assert.Contains(t, err.Error(), "vman prune")
+
+	// 已下载时临时目录只需要容纳解压后的文件
+	assert.NoError(t, m.checkDiskSpace("kubectl.tar.gz", 3000, true, tempDir, versionDir))
+
+	// 版本目录所在分区空间不足
+	available[versionDir] = 500
+	err = m.checkDiskSpace("kubectl", 600, false, tempDir, versionDir)
+	require.True(t, errors.As(err, &shortage))
+	assert.Equal(t, versionDir, shortage.Path)
+
+	// 大小未知、无法查询可用空间或不是本地文件系统时不检查
+	assert.NoError(t, m.checkDiskSpace("kubectl", 0, false, tempDir, versionDir))
+	assert.NoError(t, m.checkDiskSpace("kubectl", 600, false, tempDir, "/unknown"))
+	memManager := &DefaultManager{fs: afero.NewMemMapFs(), logger: logrus.New()}
+	assert.NoError(t, memManager.checkDiskSpace("kubectl", 1<<40, false, tempDir, versionDir))
+}
+
+func TestTempDirFor(t *testing.T) {
+	paths := types.DefaultConfigPaths(t.TempDir())
+	shm := filepath.Join(t.TempDir(), "shm")
+	originalTmpfs, originalSpace := isTmpfs, availableDiskSpace
+	isTmpfs = func(path string) (bool, error) { return path == shm, nil }
+	availableDiskSpace = func(path string) (uint64, error) { return 10000, nil }
+	defer func() { isTmpfs, availableDiskSpace = originalTmpfs, originalSpace }()
+
+	fs := afero.NewOsFs()
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	m := &DefaultManager{storageManager: storage.NewFilesystemManagerWithFs(fs, paths), fs: fs, logger: logger}
+	options := &DownloadOptions{TempDir: shm, TmpfsMaxArtifact: 2000}
+
+	// tmpfs中放得下的小安装包
+	assert.Equal(t, shm, m.tempDirFor("kubectl.tar.gz", 1000, options))
+
+	// 超过限制、tmpfs空间不足或大小未知时改用磁盘上的临时目录
+	assert.Equal(t, paths.TempDir, m.tempDirFor("kubectl.tar.gz", 3000, options))
+	options.TmpfsMaxArtifact = 1 << 30
+	assert.Equal(t, paths.TempDir, m.tempDirFor("kubectl.tar.gz", 5000, options))
+	assert.Equal(t, paths.TempDir, m.tempDirFor("kubectl.tar.gz", 0, options))
+
+	// 临时目录不在tmpfs中时直接使用
+	disk := t.TempDir()
+	assert.Equal(t, disk, m.tempDirFor("kubectl.tar.gz", 0, &DownloadOptions{TempDir: disk}))
+}
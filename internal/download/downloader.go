@@ -3,6 +3,7 @@ package download
 import (
 	"context"
 	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"net/http"
@@ -13,6 +14,7 @@ import (
 	"time"
 
 	"github.com/sirupsen/logrus"
+	"github.com/songzhibin97/vman/pkg/types"
 	"github.com/songzhibin97/vman/pkg/utils"
 	"github.com/spf13/afero"
 )
@@ -47,12 +49,12 @@ type HTTPDownloader struct {
 
 // NewHTTPDownloader 创建HTTP下载器
 func NewHTTPDownloader(fs afero.Fs, logger *logrus.Logger) Downloader {
+	client := NewHTTPClient(30 * time.Minute)
+	client.CheckRedirect = checkRedirect(logger)
 	return &HTTPDownloader{
 		fs:     fs,
 		logger: logger,
-		client: &http.Client{
-			Timeout: 30 * time.Minute,
-		},
+		client: client,
 	}
 }
 
@@ -74,7 +76,12 @@ func (d *HTTPDownloader) Download(ctx context.Context, url, targetPath string, o
 		}
 	}
 
-	// 创建HTTP请求
+	// 创建HTTP请求，重定向时按策略处理认证头
+	var redirects *types.RedirectPolicy
+	if options != nil {
+		redirects = options.Redirects
+	}
+	ctx, _ = withRedirectPolicy(ctx, redirects)
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return fmt.Errorf("创建HTTP请求失败: %w", err)
@@ -103,21 +110,17 @@ func (d *HTTPDownloader) Download(ctx context.Context, url, targetPath string, o
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
 		return fmt.Errorf("HTTP请求失败，状态码: %d", resp.StatusCode)
 	}
+	d.notifyResponse(url, resp, options)
 
 	// 打开目标文件
-	var file afero.File
-	if startOffset > 0 {
-		file, err = d.fs.OpenFile(targetPath, os.O_APPEND|os.O_WRONLY, 0644)
-	} else {
-		file, err = d.fs.Create(targetPath)
-	}
+	file, err := d.openTarget(targetPath, startOffset, resp, options)
 	if err != nil {
-		return fmt.Errorf("打开目标文件失败: %w", err)
+		return err
 	}
 	defer file.Close()
 
-	// 复制数据
-	_, err = io.Copy(file, resp.Body)
+	// 复制数据，同时计算校验和
+	_, err = copyBuffer(checksumWriter(file, options), resp.Body)
 	if err != nil {
 		return fmt.Errorf("下载数据失败: %w", err)
 	}
@@ -136,7 +139,8 @@ func (d *HTTPDownloader) DownloadWithProgress(ctx context.Context, url, targetPa
 	}
 
 	// 获取文件大小
-	totalSize, err := d.GetDownloadSize(ctx, url, options.Headers)
+	sizeCtx, _ := withRedirectPolicy(ctx, options.Redirects)
+	totalSize, err := d.GetDownloadSize(sizeCtx, url, options.Headers)
 	if err != nil {
 		d.logger.Warnf("获取文件大小失败: %v", err)
 		totalSize = 0
@@ -151,7 +155,8 @@ func (d *HTTPDownloader) DownloadWithProgress(ctx context.Context, url, targetPa
 		}
 	}
 
-	// 创建HTTP请求
+	// 创建HTTP请求，重定向时按策略处理认证头
+	ctx, _ = withRedirectPolicy(ctx, options.Redirects)
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return fmt.Errorf("创建HTTP请求失败: %w", err)
@@ -178,24 +183,23 @@ func (d *HTTPDownloader) DownloadWithProgress(ctx context.Context, url, targetPa
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
 		return fmt.Errorf("HTTP请求失败，状态码: %d", resp.StatusCode)
 	}
+	d.notifyResponse(url, resp, options)
 
 	// 打开目标文件
-	var file afero.File
-	if startOffset > 0 {
-		file, err = d.fs.OpenFile(targetPath, os.O_APPEND|os.O_WRONLY, 0644)
-	} else {
-		file, err = d.fs.Create(targetPath)
-	}
+	file, err := d.openTarget(targetPath, startOffset, resp, options)
 	if err != nil {
-		return fmt.Errorf("打开目标文件失败: %w", err)
+		return err
 	}
 	defer file.Close()
+	if resp.StatusCode == http.StatusOK {
+		startOffset = 0
+	}
 
 	// 创建进度跟踪读取器
 	reader := NewProgressReader(resp.Body, totalSize, startOffset, progress)
 
-	// 复制数据
-	_, err = io.Copy(file, reader)
+	// 复制数据，同时计算校验和
+	_, err = copyBuffer(checksumWriter(file, options), reader)
 	if err != nil {
 		return fmt.Errorf("下载数据失败: %w", err)
 	}
@@ -223,6 +227,72 @@ func (d *HTTPDownloader) Resume(ctx context.Context, url, targetPath string, opt
 	return d.Download(ctx, url, targetPath, options)
 }
 
+// openTarget 打开下载的目标文件
+// 断点续传时服务器返回 206 则追加写入，并先将已下载的部分计入校验和；返回 200 表示不支持续传，从头写入
+func (d *HTTPDownloader) openTarget(targetPath string, startOffset int64, resp *http.Response, options *DownloadOptions) (afero.File, error) {
+	if startOffset == 0 || resp.StatusCode != http.StatusPartialContent {
+		if startOffset > 0 {
+			d.logger.Debugf("服务器不支持断点续传，重新下载: %s", targetPath)
+		}
+		file, err := d.fs.Create(targetPath)
+		if err != nil {
+			return nil, fmt.Errorf("打开目标文件失败: %w", err)
+		}
+		return file, nil
+	}
+
+	if options != nil && options.Checksums != nil {
+		existing, err := d.fs.Open(targetPath)
+		if err != nil {
+			return nil, fmt.Errorf("打开目标文件失败: %w", err)
+		}
+		_, err = copyBuffer(options.Checksums, io.LimitReader(existing, startOffset))
+		existing.Close()
+		if err != nil {
+			return nil, fmt.Errorf("读取已下载的部分失败: %w", err)
+		}
+	}
+
+	file, err := d.fs.OpenFile(targetPath, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("打开目标文件失败: %w", err)
+	}
+	return file, nil
+}
+
+// checksumWriter 在设置了 options.Checksums 时将写入的数据同时送入校验和计算
+func checksumWriter(file io.Writer, options *DownloadOptions) io.Writer {
+	if options == nil || options.Checksums == nil {
+		return file
+	}
+	return io.MultiWriter(file, options.Checksums)
+}
+
+// notifyResponse 将响应信息通知给调用方
+func (d *HTTPDownloader) notifyResponse(url string, resp *http.Response, options *DownloadOptions) {
+	if options == nil || options.OnResponse == nil {
+		return
+	}
+
+	finalURL := url
+	redirects := 0
+	if resp.Request != nil && resp.Request.URL != nil {
+		finalURL = resp.Request.URL.String()
+		redirects = redirectHops(resp.Request.Context())
+	}
+
+	options.OnResponse(&ResponseInfo{
+		RequestURL:    url,
+		FinalURL:      finalURL,
+		StatusCode:    resp.StatusCode,
+		ETag:          resp.Header.Get("ETag"),
+		LastModified:  resp.Header.Get("Last-Modified"),
+		ContentType:   resp.Header.Get("Content-Type"),
+		ContentLength: resp.ContentLength,
+		Redirects:     redirects,
+	})
+}
+
 // ValidateChecksum 验证校验和
 func (d *HTTPDownloader) ValidateChecksum(filePath, expectedChecksum string) error {
 	if expectedChecksum == "" {
@@ -231,14 +301,20 @@ func (d *HTTPDownloader) ValidateChecksum(filePath, expectedChecksum string) err
 
 	d.logger.Debugf("验证文件校验和: %s", filePath)
 
-	// 计算文件的SHA256
-	actualChecksum, err := utils.CalculateFileChecksum(filePath)
+	// 校验和可以带算法前缀，如 sha512:...，没有前缀时按长度推断
+	algorithm, digest, err := utils.ParseChecksum(expectedChecksum)
+	if err != nil {
+		return err
+	}
+
+	sums, err := utils.CalculateFileChecksums(filePath, algorithm)
 	if err != nil {
 		return fmt.Errorf("计算文件校验和失败: %w", err)
 	}
 
 	// 比较校验和
-	if !strings.EqualFold(actualChecksum, expectedChecksum) {
+	actualChecksum := sums[algorithm]
+	if actualChecksum != digest {
 		return fmt.Errorf("校验和不匹配: 期望 %s, 实际 %s", expectedChecksum, actualChecksum)
 	}
 
@@ -385,6 +461,9 @@ func (pr *ProgressReader) updateProgress() {
 	})
 }
 
+// cacheDigestSuffix 缓存文件摘要的文件名后缀
+const cacheDigestSuffix = ".xxh64"
+
 // CacheManager 缓存管理器
 type CacheManager struct {
 	fs       afero.Fs
@@ -422,8 +501,12 @@ func (c *CacheManager) SaveToCache(tool, version, filename, sourcePath string) e
 		return fmt.Errorf("创建缓存目录失败: %w", err)
 	}
 
-	// 复制文件到缓存
-	return c.copyFile(sourcePath, cachedPath)
+	// 复制文件到缓存，同时计算用于完整性检查的 xxh64 摘要，保存在同名的 .xxh64 文件中
+	digest, err := c.copyFileWithDigest(sourcePath, cachedPath)
+	if err != nil {
+		return err
+	}
+	return afero.WriteFile(c.fs, cachedPath+cacheDigestSuffix, []byte(digest+"\n"), 0644)
 }
 
 // LoadFromCache 从缓存加载
@@ -434,8 +517,24 @@ func (c *CacheManager) LoadFromCache(tool, version, filename, targetPath string)
 		return fmt.Errorf("文件未缓存: %s", cachedPath)
 	}
 
-	// 从缓存复制文件
-	return c.copyFile(cachedPath, targetPath)
+	// 从缓存复制文件，并与保存时的 xxh64 摘要比对，缓存文件损坏时删除
+	digest, err := c.copyFileWithDigest(cachedPath, targetPath)
+	if err != nil {
+		return err
+	}
+	expected, err := afero.ReadFile(c.fs, cachedPath+cacheDigestSuffix)
+	if err != nil {
+		// 旧版本保存的缓存没有摘要文件，无法检查
+		c.logger.Debugf("缓存文件没有摘要，跳过完整性检查: %s", cachedPath)
+		return nil
+	}
+	if strings.TrimSpace(string(expected)) != digest {
+		c.fs.Remove(cachedPath)
+		c.fs.Remove(cachedPath + cacheDigestSuffix)
+		c.fs.Remove(targetPath)
+		return fmt.Errorf("缓存文件已损坏，已删除: %s", cachedPath)
+	}
+	return nil
 }
 
 // ClearCache 清理缓存
@@ -480,8 +579,17 @@ func (c *CacheManager) CleanExpiredCache(maxAge time.Duration) error {
 	return nil
 }
 
-// copyFile 复制文件
-func (c *CacheManager) copyFile(src, dst string) error {
+// copyFileWithDigest 复制文件并返回内容的 xxh64 摘要
+func (c *CacheManager) copyFileWithDigest(src, dst string) (string, error) {
+	hasher := utils.NewXXHash64()
+	if err := c.copyFileTo(src, dst, hasher); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+// copyFileTo 复制文件，同时将内容写入 w
+func (c *CacheManager) copyFileTo(src, dst string, w io.Writer) error {
 	srcFile, err := c.fs.Open(src)
 	if err != nil {
 		return fmt.Errorf("打开源文件失败: %w", err)
@@ -499,7 +607,7 @@ func (c *CacheManager) copyFile(src, dst string) error {
 	}
 	defer dstFile.Close()
 
-	_, err = io.Copy(dstFile, srcFile)
+	_, err = copyBuffer(io.MultiWriter(dstFile, w), srcFile)
 	return err
 }
 
@@ -547,7 +655,7 @@ func (v *ChecksumValidator) ValidateSHA256(filePath, expectedChecksum string) er
 	defer file.Close()
 
 	hasher := sha256.New()
-	if _, err := io.Copy(hasher, file); err != nil {
+	if _, err := copyBuffer(hasher, file); err != nil {
 		return fmt.Errorf("计算校验和失败: %w", err)
 	}
 
@@ -0,0 +1,90 @@
+package download
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// windowsMaxPath Windows 传统API的路径长度上限
+const windowsMaxPath = 260
+
+// windowsReservedNames Windows 保留的设备名，不论扩展名都不能用作文件名
+var windowsReservedNames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true,
+	"COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true,
+	"LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// sanitizeWindowsPath 将条目路径转换为 Windows 上合法的路径，返回是否做了修改
+// 保留设备名（如 aux.go）后追加下划线，非法字符替换为下划线，去掉结尾的点和空格
+func sanitizeWindowsPath(name string) (string, bool) {
+	parts := strings.Split(name, "/")
+	changed := false
+	for i, part := range parts {
+		if part == "" || part == "." || part == ".." {
+			continue
+		}
+
+		sanitized := strings.Map(func(r rune) rune {
+			if r < 32 || strings.ContainsRune(`<>:"|?*`, r) {
+				return '_'
+			}
+			return r
+		}, part)
+		sanitized = strings.TrimRight(sanitized, ". ")
+		if sanitized == "" {
+			sanitized = "_"
+		}
+
+		base := sanitized
+		if dot := strings.Index(base, "."); dot >= 0 {
+			base = base[:dot]
+		}
+		if windowsReservedNames[strings.ToUpper(strings.TrimRight(base, " "))] {
+			sanitized = base + "_" + sanitized[len(base):]
+		}
+
+		if sanitized != part {
+			parts[i] = sanitized
+			changed = true
+		}
+	}
+	return strings.Join(parts, "/"), changed
+}
+
+// windowsLongPath 为超过 260 个字符的 Windows 绝对路径加上 \\?\ 前缀，使其能绕过路径长度限制
+func windowsLongPath(path string) string {
+	if len(path) < windowsMaxPath || strings.HasPrefix(path, `\\?\`) {
+		return path
+	}
+	if strings.HasPrefix(path, `\\`) {
+		// UNC 路径 \\server\share 对应 \\?\UNC\server\share
+		return `\\?\UNC\` + strings.TrimPrefix(path, `\\`)
+	}
+	if len(path) >= 3 && path[1] == ':' && path[2] == '\\' {
+		return `\\?\` + path
+	}
+	return path
+}
+
+// caseCollisions 检测解压出的路径是否只有大小写不同
+// 在 Windows 和 macOS 默认的不区分大小写的文件系统上，这样的条目会互相覆盖
+type caseCollisions struct {
+	seen map[string]string
+}
+
+// check 记录路径，与已有路径只有大小写不同时返回已有的路径
+func (c *caseCollisions) check(path string) (string, bool) {
+	if c.seen == nil {
+		c.seen = make(map[string]string)
+	}
+	path = filepath.Clean(path)
+	key := strings.ToLower(path)
+	if previous, exists := c.seen[key]; exists && previous != path {
+		return previous, true
+	}
+	c.seen[key] = path
+	return "", false
+}
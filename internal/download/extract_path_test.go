@@ -0,0 +1,64 @@
+package download
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSanitizeWindowsPath(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+		changed  bool
+	}{
+		{"tool-1.0/bin/tool.exe", "tool-1.0/bin/tool.exe", false},
+		{"src/aux.go", "src/aux_.go", true},
+		{"CON", "CON_", true},
+		{"lpt1.tar.gz", "lpt1_.tar.gz", true},
+		{"console/app", "console/app", false},
+		{"docs/what?.md", "docs/what_.md", true},
+		{"trailing./file ", "trailing/file", true},
+		{"./a/../b", "./a/../b", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, changed := sanitizeWindowsPath(tt.name)
+			assert.Equal(t, tt.expected, result)
+			assert.Equal(t, tt.changed, changed)
+		})
+	}
+}
+
+func TestWindowsLongPath(t *testing.T) {
+	short := `C:\Users\dev\.vman\versions\jdk\21\bin\java.exe`
+	assert.Equal(t, short, windowsLongPath(short))
+
+	long := `C:\Users\dev\.vman\versions\` + strings.Repeat(`deep\`, 60) + `file`
+	assert.Equal(t, `\\?\`+long, windowsLongPath(long))
+	assert.Equal(t, `\\?\`+long, windowsLongPath(`\\?\`+long))
+
+	unc := `\\server\share\` + strings.Repeat(`deep\`, 60)
+	assert.Equal(t, `\\?\UNC\server\share\`+strings.Repeat(`deep\`, 60), windowsLongPath(unc))
+
+	relative := strings.Repeat(`deep\`, 60)
+	assert.Equal(t, relative, windowsLongPath(relative))
+}
+
+func TestCaseCollisions(t *testing.T) {
+	var collisions caseCollisions
+
+	_, collided := collisions.check("/out/bin/Tool")
+	assert.False(t, collided)
+	_, collided = collisions.check("/out/bin/Tool")
+	assert.False(t, collided)
+
+	previous, collided := collisions.check("/out/bin/tool")
+	assert.True(t, collided)
+	assert.Equal(t, "/out/bin/Tool", previous)
+
+	_, collided = collisions.check("/out/bin/tool2")
+	assert.False(t, collided)
+}
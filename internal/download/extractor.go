@@ -1,18 +1,18 @@
 package download
 
 import (
-	"archive/tar"
-	"archive/zip"
-	"compress/gzip"
 	"fmt"
 	"io"
 	"os"
+	"path"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
 
 	"github.com/sirupsen/logrus"
 	"github.com/songzhibin97/vman/pkg/types"
+	"github.com/songzhibin97/vman/pkg/utils"
 	"github.com/spf13/afero"
 )
 
@@ -21,6 +21,9 @@ type Extractor interface {
 	// Extract 解压文件
 	Extract(archivePath, targetDir string) error
 
+	// ExtractWithOptions 按选项解压文件
+	ExtractWithOptions(archivePath, targetDir string, options *ExtractOptions) error
+
 	// ExtractFile 解压指定文件
 	ExtractFile(archivePath, fileName, targetPath string) error
 
@@ -46,6 +49,45 @@ type BinaryExtractor interface {
 	ValidateBinary(filePath string) error
 }
 
+// ExtractOptions 解压选项
+type ExtractOptions struct {
+	// StripComponents 去掉条目路径的前几层目录，取值含义同 types.DownloadConfig.StripComponents
+	StripComponents int
+
+	// Limits 解压安全限制
+	Limits ExtractLimits
+
+	// PreserveMtime 保留压缩包中记录的修改时间，否则为解压时的时间
+	PreserveMtime bool
+
+	// PreserveXattrs 在支持的系统上恢复tar压缩包中记录的扩展属性
+	PreserveXattrs bool
+
+	// FilenameEncodings zip中非UTF-8文件名依次尝试的编码，为空时根据系统语言环境选择
+	FilenameEncodings []string
+
+	// Progress 每解压完一个文件时回调
+	Progress ExtractProgressCallback
+
+	// Filter 不为空时只解压返回 true 的文件条目，参数为去掉前几层目录后以 / 分隔的路径
+	Filter func(name string) bool
+}
+
+// ExtractProgress 解压进度
+type ExtractProgress struct {
+	// Entry 刚解压完的条目
+	Entry string
+
+	// Files 已解压的文件数
+	Files int
+
+	// Written 已写入的字节数
+	Written int64
+}
+
+// ExtractProgressCallback 解压进度回调函数
+type ExtractProgressCallback func(*ExtractProgress)
+
 // ArchiveExtractor 压缩包解压器
 type ArchiveExtractor struct {
 	fs     afero.Fs
@@ -60,243 +102,322 @@ func NewArchiveExtractor(fs afero.Fs, logger *logrus.Logger) Extractor {
 	}
 }
 
-// Extract 解压文件
+// Extract 解压文件，保持压缩包原有结构
 func (e *ArchiveExtractor) Extract(archivePath, targetDir string) error {
-	e.logger.Debugf("解压文件: %s -> %s", archivePath, targetDir)
+	return e.extract(archivePath, targetDir, 0, &ExtractOptions{})
+}
 
-	// 确保目标目录存在
-	if err := e.fs.MkdirAll(targetDir, 0755); err != nil {
-		return fmt.Errorf("创建目标目录失败: %w", err)
+// ExtractWithOptions 按选项解压文件
+// StripComponents 为 0 时自动检测，所有条目都位于同一个顶层目录下时去掉该目录；为负数时保持原有结构
+func (e *ArchiveExtractor) ExtractWithOptions(archivePath, targetDir string, options *ExtractOptions) error {
+	if options == nil {
+		options = &ExtractOptions{}
 	}
 
-	// 根据文件扩展名选择解压方法
-	switch {
-	case strings.HasSuffix(archivePath, ".tar.gz") || strings.HasSuffix(archivePath, ".tgz"):
-		return e.extractTarGz(archivePath, targetDir)
-	case strings.HasSuffix(archivePath, ".tar.bz2"):
-		return e.extractTarBz2(archivePath, targetDir)
-	case strings.HasSuffix(archivePath, ".tar.xz"):
-		return e.extractTarXz(archivePath, targetDir)
-	case strings.HasSuffix(archivePath, ".zip"):
-		return e.extractZip(archivePath, targetDir)
-	case strings.HasSuffix(archivePath, ".tar"):
-		return e.extractTar(archivePath, targetDir)
-	default:
-		// 如果不是压缩包，直接复制文件
-		return e.copyBinaryFile(archivePath, targetDir)
+	stripComponents := options.StripComponents
+	if stripComponents == 0 && DetectArchiveFormat(archivePath) != "" {
+		entries, err := ListArchive(e.fs, archivePath, options.FilenameEncodings...)
+		if err != nil {
+			return err
+		}
+		// 条目数超过上限时不必再解压
+		if max := options.Limits.MaxEntries; max > 0 && len(entries) > max {
+			return &ExtractLimitError{Limit: LimitEntries, Max: strconv.Itoa(max), Entry: entries[max].Name}
+		}
+		if root := ArchiveRoot(entries); root != "" {
+			e.logger.Debugf("去掉压缩包的顶层目录: %s", root)
+			stripComponents = 1
+		}
 	}
-}
-
-// ExtractFile 解压指定文件
-func (e *ArchiveExtractor) ExtractFile(archivePath, fileName, targetPath string) error {
-	e.logger.Debugf("解压指定文件: %s 中的 %s -> %s", archivePath, fileName, targetPath)
-
-	switch {
-	case strings.HasSuffix(archivePath, ".tar.gz") || strings.HasSuffix(archivePath, ".tgz"):
-		return e.extractTarGzFile(archivePath, fileName, targetPath)
-	case strings.HasSuffix(archivePath, ".zip"):
-		return e.extractZipFile(archivePath, fileName, targetPath)
-	default:
-		return fmt.Errorf("不支持的压缩格式: %s", archivePath)
+	if stripComponents < 0 {
+		stripComponents = 0
 	}
+	return e.extract(archivePath, targetDir, stripComponents, options)
 }
 
-// ListContents 列出压缩包内容
-func (e *ArchiveExtractor) ListContents(archivePath string) ([]string, error) {
-	switch {
-	case strings.HasSuffix(archivePath, ".tar.gz") || strings.HasSuffix(archivePath, ".tgz"):
-		return e.listTarGzContents(archivePath)
-	case strings.HasSuffix(archivePath, ".zip"):
-		return e.listZipContents(archivePath)
-	default:
-		return nil, fmt.Errorf("不支持的压缩格式: %s", archivePath)
-	}
-}
+// extract 解压文件并去掉条目路径的前 strip 层
+// 条目逐个以流的方式写入磁盘，内存占用与压缩包大小无关
+func (e *ArchiveExtractor) extract(archivePath, targetDir string, strip int, options *ExtractOptions) error {
+	e.logger.Debugf("解压文件: %s -> %s", archivePath, targetDir)
 
-// SupportsFormat 是否支持格式
-func (e *ArchiveExtractor) SupportsFormat(filename string) bool {
-	supportedExts := []string{
-		".tar.gz", ".tgz", ".tar.bz2", ".tar.xz", ".tar", ".zip",
+	// 确保目标目录存在
+	if err := e.fs.MkdirAll(targetDir, 0755); err != nil {
+		return fmt.Errorf("创建目标目录失败: %w", err)
 	}
 
-	for _, ext := range supportedExts {
-		if strings.HasSuffix(strings.ToLower(filename), ext) {
-			return true
+	// 如果不是压缩包，解压单个压缩文件或直接复制文件
+	if DetectArchiveFormat(archivePath) == "" {
+		if compression, _ := DetectCompression(archivePath); compression != "" {
+			return e.decompressBinaryFile(archivePath, targetDir, compression, options)
 		}
+		return e.copyBinaryFile(archivePath, targetDir)
 	}
 
-	return false
-}
-
-// extractTarGz 解压tar.gz文件
-func (e *ArchiveExtractor) extractTarGz(archivePath, targetDir string) error {
-	file, err := e.fs.Open(archivePath)
-	if err != nil {
-		return fmt.Errorf("打开压缩文件失败: %w", err)
-	}
-	defer file.Close()
-
-	gzReader, err := gzip.NewReader(file)
+	archive, err := OpenArchive(e.fs, archivePath, options.FilenameEncodings...)
 	if err != nil {
-		return fmt.Errorf("创建gzip读取器失败: %w", err)
+		return err
 	}
-	defer gzReader.Close()
+	defer archive.Close()
 
-	return e.extractTarReader(gzReader, targetDir)
-}
-
-// extractTar 解压tar文件
-func (e *ArchiveExtractor) extractTar(archivePath, targetDir string) error {
-	file, err := e.fs.Open(archivePath)
-	if err != nil {
-		return fmt.Errorf("打开tar文件失败: %w", err)
+	budget := &extractBudget{limits: options.Limits}
+	if info, err := e.fs.Stat(archivePath); err == nil {
+		budget.archiveSize = info.Size()
 	}
-	defer file.Close()
 
-	return e.extractTarReader(file, targetDir)
-}
-
-// extractTarReader 解压tar读取器
-func (e *ArchiveExtractor) extractTarReader(reader io.Reader, targetDir string) error {
-	tarReader := tar.NewReader(reader)
+	cleanTarget := filepath.Clean(targetDir) + string(os.PathSeparator)
+	progress := &ExtractProgress{}
+	var dirs []string
+	var dirEntries []*ArchiveEntry
+	var collisions caseCollisions
+	err = archive.Walk(func(entry *ArchiveEntry, open func() (io.ReadCloser, error)) error {
+		if err := budget.addEntry(entry.Name); err != nil {
+			return err
+		}
 
-	for {
-		header, err := tarReader.Next()
-		if err == io.EOF {
-			break
+		name := stripPathComponents(entry.Name, strip)
+		if name == "" {
+			return nil
 		}
-		if err != nil {
-			return fmt.Errorf("读取tar条目失败: %w", err)
+		if runtime.GOOS == "windows" {
+			if sanitized, changed := sanitizeWindowsPath(name); changed {
+				e.logger.Warnf("%s 在 Windows 上不是合法的文件名，解压为 %s", entry.Name, sanitized)
+				name = sanitized
+			}
 		}
-
-		targetPath := filepath.Join(targetDir, header.Name)
+		targetPath := filepath.Join(targetDir, name)
 
 		// 安全性检查：防止路径遍历攻击
-		if !strings.HasPrefix(targetPath, filepath.Clean(targetDir)+string(os.PathSeparator)) {
-			e.logger.Warnf("跳过不安全的路径: %s", header.Name)
-			continue
+		if !strings.HasPrefix(targetPath, cleanTarget) {
+			e.logger.Warnf("跳过不安全的路径: %s", entry.Name)
+			return nil
 		}
 
-		switch header.Typeflag {
-		case tar.TypeDir:
-			if err := e.fs.MkdirAll(targetPath, os.FileMode(header.Mode)); err != nil {
-				return fmt.Errorf("创建目录失败: %w", err)
+		if !entry.IsDir {
+			if previous, collided := collisions.check(targetPath); collided {
+				e.logger.Warnf("%s 与 %s 只有大小写不同，在不区分大小写的文件系统上会覆盖后者", targetPath, previous)
 			}
+		}
+		targetPath = e.fsPath(targetPath)
 
-		case tar.TypeReg:
-			// 创建父目录
-			if err := e.fs.MkdirAll(filepath.Dir(targetPath), 0755); err != nil {
-				return fmt.Errorf("创建父目录失败: %w", err)
+		switch {
+		case entry.IsDir:
+			if err := e.fs.MkdirAll(targetPath, dirMode(entry.Mode)); err != nil {
+				return fmt.Errorf("创建目录失败: %w", err)
 			}
-
-			// 创建文件
-			outFile, err := e.fs.Create(targetPath)
-			if err != nil {
-				return fmt.Errorf("创建文件失败: %w", err)
+			dirs = append(dirs, targetPath)
+			dirEntries = append(dirEntries, entry)
+		case entry.IsLink():
+			// 处理符号链接
+			e.logger.Debugf("跳过链接文件: %s", entry.Name)
+		case entry.Mode.IsRegular():
+			if options.Filter != nil && !options.Filter(name) {
+				return nil
 			}
-
-			// 复制内容
-			if _, err := io.Copy(outFile, tarReader); err != nil {
-				outFile.Close()
-				return fmt.Errorf("写入文件失败: %w", err)
+			remaining, limit := budget.remaining()
+			written, err := e.writeEntry(targetPath, entry, open, remaining)
+			if err != nil {
+				if err == errSizeLimit {
+					return budget.exceeded(limit, entry.Name)
+				}
+				return err
 			}
-			outFile.Close()
-
-			// 设置权限
-			if err := e.fs.Chmod(targetPath, os.FileMode(header.Mode)); err != nil {
-				e.logger.Warnf("设置文件权限失败: %v", err)
+			budget.written += written
+			e.applyMetadata(targetPath, entry, options)
+
+			progress.Entry = entry.Name
+			progress.Files++
+			progress.Written += written
+			if options.Progress != nil {
+				options.Progress(progress)
 			}
-
-		case tar.TypeSymlink, tar.TypeLink:
-			// 处理符号链接
-			e.logger.Debugf("跳过链接文件: %s", header.Name)
 		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
+	// 目录中写入文件会更新目录的修改时间，最后由内向外恢复
+	for i := len(dirs) - 1; i >= 0; i-- {
+		e.applyMetadata(dirs[i], dirEntries[i], options)
+	}
 	return nil
 }
 
-// extractZip 解压zip文件
-func (e *ArchiveExtractor) extractZip(archivePath, targetDir string) error {
-	// 对于afero，需要特殊处理zip文件
-	if osFs, ok := e.fs.(*afero.OsFs); ok {
-		return e.extractZipOS(archivePath, targetDir, osFs)
+// fsPath 返回实际读写文件时使用的路径，Windows 上超长的路径加上 \\?\ 前缀
+func (e *ArchiveExtractor) fsPath(path string) string {
+	if runtime.GOOS != "windows" {
+		return path
+	}
+	if _, ok := e.fs.(*afero.OsFs); !ok {
+		return path
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		return windowsLongPath(abs)
+	}
+	return path
+}
+
+// applyMetadata 按选项恢复条目的修改时间和扩展属性，失败时只记录警告
+func (e *ArchiveExtractor) applyMetadata(path string, entry *ArchiveEntry, options *ExtractOptions) {
+	if options.PreserveXattrs && len(entry.Xattrs) > 0 {
+		if _, ok := e.fs.(*afero.OsFs); ok && xattrSupported {
+			for name, value := range entry.Xattrs {
+				if err := setXattr(path, name, value); err != nil {
+					e.logger.Warnf("设置扩展属性 %s 失败: %s: %v", name, entry.Name, err)
+				}
+			}
+		}
 	}
 
-	return fmt.Errorf("zip解压暂时只支持操作系统文件系统")
+	if options.PreserveMtime && !entry.ModTime.IsZero() {
+		if err := e.fs.Chtimes(path, entry.ModTime, entry.ModTime); err != nil {
+			e.logger.Warnf("设置修改时间失败: %s: %v", entry.Name, err)
+		}
+	}
 }
 
-// extractZipOS 在操作系统文件系统上解压zip
-func (e *ArchiveExtractor) extractZipOS(archivePath, targetDir string, osFs *afero.OsFs) error {
-	reader, err := zip.OpenReader(archivePath)
+// copyFileAttributes 将源文件的修改时间和扩展属性复制到目标文件
+// 解压时未保留这些信息的文件，修改时间就是解压时间，复制后不会造成差异
+func copyFileAttributes(fs afero.Fs, src, dst string) error {
+	info, err := fs.Stat(src)
 	if err != nil {
-		return fmt.Errorf("打开zip文件失败: %w", err)
+		return err
 	}
-	defer reader.Close()
 
-	for _, file := range reader.File {
-		targetPath := filepath.Join(targetDir, file.Name)
-
-		// 安全性检查
-		if !strings.HasPrefix(targetPath, filepath.Clean(targetDir)+string(os.PathSeparator)) {
-			e.logger.Warnf("跳过不安全的路径: %s", file.Name)
-			continue
+	if _, ok := fs.(*afero.OsFs); ok && xattrSupported {
+		xattrs, err := listXattrs(src)
+		if err != nil {
+			return err
 		}
-
-		if file.FileInfo().IsDir() {
-			if err := e.fs.MkdirAll(targetPath, file.FileInfo().Mode()); err != nil {
-				return fmt.Errorf("创建目录失败: %w", err)
+		for name, value := range xattrs {
+			if !strings.HasPrefix(name, userXattrPrefix) {
+				continue
+			}
+			if err := setXattr(dst, name, value); err != nil {
+				return err
 			}
-			continue
 		}
+	}
 
-		// 创建父目录
-		if err := e.fs.MkdirAll(filepath.Dir(targetPath), 0755); err != nil {
-			return fmt.Errorf("创建父目录失败: %w", err)
-		}
+	return fs.Chtimes(dst, info.ModTime(), info.ModTime())
+}
 
-		// 打开zip文件中的文件
-		srcFile, err := file.Open()
-		if err != nil {
-			return fmt.Errorf("打开zip中的文件失败: %w", err)
-		}
+// ExtractFile 解压指定文件
+func (e *ArchiveExtractor) ExtractFile(archivePath, fileName, targetPath string) error {
+	e.logger.Debugf("解压指定文件: %s 中的 %s -> %s", archivePath, fileName, targetPath)
 
-		// 创建目标文件
-		dstFile, err := e.fs.Create(targetPath)
-		if err != nil {
-			srcFile.Close()
-			return fmt.Errorf("创建目标文件失败: %w", err)
-		}
+	archive, err := OpenArchive(e.fs, archivePath)
+	if err != nil {
+		return err
+	}
+	defer archive.Close()
 
-		// 复制内容
-		if _, err := io.Copy(dstFile, srcFile); err != nil {
-			srcFile.Close()
-			dstFile.Close()
-			return fmt.Errorf("复制文件内容失败: %w", err)
+	found := false
+	err = archive.Walk(func(entry *ArchiveEntry, open func() (io.ReadCloser, error)) error {
+		if !entry.Mode.IsRegular() || (entry.Name != fileName && !strings.HasSuffix(entry.Name, "/"+fileName)) {
+			return nil
 		}
+		found = true
+		if _, err := e.writeEntry(targetPath, entry, open, -1); err != nil {
+			return err
+		}
+		return errStopWalk
+	})
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("在压缩包中未找到文件: %s", fileName)
+	}
+	return nil
+}
 
-		srcFile.Close()
-		dstFile.Close()
+// ListContents 列出压缩包内容
+func (e *ArchiveExtractor) ListContents(archivePath string) ([]string, error) {
+	entries, err := ListArchive(e.fs, archivePath)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		files = append(files, entry.Name)
+	}
+	return files, nil
+}
+
+// SupportsFormat 是否支持格式
+func (e *ArchiveExtractor) SupportsFormat(filename string) bool {
+	return DetectArchiveFormat(filename) != ""
+}
+
+// writeEntry 将压缩包中的文件条目写入目标路径并设置权限，返回写入的字节数
+// remaining 为允许写入的最大字节数，负数表示不限制；按实际解压出的数据计数，不信任条目头中记录的大小
+func (e *ArchiveExtractor) writeEntry(targetPath string, entry *ArchiveEntry, open func() (io.ReadCloser, error), remaining int64) (int64, error) {
+	// 创建父目录
+	if err := e.fs.MkdirAll(filepath.Dir(targetPath), 0755); err != nil {
+		return 0, fmt.Errorf("创建父目录失败: %w", err)
+	}
+
+	src, err := open()
+	if err != nil {
+		return 0, fmt.Errorf("打开压缩包中的文件失败: %w", err)
+	}
+	defer src.Close()
 
-		// 设置权限
-		if err := e.fs.Chmod(targetPath, file.FileInfo().Mode()); err != nil {
-			e.logger.Warnf("设置文件权限失败: %v", err)
+	outFile, err := e.fs.Create(targetPath)
+	if err != nil {
+		return 0, fmt.Errorf("创建文件失败: %w", err)
+	}
+
+	var reader io.Reader = src
+	if remaining >= 0 {
+		reader = io.LimitReader(src, remaining+1)
+	}
+	written, err := copyBuffer(outFile, reader)
+	outFile.Close()
+	if err == nil && remaining >= 0 && written > remaining {
+		err = errSizeLimit
+	}
+	if err != nil {
+		e.fs.Remove(targetPath)
+		if err == errSizeLimit {
+			return written, err
 		}
+		return written, fmt.Errorf("写入文件失败: %w", err)
 	}
 
-	return nil
+	// 设置权限，Chmod 不受umask影响，按umask收紧压缩包中记录的权限
+	if err := e.fs.Chmod(targetPath, fileMode(entry.Mode)); err != nil {
+		e.logger.Warnf("设置文件权限失败: %v", err)
+	}
+	return written, nil
 }
 
-// extractTarBz2 解压tar.bz2文件
-func (e *ArchiveExtractor) extractTarBz2(archivePath, targetDir string) error {
-	// 这里需要使用bzip2包，暂时返回不支持
-	return fmt.Errorf("tar.bz2格式暂未支持")
+// stripPathComponents 去掉条目路径的前 n 层，剩余部分为空时返回空字符串
+func stripPathComponents(name string, n int) string {
+	parts := splitEntryPath(name)
+	if len(parts) <= n {
+		return ""
+	}
+	return strings.Join(parts[n:], "/")
 }
 
-// extractTarXz 解压tar.xz文件
-func (e *ArchiveExtractor) extractTarXz(archivePath, targetDir string) error {
-	// 这里需要使用xz包，暂时返回不支持
-	return fmt.Errorf("tar.xz格式暂未支持")
+// fileMode 文件权限，压缩包中未记录权限时使用 0644，记录的权限按umask收紧
+func fileMode(mode os.FileMode) os.FileMode {
+	if mode.Perm() == 0 {
+		return utils.UmaskMode(utils.PublicFileMode)
+	}
+	return utils.UmaskMode(mode.Perm())
+}
+
+// dirMode 目录权限，压缩包中未记录权限时使用 0755
+func dirMode(mode os.FileMode) os.FileMode {
+	if mode.Perm() == 0 {
+		return 0755
+	}
+	return mode.Perm()
 }
 
 // copyBinaryFile 复制二进制文件
@@ -320,115 +441,60 @@ func (e *ArchiveExtractor) copyBinaryFile(srcPath, targetDir string) error {
 	}
 	defer dstFile.Close()
 
-	if _, err := io.Copy(dstFile, srcFile); err != nil {
+	if _, err := copyBuffer(dstFile, srcFile); err != nil {
 		return fmt.Errorf("复制文件失败: %w", err)
 	}
 
 	// 设置可执行权限
-	return e.fs.Chmod(targetPath, 0755)
+	return e.fs.Chmod(targetPath, utils.ExecutableFileMode())
 }
 
-// extractTarGzFile 从tar.gz中解压指定文件
-func (e *ArchiveExtractor) extractTarGzFile(archivePath, fileName, targetPath string) error {
-	file, err := e.fs.Open(archivePath)
-	if err != nil {
-		return fmt.Errorf("打开压缩文件失败: %w", err)
-	}
-	defer file.Close()
+// decompressBinaryFile 解压单个压缩的二进制文件（如 tool-linux-amd64.gz），去掉压缩扩展名并设置可执行权限
+func (e *ArchiveExtractor) decompressBinaryFile(srcPath, targetDir, compression string, options *ExtractOptions) error {
+	_, name := DetectCompression(filepath.Base(srcPath))
+	targetPath := filepath.Join(targetDir, name)
 
-	gzReader, err := gzip.NewReader(file)
-	if err != nil {
-		return fmt.Errorf("创建gzip读取器失败: %w", err)
+	budget := &extractBudget{limits: options.Limits}
+	if info, err := e.fs.Stat(srcPath); err == nil {
+		budget.archiveSize = info.Size()
 	}
-	defer gzReader.Close()
-
-	tarReader := tar.NewReader(gzReader)
-
-	for {
-		header, err := tarReader.Next()
-		if err == io.EOF {
-			break
-		}
+	open := func() (io.ReadCloser, error) {
+		file, err := e.fs.Open(srcPath)
 		if err != nil {
-			return fmt.Errorf("读取tar条目失败: %w", err)
+			return nil, err
 		}
-
-		if header.Name == fileName || strings.HasSuffix(header.Name, "/"+fileName) {
-			// 找到目标文件
-			if err := e.fs.MkdirAll(filepath.Dir(targetPath), 0755); err != nil {
-				return fmt.Errorf("创建目标目录失败: %w", err)
-			}
-
-			outFile, err := e.fs.Create(targetPath)
-			if err != nil {
-				return fmt.Errorf("创建目标文件失败: %w", err)
-			}
-			defer outFile.Close()
-
-			if _, err := io.Copy(outFile, tarReader); err != nil {
-				return fmt.Errorf("写入文件失败: %w", err)
-			}
-
-			// 设置权限
-			return e.fs.Chmod(targetPath, os.FileMode(header.Mode))
+		reader, err := decompressReader(compression, file)
+		if err != nil {
+			file.Close()
+			return nil, err
 		}
+		return &decompressedFile{ReadCloser: reader, file: file}, nil
 	}
 
-	return fmt.Errorf("在压缩包中未找到文件: %s", fileName)
-}
-
-// extractZipFile 从zip中解压指定文件
-func (e *ArchiveExtractor) extractZipFile(archivePath, fileName, targetPath string) error {
-	// 暂时返回不支持
-	return fmt.Errorf("从zip中提取指定文件暂未支持")
-}
-
-// listTarGzContents 列出tar.gz内容
-func (e *ArchiveExtractor) listTarGzContents(archivePath string) ([]string, error) {
-	file, err := e.fs.Open(archivePath)
-	if err != nil {
-		return nil, fmt.Errorf("打开压缩文件失败: %w", err)
-	}
-	defer file.Close()
-
-	gzReader, err := gzip.NewReader(file)
-	if err != nil {
-		return nil, fmt.Errorf("创建gzip读取器失败: %w", err)
-	}
-	defer gzReader.Close()
-
-	tarReader := tar.NewReader(gzReader)
-	var files []string
-
-	for {
-		header, err := tarReader.Next()
-		if err == io.EOF {
-			break
+	remaining, limit := budget.remaining()
+	entry := &ArchiveEntry{Name: name, Mode: utils.ExecutableFileMode()}
+	if _, err := e.writeEntry(targetPath, entry, open, remaining); err != nil {
+		if err == errSizeLimit {
+			return budget.exceeded(limit, name)
 		}
-		if err != nil {
-			return nil, fmt.Errorf("读取tar条目失败: %w", err)
-		}
-
-		files = append(files, header.Name)
+		return fmt.Errorf("解压%s文件失败: %w", compression, err)
 	}
-
-	return files, nil
+	return e.fs.Chmod(targetPath, utils.ExecutableFileMode())
 }
 
-// listZipContents 列出zip内容
-func (e *ArchiveExtractor) listZipContents(archivePath string) ([]string, error) {
-	reader, err := zip.OpenReader(archivePath)
-	if err != nil {
-		return nil, fmt.Errorf("打开zip文件失败: %w", err)
-	}
-	defer reader.Close()
+// decompressedFile 解压流，关闭时同时关闭源文件
+type decompressedFile struct {
+	io.ReadCloser
+	file afero.File
+}
 
-	var files []string
-	for _, file := range reader.File {
-		files = append(files, file.Name)
+// Close 关闭解压流和源文件
+func (d *decompressedFile) Close() error {
+	err := d.ReadCloser.Close()
+	if fileErr := d.file.Close(); err == nil {
+		err = fileErr
 	}
-
-	return files, nil
+	return err
 }
 
 // DefaultBinaryExtractor 默认二进制文件提取器
@@ -454,14 +520,8 @@ func (e *DefaultBinaryExtractor) ExtractBinary(extractDir, toolName string, meta
 		binaryName := metadata.DownloadConfig.ExtractBinary
 		fmt.Fprintf(os.Stderr, "[DEBUG] 配置的二进制文件名: %s\n", binaryName)
 		// 尝试多种可能的路径
-		possiblePaths := []string{
-			filepath.Join(extractDir, binaryName),
-			filepath.Join(extractDir, "bin", binaryName),
-			filepath.Join(extractDir, toolName, binaryName),
-			filepath.Join(extractDir, toolName, "bin", binaryName),
-		}
-
-		for _, path := range possiblePaths {
+		for _, candidate := range binaryCandidates(binaryName, toolName) {
+			path := filepath.Join(extractDir, filepath.FromSlash(candidate))
 			fmt.Fprintf(os.Stderr, "[DEBUG] 检查路径: %s\n", path)
 			if exists, _ := afero.Exists(e.fs, path); exists {
 				if info, err := e.fs.Stat(path); err == nil && !info.IsDir() {
@@ -510,6 +570,22 @@ func (e *DefaultBinaryExtractor) ExtractBinary(extractDir, toolName string, meta
 	return binaries[0], nil
 }
 
+// binaryCandidates 配置的二进制文件在解压目录中可能的相对路径，以 / 分隔
+func binaryCandidates(binaryName, toolName string) []string {
+	binaryName = filepath.ToSlash(binaryName)
+	candidates := []string{
+		binaryName,
+		path.Join("bin", binaryName),
+		path.Join(toolName, binaryName),
+		path.Join(toolName, "bin", binaryName),
+	}
+	// extract_binary 带目录（如 bin/protoc）且该目录作为唯一顶层目录被去掉时，文件位于解压目录下
+	if base := path.Base(binaryName); base != binaryName {
+		candidates = append(candidates, base)
+	}
+	return candidates
+}
+
 // FindBinaries 查找二进制文件
 func (e *DefaultBinaryExtractor) FindBinaries(extractDir string) ([]string, error) {
 	var binaries []string
@@ -535,7 +611,7 @@ func (e *DefaultBinaryExtractor) SetExecutablePermissions(filePath string) error
 
 	// 在Unix系统上设置执行权限
 	if runtime.GOOS != "windows" {
-		return e.fs.Chmod(filePath, 0755)
+		return e.fs.Chmod(filePath, utils.ExecutableFileMode())
 	}
 
 	return nil
@@ -643,7 +719,7 @@ func NewPackageProcessor(fs afero.Fs, logger *logrus.Logger) *PackageProcessor {
 }
 
 // ProcessPackage 处理软件包
-func (p *PackageProcessor) ProcessPackage(packagePath, targetDir, toolName string, metadata *types.ToolMetadata) (string, error) {
+func (p *PackageProcessor) ProcessPackage(packagePath, targetDir, toolName string, metadata *types.ToolMetadata, options *ExtractOptions) (string, error) {
 	// 如果toolName为空，尝试使用ExtractBinary作为fallback
 	if toolName == "" && metadata != nil && metadata.DownloadConfig.ExtractBinary != "" {
 		toolName = metadata.DownloadConfig.ExtractBinary
@@ -659,30 +735,46 @@ func (p *PackageProcessor) ProcessPackage(packagePath, targetDir, toolName strin
 	}
 	defer p.fs.RemoveAll(tempExtractDir)
 
-	// 解压软件包
-	if err := p.extractor.Extract(packagePath, tempExtractDir); err != nil {
-		return "", fmt.Errorf("解压软件包失败: %w", err)
-	}
-
-	// 调试：列出解压后的文件结构
-	p.logger.Debugf("解压后的文件结构:")
-	afero.Walk(p.fs, tempExtractDir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return nil
+	extractOptions := ExtractOptions{}
+	if options != nil {
+		extractOptions = *options
+	}
+	if metadata != nil {
+		extractOptions.StripComponents = metadata.DownloadConfig.StripComponents
+		extractOptions.PreserveMtime = extractOptions.PreserveMtime || metadata.DownloadConfig.PreserveMtime
+		extractOptions.PreserveXattrs = extractOptions.PreserveXattrs || metadata.DownloadConfig.PreserveXattrs
+	}
+
+	// 配置了二进制文件名时只解压该文件，找不到时再解压整个软件包
+	var binaryPath string
+	if metadata != nil && metadata.DownloadConfig.ExtractBinary != "" && DetectArchiveFormat(packagePath) != "" {
+		candidates := binaryCandidates(metadata.DownloadConfig.ExtractBinary, toolName)
+		filtered := extractOptions
+		filtered.Filter = func(name string) bool {
+			for _, candidate := range candidates {
+				if name == candidate {
+					return true
+				}
+			}
+			return false
 		}
-		relPath, _ := filepath.Rel(tempExtractDir, path)
-		if info.IsDir() {
-			p.logger.Debugf("  [DIR]  %s", relPath)
+
+		found, err := p.extractBinary(packagePath, tempExtractDir, toolName, metadata, &filtered)
+		if err == nil {
+			binaryPath = found
 		} else {
-			p.logger.Debugf("  [FILE] %s (size: %d, mode: %s)", relPath, info.Size(), info.Mode())
+			p.logger.Debugf("只解压 %s 失败，解压整个软件包: %v", metadata.DownloadConfig.ExtractBinary, err)
+			if err := p.fs.RemoveAll(tempExtractDir); err != nil {
+				return "", fmt.Errorf("清理临时解压目录失败: %w", err)
+			}
 		}
-		return nil
-	})
-
-	// 提取二进制文件
-	binaryPath, err := p.binaryExtractor.ExtractBinary(tempExtractDir, toolName, metadata)
-	if err != nil {
-		return "", fmt.Errorf("提取二进制文件失败: %w", err)
+	}
+	if binaryPath == "" {
+		found, err := p.extractBinary(packagePath, tempExtractDir, toolName, metadata, &extractOptions)
+		if err != nil {
+			return "", err
+		}
+		binaryPath = found
 	}
 
 	p.logger.Debugf("找到的二进制文件路径: %s", binaryPath)
@@ -709,8 +801,9 @@ func (p *PackageProcessor) ProcessPackage(packagePath, targetDir, toolName strin
 	fmt.Fprintf(os.Stderr, "[DEBUG] 目标二进制路径: %s\n", targetBinaryPath)
 	fmt.Fprintf(os.Stderr, "[DEBUG] binDir: %s, toolName: %s\n", binDir, toolName)
 
-	if err := p.copyFile(binaryPath, targetBinaryPath); err != nil {
-		return "", fmt.Errorf("复制二进制文件失败: %w", err)
+	// 临时解压目录与目标目录通常位于同一文件系统，直接重命名而不是再复制一遍
+	if err := moveFile(p.fs, binaryPath, targetBinaryPath); err != nil {
+		return "", fmt.Errorf("移动二进制文件失败: %w", err)
 	}
 
 	// 设置可执行权限
@@ -727,23 +820,30 @@ func (p *PackageProcessor) ProcessPackage(packagePath, targetDir, toolName strin
 	return targetBinaryPath, nil
 }
 
-// copyFile 复制文件
-func (p *PackageProcessor) copyFile(src, dst string) error {
-	srcFile, err := p.fs.Open(src)
-	if err != nil {
-		return fmt.Errorf("打开源文件失败: %w", err)
+// extractBinary 将软件包解压到 extractDir 并找出其中的二进制文件
+func (p *PackageProcessor) extractBinary(packagePath, extractDir, toolName string, metadata *types.ToolMetadata, options *ExtractOptions) (string, error) {
+	if err := p.extractor.ExtractWithOptions(packagePath, extractDir, options); err != nil {
+		return "", fmt.Errorf("解压软件包失败: %w", err)
 	}
-	defer srcFile.Close()
 
-	dstFile, err := p.fs.Create(dst)
-	if err != nil {
-		return fmt.Errorf("创建目标文件失败: %w", err)
-	}
-	defer dstFile.Close()
+	// 调试：列出解压后的文件结构
+	p.logger.Debugf("解压后的文件结构:")
+	afero.Walk(p.fs, extractDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		relPath, _ := filepath.Rel(extractDir, path)
+		if info.IsDir() {
+			p.logger.Debugf("  [DIR]  %s", relPath)
+		} else {
+			p.logger.Debugf("  [FILE] %s (size: %d, mode: %s)", relPath, info.Size(), info.Mode())
+		}
+		return nil
+	})
 
-	if _, err := io.Copy(dstFile, srcFile); err != nil {
-		return fmt.Errorf("复制文件内容失败: %w", err)
+	binaryPath, err := p.binaryExtractor.ExtractBinary(extractDir, toolName, metadata)
+	if err != nil {
+		return "", fmt.Errorf("提取二进制文件失败: %w", err)
 	}
-
-	return nil
+	return binaryPath, nil
 }
@@ -0,0 +1,144 @@
+package download
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/songzhibin97/vman/pkg/types"
+)
+
+// ExtractLimit 解压安全限制的种类，取值与全局设置 settings.download 中的键名一致
+type ExtractLimit string
+
+const (
+	// LimitTotalSize 解压后文件的总大小
+	LimitTotalSize ExtractLimit = "max_extracted_size"
+	// LimitFileSize 解压后单个文件的大小
+	LimitFileSize ExtractLimit = "max_extracted_file_size"
+	// LimitEntries 压缩包中的条目数
+	LimitEntries ExtractLimit = "max_archive_entries"
+	// LimitCompressionRatio 解压后总大小与压缩包大小之比
+	LimitCompressionRatio ExtractLimit = "max_compression_ratio"
+)
+
+// ExtractLimits 解压安全限制，用于防范解压炸弹，各项为 0 表示不限制
+type ExtractLimits struct {
+	// MaxTotalSize 解压后文件的总大小上限（字节）
+	MaxTotalSize int64
+
+	// MaxFileSize 解压后单个文件的大小上限（字节）
+	MaxFileSize int64
+
+	// MaxEntries 压缩包中的条目数上限，包括目录和链接
+	MaxEntries int
+
+	// MaxCompressionRatio 解压后总大小与压缩包大小之比的上限
+	MaxCompressionRatio float64
+}
+
+// DefaultExtractLimits 全局设置中未配置时使用的解压安全限制
+// 足以解压 JDK、Go、Node.js 等完整的发行包，同时拦截常见的解压炸弹
+var DefaultExtractLimits = ExtractLimits{
+	MaxTotalSize:        8 << 30,
+	MaxFileSize:         4 << 30,
+	MaxEntries:          200000,
+	MaxCompressionRatio: 100,
+}
+
+// ExtractLimitsFromSettings 从全局下载设置中读取解压安全限制，未配置的项为 0
+func ExtractLimitsFromSettings(settings types.DownloadSettings) ExtractLimits {
+	return ExtractLimits{
+		MaxTotalSize:        int64(settings.MaxExtractedSize),
+		MaxFileSize:         int64(settings.MaxExtractedFileSize),
+		MaxEntries:          settings.MaxArchiveEntries,
+		MaxCompressionRatio: settings.MaxCompressionRatio,
+	}
+}
+
+// Merge 用 defaults 补全未设置的限制
+func (l ExtractLimits) Merge(defaults ExtractLimits) ExtractLimits {
+	if l.MaxTotalSize == 0 {
+		l.MaxTotalSize = defaults.MaxTotalSize
+	}
+	if l.MaxFileSize == 0 {
+		l.MaxFileSize = defaults.MaxFileSize
+	}
+	if l.MaxEntries == 0 {
+		l.MaxEntries = defaults.MaxEntries
+	}
+	if l.MaxCompressionRatio == 0 {
+		l.MaxCompressionRatio = defaults.MaxCompressionRatio
+	}
+	return l
+}
+
+// ExtractLimitError 解压超过安全限制
+type ExtractLimitError struct {
+	// Limit 触发的限制
+	Limit ExtractLimit
+
+	// Max 限制的值
+	Max string
+
+	// Entry 触发限制时正在解压的条目
+	Entry string
+}
+
+func (e *ExtractLimitError) Error() string {
+	return fmt.Sprintf("解压 %s 时超过安全限制 %s = %s，已停止解压", e.Entry, e.Limit, e.Max)
+}
+
+// extractBudget 跟踪解压过程中的安全限制
+type extractBudget struct {
+	limits      ExtractLimits
+	archiveSize int64
+	written     int64
+	entries     int
+}
+
+// addEntry 记录一个条目，超过条目数上限时返回错误
+func (b *extractBudget) addEntry(name string) error {
+	b.entries++
+	if b.limits.MaxEntries > 0 && b.entries > b.limits.MaxEntries {
+		return &ExtractLimitError{Limit: LimitEntries, Max: strconv.Itoa(b.limits.MaxEntries), Entry: name}
+	}
+	return nil
+}
+
+// remaining 下一个文件最多还能写入的字节数及对应的限制，没有限制时返回 -1
+func (b *extractBudget) remaining() (int64, ExtractLimit) {
+	remaining, limit := int64(-1), ExtractLimit("")
+	consider := func(n int64, l ExtractLimit) {
+		if n < 0 {
+			n = 0
+		}
+		if remaining < 0 || n < remaining {
+			remaining, limit = n, l
+		}
+	}
+
+	if b.limits.MaxTotalSize > 0 {
+		consider(b.limits.MaxTotalSize-b.written, LimitTotalSize)
+	}
+	if b.limits.MaxFileSize > 0 {
+		consider(b.limits.MaxFileSize, LimitFileSize)
+	}
+	if b.limits.MaxCompressionRatio > 0 && b.archiveSize > 0 {
+		consider(int64(b.limits.MaxCompressionRatio*float64(b.archiveSize))-b.written, LimitCompressionRatio)
+	}
+	return remaining, limit
+}
+
+// exceeded 创建超过指定限制的错误
+func (b *extractBudget) exceeded(limit ExtractLimit, entry string) error {
+	var max string
+	switch limit {
+	case LimitTotalSize:
+		max = types.ByteSize(b.limits.MaxTotalSize).String()
+	case LimitFileSize:
+		max = types.ByteSize(b.limits.MaxFileSize).String()
+	case LimitCompressionRatio:
+		max = strconv.FormatFloat(b.limits.MaxCompressionRatio, 'f', -1, 64)
+	}
+	return &ExtractLimitError{Limit: limit, Max: max, Entry: entry}
+}
@@ -5,6 +5,7 @@ import (
 	"io"
 
 	"github.com/songzhibin97/vman/pkg/types"
+	"github.com/songzhibin97/vman/pkg/utils"
 )
 
 // Manager 下载管理器接口
@@ -44,6 +45,9 @@ type Manager interface {
 
 	// ResumeDownload 恢复下载
 	ResumeDownload(ctx context.Context, tool, version string, options *DownloadOptions) error
+
+	// PlanInstall 查询安装工具版本需要下载的安装包和大小，不下载安装包
+	PlanInstall(ctx context.Context, tool, version string) (*PlanItem, error)
 }
 
 // Strategy 下载策略接口
@@ -61,7 +65,7 @@ type Strategy interface {
 	DownloadWithProgress(ctx context.Context, url, targetPath string, options *DownloadOptions, progress ProgressCallback) error
 
 	// ExtractArchive 解压下载的压缩包
-	ExtractArchive(archivePath, targetPath string) error
+	ExtractArchive(archivePath, targetPath string, options *ExtractOptions) error
 
 	// GetLatestVersion 获取最新版本
 	GetLatestVersion(ctx context.Context) (string, error)
@@ -99,14 +103,44 @@ type DownloadOptions struct {
 	// Resume 是否支持断点续传
 	Resume bool
 
-	// TempDir 临时目录
+	// TempDir 临时目录，为空时使用全局设置或vman数据目录下的 tmp
 	TempDir string
 
+	// TmpfsMaxArtifact 临时目录位于tmpfs时允许的安装包大小，超过时改用磁盘上的目录，为 0 时使用全局设置
+	TmpfsMaxArtifact int64
+
 	// KeepDownload 保留下载文件
 	KeepDownload bool
 
 	// Headers 自定义请求头
 	Headers map[string]string
+
+	// ExtractLimits 解压安全限制，未设置的项使用全局设置，全局设置中也未配置时使用 DefaultExtractLimits
+	ExtractLimits ExtractLimits
+
+	// PreserveMtime 保留压缩包中记录的修改时间，与全局设置任一开启即生效
+	PreserveMtime bool
+
+	// PreserveXattrs 恢复压缩包中记录的扩展属性，与全局设置任一开启即生效
+	PreserveXattrs bool
+
+	// FilenameEncodings zip中非UTF-8文件名依次尝试的编码，为空时使用全局设置
+	FilenameEncodings []string
+
+	// OnExtract 每解压完一个文件时的回调
+	OnExtract ExtractProgressCallback
+
+	// Redirects 跟随重定向的策略，为空时使用默认策略
+	Redirects *types.RedirectPolicy
+
+	// OnResponse 收到下载响应时的回调，用于记录下载来源信息
+	OnResponse ResponseCallback
+
+	// Checksums 下载时同时计算的校验和，避免下载完成后再完整读取一遍文件
+	Checksums *utils.MultiHasher
+
+	// DeltaFrom 从下载缓存中该版本的安装包和二进制差分补丁生成安装包，没有补丁或校验失败时下载完整安装包
+	DeltaFrom string
 }
 
 // ProgressInfo 下载进度信息
@@ -133,6 +167,36 @@ type ProgressInfo struct {
 // ProgressCallback 进度回调函数
 type ProgressCallback func(*ProgressInfo)
 
+// ResponseInfo 下载响应信息
+type ResponseInfo struct {
+	// RequestURL 请求的URL
+	RequestURL string
+
+	// FinalURL 跟随重定向后的最终URL
+	FinalURL string
+
+	// StatusCode HTTP状态码
+	StatusCode int
+
+	// ETag 响应的ETag头
+	ETag string
+
+	// LastModified 响应的Last-Modified头
+	LastModified string
+
+	// ContentType 响应的Content-Type头
+	ContentType string
+
+	// ContentLength 响应的内容长度
+	ContentLength int64
+
+	// Redirects 跟随的重定向次数
+	Redirects int
+}
+
+// ResponseCallback 响应回调函数
+type ResponseCallback func(*ResponseInfo)
+
 // DownloadError 下载错误
 type DownloadError struct {
 	Tool    string
@@ -2,11 +2,14 @@ package download
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
@@ -14,6 +17,8 @@ import (
 	"github.com/spf13/afero"
 
 	"github.com/songzhibin97/vman/internal/config"
+	"github.com/songzhibin97/vman/internal/proxy"
+	"github.com/songzhibin97/vman/internal/recipe"
 	"github.com/songzhibin97/vman/internal/storage"
 	"github.com/songzhibin97/vman/pkg/types"
 	"github.com/songzhibin97/vman/pkg/utils"
@@ -26,6 +31,7 @@ type DefaultManager struct {
 	fs             afero.Fs
 	logger         *logrus.Logger
 	strategies     map[string]Strategy
+	recipes        map[string]*recipe.Recipe // 来自recipe、安装时才写入工具定义的工具
 	mu             sync.RWMutex
 }
 
@@ -37,6 +43,7 @@ func NewManager(storageManager storage.Manager, configManager config.Manager) Ma
 		fs:             afero.NewOsFs(),
 		logger:         logrus.New(),
 		strategies:     make(map[string]Strategy),
+		recipes:        make(map[string]*recipe.Recipe),
 	}
 }
 
@@ -48,6 +55,7 @@ func NewManagerWithFs(storageManager storage.Manager, configManager config.Manag
 		fs:             fs,
 		logger:         logrus.New(),
 		strategies:     make(map[string]Strategy),
+		recipes:        make(map[string]*recipe.Recipe),
 	}
 }
 
@@ -76,6 +84,9 @@ func (m *DefaultManager) Download(ctx context.Context, tool, version string, opt
 	if err != nil {
 		return fmt.Errorf("获取下载信息失败: %w", err)
 	}
+	if err := m.saveRecipe(tool); err != nil {
+		return fmt.Errorf("生成工具定义失败: %w", err)
+	}
 
 	// 设置默认选项
 	if options == nil {
@@ -84,7 +95,7 @@ func (m *DefaultManager) Download(ctx context.Context, tool, version string, opt
 	m.setDefaultOptions(options)
 
 	// 创建临时目录
-	tempDir := filepath.Join(m.storageManager.GetTempDir(), fmt.Sprintf("%s-%s-%d", tool, version, time.Now().Unix()))
+	tempDir := filepath.Join(m.tempDirFor(downloadInfo.Filename, downloadInfo.Size, options), fmt.Sprintf("%s-%s-%d", tool, version, time.Now().Unix()))
 	if err := m.fs.MkdirAll(tempDir, 0755); err != nil {
 		return fmt.Errorf("创建临时目录失败: %w", err)
 	}
@@ -94,21 +105,60 @@ func (m *DefaultManager) Download(ctx context.Context, tool, version string, opt
 		}
 	}()
 
-	// 下载文件
+	// 记录下载响应信息
+	response := m.captureResponse(options)
+
+	// 下载前检查磁盘空间，安装包大小未知时在解压前检查
+	versionDir := m.storageManager.GetToolVersionPath(tool, version)
+	if err := m.checkDiskSpace(downloadInfo.Filename, downloadInfo.Size, false, tempDir, versionDir); err != nil {
+		return &DownloadError{
+			Tool:    tool,
+			Version: version,
+			URL:     downloadInfo.URL,
+			Cause:   err,
+			Code:    DiskSpaceError,
+		}
+	}
+
+	// 下载时同时计算校验和
+	checksums, err := m.prepareChecksums(options, downloadInfo.Checksum)
+	if err != nil {
+		return err
+	}
+
+	// 下载文件，下载缓存中有安装包时直接使用，增量升级时优先使用补丁
 	downloadPath := filepath.Join(tempDir, downloadInfo.Filename)
-	if err := strategy.Download(ctx, downloadInfo.URL, downloadPath, options); err != nil {
+	if !m.useCachedArtifact(tool, version, downloadInfo, downloadPath, options) && !m.downloadDelta(ctx, strategy, tool, version, downloadInfo, downloadPath, options) {
+		if err := strategy.Download(ctx, downloadInfo.URL, downloadPath, options); err != nil {
+			return &DownloadError{
+				Tool:    tool,
+				Version: version,
+				URL:     downloadInfo.URL,
+				Cause:   err,
+				Code:    NetworkError,
+			}
+		}
+	}
+
+	// 服务器返回错误页面时报告内容开头，而不是校验和或解压错误
+	if err := ValidatePayload(m.fs, downloadPath, response.ContentType); err != nil {
 		return &DownloadError{
 			Tool:    tool,
 			Version: version,
 			URL:     downloadInfo.URL,
 			Cause:   err,
-			Code:    NetworkError,
+			Code:    CorruptedFile,
 		}
 	}
 
+	// 策略没有在下载时计算校验和（如从缓存复制）时重新读取文件
+	if checksums, err = m.completeChecksums(downloadPath, checksums); err != nil {
+		return err
+	}
+
 	// 验证校验和
 	if !options.SkipChecksum && downloadInfo.Checksum != "" {
-		if err := m.validateChecksum(downloadPath, downloadInfo.Checksum); err != nil {
+		if err := m.validateChecksum(checksums, downloadInfo.Checksum); err != nil {
 			return &DownloadError{
 				Tool:    tool,
 				Version: version,
@@ -119,13 +169,25 @@ func (m *DefaultManager) Download(ctx context.Context, tool, version string, opt
 		}
 	}
 
+	// 按实际下载的大小检查解压需要的空间
+	if info, err := m.fs.Stat(downloadPath); err == nil {
+		if err := m.checkDiskSpace(downloadInfo.Filename, info.Size(), true, tempDir, versionDir); err != nil {
+			return &DownloadError{
+				Tool:    tool,
+				Version: version,
+				Cause:   err,
+				Code:    DiskSpaceError,
+			}
+		}
+	}
+
 	// 提取文件
 	extractDir := filepath.Join(tempDir, "extracted")
 	if err := m.fs.MkdirAll(extractDir, 0755); err != nil {
 		return fmt.Errorf("创建提取目录失败: %w", err)
 	}
 
-	if err := strategy.ExtractArchive(downloadPath, extractDir); err != nil {
+	if err := strategy.ExtractArchive(downloadPath, extractDir, m.extractOptions(options, nil)); err != nil {
 		return &DownloadError{
 			Tool:    tool,
 			Version: version,
@@ -139,6 +201,25 @@ func (m *DefaultManager) Download(ctx context.Context, tool, version string, opt
 		return fmt.Errorf("安装版本失败: %w", err)
 	}
 
+	// 记录版本来源
+	provenance := m.buildProvenance(strategy, downloadInfo, downloadPath, checksums, response, options)
+	if err := m.saveInstallMetadata(tool, version, provenance); err != nil {
+		m.logger.Warnf("保存 %s@%s 的版本元数据失败: %v", tool, version, err)
+	}
+
+	// 下载的文件在钩子执行期间仍然保留，钩子可以从中解压更多内容
+	if err := m.runInstallHooks(ctx, strategy, tool, version, downloadPath); err != nil {
+		return fmt.Errorf("运行安装后钩子失败: %w", err)
+	}
+
+	// 钩子可能修改安装的文件，钩子运行完成后再与其他版本共享数据
+	m.dedupVersion(tool, version)
+
+	// 保留安装包供以后增量升级
+	if options.DeltaFrom != "" || strategy.GetToolMetadata().DownloadConfig.DeltaURLTemplate != "" {
+		m.cacheArtifact(tool, version, downloadPath)
+	}
+
 	m.logger.Infof("成功下载并安装 %s@%s", tool, version)
 	return nil
 }
@@ -166,6 +247,9 @@ func (m *DefaultManager) DownloadWithProgress(ctx context.Context, tool, version
 	if err != nil {
 		return fmt.Errorf("获取下载信息失败: %w", err)
 	}
+	if err := m.saveRecipe(tool); err != nil {
+		return fmt.Errorf("生成工具定义失败: %w", err)
+	}
 
 	if options == nil {
 		options = &DownloadOptions{}
@@ -173,7 +257,7 @@ func (m *DefaultManager) DownloadWithProgress(ctx context.Context, tool, version
 	m.setDefaultOptions(options)
 
 	// 创建临时目录
-	tempDir := filepath.Join(m.storageManager.GetTempDir(), fmt.Sprintf("%s-%s-%d", tool, version, time.Now().Unix()))
+	tempDir := filepath.Join(m.tempDirFor(downloadInfo.Filename, downloadInfo.Size, options), fmt.Sprintf("%s-%s-%d", tool, version, time.Now().Unix()))
 	if err := m.fs.MkdirAll(tempDir, 0755); err != nil {
 		return fmt.Errorf("创建临时目录失败: %w", err)
 	}
@@ -183,21 +267,59 @@ func (m *DefaultManager) DownloadWithProgress(ctx context.Context, tool, version
 		}
 	}()
 
-	// 带进度下载
+	response := m.captureResponse(options)
+
+	// 下载前检查磁盘空间，安装包大小未知时在解压前检查
+	versionDir := m.storageManager.GetToolVersionPath(tool, version)
+	if err := m.checkDiskSpace(downloadInfo.Filename, downloadInfo.Size, false, tempDir, versionDir); err != nil {
+		return &DownloadError{
+			Tool:    tool,
+			Version: version,
+			URL:     downloadInfo.URL,
+			Cause:   err,
+			Code:    DiskSpaceError,
+		}
+	}
+
+	// 下载时同时计算校验和
+	checksums, err := m.prepareChecksums(options, downloadInfo.Checksum)
+	if err != nil {
+		return err
+	}
+
+	// 带进度下载，下载缓存中有安装包时直接使用，增量升级时优先使用补丁
 	downloadPath := filepath.Join(tempDir, downloadInfo.Filename)
-	if err := strategy.DownloadWithProgress(ctx, downloadInfo.URL, downloadPath, options, progress); err != nil {
+	if !m.useCachedArtifact(tool, version, downloadInfo, downloadPath, options) && !m.downloadDelta(ctx, strategy, tool, version, downloadInfo, downloadPath, options) {
+		if err := strategy.DownloadWithProgress(ctx, downloadInfo.URL, downloadPath, options, progress); err != nil {
+			return &DownloadError{
+				Tool:    tool,
+				Version: version,
+				URL:     downloadInfo.URL,
+				Cause:   err,
+				Code:    NetworkError,
+			}
+		}
+	}
+
+	// 服务器返回错误页面时报告内容开头，而不是校验和或解压错误
+	if err := ValidatePayload(m.fs, downloadPath, response.ContentType); err != nil {
 		return &DownloadError{
 			Tool:    tool,
 			Version: version,
 			URL:     downloadInfo.URL,
 			Cause:   err,
-			Code:    NetworkError,
+			Code:    CorruptedFile,
 		}
 	}
 
+	// 策略没有在下载时计算校验和（如从缓存复制）时重新读取文件
+	if checksums, err = m.completeChecksums(downloadPath, checksums); err != nil {
+		return err
+	}
+
 	// 验证和安装步骤与普通下载相同
 	if !options.SkipChecksum && downloadInfo.Checksum != "" {
-		if err := m.validateChecksum(downloadPath, downloadInfo.Checksum); err != nil {
+		if err := m.validateChecksum(checksums, downloadInfo.Checksum); err != nil {
 			return &DownloadError{
 				Tool:    tool,
 				Version: version,
@@ -208,12 +330,23 @@ func (m *DefaultManager) DownloadWithProgress(ctx context.Context, tool, version
 		}
 	}
 
+	if info, err := m.fs.Stat(downloadPath); err == nil {
+		if err := m.checkDiskSpace(downloadInfo.Filename, info.Size(), true, tempDir, versionDir); err != nil {
+			return &DownloadError{
+				Tool:    tool,
+				Version: version,
+				Cause:   err,
+				Code:    DiskSpaceError,
+			}
+		}
+	}
+
 	extractDir := filepath.Join(tempDir, "extracted")
 	if err := m.fs.MkdirAll(extractDir, 0755); err != nil {
 		return fmt.Errorf("创建提取目录失败: %w", err)
 	}
 
-	if err := strategy.ExtractArchive(downloadPath, extractDir); err != nil {
+	if err := strategy.ExtractArchive(downloadPath, extractDir, m.extractOptions(options, progress)); err != nil {
 		return &DownloadError{
 			Tool:    tool,
 			Version: version,
@@ -226,6 +359,24 @@ func (m *DefaultManager) DownloadWithProgress(ctx context.Context, tool, version
 		return fmt.Errorf("安装版本失败: %w", err)
 	}
 
+	provenance := m.buildProvenance(strategy, downloadInfo, downloadPath, checksums, response, options)
+	if err := m.saveInstallMetadata(tool, version, provenance); err != nil {
+		m.logger.Warnf("保存 %s@%s 的版本元数据失败: %v", tool, version, err)
+	}
+
+	// 下载的文件在钩子执行期间仍然保留，钩子可以从中解压更多内容
+	if err := m.runInstallHooks(ctx, strategy, tool, version, downloadPath); err != nil {
+		return fmt.Errorf("运行安装后钩子失败: %w", err)
+	}
+
+	// 钩子可能修改安装的文件，钩子运行完成后再与其他版本共享数据
+	m.dedupVersion(tool, version)
+
+	// 保留安装包供以后增量升级
+	if options.DeltaFrom != "" || strategy.GetToolMetadata().DownloadConfig.DeltaURLTemplate != "" {
+		m.cacheArtifact(tool, version, downloadPath)
+	}
+
 	m.logger.Infof("成功下载并安装 %s@%s", tool, version)
 	return nil
 }
@@ -240,10 +391,20 @@ func (m *DefaultManager) GetDownloadStrategy(tool string) (Strategy, error) {
 		return strategy, nil
 	}
 
-	// 从配置中加载工具元数据
+	// 从配置中加载工具元数据，没有工具定义时使用recipe，工具定义在安装时才写入
 	metadata, err := m.configManager.LoadToolConfig(tool)
 	if err != nil {
-		return nil, fmt.Errorf("加载工具配置失败: %w", err)
+		found, recipeErr := m.lookupRecipe(tool)
+		if recipeErr != nil {
+			if errors.Is(recipeErr, recipe.ErrNotFound) {
+				return nil, fmt.Errorf("加载工具配置失败: %w%s", err, m.suggestTools(tool))
+			}
+			return nil, fmt.Errorf("加载工具配置失败: %w", err)
+		}
+		metadata = found.Metadata
+		m.mu.Lock()
+		m.recipes[tool] = found
+		m.mu.Unlock()
 	}
 
 	// 创建下载策略
@@ -260,6 +421,70 @@ func (m *DefaultManager) GetDownloadStrategy(tool string) (Strategy, error) {
 	return strategy, nil
 }
 
+// lookupRecipe 工具定义不存在时查找工具的recipe，不写入任何文件，
+// 查询版本、安装计划等只读操作也会获取下载策略
+func (m *DefaultManager) lookupRecipe(tool string) (*recipe.Recipe, error) {
+	configDir := m.configManager.GetConfigDir()
+	if _, err := os.Stat(filepath.Join(configDir, "tools", tool+".toml")); err == nil {
+		return nil, fmt.Errorf("工具定义已存在: %s", tool)
+	}
+	return recipe.NewRegistryForSettings(configDir, m.globalSettings().Recipes).Lookup(tool)
+}
+
+// saveRecipe 安装来自recipe的工具时写入工具定义和钩子脚本，只读模式下拒绝
+func (m *DefaultManager) saveRecipe(tool string) error {
+	m.mu.RLock()
+	found, ok := m.recipes[tool]
+	m.mu.RUnlock()
+	if !ok {
+		return nil
+	}
+
+	settings := m.globalSettings()
+	if settings.IsReadOnly() {
+		return types.ErrReadOnly
+	}
+	configDir := m.configManager.GetConfigDir()
+	if _, err := found.Save(filepath.Join(configDir, "tools")); err != nil {
+		return err
+	}
+	if _, err := found.InstallHooks(settings.Hooks.GetDir(configDir)); err != nil {
+		return err
+	}
+	m.logger.Infof("使用 %s 的recipe (%s) 生成工具定义", tool, found.Source)
+
+	m.mu.Lock()
+	delete(m.recipes, tool)
+	m.mu.Unlock()
+	return nil
+}
+
+// globalSettings 读取全局设置，无法读取时返回默认值
+func (m *DefaultManager) globalSettings() types.Settings {
+	if globalConfig, err := m.configManager.LoadGlobal(); err == nil && globalConfig != nil {
+		return globalConfig.Settings
+	}
+	return types.Settings{}
+}
+
+// suggestTools 工具既没有定义也没有recipe时，从已定义的工具和recipe中找出名称相近的工具
+// 返回附加在错误信息后的提示，没有相近的名称时返回空字符串
+func (m *DefaultManager) suggestTools(tool string) string {
+	candidates, _ := m.configManager.ListTools()
+	registry := recipe.NewRegistryForSettings(m.configManager.GetConfigDir(), m.globalSettings().Recipes)
+	if recipes, err := registry.List(); err == nil {
+		for _, r := range recipes {
+			candidates = append(candidates, r.Name)
+		}
+	}
+
+	suggestions := utils.SuggestSimilar(tool, candidates)
+	if len(suggestions) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("（你是不是要找 %s？）", strings.Join(suggestions, "、"))
+}
+
 // AddSource 添加下载源
 func (m *DefaultManager) AddSource(tool string, metadata *types.ToolMetadata) error {
 	m.logger.Debugf("添加下载源: %s", tool)
@@ -432,36 +657,123 @@ func (m *DefaultManager) setDefaultOptions(options *DownloadOptions) {
 	if options.Retries == 0 {
 		options.Retries = config.Settings.Download.Retries
 	}
+	if options.TempDir == "" {
+		options.TempDir = config.Settings.Storage.GetTempDir()
+	}
 	if options.TempDir == "" {
 		options.TempDir = m.storageManager.GetTempDir()
 	}
+	if options.TmpfsMaxArtifact == 0 {
+		options.TmpfsMaxArtifact = int64(config.Settings.Storage.GetTmpfsMaxArtifact())
+	}
+	options.ExtractLimits = options.ExtractLimits.
+		Merge(ExtractLimitsFromSettings(config.Settings.Download)).
+		Merge(DefaultExtractLimits)
+	options.PreserveMtime = options.PreserveMtime || config.Settings.Download.PreserveMtime
+	options.PreserveXattrs = options.PreserveXattrs || config.Settings.Download.PreserveXattrs
+	if len(options.FilenameEncodings) == 0 {
+		options.FilenameEncodings = config.Settings.Download.ZipFilenameEncodings
+	}
+	if options.Redirects == nil {
+		options.Redirects = &config.Settings.Download.Redirects
+	}
 }
 
-// validateChecksum 验证校验和
-func (m *DefaultManager) validateChecksum(filePath, expectedChecksum string) error {
-	if expectedChecksum == "" {
-		return nil // 没有期望的校验和，跳过验证
+// extractOptions 根据下载选项创建解压选项，解压进度通过下载进度回调的状态信息报告
+func (m *DefaultManager) extractOptions(options *DownloadOptions, progress ProgressCallback) *ExtractOptions {
+	return &ExtractOptions{
+		Limits:            options.ExtractLimits,
+		PreserveMtime:     options.PreserveMtime,
+		PreserveXattrs:    options.PreserveXattrs,
+		FilenameEncodings: options.FilenameEncodings,
+		Progress: func(p *ExtractProgress) {
+			m.logger.Debugf("已解压 %s (%d 个文件, %d 字节)", p.Entry, p.Files, p.Written)
+			if options.OnExtract != nil {
+				options.OnExtract(p)
+			}
+			if progress != nil {
+				progress(&ProgressInfo{
+					Downloaded: p.Written,
+					Status:     fmt.Sprintf("正在解压: 已解压 %d 个文件", p.Files),
+				})
+			}
+		},
+	}
+}
+
+// prepareChecksums 创建下载时计算的校验和并挂载到下载选项上
+// 总是计算 sha256 用于记录来源，下载源声明了其他算法的校验和时一并计算
+func (m *DefaultManager) prepareChecksums(options *DownloadOptions, expectedChecksum string) (*utils.MultiHasher, error) {
+	algorithms := []string{utils.ChecksumSHA256}
+	if !options.SkipChecksum && expectedChecksum != "" {
+		algorithm, _, err := utils.ParseChecksum(expectedChecksum)
+		if err != nil {
+			return nil, fmt.Errorf("无效的校验和: %w", err)
+		}
+		algorithms = append(algorithms, algorithm)
 	}
 
-	m.logger.Debugf("验证文件校验和: %s", filePath)
+	checksums, err := utils.NewMultiHasher(algorithms...)
+	if err != nil {
+		return nil, err
+	}
+	options.Checksums = checksums
+	return checksums, nil
+}
 
-	// 计算文件的SHA256
-	actualChecksum, err := utils.CalculateFileChecksum(filePath)
+// completeChecksums 确认下载时计算的校验和覆盖了整个文件，否则重新读取文件计算
+func (m *DefaultManager) completeChecksums(filePath string, checksums *utils.MultiHasher) (*utils.MultiHasher, error) {
+	info, err := m.fs.Stat(filePath)
 	if err != nil {
-		return fmt.Errorf("计算文件校验和失败: %w", err)
+		return nil, fmt.Errorf("读取下载文件信息失败: %w", err)
+	}
+	if checksums.Written() == info.Size() {
+		return checksums, nil
+	}
+
+	m.logger.Debugf("重新计算文件校验和: %s", filePath)
+	recomputed, err := utils.NewMultiHasher(checksums.Algorithms()...)
+	if err != nil {
+		return nil, err
+	}
+	file, err := m.fs.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("打开文件失败: %w", err)
+	}
+	defer file.Close()
+	if _, err := copyBuffer(recomputed, file); err != nil {
+		return nil, fmt.Errorf("计算文件校验和失败: %w", err)
+	}
+	return recomputed, nil
+}
+
+// validateChecksum 将下载时计算的校验和与期望值比对
+func (m *DefaultManager) validateChecksum(checksums *utils.MultiHasher, expectedChecksum string) error {
+	if expectedChecksum == "" {
+		return nil // 没有期望的校验和，跳过验证
 	}
 
-	// 比较校验和
-	if actualChecksum != expectedChecksum {
-		return fmt.Errorf("校验和不匹配: 期望 %s, 实际 %s", expectedChecksum, actualChecksum)
+	if err := checksums.Verify(expectedChecksum); err != nil {
+		return fmt.Errorf("校验和不匹配: %w", err)
 	}
 
-	m.logger.Debugf("校验和验证通过: %s", actualChecksum)
+	m.logger.Debugf("校验和验证通过: %s", expectedChecksum)
 	return nil
 }
 
 // installVersion 安装版本到目标目录
 func (m *DefaultManager) installVersion(tool, version, extractDir string) error {
+	// 版本目录可能位于多台机器共享的网络文件系统上，安装期间持有版本锁
+	lock, err := m.storageManager.LockVersion(tool, version)
+	if err != nil {
+		return fmt.Errorf("获取版本锁失败: %w", err)
+	}
+	defer func() {
+		if err := lock.Release(); err != nil {
+			m.logger.Warnf("释放 %s@%s 的版本锁失败: %v", tool, version, err)
+		}
+	}()
+
 	// 创建版本目录
 	if err := m.storageManager.CreateVersionDir(tool, version); err != nil {
 		return fmt.Errorf("创建版本目录失败: %w", err)
@@ -469,22 +781,148 @@ func (m *DefaultManager) installVersion(tool, version, extractDir string) error
 
 	targetPath := m.storageManager.GetToolVersionPath(tool, version)
 
-	// 复制文件到目标目录
-	return m.copyDirectory(extractDir, targetPath)
+	// 解压目录位于同一存储下，直接移动到版本目录，不再复制一遍数据
+	return moveDirectory(m.fs, extractDir, targetPath)
+}
+
+// captureResponse 在下载选项上挂载响应回调，返回记录响应信息的对象
+func (m *DefaultManager) captureResponse(options *DownloadOptions) *ResponseInfo {
+	response := &ResponseInfo{}
+	previous := options.OnResponse
+	options.OnResponse = func(info *ResponseInfo) {
+		*response = *info
+		if previous != nil {
+			previous(info)
+		}
+	}
+	return response
+}
+
+// buildProvenance 根据下载过程构建来源记录
+func (m *DefaultManager) buildProvenance(strategy Strategy, downloadInfo *types.DownloadInfo, downloadPath string, checksums *utils.MultiHasher, response *ResponseInfo, options *DownloadOptions) *types.DownloadProvenance {
+	provenance := &types.DownloadProvenance{
+		URL:              downloadInfo.URL,
+		ResolvedURL:      redactedURL(response.FinalURL),
+		Filename:         downloadInfo.Filename,
+		ExpectedChecksum: downloadInfo.Checksum,
+		ChecksumVerified: !options.SkipChecksum && downloadInfo.Checksum != "",
+		ETag:             response.ETag,
+		LastModified:     response.LastModified,
+		ContentType:      response.ContentType,
+		Redirects:        response.Redirects,
+		DownloadedAt:     time.Now(),
+	}
+
+	if metadata := strategy.GetToolMetadata(); metadata != nil {
+		provenance.Strategy = metadata.DownloadConfig.Type
+	}
+
+	if info, err := m.fs.Stat(downloadPath); err == nil {
+		provenance.Size = info.Size()
+	}
+
+	provenance.Checksum = checksums.Sum(utils.ChecksumSHA256)
+
+	return provenance
+}
+
+// dedupVersion 按 settings.storage.dedup 使新安装的版本与其他版本中内容相同的文件共享数据，失败时不影响安装
+func (m *DefaultManager) dedupVersion(tool, version string) {
+	config, err := m.configManager.LoadGlobal()
+	if err != nil || config == nil || config.Settings.Storage.GetDedup() == types.DedupOff {
+		return
+	}
+	result, err := m.storageManager.DedupVersion(tool, version, config.Settings.Storage.GetDedup())
+	if err != nil {
+		m.logger.Warnf("%s@%s 与其他版本共享数据失败: %v", tool, version, err)
+		return
+	}
+	if shared := result.Reflinked + result.Hardlinked; shared > 0 {
+		m.logger.Infof("%s@%s 有 %d 个文件与其他版本共享数据，节省 %s", tool, version, shared, formatSize(result.SavedBytes))
+	}
+}
+
+// runInstallHooks 依次运行工具定义中的 post_install 命令和钩子目录中的 post-install 脚本
+func (m *DefaultManager) runInstallHooks(ctx context.Context, strategy Strategy, tool, version, downloadPath string) error {
+	var scripts []string
+	if metadata := strategy.GetToolMetadata(); metadata != nil {
+		scripts = append(scripts, metadata.PostInstall...)
+	}
+
+	var settings types.HookSettings
+	if config, err := m.configManager.LoadGlobal(); err == nil && config != nil {
+		settings = config.Settings.Hooks
+	}
+	scripts = append(scripts, proxy.HookScripts(settings.GetDir(m.configManager.GetConfigDir()), tool, proxy.HookPostInstall)...)
+	if len(scripts) == 0 {
+		return nil
+	}
+
+	installPath := m.storageManager.GetToolVersionPath(tool, version)
+	_, err := proxy.NewHookRunner(m.logger).Run(ctx, scripts, settings.GetInstallTimeout(), &proxy.HookContext{
+		Event:        proxy.HookPostInstall,
+		Tool:         tool,
+		Version:      version,
+		ExecPath:     m.storageManager.GetBinaryPath(tool, version),
+		WorkDir:      installPath,
+		InstallPath:  installPath,
+		DownloadPath: downloadPath,
+	})
+	return err
+}
+
+// saveInstallMetadata 保存下载安装的版本元数据
+func (m *DefaultManager) saveInstallMetadata(tool, version string, provenance *types.DownloadProvenance) error {
+	binaryPath := m.storageManager.GetBinaryPath(tool, version)
+
+	metadata := &types.VersionMetadata{
+		Version:     version,
+		ToolName:    tool,
+		InstallPath: m.storageManager.GetToolVersionPath(tool, version),
+		BinaryPath:  binaryPath,
+		InstalledAt: time.Now(),
+		InstallType: "download",
+		Source:      provenance.URL,
+		Provenance:  provenance,
+	}
+
+	if info, err := m.fs.Stat(binaryPath); err == nil {
+		metadata.Size = info.Size()
+		if checksum, err := m.fileChecksum(binaryPath); err == nil {
+			metadata.Checksum = checksum
+		}
+	}
+
+	return m.storageManager.SaveVersionMetadata(tool, version, metadata)
+}
+
+// fileChecksum 计算文件的SHA256校验和
+func (m *DefaultManager) fileChecksum(path string) (string, error) {
+	file, err := m.fs.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("打开文件失败: %w", err)
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := copyBuffer(hash, file); err != nil {
+		return "", fmt.Errorf("读取文件失败: %w", err)
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
 }
 
 // createStrategy 创建下载策略
+// 设置了 download.mirror 时优先从镜像下载
 func (m *DefaultManager) createStrategy(metadata *types.ToolMetadata) (Strategy, error) {
-	switch metadata.DownloadConfig.Type {
-	case "github":
-		return NewGitHubStrategy(metadata, m.fs, m.logger), nil
-	case "direct":
-		return NewDirectStrategy(metadata, m.fs, m.logger), nil
-	case "archive":
-		return NewArchiveStrategy(metadata, m.fs, m.logger), nil
-	default:
-		return nil, fmt.Errorf("不支持的下载类型: %s", metadata.DownloadConfig.Type)
+	strategy, err := NewStrategy(metadata, m.fs, m.logger)
+	if err != nil {
+		return nil, err
 	}
+	if config, err := m.configManager.LoadGlobal(); err == nil && config.Settings.Download.Mirror != "" {
+		strategy = NewMirrorStrategy(strategy, config.Settings.Download.Mirror, m.fs, m.logger)
+	}
+	return strategy, nil
 }
 
 // validateToolMetadata 验证工具元数据
@@ -511,61 +949,8 @@ func (m *DefaultManager) saveToolMetadata(path string, metadata *types.ToolMetad
 		return fmt.Errorf("创建目录失败: %w", err)
 	}
 
-	// 写入文件
-	return afero.WriteFile(m.fs, path, data, 0644)
-}
-
-// copyDirectory 复制目录
-func (m *DefaultManager) copyDirectory(src, dst string) error {
-	// 确保目标目录存在
-	if err := m.fs.MkdirAll(dst, 0755); err != nil {
-		return fmt.Errorf("创建目标目录失败: %w", err)
-	}
-
-	// 遍历源目录
-	return afero.Walk(m.fs, src, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-
-		// 计算相对路径
-		relPath, err := filepath.Rel(src, path)
-		if err != nil {
-			return err
-		}
-		targetPath := filepath.Join(dst, relPath)
-
-		if info.IsDir() {
-			// 创建目录
-			return m.fs.MkdirAll(targetPath, info.Mode())
-		} else {
-			// 复制文件
-			return m.copyFile(path, targetPath)
-		}
-	})
-}
-
-// copyFile 复制文件
-func (m *DefaultManager) copyFile(src, dst string) error {
-	srcFile, err := m.fs.Open(src)
-	if err != nil {
-		return fmt.Errorf("打开源文件失败: %w", err)
-	}
-	defer srcFile.Close()
-
-	// 确保目标目录存在
-	if err := m.fs.MkdirAll(filepath.Dir(dst), 0755); err != nil {
-		return fmt.Errorf("创建目标目录失败: %w", err)
-	}
-
-	dstFile, err := m.fs.Create(dst)
-	if err != nil {
-		return fmt.Errorf("创建目标文件失败: %w", err)
-	}
-	defer dstFile.Close()
-
-	_, err = io.Copy(dstFile, srcFile)
-	return err
+	// 工具定义中的下载请求头可能包含认证信息
+	return utils.WritePrivateFile(m.fs, path, data)
 }
 
 // calculateDirSize 计算目录大小
@@ -2,11 +2,13 @@ package download
 
 import (
 	"testing"
+	"time"
 
 	"github.com/spf13/afero"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 
+	"github.com/songzhibin97/vman/internal/storage"
 	"github.com/songzhibin97/vman/pkg/types"
 )
 
@@ -115,6 +117,46 @@ func (m *MockStorageManager) GetBinaryPath(tool, version string) string {
 	return args.String(0)
 }
 
+func (m *MockStorageManager) MarkVersionUsed(tool, version string) error {
+	args := m.Called(tool, version)
+	return args.Error(0)
+}
+
+func (m *MockStorageManager) GetLastUsed(tool, version string) (time.Time, error) {
+	args := m.Called(tool, version)
+	return args.Get(0).(time.Time), args.Error(1)
+}
+
+func (m *MockStorageManager) GetSystemVersionsDir() string {
+	args := m.Called()
+	return args.String(0)
+}
+
+func (m *MockStorageManager) IsSystemVersion(tool, version string) bool {
+	args := m.Called(tool, version)
+	return args.Bool(0)
+}
+
+func (m *MockStorageManager) GetForeignVersions(tool string) (map[string][]string, error) {
+	args := m.Called(tool)
+	return args.Get(0).(map[string][]string), args.Error(1)
+}
+
+func (m *MockStorageManager) LockVersion(tool, version string) (*storage.FileLock, error) {
+	args := m.Called(tool, version)
+	return args.Get(0).(*storage.FileLock), args.Error(1)
+}
+
+func (m *MockStorageManager) DedupVersion(tool, version, mode string) (*storage.DedupResult, error) {
+	args := m.Called(tool, version, mode)
+	return args.Get(0).(*storage.DedupResult), args.Error(1)
+}
+
+func (m *MockStorageManager) GetDedupSavings() (*storage.DedupSavings, error) {
+	args := m.Called()
+	return args.Get(0).(*storage.DedupSavings), args.Error(1)
+}
+
 // MockConfigManager 配置管理器模拟
 type MockConfigManager struct {
 	mock.Mock
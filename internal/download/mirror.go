@@ -0,0 +1,431 @@
+package download
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+
+	"github.com/songzhibin97/vman/pkg/types"
+	"github.com/songzhibin97/vman/pkg/utils"
+)
+
+// 镜像布局：vman mirror 将各平台的安装包写入 <根目录>/<工具>/<版本>/<os>-<arch>/，
+// 目录中除安装包外还有一个 mirror.json 记录文件名、大小、校验和和原始下载地址。
+// 根目录可以是本地目录，也可以是静态HTTP服务或开放读取的对象存储（如S3桶的HTTPS地址），
+// 设置 download.mirror 后安装时优先从镜像下载，镜像中没有的版本仍使用工具定义中的下载源。
+
+// MirrorIndexFile 镜像中每个平台目录下的索引文件名
+const MirrorIndexFile = "mirror.json"
+
+// DefaultMirrorPlatforms 工具定义没有限制平台时镜像的平台
+var DefaultMirrorPlatforms = []string{"linux-amd64", "linux-arm64", "darwin-amd64", "darwin-arm64", "windows-amd64"}
+
+// MirrorEntry 镜像中一个平台安装包的索引
+type MirrorEntry struct {
+	Tool     string `json:"tool"`
+	Version  string `json:"version"`
+	Platform string `json:"platform"`
+	Filename string `json:"filename"`
+	Size     int64  `json:"size"`
+	Checksum string `json:"checksum"`
+	Source   string `json:"source"`
+
+	// Deltas deltas 目录中有补丁的旧版本，补丁从这些版本的安装包生成该安装包
+	Deltas []string `json:"deltas,omitempty"`
+}
+
+// PlatformStrategy 可以获取任意平台下载信息的下载策略
+type PlatformStrategy interface {
+	// GetPlatformDownloadInfo 获取指定平台的下载信息
+	GetPlatformDownloadInfo(ctx context.Context, version string, platform *types.PlatformInfo) (*types.DownloadInfo, error)
+}
+
+// MirrorPlatforms 工具需要镜像的平台，工具定义中的 platforms 使用 linux_amd64 形式
+func MirrorPlatforms(metadata *types.ToolMetadata) []string {
+	if len(metadata.Platforms) == 0 {
+		return DefaultMirrorPlatforms
+	}
+	platforms := make([]string, 0, len(metadata.Platforms))
+	for _, platform := range metadata.Platforms {
+		platforms = append(platforms, strings.ReplaceAll(platform, "_", "-"))
+	}
+	return platforms
+}
+
+// ParseMirrorPlatform 解析 os-arch 形式的平台
+func ParseMirrorPlatform(platform string) (*types.PlatformInfo, error) {
+	goos, arch, ok := strings.Cut(strings.ReplaceAll(platform, "_", "-"), "-")
+	if !ok || goos == "" || arch == "" {
+		return nil, fmt.Errorf("无效的平台: %s，应为 os-arch 形式，如 linux-amd64", platform)
+	}
+	return &types.PlatformInfo{OS: goos, Arch: arch}, nil
+}
+
+// MirrorDir 镜像中工具版本在某个平台的目录，使用 / 分隔，可以直接拼接到HTTP地址后
+func MirrorDir(tool, version, platform string) string {
+	return path.Join(tool, version, platform)
+}
+
+// MirrorArtifact 下载工具版本在一个平台的安装包，写入镜像根目录 root 并生成索引
+// 下载信息中有校验和时先校验，索引中记录安装包的 sha256
+func MirrorArtifact(ctx context.Context, fs afero.Fs, strategy Strategy, version, platform, root string) (*MirrorEntry, error) {
+	platformStrategy, ok := strategy.(PlatformStrategy)
+	if !ok {
+		return nil, fmt.Errorf("下载策略不支持其他平台")
+	}
+	platformInfo, err := ParseMirrorPlatform(platform)
+	if err != nil {
+		return nil, err
+	}
+	tool := strategy.GetToolMetadata().Name
+
+	info, err := platformStrategy.GetPlatformDownloadInfo(ctx, version, platformInfo)
+	if err != nil {
+		return nil, fmt.Errorf("获取下载信息失败: %w", err)
+	}
+
+	dir := filepath.Join(root, filepath.FromSlash(MirrorDir(tool, version, platform)))
+	if err := fs.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("创建镜像目录失败: %w", err)
+	}
+	target := filepath.Join(dir, info.Filename)
+	var contentType string
+	options := &DownloadOptions{TempDir: dir, OnResponse: func(response *ResponseInfo) {
+		contentType = response.ContentType
+	}}
+	if err := strategy.Download(ctx, info.URL, target, options); err != nil {
+		return nil, fmt.Errorf("下载 %s 失败: %w", info.URL, err)
+	}
+	// 镜像不解压安装包，只拒绝错误页面
+	if err := CheckErrorPage(fs, target, contentType); err != nil {
+		_ = fs.Remove(target)
+		return nil, err
+	}
+
+	algorithms := []string{utils.ChecksumSHA256}
+	if info.Checksum != "" {
+		algorithm, _, err := utils.ParseChecksum(info.Checksum)
+		if err != nil {
+			return nil, fmt.Errorf("无效的校验和: %w", err)
+		}
+		algorithms = append(algorithms, algorithm)
+	}
+	checksums, size, err := hashFile(fs, target, algorithms)
+	if err != nil {
+		return nil, err
+	}
+	if info.Checksum != "" {
+		if err := checksums.Verify(info.Checksum); err != nil {
+			_ = fs.Remove(target)
+			return nil, fmt.Errorf("校验 %s 失败: %w", info.Filename, err)
+		}
+	}
+
+	entry := &MirrorEntry{
+		Tool:     tool,
+		Version:  version,
+		Platform: platform,
+		Filename: info.Filename,
+		Size:     size,
+		Checksum: utils.ChecksumSHA256 + ":" + checksums.Sum(utils.ChecksumSHA256),
+		Source:   info.URL,
+	}
+	if err := writeMirrorIndex(fs, dir, entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// writeMirrorIndex 写入平台目录下的镜像索引
+func writeMirrorIndex(fs afero.Fs, dir string, entry *MirrorEntry) error {
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return fmt.Errorf("生成镜像索引失败: %w", err)
+	}
+	if err := afero.WriteFile(fs, filepath.Join(dir, MirrorIndexFile), data, 0644); err != nil {
+		return fmt.Errorf("写入镜像索引失败: %w", err)
+	}
+	return nil
+}
+
+// MirrorDeltas 为镜像中的安装包生成从同一平台较低版本升级的补丁，最多使用 limit 个最接近的旧版本
+// diff 调用 bsdiff 从旧安装包和新安装包生成补丁文件，返回生成补丁的旧版本
+func MirrorDeltas(fs afero.Fs, root string, entry *MirrorEntry, limit int, diff func(oldPath, newPath, patchPath string) error) ([]string, error) {
+	current, err := semver.NewVersion(entry.Version)
+	if err != nil {
+		return nil, fmt.Errorf("无法比较版本 %s: %w", entry.Version, err)
+	}
+	versionDirs, err := afero.ReadDir(fs, filepath.Join(root, entry.Tool))
+	if err != nil {
+		return nil, fmt.Errorf("读取镜像目录失败: %w", err)
+	}
+
+	var bases []*MirrorEntry
+	for _, versionDir := range versionDirs {
+		v, err := semver.NewVersion(versionDir.Name())
+		if !versionDir.IsDir() || err != nil || !v.LessThan(current) {
+			continue
+		}
+		data, err := afero.ReadFile(fs, filepath.Join(root, filepath.FromSlash(MirrorDir(entry.Tool, versionDir.Name(), entry.Platform)), MirrorIndexFile))
+		if err != nil {
+			continue
+		}
+		var base MirrorEntry
+		if json.Unmarshal(data, &base) == nil && base.Filename != "" && !strings.ContainsAny(base.Filename, `/\`) {
+			bases = append(bases, &base)
+		}
+	}
+	sort.Slice(bases, func(i, j int) bool {
+		vi, _ := semver.NewVersion(bases[i].Version)
+		vj, _ := semver.NewVersion(bases[j].Version)
+		return vi.GreaterThan(vj)
+	})
+	if limit > 0 && len(bases) > limit {
+		bases = bases[:limit]
+	}
+
+	if len(bases) == 0 {
+		return nil, nil
+	}
+
+	dir := filepath.Join(root, filepath.FromSlash(MirrorDir(entry.Tool, entry.Version, entry.Platform)))
+	if err := fs.MkdirAll(filepath.Join(dir, MirrorDeltasDir), 0755); err != nil {
+		return nil, fmt.Errorf("创建补丁目录失败: %w", err)
+	}
+	var generated []string
+	for _, base := range bases {
+		baseDir := filepath.Join(root, filepath.FromSlash(MirrorDir(entry.Tool, base.Version, entry.Platform)))
+		patchPath := filepath.Join(dir, MirrorDeltasDir, base.Version+DeltaExtension)
+		if err := diff(filepath.Join(baseDir, base.Filename), filepath.Join(dir, entry.Filename), patchPath); err != nil {
+			return generated, fmt.Errorf("生成 %s -> %s 的补丁失败: %w", base.Version, entry.Version, err)
+		}
+		generated = append(generated, base.Version)
+	}
+
+	entry.Deltas = generated
+	if err := writeMirrorIndex(fs, dir, entry); err != nil {
+		return generated, err
+	}
+	return generated, nil
+}
+
+// hashFile 读取文件计算摘要和大小
+func hashFile(fs afero.Fs, filePath string, algorithms []string) (*utils.MultiHasher, int64, error) {
+	hasher, err := utils.NewMultiHasher(algorithms...)
+	if err != nil {
+		return nil, 0, err
+	}
+	file, err := fs.Open(filePath)
+	if err != nil {
+		return nil, 0, fmt.Errorf("打开文件失败: %w", err)
+	}
+	defer file.Close()
+	size, err := copyBuffer(hasher, file)
+	if err != nil {
+		return nil, 0, fmt.Errorf("计算文件校验和失败: %w", err)
+	}
+	return hasher, size, nil
+}
+
+// MirrorStrategy 优先从镜像下载的策略，镜像中没有的版本交给原策略
+type MirrorStrategy struct {
+	Strategy
+
+	base       string
+	fs         afero.Fs
+	logger     *logrus.Logger
+	downloader Downloader
+	client     *http.Client
+}
+
+// NewMirrorStrategy 创建镜像下载策略，base 为镜像根目录的HTTP地址或本地路径
+func NewMirrorStrategy(inner Strategy, base string, fs afero.Fs, logger *logrus.Logger) Strategy {
+	return &MirrorStrategy{
+		Strategy:   inner,
+		base:       strings.TrimSuffix(base, "/"),
+		fs:         fs,
+		logger:     logger,
+		downloader: NewHTTPDownloader(fs, logger),
+		client:     NewHTTPClient(30 * time.Second),
+	}
+}
+
+// isRemote 镜像根目录是否为HTTP地址
+func (m *MirrorStrategy) isRemote() bool {
+	return strings.HasPrefix(m.base, "http://") || strings.HasPrefix(m.base, "https://")
+}
+
+// location 镜像中文件的地址
+func (m *MirrorStrategy) location(version, name string) string {
+	dir := MirrorDir(m.GetToolMetadata().Name, version, types.PlatformDirName())
+	if m.isRemote() {
+		return m.base + "/" + dir + "/" + name
+	}
+	return filepath.Join(m.base, filepath.FromSlash(dir), name)
+}
+
+// lookup 读取当前平台的镜像索引，镜像中没有该版本时返回 nil
+func (m *MirrorStrategy) lookup(ctx context.Context, version string) (*MirrorEntry, error) {
+	location := m.location(version, MirrorIndexFile)
+
+	var data []byte
+	if m.isRemote() {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, location, nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := m.client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusForbidden {
+			// S3 对不存在的对象在没有列举权限时返回403
+			return nil, nil
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("读取镜像索引失败，状态码: %d", resp.StatusCode)
+		}
+		if data, err = io.ReadAll(resp.Body); err != nil {
+			return nil, err
+		}
+	} else {
+		var err error
+		if data, err = afero.ReadFile(m.fs, location); err != nil {
+			if os.IsNotExist(err) {
+				return nil, nil
+			}
+			return nil, err
+		}
+	}
+
+	var entry MirrorEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, fmt.Errorf("解析镜像索引 %s 失败: %w", location, err)
+	}
+	if entry.Filename == "" || strings.ContainsAny(entry.Filename, `/\`) {
+		return nil, fmt.Errorf("镜像索引 %s 中的文件名无效: %q", location, entry.Filename)
+	}
+	return &entry, nil
+}
+
+// mirrored 查找镜像中的版本，镜像不可用时记录警告并返回 nil
+func (m *MirrorStrategy) mirrored(ctx context.Context, version string) *MirrorEntry {
+	entry, err := m.lookup(ctx, version)
+	if err != nil {
+		m.logger.Warnf("读取镜像失败，使用原下载源: %v", err)
+		return nil
+	}
+	return entry
+}
+
+// GetDownloadInfo 镜像中有该版本时返回镜像中的下载信息
+func (m *MirrorStrategy) GetDownloadInfo(ctx context.Context, version string) (*types.DownloadInfo, error) {
+	entry := m.mirrored(ctx, version)
+	if entry == nil {
+		return m.Strategy.GetDownloadInfo(ctx, version)
+	}
+	m.logger.Debugf("从镜像下载 %s@%s", entry.Tool, version)
+	return &types.DownloadInfo{
+		URL:      m.location(version, entry.Filename),
+		Filename: entry.Filename,
+		Size:     entry.Size,
+		Checksum: entry.Checksum,
+	}, nil
+}
+
+// GetDeltaURL 镜像索引中记录了从 from 升级的补丁时返回补丁地址
+func (m *MirrorStrategy) GetDeltaURL(ctx context.Context, from, version string) string {
+	entry := m.mirrored(ctx, version)
+	if entry == nil {
+		return ""
+	}
+	for _, delta := range entry.Deltas {
+		if delta == from {
+			return m.location(version, MirrorDeltasDir+"/"+from+DeltaExtension)
+		}
+	}
+	return ""
+}
+
+// GetDownloadURL 获取下载链接
+func (m *MirrorStrategy) GetDownloadURL(ctx context.Context, version string) (string, error) {
+	info, err := m.GetDownloadInfo(ctx, version)
+	if err != nil {
+		return "", err
+	}
+	return info.URL, nil
+}
+
+// ValidateVersion 镜像中有该版本时不再访问原下载源，离线环境中也能安装
+func (m *MirrorStrategy) ValidateVersion(ctx context.Context, version string) error {
+	if m.mirrored(ctx, version) != nil {
+		return nil
+	}
+	return m.Strategy.ValidateVersion(ctx, version)
+}
+
+// GetChecksum 镜像中有该版本时返回镜像索引中的校验和
+func (m *MirrorStrategy) GetChecksum(ctx context.Context, version string) (string, error) {
+	if entry := m.mirrored(ctx, version); entry != nil {
+		return entry.Checksum, nil
+	}
+	return m.Strategy.GetChecksum(ctx, version)
+}
+
+// Download 下载镜像中的文件，不是镜像地址时交给原策略
+func (m *MirrorStrategy) Download(ctx context.Context, url, targetPath string, options *DownloadOptions) error {
+	return m.DownloadWithProgress(ctx, url, targetPath, options, nil)
+}
+
+// DownloadWithProgress 带进度的下载，本地镜像直接复制文件
+func (m *MirrorStrategy) DownloadWithProgress(ctx context.Context, url, targetPath string, options *DownloadOptions, progress ProgressCallback) error {
+	if !strings.HasPrefix(url, m.base) {
+		if progress == nil {
+			return m.Strategy.Download(ctx, url, targetPath, options)
+		}
+		return m.Strategy.DownloadWithProgress(ctx, url, targetPath, options, progress)
+	}
+	if m.isRemote() {
+		if progress == nil {
+			return m.downloader.Download(ctx, url, targetPath, options)
+		}
+		return m.downloader.DownloadWithProgress(ctx, url, targetPath, options, progress)
+	}
+
+	src, err := m.fs.Open(url)
+	if err != nil {
+		return fmt.Errorf("打开镜像文件失败: %w", err)
+	}
+	defer src.Close()
+	if err := m.fs.MkdirAll(filepath.Dir(targetPath), 0755); err != nil {
+		return fmt.Errorf("创建目录失败: %w", err)
+	}
+	dst, err := m.fs.Create(targetPath)
+	if err != nil {
+		return fmt.Errorf("创建文件失败: %w", err)
+	}
+	written, err := copyBuffer(dst, src)
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("复制镜像文件失败: %w", err)
+	}
+	if progress != nil {
+		progress(&ProgressInfo{Downloaded: written, Total: written, Percentage: 100, Status: "已从镜像复制"})
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package download
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/songzhibin97/vman/pkg/types"
+)
+
+func TestMirrorArtifactAndStrategy(t *testing.T) {
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("artifact " + r.URL.Path))
+	}))
+	defer origin.Close()
+
+	fs := afero.NewOsFs()
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	metadata := &types.ToolMetadata{
+		Name: "tool",
+		DownloadConfig: types.DownloadConfig{
+			Type:        "direct",
+			URLTemplate: origin.URL + "/{version}/tool_{os}_{arch}.tar.gz",
+		},
+	}
+	inner := NewDirectStrategy(metadata, fs, logger)
+	root := t.TempDir()
+	ctx := context.Background()
+
+	platform := types.PlatformDirName()
+	entry, err := MirrorArtifact(ctx, fs, inner, "1.0.0", platform, root)
+	require.NoError(t, err)
+
+	platformInfo, _ := ParseMirrorPlatform(platform)
+	content := "artifact /1.0.0/tool_" + platformInfo.OS + "_" + platformInfo.Arch + ".tar.gz"
+	sum := sha256.Sum256([]byte(content))
+	assert.Equal(t, "tool_"+platformInfo.OS+"_"+platformInfo.Arch+".tar.gz", entry.Filename)
+	assert.Equal(t, "sha256:"+hex.EncodeToString(sum[:]), entry.Checksum)
+	assert.Equal(t, int64(len(content)), entry.Size)
+	assert.FileExists(t, filepath.Join(root, "tool", "1.0.0", platform, MirrorIndexFile))
+
+	// 其他平台使用目标平台的地址
+	windows, err := MirrorArtifact(ctx, fs, inner, "1.0.0", "windows-amd64", root)
+	require.NoError(t, err)
+	assert.Equal(t, origin.URL+"/1.0.0/tool_windows_amd64.tar.gz", windows.Source)
+
+	for name, base := range map[string]string{"local": root, "http": ""} {
+		t.Run(name, func(t *testing.T) {
+			if base == "" {
+				server := httptest.NewServer(http.FileServer(http.Dir(root)))
+				defer server.Close()
+				base = server.URL + "/"
+			}
+			strategy := NewMirrorStrategy(inner, base, fs, logger)
+
+			info, err := strategy.GetDownloadInfo(ctx, "1.0.0")
+			require.NoError(t, err)
+			assert.Equal(t, entry.Checksum, info.Checksum)
+			assert.NotContains(t, info.URL, origin.URL)
+			require.NoError(t, strategy.ValidateVersion(ctx, "1.0.0"))
+
+			target := filepath.Join(t.TempDir(), info.Filename)
+			require.NoError(t, strategy.Download(ctx, info.URL, target, &DownloadOptions{TempDir: filepath.Dir(target)}))
+			data, err := os.ReadFile(target)
+			require.NoError(t, err)
+			assert.Equal(t, content, string(data))
+
+			// 镜像中没有的版本使用原下载源
+			info, err = strategy.GetDownloadInfo(ctx, "2.0.0")
+			require.NoError(t, err)
+			assert.Contains(t, info.URL, origin.URL)
+			assert.Empty(t, info.Checksum)
+		})
+	}
+}
+
+func TestMirrorPlatforms(t *testing.T) {
+	assert.Equal(t, DefaultMirrorPlatforms, MirrorPlatforms(&types.ToolMetadata{}))
+	assert.Equal(t, []string{"linux-amd64", "darwin-arm64"}, MirrorPlatforms(&types.ToolMetadata{Platforms: []string{"linux_amd64", "darwin_arm64"}}))
+
+	_, err := ParseMirrorPlatform("linux")
+	assert.Error(t, err)
+}
@@ -0,0 +1,223 @@
+package download
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/songzhibin97/vman/internal/config"
+	"github.com/songzhibin97/vman/pkg/types"
+)
+
+// defaultDNSPort DNS服务器未指定端口时使用的端口
+const defaultDNSPort = "53"
+
+var (
+	// networkMu 保护 networkTransport
+	networkMu sync.RWMutex
+
+	// networkTransport 所有HTTP客户端共用的传输，由 ConfigureNetwork 按网络设置替换
+	networkTransport = newNetworkTransport(&networkDialer{dialer: &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}}, types.NetworkSettings{})
+)
+
+func init() {
+	// 项目配置通过 extends 继承的远程基础配置同样按网络设置下载
+	config.HTTPClient = NewHTTPClient(30 * time.Second)
+}
+
+// ConfigureNetwork 按网络设置配置所有下载和查询请求使用的连接方式
+// 已创建的客户端也会使用新的设置，旧传输中的空闲连接会被关闭
+func ConfigureNetwork(settings types.NetworkSettings) error {
+	dialer, err := newNetworkDialer(settings)
+	if err != nil {
+		return err
+	}
+
+	transport := newNetworkTransport(dialer, settings)
+	networkMu.Lock()
+	previous := networkTransport
+	networkTransport = transport
+	networkMu.Unlock()
+	previous.CloseIdleConnections()
+	return nil
+}
+
+// NewHTTPClient 创建使用网络设置的HTTP客户端，vman 中的所有HTTP请求都应使用它创建客户端
+// 所有客户端共用同一个连接池，安装多个工具时复用到同一主机的连接
+func NewHTTPClient(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: sharedTransport{},
+	}
+}
+
+// sharedTransport 将请求交给当前的共用传输
+type sharedTransport struct{}
+
+// RoundTrip 使用当前的共用传输发送请求，并记录连接复用统计
+func (sharedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	networkMu.RLock()
+	transport := networkTransport
+	networkMu.RUnlock()
+
+	resp, err := transport.RoundTrip(traceConnections(req))
+	if err == nil && resp.ProtoMajor == 2 {
+		connectionStats.http2Requests.Add(1)
+	}
+	return resp, err
+}
+
+// newNetworkTransport 基于默认传输创建使用指定拨号方式和连接池设置的传输，保留代理等环境设置
+func newNetworkTransport(dialer *networkDialer, settings types.NetworkSettings) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialer.DialContext
+	transport.MaxIdleConns = settings.GetMaxIdleConns()
+	// 默认每个主机只保持2个空闲连接，并发下载同一主机的多个工具时连接会被反复关闭
+	transport.MaxIdleConnsPerHost = settings.GetMaxIdleConnsPerHost()
+	transport.MaxConnsPerHost = settings.MaxConnsPerHost
+	transport.IdleConnTimeout = settings.GetIdleConnTimeout()
+	transport.DisableKeepAlives = settings.DisableKeepAlives
+	if settings.DisableHTTP2 {
+		// TLSNextProto 为非nil的空map时不会协商HTTP/2
+		transport.ForceAttemptHTTP2 = false
+		transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+	return transport
+}
+
+// networkDialer 按IP版本、固定解析和自定义DNS建立连接
+type networkDialer struct {
+	dialer    *net.Dialer
+	resolver  *net.Resolver
+	hosts     map[string]string
+	ipVersion string
+}
+
+// newNetworkDialer 根据网络设置创建拨号器
+func newNetworkDialer(settings types.NetworkSettings) (*networkDialer, error) {
+	if !types.IsValidIPVersion(settings.IPVersion) {
+		return nil, fmt.Errorf("无效的 ip_version: %s", settings.IPVersion)
+	}
+
+	d := &networkDialer{
+		dialer: &net.Dialer{
+			Timeout:       30 * time.Second,
+			KeepAlive:     30 * time.Second,
+			FallbackDelay: settings.HappyEyeballsDelay,
+		},
+		ipVersion: settings.IPVersion,
+		hosts:     make(map[string]string, len(settings.Hosts)),
+	}
+	for host, ip := range settings.Hosts {
+		if net.ParseIP(ip) == nil {
+			return nil, fmt.Errorf("hosts 中 %s 的地址 %q 不是有效的IP", host, ip)
+		}
+		d.hosts[strings.ToLower(host)] = ip
+	}
+
+	if len(settings.DNSServers) > 0 {
+		servers := make([]string, 0, len(settings.DNSServers))
+		for _, server := range settings.DNSServers {
+			address, err := dnsServerAddress(server)
+			if err != nil {
+				return nil, err
+			}
+			servers = append(servers, address)
+		}
+		d.resolver = newDNSResolver(servers)
+		d.dialer.Resolver = d.resolver
+	}
+	return d, nil
+}
+
+// dnsServerAddress 将 10.0.0.53、10.0.0.53:53、[::1]:53 规范化为带端口的地址
+func dnsServerAddress(server string) (string, error) {
+	if ip := net.ParseIP(server); ip != nil {
+		return net.JoinHostPort(server, defaultDNSPort), nil
+	}
+	host, _, err := net.SplitHostPort(server)
+	if err != nil || net.ParseIP(host) == nil {
+		return "", fmt.Errorf("DNS服务器 %q 必须是IP地址或 IP:端口", server)
+	}
+	return server, nil
+}
+
+// newDNSResolver 创建使用指定DNS服务器的解析器
+// 解析器每次查询都会重新拨号，依次轮换服务器，一个服务器无响应时重试会发往下一个
+func newDNSResolver(servers []string) *net.Resolver {
+	var next uint32
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			server := servers[int(atomic.AddUint32(&next, 1)-1)%len(servers)]
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, network, server)
+		},
+	}
+}
+
+// DialContext 建立连接：固定解析的主机直接连接配置的IP，强制IP版本时只使用对应的地址族，
+// 优先某个版本时按顺序逐个尝试解析出的地址，否则由 net.Dialer 同时尝试IPv4和IPv6
+func (d *networkDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return d.dialer.DialContext(ctx, network, address)
+	}
+
+	switch d.ipVersion {
+	case types.IPVersionIPv4:
+		network = "tcp4"
+	case types.IPVersionIPv6:
+		network = "tcp6"
+	}
+
+	if ip, ok := d.hosts[strings.ToLower(host)]; ok {
+		return d.dialer.DialContext(ctx, network, net.JoinHostPort(ip, port))
+	}
+	if net.ParseIP(host) != nil || (d.ipVersion != types.IPVersionPreferIPv4 && d.ipVersion != types.IPVersionPreferIPv6) {
+		return d.dialer.DialContext(ctx, network, address)
+	}
+
+	resolver := d.resolver
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+	addrs, err := resolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	sortByIPVersion(addrs, d.ipVersion == types.IPVersionPreferIPv4)
+
+	var lastErr error
+	for _, addr := range addrs {
+		conn, err := d.dialer.DialContext(ctx, network, net.JoinHostPort(addr.IP.String(), port))
+		if err == nil {
+			return conn, nil
+		}
+		lastErr = err
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	if lastErr == nil {
+		lastErr = errors.New("no addresses")
+	}
+	return nil, fmt.Errorf("连接 %s 失败: %w", address, lastErr)
+}
+
+// sortByIPVersion 将首选地址族的地址排在前面，同一地址族内保持解析结果的顺序
+func sortByIPVersion(addrs []net.IPAddr, preferIPv4 bool) {
+	sort.SliceStable(addrs, func(i, j int) bool {
+		iv4 := addrs[i].IP.To4() != nil
+		jv4 := addrs[j].IP.To4() != nil
+		return iv4 != jv4 && iv4 == preferIPv4
+	})
+}
@@ -0,0 +1,219 @@
+package download
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/songzhibin97/vman/pkg/types"
+)
+
+// resetNetwork 测试结束后恢复默认网络设置
+func resetNetwork(t *testing.T) {
+	t.Cleanup(func() {
+		require.NoError(t, ConfigureNetwork(types.NetworkSettings{}))
+	})
+}
+
+// fetchBody 使用共用传输请求地址并返回响应内容
+func fetchBody(t *testing.T, client *http.Client, rawURL string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	require.NoError(t, err)
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	return string(body), err
+}
+
+func TestConfigureNetworkHosts(t *testing.T) {
+	resetNetwork(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("host=" + r.Host))
+	}))
+	defer server.Close()
+	_, port, err := net.SplitHostPort(strings.TrimPrefix(server.URL, "http://"))
+	require.NoError(t, err)
+
+	// 配置前创建的客户端也使用新的设置
+	client := NewHTTPClient(5 * time.Second)
+	require.NoError(t, ConfigureNetwork(types.NetworkSettings{
+		IPVersion: types.IPVersionIPv4,
+		Hosts:     map[string]string{"Mirror.vman.test": "127.0.0.1"},
+	}))
+
+	body, err := fetchBody(t, client, "http://mirror.vman.test:"+port+"/tool")
+	require.NoError(t, err)
+	assert.Equal(t, "host=mirror.vman.test:"+port, body)
+
+	// 强制IPv6时无法连接只监听IPv4的地址
+	require.NoError(t, ConfigureNetwork(types.NetworkSettings{
+		IPVersion: types.IPVersionIPv6,
+		Hosts:     map[string]string{"mirror.vman.test": "127.0.0.1"},
+	}))
+	_, err = fetchBody(t, client, "http://mirror.vman.test:"+port+"/tool")
+	assert.Error(t, err)
+
+	assert.Error(t, ConfigureNetwork(types.NetworkSettings{IPVersion: "ipv5"}))
+	assert.Error(t, ConfigureNetwork(types.NetworkSettings{Hosts: map[string]string{"github.com": "mirror"}}))
+	assert.Error(t, ConfigureNetwork(types.NetworkSettings{DNSServers: []string{"dns.example.com"}}))
+}
+
+func TestConfigureNetworkDNSServers(t *testing.T) {
+	resetNetwork(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+	_, port, err := net.SplitHostPort(strings.TrimPrefix(server.URL, "http://"))
+	require.NoError(t, err)
+
+	dns, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer dns.Close()
+	queries := make(chan string, 16)
+	go serveDNS(dns, net.IPv4(127, 0, 0, 1).To4(), queries)
+
+	for _, version := range []string{types.IPVersionAuto, types.IPVersionPreferIPv4} {
+		require.NoError(t, ConfigureNetwork(types.NetworkSettings{
+			IPVersion:  version,
+			DNSServers: []string{dns.LocalAddr().String()},
+		}))
+		body, err := fetchBody(t, NewHTTPClient(5*time.Second), "http://tool.vman.test:"+port+"/")
+		require.NoError(t, err, version)
+		assert.Equal(t, "ok", body)
+		assert.Equal(t, "tool.vman.test.", <-queries)
+	}
+}
+
+func TestSortByIPVersion(t *testing.T) {
+	addrs := func(ips ...string) []net.IPAddr {
+		result := make([]net.IPAddr, 0, len(ips))
+		for _, ip := range ips {
+			result = append(result, net.IPAddr{IP: net.ParseIP(ip)})
+		}
+		return result
+	}
+	toStrings := func(list []net.IPAddr) []string {
+		result := make([]string, 0, len(list))
+		for _, addr := range list {
+			result = append(result, addr.IP.String())
+		}
+		return result
+	}
+
+	list := addrs("2001:db8::1", "192.0.2.1", "2001:db8::2", "192.0.2.2")
+	sortByIPVersion(list, true)
+	assert.Equal(t, []string{"192.0.2.1", "192.0.2.2", "2001:db8::1", "2001:db8::2"}, toStrings(list))
+	sortByIPVersion(list, false)
+	assert.Equal(t, []string{"2001:db8::1", "2001:db8::2", "192.0.2.1", "192.0.2.2"}, toStrings(list))
+}
+
+// serveDNS 对A记录查询返回 ip，其他查询返回空结果，并把查询的域名发送到 queries
+func serveDNS(conn net.PacketConn, ip net.IP, queries chan<- string) {
+	buf := make([]byte, 512)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		query := buf[:n]
+		if len(query) < 12 {
+			continue
+		}
+
+		// 解析问题部分的域名和类型
+		var labels []string
+		offset := 12
+		for offset < len(query) && query[offset] != 0 {
+			length := int(query[offset])
+			if offset+1+length > len(query) {
+				break
+			}
+			labels = append(labels, string(query[offset+1:offset+1+length]))
+			offset += 1 + length
+		}
+		questionEnd := offset + 5
+		if questionEnd > len(query) {
+			continue
+		}
+		qtype := binary.BigEndian.Uint16(query[offset+1:])
+
+		resp := make([]byte, 0, questionEnd+16)
+		resp = append(resp, query[:2]...) // ID
+		resp = append(resp, 0x81, 0x80)   // 标准响应，可递归
+		resp = append(resp, 0, 1)         // 问题数
+		if qtype == 1 {
+			resp = append(resp, 0, 1) // 回答数
+		} else {
+			resp = append(resp, 0, 0)
+		}
+		resp = append(resp, 0, 0, 0, 0)
+		resp = append(resp, query[12:questionEnd]...)
+		if qtype == 1 {
+			resp = append(resp, 0xc0, 12, 0, 1, 0, 1, 0, 0, 0, 60, 0, 4)
+			resp = append(resp, ip...)
+			queries <- strings.Join(labels, ".") + "."
+		}
+		conn.WriteTo(resp, addr)
+	}
+}
+
+func TestConnectionReuse(t *testing.T) {
+	resetNetwork(t)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Proto))
+	}))
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	defer server.Close()
+
+	// configure 应用网络设置并信任测试服务器的证书
+	configure := func(settings types.NetworkSettings) {
+		require.NoError(t, ConfigureNetwork(settings))
+		networkMu.Lock()
+		networkTransport.TLSClientConfig = server.Client().Transport.(*http.Transport).TLSClientConfig.Clone()
+		networkMu.Unlock()
+		ResetNetworkStats()
+	}
+
+	configure(types.NetworkSettings{})
+	client := NewHTTPClient(5 * time.Second)
+	for i := 0; i < 3; i++ {
+		body, err := fetchBody(t, client, server.URL)
+		require.NoError(t, err)
+		assert.Equal(t, "HTTP/2.0", body)
+	}
+	stats := NetworkStats()
+	assert.Equal(t, int64(3), stats.Requests)
+	assert.Equal(t, int64(1), stats.NewConnections)
+	assert.Equal(t, int64(2), stats.ReusedConnections)
+	assert.Equal(t, int64(1), stats.TLSHandshakes)
+	assert.Equal(t, int64(3), stats.HTTP2Requests)
+	assert.InDelta(t, 2.0/3, stats.ReuseRatio(), 0.001)
+	assert.Contains(t, stats.String(), "复用 2 次 (67%)")
+
+	configure(types.NetworkSettings{DisableHTTP2: true, DisableKeepAlives: true})
+	for i := 0; i < 2; i++ {
+		body, err := fetchBody(t, client, server.URL)
+		require.NoError(t, err)
+		assert.Equal(t, "HTTP/1.1", body)
+	}
+	stats = NetworkStats()
+	assert.Equal(t, int64(2), stats.NewConnections)
+	assert.Equal(t, int64(0), stats.ReusedConnections)
+	assert.Equal(t, int64(0), stats.HTTP2Requests)
+}
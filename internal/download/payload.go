@@ -0,0 +1,200 @@
+package download
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/spf13/afero"
+)
+
+// payloadSniffSize 检查下载内容时读取的文件头大小
+const payloadSniffSize = 512
+
+// payloadSnippetSize 错误信息中包含的文件内容长度
+const payloadSnippetSize = 200
+
+// compressionMagic 各压缩格式的文件头，brotli 没有固定的文件头
+var compressionMagic = map[string][]byte{
+	CompressionGzip:  {0x1f, 0x8b},
+	CompressionXz:    {0xfd, '7', 'z', 'X', 'Z', 0x00},
+	CompressionBzip2: []byte("BZh"),
+	CompressionZstd:  {0x28, 0xb5, 0x2f, 0xfd},
+}
+
+// zipMagics zip 的文件头，包括空压缩包和分卷压缩包
+var zipMagics = [][]byte{
+	[]byte("PK\x03\x04"),
+	[]byte("PK\x05\x06"),
+	[]byte("PK\x07\x08"),
+}
+
+// tarMagicOffset tar 头中 ustar 标识的位置
+const tarMagicOffset = 257
+
+// PayloadError 下载的内容不是预期的安装包，通常是服务器返回的HTML或JSON错误页面
+type PayloadError struct {
+	// Filename 下载的文件名
+	Filename string
+
+	// ContentType 响应的Content-Type头
+	ContentType string
+
+	// Reason 判断依据
+	Reason string
+
+	// Snippet 文件开头的内容
+	Snippet string
+}
+
+func (e *PayloadError) Error() string {
+	msg := fmt.Sprintf("下载的 %s 不是有效的安装包: %s", e.Filename, e.Reason)
+	if e.ContentType != "" {
+		msg += fmt.Sprintf(" (Content-Type: %s)", e.ContentType)
+	}
+	return msg + fmt.Sprintf("，内容开头: %q", e.Snippet)
+}
+
+// ValidatePayload 在解压前检查下载的文件，contentType 为响应的Content-Type头，未知时为空
+// 文件扩展名为压缩包或压缩文件时检查文件头是否与格式一致；内容是HTML或JSON（如 404 页面、API 错误）时返回 PayloadError
+func ValidatePayload(fs afero.Fs, path, contentType string) error {
+	return checkPayload(fs, path, contentType, true)
+}
+
+// CheckErrorPage 只检查下载的内容是否为HTML或JSON错误页面，用于不解压安装包的场景
+func CheckErrorPage(fs afero.Fs, path, contentType string) error {
+	return checkPayload(fs, path, contentType, false)
+}
+
+// checkPayload 检查下载的文件，checkMagic 为 true 时同时检查文件头与扩展名是否一致
+func checkPayload(fs afero.Fs, path, contentType string, checkMagic bool) error {
+	file, err := fs.Open(path)
+	if err != nil {
+		return fmt.Errorf("打开下载的文件失败: %w", err)
+	}
+	defer file.Close()
+
+	head := make([]byte, payloadSniffSize)
+	n, err := io.ReadFull(file, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return fmt.Errorf("读取下载的文件失败: %w", err)
+	}
+	head = head[:n]
+
+	filename := filepath.Base(path)
+	newError := func(reason string) error {
+		return &PayloadError{Filename: filename, ContentType: contentType, Reason: reason, Snippet: payloadSnippet(head)}
+	}
+	if len(head) == 0 {
+		return newError("文件为空")
+	}
+
+	if expected, ok := expectedMagic(filename); ok && checkMagic {
+		if expected(head) {
+			return nil
+		}
+		if kind := errorPageKind(head, contentType); kind != "" {
+			return newError("内容是" + kind)
+		}
+		return newError("文件头与扩展名不符")
+	}
+
+	// 没有扩展名可以判断时只拒绝明显的错误页面，单个二进制文件也可能是脚本
+	if kind := errorPageKind(head, contentType); kind != "" {
+		return newError("内容是" + kind)
+	}
+	return nil
+}
+
+// expectedMagic 根据文件名返回检查文件头的函数，无法从扩展名判断或格式没有固定文件头时返回 false
+func expectedMagic(filename string) (func(head []byte) bool, bool) {
+	format := DetectArchiveFormat(filename)
+	switch format {
+	case FormatZip:
+		return func(head []byte) bool {
+			for _, magic := range zipMagics {
+				if bytes.HasPrefix(head, magic) {
+					return true
+				}
+			}
+			return false
+		}, true
+	case FormatTar:
+		return func(head []byte) bool {
+			return len(head) >= tarMagicOffset+5 && string(head[tarMagicOffset:tarMagicOffset+5]) == "ustar"
+		}, true
+	case "":
+	default:
+		return compressionMatcher(archiveCompressions[format])
+	}
+
+	if compression, _ := DetectCompression(filename); compression != "" {
+		return compressionMatcher(compression)
+	}
+	return nil, false
+}
+
+// compressionMatcher 检查压缩格式文件头的函数
+func compressionMatcher(compression string) (func(head []byte) bool, bool) {
+	magic, ok := compressionMagic[compression]
+	if !ok {
+		return nil, false
+	}
+	return func(head []byte) bool {
+		return bytes.HasPrefix(head, magic)
+	}, true
+}
+
+// errorPageKind 判断内容是否为HTML或JSON错误页面，不是时返回空
+// 内容本身是HTML或JSON时直接判断；Content-Type 为HTML或JSON时只要内容是文本也视为错误页面
+func errorPageKind(head []byte, contentType string) string {
+	text := bytes.TrimLeft(bytes.TrimPrefix(head, []byte("\xef\xbb\xbf")), " \t\r\n")
+	lower := strings.ToLower(string(text[:min(len(text), 64)]))
+	switch {
+	case strings.HasPrefix(lower, "<!doctype html"), strings.HasPrefix(lower, "<html"),
+		strings.HasPrefix(lower, "<head"), strings.HasPrefix(lower, "<body"):
+		return "HTML页面"
+	case strings.HasPrefix(lower, "<?xml") && strings.Contains(lower, "<error"):
+		return "XML错误响应"
+	case looksLikeJSON(lower):
+		return "JSON"
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if !utf8.Valid(head) || bytes.IndexByte(head, 0) >= 0 {
+		return ""
+	}
+	switch {
+	case mediaType == "text/html" || mediaType == "application/xhtml+xml":
+		return "HTML页面"
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		return "JSON"
+	}
+	return ""
+}
+
+// looksLikeJSON 内容是否以JSON对象或对象数组开头，如 {"message": "Not Found"}
+func looksLikeJSON(text string) bool {
+	if !strings.HasPrefix(text, "{") && !strings.HasPrefix(text, "[") {
+		return false
+	}
+	rest := strings.TrimLeft(text[1:], " \t\r\n")
+	return strings.HasPrefix(rest, "\"") || strings.HasPrefix(rest, "{") || (text[0] == '{' && strings.HasPrefix(rest, "}"))
+}
+
+// payloadSnippet 错误信息中展示的文件开头内容
+func payloadSnippet(head []byte) string {
+	if len(head) <= payloadSnippetSize {
+		return string(head)
+	}
+	// 不在多字节字符的中间截断
+	end := payloadSnippetSize
+	for i := 0; i < utf8.UTFMax-1 && !utf8.RuneStart(head[end]); i++ {
+		end--
+	}
+	return string(head[:end])
+}
@@ -0,0 +1,81 @@
+package download
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidatePayload(t *testing.T) {
+	var gz bytes.Buffer
+	gw := gzip.NewWriter(&gz)
+	_, err := gw.Write(buildTar(t))
+	require.NoError(t, err)
+	require.NoError(t, gw.Close())
+
+	notFound := "<!DOCTYPE html>\n<html><head><title>404 Not Found</title></head><body>" + strings.Repeat("页面不存在", 60) + "</body></html>"
+
+	tests := []struct {
+		name        string
+		filename    string
+		content     []byte
+		contentType string
+		reason      string
+	}{
+		{"tar.gz", "kubectl.tar.gz", gz.Bytes(), "application/octet-stream", ""},
+		{"zip", "protoc.zip", buildZip(t), "", ""},
+		{"tar", "tool.tar", buildTar(t), "", ""},
+		{"binary", "kubectl", []byte("\x7fELF\x02\x01\x01\x00"), "application/octet-stream", ""},
+		{"script", "tool", []byte("#!/bin/sh\necho tool\n"), "text/plain", ""},
+		{"brotli without magic", "tool.tar.br", []byte{0x1b, 0x02, 0x00}, "", ""},
+		{"html saved as tar.gz", "kubectl.tar.gz", []byte(notFound), "text/html; charset=utf-8", "内容是HTML页面"},
+		{"html binary", "kubectl", []byte("  <html><body>Access denied</body></html>"), "", "内容是HTML页面"},
+		{"json error", "helm.zip", []byte(`{"message": "Not Found", "documentation_url": "https://docs.github.com"}`), "application/json", "内容是JSON"},
+		{"json with spaces", "helm", []byte("{\n  \"error\": \"rate limited\"\n}"), "", "内容是JSON"},
+		{"s3 error", "tool.tar.xz", []byte(`<?xml version="1.0" encoding="UTF-8"?><Error><Code>NoSuchKey</Code></Error>`), "application/xml", "内容是XML错误响应"},
+		{"text with html content type", "tool", []byte("Service Unavailable"), "text/html", "内容是HTML页面"},
+		{"wrong magic", "tool.zst", []byte("plain text"), "", "文件头与扩展名不符"},
+		{"empty", "tool", nil, "", "文件为空"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			require.NoError(t, afero.WriteFile(fs, "/dl/"+tt.filename, tt.content, 0644))
+
+			err := ValidatePayload(fs, "/dl/"+tt.filename, tt.contentType)
+			if tt.reason == "" {
+				assert.NoError(t, err)
+				return
+			}
+			var payloadErr *PayloadError
+			require.True(t, errors.As(err, &payloadErr), "%v", err)
+			assert.Equal(t, tt.reason, payloadErr.Reason)
+			assert.LessOrEqual(t, len(payloadErr.Snippet), payloadSnippetSize)
+		})
+	}
+
+	// 只检查错误页面时不检查文件头
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/dl/tool.zst", []byte("plain text"), 0644))
+	assert.NoError(t, CheckErrorPage(fs, "/dl/tool.zst", "text/plain"))
+	require.NoError(t, afero.WriteFile(fs, "/dl/tool.tar.gz", []byte(notFound), 0644))
+	assert.Error(t, CheckErrorPage(fs, "/dl/tool.tar.gz", ""))
+
+	// 错误信息中包含内容开头，不在多字节字符中间截断
+	require.NoError(t, afero.WriteFile(fs, "/dl/kubectl.tar.gz", []byte(notFound), 0644))
+	err = ValidatePayload(fs, "/dl/kubectl.tar.gz", "text/html")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "404 Not Found")
+	assert.Contains(t, err.Error(), "text/html")
+	var payloadErr *PayloadError
+	require.True(t, errors.As(err, &payloadErr))
+	assert.True(t, utf8.ValidString(payloadErr.Snippet))
+}
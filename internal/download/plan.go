@@ -0,0 +1,153 @@
+package download
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/songzhibin97/vman/pkg/types"
+)
+
+// 安装计划中每个工具版本的操作
+const (
+	// PlanActionInstalled 已安装，不需要操作
+	PlanActionInstalled = "installed"
+	// PlanActionDownload 需要下载安装包
+	PlanActionDownload = "download"
+	// PlanActionCached 使用下载缓存中的安装包，不需要下载
+	PlanActionCached = "cached"
+	// PlanActionError 无法确定下载信息或下载地址不可用
+	PlanActionError = "error"
+)
+
+// planExpansionRatio 估算安装后占用空间时压缩包解压后相对安装包大小的倍数
+const planExpansionRatio = 3
+
+// planProbeTimeout 查询安装包大小的超时时间
+const planProbeTimeout = 30 * time.Second
+
+// PlanItem 安装计划中的一个工具版本
+type PlanItem struct {
+	Tool string `json:"tool"`
+
+	// Requested 项目配置或命令行中的版本要求，如 ^1.29
+	Requested string `json:"requested,omitempty"`
+
+	// Version 解析得到的版本
+	Version string `json:"version"`
+
+	// Action 操作：installed、download、cached 或 error
+	Action string `json:"action"`
+
+	// Source 下载方式：direct、github、archive，从镜像下载时为 mirror
+	Source string `json:"source,omitempty"`
+
+	// Asset 安装包文件名
+	Asset string `json:"asset,omitempty"`
+
+	// URL 下载地址，去掉了查询参数
+	URL string `json:"url,omitempty"`
+
+	// Checksum 下载源声明的校验和
+	Checksum string `json:"checksum,omitempty"`
+
+	// Size 安装包大小（字节），未知时为 -1
+	Size int64 `json:"size"`
+
+	// InstallSize 估算的安装后占用空间（字节），未知时为 -1
+	InstallSize int64 `json:"install_size"`
+
+	// Error 无法安装的原因
+	Error string `json:"error,omitempty"`
+}
+
+// PlanInstall 查询安装工具版本需要下载的安装包，不下载安装包
+// 下载信息中没有大小时发送 HEAD 请求查询，下载缓存中有安装包时不需要下载
+func (m *DefaultManager) PlanInstall(ctx context.Context, tool, version string) (*PlanItem, error) {
+	item := &PlanItem{Tool: tool, Version: version, Action: PlanActionDownload, Size: -1, InstallSize: -1}
+
+	strategy, err := m.GetDownloadStrategy(tool)
+	if err != nil {
+		return nil, fmt.Errorf("获取下载策略失败: %w", err)
+	}
+	item.Source = strategy.GetToolMetadata().DownloadConfig.Type
+
+	downloadInfo, err := strategy.GetDownloadInfo(ctx, version)
+	if err != nil {
+		item.Action = PlanActionError
+		item.Error = fmt.Sprintf("获取下载信息失败: %v", err)
+		return item, nil
+	}
+	if mirror, ok := strategy.(*MirrorStrategy); ok && strings.HasPrefix(downloadInfo.URL, mirror.base) {
+		item.Source = "mirror"
+	}
+	item.Asset = downloadInfo.Filename
+	item.URL = redactedURL(downloadInfo.URL)
+	item.Checksum = downloadInfo.Checksum
+
+	if cached := m.cachedArtifactFor(tool, version, downloadInfo, &DownloadOptions{}); cached != "" {
+		item.Action = PlanActionCached
+		if info, err := m.fs.Stat(cached); err == nil {
+			item.Size = info.Size()
+		}
+	} else if downloadInfo.Size > 0 {
+		item.Size = downloadInfo.Size
+	} else if err := m.probeArtifact(ctx, strategy, downloadInfo, item); err != nil {
+		item.Action = PlanActionError
+		item.Error = err.Error()
+		return item, nil
+	}
+
+	if item.Size >= 0 {
+		item.InstallSize = estimateInstallSize(downloadInfo.Filename, item.Size)
+	}
+	return item, nil
+}
+
+// probeArtifact 查询安装包的大小，并确认下载地址可以访问
+func (m *DefaultManager) probeArtifact(ctx context.Context, strategy Strategy, downloadInfo *types.DownloadInfo, item *PlanItem) error {
+	if !strings.HasPrefix(downloadInfo.URL, "http://") && !strings.HasPrefix(downloadInfo.URL, "https://") {
+		// 本地镜像中的文件
+		info, err := m.fs.Stat(downloadInfo.URL)
+		if err != nil {
+			return fmt.Errorf("安装包不存在: %w", err)
+		}
+		item.Size = info.Size()
+		return nil
+	}
+
+	headers := make(map[string]string)
+	for key, value := range strategy.GetToolMetadata().DownloadConfig.Headers {
+		headers[key] = value
+	}
+	for key, value := range downloadInfo.Headers {
+		headers[key] = value
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, planProbeTimeout)
+	defer cancel()
+	status, size, err := probeURL(ctx, NewHTTPClient(planProbeTimeout), downloadInfo.URL, headers)
+	if err != nil {
+		return fmt.Errorf("查询安装包大小失败: %w", err)
+	}
+	if status >= http.StatusBadRequest {
+		return fmt.Errorf("下载地址返回 %d", status)
+	}
+	if size > 0 {
+		item.Size = size
+	}
+	return nil
+}
+
+// estimateInstallSize 估算安装后占用的空间，压缩包和压缩文件按解压后为安装包的数倍估算
+func estimateInstallSize(filename string, size int64) int64 {
+	if format := DetectArchiveFormat(filename); format != "" && format != FormatTar {
+		return size * planExpansionRatio
+	}
+	if compression, _ := DetectCompression(filename); compression != "" {
+		return size * planExpansionRatio
+	}
+	return size
+}
@@ -0,0 +1,127 @@
+package download
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/songzhibin97/vman/internal/config"
+	"github.com/songzhibin97/vman/internal/storage"
+	"github.com/songzhibin97/vman/pkg/types"
+)
+
+func TestPlanInstall(t *testing.T) {
+	var downloads int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/1.") {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Method == http.MethodGet {
+			downloads++
+		}
+		w.Header().Set("Content-Length", "1000")
+		if r.Method == http.MethodGet {
+			w.Write(make([]byte, 1000))
+		}
+	}))
+	defer server.Close()
+
+	home := t.TempDir()
+	fs := afero.NewOsFs()
+	logger := logrus.New()
+	logger.SetLevel(logrus.ErrorLevel)
+	m := &DefaultManager{
+		storageManager: storage.NewFilesystemManagerWithFs(fs, types.DefaultConfigPaths(home)),
+		fs:             fs,
+		logger:         logger,
+		strategies:     make(map[string]Strategy),
+	}
+	metadata := &types.ToolMetadata{
+		Name: "kubectl",
+		DownloadConfig: types.DownloadConfig{
+			Type:        "direct",
+			URLTemplate: server.URL + "/{version}/kubectl.tar.gz?token=secret",
+		},
+	}
+	m.strategies["kubectl"] = NewDirectStrategy(metadata, fs, logger)
+	ctx := context.Background()
+
+	item, err := m.PlanInstall(ctx, "kubectl", "1.30.0")
+	require.NoError(t, err)
+	assert.Equal(t, PlanActionDownload, item.Action)
+	assert.Equal(t, "direct", item.Source)
+	assert.Equal(t, "kubectl.tar.gz", item.Asset)
+	assert.Equal(t, server.URL+"/1.30.0/kubectl.tar.gz", item.URL)
+	assert.Equal(t, int64(1000), item.Size)
+	assert.Equal(t, int64(3000), item.InstallSize)
+
+	item, err = m.PlanInstall(ctx, "kubectl", "9.9.9")
+	require.NoError(t, err)
+	assert.Equal(t, PlanActionError, item.Action)
+	assert.Contains(t, item.Error, "404")
+
+	// 下载缓存中有安装包时不需要下载
+	artifact := filepath.Join(t.TempDir(), "kubectl.tar.gz")
+	require.NoError(t, os.WriteFile(artifact, []byte("cached artifact"), 0644))
+	require.NoError(t, os.MkdirAll(m.storageManager.GetToolVersionPath("kubectl", "1.29.0"), 0755))
+	m.cacheArtifact("kubectl", "1.29.0", artifact)
+	item, err = m.PlanInstall(ctx, "kubectl", "1.29.0")
+	require.NoError(t, err)
+	assert.Equal(t, PlanActionCached, item.Action)
+	assert.Equal(t, int64(len("cached artifact")), item.Size)
+	assert.Equal(t, 0, downloads, "生成安装计划时不下载安装包")
+
+	info := &types.DownloadInfo{Filename: "kubectl.tar.gz"}
+	target := filepath.Join(t.TempDir(), "kubectl.tar.gz")
+	assert.True(t, m.useCachedArtifact("kubectl", "1.29.0", info, target, &DownloadOptions{}))
+	data, err := os.ReadFile(target)
+	require.NoError(t, err)
+	assert.Equal(t, "cached artifact", string(data))
+
+	// 强制重新下载或校验和不一致时不使用缓存
+	assert.Empty(t, m.cachedArtifactFor("kubectl", "1.29.0", info, &DownloadOptions{Force: true}))
+	mismatch := &types.DownloadInfo{Filename: "kubectl.tar.gz", Checksum: "sha256:" + strings.Repeat("0", 64)}
+	assert.Empty(t, m.cachedArtifactFor("kubectl", "1.29.0", mismatch, &DownloadOptions{}))
+}
+
+func TestEstimateInstallSize(t *testing.T) {
+	assert.Equal(t, int64(300), estimateInstallSize("tool.tar.gz", 100))
+	assert.Equal(t, int64(300), estimateInstallSize("tool.gz", 100))
+	assert.Equal(t, int64(100), estimateInstallSize("tool.tar", 100))
+	assert.Equal(t, int64(100), estimateInstallSize("tool", 100))
+}
+
+func TestRecipeStrategyIsReadOnly(t *testing.T) {
+	home := t.TempDir()
+	configManager, err := config.NewManager(home)
+	require.NoError(t, err)
+	paths := types.DefaultConfigPaths(home)
+	m := NewManager(storage.NewFilesystemManager(paths), configManager).(*DefaultManager)
+
+	// 获取下载策略（安装计划、搜索版本）不写入工具定义和钩子
+	strategy, err := m.GetDownloadStrategy("go")
+	require.NoError(t, err)
+	assert.Equal(t, "go", strategy.GetToolMetadata().Name)
+	_, err = os.Stat(filepath.Join(paths.ToolsDir, "go.toml"))
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(filepath.Join(configManager.GetConfigDir(), "hooks"))
+	assert.True(t, os.IsNotExist(err))
+
+	t.Setenv(types.EnvVmanReadOnly, "1")
+	assert.ErrorIs(t, m.saveRecipe("go"), types.ErrReadOnly)
+
+	t.Setenv(types.EnvVmanReadOnly, "")
+	require.NoError(t, m.saveRecipe("go"))
+	_, err = os.Stat(filepath.Join(paths.ToolsDir, "go.toml"))
+	assert.NoError(t, err)
+}
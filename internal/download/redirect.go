@@ -0,0 +1,140 @@
+package download
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/songzhibin97/vman/pkg/types"
+)
+
+// authHeaderKeywords 名称中包含这些词的自定义请求头视为认证头，如 PRIVATE-TOKEN、X-API-Key
+var authHeaderKeywords = []string{"auth", "token", "key", "secret", "password", "session", "cookie"}
+
+// redirectContextKey 请求上下文中重定向状态的键
+type redirectContextKey struct{}
+
+// redirectState 一次下载请求的重定向策略和已跟随的次数
+type redirectState struct {
+	policy types.RedirectPolicy
+	hops   int
+}
+
+// withRedirectPolicy 在请求上下文中记录重定向策略，policy 为空时使用默认策略
+func withRedirectPolicy(ctx context.Context, policy *types.RedirectPolicy) (context.Context, *redirectState) {
+	state := &redirectState{}
+	if policy != nil {
+		state.policy = *policy
+	}
+	return context.WithValue(ctx, redirectContextKey{}, state), state
+}
+
+// redirectHops 返回请求已跟随的重定向次数
+func redirectHops(ctx context.Context) int {
+	if state, ok := ctx.Value(redirectContextKey{}).(*redirectState); ok {
+		return state.hops
+	}
+	return 0
+}
+
+// checkRedirect 作为 http.Client.CheckRedirect，限制重定向次数并按策略保留或去掉认证头
+// Go 只在跳转到非子域名时去掉 Authorization 和 Cookie，自定义的令牌头会被发送到任意域名，这里按策略统一处理
+func checkRedirect(logger *logrus.Logger) func(req *http.Request, via []*http.Request) error {
+	return func(req *http.Request, via []*http.Request) error {
+		state, ok := req.Context().Value(redirectContextKey{}).(*redirectState)
+		if !ok {
+			state = &redirectState{}
+		}
+		if max := state.policy.GetMaxHops(); len(via) > max {
+			if max == 0 {
+				return fmt.Errorf("下载地址重定向到 %s，但配置了不跟随重定向", RedactURL(req.URL))
+			}
+			return fmt.Errorf("重定向次数超过 %d 次", max)
+		}
+		state.hops = len(via)
+
+		original := via[0]
+		keep := keepAuthOnRedirect(state.policy, original.URL, req.URL)
+		for name, values := range original.Header {
+			if !IsAuthHeader(name) {
+				continue
+			}
+			if keep {
+				req.Header[name] = values
+			} else {
+				req.Header.Del(name)
+			}
+		}
+		if logger != nil {
+			logger.Debugf("重定向 %d: %s (保留认证头: %t)", len(via), RedactURL(req.URL), keep)
+		}
+		return nil
+	}
+}
+
+// keepAuthOnRedirect 重定向到 target 时是否保留原请求的认证头
+// 从HTTPS降级到HTTP或目标在 drop_auth_hosts 中时总是去掉；同一主机或目标在 keep_auth_hosts 中时保留
+func keepAuthOnRedirect(policy types.RedirectPolicy, original, target *url.URL) bool {
+	if original.Scheme == "https" && target.Scheme != "https" {
+		return false
+	}
+	host := strings.ToLower(target.Hostname())
+	if MatchHost(policy.DropAuthHosts, host) {
+		return false
+	}
+	if host == strings.ToLower(original.Hostname()) {
+		return true
+	}
+	return MatchHost(policy.KeepAuthHosts, host)
+}
+
+// IsAuthHeader 是否为重定向时需要按策略处理的认证头
+func IsAuthHeader(name string) bool {
+	lower := strings.ToLower(name)
+	for _, keyword := range authHeaderKeywords {
+		if strings.Contains(lower, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchHost 主机名是否匹配列表中的任一模式，*.example.com 匹配 example.com 的子域名
+func MatchHost(patterns []string, host string) bool {
+	host = strings.ToLower(host)
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if suffix := strings.TrimPrefix(pattern, "*"); suffix != pattern {
+			if strings.HasSuffix(host, suffix) && len(host) > len(suffix) {
+				return true
+			}
+			continue
+		}
+		if host == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// RedactURL 去掉地址中的用户信息和查询参数，CDN签名地址的查询参数中包含临时凭据
+func RedactURL(u *url.URL) string {
+	redacted := *u
+	redacted.User = nil
+	redacted.RawQuery = ""
+	redacted.Fragment = ""
+	return redacted.String()
+}
+
+// redactedURL 去掉地址字符串中的签名参数，无法解析时原样返回
+func redactedURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme == "" {
+		return rawURL
+	}
+	return RedactURL(u)
+}
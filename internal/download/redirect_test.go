@@ -0,0 +1,118 @@
+package download
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/songzhibin97/vman/pkg/types"
+)
+
+func TestDownloadRedirectPolicy(t *testing.T) {
+	var received http.Header
+	cdn := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Clone()
+		w.Write([]byte("artifact"))
+	}))
+	defer cdn.Close()
+	// 127.0.0.1 与 localhost 是不同的主机名
+	cdnURL := strings.Replace(cdn.URL, "127.0.0.1", "localhost", 1)
+
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/download":
+			http.Redirect(w, r, cdnURL+"/tool.tar.gz?X-Amz-Signature=secret", http.StatusFound)
+		case "/self":
+			http.Redirect(w, r, "/final", http.StatusFound)
+		case "/final":
+			received = r.Header.Clone()
+			w.Write([]byte("artifact"))
+		default:
+			http.Redirect(w, r, "/download", http.StatusFound)
+		}
+	}))
+	defer origin.Close()
+
+	fs := afero.NewMemMapFs()
+	downloader := NewHTTPDownloader(fs, logrus.New())
+	headers := map[string]string{
+		"Authorization": "Bearer token",
+		"PRIVATE-TOKEN": "secret",
+		"Accept":        "application/octet-stream",
+	}
+	download := func(path string, policy *types.RedirectPolicy) (*ResponseInfo, error) {
+		received = nil
+		response := &ResponseInfo{}
+		options := &DownloadOptions{
+			Headers:    headers,
+			Redirects:  policy,
+			OnResponse: func(info *ResponseInfo) { *response = *info },
+		}
+		err := downloader.Download(context.Background(), origin.URL+path, filepath.Join("/dl", "tool.tar.gz"), options)
+		return response, err
+	}
+
+	// 默认重定向到其他主机时去掉认证头，保留其他请求头
+	response, err := download("/download", nil)
+	require.NoError(t, err)
+	assert.Empty(t, received.Get("Authorization"))
+	assert.Empty(t, received.Get("Private-Token"))
+	assert.Equal(t, "application/octet-stream", received.Get("Accept"))
+	assert.Equal(t, 1, response.Redirects)
+	assert.True(t, strings.HasPrefix(response.FinalURL, cdnURL))
+	assert.Equal(t, cdnURL+"/tool.tar.gz", redactedURL(response.FinalURL))
+
+	// 配置的主机保留认证头
+	_, err = download("/download", &types.RedirectPolicy{KeepAuthHosts: []string{"localhost"}})
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer token", received.Get("Authorization"))
+	assert.Equal(t, "secret", received.Get("Private-Token"))
+
+	// 同一主机保留认证头，除非配置为去掉
+	_, err = download("/self", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer token", received.Get("Authorization"))
+	_, err = download("/self", &types.RedirectPolicy{DropAuthHosts: []string{"127.0.0.1"}})
+	require.NoError(t, err)
+	assert.Empty(t, received.Get("Authorization"))
+	assert.Empty(t, received.Get("Private-Token"))
+
+	// 重定向次数限制
+	response, err = download("/chain", nil)
+	require.NoError(t, err)
+	assert.Equal(t, 2, response.Redirects)
+	_, err = download("/chain", &types.RedirectPolicy{MaxHops: 1})
+	assert.ErrorContains(t, err, "重定向次数超过 1 次")
+	_, err = download("/download", &types.RedirectPolicy{MaxHops: -1})
+	assert.ErrorContains(t, err, "不跟随重定向")
+}
+
+func TestKeepAuthOnRedirect(t *testing.T) {
+	parse := func(raw string) *http.Request {
+		req, err := http.NewRequest(http.MethodGet, raw, nil)
+		require.NoError(t, err)
+		return req
+	}
+	policy := types.RedirectPolicy{KeepAuthHosts: []string{"*.example.com"}, DropAuthHosts: []string{"cdn.example.com"}}
+	original := parse("https://example.com/tool").URL
+
+	assert.True(t, keepAuthOnRedirect(policy, original, parse("https://example.com:8443/tool").URL))
+	assert.True(t, keepAuthOnRedirect(policy, original, parse("https://dl.example.com/tool").URL))
+	assert.False(t, keepAuthOnRedirect(policy, original, parse("https://cdn.example.com/tool").URL))
+	assert.False(t, keepAuthOnRedirect(policy, original, parse("http://example.com/tool").URL))
+	assert.False(t, keepAuthOnRedirect(policy, original, parse("https://example.org/tool").URL))
+	assert.False(t, keepAuthOnRedirect(types.RedirectPolicy{}, original, parse("https://dl.example.com/tool").URL))
+
+	assert.True(t, IsAuthHeader("X-JFrog-Art-Api-Key"))
+	assert.True(t, IsAuthHeader("Cookie"))
+	assert.False(t, IsAuthHeader("Accept"))
+	assert.False(t, MatchHost([]string{"*.example.com"}, "example.com"))
+}
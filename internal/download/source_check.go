@@ -0,0 +1,452 @@
+package download
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+
+	"github.com/songzhibin97/vman/internal/config"
+	"github.com/songzhibin97/vman/pkg/types"
+)
+
+// LintSeverity 检查结果的严重程度
+type LintSeverity string
+
+const (
+	// LintError 定义不可用，安装会失败
+	LintError LintSeverity = "error"
+
+	// LintWarning 定义可用，但部分平台或版本可能出错
+	LintWarning LintSeverity = "warning"
+
+	// LintInfo 提示信息
+	LintInfo LintSeverity = "info"
+)
+
+// LintFinding 工具定义的一条检查结果
+type LintFinding struct {
+	Severity LintSeverity `json:"severity"`
+	Check    string       `json:"check"` // definition, metadata, versions, template, asset, url
+	Platform string       `json:"platform,omitempty"`
+	Message  string       `json:"message"`
+}
+
+// String 返回检查结果的单行描述
+func (f LintFinding) String() string {
+	if f.Platform != "" {
+		return fmt.Sprintf("%s [%s] %s: %s", f.Severity, f.Check, f.Platform, f.Message)
+	}
+	return fmt.Sprintf("%s [%s] %s", f.Severity, f.Check, f.Message)
+}
+
+// PlatformResolution 某个平台的下载地址解析结果
+type PlatformResolution struct {
+	Platform string `json:"platform"`
+	URL      string `json:"url,omitempty"`
+	Filename string `json:"filename,omitempty"`
+	Status   int    `json:"status,omitempty"` // HEAD 请求的状态码，未检查时为 0
+	Size     int64  `json:"size,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// SourceReport 工具定义的检查报告
+type SourceReport struct {
+	Tool      string                `json:"tool"`
+	Type      string                `json:"type"`
+	Template  string                `json:"template,omitempty"` // url_template 或 asset_pattern
+	Version   string                `json:"version,omitempty"`  // 用于解析下载地址的版本
+	Versions  []string              `json:"versions,omitempty"`
+	Platforms []*PlatformResolution `json:"platforms,omitempty"`
+	Findings  []LintFinding         `json:"findings"`
+}
+
+// HasErrors 是否存在错误级别的检查结果
+func (r *SourceReport) HasErrors() bool {
+	return r.Count(LintError) > 0
+}
+
+// Count 统计指定严重程度的检查结果数量
+func (r *SourceReport) Count(severity LintSeverity) int {
+	count := 0
+	for _, finding := range r.Findings {
+		if finding.Severity == severity {
+			count++
+		}
+	}
+	return count
+}
+
+// add 追加一条检查结果
+func (r *SourceReport) add(severity LintSeverity, check, platform, format string, args ...interface{}) {
+	r.Findings = append(r.Findings, LintFinding{
+		Severity: severity,
+		Check:    check,
+		Platform: platform,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// SourceCheckOptions 工具定义检查选项
+type SourceCheckOptions struct {
+	// Version 用于解析下载地址的版本，为空时使用最新版本
+	Version string
+
+	// Platforms 要检查的平台（os_arch），为空时使用定义中声明的平台
+	Platforms []string
+
+	// SkipHead 不发送 HEAD 请求检查下载地址
+	SkipHead bool
+
+	// VersionLimit 报告中最多列出的版本数
+	VersionLimit int
+}
+
+// defaultCheckPlatforms 工具定义未声明平台时检查的常见平台
+var defaultCheckPlatforms = []string{"linux_amd64", "linux_arm64", "darwin_amd64", "darwin_arm64", "windows_amd64"}
+
+// templatePlaceholder 模板中的 {name} 占位符
+var templatePlaceholder = regexp.MustCompile(`\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// DecodeToolDefinition 解析工具定义文件，未被识别的键作为警告返回
+func DecodeToolDefinition(data []byte) (*types.ToolMetadata, []LintFinding, error) {
+	var metadata types.ToolMetadata
+	meta, err := toml.NewDecoder(bytes.NewReader(data)).Decode(&metadata)
+	if err != nil {
+		return nil, nil, fmt.Errorf("解析工具定义失败: %w", err)
+	}
+
+	// 整个表未被识别时只报告表本身
+	undecoded := make(map[string]bool)
+	var findings []LintFinding
+	for _, key := range meta.Undecoded() {
+		undecoded[key.String()] = true
+		if len(key) > 1 && undecoded[key[:len(key)-1].String()] {
+			continue
+		}
+		findings = append(findings, LintFinding{
+			Severity: LintWarning,
+			Check:    "definition",
+			Message:  fmt.Sprintf("未识别的键 %s，该设置不会生效", key.String()),
+		})
+	}
+	return &metadata, findings, nil
+}
+
+// CheckSource 按工具定义列出版本、解析各平台的下载地址并检查其可访问性，不下载或安装任何文件
+func CheckSource(ctx context.Context, metadata *types.ToolMetadata, options SourceCheckOptions) *SourceReport {
+	report := &SourceReport{Tool: metadata.Name, Type: metadata.DownloadConfig.Type}
+
+	if err := config.NewValidator().ValidateToolMetadata(metadata); err != nil {
+		report.add(LintError, "metadata", "", "%v", err)
+	}
+
+	logger := logrus.New()
+	logger.SetLevel(logrus.WarnLevel)
+
+	strategy, err := NewStrategy(metadata, afero.NewMemMapFs(), logger)
+	if err != nil {
+		report.add(LintError, "metadata", "", "%v", err)
+		return report
+	}
+
+	switch metadata.DownloadConfig.Type {
+	case "github":
+		report.Template = metadata.DownloadConfig.AssetPattern
+		checkAssetPattern(report, metadata.DownloadConfig.AssetPattern)
+	default:
+		report.Template = metadata.DownloadConfig.URLTemplate
+		checkURLTemplate(report, metadata.DownloadConfig.URLTemplate)
+	}
+
+	report.Version = resolveCheckVersion(ctx, report, strategy, metadata, options)
+	if report.Version == "" {
+		return report
+	}
+
+	platforms := resolveCheckPlatforms(report, metadata, options.Platforms)
+
+	var release *GitHubRelease
+	if github, ok := strategy.(*GitHubStrategy); ok {
+		release, err = github.getRelease(ctx, report.Version)
+		if err != nil {
+			report.add(LintError, "versions", "", "获取 %s 的发布信息失败: %v", report.Version, err)
+			return report
+		}
+	}
+
+	client := NewHTTPClient(30 * time.Second)
+	seen := make(map[string]string)
+
+	for _, platform := range platforms {
+		key := platform.GetPlatformKey()
+		resolution := &PlatformResolution{Platform: key}
+		report.Platforms = append(report.Platforms, resolution)
+
+		if release != nil {
+			asset, err := strategy.(*GitHubStrategy).matchAsset(release.Assets, platform, report.Version)
+			if err != nil {
+				resolution.Error = err.Error()
+				report.add(LintError, "asset", key, "%v", err)
+				continue
+			}
+			resolution.URL = asset.BrowserDownloadURL
+			resolution.Filename = asset.Name
+			resolution.Size = asset.Size
+			if metadata.DownloadConfig.AssetPattern == "" && !assetMentionsPlatform(asset.Name, platform) {
+				report.add(LintWarning, "asset", key, "未配置 asset_pattern，自动匹配到的 %s 看起来不属于该平台", asset.Name)
+			}
+		} else {
+			url, err := expandURLTemplate(metadata, report.Version, platform)
+			if err != nil {
+				resolution.Error = err.Error()
+				report.add(LintError, "template", key, "%v", err)
+				continue
+			}
+			resolution.URL = url
+			resolution.Filename = url[strings.LastIndex(url, "/")+1:]
+		}
+
+		if other, ok := seen[resolution.URL]; ok {
+			report.add(LintWarning, "template", key, "与 %s 解析到同一下载地址 %s", other, resolution.URL)
+		} else {
+			seen[resolution.URL] = key
+		}
+
+		if options.SkipHead {
+			continue
+		}
+		status, size, err := probeURL(ctx, client, resolution.URL, metadata.DownloadConfig.Headers)
+		resolution.Status = status
+		if size > 0 {
+			resolution.Size = size
+		}
+		switch {
+		case err != nil:
+			resolution.Error = err.Error()
+			report.add(LintError, "url", key, "无法访问 %s: %v", resolution.URL, err)
+		case status >= 400:
+			resolution.Error = http.StatusText(status)
+			report.add(LintError, "url", key, "%s 返回状态码 %d", resolution.URL, status)
+		}
+	}
+
+	return report
+}
+
+// resolveCheckVersion 确定用于解析下载地址的版本，并记录可用版本列表
+func resolveCheckVersion(ctx context.Context, report *SourceReport, strategy Strategy, metadata *types.ToolMetadata, options SourceCheckOptions) string {
+	versions, err := strategy.ListVersions(ctx)
+	if err != nil {
+		report.add(LintInfo, "versions", "", "无法列出版本: %v", err)
+	} else if len(versions) == 0 {
+		report.add(LintWarning, "versions", "", "没有找到适用于当前平台的版本")
+	} else {
+		limit := options.VersionLimit
+		if limit <= 0 || limit > len(versions) {
+			limit = len(versions)
+		}
+		for _, v := range versions[:limit] {
+			report.Versions = append(report.Versions, v.Version)
+		}
+	}
+
+	if options.Version != "" {
+		if alias, ok := metadata.VersionConfig.Aliases[options.Version]; ok {
+			return alias
+		}
+		return options.Version
+	}
+	if len(report.Versions) > 0 {
+		return report.Versions[0]
+	}
+	if latest, err := strategy.GetLatestVersion(ctx); err == nil && latest != "" {
+		return latest
+	}
+	if alias, ok := metadata.VersionConfig.Aliases["latest"]; ok {
+		return alias
+	}
+
+	report.add(LintError, "versions", "", "无法确定要检查的版本，请指定版本")
+	return ""
+}
+
+// resolveCheckPlatforms 解析要检查的平台列表
+func resolveCheckPlatforms(report *SourceReport, metadata *types.ToolMetadata, requested []string) []*types.PlatformInfo {
+	names := requested
+	if len(names) == 0 {
+		names = metadata.Platforms
+	}
+	if len(names) == 0 {
+		names = defaultCheckPlatforms
+		report.add(LintInfo, "metadata", "", "未声明 platforms，检查常见平台: %s", strings.Join(names, ", "))
+	}
+
+	var platforms []*types.PlatformInfo
+	for _, name := range names {
+		platform, ok := parsePlatformName(name)
+		if !ok {
+			report.add(LintError, "metadata", "", "无效的平台 %q，格式应为 <os>_<arch>，如 linux_amd64", name)
+			continue
+		}
+		platforms = append(platforms, platform)
+	}
+	return platforms
+}
+
+// parsePlatformName 解析 os_arch、os-arch 或 os/arch 形式的平台名
+func parsePlatformName(name string) (*types.PlatformInfo, bool) {
+	for _, sep := range []string{"_", "-", "/"} {
+		if parts := strings.SplitN(name, sep, 2); len(parts) == 2 && parts[0] != "" && parts[1] != "" {
+			return &types.PlatformInfo{OS: parts[0], Arch: parts[1]}, true
+		}
+	}
+	return nil, false
+}
+
+// sampleTemplateData 检查模板语法时使用的示例变量
+var sampleTemplateData = TemplateData{Name: "tool", Version: "1.2.3", OS: "linux", Arch: "amd64"}
+
+// checkURLTemplate 检查URL模板中的占位符和模板函数
+func checkURLTemplate(report *SourceReport, template string) {
+	if template == "" {
+		report.add(LintError, "template", "", "未配置 url_template")
+		return
+	}
+
+	used := checkPlaceholders(report, template, "url_template")
+	if _, err := ExpandTemplate(template, sampleTemplateData); err != nil {
+		report.add(LintError, "template", "", "url_template: %v", err)
+	}
+	if !used["version"] {
+		report.add(LintWarning, "template", "", "url_template 中没有 {version}，所有版本将下载同一文件")
+	}
+	if !used["os"] || !used["arch"] {
+		report.add(LintWarning, "template", "", "url_template 中缺少 {os} 或 {arch}，不同平台可能下载同一文件")
+	}
+}
+
+// checkAssetPattern 检查资产文件名模式中的占位符和正则表达式
+func checkAssetPattern(report *SourceReport, pattern string) {
+	if pattern == "" {
+		report.add(LintInfo, "asset", "", "未配置 asset_pattern，将按文件名中的平台关键字自动匹配")
+		return
+	}
+
+	checkPlaceholders(report, pattern, "asset_pattern")
+
+	expanded, err := ExpandTemplate(pattern, sampleTemplateData)
+	if err != nil {
+		report.add(LintError, "template", "", "asset_pattern: %v", err)
+		return
+	}
+	if _, err := regexp.Compile(expanded); err != nil {
+		report.add(LintError, "asset", "", "asset_pattern 不是有效的正则表达式: %v", err)
+	}
+}
+
+// checkPlaceholders 报告模板中不支持的占位符，返回使用到的变量
+func checkPlaceholders(report *SourceReport, template, field string) map[string]bool {
+	supported := []string{"version", "os", "arch"}
+	used := make(map[string]bool)
+
+	// {{ }} 中引用的变量
+	actions := templateAction.FindAllString(template, -1)
+	for _, action := range actions {
+		for _, name := range supported {
+			if strings.Contains(strings.ToLower(action), "."+name) {
+				used[name] = true
+			}
+		}
+	}
+
+	var unknown []string
+	for _, match := range templatePlaceholder.FindAllStringSubmatch(templateAction.ReplaceAllString(template, ""), -1) {
+		name := match[1]
+		used[name] = true
+		if !containsName(supported, name) && !containsName(unknown, name) {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	for _, name := range unknown {
+		report.add(LintError, "template", "", "%s 中的占位符 {%s} 不受支持（可用: {%s}，或 {{ }} 模板函数）", field, name, strings.Join(supported, "}, {"))
+	}
+	return used
+}
+
+// assetMentionsPlatform 资产文件名中是否包含平台的操作系统关键字
+func assetMentionsPlatform(name string, platform *types.PlatformInfo) bool {
+	name = strings.ToLower(name)
+	keywords := []string{platform.OS}
+	switch platform.OS {
+	case "darwin":
+		keywords = append(keywords, "macos", "osx", "mac")
+	case "windows":
+		keywords = append(keywords, "win")
+	}
+	for _, keyword := range keywords {
+		if strings.Contains(name, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// probeURL 检查下载地址是否可访问，服务器不支持 HEAD 时改用只取首字节的 GET
+func probeURL(ctx context.Context, client *http.Client, url string, headers map[string]string) (int, int64, error) {
+	status, size, err := doProbe(ctx, client, http.MethodHead, url, headers)
+	if err == nil && (status == http.StatusMethodNotAllowed || status == http.StatusForbidden) {
+		return doProbe(ctx, client, http.MethodGet, url, headers)
+	}
+	return status, size, err
+}
+
+// doProbe 发送一次探测请求
+func doProbe(ctx context.Context, client *http.Client, method, url string, headers map[string]string) (int, int64, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	if method == http.MethodGet {
+		req.Header.Set("Range", "bytes=0-0")
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusPartialContent {
+		// Content-Range: bytes 0-0/12345 中包含完整大小
+		var size int64
+		if _, total, ok := strings.Cut(resp.Header.Get("Content-Range"), "/"); ok {
+			size, _ = strconv.ParseInt(total, 10, 64)
+		}
+		return http.StatusOK, size, nil
+	}
+	return resp.StatusCode, resp.ContentLength, nil
+}
+
+// containsName 字符串切片中是否包含指定值
+func containsName(items []string, item string) bool {
+	for _, i := range items {
+		if i == item {
+			return true
+		}
+	}
+	return false
+}
@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"net/http"
 	"path/filepath"
-	"runtime"
 	"strings"
 	"time"
 
@@ -32,23 +31,26 @@ func NewDirectStrategy(metadata *types.ToolMetadata, fs afero.Fs, logger *logrus
 		logger:     logger,
 		downloader: NewHTTPDownloader(fs, logger),
 		extractor:  NewPackageProcessor(fs, logger),
-		client: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		client:     NewHTTPClient(30 * time.Second),
 	}
 }
 
 // GetDownloadInfo 获取下载信息
 func (d *DirectStrategy) GetDownloadInfo(ctx context.Context, version string) (*types.DownloadInfo, error) {
-	d.logger.Debugf("获取直接URL下载信息: %s@%s", d.metadata.Name, version)
+	return d.GetPlatformDownloadInfo(ctx, version, types.GetCurrentPlatform())
+}
 
-	url, err := d.buildDownloadURL(version)
+// GetPlatformDownloadInfo 获取指定平台的下载信息
+func (d *DirectStrategy) GetPlatformDownloadInfo(ctx context.Context, version string, platform *types.PlatformInfo) (*types.DownloadInfo, error) {
+	d.logger.Debugf("获取直接URL下载信息: %s@%s (%s-%s)", d.metadata.Name, version, platform.OS, platform.Arch)
+
+	url, err := d.buildPlatformURL(version, platform)
 	if err != nil {
 		return nil, fmt.Errorf("构建下载URL失败: %w", err)
 	}
 
 	// 获取文件名
-	filename := d.extractFilename(url)
+	filename := d.extractFilename(url, platform.OS)
 
 	// 尝试获取文件大小
 	size, err := d.getFileSize(ctx, url)
@@ -111,8 +113,8 @@ func (d *DirectStrategy) DownloadWithProgress(ctx context.Context, url, targetPa
 }
 
 // ExtractArchive 解压下载的压缩包
-func (d *DirectStrategy) ExtractArchive(archivePath, targetPath string) error {
-	_, err := d.extractor.ProcessPackage(archivePath, targetPath, d.metadata.Name, d.metadata)
+func (d *DirectStrategy) ExtractArchive(archivePath, targetPath string, options *ExtractOptions) error {
+	_, err := d.extractor.ProcessPackage(archivePath, targetPath, d.metadata.Name, d.metadata, options)
 	return err
 }
 
@@ -180,33 +182,21 @@ func (d *DirectStrategy) GetToolMetadata() *types.ToolMetadata {
 
 // 私有方法
 
-// buildDownloadURL 构建下载URL
+// buildDownloadURL 构建当前平台的下载URL
 func (d *DirectStrategy) buildDownloadURL(version string) (string, error) {
-	template := d.metadata.DownloadConfig.URLTemplate
-	if template == "" {
-		return "", fmt.Errorf("未配置URL模板")
-	}
-
-	platform := types.GetCurrentPlatform()
-
-	// 替换模板变量
-	url := template
-	url = strings.ReplaceAll(url, "{version}", version)
-	url = strings.ReplaceAll(url, "{os}", d.mapOSName(platform.OS))
-	url = strings.ReplaceAll(url, "{arch}", d.mapArchName(platform.Arch))
-
-	// 处理版本别名
-	if d.metadata.VersionConfig.Aliases != nil {
-		if alias, exists := d.metadata.VersionConfig.Aliases[version]; exists {
-			url = strings.ReplaceAll(url, version, alias)
-		}
-	}
+	return d.buildPlatformURL(version, types.GetCurrentPlatform())
+}
 
-	return url, nil
+// buildPlatformURL 构建指定平台的下载URL
+func (d *DirectStrategy) buildPlatformURL(version string, platform *types.PlatformInfo) (string, error) {
+	return expandURLTemplate(d.metadata, version, &types.PlatformInfo{
+		OS:   d.mapOSName(platform.OS),
+		Arch: d.mapArchName(platform.Arch),
+	})
 }
 
-// extractFilename 从URL中提取文件名
-func (d *DirectStrategy) extractFilename(url string) string {
+// extractFilename 从URL中提取文件名，goos 为下载的目标平台
+func (d *DirectStrategy) extractFilename(url, goos string) string {
 	// 从URL中提取文件名
 	parts := strings.Split(url, "/")
 	filename := parts[len(parts)-1]
@@ -224,8 +214,8 @@ func (d *DirectStrategy) extractFilename(url string) string {
 			filename = d.metadata.Name
 		}
 
-		// 在Windows上添加.exe扩展名
-		if runtime.GOOS == "windows" && !strings.HasSuffix(filename, ".exe") {
+		// Windows平台添加.exe扩展名
+		if goos == "windows" && !strings.HasSuffix(filename, ".exe") {
 			filename += ".exe"
 		}
 	}
@@ -308,17 +298,20 @@ func NewArchiveStrategy(metadata *types.ToolMetadata, fs afero.Fs, logger *logru
 		logger:     logger,
 		downloader: NewHTTPDownloader(fs, logger),
 		extractor:  NewPackageProcessor(fs, logger),
-		client: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		client:     NewHTTPClient(30 * time.Second),
 	}
 }
 
 // GetDownloadInfo 获取下载信息
 func (a *ArchiveStrategy) GetDownloadInfo(ctx context.Context, version string) (*types.DownloadInfo, error) {
-	a.logger.Debugf("获取归档文件下载信息: %s@%s", a.metadata.Name, version)
+	return a.GetPlatformDownloadInfo(ctx, version, types.GetCurrentPlatform())
+}
 
-	url, err := a.buildDownloadURL(version)
+// GetPlatformDownloadInfo 获取指定平台的下载信息
+func (a *ArchiveStrategy) GetPlatformDownloadInfo(ctx context.Context, version string, platform *types.PlatformInfo) (*types.DownloadInfo, error) {
+	a.logger.Debugf("获取归档文件下载信息: %s@%s (%s-%s)", a.metadata.Name, version, platform.OS, platform.Arch)
+
+	url, err := a.buildPlatformURL(version, platform)
 	if err != nil {
 		return nil, fmt.Errorf("构建下载URL失败: %w", err)
 	}
@@ -380,8 +373,8 @@ func (a *ArchiveStrategy) DownloadWithProgress(ctx context.Context, url, targetP
 }
 
 // ExtractArchive 解压下载的压缩包
-func (a *ArchiveStrategy) ExtractArchive(archivePath, targetPath string) error {
-	_, err := a.extractor.ProcessPackage(archivePath, targetPath, a.metadata.Name, a.metadata)
+func (a *ArchiveStrategy) ExtractArchive(archivePath, targetPath string, options *ExtractOptions) error {
+	_, err := a.extractor.ProcessPackage(archivePath, targetPath, a.metadata.Name, a.metadata, options)
 	return err
 }
 
@@ -441,27 +434,17 @@ func (a *ArchiveStrategy) GetToolMetadata() *types.ToolMetadata {
 	return a.metadata
 }
 
-// buildDownloadURL 构建下载URL
+// buildDownloadURL 构建当前平台的下载URL
 func (a *ArchiveStrategy) buildDownloadURL(version string) (string, error) {
-	template := a.metadata.DownloadConfig.URLTemplate
-	if template == "" {
-		return "", fmt.Errorf("未配置URL模板")
-	}
-
-	platform := types.GetCurrentPlatform()
-
-	url := template
-	url = strings.ReplaceAll(url, "{version}", version)
-	url = strings.ReplaceAll(url, "{os}", a.mapOSName(platform.OS))
-	url = strings.ReplaceAll(url, "{arch}", a.mapArchName(platform.Arch))
-
-	if a.metadata.VersionConfig.Aliases != nil {
-		if alias, exists := a.metadata.VersionConfig.Aliases[version]; exists {
-			url = strings.ReplaceAll(url, version, alias)
-		}
-	}
+	return a.buildPlatformURL(version, types.GetCurrentPlatform())
+}
 
-	return url, nil
+// buildPlatformURL 构建指定平台的下载URL
+func (a *ArchiveStrategy) buildPlatformURL(version string, platform *types.PlatformInfo) (string, error) {
+	return expandURLTemplate(a.metadata, version, &types.PlatformInfo{
+		OS:   a.mapOSName(platform.OS),
+		Arch: a.mapArchName(platform.Arch),
+	})
 }
 
 // extractFilename 从URL中提取文件名
@@ -59,15 +59,18 @@ func NewGitHubStrategy(metadata *types.ToolMetadata, fs afero.Fs, logger *logrus
 		logger:     logger,
 		downloader: NewHTTPDownloader(fs, logger),
 		extractor:  NewPackageProcessor(fs, logger),
-		client: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		client:     NewHTTPClient(30 * time.Second),
 	}
 }
 
 // GetDownloadInfo 获取下载信息
 func (g *GitHubStrategy) GetDownloadInfo(ctx context.Context, version string) (*types.DownloadInfo, error) {
-	g.logger.Debugf("获取GitHub下载信息: %s@%s", g.metadata.Name, version)
+	return g.GetPlatformDownloadInfo(ctx, version, types.GetCurrentPlatform())
+}
+
+// GetPlatformDownloadInfo 获取指定平台的下载信息
+func (g *GitHubStrategy) GetPlatformDownloadInfo(ctx context.Context, version string, platform *types.PlatformInfo) (*types.DownloadInfo, error) {
+	g.logger.Debugf("获取GitHub下载信息: %s@%s (%s-%s)", g.metadata.Name, version, platform.OS, platform.Arch)
 
 	// 获取发布信息
 	release, err := g.getRelease(ctx, version)
@@ -75,8 +78,8 @@ func (g *GitHubStrategy) GetDownloadInfo(ctx context.Context, version string) (*
 		return nil, fmt.Errorf("获取GitHub发布信息失败: %w", err)
 	}
 
-	// 匹配当前平台的资产
-	asset, err := g.matchAsset(release.Assets, types.GetCurrentPlatform())
+	// 匹配平台的资产
+	asset, err := g.matchAsset(release.Assets, platform, version)
 	if err != nil {
 		return nil, fmt.Errorf("匹配平台资产失败: %w", err)
 	}
@@ -108,8 +111,8 @@ func (g *GitHubStrategy) DownloadWithProgress(ctx context.Context, url, targetPa
 }
 
 // ExtractArchive 解压下载的压缩包
-func (g *GitHubStrategy) ExtractArchive(archivePath, targetPath string) error {
-	_, err := g.extractor.ProcessPackage(archivePath, targetPath, g.metadata.Name, g.metadata)
+func (g *GitHubStrategy) ExtractArchive(archivePath, targetPath string, options *ExtractOptions) error {
+	_, err := g.extractor.ProcessPackage(archivePath, targetPath, g.metadata.Name, g.metadata, options)
 	return err
 }
 
@@ -165,7 +168,7 @@ func (g *GitHubStrategy) ListVersions(ctx context.Context) ([]*types.VersionInfo
 		}
 
 		// 检查是否有适合当前平台的资产
-		if asset, err := g.matchAsset(release.Assets, platform); err == nil {
+		if asset, err := g.matchAsset(release.Assets, platform, g.normalizeVersion(release.TagName)); err == nil {
 			versionInfo := &types.VersionInfo{
 				Version:      g.normalizeVersion(release.TagName),
 				ReleaseDate:  release.PublishedAt,
@@ -322,14 +325,14 @@ func (g *GitHubStrategy) getAllReleases(ctx context.Context) ([]GitHubRelease, e
 }
 
 // matchAsset 匹配平台资产
-func (g *GitHubStrategy) matchAsset(assets []GitHubAsset, platform *types.PlatformInfo) (*GitHubAsset, error) {
+func (g *GitHubStrategy) matchAsset(assets []GitHubAsset, platform *types.PlatformInfo, version string) (*GitHubAsset, error) {
 	if len(assets) == 0 {
 		return nil, fmt.Errorf("没有可用的资产")
 	}
 
 	// 如果配置了资产模式，使用模式匹配
 	if g.metadata.DownloadConfig.AssetPattern != "" {
-		return g.matchAssetByPattern(assets, platform)
+		return g.matchAssetByPattern(assets, platform, version)
 	}
 
 	// 默认匹配逻辑
@@ -337,14 +340,19 @@ func (g *GitHubStrategy) matchAsset(assets []GitHubAsset, platform *types.Platfo
 }
 
 // matchAssetByPattern 使用模式匹配资产
-func (g *GitHubStrategy) matchAssetByPattern(assets []GitHubAsset, platform *types.PlatformInfo) (*GitHubAsset, error) {
-	pattern := g.metadata.DownloadConfig.AssetPattern
-
+func (g *GitHubStrategy) matchAssetByPattern(assets []GitHubAsset, platform *types.PlatformInfo, version string) (*GitHubAsset, error) {
 	// 替换模式中的变量
 	osName := g.mapOSName(platform.OS)
 	archName := g.mapArchName(platform.Arch)
-	pattern = strings.ReplaceAll(pattern, "{os}", osName)
-	pattern = strings.ReplaceAll(pattern, "{arch}", archName)
+	pattern, err := ExpandTemplate(g.metadata.DownloadConfig.AssetPattern, TemplateData{
+		Name:    g.metadata.Name,
+		Version: g.normalizeVersion(version),
+		OS:      osName,
+		Arch:    archName,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("无效的资产模式: %w", err)
+	}
 
 	g.logger.Debugf("平台信息: OS=%s, Arch=%s", platform.OS, platform.Arch)
 	g.logger.Debugf("映射后: OS=%s, Arch=%s", osName, archName)
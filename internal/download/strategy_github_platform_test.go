@@ -118,7 +118,7 @@ func TestGitHubStrategy_matchAssetByPattern(t *testing.T) {
 				logger:   logger,
 			}
 
-			asset, err := strategy.matchAssetByPattern(tt.assets, tt.platform)
+			asset, err := strategy.matchAssetByPattern(tt.assets, tt.platform, "1.31.0")
 
 			if tt.shouldMatch {
 				if err != nil {
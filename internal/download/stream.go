@@ -0,0 +1,102 @@
+package download
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/spf13/afero"
+)
+
+// copyBufferSize 下载、解压和复制共用的缓冲区大小
+const copyBufferSize = 256 * 1024
+
+// copyBuffers 复用复制缓冲区，避免每个文件分配一次
+var copyBuffers = sync.Pool{
+	New: func() interface{} {
+		buf := make([]byte, copyBufferSize)
+		return &buf
+	},
+}
+
+// copyBuffer 使用复用的缓冲区将 src 复制到 dst
+func copyBuffer(dst io.Writer, src io.Reader) (int64, error) {
+	buf := copyBuffers.Get().(*[]byte)
+	defer copyBuffers.Put(buf)
+	return io.CopyBuffer(dst, src, *buf)
+}
+
+// moveFile 将文件移动到 dst，能重命名时不复制数据
+// 跨文件系统等无法重命名的情况下复制后删除源文件
+func moveFile(fs afero.Fs, src, dst string) error {
+	if err := fs.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return fmt.Errorf("创建目标目录失败: %w", err)
+	}
+	if err := fs.Rename(src, dst); err == nil {
+		return nil
+	}
+
+	info, err := fs.Stat(src)
+	if err != nil {
+		return err
+	}
+	srcFile, err := fs.Open(src)
+	if err != nil {
+		return fmt.Errorf("打开源文件失败: %w", err)
+	}
+	defer srcFile.Close()
+
+	dstFile, err := fs.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return fmt.Errorf("创建目标文件失败: %w", err)
+	}
+	_, err = copyBuffer(dstFile, srcFile)
+	if closeErr := dstFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		fs.Remove(dst)
+		return fmt.Errorf("复制文件内容失败: %w", err)
+	}
+
+	// 复制文件属性失败不影响文件内容，由调用方决定是否需要
+	_ = copyFileAttributes(fs, src, dst)
+	return fs.Remove(src)
+}
+
+// moveDirectory 将 src 目录下的内容移动到已存在的 dst 目录
+// 顶层条目在 dst 中不存在时整体重命名，否则逐个文件移动
+func moveDirectory(fs afero.Fs, src, dst string) error {
+	if err := fs.MkdirAll(dst, 0755); err != nil {
+		return fmt.Errorf("创建目标目录失败: %w", err)
+	}
+
+	entries, err := afero.ReadDir(fs, src)
+	if err != nil {
+		return fmt.Errorf("读取目录失败: %w", err)
+	}
+
+	for _, entry := range entries {
+		srcPath := filepath.Join(src, entry.Name())
+		dstPath := filepath.Join(dst, entry.Name())
+
+		if exists, _ := afero.Exists(fs, dstPath); !exists {
+			if err := fs.Rename(srcPath, dstPath); err == nil {
+				continue
+			}
+		}
+
+		if entry.IsDir() {
+			if err := moveDirectory(fs, srcPath, dstPath); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := moveFile(fs, srcPath, dstPath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
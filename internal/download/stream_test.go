@@ -0,0 +1,97 @@
+package download
+
+import (
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/songzhibin97/vman/pkg/types"
+)
+
+func TestMoveDirectory_MergesIntoExisting(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/src/bin/tool", []byte("new"), 0755))
+	require.NoError(t, afero.WriteFile(fs, "/src/share/doc.txt", []byte("doc"), 0644))
+	require.NoError(t, afero.WriteFile(fs, "/dst/bin/tool", []byte("old"), 0755))
+	require.NoError(t, afero.WriteFile(fs, "/dst/bin/other", []byte("other"), 0755))
+
+	require.NoError(t, moveDirectory(fs, "/src", "/dst"))
+
+	data, err := afero.ReadFile(fs, "/dst/bin/tool")
+	require.NoError(t, err)
+	assert.Equal(t, "new", string(data))
+	data, err = afero.ReadFile(fs, "/dst/share/doc.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "doc", string(data))
+	exists, _ := afero.Exists(fs, "/dst/bin/other")
+	assert.True(t, exists)
+	exists, _ = afero.Exists(fs, "/src/bin/tool")
+	assert.False(t, exists)
+}
+
+func TestExtractWithOptions_Filter(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/pkg/tool.tar", buildTar(t), 0644))
+
+	var files int
+	extractor := NewArchiveExtractor(fs, logrus.New())
+	require.NoError(t, extractor.ExtractWithOptions("/pkg/tool.tar", "/out", &ExtractOptions{
+		Filter:   func(name string) bool { return name == "bin/tool" },
+		Progress: func(p *ExtractProgress) { files = p.Files },
+	}))
+
+	assert.Equal(t, 1, files)
+	exists, _ := afero.Exists(fs, "/out/bin/tool")
+	assert.True(t, exists)
+	exists, _ = afero.Exists(fs, "/out/README.md")
+	assert.False(t, exists)
+}
+
+func TestProcessPackage_StreamsConfiguredBinary(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/tmp/dl/tool.tar", buildTar(t), 0644))
+
+	metadata := &types.ToolMetadata{Name: "tool"}
+	metadata.DownloadConfig.ExtractBinary = "bin/tool"
+
+	var extracted []string
+	processor := NewPackageProcessor(fs, logrus.New())
+	binaryPath, err := processor.ProcessPackage("/tmp/dl/tool.tar", "/tmp/dl/extracted", "tool", metadata, &ExtractOptions{
+		Progress: func(p *ExtractProgress) { extracted = append(extracted, p.Entry) },
+	})
+	require.NoError(t, err)
+
+	expected := filepath.Join("/tmp/dl/extracted", "bin", "tool")
+	if runtime.GOOS == "windows" {
+		expected += ".exe"
+	}
+	assert.Equal(t, expected, binaryPath)
+	assert.Equal(t, []string{"tool-1.0/bin/tool"}, extracted)
+
+	data, err := afero.ReadFile(fs, binaryPath)
+	require.NoError(t, err)
+	assert.Equal(t, "#!/bin/sh\necho tool\n", string(data))
+	exists, _ := afero.Exists(fs, "/tmp/dl/extract_temp")
+	assert.False(t, exists)
+}
+
+func TestProcessPackage_FallsBackToFullExtraction(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/tmp/dl/tool.tar", buildTar(t), 0644))
+
+	metadata := &types.ToolMetadata{Name: "tool"}
+	metadata.DownloadConfig.ExtractBinary = "missing"
+
+	var files int
+	processor := NewPackageProcessor(fs, logrus.New())
+	_, err := processor.ProcessPackage("/tmp/dl/tool.tar", "/tmp/dl/extracted", "tool", metadata, &ExtractOptions{
+		Progress: func(p *ExtractProgress) { files = p.Files },
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, files)
+}
@@ -0,0 +1,125 @@
+package download
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/songzhibin97/vman/pkg/types"
+)
+
+// TemplateData URL模板和资产文件名模式中可用的变量
+type TemplateData struct {
+	// Name 工具名称
+	Name string
+
+	// Version 版本号
+	Version string
+
+	// OS 操作系统，如 linux、darwin、windows
+	OS string
+
+	// Arch 架构，如 amd64、arm64
+	Arch string
+}
+
+// templateAction 模板中的 {{ ... }} 表达式
+var templateAction = regexp.MustCompile(`\{\{.*?\}\}`)
+
+// templateFuncs 模板中可用的版本处理函数
+var templateFuncs = template.FuncMap{
+	// trimV 去掉版本号的 v 前缀：v1.2.3 → 1.2.3
+	"trimV": func(version string) string {
+		return strings.TrimPrefix(version, "v")
+	},
+	// major 主版本号：1.2.3 → 1
+	"major": func(version string) string {
+		return versionPart(version, 0)
+	},
+	// minor 次版本号：1.2.3 → 2
+	"minor": func(version string) string {
+		return versionPart(version, 1)
+	},
+	// patch 修订号：1.2.3-rc.1 → 3
+	"patch": func(version string) string {
+		return versionPart(version, 2)
+	},
+	// majorMinor 主次版本号：v1.2.3 → 1.2
+	"majorMinor": func(version string) string {
+		return versionPart(version, 0) + "." + versionPart(version, 1)
+	},
+	// replace 替换所有匹配的子串，参数顺序便于管道使用：{{ .Version | replace "." "_" }}
+	"replace": func(old, new, s string) string {
+		return strings.ReplaceAll(s, old, new)
+	},
+	"upper":      strings.ToUpper,
+	"lower":      strings.ToLower,
+	"trimPrefix": func(prefix, s string) string { return strings.TrimPrefix(s, prefix) },
+	"trimSuffix": func(suffix, s string) string { return strings.TrimSuffix(s, suffix) },
+}
+
+// ExpandTemplate 展开模板：先替换 {version}、{os}、{arch} 占位符，再执行 {{ }} 中的模板函数
+func ExpandTemplate(text string, data TemplateData) (string, error) {
+	text = strings.NewReplacer(
+		"{version}", data.Version,
+		"{os}", data.OS,
+		"{arch}", data.Arch,
+	).Replace(text)
+
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+
+	tmpl, err := template.New("url").Funcs(templateFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("解析模板失败: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("展开模板失败: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// versionPart 返回版本号中第 index 段数字，不存在时返回 0
+func versionPart(version string, index int) string {
+	version = strings.TrimPrefix(version, "v")
+	if i := strings.IndexAny(version, "-+"); i >= 0 {
+		version = version[:i]
+	}
+
+	parts := strings.Split(version, ".")
+	if index >= len(parts) || parts[index] == "" {
+		return "0"
+	}
+	return parts[index]
+}
+
+// expandURLTemplate 按平台展开URL模板
+func expandURLTemplate(metadata *types.ToolMetadata, version string, platform *types.PlatformInfo) (string, error) {
+	if metadata.DownloadConfig.URLTemplate == "" {
+		return "", fmt.Errorf("未配置URL模板")
+	}
+
+	url, err := ExpandTemplate(metadata.DownloadConfig.URLTemplate, TemplateData{
+		Name:    metadata.Name,
+		Version: version,
+		OS:      platform.OS,
+		Arch:    platform.Arch,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	// 处理版本别名
+	if metadata.VersionConfig.Aliases != nil {
+		if alias, exists := metadata.VersionConfig.Aliases[version]; exists {
+			url = strings.ReplaceAll(url, version, alias)
+		}
+	}
+
+	return url, nil
+}
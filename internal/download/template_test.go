@@ -0,0 +1,59 @@
+package download
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/songzhibin97/vman/pkg/types"
+)
+
+func TestExpandTemplate(t *testing.T) {
+	data := TemplateData{Name: "tool", Version: "v1.22.3-rc.1", OS: "linux", Arch: "amd64"}
+
+	tests := []struct {
+		template string
+		expected string
+	}{
+		{"{version}/{os}/{arch}", "v1.22.3-rc.1/linux/amd64"},
+		{"{{ trimV .Version }}", "1.22.3-rc.1"},
+		{"go{{ majorMinor .Version }}", "go1.22"},
+		{"{{ major .Version }}-{{ minor .Version }}-{{ patch .Version }}", "1-22-3"},
+		{"{{ .Arch | upper }}_{{ .OS | lower }}", "AMD64_linux"},
+		{`{{ trimV .Version | replace "." "_" }}`, "1_22_3-rc_1"},
+		{`{{ .Version | trimPrefix "v" | trimSuffix "-rc.1" }}`, "1.22.3"},
+		{"{{ .Name }}-{os}", "tool-linux"},
+		{"{{ patch \"1.2\" }}", "0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.template, func(t *testing.T) {
+			result, err := ExpandTemplate(tt.template, data)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+
+	_, err := ExpandTemplate("{{ nope .Version }}", data)
+	assert.Error(t, err)
+	_, err = ExpandTemplate("{{ .Missing }}", data)
+	assert.Error(t, err)
+}
+
+func TestExpandURLTemplate(t *testing.T) {
+	metadata := &types.ToolMetadata{
+		Name: "go",
+		DownloadConfig: types.DownloadConfig{
+			URLTemplate: "https://go.dev/dl/go{{ trimV .Version }}.{os}-{arch}.tar.gz",
+		},
+	}
+
+	url, err := expandURLTemplate(metadata, "v1.22.0", &types.PlatformInfo{OS: "darwin", Arch: "arm64"})
+	require.NoError(t, err)
+	assert.Equal(t, "https://go.dev/dl/go1.22.0.darwin-arm64.tar.gz", url)
+
+	metadata.DownloadConfig.URLTemplate = ""
+	_, err = expandURLTemplate(metadata, "1.0.0", types.GetCurrentPlatform())
+	assert.Error(t, err)
+}
@@ -0,0 +1,146 @@
+package download
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+
+	"github.com/songzhibin97/vman/pkg/types"
+)
+
+// NewStrategy 根据工具元数据中的下载类型创建下载策略
+func NewStrategy(metadata *types.ToolMetadata, fs afero.Fs, logger *logrus.Logger) (Strategy, error) {
+	switch metadata.DownloadConfig.Type {
+	case "github":
+		return NewGitHubStrategy(metadata, fs, logger), nil
+	case "direct":
+		return NewDirectStrategy(metadata, fs, logger), nil
+	case "archive":
+		return NewArchiveStrategy(metadata, fs, logger), nil
+	default:
+		return nil, fmt.Errorf("不支持的下载类型: %s", metadata.DownloadConfig.Type)
+	}
+}
+
+// TrialResult 试下载结果
+type TrialResult struct {
+	// Version 试下载的版本
+	Version string
+
+	// URL 下载地址
+	URL string
+
+	// Filename 下载的文件名
+	Filename string
+
+	// Size 下载文件大小
+	Size int64
+
+	// Binary 提取出的二进制文件名
+	Binary string
+}
+
+// Artifact 按工具定义下载的安装包
+type Artifact struct {
+	// Version 下载的版本
+	Version string
+
+	// URL 下载地址
+	URL string
+
+	// Filename 下载的文件名
+	Filename string
+
+	// Path 下载后的文件路径
+	Path string
+
+	// Size 文件大小
+	Size int64
+
+	strategy Strategy
+}
+
+// FetchArtifact 按工具定义将一个版本的安装包下载到 dir 中，不解压也不安装
+// version 为空时使用最新版本
+func FetchArtifact(ctx context.Context, fs afero.Fs, metadata *types.ToolMetadata, version, dir string) (*Artifact, error) {
+	logger := logrus.New()
+	logger.SetLevel(logrus.WarnLevel)
+
+	strategy, err := NewStrategy(metadata, fs, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	if version == "" {
+		version, err = strategy.GetLatestVersion(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("获取最新版本失败: %w", err)
+		}
+	}
+
+	info, err := strategy.GetDownloadInfo(ctx, version)
+	if err != nil {
+		return nil, fmt.Errorf("获取下载信息失败: %w", err)
+	}
+
+	if err := fs.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("创建下载目录失败: %w", err)
+	}
+
+	artifact := &Artifact{
+		Version:  version,
+		URL:      info.URL,
+		Filename: info.Filename,
+		Path:     filepath.Join(dir, info.Filename),
+		strategy: strategy,
+	}
+	var contentType string
+	options := &DownloadOptions{TempDir: dir, OnResponse: func(response *ResponseInfo) {
+		contentType = response.ContentType
+	}}
+	if err := strategy.Download(ctx, info.URL, artifact.Path, options); err != nil {
+		return artifact, fmt.Errorf("下载 %s 失败: %w", info.URL, err)
+	}
+	if err := ValidatePayload(fs, artifact.Path, contentType); err != nil {
+		return artifact, err
+	}
+	if stat, err := fs.Stat(artifact.Path); err == nil {
+		artifact.Size = stat.Size()
+	}
+
+	return artifact, nil
+}
+
+// TrialDownload 按工具定义下载并解压一个版本，验证定义是否可用，不会安装任何文件
+// version 为空时使用最新版本，下载的文件保存在 tempDir 下的临时目录中并在返回前删除
+func TrialDownload(ctx context.Context, fs afero.Fs, metadata *types.ToolMetadata, version, tempDir string) (*TrialResult, error) {
+	workDir := filepath.Join(tempDir, fmt.Sprintf("trial-%s-%d", metadata.Name, time.Now().UnixNano()))
+	if err := fs.MkdirAll(workDir, 0755); err != nil {
+		return nil, fmt.Errorf("创建临时目录失败: %w", err)
+	}
+	defer fs.RemoveAll(workDir)
+
+	artifact, err := FetchArtifact(ctx, fs, metadata, version, workDir)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &TrialResult{Version: artifact.Version, URL: artifact.URL, Filename: artifact.Filename, Size: artifact.Size}
+
+	extractDir := filepath.Join(workDir, "extracted")
+	if err := artifact.strategy.ExtractArchive(artifact.Path, extractDir, nil); err != nil {
+		return result, fmt.Errorf("提取二进制文件失败: %w", err)
+	}
+
+	entries, err := afero.ReadDir(fs, filepath.Join(extractDir, "bin"))
+	if err != nil || len(entries) == 0 {
+		return result, fmt.Errorf("在 %s 中没有找到可执行文件", artifact.Filename)
+	}
+	result.Binary = entries[0].Name()
+
+	return result, nil
+}
@@ -0,0 +1,18 @@
+//go:build !linux && !darwin
+
+package download
+
+import "errors"
+
+// xattrSupported 当前系统是否支持扩展属性
+const xattrSupported = false
+
+// setXattr 当前系统不支持扩展属性
+func setXattr(path, name, value string) error {
+	return errors.New("当前系统不支持扩展属性")
+}
+
+// listXattrs 当前系统不支持扩展属性
+func listXattrs(path string) (map[string]string, error) {
+	return nil, nil
+}
@@ -0,0 +1,54 @@
+//go:build linux || darwin
+
+package download
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// xattrSupported 当前系统是否支持扩展属性
+const xattrSupported = true
+
+// setXattr 设置文件 user 命名空间的扩展属性
+func setXattr(path, name, value string) error {
+	if !strings.HasPrefix(name, userXattrPrefix) {
+		return fmt.Errorf("refusing to set xattr %s outside the user namespace", name)
+	}
+	return unix.Setxattr(path, name, []byte(value), 0)
+}
+
+// listXattrs 读取文件的所有扩展属性
+func listXattrs(path string) (map[string]string, error) {
+	size, err := unix.Listxattr(path, nil)
+	if err != nil || size == 0 {
+		return nil, err
+	}
+
+	buf := make([]byte, size)
+	size, err = unix.Listxattr(path, buf)
+	if err != nil {
+		return nil, err
+	}
+
+	xattrs := make(map[string]string)
+	for _, name := range bytes.Split(buf[:size], []byte{0}) {
+		if len(name) == 0 {
+			continue
+		}
+		valueSize, err := unix.Getxattr(path, string(name), nil)
+		if err != nil {
+			continue
+		}
+		value := make([]byte, valueSize)
+		valueSize, err = unix.Getxattr(path, string(name), value)
+		if err != nil {
+			continue
+		}
+		xattrs[string(name)] = string(value[:valueSize])
+	}
+	return xattrs, nil
+}
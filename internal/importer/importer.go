@@ -0,0 +1,326 @@
+// Package importer 读取 asdf、nvm、pyenv、tfenv 已安装的版本和版本设置，用于迁移到 vman
+package importer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/spf13/afero"
+)
+
+// 支持导入的版本管理器
+const (
+	SourceAsdf  = "asdf"
+	SourcePyenv = "pyenv"
+	SourceNvm   = "nvm"
+	SourceTfenv = "tfenv"
+)
+
+// Sources 支持导入的版本管理器
+var Sources = []string{SourceAsdf, SourceNvm, SourcePyenv, SourceTfenv}
+
+// asdfToolNames asdf 插件名与 vman 工具名不同的插件
+var asdfToolNames = map[string]string{
+	"nodejs": "node",
+	"golang": "go",
+}
+
+// asdfBinDirs 可执行文件不在安装目录 bin 中的 asdf 插件
+var asdfBinDirs = map[string]string{
+	"golang": filepath.Join("go", "bin"),
+}
+
+// Installation 其他版本管理器安装的一个工具版本
+type Installation struct {
+	Tool    string
+	Version string
+
+	// Dir 安装目录
+	Dir string
+
+	// BinDir 包含工具可执行文件的目录，通常为 Dir/bin
+	BinDir string
+}
+
+// Binary 工具可执行文件的路径
+func (i Installation) Binary() string {
+	return filepath.Join(i.BinDir, i.Tool)
+}
+
+// Result 扫描其他版本管理器的结果
+type Result struct {
+	// Source 版本管理器
+	Source string
+
+	// Root 版本管理器的数据目录
+	Root string
+
+	// Installations 已安装的版本，按工具和版本排序
+	Installations []Installation
+
+	// GlobalVersions 版本管理器中设置的全局版本
+	GlobalVersions map[string]string
+
+	// ProjectVersions 项目目录中版本文件指定的版本，ProjectFile 为该文件
+	ProjectVersions map[string]string
+	ProjectFile     string
+
+	// Skipped 无法导入的安装目录及原因
+	Skipped map[string]string
+}
+
+// Scan 扫描版本管理器的安装目录、全局版本设置和 projectDir 中的版本文件
+// 数据目录按各工具的环境变量（ASDF_DATA_DIR、NVM_DIR、PYENV_ROOT、TFENV_CONFIG_DIR）查找，未设置时使用 homeDir 下的默认目录
+func Scan(fs afero.Fs, source, homeDir, projectDir string) (*Result, error) {
+	result := &Result{
+		Source:          source,
+		GlobalVersions:  make(map[string]string),
+		ProjectVersions: make(map[string]string),
+		Skipped:         make(map[string]string),
+	}
+
+	var err error
+	switch source {
+	case SourceAsdf:
+		err = scanAsdf(fs, result, homeDir, projectDir)
+	case SourceNvm:
+		err = scanNvm(fs, result, homeDir, projectDir)
+	case SourcePyenv:
+		err = scanSingleTool(fs, result, "python", envOr("PYENV_ROOT", filepath.Join(homeDir, ".pyenv")), "bin", ".python-version", projectDir)
+	case SourceTfenv:
+		root := envOr("TFENV_CONFIG_DIR", envOr("TFENV_ROOT", filepath.Join(homeDir, ".tfenv")))
+		err = scanSingleTool(fs, result, "terraform", root, "", ".terraform-version", projectDir)
+	default:
+		return nil, fmt.Errorf("unsupported source %q, must be one of: %s", source, strings.Join(Sources, ", "))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(result.Installations, func(i, j int) bool {
+		a, b := result.Installations[i], result.Installations[j]
+		if a.Tool != b.Tool {
+			return a.Tool < b.Tool
+		}
+		return compareVersions(a.Version, b.Version) < 0
+	})
+	return result, nil
+}
+
+// scanAsdf 扫描 asdf 的 installs/<插件>/<版本>，全局版本来自 ~/.tool-versions
+func scanAsdf(fs afero.Fs, result *Result, homeDir, projectDir string) error {
+	result.Root = envOr("ASDF_DATA_DIR", filepath.Join(homeDir, ".asdf"))
+	installs := filepath.Join(result.Root, "installs")
+	plugins, err := readDirs(fs, installs)
+	if err != nil {
+		return fmt.Errorf("no asdf installations found in %s: %w", installs, err)
+	}
+	for _, plugin := range plugins {
+		tool := AsdfTool(plugin)
+		binDir := asdfBinDirs[plugin]
+		if binDir == "" {
+			binDir = "bin"
+		}
+		versions, _ := readDirs(fs, filepath.Join(installs, plugin))
+		for _, version := range versions {
+			addInstallation(fs, result, tool, version, filepath.Join(installs, plugin, version), binDir)
+		}
+	}
+
+	filename := envOr("ASDF_DEFAULT_TOOL_VERSIONS_FILENAME", ".tool-versions")
+	if data, err := afero.ReadFile(fs, filepath.Join(homeDir, filename)); err == nil {
+		result.GlobalVersions = parseToolVersions(data)
+	}
+	if projectDir != "" {
+		path := filepath.Join(projectDir, filename)
+		if data, err := afero.ReadFile(fs, path); err == nil {
+			result.ProjectVersions = parseToolVersions(data)
+			result.ProjectFile = path
+		}
+	}
+	return nil
+}
+
+// scanNvm 扫描 nvm 的 versions/node/v<版本>，全局版本为 alias/default 指向的已安装版本
+func scanNvm(fs afero.Fs, result *Result, homeDir, projectDir string) error {
+	result.Root = envOr("NVM_DIR", filepath.Join(homeDir, ".nvm"))
+	dir := filepath.Join(result.Root, "versions", "node")
+	versions, err := readDirs(fs, dir)
+	if err != nil {
+		return fmt.Errorf("no nvm installations found in %s: %w", dir, err)
+	}
+	for _, version := range versions {
+		addInstallation(fs, result, "node", version, filepath.Join(dir, version), "bin")
+	}
+
+	if version := readVersionFile(fs, filepath.Join(result.Root, "alias", "default")); version != "" {
+		if installed := matchInstalled(result.Installations, version); installed != "" {
+			result.GlobalVersions["node"] = installed
+		}
+	}
+	if projectDir != "" {
+		path := filepath.Join(projectDir, ".nvmrc")
+		if version := readVersionFile(fs, path); version != "" {
+			result.ProjectVersions["node"] = trimV(version)
+			result.ProjectFile = path
+		}
+	}
+	return nil
+}
+
+// scanSingleTool 扫描只管理一个工具的版本管理器（pyenv、tfenv）的 versions/<版本>，
+// 全局版本来自数据目录中的 version 文件
+func scanSingleTool(fs afero.Fs, result *Result, tool, root, binDir, projectFile, projectDir string) error {
+	result.Root = root
+	dir := filepath.Join(root, "versions")
+	versions, err := readDirs(fs, dir)
+	if err != nil {
+		return fmt.Errorf("no %s installations found in %s: %w", result.Source, dir, err)
+	}
+	for _, version := range versions {
+		addInstallation(fs, result, tool, version, filepath.Join(dir, version), binDir)
+	}
+
+	if version := readVersionFile(fs, filepath.Join(root, "version")); version != "" && version != "system" {
+		result.GlobalVersions[tool] = version
+	}
+	if projectDir != "" {
+		path := filepath.Join(projectDir, projectFile)
+		if version := readVersionFile(fs, path); version != "" {
+			result.ProjectVersions[tool] = version
+			result.ProjectFile = path
+		}
+	}
+	return nil
+}
+
+// addInstallation 记录包含工具可执行文件的安装目录，版本号无法识别（如 pyenv 的虚拟环境）或缺少可执行文件时跳过
+func addInstallation(fs afero.Fs, result *Result, tool, version, dir, binDir string) {
+	version = trimV(version)
+	if _, err := semver.NewVersion(version); err != nil {
+		result.Skipped[dir] = "unrecognized version"
+		return
+	}
+	installation := Installation{Tool: tool, Version: version, Dir: dir, BinDir: filepath.Join(dir, binDir)}
+	if _, err := fs.Stat(installation.Binary()); err != nil {
+		result.Skipped[dir] = fmt.Sprintf("%s not found", filepath.Join(binDir, tool))
+		return
+	}
+	result.Installations = append(result.Installations, installation)
+}
+
+// AsdfTool asdf 插件对应的 vman 工具名
+func AsdfTool(plugin string) string {
+	if tool, ok := asdfToolNames[plugin]; ok {
+		return tool
+	}
+	return plugin
+}
+
+// AsdfPlugin vman 工具对应的 asdf 插件名
+func AsdfPlugin(tool string) string {
+	for plugin, name := range asdfToolNames {
+		if name == tool {
+			return plugin
+		}
+	}
+	return tool
+}
+
+// parseToolVersions 解析 .tool-versions，每个工具取第一个版本，跳过 system、ref:、path: 等非版本号
+func parseToolVersions(data []byte) map[string]string {
+	versions := make(map[string]string)
+	for _, line := range strings.Split(string(data), "\n") {
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 || fields[1] == "system" || strings.Contains(fields[1], ":") {
+			continue
+		}
+		versions[AsdfTool(fields[0])] = trimV(fields[1])
+	}
+	return versions
+}
+
+// readVersionFile 读取版本文件的第一个非空、非注释行
+func readVersionFile(fs afero.Fs, path string) string {
+	data, err := afero.ReadFile(fs, path)
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if fields := strings.Fields(line); len(fields) > 0 && !strings.HasPrefix(fields[0], "#") {
+			return fields[0]
+		}
+	}
+	return ""
+}
+
+// matchInstalled 按 nvm 的别名规则在已安装的 node 版本中查找：完整版本号精确匹配，
+// 20、20.11 等前缀匹配最高的版本，node、stable 为最高的版本，lts/* 等无法确定的别名返回空字符串
+func matchInstalled(installations []Installation, alias string) string {
+	alias = trimV(alias)
+	if alias == "node" || alias == "stable" {
+		alias = ""
+	} else if _, err := semver.NewVersion(alias); err != nil {
+		return ""
+	}
+
+	best := ""
+	for _, installation := range installations {
+		version := installation.Version
+		if alias != "" && version != alias && !strings.HasPrefix(version, alias+".") {
+			continue
+		}
+		if best == "" || compareVersions(version, best) > 0 {
+			best = version
+		}
+	}
+	return best
+}
+
+// readDirs 列出目录中的子目录名，不包括符号链接（如 pyenv-virtualenv 创建的虚拟环境）
+func readDirs(fs afero.Fs, dir string) ([]string, error) {
+	entries, err := afero.ReadDir(fs, dir)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() && entry.Mode()&os.ModeSymlink == 0 {
+			names = append(names, entry.Name())
+		}
+	}
+	return names, nil
+}
+
+// compareVersions 比较两个版本号，无法解析时按字符串比较
+func compareVersions(a, b string) int {
+	va, errA := semver.NewVersion(a)
+	vb, errB := semver.NewVersion(b)
+	if errA != nil || errB != nil {
+		return strings.Compare(a, b)
+	}
+	return va.Compare(vb)
+}
+
+// trimV 去掉版本号的 v 前缀
+func trimV(version string) string {
+	if len(version) > 1 && version[0] == 'v' && version[1] >= '0' && version[1] <= '9' {
+		return version[1:]
+	}
+	return version
+}
+
+// envOr 返回环境变量的值，未设置时返回 fallback
+func envOr(name, fallback string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return fallback
+}
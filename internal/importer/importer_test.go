@@ -0,0 +1,123 @@
+package importer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFiles(t *testing.T, fs afero.Fs, files map[string]string) {
+	for path, content := range files {
+		require.NoError(t, afero.WriteFile(fs, path, []byte(content), 0755))
+	}
+}
+
+func TestScanAsdf(t *testing.T) {
+	t.Setenv("ASDF_DATA_DIR", "")
+	t.Setenv("ASDF_DEFAULT_TOOL_VERSIONS_FILENAME", "")
+	fs := afero.NewMemMapFs()
+	writeFiles(t, fs, map[string]string{
+		"/home/u/.asdf/installs/nodejs/20.11.0/bin/node":    "",
+		"/home/u/.asdf/installs/nodejs/18.19.0/bin/node":    "",
+		"/home/u/.asdf/installs/golang/1.22.1/go/bin/go":    "",
+		"/home/u/.asdf/installs/terraform/1.5.7/bin/.keep":  "",
+		"/home/u/.asdf/installs/python/ref-main/bin/python": "",
+		"/home/u/.tool-versions":                            "nodejs 20.11.0 18.19.0\ngolang 1.22.1 # pinned\nterraform system\nkubectl ref:v1.29.0\n",
+		"/work/.tool-versions":                              "nodejs 18.19.0\n",
+	})
+
+	result, err := Scan(fs, SourceAsdf, "/home/u", "/work")
+	require.NoError(t, err)
+	assert.Equal(t, []Installation{
+		{Tool: "go", Version: "1.22.1", Dir: "/home/u/.asdf/installs/golang/1.22.1", BinDir: "/home/u/.asdf/installs/golang/1.22.1/go/bin"},
+		{Tool: "node", Version: "18.19.0", Dir: "/home/u/.asdf/installs/nodejs/18.19.0", BinDir: "/home/u/.asdf/installs/nodejs/18.19.0/bin"},
+		{Tool: "node", Version: "20.11.0", Dir: "/home/u/.asdf/installs/nodejs/20.11.0", BinDir: "/home/u/.asdf/installs/nodejs/20.11.0/bin"},
+	}, result.Installations)
+	assert.Equal(t, map[string]string{"node": "20.11.0", "go": "1.22.1"}, result.GlobalVersions)
+	assert.Equal(t, map[string]string{"node": "18.19.0"}, result.ProjectVersions)
+	assert.Equal(t, "/work/.tool-versions", result.ProjectFile)
+	assert.Equal(t, "bin/terraform not found", result.Skipped["/home/u/.asdf/installs/terraform/1.5.7"])
+	assert.Equal(t, "unrecognized version", result.Skipped["/home/u/.asdf/installs/python/ref-main"])
+}
+
+func TestScanNvmPyenvTfenv(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	writeFiles(t, fs, map[string]string{
+		"/nvm/versions/node/v20.10.0/bin/node":      "",
+		"/nvm/versions/node/v20.11.1/bin/node":      "",
+		"/nvm/versions/node/v21.0.0/bin/node":       "",
+		"/nvm/alias/default":                        "20\n",
+		"/home/u/.pyenv/versions/3.12.1/bin/python": "",
+		"/home/u/.pyenv/version":                    "3.12.1\n3.11.7\n",
+		"/home/u/.tfenv/versions/1.7.0/terraform":   "",
+		"/home/u/.tfenv/version":                    "1.7.0\n",
+		"/work/.nvmrc":                              "v21.0.0\n",
+		"/work/.terraform-version":                  "1.7.0\n",
+	})
+	t.Setenv("NVM_DIR", "/nvm")
+	t.Setenv("PYENV_ROOT", "")
+	t.Setenv("TFENV_CONFIG_DIR", "")
+	t.Setenv("TFENV_ROOT", "")
+
+	result, err := Scan(fs, SourceNvm, "/home/u", "/work")
+	require.NoError(t, err)
+	require.Len(t, result.Installations, 3)
+	assert.Equal(t, "20.10.0", result.Installations[0].Version)
+	assert.Equal(t, map[string]string{"node": "20.11.1"}, result.GlobalVersions, "alias/default 的 20 匹配最高的 20.x")
+	assert.Equal(t, map[string]string{"node": "21.0.0"}, result.ProjectVersions)
+
+	result, err = Scan(fs, SourcePyenv, "/home/u", "")
+	require.NoError(t, err)
+	assert.Equal(t, "/home/u/.pyenv/versions/3.12.1/bin/python", result.Installations[0].Binary())
+	assert.Equal(t, map[string]string{"python": "3.12.1"}, result.GlobalVersions)
+	assert.Empty(t, result.ProjectVersions)
+
+	result, err = Scan(fs, SourceTfenv, "/home/u", "/work")
+	require.NoError(t, err)
+	assert.Equal(t, "/home/u/.tfenv/versions/1.7.0/terraform", result.Installations[0].Binary())
+	assert.Equal(t, map[string]string{"terraform": "1.7.0"}, result.ProjectVersions)
+
+	_, err = Scan(fs, "rbenv", "/home/u", "")
+	assert.ErrorContains(t, err, "unsupported source")
+	_, err = Scan(afero.NewMemMapFs(), SourceNvm, "/home/u", "")
+	assert.ErrorContains(t, err, "no nvm installations found")
+}
+
+func TestMatchInstalled(t *testing.T) {
+	installations := []Installation{{Version: "18.19.0"}, {Version: "20.1.0"}, {Version: "20.11.1"}}
+	assert.Equal(t, "20.11.1", matchInstalled(installations, "v20"))
+	assert.Equal(t, "20.1.0", matchInstalled(installations, "20.1.0"))
+	assert.Equal(t, "20.11.1", matchInstalled(installations, "node"))
+	assert.Empty(t, matchInstalled(installations, "lts/iron"))
+	assert.Empty(t, matchInstalled(installations, "19"))
+}
+
+func TestLink(t *testing.T) {
+	root := t.TempDir()
+	node := filepath.Join(root, "nodejs", "20.11.0")
+	require.NoError(t, os.MkdirAll(filepath.Join(node, "bin"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(node, "lib"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(node, "bin", "node"), []byte("#!/bin/sh\n"), 0755))
+
+	versionDir := filepath.Join(root, "vman", "node", "20.11.0")
+	require.NoError(t, Link(Installation{Tool: "node", Dir: node, BinDir: filepath.Join(node, "bin")}, versionDir))
+	target, err := os.Readlink(filepath.Join(versionDir, "lib"))
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(node, "lib"), target)
+	assert.FileExists(t, filepath.Join(versionDir, "bin", "node"))
+	info, err := os.Lstat(versionDir)
+	require.NoError(t, err)
+	assert.True(t, info.IsDir(), "版本目录本身不是链接，元数据不会写入原安装目录")
+
+	// 可执行文件不在 bin 中时链接到版本目录的 bin
+	tf := filepath.Join(root, "tfenv", "1.7.0")
+	require.NoError(t, os.MkdirAll(tf, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(tf, "terraform"), []byte("#!/bin/sh\n"), 0755))
+	versionDir = filepath.Join(root, "vman", "terraform", "1.7.0")
+	require.NoError(t, Link(Installation{Tool: "terraform", Dir: tf, BinDir: tf}, versionDir))
+	assert.FileExists(t, filepath.Join(versionDir, "bin", "terraform"))
+}
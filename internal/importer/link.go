@@ -0,0 +1,31 @@
+package importer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Link 在 versionDir 中创建指向安装目录的符号链接，复用已下载的文件而不复制：
+// 可执行文件位于安装目录的 bin 中时链接安装目录的每一项，否则在 versionDir/bin 中链接可执行文件所在目录的每一项。
+// versionDir 本身是普通目录，vman 的版本元数据写入其中而不是其他版本管理器的安装目录
+func Link(installation Installation, versionDir string) error {
+	source, target := installation.Dir, versionDir
+	if installation.BinDir != filepath.Join(installation.Dir, "bin") {
+		source, target = installation.BinDir, filepath.Join(versionDir, "bin")
+	}
+
+	entries, err := os.ReadDir(source)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", source, err)
+	}
+	if err := os.MkdirAll(target, 0755); err != nil {
+		return fmt.Errorf("failed to create version directory: %w", err)
+	}
+	for _, entry := range entries {
+		if err := os.Symlink(filepath.Join(source, entry.Name()), filepath.Join(target, entry.Name())); err != nil {
+			return fmt.Errorf("failed to link %s: %w", entry.Name(), err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+// Package nix 将vman的工具版本映射为 nixpkgs 中的包，生成 Nix flake 的 devShell
+package nix
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// Mapping vman工具在 nixpkgs 中对应的包
+type Mapping struct {
+	// Attribute nixpkgs 中的属性名，可以包含 {major}、{minor} 占位符，
+	// 如 go_{major}_{minor}，nixpkgs 只为部分工具提供按版本区分的属性
+	Attribute string
+	// Unfree 包的许可证不是自由软件许可证（如 BSL），需要 allowUnfree
+	Unfree bool
+}
+
+// mappings vman工具名到 nixpkgs 包的映射
+var mappings = map[string]Mapping{
+	"argocd":        {Attribute: "argocd"},
+	"awscli":        {Attribute: "awscli2"},
+	"bun":           {Attribute: "bun"},
+	"cmake":         {Attribute: "cmake"},
+	"consul":        {Attribute: "consul", Unfree: true},
+	"deno":          {Attribute: "deno"},
+	"eksctl":        {Attribute: "eksctl"},
+	"flux":          {Attribute: "fluxcd"},
+	"gh":            {Attribute: "gh"},
+	"go":            {Attribute: "go_{major}_{minor}"},
+	"golangci-lint": {Attribute: "golangci-lint"},
+	"helm":          {Attribute: "kubernetes-helm"},
+	"hugo":          {Attribute: "hugo"},
+	"istioctl":      {Attribute: "istioctl"},
+	"java":          {Attribute: "jdk{major}"},
+	"jq":            {Attribute: "jq"},
+	"k9s":           {Attribute: "k9s"},
+	"kind":          {Attribute: "kind"},
+	"kubectl":       {Attribute: "kubectl"},
+	"kubectx":       {Attribute: "kubectx"},
+	"kustomize":     {Attribute: "kustomize"},
+	"minikube":      {Attribute: "minikube"},
+	"node":          {Attribute: "nodejs_{major}"},
+	"nodejs":        {Attribute: "nodejs_{major}"},
+	"nomad":         {Attribute: "nomad", Unfree: true},
+	"opentofu":      {Attribute: "opentofu"},
+	"packer":        {Attribute: "packer", Unfree: true},
+	"protoc":        {Attribute: "protobuf"},
+	"pulumi":        {Attribute: "pulumi"},
+	"python":        {Attribute: "python{major}{minor}"},
+	"ruby":          {Attribute: "ruby_{major}_{minor}"},
+	"rust":          {Attribute: "rustc"},
+	"shellcheck":    {Attribute: "shellcheck"},
+	"skaffold":      {Attribute: "skaffold"},
+	"sops":          {Attribute: "sops"},
+	"stern":         {Attribute: "stern"},
+	"terraform":     {Attribute: "terraform", Unfree: true},
+	"terragrunt":    {Attribute: "terragrunt"},
+	"tflint":        {Attribute: "tflint"},
+	"vault":         {Attribute: "vault", Unfree: true},
+	"yq":            {Attribute: "yq-go"},
+	"zig":           {Attribute: "zig"},
+}
+
+// Tool 要映射的工具版本
+type Tool struct {
+	Name    string
+	Version string
+}
+
+// Package 映射到的 nixpkgs 包
+type Package struct {
+	Tool
+	Attribute string
+	Unfree    bool
+	// Pinned 属性名中固定的版本，如 go_1_22 为 1.22；属性名不含版本时为空，使用 nixpkgs 中的版本
+	Pinned string
+}
+
+// Result 映射结果
+type Result struct {
+	Packages []Package
+	// Unmapped nixpkgs 中没有对应包或版本无法解析的工具
+	Unmapped []Tool
+}
+
+// Map 将工具版本映射为 nixpkgs 中的包
+func Map(tools []Tool) *Result {
+	result := &Result{}
+	for _, tool := range tools {
+		mapping, ok := mappings[strings.ToLower(tool.Name)]
+		if !ok {
+			result.Unmapped = append(result.Unmapped, tool)
+			continue
+		}
+
+		pkg := Package{Tool: tool, Attribute: mapping.Attribute, Unfree: mapping.Unfree}
+		if strings.Contains(mapping.Attribute, "{") {
+			version, err := semver.NewVersion(tool.Version)
+			if err != nil {
+				result.Unmapped = append(result.Unmapped, tool)
+				continue
+			}
+			major, minor := fmt.Sprint(version.Major()), fmt.Sprint(version.Minor())
+			pkg.Attribute = strings.NewReplacer("{major}", major, "{minor}", minor).Replace(mapping.Attribute)
+			pkg.Pinned = major
+			if strings.Contains(mapping.Attribute, "{minor}") {
+				pkg.Pinned += "." + minor
+			}
+		}
+		result.Packages = append(result.Packages, pkg)
+	}
+	return result
+}
+
+// GenerateFlake 生成包含 devShell 的 flake.nix，env 为devShell中设置的环境变量
+func GenerateFlake(description string, result *Result, env map[string]string) string {
+	unfree := false
+	for _, pkg := range result.Packages {
+		unfree = unfree || pkg.Unfree
+	}
+
+	var b strings.Builder
+	b.WriteString("# Generated by vman export, regenerate with vman export --format nix or vman lock\n")
+	b.WriteString("{\n")
+	fmt.Fprintf(&b, "  description = %s;\n\n", quote(description))
+	b.WriteString("  inputs.nixpkgs.url = \"github:NixOS/nixpkgs/nixos-unstable\";\n\n")
+	b.WriteString("  outputs = { self, nixpkgs }:\n")
+	b.WriteString("    let\n")
+	b.WriteString("      forAllSystems = nixpkgs.lib.genAttrs [ \"x86_64-linux\" \"aarch64-linux\" \"x86_64-darwin\" \"aarch64-darwin\" ];\n")
+	b.WriteString("    in\n")
+	b.WriteString("    {\n")
+	b.WriteString("      devShells = forAllSystems (system:\n")
+	b.WriteString("        let\n")
+	if unfree {
+		b.WriteString("          pkgs = import nixpkgs { inherit system; config.allowUnfree = true; };\n")
+	} else {
+		b.WriteString("          pkgs = nixpkgs.legacyPackages.${system};\n")
+	}
+	b.WriteString("        in\n")
+	b.WriteString("        {\n")
+	b.WriteString("          default = pkgs.mkShell {\n")
+	b.WriteString("            packages = [\n")
+	for _, pkg := range result.Packages {
+		note := "nixpkgs version may differ"
+		if pkg.Pinned != "" {
+			note = "pinned to " + pkg.Pinned
+		}
+		fmt.Fprintf(&b, "              pkgs.%s # %s %s, %s\n", pkg.Attribute, pkg.Name, pkg.Version, note)
+	}
+	b.WriteString("            ];\n")
+	for _, tool := range result.Unmapped {
+		fmt.Fprintf(&b, "            # %s %s has no nixpkgs mapping\n", tool.Name, tool.Version)
+	}
+
+	keys := make([]string, 0, len(env))
+	for key := range env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Fprintf(&b, "            %s = %s;\n", key, quote(env[key]))
+	}
+	b.WriteString("          };\n")
+	b.WriteString("        });\n")
+	b.WriteString("    };\n")
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// quote 生成Nix字符串字面量
+func quote(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "${", `\${`, "\n", `\n`).Replace(value) + `"`
+}
@@ -0,0 +1,64 @@
+package nix
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMap(t *testing.T) {
+	result := Map([]Tool{
+		{Name: "go", Version: "1.22.1"},
+		{Name: "nodejs", Version: "20.11.0"},
+		{Name: "python", Version: "3.12.2"},
+		{Name: "kubectl", Version: "1.29.0"},
+		{Name: "terraform", Version: "1.6.0"},
+		{Name: "internal-cli", Version: "2.0.0"},
+		{Name: "java", Version: "latest"},
+	})
+
+	attributes := make(map[string]string)
+	pinned := make(map[string]string)
+	for _, pkg := range result.Packages {
+		attributes[pkg.Name] = pkg.Attribute
+		pinned[pkg.Name] = pkg.Pinned
+	}
+	assert.Equal(t, map[string]string{
+		"go":        "go_1_22",
+		"nodejs":    "nodejs_20",
+		"python":    "python312",
+		"kubectl":   "kubectl",
+		"terraform": "terraform",
+	}, attributes)
+	assert.Equal(t, "1.22", pinned["go"])
+	assert.Equal(t, "20", pinned["nodejs"])
+	assert.Empty(t, pinned["kubectl"])
+	assert.Equal(t, []Tool{{Name: "internal-cli", Version: "2.0.0"}, {Name: "java", Version: "latest"}}, result.Unmapped)
+}
+
+func TestGenerateFlake(t *testing.T) {
+	result := Map([]Tool{{Name: "go", Version: "1.22.1"}, {Name: "terraform", Version: "1.6.0"}, {Name: "internal-cli", Version: "2.0.0"}})
+	flake := GenerateFlake(`app "dev" shell`, result, map[string]string{"TF_DATA_DIR": "${HOME}/.tf"})
+
+	assert.Contains(t, flake, `description = "app \"dev\" shell";`)
+	assert.Contains(t, flake, "pkgs.go_1_22 # go 1.22.1, pinned to 1.22")
+	assert.Contains(t, flake, "pkgs.terraform # terraform 1.6.0, nixpkgs version may differ")
+	assert.Contains(t, flake, "config.allowUnfree = true")
+	assert.Contains(t, flake, "# internal-cli 2.0.0 has no nixpkgs mapping")
+	assert.Contains(t, flake, `TF_DATA_DIR = "\${HOME}/.tf";`)
+
+	free := GenerateFlake("app", Map([]Tool{{Name: "go", Version: "1.22.1"}}), nil)
+	assert.Contains(t, free, "nixpkgs.legacyPackages.${system}")
+
+	if _, err := exec.LookPath("nix-instantiate"); err != nil {
+		t.Skip("nix-instantiate is not installed")
+	}
+	file := filepath.Join(t.TempDir(), "flake.nix")
+	require.NoError(t, os.WriteFile(file, []byte(flake), 0644))
+	output, err := exec.Command("nix-instantiate", "--parse", file).CombinedOutput()
+	assert.NoError(t, err, string(output))
+}
@@ -0,0 +1,161 @@
+package proxy
+
+import (
+	"fmt"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/songzhibin97/vman/internal/config"
+	"github.com/songzhibin97/vman/internal/version"
+)
+
+const (
+	// ShimKindTool 工具垫片
+	ShimKindTool = "tool"
+
+	// ShimKindAlias 命令别名垫片
+	ShimKindAlias = "alias"
+)
+
+// ShimEntry 需要生成的垫片
+type ShimEntry struct {
+	Name   string `json:"name"`   // 垫片文件名，即命令名称
+	Kind   string `json:"kind"`   // tool 或 alias
+	Target string `json:"target"` // 工具名，或别名指向的 tool[@version]
+}
+
+// String 返回垫片来源的描述
+func (e ShimEntry) String() string {
+	if e.Kind == ShimKindAlias {
+		return fmt.Sprintf("alias %s -> %s", e.Name, e.Target)
+	}
+	if e.Name != e.Target {
+		return fmt.Sprintf("tool %s (shim %s)", e.Target, e.Name)
+	}
+	return "tool " + e.Target
+}
+
+// sameSource 是否为同一工具或同名别名的垫片
+func (e ShimEntry) sameSource(other ShimEntry) bool {
+	if e.Kind != other.Kind {
+		return false
+	}
+	if e.Kind == ShimKindAlias {
+		return e.Name == other.Name
+	}
+	return e.Target == other.Target
+}
+
+// ShimCollision 同名的垫片
+type ShimCollision struct {
+	Name     string      `json:"name"`
+	Entries  []ShimEntry `json:"entries"`
+	Reserved bool        `json:"reserved"` // 使用了保留名称（如 vman 本身）
+}
+
+// String 返回冲突的描述
+func (c ShimCollision) String() string {
+	var owners []string
+	for _, entry := range c.Entries {
+		owners = append(owners, entry.String())
+	}
+	if c.Reserved {
+		owners = append(owners, "reserved name "+c.Name)
+	}
+	return fmt.Sprintf("%s: %s", c.Name, strings.Join(owners, ", "))
+}
+
+// ShimCollisionError 垫片名称冲突，冲突的垫片不会生成
+type ShimCollisionError struct {
+	Collisions []ShimCollision
+}
+
+// Error 实现error接口
+func (e *ShimCollisionError) Error() string {
+	var lines []string
+	for _, collision := range e.Collisions {
+		lines = append(lines, collision.String())
+	}
+	return fmt.Sprintf("shim name collision, set `shim = \"<name>\"` in the tool definition or rename the alias: %s",
+		strings.Join(lines, "; "))
+}
+
+// CollectShimEntries 收集已安装工具和命令别名对应的垫片
+func CollectShimEntries(configManager config.Manager, versionManager version.Manager) ([]ShimEntry, error) {
+	tools, err := versionManager.ListAllTools()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tools: %w", err)
+	}
+
+	entries := make([]ShimEntry, 0, len(tools))
+	for _, tool := range tools {
+		entries = append(entries, ShimEntry{Name: ShimNameFor(configManager, tool), Kind: ShimKindTool, Target: tool})
+	}
+
+	if globalConfig, err := configManager.LoadGlobal(); err == nil {
+		names := make([]string, 0, len(globalConfig.Aliases))
+		for name := range globalConfig.Aliases {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			entries = append(entries, ShimEntry{Name: name, Kind: ShimKindAlias, Target: globalConfig.Aliases[name].Target()})
+		}
+	}
+
+	return entries, nil
+}
+
+// ShimNameFor 返回工具的垫片名称，工具定义中未改名时与工具名相同
+func ShimNameFor(configManager config.Manager, tool string) string {
+	if metadata, err := configManager.LoadToolConfig(tool); err == nil && metadata.Shim != "" {
+		return metadata.Shim
+	}
+	return tool
+}
+
+// DetectShimCollisions 检查同名的垫片，以及使用保留名称的垫片
+func DetectShimCollisions(entries []ShimEntry, reserved []string) []ShimCollision {
+	reservedKeys := make(map[string]bool, len(reserved))
+	for _, name := range reserved {
+		reservedKeys[shimKey(name)] = true
+	}
+
+	groups := make(map[string][]ShimEntry)
+	var keys []string
+	for _, entry := range entries {
+		key := shimKey(entry.Name)
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], entry)
+	}
+
+	var collisions []ShimCollision
+	for _, key := range keys {
+		group := groups[key]
+		if len(group) < 2 && !reservedKeys[key] {
+			continue
+		}
+		collisions = append(collisions, ShimCollision{
+			Name:     group[0].Name,
+			Entries:  group,
+			Reserved: reservedKeys[key],
+		})
+	}
+
+	sort.Slice(collisions, func(i, j int) bool {
+		return collisions[i].Name < collisions[j].Name
+	})
+	return collisions
+}
+
+// shimKey 垫片名称比较用的键
+// Windows 和 macOS 的默认文件系统不区分大小写，仅大小写不同的垫片同样会互相覆盖
+func shimKey(name string) string {
+	if runtime.GOOS == "windows" || runtime.GOOS == "darwin" {
+		return strings.ToLower(name)
+	}
+	return name
+}
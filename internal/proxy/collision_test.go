@@ -0,0 +1,96 @@
+package proxy
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/songzhibin97/vman/internal/config"
+	"github.com/songzhibin97/vman/internal/storage"
+	"github.com/songzhibin97/vman/internal/version"
+	"github.com/songzhibin97/vman/pkg/types"
+)
+
+func TestDetectShimCollisions(t *testing.T) {
+	entries := []ShimEntry{
+		{Name: "kubectl", Kind: ShimKindTool, Target: "kubectl"},
+		{Name: "kubectl", Kind: ShimKindAlias, Target: "kubectl@1.27"},
+		{Name: "k", Kind: ShimKindAlias, Target: "kubectl"},
+		{Name: "list", Kind: ShimKindTool, Target: "list"},
+		{Name: "tf", Kind: ShimKindTool, Target: "terraform"},
+	}
+
+	collisions := DetectShimCollisions(entries, []string{"vman", "list", "install"})
+	require.Len(t, collisions, 2)
+
+	assert.Equal(t, "kubectl", collisions[0].Name)
+	assert.False(t, collisions[0].Reserved)
+	assert.Len(t, collisions[0].Entries, 2)
+
+	assert.Equal(t, "list", collisions[1].Name)
+	assert.True(t, collisions[1].Reserved)
+	assert.Equal(t, "list: tool list, reserved name list", collisions[1].String())
+
+	err := &ShimCollisionError{Collisions: collisions}
+	assert.Contains(t, err.Error(), "kubectl: tool kubectl, alias kubectl -> kubectl@1.27")
+	assert.Contains(t, err.Error(), "shim = ")
+
+	assert.Empty(t, DetectShimCollisions(entries[2:3], nil))
+}
+
+func TestCommandProxy_ShimCollisions(t *testing.T) {
+	homeDir := t.TempDir()
+	t.Setenv("HOME", homeDir)
+	t.Setenv(types.EnvVmanHome, "")
+	t.Setenv(types.EnvVmanXDG, "")
+
+	paths := types.DefaultConfigPaths(homeDir)
+	configManager, err := config.NewManager(homeDir)
+	require.NoError(t, err)
+	require.NoError(t, configManager.Initialize())
+
+	fs := afero.NewOsFs()
+	for _, tool := range []string{"kubectl", "list"} {
+		binary := filepath.Join(paths.ToolVersionDir(tool, "1.0.0"), "bin", tool)
+		require.NoError(t, fs.MkdirAll(filepath.Dir(binary), 0755))
+		require.NoError(t, afero.WriteFile(fs, binary, []byte("#!/bin/sh\n"), 0755))
+	}
+
+	versionManager := version.NewManager(storage.NewFilesystemManager(paths), configManager)
+	cp := NewCommandProxyWithFs(fs, configManager, versionManager)
+	cp.SetReservedNames([]string{"vman", "list"})
+
+	// 使用保留名称的工具
+	var collisionErr *ShimCollisionError
+	err = cp.CheckShimName(ShimEntry{Name: "list", Kind: ShimKindTool, Target: "list"})
+	require.True(t, errors.As(err, &collisionErr))
+	assert.True(t, collisionErr.Collisions[0].Reserved)
+
+	// 与已安装工具同名的别名
+	err = cp.CheckShimName(ShimEntry{Name: "kubectl", Kind: ShimKindAlias, Target: "kubectl@1.0.0"})
+	assert.ErrorContains(t, err, "tool kubectl")
+	assert.NoError(t, cp.CheckShimName(ShimEntry{Name: "k", Kind: ShimKindAlias, Target: "kubectl"}))
+
+	// 重新生成时跳过冲突的垫片
+	err = cp.RehashShims()
+	require.True(t, errors.As(err, &collisionErr))
+	exists, _ := afero.Exists(fs, filepath.Join(paths.ShimsDir, "list"))
+	assert.False(t, exists)
+	exists, _ = afero.Exists(fs, filepath.Join(paths.ShimsDir, "kubectl"))
+	assert.True(t, exists)
+
+	// 在工具定义中为垫片改名
+	toolConfig := "name = \"list\"\nshim = \"list-cli\"\n"
+	require.NoError(t, afero.WriteFile(fs, filepath.Join(paths.ToolsDir, "list.toml"), []byte(toolConfig), 0644))
+	assert.Equal(t, "list-cli", ShimNameFor(configManager, "list"))
+	assert.NoError(t, cp.CheckShimName(ShimEntry{Name: "list-cli", Kind: ShimKindTool, Target: "list"}))
+
+	require.NoError(t, cp.RehashShims())
+	exists, _ = afero.Exists(fs, filepath.Join(paths.ShimsDir, "list-cli"))
+	assert.True(t, exists)
+	assert.Equal(t, filepath.Join(paths.ShimsDir, "list-cli"), cp.GetShimPath("list"))
+}
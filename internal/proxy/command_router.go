@@ -8,11 +8,13 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
-	"syscall"
 	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/afero"
+
+	"github.com/songzhibin97/vman/internal/storage"
+	"github.com/songzhibin97/vman/pkg/types"
 )
 
 // CommandRouter 命令路由器接口
@@ -20,6 +22,9 @@ type CommandRouter interface {
 	// RouteCommand 路由命令到正确的版本
 	RouteCommand(ctx context.Context, toolName string, args []string) (*RouteResult, error)
 
+	// RouteCommandWithVersion 路由命令到指定的版本或版本约束，不读取项目和全局配置
+	RouteCommandWithVersion(ctx context.Context, toolName, version string, args []string) (*RouteResult, error)
+
 	// ExecuteCommand 执行路由后的命令
 	ExecuteCommand(ctx context.Context, result *RouteResult) error
 
@@ -51,6 +56,13 @@ type RouteResult struct {
 	Env            map[string]string `json:"env,omitempty"`
 	WorkDir        string            `json:"work_dir,omitempty"`
 	Context        *RouteContext     `json:"context,omitempty"`
+
+	// EnvPolicy 执行时对完整环境变量（包括 Env）应用的过滤策略
+	EnvPolicy types.EnvPolicy `json:"-"`
+	// Limits 执行时的超时、内存和优先级限制
+	Limits types.ExecLimits `json:"-"`
+	// Sandbox 不为 nil 时在沙箱中执行
+	Sandbox *SandboxOptions `json:"-"`
 }
 
 // RouteContext 路由上下文
@@ -90,6 +102,7 @@ type DefaultCommandRouter struct {
 	contextManager ContextManager
 	pathManager    PathManager
 	commands       map[string]*CommandInfo // 命令注册表
+	monitor        *DefaultPerformanceMonitor
 }
 
 // NewCommandRouter 创建新的命令路由器
@@ -106,9 +119,16 @@ func NewCommandRouterWithFs(fs afero.Fs, versionManager VersionResolver, context
 		contextManager: contextManager,
 		pathManager:    pathManager,
 		commands:       make(map[string]*CommandInfo),
+		monitor:        NewPersistentPerformanceMonitor(fs, defaultStatsPath()),
 	}
 }
 
+// defaultStatsPath 默认的执行统计文件
+func defaultStatsPath() string {
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(types.DefaultConfigPaths(homeDir).CacheDir, StatsFile)
+}
+
 // RouteCommand 路由命令到正确的版本
 func (cr *DefaultCommandRouter) RouteCommand(ctx context.Context, toolName string, args []string) (*RouteResult, error) {
 	startTime := time.Now()
@@ -120,31 +140,81 @@ func (cr *DefaultCommandRouter) RouteCommand(ctx context.Context, toolName strin
 		return nil, fmt.Errorf("failed to get working directory: %w", err)
 	}
 
-	// 解析版本
-	versionResolution, err := cr.versionManager.ResolveVersion(ctx, toolName, workDir)
+	// 解析版本，嵌套调用时沿用父进程的解析结果
+	versionResolution, err := cr.resolveNested(ctx, toolName, workDir)
 	if err != nil {
 		return nil, fmt.Errorf("failed to resolve version for %s: %w", toolName, err)
 	}
 
+	return cr.buildRoute(toolName, versionResolution, args, workDir, startTime)
+}
+
+// RouteCommandWithVersion 路由命令到指定的版本或版本约束，不读取项目和全局配置
+func (cr *DefaultCommandRouter) RouteCommandWithVersion(ctx context.Context, toolName, version string, args []string) (*RouteResult, error) {
+	startTime := time.Now()
+	cr.logger.Debugf("Routing command: %s@%s %v", toolName, version, args)
+
+	workDir, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get working directory: %w", err)
+	}
+
+	// 精确版本未安装时按约束匹配已安装的版本，如 1.27 匹配 1.27.x 中最高的版本
+	resolvedVersion := version
+	if version != types.SystemVersion && !cr.versionManager.IsVersionInstalled(toolName, version) {
+		resolvedVersion, err = cr.versionManager.ResolveConstraint(toolName, version)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve version %s for %s: %w", version, toolName, err)
+		}
+	}
+
+	versionResolution := &VersionResolution{
+		ToolName:         toolName,
+		RequestedVersion: version,
+		Version:          resolvedVersion,
+		Source:           "alias",
+		ProjectPath:      workDir,
+		ResolvedAt:       time.Now(),
+	}
+
+	return cr.buildRoute(toolName, versionResolution, args, workDir, startTime)
+}
+
+// buildRoute 根据版本解析结果查找可执行文件并生成路由结果
+func (cr *DefaultCommandRouter) buildRoute(toolName string, versionResolution *VersionResolution, args []string, workDir string, startTime time.Time) (*RouteResult, error) {
+	var err error
+
 	// 检查版本是否已安装
 	if !cr.versionManager.IsVersionInstalled(toolName, versionResolution.Version) {
 		return nil, fmt.Errorf("version %s for %s is not installed. Please install it first using 'vman install %s %s'", 
 			versionResolution.Version, toolName, toolName, versionResolution.Version)
 	}
 
-	// 查找可执行文件
-	execPath, err := cr.FindExecutable(toolName, versionResolution.Version)
-	if err != nil {
-		return nil, fmt.Errorf("failed to find executable for %s@%s: %w", toolName, versionResolution.Version, err)
-	}
+	var execPath string
+	if versionResolution.Version == types.SystemVersion {
+		// 系统版本直接执行PATH中第一个非shim的同名工具
+		execPath, err = cr.versionManager.FindSystemExecutable(toolName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find system executable for %s: %w", toolName, err)
+		}
+	} else {
+		// 查找可执行文件
+		execPath, err = cr.FindExecutable(toolName, versionResolution.Version)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find executable for %s@%s: %w", toolName, versionResolution.Version, err)
+		}
 
-	// 验证可执行文件
-	if err := cr.ValidateCommand(execPath); err != nil {
-		return nil, fmt.Errorf("invalid executable %s: %w", execPath, err)
+		// 验证可执行文件
+		if err := cr.ValidateCommand(execPath); err != nil {
+			return nil, fmt.Errorf("invalid executable %s: %w", execPath, err)
+		}
+
+		// 记录版本使用时间
+		cr.recordUsage(toolName, versionResolution.Version)
 	}
 
 	// 获取环境变量
-	env := cr.buildEnvironment(toolName, versionResolution.Version, workDir)
+	env := cr.buildEnvironment(toolName, versionResolution, workDir)
 
 	// 创建路由结果
 	result := &RouteResult{
@@ -170,8 +240,22 @@ func (cr *DefaultCommandRouter) RouteCommand(ctx context.Context, toolName strin
 func (cr *DefaultCommandRouter) ExecuteCommand(ctx context.Context, result *RouteResult) error {
 	cr.logger.Debugf("Executing command: %s %v", result.ExecutablePath, result.Args)
 
-	// 创建命令
-	cmd := exec.CommandContext(ctx, result.ExecutablePath, result.Args...)
+	// 启用了沙箱的工具通过沙箱程序启动
+	execPath, args := result.ExecutablePath, result.Args
+	if result.Sandbox != nil {
+		projectPath := result.Env[types.EnvVmanProjectPath]
+		if projectPath == "" {
+			projectPath = result.WorkDir
+		}
+		var err error
+		if execPath, args, err = result.Sandbox.Wrap(execPath, args, projectPath); err != nil {
+			return err
+		}
+	}
+
+	// 创建命令，设置了超时限制时超时后终止
+	cmd, execCtx, cancel := LimitedCommand(ctx, result.Limits, execPath, args...)
+	defer cancel()
 
 	// 设置工作目录
 	if result.WorkDir != "" {
@@ -183,6 +267,7 @@ func (cr *DefaultCommandRouter) ExecuteCommand(ctx context.Context, result *Rout
 	for key, value := range result.Env {
 		cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", key, value))
 	}
+	cmd.Env = result.EnvPolicy.Apply(cmd.Env)
 
 	// 连接标准输入输出
 	cmd.Stdin = os.Stdin
@@ -190,17 +275,23 @@ func (cr *DefaultCommandRouter) ExecuteCommand(ctx context.Context, result *Rout
 	cmd.Stderr = os.Stderr
 
 	// 执行命令
+	var session *TrackingSession
+	if result.ToolName != "" && cr.monitor != nil {
+		session = cr.monitor.StartTracking(ctx, result.ToolName, result.Version)
+	}
 	startTime := time.Now()
-	err := cmd.Run()
+	err := cmd.Start()
+	if err == nil {
+		cr.applyProcessLimits(cmd.Process.Pid, result.Limits)
+		err = cmd.Wait()
+	}
 	duration := time.Since(startTime)
 
 	// 记录执行信息
 	var exitCode int
 	if err != nil {
 		if exitError, ok := err.(*exec.ExitError); ok {
-			if status, ok := exitError.Sys().(syscall.WaitStatus); ok {
-				exitCode = status.ExitStatus()
-			}
+			exitCode = ExitStatus(exitError)
 		}
 		cr.logger.Debugf("Command execution failed: %v (exit code: %d, duration: %v)", err, exitCode, duration)
 	} else {
@@ -209,8 +300,13 @@ func (cr *DefaultCommandRouter) ExecuteCommand(ctx context.Context, result *Rout
 
 	// 更新命令使用统计
 	cr.updateCommandStats(result.ToolName, err == nil)
+	if session != nil {
+		session.ExitCode = exitCode
+		session.Failed = err != nil
+		cr.monitor.EndTracking(session)
+	}
 
-	return err
+	return LimitError(execCtx, result.ToolName, result.Limits, err)
 }
 
 // InterceptCommand 拦截并执行命令（组合路由和执行）
@@ -330,10 +426,11 @@ func (cr *DefaultCommandRouter) UnregisterCommand(toolName string) error {
 }
 
 // buildEnvironment 构建执行环境变量
-func (cr *DefaultCommandRouter) buildEnvironment(toolName, version, workDir string) map[string]string {
-	env := make(map[string]string)
+func (cr *DefaultCommandRouter) buildEnvironment(toolName string, resolution *VersionResolution, workDir string) map[string]string {
+	env := NestedEnvironment(resolution, workDir)
 
 	// 添加工具特定的环境变量
+	version := resolution.Version
 	env[fmt.Sprintf("%s_VERSION", strings.ToUpper(toolName))] = version
 	env["VMAN_TOOL"] = toolName
 	env["VMAN_VERSION"] = version
@@ -368,6 +465,19 @@ func (cr *DefaultCommandRouter) updateCommandStats(toolName string, success bool
 	cr.logger.Debugf("Updated stats for %s: usage=%d, last_used=%v", toolName, info.UsageCount, info.LastUsed)
 }
 
+// recordUsage 记录版本的最后使用时间，失败时不影响命令执行
+func (cr *DefaultCommandRouter) recordUsage(toolName, version string) {
+	versionPath, err := cr.versionManager.GetVersionPath(toolName, version)
+	if err != nil {
+		cr.logger.Debugf("Failed to get version path for usage tracking: %v", err)
+		return
+	}
+
+	if err := storage.MarkUsed(cr.fs, versionPath, time.Now()); err != nil {
+		cr.logger.Debugf("Failed to record usage for %s@%s: %v", toolName, version, err)
+	}
+}
+
 // fileExists 检查文件是否存在
 func (cr *DefaultCommandRouter) fileExists(path string) bool {
 	_, err := cr.fs.Stat(path)
@@ -0,0 +1,189 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+// completionMarker vman completion install 写入shell配置文件的配置段标记
+const completionMarker = "# vman completion"
+
+// CompletionCacheDir 缓存目录下保存各版本工具补全脚本的目录名
+const CompletionCacheDir = "completions"
+
+// completionTimeout 运行工具生成补全脚本的超时时间
+const completionTimeout = 10 * time.Second
+
+// CompletionShells 支持补全桥接的shell
+var CompletionShells = []string{"bash", "zsh", "fish"}
+
+// BridgedCommand 通过补全桥接加载自身补全脚本的命令
+type BridgedCommand struct {
+	// Command 命令名（垫片名）
+	Command string
+
+	// Tool 工具名
+	Tool string
+}
+
+// CompletionSnippet 生成在shell配置文件中加载 vman 补全和工具补全桥接的片段
+func CompletionSnippet(shellType string) (string, error) {
+	switch shellType {
+	case "bash", "zsh":
+		return fmt.Sprintf("if command -v vman >/dev/null 2>&1; then\n    eval \"$(vman completion %[1]s)\"\n    eval \"$(vman completion bridge %[1]s)\"\nfi", shellType), nil
+	case "fish":
+		return "if command -v vman >/dev/null 2>&1\n    vman completion fish | source\n    vman completion bridge fish | source\nend", nil
+	default:
+		return "", fmt.Errorf("unsupported shell type: %s", shellType)
+	}
+}
+
+// InstallCompletionSnippet 将加载补全的片段写入shell配置文件末尾，已存在时替换
+// 返回配置文件是否被修改
+func InstallCompletionSnippet(fs afero.Fs, configPath, shellType string) (bool, error) {
+	snippet, err := CompletionSnippet(shellType)
+	if err != nil {
+		return false, err
+	}
+	if err := fs.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
+		return false, fmt.Errorf("failed to create config directory: %w", err)
+	}
+	return writeMarkedSection(fs, configPath, completionMarker, snippet)
+}
+
+// RemoveCompletionSnippet 从shell配置文件中移除 vman completion install 写入的片段
+// 返回是否找到并移除了片段
+func RemoveCompletionSnippet(fs afero.Fs, configPath string) (bool, error) {
+	return removeMarkedSectionFromFile(fs, configPath, completionMarker)
+}
+
+// shellIdentifier 不能用于shell变量名和函数名的字符
+var shellIdentifier = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+// CompletionBridge 生成补全桥接脚本：按 TAB 时通过 vman completion script 找到当前目录解析出的版本的补全脚本，
+// 版本变化后重新加载，使工具的补全与切换的版本一致
+func CompletionBridge(shellType string, commands []BridgedCommand) (string, error) {
+	var b strings.Builder
+	switch shellType {
+	case "bash":
+		b.WriteString(bashCompletionBridge)
+		for _, command := range commands {
+			key := shellIdentifier.ReplaceAllString(command.Command, "_")
+			fmt.Fprintf(&b, "_vman_complete_%s() { _vman_complete_bridge %q %q %s \"$@\"; }\n", key, command.Command, command.Tool, key)
+			fmt.Fprintf(&b, "complete -o default -F _vman_complete_%s %q\n", key, command.Command)
+		}
+	case "zsh":
+		b.WriteString(zshCompletionBridge)
+		for _, command := range commands {
+			key := shellIdentifier.ReplaceAllString(command.Command, "_")
+			fmt.Fprintf(&b, "_vman_complete_%s() { _vman_complete_bridge %q %q %s \"$@\"; }\n", key, command.Command, command.Tool, key)
+			fmt.Fprintf(&b, "(( $+functions[compdef] )) && compdef _vman_complete_%s %q\n", key, command.Command)
+		}
+	case "fish":
+		b.WriteString(fishCompletionBridge)
+		for _, command := range commands {
+			fmt.Fprintf(&b, "complete -c %q -a '(__vman_complete_bridge %q %q)'\n", command.Command, command.Command, command.Tool)
+		}
+	default:
+		return "", fmt.Errorf("unsupported shell type: %s", shellType)
+	}
+	return b.String(), nil
+}
+
+// bashCompletionBridge 加载工具的补全脚本后记录它注册的补全函数，再换回桥接函数，之后由桥接函数调用
+const bashCompletionBridge = `# vman completion bridge for bash
+_vman_complete_bridge() {
+    local cmd="$1" tool="$2" key="$3" script loaded func
+    shift 3
+    script="$(command vman completion script bash "$tool" 2>/dev/null)" || return 0
+    eval "loaded=\${_vman_completion_script_$key}"
+    if [ "$loaded" != "$script" ]; then
+        complete -r "$cmd" 2>/dev/null
+        . "$script"
+        func="$(complete -p "$cmd" 2>/dev/null | sed -n 's/.* -F \([^ ]*\) .*/\1/p')"
+        printf -v "_vman_completion_script_$key" '%s' "$script"
+        printf -v "_vman_completion_func_$key" '%s' "$func"
+        complete -o default -F "_vman_complete_$key" "$cmd"
+    fi
+    eval "func=\${_vman_completion_func_$key}"
+    [ -n "$func" ] && "$func" "$@"
+}
+`
+
+// zshCompletionBridge 加载工具的补全脚本后从 _comps 取得它注册的补全函数，再换回桥接函数
+const zshCompletionBridge = `# vman completion bridge for zsh
+_vman_complete_bridge() {
+    local cmd=$1 tool=$2 key=$3 script
+    local script_var=_vman_completion_script_$key func_var=_vman_completion_func_$key
+    shift 3
+    script="$(command vman completion script zsh $tool 2>/dev/null)" || return 1
+    if [[ ${(P)script_var} != $script ]]; then
+        source $script
+        typeset -g $script_var=$script
+        typeset -g $func_var=${_comps[$cmd]}
+        compdef _vman_complete_$key $cmd
+    fi
+    [[ -n ${(P)func_var} && ${(P)func_var} != _vman_complete_$key ]] && ${(P)func_var} "$@"
+}
+`
+
+// fishCompletionBridge 重新加载工具的补全脚本后通过 complete -C 计算补全，__vman_completing 防止递归
+const fishCompletionBridge = `# vman completion bridge for fish
+function __vman_complete_bridge --argument-names cmd tool
+    set -q __vman_completing; and return
+    set -l script (command vman completion script fish $tool 2>/dev/null); or return
+    set -l var __vman_completion_script_(string escape --style=var -- $cmd)
+    if test "$$var" != "$script"
+        complete -c $cmd -e
+        source $script
+        set -g $var $script
+        complete -c $cmd -a "(__vman_complete_bridge $cmd $tool)"
+    end
+    set -g __vman_completing 1
+    complete -C (commandline -cp)
+    set -e __vman_completing
+end
+`
+
+// CompletionScriptPath 工具某个版本的补全脚本在缓存目录中的路径
+func CompletionScriptPath(cacheDir, tool, version, shellType string) string {
+	return filepath.Join(cacheDir, CompletionCacheDir, tool, version, shellType)
+}
+
+// GenerateCompletionScript 运行工具生成补全脚本并缓存，args 中的 {shell} 替换为shell类型
+// 缓存中已有该版本的补全脚本时直接返回路径
+func GenerateCompletionScript(fs afero.Fs, cacheDir, tool, version, binaryPath, shellType string, args []string) (string, error) {
+	path := CompletionScriptPath(cacheDir, tool, version, shellType)
+	if info, err := fs.Stat(path); err == nil && info.Size() > 0 {
+		return path, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), completionTimeout)
+	defer cancel()
+	expanded := make([]string, len(args))
+	for i, arg := range args {
+		expanded[i] = strings.ReplaceAll(arg, "{shell}", shellType)
+	}
+	output, err := exec.CommandContext(ctx, binaryPath, expanded...).Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate %s completion for %s %s: %w", shellType, tool, version, err)
+	}
+	if len(output) == 0 {
+		return "", fmt.Errorf("%s %s printed an empty %s completion script", tool, version, shellType)
+	}
+
+	if err := fs.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", fmt.Errorf("failed to create completion cache: %w", err)
+	}
+	if err := afero.WriteFile(fs, path, output, 0644); err != nil {
+		return "", fmt.Errorf("failed to write completion cache: %w", err)
+	}
+	return path, nil
+}
@@ -0,0 +1,90 @@
+package proxy
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInstallCompletionSnippet(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	configPath := "/home/u/.bashrc"
+	require.NoError(t, afero.WriteFile(fs, configPath, []byte("# user settings\n"), 0644))
+	_, err := InstallSetupSnippet(fs, configPath, "bash")
+	require.NoError(t, err)
+
+	changed, err := InstallCompletionSnippet(fs, configPath, "bash")
+	require.NoError(t, err)
+	assert.True(t, changed)
+	changed, err = InstallCompletionSnippet(fs, configPath, "bash")
+	require.NoError(t, err)
+	assert.False(t, changed, "重复运行不应修改配置文件")
+
+	content, err := afero.ReadFile(fs, configPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "vman completion bridge bash")
+	assert.Equal(t, 2, strings.Count(string(content), completionMarker+"\n"))
+
+	// 移除补全片段不影响 vman setup 写入的片段
+	removed, err := RemoveCompletionSnippet(fs, configPath)
+	require.NoError(t, err)
+	assert.True(t, removed)
+	content, err = afero.ReadFile(fs, configPath)
+	require.NoError(t, err)
+	assert.NotContains(t, string(content), completionMarker)
+	assert.True(t, HasSetupSnippet(string(content)))
+
+	_, err = InstallCompletionSnippet(fs, configPath, "pwsh")
+	assert.Error(t, err)
+}
+
+func TestCompletionBridge(t *testing.T) {
+	commands := []BridgedCommand{{Command: "kubectl", Tool: "kubectl"}, {Command: "golangci-lint", Tool: "golangci-lint"}}
+
+	script, err := CompletionBridge("bash", commands)
+	require.NoError(t, err)
+	assert.Contains(t, script, `_vman_complete_golangci_lint() { _vman_complete_bridge "golangci-lint" "golangci-lint" golangci_lint "$@"; }`)
+	assert.Contains(t, script, `complete -o default -F _vman_complete_kubectl "kubectl"`)
+
+	script, err = CompletionBridge("zsh", commands)
+	require.NoError(t, err)
+	assert.Contains(t, script, `compdef _vman_complete_kubectl "kubectl"`)
+
+	script, err = CompletionBridge("fish", commands)
+	require.NoError(t, err)
+	assert.Contains(t, script, `complete -c "kubectl" -a '(__vman_complete_bridge "kubectl" "kubectl")'`)
+
+	_, err = CompletionBridge("cmd", commands)
+	assert.Error(t, err)
+}
+
+func TestGenerateCompletionScript(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script as the tool binary")
+	}
+	dir := t.TempDir()
+	binary := filepath.Join(dir, "tool")
+	require.NoError(t, os.WriteFile(binary, []byte("#!/bin/sh\necho \"completion for $2 via $1\"\n"), 0755))
+	cacheDir := filepath.Join(dir, "cache")
+	fs := afero.NewOsFs()
+
+	path, err := GenerateCompletionScript(fs, cacheDir, "tool", "1.0.0", binary, "zsh", []string{"completion", "{shell}"})
+	require.NoError(t, err)
+	assert.Equal(t, CompletionScriptPath(cacheDir, "tool", "1.0.0", "zsh"), path)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "completion for zsh via completion\n", string(data))
+
+	// 已缓存的版本不再运行工具
+	require.NoError(t, os.Remove(binary))
+	_, err = GenerateCompletionScript(fs, cacheDir, "tool", "1.0.0", binary, "zsh", []string{"completion", "{shell}"})
+	require.NoError(t, err)
+	_, err = GenerateCompletionScript(fs, cacheDir, "tool", "2.0.0", binary, "zsh", []string{"completion", "{shell}"})
+	assert.Error(t, err)
+}
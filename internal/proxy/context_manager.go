@@ -47,16 +47,19 @@ type ContextManager interface {
 
 // ProjectContext 项目上下文
 type ProjectContext struct {
-	RootPath      string                 `json:"root_path"`
-	ConfigFiles   []string               `json:"config_files"`
-	ProjectConfig *types.ProjectConfig   `json:"project_config,omitempty"`
-	DetectedAt    time.Time              `json:"detected_at"`
-	ProjectType   string                 `json:"project_type,omitempty"` // "node", "python", "go", etc.
-	Framework     string                 `json:"framework,omitempty"`    // "react", "vue", "django", etc.
-	BuildSystem   string                 `json:"build_system,omitempty"` // "npm", "yarn", "pip", "go mod", etc.
-	Dependencies  map[string]string      `json:"dependencies,omitempty"`
-	Scripts       map[string]string      `json:"scripts,omitempty"`
-	Metadata      map[string]interface{} `json:"metadata,omitempty"`
+	RootPath      string                      `json:"root_path"`
+	ConfigFiles   []string                    `json:"config_files"`
+	ProjectConfig *types.ProjectConfig        `json:"project_config,omitempty"`
+	DetectedAt    time.Time                   `json:"detected_at"`
+	ProjectType   string                      `json:"project_type,omitempty"` // "node", "python", "go", etc.
+	Framework     string                      `json:"framework,omitempty"`    // "react", "vue", "django", etc.
+	BuildSystem   string                      `json:"build_system,omitempty"` // "npm", "yarn", "pip", "go mod", etc.
+	Features      []string                    `json:"features,omitempty"`     // "kubernetes", "helm"
+	Engines       map[string]EngineConstraint `json:"engines,omitempty"`      // package.json、go.mod 中声明的版本要求
+	Dependencies  map[string]string           `json:"dependencies,omitempty"`
+	Scripts       map[string]string           `json:"scripts,omitempty"`
+	Metadata      map[string]interface{}      `json:"metadata,omitempty"`
+	Git           *GitInfo                    `json:"git,omitempty"`
 }
 
 // ToolContext 工具上下文
@@ -95,6 +98,7 @@ type DefaultContextManager struct {
 	configManager config.Manager
 	projectCache  map[string]*ProjectContext // projectPath -> context
 	toolCache     map[string]*ToolContext    // projectPath:toolName -> context
+	rootCache     []rootCacheEntry           // 按设备号和inode缓存的项目根目录
 	cacheTimeout  time.Duration
 }
 
@@ -145,6 +149,11 @@ func (cm *DefaultContextManager) DetectProjectContext(workingDir string) (*Proje
 		context.ProjectConfig = projectConfig
 	}
 
+	// 检测git仓库和worktree
+	if gitInfo, ok := readGitInfo(cm.fs, rootPath); ok {
+		context.Git = gitInfo
+	}
+
 	// 检测项目类型和特征
 	cm.detectProjectFeatures(context)
 
@@ -156,9 +165,27 @@ func (cm *DefaultContextManager) DetectProjectContext(workingDir string) (*Proje
 }
 
 // FindProjectRoot 查找项目根目录
+//
+// 起始目录中的符号链接会先被解析，通过不同链接进入同一项目时得到相同的根目录。
 func (cm *DefaultContextManager) FindProjectRoot(startDir string) (string, error) {
 	cm.logger.Debugf("Finding project root from: %s", startDir)
 
+	startDir = canonicalPath(startDir)
+	startInfo, _ := cm.fs.Stat(startDir)
+	if root, ok := cm.getRootFromCache(startInfo); ok {
+		return root, nil
+	}
+
+	root, err := cm.findProjectRoot(startDir)
+	if err != nil {
+		return "", err
+	}
+	cm.setRootCache(startInfo, root)
+	return root, nil
+}
+
+// findProjectRoot 从起始目录向上查找项目根目录标识
+func (cm *DefaultContextManager) findProjectRoot(startDir string) (string, error) {
 	// 项目根目录标识文件
 	rootMarkers := []string{
 		".vman",
@@ -170,6 +197,7 @@ func (cm *DefaultContextManager) FindProjectRoot(startDir string) (string, error
 		"Cargo.toml",
 		"pyproject.toml",
 		"requirements.txt",
+		"Pipfile",
 		"pom.xml",
 		"build.gradle",
 		"Makefile",
@@ -180,6 +208,15 @@ func (cm *DefaultContextManager) FindProjectRoot(startDir string) (string, error
 	for {
 		// 检查是否存在项目根目录标识
 		for _, marker := range rootMarkers {
+			if marker == ".git" {
+				// .git 可能是目录，也可能是worktree或submodule中指向实际git目录的文件
+				if _, ok := readGitInfo(cm.fs, currentDir); ok {
+					cm.logger.Debugf("Found project root marker %s in %s", marker, currentDir)
+					return currentDir, nil
+				}
+				continue
+			}
+
 			markerPath := filepath.Join(currentDir, marker)
 			if cm.fileExists(markerPath) {
 				cm.logger.Debugf("Found project root marker %s in %s", marker, currentDir)
@@ -216,28 +253,8 @@ func (cm *DefaultContextManager) GetEffectiveConfig(projectPath string) (*types.
 		projectConfig = types.GetDefaultProjectConfig()
 	}
 
-	// 合并配置
-	resolvedVersions := make(map[string]string)
-	configSource := make(map[string]string)
-
-	// 先添加全局版本
-	for tool, version := range globalConfig.GlobalVersions {
-		resolvedVersions[tool] = version
-		configSource[tool] = "global"
-	}
-
-	// 项目版本覆盖全局版本
-	for tool, version := range projectConfig.Tools {
-		resolvedVersions[tool] = version
-		configSource[tool] = projectPath
-	}
-
-	return &types.EffectiveConfig{
-		Global:           globalConfig,
-		Project:          projectConfig,
-		ResolvedVersions: resolvedVersions,
-		ConfigSource:     configSource,
-	}, nil
+	// 与配置管理器和版本解析器使用相同的合并逻辑
+	return config.NewMerger().MergeConfigs(globalConfig, projectConfig, globalConfig.Settings.Resolution.Strategy())
 }
 
 // WatchConfigChanges 监听配置变更
@@ -301,7 +318,7 @@ func (cm *DefaultContextManager) GetToolContext(toolName, projectPath string) (*
 	toolContext.Environment[fmt.Sprintf("%s_VERSION", strings.ToUpper(toolName))] = version
 	toolContext.Environment["VMAN_TOOL"] = toolName
 	toolContext.Environment["VMAN_VERSION"] = version
-	toolContext.Environment["VMAN_PROJECT_PATH"] = projectPath
+	toolContext.Environment[types.EnvVmanProjectPath] = projectPath
 
 	// 缓存结果
 	cm.setToolCache(cacheKey, toolContext)
@@ -323,6 +340,7 @@ func (cm *DefaultContextManager) UpdateProjectContext(projectPath string, contex
 func (cm *DefaultContextManager) ClearContextCache() error {
 	cm.projectCache = make(map[string]*ProjectContext)
 	cm.toolCache = make(map[string]*ToolContext)
+	cm.rootCache = nil
 	cm.logger.Info("Context cache cleared")
 	return nil
 }
@@ -351,8 +369,9 @@ func (cm *DefaultContextManager) GetEnvironmentContext() *EnvironmentContext {
 	}
 
 	// 获取vman目录
-	vmanDir := filepath.Join(homeDir, ".vman")
-	shimsDir := filepath.Join(vmanDir, "shims")
+	paths := types.DefaultConfigPaths(homeDir)
+	vmanDir := paths.ConfigDir
+	shimsDir := paths.ShimsDir
 
 	return &EnvironmentContext{
 		OS:         cm.getOS(),
@@ -389,6 +408,13 @@ func (cm *DefaultContextManager) detectProjectFeatures(context *ProjectContext)
 		context.ProjectType = "java"
 		cm.detectJavaFeatures(context)
 	}
+
+	// Kubernetes清单可以出现在任何类型的项目中
+	cm.detectKubernetesFeatures(context)
+
+	if engines := ReadEngineConstraints(cm.fs, rootPath); len(engines) > 0 {
+		context.Engines = engines
+	}
 }
 
 // detectNodeFeatures 检测Node.js项目特征
@@ -0,0 +1,100 @@
+package proxy
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/afero"
+
+	"github.com/songzhibin97/vman/internal/config"
+	"github.com/songzhibin97/vman/pkg/types"
+)
+
+// deniedDefaultEnv 项目配置的 defaults.env 不能设置的环境变量：动态链接器和解释器启动时据此加载代码或改变查找路径，
+// 克隆的仓库或 extends 引用的远程配置可以借此在执行工具时注入代码
+var deniedDefaultEnv = map[string]bool{
+	"PATH": true, "BASH_ENV": true, "ENV": true, "IFS": true,
+	"NODE_OPTIONS": true, "NODE_PATH": true,
+	"PYTHONPATH": true, "PYTHONSTARTUP": true, "PYTHONHOME": true, "PYTHONUSERBASE": true,
+	"RUBYOPT": true, "RUBYLIB": true,
+	"PERL5OPT": true, "PERL5LIB": true, "PERLLIB": true,
+	"JAVA_TOOL_OPTIONS": true, "_JAVA_OPTIONS": true, "JDK_JAVA_OPTIONS": true,
+	"GOFLAGS": true,
+}
+
+// deniedDefaultEnvPrefixes 以这些前缀开头的变量同样不能设置（LD_PRELOAD、LD_LIBRARY_PATH、DYLD_INSERT_LIBRARIES 等）
+var deniedDefaultEnvPrefixes = []string{"LD_", "DYLD_"}
+
+// IsDeniedDefaultEnv 检查环境变量是否不能通过项目配置的 defaults.env 设置
+func IsDeniedDefaultEnv(name string) bool {
+	name = strings.ToUpper(name)
+	if deniedDefaultEnv[name] {
+		return true
+	}
+	for _, prefix := range deniedDefaultEnvPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// ProjectDefaults 查找项目配置中为工具设置的默认参数和环境变量，返回默认值和所在的配置文件
+// 不能设置的环境变量（见 IsDeniedDefaultEnv）不会生效，记录在返回值的 Denied 中
+//
+// 从 workDir 向上查找第一个为该工具配置了 defaults 的 .vman.yaml；都没有时，嵌套调用再从
+// 父进程的项目目录（VMAN_PROJECT_PATH）查找。设置了 VMAN_NO_DEFAULT_ARGS 时不查找。
+func ProjectDefaults(fs afero.Fs, configManager config.Manager, toolName, workDir string) (types.ToolDefaults, string) {
+	if types.DefaultArgsDisabled() {
+		return types.ToolDefaults{}, ""
+	}
+
+	if defaults, configPath := findProjectDefaults(fs, configManager, toolName, workDir); configPath != "" {
+		return defaults, configPath
+	}
+	if parentPath := os.Getenv(types.EnvVmanProjectPath); parentPath != "" {
+		return findProjectDefaults(fs, configManager, toolName, parentPath)
+	}
+	return types.ToolDefaults{}, ""
+}
+
+// findProjectDefaults 从 startDir 向上查找为工具配置了默认值的项目配置
+func findProjectDefaults(fs afero.Fs, configManager config.Manager, toolName, startDir string) (types.ToolDefaults, string) {
+	currentDir := canonicalPath(startDir)
+	for {
+		configPath := configManager.GetProjectConfigPath(currentDir)
+		if _, err := fs.Stat(configPath); err == nil {
+			if projectConfig, err := configManager.LoadProject(currentDir); err == nil {
+				if defaults := projectConfig.Defaults[toolName]; !defaults.IsEmpty() {
+					return withoutDeniedEnv(defaults), configPath
+				}
+			}
+		}
+
+		parentDir := filepath.Dir(currentDir)
+		if parentDir == currentDir {
+			return types.ToolDefaults{}, ""
+		}
+		currentDir = parentDir
+	}
+}
+
+// withoutDeniedEnv 去掉默认值中不能设置的环境变量
+func withoutDeniedEnv(defaults types.ToolDefaults) types.ToolDefaults {
+	var env map[string]string
+	for name, value := range defaults.Env {
+		if IsDeniedDefaultEnv(name) {
+			defaults.Denied = append(defaults.Denied, name)
+			continue
+		}
+		if env == nil {
+			env = make(map[string]string)
+		}
+		env[name] = value
+	}
+	sort.Strings(defaults.Denied)
+	defaults.Env = env
+	return defaults
+}
@@ -0,0 +1,92 @@
+package proxy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/songzhibin97/vman/internal/config"
+	"github.com/songzhibin97/vman/pkg/types"
+)
+
+func TestProjectDefaults(t *testing.T) {
+	t.Setenv(types.EnvVmanHome, t.TempDir())
+	t.Setenv(types.EnvVmanProjectPath, "")
+	configManager, err := config.NewManager(t.TempDir())
+	require.NoError(t, err)
+
+	project := canonicalPath(t.TempDir())
+	infra := filepath.Join(project, "infra")
+	require.NoError(t, os.MkdirAll(infra, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(project, ".vman.yaml"), []byte(`version: "1.0"
+tools:
+  terraform: 1.7.0
+defaults:
+  terraform:
+    args: ["-chdir=infra"]
+  kubectl:
+    args: ["--context=dev"]
+    env:
+      KUBECONFIG: ./kubeconfig
+`), 0644))
+	// 子目录的项目配置没有为 kubectl 设置默认值时继续向上查找
+	require.NoError(t, os.WriteFile(filepath.Join(infra, ".vman.yaml"), []byte(`version: "1.0"
+defaults:
+  terraform:
+    args: ["-no-color"]
+`), 0644))
+
+	fs := afero.NewOsFs()
+	defaults, configPath := ProjectDefaults(fs, configManager, "terraform", infra)
+	assert.Equal(t, []string{"-no-color"}, defaults.Args)
+	assert.Equal(t, filepath.Join(infra, ".vman.yaml"), configPath)
+
+	defaults, configPath = ProjectDefaults(fs, configManager, "kubectl", infra)
+	assert.Equal(t, []string{"--context=dev"}, defaults.Args)
+	assert.Equal(t, map[string]string{"KUBECONFIG": "./kubeconfig"}, defaults.Env)
+	assert.Equal(t, filepath.Join(project, ".vman.yaml"), configPath)
+
+	// 项目外的嵌套调用从父进程的项目目录查找
+	outside := t.TempDir()
+	defaults, _ = ProjectDefaults(fs, configManager, "kubectl", outside)
+	assert.True(t, defaults.IsEmpty())
+	t.Setenv(types.EnvVmanProjectPath, project)
+	defaults, _ = ProjectDefaults(fs, configManager, "kubectl", outside)
+	assert.Equal(t, []string{"--context=dev"}, defaults.Args)
+
+	t.Setenv(types.EnvVmanNoDefaultArgs, "1")
+	defaults, configPath = ProjectDefaults(fs, configManager, "terraform", project)
+	assert.True(t, defaults.IsEmpty())
+	assert.Empty(t, configPath)
+}
+
+func TestProjectDefaultsDeniedEnv(t *testing.T) {
+	t.Setenv(types.EnvVmanHome, t.TempDir())
+	t.Setenv(types.EnvVmanProjectPath, "")
+	configManager, err := config.NewManager(t.TempDir())
+	require.NoError(t, err)
+
+	project := canonicalPath(t.TempDir())
+	require.NoError(t, os.WriteFile(filepath.Join(project, ".vman.yaml"), []byte(`version: "1.0"
+defaults:
+  node:
+    env:
+      NODE_ENV: development
+      NODE_OPTIONS: --require ./evil.js
+      LD_PRELOAD: ./evil.so
+      dyld_insert_libraries: ./evil.dylib
+`), 0644))
+
+	defaults, configPath := ProjectDefaults(afero.NewOsFs(), configManager, "node", project)
+	assert.Equal(t, map[string]string{"NODE_ENV": "development"}, defaults.Env)
+	assert.Equal(t, []string{"LD_PRELOAD", "NODE_OPTIONS", "dyld_insert_libraries"}, defaults.Denied)
+	assert.Equal(t, filepath.Join(project, ".vman.yaml"), configPath)
+
+	assert.True(t, IsDeniedDefaultEnv("PYTHONPATH"))
+	assert.True(t, IsDeniedDefaultEnv("LD_LIBRARY_PATH"))
+	assert.False(t, IsDeniedDefaultEnv("KUBECONFIG"))
+}
@@ -0,0 +1,91 @@
+package proxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/spf13/afero"
+
+	"github.com/songzhibin97/vman/pkg/types"
+)
+
+// DeprecationsFile 缓存目录中记录弃用警告上次显示时间的文件名
+const DeprecationsFile = "deprecations.json"
+
+// DeprecationWarningInterval 同一工具版本的弃用警告在该时间内只显示一次
+const DeprecationWarningInterval = 24 * time.Hour
+
+// FindDeprecation 返回工具定义中与版本匹配的弃用声明，没有时返回 nil
+func FindDeprecation(metadata *types.ToolMetadata, version string) *types.DeprecatedVersion {
+	if metadata == nil || version == "" || version == types.SystemVersion {
+		return nil
+	}
+	parsed, parseErr := semver.NewVersion(version)
+	for i := range metadata.VersionConfig.Deprecated {
+		deprecated := &metadata.VersionConfig.Deprecated[i]
+		if strings.TrimPrefix(deprecated.Versions, "v") == strings.TrimPrefix(version, "v") {
+			return deprecated
+		}
+		if parseErr != nil {
+			continue
+		}
+		constraint, err := semver.NewConstraint(deprecated.Versions)
+		if err == nil && constraint.Check(parsed) {
+			return deprecated
+		}
+	}
+	return nil
+}
+
+// DeprecationMessage 弃用版本的提示信息
+func DeprecationMessage(tool, version string, deprecated *types.DeprecatedVersion) string {
+	message := fmt.Sprintf("%s@%s 已弃用", tool, version)
+	if deprecated.Message != "" {
+		message += ": " + deprecated.Message
+	}
+	return message
+}
+
+// CheckDeprecation 按处理方式检查版本是否已弃用
+// policy 为 error 时返回错误；为 warn 时返回需要显示的警告，同一版本在 DeprecationWarningInterval 内只返回一次，
+// 显示时间记录在 statePath 中，statePath 为空时每次都返回警告
+func CheckDeprecation(fs afero.Fs, statePath string, metadata *types.ToolMetadata, version, policy string, now time.Time) (string, error) {
+	if policy == types.DeprecationIgnore {
+		return "", nil
+	}
+	deprecated := FindDeprecation(metadata, version)
+	if deprecated == nil {
+		return "", nil
+	}
+	message := DeprecationMessage(metadata.Name, version, deprecated)
+	if policy == types.DeprecationError {
+		return "", fmt.Errorf("%s（settings.resolution.deprecated 为 error）", message)
+	}
+	if statePath == "" {
+		return message, nil
+	}
+
+	// 状态文件损坏或无法写入时仍然显示警告
+	shown := make(map[string]time.Time)
+	if data, err := afero.ReadFile(fs, statePath); err == nil {
+		_ = json.Unmarshal(data, &shown)
+	}
+	key := metadata.Name + "@" + version
+	if last, ok := shown[key]; ok && now.Sub(last) < DeprecationWarningInterval {
+		return "", nil
+	}
+	shown[key] = now
+	if data, err := json.Marshal(shown); err == nil && fs.MkdirAll(filepath.Dir(statePath), 0755) == nil {
+		tmpPath := statePath + ".tmp"
+		if afero.WriteFile(fs, tmpPath, data, 0644) == nil {
+			if fs.Rename(tmpPath, statePath) != nil {
+				fs.Remove(tmpPath)
+			}
+		}
+	}
+	return message, nil
+}
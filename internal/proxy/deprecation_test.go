@@ -0,0 +1,63 @@
+package proxy
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/songzhibin97/vman/pkg/types"
+)
+
+func TestFindDeprecation(t *testing.T) {
+	metadata := &types.ToolMetadata{
+		Name: "terraform",
+		VersionConfig: types.VersionConfig{Deprecated: []types.DeprecatedVersion{
+			{Versions: "<1.5", Message: "terraform <1.5 已停止维护"},
+			{Versions: "1.6.2", Message: "存在状态文件损坏的问题"},
+		}},
+	}
+
+	deprecated := FindDeprecation(metadata, "1.4.7")
+	require.NotNil(t, deprecated)
+	assert.Equal(t, "terraform@1.4.7 已弃用: terraform <1.5 已停止维护", DeprecationMessage("terraform", "1.4.7", deprecated))
+	require.NotNil(t, FindDeprecation(metadata, "v1.6.2"))
+	assert.Nil(t, FindDeprecation(metadata, "1.7.0"))
+	assert.Nil(t, FindDeprecation(metadata, types.SystemVersion))
+	assert.Nil(t, FindDeprecation(nil, "1.4.7"))
+}
+
+func TestCheckDeprecation(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	statePath := "/cache/" + DeprecationsFile
+	metadata := &types.ToolMetadata{
+		Name:          "terraform",
+		VersionConfig: types.VersionConfig{Deprecated: []types.DeprecatedVersion{{Versions: "<1.5"}}},
+	}
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	warning, err := CheckDeprecation(fs, statePath, metadata, "1.4.7", types.DeprecationWarn, now)
+	require.NoError(t, err)
+	assert.Equal(t, "terraform@1.4.7 已弃用", warning)
+
+	// 一天内同一版本只提示一次
+	warning, err = CheckDeprecation(fs, statePath, metadata, "1.4.7", types.DeprecationWarn, now.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Empty(t, warning)
+	warning, _ = CheckDeprecation(fs, statePath, metadata, "1.4.6", types.DeprecationWarn, now.Add(time.Hour))
+	assert.NotEmpty(t, warning)
+	warning, _ = CheckDeprecation(fs, statePath, metadata, "1.4.7", types.DeprecationWarn, now.Add(25*time.Hour))
+	assert.NotEmpty(t, warning)
+
+	_, err = CheckDeprecation(fs, statePath, metadata, "1.4.7", types.DeprecationError, now)
+	assert.ErrorContains(t, err, "已弃用")
+	warning, err = CheckDeprecation(fs, statePath, metadata, "1.4.7", types.DeprecationIgnore, now.Add(48*time.Hour))
+	assert.NoError(t, err)
+	assert.Empty(t, warning)
+
+	warning, err = CheckDeprecation(fs, statePath, metadata, "1.7.0", types.DeprecationError, now)
+	assert.NoError(t, err)
+	assert.Empty(t, warning)
+}
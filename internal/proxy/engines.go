@@ -0,0 +1,95 @@
+package proxy
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/afero"
+)
+
+// EngineConstraint 项目清单文件中声明的工具版本要求
+type EngineConstraint struct {
+	Tool       string `json:"tool"`
+	Constraint string `json:"constraint"` // 语义化版本约束，如 ">=18 <21"、">=1.22"
+	Source     string `json:"source"`     // 声明约束的文件
+}
+
+// packageJSONEngines package.json engines 中可识别的工具
+var packageJSONEngines = []string{"node", "npm", "pnpm", "yarn"}
+
+// ReadEngineConstraints 读取目录中 package.json 的 engines 和 go.mod 的 go/toolchain 指令
+func ReadEngineConstraints(fs afero.Fs, dir string) map[string]EngineConstraint {
+	constraints := make(map[string]EngineConstraint)
+
+	packageJSON := filepath.Join(dir, "package.json")
+	if data, err := afero.ReadFile(fs, packageJSON); err == nil {
+		for tool, constraint := range parsePackageJSONEngines(data) {
+			constraints[tool] = EngineConstraint{Tool: tool, Constraint: constraint, Source: packageJSON}
+		}
+	}
+
+	goMod := filepath.Join(dir, "go.mod")
+	if data, err := afero.ReadFile(fs, goMod); err == nil {
+		if constraint := parseGoModVersion(data); constraint != "" {
+			constraints["go"] = EngineConstraint{Tool: "go", Constraint: constraint, Source: goMod}
+		}
+	}
+
+	return constraints
+}
+
+// parsePackageJSONEngines 解析 package.json 中的 engines 字段
+func parsePackageJSONEngines(data []byte) map[string]string {
+	var manifest struct {
+		Engines map[string]string `json:"engines"`
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil
+	}
+
+	result := make(map[string]string)
+	for _, tool := range packageJSONEngines {
+		if constraint := strings.TrimSpace(manifest.Engines[tool]); constraint != "" && constraint != "*" {
+			result[tool] = constraint
+		}
+	}
+	return result
+}
+
+// parseGoModVersion 解析 go.mod 中的 go 和 toolchain 指令
+// 两者都表示最低版本要求，toolchain 存在时优先使用
+func parseGoModVersion(data []byte) string {
+	var goVersion, toolchain string
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		switch fields[0] {
+		case "go":
+			goVersion = fields[1]
+		case "toolchain":
+			if fields[1] != "default" {
+				toolchain = strings.TrimPrefix(fields[1], "go")
+			}
+		}
+	}
+
+	switch {
+	case toolchain != "":
+		return ">=" + toolchain
+	case goVersion != "":
+		return ">=" + goVersion
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,58 @@
+package proxy
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseGoModVersion(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{"GoDirective", "module example\n\ngo 1.22\n", ">=1.22"},
+		{"Toolchain", "module example\n\ngo 1.21\n\ntoolchain go1.22.3 // pinned\n", ">=1.22.3"},
+		{"ToolchainDefault", "module example\n\ngo 1.21.0\ntoolchain default\n", ">=1.21.0"},
+		{"NoDirective", "module example\n", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, parseGoModVersion([]byte(tt.content)))
+		})
+	}
+}
+
+func TestParsePackageJSONEngines(t *testing.T) {
+	engines := parsePackageJSONEngines([]byte(`{"engines": {"node": ">=18 <21", "npm": "*", "vscode": "^1.80.0"}}`))
+	assert.Equal(t, map[string]string{"node": ">=18 <21"}, engines)
+
+	assert.Empty(t, parsePackageJSONEngines([]byte(`{"name": "app"}`)))
+	assert.Nil(t, parsePackageJSONEngines([]byte(`not json`)))
+}
+
+func TestResolveFromEngines(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/work/package.json", []byte(`{"engines": {"node": "^18.0.0"}}`), 0644))
+	require.NoError(t, afero.WriteFile(fs, "/work/backend/go.mod", []byte("module backend\n\ngo 1.22\n"), 0644))
+	require.NoError(t, fs.MkdirAll("/work/backend/cmd", 0755))
+
+	resolver := NewVersionResolverWithFs(fs, nil, nil).(*DefaultVersionResolver)
+
+	engine, ok := resolver.resolveFromEngines("go", "/work/backend/cmd")
+	require.True(t, ok)
+	assert.Equal(t, ">=1.22", engine.Constraint)
+	assert.Equal(t, "/work/backend/go.mod", engine.Source)
+
+	// 向上查找到外层目录的 package.json
+	engine, ok = resolver.resolveFromEngines("node", "/work/backend/cmd")
+	require.True(t, ok)
+	assert.Equal(t, "^18.0.0", engine.Constraint)
+
+	_, ok = resolver.resolveFromEngines("kubectl", "/work/backend/cmd")
+	assert.False(t, ok)
+}
@@ -0,0 +1,87 @@
+package proxy
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"runtime"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// exitWithStatusScript 设置时测试进程运行该脚本并以其退出状态退出，用于测试 ExitWithStatus
+const exitWithStatusScript = "VMAN_TEST_EXIT_WITH_STATUS"
+
+func TestExitStatus(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+
+	err := exec.Command("sh", "-c", "exit 3").Run()
+	var exitErr *exec.ExitError
+	require.True(t, errors.As(err, &exitErr))
+	assert.Equal(t, 3, ExitStatus(exitErr))
+	assert.Equal(t, 3, exitCodeOf(err))
+
+	// 被信号终止时与 shell 中 $? 的值一致
+	err = exec.Command("sh", "-c", "kill -PIPE $$").Run()
+	require.True(t, errors.As(err, &exitErr))
+	assert.Equal(t, 128+int(syscall.SIGPIPE), ExitStatus(exitErr))
+	assert.Equal(t, 128+int(syscall.SIGPIPE), exitCodeOf(err))
+}
+
+func TestExitWithStatus(t *testing.T) {
+	if script := os.Getenv(exitWithStatusScript); script != "" {
+		var exitErr *exec.ExitError
+		if errors.As(exec.Command("sh", "-c", script).Run(), &exitErr) {
+			ExitWithStatus(exitErr)
+		}
+		os.Exit(0)
+	}
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+
+	run := func(script string) *os.ProcessState {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestExitWithStatus$")
+		cmd.Env = append(os.Environ(), exitWithStatusScript+"="+script)
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			var exitErr *exec.ExitError
+			require.True(t, errors.As(err, &exitErr), "%v: %s", err, output)
+		}
+		// 不输出错误
+		assert.Empty(t, string(output))
+		return cmd.ProcessState
+	}
+
+	assert.Equal(t, 3, run("exit 3").ExitCode())
+
+	// SIGPIPE 以 128 加信号编号退出
+	assert.Equal(t, 128+int(syscall.SIGPIPE), run("kill -PIPE $$").ExitCode())
+
+	// SIGTERM 以相同信号终止
+	status, ok := run("kill -TERM $$").Sys().(syscall.WaitStatus)
+	require.True(t, ok)
+	assert.True(t, status.Signaled())
+	assert.Equal(t, syscall.SIGTERM, status.Signal())
+}
+
+func TestIsBrokenPipe(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("broken pipe errors differ on windows")
+	}
+
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	defer w.Close()
+	require.NoError(t, r.Close())
+
+	_, err = w.Write([]byte("vman"))
+	assert.True(t, IsBrokenPipe(err))
+	assert.False(t, IsBrokenPipe(errors.New("write failed")))
+	assert.False(t, IsBrokenPipe(nil))
+}
@@ -0,0 +1,51 @@
+//go:build !windows
+
+package proxy
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// reraisedSignals 以相同信号终止 vman 的信号，Go 运行时对这些信号的默认处理是直接退出
+// 其余信号（如 SIGPIPE、SIGSEGV）由运行时忽略或打印堆栈，改为以 128 加信号编号退出
+var reraisedSignals = map[syscall.Signal]bool{
+	syscall.SIGHUP:  true,
+	syscall.SIGINT:  true,
+	syscall.SIGTERM: true,
+}
+
+// ExitStatus 返回命令的退出状态，与 shell 中 $? 的值一致：被信号终止时为 128 加信号编号
+func ExitStatus(exitErr *exec.ExitError) int {
+	if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		return 128 + int(status.Signal())
+	}
+	return exitErr.ExitCode()
+}
+
+// ExitWithStatus 以工具的退出状态退出 vman，不输出错误，使管道和脚本看到的结果与直接运行工具相同
+// 工具被 SIGINT、SIGTERM 等信号终止时 vman 以相同信号终止
+func ExitWithStatus(exitErr *exec.ExitError) {
+	if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() && reraisedSignals[status.Signal()] {
+		signal.Reset(status.Signal())
+		if err := syscall.Kill(os.Getpid(), status.Signal()); err == nil {
+			// 等待信号送达
+			time.Sleep(100 * time.Millisecond)
+		}
+	}
+	os.Exit(ExitStatus(exitErr))
+}
+
+// IsBrokenPipe 错误是否由读取端已关闭的管道导致，如输出通过管道传给 head
+func IsBrokenPipe(err error) bool {
+	return errors.Is(err, syscall.EPIPE)
+}
+
+// ExitBrokenPipe 输出的管道已关闭时不输出错误，以被 SIGPIPE 终止的状态退出
+func ExitBrokenPipe() {
+	os.Exit(128 + int(syscall.SIGPIPE))
+}
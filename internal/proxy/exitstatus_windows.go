@@ -0,0 +1,32 @@
+//go:build windows
+
+package proxy
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"syscall"
+
+	"golang.org/x/sys/windows"
+)
+
+// ExitStatus 返回命令的退出状态
+func ExitStatus(exitErr *exec.ExitError) int {
+	return exitErr.ExitCode()
+}
+
+// ExitWithStatus 以工具的退出状态退出 vman，不输出错误，使管道和脚本看到的结果与直接运行工具相同
+func ExitWithStatus(exitErr *exec.ExitError) {
+	os.Exit(ExitStatus(exitErr))
+}
+
+// IsBrokenPipe 错误是否由读取端已关闭的管道导致，如输出通过管道传给 head
+func IsBrokenPipe(err error) bool {
+	return errors.Is(err, syscall.EPIPE) || errors.Is(err, syscall.ERROR_BROKEN_PIPE) || errors.Is(err, windows.ERROR_NO_DATA)
+}
+
+// ExitBrokenPipe 输出的管道已关闭时不输出错误，退出状态与写入失败的程序一致
+func ExitBrokenPipe() {
+	os.Exit(1)
+}
@@ -0,0 +1,177 @@
+package proxy
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/afero"
+
+	"github.com/songzhibin97/vman/internal/config"
+	"github.com/songzhibin97/vman/internal/storage"
+	"github.com/songzhibin97/vman/pkg/types"
+)
+
+// 快速路径：容器中的 CI 步骤通常每个工具只安装了一个版本，也没有项目级配置，
+// 每次调用都加载配置、解析命令行和版本的开销在短命令中很明显。满足条件时垫片跳过
+// 完整的代理流程，直接以 exec 替换为唯一安装的版本，版本选择结果与完整流程相同。
+
+// containerMarkers 容器运行时创建的标记文件
+var containerMarkers = []string{"/.dockerenv", "/run/.containerenv"}
+
+// projectVersionFiles 可能为工具指定版本的项目级文件
+var projectVersionFiles = []string{".vman.yaml", ".vman-version", ".tool-versions"}
+
+// InContainer 检测当前进程是否运行在容器中
+func InContainer() bool {
+	if os.Getenv("container") != "" || os.Getenv("KUBERNETES_SERVICE_HOST") != "" {
+		return true
+	}
+	for _, marker := range containerMarkers {
+		if _, err := os.Stat(marker); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// FastPathEnabled 根据 VMAN_FAST_PATH 判断是否尝试快速路径，未设置或为 auto 时只在容器中启用
+func FastPathEnabled() bool {
+	value := strings.TrimSpace(os.Getenv(types.EnvVmanFastPath))
+	if enabled, err := strconv.ParseBool(value); err == nil {
+		return enabled
+	}
+	return InContainer()
+}
+
+// FastPath 可以跳过版本解析直接执行的工具
+type FastPath struct {
+	Tool        string
+	Version     string
+	VersionPath string
+	ExecPath    string
+	WorkDir     string
+}
+
+// ResolveFastPath 判断工具能否走快速路径，需要同时满足：
+//   - 只安装了一个版本，且全局配置中没有指定其他版本
+//   - 当前目录和父进程的项目目录中没有项目级版本文件和 .nvmrc 等生态版本文件，也没有 package.json、go.mod 中的版本要求
+//   - 没有通过环境变量指定版本
+//   - 工具没有别名、钩子、环境变量策略、资源限制、沙箱和弃用版本声明
+//
+// 不满足时返回 false，由完整的代理流程处理
+func ResolveFastPath(fs afero.Fs, configManager config.Manager, storageManager storage.Manager, toolName, workDir string) (*FastPath, bool) {
+	upper := strings.ToUpper(toolName)
+	if os.Getenv(upper+"_VERSION") != "" || os.Getenv("VMAN_"+upper+"_VERSION") != "" {
+		return nil, false
+	}
+
+	globalConfig, err := configManager.LoadGlobal()
+	if err != nil {
+		return nil, false
+	}
+	settings := globalConfig.Settings
+	if _, ok := globalConfig.Aliases[toolName]; ok {
+		return nil, false
+	}
+
+	metadata, _ := configManager.LoadToolConfig(toolName)
+	if len(settings.Hooks.PreExecFor(toolName)) > 0 || len(settings.Hooks.PostExecFor(toolName)) > 0 ||
+		!settings.Env.PolicyFor(toolName, metadata).IsEmpty() ||
+		!settings.Limits.LimitsFor(toolName, metadata).IsEmpty() ||
+		SandboxFor(metadata) != nil ||
+		(metadata != nil && len(metadata.VersionConfig.Deprecated) > 0) {
+		return nil, false
+	}
+
+	for _, dir := range []string{workDir, os.Getenv(types.EnvVmanProjectPath)} {
+		if dir != "" && hasProjectVersion(fs, toolName, dir, settings.Resolution.VersionFilesFor(toolName)) {
+			return nil, false
+		}
+	}
+
+	versions, err := storageManager.GetToolVersions(toolName)
+	if err != nil || len(versions) != 1 || versions[0] == types.SystemVersion {
+		return nil, false
+	}
+	version := versions[0]
+
+	// 全局配置中的版本与安装的版本不同时，完整流程会报告未安装或按别名、约束解析
+	if configured := config.GlobalVersionLayer(globalConfig).Versions[toolName]; configured != "" {
+		if configured != version {
+			return nil, false
+		}
+	} else if settings.Resolution.FallbackFor(toolName) != types.FallbackLatestInstalled {
+		return nil, false
+	}
+	if parent, ok := ParseResolutionEnv(os.Getenv(types.EnvVmanResolution))[toolName]; ok && parent != version {
+		return nil, false
+	}
+
+	versionPath := storageManager.GetToolVersionPath(toolName, version)
+	execPath, ok := findVersionExecutable(fs, versionPath, toolName)
+	if !ok {
+		return nil, false
+	}
+	return &FastPath{Tool: toolName, Version: version, VersionPath: versionPath, ExecPath: execPath, WorkDir: workDir}, true
+}
+
+// hasProjectVersion 从 dir 向上查找是否有项目级版本文件、工具的生态版本文件或版本要求
+func hasProjectVersion(fs afero.Fs, toolName, dir string, versionFiles []string) bool {
+	currentDir := canonicalPath(dir)
+	for {
+		for _, name := range append(projectVersionFiles, versionFiles...) {
+			if _, err := fs.Stat(filepath.Join(currentDir, name)); err == nil {
+				return true
+			}
+		}
+		if _, ok := ReadEngineConstraints(fs, currentDir)[toolName]; ok {
+			return true
+		}
+
+		parentDir := filepath.Dir(currentDir)
+		if parentDir == currentDir {
+			return false
+		}
+		currentDir = parentDir
+	}
+}
+
+// findVersionExecutable 在版本目录中查找可执行文件，查找顺序与 FindExecutable 相同
+func findVersionExecutable(fs afero.Fs, versionPath, toolName string) (string, bool) {
+	candidates := []string{filepath.Join(versionPath, "bin", toolName), filepath.Join(versionPath, toolName)}
+	if runtime.GOOS == "windows" {
+		candidates = append(candidates, candidates[1]+".exe", candidates[0]+".exe")
+	}
+	for _, candidate := range candidates {
+		if info, err := fs.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate, true
+		}
+	}
+	return "", false
+}
+
+// Environment 返回执行工具时设置的环境变量，与完整流程设置的变量相同
+func (f *FastPath) Environment() []string {
+	env := NestedEnvironment(&VersionResolution{ToolName: f.Tool, Version: f.Version}, f.WorkDir)
+	env[strings.ToUpper(f.Tool)+"_VERSION"] = f.Version
+	env["VMAN_TOOL"] = f.Tool
+	env["VMAN_VERSION"] = f.Version
+	env["VMAN_WORKDIR"] = f.WorkDir
+
+	environ := os.Environ()
+	for key, value := range env {
+		environ = append(environ, key+"="+value)
+	}
+	return environ
+}
+
+// Exec 记录版本使用时间后执行工具，Unix 上以 exec 替换当前进程，Windows 上等待工具退出后以相同状态退出，
+// 工具启动成功时不返回
+func (f *FastPath) Exec(fs afero.Fs, args []string) error {
+	_ = storage.MarkUsed(fs, f.VersionPath, time.Now())
+	return execReplace(f.ExecPath, args, f.Environment())
+}
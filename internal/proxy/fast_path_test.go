@@ -0,0 +1,110 @@
+package proxy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/songzhibin97/vman/internal/config"
+	"github.com/songzhibin97/vman/internal/storage"
+	"github.com/songzhibin97/vman/pkg/types"
+)
+
+func TestResolveFastPath(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv(types.EnvVmanHome, root)
+	t.Setenv(types.EnvVmanProjectPath, "")
+	t.Setenv(types.EnvVmanResolution, "")
+	t.Setenv("KUBECTL_VERSION", "")
+	t.Setenv("VMAN_KUBECTL_VERSION", "")
+	paths := types.ConfigPathsFromRoot(root)
+
+	install := func(version string) string {
+		binDir := filepath.Join(paths.ToolVersionDir("kubectl", version), "bin")
+		require.NoError(t, os.MkdirAll(binDir, 0755))
+		binary := filepath.Join(binDir, "kubectl")
+		require.NoError(t, os.WriteFile(binary, []byte("#!/bin/sh\n"), 0755))
+		return binary
+	}
+	writeGlobal := func(content string) {
+		require.NoError(t, os.MkdirAll(paths.ConfigDir, 0755))
+		require.NoError(t, os.WriteFile(paths.GlobalConfigFile, []byte(content), 0644))
+	}
+	resolve := func(workDir string) (*FastPath, bool) {
+		configManager, err := config.NewManager(t.TempDir())
+		require.NoError(t, err)
+		return ResolveFastPath(afero.NewOsFs(), configManager, storage.NewFilesystemManager(paths), "kubectl", workDir)
+	}
+
+	binary := install("1.29.0")
+	writeGlobal("version: \"1.0\"\n")
+	workDir := t.TempDir()
+
+	fastPath, ok := resolve(workDir)
+	require.True(t, ok)
+	assert.Equal(t, "1.29.0", fastPath.Version)
+	assert.Equal(t, binary, fastPath.ExecPath)
+	assert.Contains(t, fastPath.Environment(), "KUBECTL_VERSION=1.29.0")
+	assert.Contains(t, fastPath.Environment(), types.EnvVmanResolution+"=kubectl@1.29.0")
+
+	// 全局配置指定同一版本时仍然可以走快速路径，指定其他版本或回退策略不是已安装的最新版本时不行
+	writeGlobal("version: \"1.0\"\nglobal_versions:\n  kubectl: 1.29.0\n")
+	_, ok = resolve(workDir)
+	assert.True(t, ok)
+	writeGlobal("version: \"1.0\"\nglobal_versions:\n  kubectl: 1.30.0\n")
+	_, ok = resolve(workDir)
+	assert.False(t, ok)
+	writeGlobal("version: \"1.0\"\nsettings:\n  resolution:\n    fallback: error\n")
+	_, ok = resolve(workDir)
+	assert.False(t, ok)
+
+	// 别名、钩子和资源限制需要完整流程处理
+	writeGlobal("version: \"1.0\"\naliases:\n  kubectl:\n    tool: kubectl\n    args: [\"--context=dev\"]\n")
+	_, ok = resolve(workDir)
+	assert.False(t, ok)
+	writeGlobal("version: \"1.0\"\nsettings:\n  hooks:\n    pre_exec: [\"audit.sh\"]\n")
+	_, ok = resolve(workDir)
+	assert.False(t, ok)
+	writeGlobal("version: \"1.0\"\n")
+
+	// 环境变量指定版本时不走快速路径
+	t.Setenv("VMAN_KUBECTL_VERSION", "1.29.0")
+	_, ok = resolve(workDir)
+	assert.False(t, ok)
+	t.Setenv("VMAN_KUBECTL_VERSION", "")
+
+	// 上层目录有项目级版本文件时不走快速路径
+	project := t.TempDir()
+	nested := filepath.Join(project, "sub")
+	require.NoError(t, os.MkdirAll(nested, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(project, ".tool-versions"), []byte("kubectl 1.29.0\n"), 0644))
+	_, ok = resolve(nested)
+	assert.False(t, ok)
+
+	// 安装了多个版本时需要完整的版本解析
+	install("1.30.0")
+	_, ok = resolve(workDir)
+	assert.False(t, ok)
+}
+
+func TestFastPathEnabled(t *testing.T) {
+	t.Setenv(types.EnvVmanFastPath, "1")
+	assert.True(t, FastPathEnabled())
+	t.Setenv(types.EnvVmanFastPath, "0")
+	assert.False(t, FastPathEnabled())
+
+	originalMarkers := containerMarkers
+	defer func() { containerMarkers = originalMarkers }()
+	t.Setenv("container", "")
+	t.Setenv("KUBERNETES_SERVICE_HOST", "")
+	t.Setenv(types.EnvVmanFastPath, "auto")
+
+	containerMarkers = []string{filepath.Join(t.TempDir(), ".dockerenv")}
+	assert.False(t, FastPathEnabled())
+	require.NoError(t, os.WriteFile(containerMarkers[0], nil, 0644))
+	assert.True(t, FastPathEnabled())
+}
@@ -0,0 +1,10 @@
+//go:build !windows
+
+package proxy
+
+import "syscall"
+
+// execReplace 以 exec 替换当前进程，信号和退出状态直接由工具处理
+func execReplace(execPath string, args []string, env []string) error {
+	return syscall.Exec(execPath, append([]string{execPath}, args...), env)
+}
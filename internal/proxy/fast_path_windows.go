@@ -0,0 +1,28 @@
+//go:build windows
+
+package proxy
+
+import (
+	"os"
+	"os/exec"
+)
+
+// execReplace Windows 不支持替换当前进程，启动工具并等待退出后以相同的状态退出
+func execReplace(execPath string, args []string, env []string) error {
+	cmd := exec.Command(execPath, args...)
+	cmd.Env = env
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	if err := cmd.Wait(); err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			ExitWithStatus(exitErr)
+		}
+		return err
+	}
+	os.Exit(0)
+	return nil
+}
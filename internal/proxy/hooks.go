@@ -0,0 +1,215 @@
+package proxy
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// 钩子事件
+const (
+	HookPreExec     = "pre-exec"
+	HookPostExec    = "post-exec"
+	HookPostInstall = "post-install"
+)
+
+// hookEnvLine 匹配钩子输出的环境变量行，如 "export KUBECONFIG=/path"
+var hookEnvLine = regexp.MustCompile(`^export\s+([A-Za-z_][A-Za-z0-9_]*)=(.*)$`)
+
+// HookContext 钩子执行上下文，以环境变量的形式传给钩子脚本
+type HookContext struct {
+	Event    string
+	Tool     string
+	Version  string
+	ExecPath string
+	Args     []string
+	WorkDir  string
+	ExitCode int // 仅post-exec钩子
+
+	// InstallPath、DownloadPath 版本目录和下载的文件，仅post-install钩子
+	InstallPath  string
+	DownloadPath string
+}
+
+// HookRunner 钩子执行器
+type HookRunner struct {
+	logger *logrus.Logger
+	stderr io.Writer
+}
+
+// NewHookRunner 创建钩子执行器
+func NewHookRunner(logger *logrus.Logger) *HookRunner {
+	return &HookRunner{
+		logger: logger,
+		stderr: os.Stderr,
+	}
+}
+
+// Run 依次执行钩子脚本，返回钩子输出的环境变量
+//
+// 钩子标准输出中形如 export KEY=VALUE 的行会作为环境变量传给后续钩子和工具，
+// 其他输出和标准错误会原样显示给用户。任一钩子失败或超时时停止执行并返回错误。
+func (hr *HookRunner) Run(ctx context.Context, scripts []string, timeout time.Duration, hc *HookContext) (map[string]string, error) {
+	exported := make(map[string]string)
+
+	for _, script := range scripts {
+		if strings.TrimSpace(script) == "" {
+			continue
+		}
+
+		hr.logger.Debugf("Running %s hook for %s: %s", hc.Event, hc.Tool, script)
+
+		output, err := hr.runScript(ctx, script, timeout, hc, exported)
+		hr.parseOutput(output, exported)
+		if err != nil {
+			return exported, fmt.Errorf("%s hook %q: %w", hc.Event, script, err)
+		}
+	}
+
+	return exported, nil
+}
+
+// runScript 执行单个钩子脚本
+func (hr *HookRunner) runScript(ctx context.Context, script string, timeout time.Duration, hc *HookContext, exported map[string]string) ([]byte, error) {
+	hookCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(hookCtx, "cmd", "/C", script)
+	} else {
+		cmd = exec.CommandContext(hookCtx, "sh", "-c", script)
+	}
+
+	if hc.WorkDir != "" {
+		cmd.Dir = hc.WorkDir
+	}
+	cmd.Env = append(os.Environ(), hc.environ()...)
+	for key, value := range exported {
+		cmd.Env = append(cmd.Env, key+"="+value)
+	}
+
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = hr.stderr
+	// 脚本启动的后台进程可能持有输出管道，超时后不再等待
+	cmd.WaitDelay = time.Second
+
+	err := cmd.Run()
+	if errors.Is(hookCtx.Err(), context.DeadlineExceeded) {
+		return stdout.Bytes(), fmt.Errorf("timed out after %s", timeout)
+	}
+	return stdout.Bytes(), err
+}
+
+// parseOutput 解析钩子输出，提取环境变量，其余内容转发给用户
+func (hr *HookRunner) parseOutput(output []byte, exported map[string]string) {
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if match := hookEnvLine.FindStringSubmatch(strings.TrimSpace(line)); match != nil {
+			exported[match[1]] = unquoteHookValue(match[2])
+			continue
+		}
+		fmt.Fprintln(hr.stderr, line)
+	}
+}
+
+// environ 生成传给钩子的环境变量
+func (hc *HookContext) environ() []string {
+	env := []string{
+		"VMAN_HOOK=" + hc.Event,
+		"VMAN_TOOL=" + hc.Tool,
+		"VMAN_TOOL_VERSION=" + hc.Version,
+		"VMAN_TOOL_PATH=" + hc.ExecPath,
+		"VMAN_ARGS=" + strings.Join(hc.Args, " "),
+		"VMAN_ARGC=" + strconv.Itoa(len(hc.Args)),
+		"VMAN_CWD=" + hc.WorkDir,
+	}
+	for i, arg := range hc.Args {
+		env = append(env, fmt.Sprintf("VMAN_ARG_%d=%s", i+1, arg))
+	}
+	if hc.Event == HookPostExec {
+		env = append(env, "VMAN_EXIT_CODE="+strconv.Itoa(hc.ExitCode))
+	}
+	if hc.Event == HookPostInstall {
+		env = append(env, "VMAN_INSTALL_PATH="+hc.InstallPath, "VMAN_DOWNLOAD_PATH="+hc.DownloadPath)
+	}
+	return env
+}
+
+// HookScripts 返回钩子目录中事件对应的脚本命令，全局脚本在前
+// 钩子目录下的脚本作用于所有工具，以工具名命名的子目录下的脚本只作用于该工具；
+// Windows 上脚本文件名需带 .cmd 或 .bat 扩展名
+func HookScripts(dir, tool, event string) []string {
+	if dir == "" {
+		return nil
+	}
+
+	names := []string{event}
+	if runtime.GOOS == "windows" {
+		names = []string{event + ".cmd", event + ".bat"}
+	}
+
+	var scripts []string
+	for _, d := range []string{dir, filepath.Join(dir, tool)} {
+		for _, name := range names {
+			path := filepath.Join(d, name)
+			info, err := os.Stat(path)
+			if err != nil || !info.Mode().IsRegular() {
+				continue
+			}
+			scripts = append(scripts, hookCommand(path, info.Mode()))
+			break
+		}
+	}
+	return scripts
+}
+
+// hookCommand 将脚本文件路径转换为可以交给 shell 执行的命令，没有执行权限的脚本用 sh 执行
+func hookCommand(path string, mode os.FileMode) string {
+	if runtime.GOOS == "windows" {
+		return `"` + path + `"`
+	}
+	quoted := "'" + strings.ReplaceAll(path, "'", `'\''`) + "'"
+	if mode&0111 == 0 {
+		return "sh " + quoted
+	}
+	return quoted
+}
+
+// unquoteHookValue 去掉值两侧的引号
+func unquoteHookValue(value string) string {
+	value = strings.TrimSpace(value)
+	if len(value) >= 2 {
+		if (value[0] == '"' && value[len(value)-1] == '"') || (value[0] == '\'' && value[len(value)-1] == '\'') {
+			return value[1 : len(value)-1]
+		}
+	}
+	return value
+}
+
+// exitCodeOf 获取命令的退出码，被信号终止时为 128 加信号编号
+func exitCodeOf(err error) int {
+	if err == nil {
+		return 0
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return ExitStatus(exitErr)
+	}
+	return -1
+}
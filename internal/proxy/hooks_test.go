@@ -0,0 +1,77 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestHookRunner() (*HookRunner, *bytes.Buffer) {
+	var stderr bytes.Buffer
+	runner := NewHookRunner(logrus.New())
+	runner.stderr = &stderr
+	return runner, &stderr
+}
+
+func TestHookRunner(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook scripts use sh")
+	}
+
+	hc := &HookContext{
+		Event:   HookPreExec,
+		Tool:    "terraform",
+		Version: "1.6.0",
+		Args:    []string{"apply", "-auto-approve"},
+		WorkDir: t.TempDir(),
+	}
+
+	t.Run("ExportsEnvironment", func(t *testing.T) {
+		runner, stderr := newTestHookRunner()
+		env, err := runner.Run(context.Background(), []string{
+			`echo "export KUBECONFIG='/etc/$VMAN_TOOL'"`,
+			`echo "export TF_ARGS=$VMAN_ARGS"; echo "notice: $VMAN_ARG_1 in $VMAN_TOOL_VERSION"`,
+			`test "$KUBECONFIG" = /etc/terraform`,
+		}, time.Second, hc)
+		require.NoError(t, err)
+		assert.Equal(t, "/etc/terraform", env["KUBECONFIG"])
+		assert.Equal(t, "apply -auto-approve", env["TF_ARGS"])
+		assert.Contains(t, stderr.String(), "notice: apply in 1.6.0")
+	})
+
+	t.Run("FailureStops", func(t *testing.T) {
+		runner, _ := newTestHookRunner()
+		env, err := runner.Run(context.Background(), []string{
+			`echo "refusing to run in prod" >&2; exit 1`,
+			`echo export SHOULD_NOT_RUN=1`,
+		}, time.Second, hc)
+		assert.Error(t, err)
+		assert.NotContains(t, env, "SHOULD_NOT_RUN")
+	})
+
+	t.Run("Timeout", func(t *testing.T) {
+		runner, _ := newTestHookRunner()
+		start := time.Now()
+		_, err := runner.Run(context.Background(), []string{"sleep 5"}, 100*time.Millisecond, hc)
+		require.Error(t, err)
+		assert.True(t, strings.Contains(err.Error(), "timed out"))
+		assert.Less(t, time.Since(start), 3*time.Second)
+	})
+
+	t.Run("PostExecExitCode", func(t *testing.T) {
+		runner, stderr := newTestHookRunner()
+		post := *hc
+		post.Event = HookPostExec
+		post.ExitCode = 3
+		_, err := runner.Run(context.Background(), []string{`echo "exit=$VMAN_EXIT_CODE hook=$VMAN_HOOK"`}, time.Second, &post)
+		require.NoError(t, err)
+		assert.Contains(t, stderr.String(), "exit=3 hook=post-exec")
+	})
+}
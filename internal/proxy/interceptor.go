@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -12,6 +13,7 @@ import (
 
 	"github.com/songzhibin97/vman/internal/config"
 	"github.com/songzhibin97/vman/internal/version"
+	"github.com/songzhibin97/vman/pkg/types"
 )
 
 // CommandProxy 命令代理接口
@@ -45,6 +47,27 @@ type CommandProxy interface {
 
 	// GetProxyStatus 获取代理状态
 	GetProxyStatus() *ProxyStatus
+
+	// CheckPath 检查受管工具在PATH中的优先级
+	CheckPath() (*PathReport, error)
+
+	// FixPath 修复指定shell中shims目录在PATH中的优先级，返回修改的配置文件
+	FixPath(shellType string) (string, error)
+
+	// SetReservedNames 设置不能用作垫片名称的命令（如 vman 本身）
+	SetReservedNames(names []string)
+
+	// CheckShimName 检查垫片名称是否与现有垫片或保留名称冲突
+	CheckShimName(entry ShimEntry) error
+
+	// CheckShimCollisions 检查所有垫片的名称冲突
+	CheckShimCollisions() ([]ShimCollision, error)
+
+	// CheckShims 检查shims目录是否可写，以及垫片是否缺失或损坏
+	CheckShims() (*ShimReport, error)
+
+	// RepairShims 重新生成缺失或损坏的垫片
+	RepairShims(problems []ShimProblem) error
 }
 
 // ProxyStatus 代理状态
@@ -69,8 +92,10 @@ type DefaultCommandProxy struct {
 	pathManager     PathManager
 	symlinkManager  SymlinkManager
 	shellIntegrator ShellIntegrator
+	hookRunner      *HookRunner
 	shimsDir        string
 	vmanPath        string
+	reservedNames   []string
 }
 
 // NewCommandProxy 创建新的命令代理
@@ -87,8 +112,7 @@ func NewCommandProxyWithFs(
 	configManager config.Manager,
 	versionManager version.Manager,
 ) CommandProxy {
-	homeDir, _ := os.UserHomeDir()
-	shimsDir := filepath.Join(homeDir, ".vman", "shims")
+	shimsDir := defaultShimsDir()
 	vmanPath := "vman" // 假设vman在PATH中
 
 	// 创建各个管理器
@@ -99,9 +123,11 @@ func NewCommandProxyWithFs(
 	versionResolver := NewVersionResolverWithFs(fs, configManager, versionManager)
 	commandRouter := NewCommandRouterWithFs(fs, versionResolver, contextManager, pathManager)
 
+	logger := logrus.New()
+
 	return &DefaultCommandProxy{
 		fs:              fs,
-		logger:          logrus.New(),
+		logger:          logger,
 		configManager:   configManager,
 		versionManager:  versionManager,
 		commandRouter:   commandRouter,
@@ -110,17 +136,130 @@ func NewCommandProxyWithFs(
 		pathManager:     pathManager,
 		symlinkManager:  symlinkManager,
 		shellIntegrator: shellIntegrator,
+		hookRunner:      NewHookRunner(logger),
 		shimsDir:        shimsDir,
 		vmanPath:        vmanPath,
 	}
 }
 
+// defaultShimsDir 默认shims目录
+func defaultShimsDir() string {
+	homeDir, _ := os.UserHomeDir()
+	return types.DefaultConfigPaths(homeDir).ShimsDir
+}
+
 // InterceptCommand 拦截并执行命令
 func (cp *DefaultCommandProxy) InterceptCommand(cmd string, args []string) error {
 	cp.logger.Debugf("Intercepting command: %s %v", cmd, args)
 
 	ctx := context.Background()
-	return cp.commandRouter.InterceptCommand(ctx, cmd, args)
+
+	var settings types.Settings
+	var alias *types.CommandAlias
+	if globalConfig, err := cp.configManager.LoadGlobal(); err == nil {
+		settings = globalConfig.Settings
+		if a, ok := globalConfig.Aliases[cmd]; ok {
+			alias = &a
+		}
+	}
+	hooks, envSettings, limitSettings := settings.Hooks, settings.Env, settings.Limits
+	invoked := cmd
+
+	// 别名展开为实际的工具、版本和预设参数
+	if alias != nil {
+		cp.logger.Debugf("Expanding alias %s to %s %v", cmd, alias.Target(), alias.Args)
+		cmd = alias.Tool
+		args = append(append([]string{}, alias.Args...), args...)
+	}
+
+	metadata, _ := cp.configManager.LoadToolConfig(cmd)
+	cp.healShim(invoked, alias != nil, metadata, settings)
+
+	envPolicy := envSettings.PolicyFor(cmd, metadata)
+	limits := limitSettings.LimitsFor(cmd, metadata)
+
+	// 项目配置中的默认参数插入到别名参数和用户参数之前
+	var defaults types.ToolDefaults
+	if workDir, err := os.Getwd(); err == nil {
+		var configPath string
+		defaults, configPath = ProjectDefaults(cp.fs, cp.configManager, cmd, workDir)
+		if len(defaults.Denied) > 0 {
+			cp.logger.Warnf("Ignoring defaults.env %s for %s from %s: project config cannot set loader or interpreter variables", strings.Join(defaults.Denied, ", "), cmd, configPath)
+		}
+		if !defaults.IsEmpty() {
+			cp.logger.Debugf("Applying defaults for %s from %s: %v", cmd, configPath, defaults.Args)
+			args = append(append([]string{}, defaults.Args...), args...)
+		}
+	}
+
+	sandbox := SandboxFor(metadata)
+	deprecationPolicy := settings.Resolution.DeprecationPolicy()
+	checkDeprecation := metadata != nil && len(metadata.VersionConfig.Deprecated) > 0 && deprecationPolicy != types.DeprecationIgnore
+
+	preExec, postExec := hooks.PreExecFor(cmd), hooks.PostExecFor(cmd)
+	plain := alias == nil && len(preExec) == 0 && len(postExec) == 0 &&
+		envPolicy.IsEmpty() && limits.IsEmpty() && len(defaults.Env) == 0 && sandbox == nil && !checkDeprecation
+	if plain {
+		return cp.commandRouter.InterceptCommand(ctx, cmd, args)
+	}
+
+	var result *RouteResult
+	var err error
+	if alias != nil && alias.Version != "" {
+		result, err = cp.commandRouter.RouteCommandWithVersion(ctx, cmd, alias.Version, args)
+	} else {
+		result, err = cp.commandRouter.RouteCommand(ctx, cmd, args)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to route command: %w", err)
+	}
+	if checkDeprecation {
+		warning, err := CheckDeprecation(cp.fs, filepath.Join(filepath.Dir(defaultStatsPath()), DeprecationsFile), metadata, result.Version, deprecationPolicy, time.Now())
+		if err != nil {
+			return err
+		}
+		if warning != "" {
+			cp.logger.Warn(warning)
+		}
+	}
+	result.EnvPolicy = envPolicy
+	result.Limits = limits
+	result.Sandbox = sandbox
+	if result.Env == nil {
+		result.Env = make(map[string]string)
+	}
+	for key, value := range defaults.Env {
+		result.Env[key] = value
+	}
+
+	hookContext := &HookContext{
+		Event:    HookPreExec,
+		Tool:     result.ToolName,
+		Version:  result.Version,
+		ExecPath: result.ExecutablePath,
+		Args:     args,
+		WorkDir:  result.WorkDir,
+	}
+
+	// 执行前钩子失败时不执行命令
+	exported, err := cp.hookRunner.Run(ctx, preExec, hooks.GetTimeout(), hookContext)
+	if err != nil {
+		return err
+	}
+	for key, value := range exported {
+		result.Env[key] = value
+	}
+
+	execErr := cp.commandRouter.ExecuteCommand(ctx, result)
+
+	// 执行后钩子失败只记录警告，保留命令本身的退出状态
+	hookContext.Event = HookPostExec
+	hookContext.ExitCode = exitCodeOf(execErr)
+	if _, err := cp.hookRunner.Run(ctx, postExec, hooks.GetTimeout(), hookContext); err != nil {
+		cp.logger.Warnf("%v", err)
+	}
+
+	return execErr
 }
 
 // ExecuteCommand 执行指定路径的命令
@@ -140,8 +279,27 @@ func (cp *DefaultCommandProxy) ExecuteCommand(toolPath string, args []string) er
 }
 
 // GenerateShim 生成命令垫片
+// 垫片名称与其他工具、别名或保留名称冲突时拒绝生成
 func (cp *DefaultCommandProxy) GenerateShim(tool, version string) error {
-	cp.logger.Infof("Generating shim for %s@%s", tool, version)
+	shimName := ShimNameFor(cp.configManager, tool)
+	if err := cp.CheckShimName(ShimEntry{Name: shimName, Kind: ShimKindTool, Target: tool}); err != nil {
+		return err
+	}
+	return cp.generateShim(tool, shimName, version)
+}
+
+// generateShim 以指定名称生成工具垫片
+func (cp *DefaultCommandProxy) generateShim(tool, shimName, version string) error {
+	cp.logger.Infof("Generating shim %s for %s@%s", shimName, tool, version)
+
+	// 系统版本只需要生成shim，由shim在执行时查找PATH中的工具
+	if version == types.SystemVersion {
+		shimPath := filepath.Join(cp.shimsDir, shimName)
+		if err := cp.shellIntegrator.GenerateShim(tool, shimPath, cp.vmanPath); err != nil {
+			return fmt.Errorf("failed to generate shim script: %w", err)
+		}
+		return nil
+	}
 
 	// 获取工具的二进制路径
 	binaryPath, err := cp.versionManager.GetVersionPath(tool, version)
@@ -150,13 +308,13 @@ func (cp *DefaultCommandProxy) GenerateShim(tool, version string) error {
 	}
 
 	// 生成shim文件
-	shimPath := filepath.Join(cp.shimsDir, tool)
+	shimPath := filepath.Join(cp.shimsDir, shimName)
 	if err := cp.shellIntegrator.GenerateShim(tool, shimPath, cp.vmanPath); err != nil {
 		return fmt.Errorf("failed to generate shim script: %w", err)
 	}
 
 	// 创建符号链接
-	if err := cp.symlinkManager.CreateToolSymlinks(tool, version, binaryPath, cp.shimsDir); err != nil {
+	if err := cp.symlinkManager.CreateToolSymlinks(shimName, version, binaryPath, cp.shimsDir); err != nil {
 		cp.logger.Warnf("Failed to create symlinks for %s: %v", tool, err)
 		// 继续执行，因为shim文件已经创建
 	}
@@ -170,13 +328,14 @@ func (cp *DefaultCommandProxy) RemoveShim(tool string) error {
 	cp.logger.Infof("Removing shim for: %s", tool)
 
 	// 移除shim文件
-	shimPath := filepath.Join(cp.shimsDir, tool)
+	shimName := ShimNameFor(cp.configManager, tool)
+	shimPath := filepath.Join(cp.shimsDir, shimName)
 	if err := cp.fs.Remove(shimPath); err != nil && !os.IsNotExist(err) {
 		cp.logger.Warnf("Failed to remove shim file %s: %v", shimPath, err)
 	}
 
 	// 移除符号链接
-	if err := cp.symlinkManager.RemoveToolSymlinks(tool, cp.shimsDir); err != nil {
+	if err := cp.symlinkManager.RemoveToolSymlinks(shimName, cp.shimsDir); err != nil {
 		cp.logger.Warnf("Failed to remove symlinks for %s: %v", tool, err)
 	}
 
@@ -191,7 +350,7 @@ func (cp *DefaultCommandProxy) UpdateShims() error {
 
 // GetShimPath 获取垫片路径
 func (cp *DefaultCommandProxy) GetShimPath(tool string) string {
-	return filepath.Join(cp.shimsDir, tool)
+	return filepath.Join(cp.shimsDir, ShimNameFor(cp.configManager, tool))
 }
 
 // SetupProxy 设置代理环境
@@ -257,14 +416,38 @@ func (cp *DefaultCommandProxy) RehashShims() error {
 		cp.logger.Warnf("Failed to clear existing shims: %v", err)
 	}
 
-	// 获取所有已安装的工具
-	tools, err := cp.versionManager.ListAllTools()
+	// 获取所有已安装的工具和命令别名
+	entries, err := CollectShimEntries(cp.configManager, cp.versionManager)
 	if err != nil {
-		return fmt.Errorf("failed to list tools: %w", err)
+		return err
 	}
 
-	// 为每个工具生成shim
-	for _, tool := range tools {
+	// 同名的垫片会互相覆盖，全部跳过并在最后报告
+	collisions := DetectShimCollisions(entries, cp.reservedNames)
+	blocked := make(map[string]bool, len(collisions))
+	for _, collision := range collisions {
+		blocked[shimKey(collision.Name)] = true
+	}
+
+	tools := 0
+	for _, entry := range entries {
+		if blocked[shimKey(entry.Name)] {
+			cp.logger.Warnf("Skipping shim %s because of a name collision", entry.Name)
+			continue
+		}
+
+		// 为命令别名生成shim
+		if entry.Kind == ShimKindAlias {
+			shimPath := filepath.Join(cp.shimsDir, entry.Name)
+			if err := cp.shellIntegrator.GenerateShim(entry.Name, shimPath, cp.vmanPath); err != nil {
+				cp.logger.Warnf("Failed to generate shim for alias %s: %v", entry.Name, err)
+			}
+			continue
+		}
+
+		tool := entry.Target
+		tools++
+
 		// 获取当前版本
 		currentVersion, err := cp.versionManager.GetCurrentVersion(tool)
 		if err != nil {
@@ -283,15 +466,55 @@ func (cp *DefaultCommandProxy) RehashShims() error {
 		}
 
 		// 生成shim
-		if err := cp.GenerateShim(tool, currentVersion); err != nil {
+		if err := cp.generateShim(tool, entry.Name, currentVersion); err != nil {
 			cp.logger.Warnf("Failed to generate shim for %s@%s: %v", tool, currentVersion, err)
 		}
 	}
 
-	cp.logger.Infof("Rehashed shims for %d tools", len(tools))
+	cp.logger.Infof("Rehashed shims for %d tools", tools)
+	if len(collisions) > 0 {
+		return &ShimCollisionError{Collisions: collisions}
+	}
 	return nil
 }
 
+// SetReservedNames 设置不能用作垫片名称的命令
+func (cp *DefaultCommandProxy) SetReservedNames(names []string) {
+	cp.reservedNames = append([]string(nil), names...)
+}
+
+// CheckShimName 检查垫片名称是否与现有垫片或保留名称冲突
+// 同一来源（同一工具或同名别名）已有的垫片会被替换，不视为冲突
+func (cp *DefaultCommandProxy) CheckShimName(entry ShimEntry) error {
+	existing, err := CollectShimEntries(cp.configManager, cp.versionManager)
+	if err != nil {
+		return err
+	}
+
+	entries := []ShimEntry{entry}
+	for _, e := range existing {
+		if !e.sameSource(entry) {
+			entries = append(entries, e)
+		}
+	}
+
+	for _, collision := range DetectShimCollisions(entries, cp.reservedNames) {
+		if shimKey(collision.Name) == shimKey(entry.Name) {
+			return &ShimCollisionError{Collisions: []ShimCollision{collision}}
+		}
+	}
+	return nil
+}
+
+// CheckShimCollisions 检查所有垫片的名称冲突
+func (cp *DefaultCommandProxy) CheckShimCollisions() ([]ShimCollision, error) {
+	entries, err := CollectShimEntries(cp.configManager, cp.versionManager)
+	if err != nil {
+		return nil, err
+	}
+	return DetectShimCollisions(entries, cp.reservedNames), nil
+}
+
 // GetProxyStatus 获取代理状态
 func (cp *DefaultCommandProxy) GetProxyStatus() *ProxyStatus {
 	// 检查shims目录是否在PATH中
@@ -320,6 +543,43 @@ func (cp *DefaultCommandProxy) GetProxyStatus() *ProxyStatus {
 	}
 }
 
+// CheckPath 检查受管工具在PATH中的优先级
+func (cp *DefaultCommandProxy) CheckPath() (*PathReport, error) {
+	tools, err := cp.versionManager.ListAllTools()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tools: %w", err)
+	}
+
+	report := &PathReport{
+		ShimsDir:   cp.shimsDir,
+		ShimsIndex: -1,
+		Tools:      make([]*ToolPrecedence, 0, len(tools)),
+	}
+
+	cleanShimsDir := filepath.Clean(cp.shimsDir)
+	for i, dir := range cp.pathManager.GetPathDirs() {
+		if filepath.Clean(dir) == cleanShimsDir {
+			report.ShimsInPath = true
+			report.ShimsIndex = i
+			break
+		}
+	}
+
+	for _, tool := range tools {
+		report.Tools = append(report.Tools, CheckToolPrecedence(cp.pathManager, ShimNameFor(cp.configManager, tool), cp.shimsDir))
+	}
+
+	return report, nil
+}
+
+// FixPath 修复指定shell中shims目录在PATH中的优先级
+func (cp *DefaultCommandProxy) FixPath(shellType string) (string, error) {
+	if shellType == "" {
+		shellType = cp.shellIntegrator.DetectShell()
+	}
+	return cp.pathManager.ApplyShimPathFix(shellType, cp.shimsDir)
+}
+
 // clearAllShims 清理所有shims
 func (cp *DefaultCommandProxy) clearAllShims() error {
 	if exists, _ := afero.Exists(cp.fs, cp.shimsDir); !exists {
@@ -0,0 +1,132 @@
+//go:build linux
+
+package proxy
+
+import (
+	"fmt"
+	"os"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// Landlock 常量，见 include/uapi/linux/landlock.h
+const (
+	landlockCreateRulesetVersion = 1 << 0
+	landlockRulePathBeneath      = 1
+
+	landlockAccessFsWriteFile  = 1 << 1
+	landlockAccessFsRemoveDir  = 1 << 4
+	landlockAccessFsRemoveFile = 1 << 5
+	landlockAccessFsMakeChar   = 1 << 6
+	landlockAccessFsMakeDir    = 1 << 7
+	landlockAccessFsMakeReg    = 1 << 8
+	landlockAccessFsMakeSock   = 1 << 9
+	landlockAccessFsMakeFifo   = 1 << 10
+	landlockAccessFsMakeBlock  = 1 << 11
+	landlockAccessFsMakeSym    = 1 << 12
+	landlockAccessFsRefer      = 1 << 13 // ABI 2
+	landlockAccessFsTruncate   = 1 << 14 // ABI 3
+
+	landlockAccessNetBindTCP    = 1 << 0 // ABI 4
+	landlockAccessNetConnectTCP = 1 << 1 // ABI 4
+
+	// landlockNetworkABI 支持限制网络访问的最低 ABI 版本
+	landlockNetworkABI = 4
+)
+
+// landlockRulesetAttr 对应 struct landlock_ruleset_attr
+type landlockRulesetAttr struct {
+	handledAccessFs  uint64
+	handledAccessNet uint64
+}
+
+// landlockPathBeneathAttr 对应 struct landlock_path_beneath_attr（packed，内核只读取前12字节）
+type landlockPathBeneathAttr struct {
+	allowedAccess uint64
+	parentFd      int32
+}
+
+// LandlockABI 返回内核支持的 Landlock ABI 版本
+func LandlockABI() (int, error) {
+	abi, _, errno := unix.Syscall(unix.SYS_LANDLOCK_CREATE_RULESET, 0, 0, landlockCreateRulesetVersion)
+	if errno != 0 {
+		return 0, fmt.Errorf("landlock is not available: %w", errno)
+	}
+	return int(abi), nil
+}
+
+// RestrictWrites 限制当前线程只能写入 writable 中的目录，offline 时同时禁止 TCP 连接和监听
+//
+// 限制只作用于调用线程，调用方需要先锁定线程，并在同一线程中 exec 要执行的程序
+func RestrictWrites(writable []string, offline bool) error {
+	abi, err := LandlockABI()
+	if err != nil {
+		return err
+	}
+
+	access := uint64(landlockAccessFsWriteFile | landlockAccessFsRemoveDir | landlockAccessFsRemoveFile |
+		landlockAccessFsMakeChar | landlockAccessFsMakeDir | landlockAccessFsMakeReg | landlockAccessFsMakeSock |
+		landlockAccessFsMakeFifo | landlockAccessFsMakeBlock | landlockAccessFsMakeSym)
+	if abi >= 2 {
+		access |= landlockAccessFsRefer
+	}
+	if abi >= 3 {
+		access |= landlockAccessFsTruncate
+	}
+
+	attr := landlockRulesetAttr{handledAccessFs: access}
+	attrSize := unsafe.Sizeof(attr.handledAccessFs)
+	if offline {
+		if abi < landlockNetworkABI {
+			return fmt.Errorf("restricting network access requires Landlock ABI %d, current ABI is %d", landlockNetworkABI, abi)
+		}
+		attr.handledAccessNet = landlockAccessNetBindTCP | landlockAccessNetConnectTCP
+		attrSize = unsafe.Sizeof(attr)
+	}
+
+	rulesetFd, _, errno := unix.Syscall(unix.SYS_LANDLOCK_CREATE_RULESET, uintptr(unsafe.Pointer(&attr)), attrSize, 0)
+	if errno != 0 {
+		return fmt.Errorf("failed to create landlock ruleset: %w", errno)
+	}
+	defer unix.Close(int(rulesetFd))
+
+	// 终端和 /dev/null 等设备始终可写
+	for _, dir := range append([]string{"/dev"}, writable...) {
+		if err := addLandlockPathRule(int(rulesetFd), dir, access); err != nil {
+			return err
+		}
+	}
+
+	if err := unix.Prctl(unix.PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0); err != nil {
+		return fmt.Errorf("failed to set no_new_privs: %w", err)
+	}
+	if _, _, errno := unix.Syscall(unix.SYS_LANDLOCK_RESTRICT_SELF, rulesetFd, 0, 0); errno != 0 {
+		return fmt.Errorf("failed to enforce landlock ruleset: %w", errno)
+	}
+	return nil
+}
+
+// addLandlockPathRule 允许写入目录，普通文件只能授予文件相关的权限
+func addLandlockPathRule(rulesetFd int, path string, access uint64) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil
+	}
+	if !info.IsDir() {
+		access &= landlockAccessFsWriteFile | landlockAccessFsTruncate
+	}
+
+	fd, err := unix.Open(path, unix.O_PATH|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer unix.Close(fd)
+
+	rule := landlockPathBeneathAttr{allowedAccess: access, parentFd: int32(fd)}
+	if _, _, errno := unix.Syscall6(unix.SYS_LANDLOCK_ADD_RULE, uintptr(rulesetFd), landlockRulePathBeneath,
+		uintptr(unsafe.Pointer(&rule)), 0, 0, 0); errno != 0 {
+		return fmt.Errorf("failed to allow writes to %s: %w", path, errno)
+	}
+	return nil
+}
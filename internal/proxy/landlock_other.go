@@ -0,0 +1,18 @@
+//go:build !linux
+
+package proxy
+
+import "errors"
+
+// errLandlockUnsupported 只有 Linux 支持 Landlock
+var errLandlockUnsupported = errors.New("landlock is only supported on Linux")
+
+// LandlockABI 只有 Linux 支持 Landlock
+func LandlockABI() (int, error) {
+	return 0, errLandlockUnsupported
+}
+
+// RestrictWrites 只有 Linux 支持 Landlock
+func RestrictWrites(writable []string, offline bool) error {
+	return errLandlockUnsupported
+}
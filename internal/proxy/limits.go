@@ -0,0 +1,84 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"syscall"
+	"time"
+
+	"github.com/songzhibin97/vman/pkg/types"
+)
+
+// limitKillDelay 超时后先请求进程退出，超过该时间仍未退出时强制终止
+const limitKillDelay = 10 * time.Second
+
+// withTimeoutLimit 设置了超时限制时返回带超时的上下文
+func withTimeoutLimit(ctx context.Context, limits types.ExecLimits) (context.Context, context.CancelFunc) {
+	if limits.Timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, limits.Timeout)
+}
+
+// configureTermination 超时后先请求命令退出，超过 limitKillDelay 仍未退出时强制终止
+func configureTermination(cmd *exec.Cmd, limits types.ExecLimits) {
+	if limits.Timeout <= 0 {
+		return
+	}
+	cmd.Cancel = func() error {
+		return terminateProcess(cmd.Process)
+	}
+	cmd.WaitDelay = limitKillDelay
+}
+
+// LimitedCommand 创建按资源限制执行的命令，设置了超时限制时超时后终止，执行结束后需要调用返回的 cancel
+// 供不经过 ExecuteCommand 执行工具的调用方（如 pkg/vman）使用，启动后调用 ApplyProcessLimits，结束后调用 LimitError
+func LimitedCommand(ctx context.Context, limits types.ExecLimits, execPath string, args ...string) (*exec.Cmd, context.Context, context.CancelFunc) {
+	execCtx, cancel := withTimeoutLimit(ctx, limits)
+	cmd := exec.CommandContext(execCtx, execPath, args...)
+	configureTermination(cmd, limits)
+	return cmd, execCtx, cancel
+}
+
+// applyProcessLimits 为已启动的进程设置内存上限和优先级，当前系统不支持的限制只给出警告
+func (cr *DefaultCommandRouter) applyProcessLimits(pid int, limits types.ExecLimits) {
+	if err := ApplyProcessLimits(pid, limits); err != nil {
+		cr.logger.Warn(err)
+	}
+}
+
+// ApplyProcessLimits 为已启动的进程设置内存上限和优先级，返回当前系统不支持的限制
+func ApplyProcessLimits(pid int, limits types.ExecLimits) error {
+	var errs []error
+	if limits.MaxMemory > 0 {
+		if err := setMemoryLimit(pid, uint64(limits.MaxMemory)); err != nil {
+			errs = append(errs, fmt.Errorf("failed to apply memory limit %s: %w", limits.MaxMemory, err))
+		}
+	}
+	if limits.Nice != 0 {
+		if err := setProcessPriority(pid, limits.Nice); err != nil {
+			errs = append(errs, fmt.Errorf("failed to set nice value %d: %w", limits.Nice, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// LimitError 命令因资源限制被终止时给出说明，保留原始错误以便获取退出状态
+func LimitError(ctx context.Context, toolName string, limits types.ExecLimits, err error) error {
+	if err == nil {
+		return nil
+	}
+	if limits.Timeout > 0 && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("%s exceeded the time limit of %s and was terminated: %w", toolName, limits.Timeout, err)
+	}
+
+	var exitErr *exec.ExitError
+	if limits.MaxMemory > 0 && errors.As(err, &exitErr) {
+		if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+			return fmt.Errorf("%s was terminated by %s with a memory limit of %s: %w", toolName, status.Signal(), limits.MaxMemory, err)
+		}
+	}
+	return err
+}
@@ -0,0 +1,45 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/songzhibin97/vman/pkg/types"
+)
+
+func TestExecuteCommand_Timeout(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	router := NewCommandRouterWithFs(afero.NewMemMapFs(), nil, nil, nil)
+
+	start := time.Now()
+	err := router.ExecuteCommand(context.Background(), &RouteResult{
+		ToolName:       "sleeper",
+		ExecutablePath: "/bin/sh",
+		Args:           []string{"-c", "exec sleep 5"},
+		Limits:         types.ExecLimits{Timeout: 100 * time.Millisecond},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "sleeper exceeded the time limit of 100ms")
+	assert.Less(t, time.Since(start), 3*time.Second)
+
+	// 保留退出状态
+	var exitErr *exec.ExitError
+	assert.True(t, errors.As(err, &exitErr))
+
+	// 未超时的命令不受影响
+	require.NoError(t, router.ExecuteCommand(context.Background(), &RouteResult{
+		ExecutablePath: "/bin/sh",
+		Args:           []string{"-c", "exit 0"},
+		Limits:         types.ExecLimits{Timeout: time.Minute, Nice: 5},
+	}))
+}
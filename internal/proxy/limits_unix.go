@@ -0,0 +1,18 @@
+//go:build !windows
+
+package proxy
+
+import (
+	"os"
+	"syscall"
+)
+
+// terminateProcess 请求进程退出
+func terminateProcess(process *os.Process) error {
+	return process.Signal(syscall.SIGTERM)
+}
+
+// setProcessPriority 设置进程的 nice 值
+func setProcessPriority(pid, nice int) error {
+	return syscall.Setpriority(syscall.PRIO_PROCESS, pid, nice)
+}
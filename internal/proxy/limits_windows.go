@@ -0,0 +1,18 @@
+//go:build windows
+
+package proxy
+
+import (
+	"errors"
+	"os"
+)
+
+// terminateProcess Windows 上没有终止信号，直接结束进程
+func terminateProcess(process *os.Process) error {
+	return process.Kill()
+}
+
+// setProcessPriority Windows 不支持 nice 值
+func setProcessPriority(pid, nice int) error {
+	return errors.New("nice is not supported on Windows")
+}
@@ -0,0 +1,10 @@
+//go:build linux
+
+package proxy
+
+import "golang.org/x/sys/unix"
+
+// setMemoryLimit 设置进程的虚拟内存上限（RLIMIT_AS）
+func setMemoryLimit(pid int, limit uint64) error {
+	return unix.Prlimit(pid, unix.RLIMIT_AS, &unix.Rlimit{Cur: limit, Max: limit}, nil)
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package proxy
+
+import "errors"
+
+// setMemoryLimit 只有 Linux 支持设置其他进程的内存上限
+func setMemoryLimit(pid int, limit uint64) error {
+	return errors.New("memory limits are only supported on Linux")
+}
@@ -0,0 +1,94 @@
+package proxy
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/songzhibin97/vman/pkg/types"
+)
+
+// 嵌套调用：make、npm 脚本等通过垫片启动的工具再调用其他受管工具时，子进程的当前目录
+// 可能不在父进程的项目中（如 make -C、脚本中的 cd）。代理执行工具时把项目目录和已解析的版本
+// 通过环境变量传给子进程，子进程在当前目录没有项目级配置时沿用父进程的解析结果。
+
+// SourceParent 沿用父进程解析结果时的版本来源
+const SourceParent = "parent"
+
+// projectSources 来自项目级配置或显式指定的解析来源，嵌套调用时优先于父进程的解析结果
+var projectSources = map[string]bool{"project": true, "env": true, "engines": true, "alias": true}
+
+// ParseResolutionEnv 解析 VMAN_RESOLUTION 的值，忽略格式不正确的条目
+func ParseResolutionEnv(value string) map[string]string {
+	versions := make(map[string]string)
+	for _, entry := range strings.Split(value, ",") {
+		tool, version, ok := strings.Cut(strings.TrimSpace(entry), "@")
+		if ok && tool != "" && version != "" {
+			versions[tool] = version
+		}
+	}
+	return versions
+}
+
+// FormatResolutionEnv 按工具名排序生成 VMAN_RESOLUTION 的值
+func FormatResolutionEnv(versions map[string]string) string {
+	entries := make([]string, 0, len(versions))
+	for tool, version := range versions {
+		entries = append(entries, tool+"@"+version)
+	}
+	sort.Strings(entries)
+	return strings.Join(entries, ",")
+}
+
+// NestedEnvironment 返回传给子进程的 VMAN_PROJECT_PATH 和 VMAN_RESOLUTION
+// 项目目录取自项目配置所在的目录，没有项目配置时沿用父进程传入的目录，再没有时使用当前目录
+func NestedEnvironment(resolution *VersionResolution, workDir string) map[string]string {
+	projectPath := os.Getenv(types.EnvVmanProjectPath)
+	if resolution.ConfigPath != "" {
+		projectPath = filepath.Dir(resolution.ConfigPath)
+	}
+	if projectPath == "" {
+		projectPath = workDir
+	}
+
+	versions := ParseResolutionEnv(os.Getenv(types.EnvVmanResolution))
+	versions[resolution.ToolName] = resolution.Version
+	return map[string]string{
+		types.EnvVmanProjectPath: projectPath,
+		types.EnvVmanResolution:  FormatResolutionEnv(versions),
+	}
+}
+
+// resolveNested 解析工具版本，由其他代理的工具启动且当前目录没有项目级配置时，
+// 先沿用父进程已解析的同一工具的版本，再从父进程的项目目录解析
+func (cr *DefaultCommandRouter) resolveNested(ctx context.Context, toolName, workDir string) (*VersionResolution, error) {
+	resolution, err := cr.versionManager.ResolveVersion(ctx, toolName, workDir)
+	if err == nil && projectSources[resolution.Source] {
+		return resolution, nil
+	}
+
+	parentPath := os.Getenv(types.EnvVmanProjectPath)
+	if version, ok := ParseResolutionEnv(os.Getenv(types.EnvVmanResolution))[toolName]; ok && cr.versionManager.IsVersionInstalled(toolName, version) {
+		cr.logger.Debugf("Using %s@%s resolved by parent process", toolName, version)
+		return &VersionResolution{
+			ToolName:    toolName,
+			Version:     version,
+			Source:      SourceParent,
+			ProjectPath: parentPath,
+			IsInstalled: true,
+			ResolvedAt:  time.Now(),
+		}, nil
+	}
+
+	if parentPath != "" && parentPath != workDir {
+		parent, parentErr := cr.versionManager.ResolveVersion(ctx, toolName, parentPath)
+		if parentErr == nil && projectSources[parent.Source] {
+			cr.logger.Debugf("Resolved %s from parent project %s", toolName, parentPath)
+			return parent, nil
+		}
+	}
+	return resolution, err
+}
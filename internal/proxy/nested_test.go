@@ -0,0 +1,101 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/songzhibin97/vman/pkg/types"
+)
+
+// nestedResolver 按目录返回固定解析结果的版本解析器
+type nestedResolver struct {
+	VersionResolver
+	resolutions map[string]*VersionResolution
+	installed   map[string]bool
+}
+
+func (r *nestedResolver) ResolveVersion(ctx context.Context, toolName, projectPath string) (*VersionResolution, error) {
+	if resolution, ok := r.resolutions[toolName+"|"+projectPath]; ok {
+		copied := *resolution
+		copied.ToolName = toolName
+		copied.ProjectPath = projectPath
+		return &copied, nil
+	}
+	return nil, fmt.Errorf("no version configured for %s", toolName)
+}
+
+func (r *nestedResolver) IsVersionInstalled(toolName, version string) bool {
+	return r.installed[toolName+"@"+version]
+}
+
+func TestResolutionEnv(t *testing.T) {
+	versions := ParseResolutionEnv("terraform@1.7.0, make@4.4,broken,@1.0,node@")
+	assert.Equal(t, map[string]string{"terraform": "1.7.0", "make": "4.4"}, versions)
+	assert.Equal(t, "make@4.4,terraform@1.7.0", FormatResolutionEnv(versions))
+}
+
+func TestNestedEnvironment(t *testing.T) {
+	t.Setenv(types.EnvVmanProjectPath, "")
+	t.Setenv(types.EnvVmanResolution, "")
+
+	env := NestedEnvironment(&VersionResolution{ToolName: "make", Version: "4.4", ConfigPath: "/src/app/.vman.yaml"}, "/src/app/build")
+	assert.Equal(t, "/src/app", env[types.EnvVmanProjectPath])
+	assert.Equal(t, "make@4.4", env[types.EnvVmanResolution])
+
+	// 子进程没有项目配置时沿用父进程的项目目录，并追加自己的版本
+	t.Setenv(types.EnvVmanProjectPath, env[types.EnvVmanProjectPath])
+	t.Setenv(types.EnvVmanResolution, env[types.EnvVmanResolution])
+	env = NestedEnvironment(&VersionResolution{ToolName: "terraform", Version: "1.7.0", Source: "global"}, "/tmp")
+	assert.Equal(t, "/src/app", env[types.EnvVmanProjectPath])
+	assert.Equal(t, "make@4.4,terraform@1.7.0", env[types.EnvVmanResolution])
+}
+
+func TestResolveNested(t *testing.T) {
+	resolver := &nestedResolver{
+		resolutions: map[string]*VersionResolution{
+			"terraform|/src/app":   {Version: "1.7.0", Source: "project", ConfigPath: "/src/app/.vman.yaml"},
+			"terraform|/tmp":       {Version: "1.5.0", Source: "global"},
+			"terraform|/src/other": {Version: "1.6.0", Source: "project", ConfigPath: "/src/other/.vman.yaml"},
+			"helm|/tmp":            {Version: "3.13.0", Source: "global"},
+		},
+		installed: map[string]bool{"helm@3.14.0": true},
+	}
+	router := &DefaultCommandRouter{logger: logrus.New(), versionManager: resolver}
+	ctx := context.Background()
+
+	// 不是嵌套调用时按当前目录解析
+	t.Setenv(types.EnvVmanProjectPath, "")
+	t.Setenv(types.EnvVmanResolution, "")
+	resolution, err := router.resolveNested(ctx, "terraform", "/tmp")
+	require.NoError(t, err)
+	assert.Equal(t, "1.5.0", resolution.Version)
+
+	// 当前目录只有全局配置时从父进程的项目目录解析
+	t.Setenv(types.EnvVmanProjectPath, "/src/app")
+	resolution, err = router.resolveNested(ctx, "terraform", "/tmp")
+	require.NoError(t, err)
+	assert.Equal(t, "1.7.0", resolution.Version)
+	assert.Equal(t, "project", resolution.Source)
+
+	// 当前目录有自己的项目配置时优先
+	resolution, err = router.resolveNested(ctx, "terraform", "/src/other")
+	require.NoError(t, err)
+	assert.Equal(t, "1.6.0", resolution.Version)
+
+	// 父进程已解析的同一工具的版本优先于父进程的项目目录
+	t.Setenv(types.EnvVmanResolution, "helm@3.14.0,terraform@9.9.9")
+	resolution, err = router.resolveNested(ctx, "helm", "/tmp")
+	require.NoError(t, err)
+	assert.Equal(t, "3.14.0", resolution.Version)
+	assert.Equal(t, SourceParent, resolution.Source)
+
+	// 父进程的版本未安装时忽略
+	resolution, err = router.resolveNested(ctx, "terraform", "/tmp")
+	require.NoError(t, err)
+	assert.Equal(t, "1.7.0", resolution.Version)
+}
@@ -9,6 +9,8 @@ import (
 
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/afero"
+
+	"github.com/songzhibin97/vman/pkg/types"
 )
 
 // PathManager PATH环境变量管理器接口
@@ -42,6 +44,15 @@ type PathManager interface {
 
 	// UpdateShellProfile 更新shell配置文件
 	UpdateShellProfile(content string) error
+
+	// FindExecutables 按PATH顺序查找所有同名可执行文件
+	FindExecutables(name string) []string
+
+	// GenerateShimPathFix 生成将shim目录移到PATH最前面的shell配置片段
+	GenerateShimPathFix(shellType, shimDir string) (string, error)
+
+	// ApplyShimPathFix 将修复片段写入指定shell的配置文件末尾，返回配置文件路径
+	ApplyShimPathFix(shellType, shimDir string) (string, error)
 }
 
 // DefaultPathManager 默认PATH管理器实现
@@ -230,7 +241,7 @@ func (pm *DefaultPathManager) CleanupShimPath(shimDir string) error {
 // BackupPath 备份当前PATH设置
 func (pm *DefaultPathManager) BackupPath() error {
 	currentPath := os.Getenv("PATH")
-	backupFile := filepath.Join(pm.homePath, ".vman", "path_backup")
+	backupFile := filepath.Join(types.DefaultConfigPaths(pm.homePath).ConfigDir, "path_backup")
 
 	// 确保备份目录存在
 	if err := pm.fs.MkdirAll(filepath.Dir(backupFile), 0755); err != nil {
@@ -248,7 +259,7 @@ func (pm *DefaultPathManager) BackupPath() error {
 
 // RestorePath 恢复PATH设置
 func (pm *DefaultPathManager) RestorePath() error {
-	backupFile := filepath.Join(pm.homePath, ".vman", "path_backup")
+	backupFile := filepath.Join(types.DefaultConfigPaths(pm.homePath).ConfigDir, "path_backup")
 
 	// 检查备份文件是否存在
 	if _, err := pm.fs.Stat(backupFile); os.IsNotExist(err) {
@@ -272,22 +283,16 @@ func (pm *DefaultPathManager) RestorePath() error {
 
 // GetShellProfile 获取shell配置文件路径
 func (pm *DefaultPathManager) GetShellProfile() string {
-	switch pm.shell {
-	case "bash":
-		// 优先检查 .bash_profile，然后是 .bashrc
-		bashProfile := filepath.Join(pm.homePath, ".bash_profile")
-		if exists, _ := afero.Exists(pm.fs, bashProfile); exists {
-			return bashProfile
-		}
-		return filepath.Join(pm.homePath, ".bashrc")
-	case "zsh":
-		return filepath.Join(pm.homePath, ".zshrc")
-	case "fish":
-		return filepath.Join(pm.homePath, ".config", "fish", "config.fish")
-	default:
-		// 默认使用 .profile
-		return filepath.Join(pm.homePath, ".profile")
+	return pm.shellProfile(pm.shell)
+}
+
+// shellProfile 获取指定shell的配置文件路径
+func (pm *DefaultPathManager) shellProfile(shell string) string {
+	if profile := ShellConfigPath(pm.fs, pm.homePath, shell); profile != "" {
+		return profile
 	}
+	// 默认使用 .profile
+	return filepath.Join(pm.homePath, ".profile")
 }
 
 // UpdateShellProfile 更新shell配置文件
@@ -0,0 +1,211 @@
+package proxy
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/spf13/afero"
+)
+
+// shimPathFixMarker PATH修复配置段的标记
+const shimPathFixMarker = "# vman path fix"
+
+// PathCandidate PATH中找到的可执行文件
+type PathCandidate struct {
+	Path   string `json:"path"`
+	Dir    string `json:"dir"`
+	IsShim bool   `json:"is_shim"`
+}
+
+// ToolPrecedence 工具在PATH中的优先级情况
+type ToolPrecedence struct {
+	Tool       string          `json:"tool"`
+	Candidates []PathCandidate `json:"candidates"`
+	Effective  string          `json:"effective,omitempty"` // 实际会被执行的文件
+	Shadowed   bool            `json:"shadowed"`            // 垫片被排在前面的其他文件遮蔽
+	NoShim     bool            `json:"no_shim"`             // PATH中找不到该工具的垫片
+}
+
+// HasConflict 是否存在问题
+func (tp *ToolPrecedence) HasConflict() bool {
+	return tp.Shadowed || tp.NoShim
+}
+
+// PathReport PATH检查报告
+type PathReport struct {
+	ShimsDir    string            `json:"shims_dir"`
+	ShimsInPath bool              `json:"shims_in_path"`
+	ShimsIndex  int               `json:"shims_index"` // shims目录在PATH中的位置，不在PATH中时为-1
+	Tools       []*ToolPrecedence `json:"tools"`
+}
+
+// HasConflicts 是否存在任何问题
+func (r *PathReport) HasConflicts() bool {
+	if !r.ShimsInPath {
+		return true
+	}
+	for _, tool := range r.Tools {
+		if tool.HasConflict() {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckToolPrecedence 检查工具在PATH中的优先级
+func CheckToolPrecedence(pm PathManager, tool, shimDir string) *ToolPrecedence {
+	result := &ToolPrecedence{
+		Tool:       tool,
+		Candidates: []PathCandidate{},
+	}
+
+	cleanShimDir := filepath.Clean(shimDir)
+	shimIndex := -1
+	for i, path := range pm.FindExecutables(tool) {
+		dir := filepath.Dir(path)
+		candidate := PathCandidate{
+			Path:   path,
+			Dir:    dir,
+			IsShim: filepath.Clean(dir) == cleanShimDir,
+		}
+		if candidate.IsShim && shimIndex < 0 {
+			shimIndex = i
+		}
+		result.Candidates = append(result.Candidates, candidate)
+	}
+
+	if len(result.Candidates) > 0 {
+		result.Effective = result.Candidates[0].Path
+	}
+	result.NoShim = shimIndex < 0
+	result.Shadowed = shimIndex > 0
+
+	return result
+}
+
+// FindExecutables 按PATH顺序查找所有同名可执行文件
+func (pm *DefaultPathManager) FindExecutables(name string) []string {
+	var names []string
+	if runtime.GOOS == "windows" && filepath.Ext(name) == "" {
+		for _, ext := range strings.Split(os.Getenv("PATHEXT"), ";") {
+			if ext != "" {
+				names = append(names, name+strings.ToLower(ext))
+			}
+		}
+		if len(names) == 0 {
+			names = []string{name + ".exe", name + ".cmd", name + ".bat"}
+		}
+	} else {
+		names = []string{name}
+	}
+
+	var results []string
+	seen := make(map[string]bool)
+	for _, dir := range pm.GetPathDirs() {
+		if dir == "" {
+			continue
+		}
+		for _, n := range names {
+			candidate := filepath.Join(dir, n)
+			if seen[candidate] {
+				continue
+			}
+			info, err := pm.fs.Stat(candidate)
+			if err != nil || info.IsDir() {
+				continue
+			}
+			if runtime.GOOS != "windows" && info.Mode()&0111 == 0 {
+				continue
+			}
+			seen[candidate] = true
+			results = append(results, candidate)
+		}
+	}
+
+	return results
+}
+
+// GenerateShimPathFix 生成将shim目录移到PATH最前面的shell配置片段
+func (pm *DefaultPathManager) GenerateShimPathFix(shellType, shimDir string) (string, error) {
+	switch shellType {
+	case "bash", "zsh", "sh":
+		return fmt.Sprintf(`export PATH="%s:$(printf '%%s' "$PATH" | tr ':' '\n' | grep -vxF '%s' | paste -sd: -)"`, shimDir, shimDir), nil
+	case "fish":
+		return fmt.Sprintf(`set -gx PATH "%s" (string match -v -- "%s" $PATH)`, shimDir, shimDir), nil
+	case "nu":
+		return fmt.Sprintf(`$env.PATH = ($env.PATH | split row (char esep) | where {|dir| $dir != '%s' } | prepend '%s')`, shimDir, shimDir), nil
+	case "xonsh":
+		return fmt.Sprintf(`$PATH = [r'%s'] + [d for d in $PATH if d != r'%s']`, shimDir, shimDir), nil
+	case "powershell", "pwsh":
+		return fmt.Sprintf(`$env:PATH = "%s" + [System.IO.Path]::PathSeparator + (($env:PATH -split [System.IO.Path]::PathSeparator | Where-Object { $_ -ne "%s" }) -join [System.IO.Path]::PathSeparator)`, shimDir, shimDir), nil
+	default:
+		return "", fmt.Errorf("unsupported shell type: %s", shellType)
+	}
+}
+
+// ApplyShimPathFix 将修复片段写入指定shell的配置文件末尾
+// 修复片段必须位于配置文件末尾，才能在其他PATH修改之后生效
+func (pm *DefaultPathManager) ApplyShimPathFix(shellType, shimDir string) (string, error) {
+	snippet, err := pm.GenerateShimPathFix(shellType, shimDir)
+	if err != nil {
+		return "", err
+	}
+
+	profilePath := pm.shellProfile(shellType)
+
+	var content string
+	if exists, _ := afero.Exists(pm.fs, profilePath); exists {
+		data, err := afero.ReadFile(pm.fs, profilePath)
+		if err != nil {
+			return "", fmt.Errorf("failed to read shell profile: %w", err)
+		}
+		content = removeMarkedSection(string(data), shimPathFixMarker)
+	}
+
+	content = strings.TrimRight(content, "\n")
+	if content != "" {
+		content += "\n\n"
+	}
+	content += shimPathFixMarker + "\n" + snippet + "\n" + shimPathFixMarker + "\n"
+
+	if err := pm.fs.MkdirAll(filepath.Dir(profilePath), 0755); err != nil {
+		return "", fmt.Errorf("failed to create profile directory: %w", err)
+	}
+	if err := afero.WriteFile(pm.fs, profilePath, []byte(content), 0644); err != nil {
+		return "", fmt.Errorf("failed to write shell profile: %w", err)
+	}
+
+	pm.logger.Infof("Applied shim path fix to: %s", profilePath)
+	return profilePath, nil
+}
+
+// removeMarkedSection 移除由标记行包围的配置段
+func removeMarkedSection(content, marker string) string {
+	lines := strings.Split(content, "\n")
+	var newLines []string
+	inSection := false
+
+	for _, line := range lines {
+		if strings.TrimSpace(line) == marker {
+			inSection = !inSection
+			continue
+		}
+		if !inSection {
+			newLines = append(newLines, line)
+		}
+	}
+
+	return strings.Join(newLines, "\n")
+}
+
+// ReplaceMarkedSection 移除由标记行包围的配置段，并将 body 包围在标记行中追加到末尾
+func ReplaceMarkedSection(content, marker, body string) string {
+	content = strings.TrimRight(removeMarkedSection(content, marker), "\n")
+	if content != "" {
+		content += "\n\n"
+	}
+	return content + marker + "\n" + strings.TrimRight(body, "\n") + "\n" + marker + "\n"
+}
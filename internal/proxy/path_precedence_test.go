@@ -0,0 +1,134 @@
+package proxy
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/songzhibin97/vman/pkg/types"
+)
+
+func writeExecutable(t *testing.T, dir, name string) string {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, []byte("#!/bin/sh\n"), 0755))
+	return path
+}
+
+func TestCheckToolPrecedence(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses unix executable bits")
+	}
+
+	root := t.TempDir()
+	sysDir := filepath.Join(root, "usr", "local", "bin")
+	shimDir := filepath.Join(root, "shims")
+	sysKubectl := writeExecutable(t, sysDir, "kubectl")
+	shimKubectl := writeExecutable(t, shimDir, "kubectl")
+	writeExecutable(t, shimDir, "terraform")
+
+	pm := NewPathManager()
+
+	t.Run("ShimsBehindSystemDir", func(t *testing.T) {
+		t.Setenv("PATH", strings.Join([]string{sysDir, shimDir}, string(os.PathListSeparator)))
+
+		result := CheckToolPrecedence(pm, "kubectl", shimDir)
+		require.Len(t, result.Candidates, 2)
+		assert.Equal(t, sysKubectl, result.Effective)
+		assert.False(t, result.Candidates[0].IsShim)
+		assert.True(t, result.Candidates[1].IsShim)
+		assert.True(t, result.Shadowed)
+		assert.True(t, result.HasConflict())
+	})
+
+	t.Run("ShimsFirst", func(t *testing.T) {
+		t.Setenv("PATH", strings.Join([]string{shimDir, sysDir}, string(os.PathListSeparator)))
+
+		result := CheckToolPrecedence(pm, "kubectl", shimDir)
+		assert.Equal(t, shimKubectl, result.Effective)
+		assert.False(t, result.Shadowed)
+		assert.False(t, result.HasConflict())
+	})
+
+	t.Run("NoShim", func(t *testing.T) {
+		t.Setenv("PATH", sysDir)
+
+		result := CheckToolPrecedence(pm, "kubectl", shimDir)
+		assert.True(t, result.NoShim)
+		assert.True(t, result.HasConflict())
+	})
+
+	t.Run("NonExecutableIgnored", func(t *testing.T) {
+		plainDir := filepath.Join(root, "plain")
+		require.NoError(t, os.MkdirAll(plainDir, 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(plainDir, "terraform"), []byte("x"), 0644))
+		t.Setenv("PATH", strings.Join([]string{plainDir, shimDir}, string(os.PathListSeparator)))
+
+		result := CheckToolPrecedence(pm, "terraform", shimDir)
+		require.Len(t, result.Candidates, 1)
+		assert.False(t, result.Shadowed)
+	})
+}
+
+func TestGenerateShimPathFix(t *testing.T) {
+	pm := NewPathManager()
+
+	for _, shell := range []string{"bash", "zsh", "fish", "nu", "xonsh", "powershell", "pwsh"} {
+		snippet, err := pm.GenerateShimPathFix(shell, "/home/u/.vman/shims")
+		assert.NoError(t, err, shell)
+		assert.Contains(t, snippet, "/home/u/.vman/shims", shell)
+	}
+
+	_, err := pm.GenerateShimPathFix("tcsh", "/home/u/.vman/shims")
+	assert.Error(t, err)
+}
+
+func TestRemoveMarkedSection(t *testing.T) {
+	content := strings.Join([]string{
+		"export FOO=1",
+		shimPathFixMarker,
+		"export PATH=old",
+		shimPathFixMarker,
+		"export BAR=2",
+	}, "\n")
+
+	assert.Equal(t, "export FOO=1\nexport BAR=2", removeMarkedSection(content, shimPathFixMarker))
+}
+
+func TestFindSystemExecutable(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses unix executable bits")
+	}
+
+	root := t.TempDir()
+	sysDir := filepath.Join(root, "usr", "bin")
+	shimDir := filepath.Join(root, "shims")
+	sysKubectl := writeExecutable(t, sysDir, "kubectl")
+	writeExecutable(t, shimDir, "kubectl")
+
+	resolver := NewVersionResolver(nil, nil).(*DefaultVersionResolver)
+	resolver.shimsDir = shimDir
+
+	t.Setenv("PATH", strings.Join([]string{shimDir, sysDir}, string(os.PathListSeparator)))
+
+	path, err := resolver.FindSystemExecutable("kubectl")
+	require.NoError(t, err)
+	assert.Equal(t, sysKubectl, path)
+	assert.True(t, resolver.IsVersionInstalled("kubectl", types.SystemVersion))
+
+	version, err := resolver.resolveVersionString("kubectl", types.SystemVersion)
+	require.NoError(t, err)
+	assert.Equal(t, types.SystemVersion, version)
+
+	// PATH变化后缓存失效
+	t.Setenv("PATH", shimDir)
+	_, err = resolver.FindSystemExecutable("kubectl")
+	assert.Error(t, err)
+	assert.False(t, resolver.IsVersionInstalled("kubectl", types.SystemVersion))
+}
@@ -0,0 +1,152 @@
+package proxy
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/afero"
+)
+
+// 项目特征
+const (
+	FeatureKubernetes = "kubernetes"
+	FeatureHelm       = "helm"
+)
+
+// kubernetesManifestDirs 常用于存放Kubernetes清单的目录
+var kubernetesManifestDirs = []string{"k8s", "kubernetes", "deploy", "deployment", "manifests", "charts", "helm"}
+
+// ToolSuggestion 根据项目类型推荐的工具
+type ToolSuggestion struct {
+	Tool   string `json:"tool"`
+	Reason string `json:"reason"`
+}
+
+// SuggestTools 根据检测到的项目上下文推荐需要在项目中固定版本的工具
+func SuggestTools(pc *ProjectContext) []ToolSuggestion {
+	var suggestions []ToolSuggestion
+	add := func(tool, reason string) {
+		for _, s := range suggestions {
+			if s.Tool == tool {
+				return
+			}
+		}
+		suggestions = append(suggestions, ToolSuggestion{Tool: tool, Reason: reason})
+	}
+
+	switch pc.ProjectType {
+	case "go":
+		add("go", "go.mod")
+		add("golangci-lint", "go project")
+	case "node":
+		add("node", "package.json")
+		switch pc.BuildSystem {
+		case "pnpm":
+			add("pnpm", "pnpm-lock.yaml")
+		case "yarn":
+			add("yarn", "yarn.lock")
+		}
+	case "python":
+		add("python", "python project")
+		if pc.BuildSystem == "poetry" {
+			add("poetry", "poetry.lock")
+		}
+	case "rust":
+		add("rust", "Cargo.toml")
+	case "java":
+		add("java", "java project")
+		if pc.BuildSystem == "gradle" {
+			add("gradle", "build.gradle")
+		} else {
+			add("maven", "pom.xml")
+		}
+	}
+
+	if pc.HasFeature(FeatureKubernetes) {
+		add("kubectl", "kubernetes manifests")
+	}
+	if pc.HasFeature(FeatureHelm) {
+		add("helm", "helm chart")
+	}
+
+	return suggestions
+}
+
+// HasFeature 检查项目是否具有指定特征
+func (pc *ProjectContext) HasFeature(feature string) bool {
+	for _, f := range pc.Features {
+		if f == feature {
+			return true
+		}
+	}
+	return false
+}
+
+// detectKubernetesFeatures 检测项目中的Kubernetes清单和Helm chart
+func (cm *DefaultContextManager) detectKubernetesFeatures(context *ProjectContext) {
+	rootPath := context.RootPath
+
+	hasHelm := cm.fileExists(filepath.Join(rootPath, "Chart.yaml")) ||
+		cm.fileExists(filepath.Join(rootPath, "helmfile.yaml"))
+	hasKubernetes := hasHelm ||
+		cm.fileExists(filepath.Join(rootPath, "kustomization.yaml")) ||
+		cm.fileExists(filepath.Join(rootPath, "skaffold.yaml"))
+
+	for _, dir := range kubernetesManifestDirs {
+		dirPath := filepath.Join(rootPath, dir)
+		if info, err := cm.fs.Stat(dirPath); err != nil || !info.IsDir() {
+			continue
+		}
+		_ = afero.Walk(cm.fs, dirPath, func(path string, info os.FileInfo, err error) error {
+			if err != nil || hasKubernetes && hasHelm {
+				return filepath.SkipDir
+			}
+			if info.IsDir() {
+				return nil
+			}
+			switch {
+			case info.Name() == "Chart.yaml":
+				hasHelm = true
+				hasKubernetes = true
+			case isKubernetesManifest(cm.fs, path):
+				hasKubernetes = true
+			}
+			return nil
+		})
+	}
+
+	if hasKubernetes {
+		context.Features = append(context.Features, FeatureKubernetes)
+	}
+	if hasHelm {
+		context.Features = append(context.Features, FeatureHelm)
+	}
+}
+
+// isKubernetesManifest 检查YAML文件是否为Kubernetes清单
+func isKubernetesManifest(fs afero.Fs, path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext != ".yaml" && ext != ".yml" {
+		return false
+	}
+
+	data, err := afero.ReadFile(fs, path)
+	if err != nil {
+		return false
+	}
+
+	var hasAPIVersion, hasKind bool
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		if bytes.HasPrefix(line, []byte("apiVersion:")) {
+			hasAPIVersion = true
+		} else if bytes.HasPrefix(line, []byte("kind:")) {
+			hasKind = true
+		}
+		if hasAPIVersion && hasKind {
+			return true
+		}
+	}
+	return false
+}
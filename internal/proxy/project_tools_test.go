@@ -0,0 +1,71 @@
+package proxy
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSuggestTools(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    map[string]string
+		expected []string
+		features []string
+	}{
+		{
+			name:     "Go",
+			files:    map[string]string{"go.mod": "module example\n"},
+			expected: []string{"go", "golangci-lint"},
+		},
+		{
+			name:     "NodeWithPnpm",
+			files:    map[string]string{"package.json": "{}", "pnpm-lock.yaml": ""},
+			expected: []string{"node", "pnpm"},
+		},
+		{
+			name:     "NodeWithNpm",
+			files:    map[string]string{"package.json": "{}"},
+			expected: []string{"node"},
+		},
+		{
+			name: "GoWithKubernetesManifests",
+			files: map[string]string{
+				"go.mod":                 "module example\n",
+				"deploy/service.yaml":    "apiVersion: v1\nkind: Service\n",
+				"deploy/values.yaml":     "replicas: 1\n",
+				"charts/app/Chart.yaml":  "name: app\n",
+				"charts/app/values.yaml": "image: app\n",
+			},
+			expected: []string{"go", "golangci-lint", "kubectl", "helm"},
+			features: []string{FeatureKubernetes, FeatureHelm},
+		},
+		{
+			name:     "YamlWithoutManifests",
+			files:    map[string]string{"k8s/notes.yaml": "key: value\n"},
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			for name, content := range tt.files {
+				require.NoError(t, afero.WriteFile(fs, "/project/"+name, []byte(content), 0644))
+			}
+
+			cm := NewContextManagerWithFs(fs, nil).(*DefaultContextManager)
+			pc := &ProjectContext{RootPath: "/project"}
+			cm.detectProjectFeatures(pc)
+
+			var tools []string
+			for _, s := range SuggestTools(pc) {
+				tools = append(tools, s.Tool)
+			}
+			assert.Equal(t, tt.expected, tools)
+			assert.Equal(t, tt.features, pc.Features)
+		})
+	}
+}
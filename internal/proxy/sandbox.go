@@ -0,0 +1,108 @@
+package proxy
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/songzhibin97/vman/pkg/types"
+	"github.com/songzhibin97/vman/pkg/utils"
+)
+
+// SandboxRunCommand 在 Landlock 限制下执行工具的 vman 内部子命令
+const SandboxRunCommand = "sandbox-run"
+
+// SandboxOptions 在沙箱中执行工具时的限制
+type SandboxOptions struct {
+	Writable []string // 除项目目录和临时目录外允许写入的目录
+	Offline  bool     // 禁止访问网络
+}
+
+// SandboxFor 根据工具定义生成沙箱设置，工具没有启用沙箱时返回 nil
+func SandboxFor(metadata *types.ToolMetadata) *SandboxOptions {
+	if metadata == nil || !metadata.Sandbox {
+		return nil
+	}
+	options := &SandboxOptions{Offline: metadata.SandboxOffline}
+	for _, dir := range metadata.SandboxWritable {
+		if expanded, err := utils.ExpandPath(dir); err == nil {
+			dir = expanded
+		}
+		options.Writable = append(options.Writable, dir)
+	}
+	return options
+}
+
+// writableDirs 沙箱中允许写入的目录：项目目录、临时目录和额外配置的目录，不存在的目录会被忽略
+func (o *SandboxOptions) writableDirs(projectPath string) []string {
+	candidates := append([]string{projectPath, os.TempDir()}, o.Writable...)
+	seen := make(map[string]bool)
+	var dirs []string
+	for _, dir := range candidates {
+		if dir == "" {
+			continue
+		}
+		dir = canonicalPath(dir)
+		if seen[dir] {
+			continue
+		}
+		if _, err := os.Stat(dir); err != nil {
+			continue
+		}
+		seen[dir] = true
+		dirs = append(dirs, dir)
+	}
+	return dirs
+}
+
+// Wrap 返回在沙箱中执行命令的可执行文件和参数
+func (o *SandboxOptions) Wrap(execPath string, args []string, projectPath string) (string, []string, error) {
+	wrappedPath, wrappedArgs, err := sandboxCommand(execPath, args, o.writableDirs(projectPath), o.Offline)
+	if err != nil {
+		return "", nil, fmt.Errorf("cannot run %s in sandbox: %w", filepath.Base(execPath), err)
+	}
+	return wrappedPath, wrappedArgs, nil
+}
+
+// bwrapArgs 生成 bubblewrap 的参数：整个文件系统只读挂载，只有 writable 中的目录可写
+func bwrapArgs(execPath string, args []string, writable []string, offline bool) []string {
+	bwrap := []string{"--ro-bind", "/", "/", "--dev-bind", "/dev", "/dev"}
+	for _, dir := range writable {
+		bwrap = append(bwrap, "--bind", dir, dir)
+	}
+	if offline {
+		bwrap = append(bwrap, "--unshare-net")
+	}
+	bwrap = append(bwrap, "--die-with-parent", "--", execPath)
+	return append(bwrap, args...)
+}
+
+// sandboxRunArgs 生成 vman sandbox-run 的参数
+func sandboxRunArgs(execPath string, args []string, writable []string, offline bool) []string {
+	run := []string{SandboxRunCommand}
+	for _, dir := range writable {
+		run = append(run, "--writable", dir)
+	}
+	if offline {
+		run = append(run, "--offline")
+	}
+	run = append(run, "--", execPath)
+	return append(run, args...)
+}
+
+// seatbeltProfile 生成 macOS sandbox-exec 的配置：禁止写入 writable 和 /dev 以外的路径
+func seatbeltProfile(writable []string, offline bool) string {
+	var b strings.Builder
+	b.WriteString("(version 1)\n(allow default)\n(deny file-write*)\n")
+	b.WriteString("(allow file-write* (subpath \"/dev\")")
+	for _, dir := range writable {
+		b.WriteString(" (subpath " + strconv.Quote(dir) + ")")
+	}
+	b.WriteString(")\n")
+	if offline {
+		b.WriteString("(deny network*)\n")
+	}
+	return b.String()
+}
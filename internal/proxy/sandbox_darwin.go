@@ -0,0 +1,17 @@
+//go:build darwin
+
+package proxy
+
+import (
+	"fmt"
+	"os/exec"
+)
+
+// sandboxCommand 使用系统自带的 sandbox-exec
+func sandboxCommand(execPath string, args []string, writable []string, offline bool) (string, []string, error) {
+	sandboxExec, err := exec.LookPath("sandbox-exec")
+	if err != nil {
+		return "", nil, fmt.Errorf("sandbox-exec not found: %w", err)
+	}
+	return sandboxExec, append([]string{"-p", seatbeltProfile(writable, offline), execPath}, args...), nil
+}
@@ -0,0 +1,31 @@
+//go:build linux
+
+package proxy
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// sandboxCommand 优先使用 bubblewrap，没有安装时通过 vman sandbox-run 使用 Landlock
+func sandboxCommand(execPath string, args []string, writable []string, offline bool) (string, []string, error) {
+	if bwrap, err := exec.LookPath("bwrap"); err == nil {
+		return bwrap, bwrapArgs(execPath, args, writable, offline), nil
+	}
+
+	abi, err := LandlockABI()
+	if err != nil {
+		return "", nil, errors.New("install bubblewrap (bwrap) or use a kernel with Landlock support")
+	}
+	if offline && abi < landlockNetworkABI {
+		return "", nil, fmt.Errorf("sandbox_offline requires bubblewrap (bwrap) or Landlock ABI %d (Linux 6.7), current ABI is %d", landlockNetworkABI, abi)
+	}
+
+	self, err := os.Executable()
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to locate vman executable: %w", err)
+	}
+	return self, sandboxRunArgs(execPath, args, writable, offline), nil
+}
@@ -0,0 +1,13 @@
+//go:build !linux && !darwin
+
+package proxy
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// sandboxCommand 当前系统不支持沙箱
+func sandboxCommand(execPath string, args []string, writable []string, offline bool) (string, []string, error) {
+	return "", nil, fmt.Errorf("sandboxed execution is not supported on %s", runtime.GOOS)
+}
@@ -0,0 +1,46 @@
+package proxy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/songzhibin97/vman/pkg/types"
+)
+
+func TestSandboxFor(t *testing.T) {
+	assert.Nil(t, SandboxFor(nil))
+	assert.Nil(t, SandboxFor(&types.ToolMetadata{Name: "kubectl"}))
+
+	home, _ := os.UserHomeDir()
+	options := SandboxFor(&types.ToolMetadata{Sandbox: true, SandboxOffline: true, SandboxWritable: []string{"~/.kube"}})
+	assert.True(t, options.Offline)
+	assert.Equal(t, []string{filepath.Join(home, ".kube")}, options.Writable)
+
+	// 不存在的目录被忽略，重复的目录只保留一个
+	project := canonicalPath(t.TempDir())
+	options = &SandboxOptions{Writable: []string{project, filepath.Join(project, "missing")}}
+	assert.Equal(t, []string{project, canonicalPath(os.TempDir())}, options.writableDirs(project))
+}
+
+func TestSandboxCommands(t *testing.T) {
+	writable := []string{"/src/app", "/tmp"}
+
+	assert.Equal(t, []string{
+		"--ro-bind", "/", "/", "--dev-bind", "/dev", "/dev",
+		"--bind", "/src/app", "/src/app", "--bind", "/tmp", "/tmp",
+		"--unshare-net", "--die-with-parent", "--", "/opt/tool", "apply", "-auto-approve",
+	}, bwrapArgs("/opt/tool", []string{"apply", "-auto-approve"}, writable, true))
+
+	assert.Equal(t, []string{
+		SandboxRunCommand, "--writable", "/src/app", "--writable", "/tmp", "--", "/opt/tool", "--help",
+	}, sandboxRunArgs("/opt/tool", []string{"--help"}, writable, false))
+
+	profile := seatbeltProfile(writable, true)
+	assert.Contains(t, profile, "(deny file-write*)")
+	assert.Contains(t, profile, `(allow file-write* (subpath "/dev") (subpath "/src/app") (subpath "/tmp"))`)
+	assert.Contains(t, profile, "(deny network*)")
+	assert.NotContains(t, seatbeltProfile(writable, false), "network")
+}
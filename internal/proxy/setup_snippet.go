@@ -0,0 +1,134 @@
+package proxy
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/afero"
+)
+
+// setupMarker vman setup 写入shell配置文件的配置段标记
+const setupMarker = "# vman setup"
+
+// SetupSnippet 生成在shell配置文件中加载vman的片段
+// Nushell 只能 source 解析时已存在的文件，片段加载 hookFile，由 InstallSetupSnippet 写入钩子脚本
+func SetupSnippet(shellType, hookFile string) (string, error) {
+	switch shellType {
+	case "bash", "zsh":
+		return fmt.Sprintf("if command -v vman >/dev/null 2>&1; then\n    eval \"$(vman shell-init %s)\"\nfi", shellType), nil
+	case "fish":
+		return "if command -v vman >/dev/null 2>&1\n    vman shell-init fish | source\nend", nil
+	case "nu":
+		return fmt.Sprintf("source '%s'", hookFile), nil
+	case "xonsh":
+		return "if !(which vman):\n    execx($(vman shell-init xonsh))", nil
+	case "powershell", "pwsh":
+		return fmt.Sprintf("if (Get-Command vman -ErrorAction SilentlyContinue) {\n    vman shell-init %s | Out-String | Invoke-Expression\n}", shellType), nil
+	default:
+		return "", fmt.Errorf("unsupported shell type: %s", shellType)
+	}
+}
+
+// NushellHookFile Nushell 钩子脚本的路径，与 config.nu 位于同一目录
+func NushellHookFile(configPath string) string {
+	return filepath.Join(filepath.Dir(configPath), "vman.nu")
+}
+
+// InstallSetupSnippet 将加载vman的片段写入shell配置文件末尾，已存在时替换
+// 返回配置文件是否被修改
+func InstallSetupSnippet(fs afero.Fs, configPath, shellType string) (bool, error) {
+	hookFile := NushellHookFile(configPath)
+	snippet, err := SetupSnippet(shellType, hookFile)
+	if err != nil {
+		return false, err
+	}
+
+	if err := fs.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
+		return false, fmt.Errorf("failed to create config directory: %w", err)
+	}
+	if shellType == "nu" {
+		hook, err := NewShellIntegratorWithFs(fs).GenerateShellHook(shellType)
+		if err != nil {
+			return false, err
+		}
+		if err := afero.WriteFile(fs, hookFile, []byte(hook), 0644); err != nil {
+			return false, fmt.Errorf("failed to write nushell hook: %w", err)
+		}
+	}
+
+	return writeMarkedSection(fs, configPath, setupMarker, snippet)
+}
+
+// writeMarkedSection 将由标记行包围的片段写入配置文件末尾，已存在时替换，返回配置文件是否被修改
+func writeMarkedSection(fs afero.Fs, configPath, marker, snippet string) (bool, error) {
+	var original string
+	if exists, _ := afero.Exists(fs, configPath); exists {
+		data, err := afero.ReadFile(fs, configPath)
+		if err != nil {
+			return false, fmt.Errorf("failed to read shell config: %w", err)
+		}
+		original = string(data)
+	}
+
+	content := ReplaceMarkedSection(original, marker, snippet)
+	if content == original {
+		return false, nil
+	}
+
+	if err := afero.WriteFile(fs, configPath, []byte(content), 0644); err != nil {
+		return false, fmt.Errorf("failed to write shell config: %w", err)
+	}
+	return true, nil
+}
+
+// RemoveSetupSnippet 从shell配置文件中移除 vman setup 写入的片段
+// 返回是否找到并移除了片段
+func RemoveSetupSnippet(fs afero.Fs, configPath, shellType string) (bool, error) {
+	if shellType == "nu" {
+		if err := fs.Remove(NushellHookFile(configPath)); err != nil && !os.IsNotExist(err) {
+			return false, fmt.Errorf("failed to remove nushell hook: %w", err)
+		}
+	}
+
+	return removeMarkedSectionFromFile(fs, configPath, setupMarker)
+}
+
+// removeMarkedSectionFromFile 从配置文件中移除由标记行包围的片段，返回是否找到并移除了片段
+func removeMarkedSectionFromFile(fs afero.Fs, configPath, marker string) (bool, error) {
+	if exists, _ := afero.Exists(fs, configPath); !exists {
+		return false, nil
+	}
+	data, err := afero.ReadFile(fs, configPath)
+	if err != nil {
+		return false, fmt.Errorf("failed to read shell config: %w", err)
+	}
+	if !hasMarker(string(data), marker) {
+		return false, nil
+	}
+
+	content := strings.TrimRight(removeMarkedSection(string(data), marker), "\n")
+	if content != "" {
+		content += "\n"
+	}
+	if err := afero.WriteFile(fs, configPath, []byte(content), 0644); err != nil {
+		return false, fmt.Errorf("failed to write shell config: %w", err)
+	}
+	return true, nil
+}
+
+// HasSetupSnippet 配置文件内容中是否有 vman setup 写入的片段
+func HasSetupSnippet(content string) bool {
+	return hasMarker(content, setupMarker)
+}
+
+// hasMarker 内容中是否有标记行
+func hasMarker(content, marker string) bool {
+	for _, line := range strings.Split(content, "\n") {
+		if strings.TrimSpace(line) == marker {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,55 @@
+package proxy
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInstallSetupSnippet(t *testing.T) {
+	for _, shell := range []string{"bash", "zsh", "fish", "nu", "xonsh", "pwsh"} {
+		t.Run(shell, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			configPath := "/home/u/.config/" + shell + "/rc"
+			require.NoError(t, afero.WriteFile(fs, configPath, []byte("# user settings\n"), 0644))
+
+			changed, err := InstallSetupSnippet(fs, configPath, shell)
+			require.NoError(t, err)
+			assert.True(t, changed)
+			changed, err = InstallSetupSnippet(fs, configPath, shell)
+			require.NoError(t, err)
+			assert.False(t, changed, "重复运行不应修改配置文件")
+
+			content, err := afero.ReadFile(fs, configPath)
+			require.NoError(t, err)
+			assert.Equal(t, 2, strings.Count(string(content), setupMarker+"\n"))
+			assert.True(t, strings.HasPrefix(string(content), "# user settings\n"))
+			if shell == "nu" {
+				assert.Contains(t, string(content), "source '"+NushellHookFile(configPath)+"'")
+				exists, _ := afero.Exists(fs, NushellHookFile(configPath))
+				assert.True(t, exists)
+			} else {
+				assert.Contains(t, string(content), "vman shell-init "+shell)
+			}
+
+			removed, err := RemoveSetupSnippet(fs, configPath, shell)
+			require.NoError(t, err)
+			assert.True(t, removed)
+			content, err = afero.ReadFile(fs, configPath)
+			require.NoError(t, err)
+			assert.Equal(t, "# user settings\n", string(content))
+			exists, _ := afero.Exists(fs, NushellHookFile(configPath))
+			assert.False(t, exists)
+
+			removed, err = RemoveSetupSnippet(fs, configPath, shell)
+			require.NoError(t, err)
+			assert.False(t, removed)
+		})
+	}
+
+	_, err := InstallSetupSnippet(afero.NewMemMapFs(), "/home/u/vman_init.cmd", "cmd")
+	assert.Error(t, err)
+}
@@ -10,6 +10,9 @@ import (
 
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/afero"
+
+	"github.com/songzhibin97/vman/pkg/types"
+	"github.com/songzhibin97/vman/pkg/utils"
 )
 
 // ShellIntegrator Shell集成器接口
@@ -84,25 +87,17 @@ func (si *DefaultShellIntegrator) GenerateShellHook(shellType string) (string, e
 		return "", fmt.Errorf("failed to get home directory: %w", err)
 	}
 
+	paths := types.DefaultConfigPaths(homeDir)
 	data := ShellHookData{
 		VmanPath:      "vman", // 假设vman已在PATH中
-		ShimDir:       filepath.Join(homeDir, ".vman", "shims"),
-		ConfigDir:     filepath.Join(homeDir, ".vman"),
+		ShimDir:       paths.ShimsDir,
+		ConfigDir:     paths.ConfigDir,
 		ShellType:     shellType,
 		PathSeparator: getPathSeparator(),
 	}
 
-	var templateStr string
-	switch shellType {
-	case "bash", "zsh":
-		templateStr = bashZshHookTemplate
-	case "fish":
-		templateStr = fishHookTemplate
-	case "cmd":
-		templateStr = cmdHookTemplate
-	case "powershell":
-		templateStr = powershellHookTemplate
-	default:
+	templateStr, ok := shellHookTemplates[shellType]
+	if !ok {
 		return "", fmt.Errorf("unsupported shell type: %s", shellType)
 	}
 
@@ -243,9 +238,9 @@ func (si *DefaultShellIntegrator) GenerateShim(toolName, shimPath, vmanPath stri
 		return fmt.Errorf("failed to create shim directory: %w", err)
 	}
 
-	// 写入shim文件
-	shimContent := buf.String()
-	if err := afero.WriteFile(si.fs, shimPath, []byte(shimContent), 0755); err != nil {
+	// 写入shim文件，记录格式版本和内容哈希
+	shimContent := stampShim(buf.String())
+	if err := utils.WriteExecutableFile(si.fs, shimPath, []byte(shimContent)); err != nil {
 		return fmt.Errorf("failed to write shim file: %w", err)
 	}
 
@@ -260,24 +255,56 @@ func (si *DefaultShellIntegrator) GenerateActivationScript(shellType, vmanPath s
 		return "", fmt.Errorf("failed to generate hook script: %w", err)
 	}
 
-	activationTemplate := `#!/bin/bash
-# vman activation script for %s
-# Add this to your shell profile or run: source <(vman init %s)
-
-%s
-
-echo "vman activated for %s shell"
-`
+	comment := "#"
+	if shellType == "cmd" {
+		comment = "REM"
+	}
+	header := fmt.Sprintf("%s vman activation script for %s\n", comment, shellType)
+	if usage := ActivationUsage(shellType); usage != "" {
+		header += fmt.Sprintf("%s Add this to your shell profile: %s\n", comment, usage)
+	}
+	return header + hookScript, nil
+}
 
-	return fmt.Sprintf(activationTemplate, shellType, shellType, hookScript, shellType), nil
+// ActivationUsage 在shell配置文件中加载激活脚本的方式
+func ActivationUsage(shellType string) string {
+	switch shellType {
+	case "bash", "zsh":
+		return fmt.Sprintf(`eval "$(vman shell-init %s)"`, shellType)
+	case "fish":
+		return "vman shell-init fish | source"
+	case "nu":
+		// Nushell 只能 source 解析时已存在的文件
+		return "vman shell-init nu | save -f ($nu.default-config-dir | path join vman.nu); then add `source vman.nu` to config.nu"
+	case "xonsh":
+		return "execx($(vman shell-init xonsh))"
+	case "powershell", "pwsh":
+		return fmt.Sprintf("vman shell-init %s | Out-String | Invoke-Expression", shellType)
+	default:
+		return ""
+	}
 }
 
 // DetectShell 检测当前使用的shell
+//
+// vman shell-init 等命令通常由 shell 直接运行，因此优先使用父进程；SHELL 是登录shell，
+// 在 bash 中启动 nu 或 xonsh 时不会改变，所以再检查这些 shell 设置的环境变量
 func (si *DefaultShellIntegrator) DetectShell() string {
+	if shell := normalizeShell(parentProcessName()); si.ValidateShellSupport(shell) {
+		return shell
+	}
+
+	switch {
+	case os.Getenv("NU_VERSION") != "":
+		return "nu"
+	case os.Getenv("XONSH_VERSION") != "":
+		return "xonsh"
+	}
+
 	// 首先检查SHELL环境变量
 	shell := os.Getenv("SHELL")
 	if shell != "" {
-		shellName := filepath.Base(shell)
+		shellName := normalizeShell(filepath.Base(shell))
 		if si.ValidateShellSupport(shellName) {
 			return shellName
 		}
@@ -292,13 +319,18 @@ func (si *DefaultShellIntegrator) DetectShell() string {
 		return "cmd"
 	}
 
+	// 其他系统上只有在 PowerShell 中才会设置 PSModulePath
+	if os.Getenv("PSModulePath") != "" {
+		return "pwsh"
+	}
+
 	// 默认返回bash
 	return "bash"
 }
 
 // GetSupportedShells 获取支持的shell列表
 func (si *DefaultShellIntegrator) GetSupportedShells() []string {
-	return []string{"bash", "zsh", "fish", "cmd", "powershell"}
+	return []string{"bash", "zsh", "fish", "nu", "xonsh", "cmd", "powershell", "pwsh"}
 }
 
 // ValidateShellSupport 验证shell是否支持
@@ -312,6 +344,25 @@ func (si *DefaultShellIntegrator) ValidateShellSupport(shellType string) bool {
 	return false
 }
 
+// parentProcessName 父进程的名称，只在提供 /proc 的系统（Linux）上可用，测试中可替换
+var parentProcessName = func() string {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/comm", os.Getppid()))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// normalizeShell 将可执行文件名转换为shell类型，如 -zsh、pwsh.exe、nushell
+func normalizeShell(name string) string {
+	name = strings.TrimPrefix(strings.ToLower(name), "-")
+	name = strings.TrimSuffix(name, ".exe")
+	if name == "nushell" {
+		return "nu"
+	}
+	return name
+}
+
 // getShellConfigPath 获取shell配置文件路径
 func (si *DefaultShellIntegrator) getShellConfigPath(shellType string) (string, error) {
 	homeDir, err := os.UserHomeDir()
@@ -319,41 +370,69 @@ func (si *DefaultShellIntegrator) getShellConfigPath(shellType string) (string,
 		return "", fmt.Errorf("failed to get home directory: %w", err)
 	}
 
+	if shellType == "cmd" {
+		// Windows CMD 不支持持久化配置，返回批处理文件
+		return filepath.Join(homeDir, "vman_init.cmd"), nil
+	}
+	if configPath := ShellConfigPath(si.fs, homeDir, shellType); configPath != "" {
+		return configPath, nil
+	}
+	return "", fmt.Errorf("unsupported shell type: %s", shellType)
+}
+
+// ShellConfigPath 获取shell启动时加载的配置文件路径，不支持的shell返回空字符串
+func ShellConfigPath(fs afero.Fs, homeDir, shellType string) string {
 	switch shellType {
 	case "bash":
 		// 优先使用 .bash_profile，然后是 .bashrc
 		bashProfile := filepath.Join(homeDir, ".bash_profile")
-		if exists, _ := afero.Exists(si.fs, bashProfile); exists {
-			return bashProfile, nil
+		if exists, _ := afero.Exists(fs, bashProfile); exists {
+			return bashProfile
 		}
-		return filepath.Join(homeDir, ".bashrc"), nil
+		return filepath.Join(homeDir, ".bashrc")
 	case "zsh":
-		return filepath.Join(homeDir, ".zshrc"), nil
+		return filepath.Join(homeDir, ".zshrc")
 	case "fish":
-		return filepath.Join(homeDir, ".config", "fish", "config.fish"), nil
-	case "cmd":
-		// Windows CMD 不支持持久化配置，返回批处理文件
-		return filepath.Join(homeDir, "vman_init.cmd"), nil
+		return filepath.Join(homeDir, ".config", "fish", "config.fish")
+	case "nu":
+		return filepath.Join(nushellConfigDir(homeDir), "config.nu")
+	case "xonsh":
+		return filepath.Join(homeDir, ".xonshrc")
 	case "powershell":
-		// PowerShell profile
-		return filepath.Join(homeDir, "Documents", "PowerShell", "Microsoft.PowerShell_profile.ps1"), nil
+		return filepath.Join(homeDir, "Documents", "PowerShell", "Microsoft.PowerShell_profile.ps1")
+	case "pwsh":
+		// PowerShell Core 在类Unix系统上使用 XDG 配置目录
+		if runtime.GOOS == "windows" {
+			return filepath.Join(homeDir, "Documents", "PowerShell", "Microsoft.PowerShell_profile.ps1")
+		}
+		return filepath.Join(homeDir, ".config", "powershell", "Microsoft.PowerShell_profile.ps1")
 	default:
-		return "", fmt.Errorf("unsupported shell type: %s", shellType)
+		return ""
+	}
+}
+
+// nushellConfigDir Nushell 的配置目录，与 $nu.default-config-dir 一致
+func nushellConfigDir(homeDir string) string {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "nushell")
+	}
+	switch runtime.GOOS {
+	case "darwin":
+		return filepath.Join(homeDir, "Library", "Application Support", "nushell")
+	case "windows":
+		if appData := os.Getenv("APPDATA"); appData != "" {
+			return filepath.Join(appData, "nushell")
+		}
 	}
+	return filepath.Join(homeDir, ".config", "nushell")
 }
 
-// getVmanMarker 获取vman标记注释
+// getVmanMarker 获取vman标记注释，钩子脚本本身不能包含与标记相同的行
 func getVmanMarker(shellType string) string {
-	switch shellType {
-	case "fish":
-		return "# vman shell integration"
-	case "cmd":
+	if shellType == "cmd" {
 		return "REM vman shell integration"
-	case "powershell":
-		return "# vman shell integration"
-	default:
-		return "# vman shell integration"
 	}
+	return "# vman shell integration"
 }
 
 // removeVmanSection 移除vman配置段落
@@ -376,9 +455,21 @@ func (si *DefaultShellIntegrator) removeVmanSection(content, marker string) stri
 	return strings.Join(newLines, "\n")
 }
 
+// shellHookTemplates 各shell的钩子模板：PATH、目录切换钩子、补全和提示符辅助函数
+var shellHookTemplates = map[string]string{
+	"bash":       bashZshHookTemplate,
+	"zsh":        bashZshHookTemplate,
+	"fish":       fishHookTemplate,
+	"nu":         nushellHookTemplate,
+	"xonsh":      xonshHookTemplate,
+	"cmd":        cmdHookTemplate,
+	"powershell": powershellHookTemplate,
+	"pwsh":       powershellHookTemplate,
+}
+
 // Shell钩子模板
 const bashZshHookTemplate = `
-# vman shell integration
+# vman: shims, hooks, completions and prompt helper for {{.ShellType}}
 export VMAN_DIR="{{.ConfigDir}}"
 export VMAN_SHIMS_DIR="{{.ShimDir}}"
 
@@ -388,61 +479,171 @@ if [[ ":$PATH:" != *":{{.ShimDir}}:"* ]]; then
 fi
 
 # Command not found hook
-command_not_found_handle() {
+{{if eq .ShellType "zsh"}}command_not_found_handler{{else}}command_not_found_handle{{end}}() {
     if command -v vman >/dev/null 2>&1; then
-        vman exec "$1" "$@"
+        vman exec "$@"
     else
-        echo "bash: $1: command not found"
+        echo "{{.ShellType}}: $1: command not found" >&2
         return 127
     fi
 }
 
-# Change directory hook for project-specific tool versions
+# Refresh the versions shown by vman_prompt_info when the directory changes
 vman_cd_hook() {
     if command -v vman >/dev/null 2>&1; then
-        vman rehash >/dev/null 2>&1
+        VMAN_PROMPT="$(vman prompt 2>/dev/null)"
     fi
 }
 
-# Set up cd hook
-if [[ "${{.ShellType}}" == "zsh" ]]; then
-    autoload -U add-zsh-hook
-    add-zsh-hook chpwd vman_cd_hook
-else
-    PROMPT_COMMAND="${PROMPT_COMMAND:+$PROMPT_COMMAND$'\n'}vman_cd_hook"
+# Prompt helper, e.g. PS1='$(vman_prompt_info) \$ '
+vman_prompt_info() {
+    printf '%s' "$VMAN_PROMPT"
+}
+{{if eq .ShellType "zsh"}}
+autoload -U add-zsh-hook
+add-zsh-hook chpwd vman_cd_hook
+
+# Completions (requires compinit)
+if (( $+functions[compdef] )) && command -v vman >/dev/null 2>&1; then
+    source <(vman completion zsh)
 fi
+{{else}}
+__vman_last_pwd=""
+__vman_prompt_command() {
+    if [[ "$PWD" != "$__vman_last_pwd" ]]; then
+        __vman_last_pwd="$PWD"
+        vman_cd_hook
+    fi
+}
+if [[ ";${PROMPT_COMMAND:-};" != *";__vman_prompt_command;"* ]]; then
+    PROMPT_COMMAND="__vman_prompt_command${PROMPT_COMMAND:+;$PROMPT_COMMAND}"
+fi
+
+# Completions
+if command -v vman >/dev/null 2>&1; then
+    source <(vman completion bash)
+fi
+{{end}}
+vman_cd_hook
 `
 
 const fishHookTemplate = `
-# vman shell integration
+# vman: shims, hooks, completions and prompt helper for fish
 set -gx VMAN_DIR "{{.ConfigDir}}"
 set -gx VMAN_SHIMS_DIR "{{.ShimDir}}"
 
 # Add shims to PATH
-if not contains "{{.ShimDir}}" $PATH
+if not contains -- "{{.ShimDir}}" $PATH
     set -gx PATH "{{.ShimDir}}" $PATH
 end
 
 # Command not found hook
 function fish_command_not_found
     if command -v vman >/dev/null 2>&1
-        vman exec $argv[1] $argv
+        vman exec $argv
     else
-        echo "fish: Unknown command: $argv[1]"
+        echo "fish: Unknown command: $argv[1]" >&2
         return 127
     end
 end
 
-# Change directory hook
+# Refresh the versions shown by vman_prompt_info when the directory changes
 function vman_cd_hook --on-variable PWD
     if command -v vman >/dev/null 2>&1
-        vman rehash >/dev/null 2>&1
+        set -g VMAN_PROMPT (vman prompt 2>/dev/null)
     end
 end
+
+# Prompt helper, e.g. echo -n (vman_prompt_info) in fish_prompt
+function vman_prompt_info
+    echo -n $VMAN_PROMPT
+end
+
+# Completions
+if command -v vman >/dev/null 2>&1
+    vman completion fish | source
+end
+
+vman_cd_hook
+`
+
+const nushellHookTemplate = `
+# vman: shims, hooks, completions and prompt helper for nu
+$env.VMAN_DIR = '{{.ConfigDir}}'
+$env.VMAN_SHIMS_DIR = '{{.ShimDir}}'
+
+# Add shims to PATH
+$env.PATH = ($env.PATH | split row (char esep) | where {|dir| $dir != '{{.ShimDir}}' } | prepend '{{.ShimDir}}')
+
+# Refresh the versions shown by vman_prompt_info when the directory changes
+$env.VMAN_PROMPT = (try { ^vman prompt | str trim } catch { '' })
+$env.config = ($env.config | upsert hooks.env_change.PWD (
+    $env.config.hooks?.env_change?.PWD? | default [] | append {|before, after|
+        $env.VMAN_PROMPT = (try { ^vman prompt | str trim } catch { '' })
+    }
+))
+
+# Prompt helper, e.g. $env.PROMPT_COMMAND_RIGHT = {|| vman_prompt_info }
+def vman_prompt_info [] {
+    $env.VMAN_PROMPT? | default ''
+}
+
+# Completions, other commands are passed to the previous external completer
+let __vman_previous_completer = $env.config.completions?.external?.completer?
+$env.config = ($env.config | upsert completions.external.enable true | upsert completions.external.completer {|spans|
+    if $spans.0 == 'vman' {
+        ^vman __complete ...($spans | skip 1) | lines | where {|line| not ($line | str starts-with ':') } | each {|line| $line | split row "\t" | first }
+    } else if $__vman_previous_completer != null {
+        do $__vman_previous_completer $spans
+    }
+})
+`
+
+const xonshHookTemplate = `
+# vman: shims, hooks, completions and prompt helper for xonsh
+$VMAN_DIR = r'{{.ConfigDir}}'
+$VMAN_SHIMS_DIR = r'{{.ShimDir}}'
+
+# Add shims to PATH
+if $VMAN_SHIMS_DIR not in $PATH:
+    $PATH.insert(0, $VMAN_SHIMS_DIR)
+
+import subprocess as _vman_subprocess
+
+def _vman_output(*args):
+    try:
+        return _vman_subprocess.run(['vman', *args], capture_output=True, text=True).stdout
+    except OSError:
+        return ''
+
+# Refresh the versions shown by the {vman} prompt field when the directory changes
+@events.on_chdir
+def _vman_cd_hook(olddir, newdir, **kwargs):
+    $VMAN_PROMPT = _vman_output('prompt').strip()
+
+# Prompt helper, e.g. $PROMPT = '{vman} ' + $PROMPT
+def vman_prompt_info():
+    return ${...}.get('VMAN_PROMPT', '')
+
+$PROMPT_FIELDS['vman'] = vman_prompt_info
+
+# Completions
+from xonsh.completers.tools import contextual_command_completer_for as _vman_completer_for
+from xonsh.completers.completer import add_one_completer as _vman_add_completer
+
+@_vman_completer_for('vman')
+def _vman_complete(context):
+    words = [arg.value for arg in context.args[1:context.arg_index]] + [context.prefix]
+    lines = _vman_output('__complete', *words).splitlines()
+    return {line.split('\t')[0] for line in lines if line and not line.startswith(':')}
+
+_vman_add_completer('vman', _vman_complete, 'start')
+
+$VMAN_PROMPT = _vman_output('prompt').strip()
 `
 
 const cmdHookTemplate = `
-REM vman shell integration
+REM vman: shims for cmd
 @echo off
 set VMAN_DIR={{.ConfigDir}}
 set VMAN_SHIMS_DIR={{.ShimDir}}
@@ -450,28 +651,52 @@ set PATH={{.ShimDir}};%PATH%
 `
 
 const powershellHookTemplate = `
-# vman shell integration
+# vman: shims, hooks, completions and prompt helper for {{.ShellType}}
 $env:VMAN_DIR = "{{.ConfigDir}}"
 $env:VMAN_SHIMS_DIR = "{{.ShimDir}}"
 
 # Add shims to PATH
-if ($env:PATH -notlike "*{{.ShimDir}}*") {
+if (($env:PATH -split [System.IO.Path]::PathSeparator) -notcontains "{{.ShimDir}}") {
     $env:PATH = "{{.ShimDir}}" + [System.IO.Path]::PathSeparator + $env:PATH
 }
 
 # Command not found hook
 $ExecutionContext.InvokeCommand.CommandNotFoundAction = {
     param($CommandName, $CommandLookupEventArgs)
-    
-    if (Get-Command vman -ErrorAction SilentlyContinue) {
-        try {
-            vman exec $CommandName @args
-            $CommandLookupEventArgs.StopSearch = $true
-        } catch {
-            # Continue with default behavior
-        }
+
+    # PowerShell also looks up get-<name>, only handle the original command
+    if ($CommandName -notlike 'get-*' -and (Get-Command vman -CommandType Application -ErrorAction SilentlyContinue)) {
+        $CommandLookupEventArgs.CommandScriptBlock = { vman exec $CommandName @args }.GetNewClosure()
+        $CommandLookupEventArgs.StopSearch = $true
+    }
+}
+
+# Refresh the versions shown by vman_prompt_info when the directory changes
+function global:vman_cd_hook {
+    if (Get-Command vman -CommandType Application -ErrorAction SilentlyContinue) {
+        $global:VMAN_PROMPT = (vman prompt 2>$null) -join ''
+    }
+}
+$global:__vmanPreviousLocationAction = $ExecutionContext.InvokeCommand.LocationChangedAction
+$ExecutionContext.InvokeCommand.LocationChangedAction = {
+    param($source, $eventArgs)
+    if ($global:__vmanPreviousLocationAction) {
+        & $global:__vmanPreviousLocationAction $source $eventArgs
     }
+    vman_cd_hook
+}
+
+# Prompt helper, e.g. function prompt { "$(vman_prompt_info) PS> " }
+function global:vman_prompt_info {
+    "$global:VMAN_PROMPT"
 }
+
+# Completions
+if (Get-Command vman -CommandType Application -ErrorAction SilentlyContinue) {
+    vman completion powershell | Out-String | Invoke-Expression
+}
+
+vman_cd_hook
 `
 
 // Shim模板
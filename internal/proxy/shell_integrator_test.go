@@ -0,0 +1,160 @@
+package proxy
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/songzhibin97/vman/pkg/types"
+)
+
+func TestGenerateShellHook(t *testing.T) {
+	homeDir := t.TempDir()
+	t.Setenv("HOME", homeDir)
+	t.Setenv(types.EnvVmanHome, "")
+	t.Setenv(types.EnvVmanXDG, "")
+	shimsDir := types.DefaultConfigPaths(homeDir).ShimsDir
+
+	tests := []struct {
+		shell    string
+		check    []string // 语法检查命令，shell未安装时跳过
+		path     string
+		cdHook   string
+		complete string
+		prompt   string
+	}{
+		{"bash", []string{"bash", "-n"}, `export PATH="` + shimsDir + `:$PATH"`, "PROMPT_COMMAND", "vman completion bash", "vman_prompt_info()"},
+		{"zsh", []string{"zsh", "-n"}, `export PATH="` + shimsDir + `:$PATH"`, "add-zsh-hook chpwd vman_cd_hook", "vman completion zsh", "vman_prompt_info()"},
+		{"fish", []string{"fish", "--no-execute"}, `set -gx PATH "` + shimsDir + `" $PATH`, "--on-variable PWD", "vman completion fish | source", "function vman_prompt_info"},
+		{"nu", []string{"nu", "--ide-check", "10"}, "prepend '" + shimsDir + "'", "hooks.env_change.PWD", "^vman __complete", "def vman_prompt_info"},
+		{"xonsh", nil, "$PATH.insert(0, $VMAN_SHIMS_DIR)", "@events.on_chdir", "'__complete'", "def vman_prompt_info"},
+		{"powershell", nil, `$env:PATH = "` + shimsDir + `"`, "LocationChangedAction", "vman completion powershell", "function global:vman_prompt_info"},
+		{"pwsh", nil, `$env:PATH = "` + shimsDir + `"`, "LocationChangedAction", "vman completion powershell", "function global:vman_prompt_info"},
+	}
+
+	si := NewShellIntegratorWithFs(afero.NewMemMapFs())
+	for _, tt := range tests {
+		t.Run(tt.shell, func(t *testing.T) {
+			script, err := si.GenerateShellHook(tt.shell)
+			require.NoError(t, err)
+			assert.Contains(t, script, tt.path)
+			assert.Contains(t, script, tt.cdHook)
+			assert.Contains(t, script, tt.complete)
+			assert.Contains(t, script, tt.prompt)
+			// 钩子脚本中与标记相同的行会导致卸载时删除错误的内容
+			for _, line := range strings.Split(script, "\n") {
+				assert.NotEqual(t, getVmanMarker(tt.shell), strings.TrimSpace(line))
+			}
+
+			if tt.check == nil {
+				return
+			}
+			if _, err := exec.LookPath(tt.check[0]); err != nil {
+				t.Skipf("%s is not installed", tt.check[0])
+			}
+			file := filepath.Join(t.TempDir(), "hook")
+			require.NoError(t, os.WriteFile(file, []byte(script), 0644))
+			output, err := exec.Command(tt.check[0], append(tt.check[1:], file)...).CombinedOutput()
+			assert.NoError(t, err, string(output))
+		})
+	}
+
+	_, err := si.GenerateShellHook("tcsh")
+	assert.Error(t, err)
+}
+
+func TestDetectShell(t *testing.T) {
+	original := parentProcessName
+	defer func() { parentProcessName = original }()
+
+	tests := []struct {
+		name   string
+		parent string
+		env    map[string]string
+		want   string
+	}{
+		{"ParentProcess", "-zsh", map[string]string{"SHELL": "/bin/bash"}, "zsh"},
+		{"ParentNushell", "nu", map[string]string{"SHELL": "/bin/bash"}, "nu"},
+		{"ParentPwsh", "pwsh", map[string]string{"SHELL": "/bin/bash"}, "pwsh"},
+		{"NuVersion", "vman", map[string]string{"SHELL": "/bin/bash", "NU_VERSION": "0.95.0"}, "nu"},
+		{"XonshVersion", "python3", map[string]string{"SHELL": "/bin/zsh", "XONSH_VERSION": "0.18.0"}, "xonsh"},
+		{"LoginShell", "", map[string]string{"SHELL": "/usr/local/bin/fish"}, "fish"},
+		{"Unknown", "", map[string]string{"SHELL": "/bin/tcsh"}, "bash"},
+	}
+	if runtime.GOOS != "windows" {
+		tests = append(tests, struct {
+			name   string
+			parent string
+			env    map[string]string
+			want   string
+		}{"PowerShellCore", "", map[string]string{"SHELL": "", "PSModulePath": "/opt/microsoft/powershell/7/Modules"}, "pwsh"})
+	}
+
+	si := NewShellIntegratorWithFs(afero.NewMemMapFs())
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range []string{"SHELL", "NU_VERSION", "XONSH_VERSION", "PSModulePath"} {
+				t.Setenv(name, tt.env[name])
+			}
+			parentProcessName = func() string { return tt.parent }
+			assert.Equal(t, tt.want, si.DetectShell())
+		})
+	}
+}
+
+func TestShellConfigPath(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses unix config locations")
+	}
+	fs := afero.NewMemMapFs()
+	t.Setenv("XDG_CONFIG_HOME", "")
+
+	assert.Equal(t, "/home/u/.bashrc", ShellConfigPath(fs, "/home/u", "bash"))
+	require.NoError(t, afero.WriteFile(fs, "/home/u/.bash_profile", nil, 0644))
+	assert.Equal(t, "/home/u/.bash_profile", ShellConfigPath(fs, "/home/u", "bash"))
+	assert.Equal(t, "/home/u/.config/fish/config.fish", ShellConfigPath(fs, "/home/u", "fish"))
+	assert.Equal(t, "/home/u/.xonshrc", ShellConfigPath(fs, "/home/u", "xonsh"))
+	assert.Equal(t, "/home/u/.config/powershell/Microsoft.PowerShell_profile.ps1", ShellConfigPath(fs, "/home/u", "pwsh"))
+	if runtime.GOOS == "linux" {
+		assert.Equal(t, "/home/u/.config/nushell/config.nu", ShellConfigPath(fs, "/home/u", "nu"))
+	}
+	t.Setenv("XDG_CONFIG_HOME", "/xdg")
+	assert.Equal(t, "/xdg/nushell/config.nu", ShellConfigPath(fs, "/home/u", "nu"))
+	assert.Empty(t, ShellConfigPath(fs, "/home/u", "tcsh"))
+}
+
+func TestInstallShellHook(t *testing.T) {
+	homeDir := t.TempDir()
+	t.Setenv("HOME", homeDir)
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv(types.EnvVmanHome, "")
+	t.Setenv(types.EnvVmanXDG, "")
+
+	for _, shell := range []string{"bash", "zsh", "fish", "nu", "xonsh", "pwsh"} {
+		t.Run(shell, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			si := NewShellIntegratorWithFs(fs)
+			configPath := ShellConfigPath(fs, homeDir, shell)
+			require.NoError(t, afero.WriteFile(fs, configPath, []byte("# user settings\n"), 0644))
+
+			require.NoError(t, si.InstallShellHook(shell, "vman"))
+			require.NoError(t, si.InstallShellHook(shell, "vman"))
+			content, err := afero.ReadFile(fs, configPath)
+			require.NoError(t, err)
+			assert.Equal(t, 2, strings.Count(string(content), getVmanMarker(shell)+"\n"), "重复安装不应重复添加")
+			assert.Contains(t, string(content), "vman_prompt_info")
+
+			require.NoError(t, si.UninstallShellHook(shell))
+			content, err = afero.ReadFile(fs, configPath)
+			require.NoError(t, err)
+			assert.Equal(t, "# user settings", strings.TrimSpace(string(content)))
+		})
+	}
+}
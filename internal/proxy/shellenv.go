@@ -0,0 +1,90 @@
+package proxy
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/songzhibin97/vman/pkg/types"
+)
+
+// GenerateShellEnv 生成登录shell使用的环境变量设置
+//
+// 与 GenerateShellHook 不同，这里只设置vman目录和PATH，不注册钩子和补全，
+// 也不运行任何子进程，适合放在 .zprofile、.bash_profile 等登录shell的配置文件中
+func GenerateShellEnv(shellType string) (string, error) {
+	templateStr, ok := shellEnvTemplates[shellType]
+	if !ok {
+		return "", fmt.Errorf("unsupported shell type: %s", shellType)
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	paths := types.DefaultConfigPaths(homeDir)
+	data := ShellHookData{
+		ShimDir:   paths.ShimsDir,
+		ConfigDir: paths.ConfigDir,
+		ShellType: shellType,
+	}
+
+	tmpl, err := template.New("shellenv").Parse(templateStr)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse template: %w", err)
+	}
+	var buf strings.Builder
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to execute template: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// ShellEnvShells 支持 GenerateShellEnv 的shell
+func ShellEnvShells() []string {
+	return []string{"sh", "bash", "zsh", "fish", "nu", "xonsh", "cmd", "powershell", "pwsh"}
+}
+
+// shellEnvTemplates 各shell的环境变量模板，除cmd外PATH中已有shims目录时不重复添加
+var shellEnvTemplates = map[string]string{
+	"sh":         posixShellEnvTemplate,
+	"bash":       posixShellEnvTemplate,
+	"zsh":        posixShellEnvTemplate,
+	"fish":       fishShellEnvTemplate,
+	"nu":         nushellShellEnvTemplate,
+	"xonsh":      xonshShellEnvTemplate,
+	"cmd":        cmdShellEnvTemplate,
+	"powershell": powershellShellEnvTemplate,
+	"pwsh":       powershellShellEnvTemplate,
+}
+
+const posixShellEnvTemplate = `export VMAN_DIR="{{.ConfigDir}}";
+export VMAN_SHIMS_DIR="{{.ShimDir}}";
+case ":${PATH}:" in *":{{.ShimDir}}:"*) ;; *) export PATH="{{.ShimDir}}${PATH+:$PATH}";; esac
+`
+
+const fishShellEnvTemplate = `set -gx VMAN_DIR "{{.ConfigDir}}";
+set -gx VMAN_SHIMS_DIR "{{.ShimDir}}";
+contains -- "{{.ShimDir}}" $PATH; or set -gx PATH "{{.ShimDir}}" $PATH;
+`
+
+const nushellShellEnvTemplate = `$env.VMAN_DIR = '{{.ConfigDir}}'
+$env.VMAN_SHIMS_DIR = '{{.ShimDir}}'
+$env.PATH = ($env.PATH | split row (char esep) | where {|dir| $dir != '{{.ShimDir}}' } | prepend '{{.ShimDir}}')
+`
+
+const xonshShellEnvTemplate = `$VMAN_DIR = r'{{.ConfigDir}}'
+$VMAN_SHIMS_DIR = r'{{.ShimDir}}'
+if $VMAN_SHIMS_DIR not in $PATH: $PATH.insert(0, $VMAN_SHIMS_DIR)
+`
+
+const cmdShellEnvTemplate = `@set "VMAN_DIR={{.ConfigDir}}"
+@set "VMAN_SHIMS_DIR={{.ShimDir}}"
+@set "PATH={{.ShimDir}};%PATH%"
+`
+
+const powershellShellEnvTemplate = `$env:VMAN_DIR = "{{.ConfigDir}}"
+$env:VMAN_SHIMS_DIR = "{{.ShimDir}}"
+if (($env:PATH -split [System.IO.Path]::PathSeparator) -notcontains "{{.ShimDir}}") { $env:PATH = "{{.ShimDir}}" + [System.IO.Path]::PathSeparator + $env:PATH }
+`
@@ -0,0 +1,41 @@
+package proxy
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/songzhibin97/vman/pkg/types"
+)
+
+func TestGenerateShellEnv(t *testing.T) {
+	homeDir := t.TempDir()
+	t.Setenv("HOME", homeDir)
+	t.Setenv(types.EnvVmanHome, "")
+	t.Setenv(types.EnvVmanXDG, "")
+	shimsDir := types.DefaultConfigPaths(homeDir).ShimsDir
+
+	for _, shell := range ShellEnvShells() {
+		script, err := GenerateShellEnv(shell)
+		require.NoError(t, err, shell)
+		assert.Contains(t, script, shimsDir, shell)
+		assert.Contains(t, script, "VMAN_SHIMS_DIR", shell)
+	}
+
+	_, err := GenerateShellEnv("tcsh")
+	assert.Error(t, err)
+
+	// 在sh中执行两次，shims目录只添加一次
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not installed")
+	}
+	script, err := GenerateShellEnv("sh")
+	require.NoError(t, err)
+	output, err := exec.Command("sh", "-c", script+script+`printf '%s' "$PATH"`).CombinedOutput()
+	require.NoError(t, err, string(output))
+	assert.True(t, strings.HasPrefix(string(output), shimsDir+":"))
+	assert.Equal(t, 1, strings.Count(string(output), shimsDir))
+}
@@ -0,0 +1,221 @@
+package proxy
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/spf13/afero"
+
+	"github.com/songzhibin97/vman/pkg/types"
+)
+
+// 垫片的问题
+const (
+	// ShimMissing 垫片文件不存在
+	ShimMissing = "missing"
+	// ShimNotExecutable 垫片没有执行权限
+	ShimNotExecutable = "not executable"
+	// ShimBroken 垫片是失效的符号链接、空文件或被其他内容覆盖
+	ShimBroken = "broken"
+	// ShimOutdated 垫片由旧版本的vman生成，行为可能与当前版本不同
+	ShimOutdated = "outdated"
+	// ShimModified 垫片生成后被手动修改过
+	ShimModified = "modified"
+)
+
+// shimsDirMissing shims目录不存在，生成垫片时会自动创建
+const shimsDirMissing = "does not exist"
+
+// ShimProblem 缺失或损坏的垫片
+type ShimProblem struct {
+	Entry ShimEntry `json:"entry"`
+	Path  string    `json:"path"`
+	Issue string    `json:"issue"`
+}
+
+// String 返回问题的描述
+func (p ShimProblem) String() string {
+	return fmt.Sprintf("%s: %s (%s)", p.Entry.Name, p.Issue, p.Entry)
+}
+
+// ShimReport 垫片完整性检查结果
+type ShimReport struct {
+	ShimsDir string        `json:"shims_dir"`
+	DirIssue string        `json:"dir_issue,omitempty"` // shims目录不存在或不可写时的描述
+	Problems []ShimProblem `json:"problems,omitempty"`
+}
+
+// CheckShims 检查shims目录是否可写，以及已安装工具和命令别名的垫片是否完整
+// 因名称冲突而不生成的垫片不检查
+func (cp *DefaultCommandProxy) CheckShims() (*ShimReport, error) {
+	report := &ShimReport{ShimsDir: cp.shimsDir, DirIssue: checkShimsDir(cp.fs, cp.shimsDir)}
+
+	entries, err := CollectShimEntries(cp.configManager, cp.versionManager)
+	if err != nil {
+		return nil, err
+	}
+	blocked := make(map[string]bool)
+	for _, collision := range DetectShimCollisions(entries, cp.reservedNames) {
+		blocked[shimKey(collision.Name)] = true
+	}
+
+	for _, entry := range entries {
+		if blocked[shimKey(entry.Name)] {
+			continue
+		}
+		path := filepath.Join(cp.shimsDir, entry.Name)
+		if issue := inspectShim(cp.fs, path, entry); issue != "" {
+			report.Problems = append(report.Problems, ShimProblem{Entry: entry, Path: path, Issue: issue})
+		}
+	}
+	return report, nil
+}
+
+// RepairShims 重新生成缺失或损坏的垫片
+func (cp *DefaultCommandProxy) RepairShims(problems []ShimProblem) error {
+	var errs []error
+	for _, problem := range problems {
+		if err := cp.repairShim(problem.Entry); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", problem.Entry.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// healShim 执行命令前检查其垫片，垫片缺失或损坏时按设置重新生成或给出警告
+//
+// 垫片被删除后 shell 找不到命令，会通过 command_not_found 钩子调用 vman exec，
+// 因此这里是发现并修复垫片的时机。name 为调用的命令名，alias 表示它是命令别名。
+func (cp *DefaultCommandProxy) healShim(name string, alias bool, metadata *types.ToolMetadata, settings types.Settings) {
+	mode := settings.Proxy.GetSelfHeal()
+	if mode == types.ShimHealOff {
+		return
+	}
+
+	entry := ShimEntry{Name: name, Kind: ShimKindAlias, Target: name}
+	if !alias {
+		entry = ShimEntry{Name: name, Kind: ShimKindTool, Target: name}
+		if metadata != nil && metadata.Shim != "" {
+			entry.Name = metadata.Shim
+		}
+	}
+	path := filepath.Join(cp.shimsDir, entry.Name)
+	issue := inspectShim(cp.fs, path, entry)
+	// 手动修改的垫片保留，由 vman doctor 报告
+	if issue == "" || issue == ShimModified {
+		return
+	}
+
+	// 未安装的工具本来就没有垫片
+	if !alias {
+		if versions, err := cp.versionManager.GetInstalledVersions(name); err != nil || len(versions) == 0 {
+			return
+		}
+	}
+
+	if mode == types.ShimHealWarn || settings.IsReadOnly() {
+		cp.logger.Warnf("Shim %s is %s, run `vman proxy rehash` to regenerate it", path, issue)
+		return
+	}
+	if err := cp.CheckShimName(entry); err != nil {
+		cp.logger.Warnf("Shim %s is %s but was not regenerated: %v", path, issue, err)
+		return
+	}
+	if err := cp.repairShim(entry); err != nil {
+		cp.logger.Warnf("Shim %s is %s and could not be regenerated: %v", path, issue, err)
+		return
+	}
+	cp.logger.Warnf("Shim %s was %s and has been regenerated", path, issue)
+}
+
+// repairShim 删除损坏的垫片后重新生成
+func (cp *DefaultCommandProxy) repairShim(entry ShimEntry) error {
+	if issue := checkShimsDir(cp.fs, cp.shimsDir); issue != "" && issue != shimsDirMissing {
+		return fmt.Errorf("shims directory %s %s", cp.shimsDir, issue)
+	}
+
+	path := filepath.Join(cp.shimsDir, entry.Name)
+	// 失效的符号链接需要先删除，否则写入时会在链接目标处创建文件
+	if err := cp.fs.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove broken shim: %w", err)
+	}
+	return cp.shellIntegrator.GenerateShim(shimCommand(entry), path, cp.vmanPath)
+}
+
+// shimCommand 垫片传给 vman exec 的命令：别名为别名本身，工具为工具名
+func shimCommand(entry ShimEntry) string {
+	if entry.Kind == ShimKindAlias {
+		return entry.Name
+	}
+	return entry.Target
+}
+
+// checkShimsDir 检查shims目录存在且可写，正常时返回空字符串
+func checkShimsDir(fs afero.Fs, dir string) string {
+	info, err := fs.Stat(dir)
+	if os.IsNotExist(err) {
+		return shimsDirMissing
+	}
+	if err != nil {
+		return err.Error()
+	}
+	if !info.IsDir() {
+		return "is not a directory"
+	}
+
+	probe, err := afero.TempFile(fs, dir, ".vman-write-check-")
+	if err != nil {
+		return "is not writable"
+	}
+	name := probe.Name()
+	probe.Close()
+	fs.Remove(filepath.Clean(name))
+	return ""
+}
+
+// inspectShim 检查垫片文件，正常时返回空字符串
+func inspectShim(fs afero.Fs, path string, entry ShimEntry) string {
+	var info os.FileInfo
+	var err error
+	if lstater, ok := fs.(afero.Lstater); ok {
+		info, _, err = lstater.LstatIfPossible(path)
+	} else {
+		info, err = fs.Stat(path)
+	}
+	if os.IsNotExist(err) {
+		return ShimMissing
+	}
+	if err != nil {
+		return ShimBroken
+	}
+
+	// 旧版本生成的指向二进制文件的符号链接，不经过版本解析
+	if info.Mode()&os.ModeSymlink != 0 {
+		if _, err := fs.Stat(path); err != nil {
+			return ShimBroken
+		}
+		return ShimOutdated
+	}
+	if !info.Mode().IsRegular() || info.Size() == 0 {
+		return ShimBroken
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0111 == 0 {
+		return ShimNotExecutable
+	}
+
+	content, err := afero.ReadFile(fs, path)
+	if err != nil {
+		return ShimBroken
+	}
+	marker := "vman shim for " + shimCommand(entry)
+	for _, line := range strings.Split(string(content), "\n") {
+		if strings.HasSuffix(strings.TrimRight(line, "\r "), marker) {
+			return checkShimStamp(string(content))
+		}
+	}
+	return ShimBroken
+}
@@ -0,0 +1,119 @@
+package proxy
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/songzhibin97/vman/internal/config"
+	"github.com/songzhibin97/vman/internal/storage"
+	"github.com/songzhibin97/vman/internal/version"
+	"github.com/songzhibin97/vman/pkg/types"
+)
+
+func TestCommandProxy_CheckShims(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("垫片的执行权限和符号链接检查只适用于类Unix系统")
+	}
+	homeDir := t.TempDir()
+	t.Setenv("HOME", homeDir)
+	t.Setenv(types.EnvVmanHome, "")
+	t.Setenv(types.EnvVmanXDG, "")
+
+	paths := types.DefaultConfigPaths(homeDir)
+	configManager, err := config.NewManager(homeDir)
+	require.NoError(t, err)
+	require.NoError(t, configManager.Initialize())
+
+	fs := afero.NewOsFs()
+	tools := []string{"helm", "kubectl", "terraform", "vault"}
+	for _, tool := range tools {
+		binary := filepath.Join(paths.ToolVersionDir(tool, "1.0.0"), "bin", tool)
+		require.NoError(t, fs.MkdirAll(filepath.Dir(binary), 0755))
+		require.NoError(t, afero.WriteFile(fs, binary, []byte("#!/bin/sh\n"), 0755))
+	}
+
+	versionManager := version.NewManager(storage.NewFilesystemManager(paths), configManager)
+	cp := NewCommandProxyWithFs(fs, configManager, versionManager)
+	_ = cp.RehashShims()
+
+	report, err := cp.CheckShims()
+	require.NoError(t, err)
+	assert.Empty(t, report.DirIssue)
+	assert.Empty(t, report.Problems)
+
+	// 删除、去掉执行权限、替换为失效的符号链接、被其他内容覆盖
+	require.NoError(t, os.Remove(filepath.Join(paths.ShimsDir, "helm")))
+	require.NoError(t, os.Chmod(filepath.Join(paths.ShimsDir, "kubectl"), 0644))
+	require.NoError(t, os.Remove(filepath.Join(paths.ShimsDir, "terraform")))
+	require.NoError(t, os.Symlink(filepath.Join(homeDir, "gone"), filepath.Join(paths.ShimsDir, "terraform")))
+	require.NoError(t, os.WriteFile(filepath.Join(paths.ShimsDir, "vault"), []byte("#!/bin/sh\necho hi\n"), 0755))
+
+	report, err = cp.CheckShims()
+	require.NoError(t, err)
+	issues := make(map[string]string)
+	for _, problem := range report.Problems {
+		issues[problem.Entry.Name] = problem.Issue
+	}
+	assert.Equal(t, map[string]string{
+		"helm":      ShimMissing,
+		"kubectl":   ShimNotExecutable,
+		"terraform": ShimBroken,
+		"vault":     ShimBroken,
+	}, issues)
+
+	require.NoError(t, cp.RepairShims(report.Problems))
+	report, err = cp.CheckShims()
+	require.NoError(t, err)
+	assert.Empty(t, report.Problems)
+	_, err = os.Stat(filepath.Join(homeDir, "gone"))
+	assert.True(t, os.IsNotExist(err), "修复失效的符号链接时不应在链接目标处创建文件")
+
+	// 旧版本生成的垫片和手动修改的垫片
+	require.NoError(t, os.WriteFile(filepath.Join(paths.ShimsDir, "helm"), []byte("#!/bin/bash\n# vman shim for helm\nexec vman exec helm \"$@\"\n"), 0755))
+	kubectlShim := filepath.Join(paths.ShimsDir, "kubectl")
+	content, err := os.ReadFile(kubectlShim)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(kubectlShim, append(content, "# local tweak\n"...), 0755))
+	report, err = cp.CheckShims()
+	require.NoError(t, err)
+	issues = make(map[string]string)
+	for _, problem := range report.Problems {
+		issues[problem.Entry.Name] = problem.Issue
+	}
+	assert.Equal(t, map[string]string{"helm": ShimOutdated, "kubectl": ShimModified}, issues)
+
+	// 执行时只重新生成过时的垫片，手动修改的垫片保留
+	dcp := cp.(*DefaultCommandProxy)
+	dcp.healShim("helm", false, nil, types.Settings{})
+	dcp.healShim("kubectl", false, nil, types.Settings{})
+	assert.Empty(t, inspectShim(fs, filepath.Join(paths.ShimsDir, "helm"), ShimEntry{Name: "helm", Kind: ShimKindTool, Target: "helm"}))
+	assert.Equal(t, ShimModified, inspectShim(fs, kubectlShim, ShimEntry{Name: "kubectl", Kind: ShimKindTool, Target: "kubectl"}))
+	require.NoError(t, cp.RepairShims(report.Problems))
+
+	// 执行时自动修复
+	require.NoError(t, os.Remove(filepath.Join(paths.ShimsDir, "helm")))
+	dcp.healShim("helm", false, nil, types.Settings{Proxy: types.ProxySettings{SelfHeal: types.ShimHealWarn}})
+	assert.Equal(t, ShimMissing, inspectShim(fs, filepath.Join(paths.ShimsDir, "helm"), ShimEntry{Name: "helm", Kind: ShimKindTool, Target: "helm"}))
+	dcp.healShim("helm", false, nil, types.Settings{})
+	assert.Empty(t, inspectShim(fs, filepath.Join(paths.ShimsDir, "helm"), ShimEntry{Name: "helm", Kind: ShimKindTool, Target: "helm"}))
+
+	// 未安装的工具没有垫片，不会生成
+	dcp.healShim("jq", false, nil, types.Settings{})
+	_, err = os.Stat(filepath.Join(paths.ShimsDir, "jq"))
+	assert.True(t, os.IsNotExist(err))
+
+	// shims目录不可写
+	if os.Geteuid() != 0 {
+		require.NoError(t, os.Chmod(paths.ShimsDir, 0555))
+		defer os.Chmod(paths.ShimsDir, 0755)
+		report, err = cp.CheckShims()
+		require.NoError(t, err)
+		assert.Equal(t, "is not writable", report.DirIssue)
+	}
+}
@@ -0,0 +1,94 @@
+package proxy
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ShimFormatVersion 垫片格式版本，垫片的内容或行为改变时递增
+// 格式版本低于当前版本的垫片视为过时，执行时或 vman proxy rehash 时重新生成
+const ShimFormatVersion = 2
+
+// VmanVersion 生成垫片的vman版本，写入垫片的标记行，由命令行设置
+var VmanVersion = "dev"
+
+// shimStampKey 垫片中标记行的关键字
+const shimStampKey = "vman-shim:"
+
+// ShimStamp 垫片中记录的格式版本、vman版本和内容哈希
+type ShimStamp struct {
+	Format      int    `json:"format"`
+	VmanVersion string `json:"vman_version"`
+	SHA256      string `json:"sha256"`
+}
+
+// String 返回标记行中关键字之后的内容
+func (s ShimStamp) String() string {
+	return fmt.Sprintf("format=%d vman=%s sha256=%s", s.Format, s.VmanVersion, s.SHA256)
+}
+
+// stampShim 在垫片的 "vman shim for" 行之后插入标记行，哈希按去掉标记行的内容计算
+func stampShim(content string) string {
+	comment := "#"
+	if strings.HasPrefix(content, "@echo off") {
+		comment = "REM"
+	}
+	stamp := ShimStamp{Format: ShimFormatVersion, VmanVersion: VmanVersion, SHA256: shimHash(content)}
+
+	lines := strings.SplitAfter(content, "\n")
+	for i, line := range lines {
+		if strings.Contains(line, "vman shim for ") {
+			stamped := append([]string{}, lines[:i+1]...)
+			stamped = append(stamped, fmt.Sprintf("%s %s %s\n", comment, shimStampKey, stamp))
+			return strings.Join(append(stamped, lines[i+1:]...), "")
+		}
+	}
+	return content
+}
+
+// parseShimStamp 读取垫片的标记，返回标记和去掉标记行的内容；没有标记行时 ok 为 false
+func parseShimStamp(content string) (stamp ShimStamp, body string, ok bool) {
+	lines := strings.SplitAfter(content, "\n")
+	for i, line := range lines {
+		_, fields, found := strings.Cut(strings.TrimRight(line, "\r\n"), shimStampKey)
+		if !found {
+			continue
+		}
+		for _, field := range strings.Fields(fields) {
+			key, value, _ := strings.Cut(field, "=")
+			switch key {
+			case "format":
+				stamp.Format, _ = strconv.Atoi(value)
+			case "vman":
+				stamp.VmanVersion = value
+			case "sha256":
+				stamp.SHA256 = value
+			}
+		}
+		body = strings.Join(lines[:i], "") + strings.Join(lines[i+1:], "")
+		return stamp, body, true
+	}
+	return stamp, content, false
+}
+
+// shimHash 垫片内容（不含标记行）的SHA256
+func shimHash(body string) string {
+	sum := sha256.Sum256([]byte(body))
+	return hex.EncodeToString(sum[:])
+}
+
+// checkShimStamp 检查垫片的标记，正常时返回空字符串
+// 没有标记或格式版本较低的垫片由旧版本的vman生成，内容与哈希不一致的垫片被手动修改过
+func checkShimStamp(content string) string {
+	stamp, body, ok := parseShimStamp(content)
+	if !ok || stamp.Format < ShimFormatVersion {
+		return ShimOutdated
+	}
+	if stamp.SHA256 != shimHash(body) {
+		return ShimModified
+	}
+	return ""
+}
@@ -0,0 +1,40 @@
+package proxy
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShimStamp(t *testing.T) {
+	original := VmanVersion
+	VmanVersion = "1.2.3"
+	defer func() { VmanVersion = original }()
+
+	content := stampShim("#!/bin/bash\n# vman shim for kubectl\nexec \"/usr/local/bin/vman\" exec \"kubectl\" \"$@\"\n")
+	lines := strings.Split(content, "\n")
+	require.True(t, strings.HasPrefix(lines[2], "# vman-shim: format="))
+
+	stamp, _, ok := parseShimStamp(content)
+	require.True(t, ok)
+	assert.Equal(t, ShimFormatVersion, stamp.Format)
+	assert.Equal(t, "1.2.3", stamp.VmanVersion)
+	assert.Empty(t, checkShimStamp(content))
+
+	// 手动修改
+	edited := strings.Replace(content, "exec \"/usr/local/bin/vman\"", "exec \"/opt/vman\"", 1)
+	assert.Equal(t, ShimModified, checkShimStamp(edited))
+
+	// 没有标记或格式版本较低
+	assert.Equal(t, ShimOutdated, checkShimStamp("#!/bin/bash\n# vman shim for kubectl\nexec vman exec kubectl \"$@\"\n"))
+	old := strings.Replace(content, fmt.Sprintf("format=%d", ShimFormatVersion), "format=1", 1)
+	assert.Equal(t, ShimOutdated, checkShimStamp(old))
+
+	// Windows 垫片使用 REM 注释
+	batch := stampShim("@echo off\r\nREM vman shim for kubectl\r\n\"vman.exe\" exec \"kubectl\" %*\r\n")
+	assert.Contains(t, batch, "REM vman-shim: format=")
+	assert.Empty(t, checkShimStamp(batch))
+}
@@ -0,0 +1,302 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/spf13/afero"
+
+	"github.com/songzhibin97/vman/internal/storage"
+)
+
+// StatsFile 缓存目录中执行统计的文件名
+const StatsFile = "stats.json"
+
+// statsLockTimeout 等待其他进程写入统计的时长，超时后放弃本次记录
+const statsLockTimeout = 2 * time.Second
+
+// LatencyBuckets 耗时直方图各区间的上界，超过最后一个上界的计入最后一个区间之后
+var LatencyBuckets = []time.Duration{
+	time.Millisecond,
+	2 * time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	20 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	200 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2 * time.Second,
+	5 * time.Second,
+	10 * time.Second,
+	30 * time.Second,
+	time.Minute,
+	5 * time.Minute,
+}
+
+// TrackingSession 一次命令执行
+type TrackingSession struct {
+	Tool      string        `json:"tool"`
+	Version   string        `json:"version"`
+	StartTime time.Time     `json:"start_time"`
+	Duration  time.Duration `json:"duration"`
+	ExitCode  int           `json:"exit_code"`
+	Failed    bool          `json:"failed"`
+}
+
+// ToolStatistics 一个工具的执行统计
+type ToolStatistics struct {
+	Tool           string         `json:"tool"`
+	ExecutionCount int            `json:"execution_count"`
+	FailureCount   int            `json:"failure_count"`
+	TotalDuration  time.Duration  `json:"total_duration"`
+	MinDuration    time.Duration  `json:"min_duration"`
+	MaxDuration    time.Duration  `json:"max_duration"`
+	LastExecuted   time.Time      `json:"last_executed"`
+	Versions       map[string]int `json:"versions,omitempty"`
+	// Histogram 按 LatencyBuckets 划分的耗时分布，长度为 len(LatencyBuckets)+1
+	Histogram []int `json:"histogram"`
+}
+
+// ExecutionStatistics 所有工具的执行统计
+type ExecutionStatistics struct {
+	Since     time.Time                  `json:"since"`
+	ToolStats map[string]*ToolStatistics `json:"tools"`
+}
+
+// DefaultPerformanceMonitor 按工具汇总命令的执行次数和耗时
+// 设置了统计文件时每次执行结束都合并写入文件，供之后的进程和 vman stats 读取
+type DefaultPerformanceMonitor struct {
+	mu    sync.Mutex
+	fs    afero.Fs
+	path  string
+	stats *ExecutionStatistics
+}
+
+// NewPersistentPerformanceMonitor 创建将统计持久化到 path 的性能监控器
+func NewPersistentPerformanceMonitor(fs afero.Fs, path string) *DefaultPerformanceMonitor {
+	return &DefaultPerformanceMonitor{fs: fs, path: path}
+}
+
+// StartTracking 开始记录一次命令执行
+func (m *DefaultPerformanceMonitor) StartTracking(ctx context.Context, tool, version string) *TrackingSession {
+	return &TrackingSession{Tool: tool, Version: version, StartTime: time.Now()}
+}
+
+// EndTracking 结束记录并计入统计，写入统计文件失败不影响命令本身
+func (m *DefaultPerformanceMonitor) EndTracking(session *TrackingSession) {
+	session.Duration = time.Since(session.StartTime)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.path == "" {
+		m.memoryStats().Record(session)
+		return
+	}
+	_ = m.update(func(stats *ExecutionStatistics) {
+		stats.Record(session)
+	})
+}
+
+// GetStatistics 返回执行统计的副本
+func (m *DefaultPerformanceMonitor) GetStatistics() *ExecutionStatistics {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.path == "" {
+		return m.memoryStats().clone()
+	}
+	stats, err := LoadExecutionStatistics(m.fs, m.path)
+	if err != nil {
+		return NewExecutionStatistics()
+	}
+	return stats
+}
+
+// ClearStatistics 清除所有工具的统计
+func (m *DefaultPerformanceMonitor) ClearStatistics() {
+	_ = m.Reset()
+}
+
+// Reset 清除指定工具的统计，没有指定工具时清除全部
+func (m *DefaultPerformanceMonitor) Reset(tools ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	reset := func(stats *ExecutionStatistics) {
+		if len(tools) == 0 {
+			*stats = *NewExecutionStatistics()
+			return
+		}
+		for _, tool := range tools {
+			delete(stats.ToolStats, tool)
+		}
+	}
+
+	if m.path == "" {
+		reset(m.memoryStats())
+		return nil
+	}
+	return m.update(reset)
+}
+
+// memoryStats 返回只保存在内存中的统计
+func (m *DefaultPerformanceMonitor) memoryStats() *ExecutionStatistics {
+	if m.stats == nil {
+		m.stats = NewExecutionStatistics()
+	}
+	return m.stats
+}
+
+// update 在锁内读取统计文件、修改后写回，避免并发执行的命令互相覆盖
+func (m *DefaultPerformanceMonitor) update(modify func(*ExecutionStatistics)) error {
+	lock, err := storage.AcquireLock(m.fs, m.path+".lock", statsLockTimeout, storage.DefaultLockStaleAfter)
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	stats, err := LoadExecutionStatistics(m.fs, m.path)
+	if err != nil {
+		// 损坏的统计文件重新开始记录
+		stats = NewExecutionStatistics()
+	}
+	modify(stats)
+
+	data, err := json.Marshal(stats)
+	if err != nil {
+		return fmt.Errorf("failed to marshal statistics: %w", err)
+	}
+	tmpPath := m.path + ".tmp"
+	if err := afero.WriteFile(m.fs, tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write statistics: %w", err)
+	}
+	if err := m.fs.Rename(tmpPath, m.path); err != nil {
+		m.fs.Remove(tmpPath)
+		return fmt.Errorf("failed to write statistics: %w", err)
+	}
+	return nil
+}
+
+// NewExecutionStatistics 创建空的执行统计
+func NewExecutionStatistics() *ExecutionStatistics {
+	return &ExecutionStatistics{
+		Since:     time.Now(),
+		ToolStats: make(map[string]*ToolStatistics),
+	}
+}
+
+// LoadExecutionStatistics 读取统计文件，文件不存在时返回空的统计
+func LoadExecutionStatistics(fs afero.Fs, path string) (*ExecutionStatistics, error) {
+	data, err := afero.ReadFile(fs, path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return NewExecutionStatistics(), nil
+		}
+		return nil, fmt.Errorf("failed to read statistics: %w", err)
+	}
+
+	stats := &ExecutionStatistics{}
+	if err := json.Unmarshal(data, stats); err != nil {
+		return nil, fmt.Errorf("failed to parse statistics %s: %w", filepath.Base(path), err)
+	}
+	if stats.ToolStats == nil {
+		stats.ToolStats = make(map[string]*ToolStatistics)
+	}
+	return stats, nil
+}
+
+// Record 计入一次命令执行
+func (s *ExecutionStatistics) Record(session *TrackingSession) {
+	tool, ok := s.ToolStats[session.Tool]
+	if !ok {
+		tool = &ToolStatistics{Tool: session.Tool, MinDuration: session.Duration}
+		s.ToolStats[session.Tool] = tool
+	}
+	if len(tool.Histogram) != len(LatencyBuckets)+1 {
+		tool.Histogram = make([]int, len(LatencyBuckets)+1)
+	}
+	if tool.Versions == nil {
+		tool.Versions = make(map[string]int)
+	}
+
+	tool.ExecutionCount++
+	if session.Failed {
+		tool.FailureCount++
+	}
+	tool.TotalDuration += session.Duration
+	if session.Duration < tool.MinDuration {
+		tool.MinDuration = session.Duration
+	}
+	if session.Duration > tool.MaxDuration {
+		tool.MaxDuration = session.Duration
+	}
+	tool.LastExecuted = session.StartTime.Add(session.Duration)
+	if session.Version != "" {
+		tool.Versions[session.Version]++
+	}
+	tool.Histogram[sort.Search(len(LatencyBuckets), func(i int) bool {
+		return session.Duration <= LatencyBuckets[i]
+	})]++
+}
+
+// Tools 按名称排序返回所有工具的统计
+func (s *ExecutionStatistics) Tools() []*ToolStatistics {
+	tools := make([]*ToolStatistics, 0, len(s.ToolStats))
+	for _, tool := range s.ToolStats {
+		tools = append(tools, tool)
+	}
+	sort.Slice(tools, func(i, j int) bool { return tools[i].Tool < tools[j].Tool })
+	return tools
+}
+
+// clone 深拷贝统计
+func (s *ExecutionStatistics) clone() *ExecutionStatistics {
+	data, _ := json.Marshal(s)
+	copied := &ExecutionStatistics{}
+	_ = json.Unmarshal(data, copied)
+	if copied.ToolStats == nil {
+		copied.ToolStats = make(map[string]*ToolStatistics)
+	}
+	return copied
+}
+
+// AverageDuration 平均耗时
+func (t *ToolStatistics) AverageDuration() time.Duration {
+	if t.ExecutionCount == 0 {
+		return 0
+	}
+	return t.TotalDuration / time.Duration(t.ExecutionCount)
+}
+
+// Percentile 按直方图估算耗时的百分位数（p 取 0-100），结果为所在区间的上界，不超过最大耗时
+func (t *ToolStatistics) Percentile(p float64) time.Duration {
+	total := 0
+	for _, count := range t.Histogram {
+		total += count
+	}
+	if total == 0 {
+		return 0
+	}
+
+	rank := p / 100 * float64(total)
+	cumulative := 0
+	for i, count := range t.Histogram {
+		cumulative += count
+		if float64(cumulative) >= rank && count > 0 {
+			if i < len(LatencyBuckets) && LatencyBuckets[i] < t.MaxDuration {
+				return LatencyBuckets[i]
+			}
+			return t.MaxDuration
+		}
+	}
+	return t.MaxDuration
+}
@@ -0,0 +1,106 @@
+package proxy
+
+import (
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/afero"
+)
+
+// 生态版本文件：nvm 的 .nvmrc、pyenv 的 .python-version、tfenv 的 .terraform-version 等
+// 只记录一个工具的版本，文件名与工具的对应关系来自 settings.resolution.version_files。
+// 读取这些文件使 vman 可以直接替换这些工具，不需要把版本改写到 .vman.yaml 中。
+
+// nodeVersionAliases .nvmrc 中表示最新版本的写法
+var nodeVersionAliases = map[string]bool{"node": true, "stable": true, "current": true}
+
+// nodeLTSMajors Node.js LTS 代号对应的主版本，新的 LTS 版本线发布后需要在这里添加
+var nodeLTSMajors = map[string]int{
+	"argon": 4, "boron": 6, "carbon": 8, "dubnium": 10, "erbium": 12, "fermium": 14,
+	"gallium": 16, "hydrogen": 18, "iron": 20, "jod": 22, "krypton": 24,
+}
+
+// partialVersionPattern 只有主版本或主次版本的版本号，如 18、3.11
+var partialVersionPattern = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?$`)
+
+// versionPrefixPattern tfenv 的 latest:<正则> 中可以转换为版本前缀的正则，如 ^1.5、^1\.5\.
+var versionPrefixPattern = regexp.MustCompile(`^\^([0-9]+(?:\\?\.[0-9]+)?)(?:\\?\.)?$`)
+
+// ReadEcosystemVersion 依次读取目录中工具的生态版本文件，返回第一个文件中的版本和文件路径
+func ReadEcosystemVersion(fs afero.Fs, dir string, files []string) (version, path string) {
+	for _, name := range files {
+		path := filepath.Join(dir, name)
+		data, err := afero.ReadFile(fs, path)
+		if err != nil {
+			continue
+		}
+		if version := parseEcosystemVersion(data); version != "" {
+			return version, path
+		}
+	}
+	return "", ""
+}
+
+// parseEcosystemVersion 取第一个非空、非注释行的第一个字段作为版本（.python-version 可以列出多个版本，第一个优先），
+// 并按原工具的语义转换为 vman 的版本要求：去掉 v 前缀，18、3.11 等不完整的版本转换为 ~18、~3.11，
+// .nvmrc 中的 node、stable 视为 latest，lts/* 和 lts/<代号> 转换为对应的 LTS 主版本，
+// .terraform-version 中的 latest 和 latest:<版本前缀的正则> 转换为 latest 和前缀约束，其他写法原样返回
+func parseEcosystemVersion(data []byte) string {
+	for _, line := range strings.Split(string(data), "\n") {
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		return ecosystemVersionRequirement(fields[0])
+	}
+	return ""
+}
+
+// ecosystemVersionRequirement 将生态版本文件中的版本转换为 vman 的版本要求
+func ecosystemVersionRequirement(version string) string {
+	if nodeVersionAliases[version] || version == "latest" {
+		return "latest"
+	}
+	if codename, ok := strings.CutPrefix(version, "lts/"); ok {
+		if codename == "*" {
+			return nodeLTSConstraint()
+		}
+		if major, ok := nodeLTSMajors[strings.ToLower(codename)]; ok {
+			return "~" + strconv.Itoa(major)
+		}
+		return version
+	}
+	if pattern, ok := strings.CutPrefix(version, "latest:"); ok {
+		if match := versionPrefixPattern.FindStringSubmatch(pattern); match != nil {
+			return "~" + strings.ReplaceAll(match[1], `\`, "")
+		}
+		return version
+	}
+	if len(version) > 1 && (version[0] == 'v' || version[0] == 'V') && version[1] >= '0' && version[1] <= '9' {
+		version = version[1:]
+	}
+	if partialVersionPattern.MatchString(version) {
+		return "~" + version
+	}
+	return version
+}
+
+// nodeLTSConstraint 匹配所有 LTS 版本线的约束，如 ~4 || ~6 || ... ，解析时取其中已安装或可用的最高版本
+func nodeLTSConstraint() string {
+	majors := make([]int, 0, len(nodeLTSMajors))
+	for _, major := range nodeLTSMajors {
+		majors = append(majors, major)
+	}
+	sort.Ints(majors)
+	parts := make([]string, len(majors))
+	for i, major := range majors {
+		parts[i] = "~" + strconv.Itoa(major)
+	}
+	return strings.Join(parts, " || ")
+}
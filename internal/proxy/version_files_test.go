@@ -0,0 +1,88 @@
+package proxy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/songzhibin97/vman/internal/config"
+	"github.com/songzhibin97/vman/pkg/types"
+)
+
+func TestParseEcosystemVersion(t *testing.T) {
+	assert.Equal(t, "20.11.0", parseEcosystemVersion([]byte("v20.11.0\n")))
+	assert.Equal(t, "latest", parseEcosystemVersion([]byte("node\n")))
+	assert.Equal(t, "~20", parseEcosystemVersion([]byte("lts/iron")))
+	assert.Equal(t, "lts/unknown", parseEcosystemVersion([]byte("lts/unknown")))
+	assert.Equal(t, "~4 || ~6 || ~8 || ~10 || ~12 || ~14 || ~16 || ~18 || ~20 || ~22 || ~24", parseEcosystemVersion([]byte("lts/*\n")))
+	assert.Equal(t, "3.12.1", parseEcosystemVersion([]byte("# pyenv\n\n3.12.1 3.11.7\n")))
+	assert.Equal(t, "1.7.0", parseEcosystemVersion([]byte("1.7.0 # pinned\n")))
+	assert.Empty(t, parseEcosystemVersion([]byte("\n# empty\n")))
+
+	// 不完整的版本按前缀匹配
+	assert.Equal(t, "~18", parseEcosystemVersion([]byte("v18\n")))
+	assert.Equal(t, "~18", parseEcosystemVersion([]byte("18")))
+	assert.Equal(t, "~3.11", parseEcosystemVersion([]byte("3.11\n")))
+	assert.Equal(t, "3.12-dev", parseEcosystemVersion([]byte("3.12-dev\n")))
+
+	// tfenv 的 latest 和 latest:<正则>
+	assert.Equal(t, "latest", parseEcosystemVersion([]byte("latest\n")))
+	assert.Equal(t, "~1.5", parseEcosystemVersion([]byte("latest:^1.5\n")))
+	assert.Equal(t, "~1.5", parseEcosystemVersion([]byte(`latest:^1\.5\.`)))
+	assert.Equal(t, "~1", parseEcosystemVersion([]byte("latest:^1")))
+	assert.Equal(t, "latest:^1.[0-5]", parseEcosystemVersion([]byte("latest:^1.[0-5]")))
+}
+
+func TestResolveEcosystemPartialVersion(t *testing.T) {
+	// .nvmrc 中的 18 解析为已安装的最高 18.x 版本
+	for _, requirement := range []string{parseEcosystemVersion([]byte("18\n")), parseEcosystemVersion([]byte("lts/hydrogen\n"))} {
+		constraint, err := semver.NewConstraint(requirement)
+		require.NoError(t, err)
+		assert.True(t, constraint.Check(semver.MustParse("18.19.0")))
+		assert.False(t, constraint.Check(semver.MustParse("20.11.0")))
+	}
+	constraint, err := semver.NewConstraint(parseEcosystemVersion([]byte("lts/*")))
+	require.NoError(t, err)
+	assert.True(t, constraint.Check(semver.MustParse("20.11.0")))
+	assert.False(t, constraint.Check(semver.MustParse("21.6.0")))
+}
+
+func TestResolveFromVersionFiles(t *testing.T) {
+	project := t.TempDir()
+	nested := filepath.Join(project, "web", "app")
+	require.NoError(t, os.MkdirAll(nested, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(project, ".nvmrc"), []byte("v18.19.0\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(project, ".terraform-version"), []byte("1.5.7\n"), 0644))
+
+	configManager, err := config.NewManager(t.TempDir())
+	require.NoError(t, err)
+	resolver := NewVersionResolverWithFs(afero.NewOsFs(), configManager, nil).(*DefaultVersionResolver)
+	files := types.ResolutionSettings{}.VersionFilesFor("node")
+
+	version, source := resolver.resolveFromProject("node", nested, files)
+	assert.Equal(t, "18.19.0", version)
+	assert.Equal(t, filepath.Join(canonicalPath(project), ".nvmrc"), source)
+
+	// 较近目录中的文件优先，同一目录中 .vman.yaml 优先于生态版本文件
+	require.NoError(t, os.WriteFile(filepath.Join(nested, ".node-version"), []byte("20.11.0\n"), 0644))
+	version, _ = resolver.resolveFromProject("node", nested, files)
+	assert.Equal(t, "20.11.0", version)
+	require.NoError(t, os.WriteFile(filepath.Join(nested, ".vman.yaml"), []byte("version: \"1.0\"\ntools:\n  node: 21.0.0\n"), 0644))
+	version, _ = resolver.resolveFromProject("node", nested, files)
+	assert.Equal(t, "21.0.0", version)
+
+	// 只读取映射到该工具的文件，映射为空列表时不读取
+	version, _ = resolver.resolveFromProject("terraform", nested, types.DefaultVersionFiles["terraform"])
+	assert.Equal(t, "1.5.7", version)
+	version, _ = resolver.resolveFromProject("terraform", nested, types.ResolutionSettings{
+		VersionFiles: map[string][]string{"terraform": {}},
+	}.VersionFilesFor("terraform"))
+	assert.Empty(t, version)
+	version, _ = resolver.resolveFromProject("kubectl", nested, types.DefaultVersionFiles["kubectl"])
+	assert.Empty(t, version)
+}
@@ -14,6 +14,7 @@ import (
 
 	"github.com/songzhibin97/vman/internal/config"
 	"github.com/songzhibin97/vman/internal/version"
+	"github.com/songzhibin97/vman/pkg/types"
 )
 
 // VersionResolver 版本解析器接口
@@ -50,6 +51,9 @@ type VersionResolver interface {
 
 	// ClearVersionCache 清除版本缓存
 	ClearVersionCache() error
+
+	// FindSystemExecutable 在PATH中查找系统自带的工具（跳过shims目录）
+	FindSystemExecutable(toolName string) (string, error)
 }
 
 // VersionResolution 版本解析结果
@@ -57,7 +61,8 @@ type VersionResolution struct {
 	ToolName         string    `json:"tool_name"`
 	RequestedVersion string    `json:"requested_version,omitempty"`
 	Version          string    `json:"version"`
-	Source           string    `json:"source"` // "global", "project", "env", "alias", "constraint", "latest"
+	Source           string    `json:"source"`                // "global", "project", "env", "alias", "engines", "constraint", "latest", "fallback"
+	SystemPath       string    `json:"system_path,omitempty"` // 版本为system时实际使用的可执行文件
 	ProjectPath      string    `json:"project_path,omitempty"`
 	ConfigPath       string    `json:"config_path,omitempty"`
 	IsInstalled      bool      `json:"is_installed"`
@@ -82,6 +87,17 @@ type DefaultVersionResolver struct {
 	versionManager version.Manager
 	cache          map[string]*VersionCache // projectPath:toolName -> cache
 	cacheTTL       time.Duration
+	merger         config.Merger
+	pathManager    PathManager
+	shimsDir       string
+	systemCache    map[string]*systemExecutableCache // toolName -> cache
+}
+
+// systemExecutableCache 系统可执行文件查找缓存
+type systemExecutableCache struct {
+	path     string
+	pathEnv  string // 查找时的PATH，PATH变化后缓存失效
+	cachedAt time.Time
 }
 
 // NewVersionResolver 创建新的版本解析器
@@ -98,6 +114,10 @@ func NewVersionResolverWithFs(fs afero.Fs, configManager config.Manager, version
 		versionManager: versionManager,
 		cache:          make(map[string]*VersionCache),
 		cacheTTL:       5 * time.Minute, // 默认缓存5分钟
+		merger:         config.NewMerger(),
+		pathManager:    NewPathManagerWithFs(fs),
+		shimsDir:       defaultShimsDir(),
+		systemCache:    make(map[string]*systemExecutableCache),
 	}
 }
 
@@ -108,14 +128,16 @@ func (vr *DefaultVersionResolver) ResolveVersion(ctx context.Context, toolName,
 	// 检查缓存
 	if cached := vr.getFromCache(toolName, projectPath); cached != nil {
 		vr.logger.Debugf("Using cached version for %s: %s", toolName, cached.Version)
-		return &VersionResolution{
+		resolution := &VersionResolution{
 			ToolName:    toolName,
 			Version:     cached.Version,
 			Source:      cached.Source,
 			ProjectPath: projectPath,
 			IsInstalled: vr.IsVersionInstalled(toolName, cached.Version),
 			ResolvedAt:  time.Now(),
-		}, nil
+		}
+		vr.fillSystemPath(resolution)
+		return resolution, nil
 	}
 
 	resolution := &VersionResolution{
@@ -127,67 +149,128 @@ func (vr *DefaultVersionResolver) ResolveVersion(ctx context.Context, toolName,
 	// 优先级顺序解析版本：
 	// 1. 环境变量
 	// 2. 项目配置
-	// 3. 全局配置
-	// 4. 最新版本
+	// 3. 全局配置（包括 tools 中记录的当前版本）
+	// 4. package.json engines、go.mod 中声明的版本要求
+	// 5. 回退策略（settings.resolution.fallback）
+
+	// 1-3. 环境变量、项目配置和全局配置按 settings.resolution.merge_strategy 合并，
+	// 与 GetEffectiveConfig 使用同一个合并器
+	layers, strategy, projectConfigPath := vr.collectVersionLayers(toolName, projectPath)
+	merged, err := vr.merger.MergeVersions(layers, strategy)
+	if err != nil {
+		return nil, err
+	}
 
-	// 1. 检查环境变量
-	if version := vr.resolveFromEnvironment(toolName); version != "" {
-		if vr.IsVersionInstalled(toolName, version) {
-			resolution.Version = version
+	if selected, ok := merged[toolName]; ok {
+		if selected.Source == config.SourceEnv {
+			// 环境变量中的版本在收集时已确认安装
+			resolution.Version = selected.Version
 			resolution.Source = "env"
 			resolution.IsInstalled = true
 			vr.setCache(toolName, projectPath, resolution)
+			vr.fillSystemPath(resolution)
 			return resolution, nil
 		}
-	}
 
-	// 2. 检查项目配置
-	if version, configPath := vr.resolveFromProject(toolName, projectPath); version != "" {
+		source := "global"
+		if selected.Source == config.SourceProject {
+			source = "project"
+			resolution.ConfigPath = projectConfigPath
+		}
+
 		// 检查是否为别名或约束
-		resolvedVersion, err := vr.resolveVersionString(toolName, version)
+		resolvedVersion, err := vr.resolveVersionString(toolName, selected.Version)
 		if err != nil {
 			// 如果解析失败，返回错误，不要继续到下一个源
-			return nil, fmt.Errorf("failed to resolve project version %s for %s: %w", version, toolName, err)
+			return nil, fmt.Errorf("failed to resolve %s version %s for %s: %w", source, selected.Version, toolName, err)
 		}
-		resolution.RequestedVersion = version
+		resolution.RequestedVersion = selected.Version
 		resolution.Version = resolvedVersion
-		resolution.Source = "project"
-		resolution.ConfigPath = configPath
+		resolution.Source = source
 		resolution.IsInstalled = vr.IsVersionInstalled(toolName, resolvedVersion)
 		vr.setCache(toolName, projectPath, resolution)
+		vr.fillSystemPath(resolution)
 		return resolution, nil
 	}
 
-	// 3. 检查全局配置
-	if version := vr.resolveFromGlobal(toolName); version != "" {
-		resolvedVersion, err := vr.resolveVersionString(toolName, version)
-		if err != nil {
-			// 如果解析失败，返回错误，不要继续到下一个源
-			return nil, fmt.Errorf("failed to resolve global version %s for %s: %w", version, toolName, err)
+	// 4. 检查项目清单文件中声明的版本要求
+	if engine, ok := vr.resolveFromEngines(toolName, projectPath); ok {
+		resolvedVersion, err := vr.ResolveConstraint(toolName, engine.Constraint)
+		if err == nil {
+			resolution.RequestedVersion = engine.Constraint
+			resolution.Version = resolvedVersion
+			resolution.Source = "engines"
+			resolution.ConfigPath = engine.Source
+			resolution.IsInstalled = true
+			vr.setCache(toolName, projectPath, resolution)
+			return resolution, nil
 		}
-		resolution.RequestedVersion = version
-		resolution.Version = resolvedVersion
-		resolution.Source = "global"
-		resolution.IsInstalled = vr.IsVersionInstalled(toolName, resolvedVersion)
-		vr.setCache(toolName, projectPath, resolution)
-		return resolution, nil
+		vr.logger.Warnf("No installed version of %s satisfies %s from %s: %v", toolName, engine.Constraint, engine.Source, err)
 	}
 
-	// 4. 使用最新版本
-	latestVersion, err := vr.GetLatestVersion(toolName)
-	if err != nil {
-		return nil, fmt.Errorf("no version found for %s and failed to get latest: %w", toolName, err)
+	// 5. 没有配置版本时按回退策略处理
+	if err := vr.resolveFallback(toolName, resolution); err != nil {
+		return nil, err
 	}
-
-	resolution.Version = latestVersion
-	resolution.Source = "latest"
-	resolution.IsInstalled = vr.IsVersionInstalled(toolName, latestVersion)
 	vr.setCache(toolName, projectPath, resolution)
 
 	vr.logger.Infof("Resolved %s to version %s from %s", toolName, resolution.Version, resolution.Source)
 	return resolution, nil
 }
 
+// resolveFallback 按回退策略解析未配置版本的工具
+func (vr *DefaultVersionResolver) resolveFallback(toolName string, resolution *VersionResolution) error {
+	fallback := types.FallbackLatestInstalled
+	readOnly := false
+	if globalConfig, err := vr.configManager.LoadGlobal(); err == nil {
+		fallback = globalConfig.Settings.Resolution.FallbackFor(toolName)
+		readOnly = globalConfig.Settings.IsReadOnly()
+	}
+	vr.logger.Debugf("No version configured for %s, using fallback %s", toolName, fallback)
+
+	switch fallback {
+	case types.FallbackError:
+		return fmt.Errorf("no version configured for %s", toolName)
+
+	case types.FallbackSystem:
+		systemPath, err := vr.FindSystemExecutable(toolName)
+		if err != nil {
+			return fmt.Errorf("no version configured for %s and %w", toolName, err)
+		}
+		resolution.Version = types.SystemVersion
+		resolution.Source = "fallback"
+		resolution.SystemPath = systemPath
+		resolution.IsInstalled = true
+
+	case types.FallbackAutoInstall:
+		latestVersion, err := vr.GetLatestVersion(toolName)
+		if err != nil && readOnly {
+			return fmt.Errorf("no version configured for %s and auto-install is disabled: %w", toolName, types.ErrReadOnly)
+		}
+		if err != nil {
+			vr.logger.Infof("No version configured for %s, installing latest version", toolName)
+			latestVersion, err = vr.versionManager.InstallLatestVersion(toolName)
+			if err != nil {
+				return fmt.Errorf("no version configured for %s and auto-install failed: %w", toolName, err)
+			}
+		}
+		resolution.Version = latestVersion
+		resolution.Source = "fallback"
+		resolution.IsInstalled = vr.IsVersionInstalled(toolName, latestVersion)
+
+	default:
+		latestVersion, err := vr.GetLatestVersion(toolName)
+		if err != nil {
+			return fmt.Errorf("no version found for %s and failed to get latest: %w", toolName, err)
+		}
+		resolution.Version = latestVersion
+		resolution.Source = "latest"
+		resolution.IsInstalled = vr.IsVersionInstalled(toolName, latestVersion)
+	}
+
+	return nil
+}
+
 // GetVersionPath 获取版本路径
 func (vr *DefaultVersionResolver) GetVersionPath(toolName, version string) (string, error) {
 	return vr.versionManager.GetVersionPath(toolName, version)
@@ -274,6 +357,10 @@ func (vr *DefaultVersionResolver) GetAvailableVersions(toolName string) ([]strin
 
 // IsVersionInstalled 检查版本是否已安装
 func (vr *DefaultVersionResolver) IsVersionInstalled(toolName, version string) bool {
+	if version == types.SystemVersion {
+		_, err := vr.FindSystemExecutable(toolName)
+		return err == nil
+	}
 	return vr.versionManager.IsVersionInstalled(toolName, version)
 }
 
@@ -285,8 +372,8 @@ func (vr *DefaultVersionResolver) ResolveAlias(toolName, alias string) (string,
 	switch alias {
 	case "latest":
 		return vr.GetLatestVersion(toolName)
-	case "system":
-		// 系统版本，返回PATH中找到的版本（如果有的话）
+	case types.SystemVersion:
+		// 系统版本，PATH中存在该工具时返回system
 		return vr.getSystemVersion(toolName)
 	}
 
@@ -323,12 +410,22 @@ func (vr *DefaultVersionResolver) SetVersionCache(toolName, projectPath, version
 // ClearVersionCache 清除版本缓存
 func (vr *DefaultVersionResolver) ClearVersionCache() error {
 	vr.cache = make(map[string]*VersionCache)
+	vr.systemCache = make(map[string]*systemExecutableCache)
 	vr.logger.Info("Version cache cleared")
 	return nil
 }
 
 // resolveVersionString 解析版本字符串（可能是别名、约束或精确版本）
 func (vr *DefaultVersionResolver) resolveVersionString(toolName, versionStr string) (string, error) {
+	if versionStr == types.SystemVersion {
+		return vr.getSystemVersion(toolName)
+	}
+
+	// 带范围运算符的版本要求（如 ~1.29、^1.2、>=1.28）按约束解析
+	if strings.ContainsAny(versionStr, "~^<>=*, |") {
+		return vr.ResolveConstraint(toolName, versionStr)
+	}
+
 	// 首先验证版本格式是否有效
 	if err := vr.ValidateVersion(versionStr); err == nil {
 		// 这是一个有效的版本格式，检查是否已安装
@@ -371,9 +468,11 @@ func (vr *DefaultVersionResolver) resolveFromEnvironment(toolName string) string
 	return ""
 }
 
-// resolveFromProject 从项目配置解析版本
-func (vr *DefaultVersionResolver) resolveFromProject(toolName, projectPath string) (string, string) {
-	// 向上查找项目配置文件
+// resolveFromProject 从项目配置解析版本，versionFiles 为工具兼容读取的生态版本文件
+// 同一目录中 vman 自身的版本文件和项目配置优先于生态版本文件
+func (vr *DefaultVersionResolver) resolveFromProject(toolName, projectPath string, versionFiles []string) (string, string) {
+	// 解析符号链接后向上查找项目配置文件，通过符号链接进入项目时也能找到实际路径上层的配置
+	projectPath = canonicalPath(projectPath)
 	currentDir := projectPath
 	for {
 		// 检查 .vman-version 文件
@@ -394,16 +493,35 @@ func (vr *DefaultVersionResolver) resolveFromProject(toolName, projectPath strin
 			}
 		}
 
-		// 检查项目配置文件
+		// 检查项目配置文件，workspaces 中按当前的 terraform 工作区匹配，
+		// paths 中按工作目录相对于配置文件所在目录的路径匹配
 		projectConfig, err := vr.configManager.LoadProject(currentDir)
-		if err == nil && projectConfig.Tools != nil {
-			if version, exists := projectConfig.Tools[toolName]; exists {
+		if err == nil {
+			if len(projectConfig.Workspaces) > 0 {
+				workspace, _ := config.ActiveWorkspace(vr.fs, projectPath, currentDir)
+				if version, ok := projectConfig.ToolVersionForWorkspace(toolName, workspace); ok {
+					vr.logger.Debugf("Found version in project config workspaces[%s]: %s", workspace, version)
+					return version, vr.configManager.GetProjectConfigPath(currentDir)
+				}
+			}
+			relPath, _ := filepath.Rel(currentDir, projectPath)
+			if version, pattern, ok := projectConfig.ToolVersionForPath(toolName, filepath.ToSlash(relPath)); ok {
 				configPath := vr.configManager.GetProjectConfigPath(currentDir)
-				vr.logger.Debugf("Found version in project config: %s", version)
+				if pattern != "" {
+					vr.logger.Debugf("Found version in project config paths[%s]: %s", pattern, version)
+				} else {
+					vr.logger.Debugf("Found version in project config: %s", version)
+				}
 				return version, configPath
 			}
 		}
 
+		// 检查 .nvmrc、.python-version 等生态版本文件
+		if version, path := ReadEcosystemVersion(vr.fs, currentDir, versionFiles); version != "" {
+			vr.logger.Debugf("Found version in %s: %s", path, version)
+			return version, path
+		}
+
 		// 向上一级目录
 		parentDir := filepath.Dir(currentDir)
 		if parentDir == currentDir {
@@ -416,28 +534,124 @@ func (vr *DefaultVersionResolver) resolveFromProject(toolName, projectPath strin
 	return "", ""
 }
 
-// resolveFromGlobal 从全局配置解析版本
-func (vr *DefaultVersionResolver) resolveFromGlobal(toolName string) string {
-	globalConfig, err := vr.configManager.LoadGlobal()
-	if err != nil {
+// resolveFromEngines 从项目清单文件（package.json、go.mod）中查找版本要求
+func (vr *DefaultVersionResolver) resolveFromEngines(toolName, projectPath string) (EngineConstraint, bool) {
+	currentDir := canonicalPath(projectPath)
+	for {
+		if engine, ok := ReadEngineConstraints(vr.fs, currentDir)[toolName]; ok {
+			vr.logger.Debugf("Found version requirement in %s: %s", engine.Source, engine.Constraint)
+			return engine, true
+		}
+
+		parentDir := filepath.Dir(currentDir)
+		if parentDir == currentDir {
+			break
+		}
+		currentDir = parentDir
+	}
+
+	return EngineConstraint{}, false
+}
+
+// collectVersionLayers 收集工具在全局配置、项目配置和环境变量中的版本，按优先级从低到高排列
+// 同时返回配置的合并策略和项目配置文件路径
+func (vr *DefaultVersionResolver) collectVersionLayers(toolName, projectPath string) ([]config.VersionLayer, types.ConfigMergeStrategy, string) {
+	strategy := types.OverrideStrategy
+	versionFiles := types.DefaultVersionFiles[toolName]
+	var layers []config.VersionLayer
+
+	if globalConfig, err := vr.configManager.LoadGlobal(); err != nil {
 		vr.logger.Warnf("Failed to load global config: %v", err)
-		return ""
+	} else {
+		strategy = globalConfig.Settings.Resolution.Strategy()
+		versionFiles = globalConfig.Settings.Resolution.VersionFilesFor(toolName)
+		layers = append(layers, config.GlobalVersionLayer(globalConfig).Filter(toolName))
 	}
 
-	if globalConfig.GlobalVersions != nil {
-		if version, exists := globalConfig.GlobalVersions[toolName]; exists {
-			vr.logger.Debugf("Found version in global config: %s", version)
-			return version
-		}
+	var configPath string
+	if version, path := vr.resolveFromProject(toolName, projectPath, versionFiles); version != "" {
+		configPath = path
+		layers = append(layers, config.VersionLayer{
+			Source:   config.SourceProject,
+			Versions: map[string]string{toolName: version},
+		})
 	}
 
-	return ""
+	// 环境变量中的版本只在已安装时生效
+	if version := vr.resolveFromEnvironment(toolName); version != "" && vr.IsVersionInstalled(toolName, version) {
+		layers = append(layers, config.VersionLayer{
+			Source:   config.SourceEnv,
+			Versions: map[string]string{toolName: version},
+		})
+	}
+
+	return layers, strategy, configPath
 }
 
 // getSystemVersion 获取系统版本
 func (vr *DefaultVersionResolver) getSystemVersion(toolName string) (string, error) {
-	// 这里应该调用系统命令来获取版本，暂时返回错误
-	return "", fmt.Errorf("system version resolution not implemented")
+	if _, err := vr.FindSystemExecutable(toolName); err != nil {
+		return "", err
+	}
+	return types.SystemVersion, nil
+}
+
+// FindSystemExecutable 在PATH中查找系统自带的工具（跳过shims目录）
+func (vr *DefaultVersionResolver) FindSystemExecutable(toolName string) (string, error) {
+	pathEnv := os.Getenv("PATH")
+	if cached, ok := vr.systemCache[toolName]; ok {
+		if cached.pathEnv == pathEnv && time.Since(cached.cachedAt) < vr.cacheTTL {
+			if cached.path == "" {
+				return "", fmt.Errorf("no system %s found in PATH", toolName)
+			}
+			return cached.path, nil
+		}
+		delete(vr.systemCache, toolName)
+	}
+
+	path := vr.findSystemExecutable(toolName)
+	vr.systemCache[toolName] = &systemExecutableCache{
+		path:     path,
+		pathEnv:  pathEnv,
+		cachedAt: time.Now(),
+	}
+
+	if path == "" {
+		return "", fmt.Errorf("no system %s found in PATH", toolName)
+	}
+	vr.logger.Debugf("Found system %s: %s", toolName, path)
+	return path, nil
+}
+
+// findSystemExecutable 返回PATH中第一个不在shims目录中的同名可执行文件
+func (vr *DefaultVersionResolver) findSystemExecutable(toolName string) string {
+	shimsDir := filepath.Clean(vr.shimsDir)
+	realShimsDir, err := filepath.EvalSymlinks(shimsDir)
+	if err != nil {
+		realShimsDir = shimsDir
+	}
+
+	for _, path := range vr.pathManager.FindExecutables(toolName) {
+		dir := filepath.Clean(filepath.Dir(path))
+		if dir == shimsDir || dir == realShimsDir {
+			continue
+		}
+		if realDir, err := filepath.EvalSymlinks(dir); err == nil && realDir == realShimsDir {
+			continue
+		}
+		return path
+	}
+	return ""
+}
+
+// fillSystemPath 版本为system时记录实际使用的可执行文件
+func (vr *DefaultVersionResolver) fillSystemPath(resolution *VersionResolution) {
+	if resolution.Version != types.SystemVersion {
+		return
+	}
+	if path, err := vr.FindSystemExecutable(resolution.ToolName); err == nil {
+		resolution.SystemPath = path
+	}
 }
 
 // readVersionFromFile 从版本文件读取版本
@@ -0,0 +1,122 @@
+package proxy
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+// GitInfo 项目根目录的git信息
+type GitInfo struct {
+	GitDir       string `json:"git_dir"`                 // 实际的git目录
+	IsWorktree   bool   `json:"is_worktree"`             // 是否为 git worktree 创建的工作树
+	MainWorktree string `json:"main_worktree,omitempty"` // worktree 所属的主工作树
+}
+
+// rootCacheEntry 项目根目录缓存项，按目录的设备号和inode匹配
+type rootCacheEntry struct {
+	info     os.FileInfo
+	root     string
+	cachedAt time.Time
+}
+
+// canonicalPath 解析路径中的符号链接，无法解析时返回清理后的路径
+func canonicalPath(dir string) string {
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+		return resolved
+	}
+	return filepath.Clean(dir)
+}
+
+// readGitInfo 读取目录下的 .git，支持 .git 目录以及 worktree 和 submodule 使用的 "gitdir: <path>" 文件
+func readGitInfo(fs afero.Fs, dir string) (*GitInfo, bool) {
+	dotGit := filepath.Join(dir, ".git")
+	info, err := fs.Stat(dotGit)
+	if err != nil {
+		return nil, false
+	}
+	if info.IsDir() {
+		return &GitInfo{GitDir: dotGit}, true
+	}
+
+	gitDir := readGitDirPointer(fs, dotGit)
+	if gitDir == "" {
+		return nil, false
+	}
+	if !filepath.IsAbs(gitDir) {
+		gitDir = filepath.Join(dir, gitDir)
+	}
+	gitDir = filepath.Clean(gitDir)
+	if stat, err := fs.Stat(gitDir); err != nil || !stat.IsDir() {
+		return nil, false
+	}
+
+	result := &GitInfo{GitDir: gitDir}
+
+	// worktree 的git目录中有指向主仓库git目录的 commondir 文件
+	if data, err := afero.ReadFile(fs, filepath.Join(gitDir, "commondir")); err == nil {
+		commonDir := strings.TrimSpace(string(data))
+		if !filepath.IsAbs(commonDir) {
+			commonDir = filepath.Join(gitDir, commonDir)
+		}
+		result.IsWorktree = true
+		result.MainWorktree = filepath.Dir(filepath.Clean(commonDir))
+	}
+
+	return result, true
+}
+
+// readGitDirPointer 读取 .git 文件中的 gitdir 指向
+func readGitDirPointer(fs afero.Fs, path string) string {
+	file, err := fs.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "gitdir:") {
+			return strings.TrimSpace(strings.TrimPrefix(line, "gitdir:"))
+		}
+	}
+	return ""
+}
+
+// getRootFromCache 按设备号和inode查找缓存的项目根目录，同一目录通过不同的符号链接进入时命中同一缓存
+func (cm *DefaultContextManager) getRootFromCache(info os.FileInfo) (string, bool) {
+	if info == nil {
+		return "", false
+	}
+	for i, entry := range cm.rootCache {
+		if !os.SameFile(entry.info, info) {
+			continue
+		}
+		if time.Since(entry.cachedAt) > cm.cacheTimeout {
+			cm.rootCache = append(cm.rootCache[:i], cm.rootCache[i+1:]...)
+			return "", false
+		}
+		return entry.root, true
+	}
+	return "", false
+}
+
+// setRootCache 缓存项目根目录
+func (cm *DefaultContextManager) setRootCache(info os.FileInfo, root string) {
+	if info == nil {
+		return
+	}
+	cm.rootCache = append(cm.rootCache, rootCacheEntry{
+		info:     info,
+		root:     root,
+		cachedAt: time.Now(),
+	})
+}
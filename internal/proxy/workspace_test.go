@@ -0,0 +1,97 @@
+package proxy
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindProjectRoot_GitWorktree(t *testing.T) {
+	root := canonicalPath(t.TempDir())
+
+	// 主仓库
+	mainRepo := filepath.Join(root, "repo")
+	worktreeGitDir := filepath.Join(mainRepo, ".git", "worktrees", "feature")
+	require.NoError(t, os.MkdirAll(worktreeGitDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(worktreeGitDir, "commondir"), []byte("../..\n"), 0644))
+
+	// git worktree add 创建的工作树，.git 是一个文件
+	worktree := filepath.Join(root, "feature")
+	subDir := filepath.Join(worktree, "src", "pkg")
+	require.NoError(t, os.MkdirAll(subDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(worktree, ".git"), []byte("gitdir: "+worktreeGitDir+"\n"), 0644))
+
+	cm := NewContextManager(nil).(*DefaultContextManager)
+
+	found, err := cm.FindProjectRoot(subDir)
+	require.NoError(t, err)
+	assert.Equal(t, worktree, found)
+
+	info, ok := readGitInfo(afero.NewOsFs(), worktree)
+	require.True(t, ok)
+	assert.Equal(t, worktreeGitDir, info.GitDir)
+	assert.True(t, info.IsWorktree)
+	assert.Equal(t, mainRepo, info.MainWorktree)
+
+	t.Run("RelativeGitDir", func(t *testing.T) {
+		submodule := filepath.Join(mainRepo, "vendor", "lib")
+		moduleGitDir := filepath.Join(mainRepo, ".git", "modules", "lib")
+		require.NoError(t, os.MkdirAll(submodule, 0755))
+		require.NoError(t, os.MkdirAll(moduleGitDir, 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(submodule, ".git"), []byte("gitdir: ../../.git/modules/lib\n"), 0644))
+
+		info, ok := readGitInfo(afero.NewOsFs(), submodule)
+		require.True(t, ok)
+		assert.Equal(t, moduleGitDir, info.GitDir)
+		assert.False(t, info.IsWorktree)
+	})
+
+	t.Run("DanglingGitFileIgnored", func(t *testing.T) {
+		parent := filepath.Join(root, "outer")
+		stale := filepath.Join(parent, "stale")
+		require.NoError(t, os.MkdirAll(stale, 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(parent, "go.mod"), []byte("module outer\n"), 0644))
+		require.NoError(t, os.WriteFile(filepath.Join(stale, ".git"), []byte("gitdir: /does/not/exist\n"), 0644))
+
+		found, err := cm.FindProjectRoot(stale)
+		require.NoError(t, err)
+		assert.Equal(t, parent, found)
+	})
+}
+
+func TestFindProjectRoot_Symlink(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks require elevated privileges on windows")
+	}
+
+	root := canonicalPath(t.TempDir())
+	project := filepath.Join(root, "project")
+	subDir := filepath.Join(project, "cmd")
+	require.NoError(t, os.MkdirAll(subDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(project, "go.mod"), []byte("module example\n"), 0644))
+
+	// 通过指向子目录的符号链接进入项目，链接本身所在目录没有项目标识
+	link := filepath.Join(root, "links", "cmd")
+	require.NoError(t, os.MkdirAll(filepath.Dir(link), 0755))
+	require.NoError(t, os.Symlink(subDir, link))
+
+	cm := NewContextManager(nil).(*DefaultContextManager)
+
+	found, err := cm.FindProjectRoot(link)
+	require.NoError(t, err)
+	assert.Equal(t, project, found)
+
+	// 同一目录通过实际路径进入时命中同一个缓存项
+	found, err = cm.FindProjectRoot(subDir)
+	require.NoError(t, err)
+	assert.Equal(t, project, found)
+	assert.Len(t, cm.rootCache, 1)
+
+	cm.ClearContextCache()
+	assert.Empty(t, cm.rootCache)
+}
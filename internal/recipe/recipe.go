@@ -0,0 +1,233 @@
+// Package recipe 提供常用工具的预置定义（recipe），vman add 据此直接生成工具定义而不需要手写 TOML
+//
+// 每个 recipe 是一个以工具名命名的目录：
+//
+//	<tool>/recipe.toml        工具定义，格式与 tools/<tool>.toml 相同
+//	<tool>/hooks/<event>      可选的钩子脚本，如 post-install，添加工具时复制到钩子目录
+//
+// 除内置 recipe 外，还可以在配置目录下的 recipes 目录或 recipes.dirs 设置的目录中放置 recipe
+// （如克隆的社区 recipe 仓库），这些目录按顺序优先于内置 recipe。recipes.registries 中的远程注册表
+// 由 vman registry update 下载到配置目录下的 registries 目录，索引需要由受信任的发布者签名。
+package recipe
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+
+	"github.com/BurntSushi/toml"
+	"github.com/spf13/afero"
+
+	"github.com/songzhibin97/vman/pkg/types"
+	"github.com/songzhibin97/vman/pkg/utils"
+)
+
+// BundledSource 内置 recipe 的来源名称
+const BundledSource = "bundled"
+
+// recipeFile recipe 目录中的工具定义文件名
+const recipeFile = "recipe.toml"
+
+// hooksDir recipe 目录中的钩子脚本目录名
+const hooksDir = "hooks"
+
+//go:embed bundled
+var bundled embed.FS
+
+// ErrNotFound 没有找到工具的 recipe
+var ErrNotFound = errors.New("recipe not found")
+
+// Recipe 一个工具的 recipe
+type Recipe struct {
+	// Name 工具名称
+	Name string
+
+	// Source recipe 所在的目录，内置 recipe 为 BundledSource
+	Source string
+
+	// Metadata 工具定义
+	Metadata *types.ToolMetadata
+
+	// Definition recipe.toml 的原始内容
+	Definition []byte
+
+	// Hooks 钩子脚本，键为文件名（如 post-install）
+	Hooks map[string][]byte
+}
+
+// Registry 按顺序在多个目录和内置 recipe 中查找 recipe
+type Registry struct {
+	sources []fs.FS
+	names   []string
+}
+
+// NewRegistry 创建 recipe 注册表，dirs 中的目录按顺序优先于内置 recipe，不存在的目录会被忽略
+func NewRegistry(dirs ...string) *Registry {
+	r := &Registry{}
+	for _, dir := range dirs {
+		if dir == "" {
+			continue
+		}
+		r.sources = append(r.sources, os.DirFS(dir))
+		r.names = append(r.names, dir)
+	}
+
+	sub, _ := fs.Sub(bundled, BundledSource)
+	r.sources = append(r.sources, sub)
+	r.names = append(r.names, BundledSource)
+	return r
+}
+
+// NewRegistryForSettings 按设置创建 recipe 注册表
+// 配置目录下的 recipes 目录最先查找，然后是 recipes.dirs 中的目录和通过 vman registry update
+// 下载的远程注册表，最后是内置 recipe
+func NewRegistryForSettings(configDir string, settings types.RecipeSettings) *Registry {
+	dirs := []string{filepath.Join(configDir, "recipes")}
+	for _, dir := range settings.Dirs {
+		if expanded, err := utils.ExpandPath(dir); err == nil {
+			dir = expanded
+		}
+		dirs = append(dirs, dir)
+	}
+	for _, registry := range settings.Registries {
+		dirs = append(dirs, RegistryDir(configDir, registry.Name))
+	}
+	return NewRegistry(dirs...)
+}
+
+// Lookup 查找工具的 recipe，找不到时返回 ErrNotFound
+func (r *Registry) Lookup(name string) (*Recipe, error) {
+	if !fs.ValidPath(name) || path.Base(name) != name {
+		return nil, fmt.Errorf("invalid recipe name: %s", name)
+	}
+
+	for i, source := range r.sources {
+		if _, err := fs.Stat(source, path.Join(name, recipeFile)); err != nil {
+			continue
+		}
+		return load(source, r.names[i], name)
+	}
+	return nil, fmt.Errorf("%w: %s", ErrNotFound, name)
+}
+
+// List 列出所有可用的 recipe，同名 recipe 只返回优先级最高的一个
+func (r *Registry) List() ([]*Recipe, error) {
+	seen := make(map[string]bool)
+	var recipes []*Recipe
+
+	for i, source := range r.sources {
+		entries, err := fs.ReadDir(source, ".")
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			name := entry.Name()
+			if !entry.IsDir() || seen[name] {
+				continue
+			}
+			if _, err := fs.Stat(source, path.Join(name, recipeFile)); err != nil {
+				continue
+			}
+			recipe, err := load(source, r.names[i], name)
+			if err != nil {
+				return nil, err
+			}
+			seen[name] = true
+			recipes = append(recipes, recipe)
+		}
+	}
+
+	sort.Slice(recipes, func(i, j int) bool {
+		return recipes[i].Name < recipes[j].Name
+	})
+	return recipes, nil
+}
+
+// load 读取 recipe 目录中的工具定义和钩子脚本
+func load(source fs.FS, sourceName, name string) (*Recipe, error) {
+	data, err := fs.ReadFile(source, path.Join(name, recipeFile))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read recipe %s: %w", name, err)
+	}
+
+	var metadata types.ToolMetadata
+	if err := toml.Unmarshal(data, &metadata); err != nil {
+		return nil, fmt.Errorf("failed to parse recipe %s from %s: %w", name, sourceName, err)
+	}
+	if metadata.Name == "" {
+		metadata.Name = name
+	}
+	if metadata.Name != name {
+		return nil, fmt.Errorf("recipe %s from %s defines tool %s", name, sourceName, metadata.Name)
+	}
+
+	recipe := &Recipe{
+		Name:       name,
+		Source:     sourceName,
+		Metadata:   &metadata,
+		Definition: data,
+		Hooks:      make(map[string][]byte),
+	}
+
+	entries, err := fs.ReadDir(source, path.Join(name, hooksDir))
+	if err != nil {
+		return recipe, nil
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		script, err := fs.ReadFile(source, path.Join(name, hooksDir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read hook %s of recipe %s: %w", entry.Name(), name, err)
+		}
+		recipe.Hooks[entry.Name()] = script
+	}
+	return recipe, nil
+}
+
+// Save 将 recipe 保存为工具定义目录下的 <tool>.toml，返回写入的文件路径
+func (r *Recipe) Save(toolsDir string) (string, error) {
+	if err := os.MkdirAll(toolsDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create tools directory: %w", err)
+	}
+	target := filepath.Join(toolsDir, r.Name+".toml")
+	// 工具定义中的下载请求头可能包含认证信息
+	if err := utils.WritePrivateFile(afero.NewOsFs(), target, r.Definition); err != nil {
+		return "", fmt.Errorf("failed to write tool definition %s: %w", target, err)
+	}
+	return target, nil
+}
+
+// InstallHooks 将 recipe 的钩子脚本复制到钩子目录下以工具名命名的子目录中，返回写入的文件路径
+func (r *Recipe) InstallHooks(hooksRoot string) ([]string, error) {
+	if len(r.Hooks) == 0 {
+		return nil, nil
+	}
+
+	dir := filepath.Join(hooksRoot, r.Name)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create hooks directory: %w", err)
+	}
+
+	names := make([]string, 0, len(r.Hooks))
+	for name := range r.Hooks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var written []string
+	for _, name := range names {
+		target := filepath.Join(dir, name)
+		if err := utils.WriteExecutableFile(afero.NewOsFs(), target, r.Hooks[name]); err != nil {
+			return written, fmt.Errorf("failed to write hook %s: %w", target, err)
+		}
+		written = append(written, target)
+	}
+	return written, nil
+}
@@ -0,0 +1,213 @@
+package recipe_test
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/songzhibin97/vman/internal/config"
+	"github.com/songzhibin97/vman/internal/download"
+	"github.com/songzhibin97/vman/internal/recipe"
+)
+
+func TestBundledRecipes(t *testing.T) {
+	recipes, err := recipe.NewRegistry().List()
+	require.NoError(t, err)
+
+	names := make([]string, 0, len(recipes))
+	for _, r := range recipes {
+		names = append(names, r.Name)
+	}
+	assert.GreaterOrEqual(t, len(names), 30)
+	for _, name := range []string{"kubectl", "helm", "terraform", "node", "go", "jq", "gh", "awscli", "kustomize", "k9s", "argocd", "vault", "yq", "protoc"} {
+		assert.Contains(t, names, name)
+	}
+
+	validator := config.NewValidator()
+	for _, r := range recipes {
+		t.Run(r.Name, func(t *testing.T) {
+			assert.Equal(t, recipe.BundledSource, r.Source)
+			require.NoError(t, validator.ValidateToolMetadata(r.Metadata))
+
+			// 模板在所有平台上都能展开
+			for _, goos := range []string{"linux", "darwin", "windows"} {
+				for _, arch := range []string{"amd64", "arm64"} {
+					data := download.TemplateData{Name: r.Name, Version: "1.2.3", OS: goos, Arch: arch}
+					for _, text := range []string{r.Metadata.DownloadConfig.URLTemplate, r.Metadata.DownloadConfig.AssetPattern} {
+						expanded, err := download.ExpandTemplate(text, data)
+						require.NoError(t, err)
+						assert.NotContains(t, expanded, "{")
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestRegistry_UserDirOverridesBundled(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "kubectl", "hooks"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "kubectl", "recipe.toml"), []byte(`
+description = "patched kubectl"
+homepage = "https://example.com"
+repository = "https://example.com/kubectl"
+
+[download]
+type = "direct"
+url_template = "https://mirror.example.com/kubectl/{version}/{os}/{arch}/kubectl"
+`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "kubectl", "hooks", "post-install"), []byte("#!/bin/sh\necho done\n"), 0644))
+
+	registry := recipe.NewRegistry(filepath.Join(dir, "missing"), dir)
+	r, err := registry.Lookup("kubectl")
+	require.NoError(t, err)
+	assert.Equal(t, dir, r.Source)
+	assert.Equal(t, "kubectl", r.Metadata.Name)
+	assert.Equal(t, "patched kubectl", r.Metadata.Description)
+
+	hooksRoot := t.TempDir()
+	written, err := r.InstallHooks(hooksRoot)
+	require.NoError(t, err)
+	require.Len(t, written, 1)
+	assert.Equal(t, filepath.Join(hooksRoot, "kubectl", "post-install"), written[0])
+	info, err := os.Stat(written[0])
+	require.NoError(t, err)
+	if filepath.Separator == '/' {
+		assert.NotZero(t, info.Mode()&0111)
+	}
+
+	recipes, err := registry.List()
+	require.NoError(t, err)
+	count := 0
+	for _, r := range recipes {
+		if r.Name == "kubectl" {
+			count++
+			assert.Equal(t, dir, r.Source)
+		}
+	}
+	assert.Equal(t, 1, count)
+}
+
+func TestRegistry_Lookup(t *testing.T) {
+	registry := recipe.NewRegistry()
+
+	_, err := registry.Lookup("no-such-tool")
+	assert.ErrorIs(t, err, recipe.ErrNotFound)
+
+	_, err = registry.Lookup("../kubectl")
+	assert.Error(t, err)
+
+	r, err := registry.Lookup("go")
+	require.NoError(t, err)
+	assert.Contains(t, r.Hooks, "post-install")
+}
+
+// TestBundledRecipes_ReleasePatterns 用真实发布的下载地址和资产名称检查 recipe 的模板
+func TestBundledRecipes_ReleasePatterns(t *testing.T) {
+	tests := []struct {
+		tool    string
+		version string
+		os      string
+		arch    string
+		// want 直接下载时展开后的地址，GitHub 发布时应匹配的资产名称
+		want string
+		// reject GitHub 发布中不应匹配的资产名称
+		reject []string
+	}{
+		{tool: "kubectl", version: "1.30.0", os: "linux", arch: "amd64", want: "https://dl.k8s.io/release/v1.30.0/bin/linux/amd64/kubectl"},
+		{tool: "kubectl", version: "1.30.0", os: "windows", arch: "amd64", want: "https://dl.k8s.io/release/v1.30.0/bin/windows/amd64/kubectl.exe"},
+		{tool: "helm", version: "3.14.0", os: "darwin", arch: "arm64", want: "https://get.helm.sh/helm-v3.14.0-darwin-arm64.tar.gz"},
+		{tool: "helm", version: "3.14.0", os: "windows", arch: "amd64", want: "https://get.helm.sh/helm-v3.14.0-windows-amd64.zip"},
+		{tool: "kustomize", version: "5.3.0", os: "linux", arch: "amd64", want: "https://github.com/kubernetes-sigs/kustomize/releases/download/kustomize%2Fv5.3.0/kustomize_v5.3.0_linux_amd64.tar.gz"},
+		{tool: "kind", version: "0.22.0", os: "darwin", arch: "arm64", want: "https://github.com/kubernetes-sigs/kind/releases/download/v0.22.0/kind-darwin-arm64"},
+		{tool: "minikube", version: "1.32.0", os: "linux", arch: "arm64", want: "https://github.com/kubernetes/minikube/releases/download/v1.32.0/minikube-linux-arm64"},
+		{tool: "skaffold", version: "2.10.0", os: "linux", arch: "amd64", want: "https://storage.googleapis.com/skaffold/releases/v2.10.0/skaffold-linux-amd64"},
+		{tool: "terraform", version: "1.7.3", os: "linux", arch: "amd64", want: "https://releases.hashicorp.com/terraform/1.7.3/terraform_1.7.3_linux_amd64.zip"},
+		{tool: "packer", version: "1.10.1", os: "darwin", arch: "arm64", want: "https://releases.hashicorp.com/packer/1.10.1/packer_1.10.1_darwin_arm64.zip"},
+		{tool: "vault", version: "1.15.5", os: "windows", arch: "amd64", want: "https://releases.hashicorp.com/vault/1.15.5/vault_1.15.5_windows_amd64.zip"},
+		{tool: "consul", version: "1.17.2", os: "linux", arch: "arm64", want: "https://releases.hashicorp.com/consul/1.17.2/consul_1.17.2_linux_arm64.zip"},
+		{tool: "jq", version: "1.7.1", os: "darwin", arch: "arm64", want: "https://github.com/jqlang/jq/releases/download/jq-1.7.1/jq-macos-arm64"},
+		{tool: "jq", version: "1.7.1", os: "windows", arch: "amd64", want: "https://github.com/jqlang/jq/releases/download/jq-1.7.1/jq-windows-amd64.exe"},
+		{tool: "node", version: "20.11.0", os: "linux", arch: "amd64", want: "https://nodejs.org/dist/v20.11.0/node-v20.11.0-linux-x64.tar.gz"},
+		{tool: "node", version: "20.11.0", os: "windows", arch: "amd64", want: "https://nodejs.org/dist/v20.11.0/node-v20.11.0-win-x64.zip"},
+		{tool: "go", version: "1.22.0", os: "linux", arch: "amd64", want: "https://go.dev/dl/go1.22.0.linux-amd64.tar.gz"},
+		{tool: "go", version: "1.22.0", os: "windows", arch: "arm64", want: "https://go.dev/dl/go1.22.0.windows-arm64.zip"},
+		{tool: "awscli", version: "2.15.0", os: "linux", arch: "amd64", want: "https://awscli.amazonaws.com/awscli-exe-linux-x86_64-2.15.0.zip"},
+		{tool: "awscli", version: "2.15.0", os: "linux", arch: "arm64", want: "https://awscli.amazonaws.com/awscli-exe-linux-aarch64-2.15.0.zip"},
+
+		{tool: "k9s", version: "0.31.9", os: "linux", arch: "amd64", want: "k9s_Linux_amd64.tar.gz", reject: []string{"k9s_Linux_amd64.tar.gz.sbom", "k9s_Darwin_amd64.tar.gz", "k9s_linux_amd64.deb"}},
+		{tool: "k9s", version: "0.31.9", os: "windows", arch: "arm64", want: "k9s_Windows_arm64.zip", reject: []string{"k9s_Windows_amd64.zip"}},
+		{tool: "stern", version: "1.28.0", os: "darwin", arch: "arm64", want: "stern_1.28.0_darwin_arm64.tar.gz", reject: []string{"stern_1.28.0_linux_arm64.tar.gz"}},
+		{tool: "kubectx", version: "0.9.5", os: "linux", arch: "amd64", want: "kubectx_v0.9.5_linux_x86_64.tar.gz", reject: []string{"kubens_v0.9.5_linux_x86_64.tar.gz", "kubectx"}},
+		{tool: "kubectx", version: "0.9.5", os: "darwin", arch: "arm64", want: "kubectx_v0.9.5_darwin_arm64.tar.gz"},
+		{tool: "helmfile", version: "0.162.0", os: "linux", arch: "arm64", want: "helmfile_0.162.0_linux_arm64.tar.gz", reject: []string{"helmfile_0.162.0_checksums.txt"}},
+		{tool: "argocd", version: "2.10.1", os: "linux", arch: "amd64", want: "argocd-linux-amd64", reject: []string{"argocd-darwin-amd64", "argocd-linux-amd64.sha256"}},
+		{tool: "argocd", version: "2.10.1", os: "windows", arch: "amd64", want: "argocd-windows-amd64.exe"},
+		{tool: "flux", version: "2.2.3", os: "linux", arch: "amd64", want: "flux_2.2.3_linux_amd64.tar.gz", reject: []string{"flux_2.2.3_checksums.txt"}},
+		{tool: "kubeseal", version: "0.25.0", os: "darwin", arch: "arm64", want: "kubeseal-0.25.0-darwin-arm64.tar.gz", reject: []string{"controller.yaml"}},
+		{tool: "velero", version: "1.13.0", os: "linux", arch: "amd64", want: "velero-v1.13.0-linux-amd64.tar.gz", reject: []string{"velero-v1.13.0-linux-arm.tar.gz"}},
+		{tool: "eksctl", version: "0.171.0", os: "linux", arch: "amd64", want: "eksctl_Linux_amd64.tar.gz", reject: []string{"eksctl_Linux_arm64.tar.gz"}},
+		{tool: "tflint", version: "0.50.3", os: "linux", arch: "amd64", want: "tflint_linux_amd64.zip", reject: []string{"tflint_linux_amd64.zip.keyless.sig"}},
+		{tool: "terragrunt", version: "0.55.1", os: "darwin", arch: "arm64", want: "terragrunt_darwin_arm64", reject: []string{"terragrunt_darwin_amd64", "SHA256SUMS"}},
+		{tool: "terragrunt", version: "0.55.1", os: "windows", arch: "amd64", want: "terragrunt_windows_amd64.exe"},
+		{tool: "gh", version: "2.40.0", os: "darwin", arch: "arm64", want: "gh_2.40.0_macOS_arm64.zip", reject: []string{"gh_2.40.0_macOS_amd64.zip"}},
+		{tool: "gh", version: "2.40.0", os: "linux", arch: "amd64", want: "gh_2.40.0_linux_amd64.tar.gz", reject: []string{"gh_2.40.0_linux_amd64.deb", "gh_2.40.0_linux_amd64.rpm"}},
+		{tool: "yq", version: "4.40.5", os: "linux", arch: "amd64", want: "yq_linux_amd64", reject: []string{"yq_linux_amd64.tar.gz"}},
+		{tool: "yq", version: "4.40.5", os: "windows", arch: "amd64", want: "yq_windows_amd64.exe", reject: []string{"yq_windows_amd64.zip"}},
+		{tool: "golangci-lint", version: "1.56.2", os: "linux", arch: "amd64", want: "golangci-lint-1.56.2-linux-amd64.tar.gz", reject: []string{"golangci-lint-1.56.2-linux-amd64.deb"}},
+		{tool: "buf", version: "1.29.0", os: "linux", arch: "amd64", want: "buf-Linux-x86_64", reject: []string{"buf-Linux-x86_64.tar.gz", "protoc-gen-buf-breaking-Linux-x86_64"}},
+		{tool: "buf", version: "1.29.0", os: "linux", arch: "arm64", want: "buf-Linux-aarch64"},
+		{tool: "buf", version: "1.29.0", os: "darwin", arch: "arm64", want: "buf-Darwin-arm64"},
+		{tool: "protoc", version: "26.1", os: "linux", arch: "amd64", want: "protoc-26.1-linux-x86_64.zip", reject: []string{"protobuf-26.1.zip"}},
+		{tool: "protoc", version: "26.1", os: "darwin", arch: "arm64", want: "protoc-26.1-osx-aarch_64.zip", reject: []string{"protoc-26.1-osx-universal_binary.zip"}},
+		{tool: "protoc", version: "26.1", os: "windows", arch: "amd64", want: "protoc-26.1-win64.zip", reject: []string{"protoc-26.1-win32.zip"}},
+		{tool: "task", version: "3.35.1", os: "linux", arch: "amd64", want: "task_linux_amd64.tar.gz", reject: []string{"task_linux_amd64.deb"}},
+		{tool: "sops", version: "3.8.1", os: "linux", arch: "amd64", want: "sops-v3.8.1.linux.amd64", reject: []string{"sops-v3.8.1.linux.amd64.sig"}},
+		{tool: "shellcheck", version: "0.10.0", os: "linux", arch: "amd64", want: "shellcheck-v0.10.0.linux.x86_64.tar.xz"},
+		{tool: "shellcheck", version: "0.10.0", os: "darwin", arch: "arm64", want: "shellcheck-v0.10.0.darwin.aarch64.tar.xz"},
+	}
+
+	registry := recipe.NewRegistry()
+	for _, tt := range tests {
+		t.Run(tt.tool+"_"+tt.os+"_"+tt.arch, func(t *testing.T) {
+			r, err := registry.Lookup(tt.tool)
+			require.NoError(t, err)
+
+			data := download.TemplateData{Name: tt.tool, Version: tt.version, OS: tt.os, Arch: tt.arch}
+			config := r.Metadata.DownloadConfig
+			if config.Type != "github" {
+				url, err := download.ExpandTemplate(config.URLTemplate, data)
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, url)
+				return
+			}
+
+			pattern, err := download.ExpandTemplate(config.AssetPattern, data)
+			require.NoError(t, err)
+			re, err := regexp.Compile(pattern)
+			require.NoError(t, err)
+			assert.True(t, re.MatchString(tt.want), "%s should match %s", pattern, tt.want)
+			for _, name := range tt.reject {
+				assert.False(t, re.MatchString(name), "%s should not match %s", pattern, name)
+			}
+		})
+	}
+}
+
+func TestRecipe_Save(t *testing.T) {
+	r, err := recipe.NewRegistry().Lookup("kubectl")
+	require.NoError(t, err)
+
+	toolsDir := filepath.Join(t.TempDir(), "tools")
+	target, err := r.Save(toolsDir)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(toolsDir, "kubectl.toml"), target)
+
+	data, err := os.ReadFile(target)
+	require.NoError(t, err)
+	assert.Equal(t, r.Definition, data)
+}
@@ -0,0 +1,181 @@
+package recipe
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/songzhibin97/vman/pkg/types"
+)
+
+// RegistriesDir 配置目录下保存远程recipe注册表的目录名
+const RegistriesDir = "registries"
+
+// maxRegistryFileSize 注册表中单个文件的大小上限
+const maxRegistryFileSize = 4 << 20
+
+// errNotFound 远程文件不存在
+var errNotFound = errors.New("not found")
+
+// UpdateResult 更新注册表的结果
+type UpdateResult struct {
+	Name    string
+	Dir     string
+	Recipes int
+	Files   int
+	Signed  bool
+}
+
+// RegistryDir 注册表在本地的目录
+func RegistryDir(configDir, name string) string {
+	return filepath.Join(configDir, RegistriesDir, name)
+}
+
+// TrustedKeysFor 注册表信任的公钥，只有注册表配置的公钥，一个注册表的发布者不能为其他注册表签名
+func TrustedKeysFor(registry types.RecipeRegistry) []string {
+	return append([]string{}, registry.PublicKeys...)
+}
+
+// UpdateRegistry 下载注册表的索引和其中的文件，验证签名和每个文件的摘要后替换本地副本
+//
+// 索引没有签名时返回 ErrUnsigned，allowUnsigned 为 true 时仍然接受；签名无效或文件内容与索引
+// 不一致时总是拒绝，索引的 updated_at 早于本地副本时返回 ErrRollback。验证全部通过之前不会修改本地副本。
+func UpdateRegistry(ctx context.Context, client *http.Client, registry types.RecipeRegistry, configDir string, allowUnsigned bool) (*UpdateResult, error) {
+	baseURL := strings.TrimSuffix(registry.URL, "/")
+	indexData, err := fetch(ctx, client, baseURL+"/"+IndexFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch index of registry %s: %w", registry.Name, err)
+	}
+	signature, err := fetch(ctx, client, baseURL+"/"+SignatureFile)
+	if err != nil && !errors.Is(err, errNotFound) {
+		return nil, fmt.Errorf("failed to fetch signature of registry %s: %w", registry.Name, err)
+	}
+
+	result := &UpdateResult{Name: registry.Name, Dir: RegistryDir(configDir, registry.Name)}
+	switch err := VerifyIndex(indexData, string(signature), TrustedKeysFor(registry)); {
+	case err == nil:
+		result.Signed = true
+	case errors.Is(err, ErrUnsigned) && allowUnsigned:
+	default:
+		return nil, fmt.Errorf("registry %s: %w", registry.Name, err)
+	}
+
+	var index Index
+	if err := json.Unmarshal(indexData, &index); err != nil {
+		return nil, fmt.Errorf("failed to parse index of registry %s: %w", registry.Name, err)
+	}
+	if local, _, err := LocalIndex(configDir, registry.Name); err == nil && index.UpdatedAt.Before(local.UpdatedAt) {
+		return nil, fmt.Errorf("registry %s: %w (%s < %s)", registry.Name, ErrRollback,
+			index.UpdatedAt.Format(time.RFC3339Nano), local.UpdatedAt.Format(time.RFC3339Nano))
+	}
+
+	// 先写入临时目录，全部验证通过后再替换
+	tmpDir := result.Dir + ".tmp"
+	os.RemoveAll(tmpDir)
+	defer os.RemoveAll(tmpDir)
+
+	for name, sum := range index.Files {
+		if !fs.ValidPath(name) || name == IndexFile || name == SignatureFile {
+			return nil, fmt.Errorf("registry %s: invalid file path %q in index", registry.Name, name)
+		}
+		data, err := fetch(ctx, client, baseURL+"/"+name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch %s from registry %s: %w", name, registry.Name, err)
+		}
+		if digest(data) != sum {
+			return nil, fmt.Errorf("registry %s: %s does not match the registry index (tampered or outdated mirror)", registry.Name, name)
+		}
+
+		target := filepath.Join(tmpDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return nil, fmt.Errorf("failed to create registry directory: %w", err)
+		}
+		mode := os.FileMode(0644)
+		if strings.Contains(name, "/"+hooksDir+"/") {
+			mode = 0755
+		}
+		if err := os.WriteFile(target, data, mode); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", target, err)
+		}
+	}
+
+	// 本地副本保留索引和签名，便于 vman registry list 显示
+	if err := os.MkdirAll(tmpDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create registry directory: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, IndexFile), indexData, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write index: %w", err)
+	}
+	if result.Signed {
+		if err := os.WriteFile(filepath.Join(tmpDir, SignatureFile), signature, 0644); err != nil {
+			return nil, fmt.Errorf("failed to write signature: %w", err)
+		}
+	}
+
+	// 替换前确认所有recipe都能正确解析
+	if _, err := NewRegistry(tmpDir).List(); err != nil {
+		return nil, fmt.Errorf("registry %s: %w", registry.Name, err)
+	}
+
+	if err := os.RemoveAll(result.Dir); err != nil {
+		return nil, fmt.Errorf("failed to remove old copy of registry %s: %w", registry.Name, err)
+	}
+	if err := os.Rename(tmpDir, result.Dir); err != nil {
+		return nil, fmt.Errorf("failed to install registry %s: %w", registry.Name, err)
+	}
+
+	result.Recipes = len(index.Recipes())
+	result.Files = len(index.Files)
+	return result, nil
+}
+
+// LocalIndex 读取本地注册表副本的索引，signed 表示更新时验证过签名
+func LocalIndex(configDir, name string) (index *Index, signed bool, err error) {
+	dir := RegistryDir(configDir, name)
+	data, err := os.ReadFile(filepath.Join(dir, IndexFile))
+	if err != nil {
+		return nil, false, err
+	}
+	index = &Index{}
+	if err := json.Unmarshal(data, index); err != nil {
+		return nil, false, fmt.Errorf("failed to parse index of registry %s: %w", name, err)
+	}
+	_, err = os.Stat(filepath.Join(dir, SignatureFile))
+	return index, err == nil, nil
+}
+
+// fetch 下载文件内容，404 时返回 errNotFound
+func fetch(ctx context.Context, client *http.Client, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, errNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxRegistryFileSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxRegistryFileSize {
+		return nil, fmt.Errorf("%s is larger than %d bytes", url, maxRegistryFileSize)
+	}
+	return data, nil
+}
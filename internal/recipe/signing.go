@@ -0,0 +1,144 @@
+package recipe
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// IndexFile 注册表的索引文件，列出所有文件及其 SHA-256
+	IndexFile = "index.json"
+	// SignatureFile 索引文件的签名（base64），签名覆盖索引的原始内容
+	SignatureFile = "index.json.sig"
+)
+
+// ErrUnsigned 注册表的索引没有签名
+var ErrUnsigned = errors.New("registry index is not signed")
+
+// ErrBadSignature 注册表索引的签名无效，或者不是由受信任的发布者签名
+var ErrBadSignature = errors.New("registry index signature is invalid or not from a trusted publisher")
+
+// ErrRollback 注册表索引比本地副本旧，可能是重放的旧索引
+var ErrRollback = errors.New("registry index is older than the local copy")
+
+// Index 注册表的索引
+type Index struct {
+	UpdatedAt time.Time         `json:"updated_at"`
+	Files     map[string]string `json:"files"` // 相对路径（如 kubectl/recipe.toml）-> SHA-256
+}
+
+// Recipes 索引中包含的 recipe 名称
+func (i *Index) Recipes() []string {
+	var names []string
+	for name := range i.Files {
+		if path.Base(name) == recipeFile && path.Dir(path.Dir(name)) == "." {
+			names = append(names, path.Dir(name))
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ParsePublicKey 解析 base64 编码的 Ed25519 公钥
+func ParsePublicKey(text string) (ed25519.PublicKey, error) {
+	data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(text))
+	if err != nil || len(data) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid Ed25519 public key: %q", text)
+	}
+	return ed25519.PublicKey(data), nil
+}
+
+// GenerateKey 生成发布者密钥对，返回 base64 编码的公钥和私钥
+func GenerateKey() (string, string, error) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate key: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(public), base64.StdEncoding.EncodeToString(private), nil
+}
+
+// SignIndex 用 base64 编码的私钥对索引签名，返回 base64 编码的签名
+func SignIndex(privateKey string, index []byte) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(privateKey))
+	if err != nil || len(data) != ed25519.PrivateKeySize {
+		return "", errors.New("invalid Ed25519 private key")
+	}
+	return base64.StdEncoding.EncodeToString(ed25519.Sign(ed25519.PrivateKey(data), index)), nil
+}
+
+// VerifyIndex 验证索引的签名，keys 中任一公钥验证通过即可
+func VerifyIndex(index []byte, signature string, keys []string) error {
+	if strings.TrimSpace(signature) == "" {
+		return ErrUnsigned
+	}
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(signature))
+	if err != nil {
+		return ErrBadSignature
+	}
+	for _, key := range keys {
+		public, err := ParsePublicKey(key)
+		if err != nil {
+			continue
+		}
+		if ed25519.Verify(public, index, sig) {
+			return nil
+		}
+	}
+	return ErrBadSignature
+}
+
+// BuildIndex 为注册表目录生成索引，忽略索引、签名文件和隐藏文件
+func BuildIndex(dir string) ([]byte, error) {
+	index := Index{UpdatedAt: time.Now().UTC(), Files: make(map[string]string)}
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if strings.HasPrefix(entry.Name(), ".") && path != dir {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if rel == IndexFile || rel == SignatureFile {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		index.Files[rel] = digest(data)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to build index for %s: %w", dir, err)
+	}
+	return json.MarshalIndent(index, "", "  ")
+}
+
+// digest 计算内容的 SHA-256
+func digest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
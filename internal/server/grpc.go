@@ -0,0 +1,194 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// GRPCServiceName gRPC 服务名，定义见 docs/vman.proto
+// 请求和响应都是 google.protobuf.Struct，字段与 REST 接口的 JSON 相同，不需要生成代码即可调用
+const GRPCServiceName = "vman.v1.Vman"
+
+// grpcHealthPrefix 标准健康检查服务的方法前缀，不需要认证
+const grpcHealthPrefix = "/grpc.health.v1.Health/"
+
+// grpcServiceDesc vman.v1.Vman 服务的方法
+var grpcServiceDesc = grpc.ServiceDesc{
+	ServiceName: GRPCServiceName,
+	HandlerType: (*interface{})(nil),
+	Methods: []grpc.MethodDesc{
+		grpcMethod("GetConfig", func(s *Server, ctx context.Context, req *structpb.Struct) (interface{}, error) {
+			return s.config()
+		}),
+		grpcMethod("ListTools", func(s *Server, ctx context.Context, req *structpb.Struct) (interface{}, error) {
+			return s.tools()
+		}),
+		grpcMethod("ListVersions", func(s *Server, ctx context.Context, req *structpb.Struct) (interface{}, error) {
+			tool, err := requiredField(req, "tool")
+			if err != nil {
+				return nil, err
+			}
+			return s.versions(tool)
+		}),
+		grpcMethod("Resolve", func(s *Server, ctx context.Context, req *structpb.Struct) (interface{}, error) {
+			tool, err := requiredField(req, "tool")
+			if err != nil {
+				return nil, err
+			}
+			return s.resolve(ctx, tool, stringField(req, "dir"))
+		}),
+		grpcMethod("Install", func(s *Server, ctx context.Context, req *structpb.Struct) (interface{}, error) {
+			tool, err := requiredField(req, "tool")
+			if err != nil {
+				return nil, err
+			}
+			return s.install(ctx, tool, stringField(req, "version"))
+		}),
+		grpcMethod("SetGlobalVersion", func(s *Server, ctx context.Context, req *structpb.Struct) (interface{}, error) {
+			tool, err := requiredField(req, "tool")
+			if err != nil {
+				return nil, err
+			}
+			return s.setGlobal(tool, stringField(req, "version"))
+		}),
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "vman.proto",
+}
+
+// GRPCServer 创建提供 vman.v1.Vman 服务和标准健康检查服务的 gRPC 服务
+// 除健康检查外，所有调用都需要在 authorization 元数据中携带 "Bearer <token>"
+func (s *Server) GRPCServer() *grpc.Server {
+	grpcServer := grpc.NewServer(
+		grpc.UnaryInterceptor(s.grpcAuth),
+		grpc.ConnectionTimeout(readHeaderTimeout),
+		grpc.MaxRecvMsgSize(maxBodySize),
+	)
+	grpcServer.RegisterService(&grpcServiceDesc, s)
+	healthpb.RegisterHealthServer(grpcServer, health.NewServer())
+	return grpcServer
+}
+
+// ServeGRPC 在 addr 上提供 gRPC 服务，直到 ctx 被取消
+func (s *Server) ServeGRPC(ctx context.Context, addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	grpcServer := s.GRPCServer()
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- grpcServer.Serve(listener)
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		grpcServer.GracefulStop()
+		return nil
+	}
+}
+
+// grpcAuth 检查 authorization 元数据中的令牌
+func (s *Server) grpcAuth(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if strings.HasPrefix(info.FullMethod, grpcHealthPrefix) {
+		return handler(ctx, req)
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) == 0 || !s.validAuthorization(values[0]) {
+		return nil, status.Error(codes.Unauthenticated, "missing or invalid token")
+	}
+	s.logger.Debugf("gRPC %s", info.FullMethod)
+	return handler(ctx, req)
+}
+
+// grpcMethod 创建一元方法，请求和响应都转换为 google.protobuf.Struct
+func grpcMethod(name string, call func(s *Server, ctx context.Context, req *structpb.Struct) (interface{}, error)) grpc.MethodDesc {
+	return grpc.MethodDesc{
+		MethodName: name,
+		Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+			req := new(structpb.Struct)
+			if err := dec(req); err != nil {
+				return nil, err
+			}
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				result, err := call(srv.(*Server), ctx, req.(*structpb.Struct))
+				if err != nil {
+					return nil, grpcError(err)
+				}
+				return toStruct(result)
+			}
+			if interceptor == nil {
+				return handler(ctx, req)
+			}
+			info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/" + GRPCServiceName + "/" + name}
+			return interceptor(ctx, req, info, handler)
+		},
+	}
+}
+
+// grpcError 将操作的错误按附加的 HTTP 状态码转换为 gRPC 错误码
+func grpcError(err error) error {
+	code := codes.Internal
+	var statusErr *statusError
+	if errors.As(err, &statusErr) {
+		switch statusErr.status {
+		case http.StatusBadRequest:
+			code = codes.InvalidArgument
+		case http.StatusForbidden:
+			code = codes.PermissionDenied
+		case http.StatusUnprocessableEntity:
+			code = codes.FailedPrecondition
+		case http.StatusBadGateway:
+			code = codes.Unavailable
+		}
+	}
+	return status.Error(code, err.Error())
+}
+
+// toStruct 将操作的结果按 REST 接口的 JSON 格式转换为 google.protobuf.Struct
+func toStruct(v interface{}) (*structpb.Struct, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, status.Error(codes.Internal, fmt.Sprintf("failed to encode response: %v", err))
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, status.Error(codes.Internal, fmt.Sprintf("failed to encode response: %v", err))
+	}
+	result, err := structpb.NewStruct(fields)
+	if err != nil {
+		return nil, status.Error(codes.Internal, fmt.Sprintf("failed to encode response: %v", err))
+	}
+	return result, nil
+}
+
+// stringField 读取请求中的字符串字段
+func stringField(req *structpb.Struct, name string) string {
+	return req.GetFields()[name].GetStringValue()
+}
+
+// requiredField 读取请求中必需的字符串字段
+func requiredField(req *structpb.Struct, name string) (string, error) {
+	value := stringField(req, name)
+	if value == "" {
+		return "", withStatus(http.StatusBadRequest, fmt.Errorf("%s is required", name))
+	}
+	return value, nil
+}
@@ -0,0 +1,105 @@
+package server
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/songzhibin97/vman/pkg/types"
+)
+
+// dialTestGRPC 在内存连接上启动 gRPC 服务并返回客户端连接
+func dialTestGRPC(t *testing.T, s *Server) *grpc.ClientConn {
+	t.Helper()
+	listener := bufconn.Listen(1 << 20)
+	grpcServer := s.GRPCServer()
+	go grpcServer.Serve(listener)
+	t.Cleanup(grpcServer.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// invoke 调用 vman.v1.Vman 的方法
+func invoke(conn *grpc.ClientConn, token, method string, fields map[string]interface{}) (*structpb.Struct, error) {
+	ctx := context.Background()
+	if token != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+	}
+	req, err := structpb.NewStruct(fields)
+	if err != nil {
+		return nil, err
+	}
+	resp := new(structpb.Struct)
+	err = conn.Invoke(ctx, "/"+GRPCServiceName+"/"+method, req, resp)
+	return resp, err
+}
+
+func TestGRPC_Auth(t *testing.T) {
+	conn := dialTestGRPC(t, newTestServer(t))
+
+	// 健康检查不需要认证
+	health, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, health.Status)
+
+	_, err = invoke(conn, "", "ListTools", nil)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	_, err = invoke(conn, "wrong", "ListTools", nil)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	resp, err := invoke(conn, testToken, "ListTools", nil)
+	require.NoError(t, err)
+	assert.Empty(t, resp.Fields["tools"].GetListValue().GetValues())
+}
+
+func TestGRPC_Methods(t *testing.T) {
+	conn := dialTestGRPC(t, newTestServer(t))
+
+	resp, err := invoke(conn, testToken, "ListVersions", map[string]interface{}{"tool": "terraform"})
+	require.NoError(t, err)
+	assert.Equal(t, "terraform", resp.Fields["tool"].GetStringValue())
+
+	resp, err = invoke(conn, testToken, "GetConfig", nil)
+	require.NoError(t, err)
+	assert.Contains(t, resp.Fields, "global_versions")
+
+	_, err = invoke(conn, testToken, "SetGlobalVersion", map[string]interface{}{"tool": "terraform", "version": "1.6.0"})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	assert.Contains(t, status.Convert(err).Message(), "not installed")
+
+	_, err = invoke(conn, testToken, "SetGlobalVersion", map[string]interface{}{"tool": "terraform"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = invoke(conn, testToken, "Resolve", nil)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = invoke(conn, testToken, "Unknown", nil)
+	assert.Equal(t, codes.Unimplemented, status.Code(err))
+}
+
+func TestGRPC_ReadOnly(t *testing.T) {
+	t.Setenv(types.EnvVmanReadOnly, "1")
+	conn := dialTestGRPC(t, newTestServer(t))
+
+	_, err := invoke(conn, testToken, "Install", map[string]interface{}{"tool": "terraform", "version": "1.6.0"})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
@@ -0,0 +1,394 @@
+// Package server 实现 vman serve 的本地 REST API，供编辑器扩展和内部面板查询、安装工具版本
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/songzhibin97/vman/pkg/types"
+	"github.com/songzhibin97/vman/pkg/vman"
+)
+
+// APIPrefix REST API 的路径前缀
+const APIPrefix = "/api/v1"
+
+// TokenFileName 自动生成的访问令牌保存的文件名，位于配置目录下
+const TokenFileName = "serve.token"
+
+const (
+	// readHeaderTimeout 读取请求头的超时时间，避免慢速客户端一直占用连接
+	readHeaderTimeout = 10 * time.Second
+
+	// readTimeout 读取整个请求（包括请求体）的超时时间
+	readTimeout = 30 * time.Second
+
+	// maxBodySize 请求体的最大字节数
+	maxBodySize = 1 << 20
+)
+
+// Server 本地 API 服务
+type Server struct {
+	client *vman.Client
+	token  string
+	logger *logrus.Logger
+
+	// installMu 串行化安装请求，避免同一工具并发安装
+	installMu sync.Mutex
+}
+
+// versionRequest 安装和设置版本的请求体
+type versionRequest struct {
+	Version string `json:"version"`
+}
+
+// errorResponse 错误响应
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// New 创建 API 服务，token 为空时拒绝所有需要认证的请求
+func New(client *vman.Client, token string, logger *logrus.Logger) *Server {
+	if logger == nil {
+		logger = logrus.New()
+	}
+	return &Server{
+		client: client,
+		token:  token,
+		logger: logger,
+	}
+}
+
+// ServeHTTP 处理 API 请求
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimSuffix(r.URL.Path, "/")
+	if !strings.HasPrefix(path, APIPrefix+"/") {
+		writeError(w, http.StatusNotFound, fmt.Errorf("unknown endpoint %s", r.URL.Path))
+		return
+	}
+	path = strings.TrimPrefix(path, APIPrefix+"/")
+
+	// 健康检查不需要认证，便于探测服务是否已启动
+	if path == "health" {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+		return
+	}
+
+	if !s.authorized(r) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="vman"`)
+		writeError(w, http.StatusUnauthorized, errors.New("missing or invalid token"))
+		return
+	}
+
+	s.logger.Debugf("%s %s", r.Method, r.URL.Path)
+
+	parts := strings.Split(path, "/")
+	switch {
+	case len(parts) == 1 && parts[0] == "config":
+		s.handleConfig(w, r)
+	case len(parts) == 1 && parts[0] == "tools":
+		s.handleTools(w, r)
+	case len(parts) == 3 && parts[0] == "tools" && parts[1] != "":
+		s.handleTool(w, r, parts[1], parts[2])
+	default:
+		writeError(w, http.StatusNotFound, fmt.Errorf("unknown endpoint %s", r.URL.Path))
+	}
+}
+
+// authorized 检查请求中的 Bearer 令牌
+func (s *Server) authorized(r *http.Request) bool {
+	return s.validAuthorization(r.Header.Get("Authorization"))
+}
+
+// validAuthorization 检查 "Bearer <token>" 形式的认证信息，REST 和 gRPC 共用
+func (s *Server) validAuthorization(authorization string) bool {
+	if s.token == "" {
+		return false
+	}
+	token, ok := strings.CutPrefix(authorization, "Bearer ")
+	if !ok {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(strings.TrimSpace(token)), []byte(s.token)) == 1
+}
+
+// handleConfig GET /config 返回全局配置中的版本设置
+func (s *Server) handleConfig(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	result, err := s.config()
+	respond(w, result, err)
+}
+
+// handleTools GET /tools 列出已安装的工具
+func (s *Server) handleTools(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	result, err := s.tools()
+	respond(w, result, err)
+}
+
+// handleTool 处理 /tools/{tool}/{action}
+func (s *Server) handleTool(w http.ResponseWriter, r *http.Request, tool, action string) {
+	switch action {
+	case "versions":
+		if !allowMethod(w, r, http.MethodGet) {
+			return
+		}
+		result, err := s.versions(tool)
+		respond(w, result, err)
+
+	case "resolve":
+		if !allowMethod(w, r, http.MethodGet) {
+			return
+		}
+		result, err := s.resolve(r.Context(), tool, r.URL.Query().Get("dir"))
+		respond(w, result, err)
+
+	case "install":
+		if !allowMethod(w, r, http.MethodPost) {
+			return
+		}
+		var req versionRequest
+		if !decodeBody(w, r, &req) {
+			return
+		}
+		result, err := s.install(r.Context(), tool, req.Version)
+		respond(w, result, err)
+
+	case "global":
+		if !allowMethod(w, r, http.MethodPut) {
+			return
+		}
+		var req versionRequest
+		if !decodeBody(w, r, &req) {
+			return
+		}
+		result, err := s.setGlobal(tool, req.Version)
+		respond(w, result, err)
+
+	default:
+		writeError(w, http.StatusNotFound, fmt.Errorf("unknown endpoint %s", r.URL.Path))
+	}
+}
+
+// statusError 带 HTTP 状态码的错误，gRPC 接口按状态码转换为对应的错误码
+type statusError struct {
+	status int
+	err    error
+}
+
+func (e *statusError) Error() string {
+	return e.err.Error()
+}
+
+func (e *statusError) Unwrap() error {
+	return e.err
+}
+
+// withStatus 为错误附加 HTTP 状态码，只读模式下的写操作返回 403
+func withStatus(status int, err error) error {
+	if errors.Is(err, types.ErrReadOnly) {
+		status = http.StatusForbidden
+	}
+	return &statusError{status: status, err: err}
+}
+
+// config 全局配置中的版本设置
+func (s *Server) config() (interface{}, error) {
+	globalConfig, err := s.client.GlobalConfig()
+	if err != nil {
+		return nil, withStatus(http.StatusInternalServerError, err)
+	}
+	globalVersions := globalConfig.GlobalVersions
+	if globalVersions == nil {
+		globalVersions = map[string]string{}
+	}
+	return map[string]interface{}{
+		"version":         globalConfig.Version,
+		"global_versions": globalVersions,
+	}, nil
+}
+
+// tools 已安装的工具
+func (s *Server) tools() (interface{}, error) {
+	tools, err := s.client.ListTools()
+	if err != nil {
+		return nil, withStatus(http.StatusInternalServerError, err)
+	}
+	if tools == nil {
+		tools = []string{}
+	}
+	return map[string][]string{"tools": tools}, nil
+}
+
+// versions 工具已安装的版本
+func (s *Server) versions(tool string) (interface{}, error) {
+	versions, err := s.client.ListVersions(tool)
+	if err != nil {
+		return nil, withStatus(http.StatusInternalServerError, err)
+	}
+	return map[string]interface{}{"tool": tool, "versions": versions}, nil
+}
+
+// resolve 目录下生效的版本
+func (s *Server) resolve(ctx context.Context, tool, dir string) (interface{}, error) {
+	resolution, err := s.client.Resolve(ctx, tool, dir)
+	if err != nil {
+		return nil, withStatus(http.StatusUnprocessableEntity, err)
+	}
+	return resolution, nil
+}
+
+// install 安装版本，安装请求串行执行
+func (s *Server) install(ctx context.Context, tool, version string) (interface{}, error) {
+	s.installMu.Lock()
+	installed, err := s.client.Install(ctx, tool, version)
+	s.installMu.Unlock()
+	if err != nil {
+		return nil, withStatus(http.StatusBadGateway, err)
+	}
+	return map[string]string{"tool": tool, "version": installed}, nil
+}
+
+// setGlobal 设置全局版本
+func (s *Server) setGlobal(tool, version string) (interface{}, error) {
+	if version == "" {
+		return nil, withStatus(http.StatusBadRequest, errors.New("version is required"))
+	}
+	if err := s.client.SetGlobalVersion(tool, version); err != nil {
+		return nil, withStatus(http.StatusUnprocessableEntity, err)
+	}
+	return map[string]string{"tool": tool, "version": version}, nil
+}
+
+// ListenAndServe 在 addr 上提供服务，直到 ctx 被取消
+func (s *Server) ListenAndServe(ctx context.Context, addr string) error {
+	httpServer := s.httpServer(addr)
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- httpServer.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		if err := httpServer.Shutdown(context.Background()); err != nil {
+			return err
+		}
+		return nil
+	}
+}
+
+// httpServer 创建监听 addr 的 HTTP 服务，限制读取请求的时间
+// 不设置写超时，安装请求可能需要较长时间下载
+func (s *Server) httpServer(addr string) *http.Server {
+	return &http.Server{
+		Addr:              addr,
+		Handler:           s,
+		ReadHeaderTimeout: readHeaderTimeout,
+		ReadTimeout:       readTimeout,
+	}
+}
+
+// LoadOrCreateToken 读取配置目录下保存的访问令牌，不存在时生成一个新令牌并以 0600 权限保存
+func LoadOrCreateToken(configDir string) (string, error) {
+	tokenPath := filepath.Join(configDir, TokenFileName)
+	if token, err := ReadTokenFile(tokenPath); err == nil && token != "" {
+		return token, nil
+	} else if err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	token := hex.EncodeToString(buf)
+	if err := os.WriteFile(tokenPath, []byte(token+"\n"), 0600); err != nil {
+		return "", fmt.Errorf("failed to save token file: %w", err)
+	}
+	return token, nil
+}
+
+// ReadTokenFile 读取令牌文件，去掉首尾空白
+func ReadTokenFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", err
+		}
+		return "", fmt.Errorf("failed to read token file: %w", err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// allowMethod 检查请求方法，不匹配时返回 405
+func allowMethod(w http.ResponseWriter, r *http.Request, method string) bool {
+	if r.Method == method {
+		return true
+	}
+	w.Header().Set("Allow", method)
+	writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+	return false
+}
+
+// decodeBody 解析 JSON 请求体，空请求体视为空对象，超过 maxBodySize 时返回 413
+func decodeBody(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if r.Body == nil || r.ContentLength == 0 {
+		return true
+	}
+	body := http.MaxBytesReader(w, r.Body, maxBodySize)
+	if err := json.NewDecoder(body).Decode(v); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeError(w, http.StatusRequestEntityTooLarge, fmt.Errorf("request body exceeds %d bytes", tooLarge.Limit))
+			return false
+		}
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return false
+	}
+	return true
+}
+
+// respond 写入操作的结果，错误按附加的状态码返回
+func respond(w http.ResponseWriter, v interface{}, err error) {
+	if err != nil {
+		status := http.StatusInternalServerError
+		var statusErr *statusError
+		if errors.As(err, &statusErr) {
+			status = statusErr.status
+		}
+		writeError(w, status, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, v)
+}
+
+// writeJSON 写入 JSON 响应
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// writeError 写入错误响应
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, errorResponse{Error: err.Error()})
+}
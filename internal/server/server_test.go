@@ -0,0 +1,131 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/songzhibin97/vman/pkg/types"
+	"github.com/songzhibin97/vman/pkg/vman"
+)
+
+const testToken = "secret"
+
+func newTestServer(t *testing.T) *Server {
+	t.Helper()
+	client, err := vman.New(&vman.Options{HomeDir: t.TempDir()})
+	require.NoError(t, err)
+	return New(client, testToken, nil)
+}
+
+func doRequest(s *Server, method, path, token, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestServer_Auth(t *testing.T) {
+	s := newTestServer(t)
+
+	assert.Equal(t, http.StatusOK, doRequest(s, http.MethodGet, "/api/v1/health", "", "").Code)
+	assert.Equal(t, http.StatusUnauthorized, doRequest(s, http.MethodGet, "/api/v1/tools", "", "").Code)
+	assert.Equal(t, http.StatusUnauthorized, doRequest(s, http.MethodGet, "/api/v1/tools", "wrong", "").Code)
+	assert.Equal(t, http.StatusOK, doRequest(s, http.MethodGet, "/api/v1/tools", testToken, "").Code)
+
+	// 未设置令牌时拒绝所有需要认证的请求
+	s.token = ""
+	assert.Equal(t, http.StatusUnauthorized, doRequest(s, http.MethodGet, "/api/v1/tools", "", "").Code)
+}
+
+func TestServer_Endpoints(t *testing.T) {
+	s := newTestServer(t)
+
+	rec := doRequest(s, http.MethodGet, "/api/v1/tools", testToken, "")
+	assert.JSONEq(t, `{"tools":[]}`, rec.Body.String())
+
+	rec = doRequest(s, http.MethodGet, "/api/v1/tools/terraform/versions", testToken, "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	var versions struct {
+		Tool     string                  `json:"tool"`
+		Versions []vman.InstalledVersion `json:"versions"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &versions))
+	assert.Equal(t, "terraform", versions.Tool)
+	assert.Empty(t, versions.Versions)
+
+	rec = doRequest(s, http.MethodGet, "/api/v1/config", testToken, "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"global_versions"`)
+
+	rec = doRequest(s, http.MethodPut, "/api/v1/tools/terraform/global", testToken, `{"version":"1.6.0"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Contains(t, rec.Body.String(), "not installed")
+
+	rec = doRequest(s, http.MethodPut, "/api/v1/tools/terraform/global", testToken, `{}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = doRequest(s, http.MethodGet, "/api/v1/tools/terraform/install", testToken, "")
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+	assert.Equal(t, http.MethodPost, rec.Header().Get("Allow"))
+
+	assert.Equal(t, http.StatusNotFound, doRequest(s, http.MethodGet, "/api/v1/unknown", testToken, "").Code)
+}
+
+func TestServer_ReadOnly(t *testing.T) {
+	t.Setenv(types.EnvVmanReadOnly, "1")
+	s := newTestServer(t)
+
+	assert.Equal(t, http.StatusOK, doRequest(s, http.MethodGet, "/api/v1/tools", testToken, "").Code)
+
+	rec := doRequest(s, http.MethodPut, "/api/v1/tools/terraform/global", testToken, `{"version":"1.6.0"}`)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Contains(t, rec.Body.String(), "read-only")
+
+	rec = doRequest(s, http.MethodPost, "/api/v1/tools/terraform/install", testToken, `{"version":"1.6.0"}`)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
+
+func TestServer_Limits(t *testing.T) {
+	s := newTestServer(t)
+
+	body := `{"version":"` + strings.Repeat("1", maxBodySize) + `"}`
+	rec := doRequest(s, http.MethodPut, "/api/v1/tools/terraform/global", testToken, body)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+
+	httpServer := s.httpServer("localhost:0")
+	assert.Equal(t, readHeaderTimeout, httpServer.ReadHeaderTimeout)
+	assert.Equal(t, readTimeout, httpServer.ReadTimeout)
+}
+
+func TestLoadOrCreateToken(t *testing.T) {
+	dir := t.TempDir()
+
+	token, err := LoadOrCreateToken(dir)
+	require.NoError(t, err)
+	assert.Len(t, token, 64)
+
+	info, err := os.Stat(filepath.Join(dir, TokenFileName))
+	require.NoError(t, err)
+	if os.PathSeparator == '/' {
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	}
+
+	again, err := LoadOrCreateToken(dir)
+	require.NoError(t, err)
+	assert.Equal(t, token, again)
+
+	fromFile, err := ReadTokenFile(filepath.Join(dir, TokenFileName))
+	require.NoError(t, err)
+	assert.Equal(t, token, fromFile)
+}
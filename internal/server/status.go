@@ -0,0 +1,381 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"net/url"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/songzhibin97/vman/internal/version"
+	"github.com/songzhibin97/vman/pkg/vman"
+)
+
+// JSON-RPC 2.0 错误码
+const (
+	rpcParseError     = -32700
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+	rpcInternalError  = -32603
+)
+
+// StatusChangedMethod 工作区文件夹的工具版本变化时发送的通知
+const StatusChangedMethod = "vman/statusChanged"
+
+// rpcMessage JSON-RPC 请求、响应或通知
+type rpcMessage struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id,omitempty"`
+	Method  string           `json:"method,omitempty"`
+	Params  json.RawMessage  `json:"params,omitempty"`
+	Result  interface{}      `json:"result,omitempty"`
+	Error   *rpcError        `json:"error,omitempty"`
+}
+
+// rpcError JSON-RPC 错误
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// workspaceFolder LSP 中的工作区文件夹
+type workspaceFolder struct {
+	URI  string `json:"uri"`
+	Name string `json:"name,omitempty"`
+}
+
+// initializeParams initialize 请求的参数，兼容 LSP 的 rootUri 和 workspaceFolders
+type initializeParams struct {
+	RootURI          string            `json:"rootUri"`
+	RootPath         string            `json:"rootPath"`
+	WorkspaceFolders []workspaceFolder `json:"workspaceFolders"`
+}
+
+// statusParams vman/status 请求的参数，folder 可以是路径或 file:// URI
+type statusParams struct {
+	Folder string `json:"folder"`
+}
+
+// didChangeWorkspaceFoldersParams workspace/didChangeWorkspaceFolders 通知的参数
+type didChangeWorkspaceFoldersParams struct {
+	Event struct {
+		Added   []workspaceFolder `json:"added"`
+		Removed []workspaceFolder `json:"removed"`
+	} `json:"event"`
+}
+
+// ToolStatus 文件夹中一个工具生效的版本
+type ToolStatus struct {
+	*vman.Resolution
+
+	// Label 适合显示在状态栏的文本，如 terraform 1.6.0 (project)
+	Label string `json:"label"`
+}
+
+// FolderStatus 文件夹中所有工具生效的版本
+type FolderStatus struct {
+	Folder string       `json:"folder"`
+	Tools  []ToolStatus `json:"tools"`
+}
+
+// StatusServer 通过标准输入输出上的 JSON-RPC 回答"哪些版本作用于该文件夹"的查询，
+// 并监视已查询过的文件夹，版本变化时发送 vman/statusChanged 通知。
+// 消息使用与 LSP 相同的 Content-Length 头分帧，编辑器扩展可以直接使用 LSP 客户端库。
+type StatusServer struct {
+	client   *vman.Client
+	interval time.Duration
+
+	writeMu sync.Mutex
+	out     *bufio.Writer
+
+	mu      sync.Mutex
+	folders map[string][]ToolStatus
+
+	// resolveMu 串行化版本解析，解析器的缓存不能并发访问
+	resolveMu sync.Mutex
+}
+
+// NewStatusServer 创建状态服务，interval 为监视文件夹的轮询间隔，为 0 时不监视
+func NewStatusServer(client *vman.Client, interval time.Duration) *StatusServer {
+	return &StatusServer{
+		client:   client,
+		interval: interval,
+		folders:  make(map[string][]ToolStatus),
+	}
+}
+
+// Serve 从 in 读取请求并将响应写入 out，直到收到 exit 通知、in 结束或 ctx 被取消
+func (s *StatusServer) Serve(ctx context.Context, in io.Reader, out io.Writer) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	s.out = bufio.NewWriter(out)
+	if s.interval > 0 {
+		go s.watch(ctx)
+	}
+
+	reader := bufio.NewReader(in)
+	for {
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		body, err := readMessage(reader)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+
+		var msg rpcMessage
+		if err := json.Unmarshal(body, &msg); err != nil {
+			s.reply(nil, nil, &rpcError{Code: rpcParseError, Message: err.Error()})
+			continue
+		}
+		if msg.Method == "exit" {
+			return nil
+		}
+
+		result, rpcErr := s.handle(ctx, &msg)
+		// 没有 id 的是通知，不需要响应
+		if msg.ID != nil {
+			s.reply(msg.ID, result, rpcErr)
+		}
+	}
+}
+
+// handle 处理单个请求
+func (s *StatusServer) handle(ctx context.Context, msg *rpcMessage) (interface{}, *rpcError) {
+	switch msg.Method {
+	case "initialize":
+		var params initializeParams
+		if err := unmarshalParams(msg.Params, &params); err != nil {
+			return nil, err
+		}
+		var folders []string
+		for _, folder := range params.WorkspaceFolders {
+			folders = append(folders, folder.URI)
+		}
+		if len(folders) == 0 && params.RootURI != "" {
+			folders = append(folders, params.RootURI)
+		}
+		if len(folders) == 0 && params.RootPath != "" {
+			folders = append(folders, params.RootPath)
+		}
+		for _, folder := range folders {
+			if path, err := folderPath(folder); err == nil {
+				s.refresh(ctx, path)
+			}
+		}
+		return map[string]interface{}{
+			"serverInfo": map[string]string{
+				"name":    "vman",
+				"version": version.GetVersionString(),
+			},
+			"capabilities": map[string]interface{}{
+				"statusProvider":      true,
+				"statusNotifications": s.interval > 0,
+			},
+		}, nil
+
+	case "initialized", "$/cancelRequest", "$/setTrace":
+		return nil, nil
+
+	case "shutdown":
+		return nil, nil
+
+	case "vman/status":
+		var params statusParams
+		if err := unmarshalParams(msg.Params, &params); err != nil {
+			return nil, err
+		}
+		path, err := folderPath(params.Folder)
+		if err != nil {
+			return nil, &rpcError{Code: rpcInvalidParams, Message: err.Error()}
+		}
+		status, err := s.refresh(ctx, path)
+		if err != nil {
+			return nil, &rpcError{Code: rpcInternalError, Message: err.Error()}
+		}
+		return status, nil
+
+	case "workspace/didChangeWorkspaceFolders":
+		var params didChangeWorkspaceFoldersParams
+		if err := unmarshalParams(msg.Params, &params); err != nil {
+			return nil, err
+		}
+		s.mu.Lock()
+		for _, folder := range params.Event.Removed {
+			if path, err := folderPath(folder.URI); err == nil {
+				delete(s.folders, path)
+			}
+		}
+		s.mu.Unlock()
+		for _, folder := range params.Event.Added {
+			if path, err := folderPath(folder.URI); err == nil {
+				s.refresh(ctx, path)
+			}
+		}
+		return nil, nil
+
+	default:
+		return nil, &rpcError{Code: rpcMethodNotFound, Message: fmt.Sprintf("method %s not found", msg.Method)}
+	}
+}
+
+// refresh 重新解析文件夹的状态并开始监视该文件夹
+func (s *StatusServer) refresh(ctx context.Context, folder string) (*FolderStatus, error) {
+	s.resolveMu.Lock()
+	resolutions, err := s.client.Status(ctx, folder)
+	s.resolveMu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	tools := make([]ToolStatus, 0, len(resolutions))
+	for _, resolution := range resolutions {
+		tools = append(tools, ToolStatus{
+			Resolution: resolution,
+			Label:      fmt.Sprintf("%s %s (%s)", resolution.Tool, resolution.Version, resolution.Source),
+		})
+	}
+
+	s.mu.Lock()
+	s.folders[folder] = tools
+	s.mu.Unlock()
+
+	return &FolderStatus{Folder: folder, Tools: tools}, nil
+}
+
+// watch 定期重新解析监视中的文件夹，版本变化时发送通知
+func (s *StatusServer) watch(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		s.mu.Lock()
+		previous := make(map[string][]ToolStatus, len(s.folders))
+		for folder, tools := range s.folders {
+			previous[folder] = tools
+		}
+		s.mu.Unlock()
+
+		for folder, tools := range previous {
+			status, err := s.refresh(ctx, folder)
+			if err != nil || reflect.DeepEqual(tools, status.Tools) {
+				continue
+			}
+			s.notify(StatusChangedMethod, status)
+		}
+	}
+}
+
+// reply 发送响应
+func (s *StatusServer) reply(id *json.RawMessage, result interface{}, rpcErr *rpcError) {
+	msg := rpcMessage{JSONRPC: "2.0", ID: id, Error: rpcErr}
+	if id == nil {
+		null := json.RawMessage("null")
+		msg.ID = &null
+	}
+	if rpcErr == nil {
+		// 成功响应必须包含 result，没有结果时为 null
+		if result == nil {
+			result = json.RawMessage("null")
+		}
+		msg.Result = result
+	}
+	s.write(msg)
+}
+
+// notify 发送通知
+func (s *StatusServer) notify(method string, params interface{}) {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return
+	}
+	s.write(rpcMessage{JSONRPC: "2.0", Method: method, Params: data})
+}
+
+// write 以 Content-Length 分帧写出消息
+func (s *StatusServer) write(msg rpcMessage) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return
+	}
+
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	fmt.Fprintf(s.out, "Content-Length: %d\r\n\r\n", len(data))
+	s.out.Write(data)
+	s.out.Flush()
+}
+
+// readMessage 读取一条 Content-Length 分帧的消息
+func readMessage(reader *bufio.Reader) ([]byte, error) {
+	header, err := textproto.NewReader(reader).ReadMIMEHeader()
+	if err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, io.EOF
+		}
+		return nil, fmt.Errorf("failed to read message header: %w", err)
+	}
+
+	length, err := strconv.Atoi(header.Get("Content-Length"))
+	if err != nil || length < 0 {
+		return nil, fmt.Errorf("invalid Content-Length header %q", header.Get("Content-Length"))
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(reader, body); err != nil {
+		return nil, fmt.Errorf("failed to read message body: %w", err)
+	}
+	return body, nil
+}
+
+// unmarshalParams 解析请求参数
+func unmarshalParams(data json.RawMessage, v interface{}) *rpcError {
+	if len(data) == 0 || string(data) == "null" {
+		return nil
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return &rpcError{Code: rpcInvalidParams, Message: err.Error()}
+	}
+	return nil
+}
+
+// folderPath 将文件夹路径或 file:// URI 转换为绝对路径
+func folderPath(folder string) (string, error) {
+	if folder == "" {
+		return "", errors.New("folder is required")
+	}
+	if strings.HasPrefix(folder, "file://") {
+		u, err := url.Parse(folder)
+		if err != nil {
+			return "", fmt.Errorf("invalid folder uri %s: %w", folder, err)
+		}
+		folder = u.Path
+		// Windows 的 URI 形如 file:///c:/work，去掉盘符前的斜杠
+		if len(folder) >= 3 && folder[0] == '/' && folder[2] == ':' {
+			folder = folder[1:]
+		}
+	} else if strings.Contains(folder, "://") {
+		return "", fmt.Errorf("unsupported folder uri %s", folder)
+	}
+	return filepath.Abs(filepath.FromSlash(folder))
+}
@@ -0,0 +1,101 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/songzhibin97/vman/pkg/vman"
+)
+
+// frame 以 Content-Length 分帧编码消息
+func frame(t *testing.T, msg map[string]interface{}) string {
+	t.Helper()
+	data, err := json.Marshal(msg)
+	require.NoError(t, err)
+	return fmt.Sprintf("Content-Length: %d\r\n\r\n%s", len(data), data)
+}
+
+// readResponses 解码服务写出的所有消息
+func readResponses(t *testing.T, out *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+	reader := bufio.NewReader(out)
+	var messages []map[string]interface{}
+	for out.Len() > 0 || reader.Buffered() > 0 {
+		body, err := readMessage(reader)
+		require.NoError(t, err)
+		var msg map[string]interface{}
+		require.NoError(t, json.Unmarshal(body, &msg))
+		messages = append(messages, msg)
+	}
+	return messages
+}
+
+func TestStatusServer_Serve(t *testing.T) {
+	client, err := vman.New(&vman.Options{HomeDir: t.TempDir()})
+	require.NoError(t, err)
+	folder := t.TempDir()
+
+	var in bytes.Buffer
+	in.WriteString(frame(t, map[string]interface{}{
+		"jsonrpc": "2.0", "id": 1, "method": "initialize",
+		"params": map[string]interface{}{"rootUri": "file://" + filepath.ToSlash(folder)},
+	}))
+	in.WriteString(frame(t, map[string]interface{}{"jsonrpc": "2.0", "method": "initialized"}))
+	in.WriteString(frame(t, map[string]interface{}{
+		"jsonrpc": "2.0", "id": 2, "method": "vman/status",
+		"params": map[string]interface{}{"folder": folder},
+	}))
+	in.WriteString(frame(t, map[string]interface{}{"jsonrpc": "2.0", "id": "3", "method": "unknown"}))
+	in.WriteString(frame(t, map[string]interface{}{"jsonrpc": "2.0", "id": 4, "method": "vman/status", "params": map[string]interface{}{}}))
+	in.WriteString(frame(t, map[string]interface{}{"jsonrpc": "2.0", "id": 5, "method": "shutdown"}))
+	in.WriteString(frame(t, map[string]interface{}{"jsonrpc": "2.0", "method": "exit"}))
+
+	var out bytes.Buffer
+	s := NewStatusServer(client, 0)
+	require.NoError(t, s.Serve(context.Background(), &in, &out))
+
+	responses := readResponses(t, &out)
+	require.Len(t, responses, 5)
+
+	assert.EqualValues(t, 1, responses[0]["id"])
+	result := responses[0]["result"].(map[string]interface{})
+	assert.Equal(t, "vman", result["serverInfo"].(map[string]interface{})["name"])
+
+	assert.EqualValues(t, 2, responses[1]["id"])
+	status := responses[1]["result"].(map[string]interface{})
+	assert.Equal(t, folder, status["folder"])
+	assert.Empty(t, status["tools"])
+
+	assert.Equal(t, "3", responses[2]["id"])
+	assert.EqualValues(t, rpcMethodNotFound, responses[2]["error"].(map[string]interface{})["code"])
+
+	assert.EqualValues(t, rpcInvalidParams, responses[3]["error"].(map[string]interface{})["code"])
+
+	assert.EqualValues(t, 5, responses[4]["id"])
+	assert.Contains(t, responses[4], "result")
+	assert.Nil(t, responses[4]["result"])
+
+	// initialize 和 vman/status 中的文件夹都在监视中
+	assert.Contains(t, s.folders, folder)
+}
+
+func TestFolderPath(t *testing.T) {
+	path, err := folderPath("file:///work/my%20project")
+	require.NoError(t, err)
+	assert.True(t, filepath.IsAbs(path))
+	assert.Equal(t, "my project", filepath.Base(path))
+
+	_, err = folderPath("https://example.com/work")
+	assert.Error(t, err)
+
+	_, err = folderPath("")
+	assert.Error(t, err)
+}
@@ -0,0 +1,304 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/afero"
+
+	"github.com/songzhibin97/vman/pkg/types"
+	"github.com/songzhibin97/vman/pkg/utils"
+)
+
+const (
+	// DedupLedgerFile 版本目录中记录文件内容和共享方式的文件
+	DedupLedgerFile = ".dedup.json"
+
+	// StoreDirName 内容寻址存储目录名，位于版本目录的上级目录中
+	StoreDirName = "store"
+
+	// dedupMinSize 参与共享的最小文件大小，更小的文件共享后节省不了磁盘块
+	dedupMinSize = 4096
+)
+
+// DedupResult 一个版本中的文件与其他版本共享数据的结果
+type DedupResult struct {
+	// Files 记录到内容寻址存储的文件数
+	Files int
+
+	// Reflinked 通过写时复制克隆共享数据的文件数
+	Reflinked int
+
+	// Hardlinked 通过硬链接共享数据的文件数
+	Hardlinked int
+
+	// SavedBytes 节省的字节数
+	SavedBytes int64
+}
+
+// DedupSavings 所有已安装版本通过共享数据节省的空间
+type DedupSavings struct {
+	// Versions 记录了共享情况的版本数
+	Versions int `json:"versions"`
+
+	// Reflinked 通过写时复制克隆共享数据的文件数
+	Reflinked int `json:"reflinked"`
+
+	// Hardlinked 通过硬链接共享数据的文件数
+	Hardlinked int `json:"hardlinked"`
+
+	// SavedBytes 节省的字节数
+	SavedBytes int64 `json:"saved_bytes"`
+}
+
+// dedupLedger 版本目录中的共享记录
+type dedupLedger struct {
+	Mode  string       `json:"mode"`
+	Files []dedupEntry `json:"files"`
+}
+
+// dedupEntry 一个文件的内容和共享方式
+type dedupEntry struct {
+	Path   string `json:"path"`
+	SHA256 string `json:"sha256"`
+	Size   int64  `json:"size"`
+	Shared string `json:"shared,omitempty"` // reflink 或 hardlink，与存储中已有的文件共享数据
+}
+
+// GetStoreDir 获取内容寻址存储目录，与版本目录位于同一分区，其中的文件是已安装文件的硬链接，不占用额外空间
+func (f *FilesystemManager) GetStoreDir() string {
+	return filepath.Join(filepath.Dir(f.paths.VersionsDir), StoreDirName)
+}
+
+// storeObjectPath 内容的SHA256在存储中对应的文件
+func (f *FilesystemManager) storeObjectPath(sum string) string {
+	return filepath.Join(f.GetStoreDir(), sum[:2], sum)
+}
+
+// DedupVersion 使新安装的版本中与其他版本内容相同的文件共享数据
+// 存储中已有相同内容时按 mode 克隆或硬链接存储中的文件替换版本中的文件，否则将版本中的文件硬链接到存储中
+// 共享情况记录在版本目录的 .dedup.json 中，供 GetDedupSavings 统计
+func (f *FilesystemManager) DedupVersion(tool, version, mode string) (*DedupResult, error) {
+	result := &DedupResult{}
+	if mode == "" || mode == types.DedupOff || !dedupSupported {
+		return result, nil
+	}
+	if _, ok := f.fs.(*afero.OsFs); !ok {
+		return result, nil
+	}
+
+	versionPath := f.userVersionPath(tool, version)
+	ledger := &dedupLedger{Mode: mode, Files: []dedupEntry{}}
+	err := filepath.WalkDir(versionPath, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(versionPath, path)
+		if !entry.Type().IsRegular() || rel == DedupLedgerFile {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		if info.Size() < dedupMinSize {
+			return nil
+		}
+
+		sum, err := utils.CalculateFileChecksum(path)
+		if err != nil {
+			return err
+		}
+		shared, err := f.shareFile(path, info, sum, mode)
+		if err != nil {
+			f.logger.Debugf("Failed to share %s: %v", path, err)
+		}
+
+		ledger.Files = append(ledger.Files, dedupEntry{Path: filepath.ToSlash(rel), SHA256: sum, Size: info.Size(), Shared: shared})
+		result.Files++
+		switch shared {
+		case types.DedupReflink:
+			result.Reflinked++
+			result.SavedBytes += info.Size()
+		case types.DedupHardlink:
+			result.Hardlinked++
+			result.SavedBytes += info.Size()
+		}
+		return nil
+	})
+	if err != nil {
+		return result, fmt.Errorf("failed to deduplicate %s@%s: %w", tool, version, err)
+	}
+
+	data, err := json.MarshalIndent(ledger, "", "  ")
+	if err != nil {
+		return result, err
+	}
+	if err := afero.WriteFile(f.fs, filepath.Join(versionPath, DedupLedgerFile), data, 0644); err != nil {
+		return result, fmt.Errorf("failed to write dedup ledger: %w", err)
+	}
+	return result, nil
+}
+
+// shareFile 存储中有相同内容的文件时用它替换 path，返回共享方式；否则将 path 加入存储，返回空字符串
+func (f *FilesystemManager) shareFile(path string, info os.FileInfo, sum, mode string) (string, error) {
+	object := f.storeObjectPath(sum)
+	objectInfo, err := os.Stat(object)
+	if err == nil {
+		if os.SameFile(info, objectInfo) {
+			return "", nil
+		}
+		// 存储中的文件是其他版本中文件的硬链接，可能已在那个版本中被修改
+		if objectInfo.Size() == info.Size() {
+			if objectSum, err := utils.CalculateFileChecksum(object); err == nil && objectSum == sum {
+				return replaceWithShared(object, objectInfo, path, info, mode)
+			}
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(object), 0755); err != nil {
+		return "", err
+	}
+	tmp := object + ".tmp"
+	os.Remove(tmp)
+	if err := os.Link(path, tmp); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp, object); err != nil {
+		os.Remove(tmp)
+		return "", err
+	}
+	return "", nil
+}
+
+// replaceWithShared 用存储中的文件的克隆或硬链接替换 path
+func replaceWithShared(object string, objectInfo os.FileInfo, path string, info os.FileInfo, mode string) (string, error) {
+	tmp := path + ".vman-dedup"
+	os.Remove(tmp)
+
+	shared := ""
+	if mode == types.DedupReflink || mode == types.DedupAuto {
+		err := cloneFile(object, tmp)
+		if err == nil {
+			// 克隆的文件有自己的权限和修改时间
+			shared = types.DedupReflink
+			if err := os.Chmod(tmp, info.Mode().Perm()); err != nil {
+				os.Remove(tmp)
+				return "", err
+			}
+			os.Chtimes(tmp, info.ModTime(), info.ModTime())
+		} else {
+			os.Remove(tmp)
+			if mode == types.DedupReflink {
+				return "", err
+			}
+		}
+	}
+	if shared == "" {
+		// 硬链接共用权限，权限不同的文件不共享
+		if objectInfo.Mode() != info.Mode() {
+			return "", nil
+		}
+		if err := os.Link(object, tmp); err != nil {
+			return "", err
+		}
+		shared = types.DedupHardlink
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return "", err
+	}
+	return shared, nil
+}
+
+// pruneStore 删除存储中不再被任何版本使用的文件，即只剩存储中一个链接的文件
+func (f *FilesystemManager) pruneStore() (int, error) {
+	if !dedupSupported {
+		return 0, nil
+	}
+	if _, ok := f.fs.(*afero.OsFs); !ok {
+		return 0, nil
+	}
+	storeDir := f.GetStoreDir()
+	if _, err := os.Stat(storeDir); err != nil {
+		return 0, nil
+	}
+
+	removed := 0
+	err := filepath.WalkDir(storeDir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		if links, ok := linkCount(info); ok && links <= 1 {
+			if err := os.Remove(path); err != nil {
+				return err
+			}
+			removed++
+		}
+		return nil
+	})
+	return removed, err
+}
+
+// GetDedupSavings 根据各版本的共享记录统计节省的空间
+// 内容相同的 n 个文件中有 k 个与其他文件共享数据时节省 min(k, n-1) 份
+func (f *FilesystemManager) GetDedupSavings() (*DedupSavings, error) {
+	pattern := filepath.Join(f.paths.VersionsDir, "*", "*", types.PlatformDirName(), DedupLedgerFile)
+	ledgers, err := afero.Glob(f.fs, pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	type contentGroup struct {
+		size   int64
+		files  int
+		shared int
+	}
+	groups := make(map[string]*contentGroup)
+	savings := &DedupSavings{}
+	for _, path := range ledgers {
+		data, err := afero.ReadFile(f.fs, path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read dedup ledger %s: %w", path, err)
+		}
+		var ledger dedupLedger
+		if err := json.Unmarshal(data, &ledger); err != nil {
+			f.logger.Debugf("Ignoring invalid dedup ledger %s: %v", path, err)
+			continue
+		}
+		savings.Versions++
+
+		for _, entry := range ledger.Files {
+			group, ok := groups[entry.SHA256]
+			if !ok {
+				group = &contentGroup{size: entry.Size}
+				groups[entry.SHA256] = group
+			}
+			group.files++
+			switch entry.Shared {
+			case types.DedupReflink:
+				savings.Reflinked++
+				group.shared++
+			case types.DedupHardlink:
+				savings.Hardlinked++
+				group.shared++
+			}
+		}
+	}
+
+	for _, group := range groups {
+		savings.SavedBytes += group.size * int64(min(group.shared, group.files-1))
+	}
+	return savings, nil
+}
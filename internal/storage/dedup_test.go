@@ -0,0 +1,130 @@
+//go:build !windows
+
+package storage
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/songzhibin97/vman/pkg/types"
+)
+
+func TestDedupVersion(t *testing.T) {
+	paths := types.ConfigPathsFromRoot(t.TempDir())
+	manager := NewFilesystemManager(paths).(*FilesystemManager)
+
+	sdk := bytes.Repeat([]byte("sdk"), 4096)
+	install := func(version string, binary []byte) string {
+		dir := paths.ToolVersionDir("sdk", version)
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "bin"), 0755))
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "lib"), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "bin", "sdk"), binary, 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "lib", "runtime.so"), sdk, 0644))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "VERSION"), []byte(version), 0644))
+		return dir
+	}
+
+	first := install("1.0.0", bytes.Repeat([]byte("v1"), 4096))
+	result, err := manager.DedupVersion("sdk", "1.0.0", types.DedupHardlink)
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Files, "小文件不参与共享")
+	assert.Zero(t, result.Hardlinked)
+
+	second := install("1.0.1", bytes.Repeat([]byte("v2"), 4096))
+	result, err = manager.DedupVersion("sdk", "1.0.1", types.DedupHardlink)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Hardlinked)
+	assert.Equal(t, int64(len(sdk)), result.SavedBytes)
+
+	firstInfo, err := os.Stat(filepath.Join(first, "lib", "runtime.so"))
+	require.NoError(t, err)
+	secondInfo, err := os.Stat(filepath.Join(second, "lib", "runtime.so"))
+	require.NoError(t, err)
+	assert.True(t, os.SameFile(firstInfo, secondInfo))
+	assert.Equal(t, os.FileMode(0644), secondInfo.Mode().Perm())
+
+	savings, err := manager.GetDedupSavings()
+	require.NoError(t, err)
+	assert.Equal(t, 2, savings.Versions)
+	assert.Equal(t, 1, savings.Hardlinked)
+	assert.Equal(t, int64(len(sdk)), savings.SavedBytes)
+
+	// 删除版本后只被它使用的文件从存储中删除，共享的文件保留
+	require.NoError(t, manager.RemoveVersionDir("sdk", "1.0.0"))
+	objects := storeObjects(t, manager)
+	assert.Len(t, objects, 2)
+	data, err := os.ReadFile(filepath.Join(second, "lib", "runtime.so"))
+	require.NoError(t, err)
+	assert.Equal(t, sdk, data)
+
+	savings, err = manager.GetDedupSavings()
+	require.NoError(t, err)
+	assert.Zero(t, savings.SavedBytes)
+
+	require.NoError(t, manager.RemoveVersionDir("sdk", "1.0.1"))
+	assert.Empty(t, storeObjects(t, manager))
+}
+
+func TestDedupVersionModes(t *testing.T) {
+	paths := types.ConfigPathsFromRoot(t.TempDir())
+	manager := NewFilesystemManager(paths).(*FilesystemManager)
+
+	content := bytes.Repeat([]byte("x"), 8192)
+	for _, version := range []string{"1.0.0", "1.0.1", "1.0.2"} {
+		dir := paths.ToolVersionDir("sdk", version)
+		require.NoError(t, os.MkdirAll(dir, 0755))
+		mode := os.FileMode(0644)
+		if version == "1.0.1" {
+			mode = 0755
+		}
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "data"), content, mode))
+	}
+
+	_, err := manager.DedupVersion("sdk", "1.0.0", types.DedupAuto)
+	require.NoError(t, err)
+
+	// 权限不同的文件不能硬链接，auto 在不支持克隆的文件系统上不共享
+	result, err := manager.DedupVersion("sdk", "1.0.1", types.DedupAuto)
+	require.NoError(t, err)
+	assert.Zero(t, result.Hardlinked)
+	info, err := os.Stat(filepath.Join(paths.ToolVersionDir("sdk", "1.0.1"), "data"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0755), info.Mode().Perm())
+
+	// reflink 模式从不使用硬链接
+	result, err = manager.DedupVersion("sdk", "1.0.2", types.DedupReflink)
+	require.NoError(t, err)
+	assert.Zero(t, result.Hardlinked)
+	assert.Equal(t, result.Reflinked, int(result.SavedBytes/int64(len(content))))
+
+	// off 和内存文件系统不共享
+	result, err = manager.DedupVersion("sdk", "1.0.2", types.DedupOff)
+	require.NoError(t, err)
+	assert.Zero(t, result.Files)
+	memManager := NewFilesystemManagerWithFs(afero.NewMemMapFs(), paths)
+	result, err = memManager.DedupVersion("sdk", "1.0.2", types.DedupHardlink)
+	require.NoError(t, err)
+	assert.Zero(t, result.Files)
+}
+
+// storeObjects 列出存储中的文件
+func storeObjects(t *testing.T, manager *FilesystemManager) []string {
+	var objects []string
+	err := filepath.Walk(manager.GetStoreDir(), func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Mode().IsRegular() {
+			objects = append(objects, path)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	return objects
+}
@@ -0,0 +1,20 @@
+//go:build !windows
+
+package storage
+
+import (
+	"os"
+	"syscall"
+)
+
+// dedupSupported 当前系统是否支持版本间共享数据
+const dedupSupported = true
+
+// linkCount 文件的硬链接数
+func linkCount(info os.FileInfo) (uint64, bool) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, false
+	}
+	return uint64(stat.Nlink), true
+}
@@ -0,0 +1,21 @@
+//go:build windows
+
+package storage
+
+import (
+	"errors"
+	"os"
+)
+
+// dedupSupported Windows 上无法方便地查询硬链接数，不能清理存储中不再使用的文件，不共享数据
+const dedupSupported = false
+
+// linkCount Windows 上不查询硬链接数
+func linkCount(info os.FileInfo) (uint64, bool) {
+	return 0, false
+}
+
+// cloneFile Windows 上不支持写时复制克隆
+func cloneFile(src, dst string) error {
+	return errors.New("reflink is not supported on windows")
+}
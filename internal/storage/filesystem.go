@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -74,13 +75,41 @@ type Manager interface {
 
 	// GetBinaryPath 获取工具二进制文件路径
 	GetBinaryPath(tool, version string) string
+
+	// MarkVersionUsed 记录工具版本被使用
+	MarkVersionUsed(tool, version string) error
+
+	// GetLastUsed 获取工具版本的最后使用时间
+	GetLastUsed(tool, version string) (time.Time, error)
+
+	// GetSystemVersionsDir 获取系统级共享存储的版本目录，未配置时返回空字符串
+	GetSystemVersionsDir() string
+
+	// IsSystemVersion 检查版本是否来自系统级共享存储
+	IsSystemVersion(tool, version string) bool
+
+	// GetForeignVersions 获取只为其他平台安装的版本及其平台列表
+	GetForeignVersions(tool string) (map[string][]string, error)
+
+	// LockVersion 获取工具版本的安装锁，返回的锁使用完毕后需要释放
+	LockVersion(tool, version string) (*FileLock, error)
+
+	// DedupVersion 使版本中与其他版本内容相同的文件共享数据
+	DedupVersion(tool, version, mode string) (*DedupResult, error)
+
+	// GetDedupSavings 统计所有已安装版本通过共享数据节省的空间
+	GetDedupSavings() (*DedupSavings, error)
 }
 
 // FilesystemManager 文件系统存储管理器实现
 type FilesystemManager struct {
 	fs     afero.Fs
 	paths  *types.ConfigPaths
+	system *types.ConfigPaths // 系统级共享存储（只读），未配置时为nil
 	logger *logrus.Logger
+
+	// listOnly 为 true 时 system 只在列出已安装版本时叠加，查找、安装和删除版本只作用于 paths
+	listOnly bool
 }
 
 // NewManager 创建新的存储管理器
@@ -108,6 +137,30 @@ func NewFilesystemManagerWithFs(fs afero.Fs, configPaths *types.ConfigPaths) Man
 	}
 }
 
+// NewFilesystemManagerWithSystemStore 创建叠加了系统级共享存储的存储管理器
+// 查找版本时先查找用户存储再查找系统存储，安装和删除只作用于用户存储
+func NewFilesystemManagerWithSystemStore(fs afero.Fs, configPaths, systemPaths *types.ConfigPaths) Manager {
+	return &FilesystemManager{
+		fs:     fs,
+		paths:  configPaths,
+		system: systemPaths,
+		logger: logrus.New(),
+	}
+}
+
+// NewFilesystemManagerForSystemInstall 创建直接安装到系统级共享存储的存储管理器（vman install --system）
+// 查找、安装和删除版本只作用于 systemPaths，用户存储 userPaths 只在列出已安装版本时叠加，
+// 使配置中的已安装版本记录仍包含用户安装的版本，用户已安装同一版本时仍会安装到系统存储
+func NewFilesystemManagerForSystemInstall(fs afero.Fs, systemPaths, userPaths *types.ConfigPaths) Manager {
+	return &FilesystemManager{
+		fs:       fs,
+		paths:    systemPaths,
+		system:   userPaths,
+		logger:   logrus.New(),
+		listOnly: true,
+	}
+}
+
 // GetToolsDir 获取工具存储目录
 func (f *FilesystemManager) GetToolsDir() string {
 	return f.paths.ToolsDir
@@ -160,6 +213,7 @@ func (f *FilesystemManager) EnsureDirectories() error {
 
 	dirs := []string{
 		f.paths.ConfigDir,
+		f.paths.DataDir,
 		f.paths.ToolsDir,
 		f.paths.BinDir,
 		f.paths.ShimsDir,
@@ -171,6 +225,9 @@ func (f *FilesystemManager) EnsureDirectories() error {
 	}
 
 	for _, dir := range dirs {
+		if dir == "" {
+			continue
+		}
 		if err := f.fs.MkdirAll(dir, 0755); err != nil {
 			return fmt.Errorf("failed to create directory %s: %w", dir, err)
 		}
@@ -181,9 +238,22 @@ func (f *FilesystemManager) EnsureDirectories() error {
 	return nil
 }
 
-// GetToolVersionPath 获取工具版本的存储路径
+// GetToolVersionPath 获取工具版本在当前平台下的存储路径
+// 版本只安装在系统存储中时返回系统存储中的路径
 func (f *FilesystemManager) GetToolVersionPath(tool, version string) string {
-	return filepath.Join(f.paths.VersionsDir, tool, version)
+	userPath := f.userVersionPath(tool, version)
+	if f.system != nil && !f.listOnly && !f.isInstalledAt(userPath, tool) {
+		systemPath := f.system.ToolVersionDir(tool, version)
+		if f.isInstalledAt(systemPath, tool) {
+			return systemPath
+		}
+	}
+	return userPath
+}
+
+// userVersionPath 获取工具版本在用户存储中的路径
+func (f *FilesystemManager) userVersionPath(tool, version string) string {
+	return f.paths.ToolVersionDir(tool, version)
 }
 
 // GetVersionMetadataPath 获取版本元数据文件路径
@@ -200,7 +270,7 @@ func (f *FilesystemManager) GetBinaryPath(tool, version string) string {
 func (f *FilesystemManager) CreateVersionDir(tool, version string) error {
 	f.logger.Debugf("Creating version directory for %s@%s", tool, version)
 
-	versionPath := f.GetToolVersionPath(tool, version)
+	versionPath := f.userVersionPath(tool, version)
 	binPath := filepath.Join(versionPath, "bin")
 
 	// 创建版本目录和bin子目录
@@ -216,19 +286,39 @@ func (f *FilesystemManager) CreateVersionDir(tool, version string) error {
 func (f *FilesystemManager) RemoveVersionDir(tool, version string) error {
 	f.logger.Debugf("Removing version directory for %s@%s", tool, version)
 
-	versionPath := f.GetToolVersionPath(tool, version)
+	if f.IsSystemVersion(tool, version) {
+		return fmt.Errorf("%s@%s is installed in the read-only store %s and cannot be removed from %s", tool, version, f.system.VersionsDir, f.paths.VersionsDir)
+	}
+
+	versionPath := f.userVersionPath(tool, version)
 	if err := f.fs.RemoveAll(versionPath); err != nil {
 		return fmt.Errorf("failed to remove version directory %s: %w", versionPath, err)
 	}
 
+	// 其他平台的安装保留，没有剩余内容时删除版本目录
+	if err := removeIfEmptyDir(f.fs, filepath.Dir(versionPath)); err != nil {
+		return fmt.Errorf("failed to remove version directory %s: %w", filepath.Dir(versionPath), err)
+	}
+
+	// 存储中只被该版本使用的文件不再需要
+	if removed, err := f.pruneStore(); err != nil {
+		f.logger.Debugf("Failed to prune store: %v", err)
+	} else if removed > 0 {
+		f.logger.Debugf("Removed %d unused files from store", removed)
+	}
+
 	f.logger.Debugf("Removed version directory: %s", versionPath)
 	return nil
 }
 
 // IsVersionInstalled 检查版本是否已安装
 func (f *FilesystemManager) IsVersionInstalled(tool, version string) bool {
-	versionPath := f.GetToolVersionPath(tool, version)
-	binaryPath := f.GetBinaryPath(tool, version)
+	return f.isInstalledAt(f.GetToolVersionPath(tool, version), tool)
+}
+
+// isInstalledAt 检查版本目录中是否包含工具的二进制文件
+func (f *FilesystemManager) isInstalledAt(versionPath, tool string) bool {
+	binaryPath := filepath.Join(versionPath, "bin", tool)
 
 	// 检查版本目录是否存在
 	if exists, err := afero.DirExists(f.fs, versionPath); err != nil || !exists {
@@ -247,7 +337,31 @@ func (f *FilesystemManager) IsVersionInstalled(tool, version string) bool {
 func (f *FilesystemManager) GetToolVersions(tool string) ([]string, error) {
 	f.logger.Debugf("Getting versions for tool: %s", tool)
 
-	toolDir := filepath.Join(f.paths.VersionsDir, tool)
+	versions, err := f.listVersionsIn(f.paths.VersionsDir, tool)
+	if err != nil {
+		return nil, err
+	}
+
+	if f.system != nil {
+		systemVersions, err := f.listVersionsIn(f.system.VersionsDir, tool)
+		if err != nil {
+			return nil, err
+		}
+		for _, version := range systemVersions {
+			if !containsVersion(versions, version) {
+				versions = append(versions, version)
+			}
+		}
+		sort.Strings(versions)
+	}
+
+	f.logger.Debugf("Found %d versions for tool %s: %v", len(versions), tool, versions)
+	return versions, nil
+}
+
+// listVersionsIn 列出版本存储目录中工具的已安装版本
+func (f *FilesystemManager) listVersionsIn(versionsDir, tool string) ([]string, error) {
+	toolDir := filepath.Join(versionsDir, tool)
 	if exists, err := afero.DirExists(f.fs, toolDir); err != nil {
 		return nil, fmt.Errorf("failed to check tool directory %s: %w", toolDir, err)
 	} else if !exists {
@@ -259,18 +373,16 @@ func (f *FilesystemManager) GetToolVersions(tool string) ([]string, error) {
 		return nil, fmt.Errorf("failed to read tool directory %s: %w", toolDir, err)
 	}
 
-	var versions []string
+	versions := []string{}
 	for _, entry := range entries {
 		if entry.IsDir() {
 			version := entry.Name()
 			// 验证这是一个有效的版本目录（包含二进制文件）
-			if f.IsVersionInstalled(tool, version) {
+			if f.isInstalledAt(filepath.Join(toolDir, version, types.PlatformDirName()), tool) {
 				versions = append(versions, version)
 			}
 		}
 	}
-
-	f.logger.Debugf("Found %d versions for tool %s: %v", len(versions), tool, versions)
 	return versions, nil
 }
 
@@ -372,6 +484,13 @@ func (f *FilesystemManager) cleanupOrphanedVersions() error {
 					// 如果版本未正确安装，删除该目录
 					if !f.IsVersionInstalled(toolName, version) {
 						orphanedPath := filepath.Join(toolDir, version)
+						// 共享版本目录中其他平台的安装不能删除，只清理当前平台的残留
+						if platforms, _ := f.foreignPlatforms(f.paths.VersionsDir, toolName, version); len(platforms) > 0 {
+							orphanedPath = f.userVersionPath(toolName, version)
+							if exists, _ := afero.Exists(f.fs, orphanedPath); !exists {
+								continue
+							}
+						}
 						f.logger.Infof("Removing orphaned version directory: %s", orphanedPath)
 						if err := f.fs.RemoveAll(orphanedPath); err != nil {
 							f.logger.Warnf("Failed to remove orphaned directory %s: %v", orphanedPath, err)
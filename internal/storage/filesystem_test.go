@@ -67,7 +67,7 @@ func TestFilesystemManager(t *testing.T) {
 
 		// 获取版本路径
 		versionPath := manager.GetToolVersionPath(tool, version)
-		expectedVersionPath := filepath.Join(configPaths.VersionsDir, tool, version)
+		expectedVersionPath := filepath.Join(configPaths.VersionsDir, tool, version, types.PlatformDirName())
 		assert.Equal(t, expectedVersionPath, versionPath)
 
 		// 获取二进制文件路径
@@ -222,6 +222,55 @@ func TestFilesystemManager(t *testing.T) {
 		assert.Error(t, err)
 	})
 
+	t.Run("SaveAndLoadVersionMetadataWithProvenance", func(t *testing.T) {
+		err := manager.EnsureDirectories()
+		require.NoError(t, err)
+
+		tool := "provenance-test"
+		version := "2.0.0"
+
+		err = manager.CreateVersionDir(tool, version)
+		require.NoError(t, err)
+
+		downloadedAt := time.Now().UTC().Truncate(time.Second)
+		metadata := &types.VersionMetadata{
+			Version:     version,
+			ToolName:    tool,
+			InstallPath: manager.GetToolVersionPath(tool, version),
+			BinaryPath:  manager.GetBinaryPath(tool, version),
+			InstalledAt: time.Now(),
+			InstallType: "download",
+			Source:      "https://example.com/tool-2.0.0.tar.gz",
+			Provenance: &types.DownloadProvenance{
+				URL:              "https://example.com/tool-2.0.0.tar.gz",
+				ResolvedURL:      "https://mirror.example.com/tool-2.0.0.tar.gz",
+				Strategy:         "direct",
+				Filename:         "tool-2.0.0.tar.gz",
+				Checksum:         "def456",
+				ExpectedChecksum: "def456",
+				ChecksumVerified: true,
+				Size:             2048,
+				ETag:             `"abc"`,
+				DownloadedAt:     downloadedAt,
+			},
+		}
+
+		err = manager.SaveVersionMetadata(tool, version, metadata)
+		require.NoError(t, err)
+
+		loadedMetadata, err := manager.LoadVersionMetadata(tool, version)
+		require.NoError(t, err)
+		require.NotNil(t, loadedMetadata.Provenance)
+
+		assert.Equal(t, metadata.Provenance.URL, loadedMetadata.Provenance.URL)
+		assert.Equal(t, metadata.Provenance.ResolvedURL, loadedMetadata.Provenance.ResolvedURL)
+		assert.Equal(t, metadata.Provenance.Strategy, loadedMetadata.Provenance.Strategy)
+		assert.Equal(t, metadata.Provenance.Checksum, loadedMetadata.Provenance.Checksum)
+		assert.True(t, loadedMetadata.Provenance.ChecksumVerified)
+		assert.Equal(t, metadata.Provenance.ETag, loadedMetadata.Provenance.ETag)
+		assert.True(t, downloadedAt.Equal(loadedMetadata.Provenance.DownloadedAt))
+	})
+
 	t.Run("CleanupOrphaned", func(t *testing.T) {
 		err := manager.EnsureDirectories()
 		require.NoError(t, err)
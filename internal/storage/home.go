@@ -0,0 +1,119 @@
+package storage
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/spf13/afero"
+
+	"github.com/songzhibin97/vman/pkg/types"
+)
+
+// HomeMove 迁移vman主目录时的一次移动操作
+type HomeMove struct {
+	// Name 被移动的条目名称（如 versions、config.yaml）
+	Name string
+
+	// From 源路径
+	From string
+
+	// To 目标路径
+	To string
+}
+
+// PlanHomeMoves 计算从旧目录布局迁移到新目录布局需要的移动操作
+// 源路径不存在或与目标路径相同的条目会被跳过，临时目录不迁移
+func PlanHomeMoves(fs afero.Fs, from, to *types.ConfigPaths) ([]HomeMove, error) {
+	candidates := []HomeMove{
+		{Name: "config.yaml", From: from.GlobalConfigFile, To: to.GlobalConfigFile},
+		{Name: LayoutVersionFile, From: filepath.Join(from.ConfigDir, LayoutVersionFile), To: filepath.Join(to.ConfigDir, LayoutVersionFile)},
+		{Name: "tools", From: from.ToolsDir, To: to.ToolsDir},
+		{Name: "versions", From: from.VersionsDir, To: to.VersionsDir},
+		{Name: "bin", From: from.BinDir, To: to.BinDir},
+		{Name: "shims", From: from.ShimsDir, To: to.ShimsDir},
+		{Name: "logs", From: from.LogsDir, To: to.LogsDir},
+		{Name: "cache", From: from.CacheDir, To: to.CacheDir},
+	}
+
+	var moves []HomeMove
+	for _, move := range candidates {
+		if filepath.Clean(move.From) == filepath.Clean(move.To) {
+			continue
+		}
+
+		exists, err := afero.Exists(fs, move.From)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check %s: %w", move.From, err)
+		}
+		if !exists {
+			continue
+		}
+
+		if occupied, err := isOccupied(fs, move.To); err != nil {
+			return nil, err
+		} else if occupied {
+			return nil, fmt.Errorf("cannot move %s: destination %s already exists", move.From, move.To)
+		}
+
+		moves = append(moves, move)
+	}
+
+	return moves, nil
+}
+
+// MoveHome 依次执行移动操作，跨文件系统时退化为复制后删除
+func MoveHome(fs afero.Fs, moves []HomeMove) error {
+	for _, move := range moves {
+		if err := fs.MkdirAll(filepath.Dir(move.To), 0755); err != nil {
+			return fmt.Errorf("failed to create directory %s: %w", filepath.Dir(move.To), err)
+		}
+
+		// 目标位置可能已由 EnsureDirectories 创建为空目录
+		if err := removeIfEmptyDir(fs, move.To); err != nil {
+			return err
+		}
+
+		if err := fs.Rename(move.From, move.To); err == nil {
+			continue
+		}
+
+		if err := copyTree(fs, move.From, move.To); err != nil {
+			return fmt.Errorf("failed to copy %s to %s: %w", move.From, move.To, err)
+		}
+		if err := fs.RemoveAll(move.From); err != nil {
+			return fmt.Errorf("failed to remove %s after copy: %w", move.From, err)
+		}
+	}
+	return nil
+}
+
+// isOccupied 目标路径是否已存在文件或非空目录
+func isOccupied(fs afero.Fs, path string) (bool, error) {
+	info, err := fs.Stat(path)
+	if err != nil {
+		return false, nil
+	}
+	if !info.IsDir() {
+		return true, nil
+	}
+
+	empty, err := afero.IsEmpty(fs, path)
+	if err != nil {
+		return false, fmt.Errorf("failed to check %s: %w", path, err)
+	}
+	return !empty, nil
+}
+
+// removeIfEmptyDir 删除空目录，其他情况不做处理
+func removeIfEmptyDir(fs afero.Fs, path string) error {
+	info, err := fs.Stat(path)
+	if err != nil || !info.IsDir() {
+		return nil
+	}
+
+	empty, err := afero.IsEmpty(fs, path)
+	if err != nil || !empty {
+		return err
+	}
+	return fs.Remove(path)
+}
@@ -0,0 +1,84 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/songzhibin97/vman/pkg/types"
+)
+
+func TestPlanHomeMoves(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	from := types.ConfigPathsFromRoot("/home/test/.config/vman")
+	to := types.ConfigPathsFromRoot("/data/vman")
+
+	require.NoError(t, afero.WriteFile(fs, from.GlobalConfigFile, []byte("version: \"1.0\"\n"), 0644))
+	require.NoError(t, afero.WriteFile(fs, filepath.Join(from.VersionsDir, "kubectl", "1.29.0", "kubectl"), []byte("bin"), 0755))
+	require.NoError(t, fs.MkdirAll(from.TempDir, 0755))
+
+	// 目标位置已存在的空目录不影响迁移
+	require.NoError(t, fs.MkdirAll(to.VersionsDir, 0755))
+
+	moves, err := PlanHomeMoves(fs, from, to)
+	require.NoError(t, err)
+
+	var names []string
+	for _, move := range moves {
+		names = append(names, move.Name)
+	}
+	assert.Equal(t, []string{"config.yaml", "versions"}, names)
+
+	require.NoError(t, MoveHome(fs, moves))
+
+	data, err := afero.ReadFile(fs, filepath.Join(to.VersionsDir, "kubectl", "1.29.0", "kubectl"))
+	require.NoError(t, err)
+	assert.Equal(t, "bin", string(data))
+
+	exists, err := afero.Exists(fs, from.GlobalConfigFile)
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	exists, err = afero.Exists(fs, to.GlobalConfigFile)
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	// 迁移完成后再次规划不会有任何操作
+	moves, err = PlanHomeMoves(fs, from, to)
+	require.NoError(t, err)
+	assert.Empty(t, moves)
+}
+
+func TestPlanHomeMoves_DestinationOccupied(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	from := types.ConfigPathsFromRoot("/home/test/.config/vman")
+	to := types.ConfigPathsFromRoot("/data/vman")
+
+	require.NoError(t, afero.WriteFile(fs, filepath.Join(from.ShimsDir, "kubectl"), []byte("shim"), 0755))
+	require.NoError(t, afero.WriteFile(fs, filepath.Join(to.ShimsDir, "kubectl"), []byte("shim"), 0755))
+
+	_, err := PlanHomeMoves(fs, from, to)
+	assert.ErrorContains(t, err, "already exists")
+}
+
+func TestPlanHomeMoves_SameConfigDir(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("XDG_DATA_HOME", "")
+	t.Setenv("XDG_CACHE_HOME", "")
+
+	from := types.ConfigPathsFromRoot("/home/test/.config/vman")
+	to := types.XDGConfigPaths("/home/test")
+
+	require.NoError(t, afero.WriteFile(fs, from.GlobalConfigFile, []byte("version: \"1.0\"\n"), 0644))
+	require.NoError(t, fs.MkdirAll(filepath.Join(from.VersionsDir, "kubectl"), 0755))
+
+	moves, err := PlanHomeMoves(fs, from, to)
+	require.NoError(t, err)
+	require.Len(t, moves, 1)
+	assert.Equal(t, "versions", moves[0].Name)
+	assert.Equal(t, "/home/test/.local/share/vman/versions", moves[0].To)
+}
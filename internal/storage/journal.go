@@ -0,0 +1,198 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+// JournalDirName 数据目录中保存操作日志的目录名
+const JournalDirName = "journal"
+
+// StepStatus 操作步骤的状态
+type StepStatus string
+
+const (
+	// StepPending 尚未执行
+	StepPending StepStatus = "pending"
+	// StepDone 已成功完成，继续操作时跳过
+	StepDone StepStatus = "done"
+	// StepFailed 执行失败，继续操作时从该步骤开始
+	StepFailed StepStatus = "failed"
+)
+
+// JournalStep 操作中的一个步骤
+type JournalStep struct {
+	Name       string            `json:"name"`
+	Params     map[string]string `json:"params,omitempty"`
+	Status     StepStatus        `json:"status"`
+	Error      string            `json:"error,omitempty"`
+	Attempts   int               `json:"attempts,omitempty"`
+	FinishedAt time.Time         `json:"finished_at,omitempty"`
+}
+
+// Journal 多步骤操作的日志，每完成一个步骤即写入磁盘
+// 操作中途失败时日志保留在磁盘上，之后可以从失败的步骤继续，全部完成后删除
+type Journal struct {
+	ID        string            `json:"id"`
+	Operation string            `json:"operation"`
+	Command   string            `json:"command,omitempty"`
+	WorkDir   string            `json:"work_dir,omitempty"`
+	Options   map[string]string `json:"options,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+	Steps     []*JournalStep    `json:"steps"`
+
+	fs   afero.Fs
+	path string
+}
+
+// NewJournal 在 dir 中创建操作日志，调用 Save 或 Run 之前不会写入磁盘
+func NewJournal(fs afero.Fs, dir, operation string) *Journal {
+	now := time.Now()
+	id := fmt.Sprintf("%s-%s-%d", operation, now.Format("20060102-150405"), os.Getpid())
+	return &Journal{
+		ID:        id,
+		Operation: operation,
+		Options:   make(map[string]string),
+		CreatedAt: now,
+		UpdatedAt: now,
+		fs:        fs,
+		path:      filepath.Join(dir, id+".json"),
+	}
+}
+
+// LoadJournal 读取操作日志
+func LoadJournal(fs afero.Fs, path string) (*Journal, error) {
+	data, err := afero.ReadFile(fs, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read journal %s: %w", path, err)
+	}
+	journal := &Journal{}
+	if err := json.Unmarshal(data, journal); err != nil {
+		return nil, fmt.Errorf("failed to parse journal %s: %w", path, err)
+	}
+	if journal.Options == nil {
+		journal.Options = make(map[string]string)
+	}
+	journal.fs = fs
+	journal.path = path
+	return journal, nil
+}
+
+// ListJournals 按创建时间从早到晚返回 dir 中所有未完成的操作日志，无法解析的文件会被跳过
+func ListJournals(fs afero.Fs, dir string) ([]*Journal, error) {
+	entries, err := afero.ReadDir(fs, dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read journal directory: %w", err)
+	}
+
+	var journals []*Journal
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		journal, err := LoadJournal(fs, filepath.Join(dir, entry.Name()))
+		if err != nil {
+			continue
+		}
+		journals = append(journals, journal)
+	}
+	sort.Slice(journals, func(i, j int) bool {
+		return journals[i].CreatedAt.Before(journals[j].CreatedAt)
+	})
+	return journals, nil
+}
+
+// Path 操作日志的文件路径
+func (j *Journal) Path() string {
+	return j.path
+}
+
+// AddStep 追加一个待执行的步骤
+func (j *Journal) AddStep(name string, params map[string]string) {
+	j.Steps = append(j.Steps, &JournalStep{Name: name, Params: params, Status: StepPending})
+}
+
+// Remaining 返回尚未完成的步骤
+func (j *Journal) Remaining() []*JournalStep {
+	var remaining []*JournalStep
+	for _, step := range j.Steps {
+		if step.Status != StepDone {
+			remaining = append(remaining, step)
+		}
+	}
+	return remaining
+}
+
+// Run 依次执行尚未完成的步骤，每个步骤结束后保存日志
+// 步骤失败时停止并返回错误，日志保留以便继续；全部完成后删除日志
+func (j *Journal) Run(execute func(step *JournalStep) error) error {
+	if err := j.Save(); err != nil {
+		return err
+	}
+
+	for _, step := range j.Steps {
+		if step.Status == StepDone {
+			continue
+		}
+
+		step.Attempts++
+		err := execute(step)
+		step.FinishedAt = time.Now()
+		if err != nil {
+			step.Status = StepFailed
+			step.Error = err.Error()
+		} else {
+			step.Status = StepDone
+			step.Error = ""
+		}
+
+		if saveErr := j.Save(); saveErr != nil {
+			return saveErr
+		}
+		if err != nil {
+			return fmt.Errorf("step %s failed: %w", step.Name, err)
+		}
+	}
+
+	return j.Remove()
+}
+
+// Save 写入操作日志，先写临时文件再重命名，中途退出时不会留下不完整的日志
+func (j *Journal) Save() error {
+	j.UpdatedAt = time.Now()
+	data, err := json.MarshalIndent(j, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal journal: %w", err)
+	}
+	if err := j.fs.MkdirAll(filepath.Dir(j.path), 0755); err != nil {
+		return fmt.Errorf("failed to create journal directory: %w", err)
+	}
+	tmpPath := j.path + ".tmp"
+	if err := afero.WriteFile(j.fs, tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+	if err := j.fs.Rename(tmpPath, j.path); err != nil {
+		j.fs.Remove(tmpPath)
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+	return nil
+}
+
+// Remove 删除操作日志
+func (j *Journal) Remove() error {
+	if err := j.fs.Remove(j.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove journal: %w", err)
+	}
+	return nil
+}
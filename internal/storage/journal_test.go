@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJournal_RunAndResume(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	journal := NewJournal(fs, "/data/journal", "install")
+	journal.Options["global"] = "true"
+	journal.AddStep("kubectl@1.29.0", map[string]string{"tool": "kubectl", "version": "1.29.0"})
+	journal.AddStep("helm@3.14.0", map[string]string{"tool": "helm", "version": "3.14.0"})
+	journal.AddStep("terraform@1.7.0", map[string]string{"tool": "terraform", "version": "1.7.0"})
+
+	// 第二步失败时停止，日志保留
+	var executed []string
+	err := journal.Run(func(step *JournalStep) error {
+		executed = append(executed, step.Name)
+		if step.Params["tool"] == "helm" {
+			return errors.New("connection reset")
+		}
+		return nil
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "helm@3.14.0")
+	assert.Equal(t, []string{"kubectl@1.29.0", "helm@3.14.0"}, executed)
+
+	journals, err := ListJournals(fs, "/data/journal")
+	require.NoError(t, err)
+	require.Len(t, journals, 1)
+	loaded := journals[0]
+	assert.Equal(t, "install", loaded.Operation)
+	assert.Equal(t, "true", loaded.Options["global"])
+	assert.Equal(t, StepDone, loaded.Steps[0].Status)
+	assert.Equal(t, StepFailed, loaded.Steps[1].Status)
+	assert.Equal(t, "connection reset", loaded.Steps[1].Error)
+	assert.Len(t, loaded.Remaining(), 2)
+
+	// 继续时跳过已完成的步骤，全部完成后删除日志
+	executed = nil
+	require.NoError(t, loaded.Run(func(step *JournalStep) error {
+		executed = append(executed, step.Name)
+		return nil
+	}))
+	assert.Equal(t, []string{"helm@3.14.0", "terraform@1.7.0"}, executed)
+	assert.Equal(t, 2, loaded.Steps[1].Attempts)
+
+	journals, err = ListJournals(fs, "/data/journal")
+	require.NoError(t, err)
+	assert.Empty(t, journals)
+}
+
+func TestListJournals_SkipsInvalid(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(fs, "/data/journal/broken.json", []byte("{"), 0644))
+	require.NoError(t, afero.WriteFile(fs, "/data/journal/notes.txt", []byte("x"), 0644))
+
+	journal := NewJournal(fs, "/data/journal", "install")
+	journal.AddStep("kubectl@1.29.0", nil)
+	require.NoError(t, journal.Save())
+
+	journals, err := ListJournals(fs, "/data/journal")
+	require.NoError(t, err)
+	require.Len(t, journals, 1)
+	assert.Equal(t, journal.ID, journals[0].ID)
+	assert.Equal(t, journal.Path(), journals[0].Path())
+
+	journals, err = ListJournals(fs, "/missing")
+	require.NoError(t, err)
+	assert.Empty(t, journals)
+}
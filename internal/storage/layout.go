@@ -0,0 +1,353 @@
+package storage
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+
+	"github.com/songzhibin97/vman/pkg/types"
+)
+
+const (
+	// LayoutVersionFile 记录存储目录结构版本的标记文件（位于配置根目录）
+	LayoutVersionFile = ".layout-version"
+
+	// CurrentLayoutVersion 当前程序使用的存储目录结构版本
+	CurrentLayoutVersion = 2
+
+	// LegacyLayoutVersion 引入标记文件之前的目录结构版本
+	LegacyLayoutVersion = 0
+)
+
+// LayoutMigration 存储目录结构迁移步骤，将目录结构从 From 升级到 From+1
+type LayoutMigration struct {
+	// From 迁移前的目录结构版本
+	From int
+
+	// Description 迁移内容说明
+	Description string
+
+	// Backup 迁移前需要备份的路径（相对于配置根目录）
+	// 不声明备份路径的步骤失败时无法恢复，必须在任一位置中断后都可以重复执行
+	Backup []string
+
+	// Apply 执行迁移
+	Apply func(fs afero.Fs, paths *types.ConfigPaths) error
+}
+
+// LayoutMigrationPlan 目录结构迁移计划
+type LayoutMigrationPlan struct {
+	// From 当前目录结构版本
+	From int
+
+	// To 迁移后的目录结构版本
+	To int
+
+	// Fresh 配置目录中尚无任何安装，只需写入标记文件
+	Fresh bool
+
+	// Steps 需要依次执行的迁移步骤
+	Steps []LayoutMigration
+
+	// BackupPath 迁移前的备份目录，未备份时为空
+	BackupPath string
+}
+
+// NeedsMigration 是否有需要执行的迁移步骤
+func (p *LayoutMigrationPlan) NeedsMigration() bool {
+	return len(p.Steps) > 0
+}
+
+// layoutMigrations 已注册的迁移步骤，按 From 升序排列
+// 修改目录结构时递增 CurrentLayoutVersion 并在此追加对应的迁移
+var layoutMigrations = []LayoutMigration{
+	{
+		From:        0,
+		Description: "record storage layout version marker",
+	},
+	{
+		From:        1,
+		Description: "move installed versions into per-platform directories (versions/<tool>/<version>/<os>-<arch>)",
+		Apply:       migrateVersionsToPlatformDirs,
+	},
+}
+
+// LayoutMigrator 存储目录结构迁移器
+type LayoutMigrator struct {
+	fs         afero.Fs
+	paths      *types.ConfigPaths
+	migrations []LayoutMigration
+	target     int
+	logger     *logrus.Logger
+	now        func() time.Time
+}
+
+// NewLayoutMigrator 创建存储目录结构迁移器
+func NewLayoutMigrator(configPaths *types.ConfigPaths) *LayoutMigrator {
+	return NewLayoutMigratorWithFs(afero.NewOsFs(), configPaths)
+}
+
+// NewLayoutMigratorWithFs 使用指定文件系统创建存储目录结构迁移器（用于测试）
+func NewLayoutMigratorWithFs(fs afero.Fs, configPaths *types.ConfigPaths) *LayoutMigrator {
+	return &LayoutMigrator{
+		fs:         fs,
+		paths:      configPaths,
+		migrations: layoutMigrations,
+		target:     CurrentLayoutVersion,
+		logger:     logrus.New(),
+		now:        time.Now,
+	}
+}
+
+// ReadLayoutVersion 读取配置目录中记录的目录结构版本
+// 标记文件不存在时返回 (LegacyLayoutVersion, false)
+func ReadLayoutVersion(fs afero.Fs, configDir string) (int, bool, error) {
+	markerPath := filepath.Join(configDir, LayoutVersionFile)
+
+	data, err := afero.ReadFile(fs, markerPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return LegacyLayoutVersion, false, nil
+		}
+		return 0, false, fmt.Errorf("failed to read layout version %s: %w", markerPath, err)
+	}
+
+	version, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || version < 0 {
+		return 0, false, fmt.Errorf("invalid layout version in %s: %q", markerPath, strings.TrimSpace(string(data)))
+	}
+
+	return version, true, nil
+}
+
+// WriteLayoutVersion 写入目录结构版本标记
+func WriteLayoutVersion(fs afero.Fs, configDir string, version int) error {
+	if err := fs.MkdirAll(configDir, 0755); err != nil {
+		return fmt.Errorf("failed to create config directory %s: %w", configDir, err)
+	}
+
+	markerPath := filepath.Join(configDir, LayoutVersionFile)
+	if err := afero.WriteFile(fs, markerPath, []byte(strconv.Itoa(version)+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to write layout version %s: %w", markerPath, err)
+	}
+	return nil
+}
+
+// Plan 计算从当前目录结构升级到最新版本所需的迁移步骤
+func (m *LayoutMigrator) Plan() (*LayoutMigrationPlan, error) {
+	current, recorded, err := ReadLayoutVersion(m.fs, m.paths.ConfigDir)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &LayoutMigrationPlan{From: current, To: m.target}
+
+	if !recorded {
+		installed, err := m.hasExistingInstallation()
+		if err != nil {
+			return nil, err
+		}
+		if !installed {
+			plan.From = m.target
+			plan.Fresh = true
+			return plan, nil
+		}
+	}
+
+	if current > m.target {
+		return nil, fmt.Errorf("storage layout version %d in %s is newer than supported version %d, please upgrade vman", current, m.paths.ConfigDir, m.target)
+	}
+
+	for version := current; version < m.target; version++ {
+		step, ok := m.findMigration(version)
+		if !ok {
+			return nil, fmt.Errorf("no migration registered for storage layout version %d", version)
+		}
+		plan.Steps = append(plan.Steps, step)
+	}
+
+	return plan, nil
+}
+
+// Migrate 将存储目录结构升级到最新版本
+// dryRun 为 true 时只返回迁移计划，不修改任何文件
+// 迁移前会备份各步骤声明的路径，任一步骤失败时从备份恢复
+func (m *LayoutMigrator) Migrate(dryRun bool) (*LayoutMigrationPlan, error) {
+	plan, err := m.Plan()
+	if err != nil {
+		return nil, err
+	}
+
+	if dryRun {
+		return plan, nil
+	}
+
+	if plan.Fresh {
+		return plan, WriteLayoutVersion(m.fs, m.paths.ConfigDir, plan.To)
+	}
+
+	if !plan.NeedsMigration() {
+		return plan, nil
+	}
+
+	backupPaths := plan.backupPaths()
+	if len(backupPaths) > 0 {
+		plan.BackupPath = filepath.Join(m.paths.ConfigDir, fmt.Sprintf("backup_layout_v%d_%d", plan.From, m.now().Unix()))
+		if err := m.backup(plan.BackupPath, backupPaths); err != nil {
+			return plan, fmt.Errorf("failed to backup before layout migration: %w", err)
+		}
+	}
+
+	for _, step := range plan.Steps {
+		m.logger.Infof("Migrating storage layout from version %d to %d: %s", step.From, step.From+1, step.Description)
+
+		if step.Apply != nil {
+			if err := step.Apply(m.fs, m.paths); err != nil {
+				migrateErr := fmt.Errorf("layout migration from version %d failed: %w", step.From, err)
+				if plan.BackupPath == "" {
+					return plan, migrateErr
+				}
+				if restoreErr := m.restore(plan.BackupPath, backupPaths); restoreErr != nil {
+					return plan, fmt.Errorf("%w (restore from %s failed: %v)", migrateErr, plan.BackupPath, restoreErr)
+				}
+				return plan, fmt.Errorf("%w (restored from %s)", migrateErr, plan.BackupPath)
+			}
+		}
+
+		// 每完成一步即更新标记，中断后可从该步骤继续
+		if err := WriteLayoutVersion(m.fs, m.paths.ConfigDir, step.From+1); err != nil {
+			return plan, err
+		}
+	}
+
+	return plan, nil
+}
+
+// findMigration 查找从指定版本开始的迁移步骤
+func (m *LayoutMigrator) findMigration(from int) (LayoutMigration, bool) {
+	for _, migration := range m.migrations {
+		if migration.From == from {
+			return migration, true
+		}
+	}
+	return LayoutMigration{}, false
+}
+
+// hasExistingInstallation 配置目录中是否已有旧版本留下的安装
+func (m *LayoutMigrator) hasExistingInstallation() (bool, error) {
+	for _, path := range []string{m.paths.GlobalConfigFile, m.paths.VersionsDir, m.paths.ToolsDir} {
+		exists, err := afero.Exists(m.fs, path)
+		if err != nil {
+			return false, fmt.Errorf("failed to check %s: %w", path, err)
+		}
+		if exists {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// backupPaths 汇总迁移步骤需要备份的路径（去重，保持顺序）
+func (p *LayoutMigrationPlan) backupPaths() []string {
+	var paths []string
+	seen := make(map[string]bool)
+	for _, step := range p.Steps {
+		for _, path := range step.Backup {
+			path = filepath.Clean(path)
+			if !seen[path] {
+				seen[path] = true
+				paths = append(paths, path)
+			}
+		}
+	}
+	return paths
+}
+
+// backup 将配置根目录下的指定路径复制到备份目录
+func (m *LayoutMigrator) backup(backupDir string, paths []string) error {
+	if err := m.fs.MkdirAll(backupDir, 0755); err != nil {
+		return fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	for _, rel := range paths {
+		src := filepath.Join(m.paths.ConfigDir, rel)
+		if exists, err := afero.Exists(m.fs, src); err != nil {
+			return err
+		} else if !exists {
+			continue
+		}
+		if err := copyTree(m.fs, src, filepath.Join(backupDir, rel)); err != nil {
+			return fmt.Errorf("failed to backup %s: %w", src, err)
+		}
+	}
+
+	m.logger.Infof("Backed up storage layout to: %s", backupDir)
+	return nil
+}
+
+// restore 用备份目录中的内容替换配置根目录下的指定路径
+func (m *LayoutMigrator) restore(backupDir string, paths []string) error {
+	for _, rel := range paths {
+		dst := filepath.Join(m.paths.ConfigDir, rel)
+		if err := m.fs.RemoveAll(dst); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", dst, err)
+		}
+
+		src := filepath.Join(backupDir, rel)
+		if exists, err := afero.Exists(m.fs, src); err != nil {
+			return err
+		} else if !exists {
+			continue
+		}
+		if err := copyTree(m.fs, src, dst); err != nil {
+			return fmt.Errorf("failed to restore %s: %w", dst, err)
+		}
+	}
+	return nil
+}
+
+// copyTree 递归复制文件或目录，保留权限位
+func copyTree(fs afero.Fs, src, dst string) error {
+	return afero.Walk(fs, src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		if info.IsDir() {
+			return fs.MkdirAll(target, info.Mode().Perm())
+		}
+
+		if err := fs.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+
+		in, err := fs.Open(path)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+
+		out, err := fs.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode().Perm())
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(out, in); err != nil {
+			out.Close()
+			return err
+		}
+		return out.Close()
+	})
+}
@@ -0,0 +1,141 @@
+package storage
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/songzhibin97/vman/pkg/types"
+)
+
+func newTestLayoutMigrator(fs afero.Fs, migrations []LayoutMigration, target int) *LayoutMigrator {
+	m := NewLayoutMigratorWithFs(fs, types.DefaultConfigPaths("/home/test"))
+	m.migrations = migrations
+	m.target = target
+	m.now = func() time.Time { return time.Unix(1700000000, 0) }
+	return m
+}
+
+func TestLayoutMigrator_FreshInstall(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	m := NewLayoutMigratorWithFs(fs, types.DefaultConfigPaths("/home/test"))
+
+	plan, err := m.Migrate(true)
+	require.NoError(t, err)
+	assert.True(t, plan.Fresh)
+	assert.False(t, plan.NeedsMigration())
+
+	_, recorded, err := ReadLayoutVersion(fs, m.paths.ConfigDir)
+	require.NoError(t, err)
+	assert.False(t, recorded, "dry run must not write the marker")
+
+	_, err = m.Migrate(false)
+	require.NoError(t, err)
+
+	version, recorded, err := ReadLayoutVersion(fs, m.paths.ConfigDir)
+	require.NoError(t, err)
+	assert.True(t, recorded)
+	assert.Equal(t, CurrentLayoutVersion, version)
+}
+
+func TestLayoutMigrator_LegacyInstall(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	paths := types.DefaultConfigPaths("/home/test")
+	require.NoError(t, afero.WriteFile(fs, paths.GlobalConfigFile, []byte("version: \"1.0\"\n"), 0644))
+	require.NoError(t, fs.MkdirAll(filepath.Join(paths.VersionsDir, "kubectl", "1.29.0"), 0755))
+
+	var applied []int
+	migrations := []LayoutMigration{
+		{From: 0, Apply: func(fs afero.Fs, p *types.ConfigPaths) error { applied = append(applied, 0); return nil }},
+		{
+			From:   1,
+			Backup: []string{"config.yaml"},
+			Apply: func(fs afero.Fs, p *types.ConfigPaths) error {
+				applied = append(applied, 1)
+				return afero.WriteFile(fs, p.GlobalConfigFile, []byte("version: \"2.0\"\n"), 0644)
+			},
+		},
+	}
+	m := newTestLayoutMigrator(fs, migrations, 2)
+
+	plan, err := m.Migrate(true)
+	require.NoError(t, err)
+	assert.Equal(t, 0, plan.From)
+	assert.Equal(t, 2, plan.To)
+	assert.Len(t, plan.Steps, 2)
+	assert.Empty(t, applied)
+
+	plan, err = m.Migrate(false)
+	require.NoError(t, err)
+	assert.Equal(t, []int{0, 1}, applied)
+
+	version, _, err := ReadLayoutVersion(fs, paths.ConfigDir)
+	require.NoError(t, err)
+	assert.Equal(t, 2, version)
+
+	require.NotEmpty(t, plan.BackupPath)
+	backup, err := afero.ReadFile(fs, filepath.Join(plan.BackupPath, "config.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, "version: \"1.0\"\n", string(backup))
+
+	// 已是最新版本时不再执行任何步骤
+	plan, err = m.Migrate(false)
+	require.NoError(t, err)
+	assert.False(t, plan.NeedsMigration())
+	assert.Equal(t, []int{0, 1}, applied)
+}
+
+func TestLayoutMigrator_RestoresOnFailure(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	paths := types.DefaultConfigPaths("/home/test")
+	require.NoError(t, WriteLayoutVersion(fs, paths.ConfigDir, 1))
+	require.NoError(t, afero.WriteFile(fs, filepath.Join(paths.ToolsDir, "kubectl.toml"), []byte("name = \"kubectl\"\n"), 0644))
+
+	migrations := []LayoutMigration{
+		{
+			From:   1,
+			Backup: []string{"tools"},
+			Apply: func(fs afero.Fs, p *types.ConfigPaths) error {
+				if err := fs.RemoveAll(p.ToolsDir); err != nil {
+					return err
+				}
+				return errors.New("boom")
+			},
+		},
+	}
+	m := newTestLayoutMigrator(fs, migrations, 2)
+
+	_, err := m.Migrate(false)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "boom")
+
+	data, err := afero.ReadFile(fs, filepath.Join(paths.ToolsDir, "kubectl.toml"))
+	require.NoError(t, err)
+	assert.Equal(t, "name = \"kubectl\"\n", string(data))
+
+	version, _, err := ReadLayoutVersion(fs, paths.ConfigDir)
+	require.NoError(t, err)
+	assert.Equal(t, 1, version)
+}
+
+func TestLayoutMigrator_Errors(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	paths := types.DefaultConfigPaths("/home/test")
+
+	require.NoError(t, WriteLayoutVersion(fs, paths.ConfigDir, 5))
+	_, err := newTestLayoutMigrator(fs, nil, 2).Plan()
+	assert.ErrorContains(t, err, "newer than supported")
+
+	require.NoError(t, WriteLayoutVersion(fs, paths.ConfigDir, 0))
+	_, err = newTestLayoutMigrator(fs, nil, 1).Plan()
+	assert.ErrorContains(t, err, "no migration registered")
+
+	require.NoError(t, afero.WriteFile(fs, filepath.Join(paths.ConfigDir, LayoutVersionFile), []byte("v2"), 0644))
+	_, _, err = ReadLayoutVersion(fs, paths.ConfigDir)
+	assert.ErrorContains(t, err, "invalid layout version")
+}
@@ -0,0 +1,216 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+const (
+	// DefaultLockTimeout 等待其他进程释放锁的默认时长
+	DefaultLockTimeout = 5 * time.Minute
+
+	// DefaultLockStaleAfter 锁文件超过该时长未更新时视为遗留锁
+	// 持有者位于其他机器时无法检查其进程是否存活，只能依据锁文件的修改时间判断；
+	// 持有期间会定期刷新修改时间，耗时较长的安装不会被误判为遗留锁
+	DefaultLockStaleAfter = 30 * time.Minute
+
+	// lockPollInterval 等待锁时的轮询间隔
+	lockPollInterval = 200 * time.Millisecond
+)
+
+// FileLock 基于独占创建锁文件的进程间锁
+// 不依赖 flock 等在NFS上行为不一致的系统调用，锁文件中记录持有者的主机名和进程号
+type FileLock struct {
+	fs    afero.Fs
+	path  string
+	owner string
+
+	// stop 通知心跳停止，done 在心跳退出后关闭
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// LockOwner 锁文件中记录的持有者信息
+type LockOwner struct {
+	Host       string
+	PID        int
+	AcquiredAt time.Time
+}
+
+// String 返回持有者的描述
+func (o LockOwner) String() string {
+	return fmt.Sprintf("%s (pid %d) since %s", o.Host, o.PID, o.AcquiredAt.Format(time.RFC3339))
+}
+
+// AcquireLock 获取锁文件，锁已被持有时等待至超时
+// 同一主机上持有者进程已退出，或锁文件超过 staleAfter 未更新时，接管遗留锁；
+// 持有期间每隔 staleAfter/3 刷新一次锁文件的修改时间，直到释放
+func AcquireLock(fs afero.Fs, path string, timeout, staleAfter time.Duration) (*FileLock, error) {
+	if err := fs.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create lock directory: %w", err)
+	}
+
+	host, _ := os.Hostname()
+	owner := fmt.Sprintf("%s\n%d\n%d\n", host, os.Getpid(), time.Now().Unix())
+	deadline := time.Now().Add(timeout)
+
+	for {
+		file, err := fs.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			_, writeErr := file.WriteString(owner)
+			closeErr := file.Close()
+			if writeErr != nil || closeErr != nil {
+				fs.Remove(path)
+				return nil, fmt.Errorf("failed to write lock file %s: %w", path, errors.Join(writeErr, closeErr))
+			}
+			lock := &FileLock{fs: fs, path: path, owner: owner}
+			if staleAfter > 0 {
+				lock.stop = make(chan struct{})
+				lock.done = make(chan struct{})
+				go lock.heartbeat(staleAfter / 3)
+			}
+			return lock, nil
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("failed to create lock file %s: %w", path, err)
+		}
+
+		if stale, _ := isStaleLock(fs, path, host, staleAfter); stale {
+			if err := fs.Remove(path); err != nil && !os.IsNotExist(err) {
+				return nil, fmt.Errorf("failed to remove stale lock %s: %w", path, err)
+			}
+			continue
+		}
+
+		if time.Now().After(deadline) {
+			holder, _ := ReadLockOwner(fs, path)
+			return nil, fmt.Errorf("timed out waiting for lock %s held by %s", path, holder)
+		}
+		time.Sleep(lockPollInterval)
+	}
+}
+
+// heartbeat 定期刷新锁文件的修改时间，锁被其他进程接管后停止
+func (l *FileLock) heartbeat(interval time.Duration) {
+	defer close(l.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+			if held, _ := l.held(); !held {
+				return
+			}
+			now := time.Now()
+			_ = l.fs.Chtimes(l.path, now, now)
+		}
+	}
+}
+
+// held 检查锁文件是否仍由当前持有者持有
+func (l *FileLock) held() (bool, error) {
+	data, err := afero.ReadFile(l.fs, l.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to read lock file %s: %w", l.path, err)
+	}
+	return string(data) == l.owner, nil
+}
+
+// Release 释放锁，锁文件已被其他进程接管时不做处理
+func (l *FileLock) Release() error {
+	if l.stop != nil {
+		l.stopOnce.Do(func() { close(l.stop) })
+		<-l.done
+	}
+
+	held, err := l.held()
+	if err != nil || !held {
+		return err
+	}
+	if err := l.fs.Remove(l.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove lock file %s: %w", l.path, err)
+	}
+	return nil
+}
+
+// ReadLockOwner 读取锁文件中的持有者信息
+func ReadLockOwner(fs afero.Fs, path string) (LockOwner, error) {
+	data, err := afero.ReadFile(fs, path)
+	if err != nil {
+		return LockOwner{}, err
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) < 3 {
+		return LockOwner{}, fmt.Errorf("invalid lock file %s", path)
+	}
+
+	pid, err := strconv.Atoi(strings.TrimSpace(lines[1]))
+	if err != nil {
+		return LockOwner{}, fmt.Errorf("invalid pid in lock file %s: %w", path, err)
+	}
+	acquired, err := strconv.ParseInt(strings.TrimSpace(lines[2]), 10, 64)
+	if err != nil {
+		return LockOwner{}, fmt.Errorf("invalid timestamp in lock file %s: %w", path, err)
+	}
+
+	return LockOwner{Host: strings.TrimSpace(lines[0]), PID: pid, AcquiredAt: time.Unix(acquired, 0)}, nil
+}
+
+// isStaleLock 判断锁文件是否为遗留锁
+func isStaleLock(fs afero.Fs, path, host string, staleAfter time.Duration) (bool, error) {
+	info, err := fs.Stat(path)
+	if err != nil {
+		return false, err
+	}
+	if time.Since(info.ModTime()) > staleAfter {
+		return true, nil
+	}
+
+	owner, err := ReadLockOwner(fs, path)
+	if err != nil {
+		// 持有者可能正在写入，等待下一轮检查
+		return false, nil
+	}
+	return owner.Host == host && !ProcessAlive(owner.PID), nil
+}
+
+// ProcessAlive 检查本机进程是否存在
+func ProcessAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	// Windows 下 FindProcess 成功即表示进程存在
+	if runtime.GOOS == "windows" {
+		return true
+	}
+	err = process.Signal(syscall.Signal(0))
+	return err == nil || errors.Is(err, os.ErrPermission)
+}
+
+// LockVersion 获取工具版本的安装锁
+// 锁文件位于版本目录旁，与版本目录位于同一文件系统，多台机器共享版本目录时同样有效
+func (f *FilesystemManager) LockVersion(tool, version string) (*FileLock, error) {
+	lockPath := f.userVersionPath(tool, version) + ".lock"
+	return AcquireLock(f.fs, lockPath, DefaultLockTimeout, DefaultLockStaleAfter)
+}
@@ -0,0 +1,140 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/songzhibin97/vman/pkg/types"
+)
+
+const testLockPath = "/shared/vman/versions/kubectl/1.29.0/linux-amd64.lock"
+
+func TestAcquireLock(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	lock, err := AcquireLock(fs, testLockPath, time.Second, time.Hour)
+	require.NoError(t, err)
+
+	owner, err := ReadLockOwner(fs, testLockPath)
+	require.NoError(t, err)
+	assert.Equal(t, os.Getpid(), owner.PID)
+
+	// 持有者仍然存活时等待至超时
+	_, err = AcquireLock(fs, testLockPath, 0, time.Hour)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "timed out")
+
+	require.NoError(t, lock.Release())
+	exists, err := afero.Exists(fs, testLockPath)
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	lock, err = AcquireLock(fs, testLockPath, 0, time.Hour)
+	require.NoError(t, err)
+	require.NoError(t, lock.Release())
+}
+
+func TestAcquireLock_StaleByAge(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	// 其他机器遗留的锁无法检查进程，按修改时间判断
+	require.NoError(t, afero.WriteFile(fs, testLockPath, []byte("other-host\n1\n1700000000\n"), 0644))
+	old := time.Now().Add(-time.Hour)
+	require.NoError(t, fs.Chtimes(testLockPath, old, old))
+
+	lock, err := AcquireLock(fs, testLockPath, 0, 30*time.Minute)
+	require.NoError(t, err)
+
+	owner, err := ReadLockOwner(fs, testLockPath)
+	require.NoError(t, err)
+	assert.Equal(t, os.Getpid(), owner.PID)
+	require.NoError(t, lock.Release())
+}
+
+func TestFileLock_Heartbeat(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	staleAfter := 300 * time.Millisecond
+
+	lock, err := AcquireLock(fs, testLockPath, 0, staleAfter)
+	require.NoError(t, err)
+
+	// 持有时间超过 staleAfter 的锁仍会被刷新，不会被其他进程当作遗留锁接管
+	old := time.Now().Add(-time.Hour)
+	require.NoError(t, fs.Chtimes(testLockPath, old, old))
+	assert.Eventually(t, func() bool {
+		info, err := fs.Stat(testLockPath)
+		return err == nil && time.Since(info.ModTime()) < staleAfter
+	}, 2*time.Second, 20*time.Millisecond)
+
+	time.Sleep(2 * staleAfter)
+	_, err = AcquireLock(fs, testLockPath, 0, staleAfter)
+	assert.ErrorContains(t, err, "timed out")
+
+	// 释放后停止刷新
+	require.NoError(t, lock.Release())
+	require.NoError(t, lock.Release())
+	exists, err := afero.Exists(fs, testLockPath)
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func TestAcquireLock_StaleDeadProcess(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("process liveness is not checked on windows")
+	}
+
+	cmd := exec.Command("true")
+	require.NoError(t, cmd.Run())
+
+	fs := afero.NewMemMapFs()
+	host, _ := os.Hostname()
+	content := fmt.Sprintf("%s\n%d\n%d\n", host, cmd.Process.Pid, time.Now().Unix())
+	require.NoError(t, afero.WriteFile(fs, testLockPath, []byte(content), 0644))
+
+	lock, err := AcquireLock(fs, testLockPath, 0, time.Hour)
+	require.NoError(t, err)
+	require.NoError(t, lock.Release())
+}
+
+func TestFileLock_ReleaseTakenOver(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	lock, err := AcquireLock(fs, testLockPath, 0, time.Hour)
+	require.NoError(t, err)
+
+	// 锁被其他进程接管后释放不会删除对方的锁文件
+	require.NoError(t, afero.WriteFile(fs, testLockPath, []byte("other-host\n1\n1700000000\n"), 0644))
+	require.NoError(t, lock.Release())
+
+	exists, err := afero.Exists(fs, testLockPath)
+	require.NoError(t, err)
+	assert.True(t, exists)
+}
+
+func TestFilesystemManager_LockVersion(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	paths := types.ConfigPathsFromRoot("/shared/vman")
+	manager := NewFilesystemManagerWithFs(fs, paths)
+
+	lock, err := manager.LockVersion("kubectl", "1.29.0")
+	require.NoError(t, err)
+
+	exists, err := afero.Exists(fs, paths.ToolVersionDir("kubectl", "1.29.0")+".lock")
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	// 锁文件不会被当作已安装的版本
+	versions, err := manager.GetToolVersions("kubectl")
+	require.NoError(t, err)
+	assert.Empty(t, versions)
+
+	require.NoError(t, lock.Release())
+}
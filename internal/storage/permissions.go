@@ -0,0 +1,157 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+
+	"github.com/spf13/afero"
+
+	"github.com/songzhibin97/vman/pkg/types"
+	"github.com/songzhibin97/vman/pkg/utils"
+)
+
+// PermissionKind 权限策略的类别
+type PermissionKind string
+
+const (
+	// PermissionExecutable 已安装的二进制和垫片，所有者必须可以执行
+	PermissionExecutable PermissionKind = "executable"
+	// PermissionPrivate 可能包含凭据的文件，组和其他用户不能访问
+	PermissionPrivate PermissionKind = "private"
+)
+
+// PermissionIssue 权限不符合策略的文件
+type PermissionIssue struct {
+	Path string
+	Kind PermissionKind
+	Mode os.FileMode
+	Want os.FileMode
+}
+
+// String 返回问题的描述
+func (i PermissionIssue) String() string {
+	return fmt.Sprintf("%s: %04o，应为 %04o", i.Path, i.Mode.Perm(), i.Want.Perm())
+}
+
+// CheckPermissions 检查vman管理的文件是否符合权限策略
+// 版本目录 bin 下的文件和垫片必须可执行，全局配置、工具定义和 privateFiles 中的文件不能被组和其他用户访问
+// Windows 不使用Unix权限位，始终返回空
+func CheckPermissions(fs afero.Fs, paths *types.ConfigPaths, privateFiles ...string) ([]PermissionIssue, error) {
+	if runtime.GOOS == "windows" {
+		return nil, nil
+	}
+
+	var issues []PermissionIssue
+	checkExecutable := func(path string, info os.FileInfo) {
+		if info.Mode().IsRegular() && info.Mode().Perm()&0100 == 0 {
+			issues = append(issues, PermissionIssue{
+				Path: path,
+				Kind: PermissionExecutable,
+				Mode: info.Mode().Perm(),
+				Want: info.Mode().Perm() | utils.ExecutableFileMode()&0111,
+			})
+		}
+	}
+	checkPrivate := func(path string, info os.FileInfo) {
+		if info.Mode().IsRegular() && info.Mode().Perm()&0077 != 0 {
+			issues = append(issues, PermissionIssue{
+				Path: path,
+				Kind: PermissionPrivate,
+				Mode: info.Mode().Perm(),
+				Want: utils.PrivateFileMode,
+			})
+		}
+	}
+
+	err := afero.Walk(fs, paths.VersionsDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) || os.IsPermission(err) {
+				return nil
+			}
+			return err
+		}
+		if filepath.Base(filepath.Dir(path)) == "bin" {
+			checkExecutable(path, info)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan versions directory: %w", err)
+	}
+
+	for _, dir := range []string{paths.ShimsDir, paths.ToolsDir} {
+		entries, err := afero.ReadDir(fs, dir)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to read directory %s: %w", dir, err)
+		}
+		for _, entry := range entries {
+			path := filepath.Join(dir, entry.Name())
+			if dir == paths.ShimsDir {
+				checkExecutable(path, entry)
+			} else {
+				checkPrivate(path, entry)
+			}
+		}
+	}
+
+	for _, path := range append([]string{paths.GlobalConfigFile}, privateFiles...) {
+		if info, err := fs.Stat(path); err == nil {
+			checkPrivate(path, info)
+		}
+	}
+
+	sort.Slice(issues, func(i, j int) bool {
+		return issues[i].Path < issues[j].Path
+	})
+	return issues, nil
+}
+
+// FixPermissions 将文件权限修正为策略要求的权限，返回所有失败的错误
+func FixPermissions(fs afero.Fs, issues []PermissionIssue) error {
+	var errs []error
+	for _, issue := range issues {
+		if err := fs.Chmod(issue.Path, issue.Want); err != nil {
+			errs = append(errs, fmt.Errorf("failed to fix permissions of %s: %w", issue.Path, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// FindForeignFiles 查找 dirs 中所有者不是 uid 的文件和目录，最多返回 limit 个（0 表示不限制）
+// 通常是用 sudo 运行 vman 时留下的 root 所有的文件，之后以原用户运行时无法修改
+// 不支持UID的平台和文件系统始终返回空
+func FindForeignFiles(fs afero.Fs, uid, limit int, dirs ...string) ([]string, error) {
+	var foreign []string
+	errLimit := errors.New("limit reached")
+	for _, dir := range dirs {
+		err := afero.Walk(fs, dir, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) || os.IsPermission(err) {
+					return nil
+				}
+				return err
+			}
+			if owner, ok := utils.FileOwner(info); ok && owner != uid {
+				foreign = append(foreign, path)
+				if limit > 0 && len(foreign) >= limit {
+					return errLimit
+				}
+			}
+			return nil
+		})
+		if errors.Is(err, errLimit) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan %s: %w", dir, err)
+		}
+	}
+	return foreign, nil
+}
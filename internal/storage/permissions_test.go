@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/songzhibin97/vman/pkg/types"
+)
+
+func TestCheckAndFixPermissions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Windows 不使用Unix权限位")
+	}
+
+	fs := afero.NewMemMapFs()
+	paths := types.ConfigPathsFromRoot("/home/user/.vman")
+	binary := filepath.Join(paths.VersionsDir, "kubectl", "1.29.0", "linux-amd64", "bin", "kubectl")
+	readme := filepath.Join(paths.VersionsDir, "kubectl", "1.29.0", "linux-amd64", "README.md")
+	shim := filepath.Join(paths.ShimsDir, "kubectl")
+	definition := filepath.Join(paths.ToolsDir, "kubectl.toml")
+	token := filepath.Join(paths.ConfigDir, "serve.token")
+
+	require.NoError(t, afero.WriteFile(fs, binary, []byte("bin"), 0644))
+	require.NoError(t, afero.WriteFile(fs, readme, []byte("doc"), 0644))
+	require.NoError(t, afero.WriteFile(fs, shim, []byte("#!/bin/sh"), 0755))
+	require.NoError(t, afero.WriteFile(fs, definition, []byte("name = \"kubectl\""), 0644))
+	require.NoError(t, afero.WriteFile(fs, paths.GlobalConfigFile, []byte("version: \"1.0\""), 0600))
+	require.NoError(t, afero.WriteFile(fs, token, []byte("secret"), 0640))
+
+	issues, err := CheckPermissions(fs, paths, token, filepath.Join(paths.ConfigDir, "missing"))
+	require.NoError(t, err)
+	require.Len(t, issues, 3)
+
+	byPath := make(map[string]PermissionIssue)
+	for _, issue := range issues {
+		byPath[issue.Path] = issue
+	}
+	assert.Equal(t, PermissionExecutable, byPath[binary].Kind)
+	assert.NotZero(t, byPath[binary].Want&0100)
+	assert.Equal(t, PermissionPrivate, byPath[definition].Kind)
+	assert.Equal(t, os.FileMode(0600), byPath[definition].Want)
+	assert.Equal(t, os.FileMode(0640), byPath[token].Mode)
+
+	require.NoError(t, FixPermissions(fs, issues))
+	issues, err = CheckPermissions(fs, paths, token)
+	require.NoError(t, err)
+	assert.Empty(t, issues)
+}
+
+func TestFindForeignFiles(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Windows 没有UID")
+	}
+
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "tools"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "config.yaml"), []byte("version: \"1.0\"\n"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "tools", "kubectl.toml"), nil, 0600))
+	fs := afero.NewOsFs()
+
+	foreign, err := FindForeignFiles(fs, os.Geteuid(), 0, dir, filepath.Join(dir, "missing"))
+	require.NoError(t, err)
+	assert.Empty(t, foreign)
+
+	// 以其他用户的身份检查时所有文件都不属于该用户
+	foreign, err = FindForeignFiles(fs, os.Geteuid()+1, 0, dir)
+	require.NoError(t, err)
+	assert.Len(t, foreign, 4)
+
+	foreign, err = FindForeignFiles(fs, os.Geteuid()+1, 2, dir)
+	require.NoError(t, err)
+	assert.Len(t, foreign, 2)
+}
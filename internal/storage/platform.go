@@ -0,0 +1,188 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/afero"
+
+	"github.com/songzhibin97/vman/pkg/types"
+)
+
+// GetForeignVersions 获取只为其他平台安装的版本及其平台列表
+// 版本目录位于多台机器共享的网络文件系统上时，其他机器安装的版本对当前平台不可用
+func (f *FilesystemManager) GetForeignVersions(tool string) (map[string][]string, error) {
+	foreign := make(map[string][]string)
+
+	versionsDirs := []string{f.paths.VersionsDir}
+	if f.system != nil {
+		versionsDirs = append(versionsDirs, f.system.VersionsDir)
+	}
+
+	for _, versionsDir := range versionsDirs {
+		toolDir := filepath.Join(versionsDir, tool)
+		entries, err := afero.ReadDir(f.fs, toolDir)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to read tool directory %s: %w", toolDir, err)
+		}
+
+		for _, entry := range entries {
+			if !entry.IsDir() || f.IsVersionInstalled(tool, entry.Name()) {
+				continue
+			}
+			platforms, err := f.foreignPlatforms(versionsDir, tool, entry.Name())
+			if err != nil {
+				return nil, err
+			}
+			for _, platform := range platforms {
+				if !containsVersion(foreign[entry.Name()], platform) {
+					foreign[entry.Name()] = append(foreign[entry.Name()], platform)
+				}
+			}
+		}
+	}
+
+	for version := range foreign {
+		sort.Strings(foreign[version])
+	}
+	return foreign, nil
+}
+
+// foreignPlatforms 列出版本目录中为其他平台安装的平台子目录
+func (f *FilesystemManager) foreignPlatforms(versionsDir, tool, version string) ([]string, error) {
+	versionDir := filepath.Join(versionsDir, tool, version)
+	entries, err := afero.ReadDir(f.fs, versionDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read version directory %s: %w", versionDir, err)
+	}
+
+	current := types.PlatformDirName()
+	var platforms []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() || name == current || !types.IsPlatformDirName(name) {
+			continue
+		}
+		// 其他平台的二进制文件可能带有扩展名（如 windows 下的 .exe），只检查 bin 目录
+		if exists, _ := afero.DirExists(f.fs, filepath.Join(versionDir, name, "bin")); exists {
+			platforms = append(platforms, name)
+		}
+	}
+	return platforms, nil
+}
+
+// migrateVersionsToPlatformDirs 将旧布局 versions/<tool>/<version>/ 中的内容移动到当前平台子目录
+// 只移动不属于平台子目录的内容，元数据中的路径已更新时不再修改，因此在任一位置中断后都可以重复执行，
+// 版本目录可能很大，该步骤不备份 versions
+func migrateVersionsToPlatformDirs(fs afero.Fs, paths *types.ConfigPaths) error {
+	toolEntries, err := afero.ReadDir(fs, paths.VersionsDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read versions directory %s: %w", paths.VersionsDir, err)
+	}
+
+	platform := types.PlatformDirName()
+	for _, toolEntry := range toolEntries {
+		if !toolEntry.IsDir() {
+			continue
+		}
+		toolDir := filepath.Join(paths.VersionsDir, toolEntry.Name())
+		versionEntries, err := afero.ReadDir(fs, toolDir)
+		if err != nil {
+			return fmt.Errorf("failed to read tool directory %s: %w", toolDir, err)
+		}
+
+		for _, versionEntry := range versionEntries {
+			if !versionEntry.IsDir() {
+				continue
+			}
+			versionDir := filepath.Join(toolDir, versionEntry.Name())
+			entries, err := afero.ReadDir(fs, versionDir)
+			if err != nil {
+				return fmt.Errorf("failed to read version directory %s: %w", versionDir, err)
+			}
+			loose := looseEntries(entries)
+			target := filepath.Join(versionDir, platform)
+			if len(loose) == 0 {
+				// 上次迁移移动内容后、更新元数据前中断时补上元数据的更新
+				if err := rewriteMetadataPaths(fs, versionDir, target); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if err := fs.MkdirAll(target, 0755); err != nil {
+				return fmt.Errorf("failed to create %s: %w", target, err)
+			}
+			for _, entry := range loose {
+				from := filepath.Join(versionDir, entry.Name())
+				if err := fs.Rename(from, filepath.Join(target, entry.Name())); err != nil {
+					return fmt.Errorf("failed to move %s: %w", from, err)
+				}
+			}
+			if err := rewriteMetadataPaths(fs, versionDir, target); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// looseEntries 返回不属于任何平台子目录的目录项
+func looseEntries(entries []os.FileInfo) []os.FileInfo {
+	var loose []os.FileInfo
+	for _, entry := range entries {
+		if entry.IsDir() && types.IsPlatformDirName(entry.Name()) {
+			continue
+		}
+		loose = append(loose, entry)
+	}
+	return loose
+}
+
+// rewriteMetadataPaths 将元数据中记录的安装路径更新为平台子目录
+func rewriteMetadataPaths(fs afero.Fs, oldDir, newDir string) error {
+	metadataPath := filepath.Join(newDir, "metadata.json")
+	data, err := afero.ReadFile(fs, metadataPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read metadata file %s: %w", metadataPath, err)
+	}
+
+	var metadata types.VersionMetadata
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		// 无法解析的元数据保持原样，不影响版本本身的迁移
+		return nil
+	}
+	if metadata.InstallPath != filepath.Clean(oldDir) {
+		return nil
+	}
+
+	metadata.InstallPath = newDir
+	if rel, err := filepath.Rel(oldDir, metadata.BinaryPath); err == nil && !strings.HasPrefix(rel, "..") {
+		metadata.BinaryPath = filepath.Join(newDir, rel)
+	}
+
+	data, err = json.Marshal(&metadata)
+	if err != nil {
+		return fmt.Errorf("failed to marshal metadata: %w", err)
+	}
+	if err := afero.WriteFile(fs, metadataPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write metadata file %s: %w", metadataPath, err)
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package storage
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/songzhibin97/vman/pkg/types"
+)
+
+// otherPlatform 返回一个不同于当前平台的平台目录名
+func otherPlatform() string {
+	if types.PlatformDirName() == "darwin-arm64" {
+		return "linux-amd64"
+	}
+	return "darwin-arm64"
+}
+
+func TestMigrateVersionsToPlatformDirs(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	paths := types.ConfigPathsFromRoot("/home/test/.config/vman")
+
+	legacyDir := filepath.Join(paths.VersionsDir, "kubectl", "1.29.0")
+	require.NoError(t, afero.WriteFile(fs, filepath.Join(legacyDir, "bin", "kubectl"), []byte("bin"), 0755))
+	metadata := fmt.Sprintf(`{"version":"1.29.0","tool_name":"kubectl","install_path":%q,"binary_path":%q}`,
+		legacyDir, filepath.Join(legacyDir, "bin", "kubectl"))
+	require.NoError(t, afero.WriteFile(fs, filepath.Join(legacyDir, "metadata.json"), []byte(metadata), 0644))
+
+	// 其他机器已按新布局安装的内容保持不变
+	foreignBinary := filepath.Join(legacyDir, otherPlatform(), "bin", "kubectl")
+	require.NoError(t, afero.WriteFile(fs, foreignBinary, []byte("foreign"), 0755))
+
+	require.NoError(t, migrateVersionsToPlatformDirs(fs, paths))
+
+	data, err := afero.ReadFile(fs, filepath.Join(paths.ToolVersionDir("kubectl", "1.29.0"), "bin", "kubectl"))
+	require.NoError(t, err)
+	assert.Equal(t, "bin", string(data))
+
+	manager := NewFilesystemManagerWithFs(fs, paths)
+	loaded, err := manager.LoadVersionMetadata("kubectl", "1.29.0")
+	require.NoError(t, err)
+	assert.Equal(t, paths.ToolVersionDir("kubectl", "1.29.0"), loaded.InstallPath)
+	assert.Equal(t, manager.GetBinaryPath("kubectl", "1.29.0"), loaded.BinaryPath)
+
+	exists, err := afero.Exists(fs, filepath.Join(legacyDir, "bin"))
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	data, err = afero.ReadFile(fs, foreignBinary)
+	require.NoError(t, err)
+	assert.Equal(t, "foreign", string(data))
+
+	// 重复执行不会改变已迁移的内容
+	require.NoError(t, migrateVersionsToPlatformDirs(fs, paths))
+	assert.True(t, manager.IsVersionInstalled("kubectl", "1.29.0"))
+}
+
+func TestMigrateVersionsToPlatformDirs_Resume(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	paths := types.ConfigPathsFromRoot("/home/test/.config/vman")
+
+	// 上次迁移已移动全部内容，但在更新元数据前中断
+	versionDir := filepath.Join(paths.VersionsDir, "kubectl", "1.29.0")
+	target := filepath.Join(versionDir, types.PlatformDirName())
+	require.NoError(t, afero.WriteFile(fs, filepath.Join(target, "bin", "kubectl"), []byte("bin"), 0755))
+	metadata := fmt.Sprintf(`{"version":"1.29.0","tool_name":"kubectl","install_path":%q,"binary_path":%q}`,
+		versionDir, filepath.Join(versionDir, "bin", "kubectl"))
+	require.NoError(t, afero.WriteFile(fs, filepath.Join(target, "metadata.json"), []byte(metadata), 0644))
+
+	require.NoError(t, migrateVersionsToPlatformDirs(fs, paths))
+
+	manager := NewFilesystemManagerWithFs(fs, paths)
+	loaded, err := manager.LoadVersionMetadata("kubectl", "1.29.0")
+	require.NoError(t, err)
+	assert.Equal(t, target, loaded.InstallPath)
+	assert.Equal(t, filepath.Join(target, "bin", "kubectl"), loaded.BinaryPath)
+}
+
+func TestFilesystemManager_GetForeignVersions(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	paths := types.ConfigPathsFromRoot("/shared/vman")
+	manager := NewFilesystemManagerWithFs(fs, paths)
+
+	install := func(version, platform string) {
+		binary := filepath.Join(paths.VersionsDir, "terraform", version, platform, "bin", "terraform")
+		require.NoError(t, afero.WriteFile(fs, binary, []byte("bin"), 0755))
+	}
+	install("1.6.0", types.PlatformDirName())
+	install("1.6.0", otherPlatform())
+	install("1.7.0", otherPlatform())
+
+	versions, err := manager.GetToolVersions("terraform")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1.6.0"}, versions)
+	assert.False(t, manager.IsVersionInstalled("terraform", "1.7.0"))
+
+	foreign, err := manager.GetForeignVersions("terraform")
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]string{"1.7.0": {otherPlatform()}}, foreign)
+
+	// 删除当前平台的安装不影响其他平台
+	require.NoError(t, manager.RemoveVersionDir("terraform", "1.6.0"))
+	exists, err := afero.Exists(fs, filepath.Join(paths.VersionsDir, "terraform", "1.6.0", otherPlatform(), "bin", "terraform"))
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	foreign, err = manager.GetForeignVersions("terraform")
+	require.NoError(t, err)
+	assert.Len(t, foreign, 2)
+}
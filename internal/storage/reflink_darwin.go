@@ -0,0 +1,10 @@
+//go:build darwin
+
+package storage
+
+import "golang.org/x/sys/unix"
+
+// cloneFile 使用 clonefile 创建与 src 共享数据块的 dst，APFS 以外的文件系统返回错误
+func cloneFile(src, dst string) error {
+	return unix.Clonefile(src, dst, unix.CLONE_NOFOLLOW)
+}
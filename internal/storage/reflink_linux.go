@@ -0,0 +1,29 @@
+//go:build linux
+
+package storage
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// cloneFile 使用 FICLONE 创建与 src 共享数据块的 dst，文件系统（如 btrfs、XFS）不支持时返回错误
+func cloneFile(src, dst string) error {
+	source, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	target, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	if err := unix.IoctlFileClone(int(target.Fd()), int(source.Fd())); err != nil {
+		target.Close()
+		os.Remove(dst)
+		return err
+	}
+	return target.Close()
+}
@@ -0,0 +1,10 @@
+//go:build !linux && !darwin && !windows
+
+package storage
+
+import "errors"
+
+// cloneFile 当前系统不支持写时复制克隆
+func cloneFile(src, dst string) error {
+	return errors.New("reflink is not supported on this platform")
+}
@@ -0,0 +1,50 @@
+package storage
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/spf13/afero"
+)
+
+// GetSystemVersionsDir 获取系统级共享存储的版本目录，未配置时返回空字符串
+func (f *FilesystemManager) GetSystemVersionsDir() string {
+	if f.system == nil {
+		return ""
+	}
+	return f.system.VersionsDir
+}
+
+// IsSystemVersion 检查版本是否来自系统级共享存储
+// 用户存储中存在同一版本时以用户存储为准
+func (f *FilesystemManager) IsSystemVersion(tool, version string) bool {
+	if f.system == nil {
+		return false
+	}
+	return f.GetToolVersionPath(tool, version) != f.userVersionPath(tool, version)
+}
+
+// CheckWritable 检查当前用户能否在目录中创建文件，目录不存在时会尝试创建
+func CheckWritable(fs afero.Fs, dir string) error {
+	if err := fs.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("cannot create %s: %w", dir, err)
+	}
+
+	probe, err := afero.TempFile(fs, dir, ".vman-write-check-")
+	if err != nil {
+		return fmt.Errorf("no write permission for %s: %w", dir, err)
+	}
+	name := probe.Name()
+	probe.Close()
+	return fs.Remove(filepath.Clean(name))
+}
+
+// containsVersion 检查版本列表中是否包含指定版本
+func containsVersion(versions []string, version string) bool {
+	for _, v := range versions {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,101 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/songzhibin97/vman/pkg/types"
+)
+
+func TestFilesystemManager_SystemStore(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	userPaths := types.ConfigPathsFromRoot("/home/test/.config/vman")
+	systemPaths := types.ConfigPathsFromRoot("/opt/vman")
+
+	install := func(versionsDir, tool, version string) {
+		binary := filepath.Join(versionsDir, tool, version, types.PlatformDirName(), "bin", tool)
+		require.NoError(t, afero.WriteFile(fs, binary, []byte("bin"), 0755))
+	}
+	install(userPaths.VersionsDir, "kubectl", "1.29.0")
+	install(systemPaths.VersionsDir, "kubectl", "1.28.0")
+	install(systemPaths.VersionsDir, "kubectl", "1.29.0")
+	install(systemPaths.VersionsDir, "helm", "3.14.0")
+
+	manager := NewFilesystemManagerWithSystemStore(fs, userPaths, systemPaths)
+
+	t.Run("LookupOrder", func(t *testing.T) {
+		assert.Equal(t, userPaths.ToolVersionDir("kubectl", "1.29.0"), manager.GetToolVersionPath("kubectl", "1.29.0"))
+		assert.Equal(t, systemPaths.ToolVersionDir("kubectl", "1.28.0"), manager.GetToolVersionPath("kubectl", "1.28.0"))
+		assert.Equal(t, filepath.Join(systemPaths.ToolVersionDir("helm", "3.14.0"), "bin", "helm"), manager.GetBinaryPath("helm", "3.14.0"))
+
+		// 两处都没有时指向用户存储
+		assert.Equal(t, userPaths.ToolVersionDir("kubectl", "1.30.0"), manager.GetToolVersionPath("kubectl", "1.30.0"))
+	})
+
+	t.Run("IsSystemVersion", func(t *testing.T) {
+		assert.True(t, manager.IsSystemVersion("kubectl", "1.28.0"))
+		assert.False(t, manager.IsSystemVersion("kubectl", "1.29.0"))
+		assert.False(t, manager.IsSystemVersion("kubectl", "1.30.0"))
+		assert.True(t, manager.IsVersionInstalled("helm", "3.14.0"))
+		assert.Equal(t, systemPaths.VersionsDir, manager.GetSystemVersionsDir())
+	})
+
+	t.Run("GetToolVersions", func(t *testing.T) {
+		versions, err := manager.GetToolVersions("kubectl")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"1.28.0", "1.29.0"}, versions)
+	})
+
+	t.Run("RemoveSystemVersion", func(t *testing.T) {
+		err := manager.RemoveVersionDir("kubectl", "1.28.0")
+		assert.ErrorContains(t, err, "read-only store")
+		assert.True(t, manager.IsVersionInstalled("kubectl", "1.28.0"))
+	})
+
+	t.Run("MarkSystemVersionUsed", func(t *testing.T) {
+		require.NoError(t, manager.MarkVersionUsed("kubectl", "1.28.0"))
+		exists, err := afero.Exists(fs, filepath.Join(systemPaths.ToolVersionDir("kubectl", "1.28.0"), LastUsedMarkerFile))
+		require.NoError(t, err)
+		assert.False(t, exists)
+	})
+
+	t.Run("WithoutSystemStore", func(t *testing.T) {
+		userOnly := NewFilesystemManagerWithFs(fs, userPaths)
+		assert.Empty(t, userOnly.GetSystemVersionsDir())
+		assert.False(t, userOnly.IsVersionInstalled("kubectl", "1.28.0"))
+	})
+}
+
+func TestFilesystemManager_SystemInstall(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	userPaths := types.ConfigPathsFromRoot("/home/test/.config/vman")
+	systemPaths := types.ConfigPathsFromRoot("/opt/vman")
+	binary := filepath.Join(userPaths.ToolVersionDir("kubectl", "1.29.0"), "bin", "kubectl")
+	require.NoError(t, afero.WriteFile(fs, binary, []byte("bin"), 0755))
+
+	// 用户已安装的版本不算作系统存储中已安装，安装目标为系统存储
+	manager := NewFilesystemManagerForSystemInstall(fs, systemPaths, userPaths)
+	assert.False(t, manager.IsVersionInstalled("kubectl", "1.29.0"))
+	assert.Equal(t, systemPaths.ToolVersionDir("kubectl", "1.29.0"), manager.GetToolVersionPath("kubectl", "1.29.0"))
+	assert.False(t, manager.IsSystemVersion("kubectl", "1.29.0"))
+
+	// 列出已安装版本时仍包含用户存储中的版本
+	versions, err := manager.GetToolVersions("kubectl")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1.29.0"}, versions)
+}
+
+func TestCheckWritable(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, CheckWritable(fs, "/opt/vman/versions"))
+
+	entries, err := afero.ReadDir(fs, "/opt/vman/versions")
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	assert.Error(t, CheckWritable(afero.NewReadOnlyFs(afero.NewMemMapFs()), "/opt/vman/versions"))
+}
@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+const (
+	// LastUsedMarkerFile 版本目录中记录最后使用时间的标记文件
+	LastUsedMarkerFile = ".last-used"
+
+	// LastUsedResolution 最后使用时间的记录精度，同一周期内只更新一次标记文件
+	LastUsedResolution = 24 * time.Hour
+)
+
+// MarkUsed 更新版本目录中的最后使用标记
+// 标记文件的修改时间即为最后使用时间，距离上次更新不足 LastUsedResolution 时不做任何写入
+func MarkUsed(fs afero.Fs, versionPath string, now time.Time) error {
+	markerPath := filepath.Join(versionPath, LastUsedMarkerFile)
+
+	info, err := fs.Stat(markerPath)
+	if err == nil {
+		if now.Sub(info.ModTime()) < LastUsedResolution {
+			return nil
+		}
+		return fs.Chtimes(markerPath, now, now)
+	}
+	if !os.IsNotExist(err) {
+		return fmt.Errorf("failed to stat last used marker %s: %w", markerPath, err)
+	}
+
+	file, err := fs.Create(markerPath)
+	if err != nil {
+		return fmt.Errorf("failed to create last used marker %s: %w", markerPath, err)
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return fs.Chtimes(markerPath, now, now)
+}
+
+// LastUsed 读取版本目录中的最后使用时间，从未使用过时返回零值
+func LastUsed(fs afero.Fs, versionPath string) (time.Time, error) {
+	markerPath := filepath.Join(versionPath, LastUsedMarkerFile)
+
+	info, err := fs.Stat(markerPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return time.Time{}, nil
+		}
+		return time.Time{}, fmt.Errorf("failed to stat last used marker %s: %w", markerPath, err)
+	}
+
+	return info.ModTime(), nil
+}
+
+// MarkVersionUsed 记录工具版本被使用
+func (f *FilesystemManager) MarkVersionUsed(tool, version string) error {
+	// 系统级共享存储对普通用户只读，不记录使用时间
+	if f.IsSystemVersion(tool, version) {
+		return nil
+	}
+	return MarkUsed(f.fs, f.GetToolVersionPath(tool, version), time.Now())
+}
+
+// GetLastUsed 获取工具版本的最后使用时间，从未使用过时返回零值
+func (f *FilesystemManager) GetLastUsed(tool, version string) (time.Time, error) {
+	return LastUsed(f.fs, f.GetToolVersionPath(tool, version))
+}
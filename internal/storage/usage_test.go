@@ -0,0 +1,54 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarkUsed(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	versionPath := "/vman/versions/kubectl/1.29.0"
+	require.NoError(t, fs.MkdirAll(versionPath, 0755))
+
+	t.Run("NeverUsed", func(t *testing.T) {
+		lastUsed, err := LastUsed(fs, versionPath)
+		require.NoError(t, err)
+		assert.True(t, lastUsed.IsZero())
+	})
+
+	first := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	t.Run("CreatesMarker", func(t *testing.T) {
+		require.NoError(t, MarkUsed(fs, versionPath, first))
+
+		exists, err := afero.Exists(fs, filepath.Join(versionPath, LastUsedMarkerFile))
+		require.NoError(t, err)
+		assert.True(t, exists)
+
+		lastUsed, err := LastUsed(fs, versionPath)
+		require.NoError(t, err)
+		assert.True(t, first.Equal(lastUsed))
+	})
+
+	t.Run("SkipsWithinResolution", func(t *testing.T) {
+		require.NoError(t, MarkUsed(fs, versionPath, first.Add(time.Hour)))
+
+		lastUsed, err := LastUsed(fs, versionPath)
+		require.NoError(t, err)
+		assert.True(t, first.Equal(lastUsed))
+	})
+
+	t.Run("UpdatesAfterResolution", func(t *testing.T) {
+		later := first.Add(LastUsedResolution + time.Minute)
+		require.NoError(t, MarkUsed(fs, versionPath, later))
+
+		lastUsed, err := LastUsed(fs, versionPath)
+		require.NoError(t, err)
+		assert.True(t, later.Equal(lastUsed))
+	})
+}
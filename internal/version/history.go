@@ -0,0 +1,127 @@
+package version
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+// HistoryFile 配置目录中的版本切换历史文件名
+const HistoryFile = "history.jsonl"
+
+// 版本切换的范围
+const (
+	// HistoryScopeGlobal 全局版本（config.yaml 中的 global_versions）
+	HistoryScopeGlobal = "global"
+	// HistoryScopeProject 项目版本（.vman.yaml 或 .vman-version）
+	HistoryScopeProject = "project"
+)
+
+// HistoryEntry 一次版本切换记录
+type HistoryEntry struct {
+	ID      int64     `json:"id"`
+	Time    time.Time `json:"time"`
+	Tool    string    `json:"tool"`
+	Scope   string    `json:"scope"`
+	Path    string    `json:"path,omitempty"` // 项目版本所在的配置文件
+	Old     string    `json:"old,omitempty"`  // 切换前的版本，为空表示之前没有设置
+	New     string    `json:"new,omitempty"`  // 切换后的版本，为空表示取消设置
+	Command string    `json:"command,omitempty"`
+	Undoes  int64     `json:"undoes,omitempty"` // 撤销的记录ID
+}
+
+// History 版本切换历史，每行一条JSON记录，只追加不修改
+type History struct {
+	fs   afero.Fs
+	path string
+}
+
+// NewHistory 创建版本切换历史，path 为历史文件路径
+func NewHistory(fs afero.Fs, path string) *History {
+	return &History{fs: fs, path: path}
+}
+
+// Append 追加一条记录，未设置的ID和时间使用当前时间
+func (h *History) Append(entry *HistoryEntry) error {
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+	if entry.ID == 0 {
+		entry.ID = entry.Time.UnixNano()
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal history entry: %w", err)
+	}
+	if err := h.fs.MkdirAll(filepath.Dir(h.path), 0755); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+	file, err := h.fs.OpenFile(h.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open history file: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write history entry: %w", err)
+	}
+	return nil
+}
+
+// Entries 按时间顺序返回所有记录，无法解析的行会被跳过
+func (h *History) Entries() ([]*HistoryEntry, error) {
+	file, err := h.fs.Open(h.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to open history file: %w", err)
+	}
+	defer file.Close()
+
+	var entries []*HistoryEntry
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry HistoryEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		entries = append(entries, &entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read history file: %w", err)
+	}
+	return entries, nil
+}
+
+// LastUndoable 返回最近一条满足 match 且尚未撤销的切换记录，撤销记录本身不会被再次撤销
+func (h *History) LastUndoable(match func(*HistoryEntry) bool) (*HistoryEntry, error) {
+	entries, err := h.Entries()
+	if err != nil {
+		return nil, err
+	}
+
+	undone := make(map[int64]bool)
+	for _, entry := range entries {
+		if entry.Undoes != 0 {
+			undone[entry.Undoes] = true
+		}
+	}
+
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry := entries[i]
+		if entry.Undoes != 0 || undone[entry.ID] {
+			continue
+		}
+		if match == nil || match(entry) {
+			return entry, nil
+		}
+	}
+	return nil, nil
+}
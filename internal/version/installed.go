@@ -0,0 +1,118 @@
+package version
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/songzhibin97/vman/pkg/types"
+)
+
+// InstalledVersionsDrift 配置中记录的已安装版本与versions目录的差异
+type InstalledVersionsDrift struct {
+	Tool         string   `json:"tool"`
+	Untracked    []string `json:"untracked,omitempty"`     // 已安装但配置中没有记录
+	Missing      []string `json:"missing,omitempty"`       // 配置中有记录但未安装
+	StaleCurrent string   `json:"stale_current,omitempty"` // tools.<tool>.current_version 指向未安装的版本
+	StaleGlobal  string   `json:"stale_global,omitempty"`  // global_versions 指向未安装的版本
+	Reconciled   bool     `json:"reconciled,omitempty"`    // 配置已按磁盘更新
+}
+
+// HasDrift 是否存在差异
+func (d *InstalledVersionsDrift) HasDrift() bool {
+	return len(d.Untracked) > 0 || len(d.Missing) > 0 || d.StaleCurrent != "" || d.StaleGlobal != ""
+}
+
+// VerifyInstalledVersions 比较配置中记录的已安装版本与versions目录，不修改配置
+func (m *DefaultManager) VerifyInstalledVersions(tool string) (*InstalledVersionsDrift, error) {
+	globalConfig, err := m.configManager.LoadGlobal()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load global config: %w", err)
+	}
+
+	return m.diffInstalledVersions(tool, globalConfig)
+}
+
+// ReconcileInstalledVersions 以versions目录为准更新配置中记录的已安装版本
+// global_versions 由用户选择，不会被修改，只在结果中报告
+func (m *DefaultManager) ReconcileInstalledVersions(tool string) (*InstalledVersionsDrift, error) {
+	globalConfig, err := m.configManager.LoadGlobal()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load global config: %w", err)
+	}
+
+	drift, err := m.diffInstalledVersions(tool, globalConfig)
+	if err != nil {
+		return nil, err
+	}
+	if len(drift.Untracked) == 0 && len(drift.Missing) == 0 && drift.StaleCurrent == "" {
+		return drift, nil
+	}
+
+	installed, err := m.GetInstalledVersions(tool)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(installed)
+
+	toolInfo := globalConfig.Tools[tool]
+	toolInfo.InstalledVersions = installed
+	if drift.StaleCurrent != "" {
+		toolInfo.CurrentVersion = ""
+	}
+
+	if globalConfig.Tools == nil {
+		globalConfig.Tools = make(map[string]types.ToolInfo)
+	}
+	if len(toolInfo.InstalledVersions) == 0 && toolInfo.CurrentVersion == "" {
+		delete(globalConfig.Tools, tool)
+	} else {
+		globalConfig.Tools[tool] = toolInfo
+	}
+
+	if err := m.configManager.SaveGlobal(globalConfig); err != nil {
+		return nil, fmt.Errorf("failed to save global config: %w", err)
+	}
+
+	m.logger.Debugf("Reconciled installed versions of %s: %v", tool, installed)
+	drift.Reconciled = true
+	return drift, nil
+}
+
+// diffInstalledVersions 计算配置与磁盘的差异
+func (m *DefaultManager) diffInstalledVersions(tool string, globalConfig *types.GlobalConfig) (*InstalledVersionsDrift, error) {
+	installed, err := m.GetInstalledVersions(tool)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list installed versions: %w", err)
+	}
+
+	drift := &InstalledVersionsDrift{Tool: tool}
+	toolInfo := globalConfig.Tools[tool]
+
+	onDisk := make(map[string]bool, len(installed))
+	for _, v := range installed {
+		onDisk[v] = true
+	}
+	recorded := make(map[string]bool, len(toolInfo.InstalledVersions))
+	for _, v := range toolInfo.InstalledVersions {
+		recorded[v] = true
+		if !onDisk[v] {
+			drift.Missing = append(drift.Missing, v)
+		}
+	}
+	for _, v := range installed {
+		if !recorded[v] {
+			drift.Untracked = append(drift.Untracked, v)
+		}
+	}
+	sort.Strings(drift.Missing)
+	sort.Strings(drift.Untracked)
+
+	if v := toolInfo.CurrentVersion; v != "" && v != types.SystemVersion && !onDisk[v] {
+		drift.StaleCurrent = v
+	}
+	if v := globalConfig.GlobalVersions[tool]; v != "" && v != types.SystemVersion && !onDisk[v] {
+		drift.StaleGlobal = v
+	}
+
+	return drift, nil
+}
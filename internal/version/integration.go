@@ -37,6 +37,7 @@ type DownloadOptions struct {
 	TempDir      string
 	KeepDownload bool
 	Headers      map[string]string
+	DeltaFrom    string // 使用从该版本的安装包升级的二进制差分补丁
 }
 
 // NewIntegratedManager 创建集成版本管理器
@@ -71,6 +72,11 @@ func NewIntegratedManagerWithFs(storageManager storage.Manager, configManager co
 
 // InstallVersion 自动下载并安装工具版本
 func (im *IntegratedManager) InstallVersion(tool, version string) error {
+	return im.installVersion(tool, version, &DownloadOptions{})
+}
+
+// installVersion 使用下载选项下载并安装工具版本
+func (im *IntegratedManager) installVersion(tool, version string, options *DownloadOptions) error {
 	im.logger.Debugf("安装版本 %s@%s", tool, version)
 
 	// 检查版本是否已安装
@@ -81,14 +87,15 @@ func (im *IntegratedManager) InstallVersion(tool, version string) error {
 
 	// 使用下载管理器下载并安装
 	ctx := context.Background()
-	options := &DownloadOptions{
-		Force: false,
-	}
-
 	if err := im.downloadManager.Download(ctx, tool, version, options); err != nil {
 		return fmt.Errorf("下载安装失败: %w", err)
 	}
 
+	// 配置中的已安装版本记录以磁盘为准
+	if _, err := im.ReconcileInstalledVersions(tool); err != nil {
+		im.logger.Warnf("更新配置中的已安装版本失败: %v", err)
+	}
+
 	im.logger.Infof("成功安装 %s@%s", tool, version)
 	return nil
 }
@@ -119,12 +126,22 @@ func (im *IntegratedManager) InstallVersionWithProgress(tool, version string, pr
 		return fmt.Errorf("下载安装失败: %w", err)
 	}
 
+	// 配置中的已安装版本记录以磁盘为准
+	if _, err := im.ReconcileInstalledVersions(tool); err != nil {
+		im.logger.Warnf("更新配置中的已安装版本失败: %v", err)
+	}
+
 	im.logger.Infof("成功安装 %s@%s", tool, version)
 	return nil
 }
 
 // InstallLatestVersion 安装最新版本
 func (im *IntegratedManager) InstallLatestVersion(tool string) (string, error) {
+	return im.installLatestVersion(tool, &DownloadOptions{})
+}
+
+// installLatestVersion 使用下载选项安装最新版本
+func (im *IntegratedManager) installLatestVersion(tool string, options *DownloadOptions) (string, error) {
 	im.logger.Debugf("安装最新版本: %s", tool)
 
 	// 搜索可用版本
@@ -152,7 +169,7 @@ func (im *IntegratedManager) InstallLatestVersion(tool string) (string, error) {
 	}
 
 	// 安装版本
-	if err := im.InstallVersion(tool, latestVersion); err != nil {
+	if err := im.installVersion(tool, latestVersion, options); err != nil {
 		return "", fmt.Errorf("安装版本失败: %w", err)
 	}
 
@@ -176,6 +193,17 @@ func (im *IntegratedManager) IsVersionAvailable(tool, version string) bool {
 
 // UpdateTool 更新工具到最新版本
 func (im *IntegratedManager) UpdateTool(tool string) (string, error) {
+	return im.updateTool(tool, false)
+}
+
+// UpdateToolWithDelta 更新工具到最新版本，优先使用从当前版本安装包升级的二进制差分补丁
+// 没有补丁或旧安装包时下载完整安装包，新版本的安装包保留在下载缓存中供下次增量升级
+func (im *IntegratedManager) UpdateToolWithDelta(tool string) (string, error) {
+	return im.updateTool(tool, true)
+}
+
+// updateTool 更新工具到最新版本，delta 为 true 时使用二进制差分补丁
+func (im *IntegratedManager) updateTool(tool string, delta bool) (string, error) {
 	im.logger.Debugf("更新工具: %s", tool)
 
 	// 获取当前版本
@@ -185,8 +213,13 @@ func (im *IntegratedManager) UpdateTool(tool string) (string, error) {
 		return im.InstallLatestVersion(tool)
 	}
 
+	options := &DownloadOptions{}
+	if delta {
+		options.DeltaFrom = currentVersion
+	}
+
 	// 获取最新版本
-	latestVersion, err := im.InstallLatestVersion(tool)
+	latestVersion, err := im.installLatestVersion(tool, options)
 	if err != nil {
 		return "", fmt.Errorf("获取最新版本失败: %w", err)
 	}
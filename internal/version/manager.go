@@ -44,9 +44,15 @@ type Manager interface {
 	// IsVersionInstalled 检查版本是否已安装
 	IsVersionInstalled(tool, version string) bool
 
-	// GetInstalledVersions 获取已安装版本列表
+	// GetInstalledVersions 获取已安装版本列表（扫描versions目录）
 	GetInstalledVersions(tool string) ([]string, error)
 
+	// VerifyInstalledVersions 比较配置中记录的已安装版本与versions目录
+	VerifyInstalledVersions(tool string) (*InstalledVersionsDrift, error)
+
+	// ReconcileInstalledVersions 以versions目录为准更新配置中记录的已安装版本
+	ReconcileInstalledVersions(tool string) (*InstalledVersionsDrift, error)
+
 	// ValidateVersion 验证版本格式
 	ValidateVersion(version string) error
 
@@ -10,6 +10,7 @@ import (
 
 	"github.com/Masterminds/semver/v3"
 	"github.com/songzhibin97/vman/pkg/types"
+	"github.com/songzhibin97/vman/pkg/utils"
 	"github.com/spf13/afero"
 )
 
@@ -17,7 +18,7 @@ import (
 func (m *DefaultManager) SetGlobalVersion(tool, version string) error {
 	m.logger.Debugf("Setting global version %s@%s", tool, version)
 
-	if !m.IsVersionInstalled(tool, version) {
+	if version != types.SystemVersion && !m.IsVersionInstalled(tool, version) {
 		return fmt.Errorf("version %s@%s is not installed", tool, version)
 	}
 
@@ -37,7 +38,7 @@ func (m *DefaultManager) SetLocalVersion(tool, version string) error {
 func (m *DefaultManager) SetProjectVersion(tool, version, projectPath string) error {
 	m.logger.Debugf("Setting project version %s@%s for project %s", tool, version, projectPath)
 
-	if !m.IsVersionInstalled(tool, version) {
+	if version != types.SystemVersion && !m.IsVersionInstalled(tool, version) {
 		return fmt.Errorf("version %s@%s is not installed", tool, version)
 	}
 
@@ -156,7 +157,34 @@ func (m *DefaultManager) GetVersionMetadata(tool, version string) (*types.Versio
 
 // ListAllTools 列出所有已安装的工具
 func (m *DefaultManager) ListAllTools() ([]string, error) {
-	versionsDir := m.storageManager.GetVersionsDir()
+	tools, err := m.listToolsIn(m.storageManager.GetVersionsDir())
+	if err != nil {
+		return nil, err
+	}
+
+	// 系统级共享存储中的工具同样可用
+	if systemDir := m.storageManager.GetSystemVersionsDir(); systemDir != "" {
+		systemTools, err := m.listToolsIn(systemDir)
+		if err != nil {
+			return nil, err
+		}
+		seen := make(map[string]bool, len(tools))
+		for _, tool := range tools {
+			seen[tool] = true
+		}
+		for _, tool := range systemTools {
+			if !seen[tool] {
+				tools = append(tools, tool)
+			}
+		}
+	}
+
+	sort.Strings(tools)
+	return tools, nil
+}
+
+// listToolsIn 列出版本目录中至少安装了一个有效版本的工具
+func (m *DefaultManager) listToolsIn(versionsDir string) ([]string, error) {
 	entries, err := afero.ReadDir(m.fs, versionsDir)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -165,7 +193,7 @@ func (m *DefaultManager) ListAllTools() ([]string, error) {
 		return nil, fmt.Errorf("failed to read versions directory: %w", err)
 	}
 
-	var tools []string
+	tools := []string{}
 	for _, entry := range entries {
 		if entry.IsDir() {
 			toolName := entry.Name()
@@ -195,7 +223,6 @@ func (m *DefaultManager) ListAllTools() ([]string, error) {
 		}
 	}
 
-	sort.Strings(tools)
 	return tools, nil
 }
 
@@ -226,8 +253,8 @@ func (m *DefaultManager) copyBinary(sourcePath, targetPath string) error {
 		return fmt.Errorf("failed to copy file content: %w", err)
 	}
 
-	// 设置可执行权限
-	if err := m.fs.Chmod(targetPath, 0755); err != nil {
+	// 设置可执行权限，Chmod 不受umask影响，按umask收紧
+	if err := m.fs.Chmod(targetPath, utils.ExecutableFileMode()); err != nil {
 		return fmt.Errorf("failed to set executable permissions: %w", err)
 	}
 
@@ -235,33 +262,10 @@ func (m *DefaultManager) copyBinary(sourcePath, targetPath string) error {
 }
 
 // updateInstalledVersions 更新配置中的已安装版本
+// 以versions目录为准重新生成记录，同时修正之前遗留的差异
 func (m *DefaultManager) updateInstalledVersions(tool, version string) error {
-	config, err := m.configManager.LoadGlobal()
-	if err != nil {
-		return err
-	}
-
-	if config.Tools == nil {
-		config.Tools = make(map[string]types.ToolInfo)
-	}
-
-	toolInfo := config.Tools[tool]
-
-	// 添加到已安装版本列表（如果不存在）
-	found := false
-	for _, v := range toolInfo.InstalledVersions {
-		if v == version {
-			found = true
-			break
-		}
-	}
-	if !found {
-		toolInfo.InstalledVersions = append(toolInfo.InstalledVersions, version)
-		sort.Strings(toolInfo.InstalledVersions)
-	}
-
-	config.Tools[tool] = toolInfo
-	return m.configManager.SaveGlobal(config)
+	_, err := m.ReconcileInstalledVersions(tool)
+	return err
 }
 
 // InstallVersion 自动下载并安装工具版本 (基础版本不支持)
@@ -295,32 +299,8 @@ func (m *DefaultManager) UpdateTool(tool string) (string, error) {
 }
 
 // removeFromInstalledVersions 从配置中移除已安装版本
+// 版本目录删除后按磁盘重新生成记录，移除的版本为当前版本时一并清空
 func (m *DefaultManager) removeFromInstalledVersions(tool, version string) error {
-	config, err := m.configManager.LoadGlobal()
-	if err != nil {
-		return err
-	}
-
-	if config.Tools == nil {
-		return nil
-	}
-
-	toolInfo := config.Tools[tool]
-
-	// 从已安装版本列表中移除
-	var newVersions []string
-	for _, v := range toolInfo.InstalledVersions {
-		if v != version {
-			newVersions = append(newVersions, v)
-		}
-	}
-	toolInfo.InstalledVersions = newVersions
-
-	// 如果移除的是当前版本，清空当前版本
-	if toolInfo.CurrentVersion == version {
-		toolInfo.CurrentVersion = ""
-	}
-
-	config.Tools[tool] = toolInfo
-	return m.configManager.SaveGlobal(config)
+	_, err := m.ReconcileInstalledVersions(tool)
+	return err
 }
@@ -1,42 +1,863 @@
 package types
 
 import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
 	"runtime"
+	"strconv"
+	"strings"
 	"time"
+
+	"github.com/songzhibin97/vman/pkg/utils"
 )
 
+// SystemVersion 特殊版本号，表示使用PATH中系统自带的工具而不是vman管理的版本
+const SystemVersion = "system"
+
 // GlobalConfig 全局配置结构
 type GlobalConfig struct {
-	Version        string              `yaml:"version"`
-	Settings       Settings            `yaml:"settings"`
-	GlobalVersions map[string]string   `yaml:"global_versions"`
-	Tools          map[string]ToolInfo `yaml:"tools"`
+	Version        string                  `yaml:"version"`
+	Settings       Settings                `yaml:"settings"`
+	GlobalVersions map[string]string       `yaml:"global_versions"`
+	Tools          map[string]ToolInfo     `yaml:"tools"`
+	Aliases        map[string]CommandAlias `yaml:"aliases,omitempty"`
+
+	// Secrets 文件中加密、读取时解密的值，保存时没有修改的值重新写入加密内容
+	Secrets SecretValues `yaml:"-"`
+	// SopsEncrypted 整个文件由 sops 加密，不能直接保存
+	SopsEncrypted bool `yaml:"-"`
+	// Remote 从 settings.remote 读取、覆盖本地配置的设置，保存时不写入本地配置文件
+	Remote *RemoteLayer `yaml:"-"`
+}
+
+// RemoteLayer 全局配置中来自远程配置的设置
+type RemoteLayer struct {
+	Source string        // 远程配置地址，不含认证信息
+	Stale  bool          // 远程配置不可用，使用的是过期的缓存
+	Values []RemoteValue // 远程配置覆盖的值
+}
+
+// RemoteValue 远程配置中的一个值
+type RemoteValue struct {
+	Path  []string    // 值在配置文档中的键路径，如 [settings download mirror]
+	Value interface{} // 远程配置中的值
+	Local interface{} // 被覆盖的本地配置中的值，本地没有设置时为 nil
+}
+
+// SecretValue 配置文件中加密的值
+type SecretValue struct {
+	Ciphertext string // 文件中的加密内容
+	Plaintext  string // 解密后的值
+}
+
+// SecretValues 配置中加密的值，键为值在配置文档中的路径，如 defaults.terraform.env.TF_TOKEN
+type SecretValues map[string]SecretValue
+
+// CommandAlias 命令别名，将自定义命令名映射到工具、版本和预设参数
+type CommandAlias struct {
+	Tool    string   `yaml:"tool" json:"tool"`
+	Version string   `yaml:"version,omitempty" json:"version,omitempty"` // 为空时使用当前目录解析出的版本
+	Args    []string `yaml:"args,omitempty" json:"args,omitempty"`
+}
+
+// Target 返回别名指向的 tool 或 tool@version
+func (a CommandAlias) Target() string {
+	if a.Version == "" {
+		return a.Tool
+	}
+	return a.Tool + "@" + a.Version
 }
 
 // ProjectConfig 项目配置结构
 type ProjectConfig struct {
-	Version string            `yaml:"version"`
-	Tools   map[string]string `yaml:"tools"`
+	Version string                       `yaml:"version"`
+	Extends string                       `yaml:"extends,omitempty"` // 继承的基础配置：相对于本文件所在目录的路径或 http(s) URL
+	Tools   map[string]string            `yaml:"tools"`
+	Paths   map[string]map[string]string `yaml:"paths,omitempty"` // 子目录glob模式 -> 工具版本，用于monorepo
+
+	Defaults map[string]ToolDefaults `yaml:"defaults,omitempty"` // 代理执行工具时注入的默认参数和环境变量
+	Groups   map[string][]string     `yaml:"groups,omitempty"`   // 工具分组，vman install --group 只安装组内的工具
+
+	// Workspaces terraform/tofu 工作区 -> 版本，当前工作区来自 TF_WORKSPACE 或 .terraform/environment
+	Workspaces map[string]string `yaml:"workspaces,omitempty"`
+
+	// Conditions tools 中设置了启用条件的工具，Inactive 为本机不满足条件、没有放入 Tools 的工具版本
+	Conditions map[string]ToolCondition `yaml:"-"`
+	Inactive   map[string]string        `yaml:"-"`
+	// Optional tools 中标记为可选的工具，缺少或安装失败时只警告
+	Optional map[string]bool `yaml:"-"`
+
+	// Inherited 从 extends 继承、本文件中没有覆盖的设置，保存时没有修改的继承设置不写入本文件
+	Inherited *ProjectConfig `yaml:"-"`
+	// BaseSources 合并的基础配置来源，从直接继承的配置开始
+	BaseSources []string `yaml:"-"`
+
+	// Secrets 本文件和基础配置中加密、读取时解密的值，保存时没有修改的值重新写入加密内容
+	Secrets SecretValues `yaml:"-"`
+	// SopsEncrypted 本文件由 sops 加密，不能直接保存
+	SopsEncrypted bool `yaml:"-"`
+}
+
+// IsOptional 工具是否标记为可选
+func (c *ProjectConfig) IsOptional(toolName string) bool {
+	return c.Optional[toolName]
+}
+
+// ToolDefaults 项目中执行工具时的默认参数和环境变量
+type ToolDefaults struct {
+	Args []string          `yaml:"args,omitempty"` // 插入到用户参数之前
+	Env  map[string]string `yaml:"env,omitempty"`
+
+	// Denied 因可以在执行时注入代码（如 LD_PRELOAD、NODE_OPTIONS）而被忽略的环境变量，不来自配置文件
+	Denied []string `yaml:"-"`
+}
+
+// IsEmpty 是否没有配置默认参数和环境变量
+func (d ToolDefaults) IsEmpty() bool {
+	return len(d.Args) == 0 && len(d.Env) == 0
+}
+
+// DefaultArgsDisabled 是否通过 VMAN_NO_DEFAULT_ARGS 禁用了项目配置中的默认参数
+func DefaultArgsDisabled() bool {
+	disabled, _ := strconv.ParseBool(os.Getenv(EnvVmanNoDefaultArgs))
+	return disabled
+}
+
+// ToolVersionForPath 获取相对于配置文件所在目录的路径下工具的版本
+//
+// relPath 使用 / 分隔，根目录为空字符串或 "."。paths 中匹配且配置了该工具的最具体的模式优先，
+// 都不匹配时使用 tools。返回匹配的模式，使用 tools 时为空字符串。
+func (c *ProjectConfig) ToolVersionForPath(toolName, relPath string) (version, pattern string, ok bool) {
+	bestScore := -1
+	for p, tools := range c.Paths {
+		v, exists := tools[toolName]
+		if !exists || v == "" || !utils.MatchPathGlob(p, relPath) {
+			continue
+		}
+
+		score := utils.GlobSpecificity(p)
+		if score > bestScore || (score == bestScore && (len(p) > len(pattern) || (len(p) == len(pattern) && p < pattern))) {
+			bestScore = score
+			version, pattern = v, p
+		}
+	}
+	if bestScore >= 0 {
+		return version, pattern, true
+	}
+
+	if v, exists := c.Tools[toolName]; exists && v != "" {
+		return v, "", true
+	}
+	return "", "", false
+}
+
+// ToolVersionForWorkspace 获取工具在 terraform 工作区中的版本，只适用于 WorkspaceTools 中的工具
+func (c *ProjectConfig) ToolVersionForWorkspace(toolName, workspace string) (string, bool) {
+	if !IsWorkspaceTool(toolName) {
+		return "", false
+	}
+	version, ok := c.Workspaces[workspace]
+	return version, ok && version != ""
 }
 
 // Settings 全局设置
 type Settings struct {
-	Download DownloadSettings `yaml:"download"`
-	Proxy    ProxySettings    `yaml:"proxy"`
-	Logging  LoggingSettings  `yaml:"logging"`
+	Download   DownloadSettings   `yaml:"download"`
+	Network    NetworkSettings    `yaml:"network,omitempty"`
+	Storage    StorageSettings    `yaml:"storage,omitempty"`
+	Proxy      ProxySettings      `yaml:"proxy"`
+	Logging    LoggingSettings    `yaml:"logging"`
+	Resolution ResolutionSettings `yaml:"resolution"`
+	Hooks      HookSettings       `yaml:"hooks,omitempty"`
+	Env        EnvSettings        `yaml:"env,omitempty"`
+	Limits     LimitSettings      `yaml:"limits,omitempty"`
+	System     SystemSettings     `yaml:"system,omitempty"`
+	Recipes    RecipeSettings     `yaml:"recipes,omitempty"`
+	Backup     BackupSettings     `yaml:"backup,omitempty"`
+	Remote     RemoteSettings     `yaml:"remote,omitempty"`
+	ReadOnly   bool               `yaml:"readonly,omitempty"`    // 只读模式，拒绝安装、切换版本等修改操作，版本解析和执行不受影响
+	RootPolicy string             `yaml:"root_policy,omitempty"` // 当前用户不是vman目录所有者（如用 sudo 运行）时的处理方式
+}
+
+// 当前用户不是vman目录所有者时的处理方式
+const (
+	// RootPolicyWarn 给出警告后继续执行
+	RootPolicyWarn = "warn"
+	// RootPolicyRefuse 拒绝执行修改操作
+	RootPolicyRefuse = "refuse"
+	// RootPolicyAllow 不检查
+	RootPolicyAllow = "allow"
+)
+
+// IsValidRootPolicy 检查处理方式是否有效，空值表示默认的 warn
+func IsValidRootPolicy(policy string) bool {
+	switch policy {
+	case "", RootPolicyWarn, RootPolicyRefuse, RootPolicyAllow:
+		return true
+	}
+	return false
+}
+
+// ErrReadOnly 只读模式下执行修改操作时返回的错误
+var ErrReadOnly = errors.New("vman is in read-only mode (settings.readonly or " + EnvVmanReadOnly + ")")
+
+// IsReadOnly 是否处于只读模式，环境变量 VMAN_READONLY 优先
+func (s Settings) IsReadOnly() bool {
+	if value := os.Getenv(EnvVmanReadOnly); value != "" {
+		if readOnly, err := strconv.ParseBool(value); err == nil {
+			return readOnly
+		}
+	}
+	return s.ReadOnly
+}
+
+// BackupSettings 自动备份设置
+type BackupSettings struct {
+	Enabled  bool          `yaml:"enabled"`             // 在修改配置的命令执行前和 vman serve 运行期间定时备份配置
+	Interval time.Duration `yaml:"interval,omitempty"`  // vman serve 定时备份的间隔，默认24小时
+	KeepLast int           `yaml:"keep_last,omitempty"` // 保留的自动备份数量，默认10个
+}
+
+const (
+	// DefaultBackupInterval 默认的定时备份间隔
+	DefaultBackupInterval = 24 * time.Hour
+	// DefaultBackupKeepLast 默认保留的自动备份数量
+	DefaultBackupKeepLast = 10
+)
+
+// GetInterval 获取定时备份间隔
+func (b BackupSettings) GetInterval() time.Duration {
+	if b.Interval <= 0 {
+		return DefaultBackupInterval
+	}
+	return b.Interval
+}
+
+// GetKeepLast 获取保留的自动备份数量
+func (b BackupSettings) GetKeepLast() int {
+	if b.KeepLast <= 0 {
+		return DefaultBackupKeepLast
+	}
+	return b.KeepLast
+}
+
+// RemoteSettings 远程配置设置，全局配置中的设置可以由远程配置统一下发
+type RemoteSettings struct {
+	URL   string        `yaml:"url,omitempty"`   // https://、consul+https://主机:端口/键 或 etcd+https://主机:端口/键
+	TTL   time.Duration `yaml:"ttl,omitempty"`   // 远程配置的缓存有效期，默认15分钟
+	Trust []string      `yaml:"trust,omitempty"` // 允许远程配置覆盖的受保护设置项，如 hooks、env、recipes、download.mirror
+}
+
+// DefaultRemoteTTL 默认的远程配置缓存有效期
+const DefaultRemoteTTL = 15 * time.Minute
+
+// GetTTL 获取远程配置的缓存有效期
+func (r RemoteSettings) GetTTL() time.Duration {
+	if r.TTL <= 0 {
+		return DefaultRemoteTTL
+	}
+	return r.TTL
+}
+
+// RecipeSettings 工具recipe设置
+type RecipeSettings struct {
+	Dirs       []string         `yaml:"dirs,omitempty"`       // 额外的recipe目录（如克隆的社区recipe仓库），按顺序优先于内置recipe
+	Registries []RecipeRegistry `yaml:"registries,omitempty"` // 通过 vman registry update 下载的远程recipe注册表
+}
+
+// RecipeRegistry 远程recipe注册表，索引需要由受信任的发布者签名
+type RecipeRegistry struct {
+	Name       string   `yaml:"name"`
+	URL        string   `yaml:"url"`                   // 注册表根地址，其下为 index.json、index.json.sig 和各个recipe目录
+	PublicKeys []string `yaml:"public_keys,omitempty"` // 信任的发布者公钥（Ed25519，base64），只用于验证该注册表
+}
+
+// SystemSettings 多用户共享的系统级存储设置
+type SystemSettings struct {
+	Root string `yaml:"root,omitempty"` // 系统级存储根目录，如 /opt/vman，所有用户只读共享
+}
+
+// GetRoot 获取系统级存储根目录，环境变量 VMAN_SYSTEM_ROOT 优先
+func (s SystemSettings) GetRoot() string {
+	if root := os.Getenv(EnvVmanSystemRoot); root != "" {
+		return root
+	}
+	return s.Root
+}
+
+// StorageSettings 安装过程中的本地存储设置
+type StorageSettings struct {
+	TempDir          string   `yaml:"temp_dir,omitempty"`           // 下载和解压安装包的临时目录，默认为vman数据目录下的 tmp
+	TmpfsMaxArtifact ByteSize `yaml:"tmpfs_max_artifact,omitempty"` // 临时目录位于tmpfs时，超过该大小的安装包改用磁盘上的目录，默认512MB
+	Dedup            string   `yaml:"dedup,omitempty"`              // 不同版本中内容相同的文件共享数据：off、reflink、hardlink、auto，默认off
+}
+
+// 不同版本中内容相同的文件共享数据的方式
+const (
+	// DedupOff 不共享
+	DedupOff = "off"
+	// DedupReflink 使用写时复制克隆（Linux 的 FICLONE、macOS 的 clonefile），文件系统不支持时不共享
+	DedupReflink = "reflink"
+	// DedupHardlink 使用硬链接，所有版本共用同一个文件，修改一个版本中的文件会影响其他版本
+	DedupHardlink = "hardlink"
+	// DedupAuto 优先使用写时复制克隆，文件系统不支持时使用硬链接
+	DedupAuto = "auto"
+)
+
+// IsValidDedupMode 检查共享方式是否有效，空值表示默认的 off
+func IsValidDedupMode(mode string) bool {
+	switch mode {
+	case "", DedupOff, DedupReflink, DedupHardlink, DedupAuto:
+		return true
+	}
+	return false
+}
+
+// DefaultTmpfsMaxArtifact 临时目录位于tmpfs时默认允许的安装包大小
+const DefaultTmpfsMaxArtifact ByteSize = 512 << 20
+
+// GetTempDir 获取配置的临时目录，环境变量 VMAN_TEMP_DIR 优先，未配置时为空
+func (s StorageSettings) GetTempDir() string {
+	if dir := os.Getenv(EnvVmanTempDir); dir != "" {
+		return dir
+	}
+	return s.TempDir
+}
+
+// GetDedup 获取共享方式
+func (s StorageSettings) GetDedup() string {
+	if s.Dedup == "" {
+		return DedupOff
+	}
+	return s.Dedup
+}
+
+// GetTmpfsMaxArtifact 获取临时目录位于tmpfs时允许的安装包大小
+func (s StorageSettings) GetTmpfsMaxArtifact() ByteSize {
+	if s.TmpfsMaxArtifact <= 0 {
+		return DefaultTmpfsMaxArtifact
+	}
+	return s.TmpfsMaxArtifact
 }
 
 // DownloadSettings 下载设置
 type DownloadSettings struct {
-	Timeout             time.Duration `yaml:"timeout"`
-	Retries             int           `yaml:"retries"`
-	ConcurrentDownloads int           `yaml:"concurrent_downloads"`
+	Timeout              time.Duration  `yaml:"timeout"`
+	Retries              int            `yaml:"retries"`
+	ConcurrentDownloads  int            `yaml:"concurrent_downloads"`
+	MaxExtractedSize     ByteSize       `yaml:"max_extracted_size,omitempty"`      // 解压后文件的总大小上限，0 表示使用默认值
+	MaxExtractedFileSize ByteSize       `yaml:"max_extracted_file_size,omitempty"` // 解压后单个文件的大小上限，0 表示使用默认值
+	MaxArchiveEntries    int            `yaml:"max_archive_entries,omitempty"`     // 压缩包中的条目数上限，0 表示使用默认值
+	MaxCompressionRatio  float64        `yaml:"max_compression_ratio,omitempty"`   // 解压后总大小与压缩包大小之比的上限，0 表示使用默认值
+	PreserveMtime        bool           `yaml:"preserve_mtime"`                    // 解压时保留压缩包中记录的修改时间
+	PreserveXattrs       bool           `yaml:"preserve_xattrs,omitempty"`         // 解压时恢复tar压缩包中记录的扩展属性
+	ZipFilenameEncodings []string       `yaml:"zip_filename_encodings,omitempty"`  // zip中非UTF-8文件名依次尝试的编码，为空时根据系统语言环境选择
+	ProgressInterval     time.Duration  `yaml:"progress_interval,omitempty"`       // 输出不是终端时打印下载进度的间隔，默认30秒
+	Mirror               string         `yaml:"mirror,omitempty"`                  // vman mirror 生成的镜像根目录的HTTP地址或本地路径，安装时优先从镜像下载
+	Redirects            RedirectPolicy `yaml:"redirects,omitempty"`               // 下载时跟随重定向的策略
+}
+
+// DefaultMaxRedirects 默认最多跟随的重定向次数
+const DefaultMaxRedirects = 10
+
+// RedirectPolicy 下载时跟随重定向的策略
+// 重定向到其他域名（包括子域名）或从HTTPS降级到HTTP时默认不再发送认证头，
+// 认证头为 Authorization、Cookie 和名称中包含 token、auth、key、secret 等的自定义请求头
+type RedirectPolicy struct {
+	MaxHops       int      `yaml:"max_hops,omitempty"`        // 最多跟随的重定向次数，0 表示默认的10次，-1 表示不跟随重定向
+	KeepAuthHosts []string `yaml:"keep_auth_hosts,omitempty"` // 重定向到这些域名时仍然发送认证头，*.example.com 匹配子域名
+	DropAuthHosts []string `yaml:"drop_auth_hosts,omitempty"` // 重定向到这些域名时总是去掉认证头，如拒绝额外认证的CDN签名地址
+}
+
+// GetMaxHops 最多跟随的重定向次数，不跟随时返回0
+func (p RedirectPolicy) GetMaxHops() int {
+	switch {
+	case p.MaxHops < 0:
+		return 0
+	case p.MaxHops == 0:
+		return DefaultMaxRedirects
+	default:
+		return p.MaxHops
+	}
+}
+
+// 连接下载源时使用的IP版本
+const (
+	// IPVersionAuto 同时尝试IPv4和IPv6（Happy Eyeballs）
+	IPVersionAuto = ""
+	// IPVersionIPv4 只使用IPv4
+	IPVersionIPv4 = "ipv4"
+	// IPVersionIPv6 只使用IPv6
+	IPVersionIPv6 = "ipv6"
+	// IPVersionPreferIPv4 优先使用IPv4，连接失败时再尝试IPv6
+	IPVersionPreferIPv4 = "prefer-ipv4"
+	// IPVersionPreferIPv6 优先使用IPv6，连接失败时再尝试IPv4
+	IPVersionPreferIPv6 = "prefer-ipv6"
+)
+
+// IsValidIPVersion 检查IP版本设置是否有效
+func IsValidIPVersion(version string) bool {
+	switch version {
+	case IPVersionAuto, IPVersionIPv4, IPVersionIPv6, IPVersionPreferIPv4, IPVersionPreferIPv6:
+		return true
+	}
+	return false
+}
+
+// NetworkSettings 下载、查询版本等所有HTTP请求使用的网络设置
+type NetworkSettings struct {
+	IPVersion          string            `yaml:"ip_version,omitempty"`           // ipv4、ipv6、prefer-ipv4、prefer-ipv6，默认同时尝试
+	HappyEyeballsDelay time.Duration     `yaml:"happy_eyeballs_delay,omitempty"` // 同时尝试IPv4和IPv6时，首选地址族连接未完成多久后尝试另一个，默认300ms，负数表示不尝试
+	Hosts              map[string]string `yaml:"hosts,omitempty"`                // 主机名到IP的固定解析，类似 /etc/hosts
+	DNSServers         []string          `yaml:"dns_servers,omitempty"`          // 解析主机名使用的DNS服务器，如 10.0.0.53 或 10.0.0.53:53，默认使用系统设置
+
+	// 连接复用，安装多个工具时复用到同一主机的连接，避免重复建立TLS连接
+	MaxIdleConns        int           `yaml:"max_idle_conns,omitempty"`          // 保持的空闲连接总数，默认100
+	MaxIdleConnsPerHost int           `yaml:"max_idle_conns_per_host,omitempty"` // 每个主机保持的空闲连接数，默认16
+	MaxConnsPerHost     int           `yaml:"max_conns_per_host,omitempty"`      // 每个主机的最大连接数，默认不限制
+	IdleConnTimeout     time.Duration `yaml:"idle_conn_timeout,omitempty"`       // 空闲连接保持的时间，默认90s
+	DisableHTTP2        bool          `yaml:"disable_http2,omitempty"`           // 不使用HTTP/2，用于不兼容HTTP/2的代理或服务器
+	DisableKeepAlives   bool          `yaml:"disable_keep_alives,omitempty"`     // 每个请求使用新的连接
+}
+
+// 连接池的默认设置
+const (
+	DefaultMaxIdleConns        = 100
+	DefaultMaxIdleConnsPerHost = 16
+	DefaultIdleConnTimeout     = 90 * time.Second
+)
+
+// GetMaxIdleConns 获取保持的空闲连接总数
+func (n NetworkSettings) GetMaxIdleConns() int {
+	if n.MaxIdleConns <= 0 {
+		return DefaultMaxIdleConns
+	}
+	return n.MaxIdleConns
+}
+
+// GetMaxIdleConnsPerHost 获取每个主机保持的空闲连接数
+func (n NetworkSettings) GetMaxIdleConnsPerHost() int {
+	if n.MaxIdleConnsPerHost <= 0 {
+		return DefaultMaxIdleConnsPerHost
+	}
+	return n.MaxIdleConnsPerHost
+}
+
+// GetIdleConnTimeout 获取空闲连接保持的时间
+func (n NetworkSettings) GetIdleConnTimeout() time.Duration {
+	if n.IdleConnTimeout <= 0 {
+		return DefaultIdleConnTimeout
+	}
+	return n.IdleConnTimeout
+}
+
+// DefaultProgressInterval 输出不是终端时默认的下载进度间隔
+const DefaultProgressInterval = 30 * time.Second
+
+// GetProgressInterval 获取输出不是终端时打印下载进度的间隔
+func (d DownloadSettings) GetProgressInterval() time.Duration {
+	if d.ProgressInterval <= 0 {
+		return DefaultProgressInterval
+	}
+	return d.ProgressInterval
+}
+
+// ByteSize 字节数，配置文件中可以写作 512MB、8GB 等
+type ByteSize int64
+
+// byteSizeUnits 字节单位，按1024进制换算
+var byteSizeUnits = []struct {
+	suffix string
+	size   ByteSize
+}{
+	{"TB", 1 << 40},
+	{"GB", 1 << 30},
+	{"MB", 1 << 20},
+	{"KB", 1 << 10},
+	{"T", 1 << 40},
+	{"G", 1 << 30},
+	{"M", 1 << 20},
+	{"K", 1 << 10},
+	{"B", 1},
+}
+
+// ParseByteSize 解析字节数，如 1024、512KB、1.5GB
+func ParseByteSize(text string) (ByteSize, error) {
+	value := strings.ToUpper(strings.TrimSpace(text))
+	unit := ByteSize(1)
+	for _, u := range byteSizeUnits {
+		if strings.HasSuffix(value, u.suffix) {
+			value = strings.TrimSpace(strings.TrimSuffix(value, u.suffix))
+			unit = u.size
+			break
+		}
+	}
+
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil || number < 0 {
+		return 0, fmt.Errorf("invalid byte size: %q", text)
+	}
+	return ByteSize(number * float64(unit)), nil
+}
+
+// String 以最大的整数单位显示，如 8GB
+func (s ByteSize) String() string {
+	for _, u := range byteSizeUnits[:4] {
+		if s >= u.size && s%u.size == 0 {
+			return fmt.Sprintf("%d%s", s/u.size, u.suffix)
+		}
+	}
+	return strconv.FormatInt(int64(s), 10)
+}
+
+// UnmarshalYAML 支持数字和带单位的字符串
+func (s *ByteSize) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var text string
+	if err := unmarshal(&text); err != nil {
+		return err
+	}
+	size, err := ParseByteSize(text)
+	if err != nil {
+		return err
+	}
+	*s = size
+	return nil
+}
+
+// MarshalYAML 保存为带单位的字符串
+func (s ByteSize) MarshalYAML() (interface{}, error) {
+	return s.String(), nil
+}
+
+// UnmarshalText 支持在 TOML 中写作带单位的字符串
+func (s *ByteSize) UnmarshalText(text []byte) error {
+	size, err := ParseByteSize(string(text))
+	if err != nil {
+		return err
+	}
+	*s = size
+	return nil
 }
 
 // ProxySettings 代理设置
 type ProxySettings struct {
-	Enabled     bool `yaml:"enabled"`
-	ShimsInPath bool `yaml:"shims_in_path"`
+	Enabled     bool   `yaml:"enabled"`
+	ShimsInPath bool   `yaml:"shims_in_path"`
+	SelfHeal    string `yaml:"self_heal,omitempty"` // 执行时发现垫片缺失或损坏的处理方式
+}
+
+// 执行时发现垫片缺失或损坏的处理方式
+const (
+	// ShimHealAuto 重新生成垫片
+	ShimHealAuto = "auto"
+	// ShimHealWarn 只给出警告
+	ShimHealWarn = "warn"
+	// ShimHealOff 不检查
+	ShimHealOff = "off"
+)
+
+// IsValidShimHeal 检查处理方式是否有效，空值表示默认的 auto
+func IsValidShimHeal(mode string) bool {
+	switch mode {
+	case "", ShimHealAuto, ShimHealWarn, ShimHealOff:
+		return true
+	}
+	return false
+}
+
+// GetSelfHeal 获取垫片缺失或损坏时的处理方式
+func (p ProxySettings) GetSelfHeal() string {
+	if p.SelfHeal == "" {
+		return ShimHealAuto
+	}
+	return p.SelfHeal
+}
+
+// 未配置版本时的回退策略
+const (
+	// FallbackError 报错
+	FallbackError = "error"
+	// FallbackSystem 使用PATH中系统自带的工具
+	FallbackSystem = "system"
+	// FallbackLatestInstalled 使用已安装的最新版本
+	FallbackLatestInstalled = "latest-installed"
+	// FallbackAutoInstall 自动安装最新版本
+	FallbackAutoInstall = "auto-install"
+)
+
+// ResolutionSettings 版本解析设置
+type ResolutionSettings struct {
+	Fallback      string            `yaml:"fallback"`
+	Tools         map[string]string `yaml:"tools,omitempty"`          // 按工具覆盖回退策略
+	MergeStrategy string            `yaml:"merge_strategy,omitempty"` // 全局、项目、环境变量版本的合并策略: override, append, error
+	Deprecated    string            `yaml:"deprecated,omitempty"`     // 使用工具定义中弃用的版本时的处理方式: warn, error, ignore
+
+	// VersionFiles 工具 -> 兼容读取的生态版本文件（如 .nvmrc），覆盖 DefaultVersionFiles 中的映射，空列表表示不读取
+	VersionFiles map[string][]string `yaml:"version_files,omitempty"`
+}
+
+// DefaultVersionFiles 默认兼容读取的生态版本文件，与 nvm、pyenv、tfenv 等工具使用的文件相同
+var DefaultVersionFiles = map[string][]string{
+	"node":       {".nvmrc", ".node-version"},
+	"python":     {".python-version"},
+	"terraform":  {".terraform-version"},
+	"terragrunt": {".terragrunt-version"},
+	"go":         {".go-version"},
+	"ruby":       {".ruby-version"},
+	"java":       {".java-version"},
+}
+
+// VersionFilesFor 获取工具兼容读取的生态版本文件，settings.resolution.version_files 优先
+func (s ResolutionSettings) VersionFilesFor(tool string) []string {
+	if files, ok := s.VersionFiles[tool]; ok {
+		return files
+	}
+	return DefaultVersionFiles[tool]
+}
+
+// 使用弃用版本时的处理方式
+const (
+	// DeprecationWarn 显示警告后继续，同一版本每天最多提示一次
+	DeprecationWarn = "warn"
+	// DeprecationError 拒绝执行或切换到弃用的版本
+	DeprecationError = "error"
+	// DeprecationIgnore 不提示
+	DeprecationIgnore = "ignore"
+)
+
+// IsValidDeprecationPolicy 检查弃用版本的处理方式是否有效，空值表示默认的 warn
+func IsValidDeprecationPolicy(policy string) bool {
+	switch policy {
+	case "", DeprecationWarn, DeprecationError, DeprecationIgnore:
+		return true
+	}
+	return false
+}
+
+// DeprecationPolicy 获取弃用版本的处理方式，环境变量 VMAN_DEPRECATED 优先
+func (s ResolutionSettings) DeprecationPolicy() string {
+	if policy := os.Getenv(EnvVmanDeprecated); policy != "" && IsValidDeprecationPolicy(policy) {
+		return policy
+	}
+	if s.Deprecated == "" {
+		return DeprecationWarn
+	}
+	return s.Deprecated
+}
+
+// Strategy 获取版本合并策略，无效值按覆盖策略处理
+func (s ResolutionSettings) Strategy() ConfigMergeStrategy {
+	strategy, _ := ParseMergeStrategy(s.MergeStrategy)
+	return strategy
+}
+
+// FallbackFor 获取工具的回退策略
+func (s ResolutionSettings) FallbackFor(tool string) string {
+	if fallback, ok := s.Tools[tool]; ok && fallback != "" {
+		return fallback
+	}
+	if s.Fallback == "" {
+		return FallbackLatestInstalled
+	}
+	return s.Fallback
+}
+
+// IsValidFallback 检查回退策略是否有效
+func IsValidFallback(fallback string) bool {
+	switch fallback {
+	case FallbackError, FallbackSystem, FallbackLatestInstalled, FallbackAutoInstall:
+		return true
+	}
+	return false
+}
+
+// DefaultHookTimeout 钩子默认超时时间
+const DefaultHookTimeout = 10 * time.Second
+
+// DefaultInstallHookTimeout 安装钩子默认超时时间，安装钩子可能需要解压或运行安装程序
+const DefaultInstallHookTimeout = 5 * time.Minute
+
+// HookSettings 工具执行钩子设置
+type HookSettings struct {
+	Timeout        time.Duration        `yaml:"timeout,omitempty"`
+	InstallTimeout time.Duration        `yaml:"install_timeout,omitempty"`
+	Dir            string               `yaml:"dir,omitempty"`       // 安装钩子脚本目录，默认为配置目录下的 hooks
+	PreExec        []string             `yaml:"pre_exec,omitempty"`  // 所有工具执行前运行的脚本
+	PostExec       []string             `yaml:"post_exec,omitempty"` // 所有工具执行后运行的脚本
+	Tools          map[string]ToolHooks `yaml:"tools,omitempty"`     // 按工具配置的钩子
+}
+
+// EnvPolicy 执行工具时的环境变量策略，模式按 path.Match 匹配变量名，如 AWS_*
+type EnvPolicy struct {
+	StripVman bool     `yaml:"strip_vman,omitempty" toml:"strip_vman,omitempty"` // 去掉 VMAN_ 开头的变量
+	Strip     []string `yaml:"strip,omitempty" toml:"strip,omitempty"`           // 去掉名称匹配这些模式的变量
+	Allow     []string `yaml:"allow,omitempty" toml:"allow,omitempty"`           // 不为空时只保留名称匹配这些模式的变量
+}
+
+// IsEmpty 策略是否不修改环境变量
+func (p EnvPolicy) IsEmpty() bool {
+	return !p.StripVman && len(p.Strip) == 0 && len(p.Allow) == 0
+}
+
+// Merge 合并策略：StripVman 任一开启即生效，Strip 和 Allow 取并集
+func (p EnvPolicy) Merge(other EnvPolicy) EnvPolicy {
+	return EnvPolicy{
+		StripVman: p.StripVman || other.StripVman,
+		Strip:     append(append([]string{}, p.Strip...), other.Strip...),
+		Allow:     append(append([]string{}, p.Allow...), other.Allow...),
+	}
+}
+
+// Apply 按策略过滤 KEY=VALUE 形式的环境变量
+func (p EnvPolicy) Apply(environ []string) []string {
+	if p.IsEmpty() {
+		return environ
+	}
+	filtered := make([]string, 0, len(environ))
+	for _, entry := range environ {
+		name, _, _ := strings.Cut(entry, "=")
+		if len(p.Allow) > 0 && !matchEnvName(p.Allow, name) {
+			continue
+		}
+		if p.StripVman && strings.HasPrefix(strings.ToUpper(name), "VMAN_") {
+			continue
+		}
+		if matchEnvName(p.Strip, name) {
+			continue
+		}
+		filtered = append(filtered, entry)
+	}
+	return filtered
+}
+
+// ValidateEnvPatterns 检查环境变量名模式的语法
+func ValidateEnvPatterns(patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// matchEnvName 检查变量名是否匹配任一模式，Windows 上变量名不区分大小写
+func matchEnvName(patterns []string, name string) bool {
+	if runtime.GOOS == "windows" {
+		name = strings.ToUpper(name)
+	}
+	for _, pattern := range patterns {
+		if runtime.GOOS == "windows" {
+			pattern = strings.ToUpper(pattern)
+		}
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// EnvSettings 执行工具时的环境变量设置
+type EnvSettings struct {
+	EnvPolicy `yaml:",inline"`
+	Tools     map[string]EnvPolicy `yaml:"tools,omitempty"` // 按工具配置的策略，与全局策略合并
+}
+
+// PolicyFor 获取工具的环境变量策略：全局策略、工具定义中的策略和按工具配置的策略合并
+func (e EnvSettings) PolicyFor(tool string, metadata *ToolMetadata) EnvPolicy {
+	policy := e.EnvPolicy
+	if metadata != nil {
+		policy = policy.Merge(metadata.Env)
+	}
+	return policy.Merge(e.Tools[tool])
+}
+
+// ExecLimits 执行工具时的资源限制，各项为 0 表示不限制
+type ExecLimits struct {
+	Timeout   time.Duration `yaml:"timeout,omitempty" toml:"timeout,omitempty"`       // 最长运行时间，超时后终止
+	MaxMemory ByteSize      `yaml:"max_memory,omitempty" toml:"max_memory,omitempty"` // 虚拟内存上限，仅 Linux 支持
+	Nice      int           `yaml:"nice,omitempty" toml:"nice,omitempty"`             // 进程优先级（nice 值，-20 到 19），Windows 不支持
+}
+
+// IsEmpty 是否没有设置任何限制
+func (l ExecLimits) IsEmpty() bool {
+	return l.Timeout == 0 && l.MaxMemory == 0 && l.Nice == 0
+}
+
+// Merge 合并限制，other 中设置了的项覆盖当前值
+func (l ExecLimits) Merge(other ExecLimits) ExecLimits {
+	if other.Timeout != 0 {
+		l.Timeout = other.Timeout
+	}
+	if other.MaxMemory != 0 {
+		l.MaxMemory = other.MaxMemory
+	}
+	if other.Nice != 0 {
+		l.Nice = other.Nice
+	}
+	return l
+}
+
+// LimitSettings 执行工具时的资源限制设置
+type LimitSettings struct {
+	ExecLimits `yaml:",inline"`
+	Tools      map[string]ExecLimits `yaml:"tools,omitempty"` // 按工具配置的限制，覆盖全局限制
+}
+
+// LimitsFor 获取工具的资源限制：按全局限制、工具定义中的限制、按工具配置的限制依次覆盖
+func (s LimitSettings) LimitsFor(tool string, metadata *ToolMetadata) ExecLimits {
+	limits := s.ExecLimits
+	if metadata != nil {
+		limits = limits.Merge(metadata.Limits)
+	}
+	return limits.Merge(s.Tools[tool])
+}
+
+// ToolHooks 单个工具的钩子
+type ToolHooks struct {
+	PreExec  []string `yaml:"pre_exec,omitempty"`
+	PostExec []string `yaml:"post_exec,omitempty"`
+}
+
+// PreExecFor 获取工具执行前的钩子，全局钩子在前
+func (h HookSettings) PreExecFor(tool string) []string {
+	hooks := append([]string{}, h.PreExec...)
+	return append(hooks, h.Tools[tool].PreExec...)
+}
+
+// PostExecFor 获取工具执行后的钩子，全局钩子在前
+func (h HookSettings) PostExecFor(tool string) []string {
+	hooks := append([]string{}, h.PostExec...)
+	return append(hooks, h.Tools[tool].PostExec...)
+}
+
+// GetTimeout 获取钩子超时时间
+func (h HookSettings) GetTimeout() time.Duration {
+	if h.Timeout <= 0 {
+		return DefaultHookTimeout
+	}
+	return h.Timeout
+}
+
+// GetInstallTimeout 获取安装钩子超时时间
+func (h HookSettings) GetInstallTimeout() time.Duration {
+	if h.InstallTimeout <= 0 {
+		return DefaultInstallHookTimeout
+	}
+	return h.InstallTimeout
+}
+
+// GetDir 获取安装钩子脚本目录，未配置时为配置目录下的 hooks
+func (h HookSettings) GetDir(configDir string) string {
+	if h.Dir != "" {
+		if dir, err := utils.ExpandPath(h.Dir); err == nil {
+			return dir
+		}
+		return h.Dir
+	}
+	return filepath.Join(configDir, "hooks")
 }
 
 // LoggingSettings 日志设置
@@ -57,9 +878,28 @@ type ToolMetadata struct {
 	Description    string         `toml:"description"`
 	Homepage       string         `toml:"homepage"`
 	Repository     string         `toml:"repository"`
+	Platforms      []string       `toml:"platforms,omitempty"`  // 支持的平台，如 linux_amd64
+	Shim           string         `toml:"shim,omitempty"`       // 垫片（命令）名称，默认与工具名相同
+	Changelog      string         `toml:"changelog,omitempty"`  // 发布说明地址，可以使用 {version} 等模板变量
+	Completion     []string       `toml:"completion,omitempty"` // 输出shell补全脚本的参数，{shell} 替换为 bash、zsh 或 fish
 	DownloadConfig DownloadConfig `toml:"download"`
 	VersionConfig  VersionConfig  `toml:"versions"`
 	PostInstall    []string       `toml:"post_install,omitempty"`
+	Env            EnvPolicy      `toml:"env,omitempty"`    // 执行工具时的环境变量策略
+	Limits         ExecLimits     `toml:"limits,omitempty"` // 执行工具时的资源限制
+
+	Sandbox         bool     `toml:"sandbox,omitempty"`          // 在沙箱中执行，只允许写入项目目录和临时目录
+	SandboxOffline  bool     `toml:"sandbox_offline,omitempty"`  // 沙箱中禁止访问网络
+	SandboxWritable []string `toml:"sandbox_writable,omitempty"` // 沙箱中额外允许写入的目录，如 ~/.kube
+}
+
+// ShimName 返回工具垫片的命令名称
+// 与其他工具、别名或vman子命令同名时，可通过 shim 字段改名
+func (m *ToolMetadata) ShimName() string {
+	if m.Shim != "" {
+		return m.Shim
+	}
+	return m.Name
 }
 
 // DownloadConfig 下载配置
@@ -70,12 +910,30 @@ type DownloadConfig struct {
 	URLTemplate   string            `toml:"url_template,omitempty"`
 	ExtractBinary string            `toml:"extract_binary,omitempty"`
 	Headers       map[string]string `toml:"headers,omitempty"`
+
+	// DeltaURLTemplate 从旧版本安装包升级的 bsdiff 补丁地址，可以使用 {from} 表示旧版本
+	DeltaURLTemplate string `toml:"delta_url_template,omitempty"`
+
+	// PreserveMtime、PreserveXattrs 解压时保留修改时间、恢复扩展属性，与全局设置任一开启即生效
+	PreserveMtime  bool `toml:"preserve_mtime,omitempty"`
+	PreserveXattrs bool `toml:"preserve_xattrs,omitempty"`
+
+	// StripComponents 解压时去掉条目路径的前几层目录
+	// 0 表示自动检测：所有文件都位于同一个顶层目录下时去掉该目录；-1 表示保持压缩包原有结构
+	StripComponents int `toml:"strip_components,omitempty"`
 }
 
 // VersionConfig 版本配置
 type VersionConfig struct {
-	Aliases     map[string]string  `toml:"aliases,omitempty"`
-	Constraints VersionConstraints `toml:"constraints,omitempty"`
+	Aliases     map[string]string   `toml:"aliases,omitempty"`
+	Constraints VersionConstraints  `toml:"constraints,omitempty"`
+	Deprecated  []DeprecatedVersion `toml:"deprecated,omitempty"` // 弃用的版本，解析或执行时提示
+}
+
+// DeprecatedVersion 弃用的版本或版本范围
+type DeprecatedVersion struct {
+	Versions string `toml:"versions"`          // 精确版本或版本范围，如 "<1.5"
+	Message  string `toml:"message,omitempty"` // 提示信息，如 "terraform <1.5 已停止维护"
 }
 
 // VersionConstraints 版本约束
@@ -196,6 +1054,25 @@ type VersionMetadata struct {
 	Size        int64     `json:"size"`
 	Checksum    string    `json:"checksum,omitempty"`
 	Source      string    `json:"source,omitempty"` // 安装来源描述
+
+	Provenance *DownloadProvenance `json:"provenance,omitempty"` // 下载来源记录
+}
+
+// DownloadProvenance 下载来源记录，用于审计二进制文件的获取方式
+type DownloadProvenance struct {
+	URL              string    `json:"url"`                         // 原始下载地址
+	ResolvedURL      string    `json:"resolved_url,omitempty"`      // 重定向后实际下载地址（镜像、CDN），不含查询参数中的签名
+	Strategy         string    `json:"strategy"`                    // 下载策略: github, direct, archive
+	Filename         string    `json:"filename,omitempty"`          // 下载的文件名
+	Checksum         string    `json:"checksum"`                    // 下载文件的SHA256
+	ExpectedChecksum string    `json:"expected_checksum,omitempty"` // 下载源声明的校验和
+	ChecksumVerified bool      `json:"checksum_verified"`           // 是否已与声明的校验和比对
+	Size             int64     `json:"size"`                        // 下载文件大小
+	ETag             string    `json:"etag,omitempty"`
+	LastModified     string    `json:"last_modified,omitempty"`
+	ContentType      string    `json:"content_type,omitempty"`
+	Redirects        int       `json:"redirects,omitempty"` // 跟随的重定向次数
+	DownloadedAt     time.Time `json:"downloaded_at"`
 }
 
 // VersionRegistry 版本注册表
@@ -0,0 +1,209 @@
+package types
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func TestResolutionSettings_FallbackFor(t *testing.T) {
+	settings := ResolutionSettings{
+		Fallback: FallbackError,
+		Tools: map[string]string{
+			"kubectl": FallbackSystem,
+		},
+	}
+
+	assert.Equal(t, FallbackSystem, settings.FallbackFor("kubectl"))
+	assert.Equal(t, FallbackError, settings.FallbackFor("terraform"))
+
+	// 未配置时保持使用已安装的最新版本
+	assert.Equal(t, FallbackLatestInstalled, ResolutionSettings{}.FallbackFor("kubectl"))
+}
+
+func TestIsValidFallback(t *testing.T) {
+	for _, fallback := range []string{FallbackError, FallbackSystem, FallbackLatestInstalled, FallbackAutoInstall} {
+		assert.True(t, IsValidFallback(fallback), fallback)
+	}
+	assert.False(t, IsValidFallback(""))
+	assert.False(t, IsValidFallback("latest"))
+}
+
+func TestHookSettings(t *testing.T) {
+	hooks := HookSettings{
+		PreExec:  []string{"global-pre"},
+		PostExec: []string{"global-post"},
+		Tools: map[string]ToolHooks{
+			"terraform": {PreExec: []string{"tf-pre"}},
+		},
+	}
+
+	assert.Equal(t, []string{"global-pre", "tf-pre"}, hooks.PreExecFor("terraform"))
+	assert.Equal(t, []string{"global-post"}, hooks.PostExecFor("terraform"))
+	assert.Equal(t, []string{"global-pre"}, hooks.PreExecFor("kubectl"))
+	assert.Empty(t, HookSettings{}.PreExecFor("kubectl"))
+
+	assert.Equal(t, DefaultHookTimeout, hooks.GetTimeout())
+	hooks.Timeout = time.Minute
+	assert.Equal(t, time.Minute, hooks.GetTimeout())
+}
+
+func TestEnvSettings_PolicyFor(t *testing.T) {
+	settings := EnvSettings{
+		EnvPolicy: EnvPolicy{Strip: []string{"SECRET_*"}},
+		Tools: map[string]EnvPolicy{
+			"terraform": {Allow: []string{"PATH", "HOME", "TF_*", "VMAN_*"}, StripVman: true},
+		},
+	}
+	environ := []string{"PATH=/usr/bin", "HOME=/home/user", "SECRET_TOKEN=x", "TF_LOG=debug", "AWS_PROFILE=dev", "VMAN_PROJECT_PATH=/src"}
+
+	policy := settings.PolicyFor("kubectl", &ToolMetadata{Env: EnvPolicy{Strip: []string{"AWS_*"}}})
+	assert.Equal(t, []string{"PATH=/usr/bin", "HOME=/home/user", "TF_LOG=debug", "VMAN_PROJECT_PATH=/src"}, policy.Apply(environ))
+
+	policy = settings.PolicyFor("terraform", nil)
+	assert.Equal(t, []string{"PATH=/usr/bin", "HOME=/home/user", "TF_LOG=debug"}, policy.Apply(environ))
+
+	// 未配置策略时不修改环境变量
+	assert.True(t, EnvSettings{}.PolicyFor("kubectl", nil).IsEmpty())
+	assert.Equal(t, environ, EnvPolicy{}.Apply(environ))
+
+	assert.NoError(t, ValidateEnvPatterns([]string{"AWS_*", "TF_?OG"}))
+	assert.Error(t, ValidateEnvPatterns([]string{"AWS_[*"}))
+}
+
+func TestLimitSettings_LimitsFor(t *testing.T) {
+	settings := LimitSettings{
+		ExecLimits: ExecLimits{Timeout: 10 * time.Minute, Nice: 5},
+		Tools: map[string]ExecLimits{
+			"terraform": {Timeout: time.Hour},
+		},
+	}
+
+	limits := settings.LimitsFor("kubectl", &ToolMetadata{Limits: ExecLimits{MaxMemory: 512 << 20}})
+	assert.Equal(t, ExecLimits{Timeout: 10 * time.Minute, MaxMemory: 512 << 20, Nice: 5}, limits)
+	assert.Equal(t, ExecLimits{Timeout: time.Hour, Nice: 5}, settings.LimitsFor("terraform", nil))
+	assert.True(t, LimitSettings{}.LimitsFor("kubectl", nil).IsEmpty())
+}
+
+func TestProjectConfig_ToolVersionForPath(t *testing.T) {
+	config := &ProjectConfig{
+		Tools: map[string]string{
+			"go":        "1.21.0",
+			"terraform": "1.6.0",
+		},
+		Paths: map[string]map[string]string{
+			"services/api/**": {"go": "1.22.0"},
+			"services/*/cmd":  {"go": "1.20.0"},
+			"infra/**":        {"terraform": "1.7.0"},
+		},
+	}
+
+	tests := []struct {
+		tool, path, version, pattern string
+	}{
+		{"go", "", "1.21.0", ""},
+		{"go", "services/api", "1.22.0", "services/api/**"},
+		{"go", "services/api/internal", "1.22.0", "services/api/**"},
+		{"go", "services/api/cmd", "1.20.0", "services/*/cmd"},
+		{"go", "infra/aws", "1.21.0", ""},
+		{"terraform", "infra/aws", "1.7.0", "infra/**"},
+		{"terraform", "services/api", "1.6.0", ""},
+	}
+
+	for _, tt := range tests {
+		version, pattern, ok := config.ToolVersionForPath(tt.tool, tt.path)
+		assert.True(t, ok)
+		assert.Equal(t, tt.version, version, "%s in %s", tt.tool, tt.path)
+		assert.Equal(t, tt.pattern, pattern, "%s in %s", tt.tool, tt.path)
+	}
+
+	_, _, ok := config.ToolVersionForPath("kubectl", "services/api")
+	assert.False(t, ok)
+}
+
+func TestParseMergeStrategy(t *testing.T) {
+	for _, strategy := range []ConfigMergeStrategy{OverrideStrategy, MergeStrategy, IgnoreStrategy, AppendStrategy, ErrorOnConflictStrategy} {
+		parsed, err := ParseMergeStrategy(strategy.String())
+		assert.NoError(t, err)
+		assert.Equal(t, strategy, parsed)
+	}
+
+	parsed, err := ParseMergeStrategy("")
+	assert.NoError(t, err)
+	assert.Equal(t, OverrideStrategy, parsed)
+
+	_, err = ParseMergeStrategy("replace")
+	assert.Error(t, err)
+
+	assert.Equal(t, ErrorOnConflictStrategy, ResolutionSettings{MergeStrategy: "error"}.Strategy())
+	assert.Equal(t, OverrideStrategy, ResolutionSettings{MergeStrategy: "bogus"}.Strategy())
+}
+
+func TestDefaultConfigPaths_VmanHome(t *testing.T) {
+	t.Setenv(EnvVmanHome, "/data/vman")
+	t.Setenv(EnvVmanXDG, "1")
+
+	paths := DefaultConfigPaths("/home/test")
+	assert.Equal(t, "/data/vman", paths.ConfigDir)
+	assert.Equal(t, "/data/vman", paths.DataDir)
+	assert.Equal(t, "/data/vman/versions", paths.VersionsDir)
+	assert.Equal(t, "/data/vman/cache", paths.CacheDir)
+}
+
+func TestDefaultConfigPaths_XDG(t *testing.T) {
+	t.Setenv(EnvVmanHome, "")
+	t.Setenv(EnvVmanXDG, "true")
+	t.Setenv("XDG_CONFIG_HOME", "/xdg/config")
+	t.Setenv("XDG_DATA_HOME", "")
+	t.Setenv("XDG_CACHE_HOME", "/xdg/cache")
+
+	paths := DefaultConfigPaths("/home/test")
+	assert.Equal(t, "/xdg/config/vman", paths.ConfigDir)
+	assert.Equal(t, "/xdg/config/vman/config.yaml", paths.GlobalConfigFile)
+	assert.Equal(t, "/xdg/config/vman/tools", paths.ToolsDir)
+	assert.Equal(t, "/home/test/.local/share/vman", paths.DataDir)
+	assert.Equal(t, "/home/test/.local/share/vman/versions", paths.VersionsDir)
+	assert.Equal(t, "/home/test/.local/share/vman/shims", paths.ShimsDir)
+	assert.Equal(t, "/xdg/cache/vman", paths.CacheDir)
+	assert.Equal(t, "/xdg/cache/vman/tmp", paths.TempDir)
+}
+
+func TestParseByteSize(t *testing.T) {
+	tests := map[string]ByteSize{
+		"1024":  1024,
+		"512KB": 512 << 10,
+		"8gb":   8 << 30,
+		"1.5G":  3 << 29,
+		"2 MB":  2 << 20,
+		"10B":   10,
+	}
+	for text, expected := range tests {
+		size, err := ParseByteSize(text)
+		assert.NoError(t, err, text)
+		assert.Equal(t, expected, size, text)
+	}
+
+	for _, text := range []string{"", "abc", "-1GB", "1PB"} {
+		_, err := ParseByteSize(text)
+		assert.Error(t, err, text)
+	}
+
+	assert.Equal(t, "8GB", ByteSize(8<<30).String())
+	assert.Equal(t, "1536MB", ByteSize(3<<29).String())
+	assert.Equal(t, "1000", ByteSize(1000).String())
+}
+
+func TestByteSize_YAML(t *testing.T) {
+	var settings DownloadSettings
+	assert.NoError(t, yaml.Unmarshal([]byte("max_extracted_size: 4GB\n"), &settings))
+	assert.Equal(t, ByteSize(4<<30), settings.MaxExtractedSize)
+
+	assert.NoError(t, yaml.Unmarshal([]byte("max_extracted_size: 2048\n"), &settings))
+	assert.Equal(t, ByteSize(2048), settings.MaxExtractedSize)
+
+	data, err := yaml.Marshal(DownloadSettings{MaxExtractedSize: 4 << 30})
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "max_extracted_size: 4GB")
+}
@@ -0,0 +1,220 @@
+package types
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"runtime"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ToolCondition 项目配置中工具的启用条件
+// 设置的各项条件都满足时启用，每项条件中任一值匹配即满足
+type ToolCondition struct {
+	Only   []string `yaml:"only,omitempty"`   // 操作系统或 os/arch，如 darwin、linux/amd64
+	Hosts  []string `yaml:"hosts,omitempty"`  // 主机名的glob模式，不区分大小写
+	Labels []string `yaml:"labels,omitempty"` // 本机通过 VMAN_LABELS 设置的标签
+}
+
+// ConditionEnv 判断启用条件时使用的本机信息
+type ConditionEnv struct {
+	OS       string
+	Arch     string
+	Hostname string
+	Labels   []string
+}
+
+// CurrentConditionEnv 返回本机的平台、主机名和 VMAN_LABELS 中的标签
+func CurrentConditionEnv() ConditionEnv {
+	env := ConditionEnv{OS: runtime.GOOS, Arch: runtime.GOARCH}
+	env.Hostname, _ = os.Hostname()
+	for _, label := range strings.Split(os.Getenv(EnvVmanLabels), ",") {
+		if label = strings.TrimSpace(label); label != "" {
+			env.Labels = append(env.Labels, label)
+		}
+	}
+	return env
+}
+
+// IsEmpty 是否没有设置任何条件
+func (c ToolCondition) IsEmpty() bool {
+	return len(c.Only) == 0 && len(c.Hosts) == 0 && len(c.Labels) == 0
+}
+
+// Matches 本机是否满足启用条件
+func (c ToolCondition) Matches(env ConditionEnv) bool {
+	if len(c.Only) > 0 && !matchAny(c.Only, func(platform string) bool {
+		goos, arch, hasArch := strings.Cut(platform, "/")
+		return goos == env.OS && (!hasArch || arch == env.Arch)
+	}) {
+		return false
+	}
+	if len(c.Hosts) > 0 && !matchAny(c.Hosts, func(pattern string) bool {
+		matched, _ := path.Match(strings.ToLower(pattern), strings.ToLower(env.Hostname))
+		return matched
+	}) {
+		return false
+	}
+	if len(c.Labels) > 0 && !matchAny(c.Labels, func(label string) bool {
+		for _, l := range env.Labels {
+			if l == label {
+				return true
+			}
+		}
+		return false
+	}) {
+		return false
+	}
+	return true
+}
+
+// Validate 检查平台和主机名模式是否有效
+func (c ToolCondition) Validate() error {
+	for _, platform := range c.Only {
+		goos, arch, hasArch := strings.Cut(platform, "/")
+		if !IsKnownOS(goos) || (hasArch && arch == "") {
+			return fmt.Errorf("invalid platform %q in only, expected os or os/arch", platform)
+		}
+	}
+	for _, pattern := range c.Hosts {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid host pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// matchAny 是否有任一值满足 match
+func matchAny(values []string, match func(string) bool) bool {
+	for _, value := range values {
+		if match(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// projectTool tools 中写作对象的工具，可以设置启用条件和是否可选
+type projectTool struct {
+	Version       string `yaml:"version"`
+	Optional      bool   `yaml:"optional,omitempty"`
+	ToolCondition `yaml:",inline"`
+}
+
+// UnmarshalYAML 支持 tools 中的工具写作对象，如 {version: "2.15.0", only: [darwin], optional: true}
+// 本机不满足条件的工具不放入 Tools，记录在 Inactive 中
+func (c *ProjectConfig) UnmarshalYAML(node *yaml.Node) error {
+	type plain ProjectConfig
+	if node.Kind != yaml.MappingNode {
+		return node.Decode((*plain)(c))
+	}
+
+	rest := *node
+	rest.Content = nil
+	var toolsNode *yaml.Node
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == "tools" {
+			toolsNode = node.Content[i+1]
+			continue
+		}
+		rest.Content = append(rest.Content, node.Content[i], node.Content[i+1])
+	}
+	if err := rest.Decode((*plain)(c)); err != nil {
+		return err
+	}
+	if toolsNode == nil || toolsNode.Tag == "!!null" {
+		return nil
+	}
+	if toolsNode.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d: tools must be a mapping", toolsNode.Line)
+	}
+
+	c.Tools = make(map[string]string)
+	var env *ConditionEnv
+	for i := 0; i+1 < len(toolsNode.Content); i += 2 {
+		name, value := toolsNode.Content[i].Value, toolsNode.Content[i+1]
+		if value.Kind != yaml.MappingNode {
+			var version string
+			if err := value.Decode(&version); err != nil {
+				return err
+			}
+			c.Tools[name] = version
+			continue
+		}
+
+		var tool projectTool
+		if err := value.Decode(&tool); err != nil {
+			return err
+		}
+		if tool.Version == "" {
+			return fmt.Errorf("line %d: version is required for tool %s", value.Line, name)
+		}
+		if tool.Optional {
+			if c.Optional == nil {
+				c.Optional = make(map[string]bool)
+			}
+			c.Optional[name] = true
+		}
+		if tool.IsEmpty() {
+			c.Tools[name] = tool.Version
+			continue
+		}
+
+		if c.Conditions == nil {
+			c.Conditions = make(map[string]ToolCondition)
+		}
+		c.Conditions[name] = tool.ToolCondition
+		if env == nil {
+			current := CurrentConditionEnv()
+			env = &current
+		}
+		if tool.Matches(*env) {
+			c.Tools[name] = tool.Version
+			continue
+		}
+		if c.Inactive == nil {
+			c.Inactive = make(map[string]string)
+		}
+		c.Inactive[name] = tool.Version
+	}
+	return nil
+}
+
+// MarshalYAML 保存时写回启用条件、可选标记和本机未启用的工具
+func (c ProjectConfig) MarshalYAML() (interface{}, error) {
+	type plain ProjectConfig
+	if len(c.Conditions) == 0 && len(c.Inactive) == 0 && len(c.Optional) == 0 {
+		return plain(c), nil
+	}
+
+	tools := make(map[string]interface{}, len(c.Tools)+len(c.Inactive))
+	for name, version := range c.Inactive {
+		tools[name] = version
+	}
+	for name, version := range c.Tools {
+		tools[name] = version
+	}
+	for name, version := range tools {
+		condition, conditional := c.Conditions[name]
+		if conditional || c.Optional[name] {
+			tools[name] = projectTool{Version: version.(string), Optional: c.Optional[name], ToolCondition: condition}
+		}
+	}
+
+	out := plain(c)
+	out.Tools = nil
+	node := &yaml.Node{}
+	if err := node.Encode(out); err != nil {
+		return nil, err
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == "tools" {
+			if err := node.Content[i+1].Encode(tools); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return node, nil
+}
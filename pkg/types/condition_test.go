@@ -0,0 +1,98 @@
+package types
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestToolCondition_Matches(t *testing.T) {
+	env := ConditionEnv{OS: "linux", Arch: "amd64", Hostname: "CI-Runner-3", Labels: []string{"ci", "gpu"}}
+
+	assert.True(t, ToolCondition{}.Matches(env))
+	assert.True(t, ToolCondition{Only: []string{"darwin", "linux/amd64"}}.Matches(env))
+	assert.True(t, ToolCondition{Only: []string{"linux"}}.Matches(env))
+	assert.False(t, ToolCondition{Only: []string{"linux/arm64", "windows"}}.Matches(env))
+	assert.True(t, ToolCondition{Hosts: []string{"ci-runner-*"}}.Matches(env))
+	assert.False(t, ToolCondition{Hosts: []string{"dev-*"}}.Matches(env))
+	assert.True(t, ToolCondition{Labels: []string{"gpu"}}.Matches(env))
+	// 各项条件都需要满足
+	assert.False(t, ToolCondition{Only: []string{"linux"}, Labels: []string{"desktop"}}.Matches(env))
+
+	assert.NoError(t, ToolCondition{Only: []string{"darwin", "linux/amd64"}, Hosts: []string{"ci-*"}}.Validate())
+	assert.Error(t, ToolCondition{Only: []string{"macos"}}.Validate())
+	assert.Error(t, ToolCondition{Only: []string{"linux/"}}.Validate())
+	assert.Error(t, ToolCondition{Hosts: []string{"ci-["}}.Validate())
+}
+
+func TestProjectConfig_ConditionalTools(t *testing.T) {
+	other := "windows"
+	if runtime.GOOS == "windows" {
+		other = "linux"
+	}
+	data := []byte(`version: "1.0"
+tools:
+  kubectl: 1.29.0
+  awscli:
+    version: 2.15.0
+    only: [` + runtime.GOOS + `]
+  winget:
+    version: 1.7.0
+    only: [` + other + `]
+paths:
+  infra/**:
+    terraform: 1.7.0
+`)
+
+	var config ProjectConfig
+	require.NoError(t, yaml.Unmarshal(data, &config))
+	assert.Equal(t, map[string]string{"kubectl": "1.29.0", "awscli": "2.15.0"}, config.Tools)
+	assert.Equal(t, map[string]string{"winget": "1.7.0"}, config.Inactive)
+	assert.Equal(t, []string{other}, config.Conditions["winget"].Only)
+	assert.Equal(t, "1.7.0", config.Paths["infra/**"]["terraform"])
+
+	// 保存时写回条件和本机未启用的工具
+	config.Tools["kubectl"] = "1.30.0"
+	out, err := yaml.Marshal(&config)
+	require.NoError(t, err)
+	var reloaded ProjectConfig
+	require.NoError(t, yaml.Unmarshal(out, &reloaded))
+	assert.Equal(t, map[string]string{"kubectl": "1.30.0", "awscli": "2.15.0"}, reloaded.Tools)
+	assert.Equal(t, config.Inactive, reloaded.Inactive)
+	assert.Equal(t, config.Conditions, reloaded.Conditions)
+	assert.Equal(t, config.Paths, reloaded.Paths)
+
+	// 没有条件时保持原来的格式
+	out, err = yaml.Marshal(&ProjectConfig{Version: "1.0", Tools: map[string]string{"kubectl": "1.29.0"}})
+	require.NoError(t, err)
+	assert.Contains(t, string(out), "kubectl: 1.29.0")
+
+	assert.Error(t, yaml.Unmarshal([]byte("tools:\n  awscli:\n    only: [linux]\n"), &ProjectConfig{}))
+}
+
+func TestProjectConfig_OptionalTools(t *testing.T) {
+	var config ProjectConfig
+	require.NoError(t, yaml.Unmarshal([]byte(`tools:
+  terraform: 1.7.0
+  hugo:
+    version: 0.121.0
+    optional: true
+groups:
+  docs: [hugo]
+`), &config))
+	assert.Equal(t, map[string]string{"terraform": "1.7.0", "hugo": "0.121.0"}, config.Tools)
+	assert.True(t, config.IsOptional("hugo"))
+	assert.False(t, config.IsOptional("terraform"))
+	assert.Equal(t, []string{"hugo"}, config.Groups["docs"])
+
+	out, err := yaml.Marshal(&config)
+	require.NoError(t, err)
+	var reloaded ProjectConfig
+	require.NoError(t, yaml.Unmarshal(out, &reloaded))
+	assert.Equal(t, config.Tools, reloaded.Tools)
+	assert.Equal(t, config.Optional, reloaded.Optional)
+	assert.Equal(t, config.Groups, reloaded.Groups)
+}
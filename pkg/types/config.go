@@ -1,9 +1,11 @@
 package types
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"time"
 )
 
@@ -63,11 +65,58 @@ func (e *ConfigValidationError) Error() string {
 	return e.Message
 }
 
+const (
+	// EnvVmanHome 指定vman根目录的环境变量，设置后所有数据都存放在该目录下
+	EnvVmanHome = "VMAN_HOME"
+
+	// EnvVmanXDG 启用XDG基础目录拆分的环境变量（配置、数据、缓存分别存放）
+	EnvVmanXDG = "VMAN_XDG"
+
+	// EnvVmanSystemRoot 指定多用户共享的系统级存储根目录的环境变量
+	EnvVmanSystemRoot = "VMAN_SYSTEM_ROOT"
+
+	// EnvVmanAPIToken vman serve 使用的访问令牌
+	EnvVmanAPIToken = "VMAN_API_TOKEN"
+
+	// EnvVmanReadOnly 启用只读模式的环境变量，优先于 settings.readonly
+	EnvVmanReadOnly = "VMAN_READONLY"
+
+	// EnvVmanProjectPath 代理执行工具时传给子进程的项目目录
+	EnvVmanProjectPath = "VMAN_PROJECT_PATH"
+
+	// EnvVmanResolution 代理执行工具时传给子进程的已解析版本，格式为 tool@version,tool@version
+	EnvVmanResolution = "VMAN_RESOLUTION"
+
+	// EnvVmanNoDefaultArgs 设置为 1 时不注入项目配置中的默认参数和环境变量
+	EnvVmanNoDefaultArgs = "VMAN_NO_DEFAULT_ARGS"
+
+	// EnvVmanFastPath 控制垫片的快速路径：1 始终尝试，0 禁用，未设置或为 auto 时只在容器中尝试
+	EnvVmanFastPath = "VMAN_FAST_PATH"
+
+	// EnvVmanLabels 本机的标签，逗号分隔，用于项目配置中按标签启用工具
+	EnvVmanLabels = "VMAN_LABELS"
+
+	// EnvVmanDeprecated 使用弃用版本时的处理方式，优先于 settings.resolution.deprecated
+	EnvVmanDeprecated = "VMAN_DEPRECATED"
+
+	// EnvVmanTempDir 下载和解压安装包的临时目录，优先于 settings.storage.temp_dir
+	EnvVmanTempDir = "VMAN_TEMP_DIR"
+
+	// EnvVmanAgeKey 解密配置中 age 加密的值使用的私钥（AGE-SECRET-KEY-1...）
+	EnvVmanAgeKey = "VMAN_AGE_KEY"
+
+	// EnvVmanAgeKeyFile 解密配置中 age 加密的值使用的私钥文件
+	EnvVmanAgeKeyFile = "VMAN_AGE_KEY_FILE"
+)
+
 // ConfigPaths 配置路径结构
 type ConfigPaths struct {
 	// ConfigDir 配置根目录 (~/.vman)
 	ConfigDir string
 
+	// DataDir 数据根目录，未拆分时与 ConfigDir 相同
+	DataDir string
+
 	// GlobalConfigFile 全局配置文件 (~/.vman/config.yaml)
 	GlobalConfigFile string
 
@@ -93,10 +142,54 @@ type ConfigPaths struct {
 	TempDir string
 }
 
+// PlatformDirName 当前平台在版本存储中的子目录名，如 linux-amd64
+// 版本目录可能位于多台机器共享的网络文件系统上，按平台分开存放二进制文件
+func PlatformDirName() string {
+	return runtime.GOOS + "-" + runtime.GOARCH
+}
+
+// IsPlatformDirName 检查目录名是否为 <os>-<arch> 形式的平台子目录
+func IsPlatformDirName(name string) bool {
+	parts := strings.SplitN(name, "-", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return false
+	}
+	return IsKnownOS(parts[0])
+}
+
+// IsKnownOS 检查是否为 Go 支持的操作系统名（GOOS）
+func IsKnownOS(name string) bool {
+	switch name {
+	case "aix", "android", "darwin", "dragonfly", "freebsd", "illumos", "ios", "js",
+		"linux", "netbsd", "openbsd", "plan9", "solaris", "wasip1", "windows":
+		return true
+	}
+	return false
+}
+
+// ToolVersionDir 工具版本在当前平台下的安装目录 (versions/<tool>/<version>/<os>-<arch>)
+func (p *ConfigPaths) ToolVersionDir(tool, version string) string {
+	return filepath.Join(p.VersionsDir, tool, version, PlatformDirName())
+}
+
 // DefaultConfigPaths 创建默认配置路径
+// 优先级: VMAN_HOME > VMAN_XDG 拆分目录 > 平台默认目录
 func DefaultConfigPaths(homeDir string) *ConfigPaths {
+	if vmanHome := os.Getenv(EnvVmanHome); vmanHome != "" {
+		return ConfigPathsFromRoot(vmanHome)
+	}
+
+	if xdgEnabled() {
+		return XDGConfigPaths(homeDir)
+	}
+
+	return PlatformConfigPaths(homeDir)
+}
+
+// PlatformConfigPaths 创建平台默认的配置路径（忽略 VMAN_HOME 和 VMAN_XDG）
+func PlatformConfigPaths(homeDir string) *ConfigPaths {
 	var configDir string
-	
+
 	// 根据操作系统确定配置目录
 	switch runtime.GOOS {
 	case "darwin":
@@ -111,26 +204,66 @@ func DefaultConfigPaths(homeDir string) *ConfigPaths {
 		}
 	default:
 		// Linux and other Unix-like: ~/.config/vman
-		if xdgConfig := os.Getenv("XDG_CONFIG_HOME"); xdgConfig != "" {
-			configDir = filepath.Join(xdgConfig, "vman")
-		} else {
-			configDir = filepath.Join(homeDir, ".config", "vman")
-		}
+		configDir = xdgDir("XDG_CONFIG_HOME", homeDir, ".config")
 	}
-	
+
+	return ConfigPathsFromRoot(configDir)
+}
+
+// ConfigPathsFromRoot 创建所有数据都位于同一根目录下的配置路径
+func ConfigPathsFromRoot(root string) *ConfigPaths {
+	return &ConfigPaths{
+		ConfigDir:        root,
+		DataDir:          root,
+		GlobalConfigFile: filepath.Join(root, "config.yaml"),
+		ToolsDir:         filepath.Join(root, "tools"),
+		BinDir:           filepath.Join(root, "bin"),
+		ShimsDir:         filepath.Join(root, "shims"),
+		VersionsDir:      filepath.Join(root, "versions"),
+		LogsDir:          filepath.Join(root, "logs"),
+		CacheDir:         filepath.Join(root, "cache"),
+		TempDir:          filepath.Join(root, "tmp"),
+	}
+}
+
+// XDGConfigPaths 按XDG基础目录规范拆分的配置路径
+// 配置: $XDG_CONFIG_HOME/vman，数据: $XDG_DATA_HOME/vman，缓存: $XDG_CACHE_HOME/vman
+func XDGConfigPaths(homeDir string) *ConfigPaths {
+	configDir := xdgDir("XDG_CONFIG_HOME", homeDir, ".config")
+	dataDir := xdgDir("XDG_DATA_HOME", homeDir, filepath.Join(".local", "share"))
+	cacheDir := xdgDir("XDG_CACHE_HOME", homeDir, ".cache")
+
 	return &ConfigPaths{
 		ConfigDir:        configDir,
+		DataDir:          dataDir,
 		GlobalConfigFile: filepath.Join(configDir, "config.yaml"),
 		ToolsDir:         filepath.Join(configDir, "tools"),
-		BinDir:           filepath.Join(configDir, "bin"),
-		ShimsDir:         filepath.Join(configDir, "shims"),
-		VersionsDir:      filepath.Join(configDir, "versions"),
-		LogsDir:          filepath.Join(configDir, "logs"),
-		CacheDir:         filepath.Join(configDir, "cache"),
-		TempDir:          filepath.Join(configDir, "tmp"),
+		BinDir:           filepath.Join(dataDir, "bin"),
+		ShimsDir:         filepath.Join(dataDir, "shims"),
+		VersionsDir:      filepath.Join(dataDir, "versions"),
+		LogsDir:          filepath.Join(dataDir, "logs"),
+		CacheDir:         cacheDir,
+		TempDir:          filepath.Join(cacheDir, "tmp"),
 	}
 }
 
+// xdgDir 返回XDG环境变量指定的目录下的vman目录，未设置时使用 homeDir/fallback
+func xdgDir(env, homeDir, fallback string) string {
+	if dir := os.Getenv(env); dir != "" {
+		return filepath.Join(dir, "vman")
+	}
+	return filepath.Join(homeDir, fallback, "vman")
+}
+
+// xdgEnabled 是否启用了XDG目录拆分
+func xdgEnabled() bool {
+	switch strings.ToLower(os.Getenv(EnvVmanXDG)) {
+	case "1", "true", "yes", "on":
+		return true
+	}
+	return false
+}
+
 // GlobalConfigDefaults 全局配置默认值
 type GlobalConfigDefaults struct {
 	Version  string
@@ -172,6 +305,7 @@ func GetDefaultGlobalConfig() *GlobalConfig {
 				Timeout:             300 * time.Second,
 				Retries:             3,
 				ConcurrentDownloads: 2,
+				PreserveMtime:       true,
 			},
 			Proxy: ProxySettings{
 				Enabled:     true,
@@ -181,6 +315,9 @@ func GetDefaultGlobalConfig() *GlobalConfig {
 				Level: "info",
 				File:  "~/.vman/logs/vman.log",
 			},
+			Resolution: ResolutionSettings{
+				Fallback: FallbackLatestInstalled,
+			},
 		},
 		GlobalVersions: make(map[string]string),
 		Tools:          make(map[string]ToolInfo),
@@ -205,8 +342,48 @@ const (
 	MergeStrategy
 	// IgnoreStrategy 忽略策略：只使用全局配置
 	IgnoreStrategy
+	// AppendStrategy 追加策略：上层配置只添加下层没有配置的工具，不覆盖已有版本
+	AppendStrategy
+	// ErrorOnConflictStrategy 冲突报错策略：上层配置与下层配置的版本不一致时返回错误
+	ErrorOnConflictStrategy
 )
 
+// String 返回合并策略的名称
+func (s ConfigMergeStrategy) String() string {
+	switch s {
+	case OverrideStrategy:
+		return "override"
+	case MergeStrategy:
+		return "merge"
+	case IgnoreStrategy:
+		return "ignore"
+	case AppendStrategy:
+		return "append"
+	case ErrorOnConflictStrategy:
+		return "error"
+	default:
+		return fmt.Sprintf("ConfigMergeStrategy(%d)", int(s))
+	}
+}
+
+// ParseMergeStrategy 解析合并策略名称，空字符串为覆盖策略
+func ParseMergeStrategy(name string) (ConfigMergeStrategy, error) {
+	switch name {
+	case "", "override":
+		return OverrideStrategy, nil
+	case "merge":
+		return MergeStrategy, nil
+	case "ignore":
+		return IgnoreStrategy, nil
+	case "append":
+		return AppendStrategy, nil
+	case "error":
+		return ErrorOnConflictStrategy, nil
+	default:
+		return OverrideStrategy, fmt.Errorf("unknown merge strategy: %s", name)
+	}
+}
+
 // EffectiveConfig 有效配置（合并后的配置）
 type EffectiveConfig struct {
 	// GlobalConfig 全局配置
@@ -219,7 +396,7 @@ type EffectiveConfig struct {
 	ResolvedVersions map[string]string
 
 	// ConfigSource 配置来源映射
-	ConfigSource map[string]string // "global" or project path
+	ConfigSource map[string]string // "global", "global_tool" or "project"
 }
 
 // VersionResolution 版本解析结果
@@ -0,0 +1,22 @@
+package types
+
+const (
+	// EnvTFWorkspace terraform 和 tofu 选择工作区的环境变量，优先于 .terraform/environment
+	EnvTFWorkspace = "TF_WORKSPACE"
+
+	// DefaultWorkspace 没有选择工作区时 terraform 使用的工作区
+	DefaultWorkspace = "default"
+)
+
+// WorkspaceTools 按项目配置 workspaces 选择版本的工具
+var WorkspaceTools = []string{"terraform", "tofu", "opentofu"}
+
+// IsWorkspaceTool 工具是否按 terraform 工作区选择版本
+func IsWorkspaceTool(toolName string) bool {
+	for _, tool := range WorkspaceTools {
+		if tool == toolName {
+			return true
+		}
+	}
+	return false
+}
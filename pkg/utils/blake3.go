@@ -0,0 +1,227 @@
+package utils
+
+import (
+	"encoding/binary"
+	"hash"
+	"math/bits"
+)
+
+// BLAKE3 哈希模式的实现，输出 32 字节摘要，不支持密钥和密钥派生模式
+
+const (
+	blake3BlockLen = 64
+	blake3ChunkLen = 1024
+	blake3Size     = 32
+
+	blake3ChunkStart = 1 << 0
+	blake3ChunkEnd   = 1 << 1
+	blake3Parent     = 1 << 2
+	blake3Root       = 1 << 3
+)
+
+var blake3IV = [8]uint32{
+	0x6A09E667, 0xBB67AE85, 0x3C6EF372, 0xA54FF53A,
+	0x510E527F, 0x9B05688C, 0x1F83D9AB, 0x5BE0CD19,
+}
+
+var blake3MsgPermutation = [16]int{2, 6, 3, 10, 7, 0, 4, 13, 1, 11, 12, 5, 9, 14, 15, 8}
+
+func blake3G(s *[16]uint32, a, b, c, d int, mx, my uint32) {
+	s[a] = s[a] + s[b] + mx
+	s[d] = bits.RotateLeft32(s[d]^s[a], -16)
+	s[c] = s[c] + s[d]
+	s[b] = bits.RotateLeft32(s[b]^s[c], -12)
+	s[a] = s[a] + s[b] + my
+	s[d] = bits.RotateLeft32(s[d]^s[a], -8)
+	s[c] = s[c] + s[d]
+	s[b] = bits.RotateLeft32(s[b]^s[c], -7)
+}
+
+func blake3Round(s *[16]uint32, m *[16]uint32) {
+	blake3G(s, 0, 4, 8, 12, m[0], m[1])
+	blake3G(s, 1, 5, 9, 13, m[2], m[3])
+	blake3G(s, 2, 6, 10, 14, m[4], m[5])
+	blake3G(s, 3, 7, 11, 15, m[6], m[7])
+	blake3G(s, 0, 5, 10, 15, m[8], m[9])
+	blake3G(s, 1, 6, 11, 12, m[10], m[11])
+	blake3G(s, 2, 7, 8, 13, m[12], m[13])
+	blake3G(s, 3, 4, 9, 14, m[14], m[15])
+}
+
+func blake3Compress(cv *[8]uint32, block *[16]uint32, counter uint64, blockLen, flags uint32) [16]uint32 {
+	s := [16]uint32{
+		cv[0], cv[1], cv[2], cv[3], cv[4], cv[5], cv[6], cv[7],
+		blake3IV[0], blake3IV[1], blake3IV[2], blake3IV[3],
+		uint32(counter), uint32(counter >> 32), blockLen, flags,
+	}
+	m := *block
+	for r := 0; r < 7; r++ {
+		blake3Round(&s, &m)
+		if r < 6 {
+			var permuted [16]uint32
+			for i, p := range blake3MsgPermutation {
+				permuted[i] = m[p]
+			}
+			m = permuted
+		}
+	}
+	for i := 0; i < 8; i++ {
+		s[i] ^= s[i+8]
+		s[i+8] ^= cv[i]
+	}
+	return s
+}
+
+func blake3Words(block []byte) [16]uint32 {
+	var padded [blake3BlockLen]byte
+	copy(padded[:], block)
+	var words [16]uint32
+	for i := range words {
+		words[i] = binary.LittleEndian.Uint32(padded[i*4:])
+	}
+	return words
+}
+
+func blake3First8(words [16]uint32) [8]uint32 {
+	var cv [8]uint32
+	copy(cv[:], words[:8])
+	return cv
+}
+
+// blake3Output 尚未确定是否为根节点的压缩输入
+type blake3Output struct {
+	inputCV  [8]uint32
+	block    [16]uint32
+	counter  uint64
+	blockLen uint32
+	flags    uint32
+}
+
+func (o *blake3Output) chainingValue() [8]uint32 {
+	return blake3First8(blake3Compress(&o.inputCV, &o.block, o.counter, o.blockLen, o.flags))
+}
+
+func (o *blake3Output) rootBytes(out []byte) {
+	words := blake3Compress(&o.inputCV, &o.block, 0, o.blockLen, o.flags|blake3Root)
+	var buf [64]byte
+	for i, w := range words {
+		binary.LittleEndian.PutUint32(buf[i*4:], w)
+	}
+	copy(out, buf[:])
+}
+
+func blake3ParentOutput(left, right [8]uint32) *blake3Output {
+	var block [16]uint32
+	copy(block[:8], left[:])
+	copy(block[8:], right[:])
+	return &blake3Output{inputCV: blake3IV, block: block, blockLen: blake3BlockLen, flags: blake3Parent}
+}
+
+// blake3ChunkState 单个 1024 字节块的压缩状态
+type blake3ChunkState struct {
+	cv               [8]uint32
+	chunkCounter     uint64
+	block            [blake3BlockLen]byte
+	blockLen         int
+	blocksCompressed int
+}
+
+func newBlake3ChunkState(chunkCounter uint64) blake3ChunkState {
+	return blake3ChunkState{cv: blake3IV, chunkCounter: chunkCounter}
+}
+
+func (c *blake3ChunkState) len() int {
+	return blake3BlockLen*c.blocksCompressed + c.blockLen
+}
+
+func (c *blake3ChunkState) startFlag() uint32 {
+	if c.blocksCompressed == 0 {
+		return blake3ChunkStart
+	}
+	return 0
+}
+
+func (c *blake3ChunkState) update(input []byte) {
+	for len(input) > 0 {
+		if c.blockLen == blake3BlockLen {
+			words := blake3Words(c.block[:])
+			c.cv = blake3First8(blake3Compress(&c.cv, &words, c.chunkCounter, blake3BlockLen, c.startFlag()))
+			c.blocksCompressed++
+			c.block = [blake3BlockLen]byte{}
+			c.blockLen = 0
+		}
+		n := copy(c.block[c.blockLen:], input)
+		c.blockLen += n
+		input = input[n:]
+	}
+}
+
+func (c *blake3ChunkState) output() *blake3Output {
+	return &blake3Output{
+		inputCV:  c.cv,
+		block:    blake3Words(c.block[:c.blockLen]),
+		counter:  c.chunkCounter,
+		blockLen: uint32(c.blockLen),
+		flags:    c.startFlag() | blake3ChunkEnd,
+	}
+}
+
+// blake3Hasher 实现 hash.Hash
+type blake3Hasher struct {
+	chunk   blake3ChunkState
+	cvStack [][8]uint32
+}
+
+// NewBlake3 创建 BLAKE3 哈希，摘要长度为 32 字节
+func NewBlake3() hash.Hash {
+	return &blake3Hasher{chunk: newBlake3ChunkState(0)}
+}
+
+func (h *blake3Hasher) addChunkChainingValue(cv [8]uint32, totalChunks uint64) {
+	// 每完成一个块，按完成块数二进制末尾 0 的个数合并子树
+	for totalChunks&1 == 0 {
+		top := h.cvStack[len(h.cvStack)-1]
+		h.cvStack = h.cvStack[:len(h.cvStack)-1]
+		cv = blake3ParentOutput(top, cv).chainingValue()
+		totalChunks >>= 1
+	}
+	h.cvStack = append(h.cvStack, cv)
+}
+
+func (h *blake3Hasher) Write(p []byte) (int, error) {
+	n := len(p)
+	for len(p) > 0 {
+		if h.chunk.len() == blake3ChunkLen {
+			cv := h.chunk.output().chainingValue()
+			totalChunks := h.chunk.chunkCounter + 1
+			h.addChunkChainingValue(cv, totalChunks)
+			h.chunk = newBlake3ChunkState(totalChunks)
+		}
+		want := blake3ChunkLen - h.chunk.len()
+		if want > len(p) {
+			want = len(p)
+		}
+		h.chunk.update(p[:want])
+		p = p[want:]
+	}
+	return n, nil
+}
+
+func (h *blake3Hasher) Sum(b []byte) []byte {
+	output := h.chunk.output()
+	for i := len(h.cvStack) - 1; i >= 0; i-- {
+		output = blake3ParentOutput(h.cvStack[i], output.chainingValue())
+	}
+	var out [blake3Size]byte
+	output.rootBytes(out[:])
+	return append(b, out[:]...)
+}
+
+func (h *blake3Hasher) Reset() {
+	h.chunk = newBlake3ChunkState(0)
+	h.cvStack = h.cvStack[:0]
+}
+
+func (h *blake3Hasher) Size() int { return blake3Size }
+
+func (h *blake3Hasher) BlockSize() int { return blake3BlockLen }
@@ -0,0 +1,179 @@
+package utils
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// 支持的校验和算法
+const (
+	ChecksumSHA256 = "sha256"
+	ChecksumSHA512 = "sha512"
+	ChecksumBLAKE3 = "blake3"
+	// ChecksumXXH64 只用于缓存文件的完整性检查，不能用于安全校验
+	ChecksumXXH64 = "xxh64"
+)
+
+// parallelHashThreshold 单次写入超过该大小且有多个哈希时并行计算
+const parallelHashThreshold = 32 * 1024
+
+// NewChecksumHash 按算法名称创建哈希，名称不区分大小写
+func NewChecksumHash(algorithm string) (hash.Hash, error) {
+	switch strings.ToLower(algorithm) {
+	case ChecksumSHA256:
+		return sha256.New(), nil
+	case ChecksumSHA512:
+		return sha512.New(), nil
+	case ChecksumBLAKE3:
+		return NewBlake3(), nil
+	case ChecksumXXH64:
+		return NewXXHash64(), nil
+	default:
+		return nil, fmt.Errorf("unsupported checksum algorithm: %s", algorithm)
+	}
+}
+
+// ParseChecksum 解析 "算法:十六进制摘要" 形式的校验和
+// 没有算法前缀时按摘要长度推断：64 位为 sha256，128 位为 sha512
+func ParseChecksum(checksum string) (algorithm, digest string, err error) {
+	checksum = strings.TrimSpace(checksum)
+	if i := strings.Index(checksum, ":"); i >= 0 {
+		algorithm, digest = strings.ToLower(checksum[:i]), checksum[i+1:]
+	} else {
+		digest = checksum
+		switch len(digest) {
+		case sha256.Size * 2:
+			algorithm = ChecksumSHA256
+		case sha512.Size * 2:
+			algorithm = ChecksumSHA512
+		default:
+			return "", "", fmt.Errorf("cannot infer checksum algorithm from %d hex digits, use a prefix such as sha256:", len(digest))
+		}
+	}
+
+	if _, err := NewChecksumHash(algorithm); err != nil {
+		return "", "", err
+	}
+	if _, err := hex.DecodeString(digest); err != nil {
+		return "", "", fmt.Errorf("invalid %s checksum %q: %w", algorithm, digest, err)
+	}
+	return algorithm, strings.ToLower(digest), nil
+}
+
+// MultiHasher 一次读取同时计算多个算法的摘要，数据块较大时各算法并行计算
+type MultiHasher struct {
+	algorithms []string
+	hashes     []hash.Hash
+	written    int64
+}
+
+// NewMultiHasher 创建计算指定算法摘要的 MultiHasher，重复的算法只计算一次
+func NewMultiHasher(algorithms ...string) (*MultiHasher, error) {
+	m := &MultiHasher{}
+	seen := make(map[string]bool)
+	for _, algorithm := range algorithms {
+		algorithm = strings.ToLower(algorithm)
+		if seen[algorithm] {
+			continue
+		}
+		seen[algorithm] = true
+
+		h, err := NewChecksumHash(algorithm)
+		if err != nil {
+			return nil, err
+		}
+		m.algorithms = append(m.algorithms, algorithm)
+		m.hashes = append(m.hashes, h)
+	}
+	return m, nil
+}
+
+// Write 将数据写入所有哈希
+func (m *MultiHasher) Write(p []byte) (int, error) {
+	m.written += int64(len(p))
+	if len(m.hashes) < 2 || len(p) < parallelHashThreshold {
+		for _, h := range m.hashes {
+			h.Write(p)
+		}
+		return len(p), nil
+	}
+
+	var wg sync.WaitGroup
+	for _, h := range m.hashes {
+		wg.Add(1)
+		go func(h hash.Hash) {
+			defer wg.Done()
+			h.Write(p)
+		}(h)
+	}
+	wg.Wait()
+	return len(p), nil
+}
+
+// Algorithms 参与计算的算法
+func (m *MultiHasher) Algorithms() []string {
+	return append([]string(nil), m.algorithms...)
+}
+
+// Written 已写入的字节数
+func (m *MultiHasher) Written() int64 {
+	return m.written
+}
+
+// Sum 返回指定算法的十六进制摘要，算法未参与计算时返回空字符串
+func (m *MultiHasher) Sum(algorithm string) string {
+	algorithm = strings.ToLower(algorithm)
+	for i, a := range m.algorithms {
+		if a == algorithm {
+			return hex.EncodeToString(m.hashes[i].Sum(nil))
+		}
+	}
+	return ""
+}
+
+// Verify 将摘要与 "算法:十六进制摘要" 形式的校验和比对
+func (m *MultiHasher) Verify(expected string) error {
+	algorithm, digest, err := ParseChecksum(expected)
+	if err != nil {
+		return err
+	}
+	actual := m.Sum(algorithm)
+	if actual == "" {
+		return fmt.Errorf("%s digest was not computed", algorithm)
+	}
+	if actual != digest {
+		return fmt.Errorf("%s checksum mismatch: expected %s, got %s", algorithm, digest, actual)
+	}
+	return nil
+}
+
+// CalculateFileChecksums 读取一次文件，计算多个算法的十六进制摘要
+func CalculateFileChecksums(filePath string, algorithms ...string) (map[string]string, error) {
+	hasher, err := NewMultiHasher(algorithms...)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file %s: %w", filePath, err)
+	}
+	defer file.Close()
+
+	if _, err := io.CopyBuffer(hasher, file, make([]byte, 1024*1024)); err != nil {
+		return nil, fmt.Errorf("failed to calculate checksum: %w", err)
+	}
+
+	sums := make(map[string]string, len(hasher.algorithms))
+	for _, algorithm := range hasher.algorithms {
+		sums[algorithm] = hasher.Sum(algorithm)
+	}
+	return sums, nil
+}
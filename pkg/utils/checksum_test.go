@@ -0,0 +1,133 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testInput 与 BLAKE3 官方测试向量相同的输入：第 i 个字节为 i % 251
+func testInput(n int) []byte {
+	input := make([]byte, n)
+	for i := range input {
+		input[i] = byte(i % 251)
+	}
+	return input
+}
+
+func TestBlake3(t *testing.T) {
+	tests := []struct {
+		length int
+		want   string
+	}{
+		{0, "af1349b9f5f9a1a6a0404dea36dcc9499bcb25c9adc112b7cc9a93cae41f3262"},
+		{1, "2d3adedff11b61f14c886e35afa036736dcd87a74d27b5c1510225d0f592e213"},
+		{1024, "42214739f095a406f3fc83deb889744ac00df831c10daa55189b5d121c855af7"},
+		{1025, "d00278ae47eb27b34faecf67b4fe263f82d5412916c1ffd97c8cb7fb814b8444"},
+	}
+
+	for _, tt := range tests {
+		h := NewBlake3()
+		h.Write(testInput(tt.length))
+		assert.Equal(t, tt.want, hex.EncodeToString(h.Sum(nil)), "length %d", tt.length)
+	}
+
+	// 分多次写入与一次写入的结果相同
+	input := testInput(10 * 1024)
+	whole := NewBlake3()
+	whole.Write(input)
+	pieces := NewBlake3()
+	for len(input) > 0 {
+		n := 777
+		if n > len(input) {
+			n = len(input)
+		}
+		pieces.Write(input[:n])
+		input = input[n:]
+	}
+	assert.Equal(t, whole.Sum(nil), pieces.Sum(nil))
+}
+
+func TestXXHash64(t *testing.T) {
+	tests := []struct {
+		input string
+		want  uint64
+	}{
+		{"", 0xef46db3751d8e999},
+		{"a", 0xd24ec4f1a98c6e5b},
+		{"abc", 0x44bc2cf5ad770999},
+	}
+
+	for _, tt := range tests {
+		h := NewXXHash64()
+		h.Write([]byte(tt.input))
+		assert.Equal(t, tt.want, h.Sum64(), "input %q", tt.input)
+	}
+
+	input := testInput(1000)
+	whole := NewXXHash64()
+	whole.Write(input)
+	pieces := NewXXHash64()
+	pieces.Write(input[:7])
+	pieces.Write(input[7:40])
+	pieces.Write(input[40:])
+	assert.Equal(t, whole.Sum64(), pieces.Sum64())
+}
+
+func TestParseChecksum(t *testing.T) {
+	sha256Hex := "E3B0C44298FC1C149AFBF4C8996FB92427AE41E4649B934CA495991B7852B855"
+
+	algorithm, digest, err := ParseChecksum(sha256Hex)
+	require.NoError(t, err)
+	assert.Equal(t, ChecksumSHA256, algorithm)
+	assert.Equal(t, "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855", digest)
+
+	algorithm, _, err = ParseChecksum("BLAKE3:" + sha256Hex)
+	require.NoError(t, err)
+	assert.Equal(t, ChecksumBLAKE3, algorithm)
+
+	_, _, err = ParseChecksum("md5:d41d8cd98f00b204e9800998ecf8427e")
+	assert.Error(t, err)
+
+	_, _, err = ParseChecksum("abcd")
+	assert.Error(t, err)
+
+	_, _, err = ParseChecksum("sha256:not-hex")
+	assert.Error(t, err)
+}
+
+func TestMultiHasher(t *testing.T) {
+	input := testInput(256 * 1024)
+
+	hasher, err := NewMultiHasher(ChecksumSHA256, ChecksumSHA512, ChecksumBLAKE3, ChecksumSHA256)
+	require.NoError(t, err)
+	assert.Equal(t, []string{ChecksumSHA256, ChecksumSHA512, ChecksumBLAKE3}, hasher.Algorithms())
+
+	// 大块写入时并行计算
+	_, err = bytes.NewReader(input).WriteTo(hasher)
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(input)), hasher.Written())
+
+	path := filepath.Join(t.TempDir(), "artifact")
+	require.NoError(t, os.WriteFile(path, input, 0644))
+	sums, err := CalculateFileChecksums(path, ChecksumSHA256, ChecksumSHA512, ChecksumBLAKE3)
+	require.NoError(t, err)
+
+	single, err := CalculateFileChecksum(path)
+	require.NoError(t, err)
+	assert.Equal(t, single, sums[ChecksumSHA256])
+
+	for algorithm, sum := range sums {
+		assert.Equal(t, sum, hasher.Sum(algorithm), algorithm)
+		assert.NoError(t, hasher.Verify(algorithm+":"+sum))
+	}
+	assert.NoError(t, hasher.Verify(sums[ChecksumSHA512]))
+
+	assert.Error(t, hasher.Verify("sha256:"+sums[ChecksumBLAKE3]))
+	assert.Error(t, hasher.Verify("xxh64:0000000000000000"))
+}
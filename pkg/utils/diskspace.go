@@ -0,0 +1,32 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// AvailableDiskSpace 返回路径所在文件系统中当前用户可用的字节数
+// 路径不存在时（如尚未创建的版本目录）使用最近的已存在的上级目录
+func AvailableDiskSpace(path string) (uint64, error) {
+	return availableDiskSpace(existingParent(path))
+}
+
+// IsTmpfs 检查路径所在的文件系统是否为tmpfs等内存文件系统，路径不存在时使用最近的已存在的上级目录
+func IsTmpfs(path string) (bool, error) {
+	return isTmpfs(existingParent(path))
+}
+
+// existingParent 返回路径本身或最近的已存在的上级目录
+func existingParent(path string) string {
+	dir := filepath.Clean(path)
+	for {
+		if _, err := os.Stat(dir); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return dir
+		}
+		dir = parent
+	}
+}
@@ -0,0 +1,27 @@
+package utils
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAvailableDiskSpace(t *testing.T) {
+	dir := t.TempDir()
+	available, err := AvailableDiskSpace(dir)
+	require.NoError(t, err)
+	assert.Greater(t, available, uint64(0))
+
+	// 不存在的目录使用最近的已存在的上级目录
+	missing, err := AvailableDiskSpace(filepath.Join(dir, "versions", "kubectl", "1.30.0"))
+	require.NoError(t, err)
+	assert.Greater(t, missing, uint64(0))
+}
+
+func TestIsTmpfs(t *testing.T) {
+	// 不存在的目录使用最近的已存在的上级目录
+	_, err := IsTmpfs(filepath.Join(t.TempDir(), "tmp", "kubectl-1.30.0"))
+	assert.NoError(t, err)
+}
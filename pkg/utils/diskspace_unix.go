@@ -0,0 +1,14 @@
+//go:build !windows
+
+package utils
+
+import "golang.org/x/sys/unix"
+
+// availableDiskSpace 非特权用户可用的块数乘以块大小，不包括为root保留的空间
+func availableDiskSpace(dir string) (uint64, error) {
+	var stat unix.Statfs_t
+	if err := unix.Statfs(dir, &stat); err != nil {
+		return 0, err
+	}
+	return uint64(stat.Bavail) * uint64(stat.Bsize), nil
+}
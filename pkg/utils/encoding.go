@@ -0,0 +1,88 @@
+package utils
+
+import (
+	"os"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/korean"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/traditionalchinese"
+)
+
+// filenameEncodings 支持的文件名编码
+var filenameEncodings = map[string]encoding.Encoding{
+	"gbk":       simplifiedchinese.GBK,
+	"gb18030":   simplifiedchinese.GB18030,
+	"big5":      traditionalchinese.Big5,
+	"shift_jis": japanese.ShiftJIS,
+	"euc-jp":    japanese.EUCJP,
+	"euc-kr":    korean.EUCKR,
+	"cp437":     charmap.CodePage437,
+}
+
+// LookupEncoding 按名称查找文件名编码，名称不区分大小写
+func LookupEncoding(name string) (encoding.Encoding, bool) {
+	enc, ok := filenameEncodings[strings.ToLower(strings.TrimSpace(name))]
+	return enc, ok
+}
+
+// EncodingNames 返回支持的文件名编码名称
+func EncodingNames() []string {
+	names := make([]string, 0, len(filenameEncodings))
+	for name := range filenameEncodings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LocaleEncodings 根据 LC_ALL、LC_CTYPE、LANG 推测非 UTF-8 文件名最可能使用的编码
+// 简体中文环境为 gbk，繁体中文为 big5，日文为 shift_jis，韩文为 euc-kr，其他环境返回空
+func LocaleEncodings() []string {
+	locale := ""
+	for _, key := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		if value := os.Getenv(key); value != "" {
+			locale = strings.ToLower(value)
+			break
+		}
+	}
+
+	switch {
+	case strings.HasPrefix(locale, "zh_tw"), strings.HasPrefix(locale, "zh_hk"), strings.HasPrefix(locale, "zh_mo"):
+		return []string{"big5"}
+	case strings.HasPrefix(locale, "zh"):
+		return []string{"gbk"}
+	case strings.HasPrefix(locale, "ja"):
+		return []string{"shift_jis"}
+	case strings.HasPrefix(locale, "ko"):
+		return []string{"euc-kr"}
+	default:
+		return nil
+	}
+}
+
+// DecodeFilename 将非 UTF-8 的文件名按顺序尝试用各编码解码，返回第一个能完整解码的结果
+// 文件名已是合法的 UTF-8 或所有编码都失败时原样返回
+func DecodeFilename(name string, encodings []string) (string, bool) {
+	if utf8.ValidString(name) {
+		return name, false
+	}
+
+	for _, encName := range encodings {
+		enc, ok := LookupEncoding(encName)
+		if !ok {
+			continue
+		}
+		decoded, err := enc.NewDecoder().String(name)
+		if err != nil || strings.ContainsRune(decoded, utf8.RuneError) {
+			continue
+		}
+		return decoded, true
+	}
+	return name, false
+}
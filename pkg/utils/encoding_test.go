@@ -0,0 +1,61 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/simplifiedchinese"
+)
+
+func TestDecodeFilename(t *testing.T) {
+	gbk, err := simplifiedchinese.GBK.NewEncoder().String("工具/说明.txt")
+	assert.NoError(t, err)
+	sjis, err := japanese.ShiftJIS.NewEncoder().String("ツール.exe")
+	assert.NoError(t, err)
+
+	name, decoded := DecodeFilename(gbk, []string{"shift_jis", "gbk"})
+	assert.True(t, decoded)
+	assert.Equal(t, "工具/说明.txt", name)
+
+	name, decoded = DecodeFilename(sjis, []string{"shift_jis"})
+	assert.True(t, decoded)
+	assert.Equal(t, "ツール.exe", name)
+
+	// 合法的UTF-8文件名不做处理
+	name, decoded = DecodeFilename("工具.txt", []string{"gbk"})
+	assert.False(t, decoded)
+	assert.Equal(t, "工具.txt", name)
+
+	// 没有可用的编码时原样返回
+	name, decoded = DecodeFilename(gbk, []string{"unknown"})
+	assert.False(t, decoded)
+	assert.Equal(t, gbk, name)
+}
+
+func TestLocaleEncodings(t *testing.T) {
+	tests := map[string][]string{
+		"zh_CN.UTF-8": {"gbk"},
+		"zh_TW.UTF-8": {"big5"},
+		"ja_JP.UTF-8": {"shift_jis"},
+		"ko_KR.UTF-8": {"euc-kr"},
+		"en_US.UTF-8": nil,
+	}
+	for locale, expected := range tests {
+		t.Setenv("LC_ALL", "")
+		t.Setenv("LC_CTYPE", "")
+		t.Setenv("LANG", locale)
+		assert.Equal(t, expected, LocaleEncodings(), locale)
+	}
+
+	t.Setenv("LC_ALL", "ja_JP")
+	assert.Equal(t, []string{"shift_jis"}, LocaleEncodings())
+}
+
+func TestLookupEncoding(t *testing.T) {
+	_, ok := LookupEncoding("GBK")
+	assert.True(t, ok)
+	_, ok = LookupEncoding("latin9")
+	assert.False(t, ok)
+	assert.Contains(t, EncodingNames(), "shift_jis")
+}
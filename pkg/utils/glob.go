@@ -0,0 +1,76 @@
+package utils
+
+import (
+	"path"
+	"strings"
+)
+
+// MatchPathGlob 匹配以 / 分隔的相对路径，支持 path.Match 的通配符以及匹配任意多级目录的 **
+//
+// 例如 services/api/** 匹配 services/api、services/api/cmd 和 services/api/cmd/server。
+func MatchPathGlob(pattern, name string) bool {
+	return matchSegments(splitGlobPath(pattern), splitGlobPath(name))
+}
+
+// GlobSpecificity 计算模式的具体程度，不含通配符的路径段计2分，含通配符的路径段计1分，** 不计分
+func GlobSpecificity(pattern string) int {
+	score := 0
+	for _, segment := range splitGlobPath(pattern) {
+		switch {
+		case segment == "**":
+		case strings.ContainsAny(segment, "*?["):
+			score++
+		default:
+			score += 2
+		}
+	}
+	return score
+}
+
+// ValidatePathGlob 检查模式语法是否有效
+func ValidatePathGlob(pattern string) error {
+	for _, segment := range splitGlobPath(pattern) {
+		if segment == "**" {
+			continue
+		}
+		if _, err := path.Match(segment, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// splitGlobPath 拆分路径，忽略首尾和重复的分隔符以及 . 路径段
+func splitGlobPath(p string) []string {
+	var segments []string
+	for _, segment := range strings.Split(p, "/") {
+		if segment != "" && segment != "." {
+			segments = append(segments, segment)
+		}
+	}
+	return segments
+}
+
+// matchSegments 逐段匹配
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			// ** 可以匹配零个或多个路径段
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(name) == 0 {
+			return false
+		}
+		if ok, err := path.Match(pattern[0], name[0]); err != nil || !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
@@ -0,0 +1,43 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchPathGlob(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{"services/api/**", "services/api", true},
+		{"services/api/**", "services/api/cmd/server", true},
+		{"services/api/**", "services/apigw", false},
+		{"services/*", "services/api", true},
+		{"services/*", "services/api/cmd", false},
+		{"**/cmd", "services/api/cmd", true},
+		{"**/cmd", "cmd", true},
+		{"infra/**/prod", "infra/aws/eu/prod", true},
+		{"infra/**/prod", "infra/aws/eu/dev", false},
+		{"**", "", true},
+		{"web", ".", false},
+		{"./web/", "web", true},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, MatchPathGlob(tt.pattern, tt.name), "%s vs %s", tt.pattern, tt.name)
+	}
+}
+
+func TestGlobSpecificity(t *testing.T) {
+	assert.Greater(t, GlobSpecificity("services/*/cmd"), GlobSpecificity("services/api/**"))
+	assert.Greater(t, GlobSpecificity("services/api/**"), GlobSpecificity("services/**"))
+	assert.Equal(t, 0, GlobSpecificity("**"))
+}
+
+func TestValidatePathGlob(t *testing.T) {
+	assert.NoError(t, ValidatePathGlob("services/**/[a-z]*"))
+	assert.Error(t, ValidatePathGlob("services/[a-"))
+}
@@ -0,0 +1,69 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/spf13/afero"
+)
+
+// 文件权限策略
+const (
+	// ExecutableMode 已安装的二进制、垫片和钩子脚本
+	ExecutableMode os.FileMode = 0755
+
+	// PublicFileMode 不含敏感信息的普通文件
+	PublicFileMode os.FileMode = 0644
+
+	// PrivateFileMode 可能包含令牌、代理密码、认证头等凭据的文件，只有所有者可以读写
+	PrivateFileMode os.FileMode = 0600
+)
+
+var (
+	umaskOnce  sync.Once
+	umaskValue os.FileMode
+)
+
+// Umask 返回进程的umask，只在第一次调用时读取
+func Umask() os.FileMode {
+	umaskOnce.Do(func() {
+		umaskValue = readUmask()
+	})
+	return umaskValue
+}
+
+// UmaskMode 按umask收紧权限，与创建文件时的行为一致
+// Chmod 不受umask影响，设置权限前应先经过该函数；所有者的权限位始终保留，
+// 因此即使umask为 0177 之类的限制性设置，二进制文件也仍然可以执行
+func UmaskMode(mode os.FileMode) os.FileMode {
+	return mode&^Umask() | mode&0700
+}
+
+// ExecutableFileMode 按umask收紧后的可执行文件权限，默认umask下为 0755
+func ExecutableFileMode() os.FileMode {
+	return UmaskMode(ExecutableMode)
+}
+
+// WritePrivateFile 写入包含凭据的文件，权限始终为 0600
+// 文件已存在时 WriteFile 不会修改其权限，因此写入后再设置一次
+func WritePrivateFile(fs afero.Fs, path string, data []byte) error {
+	if err := afero.WriteFile(fs, path, data, PrivateFileMode); err != nil {
+		return err
+	}
+	if err := fs.Chmod(path, PrivateFileMode); err != nil {
+		return fmt.Errorf("failed to set permissions of %s: %w", path, err)
+	}
+	return nil
+}
+
+// WriteExecutableFile 写入可执行文件，权限为按umask收紧后的 0755
+func WriteExecutableFile(fs afero.Fs, path string, data []byte) error {
+	if err := afero.WriteFile(fs, path, data, ExecutableMode); err != nil {
+		return err
+	}
+	if err := fs.Chmod(path, ExecutableFileMode()); err != nil {
+		return fmt.Errorf("failed to set permissions of %s: %w", path, err)
+	}
+	return nil
+}
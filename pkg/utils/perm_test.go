@@ -0,0 +1,48 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUmaskMode_KeepsOwnerBits(t *testing.T) {
+	umaskOnce.Do(func() {})
+	previous := umaskValue
+	defer func() { umaskValue = previous }()
+
+	umaskValue = 0022
+	assert.Equal(t, os.FileMode(0755), UmaskMode(0755))
+	assert.Equal(t, os.FileMode(0644), UmaskMode(0666))
+
+	umaskValue = 0077
+	assert.Equal(t, os.FileMode(0700), UmaskMode(0755))
+
+	// 所有者的权限位不受umask影响
+	umaskValue = 0177
+	assert.Equal(t, os.FileMode(0700), UmaskMode(0755))
+}
+
+func TestWritePrivateFile_TightensExistingFile(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Windows 不使用Unix权限位")
+	}
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("old"), 0644))
+	require.NoError(t, os.Chmod(path, 0644))
+
+	require.NoError(t, WritePrivateFile(afero.NewOsFs(), path, []byte("new")))
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, PrivateFileMode, info.Mode().Perm())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "new", string(data))
+}
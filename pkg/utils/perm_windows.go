@@ -0,0 +1,15 @@
+//go:build windows
+
+package utils
+
+import "os"
+
+// readUmask Windows没有umask
+func readUmask() os.FileMode {
+	return 0
+}
+
+// FileOwner Windows没有UID，始终返回 false
+func FileOwner(info os.FileInfo) (int, bool) {
+	return 0, false
+}
@@ -0,0 +1,61 @@
+package utils
+
+import (
+	"sort"
+	"strings"
+)
+
+// Levenshtein 计算两个字符串的编辑距离（插入、删除、替换各计1），按字符比较
+func Levenshtein(a, b string) int {
+	s, t := []rune(a), []rune(b)
+	prev := make([]int, len(t)+1)
+	curr := make([]int, len(t)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(s); i++ {
+		curr[0] = i
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(t)]
+}
+
+// SuggestSimilar 返回 candidates 中与 name 相近的名称，按编辑距离从小到大排列，距离相同时按名称排列
+// 忽略大小写比较；名称不超过3个字符时只接受距离为1的候选，否则接受距离不超过2的候选
+func SuggestSimilar(name string, candidates []string) []string {
+	name = strings.ToLower(name)
+	maxDistance := 2
+	if len([]rune(name)) <= 3 {
+		maxDistance = 1
+	}
+
+	distances := make(map[string]int)
+	for _, candidate := range candidates {
+		if _, seen := distances[candidate]; seen {
+			continue
+		}
+		if distance := Levenshtein(name, strings.ToLower(candidate)); distance <= maxDistance {
+			distances[candidate] = distance
+		}
+	}
+
+	suggestions := make([]string, 0, len(distances))
+	for candidate := range distances {
+		suggestions = append(suggestions, candidate)
+	}
+	sort.Slice(suggestions, func(i, j int) bool {
+		di, dj := distances[suggestions[i]], distances[suggestions[j]]
+		if di != dj {
+			return di < dj
+		}
+		return suggestions[i] < suggestions[j]
+	})
+	return suggestions
+}
@@ -0,0 +1,25 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLevenshtein(t *testing.T) {
+	assert.Equal(t, 0, Levenshtein("kubectl", "kubectl"))
+	assert.Equal(t, 1, Levenshtein("kubctl", "kubectl"))
+	assert.Equal(t, 2, Levenshtein("isntall", "install"))
+	assert.Equal(t, 3, Levenshtein("", "abc"))
+	assert.Equal(t, 1, Levenshtein("版本", "版"))
+}
+
+func TestSuggestSimilar(t *testing.T) {
+	candidates := []string{"kubectl", "kubectx", "helm", "terraform", "kubectl"}
+	assert.Equal(t, []string{"kubectl", "kubectx"}, SuggestSimilar("kubctl", candidates))
+	assert.Equal(t, []string{"kubectl", "kubectx"}, SuggestSimilar("KUBECTL", candidates), "忽略大小写，按距离排列")
+	assert.Equal(t, []string{"terraform"}, SuggestSimilar("terrafrom", candidates))
+	assert.Empty(t, SuggestSimilar("hx", candidates), "短名称只接受距离为1的候选")
+	assert.Equal(t, []string{"helm"}, SuggestSimilar("hel", candidates))
+	assert.Empty(t, SuggestSimilar("docker", candidates))
+}
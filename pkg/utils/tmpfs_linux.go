@@ -0,0 +1,15 @@
+//go:build linux
+
+package utils
+
+import "golang.org/x/sys/unix"
+
+// isTmpfs 根据文件系统类型判断是否为tmpfs或ramfs
+func isTmpfs(dir string) (bool, error) {
+	var stat unix.Statfs_t
+	if err := unix.Statfs(dir, &stat); err != nil {
+		return false, err
+	}
+	fsType := uint32(stat.Type)
+	return fsType == unix.TMPFS_MAGIC || fsType == unix.RAMFS_MAGIC, nil
+}
@@ -0,0 +1,8 @@
+//go:build !linux
+
+package utils
+
+// isTmpfs 其他系统的临时目录通常位于磁盘上，不检查
+func isTmpfs(dir string) (bool, error) {
+	return false, nil
+}
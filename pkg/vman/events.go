@@ -0,0 +1,82 @@
+package vman
+
+import (
+	"time"
+
+	"github.com/songzhibin97/vman/pkg/types"
+)
+
+// EventKind 事件种类
+type EventKind string
+
+const (
+	// EventProgress 下载或解压进度
+	EventProgress EventKind = "progress"
+	// EventLog 日志消息
+	EventLog EventKind = "log"
+	// EventState 操作状态变化
+	EventState EventKind = "state"
+)
+
+// State 操作所处的阶段
+type State string
+
+const (
+	// StateResolving 正在解析版本
+	StateResolving State = "resolving"
+	// StateInstalling 正在下载和安装
+	StateInstalling State = "installing"
+	// StateInstalled 安装完成，或版本已安装
+	StateInstalled State = "installed"
+	// StateRunning 命令已启动
+	StateRunning State = "running"
+	// StateExited 命令已退出
+	StateExited State = "exited"
+	// StateFailed 操作失败，Event.Err 中为失败原因
+	StateFailed State = "failed"
+)
+
+// LogLevel 日志事件的级别
+type LogLevel string
+
+const (
+	LogDebug LogLevel = "debug"
+	LogInfo  LogLevel = "info"
+	LogWarn  LogLevel = "warn"
+)
+
+// Event 嵌入方可以订阅的结构化事件
+type Event struct {
+	// Kind 事件种类，决定下面哪些字段有效
+	Kind EventKind
+
+	// Time 事件发生的时间
+	Time time.Time
+
+	// Tool 事件所属的工具
+	Tool string
+
+	// Version 事件所属的版本，解析完成前可能为空
+	Version string
+
+	// State 状态事件的新状态
+	State State
+
+	// Progress 进度事件的进度信息
+	Progress *types.ProgressInfo
+
+	// Level 日志事件的级别
+	Level LogLevel
+
+	// Message 日志消息或状态说明
+	Message string
+
+	// ExitCode StateExited 事件中命令的退出码
+	ExitCode int
+
+	// Err StateFailed 事件的失败原因
+	Err error
+}
+
+// EventHandler 事件回调，在触发事件的 goroutine 中同步调用
+type EventHandler func(Event)
@@ -0,0 +1,529 @@
+// Package vman 提供嵌入 vman 的稳定 Go API，其他 Go 程序（IDE 插件、CI 工具等）
+// 可以直接安装、解析和执行工具版本，而无需调用 vman 命令行并解析其输出。
+//
+// 所有操作通过 Options 中的 Events 通道或 OnEvent 回调报告进度、日志和状态变化。
+package vman
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/spf13/afero"
+
+	"github.com/songzhibin97/vman/internal/config"
+	"github.com/songzhibin97/vman/internal/download"
+	"github.com/songzhibin97/vman/internal/proxy"
+	"github.com/songzhibin97/vman/internal/storage"
+	"github.com/songzhibin97/vman/internal/version"
+	"github.com/songzhibin97/vman/pkg/types"
+	"github.com/songzhibin97/vman/pkg/utils"
+)
+
+// LatestVersion 传给 Install 时安装最新的稳定版本
+const LatestVersion = "latest"
+
+// Options 创建客户端的选项
+type Options struct {
+	// HomeDir 用户主目录，vman 的数据位于其下的 .vman，为空时使用当前用户的主目录
+	HomeDir string
+
+	// Events 接收事件的通道，发送是阻塞的，调用方需要持续读取直到操作返回
+	Events chan<- Event
+
+	// OnEvent 事件回调，与 Events 可以同时设置
+	OnEvent EventHandler
+}
+
+// Resolution 版本解析结果
+type Resolution struct {
+	// Tool 工具名称
+	Tool string `json:"tool"`
+
+	// Version 解析出的具体版本
+	Version string `json:"version"`
+
+	// Requested 配置中声明的版本或约束，如 ^1.20、lts
+	Requested string `json:"requested,omitempty"`
+
+	// Source 版本来源：env、project、global、engines、fallback 等
+	Source string `json:"source"`
+
+	// ConfigPath 声明版本的配置文件
+	ConfigPath string `json:"config_path,omitempty"`
+
+	// Installed 版本是否已安装
+	Installed bool `json:"installed"`
+
+	// Executable 可执行文件的路径，未安装时为空
+	Executable string `json:"executable,omitempty"`
+}
+
+// InstalledVersion 已安装的版本
+type InstalledVersion struct {
+	// Version 版本号
+	Version string `json:"version"`
+
+	// Path 版本的安装目录
+	Path string `json:"path"`
+
+	// Current 是否为全局当前版本
+	Current bool `json:"current"`
+}
+
+// ExecOptions 执行命令的选项
+type ExecOptions struct {
+	// Dir 工作目录，同时用于解析项目版本，为空时使用当前目录
+	Dir string
+
+	// Version 指定版本，为空时按 Dir 中的项目配置解析
+	Version string
+
+	// Env 追加的环境变量，格式为 KEY=VALUE
+	Env []string
+
+	// Stdin、Stdout、Stderr 为空时不连接
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+// Client vman 客户端，可以在多个 goroutine 中使用，但同一工具的安装不应并发进行
+type Client struct {
+	options   Options
+	paths     *types.ConfigPaths
+	config    config.Manager
+	versions  version.Manager
+	downloads download.Manager
+	resolver  proxy.VersionResolver
+	router    proxy.CommandRouter
+}
+
+// New 创建客户端，必要时初始化 vman 的目录和配置
+func New(options *Options) (*Client, error) {
+	var opts Options
+	if options != nil {
+		opts = *options
+	}
+
+	homeDir := opts.HomeDir
+	if homeDir == "" {
+		dir, err := utils.GetHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get home directory: %w", err)
+		}
+		homeDir = dir
+	}
+	configPaths := types.DefaultConfigPaths(homeDir)
+
+	configManager, err := config.NewManager(homeDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create config manager: %w", err)
+	}
+
+	if _, err := storage.NewLayoutMigrator(configPaths).Migrate(false); err != nil {
+		return nil, fmt.Errorf("failed to migrate storage layout: %w", err)
+	}
+
+	if err := storage.NewFilesystemManager(configPaths).EnsureDirectories(); err != nil {
+		return nil, fmt.Errorf("failed to ensure directories: %w", err)
+	}
+
+	if err := configManager.Initialize(); err != nil {
+		return nil, fmt.Errorf("failed to initialize config: %w", err)
+	}
+
+	// 与命令行一致，配置了系统级存储时叠加在用户存储之后
+	var storageManager storage.Manager = storage.NewFilesystemManager(configPaths)
+	if globalConfig, err := configManager.LoadGlobal(); err == nil {
+		if err := download.ConfigureNetwork(globalConfig.Settings.Network); err != nil {
+			return nil, fmt.Errorf("invalid network settings: %w", err)
+		}
+		if systemRoot := globalConfig.Settings.System.GetRoot(); systemRoot != "" {
+			storageManager = storage.NewFilesystemManagerWithSystemStore(afero.NewOsFs(), configPaths, types.ConfigPathsFromRoot(systemRoot))
+		}
+	}
+
+	versionManager := version.NewManager(storageManager, configManager)
+	resolver := proxy.NewVersionResolver(configManager, versionManager)
+
+	return &Client{
+		options:   opts,
+		paths:     configPaths,
+		config:    configManager,
+		versions:  versionManager,
+		downloads: download.NewManager(storageManager, configManager),
+		resolver:  resolver,
+		router:    proxy.NewCommandRouter(resolver, proxy.NewContextManager(configManager), proxy.NewPathManager()),
+	}, nil
+}
+
+// Install 下载并安装工具版本，返回实际安装的版本
+// version 为 LatestVersion 时安装最新的稳定版本，版本已安装时直接返回
+func (c *Client) Install(ctx context.Context, tool, version string) (string, error) {
+	if version == "" || version == LatestVersion {
+		c.state(tool, "", StateResolving, "")
+		latest, err := c.latestVersion(ctx, tool)
+		if err != nil {
+			return "", c.fail(tool, "", err)
+		}
+		version = latest
+	}
+
+	if c.versions.IsVersionInstalled(tool, version) {
+		c.state(tool, version, StateInstalled, "已安装")
+		return version, nil
+	}
+	if err := c.checkWritable(); err != nil {
+		return "", c.fail(tool, version, err)
+	}
+
+	c.state(tool, version, StateInstalling, "")
+	err := c.downloads.DownloadWithProgress(ctx, tool, version, &download.DownloadOptions{}, func(info *download.ProgressInfo) {
+		c.emit(Event{
+			Kind:    EventProgress,
+			Tool:    tool,
+			Version: version,
+			Progress: &types.ProgressInfo{
+				Total:      info.Total,
+				Downloaded: info.Downloaded,
+				Percentage: info.Percentage,
+				Speed:      info.Speed,
+				ETA:        info.ETA,
+				Status:     info.Status,
+			},
+		})
+	})
+	if err != nil {
+		return "", c.fail(tool, version, fmt.Errorf("failed to install %s@%s: %w", tool, version, err))
+	}
+
+	// 配置中的已安装版本记录以磁盘为准
+	if _, err := c.versions.ReconcileInstalledVersions(tool); err != nil {
+		c.log(tool, version, LogWarn, fmt.Sprintf("failed to update installed versions: %v", err))
+	}
+
+	c.state(tool, version, StateInstalled, "")
+	return version, nil
+}
+
+// Resolve 解析 dir 目录下工具生效的版本，dir 为空时使用当前目录
+func (c *Client) Resolve(ctx context.Context, tool, dir string) (*Resolution, error) {
+	dir, err := workDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	c.state(tool, "", StateResolving, dir)
+	resolved, err := c.resolver.ResolveVersion(ctx, tool, dir)
+	if err != nil {
+		return nil, c.fail(tool, "", err)
+	}
+
+	resolution := &Resolution{
+		Tool:       tool,
+		Version:    resolved.Version,
+		Requested:  resolved.RequestedVersion,
+		Source:     resolved.Source,
+		ConfigPath: resolved.ConfigPath,
+		Installed:  resolved.IsInstalled,
+	}
+	if resolution.Installed {
+		resolution.Executable, err = c.executable(tool, resolved)
+		if err != nil {
+			return nil, c.fail(tool, resolved.Version, err)
+		}
+	}
+	return resolution, nil
+}
+
+// Exec 使用解析出的版本执行工具，返回命令的退出码
+// 命令以非零状态退出时 error 为 nil，只有无法启动或等待命令时才返回错误
+func (c *Client) Exec(ctx context.Context, tool string, args []string, options *ExecOptions) (int, error) {
+	var opts ExecOptions
+	if options != nil {
+		opts = *options
+	}
+
+	dir, err := workDir(opts.Dir)
+	if err != nil {
+		return -1, err
+	}
+
+	var execPath, execVersion, configPath string
+	if opts.Version != "" {
+		route, err := c.router.RouteCommandWithVersion(ctx, tool, opts.Version, args)
+		if err != nil {
+			return -1, c.fail(tool, opts.Version, err)
+		}
+		execPath, execVersion = route.ExecutablePath, route.Version
+	} else {
+		resolution, err := c.Resolve(ctx, tool, dir)
+		if err != nil {
+			return -1, err
+		}
+		if !resolution.Installed {
+			return -1, c.fail(tool, resolution.Version, fmt.Errorf("version %s for %s is not installed", resolution.Version, tool))
+		}
+		execPath, execVersion, configPath = resolution.Executable, resolution.Version, resolution.ConfigPath
+	}
+
+	policy, limits, sandbox := c.execSettings(tool)
+	nested := proxy.NestedEnvironment(&proxy.VersionResolution{ToolName: tool, Version: execVersion, ConfigPath: configPath}, dir)
+	startPath, startArgs := execPath, args
+	if sandbox != nil {
+		projectPath := nested[types.EnvVmanProjectPath]
+		if projectPath == "" {
+			projectPath = dir
+		}
+		if startPath, startArgs, err = sandbox.Wrap(execPath, args, projectPath); err != nil {
+			return -1, c.fail(tool, execVersion, err)
+		}
+	}
+
+	cmd, execCtx, cancel := proxy.LimitedCommand(ctx, limits, startPath, startArgs...)
+	defer cancel()
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"VMAN_TOOL="+tool,
+		"VMAN_VERSION="+execVersion,
+		"VMAN_WORKDIR="+dir,
+	)
+	// 工具再调用其他受管工具时沿用本次的项目目录和版本
+	for key, value := range nested {
+		cmd.Env = append(cmd.Env, key+"="+value)
+	}
+	cmd.Env = append(cmd.Env, opts.Env...)
+	cmd.Env = policy.Apply(cmd.Env)
+	cmd.Stdin = opts.Stdin
+	cmd.Stdout = opts.Stdout
+	cmd.Stderr = opts.Stderr
+
+	if err := cmd.Start(); err != nil {
+		return -1, c.fail(tool, execVersion, fmt.Errorf("failed to start %s: %w", execPath, err))
+	}
+	if err := proxy.ApplyProcessLimits(cmd.Process.Pid, limits); err != nil {
+		c.emit(Event{Kind: EventLog, Tool: tool, Version: execVersion, Level: LogWarn, Message: err.Error()})
+	}
+	c.state(tool, execVersion, StateRunning, execPath)
+
+	err = cmd.Wait()
+	exitCode := cmd.ProcessState.ExitCode()
+	// 超时或超出内存上限被终止时返回说明原因的错误
+	if limitErr := proxy.LimitError(execCtx, tool, limits, err); limitErr != err {
+		return exitCode, c.fail(tool, execVersion, limitErr)
+	}
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return -1, c.fail(tool, execVersion, err)
+	}
+
+	c.emit(Event{Kind: EventState, Tool: tool, Version: execVersion, State: StateExited, ExitCode: exitCode})
+	return exitCode, nil
+}
+
+// Status 解析 dir 目录下所有相关工具生效的版本，按工具名排序
+// 相关工具包括已安装的工具、配置了全局版本的工具和项目配置中的工具，无法解析版本的工具会被跳过
+func (c *Client) Status(ctx context.Context, dir string) ([]*Resolution, error) {
+	dir, err := workDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	tools := make(map[string]bool)
+	installed, err := c.versions.ListAllTools()
+	if err != nil {
+		return nil, err
+	}
+	for _, tool := range installed {
+		tools[tool] = true
+	}
+	if effective, err := c.config.GetEffectiveConfig(dir); err == nil {
+		for tool := range effective.ResolvedVersions {
+			tools[tool] = true
+		}
+	}
+
+	names := make([]string, 0, len(tools))
+	for tool := range tools {
+		names = append(names, tool)
+	}
+	sort.Strings(names)
+
+	// 配置可能在两次查询之间被修改，不使用解析器的缓存
+	if err := c.resolver.ClearVersionCache(); err != nil {
+		return nil, err
+	}
+
+	var resolutions []*Resolution
+	for _, tool := range names {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		resolution, err := c.Resolve(ctx, tool, dir)
+		if err != nil {
+			continue
+		}
+		resolutions = append(resolutions, resolution)
+	}
+	return resolutions, nil
+}
+
+// ListVersions 列出工具已安装的版本，按版本号从低到高排序
+func (c *Client) ListVersions(tool string) ([]InstalledVersion, error) {
+	installed, err := c.versions.GetInstalledVersions(tool)
+	if err != nil {
+		return nil, err
+	}
+
+	current, _ := c.versions.GetCurrentVersion(tool)
+	sort.Slice(installed, func(i, j int) bool {
+		return compareVersions(installed[i], installed[j]) < 0
+	})
+
+	versions := make([]InstalledVersion, 0, len(installed))
+	for _, v := range installed {
+		path, err := c.versions.GetVersionPath(tool, v)
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, InstalledVersion{Version: v, Path: path, Current: v == current})
+	}
+	return versions, nil
+}
+
+// ListTools 列出所有已安装的工具
+func (c *Client) ListTools() ([]string, error) {
+	return c.versions.ListAllTools()
+}
+
+// SetGlobalVersion 设置工具的全局版本，版本必须已安装
+func (c *Client) SetGlobalVersion(tool, version string) error {
+	if err := c.checkWritable(); err != nil {
+		return err
+	}
+	if err := c.versions.SetGlobalVersion(tool, version); err != nil {
+		return err
+	}
+	c.log(tool, version, LogInfo, fmt.Sprintf("global version of %s set to %s", tool, version))
+	return nil
+}
+
+// GlobalConfig 返回全局配置
+func (c *Client) GlobalConfig() (*types.GlobalConfig, error) {
+	return c.config.LoadGlobal()
+}
+
+// Paths 返回 vman 使用的目录
+func (c *Client) Paths() types.ConfigPaths {
+	return *c.paths
+}
+
+// execSettings 获取执行工具时的环境变量策略、资源限制和沙箱设置
+func (c *Client) execSettings(tool string) (types.EnvPolicy, types.ExecLimits, *proxy.SandboxOptions) {
+	metadata, _ := c.config.LoadToolConfig(tool)
+	sandbox := proxy.SandboxFor(metadata)
+	globalConfig, err := c.config.LoadGlobal()
+	if err != nil {
+		return types.EnvPolicy{}, types.ExecLimits{}, sandbox
+	}
+	settings := globalConfig.Settings
+	return settings.Env.PolicyFor(tool, metadata), settings.Limits.LimitsFor(tool, metadata), sandbox
+}
+
+// checkWritable 只读模式下返回 types.ErrReadOnly
+func (c *Client) checkWritable() error {
+	globalConfig, err := c.config.LoadGlobal()
+	if err != nil {
+		globalConfig = &types.GlobalConfig{}
+	}
+	if globalConfig.Settings.IsReadOnly() {
+		return types.ErrReadOnly
+	}
+	return nil
+}
+
+// latestVersion 查询工具最新的稳定版本，没有稳定版本时使用最新的预发布版本
+func (c *Client) latestVersion(ctx context.Context, tool string) (string, error) {
+	available, err := c.downloads.SearchVersions(ctx, tool)
+	if err != nil {
+		return "", fmt.Errorf("failed to search versions for %s: %w", tool, err)
+	}
+	if len(available) == 0 {
+		return "", fmt.Errorf("no versions available for %s", tool)
+	}
+
+	for _, info := range available {
+		if !info.IsPrerelease {
+			return info.Version, nil
+		}
+	}
+	return available[0].Version, nil
+}
+
+// executable 查找解析出的版本对应的可执行文件
+func (c *Client) executable(tool string, resolved *proxy.VersionResolution) (string, error) {
+	if resolved.Version == types.SystemVersion {
+		return resolved.SystemPath, nil
+	}
+	return c.router.FindExecutable(tool, resolved.Version)
+}
+
+// compareVersions 比较版本号，无法按语义化版本解析时按字符串比较
+func compareVersions(a, b string) int {
+	va, errA := semver.NewVersion(a)
+	vb, errB := semver.NewVersion(b)
+	if errA == nil && errB == nil {
+		return va.Compare(vb)
+	}
+	return strings.Compare(a, b)
+}
+
+// workDir 返回绝对路径形式的工作目录，dir 为空时使用当前目录
+func workDir(dir string) (string, error) {
+	if dir == "" {
+		return os.Getwd()
+	}
+	dir, err := utils.ExpandPath(dir)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Abs(dir)
+}
+
+// emit 将事件发送到通道和回调
+func (c *Client) emit(event Event) {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	if c.options.OnEvent != nil {
+		c.options.OnEvent(event)
+	}
+	if c.options.Events != nil {
+		c.options.Events <- event
+	}
+}
+
+// state 发送状态变化事件
+func (c *Client) state(tool, version string, state State, message string) {
+	c.emit(Event{Kind: EventState, Tool: tool, Version: version, State: state, Message: message})
+}
+
+// log 发送日志事件
+func (c *Client) log(tool, version string, level LogLevel, message string) {
+	c.emit(Event{Kind: EventLog, Tool: tool, Version: version, Level: level, Message: message})
+}
+
+// fail 发送失败事件并原样返回错误
+func (c *Client) fail(tool, version string, err error) error {
+	c.emit(Event{Kind: EventState, Tool: tool, Version: version, State: StateFailed, Message: err.Error(), Err: err})
+	return err
+}
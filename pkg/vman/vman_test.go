@@ -0,0 +1,119 @@
+package vman
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/songzhibin97/vman/pkg/types"
+)
+
+func newTestClient(t *testing.T, handler EventHandler) *Client {
+	t.Helper()
+	client, err := New(&Options{HomeDir: t.TempDir(), OnEvent: handler})
+	require.NoError(t, err)
+	return client
+}
+
+// registerScript 将 shell 脚本注册为工具的一个版本
+func registerScript(t *testing.T, client *Client, tool, version, script string) {
+	t.Helper()
+	source := filepath.Join(t.TempDir(), tool)
+	require.NoError(t, os.WriteFile(source, []byte("#!/bin/sh\n"+script+"\n"), 0755))
+	require.NoError(t, client.versions.RegisterVersion(tool, version, source))
+}
+
+func TestClient_ListVersions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell scripts are not executable on windows")
+	}
+	client := newTestClient(t, nil)
+
+	versions, err := client.ListVersions("demo")
+	require.NoError(t, err)
+	assert.Empty(t, versions)
+
+	registerScript(t, client, "demo", "1.10.0", "true")
+	registerScript(t, client, "demo", "1.9.0", "true")
+	require.NoError(t, client.versions.SetGlobalVersion("demo", "1.9.0"))
+
+	versions, err = client.ListVersions("demo")
+	require.NoError(t, err)
+	require.Len(t, versions, 2)
+	assert.Equal(t, "1.9.0", versions[0].Version)
+	assert.True(t, versions[0].Current)
+	assert.Equal(t, "1.10.0", versions[1].Version)
+	assert.False(t, versions[1].Current)
+}
+
+func TestClient_ResolveAndExec(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell scripts are not executable on windows")
+	}
+
+	var events []Event
+	client := newTestClient(t, func(event Event) { events = append(events, event) })
+	registerScript(t, client, "demo", "1.2.3", `echo "demo $VMAN_VERSION $1"; exit 3`)
+	require.NoError(t, client.versions.SetGlobalVersion("demo", "1.2.3"))
+
+	dir := t.TempDir()
+	resolution, err := client.Resolve(context.Background(), "demo", dir)
+	require.NoError(t, err)
+	assert.Equal(t, "1.2.3", resolution.Version)
+	assert.Equal(t, "global", resolution.Source)
+	assert.True(t, resolution.Installed)
+	assert.FileExists(t, resolution.Executable)
+
+	var stdout bytes.Buffer
+	exitCode, err := client.Exec(context.Background(), "demo", []string{"hello"}, &ExecOptions{Dir: dir, Stdout: &stdout})
+	require.NoError(t, err)
+	assert.Equal(t, 3, exitCode)
+	assert.Equal(t, "demo 1.2.3 hello\n", stdout.String())
+
+	last := events[len(events)-1]
+	assert.Equal(t, EventState, last.Kind)
+	assert.Equal(t, StateExited, last.State)
+	assert.Equal(t, 3, last.ExitCode)
+}
+
+func TestClient_EventsChannel(t *testing.T) {
+	events := make(chan Event, 16)
+	client, err := New(&Options{HomeDir: t.TempDir(), Events: events})
+	require.NoError(t, err)
+
+	_, err = client.Exec(context.Background(), "missing", nil, &ExecOptions{Version: "1.0.0"})
+	require.Error(t, err)
+
+	require.Len(t, events, 1)
+	event := <-events
+	assert.Equal(t, StateFailed, event.State)
+	assert.Equal(t, "missing", event.Tool)
+	assert.Equal(t, err, event.Err)
+	assert.False(t, event.Time.IsZero())
+}
+
+func TestClient_ExecLimits(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell scripts are not executable on windows")
+	}
+
+	client := newTestClient(t, nil)
+	registerScript(t, client, "demo", "1.2.3", "sleep 5")
+	require.NoError(t, client.versions.SetGlobalVersion("demo", "1.2.3"))
+	globalConfig, err := client.config.LoadGlobal()
+	require.NoError(t, err)
+	globalConfig.Settings.Limits.Tools = map[string]types.ExecLimits{"demo": {Timeout: 200 * time.Millisecond}}
+	require.NoError(t, client.config.SaveGlobal(globalConfig))
+
+	start := time.Now()
+	_, err = client.Exec(context.Background(), "demo", nil, &ExecOptions{Dir: t.TempDir()})
+	assert.ErrorContains(t, err, "exceeded the time limit")
+	assert.Less(t, time.Since(start), 4*time.Second)
+}